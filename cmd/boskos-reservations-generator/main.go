@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/boskos/common"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/lease"
+)
+
+type options struct {
+	reservationsFile string
+	boskosConfig     string
+}
+
+func gatherOptions() options {
+	o := options{}
+	flag.StringVar(&o.reservationsFile, "reservations", "", "Path to the file capping the number of concurrent jobs per cluster profile, as passed to ci-operator with --lease-reservations-file")
+	flag.StringVar(&o.boskosConfig, "boskos-config", "", "Path to the configuration of the lease server, updated in place")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.reservationsFile == "" {
+		errs = append(errs, errors.New("--reservations is required"))
+	}
+	if o.boskosConfig == "" {
+		errs = append(errs, errors.New("--boskos-config is required"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func update(reservationsFile, boskosConfig string) error {
+	reservations, err := lease.LoadReservations(reservationsFile)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(boskosConfig)
+	if err != nil {
+		return fmt.Errorf("failed to read the lease server configuration: %w", err)
+	}
+	var config common.BoskosConfig
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return fmt.Errorf("failed to parse the lease server configuration: %w", err)
+	}
+	reservations.UpdateBoskosConfig(&config)
+	if raw, err = yaml.Marshal(config); err != nil {
+		return fmt.Errorf("failed to marshal the lease server configuration: %w", err)
+	}
+	if err := os.WriteFile(boskosConfig, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write the lease server configuration: %w", err)
+	}
+	return nil
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options.")
+	}
+	if err := update(o.reservationsFile, o.boskosConfig); err != nil {
+		logrus.WithError(err).Fatal("Failed to add the reservations to the lease server configuration.")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	reservations := filepath.Join(dir, "reservations.yaml")
+	if err := os.WriteFile(reservations, []byte("profiles:\n  aws: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "_boskos.yaml")
+	if err := os.WriteFile(config, []byte(`resources:
+- type: aws-quota-slice
+  state: free
+  names:
+  - us-east-1
+- type: gcp-reservation
+  state: free
+  names:
+  - gcp-reservation-00
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := update(reservations, config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, err := os.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `resources:
+- config: {}
+  names:
+  - us-east-1
+  state: free
+  type: aws-quota-slice
+- config: {}
+  names:
+  - aws-reservation-00
+  - aws-reservation-01
+  state: free
+  type: aws-reservation
+`
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("unexpected configuration: %s", diff)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/branchprotection"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+)
+
+type options struct {
+	config.Options
+	flagutil.GitHubOptions
+	jobDir      string
+	reportPath  string
+	failOnDrift bool
+}
+
+func gatherOptions() options {
+	o := options{}
+	fs := flag.CommandLine
+	o.Options.Bind(fs)
+	o.GitHubOptions.AddFlags(fs)
+	fs.StringVar(&o.jobDir, "job-dir", "", "Path to the Prow job configuration directory")
+	fs.StringVar(&o.reportPath, "report", "", "Path to write the drift report to, printed to the standard output if unset")
+	fs.BoolVar(&o.failOnDrift, "fail-on-drift", false, "Exit with a non-zero code when any branch drifted")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.jobDir == "" {
+		errs = append(errs, errors.New("--job-dir is required"))
+	}
+	if err := o.GitHubOptions.Validate(false); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+type report struct {
+	Drifts []branchprotection.Drift `json:"drifts,omitempty"`
+	Errors []string                 `json:"errors,omitempty"`
+}
+
+func (r *report) write(path string) error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	if err := o.Options.Complete(); err != nil {
+		logrus.Fatalf("Couldn't complete the config options: %v", err)
+	}
+
+	var configs []api.ReleaseBuildConfiguration
+	if err := o.OperateOnCIOperatorConfigDir(o.ConfigDir, func(configuration *api.ReleaseBuildConfiguration, _ *config.Info) error {
+		configs = append(configs, *configuration)
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to load ci-operator configurations.")
+	}
+	jobConfig, err := jobconfig.ReadFromDir(o.jobDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load Prow jobs.")
+	}
+	intents, err := branchprotection.IntentsFor(configs, jobConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to determine the expected branch protection.")
+	}
+
+	client, err := o.GitHubOptions.GitHubClient(true)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create GitHub client.")
+	}
+	var r report
+	r.Drifts, err = branchprotection.Detect(client, intents)
+	if err != nil {
+		var aggregate utilerrors.Aggregate
+		if !errors.As(err, &aggregate) {
+			aggregate = utilerrors.NewAggregate([]error{err})
+		}
+		for _, err := range aggregate.Errors() {
+			r.Errors = append(r.Errors, err.Error())
+		}
+	}
+	if err := r.write(o.reportPath); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	logrus.Infof("Checked %d branches, %d drifted.", len(intents), len(r.Drifts))
+	if len(r.Errors) != 0 {
+		logrus.Fatalf("Failed to check %d branches.", len(r.Errors))
+	}
+	if o.failOnDrift && len(r.Drifts) != 0 {
+		os.Exit(1)
+	}
+}
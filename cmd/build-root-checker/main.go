@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/buildroot"
+)
+
+type options struct {
+	repoDir string
+	bump    bool
+
+	digestRecordsDir string
+	maxDigestChanges int
+	driftWindow      time.Duration
+}
+
+func gatherOptions() (*options, error) {
+	o := &options{}
+	flag.StringVar(&o.repoDir, "repo-dir", "", "Path to a checkout of a repository that reads its build root from .ci-operator.yaml")
+	flag.BoolVar(&o.bump, "bump", false, "Update the build root in .ci-operator.yaml to the Go version required by go.mod")
+	flag.StringVar(&o.digestRecordsDir, "digest-records-dir", "", fmt.Sprintf("Path to a directory with %s artifacts from past ci-operator runs to check for build root drift", buildroot.DigestRecordsFile))
+	flag.IntVar(&o.maxDigestChanges, "max-digest-changes", 3, "Maximum number of times a build root tag may change its digest within --drift-window")
+	flag.DurationVar(&o.driftWindow, "drift-window", 7*24*time.Hour, "Period over which build root digest changes are counted")
+	flag.Parse()
+
+	if o.repoDir == "" && o.digestRecordsDir == "" {
+		return nil, errors.New("one of --repo-dir or --digest-records-dir is mandatory")
+	}
+	if o.repoDir != "" && o.digestRecordsDir != "" {
+		return nil, errors.New("--repo-dir and --digest-records-dir are mutually exclusive")
+	}
+	if o.bump && o.repoDir == "" {
+		return nil, errors.New("--bump requires --repo-dir")
+	}
+	return o, nil
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+
+	if o.digestRecordsDir != "" {
+		checkDrift(o)
+		return
+	}
+
+	report, err := buildroot.Inspect(o.repoDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to inspect repository")
+	}
+	if o.bump {
+		bumped, err := buildroot.Bump(o.repoDir, report)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to bump build root")
+		}
+		if bumped {
+			logrus.Infof("Bumped build root to %s", report.BuildRoot.ISTagName())
+			if report, err = buildroot.Inspect(o.repoDir); err != nil {
+				logrus.WithError(err).Fatal("failed to inspect repository")
+			}
+		}
+	}
+
+	raw, err := yaml.Marshal(report)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to marshal report")
+	}
+	fmt.Print(string(raw))
+	if len(report.Mismatches) > 0 {
+		os.Exit(1)
+	}
+}
+
+func checkDrift(o *options) {
+	records, err := buildroot.LoadDigestRecords(o.digestRecordsDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load build root digest records")
+	}
+	policy := buildroot.DriftPolicy{MaxChanges: o.maxDigestChanges, Window: o.driftWindow}
+	alerts := buildroot.CheckDrift(records, policy, time.Now())
+	for _, alert := range alerts {
+		logrus.Error(alert)
+	}
+	if len(alerts) > 0 {
+		os.Exit(1)
+	}
+	logrus.Infof("No build root drift found in %d records", len(records))
+}
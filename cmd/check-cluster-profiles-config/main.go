@@ -100,6 +100,21 @@ func (validator *profileValidator) Validate(profiles api.ClusterProfilesList) er
 		if _, found := validator.profiles[p.Profile]; found {
 			return fmt.Errorf("cluster profile '%v' already exists in the configuration file", p.Profile)
 		}
+		if p.WorkloadIdentity != nil {
+			if err := p.WorkloadIdentity.Validate(); err != nil {
+				return fmt.Errorf("cluster profile '%v' has invalid workload_identity: %w", p.Profile, err)
+			}
+		}
+		if p.LeakDetection != nil {
+			if err := p.LeakDetection.Validate(); err != nil {
+				return fmt.Errorf("cluster profile '%v' has invalid leak_detection: %w", p.Profile, err)
+			}
+		}
+		for _, stack := range p.NetworkStacks {
+			if err := stack.Validate(); err != nil {
+				return fmt.Errorf("cluster profile '%v' has invalid network_stacks: %w", p.Profile, err)
+			}
+		}
 		validator.profiles[p.Profile] = p
 	}
 	return nil
@@ -51,6 +51,36 @@ func TestValidate(t *testing.T) {
 			},
 			expected: fmt.Errorf("cluster profile 'aws' already exists in the configuration file"),
 		},
+		{
+			name: "Invalid workload identity",
+			profiles: api.ClusterProfilesList{
+				api.ClusterProfileDetails{
+					Profile:          "azure",
+					WorkloadIdentity: &api.WorkloadIdentity{Provider: api.WorkloadIdentityProviderAWS},
+				},
+			},
+			expected: fmt.Errorf(`cluster profile 'azure' has invalid workload_identity: aws_role_arn: "" is not the ARN of a role`),
+		},
+		{
+			name: "Invalid leak detection",
+			profiles: api.ClusterProfilesList{
+				api.ClusterProfileDetails{
+					Profile:       "aws-2",
+					LeakDetection: &api.LeakDetection{Tag: "owner"},
+				},
+			},
+			expected: fmt.Errorf(`cluster profile 'aws-2' has invalid leak_detection: tag "owner" must contain ${CLUSTER_NAME}`),
+		},
+		{
+			name: "Invalid network stack",
+			profiles: api.ClusterProfilesList{
+				api.ClusterProfileDetails{
+					Profile:       "aws-3",
+					NetworkStacks: []api.NetworkStack{api.NetworkStackDualStack, "ipv5"},
+				},
+			},
+			expected: fmt.Errorf(`cluster profile 'aws-3' has invalid network_stacks: "ipv5" is not a network stack, expected one of ipv4, ipv6 or dualstack`),
+		},
 	}
 
 	validator := newValidator(fakectrlruntimeclient.NewFakeClient())
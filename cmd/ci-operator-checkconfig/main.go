@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
 	"github.com/openshift/ci-tools/pkg/api"
+	apihelper "github.com/openshift/ci-tools/pkg/api/helper"
+	"github.com/openshift/ci-tools/pkg/api/schema"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
 	"github.com/openshift/ci-tools/pkg/config"
 	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/github"
 	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/load/agents"
 	"github.com/openshift/ci-tools/pkg/registry"
@@ -20,13 +34,6 @@ import (
 	"github.com/openshift/ci-tools/pkg/validation"
 )
 
-type tagSet map[api.ImageStreamTagReference][]*api.Metadata
-
-type promotedTag struct {
-	tag      api.ImageStreamTagReference
-	metadata *api.Metadata
-}
-
 type options struct {
 	config.Options
 
@@ -34,25 +41,102 @@ type options struct {
 	ciOPConfigAgent    agents.ConfigAgent
 	clusterProfiles    api.ClusterProfilesMap
 	clusterClaimOwners api.ClusterClaimOwnersMap
+	pullSecrets        sets.Set[string]
+	goVersionPolicy    *api.GoVersionPolicy
+	// resourceCeilings restricts the resources builds and tests may
+	// request, they are not restricted when it is not provided.
+	resourceCeilings *api.ResourceCeilingPolicy
+	// architectures replace the architectures images may be built for
+	architectures sets.Set[string]
+	// ruleAllowlist restricts the validation rules configurations may
+	// disable, none may be disabled when it is not provided.
+	ruleAllowlist *api.ValidationRuleAllowlist
+	// inRepoBuildRoot reads the build roots declared in repositories, it is
+	// unset when those are not checked against the Go version policy.
+	inRepoBuildRoot validation.InRepoBuildRootGetter
+	// strict enforces the strict validation ruleset.
+	strict bool
+	// defaultJobTimeout is the timeout Prow gives the jobs of a repository
+	// when their test does not set one, zero when the Prow configuration is
+	// not available in the release repo.
+	defaultJobTimeout func(metadata api.Metadata) time.Duration
+	// clusterPools are the cluster pools cluster claims are checked against,
+	// they are not checked unless a Hive kubeconfig is provided.
+	clusterPools []hivev1.ClusterPool
+
+	// graph is the step registry graph, used to find the configurations
+	// affected by changes to the registry.
+	graph       registry.NodeByName
+	releaseRepo string
+	baseRef     string
+	full        bool
+	// newConfigs are the configurations of repositories added since the
+	// base revision, which must use the latest schema version.
+	newConfigs sets.Set[string]
+	// globalFiles are the inputs, relative to the release repo, a change to
+	// which affects all configurations.
+	globalFiles sets.Set[string]
+	// printSchema prints the JSON Schema of the configuration instead of
+	// validating configurations.
+	printSchema bool
 }
 
 func (o *options) parse() error {
 	var registryDir string
+	var snapshotsDir string
+	var overlaysDir string
 	var profilesConfigPath string
 	var clusterClaimConfigPath string
+	var secretBootstrapConfigPath string
+	var goVersionPolicyPath string
+	var ruleAllowlistPath string
+	var resourceCeilingsPath string
+	var checkInRepoBuildRoots bool
+	var hiveKubeconfigPath string
+	var architectures string
 
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 
 	fs.StringVar(&registryDir, "registry", "", "Path to the step registry directory")
+	fs.StringVar(&snapshotsDir, "registry-snapshots", "", "Path to the step registry snapshots directory")
+	fs.StringVar(&overlaysDir, "registry-overlays", "", "Path to the step registry overlays directory")
 	fs.StringVar(&profilesConfigPath, "cluster-profiles-config", "", "Path to the cluster profile config file")
 	fs.StringVar(&clusterClaimConfigPath, "cluster-claim-owners-config", "", "Path to the cluster claim owners config file")
+	fs.StringVar(&secretBootstrapConfigPath, "secret-bootstrap-config", "", "Path to the ci-secret-bootstrap config file, used to validate pull secrets")
+	fs.StringVar(&goVersionPolicyPath, "go-version-policy", "", "Path to the policy declaring the Go versions allowed in build roots for each release")
+	fs.StringVar(&ruleAllowlistPath, "validation-rule-allowlist", "", "Path to the allowlist of the validation rules the configurations of each repository may disable")
+	fs.StringVar(&resourceCeilingsPath, "resource-ceilings", "", "Path to the policy declaring the largest cpu and memory requests of builds and tests for each organization")
+	fs.BoolVar(&checkInRepoBuildRoots, "check-in-repo-build-roots", false, "Fetch the build roots read from repositories from GitHub to check them against the Go version policy")
+	fs.StringVar(&o.releaseRepo, "release-repo", "", "Path to the release repo, used with --base-ref and to read the default job timeout from the Prow configuration")
+	fs.StringVar(&o.baseRef, "base-ref", "", "When set, only validate the configurations affected by the changes to the release repo since this revision")
+	fs.BoolVar(&o.full, "full", false, "Validate all configurations, even if --base-ref is set")
+	fs.StringVar(&hiveKubeconfigPath, "hive-kubeconfig", "", "Path to the kubeconfig of the cluster running Hive, used to check that the cluster claims of tests are served by a cluster pool")
+	fs.BoolVar(&o.strict, "strict", false, "Enforce the strict validation rules, e.g. require container tests and literal test steps to request cpu and memory explicitly")
+	fs.StringVar(&architectures, "architectures", "", "Comma-separated list of the architectures images may be built for, replacing the default ones, e.g. when the build farms gain nodes of a new architecture")
+	fs.BoolVar(&o.printSchema, "print-schema", false, "Print the JSON Schema of the ci-operator configuration and step registry files and exit")
 	o.Options.Bind(fs)
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
+	if o.printSchema {
+		return nil
+	}
+	if architectures != "" {
+		parsed, err := api.ParseArchitectures(strings.Split(architectures, ","))
+		if err != nil {
+			return fmt.Errorf("invalid --architectures: %w", err)
+		}
+		o.architectures = parsed
+	}
 
-	if err := o.loadResolver(registryDir); err != nil {
+	if o.baseRef != "" && o.releaseRepo == "" {
+		return errors.New("--release-repo is required with --base-ref")
+	}
+	if o.baseRef != "" && registryDir == "" {
+		return errors.New("--registry is required with --base-ref")
+	}
+	if err := o.loadResolver(registryDir, snapshotsDir, overlaysDir); err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 
@@ -68,6 +152,63 @@ func (o *options) parse() error {
 	}
 	o.clusterClaimOwners = claimOwners
 
+	if secretBootstrapConfigPath != "" {
+		var secretConfig secretbootstrap.Config
+		if err := secretbootstrap.LoadConfigFromFile(secretBootstrapConfigPath, &secretConfig); err != nil {
+			return fmt.Errorf("failed to load secret bootstrap config: %w", err)
+		}
+		o.pullSecrets = testCredentialsPullSecrets(&secretConfig)
+	}
+
+	if goVersionPolicyPath != "" {
+		if o.goVersionPolicy, err = load.GoVersionPolicy(goVersionPolicyPath); err != nil {
+			return err
+		}
+		if checkInRepoBuildRoots {
+			o.inRepoBuildRoot = inRepoBuildRoot
+		}
+	} else if checkInRepoBuildRoots {
+		return errors.New("--check-in-repo-build-roots requires --go-version-policy")
+	}
+
+	if hiveKubeconfigPath != "" {
+		if o.clusterPools, err = listClusterPools(hiveKubeconfigPath); err != nil {
+			return err
+		}
+	}
+
+	if resourceCeilingsPath != "" {
+		if o.resourceCeilings, err = load.ResourceCeilingPolicy(resourceCeilingsPath); err != nil {
+			return err
+		}
+	}
+
+	o.ruleAllowlist = &api.ValidationRuleAllowlist{}
+	if ruleAllowlistPath != "" {
+		if o.ruleAllowlist, err = load.ValidationRuleAllowlist(ruleAllowlistPath); err != nil {
+			return err
+		}
+	}
+
+	o.defaultJobTimeout = func(api.Metadata) time.Duration { return 0 }
+	o.globalFiles = sets.New[string]()
+	if o.releaseRepo != "" {
+		prowConfig, err := config.LoadProwConfig(o.releaseRepo)
+		if err != nil {
+			return err
+		}
+		o.defaultJobTimeout = api.DefaultJobTimeouts(prowConfig)
+		o.globalFiles.Insert(config.ConfigInRepoPath)
+	}
+	for _, path := range []string{profilesConfigPath, clusterClaimConfigPath, secretBootstrapConfigPath, goVersionPolicyPath, ruleAllowlistPath, resourceCeilingsPath} {
+		if path == "" || o.releaseRepo == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(o.releaseRepo, path); err == nil {
+			o.globalFiles.Insert(rel)
+		}
+	}
+
 	ciOPConfigAgent, err := agents.NewConfigAgent(o.ConfigDir, nil, agents.WithOrg(o.Org), agents.WithRepo(o.Repo))
 	if err != nil {
 		return fmt.Errorf("failed to create CI Op config agent: %w", err)
@@ -83,45 +224,77 @@ func (o *options) parse() error {
 	return nil
 }
 
-func (o *options) validate() (ret []error) {
-	inputCh := make(chan api.ReleaseBuildConfiguration)
-	produce := func() error {
-		defer close(inputCh)
-		for _, v := range o.ciOPConfigAgent.GetAll() {
-			for _, configs := range v {
-				for _, c := range configs {
-					inputCh <- c
-				}
-			}
+func (o *options) validate() (*validation.Report, []error) {
+	var configs []api.ReleaseBuildConfiguration
+	for _, v := range o.ciOPConfigAgent.GetAll() {
+		for _, c := range v {
+			configs = append(configs, c...)
 		}
-		return nil
 	}
-	outputCh := make(chan promotedTag)
-	errCh := make(chan error)
-	map_ := func() error {
-		validator := validation.NewValidator(o.clusterProfiles, o.clusterClaimOwners)
-		for c := range inputCh {
-			if err := o.validateConfiguration(&validator, outputCh, c); err != nil {
-				errCh <- fmt.Errorf("failed to validate configuration %s: %w", c.Metadata.RelativePath(), err)
-			}
+	// promoted tags are checked across all configurations, even when only
+	// some of them are validated
+	corpus := validation.NewCorpusValidator(configs, release.PromotedTags)
+	configs, err := o.affectedConfigurations(configs)
+	if err != nil {
+		return &validation.Report{}, []error{err}
+	}
+	if o.baseRef != "" {
+		added, err := config.GetNewRepositoryConfigs(o.releaseRepo, o.baseRef)
+		if err != nil {
+			return &validation.Report{}, []error{fmt.Errorf("failed to determine new configurations: %w", err)}
 		}
-		return nil
+		o.newConfigs = sets.New[string](added...)
 	}
-	seen := tagSet{}
-	reduce := func() error {
-		for i := range outputCh {
-			seen[i.tag] = append(seen[i.tag], i.metadata)
+	newValidator := func() validation.Validator {
+		validator := validation.NewValidator(o.clusterProfiles, o.clusterClaimOwners, o.pullSecrets)
+		if o.strict {
+			validator = validator.WithStrictMode()
 		}
-		return nil
+		if o.clusterPools != nil {
+			validator = validator.WithClusterPools(o.clusterPools)
+		}
+		if o.resourceCeilings != nil {
+			validator = validator.WithResourceCeilings(o.resourceCeilings)
+		}
+		if o.architectures != nil {
+			validator = validator.WithArchitectures(o.architectures)
+		}
+		validator = validator.WithTimeoutChains(o.defaultJobTimeout)
+		return validator
+	}
+	report := validation.ValidateAll(configs, 0, newValidator, func(validator *validation.Validator, c *api.ReleaseBuildConfiguration) error {
+		return o.validateConfiguration(validator, *c)
+	})
+	return report, corpus.Validate()
+}
+
+// affectedConfigurations filters the configurations down to the ones affected
+// by the changes since the base revision, unless all of them are to be
+// validated.
+func (o *options) affectedConfigurations(configs []api.ReleaseBuildConfiguration) ([]api.ReleaseBuildConfiguration, error) {
+	if o.full || o.baseRef == "" {
+		return configs, nil
+	}
+	changed, err := config.GetChangedFiles(o.releaseRepo, o.baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine changed files: %w", err)
 	}
-	done := func() { close(outputCh) }
-	if err := util.ProduceMapReduce(0, produce, map_, reduce, done, errCh); err != nil {
-		ret = append(ret, err)
+	scope := config.GetValidationScope(changed, o.globalFiles, o.graph, configs)
+	if scope.Full {
+		logrus.Infof("Validating all configurations: %s", scope.Reason)
+		return configs, nil
 	}
-	return append(ret, validateTags(seen)...)
+	var ret []api.ReleaseBuildConfiguration
+	for i := range configs {
+		if scope.Includes(&configs[i].Metadata) {
+			ret = append(ret, configs[i])
+		}
+	}
+	logrus.Infof("Validating %d of %d configurations affected by %d changed files", len(ret), len(configs), len(changed))
+	return ret, nil
 }
 
-func (o *options) loadResolver(path string) error {
+func (o *options) loadResolver(path, snapshotsPath, overlaysPath string) error {
 	if path == "" {
 		return nil
 	}
@@ -129,22 +302,44 @@ func (o *options) loadResolver(path string) error {
 	if err != nil {
 		return err
 	}
-	o.resolver = registry.NewResolver(refs, chains, workflows, observers)
-	return nil
+	if o.graph, err = registry.NewGraph(refs, chains, workflows, observers); err != nil {
+		return err
+	}
+	var snapshots map[string]registry.Snapshot
+	if snapshotsPath != "" {
+		if snapshots, err = load.RegistrySnapshots(snapshotsPath, load.RegistryFlag(0), nil); err != nil {
+			return err
+		}
+	}
+	var overlays []registry.Overlay
+	if overlaysPath != "" {
+		if overlays, err = load.RegistryOverlays(overlaysPath, load.RegistryFlag(0)); err != nil {
+			return err
+		}
+	}
+	o.resolver, err = registry.NewResolverWithOverlays(refs, chains, workflows, observers, snapshots, overlays)
+	return err
 }
 
 func (o *options) validateConfiguration(
 	validator *validation.Validator,
-	seenCh chan<- promotedTag,
 	configuration api.ReleaseBuildConfiguration,
 ) error {
+	// the steps of the resolved configuration include the registry steps, so
+	// their commands are linted as well
+	warnings := validation.ConfigurationWarnings(&configuration)
 	if o.resolver != nil {
-		if c, err := registry.ResolveConfig(o.resolver, configuration); err != nil {
+		c, err := registry.ResolveConfig(o.resolver, configuration)
+		if err != nil {
 			return err
-		} else if err := validator.IsValidResolvedConfiguration(&c); err != nil {
+		}
+		if warnings, err = validator.IsValidResolvedConfiguration(&c); err != nil {
 			return err
 		}
 	}
+	for _, warning := range warnings {
+		logrus.WithField("config", configuration.Metadata.RelativePath()).Warnf("Configuration warning: %s", warning)
+	}
 	if _, err := o.ciOPConfigAgent.GetMatchingConfig(configuration.Metadata); err != nil {
 		return err
 	}
@@ -152,32 +347,67 @@ func (o *options) validateConfiguration(
 	if err := validation.IsValidGraphConfiguration(graphConf.Steps); err != nil {
 		return err
 	}
-	for _, tag := range release.PromotedTags(&configuration) {
-		seenCh <- promotedTag{tag, &configuration.Metadata}
-	}
 	if configuration.PromotionConfiguration != nil && configuration.PromotionConfiguration.RegistryOverride != "" {
 		return errors.New("setting promotion.registry_override is not allowed")
 	}
+	if err := validation.ValidateDisabledRules(o.ruleAllowlist, &configuration); err != nil {
+		return err
+	}
+	if o.newConfigs.Has(configuration.Metadata.RelativePath()) {
+		if err := validation.ValidateLatestSchemaVersion(&configuration); err != nil {
+			return err
+		}
+	}
+	if o.goVersionPolicy != nil {
+		return validation.ValidateGoVersionPolicy(o.goVersionPolicy, &configuration, o.inRepoBuildRoot)
+	}
 	return nil
 }
 
-func validateTags(seen tagSet) []error {
-	var dupes []error
-	for tag, infos := range seen {
-		if len(infos) <= 1 {
-			continue
-		}
-		formatted := []string{}
-		for _, info := range infos {
-			identifier := fmt.Sprintf("%s/%s@%s", info.Org, info.Repo, info.Branch)
-			if info.Variant != "" {
-				identifier = fmt.Sprintf("%s [%s]", identifier, info.Variant)
+// inRepoBuildRoot fetches the build root declared in the repository from
+// GitHub.
+func inRepoBuildRoot(metadata api.Metadata) (*api.ImageStreamTagReference, error) {
+	root, err := apihelper.TagReferenceInRepoConfigFile(metadata, github.FileGetterFactory)
+	if err != nil || root == (api.ImageStreamTagReference{}) {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// testCredentialsPullSecrets returns the names of all the docker config secrets
+// that ci-secret-bootstrap populates in the test-credentials namespace.
+func testCredentialsPullSecrets(config *secretbootstrap.Config) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, secret := range config.Secrets {
+		for _, to := range secret.To {
+			if to.Namespace == "test-credentials" && to.Type == corev1.SecretTypeDockerConfigJson {
+				ret.Insert(to.Name)
 			}
-			formatted = append(formatted, identifier)
 		}
-		dupes = append(dupes, fmt.Errorf("output tag %s is promoted from more than one place: %v", tag.ISTagName(), strings.Join(formatted, ", ")))
 	}
-	return dupes
+	return ret
+}
+
+// listClusterPools lists the cluster pools of all namespaces of the cluster
+// running Hive.
+func listClusterPools(kubeconfigPath string) ([]hivev1.ClusterPool, error) {
+	kubeconfig, err := util.LoadKubeConfig(kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load Hive kube config from path %s: %w", kubeconfigPath, err)
+	}
+	scheme := runtime.NewScheme()
+	if err := hivev1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add hivev1 to scheme: %w", err)
+	}
+	client, err := ctrlruntimeclient.New(kubeconfig, ctrlruntimeclient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("could not create Hive client: %w", err)
+	}
+	pools := &hivev1.ClusterPoolList{}
+	if err := client.List(context.Background(), pools); err != nil {
+		return nil, fmt.Errorf("failed to list cluster pools: %w", err)
+	}
+	return pools.Items, nil
 }
 
 func main() {
@@ -185,10 +415,22 @@ func main() {
 	if err := o.parse(); err != nil {
 		logrus.WithError(err).Fatal("failed to parse arguments")
 	}
-	if errs := o.validate(); errs != nil {
-		for _, err := range errs {
-			logrus.WithError(err).Error()
+	if o.printSchema {
+		raw, err := schema.Print()
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to generate the schema")
 		}
+		fmt.Println(string(raw))
+		return
+	}
+	report, errs := o.validate()
+	for _, err := range errs {
+		logrus.WithError(err).Error()
+	}
+	if len(report.Errors) > 0 {
+		fmt.Fprint(os.Stderr, report.String())
+	}
+	if len(report.Errors) > 0 || len(errs) > 0 {
 		logrus.Fatal("error validating configuration files")
 	}
 }
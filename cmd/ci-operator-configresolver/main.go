@@ -27,11 +27,14 @@ import (
 	"sigs.k8s.io/prow/pkg/metrics"
 	"sigs.k8s.io/prow/pkg/pjutil"
 	"sigs.k8s.io/prow/pkg/simplifypath"
+	"sigs.k8s.io/prow/pkg/version"
 
 	imagev1 "github.com/openshift/api/image/v1"
 
+	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/configresolver"
 	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/configquery"
 	"github.com/openshift/ci-tools/pkg/html"
 	"github.com/openshift/ci-tools/pkg/load/agents"
 	registryserver "github.com/openshift/ci-tools/pkg/registry/server"
@@ -42,6 +45,8 @@ import (
 type options struct {
 	configPath             string
 	registryPath           string
+	snapshotsPath          string
+	overlaysPath           string
 	logLevel               string
 	address                string
 	releaseRepoGitSyncPath string
@@ -51,6 +56,7 @@ type options struct {
 	gracePeriod            time.Duration
 	validateOnly           bool
 	flatRegistry           bool
+	resolutionCacheSize    int
 	instrumentationOptions flagutil.InstrumentationOptions
 }
 
@@ -63,6 +69,8 @@ func gatherOptions() (options, error) {
 	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	fs.StringVar(&o.configPath, "config", "", "Path to config dirs")
 	fs.StringVar(&o.registryPath, "registry", "", "Path to registry dirs")
+	fs.StringVar(&o.snapshotsPath, "registry-snapshots", "", "Path to the registry snapshots that configurations can pin their workflows, chains and references to")
+	fs.StringVar(&o.overlaysPath, "registry-overlays", "", "Path to the registry overlays applied to configurations on matching branches")
 	fs.StringVar(&o.releaseRepoGitSyncPath, "release-repo-git-sync-path", "", "Path to release repository dir")
 	fs.StringVar(&o.logLevel, "log-level", "info", "Level at which to log output.")
 	fs.StringVar(&o.address, "address", ":8080", "DEPRECATED: Address to run server on")
@@ -73,6 +81,7 @@ func gatherOptions() (options, error) {
 	_ = fs.Duration("cycle", time.Minute*2, "Legacy flag kept for compatibility. Does nothing")
 	fs.BoolVar(&o.validateOnly, "validate-only", false, "Load the config and registry, validate them and exit.")
 	fs.BoolVar(&o.flatRegistry, "flat-registry", false, "Disable directory structure based registry validation")
+	fs.IntVar(&o.resolutionCacheSize, "resolution-cache-size", 1000, "Number of resolved configurations to cache, 0 disables the cache")
 	o.instrumentationOptions.AddFlags(fs)
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return o, fmt.Errorf("failed to parse flags: %w", err)
@@ -86,8 +95,12 @@ func validateOptions(o *options) error {
 		return fmt.Errorf("invalid --log-level: %w", err)
 	}
 
-	if o.releaseRepoGitSyncPath != "" && (o.configPath != "" || o.registryPath != "") {
-		return fmt.Errorf("--release-repo-path is mutually exclusive with --config and --registry")
+	if o.resolutionCacheSize < 0 {
+		return fmt.Errorf("--resolution-cache-size must not be negative")
+	}
+
+	if o.releaseRepoGitSyncPath != "" && (o.configPath != "" || o.registryPath != "" || o.snapshotsPath != "" || o.overlaysPath != "") {
+		return fmt.Errorf("--release-repo-path is mutually exclusive with --config, --registry, --registry-snapshots and --registry-overlays")
 	}
 
 	if o.releaseRepoGitSyncPath == "" {
@@ -122,6 +135,12 @@ func validateOptions(o *options) error {
 
 		o.configPath = filepath.Join(o.releaseRepoGitSyncPath, config.CiopConfigInRepoPath)
 		o.registryPath = filepath.Join(o.releaseRepoGitSyncPath, config.RegistryPath)
+		if snapshotsPath := filepath.Join(o.releaseRepoGitSyncPath, config.RegistrySnapshotsPath); exists(snapshotsPath) {
+			o.snapshotsPath = snapshotsPath
+		}
+		if overlaysPath := filepath.Join(o.releaseRepoGitSyncPath, config.RegistryOverlaysPath); exists(overlaysPath) {
+			o.overlaysPath = overlaysPath
+		}
 	}
 
 	if o.validateOnly && o.flatRegistry {
@@ -130,6 +149,11 @@ func validateOptions(o *options) error {
 	return o.instrumentationOptions.Validate(false)
 }
 
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func getConfigGeneration(agent agents.ConfigAgent) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -144,6 +168,22 @@ func getRegistryGeneration(agent agents.RegistryAgent) http.HandlerFunc {
 	}
 }
 
+func getRegistryDigest(agent agents.RegistryAgent) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, agent.GetDigest())
+	}
+}
+
+// getVersion advertises the version of the resolver, which is deployed from
+// the same revision as ci-operator.
+func getVersion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, version.Version)
+	}
+}
+
 type memoryCache struct {
 	Client                 ctrlruntimeclient.Client
 	IntegratedStreamsMutex sync.Mutex
@@ -292,7 +332,7 @@ func main() {
 	go func() { logrus.Fatal(<-configErrCh) }()
 
 	registryErrCh := make(chan error)
-	registryAgent, err := agents.NewRegistryAgent(o.registryPath, registryErrCh, agents.WithRegistryMetrics(configresolverMetrics.ErrorRate), agents.WithRegistryFlat(o.flatRegistry), registryAgentOption)
+	registryAgent, err := agents.NewRegistryAgent(o.registryPath, registryErrCh, agents.WithRegistryMetrics(configresolverMetrics.ErrorRate), agents.WithRegistryFlat(o.flatRegistry), agents.WithRegistrySnapshots(o.snapshotsPath), agents.WithRegistryOverlays(o.overlaysPath), registryAgentOption)
 	if err != nil {
 		logrus.Fatalf("Failed to get registry agent: %v", err)
 	}
@@ -307,6 +347,17 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to create oc client")
 	}
 
+	// the default job timeout is only known when the Prow configuration is
+	// synced along with the ci-operator configuration
+	defaultJobTimeout := func(api.Metadata) time.Duration { return 0 }
+	if o.releaseRepoGitSyncPath != "" {
+		prowConfig, err := config.LoadProwConfig(o.releaseRepoGitSyncPath)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to load the Prow configuration")
+		}
+		defaultJobTimeout = api.DefaultJobTimeouts(prowConfig)
+	}
+
 	if o.validateOnly {
 		os.Exit(0)
 	}
@@ -320,9 +371,12 @@ func main() {
 		l("config"),
 		l("resolve"),
 		l("clusterProfile"),
+		l("usages"),
 		l("configGeneration"),
 		l("registryGeneration"),
+		l("registryDigest"),
 		l("integratedStream"),
+		l("version"),
 	))
 
 	uisimplifier := simplifypath.NewSimplifier(l("", // shadow element mimicing the root
@@ -337,12 +391,20 @@ func main() {
 	uihandler := metrics.TraceHandler(uisimplifier, configresolverMetrics.HTTPRequestDuration, configresolverMetrics.HTTPResponseSize)
 	// add handler func for incorrect paths as well; can help with identifying errors/404s caused by incorrect paths
 	http.HandleFunc("/", handler(http.HandlerFunc(http.NotFound)).ServeHTTP)
-	http.HandleFunc("/config", handler(registryserver.ResolveConfig(configAgent, registryAgent, configresolverMetrics)).ServeHTTP)
-	http.HandleFunc("/mergeConfigsWithInjectedTest", handler(registryserver.ResolveAndMergeConfigsAndInjectTest(configAgent, registryAgent, configresolverMetrics)).ServeHTTP)
-	http.HandleFunc("/resolve", handler(registryserver.ResolveLiteralConfig(registryAgent, configresolverMetrics)).ServeHTTP)
+	var resolver registryserver.Resolver = registryAgent
+	if o.resolutionCacheSize > 0 {
+		resolver = registryserver.NewCachingResolver(registryAgent, registryAgent.GetGeneration, o.resolutionCacheSize)
+	}
+	http.HandleFunc("/config", handler(registryserver.ResolveConfig(configAgent, resolver, configresolverMetrics)).ServeHTTP)
+	http.HandleFunc("/mergeConfigsWithInjectedTest", handler(registryserver.ResolveAndMergeConfigsAndInjectTest(configAgent, resolver, configresolverMetrics)).ServeHTTP)
+	http.HandleFunc("/resolve", handler(registryserver.ResolveLiteralConfig(resolver, configresolverMetrics)).ServeHTTP)
 	http.HandleFunc("/clusterProfile", handler(registryserver.ResolveClusterProfile(registryAgent, configresolverMetrics)).ServeHTTP)
+	http.HandleFunc("/usages", handler(registryserver.ResolveUsages(configquery.NewService(configAgent, registryAgent), configresolverMetrics)).ServeHTTP)
+	http.HandleFunc("/timeouts", handler(registryserver.ResolveTimeouts(configAgent, resolver, defaultJobTimeout, configresolverMetrics)).ServeHTTP)
 	http.HandleFunc("/configGeneration", handler(getConfigGeneration(configAgent)).ServeHTTP)
 	http.HandleFunc("/registryGeneration", handler(getRegistryGeneration(registryAgent)).ServeHTTP)
+	http.HandleFunc("/registryDigest", handler(getRegistryDigest(registryAgent)).ServeHTTP)
+	http.HandleFunc("/version", handler(getVersion()).ServeHTTP)
 	cache := memoryCache{Client: ocClient, CacheDuration: time.Minute}
 	http.HandleFunc("/integratedStream", handler(getIntegratedStream(context.Background(), &cache)).ServeHTTP)
 	http.HandleFunc("/readyz", func(_ http.ResponseWriter, _ *http.Request) {})
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -31,11 +32,15 @@ type options struct {
 	toDir         string
 	toReleaseRepo bool
 
-	registryPath string
-	resolver     registry.Resolver
+	registryPath          string
+	registrySnapshotsPath string
+	registryOverlaysPath  string
+	resolver              registry.Resolver
 
 	knownInfraJobFiles flagutil.Strings
 
+	pruneGracePeriod time.Duration
+
 	help bool
 }
 
@@ -49,11 +54,15 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.BoolVar(&opt.toReleaseRepo, "to-release-repo", false, "If set, it behaves like --to-dir=$GOPATH/src/github.com/openshift/release/ci-operator/jobs")
 
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&opt.registrySnapshotsPath, "registry-snapshots", "", "Path to the stored snapshots of the step registry, which versions pinned with name@version are resolved from")
+	flag.StringVar(&opt.registryOverlaysPath, "registry-overlays", "", "Path to the registry overlays applied to configurations on matching branches")
 
 	flag.BoolVar(&opt.help, "h", false, "Show help for ci-operator-prowgen")
 
 	flag.Var(&opt.knownInfraJobFiles, "known-infra-file", "Name of a known infra-file that will not be acted on. Can be passed multiple times.")
 
+	flag.DurationVar(&opt.pruneGracePeriod, "prune-grace-period", 0, "If set, jobs of removed tests are made optional and no longer run automatically, and are only removed once this period has passed")
+
 	opt.Options.Bind(flag)
 
 	return opt
@@ -91,11 +100,11 @@ func (o *options) process() error {
 		return fmt.Errorf("failed to complete config options: %w", err)
 	}
 	if o.registryPath != "" {
-		refs, chains, workflows, _, _, _, observers, err := load.Registry(o.registryPath, load.RegistryFlag(0))
+		resolver, err := load.RegistryResolver(o.registryPath, o.registrySnapshotsPath, o.registryOverlaysPath, load.RegistryFlag(0))
 		if err != nil {
 			return fmt.Errorf("failed to load registry: %w", err)
 		}
-		o.resolver = registry.NewResolver(refs, chains, workflows, observers)
+		o.resolver = resolver
 	}
 	return nil
 }
@@ -117,7 +126,7 @@ func (o *options) generateJobsToDir(subDir string, prowConfig map[string]*config
 	}); err != nil {
 		return fmt.Errorf("failed to read job directory paths: %w", err)
 	}
-	return writeToDir(o.toDir, generated)
+	return writeToDir(o.toDir, generated, o.pruneGracePeriod)
 }
 
 func generateJobs(resolver registry.Resolver, cache map[string]*config.Prowgen, output map[string]*prowconfig.JobConfig) func(configSpec *cioperatorapi.ReleaseBuildConfiguration, info *config.Info) error {
@@ -179,7 +188,7 @@ func getReleaseRepoDir(directory string) (string, error) {
 	return "", fmt.Errorf("%s is not an existing directory", tentative)
 }
 
-func writeToDir(dir string, c map[string]*prowconfig.JobConfig) error {
+func writeToDir(dir string, c map[string]*prowconfig.JobConfig, pruneGracePeriod time.Duration) error {
 	type item struct {
 		k string
 		v *prowconfig.JobConfig
@@ -197,7 +206,7 @@ func writeToDir(dir string, c map[string]*prowconfig.JobConfig) error {
 		for x := range ch {
 			i := strings.Index(x.k, "/")
 			org, repo := x.k[:i], x.k[i+1:]
-			if err := jc.WriteToDir(dir, org, repo, x.v, prowgen.Generator, nil); err != nil {
+			if err := jc.WriteToDir(dir, org, repo, x.v, prowgen.Generator, nil, jc.WithPruneGracePeriod(pruneGracePeriod)); err != nil {
 				errCh <- err
 			}
 		}
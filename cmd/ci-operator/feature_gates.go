@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/featuregate"
+)
+
+// jobOrg is the organization of the repository the job runs for, empty for
+// jobs without any refs.
+func jobOrg(spec *api.JobSpec) string {
+	if spec.Refs != nil {
+		return spec.Refs.Org
+	}
+	if len(spec.ExtraRefs) > 0 {
+		return spec.ExtraRefs[0].Org
+	}
+	return ""
+}
+
+// resolveFeatureGates determines the feature gates of the job and turns off
+// the behaviors requested by flags whose gate is disabled.
+func (o *options) resolveFeatureGates() error {
+	var config *featuregate.Configuration
+	if o.featureGateConfigPath != "" {
+		var err error
+		if config, err = featuregate.LoadConfiguration(o.featureGateConfigPath); err != nil {
+			return err
+		}
+	}
+	o.featureGates = featuregate.Resolve(config, o.featureGateOverrides, jobOrg(o.jobSpec), o.jobSpec.Job)
+	for _, gated := range []struct {
+		gate      featuregate.Gate
+		requested bool
+		disable   func()
+	}{
+		{gate: featuregate.BuildCache, requested: o.buildCacheNamespace != "", disable: func() { o.buildCacheNamespace = "" }},
+		{gate: featuregate.ResultReuse, requested: o.resultReuseNamespace != "", disable: func() { o.resultReuseNamespace = "" }},
+		{gate: featuregate.StreamArtifacts, requested: o.streamArtifacts, disable: func() { o.streamArtifacts = false }},
+		{gate: featuregate.StreamStepLogs, requested: o.streamStepLogs, disable: func() { o.streamStepLogs = false }},
+	} {
+		if gated.requested && !o.featureGates.Enabled(gated.gate) {
+			logrus.Infof("The %s feature gate is disabled for this job.", gated.gate)
+			gated.disable()
+		}
+	}
+	return nil
+}
+
+// reportFeatureGates sends the feature gates of the job, so that the outcome
+// of jobs can be compared between the gates being rolled out.
+func (o *options) reportFeatureGates() {
+	reporter, err := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not load result reporting options, the feature gates will not be reported.")
+		return
+	}
+	gates := map[string]bool{}
+	for gate, enabled := range o.featureGates {
+		gates[string(gate)] = enabled
+	}
+	reporter.ReportFeatureGates(jobOrg(o.jobSpec), gates)
+}
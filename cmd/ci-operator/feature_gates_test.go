@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/featuregate"
+)
+
+func TestResolveFeatureGates(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "feature-gates.yaml")
+	if err := os.WriteFile(config, []byte("gates:\n  BuildCache:\n    orgs:\n      org: 0\n  ResultReuse:\n    percentage: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o := &options{
+		jobSpec:               &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-unit", Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}},
+		featureGateConfigPath: config,
+		featureGateOverrides:  featuregate.Overrides{featuregate.ResultReuse: true},
+		buildCacheNamespace:   "build-cache",
+		resultReuseNamespace:  "result-reuse",
+		streamArtifacts:       true,
+	}
+	if err := o.resolveFeatureGates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := featuregate.Gates{featuregate.BuildCache: false, featuregate.ResultReuse: true, featuregate.StreamArtifacts: true, featuregate.StreamStepLogs: true}
+	if diff := cmp.Diff(expected, o.featureGates); diff != "" {
+		t.Errorf("unexpected gates: %s", diff)
+	}
+	if o.buildCacheNamespace != "" {
+		t.Errorf("expected the build cache to be disabled, got namespace %q", o.buildCacheNamespace)
+	}
+	if o.resultReuseNamespace != "result-reuse" || !o.streamArtifacts {
+		t.Errorf("expected the behaviors of enabled gates to be kept, got result reuse namespace %q and streaming %t", o.resultReuseNamespace, o.streamArtifacts)
+	}
+}
+
+func TestJobOrg(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		spec     downwardapi.JobSpec
+		expected string
+	}{
+		{
+			name:     "refs",
+			spec:     downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org"}, ExtraRefs: []prowapi.Refs{{Org: "other"}}},
+			expected: "org",
+		},
+		{
+			name:     "periodic with extra refs",
+			spec:     downwardapi.JobSpec{ExtraRefs: []prowapi.Refs{{Org: "other"}}},
+			expected: "other",
+		},
+		{
+			name: "no refs",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, jobOrg(&api.JobSpec{JobSpec: tc.spec})); diff != "" {
+				t.Errorf("unexpected org: %s", diff)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"math/rand"
 	"os"
 	"os/exec"
@@ -26,6 +27,7 @@ import (
 	"time"
 
 	"github.com/bombsimon/logrusr/v3"
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/go-logr/logr"
 	egressfirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
 	"github.com/sirupsen/logrus"
@@ -37,6 +39,7 @@ import (
 	rbacapi "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -57,9 +60,11 @@ import (
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	_ "sigs.k8s.io/prow/pkg/cache"
 	"sigs.k8s.io/prow/pkg/config/secret"
+	"sigs.k8s.io/prow/pkg/github"
 	prowio "sigs.k8s.io/prow/pkg/io"
 	"sigs.k8s.io/prow/pkg/logrusutil"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/secretutil"
 	"sigs.k8s.io/prow/pkg/version"
 	csiapi "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
 	"sigs.k8s.io/yaml"
@@ -78,17 +83,27 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/configresolver"
 	"github.com/openshift/ci-tools/pkg/api/nsttl"
+	"github.com/openshift/ci-tools/pkg/attestation"
+	"github.com/openshift/ci-tools/pkg/buildroot"
+	"github.com/openshift/ci-tools/pkg/controller/namespacepool"
 	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/durationslo"
+	"github.com/openshift/ci-tools/pkg/featuregate"
+	"github.com/openshift/ci-tools/pkg/github/apptoken"
 	"github.com/openshift/ci-tools/pkg/interrupt"
 	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/labeledclient"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/registry"
 	"github.com/openshift/ci-tools/pkg/registry/server"
+	"github.com/openshift/ci-tools/pkg/resultreuse"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/targetsummary"
+	"github.com/openshift/ci-tools/pkg/timing"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 	"github.com/openshift/ci-tools/pkg/validation"
@@ -174,7 +189,31 @@ defined in the config file, such as base images and the release tag configuratio
 After a successful build the --promote will tag each built image (in "images")
 to the image stream(s) identified by the "promotion" config. You may add
 additional images to promote and their target names via the "additional_images"
-map.
+map. With --promote-dry-run the promotion only reports the tags it would create
+or overwrite, with their current and new digests, and pushes nothing.
+
+Once the namespace is set up and before executing the graph, ci-operator probes
+the health of the build farm: the latency of the API server, whether the input
+imagestream tags have an image and the resource quotas of the namespace. The
+API server is probed several times, so that a single slow answer does not abort
+the job. When a probe fails, the job is aborted and its result is reported with
+the "infrastructure_degraded" reason alone, as it can be retried once the build
+farm recovers. --skip-preflight disables the probes.
+
+Presubmit tests setting "reuse_results" report their last successful result on
+the pull request instead of running again when a new push changes none of their
+inputs: the files matching their source paths, the resolved configuration and the
+input images. Results are recorded in the --result-reuse-namespace and the
+"ci/no-result-reuse" label on the pull request makes the tests run regardless.
+
+Behaviors which are risky to enable for all jobs at once are governed by feature
+gates, which the infrastructure rolls out to a percentage of the jobs of each
+organization with the --feature-gate-config file. --feature-gates sets gates for
+a run regardless of their rollout, e.g. --feature-gates=ResultReuse=false.
+
+To run ci-operator outside of Prow, "ci-operator synth-jobspec --org ORG --repo REPO
+--branch BRANCH [--pr NUMBER]" prints a JOB_SPEC for the current commits of the branch
+or pull request, which can be exported in the environment of ci-operator.
 `
 
 const (
@@ -192,6 +231,12 @@ var (
 const CustomProwMetadata = "custom-prow-metadata.json"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == synthJobSpecCommand {
+		if err := synthJobSpec(os.Args[2:], os.Stdout); err != nil {
+			logrus.WithError(err).Fatal("failed to synthesize job spec")
+		}
+		return
+	}
 	censor, closer, err := setupLogger()
 	if err != nil {
 		logrus.WithError(err).Fatal("Could not set up logging.")
@@ -357,8 +402,15 @@ type options struct {
 	sshKeyPath           string
 	oauthTokenPath       string
 
-	targets stringSlice
-	promote bool
+	githubAppID             string
+	githubAppPrivateKeyPath string
+	githubAppTokens         *githubAppTokens
+
+	targets       stringSlice
+	promote       bool
+	promoteDryRun bool
+
+	summaryCommentTokenPath string
 
 	verbose    bool
 	help       bool
@@ -375,8 +427,13 @@ type options struct {
 	idleCleanupDurationSet bool
 	cleanupDuration        time.Duration
 	cleanupDurationSet     bool
+	// claimPooledNamespace requests a pre-warmed namespace, namespaceClaimed
+	// records whether one was claimed
+	claimPooledNamespace bool
+	namespaceClaimed     bool
 
 	inputHash                  string
+	stepInputs                 api.InputDefinition
 	secrets                    []*coreapi.Secret
 	templates                  []*templateapi.Template
 	graphConfig                api.GraphConfiguration
@@ -390,6 +447,8 @@ type options struct {
 	leaseServerCredentialsFile string
 	leaseAcquireTimeout        time.Duration
 	leaseClient                lease.Client
+	leaseReservationsFile      string
+	leaseReservations          *lease.Reservations
 	clusterProfiles            []clusterProfileForTarget
 
 	givePrAuthorAccessToNamespace bool
@@ -397,13 +456,17 @@ type options struct {
 	authors                       []string
 
 	resolverAddress string
-	resolverClient  server.ResolverClient
-
-	registryPath string
-	org          string
-	repo         string
-	branch       string
-	variant      string
+	// resolvedConfigCacheDir caches the resolutions of --unresolved-config
+	resolvedConfigCacheDir string
+	resolverClient         server.ResolverClient
+
+	registryPath          string
+	registrySnapshotsPath string
+	registryOverlaysPath  string
+	org                   string
+	repo                  string
+	branch                string
+	variant               string
 
 	injectTest string
 
@@ -418,6 +481,13 @@ type options struct {
 	uploadSecretPath string
 	uploadSecret     *coreapi.Secret
 
+	streamArtifacts         bool
+	streamStepLogs          bool
+	s3UploadCredentialsPath string
+	artifactSizeLimit       string
+	junitFailureLogLimit    string
+	artifactOptions         steps.ArtifactOptions
+
 	cloneAuthConfig *steps.CloneAuthConfig
 
 	resultsOptions results.Options
@@ -436,6 +506,34 @@ type options struct {
 
 	restrictNetworkAccess       bool
 	enableSecretsStoreCSIDriver bool
+	stepLogLimit                api.LogLimit
+
+	artMetadataEndpoint string
+	cveScannerImage     string
+
+	importCoordinationNamespace string
+	buildCacheNamespace         string
+
+	resultReuseNamespace string
+	resultReuseTokenPath string
+	// resultStore and resultReuseKey record the result of the target when
+	// it may be reused by later pushes to the pull request
+	resultStore    *resultreuse.Store
+	resultReuseKey string
+
+	featureGateConfigPath string
+	featureGateOverrides  featuregate.Overrides
+	// featureGates are the gates resolved for the job
+	featureGates featuregate.Gates
+
+	// architectures replace the architectures images may be built for
+	architectures string
+	// supportedArchitectures are the architectures images may be built for
+	supportedArchitectures sets.Set[string]
+
+	skipPreflight       bool
+	preflightAPILatency time.Duration
+	preflightTimeout    time.Duration
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -455,7 +553,10 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease.")
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
+	flag.StringVar(&opt.leaseReservationsFile, "lease-reservations-file", "", "The path to a file capping the number of concurrent jobs per cluster profile. Tests using a profile with a reservation wait for quota before acquiring leases.")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&opt.registrySnapshotsPath, "registry-snapshots", "", "Path to the stored snapshots of the step registry, which versions pinned with name@version are resolved from")
+	flag.StringVar(&opt.registryOverlaysPath, "registry-overlays", "", "Path to the registry overlays applied to configurations on matching branches")
 	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
 	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
@@ -466,16 +567,21 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.Var(&opt.secretDirectories, "secret-dir", "One or more directories that should converted into secrets in the test namespace. If the directory contains a single file with name .dockercfg or config.json it becomes a pull secret.")
 	flag.StringVar(&opt.sshKeyPath, "ssh-key-path", "", "A path of the private ssh key that is going to be used to clone a private repository.")
 	flag.StringVar(&opt.oauthTokenPath, "oauth-token-path", "", "A path of the OAuth token that is going to be used to clone a private repository.")
+	flag.StringVar(&opt.githubAppID, "github-app-id", "", "The ID of a GitHub App whose installation tokens, scoped to the repositories of the job, are used to clone them. The token may also report statuses, it is refreshed during the run and stored in the "+githubAppTokenSecret+" secret of the test namespace.")
+	flag.StringVar(&opt.githubAppPrivateKeyPath, "github-app-private-key-path", "", "A path of the private key of the GitHub App set with --github-app-id.")
 
 	// the target namespace and cleanup behavior
 	flag.Var(&opt.extraInputHash, "input-hash", "Add arbitrary inputs to the build input hash to make the created namespace unique.")
 	flag.StringVar(&opt.namespace, "namespace", "", "Namespace to create builds into, defaults to build_id from JOB_SPEC. If the string '{id}' is in this value it will be replaced with the build input hash.")
+	flag.BoolVar(&opt.claimPooledNamespace, "claim-pooled-namespace", false, "Experimental: claim a pre-warmed namespace from the pool of the namespacepool controller instead of creating one, falling back to creating it when the pool is empty. The service account running ci-operator needs to be allowed to list and update namespaces. Pooled namespaces are not shared by jobs with the same inputs, so this only suits short jobs like lint or unit tests. Mutually exclusive with --namespace.")
 	flag.StringVar(&opt.baseNamespace, "base-namespace", "stable", "Namespace to read builds from, defaults to stable.")
 	flag.DurationVar(&opt.idleCleanupDuration, "delete-when-idle", opt.idleCleanupDuration, "If no pod is running for longer than this interval, delete the namespace. Set to zero to retain the contents. Requires the namespace TTL controller to be deployed.")
 	flag.DurationVar(&opt.cleanupDuration, "delete-after", opt.cleanupDuration, "If namespace exists for longer than this interval, delete the namespace. Set to zero to retain the contents. Requires the namespace TTL controller to be deployed.")
 
 	// actions to add to the graph
 	flag.BoolVar(&opt.promote, "promote", false, "When all other targets complete, publish the set of images built by this job into the release configuration.")
+	flag.BoolVar(&opt.promoteDryRun, "promote-dry-run", false, "Run the promotion checks and report the tags --promote would create or overwrite, without pushing any image. Implies --promote.")
+	flag.StringVar(&opt.summaryCommentTokenPath, "summary-comment-token-path", "", "A path of a GitHub token used to comment the summary of the targets on the pull request of a presubmit job running more than one target.")
 
 	// output control
 	flag.StringVar(&opt.artifactDir, "artifact-dir", "", "DEPRECATED. Does nothing, set $ARTIFACTS instead.")
@@ -487,9 +593,12 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.impersonateUser, "as", "", "Username to impersonate")
 	flag.BoolVar(&opt.restrictNetworkAccess, "restrict-network-access", false, "Restrict network access to 10.0.0.0/8 (RedHat intranet).")
 	flag.BoolVar(&opt.enableSecretsStoreCSIDriver, "enable-secrets-store-csi-driver", false, "Use Secrets Store CSI driver for accessing multi-stage credentials.")
+	flag.StringVar(&opt.stepLogLimit.Head, "step-log-limit-head", "", "Quantity of the start of the output of multi-stage steps kept in their logs, when steps do not set their own limit.")
+	flag.StringVar(&opt.stepLogLimit.Tail, "step-log-limit-tail", "", "Quantity of the end of the output of multi-stage steps kept in their logs, when steps do not set their own limit.")
 
 	// flags needed for the configresolver
 	flag.StringVar(&opt.resolverAddress, "resolver-address", configResolverAddress, "Address of configresolver")
+	flag.StringVar(&opt.resolvedConfigCacheDir, "resolved-config-cache-dir", "", "Directory caching the resolutions of --unresolved-config until the registry of the configresolver changes.")
 	flag.StringVar(&opt.org, "org", "", "Org of the project (used by configresolver)")
 	flag.StringVar(&opt.repo, "repo", "", "Repo of the project (used by configresolver)")
 	flag.StringVar(&opt.branch, "branch", "", "Branch of the project (used by configresolver)")
@@ -500,6 +609,13 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.pullSecretPath, "image-import-pull-secret", "", "A set of dockercfg credentials used to import images for the tag_specification.")
 	flag.StringVar(&opt.pushSecretPath, "image-mirror-push-secret", "", "A set of dockercfg credentials used to mirror images for the promotion.")
 	flag.StringVar(&opt.uploadSecretPath, "gcs-upload-secret", "", "GCS credentials used to upload logs and artifacts.")
+	flag.BoolVar(&opt.streamStepLogs, "stream-step-logs", false, "Upload the logs of multi-stage steps to the GCS or S3 bucket of the job while they run, as a live-log.txt next to the build-log.txt the sidecar uploads once the step finishes, so that long steps can be followed. Only the last 10000 lines of the log, at most 10MiB of them, are uploaded live. Uses the credentials from --gcs-upload-secret or --s3-upload-credentials.")
+	flag.BoolVar(&opt.streamArtifacts, "stream-artifacts", false, "Stream the artifacts copied out of the pods of template tests straight to the GCS or S3 bucket of the job instead of staging them in the artifact directory, which may exhaust the ephemeral storage for huge artifacts. Uses the credentials from --gcs-upload-secret or --s3-upload-credentials.")
+	flag.StringVar(&opt.artifactSizeLimit, "artifact-size-limit", "", "Maximum total size of the artifacts copied out of the pods of each template test, as a quantity like 5Gi. Artifacts are truncated and left out once the limit is reached, which is recorded in the ARTIFACT_MANIFEST.json listing the artifacts of the test. Unlimited when unset.")
+	flag.StringVar(&opt.junitFailureLogLimit, "junit-failure-log-limit", "", "Size of the end of the log of failed containers of template and container tests attached to their junit test case, as a quantity like 64Ki. Only the termination message of the containers is attached when unset.")
+	flag.IntVar(&opt.artifactOptions.DownloadConcurrency, "artifact-download-concurrency", 0, "Number of pods of each template test artifacts are copied out of at the same time. Defaults to 4 when unset.")
+	flag.IntVar(&opt.artifactOptions.DownloadAttempts, "artifact-download-attempts", 0, "Number of attempts at copying the artifacts out of a pod of a template test when the copy fails for transient reasons, with an exponential backoff starting at two seconds. Defaults to 5 when unset.")
+	flag.StringVar(&opt.s3UploadCredentialsPath, "s3-upload-credentials", "", "S3 credentials used to stream artifacts to the bucket of the job with --stream-artifacts.")
 
 	flag.StringVar(&opt.hiveKubeconfigPath, "hive-kubeconfig", "", "Path to the kubeconfig file to use for requests to Hive.")
 
@@ -510,6 +626,18 @@ func bindOptions(flag *flag.FlagSet) *options {
 
 	flag.StringVar(&opt.manifestToolDockerCfg, "manifest-tool-dockercfg", "/secrets/manifest-tool/.dockerconfigjson", "The dockercfg file path to be used to push the manifest listed image after build. This is being used by the manifest-tool binary.")
 	flag.StringVar(&opt.localRegistryDNS, "local-registry-dns", "image-registry.openshift-image-registry.svc:5000", "Defines the target image registry.")
+	flag.StringVar(&opt.importCoordinationNamespace, "image-import-coordination-namespace", "", "A namespace shared by all jobs on the cluster into which external input images are imported once, for the other jobs to tag instead of importing them again. Jobs must be allowed to manage imagestreams in it. Input images are imported by every job when unset.")
+	flag.StringVar(&opt.buildCacheNamespace, "build-cache-namespace", "", "A namespace shared by all jobs on the cluster whose pipeline imagestream holds the images built by the jobs, tagged by the digest of their inputs, for the other jobs to reuse instead of building identical images again. Jobs must be allowed to manage imagestreams in it. Images not reused for a week are pruned. Images are built by every job when unset.")
+	flag.StringVar(&opt.resultReuseNamespace, "result-reuse-namespace", "", "A namespace shared by all jobs on the cluster in which the successful results of presubmit targets setting `reuse_results` are recorded, so that later pushes to the pull request which do not change any input of the target report that result instead of running it again. Jobs must be allowed to manage ConfigMaps in it. Results older than a week are pruned. Results are never reused when unset.")
+	flag.StringVar(&opt.featureGateConfigPath, "feature-gate-config", "", "A path of a file, usually mounted from a ConfigMap, rolling feature gates out to a percentage of the jobs of each organization. Gates keep their defaults when unset or when the file does not exist.")
+	flag.Var(&opt.featureGateOverrides, "feature-gates", fmt.Sprintf("Comma-separated Gate=true|false pairs setting feature gates regardless of their rollout. Known gates: %s.", featuregate.Known()))
+	flag.StringVar(&opt.resultReuseTokenPath, "result-reuse-token-path", "", "A path of a GitHub token used to list the files of the repository and the labels of the pull request when determining whether a result can be reused. Required with --result-reuse-namespace.")
+	flag.StringVar(&opt.artMetadataEndpoint, "art-image-metadata-endpoint", "", "The ART image metadata endpoint queried before promotion when promotion.art_consistency_check is set. Promotions requesting the check fail without it.")
+	flag.StringVar(&opt.cveScannerImage, "cve-scanner-image", "", "The trivy image scanning the images to promote and the ones they replace when promotion.cve_gate is set.")
+	flag.StringVar(&opt.architectures, "architectures", "", "Comma-separated list of the architectures images may be built for, replacing the default ones. Images are only built for the architectures of the nodes of the cluster among them.")
+	flag.BoolVar(&opt.skipPreflight, "skip-preflight", false, "Do not probe the health of the build farm before executing the graph.")
+	flag.DurationVar(&opt.preflightAPILatency, "preflight-api-latency", 5*time.Second, "Maximum latency of the API server tolerated by the pre-flight checks.")
+	flag.DurationVar(&opt.preflightTimeout, "preflight-timeout", 30*time.Second, "Maximum amount of time each pre-flight check may take.")
 
 	opt.resultsOptions.Bind(flag)
 	return opt
@@ -536,19 +664,48 @@ func (o *options) Complete() error {
 		jobSpec.Refs = spec.Refs
 	}
 	jobSpec.BaseNamespace = o.baseNamespace
+	if o.promoteDryRun {
+		o.promote = true
+	}
+	if err := o.stepLogLimit.Validate(); err != nil {
+		return fmt.Errorf("invalid --step-log-limit-%w", err)
+	}
+	o.supportedArchitectures = api.DefaultArchitectures()
+	if o.architectures != "" {
+		if o.supportedArchitectures, err = api.ParseArchitectures(strings.Split(o.architectures, ",")); err != nil {
+			return fmt.Errorf("invalid --architectures: %w", err)
+		}
+	}
+	if o.leaseReservationsFile != "" {
+		if o.leaseReservations, err = lease.LoadReservations(o.leaseReservationsFile); err != nil {
+			return err
+		}
+	}
 	target := "all"
 	if len(o.targets.values) > 0 {
 		target = o.targets.values[0]
 	}
 	o.jobSpec = jobSpec
 	o.jobSpec.Target = target
+	if err := o.resolveFeatureGates(); err != nil {
+		return err
+	}
 
 	info := o.getResolverInfo(jobSpec)
 	o.resolverClient = server.NewResolverClient(o.resolverAddress)
+	if o.resolvedConfigCacheDir != "" {
+		o.resolverClient = server.NewCachingResolverClient(o.resolverAddress, o.resolvedConfigCacheDir)
+	}
 
 	if o.unresolvedConfigPath != "" && o.configSpecPath != "" {
 		return errors.New("cannot set --config and --unresolved-config at the same time")
 	}
+	if o.claimPooledNamespace && o.namespace != "" {
+		return errors.New("cannot set --namespace and --claim-pooled-namespace at the same time")
+	}
+	if o.resultReuseNamespace != "" && o.resultReuseTokenPath == "" {
+		return errors.New("--result-reuse-token-path is required with --result-reuse-namespace, the GitHub API is rate-limited for anonymous access")
+	}
 	if o.unresolvedConfigPath != "" && o.resolverAddress == "" {
 		return errors.New("cannot request resolved config with --unresolved-config unless providing --resolver-address")
 	}
@@ -581,16 +738,34 @@ func (o *options) Complete() error {
 	if err != nil {
 		return results.ForReason("loading_config").WithError(err).Errorf("failed to load configuration: %v", err)
 	}
-
 	if len(o.gitRef) != 0 && config.CanonicalGoRepository != nil {
 		o.jobSpec.Refs.PathAlias = *config.CanonicalGoRepository
 	}
 	o.configSpec = config
 	o.jobSpec.Metadata = config.Metadata
 	mergedConfig := o.injectTest != ""
-	if err := validation.IsValidResolvedConfiguration(o.configSpec, mergedConfig); err != nil {
+	warnings, err := validation.IsValidResolvedConfiguration(o.configSpec, mergedConfig, o.supportedArchitectures)
+	// timeouts cut short by the ones they run within are only reported at
+	// runtime, as the timeout of the job is the one Prow enforces
+	var jobTimeout time.Duration
+	if decoration := o.jobSpec.DecorationConfig; decoration != nil && decoration.Timeout != nil {
+		jobTimeout = decoration.Timeout.Duration
+	}
+	warnings = append(warnings, validation.TimeoutChainWarnings(o.configSpec, sets.New[string](o.targets.values...), jobTimeout)...)
+	for _, warning := range warnings {
+		logrus.Warnf("Configuration warning: %s", warning)
+	}
+	if err != nil {
+		return results.ForReason("validating_config").ForError(err)
+	}
+	clusterGroups, err := api.ClusterGroupTests(o.configSpec.Tests)
+	if err != nil {
+		return results.ForReason("validating_config").ForError(err)
+	}
+	if err := validation.IsValidClusterGroupTests(o.configSpec, clusterGroups, o.supportedArchitectures); err != nil {
 		return results.ForReason("validating_config").ForError(err)
 	}
+	o.configSpec.Tests = append(o.configSpec.Tests, clusterGroups...)
 	o.graphConfig = defaults.FromConfigStatic(o.configSpec)
 	if err := validation.IsValidGraphConfiguration(o.graphConfig.Steps); err != nil {
 		return results.ForReason("validating_config").ForError(err)
@@ -627,6 +802,23 @@ func (o *options) Complete() error {
 	if len(o.sshKeyPath) > 0 && len(o.oauthTokenPath) > 0 {
 		return errors.New("both --ssh-key-path and --oauth-token-path are specified")
 	}
+	if len(o.githubAppID) > 0 {
+		if len(o.sshKeyPath) > 0 || len(o.oauthTokenPath) > 0 {
+			return errors.New("--github-app-id cannot be specified with --ssh-key-path or --oauth-token-path")
+		}
+		if len(o.githubAppPrivateKeyPath) == 0 {
+			return errors.New("--github-app-private-key-path is required with --github-app-id")
+		}
+		minter, err := apptoken.NewMinter(o.githubAppID, o.githubAppPrivateKeyPath)
+		if err != nil {
+			return fmt.Errorf("could not load the GitHub App: %w", err)
+		}
+		if o.githubAppTokens, err = newGitHubAppTokens(context.TODO(), minter, refs); err != nil {
+			return fmt.Errorf("could not mint a GitHub App token: %w", err)
+		}
+		o.censor.AddSecrets(o.githubAppTokens.token.Value)
+		o.cloneAuthConfig = &steps.CloneAuthConfig{Type: steps.CloneAuthTypeOAuth, Secret: o.githubAppTokens.secret()}
+	}
 
 	var cloneAuthSecretPath string
 	if len(o.oauthTokenPath) > 0 {
@@ -718,6 +910,61 @@ func (o *options) Complete() error {
 		}
 	}
 
+	if o.streamArtifacts || o.streamStepLogs || o.uploadSecretPath != "" {
+		opener, err := prowio.NewOpener(context.Background(), o.uploadSecretPath, o.s3UploadCredentialsPath)
+		if err != nil {
+			return fmt.Errorf("could not create an opener for the bucket of the job: %w", err)
+		}
+		if o.streamArtifacts || o.streamStepLogs {
+			uploader, err := steps.NewBucketArtifactUploader(opener, o.jobSpec)
+			if err != nil {
+				return fmt.Errorf("could not stream artifacts to the bucket of the job: %w", err)
+			}
+			if o.streamArtifacts {
+				o.artifactOptions.Uploader = uploader
+			}
+			if o.streamStepLogs {
+				o.artifactOptions.LiveLogs = uploader
+			}
+		}
+		if o.uploadSecretPath != "" {
+			// the sidecars of multi-stage steps upload their artifacts with
+			// the same credentials
+			if o.artifactOptions.StepArtifacts, err = steps.NewBucketArtifactReader(opener, o.jobSpec); err != nil {
+				logrus.WithError(err).Warn("Could not read the artifacts of multi-stage steps, their junit files will be missing from the summaries of the tests.")
+			}
+		}
+	}
+
+	if o.artifactSizeLimit != "" {
+		limit, err := resource.ParseQuantity(o.artifactSizeLimit)
+		if err != nil {
+			return fmt.Errorf("could not parse --artifact-size-limit: %w", err)
+		}
+		if limit.Sign() <= 0 {
+			return fmt.Errorf("--artifact-size-limit must be positive, got %s", o.artifactSizeLimit)
+		}
+		o.artifactOptions.SizeLimit = limit.Value()
+	}
+
+	if o.junitFailureLogLimit != "" {
+		limit, err := resource.ParseQuantity(o.junitFailureLogLimit)
+		if err != nil {
+			return fmt.Errorf("could not parse --junit-failure-log-limit: %w", err)
+		}
+		if limit.Sign() <= 0 {
+			return fmt.Errorf("--junit-failure-log-limit must be positive, got %s", o.junitFailureLogLimit)
+		}
+		o.artifactOptions.FailureLogLimit = limit.Value()
+	}
+
+	if o.artifactOptions.DownloadConcurrency < 0 {
+		return fmt.Errorf("--artifact-download-concurrency must not be negative, got %d", o.artifactOptions.DownloadConcurrency)
+	}
+	if o.artifactOptions.DownloadAttempts < 0 {
+		return fmt.Errorf("--artifact-download-attempts must not be negative, got %d", o.artifactOptions.DownloadAttempts)
+	}
+
 	if o.hiveKubeconfigPath != "" {
 		kubeConfig, err := util.LoadKubeConfig(o.hiveKubeconfigPath)
 		if err != nil {
@@ -842,6 +1089,7 @@ func overrideTestStepDependencyParams(o *options) error {
 			for dependencyName, pullspec := range test.MultiStageTestConfigurationLiteral.DependencyOverrides {
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Pre)
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Test)
+				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Gather)
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Post)
 			}
 		}
@@ -853,6 +1101,7 @@ func overrideTestStepDependencyParams(o *options) error {
 			if test.MultiStageTestConfigurationLiteral != nil {
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Pre)
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Test)
+				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Gather)
 				overrideTestStepDependency(dependencyName, pullspec, &test.MultiStageTestConfigurationLiteral.Post)
 			}
 		}
@@ -901,6 +1150,34 @@ func (o *options) Report(errs ...error) {
 	if len(errorToReport) == 0 {
 		reporter.Report(nil)
 	}
+
+	if podAPIErrors := podAPIErrorCounts(); len(podAPIErrors) != 0 {
+		reporter.ReportPodAPIErrors(podAPIErrors)
+	}
+}
+
+// podAPIErrorCounts returns the errors the pod exec and log APIs returned
+// during the run, sorted for the report to be stable.
+func podAPIErrorCounts() []results.PodAPIErrorCount {
+	var ret []results.PodAPIErrorCount
+	for podAPIError, count := range kubernetes.PodAPIErrors() {
+		ret = append(ret, results.PodAPIErrorCount{
+			Operation: podAPIError.Operation,
+			Category:  podAPIError.Category,
+			Retried:   podAPIError.Retried,
+			Count:     count,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Operation != ret[j].Operation {
+			return ret[i].Operation < ret[j].Operation
+		}
+		if ret[i].Category != ret[j].Category {
+			return ret[i].Category < ret[j].Category
+		}
+		return !ret[i].Retried && ret[j].Retried
+	})
+	return ret
 }
 
 func (o *options) Run() []error {
@@ -909,6 +1186,8 @@ func (o *options) Run() []error {
 		logrus.Infof("Ran for %s", time.Since(start).Truncate(time.Second))
 	}()
 	ctx, cancel := context.WithCancel(context.Background())
+	// stops the background work of the run, like refreshing tokens
+	defer cancel()
 	handler := func(s os.Signal) {
 		logrus.Infof("error: Process interrupted with signal %s, cancelling execution...", s)
 		cancel()
@@ -919,6 +1198,9 @@ func (o *options) Run() []error {
 	}
 
 	o.resolveConsoleHost()
+	o.checkVersionSkew()
+	o.reportFeatureGates()
+	quotaAdmission := o.quotaAdmission()
 
 	streams, err := integratedStreams(o.configSpec, o.resolverClient, o.clusterConfig)
 	if err != nil {
@@ -934,12 +1216,13 @@ func (o *options) Run() []error {
 	if err != nil {
 		return []error{fmt.Errorf("could not resolve the node architectures: %w", err)}
 	}
+	nodeArchitectures = api.FilterArchitectures(o.supportedArchitectures, nodeArchitectures)
 
 	injectedTest := o.injectTest != ""
 	// load the graph from the configuration
-	buildSteps, promotionSteps, err := defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.clusterConfig,
-		o.podPendingTimeout, leaseClient, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.hiveKubeconfig,
-		o.nodeName, nodeArchitectures, o.targetAdditionalSuffix, o.manifestToolDockerCfg, o.localRegistryDNS, streams, injectedTest, o.enableSecretsStoreCSIDriver)
+	buildSteps, promotionSteps, err := defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.promoteDryRun, o.clusterConfig,
+		o.podPendingTimeout, leaseClient, quotaAdmission, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.hiveKubeconfig,
+		o.nodeName, nodeArchitectures, o.targetAdditionalSuffix, o.manifestToolDockerCfg, o.localRegistryDNS, streams, injectedTest, o.enableSecretsStoreCSIDriver, &o.stepLogLimit, o.artMetadataEndpoint, o.cveScannerImage, o.importCoordinationNamespace, o.buildCacheNamespace, o.artifactOptions)
 	if err != nil {
 		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
 	}
@@ -955,6 +1238,7 @@ func (o *options) Run() []error {
 	if err := o.writeMetadataJSON(); err != nil {
 		return []error{fmt.Errorf("unable to write metadata.json for build: %w", err)}
 	}
+	o.writeAttestation(ctx)
 	// convert the full graph into the subset we must run
 	nodes, err := api.BuildPartialGraph(buildSteps, o.targets.values)
 	if err != nil {
@@ -975,6 +1259,9 @@ func (o *options) Run() []error {
 		}
 		return nil
 	}
+	if o.reuseResult(ctx) {
+		return nil
+	}
 	graph, errs := calculateGraph(stepList)
 	if errs != nil {
 		return errs
@@ -987,12 +1274,18 @@ func (o *options) Run() []error {
 		}
 
 		_ = api.SaveArtifact(o.censor, api.CIOperatorStepGraphJSONFilename, serializedGraph)
+		o.reportTiming(*graph)
+		o.reportTargetSummary(*graph)
 	}()
 	// initialize the namespace if necessary and create any resources that must
 	// exist prior to execution
-	if err := o.initializeNamespace(); err != nil {
+	if err := o.initializeNamespace(ctx); err != nil {
 		return []error{results.ForReason("initializing_namespace").WithError(err).Errorf("could not initialize namespace: %v", err)}
 	}
+	// the quotas of the namespace are only known once it is set up
+	if err := o.runPreflight(ctx); err != nil {
+		return []error{err}
+	}
 
 	return interrupt.New(handler, o.saveNamespaceArtifacts).Run(func() []error {
 		if leaseClient != nil {
@@ -1013,10 +1306,15 @@ func (o *options) Run() []error {
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobStarted", eventJobDescription(o.jobSpec, o.namespace))
 		// execute the graph
 		suites, graphDetails, errs := steps.Run(ctx, nodes)
+		o.recordDurationSLOs(suites, graphDetails)
 		if err := o.writeJUnit(suites, "operator"); err != nil {
 			logrus.WithError(err).Warn("Unable to write JUnit result.")
 		}
+		if err := o.writeTestResults(suites); err != nil {
+			logrus.WithError(err).Warn("Unable to write test results.")
+		}
 		graph.MergeFrom(graphDetails...)
+		o.saveBuildRootDigests(ctx)
 		// Rewrite the Metadata JSON to catch custom metadata if it has been generated by the job
 		if err := o.writeMetadataJSON(); err != nil {
 			logrus.WithError(err).Warn("Unable to update metadata.json for build")
@@ -1031,35 +1329,72 @@ func (o *options) Run() []error {
 		}
 
 		// Run each of the promotion steps concurrently
-		lenOfPromotionSteps := len(promotionSteps)
-		detailsChan := make(chan api.CIOperatorStepDetails, lenOfPromotionSteps)
-		errChan := make(chan error, lenOfPromotionSteps)
-		for _, step := range promotionSteps {
-			go runPromotionStep(ctx, step, detailsChan, errChan)
+		promotionSuites, promotionDetails, err := runPromotionSteps(ctx, promotionSteps)
+		graph.MergeFrom(promotionDetails...)
+		if err := o.writeJUnit(promotionSuites, "promotion"); err != nil {
+			logrus.WithError(err).Warn("Unable to write promotion JUnit result.")
 		}
-		for i := 0; i < lenOfPromotionSteps; i++ {
-			select {
-			case details := <-detailsChan:
-				graph.MergeFrom(details)
-			case err := <-errChan:
-				eventRecorder.Event(runtimeObject, coreapi.EventTypeWarning, "PostStepFailed",
-					fmt.Sprintf("post step failed while %s. with error: %v", eventJobDescription(o.jobSpec, o.namespace), err))
-				return []error{results.ForReason("executing_post").WithError(err).Unwrap()} // If any of the promotion steps fail, it is considered a failure
-			}
+		if err != nil {
+			eventRecorder.Event(runtimeObject, coreapi.EventTypeWarning, "PostStepFailed",
+				fmt.Sprintf("post step failed while %s. with error: %v", eventJobDescription(o.jobSpec, o.namespace), err))
+			return []error{results.ForReason("executing_post").WithError(err).Unwrap()} // If any of the promotion steps fail, it is considered a failure
 		}
 
+		o.recordResult(ctx)
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobSucceeded", eventJobDescription(o.jobSpec, o.namespace))
 		return nil
 	})
 }
 
-func runPromotionStep(ctx context.Context, step api.Step, detailsChan chan<- api.CIOperatorStepDetails, errChan chan<- error) {
-	details, err := runStep(ctx, step)
-	if err != nil {
-		errChan <- fmt.Errorf("could not run promotion step %s: %w", step.Name(), err)
-	} else {
-		detailsChan <- details
+// promotionResult is the outcome of a promotion step.
+type promotionResult struct {
+	details api.CIOperatorStepDetails
+	tests   []*junit.TestCase
+	err     error
+}
+
+// runPromotionSteps runs the promotion steps concurrently and returns the
+// tests they reported, their details and the first failure among them.
+func runPromotionSteps(ctx context.Context, promotionSteps []api.Step) (*junit.TestSuites, []api.CIOperatorStepDetails, error) {
+	resultChan := make(chan promotionResult, len(promotionSteps))
+	for _, step := range promotionSteps {
+		go func(step api.Step) {
+			details, tests, err := runStep(ctx, step)
+			if err != nil {
+				err = fmt.Errorf("could not run promotion step %s: %w", step.Name(), err)
+			}
+			resultChan <- promotionResult{details: details, tests: tests, err: err}
+		}(step)
+	}
+	var details []api.CIOperatorStepDetails
+	var tests []*junit.TestCase
+	var firstErr error
+	for range promotionSteps {
+		result := <-resultChan
+		tests = append(tests, result.tests...)
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			continue
+		}
+		details = append(details, result.details)
 	}
+	if len(tests) == 0 {
+		return nil, details, firstErr
+	}
+	suite := &junit.TestSuite{Name: "promotion"}
+	for _, test := range tests {
+		switch {
+		case test.FailureOutput != nil:
+			suite.NumFailed++
+		case test.SkipMessage != nil:
+			suite.NumSkipped++
+		}
+		suite.NumTests++
+		suite.TestCases = append(suite.TestCases, test)
+	}
+	return &junit.TestSuites{Suites: []*junit.TestSuite{suite}}, details, firstErr
 }
 
 func integratedStreams(config *api.ReleaseBuildConfiguration, client server.ResolverClient, clusterConfig *rest.Config) (map[string]*configresolver.IntegratedStream, error) {
@@ -1106,9 +1441,13 @@ func integratedStreams(config *api.ReleaseBuildConfiguration, client server.Reso
 
 // runStep mostly duplicates steps.runStep. The latter uses an *api.StepNode though and we only have an api.Step for the PostSteps
 // so we can not re-use it.
-func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, error) {
+func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, []*junit.TestCase, error) {
 	start := time.Now()
 	err := step.Run(ctx)
+	var tests []*junit.TestCase
+	if reporter, ok := step.(steps.SubtestReporter); ok {
+		tests = reporter.SubTests()
+	}
 	duration := time.Since(start)
 	failed := err != nil
 
@@ -1130,7 +1469,7 @@ func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, err
 			Failed:   &failed,
 		},
 		Substeps: subSteps,
-	}, err
+	}, tests, err
 }
 
 func (o *options) resolveConsoleHost() {
@@ -1155,6 +1494,7 @@ func (o *options) resolveInputs(steps []api.Step) error {
 		}
 		inputs = append(inputs, definition...)
 	}
+	o.stepInputs = inputs
 
 	// a change in the config for the build changes the output
 	cs := o.configSpec
@@ -1202,6 +1542,12 @@ func (o *options) resolveInputs(steps []api.Step) error {
 		o.namespace = "ci-op-{id}"
 	}
 	o.namespace = strings.Replace(o.namespace, "{id}", o.inputHash, -1)
+	// the graph is only printed, nothing runs in the namespace
+	if o.claimPooledNamespace && !o.printGraph {
+		if err := o.claimNamespace(); err != nil {
+			return err
+		}
+	}
 	// TODO: instead of mutating this here, we should pass the parts of graph execution that are resolved
 	// after the graph is created but before it is run down into the run step.
 	o.jobSpec.SetNamespace(o.namespace)
@@ -1216,7 +1562,54 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	return nil
 }
 
-func (o *options) initializeNamespace() error {
+// claimNamespace takes a pre-warmed namespace out of the pool, if one is
+// available, to skip the set up of a new namespace. The namespace is claimed
+// with its TTL annotations, so that it is cleaned up even when the job fails
+// before initializing it.
+func (o *options) claimNamespace() error {
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to construct client: %w", err)
+	}
+	claimed, err := namespacepool.Claim(context.TODO(), client, o.namespaceTTLAnnotations())
+	if err != nil {
+		return fmt.Errorf("could not claim a pooled namespace: %w", err)
+	}
+	if claimed == "" {
+		logrus.Info("No pooled namespace is available, creating one")
+		return nil
+	}
+	logrus.Debugf("Claimed pooled namespace %s", claimed)
+	o.namespace = claimed
+	o.namespaceClaimed = true
+	return nil
+}
+
+// namespaceTTLAnnotations are the annotations of the namespace for its cleanup
+// by the ci-ns-ttl-controller.
+func (o *options) namespaceTTLAnnotations() map[string]string {
+	annotations := map[string]string{}
+	if o.idleCleanupDuration > 0 {
+		if o.idleCleanupDurationSet {
+			logrus.Debugf("Setting a soft TTL of %s for the namespace", o.idleCleanupDuration.String())
+		}
+		annotations[nsttl.AnnotationIdleCleanupDurationTTL] = o.idleCleanupDuration.String()
+	}
+
+	if o.cleanupDuration > 0 {
+		if o.cleanupDurationSet {
+			logrus.Debugf("Setting a hard TTL of %s for the namespace", o.cleanupDuration.String())
+		}
+		annotations[nsttl.AnnotationCleanupDurationTTL] = o.cleanupDuration.String()
+	}
+
+	// This label makes sure that the namespace is active, and the value will be updated
+	// if the namespace will be reused.
+	annotations[nsttl.AnnotationNamespaceLastActive] = time.Now().Format(time.RFC3339)
+	return annotations
+}
+
+func (o *options) initializeNamespace(ctx context.Context) error {
 	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
 	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
 	if err != nil {
@@ -1228,12 +1621,12 @@ func (o *options) initializeNamespace() error {
 	}
 	client := ctrlruntimeclient.NewNamespacedClient(ctrlClient, o.namespace)
 	client = labeledclient.Wrap(client, o.jobSpec)
-	ctx := context.Background()
 
 	logrus.Debugf("Creating namespace %s", o.namespace)
 	authTimeout := 15 * time.Second
 	initBeginning := time.Now()
-	for {
+	// a claimed namespace already exists
+	for !o.namespaceClaimed {
 		project, err := projectGetter.ProjectV1().ProjectRequests().Create(context.TODO(), &projectapi.ProjectRequest{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   o.namespace,
@@ -1298,25 +1691,15 @@ func (o *options) initializeNamespace() error {
 	//
 	// We can also only annotate the project *after* the SSAR check above, which
 	// means that if SSAR fails, the project will *not* be annotated for cleanup.
-	annotationUpdates := map[string]string{}
-	if o.idleCleanupDuration > 0 {
-		if o.idleCleanupDurationSet {
-			logrus.Debugf("Setting a soft TTL of %s for the namespace", o.idleCleanupDuration.String())
-		}
-		annotationUpdates[nsttl.AnnotationIdleCleanupDurationTTL] = o.idleCleanupDuration.String()
-	}
+	annotationUpdates := o.namespaceTTLAnnotations()
 
-	if o.cleanupDuration > 0 {
-		if o.cleanupDurationSet {
-			logrus.Debugf("Setting a hard TTL of %s for the namespace", o.cleanupDuration.String())
-		}
-		annotationUpdates[nsttl.AnnotationCleanupDurationTTL] = o.cleanupDuration.String()
+	if o.namespaceClaimed {
+		// a pooled namespace was requested by the pool, so it has to be
+		// linked to the job the way the project request would have
+		annotationUpdates["openshift.io/display-name"] = fmt.Sprintf("%s - %s", o.namespace, o.jobSpec.Job)
+		annotationUpdates["openshift.io/description"] = jobDescription(o.jobSpec)
 	}
 
-	// This label makes sure that the namespace is active, and the value will be updated
-	// if the namespace will be reused.
-	annotationUpdates[nsttl.AnnotationNamespaceLastActive] = time.Now().Format(time.RFC3339)
-
 	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		ns := &coreapi.Namespace{}
 		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: o.namespace}, ns); err != nil {
@@ -1482,10 +1865,16 @@ func (o *options) initializeNamespace() error {
 	})
 
 	if o.cloneAuthConfig != nil && o.cloneAuthConfig.Secret != nil {
-		o.cloneAuthConfig.Secret.Immutable = utilpointer.Bool(true)
+		// tokens of the GitHub App are replaced before they expire
+		if o.githubAppTokens == nil {
+			o.cloneAuthConfig.Secret.Immutable = utilpointer.Bool(true)
+		}
 		if err := client.Create(ctx, o.cloneAuthConfig.Secret); err != nil && !kerrors.IsAlreadyExists(err) {
 			return fmt.Errorf("couldn't create secret %s for %s authentication: %w", o.cloneAuthConfig.Secret.Name, o.cloneAuthConfig.Type, err)
 		}
+		if o.githubAppTokens != nil {
+			go o.githubAppTokens.refresh(ctx, client, o.censor)
+		}
 	}
 
 	// adds the appropriate cluster profile secrets to o.secrets,
@@ -1499,17 +1888,15 @@ func (o *options) initializeNamespace() error {
 		o.secrets = append(o.secrets, cpSecret)
 	}
 
-	if o.configSpec.ExternalImages != nil {
-		for _, image := range o.configSpec.ExternalImages {
-			if image.PullSecret != "" {
-				secret, err := getExternalImagePullSecret(ctx, labeledclient.Wrap(ctrlClient, o.jobSpec), image)
-				if err != nil {
-					return fmt.Errorf("failed to get external image pull secret: %w", err)
-				}
-				secret.Namespace = o.namespace
-				o.secrets = append(o.secrets, secret)
-			}
+	// the credentials of promotion mirrors allow pushing, only jobs actually
+	// promoting get them as code from pull requests runs in the namespace
+	for _, name := range sets.List(pullSecretsFor(o.configSpec, o.promote && !o.promoteDryRun)) {
+		secret, err := getExternalImagePullSecret(ctx, labeledclient.Wrap(ctrlClient, o.jobSpec), name)
+		if err != nil {
+			return fmt.Errorf("failed to get external image pull secret: %w", err)
 		}
+		secret.Namespace = o.namespace
+		o.secrets = append(o.secrets, secret)
 	}
 
 	for _, secret := range o.secrets {
@@ -1531,18 +1918,50 @@ func (o *options) initializeNamespace() error {
 	return nil
 }
 
-func getExternalImagePullSecret(ctx context.Context, client ctrlruntimeclient.Client, image api.ExternalImage) (*coreapi.Secret, error) {
+// pullSecretsFor collects the names of the pull secrets requested by external
+// images, by individual multi-stage test steps and, when promoting, by the
+// promotion targets pushing to external registries.
+func pullSecretsFor(config *api.ReleaseBuildConfiguration, promote bool) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, image := range config.ExternalImages {
+		if image.PullSecret != "" {
+			ret.Insert(image.PullSecret)
+		}
+	}
+	for _, test := range config.Tests {
+		literal := test.MultiStageTestConfigurationLiteral
+		if literal == nil {
+			continue
+		}
+		for _, step := range literal.Steps() {
+			if step.PullSecret != "" {
+				ret.Insert(step.PullSecret)
+			}
+		}
+	}
+	if !promote {
+		return ret
+	}
+	for _, target := range api.PromotionTargets(config.PromotionConfiguration) {
+		if target.Mirror != nil && !target.Disabled {
+			ret.Insert(target.Mirror.Credentials)
+		}
+	}
+	return ret
+}
+
+func getExternalImagePullSecret(ctx context.Context, client ctrlruntimeclient.Client, name string) (*coreapi.Secret, error) {
 	ciSecret := &coreapi.Secret{}
-	err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "test-credentials", Name: image.PullSecret}, ciSecret)
+	err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "test-credentials", Name: name}, ciSecret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret '%s' from test-credentials namespace: %w", image.PullSecret, err)
+		return nil, fmt.Errorf("failed to get secret '%s' from test-credentials namespace: %w", name, err)
 	}
 
 	newSecret := &coreapi.Secret{
 		Data: ciSecret.Data,
 		Type: coreapi.SecretTypeDockerConfigJson,
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "external-pull-secret-" + image.PullSecret,
+			Name: api.ExternalPullSecretName(name),
 		},
 	}
 	return newSecret, nil
@@ -1811,6 +2230,108 @@ func (o *options) writeFailingJUnit(errs []error) {
 	}
 }
 
+// writeAttestation saves the environment of the run as an artifact, so that
+// it can be audited or reproduced later.
+func (o *options) writeAttestation(ctx context.Context) {
+	attested, err := attestation.New(version.Version, o.jobSpec, o.configSpec)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate the attestation of the run")
+		return
+	}
+	resolveCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := attested.ResolveDigests(resolveCtx, docker.NewResolver(docker.ResolverOptions{})); err != nil {
+		logrus.WithError(err).Warn("Failed to resolve the digests of the utility images of the run")
+	}
+	attested.InputHash = o.inputHash
+	attested.Inputs = o.stepInputs
+	attested.Registry = o.registryPath
+	if attested.Registry == "" {
+		attested.Registry = o.resolverAddress
+	}
+	if o.clusterConfig != nil {
+		attested.BuildCluster = o.clusterConfig.Host
+	}
+	for _, profile := range o.clusterProfiles {
+		attested.ClusterProfiles = append(attested.ClusterProfiles, profile.profileName)
+	}
+	serialized, err := json.MarshalIndent(attested, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal the attestation of the run")
+		return
+	}
+	_ = api.SaveArtifact(o.censor, attestation.ArtifactFilename, serialized)
+}
+
+// reportTiming prints the time spent per phase of each step that ran and
+// saves it as an artifact.
+func (o *options) reportTiming(graph api.CIOperatorStepGraph) {
+	breakdown := timing.Breakdown(graph)
+	if len(breakdown) == 0 {
+		return
+	}
+	logrus.Infof("Time spent per phase of each step:\n%s", timing.Table(breakdown))
+	serialized, err := json.Marshal(breakdown)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal timing breakdown")
+		return
+	}
+	_ = api.SaveArtifact(o.censor, timing.ArtifactFilename, serialized)
+}
+
+// reportTargetSummary prints the outcome of each target of a run with more
+// than one target and saves it as an artifact. The summary is commented on
+// the pull request when a token is provided, replacing the summary of an
+// earlier run of the job.
+func (o *options) reportTargetSummary(graph api.CIOperatorStepGraph) {
+	if len(o.targets.values) < 2 {
+		return
+	}
+	targets := targetsummary.Summarize(o.targets.values, graph, targetsummary.ArtifactsURL(&o.jobSpec.JobSpec))
+	if artifactDir, set := api.Artifacts(); set {
+		targetsummary.MarkTruncatedArtifacts(targets, artifactDir)
+	}
+	summary := targetsummary.Markdown(targets)
+	logrus.Infof("Summary of the targets:\n%s", summary)
+	_ = api.SaveArtifact(o.censor, targetsummary.ArtifactFilename, []byte(summary))
+	if o.summaryCommentTokenPath == "" {
+		return
+	}
+	refs := o.jobSpec.Refs
+	if o.jobSpec.Type != prowapi.PresubmitJob || refs == nil || len(refs.Pulls) == 0 {
+		logrus.Debug("Not commenting the summary of the targets outside of a pull request.")
+		return
+	}
+	token, err := secrets.ReadFromFile(o.summaryCommentTokenPath, o.censor)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read the token to comment the summary of the targets.")
+		return
+	}
+	client, err := github.NewClient(func() []byte { return []byte(token) }, secretutil.AdaptCensorer(o.censor), github.DefaultGraphQLEndpoint, github.DefaultAPIEndpoint)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to create the client to comment the summary of the targets.")
+		return
+	}
+	if err := targetsummary.Comment(client, refs.Org, refs.Repo, refs.Pulls[0].Number, o.jobSpec.Job, summary); err != nil {
+		logrus.WithError(err).Warn("Failed to comment the summary of the targets on the pull request.")
+	}
+}
+
+// recordDurationSLOs records the actual durations of tests that declare an
+// expected duration in the properties of the step graph suite.
+func (o *options) recordDurationSLOs(suites *junit.TestSuites, details []api.CIOperatorStepDetails) {
+	if suites == nil || len(suites.Suites) == 0 {
+		return
+	}
+	measurements := durationslo.Measure(o.configSpec.Tests, details)
+	for _, m := range measurements {
+		if m.Violated() {
+			logrus.Warnf("Test %s took %s, longer than its expected duration of %s.", m.Test, m.Actual.Truncate(time.Second), m.Expected)
+		}
+	}
+	suites.Suites[0].Properties = append(suites.Suites[0].Properties, durationslo.Properties(measurements)...)
+}
+
 func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	if suites == nil {
 		return nil
@@ -1829,6 +2350,21 @@ func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	return api.SaveArtifact(o.censor, fmt.Sprintf("junit_%s.xml", name), out)
 }
 
+const testResultsFilename = "test-results.json"
+
+// writeTestResults writes the JSON form of the results next to their jUnit,
+// which must be written first to censor and sort them.
+func (o *options) writeTestResults(suites *junit.TestSuites) error {
+	if suites == nil {
+		return nil
+	}
+	var out bytes.Buffer
+	if err := junit.WriteJSON(&out, suites); err != nil {
+		return err
+	}
+	return api.SaveArtifact(o.censor, testResultsFilename, out.Bytes())
+}
+
 // oneWayEncoding can be used to encode hex to a 62-character set (0 and 1 are duplicates) for use in
 // short display names that are safe for use in kubernetes as resource names.
 var oneWayNameEncoding = base32.NewEncoding("bcdfghijklmnpqrstvwxyz0123456789").WithPadding(base32.NoPadding)
@@ -1889,6 +2425,75 @@ func (o *options) saveNamespaceArtifacts() {
 	}
 }
 
+// saveBuildRootDigests is a best effort attempt to record the images that build roots
+// read from the repository resolved to, so that tag drift can be detected across runs.
+func (o *options) saveBuildRootDigests(ctx context.Context) {
+	buildRoots := map[string]api.BuildRootImageConfiguration{}
+	if o.configSpec.BuildRootImage != nil {
+		buildRoots[""] = *o.configSpec.BuildRootImage
+	}
+	for ref, root := range o.configSpec.BuildRootImages {
+		buildRoots[ref] = root
+	}
+	fromRepository := map[api.PipelineImageStreamTagReference]string{}
+	for ref, root := range buildRoots {
+		if !root.FromRepository {
+			continue
+		}
+		rootTag := string(api.PipelineImageStreamTagReferenceRoot)
+		if ref != "" {
+			rootTag = fmt.Sprintf("%s-%s", rootTag, ref)
+		}
+		fromRepository[api.PipelineImageStreamTagReference(rootTag)] = ref
+	}
+	if len(fromRepository) == 0 {
+		return
+	}
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to create client to record build root digests.")
+		return
+	}
+	var records []buildroot.DigestRecord
+	for _, step := range o.graphConfig.InputImages() {
+		ref, ok := fromRepository[step.InputImage.To]
+		if !ok {
+			continue
+		}
+		record := buildroot.DigestRecord{Ref: ref, ImageStreamTag: step.InputImage.BaseImage.ISTagName(), Timestamp: time.Now().UTC()}
+		ist := &imageapi.ImageStreamTag{}
+		name := fmt.Sprintf("%s:%s", api.PipelineImageStream, step.InputImage.To)
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: name}, ist); err != nil {
+			logrus.WithError(err).Warnf("Unable to resolve the digest of build root %s.", record.ImageStreamTag)
+		} else {
+			record.Digest = ist.Image.Name
+		}
+		records = append(records, record)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to marshal build root digests.")
+		return
+	}
+	_ = api.SaveArtifact(o.censor, buildroot.DigestRecordsFile, data)
+}
+
+// quotaAdmission enforces the lease reservations, if any, and reports the time
+// spent waiting for them.
+func (o *options) quotaAdmission() *steps.QuotaAdmission {
+	if o.leaseReservations == nil {
+		return nil
+	}
+	admission := &steps.QuotaAdmission{Reservations: o.leaseReservations}
+	reporter, err := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not load result reporting options, time spent waiting for quota will not be reported.")
+		return admission
+	}
+	admission.Observe = reporter.ReportQuotaWait
+	return admission
+}
+
 func loadLeaseCredentials(leaseServerCredentialsFile string) (string, func() []byte, error) {
 	if err := secret.Add(leaseServerCredentialsFile); err != nil {
 		return "", nil, fmt.Errorf("failed to start secret agent on file %s: %s", leaseServerCredentialsFile, string(secret.Censor([]byte(err.Error()))))
@@ -2149,6 +2754,69 @@ func getCloneSecretFromPath(cloneAuthType steps.CloneAuthType, secretPath string
 	return secret, nil
 }
 
+// githubAppTokenSecret holds the installation token of the GitHub App used
+// by the run, kept valid while the run lasts.
+const githubAppTokenSecret = "github-app-token"
+
+// githubAppTokens mints the installation tokens of the GitHub App, scoped to
+// the repositories of the job.
+type githubAppTokens struct {
+	minter *apptoken.Minter
+	org    string
+	repos  []string
+	token  *apptoken.Token
+}
+
+func newGitHubAppTokens(ctx context.Context, minter *apptoken.Minter, refs []prowapi.Refs) (*githubAppTokens, error) {
+	if len(refs) == 0 {
+		return nil, errors.New("the job has no repositories to scope the token to")
+	}
+	repos := sets.New[string]()
+	for _, ref := range refs {
+		if ref.Org != refs[0].Org {
+			return nil, fmt.Errorf("tokens can only be scoped to the repositories of one organization, got %s and %s", refs[0].Org, ref.Org)
+		}
+		repos.Insert(ref.Repo)
+	}
+	t := &githubAppTokens{minter: minter, org: refs[0].Org, repos: sets.List(repos)}
+	var err error
+	t.token, err = t.mint(ctx)
+	return t, err
+}
+
+func (t *githubAppTokens) mint(ctx context.Context) (*apptoken.Token, error) {
+	return t.minter.Mint(ctx, t.org, t.repos)
+}
+
+// secret holds the token in the keys used by source builds and clonerefs.
+func (t *githubAppTokens) secret() *coreapi.Secret {
+	data := []byte(t.token.Value)
+	return &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: githubAppTokenSecret},
+		Type:       coreapi.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			steps.OauthSecretKey: data,
+			"username":           data,
+			"password":           data,
+		},
+	}
+}
+
+// refresh replaces the token in the secret before it expires, for steps
+// starting late in long jobs.
+func (t *githubAppTokens) refresh(ctx context.Context, client ctrlruntimeclient.Client, censor *secrets.DynamicCensor) {
+	apptoken.Refresh(ctx, t.token, t.mint, func(token *apptoken.Token) error {
+		censor.AddSecrets(token.Value)
+		t.token = token
+		secret := &coreapi.Secret{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: githubAppTokenSecret}, secret); err != nil {
+			return fmt.Errorf("failed to get secret %s: %w", githubAppTokenSecret, err)
+		}
+		secret.Data = t.secret().Data
+		return client.Update(ctx, secret)
+	})
+}
+
 func getHashFromBytes(b []byte) string {
 	hash := sha256.New()
 	if _, err := hash.Write(b); err != nil {
@@ -2327,6 +2995,12 @@ func (o *options) loadConfig(info *api.Metadata, gcsReader gcsFileReader) (*api.
 		err = results.ForReason("config_resolver").ForError(err)
 		return configSpec, err
 	}
+	if err := load.RejectYAMLAliases([]byte(raw)); err != nil {
+		if len(o.configSpecPath) > 0 {
+			return nil, fmt.Errorf("invalid configuration in file %s: %w", o.configSpecPath, err)
+		}
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 	configSpec := api.ReleaseBuildConfiguration{}
 	if err := yaml.UnmarshalStrict([]byte(raw), &configSpec); err != nil {
 		if len(o.configSpecPath) > 0 {
@@ -2335,11 +3009,11 @@ func (o *options) loadConfig(info *api.Metadata, gcsReader gcsFileReader) (*api.
 		return nil, fmt.Errorf("invalid configuration: %w\nvalue:\n%s", err, raw)
 	}
 	if o.registryPath != "" {
-		refs, chains, workflows, _, _, _, observers, err := load.Registry(o.registryPath, load.RegistryFlag(0))
+		resolver, err := load.RegistryResolver(o.registryPath, o.registrySnapshotsPath, o.registryOverlaysPath, load.RegistryFlag(0))
 		if err != nil {
 			return nil, fmt.Errorf("failed to load registry: %w", err)
 		}
-		configSpec, err = registry.ResolveConfig(registry.NewResolver(refs, chains, workflows, observers), configSpec)
+		configSpec, err = registry.ResolveConfig(resolver, configSpec)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
 		}
@@ -2450,10 +3124,51 @@ func getClusterProfileSecret(cp clusterProfileForTarget, client ctrlruntimeclien
 			Name: fmt.Sprintf("%s-cluster-profile", cp.target),
 		},
 	}
+	if wi := cpDetails.WorkloadIdentity; wi != nil {
+		if err := addWorkloadIdentity(newSecret, wi); err != nil {
+			return nil, fmt.Errorf("failed to configure workload identity for '%s' cluster profile: %w", cp.profileName, err)
+		}
+	}
+	if ld := cpDetails.LeakDetection; ld != nil {
+		raw, err := json.Marshal(ld)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal leak detection configuration for '%s' cluster profile: %w", cp.profileName, err)
+		}
+		newSecret.Data = maps.Clone(newSecret.Data)
+		if newSecret.Data == nil {
+			newSecret.Data = map[string][]byte{}
+		}
+		newSecret.Data[api.LeakDetectionConfigKey] = raw
+	}
 
 	return newSecret, nil
 }
 
+// addWorkloadIdentity adds the workload identity configuration of a cluster
+// profile to its secret, from where it is read by the multi-stage tests using
+// the profile.
+func addWorkloadIdentity(secret *coreapi.Secret, wi *api.WorkloadIdentity) error {
+	if err := wi.Validate(); err != nil {
+		return err
+	}
+	data := maps.Clone(secret.Data)
+	if data == nil {
+		data = map[string][]byte{}
+	}
+	raw, err := json.Marshal(wi)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workload identity configuration: %w", err)
+	}
+	data[api.WorkloadIdentityConfigKey] = raw
+	if wi.Provider == api.WorkloadIdentityProviderGCP {
+		if data[api.GCPWorkloadIdentityCredentialsKey], err = wi.GCPCredentialConfiguration(); err != nil {
+			return fmt.Errorf("failed to generate GCP credential configuration: %w", err)
+		}
+	}
+	secret.Data = data
+	return nil
+}
+
 type clusterProfileForTarget struct {
 	target      string
 	profileName string
@@ -16,6 +16,7 @@ import (
 	rbacapi "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/diff"
 	"k8s.io/utils/pointer"
@@ -27,10 +28,12 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/steps/release"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 	utilgzip "github.com/openshift/ci-tools/pkg/util/gzip"
 )
@@ -1032,6 +1035,7 @@ func TestBuildPartialGraph(t *testing.T) {
 					&api.InputImageTagStepConfiguration{InputImage: api.InputImage{To: api.PipelineImageStreamTagReferenceRoot}},
 					loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Name: ":"}}).Build()),
 					nil,
+					nil,
 				),
 				steps.SourceStep(api.SourceStepConfiguration{From: api.PipelineImageStreamTagReferenceRoot, To: api.PipelineImageStreamTagReferenceSource}, api.ResourceConfiguration{}, nil, nil, &api.JobSpec{}, nil, nil),
 				steps.ProjectDirectoryImageBuildStep(
@@ -1832,3 +1836,107 @@ func TestGetClusterProfileNamesFromTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestNewGitHubAppTokens(t *testing.T) {
+	testCases := []struct {
+		name          string
+		refs          []prowapi.Refs
+		expectedError error
+	}{
+		{
+			name:          "no repositories",
+			expectedError: errors.New("the job has no repositories to scope the token to"),
+		},
+		{
+			name:          "repositories of several organizations",
+			refs:          []prowapi.Refs{{Org: "org", Repo: "repo"}, {Org: "other", Repo: "repo"}},
+			expectedError: errors.New("tokens can only be scoped to the repositories of one organization, got org and other"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newGitHubAppTokens(context.Background(), nil, tc.refs)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+type failingPromotionStep struct {
+	api.Step
+}
+
+func (failingPromotionStep) Name() string                  { return "failing" }
+func (failingPromotionStep) Description() string           { return "Fail the promotion" }
+func (failingPromotionStep) Run(ctx context.Context) error { return errors.New("oops") }
+
+func TestRunPromotionSteps(t *testing.T) {
+	configuration := &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{
+		{Namespace: "ci", Name: "frozen", PausedUntil: "2999-01-01T00:00:00Z"},
+	}}}
+	targetName := func(_ string, target api.PromotionTarget) string {
+		return target.Namespace + "/" + target.Name
+	}
+	promotion := release.PromotionStep(api.PromotionStepName, configuration, nil, &api.JobSpec{}, nil, nil, "", nil, targetName, nil, nil, nil, false)
+
+	for _, tc := range []struct {
+		name          string
+		steps         []api.Step
+		expected      *junit.TestSuites
+		expectedError error
+	}{
+		{
+			name:  "paused targets are reported as skipped",
+			steps: []api.Step{promotion},
+			expected: &junit.TestSuites{Suites: []*junit.TestSuite{{
+				Name:       "promotion",
+				NumTests:   2,
+				NumSkipped: 1,
+				TestCases: []*junit.TestCase{
+					{Name: "Promote built images into the release image streams: ci/frozen"},
+					{Name: "Promote images to ci/frozen", SkipMessage: &junit.SkipMessage{Message: "promotion to ci/frozen is paused until 2999-01-01T00:00:00Z"}},
+				},
+			}}},
+		},
+		{
+			name:          "failing step without tests",
+			steps:         []api.Step{failingPromotionStep{}},
+			expectedError: errors.New("could not run promotion step failing: oops"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			suites, _, err := runPromotionSteps(context.Background(), tc.steps)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, suites); diff != "" {
+				t.Errorf("unexpected suites: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPullSecretsFor(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{ExternalImages: map[string]api.ExternalImage{"base": {PullSecret: "registry-pull"}}},
+		PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{{
+			Namespace: "ocp",
+			Mirror:    &api.PromotionMirror{Registry: "quay.io", Repository: "org", Credentials: "quay-push"},
+		}}},
+	}
+	for _, tc := range []struct {
+		name     string
+		promote  bool
+		expected []string
+	}{
+		{name: "mirror credentials are not given to jobs which do not promote", expected: []string{"registry-pull"}},
+		{name: "mirror credentials are given to jobs which promote", promote: true, expected: []string{"quay-push", "registry-pull"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, sets.List(pullSecretsFor(config, tc.promote))); diff != "" {
+				t.Errorf("unexpected pull secrets: %s", diff)
+			}
+		})
+	}
+}
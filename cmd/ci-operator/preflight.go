@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/client-go/discovery"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/preflight"
+)
+
+// runPreflight probes the health of the build farm once the namespace is set
+// up and before the graph is executed: a job started during an incident on the
+// farm would only fail after a long time, for reasons unrelated to what it
+// tests.
+func (o *options) runPreflight(ctx context.Context) error {
+	if o.skipPreflight {
+		return nil
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(o.clusterConfig)
+	if err != nil {
+		return fmt.Errorf("could not get discovery client for cluster config: %w", err)
+	}
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return fmt.Errorf("could not get client for cluster config: %w", err)
+	}
+	checks := []preflight.Check{
+		preflight.APILatency(discoveryClient, o.preflightAPILatency),
+		preflight.ImageStreamImports(client, preflight.InputImageStreamTags(o.configSpec)),
+		preflight.QuotaHeadroom(client, o.namespace),
+	}
+	logrus.Debug("Running the pre-flight checks of the build farm.")
+	report, err := preflight.Run(ctx, checks, o.preflightTimeout)
+	if data, marshalErr := report.Marshal(); marshalErr != nil {
+		logrus.WithError(marshalErr).Warn("Unable to marshal the pre-flight report.")
+	} else {
+		_ = api.SaveArtifact(o.censor, preflight.ReportFile, data)
+	}
+	// the error is not wrapped, so that the result is reported with the
+	// infrastructure_degraded reason alone and the runs aborted on a degraded
+	// build farm can be told apart from the ones which failed
+	return err
+}
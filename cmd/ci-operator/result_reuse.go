@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/secretutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/resultreuse"
+	"github.com/openshift/ci-tools/pkg/secrets"
+)
+
+// reusableTarget returns the test whose earlier result the run may report:
+// runs of presubmit jobs on a single pull request with a single target, which
+// is a test opting into reusing results.
+func (o *options) reusableTarget() *api.TestStepConfiguration {
+	refs := o.jobSpec.Refs
+	if o.jobSpec.Type != prowapi.PresubmitJob || refs == nil || len(refs.Pulls) != 1 || len(o.targets.values) != 1 {
+		return nil
+	}
+	for i, test := range o.configSpec.Tests {
+		if test.As == o.targets.values[0] && test.ReuseResults != nil {
+			return &o.configSpec.Tests[i]
+		}
+	}
+	return nil
+}
+
+// resultReuseInputs determines the inputs of the target: the files of the
+// repository it depends on, the resolved configuration and the inputs of the
+// steps other than the source code, which changes with every push.
+func (o *options) resultReuseInputs(client resultreuse.GitHubClient, test *api.TestStepConfiguration) (resultreuse.Inputs, error) {
+	refs := o.jobSpec.Refs
+	sources, err := resultreuse.Sources(client, *refs, o.jobSpec.ExtraRefs, test.ReuseResults.SourcePaths)
+	if err != nil {
+		return resultreuse.Inputs{}, fmt.Errorf("could not determine the files of the repository: %w", err)
+	}
+	config, err := yaml.Marshal(o.configSpec)
+	if err != nil {
+		return resultreuse.Inputs{}, fmt.Errorf("could not serialize the configuration: %w", err)
+	}
+	images := sets.New[string](o.stepInputs...).Delete(o.jobSpec.Inputs()...)
+	return resultreuse.Inputs{
+		Job:     o.jobSpec.Job,
+		Target:  test.As,
+		Org:     refs.Org,
+		Repo:    refs.Repo,
+		Pull:    refs.Pulls[0].Number,
+		Config:  string(config),
+		Images:  sets.List(images),
+		Sources: sources,
+	}, nil
+}
+
+// reuseResult reports the result of an earlier run of the target on the pull
+// request instead of running it again when none of its inputs changed, unless
+// the pull request asks for it to run. It returns whether the result was
+// reused. The target runs whenever that cannot be determined.
+func (o *options) reuseResult(ctx context.Context) bool {
+	if o.resultReuseNamespace == "" {
+		return false
+	}
+	test := o.reusableTarget()
+	if test == nil {
+		return false
+	}
+	logger := logrus.WithField("target", test.As)
+	raw, err := secrets.ReadFromFile(o.resultReuseTokenPath, o.censor)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read the GitHub token, not reusing results.")
+		return false
+	}
+	token := []byte(raw)
+	getToken := func() []byte { return token }
+	prowClient, err := github.NewClient(getToken, secretutil.AdaptCensorer(o.censor), github.DefaultGraphQLEndpoint, github.DefaultAPIEndpoint)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to create the GitHub client, not reusing results.")
+		return false
+	}
+	gitHubClient := resultreuse.NewGitHubClient(prowClient, github.DefaultAPIEndpoint, getToken)
+	refs := o.jobSpec.Refs
+	if overridden, err := resultreuse.Overridden(gitHubClient, refs.Org, refs.Repo, refs.Pulls[0].Number); err != nil {
+		logger.WithError(err).Warn("Failed to get the labels of the pull request, not reusing results.")
+		return false
+	} else if overridden {
+		logger.Infof("The pull request has the %s label, running the target.", resultreuse.OverrideLabel)
+		return false
+	}
+	inputs, err := o.resultReuseInputs(gitHubClient, test)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to determine the inputs of the target, not reusing results.")
+		return false
+	}
+	key, err := inputs.Key()
+	if err != nil {
+		logger.WithError(err).Warn("Failed to hash the inputs of the target, not reusing results.")
+		return false
+	}
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		logger.WithError(err).Warn("Failed to create the client for recorded results, not reusing results.")
+		return false
+	}
+	o.resultStore, o.resultReuseKey = resultreuse.NewStore(client, o.resultReuseNamespace), key
+	record, err := o.resultStore.Get(ctx, key)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get the recorded result, running the target.")
+		return false
+	}
+	if record == nil {
+		logger.Debug("No result was recorded for the inputs of the target, running it.")
+		return false
+	}
+	logger.Infof("None of the inputs of %s changed since it succeeded in build %s of %s on %s, reporting that result instead of running it again. Add the %s label to the pull request to run it.", test.As, record.BuildID, record.Job, record.SHA, resultreuse.OverrideLabel)
+	if data, err := json.MarshalIndent(record, "", "  "); err != nil {
+		logger.WithError(err).Warn("Unable to marshal the reused result.")
+	} else {
+		_ = api.SaveArtifact(o.censor, resultreuse.ArtifactFilename, data)
+	}
+	suites := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		Name:     "step graph",
+		NumTests: 1,
+		TestCases: []*junit.TestCase{{
+			Name:      fmt.Sprintf("Reuse the result of %s", test.As),
+			SystemOut: fmt.Sprintf("%s succeeded in build %s of %s on %s with identical inputs.", test.As, record.BuildID, record.Job, record.SHA),
+		}},
+	}}}
+	if err := o.writeJUnit(suites, "operator"); err != nil {
+		logger.WithError(err).Warn("Unable to write JUnit result.")
+	}
+	if err := o.writeTestResults(suites); err != nil {
+		logger.WithError(err).Warn("Unable to write test results.")
+	}
+	if reporter, err := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost); err != nil {
+		logger.WithError(err).Debug("Could not load result reporting options, the reused result will not be reported.")
+	} else {
+		reporter.ReportReusedResult(test.As)
+	}
+	return true
+}
+
+// recordResult records the successful result of the target, for later pushes
+// to the pull request to reuse.
+func (o *options) recordResult(ctx context.Context) {
+	if o.resultStore == nil {
+		return
+	}
+	record := resultreuse.Record{
+		Key:      o.resultReuseKey,
+		Target:   o.targets.values[0],
+		Job:      o.jobSpec.Job,
+		BuildID:  o.jobSpec.BuildID,
+		SHA:      o.jobSpec.Refs.Pulls[0].SHA,
+		Finished: time.Now(),
+	}
+	if err := o.resultStore.Put(ctx, record); err != nil {
+		logrus.WithError(err).Warn("Failed to record the result of the target for later pushes to reuse.")
+	}
+}
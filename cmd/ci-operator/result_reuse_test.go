@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestReusableTarget(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{
+		{As: "unit", ReuseResults: &api.ReuseResultsConfiguration{}},
+		{As: "e2e"},
+	}}
+	presubmit := func(pulls ...prowapi.Pull) *api.JobSpec {
+		return &api.JobSpec{JobSpec: downwardapi.JobSpec{Type: prowapi.PresubmitJob, Refs: &prowapi.Refs{Org: "org", Repo: "repo", Pulls: pulls}}}
+	}
+	for _, tc := range []struct {
+		name     string
+		jobSpec  *api.JobSpec
+		targets  []string
+		expected string
+	}{
+		{
+			name:     "presubmit of a test reusing results",
+			jobSpec:  presubmit(prowapi.Pull{Number: 1}),
+			targets:  []string{"unit"},
+			expected: "unit",
+		},
+		{
+			name:    "test not reusing results",
+			jobSpec: presubmit(prowapi.Pull{Number: 1}),
+			targets: []string{"e2e"},
+		},
+		{
+			name:    "more than one target",
+			jobSpec: presubmit(prowapi.Pull{Number: 1}),
+			targets: []string{"unit", "e2e"},
+		},
+		{
+			name:    "batch of pull requests",
+			jobSpec: presubmit(prowapi.Pull{Number: 1}, prowapi.Pull{Number: 2}),
+			targets: []string{"unit"},
+		},
+		{
+			name:    "postsubmit",
+			jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Type: prowapi.PostsubmitJob, Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}},
+			targets: []string{"unit"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{configSpec: config, jobSpec: tc.jobSpec, targets: stringSlice{values: tc.targets}}
+			var actual string
+			if test := o.reusableTarget(); test != nil {
+				actual = test.As
+			}
+			if actual != tc.expected {
+				t.Errorf("expected target %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
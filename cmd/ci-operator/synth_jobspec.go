@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+)
+
+// synthJobSpecCommand prints a JOB_SPEC for manual runs instead of running
+// ci-operator.
+const synthJobSpecCommand = "synth-jobspec"
+
+type synthJobSpecOptions struct {
+	org     string
+	repo    string
+	branch  string
+	pr      int
+	job     string
+	buildID string
+	github  flagutil.GitHubOptions
+}
+
+func bindSynthJobSpecOptions(fs *flag.FlagSet) *synthJobSpecOptions {
+	o := &synthJobSpecOptions{github: flagutil.GitHubOptions{AllowAnonymous: true}}
+	fs.StringVar(&o.org, "org", "", "Organization of the repository to test.")
+	fs.StringVar(&o.repo, "repo", "", "Name of the repository to test.")
+	fs.StringVar(&o.branch, "branch", "", "Branch of the repository to test, or the base branch of the pull request.")
+	fs.IntVar(&o.pr, "pr", 0, "Number of a pull request to test on top of the branch. The branch is tested alone if unset.")
+	fs.StringVar(&o.job, "job", "dev", "Name of the job in the JOB_SPEC.")
+	fs.StringVar(&o.buildID, "build-id", "0", "Build ID of the job in the JOB_SPEC.")
+	o.github.AddFlags(fs)
+	return o
+}
+
+func (o *synthJobSpecOptions) validate() error {
+	var errs []error
+	for flag, value := range map[string]string{"--org": o.org, "--repo": o.repo, "--branch": o.branch, "--job": o.job} {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", flag))
+		}
+	}
+	if o.pr < 0 {
+		errs = append(errs, errors.New("--pr must be positive"))
+	}
+	if err := o.github.Validate(false); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+type jobSpecGitHubClient interface {
+	GetRef(org, repo, ref string) (string, error)
+	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+}
+
+// synthesizeJobSpec builds the JOB_SPEC Prow would pass to a job testing the
+// branch, or the pull request on top of it, at their current commits.
+func (o *synthJobSpecOptions) synthesizeJobSpec(client jobSpecGitHubClient) (*downwardapi.JobSpec, error) {
+	baseSHA, err := client.GetRef(o.org, o.repo, "heads/"+o.branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch %s of %s/%s: %w", o.branch, o.org, o.repo, err)
+	}
+	refs := &prowapi.Refs{
+		Org:      o.org,
+		Repo:     o.repo,
+		RepoLink: fmt.Sprintf("https://github.com/%s/%s", o.org, o.repo),
+		BaseRef:  o.branch,
+		BaseSHA:  baseSHA,
+		BaseLink: fmt.Sprintf("https://github.com/%s/%s/commit/%s", o.org, o.repo, baseSHA),
+	}
+	spec := &downwardapi.JobSpec{Type: prowapi.PostsubmitJob, Job: o.job, BuildID: o.buildID, Refs: refs}
+	if o.pr == 0 {
+		return spec, nil
+	}
+
+	pr, err := client.GetPullRequest(o.org, o.repo, o.pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request %s/%s#%d: %w", o.org, o.repo, o.pr, err)
+	}
+	// the configuration of the job is determined by the base branch, so testing
+	// a pull request against another branch would silently use the wrong one
+	if pr.Base.Ref != o.branch {
+		return nil, fmt.Errorf("pull request %s/%s#%d targets branch %s, not %s", o.org, o.repo, o.pr, pr.Base.Ref, o.branch)
+	}
+	spec.Type = prowapi.PresubmitJob
+	refs.Pulls = []prowapi.Pull{{
+		Number:     pr.Number,
+		Author:     pr.User.Login,
+		SHA:        pr.Head.SHA,
+		Title:      pr.Title,
+		Ref:        pr.Head.Ref,
+		Link:       pr.HTMLURL,
+		CommitLink: fmt.Sprintf("https://github.com/%s/%s/pull/%d/commits/%s", o.org, o.repo, pr.Number, pr.Head.SHA),
+		AuthorLink: pr.User.HTMLURL,
+	}}
+	return spec, nil
+}
+
+// synthJobSpec prints the JOB_SPEC described by the arguments.
+func synthJobSpec(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(synthJobSpecCommand, flag.ContinueOnError)
+	o := bindSynthJobSpecOptions(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := o.validate(); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	client, err := o.github.GitHubClient(false)
+	if err != nil {
+		return fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	spec, err := o.synthesizeJobSpec(client)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job spec: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(raw))
+	return err
+}
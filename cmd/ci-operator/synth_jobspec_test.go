@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeJobSpecGitHubClient struct {
+	refs map[string]string
+	prs  map[int]*github.PullRequest
+}
+
+func (f *fakeJobSpecGitHubClient) GetRef(org, repo, ref string) (string, error) {
+	sha, ok := f.refs[org+"/"+repo+"/"+ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return sha, nil
+}
+
+func (f *fakeJobSpecGitHubClient) GetPullRequest(_, _ string, number int) (*github.PullRequest, error) {
+	pr, ok := f.prs[number]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return pr, nil
+}
+
+func TestSynthesizeJobSpec(t *testing.T) {
+	client := &fakeJobSpecGitHubClient{
+		refs: map[string]string{"org/repo/heads/main": "base-sha"},
+		prs: map[int]*github.PullRequest{
+			1: {
+				Number:  1,
+				Title:   "Fix things",
+				HTMLURL: "https://github.com/org/repo/pull/1",
+				User:    github.User{Login: "author", HTMLURL: "https://github.com/author"},
+				Head:    github.PullRequestBranch{Ref: "fix", SHA: "head-sha"},
+				Base:    github.PullRequestBranch{Ref: "main"},
+			},
+			2: {Number: 2, Base: github.PullRequestBranch{Ref: "release-1.0"}},
+		},
+	}
+	baseRefs := func() *prowapi.Refs {
+		return &prowapi.Refs{
+			Org:      "org",
+			Repo:     "repo",
+			RepoLink: "https://github.com/org/repo",
+			BaseRef:  "main",
+			BaseSHA:  "base-sha",
+			BaseLink: "https://github.com/org/repo/commit/base-sha",
+		}
+	}
+	testCases := []struct {
+		name          string
+		options       synthJobSpecOptions
+		expected      *downwardapi.JobSpec
+		expectedError error
+	}{
+		{
+			name:     "branch",
+			options:  synthJobSpecOptions{org: "org", repo: "repo", branch: "main", job: "dev", buildID: "0"},
+			expected: &downwardapi.JobSpec{Type: prowapi.PostsubmitJob, Job: "dev", BuildID: "0", Refs: baseRefs()},
+		},
+		{
+			name:    "pull request",
+			options: synthJobSpecOptions{org: "org", repo: "repo", branch: "main", pr: 1, job: "dev", buildID: "0"},
+			expected: func() *downwardapi.JobSpec {
+				refs := baseRefs()
+				refs.Pulls = []prowapi.Pull{{
+					Number:     1,
+					Author:     "author",
+					SHA:        "head-sha",
+					Title:      "Fix things",
+					Ref:        "fix",
+					Link:       "https://github.com/org/repo/pull/1",
+					CommitLink: "https://github.com/org/repo/pull/1/commits/head-sha",
+					AuthorLink: "https://github.com/author",
+				}}
+				return &downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "dev", BuildID: "0", Refs: refs}
+			}(),
+		},
+		{
+			name:          "unknown branch",
+			options:       synthJobSpecOptions{org: "org", repo: "repo", branch: "master", job: "dev"},
+			expectedError: errors.New("failed to resolve branch master of org/repo: not found"),
+		},
+		{
+			name:          "pull request against another branch",
+			options:       synthJobSpecOptions{org: "org", repo: "repo", branch: "main", pr: 2, job: "dev"},
+			expectedError: errors.New("pull request org/repo#2 targets branch release-1.0, not main"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := tc.options.synthesizeJobSpec(client)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, spec, cmpopts.IgnoreUnexported(downwardapi.JobSpec{})); diff != "" {
+				t.Errorf("unexpected job spec: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/version"
+)
+
+// releasedVersion matches the versions of the binaries built by
+// hack/install.sh from a clean checkout: v${build_date}-${git_commit}
+var releasedVersion = regexp.MustCompile(`^v(\d{8})-[0-9a-zA-Z.-]+$`)
+
+// buildDate is the date a released binary was built on.
+func buildDate(v string) (time.Time, bool) {
+	if strings.HasSuffix(v, "-dirty") {
+		return time.Time{}, false
+	}
+	m := releasedVersion.FindStringSubmatch(v)
+	if m == nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("20060102", m[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// versionSkew describes how the version of the binary lags behind the
+// advertised version, and is empty when it does not. Binaries built locally
+// are always reported, as their revision cannot be compared.
+func versionSkew(current, advertised string) string {
+	if advertised == "" || current == advertised {
+		return ""
+	}
+	deployed, ok := buildDate(advertised)
+	if !ok {
+		return ""
+	}
+	built, ok := buildDate(current)
+	if !ok {
+		return fmt.Sprintf("ci-operator was built locally as version %q, it may not match the deployed version %s", current, advertised)
+	}
+	if !built.Before(deployed) {
+		return ""
+	}
+	return fmt.Sprintf("ci-operator %s is %d days older than the deployed version %s", current, int(deployed.Sub(built).Hours()/24), advertised)
+}
+
+// checkVersionSkew warns when the binary is older than the version of
+// ci-operator advertised by the configresolver, as issues caused by running
+// a stale binary are hard to tell apart from actual failures.
+func (o *options) checkVersionSkew() {
+	if o.resolverClient == nil {
+		return
+	}
+	advertised, err := o.resolverClient.Version()
+	if err != nil {
+		logrus.WithError(err).Debug("Could not get the version advertised by the configresolver, not checking for a stale binary.")
+		return
+	}
+	skew := versionSkew(version.Version, advertised)
+	if skew == "" {
+		return
+	}
+	logrus.Warnf("%s, consider updating it before debugging unexpected failures.", skew)
+	reporter, err := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not load result reporting options, the version skew will not be reported.")
+		return
+	}
+	reporter.ReportVersionSkew(version.Version, advertised)
+}
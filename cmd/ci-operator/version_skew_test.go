@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVersionSkew(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		current    string
+		advertised string
+		expected   string
+	}{
+		{
+			name:       "same version",
+			current:    "v20240110-abc1234",
+			advertised: "v20240110-abc1234",
+		},
+		{
+			name:       "newer binary",
+			current:    "v20240112-def5678",
+			advertised: "v20240110-abc1234",
+		},
+		{
+			name:       "another build on the same day",
+			current:    "v20240110-def5678",
+			advertised: "v20240110-abc1234",
+		},
+		{
+			name:       "stale binary",
+			current:    "v20240101-def5678",
+			advertised: "v20240110-abc1234",
+			expected:   "ci-operator v20240101-def5678 is 9 days older than the deployed version v20240110-abc1234",
+		},
+		{
+			name:       "binary built without a version",
+			current:    "0",
+			advertised: "v20240110-abc1234",
+			expected:   `ci-operator was built locally as version "0", it may not match the deployed version v20240110-abc1234`,
+		},
+		{
+			name:       "binary built from a dirty checkout",
+			current:    "v20240112-def5678-dirty",
+			advertised: "v20240110-abc1234",
+			expected:   `ci-operator was built locally as version "v20240112-def5678-dirty", it may not match the deployed version v20240110-abc1234`,
+		},
+		{
+			name:       "nothing advertised",
+			current:    "v20240101-def5678",
+			advertised: "",
+		},
+		{
+			name:       "advertised version cannot be compared",
+			current:    "v20240101-def5678",
+			advertised: "0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, versionSkew(tc.current, tc.advertised)); diff != "" {
+				t.Errorf("unexpected skew: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// secretDiff lists the keys of a secret that a sync would change. Values are
+// never part of the diff, so that it can be shared safely.
+type secretDiff struct {
+	cluster, namespace, name string
+	// created is set when the secret does not exist yet
+	created bool
+	// typeChange describes the change of the type of the secret, which is
+	// recreated when it happens
+	typeChange string
+	added      []string
+	changed    []string
+	removed    []string
+}
+
+func (d secretDiff) empty() bool {
+	return !d.created && d.typeChange == "" && len(d.added)+len(d.changed)+len(d.removed) == 0
+}
+
+// diffSecrets compares the secrets that would be synced with the ones in the
+// clusters, without mutating anything.
+func diffSecrets(getters map[string]Getter, secretsMap map[string][]*coreapi.Secret, osdGlobalPullSecretGroup sets.Set[string]) ([]secretDiff, error) {
+	var diffs []secretDiff
+	var errs []error
+	for _, cluster := range sets.List(sets.KeySet(secretsMap)) {
+		getter, ok := getters[cluster]
+		if !ok {
+			errs = append(errs, fmt.Errorf("failed to get client getter for cluster %s", cluster))
+			continue
+		}
+		for _, secret := range secretsMap[cluster] {
+			existing, err := getter.Secrets(secret.Namespace).Get(context.TODO(), secret.Name, metav1.GetOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("error reading secret %s:%s/%s: %w", cluster, secret.Namespace, secret.Name, err))
+				continue
+			}
+			if err != nil {
+				existing = nil
+			}
+			var diff secretDiff
+			if secret.Namespace == "openshift-config" && secret.Name == "pull-secret" && osdGlobalPullSecretGroup.Has(cluster) {
+				diff, err = diffGlobalPullSecret(existing, secret)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to diff secret %s:%s/%s: %w", cluster, secret.Namespace, secret.Name, err))
+					continue
+				}
+			} else {
+				diff = diffSecret(existing, secret)
+			}
+			diff.cluster, diff.namespace, diff.name = cluster, secret.Namespace, secret.Name
+			if !diff.empty() {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].cluster != diffs[j].cluster {
+			return diffs[i].cluster < diffs[j].cluster
+		}
+		if diffs[i].namespace != diffs[j].namespace {
+			return diffs[i].namespace < diffs[j].namespace
+		}
+		return diffs[i].name < diffs[j].name
+	})
+	return diffs, utilerrors.NewAggregate(errs)
+}
+
+func diffSecret(existing, secret *coreapi.Secret) secretDiff {
+	var diff secretDiff
+	if existing == nil {
+		diff.created = true
+		diff.added = sets.List(sets.KeySet(secret.Data))
+		return diff
+	}
+	if secret.Type != existing.Type {
+		diff.typeChange = fmt.Sprintf("%s -> %s", existing.Type, secret.Type)
+	}
+	for _, key := range sets.List(sets.KeySet(secret.Data)) {
+		value, exists := existing.Data[key]
+		if !exists {
+			diff.added = append(diff.added, key)
+		} else if !bytes.Equal(value, secret.Data[key]) {
+			diff.changed = append(diff.changed, key)
+		}
+	}
+	// stale keys are only removed when the secret is updated with some data
+	if len(secret.Data) > 0 {
+		for _, key := range sets.List(sets.KeySet(existing.Data)) {
+			if _, exists := secret.Data[key]; !exists {
+				diff.removed = append(diff.removed, key)
+			}
+		}
+	}
+	return diff
+}
+
+// diffGlobalPullSecret reports the change of the global pull secret of an OSD
+// cluster, which is only partially updated by the sync.
+func diffGlobalPullSecret(existing, secret *coreapi.Secret) (secretDiff, error) {
+	var diff secretDiff
+	if existing == nil {
+		return diff, fmt.Errorf("the global pull secret does not exist")
+	}
+	mutated, err := mutateGlobalPullSecret(existing.DeepCopy(), secret)
+	if err != nil {
+		return diff, err
+	}
+	if mutated {
+		diff.changed = []string{coreapi.DockerConfigJsonKey}
+	}
+	return diff, nil
+}
+
+// formatDiffs renders the diffs grouped by cluster.
+func formatDiffs(diffs []secretDiff) string {
+	if len(diffs) == 0 {
+		return "No secrets would change.\n"
+	}
+	var b strings.Builder
+	var cluster string
+	for _, diff := range diffs {
+		if diff.cluster != cluster {
+			cluster = diff.cluster
+			fmt.Fprintf(&b, "cluster %s:\n", cluster)
+		}
+		var changes []string
+		if diff.created {
+			changes = append(changes, "created")
+		}
+		if diff.typeChange != "" {
+			changes = append(changes, fmt.Sprintf("type %s", diff.typeChange))
+		}
+		for _, keys := range []struct {
+			verb string
+			keys []string
+		}{{verb: "added", keys: diff.added}, {verb: "changed", keys: diff.changed}, {verb: "removed", keys: diff.removed}} {
+			if len(keys.keys) > 0 {
+				changes = append(changes, fmt.Sprintf("%s %s", keys.verb, strings.Join(keys.keys, ", ")))
+			}
+		}
+		fmt.Fprintf(&b, "  %s/%s: %s\n", diff.namespace, diff.name, strings.Join(changes, "; "))
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiffSecrets(t *testing.T) {
+	existing := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "creds"},
+		Type:       coreapi.SecretTypeOpaque,
+		Data:       map[string][]byte{"same": []byte("1"), "rotated": []byte("old"), "stale": []byte("x")},
+	}
+	unchanged := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "unchanged"},
+		Type:       coreapi.SecretTypeOpaque,
+		Data:       map[string][]byte{"key": []byte("value")},
+	}
+	getters := map[string]Getter{
+		"build01": fake.NewSimpleClientset(existing, unchanged).CoreV1(),
+		"build02": fake.NewSimpleClientset().CoreV1(),
+	}
+	secretsMap := map[string][]*coreapi.Secret{
+		"build01": {
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "creds"},
+				Type:       coreapi.SecretTypeOpaque,
+				Data:       map[string][]byte{"same": []byte("1"), "rotated": []byte("new"), "new": []byte("y")},
+			},
+			unchanged.DeepCopy(),
+		},
+		"build02": {
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "creds"},
+				Type:       coreapi.SecretTypeOpaque,
+				Data:       map[string][]byte{"b": []byte("1"), "a": []byte("2")},
+			},
+		},
+	}
+	diffs, err := diffSecrets(getters, secretsMap, sets.New[string]())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []secretDiff{
+		{cluster: "build01", namespace: "ci", name: "creds", added: []string{"new"}, changed: []string{"rotated"}, removed: []string{"stale"}},
+		{cluster: "build02", namespace: "ci", name: "creds", created: true, added: []string{"a", "b"}},
+	}
+	if diff := cmp.Diff(expected, diffs, cmp.AllowUnexported(secretDiff{})); diff != "" {
+		t.Errorf("unexpected diffs: %s", diff)
+	}
+
+	expectedOutput := `cluster build01:
+  ci/creds: added new; changed rotated; removed stale
+cluster build02:
+  ci/creds: created; added a, b
+`
+	if diff := cmp.Diff(expectedOutput, formatDiffs(diffs)); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+	if diff := cmp.Diff("No secrets would change.\n", formatDiffs(nil)); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+}
+
+func TestDiffSecretTypeChange(t *testing.T) {
+	existing := &coreapi.Secret{Type: coreapi.SecretTypeOpaque, Data: map[string][]byte{coreapi.DockerConfigJsonKey: []byte("{}")}}
+	secret := &coreapi.Secret{Type: coreapi.SecretTypeDockerConfigJson, Data: map[string][]byte{coreapi.DockerConfigJsonKey: []byte("{}")}}
+	expected := secretDiff{typeChange: "Opaque -> kubernetes.io/dockerconfigjson"}
+	if diff := cmp.Diff(expected, diffSecret(existing, secret), cmp.AllowUnexported(secretDiff{})); diff != "" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	vaultapi "github.com/openshift/ci-tools/pkg/api/vault"
+)
+
+// selfServicePrefix is the path under which the self-service secret
+// collections are stored in Vault.
+const selfServicePrefix = "selfservice/"
+
+// collectionOf is the collection holding a Vault item: the directory of the
+// item in the self-service area or the top-level directory otherwise. Items
+// at the top level are a collection of their own.
+func collectionOf(item string) string {
+	item = strings.TrimPrefix(item, selfServicePrefix)
+	collection, _, _ := strings.Cut(item, "/")
+	return collection
+}
+
+// configItems are the Vault items a secret configuration reads from.
+func configItems(secretConfig secretbootstrap.SecretConfig) []string {
+	var items []string
+	for _, itemContext := range secretConfig.From {
+		if itemContext.Item != "" {
+			items = append(items, itemContext.Item)
+		}
+		for _, data := range itemContext.DockerConfigJSONData {
+			items = append(items, data.Item)
+		}
+	}
+	return items
+}
+
+// filterSecretsForCollection keeps the secrets with at least one key sourced
+// from the collection. The secrets are kept whole, as syncing a secret replaces
+// all of its data. User secrets record the Vault paths they are sourced from,
+// which include the prefix the tool operates under.
+func filterSecretsForCollection(secretsMap map[string][]*coreapi.Secret, config secretbootstrap.Config, collection, vaultPrefix string) map[string][]*coreapi.Secret {
+	inCollection := func(item string) bool {
+		return collectionOf(item) == collection
+	}
+	fromConfig := map[string]sets.Set[types.NamespacedName]{}
+	for _, secretConfig := range config.Secrets {
+		matches := false
+		for _, item := range configItems(secretConfig) {
+			matches = matches || inCollection(item)
+		}
+		if !matches {
+			continue
+		}
+		for _, to := range secretConfig.To {
+			if fromConfig[to.Cluster] == nil {
+				fromConfig[to.Cluster] = sets.New[types.NamespacedName]()
+			}
+			fromConfig[to.Cluster].Insert(types.NamespacedName{Namespace: to.Namespace, Name: to.Name})
+		}
+	}
+
+	filtered := map[string][]*coreapi.Secret{}
+	for cluster, secrets := range secretsMap {
+		for _, secret := range secrets {
+			matches := fromConfig[cluster].Has(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name})
+			if sources := string(secret.Data[vaultapi.VaultSourceKey]); sources != "" {
+				for _, path := range strings.Split(sources, ",") {
+					matches = matches || inCollection(strings.TrimPrefix(path, vaultPrefix+"/"))
+				}
+			}
+			if matches {
+				filtered[cluster] = append(filtered[cluster], secret)
+			}
+		}
+	}
+	return filtered
+}
+
+func secretCount(secretsMap map[string][]*coreapi.Secret) int {
+	var count int
+	for _, secrets := range secretsMap {
+		count += len(secrets)
+	}
+	return count
+}
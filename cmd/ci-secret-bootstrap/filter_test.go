@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	vaultapi "github.com/openshift/ci-tools/pkg/api/vault"
+)
+
+func TestCollectionOf(t *testing.T) {
+	for item, expected := range map[string]string{
+		"dptp/quay":                    "dptp",
+		"selfservice/team-a/aws-creds": "team-a",
+		"top-level-item":               "top-level-item",
+	} {
+		if actual := collectionOf(item); actual != expected {
+			t.Errorf("%s: expected collection %q, got %q", item, expected, actual)
+		}
+	}
+}
+
+func TestFilterSecretsForCollection(t *testing.T) {
+	secret := func(namespace, name string, data map[string][]byte) *coreapi.Secret {
+		return &coreapi.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Data: data}
+	}
+	config := secretbootstrap.Config{Secrets: []secretbootstrap.SecretConfig{
+		{
+			From: map[string]secretbootstrap.ItemContext{"token": {Item: "dptp/quay", Field: "token"}},
+			To:   []secretbootstrap.SecretContext{{Cluster: "build01", Namespace: "ci", Name: "quay"}},
+		},
+		{
+			From: map[string]secretbootstrap.ItemContext{".dockerconfigjson": {DockerConfigJSONData: []secretbootstrap.DockerConfigJSONData{{Item: "other/registry"}}}},
+			To:   []secretbootstrap.SecretContext{{Cluster: "build01", Namespace: "ci", Name: "registry"}},
+		},
+		{
+			From: map[string]secretbootstrap.ItemContext{"password": {Item: "top-level-item", Field: "password"}},
+			To:   []secretbootstrap.SecretContext{{Cluster: "build01", Namespace: "ci", Name: "top-level"}},
+		},
+	}}
+	quay := secret("ci", "quay", map[string][]byte{"token": []byte("t")})
+	registry := secret("ci", "registry", map[string][]byte{".dockerconfigjson": []byte("{}")})
+	topLevel := secret("ci", "top-level", map[string][]byte{"password": []byte("p")})
+	user := secret("team", "creds", map[string][]byte{"key": []byte("v"), vaultapi.VaultSourceKey: []byte("kv/selfservice/team-a/creds,kv/selfservice/dptp/other")})
+	secretsMap := map[string][]*coreapi.Secret{
+		"build01": {quay, registry, topLevel, user},
+		"build02": {quay.DeepCopy()},
+	}
+
+	expected := map[string][]*coreapi.Secret{"build01": {quay, user}}
+	if diff := cmp.Diff(expected, filterSecretsForCollection(secretsMap, config, "dptp", "kv")); diff != "" {
+		t.Errorf("unexpected secrets for dptp: %s", diff)
+	}
+	expected = map[string][]*coreapi.Secret{"build01": {user}}
+	if diff := cmp.Diff(expected, filterSecretsForCollection(secretsMap, config, "team-a", "kv")); diff != "" {
+		t.Errorf("unexpected secrets for team-a: %s", diff)
+	}
+	expected = map[string][]*coreapi.Secret{"build01": {topLevel}}
+	if diff := cmp.Diff(expected, filterSecretsForCollection(secretsMap, config, "top-level-item", "kv")); diff != "" {
+		t.Errorf("unexpected secrets for top-level-item: %s", diff)
+	}
+}
@@ -48,12 +48,14 @@ type options struct {
 	force              bool
 	validateItemsUsage bool
 	confirm            bool
+	diff               bool
 
 	kubernetesOptions   flagutil.KubernetesOptions
 	configPath          string
 	generatorConfigPath string
 	cluster             string
 	secretNamesRaw      flagutil.Strings
+	collection          string
 	logLevel            string
 	impersonateUser     string
 
@@ -86,6 +88,8 @@ func parseOptions(censor *secrets.DynamicCensor) (options, error) {
 	fs.StringVar(&o.generatorConfigPath, "generator-config", "", "Path to the secret-generator config file.")
 	fs.StringVar(&o.cluster, "cluster", "", "If set, only provision secrets for this cluster")
 	fs.Var(&o.secretNamesRaw, "secret-names", "If set, only provision secrets with the given name. user_secrets_target_clusters in the configuration is ignored. Can be passed multiple times.")
+	fs.BoolVar(&o.diff, "diff", false, "If set, only print which keys of which secrets in which clusters would change, without mutating anything.")
+	fs.StringVar(&o.collection, "filter", "", "If set, only sync secrets with keys sourced from the given Vault collection, or from the given item at the top level.")
 	fs.BoolVar(&o.force, "force", false, "If true, update the secrets even if existing one differs from Bitwarden items instead of existing with error. Default false.")
 	fs.StringVar(&o.logLevel, "log-level", "info", fmt.Sprintf("Log level is one of %v.", logrus.AllLevels))
 	fs.StringVar(&o.impersonateUser, "as", "", "Username to impersonate")
@@ -110,6 +114,12 @@ func (o *options) validateOptions() error {
 	if len(o.allowUnused.Strings()) > 0 && !o.validateItemsUsage {
 		errs = append(errs, errors.New("--bw-allow-unused must be specified with --validate-items-usage"))
 	}
+	if o.diff && o.validateOnly {
+		errs = append(errs, errors.New("--diff cannot be specified with --validate-only"))
+	}
+	if strings.Contains(o.collection, "/") {
+		errs = append(errs, fmt.Errorf("--filter must be the name of a collection, got %q", o.collection))
+	}
 	errs = append(errs, o.kubernetesOptions.Validate(o.dryRun))
 	return utilerrors.NewAggregate(errs)
 }
@@ -988,6 +998,20 @@ func reconcileSecrets(o options, client secrets.ReadOnlyClient, prowDisabledClus
 		}
 	}
 
+	if o.collection != "" {
+		secretsMap = filterSecretsForCollection(secretsMap, o.config, o.collection, o.secrets.VaultPrefix)
+		logrus.WithField("collection", o.collection).Infof("Syncing %d secrets sourced from the collection", secretCount(secretsMap))
+	}
+
+	if o.diff {
+		diffs, err := diffSecrets(o.secretsGetters, secretsMap, sets.New[string](o.config.OSDGlobalPullSecretGroup()...))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to diff secrets: %w", err))
+		}
+		fmt.Print(formatDiffs(diffs))
+		return errs
+	}
+
 	if o.dryRun {
 		logrus.Infof("Running in dry-run mode")
 		if err := writeSecrets(secretsMap); err != nil {
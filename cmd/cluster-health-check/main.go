@@ -0,0 +1,136 @@
+// cluster-health-check asserts the health of the cluster under test. It runs
+// as the typed `health_check` step of multi-stage tests, reporting every check
+// as a jUnit test case in the artifacts of the step and failing the step when
+// any check fails.
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/flagutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/healthcheck"
+)
+
+type options struct {
+	kubeconfig   string
+	artifactDir  string
+	checks       flagutil.Strings
+	wait         time.Duration
+	pollInterval time.Duration
+}
+
+func defaultKubeconfig() string {
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return kubeconfig
+	}
+	if sharedDir := os.Getenv("SHARED_DIR"); sharedDir != "" {
+		return filepath.Join(sharedDir, "kubeconfig")
+	}
+	return ""
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("cluster-health-check", flag.ContinueOnError)
+	fs.StringVar(&o.kubeconfig, "kubeconfig", defaultKubeconfig(), "Path to the kubeconfig of the cluster, defaults to $KUBECONFIG or $SHARED_DIR/kubeconfig")
+	fs.StringVar(&o.artifactDir, "artifact-dir", os.Getenv("ARTIFACT_DIR"), "Directory where the jUnit is written, defaults to $ARTIFACT_DIR")
+	fs.Var(&o.checks, "check", "Check to run, can be passed multiple times. Defaults to all checks")
+	fs.DurationVar(&o.wait, "wait", 0, "How long failing checks are retried, checks run only once when unset")
+	fs.DurationVar(&o.pollInterval, "poll-interval", 10*time.Second, "How often failing checks are retried")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) step() api.HealthCheckStep {
+	step := api.HealthCheckStep{Wait: &prowv1.Duration{Duration: o.wait}}
+	for _, check := range o.checks.Strings() {
+		step.Checks = append(step.Checks, api.HealthCheck(check))
+	}
+	return step
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.kubeconfig == "" {
+		errs = append(errs, errors.New("--kubeconfig is required when neither $KUBECONFIG nor $SHARED_DIR is set"))
+	}
+	if o.artifactDir == "" {
+		errs = append(errs, errors.New("--artifact-dir is required when $ARTIFACT_DIR is not set"))
+	}
+	if o.pollInterval <= 0 {
+		errs = append(errs, errors.New("--poll-interval must be positive"))
+	}
+	step := o.step()
+	errs = append(errs, step.Validate()...)
+	return utilerrors.NewAggregate(errs)
+}
+
+func newClient(kubeconfig string) (ctrlruntimeclient.Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add client-go types to scheme: %w", err)
+	}
+	if err := configv1.Install(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add configv1 to scheme: %w", err)
+	}
+	return ctrlruntimeclient.New(config, ctrlruntimeclient.Options{Scheme: scheme})
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	client, err := newClient(o.kubeconfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create the cluster client")
+	}
+	step := o.step()
+	results := healthcheck.Run(context.Background(), client, step.EnabledChecks(), o.wait, o.pollInterval)
+	var failed int
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			logrus.Errorf("Check %s failed:\n%v", result.Check, result.Err)
+		} else {
+			logrus.Infof("Check %s passed.", result.Check)
+		}
+	}
+	raw, err := xml.MarshalIndent(healthcheck.JUnit(results), "", "    ")
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to marshal jUnit")
+	}
+	if err := os.WriteFile(filepath.Join(o.artifactDir, healthcheck.JUnitFile), raw, 0644); err != nil {
+		logrus.WithError(err).Fatal("failed to write jUnit")
+	}
+	if failed != 0 {
+		logrus.Fatalf("%d of %d checks failed", failed, len(results))
+	}
+}
@@ -0,0 +1,135 @@
+// cluster-upgrade upgrades the cluster under test to a release payload and
+// waits for the upgrade to complete. It runs as the typed `upgrade` step of
+// multi-stage tests, recording the progress of the upgrade and a jUnit test
+// case with diagnostics in the artifacts of the step.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/upgrade"
+)
+
+type options struct {
+	kubeconfig   string
+	artifactDir  string
+	to           string
+	channel      string
+	timeout      time.Duration
+	force        string
+	pollInterval time.Duration
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("cluster-upgrade", flag.ContinueOnError)
+	fs.StringVar(&o.kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig of the cluster, defaults to $KUBECONFIG")
+	fs.StringVar(&o.artifactDir, "artifact-dir", os.Getenv("ARTIFACT_DIR"), "Directory where the progress and jUnit are written, defaults to $ARTIFACT_DIR")
+	fs.StringVar(&o.to, "to", "", "Pull spec of the release payload to upgrade to")
+	fs.StringVar(&o.channel, "channel", "", "Update channel to set on the cluster before upgrading")
+	fs.DurationVar(&o.timeout, "timeout", api.DefaultUpgradeTimeout, "How long to wait for the upgrade to complete")
+	fs.StringVar(&o.force, "force", string(api.UpgradeForceNever), "When to force the upgrade: never, always or if_unverified")
+	fs.DurationVar(&o.pollInterval, "poll-interval", 15*time.Second, "How often the status of the upgrade is checked")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.kubeconfig == "" {
+		errs = append(errs, errors.New("--kubeconfig is required when $KUBECONFIG is not set"))
+	}
+	if o.artifactDir == "" {
+		errs = append(errs, errors.New("--artifact-dir is required when $ARTIFACT_DIR is not set"))
+	}
+	if o.to == "" {
+		errs = append(errs, errors.New("--to is required"))
+	}
+	if o.pollInterval <= 0 {
+		errs = append(errs, errors.New("--poll-interval must be positive"))
+	}
+	step := api.UpgradeStep{Channel: o.channel, Force: api.UpgradeForce(o.force), Timeout: &prowv1.Duration{Duration: o.timeout}}
+	errs = append(errs, step.Validate()...)
+	return utilerrors.NewAggregate(errs)
+}
+
+func writeArtifacts(artifactDir string, result *upgrade.Result) error {
+	raw, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactDir, upgrade.ProgressFile), raw, 0644); err != nil {
+		return fmt.Errorf("failed to write progress: %w", err)
+	}
+	raw, err = xml.MarshalIndent(upgrade.JUnit(result), "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal jUnit: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactDir, upgrade.JUnitFile), raw, 0644); err != nil {
+		return fmt.Errorf("failed to write jUnit: %w", err)
+	}
+	return nil
+}
+
+func newClient(kubeconfig string) (ctrlruntimeclient.Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := configv1.Install(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add configv1 to scheme: %w", err)
+	}
+	return ctrlruntimeclient.New(config, ctrlruntimeclient.Options{Scheme: scheme})
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	client, err := newClient(o.kubeconfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create the cluster client")
+	}
+	result, upgradeErr := upgrade.New(client, upgrade.Options{
+		Target:       o.to,
+		Channel:      o.channel,
+		Timeout:      o.timeout,
+		Force:        api.UpgradeForce(o.force),
+		PollInterval: o.pollInterval,
+	}).Run(context.Background())
+	if err := writeArtifacts(o.artifactDir, result); err != nil {
+		logrus.WithError(err).Error("failed to write artifacts")
+	}
+	if upgradeErr != nil {
+		for _, diagnostic := range result.Diagnostics {
+			logrus.Warn(diagnostic)
+		}
+		logrus.WithError(upgradeErr).Fatal("upgrade failed")
+	}
+	logrus.Infof("Upgraded to %s in %s.", o.to, result.Finished.Sub(result.Started).Round(time.Second))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/configmutation"
+	"github.com/openshift/ci-tools/pkg/github/prcreation"
+)
+
+type options struct {
+	prcreation.PRCreationOptions
+	configDir    string
+	mutationPath string
+	diffPath     string
+	confirm      bool
+	createPRs    bool
+	releaseRepo  string
+	prOrg        string
+	prRepo       string
+	prBranch     string
+}
+
+func gatherOptions() options {
+	o := options{}
+	o.PRCreationOptions.AddFlags(flag.CommandLine)
+	flag.StringVar(&o.configDir, "config-dir", "", "Path to the CI Operator configuration directory")
+	flag.StringVar(&o.mutationPath, "mutation", "", "Path to the file declaring the mutation")
+	flag.StringVar(&o.diffPath, "diff", "", "Path to write the diff of all changes to, printed to the standard output if unset")
+	flag.BoolVar(&o.confirm, "confirm", false, "Write the mutated configurations")
+	flag.BoolVar(&o.createPRs, "create-prs", false, "Open a pull request per organization with the mutated configurations. Requires --release-repo")
+	flag.StringVar(&o.releaseRepo, "release-repo", "", "Path to the git checkout containing --config-dir, which pull requests are created from")
+	flag.StringVar(&o.prOrg, "pr-org", "openshift", "Organization of the repository to open pull requests against")
+	flag.StringVar(&o.prRepo, "pr-repo", "release", "Name of the repository to open pull requests against")
+	flag.StringVar(&o.prBranch, "pr-branch", "master", "Branch to open pull requests against")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.configDir == "" {
+		errs = append(errs, errors.New("--config-dir is required"))
+	}
+	if o.mutationPath == "" {
+		errs = append(errs, errors.New("--mutation is required"))
+	}
+	if o.createPRs {
+		if o.confirm {
+			errs = append(errs, errors.New("--create-prs and --confirm are mutually exclusive"))
+		}
+		if o.releaseRepo == "" {
+			errs = append(errs, errors.New("--release-repo is required when --create-prs is set"))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func writeDiff(w io.Writer, changes []configmutation.Change) error {
+	for _, change := range changes {
+		diff, err := change.Diff()
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", change.Info.RelativePath(), err)
+		}
+		if _, err := io.WriteString(w, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func prBody(name string, changes []configmutation.Change) string {
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, "* `"+change.Info.RelativePath()+"`")
+	}
+	return fmt.Sprintf("This is an autogenerated PR that applies the `%s` bulk mutation to the following configurations:\n\n%s\n", name, strings.Join(paths, "\n"))
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	spec, err := configmutation.LoadSpec(o.mutationPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the mutation.")
+	}
+	mutation, err := spec.Mutation()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid mutation.")
+	}
+	// creating pull requests changes the working directory
+	for _, dir := range []*string{&o.configDir, &o.releaseRepo} {
+		if *dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to determine the absolute path of %s.", *dir)
+		}
+		*dir = abs
+	}
+
+	changes, err := configmutation.Apply(o.configDir, mutation)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to mutate configurations.")
+	}
+	out := os.Stdout
+	if o.diffPath != "" {
+		if out, err = os.Create(o.diffPath); err != nil {
+			logrus.WithError(err).Fatal("Failed to create the diff file.")
+		}
+		defer out.Close()
+	}
+	if err := writeDiff(out, changes); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the diff.")
+	}
+
+	switch {
+	case o.confirm:
+		if err := configmutation.CommitAll(o.configDir, changes); err != nil {
+			logrus.WithError(err).Fatal("Failed to write configurations.")
+		}
+		logrus.Infof("Rewrote %d configurations.", len(changes))
+	case o.createPRs:
+		if err := o.PRCreationOptions.Finalize(); err != nil {
+			logrus.WithError(err).Fatal("Failed to set up pull request creation.")
+		}
+		proposal := configmutation.Proposal{
+			ReleaseRepo: o.releaseRepo,
+			ConfigDir:   o.configDir,
+			Org:         o.prOrg,
+			Repo:        o.prRepo,
+			Branch:      o.prBranch,
+			Title: func(org string) string {
+				return fmt.Sprintf("Bulk config mutation %s for %s", spec.Name, org)
+			},
+			Body: func(_ string, changes []configmutation.Change) string {
+				return prBody(spec.Name, changes)
+			},
+		}
+		if err := proposal.CreatePullRequests(&o.PRCreationOptions, changes); err != nil {
+			logrus.WithError(err).Fatal("Failed to create pull requests.")
+		}
+	default:
+		logrus.Infof("Would rewrite %d configurations.", len(changes))
+	}
+}
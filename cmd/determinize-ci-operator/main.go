@@ -13,6 +13,7 @@ import (
 	"sigs.k8s.io/prow/pkg/flagutil"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/migrate"
 	"github.com/openshift/ci-tools/pkg/config"
 )
 
@@ -31,6 +32,7 @@ type options struct {
 	templateMigrationAllowedBranches        flagutil.Strings
 	templateMigrationAllowedOrgs            flagutil.Strings
 	templateMigrationAllowedClusterProfiles flagutil.Strings
+	upgradeSchema                           bool
 }
 
 func (o options) validate() error {
@@ -53,6 +55,7 @@ func gatherOptions() options {
 	flag.Var(&o.templateMigrationAllowedBranches, "template-migration-allowed-branch", "Allowed branches to automigrate templates on. Can be passed multiple times. All branches are allowed if unset.")
 	flag.Var(&o.templateMigrationAllowedOrgs, "template-migration-allowed-org", "Allowed orgs to automigrate templates on. Can be passed multiple times. All orgs are allowed if unset.")
 	flag.Var(&o.templateMigrationAllowedClusterProfiles, "template-migration-allowed-cluster-profile", "Allowed cluster profiles to automigrate templates on. Can be passed multiple times. All cluster profiles are allowed if unset.")
+	flag.BoolVar(&o.upgradeSchema, "upgrade-schema", false, "Upgrade the configurations written in older schema versions to the latest one")
 	flag.Parse()
 
 	return o
@@ -90,6 +93,16 @@ func main() {
 			migratedCount += migrateOpenShiftInstallerTemplates(&output, allowedBranches, allowedOrgs, allowedClusterProfiles)
 		}
 
+		if o.upgradeSchema {
+			changes, err := migrate.Migrate(&output.Configuration)
+			if err != nil {
+				return fmt.Errorf("failed to upgrade %s: %w", info.Filename, err)
+			}
+			for _, change := range changes {
+				output.Logger().Infof("Upgraded the configuration to schema version %d: %s", output.Configuration.SchemaVersion, change)
+			}
+		}
+
 		// we treat the filepath as the ultimate source of truth for this
 		// data, but we record it in the configuration files to ensure that
 		// it's easy to consume it for downstream tools
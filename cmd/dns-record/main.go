@@ -0,0 +1,115 @@
+// dns-record manages DNS records from multi-stage test steps. Records are
+// registered in the shared directory of the test when they are created and
+// the `cleanup` action, meant to run in a best-effort `post` step, deletes all
+// the records that are still registered.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/flagutil"
+
+	"github.com/openshift/ci-tools/pkg/dns"
+)
+
+const (
+	actionUpsert  = "upsert"
+	actionDelete  = "delete"
+	actionCleanup = "cleanup"
+)
+
+type options struct {
+	action    string
+	sharedDir string
+
+	provider string
+	zone     string
+	name     string
+	kind     string
+	ttl      int64
+	values   flagutil.Strings
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	if len(args) == 0 {
+		return o, fmt.Errorf("an action is required, one of %s, %s or %s", actionUpsert, actionDelete, actionCleanup)
+	}
+	o.action = args[0]
+	fs := flag.NewFlagSet(o.action, flag.ContinueOnError)
+	fs.StringVar(&o.sharedDir, "shared-dir", os.Getenv("SHARED_DIR"), "Directory where the records are registered for cleanup, defaults to $SHARED_DIR")
+	fs.StringVar(&o.provider, "provider", "", "DNS provider hosting the zone, one of aws or gcp")
+	fs.StringVar(&o.zone, "zone", "", "ID of the Route 53 hosted zone or <project>/<zone> of the Cloud DNS managed zone")
+	fs.StringVar(&o.name, "name", "", "Fully qualified domain name of the record")
+	fs.StringVar(&o.kind, "type", "A", "Type of the record")
+	fs.Int64Var(&o.ttl, "ttl", 60, "Time to live of the record in seconds")
+	fs.Var(&o.values, "value", "Value of the record, can be passed multiple times")
+	if err := fs.Parse(args[1:]); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.sharedDir == "" {
+		return errors.New("--shared-dir is required when $SHARED_DIR is not set")
+	}
+	switch o.action {
+	case actionUpsert, actionDelete:
+		if o.provider == "" || o.zone == "" || o.name == "" {
+			return errors.New("--provider, --zone and --name are required")
+		}
+	case actionCleanup:
+	default:
+		return fmt.Errorf("unknown action %q, expected one of %s, %s or %s", o.action, actionUpsert, actionDelete, actionCleanup)
+	}
+	return nil
+}
+
+func (o *options) record() dns.Record {
+	return dns.Record{
+		Provider: dns.Provider(o.provider),
+		Zone:     o.zone,
+		Name:     o.name,
+		Type:     o.kind,
+		TTL:      o.ttl,
+		Values:   o.values.Strings(),
+	}
+}
+
+func run(ctx context.Context, o options) error {
+	path := filepath.Join(o.sharedDir, dns.RecordsFile)
+	if o.action == actionCleanup {
+		return dns.Cleanup(ctx, dns.NewClient, path)
+	}
+	record := o.record()
+	client, err := dns.NewClient(ctx, record.Provider)
+	if err != nil {
+		return err
+	}
+	if o.action == actionUpsert {
+		return dns.Upsert(ctx, client, path, record)
+	}
+	return dns.Delete(ctx, client, path, record)
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	if err := run(context.Background(), o); err != nil {
+		logrus.WithError(err).Fatalf("failed to %s DNS records", o.action)
+	}
+	logrus.Infof("Finished %s of DNS records.", o.action)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/dns"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		args           []string
+		expectedRecord dns.Record
+		expectedErr    error
+	}{
+		{
+			name:           "upsert",
+			args:           []string{"upsert", "--shared-dir", "/shared", "--provider", "aws", "--zone", "Z123", "--name", "api.ci.example.com", "--value", "10.0.0.1", "--value", "10.0.0.2"},
+			expectedRecord: dns.Record{Provider: dns.ProviderAWS, Zone: "Z123", Name: "api.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1", "10.0.0.2"}},
+		},
+		{
+			name:        "missing record",
+			args:        []string{"delete", "--shared-dir", "/shared", "--provider", "aws"},
+			expectedErr: errors.New("--provider, --zone and --name are required"),
+		},
+		{
+			name:        "unknown action",
+			args:        []string{"create", "--shared-dir", "/shared"},
+			expectedErr: errors.New(`unknown action "create", expected one of upsert, delete or cleanup`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := gatherOptions(tc.args)
+			if err != nil {
+				t.Fatalf("failed to parse arguments: %v", err)
+			}
+			err = o.validate()
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedRecord, o.record()); diff != "" {
+				t.Errorf("unexpected record: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepRegistry(t *testing.T) {
+	// the steps are copied into a registry which holds the cluster profiles
+	references, chains, workflows, _, _, _, observers, err := load.Registry("step-registry", load.RegistryUnvalidated)
+	if err != nil {
+		t.Fatalf("failed to load the step registry: %v", err)
+	}
+	if err := registry.Validate(references, chains, workflows, observers); err != nil {
+		t.Fatalf("invalid step registry: %v", err)
+	}
+	upsert, cleanup := "dns-record-upsert", "dns-record-cleanup"
+	resolved, err := registry.NewResolver(references, chains, workflows, observers).Resolve("e2e", api.MultiStageTestConfiguration{
+		Pre:  []api.TestStep{{Reference: &upsert}},
+		Post: []api.TestStep{{Reference: &cleanup}},
+		Environment: api.TestEnvironment{
+			"DNS_RECORD_PROVIDER": "aws",
+			"DNS_RECORD_ZONE":     "Z123",
+			"DNS_RECORD_NAME":     "api.ci.example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to resolve a test using the steps: %v", err)
+	}
+	for _, step := range append(resolved.Pre, resolved.Post...) {
+		if step.FromImage == nil || step.FromImage.Name != "dns-record" {
+			t.Errorf("step %s does not run in the dns-record image", step.As)
+		}
+	}
+	if bestEffort := resolved.Post[0].BestEffort; bestEffort == nil || !*bestEffort {
+		t.Errorf("expected the cleanup step to be best effort")
+	}
+}
@@ -0,0 +1,14 @@
+#!/bin/bash
+
+set -o nounset
+set -o errexit
+set -o pipefail
+
+if [[ -f "${CLUSTER_PROFILE_DIR:-}/.awscred" ]]; then
+  export AWS_SHARED_CREDENTIALS_FILE="${CLUSTER_PROFILE_DIR}/.awscred"
+fi
+if [[ -f "${CLUSTER_PROFILE_DIR:-}/gce.json" ]]; then
+  export GOOGLE_APPLICATION_CREDENTIALS="${CLUSTER_PROFILE_DIR}/gce.json"
+fi
+
+dns-record cleanup
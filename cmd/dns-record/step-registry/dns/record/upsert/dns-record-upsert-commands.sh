@@ -0,0 +1,23 @@
+#!/bin/bash
+
+set -o nounset
+set -o errexit
+set -o pipefail
+
+if [[ -f "${CLUSTER_PROFILE_DIR:-}/.awscred" ]]; then
+  export AWS_SHARED_CREDENTIALS_FILE="${CLUSTER_PROFILE_DIR}/.awscred"
+fi
+if [[ -f "${CLUSTER_PROFILE_DIR:-}/gce.json" ]]; then
+  export GOOGLE_APPLICATION_CREDENTIALS="${CLUSTER_PROFILE_DIR}/gce.json"
+fi
+
+values="${DNS_RECORD_VALUES}"
+if [[ -z "${values}" && -n "${DNS_RECORD_VALUES_FILE}" ]]; then
+  values="$(cat "${SHARED_DIR}/${DNS_RECORD_VALUES_FILE}")"
+fi
+
+args=(upsert --provider "${DNS_RECORD_PROVIDER}" --zone "${DNS_RECORD_ZONE}" --name "${DNS_RECORD_NAME}" --type "${DNS_RECORD_TYPE}" --ttl "${DNS_RECORD_TTL}")
+for value in ${values}; do
+  args+=(--value "${value}")
+done
+dns-record "${args[@]}"
@@ -15,6 +15,7 @@ import (
 	"github.com/bombsimon/logrusr/v3"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/fsnotify.v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -35,7 +36,10 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	orchestrationv1 "github.com/openshift/ci-tools/pkg/api/orchestration/v1"
 	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/controller/namespacepool"
+	"github.com/openshift/ci-tools/pkg/controller/orchestration"
 	"github.com/openshift/ci-tools/pkg/controller/promotionreconciler"
 	serviceaccountsecretrefresher "github.com/openshift/ci-tools/pkg/controller/serviceaccount_secret_refresher"
 	testimagesdistributor "github.com/openshift/ci-tools/pkg/controller/test-images-distributor"
@@ -54,6 +58,8 @@ var allControllers = sets.New[string](
 	testimagesdistributor.ControllerName,
 	serviceaccountsecretrefresher.ControllerName,
 	testimagestreamimportcleaner.ControllerName,
+	namespacepool.ControllerName,
+	orchestration.ControllerName,
 )
 
 type options struct {
@@ -72,6 +78,7 @@ type options struct {
 	serviceAccountSecretRefresherOptions serviceAccountSecretRefresherOptions
 	imagePusherOptions                   imagePusherOptions
 	promotionReconcilerOptions           promotionReconcilerOptions
+	namespacePoolOptions                 namespacePoolOptions
 	*flagutil.GitHubOptions
 	releaseRepoGitSyncPath string
 }
@@ -105,6 +112,12 @@ type imagePusherOptions struct {
 	imageStreams    sets.Set[string]
 }
 
+type namespacePoolOptions struct {
+	size     int
+	adminRaw string
+	admin    types.NamespacedName
+}
+
 type serviceAccountSecretRefresherOptions struct {
 	enabledNamespaces     flagutil.Strings
 	removeOldSecrets      bool
@@ -137,6 +150,8 @@ func newOpts() (*options, error) {
 	fs.Var(&opts.imagePusherOptions.imageStreamsRaw, "imagePusherOptions.image-stream", "An imagestream that will be synced. It must be in namespace/name format (e.G `ci/clonerefs`). Can be passed multiple times.")
 	fs.Var(&opts.promotionReconcilerOptions.ignoreImageStreamsRaw, "promotionReconcilerOptions.ignore-image-stream", "The image stream to ignore. It is an regular expression (e.G ^openshift-priv/.+). Can be passed multiple times.")
 	fs.StringVar(&opts.promotionReconcilerOptions.sinceRaw, "promotionReconcilerOptions.since", "360h", "The image stream tags to reconcile if it is younger than a relative duration like 5s, 2m, or 3h. Defaults to 360h, i.e., 15 days")
+	fs.IntVar(&opts.namespacePoolOptions.size, "namespacePoolOptions.size", 5, "The number of pre-warmed namespaces kept in the pool of every build cluster.")
+	fs.StringVar(&opts.namespacePoolOptions.adminRaw, "namespacePoolOptions.admin", "ci/ci-operator", "The service account running ci-operator, granted admin access to the pooled namespaces. It must be in namespace/name format.")
 	fs.BoolVar(&opts.dryRun, "dry-run", true, "Whether to run the controller-manager with dry-run")
 	fs.StringVar(&opts.releaseRepoGitSyncPath, "release-repo-git-sync-path", "", "Path to release repository dir")
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -225,6 +240,17 @@ func newOpts() (*options, error) {
 		}
 	}
 
+	if opts.enabledControllersSet.Has(namespacepool.ControllerName) {
+		if opts.namespacePoolOptions.size < 1 {
+			errs = append(errs, fmt.Errorf("--namespacePoolOptions.size must be positive when enabling the %s controller", namespacepool.ControllerName))
+		}
+		if slashSplit := strings.Split(opts.namespacePoolOptions.adminRaw, "/"); len(slashSplit) != 2 || slashSplit[0] == "" || slashSplit[1] == "" {
+			errs = append(errs, fmt.Errorf("--namespacePoolOptions.admin value %s was not in namespace/name format", opts.namespacePoolOptions.adminRaw))
+		} else {
+			opts.namespacePoolOptions.admin = types.NamespacedName{Namespace: slashSplit[0], Name: slashSplit[1]}
+		}
+	}
+
 	if err := opts.GitHubOptions.Validate(opts.dryRun); err != nil {
 		errs = append(errs, err)
 	}
@@ -423,6 +449,9 @@ func main() {
 	if err := prowv1.AddToScheme(mgr.GetScheme()); err != nil {
 		logrus.WithError(err).Fatal("Failed to add prowv1 to scheme")
 	}
+	if err := orchestrationv1.AddToScheme(mgr.GetScheme()); err != nil {
+		logrus.WithError(err).Fatal("Failed to add orchestrationv1 to scheme")
+	}
 	pprof.Serve(flagutil.DefaultPProfPort)
 
 	for cluster, buildClusterMgr := range allManagers {
@@ -519,6 +548,21 @@ func main() {
 		}
 	}
 
+	if opts.enabledControllersSet.Has(namespacepool.ControllerName) {
+		poolOptions := namespacepool.Options{Size: opts.namespacePoolOptions.size, Admin: opts.namespacePoolOptions.admin}
+		for clusterName, clusterMgr := range allClustersExceptRegistryCluster {
+			if err := namespacepool.AddToManager(clusterName, clusterMgr, poolOptions); err != nil {
+				logrus.WithError(err).Fatalf("Failed to add the %s controller to the %s cluster", namespacepool.ControllerName, clusterName)
+			}
+		}
+	}
+
+	if opts.enabledControllersSet.Has(orchestration.ControllerName) {
+		if err := orchestration.AddToManager(mgr); err != nil {
+			logrus.WithError(err).Fatal("Failed to construct the orchestration controller")
+		}
+	}
+
 	if err := mgr.Start(ctx); err != nil {
 		logrus.WithError(err).Fatal("Manager ended with error")
 	}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/durationslo"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const junitFilename = "junit_operator.xml"
+
+type options struct {
+	resultsDir string
+	reportPath string
+	minRuns    int
+	threshold  float64
+}
+
+func gatherOptions() options {
+	o := options{}
+	flag.StringVar(&o.resultsDir, "results-dir", "", "Path to the job results, laid out as <job>/<build>/.../"+junitFilename)
+	flag.StringVar(&o.reportPath, "report", "", "Path to write the report to, printed to the standard output if unset")
+	flag.IntVar(&o.minRuns, "min-runs", 5, "Number of runs of a test needed to judge it")
+	flag.Float64Var(&o.threshold, "violation-threshold", 0.5, "Fraction of runs over the expected duration from which a test is reported")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.resultsDir == "" {
+		errs = append(errs, errors.New("--results-dir is required"))
+	}
+	if o.minRuns < 1 {
+		errs = append(errs, errors.New("--min-runs must be positive"))
+	}
+	if o.threshold <= 0 || o.threshold > 1 {
+		errs = append(errs, errors.New("--violation-threshold must be in (0, 1]"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// loadRuns reads the measurements from every JUnit result of ci-operator
+// under the directory, the job being the first element of their path.
+func loadRuns(dir string) ([]durationslo.Run, error) {
+	var paths []string
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == junitFilename {
+			paths = append(paths, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var runs []durationslo.Run
+	var errs []error
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		job, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		measurements, err := loadMeasurements(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		runs = append(runs, durationslo.Run{Job: job, Measurements: measurements})
+	}
+	return runs, utilerrors.NewAggregate(errs)
+}
+
+func loadMeasurements(path string) ([]durationslo.Measurement, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var suites junit.TestSuites
+	if err := xml.Unmarshal(raw, &suites); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JUnit: %w", err)
+	}
+	var measurements []durationslo.Measurement
+	for _, suite := range suites.Suites {
+		m, err := durationslo.FromProperties(suite.Properties)
+		if err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m...)
+	}
+	return measurements, nil
+}
+
+type report struct {
+	Violators []durationslo.Violator `json:"violators,omitempty"`
+}
+
+func (r *report) write(path string) error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	runs, err := loadRuns(o.resultsDir)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load some job results.")
+	}
+	r := report{Violators: durationslo.ChronicViolators(runs, durationslo.Policy{MinRuns: o.minRuns, Threshold: o.threshold})}
+	if err := r.write(o.reportPath); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	logrus.Infof("Checked %d runs, %d tests chronically run over their expected duration.", len(runs), len(r.Violators))
+}
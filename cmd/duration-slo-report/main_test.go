@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/durationslo"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+func TestLoadRuns(t *testing.T) {
+	dir := t.TempDir()
+	measurements := []durationslo.Measurement{{Test: "e2e", Expected: time.Hour, Actual: 2 * time.Hour}}
+	raw, err := xml.Marshal(&junit.TestSuites{Suites: []*junit.TestSuite{{Name: "step graph", Properties: durationslo.Properties(measurements)}}})
+	if err != nil {
+		t.Fatalf("failed to marshal the junit: %v", err)
+	}
+	for _, name := range []string{
+		"job/1/artifacts/junit_operator.xml",
+		// the summary of the junit of the steps of a test is not a run
+		"job/1/artifacts/e2e/" + junit.SummaryFilename,
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err := loadRuns(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []durationslo.Run{{Job: "job", Measurements: measurements}}
+	if diff := cmp.Diff(expected, runs); diff != "" {
+		t.Errorf("unexpected runs: %s", diff)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// tailSyncInterval is how often the tail file is brought up to date with the
+// buffered tail at most.
+const tailSyncInterval = time.Second
+
+// logLimiter caps the size of the output and error output of the wrapped
+// command, which share the limit. The head of the output is written through
+// as it is produced, the tail is buffered and written once the command exits,
+// after a marker recording how much of the output was dropped in between.
+// When a tail file is given, it is kept up to date with the buffered tail so
+// that the end of the output outlives the wrapper when it is killed before it
+// could write it.
+type logLimiter struct {
+	sync.Mutex
+	head int64
+	// written is how much of the head was written.
+	written int64
+	// tail holds the most recent output past the head in the order it was
+	// written, at most tailSize bytes of it. It only grows as output is
+	// buffered, as most commands never fill it.
+	tail     []segment
+	tailSize int64
+	size     int64
+	dropped  int64
+	// droppedFrom is the stream output was last dropped from, which gets the
+	// marker when none of the output is buffered.
+	droppedFrom io.Writer
+	// tailFile mirrors the tail, rewritten by Sync when it is dirty.
+	tailFile *os.File
+	dirty    bool
+}
+
+// segment is output buffered for one of the streams.
+type segment struct {
+	out  io.Writer
+	data []byte
+}
+
+func newLogLimiter(head, tail int64, tailFile string) (*logLimiter, error) {
+	l := &logLimiter{head: head, tailSize: tail}
+	if tailFile != "" {
+		f, err := os.Create(tailFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not create the tail file: %w", err)
+		}
+		l.tailFile = f
+	}
+	return l, nil
+}
+
+// Writer returns a writer limiting the output written to out.
+func (l *logLimiter) Writer(out io.Writer) io.Writer {
+	return &limitedWriter{limiter: l, out: out}
+}
+
+type limitedWriter struct {
+	limiter *logLimiter
+	out     io.Writer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	return w.limiter.write(w.out, p)
+}
+
+func (l *logLimiter) write(out io.Writer, p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	n := len(p)
+	if remaining := l.head - l.written; remaining > 0 {
+		chunk := p[:min(int64(len(p)), remaining)]
+		written, err := out.Write(chunk)
+		l.written += int64(written)
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	l.buffer(out, p)
+	l.dirty = true
+	return n, nil
+}
+
+// buffer keeps the output in the tail, dropping what it no longer has room for.
+func (l *logLimiter) buffer(out io.Writer, p []byte) {
+	if overflow := int64(len(p)) - l.tailSize; overflow > 0 {
+		l.drop(out, l.size+overflow)
+		l.tail, l.size = nil, 0
+		p = p[overflow:]
+	}
+	if len(p) == 0 {
+		return
+	}
+	if last := len(l.tail) - 1; last >= 0 && l.tail[last].out == out {
+		l.tail[last].data = append(l.tail[last].data, p...)
+	} else {
+		l.tail = append(l.tail, segment{out: out, data: append([]byte(nil), p...)})
+	}
+	l.size += int64(len(p))
+	for overflow := l.size - l.tailSize; overflow > 0; {
+		first := &l.tail[0]
+		if int64(len(first.data)) > overflow {
+			l.drop(first.out, overflow)
+			first.data = first.data[overflow:]
+			l.size -= overflow
+			break
+		}
+		l.drop(first.out, int64(len(first.data)))
+		overflow -= int64(len(first.data))
+		l.size -= int64(len(first.data))
+		l.tail = l.tail[1:]
+	}
+}
+
+func (l *logLimiter) drop(out io.Writer, n int64) {
+	if n > 0 {
+		l.dropped += n
+		l.droppedFrom = out
+	}
+}
+
+// buffered returns the marker recording the dropped output, if any, and the
+// tail in order.
+func (l *logLimiter) buffered() []segment {
+	var ret []segment
+	if l.dropped > 0 {
+		out := l.droppedFrom
+		if len(l.tail) > 0 {
+			out = l.tail[0].out
+		}
+		ret = append(ret, segment{out: out, data: []byte(fmt.Sprintf("\n[... %d bytes of output truncated by the log limit of the step ...]\n", l.dropped))})
+	}
+	return append(ret, l.tail...)
+}
+
+// syncTailFile replaces the content of the tail file with the buffered tail.
+func (l *logLimiter) syncTailFile() error {
+	if err := l.tailFile.Truncate(0); err != nil {
+		return err
+	}
+	var offset int64
+	for _, chunk := range l.buffered() {
+		n, err := l.tailFile.WriteAt(chunk.data, offset)
+		if err != nil {
+			return err
+		}
+		offset += int64(n)
+	}
+	l.dirty = false
+	return nil
+}
+
+// Sync brings the tail file up to date with output buffered since the last
+// time it was.
+func (l *logLimiter) Sync() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.tailFile == nil || !l.dirty {
+		return nil
+	}
+	return l.syncTailFile()
+}
+
+// Flush writes the buffered tail of the output. The tail file is removed once
+// the tail is written, it is only needed when the wrapper is killed.
+func (l *logLimiter) Flush() error {
+	l.Lock()
+	defer l.Unlock()
+	for _, chunk := range l.buffered() {
+		if _, err := chunk.out.Write(chunk.data); err != nil {
+			return err
+		}
+	}
+	if l.tailFile == nil {
+		return nil
+	}
+	if err := l.tailFile.Close(); err != nil {
+		return err
+	}
+	return os.Remove(l.tailFile.Name())
+}
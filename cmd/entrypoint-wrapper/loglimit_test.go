@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogLimiter(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		head, tail int64
+		writes     []string
+		expected   string
+	}{
+		{
+			name:     "output within the limit",
+			head:     10,
+			tail:     10,
+			writes:   []string{"hello ", "world"},
+			expected: "hello world",
+		},
+		{
+			name:     "head and tail",
+			head:     4,
+			tail:     4,
+			writes:   []string{"ab", "cdef", "ghij", "kl"},
+			expected: "abcd\n[... 4 bytes of output truncated by the log limit of the step ...]\nijkl",
+		},
+		{
+			name:     "write larger than the tail",
+			head:     2,
+			tail:     3,
+			writes:   []string{"abcdefghij"},
+			expected: "ab\n[... 5 bytes of output truncated by the log limit of the step ...]\nhij",
+		},
+		{
+			name:     "tail wrapping around the buffer",
+			tail:     5,
+			writes:   []string{"abc", "def", "ghi", "j"},
+			expected: "\n[... 5 bytes of output truncated by the log limit of the step ...]\nfghij",
+		},
+		{
+			name:     "only head",
+			head:     3,
+			writes:   []string{"abcdef", "ghi"},
+			expected: "abc\n[... 6 bytes of output truncated by the log limit of the step ...]\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			limiter, err := newLogLimiter(tc.head, tc.tail, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			writer := limiter.Writer(out)
+			for _, w := range tc.writes {
+				if n, err := writer.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("unexpected write result: %d, %v", n, err)
+				}
+			}
+			if err := limiter.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("unexpected output: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLogLimiterSharedStreams(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	limiter, err := newLogLimiter(4, 6, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	outWriter, errWriter := limiter.Writer(stdout), limiter.Writer(stderr)
+	for _, w := range []struct {
+		writer io.Writer
+		data   string
+	}{
+		{writer: outWriter, data: "abc"},
+		{writer: errWriter, data: "ABC"},
+		{writer: outWriter, data: "defgh"},
+		{writer: errWriter, data: "DEF"},
+	} {
+		if _, err := w.writer.Write([]byte(w.data)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := limiter.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("abc\n[... 4 bytes of output truncated by the log limit of the step ...]\nfgh", stdout.String()); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+	if diff := cmp.Diff("ADEF", stderr.String()); diff != "" {
+		t.Errorf("unexpected error output: %s", diff)
+	}
+}
+
+func TestLogLimiterTailFile(t *testing.T) {
+	out := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "tail.txt")
+	limiter, err := newLogLimiter(2, 4, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writer := limiter.Writer(out)
+	for _, w := range []string{"abcdef", "ghij"} {
+		if _, err := writer.Write([]byte(w)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := limiter.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the tail file: %v", err)
+	}
+	if diff := cmp.Diff("\n[... 4 bytes of output truncated by the log limit of the step ...]\nghij", string(raw)); diff != "" {
+		t.Errorf("unexpected tail file: %s", diff)
+	}
+	// the file is only rewritten when output was buffered since
+	if err := os.WriteFile(path, []byte("untouched"), 0644); err != nil {
+		t.Fatalf("failed to write the tail file: %v", err)
+	}
+	if err := limiter.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw, err := os.ReadFile(path); err != nil || string(raw) != "untouched" {
+		t.Errorf("expected the tail file not to be rewritten, got %q, %v", raw, err)
+	}
+	if err := limiter.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the tail file to be removed, got %v", err)
+	}
+}
+
+func TestExecCmdBackgroundProcess(t *testing.T) {
+	waitDelay := outputWaitDelay
+	outputWaitDelay = 100 * time.Millisecond
+	t.Cleanup(func() { outputWaitDelay = waitDelay })
+	t.Setenv("ARTIFACT_DIR", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	o := &options{cmd: []string{"sh", "-c", "sleep 30 & echo started"}, logLimitHead: 10, logLimitTail: 10}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if code, err := o.execCmd(); err != nil || code != 0 {
+			t.Errorf("unexpected result: %d, %v", code, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the command did not return while a background process held its output")
+	}
+}
@@ -48,6 +48,10 @@ const (
 	errorCode            = 1
 )
 
+// outputWaitDelay is how long the output of the command is copied for after
+// it exits.
+var outputWaitDelay = 10 * time.Second
+
 func init() {
 	utilruntime.Must(coreapi.AddToScheme(coreScheme))
 	encoder = codecFactory.LegacyCodec(coreapi.SchemeGroupVersion)
@@ -82,6 +86,8 @@ type options struct {
 	rwKubeconfig     bool
 	uploadKubeconfig bool
 	updateSharedDir  bool
+	logLimitHead     int64
+	logLimitTail     int64
 	cmd              []string
 	client           coreclientset.SecretInterface
 }
@@ -92,6 +98,8 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.waitPath, "wait-for-file", "", "Wait for a file to appear at this path before starting the program")
 	flag.StringVar(&opt.waitTimeoutStr, "wait-timeout", "", "Used with --wait-for-file, maximum wait time before starting the program")
 	flag.StringVar(&opt.mode, "mode", manageKubeconfigMode, fmt.Sprintf("Set how kubeconfig should be managed. Allowed values are: %s, %s or %s", manageKubeconfigMode, skipKubeconfigMode, observerMode))
+	flag.Int64Var(&opt.logLimitHead, "log-limit-head", 0, "Bytes kept from the start of the output and error output of the program together when it is limited")
+	flag.Int64Var(&opt.logLimitTail, "log-limit-tail", 0, "Bytes kept from the end of the output and error output of the program together when it is limited")
 	return opt
 }
 
@@ -99,6 +107,9 @@ func (o *options) complete() error {
 	if len(o.cmd) == 0 {
 		return fmt.Errorf("a command is required")
 	}
+	if o.logLimitHead < 0 || o.logLimitTail < 0 {
+		return fmt.Errorf("--log-limit-head and --log-limit-tail must not be negative")
+	}
 	if w := o.waitTimeoutStr; w != "" {
 		if o.waitPath == "" {
 			return fmt.Errorf("--wait-timeout requires --wait-for-file")
@@ -273,6 +284,23 @@ func (o *options) execCmd() (exitCode int, err error) {
 	proc := exec.Command(argv[0], argv[1:]...)
 	proc.Stdout = os.Stdout
 	proc.Stderr = os.Stderr
+	if o.logLimitHead > 0 || o.logLimitTail > 0 {
+		// the output is copied by goroutines when it is limited, which would
+		// wait for background processes the command leaves holding it open
+		proc.WaitDelay = outputWaitDelay
+		limiter, err := o.limitOutput(proc)
+		if err != nil {
+			return errorCode, err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go syncTailFile(ctx, limiter)
+		defer func() {
+			cancel()
+			if err := limiter.Flush(); err != nil {
+				logrus.WithError(err).Error("Failed to write the end of the output")
+			}
+		}()
+	}
 	if proc.Env == nil {
 		// the command inherits the environment if it's nil,
 		// explicitly set it so when we change it, we add to
@@ -311,9 +339,45 @@ func (o *options) execCmd() (exitCode int, err error) {
 	}()
 	// we have to Wait() for the process before we can call ExitCode()
 	err = proc.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		logrus.Warn("Processes started by the command kept its output open after it exited, the output they wrote since was dropped")
+		err = nil
+	}
 	return proc.ProcessState.ExitCode(), err
 }
 
+// limitOutput caps the output and the error output of the command, which
+// share the limit. The tail is kept in a file of the artifact directory while
+// the command runs, so that it is uploaded even when the wrapper is killed.
+func (o *options) limitOutput(proc *exec.Cmd) (*logLimiter, error) {
+	var tailFile string
+	if dir := os.Getenv("ARTIFACT_DIR"); dir != "" {
+		tailFile = filepath.Join(dir, "log-tail.txt")
+	}
+	limiter, err := newLogLimiter(o.logLimitHead, o.logLimitTail, tailFile)
+	if err != nil {
+		return nil, err
+	}
+	proc.Stdout, proc.Stderr = limiter.Writer(os.Stdout), limiter.Writer(os.Stderr)
+	return limiter, nil
+}
+
+// syncTailFile keeps the tail file up to date until the context is done.
+func syncTailFile(ctx context.Context, limiter *logLimiter) {
+	ticker := time.NewTicker(tailSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := limiter.Sync(); err != nil {
+				logrus.WithError(err).Warn("Failed to keep the end of the output in the artifacts")
+			}
+		}
+	}
+}
+
 // manageCLI configures the PATH to include a CLI_DIR if one was provided
 func manageCLI(proc *exec.Cmd) {
 	cliDir, set := os.LookupEnv(api.CliEnv)
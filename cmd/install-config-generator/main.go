@@ -0,0 +1,134 @@
+// install-config-generator composes the install-config.yaml of the cluster
+// under test in the shared directory. It runs as the typed `install_config`
+// step of multi-stage tests, merging YAML patches parameterized by the step
+// into a base configuration and validating the result against the schema of
+// the installer.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/installconfig"
+)
+
+type options struct {
+	sharedDir   string
+	artifactDir string
+	profileDir  string
+	base        string
+	patchesPath string
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("install-config-generator", flag.ContinueOnError)
+	fs.StringVar(&o.sharedDir, "shared-dir", os.Getenv("SHARED_DIR"), "Directory the install-config is written to, defaults to $SHARED_DIR")
+	fs.StringVar(&o.artifactDir, "artifact-dir", os.Getenv("ARTIFACT_DIR"), "Directory where a copy of the install-config without secrets is written, defaults to $ARTIFACT_DIR")
+	fs.StringVar(&o.profileDir, "profile-dir", os.Getenv("CLUSTER_PROFILE_DIR"), "Directory holding the cluster profile, defaults to $CLUSTER_PROFILE_DIR")
+	fs.StringVar(&o.base, "base", string(api.InstallConfigBaseProfile), "What the install-config is composed on top of: profile or shared_dir")
+	fs.StringVar(&o.patchesPath, "patches", "", "Path to a JSON list of YAML patches merged into the install-config in order")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.sharedDir == "" {
+		errs = append(errs, errors.New("--shared-dir is required when $SHARED_DIR is not set"))
+	}
+	switch api.InstallConfigBase(o.base) {
+	case api.InstallConfigBaseProfile:
+		if o.profileDir == "" {
+			errs = append(errs, errors.New("--profile-dir is required when $CLUSTER_PROFILE_DIR is not set"))
+		}
+	case api.InstallConfigBaseSharedDir:
+	default:
+		errs = append(errs, fmt.Errorf("--base must be one of %s or %s", api.InstallConfigBaseProfile, api.InstallConfigBaseSharedDir))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func readPatches(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patches: %w", err)
+	}
+	var patches []string
+	if err := json.Unmarshal(raw, &patches); err != nil {
+		return nil, fmt.Errorf("failed to parse patches: %w", err)
+	}
+	return patches, nil
+}
+
+func run(o options, patches []string, getenv func(string) string, lookup func(string) (string, bool)) error {
+	var config installconfig.Config
+	path := filepath.Join(o.sharedDir, installconfig.Filename)
+	if api.InstallConfigBase(o.base) == api.InstallConfigBaseSharedDir {
+		var err error
+		if config, err = installconfig.Load(path); err != nil {
+			return err
+		}
+	} else {
+		profile, err := installconfig.LoadProfile(o.profileDir, getenv)
+		if err != nil {
+			return err
+		}
+		config = installconfig.Skeleton(profile)
+	}
+	if err := config.Apply(patches, lookup); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid install-config: %w", err)
+	}
+	raw, err := config.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal install-config: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write install-config: %w", err)
+	}
+	if o.artifactDir != "" {
+		raw, err := config.Redacted().Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal redacted install-config: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(o.artifactDir, installconfig.Filename), raw, 0644); err != nil {
+			return fmt.Errorf("failed to write redacted install-config: %w", err)
+		}
+	}
+	return nil
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	patches, err := readPatches(o.patchesPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load patches")
+	}
+	if err := run(o, patches, os.Getenv, os.LookupEnv); err != nil {
+		logrus.WithError(err).Fatal("failed to compose the install-config")
+	}
+	logrus.Infof("Wrote %s with %d patches applied.", filepath.Join(o.sharedDir, installconfig.Filename), len(patches))
+}
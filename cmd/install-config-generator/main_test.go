@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	profileDir, sharedDir, artifactDir := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(profileDir, "pull-secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"NAMESPACE": "ci-op-abc", "UNIQUE_HASH": "xyz", "CLUSTER_TYPE": "aws", "LEASED_RESOURCE": "us-east-1", "BASE_DOMAIN": "ci.example.com", "REPLICAS": "1"}
+	getenv := func(name string) string { return env[name] }
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	o := options{sharedDir: sharedDir, artifactDir: artifactDir, profileDir: profileDir, base: "profile"}
+	if err := run(o, []string{"controlPlane:\n  replicas: ${REPLICAS}\n"}, getenv, lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o.base = "shared_dir"
+	if err := run(o, []string{"networking:\n  networkType: OVNKubernetes\n"}, getenv, lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `apiVersion: v1
+baseDomain: ci.example.com
+controlPlane:
+  replicas: 1
+metadata:
+  name: ci-op-abc-xyz
+networking:
+  networkType: OVNKubernetes
+platform:
+  aws:
+    region: us-east-1
+pullSecret: %s
+`
+	for dir, pullSecret := range map[string]string{sharedDir: "secret", artifactDir: "REDACTED"} {
+		raw, err := os.ReadFile(filepath.Join(dir, "install-config.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(strings.Replace(expected, "%s", pullSecret, 1), string(raw)); diff != "" {
+			t.Errorf("unexpected install-config in %s: %s", dir, diff)
+		}
+	}
+
+	if err := run(o, []string{"platform:\n  gcp: {}\n"}, getenv, lookup); err == nil {
+		t.Error("expected an invalid install-config to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+// leak-detector lists the cloud resources of a cluster which survived its
+// teardown. It runs as the last `post` step of multi-stage tests whose cluster
+// profile enables leak detection, reports leaks as a flaky jUnit test case and
+// writes a manifest of the leaked resources for the reaper. Leaks never fail
+// the step.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/leaks"
+)
+
+type options struct {
+	sharedDir   string
+	artifactDir string
+	profileDir  string
+	tag         string
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("leak-detector", flag.ContinueOnError)
+	fs.StringVar(&o.sharedDir, "shared-dir", os.Getenv("SHARED_DIR"), "Directory holding the metadata.json of the cluster, defaults to $SHARED_DIR")
+	fs.StringVar(&o.artifactDir, "artifact-dir", os.Getenv("ARTIFACT_DIR"), "Directory where the manifest and jUnit are written, defaults to $ARTIFACT_DIR")
+	fs.StringVar(&o.profileDir, "profile-dir", os.Getenv("CLUSTER_PROFILE_DIR"), "Directory holding the cloud credentials, defaults to $CLUSTER_PROFILE_DIR")
+	fs.StringVar(&o.tag, "tag", "", "Key of the tag or label identifying the resources of the cluster, ${CLUSTER_NAME} is replaced with its infrastructure name")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.sharedDir == "" {
+		return errors.New("--shared-dir is required when $SHARED_DIR is not set")
+	}
+	if o.artifactDir == "" {
+		return errors.New("--artifact-dir is required when $ARTIFACT_DIR is not set")
+	}
+	return nil
+}
+
+type scannerFactory func(ctx context.Context, provider leaks.Provider, metadata *leaks.Metadata) (leaks.Scanner, error)
+
+func newScanner(ctx context.Context, provider leaks.Provider, metadata *leaks.Metadata) (leaks.Scanner, error) {
+	if provider == leaks.ProviderGCP {
+		return leaks.NewGCPScanner(ctx, metadata.GCP.ProjectID)
+	}
+	return leaks.NewAWSScanner(ctx, metadata.AWS.Region)
+}
+
+// credentialFiles are the files in the cluster profile holding the credentials
+// for each provider, used unless the step is already configured otherwise,
+// e.g. with workload identity.
+var credentialFiles = map[leaks.Provider]struct{ env, file string }{
+	leaks.ProviderAWS: {env: "AWS_SHARED_CREDENTIALS_FILE", file: ".awscred"},
+	leaks.ProviderGCP: {env: "GOOGLE_APPLICATION_CREDENTIALS", file: "gce.json"},
+}
+
+func configureCredentials(provider leaks.Provider, profileDir string) error {
+	if profileDir == "" || os.Getenv("AWS_ROLE_ARN") != "" {
+		return nil
+	}
+	creds := credentialFiles[provider]
+	if os.Getenv(creds.env) != "" {
+		return nil
+	}
+	path := filepath.Join(profileDir, creds.file)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	return os.Setenv(creds.env, path)
+}
+
+func run(ctx context.Context, o options, factory scannerFactory) error {
+	metadataPath := filepath.Join(o.sharedDir, "metadata.json")
+	metadata, err := leaks.ReadMetadata(metadataPath)
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Infof("No cluster metadata found at %s, nothing to scan.", metadataPath)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	provider, err := metadata.Provider()
+	if err != nil {
+		logrus.WithError(err).Warn("Not scanning for leaked resources.")
+		return nil
+	}
+	tag := o.tag
+	if tag == "" {
+		tag = leaks.DefaultTag(provider)
+	}
+	manifest := &leaks.Manifest{
+		Cluster:   metadata.InfraID,
+		Provider:  provider,
+		Tag:       leaks.TagFor(tag, metadata.InfraID),
+		ScannedAt: time.Now().UTC(),
+	}
+	if provider == leaks.ProviderGCP {
+		manifest.Project = metadata.GCP.ProjectID
+	} else {
+		manifest.Region = metadata.AWS.Region
+	}
+	if err := configureCredentials(provider, o.profileDir); err != nil {
+		return fmt.Errorf("failed to configure credentials: %w", err)
+	}
+	logrus.Infof("Scanning %s for resources tagged with %s.", provider, manifest.Tag)
+	scanner, scanErr := factory(ctx, provider, metadata)
+	if scanErr == nil {
+		scanErr = leaks.Scan(ctx, scanner, manifest)
+	}
+	if scanErr != nil {
+		logrus.WithError(scanErr).Warn("Could not scan for leaked resources.")
+	} else {
+		logrus.Infof("Found %d leaked resources.", len(manifest.Resources))
+		raw, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(o.artifactDir, leaks.ManifestFile), raw, 0644); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	raw, err := xml.MarshalIndent(leaks.JUnit(manifest, scanErr), "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal jUnit: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(o.artifactDir, leaks.JUnitFile), raw, 0644); err != nil {
+		return fmt.Errorf("failed to write jUnit: %w", err)
+	}
+	return nil
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	if err := run(context.Background(), o, newScanner); err != nil {
+		logrus.WithError(err).Fatal("failed to detect leaked resources")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/openshift/ci-tools/pkg/leaks"
+)
+
+type fakeScanner struct {
+	resources []leaks.Resource
+	tag       *string
+}
+
+func (s fakeScanner) Scan(_ context.Context, tag string) ([]leaks.Resource, error) {
+	*s.tag = tag
+	return s.resources, nil
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		metadata         string
+		tag              string
+		expectedTag      string
+		expectedManifest *leaks.Manifest
+		expectJUnit      bool
+	}{
+		{
+			name: "no cluster",
+		},
+		{
+			name:     "unsupported platform",
+			metadata: `{"infraID":"ci-op-abc-xyz","azure":{"region":"eastus"}}`,
+		},
+		{
+			name:        "aws",
+			metadata:    `{"infraID":"ci-op-abc-xyz","aws":{"region":"us-east-1"}}`,
+			expectedTag: "kubernetes.io/cluster/ci-op-abc-xyz",
+			expectedManifest: &leaks.Manifest{
+				Cluster:   "ci-op-abc-xyz",
+				Provider:  leaks.ProviderAWS,
+				Tag:       "kubernetes.io/cluster/ci-op-abc-xyz",
+				Region:    "us-east-1",
+				Resources: []leaks.Resource{{Type: "volume", ID: "vol-1"}},
+			},
+			expectJUnit: true,
+		},
+		{
+			name:        "gcp with custom label",
+			metadata:    `{"infraID":"ci-op-abc-xyz","gcp":{"region":"us-east1","projectID":"ci-project"}}`,
+			tag:         "owner-${CLUSTER_NAME}",
+			expectedTag: "owner-ci-op-abc-xyz",
+			expectedManifest: &leaks.Manifest{
+				Cluster:   "ci-op-abc-xyz",
+				Provider:  leaks.ProviderGCP,
+				Tag:       "owner-ci-op-abc-xyz",
+				Project:   "ci-project",
+				Resources: []leaks.Resource{{Type: "volume", ID: "vol-1"}},
+			},
+			expectJUnit: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sharedDir, artifactDir := t.TempDir(), t.TempDir()
+			if tc.metadata != "" {
+				if err := os.WriteFile(filepath.Join(sharedDir, "metadata.json"), []byte(tc.metadata), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var tag string
+			factory := func(context.Context, leaks.Provider, *leaks.Metadata) (leaks.Scanner, error) {
+				return fakeScanner{resources: []leaks.Resource{{Type: "volume", ID: "vol-1"}}, tag: &tag}, nil
+			}
+			o := options{sharedDir: sharedDir, artifactDir: artifactDir, tag: tc.tag}
+			if err := run(context.Background(), o, factory); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			if tag != tc.expectedTag {
+				t.Errorf("expected tag %q, got %q", tc.expectedTag, tag)
+			}
+			var manifest *leaks.Manifest
+			if raw, err := os.ReadFile(filepath.Join(artifactDir, leaks.ManifestFile)); err == nil {
+				manifest = &leaks.Manifest{}
+				if err := json.Unmarshal(raw, manifest); err != nil {
+					t.Fatal(err)
+				}
+			} else if !errors.Is(err, os.ErrNotExist) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectedManifest, manifest, cmpopts.IgnoreFields(leaks.Manifest{}, "ScannedAt")); diff != "" {
+				t.Errorf("unexpected manifest: %s", diff)
+			}
+			_, err := os.Stat(filepath.Join(artifactDir, leaks.JUnitFile))
+			if hasJUnit := err == nil; hasJUnit != tc.expectJUnit {
+				t.Errorf("expected jUnit to be written: %v, got %v", tc.expectJUnit, hasJUnit)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/ocpbuilddata"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/steps/release"
+)
+
+const (
+	// registry is where the images promoted by ci-operator are published,
+	// ocp-build-data refers to them with pull specs for this registry
+	registry = "registry.ci.openshift.org"
+	// builderPrefix identifies the golang builder images, which are used as
+	// build roots in ci-operator
+	builderPrefix = registry + "/ocp/builder:"
+)
+
+// drift is a difference between how an image is built in CI and how it is
+// built for the product.
+type drift struct {
+	// Image is the pull spec the image is promoted to.
+	Image string
+	// Config is the ci-operator configuration building the image, unset when
+	// the image is not built in CI.
+	Config string
+	// Field is the aspect of the build which differs.
+	Field string
+	// CI is the value in the ci-operator configuration.
+	CI string
+	// OCPBuildData is the value in ocp-build-data.
+	OCPBuildData string
+
+	// config is the ci-operator configuration building the image.
+	config *config.DataWithInfo
+	// fix updates the ci-operator configuration to match ocp-build-data, it
+	// is unset when the drift cannot be fixed automatically.
+	fix func()
+}
+
+const (
+	fieldImage      = "image"
+	fieldRepository = "repository"
+	fieldDockerfile = "dockerfile"
+	fieldBuildRoot  = "build_root"
+)
+
+// ciImage is an image built by a ci-operator configuration and promoted into
+// the release.
+type ciImage struct {
+	config *config.DataWithInfo
+	image  *api.ProjectDirectoryImageBuildStepConfiguration
+}
+
+// promotedImages indexes the images promoted into the `ocp/<version>` stream
+// by the pull spec they are promoted to.
+func promotedImages(configs []config.DataWithInfo, version string) map[string][]ciImage {
+	promoted := map[string][]ciImage{}
+	for i := range configs {
+		c := &configs[i]
+		byName := map[string]*api.ProjectDirectoryImageBuildStepConfiguration{}
+		for j := range c.Configuration.Images {
+			byName[string(c.Configuration.Images[j].To)] = &c.Configuration.Images[j]
+		}
+		tags, _ := release.PromotedTagsWithRequiredImages(&c.Configuration)
+		for source, targets := range tags {
+			image, ok := byName[source]
+			if !ok {
+				// additional images promoted without being built
+				continue
+			}
+			for _, target := range targets {
+				if target.Namespace != "ocp" || target.Name != version {
+					continue
+				}
+				pullSpec := fmt.Sprintf("%s/%s", registry, target.ISTagName())
+				promoted[pullSpec] = append(promoted[pullSpec], ciImage{config: c, image: image})
+			}
+		}
+	}
+	return promoted
+}
+
+// dockerfilePath joins a context directory and the Dockerfile in it, which
+// is named `Dockerfile` when unset.
+func dockerfilePath(contextDir, dockerfile string) string {
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	return path.Join(contextDir, dockerfile)
+}
+
+// findDrift compares the images of the release defined in ocp-build-data to
+// the ones built and promoted by the ci-operator configurations: it reports
+// images not built in CI at all, built from a different repository or a
+// different Dockerfile and built with a build root which is none of the
+// golang builders used for the product.
+func findDrift(ocpConfigs []ocpbuilddata.OCPImageConfig, configs []config.DataWithInfo, version string) []drift {
+	promoted := promotedImages(configs, version)
+	var drifts []drift
+	for i := range ocpConfigs {
+		ocpConfig := &ocpConfigs[i]
+		pullSpec := ocpConfig.PromotesTo()
+		images, ok := promoted[pullSpec]
+		if !ok {
+			drifts = append(drifts, drift{Image: pullSpec, Field: fieldImage, CI: "<none>", OCPBuildData: ocpConfig.SourceFileName})
+			continue
+		}
+		for _, ci := range images {
+			drifts = append(drifts, compare(ocpConfig, ci, pullSpec)...)
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Image != drifts[j].Image {
+			return drifts[i].Image < drifts[j].Image
+		}
+		if drifts[i].Config != drifts[j].Config {
+			return drifts[i].Config < drifts[j].Config
+		}
+		return drifts[i].Field < drifts[j].Field
+	})
+	return drifts
+}
+
+func compare(ocpConfig *ocpbuilddata.OCPImageConfig, ci ciImage, pullSpec string) []drift {
+	var drifts []drift
+	metadata := ci.config.Info.Metadata
+	newDrift := func(field, ciValue, ocpValue string) drift {
+		return drift{Image: pullSpec, Config: metadata.AsString(), Field: field, CI: ciValue, OCPBuildData: ocpValue, config: ci.config}
+	}
+	if ocpConfig.PublicRepo.Org != "" && ocpConfig.PublicRepo.String() != fmt.Sprintf("%s/%s", metadata.Org, metadata.Repo) {
+		drifts = append(drifts, newDrift(fieldRepository, fmt.Sprintf("%s/%s", metadata.Org, metadata.Repo), ocpConfig.PublicRepo.String()))
+	}
+	if ocpConfig.Content != nil {
+		source := ocpConfig.Content.Source
+		expected := dockerfilePath(source.Path, source.Dockerfile)
+		switch {
+		case ci.image.DockerfileLiteral != nil:
+			drifts = append(drifts, newDrift(fieldDockerfile, "<dockerfile_literal>", expected))
+		case dockerfilePath(ci.image.ContextDir, ci.image.DockerfilePath) != expected:
+			d := newDrift(fieldDockerfile, dockerfilePath(ci.image.ContextDir, ci.image.DockerfilePath), expected)
+			image := ci.image
+			d.fix = func() {
+				image.ContextDir, image.DockerfilePath = source.Path, source.Dockerfile
+			}
+			drifts = append(drifts, d)
+		}
+	}
+	buildRoot := ci.config.Configuration.BuildRootImage
+	if buildRoot != nil && buildRoot.ImageStreamTagReference != nil {
+		builders := sets.New[string]()
+		for _, builder := range ocpConfig.From.Builder {
+			if strings.HasPrefix(builder.Stream, builderPrefix) {
+				builders.Insert(builder.Stream)
+			}
+		}
+		if actual := fmt.Sprintf("%s/%s", registry, buildRoot.ImageStreamTagReference.ISTagName()); builders.Len() > 0 && !builders.Has(actual) {
+			drifts = append(drifts, newDrift(fieldBuildRoot, actual, strings.Join(sets.List(builders), ", ")))
+		}
+	}
+	return drifts
+}
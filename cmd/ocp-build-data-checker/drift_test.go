@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/ocpbuilddata"
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+func TestFindDrift(t *testing.T) {
+	ocpConfig := func(name, repo, contextDir, dockerfile string, builders ...string) ocpbuilddata.OCPImageConfig {
+		c := ocpbuilddata.OCPImageConfig{
+			Name:           "openshift/ose-" + name,
+			SourceFileName: "images/ose-" + name + ".yml",
+			Version:        ocpbuilddata.MajorMinor{Major: "4", Minor: "15"},
+			PublicRepo:     ocpbuilddata.OrgRepo{Org: "openshift", Repo: repo},
+			Content:        &ocpbuilddata.OCPImageConfigContent{Source: ocpbuilddata.OCPImageConfigSource{Path: contextDir, Dockerfile: dockerfile}},
+		}
+		for _, builder := range builders {
+			c.From.Builder = append(c.From.Builder, ocpbuilddata.OCPImageConfigFromStream{Stream: builder})
+		}
+		return c
+	}
+	ciConfig := func(repo, buildRoot string, images ...api.ProjectDirectoryImageBuildStepConfiguration) config.DataWithInfo {
+		metadata := api.Metadata{Org: "openshift", Repo: repo, Branch: "master"}
+		return config.DataWithInfo{
+			Configuration: api.ReleaseBuildConfiguration{
+				Metadata: metadata,
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: buildRoot}},
+				},
+				Images:                 images,
+				PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ocp", Name: "4.15"}}},
+			},
+			Info: config.Info{Metadata: metadata, Filename: "openshift-" + repo + "-master.yaml"},
+		}
+	}
+	image := func(to, contextDir, dockerfile string) api.ProjectDirectoryImageBuildStepConfiguration {
+		return api.ProjectDirectoryImageBuildStepConfiguration{
+			To:                               api.PipelineImageStreamTagReference(to),
+			ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{ContextDir: contextDir, DockerfilePath: dockerfile},
+		}
+	}
+	const golang = "registry.ci.openshift.org/ocp/builder:rhel-9-golang-1.21-openshift-4.15"
+	ocpConfigs := []ocpbuilddata.OCPImageConfig{
+		ocpConfig("operator", "operator", "", "Dockerfile.rhel", golang, "registry.ci.openshift.org/ocp/4.15:base"),
+		ocpConfig("operator-tests", "operator", "tests", "", golang),
+		ocpConfig("installer", "installer", "images/installer", "Dockerfile.ci"),
+		ocpConfig("missing", "missing", "", ""),
+	}
+	configs := []config.DataWithInfo{
+		ciConfig("operator", "rhel-8-golang-1.20-openshift-4.15",
+			image("operator", "", "Dockerfile.rhel"),
+			image("operator-tests", "", "tests/Dockerfile"),
+		),
+		ciConfig("other-installer", "rhel-9-golang-1.21-openshift-4.15",
+			image("installer", "images/installer", "Dockerfile.rhel"),
+		),
+	}
+
+	drifts := findDrift(ocpConfigs, configs, "4.15")
+	expected := []drift{
+		{Image: "registry.ci.openshift.org/ocp/4.15:installer", Config: "openshift/other-installer@master", Field: "dockerfile", CI: "images/installer/Dockerfile.rhel", OCPBuildData: "images/installer/Dockerfile.ci"},
+		{Image: "registry.ci.openshift.org/ocp/4.15:installer", Config: "openshift/other-installer@master", Field: "repository", CI: "openshift/other-installer", OCPBuildData: "openshift/installer"},
+		{Image: "registry.ci.openshift.org/ocp/4.15:missing", Field: "image", CI: "<none>", OCPBuildData: "images/ose-missing.yml"},
+		{Image: "registry.ci.openshift.org/ocp/4.15:operator", Config: "openshift/operator@master", Field: "build_root", CI: "registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.20-openshift-4.15", OCPBuildData: golang},
+		{Image: "registry.ci.openshift.org/ocp/4.15:operator-tests", Config: "openshift/operator@master", Field: "build_root", CI: "registry.ci.openshift.org/ocp/builder:rhel-8-golang-1.20-openshift-4.15", OCPBuildData: golang},
+	}
+	if diff := cmp.Diff(expected, drifts, cmpopts.IgnoreUnexported(drift{})); diff != "" {
+		t.Fatalf("unexpected drift: %s", diff)
+	}
+
+	for _, d := range drifts {
+		if d.fix != nil {
+			d.fix()
+		}
+	}
+	expectedImages := []api.ProjectDirectoryImageBuildStepConfiguration{image("installer", "images/installer", "Dockerfile.ci")}
+	if diff := cmp.Diff(expectedImages, configs[1].Configuration.Images, cmpopts.IgnoreUnexported(api.ProjectDirectoryImageBuildStepConfiguration{})); diff != "" {
+		t.Errorf("unexpected images after fixing: %s", diff)
+	}
+	if drifts := findDrift(ocpConfigs, configs, "4.15"); len(drifts) != 4 {
+		t.Errorf("expected only the drift which cannot be fixed to remain, got %d", len(drifts))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/ocpbuilddata"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/github/prcreation"
+)
+
+type options struct {
+	configDir           string
+	ocpBuildDataRepoDir string
+	majorMinor          ocpbuilddata.MajorMinor
+	fix                 bool
+	createPR            bool
+	*prcreation.PRCreationOptions
+}
+
+func gatherOptions() (*options, error) {
+	o := &options{PRCreationOptions: &prcreation.PRCreationOptions{}}
+	o.PRCreationOptions.AddFlags(flag.CommandLine)
+	flag.StringVar(&o.configDir, "config-dir", "", "The directory with the ci-operator configs")
+	flag.StringVar(&o.ocpBuildDataRepoDir, "ocp-build-data-repo-dir", "../ocp-build-data", "The directory in which the ocp-build-data repository is")
+	flag.StringVar(&o.majorMinor.Minor, "minor", "", "The minor version to target")
+	flag.BoolVar(&o.fix, "fix", false, "If the ci-operator configs should be updated to match ocp-build-data where possible")
+	flag.BoolVar(&o.createPR, "create-pr", false, "If the tool should create a PR with the updated ci-operator configs, implies --fix")
+	flag.Parse()
+
+	var errs []error
+	if o.configDir == "" {
+		errs = append(errs, errors.New("--config-dir is mandatory"))
+	}
+	if o.majorMinor.Minor == "" {
+		errs = append(errs, errors.New("--minor is mandatory"))
+	}
+	if o.createPR {
+		o.fix = true
+		if err := o.PRCreationOptions.Finalize(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to finalize pr creation options: %w", err))
+		}
+	}
+	o.majorMinor.Major = "4"
+	o.ocpBuildDataRepoDir = filepath.Clean(o.ocpBuildDataRepoDir)
+	return o, utilerrors.NewAggregate(errs)
+}
+
+func printReport(out io.Writer, drifts []drift) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tCONFIG\tFIELD\tCI\tOCP-BUILD-DATA\tFIXABLE")
+	for _, d := range drifts {
+		config := d.Config
+		if config == "" {
+			config = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", d.Image, config, d.Field, d.CI, d.OCPBuildData, d.fix != nil)
+	}
+	return w.Flush()
+}
+
+func main() {
+	logrus.StandardLogger().SetFormatter(&logrus.TextFormatter{EnvironmentOverrideColors: true})
+	opts, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to gather options")
+	}
+
+	ocpConfigs, err := ocpbuilddata.LoadImageConfigs(opts.ocpBuildDataRepoDir, opts.majorMinor)
+	if err != nil {
+		// images which cannot be loaded are not compared, the rest still are
+		var agg utilerrors.Aggregate
+		if errors.As(err, &agg) && len(ocpConfigs) > 0 {
+			for _, err := range agg.Errors() {
+				logrus.WithError(err).Warn("Ignoring ocp-build-data image config")
+			}
+		} else {
+			logrus.WithError(err).Fatal("Failed to load ocp-build-data image configs")
+		}
+	}
+
+	var configs []config.DataWithInfo
+	if err := config.OperateOnCIOperatorConfigDir(opts.configDir, func(c *api.ReleaseBuildConfiguration, info *config.Info) error {
+		configs = append(configs, config.DataWithInfo{Configuration: *c, Info: *info})
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to load ci-operator configs")
+	}
+
+	drifts := findDrift(ocpConfigs, configs, opts.majorMinor.String())
+	if err := printReport(os.Stdout, drifts); err != nil {
+		logrus.WithError(err).Fatal("Failed to print the drift report")
+	}
+	logrus.Infof("Found %d differences between ci-operator configs and ocp-build-data for %d images", len(drifts), len(ocpConfigs))
+	if !opts.fix {
+		return
+	}
+
+	fixed := map[*config.DataWithInfo]bool{}
+	for _, d := range drifts {
+		if d.fix != nil {
+			d.fix()
+			fixed[d.config] = true
+		}
+	}
+	for c := range fixed {
+		if err := c.CommitTo(opts.configDir); err != nil {
+			logrus.WithError(err).Fatal("Failed to write the updated ci-operator config")
+		}
+	}
+	logrus.Infof("Updated %d ci-operator configs", len(fixed))
+	if !opts.createPR {
+		return
+	}
+
+	if err := opts.PRCreationOptions.UpsertPR(
+		opts.configDir,
+		"openshift",
+		"release",
+		"master",
+		"Align ci-operator configs with ocp-build-data",
+		prcreation.PrBody(strings.Join([]string{
+			"This PR is autogenerated by the [ocp-build-data-checker][1].",
+			"It updates the Dockerfiles of the images promoted into the release to match",
+			"the ones used for producing release artifacts in the [ocp-build-data repository][2].",
+			"",
+			"[1]: https://github.com/openshift/ci-tools/tree/master/cmd/ocp-build-data-checker",
+			"[2]: https://github.com/openshift-eng/ocp-build-data",
+		}, "\n")),
+	); err != nil {
+		logrus.WithError(err).Fatal("Failed to create PR")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/prow/pkg/repoowners"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/autodisable"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/configmutation"
+	"github.com/openshift/ci-tools/pkg/github/prcreation"
+)
+
+type options struct {
+	prcreation.PRCreationOptions
+	configDir          string
+	gcsBucket          string
+	gcsCredentialsFile string
+	deprioritizeAfter  int
+	disableAfter       int
+	interval           time.Duration
+	ownerGracePeriod   time.Duration
+	confirm            bool
+	createPRs          bool
+	releaseRepo        string
+	prOrg              string
+	prRepo             string
+	prBranch           string
+}
+
+func gatherOptions() options {
+	o := options{}
+	o.PRCreationOptions.AddFlags(flag.CommandLine)
+	flag.StringVar(&o.configDir, "config-dir", "", "Path to the CI Operator configuration directory")
+	flag.StringVar(&o.gcsBucket, "gcs-bucket", "test-platform-results", "GCS bucket holding the results of the jobs")
+	flag.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored")
+	flag.IntVar(&o.deprioritizeAfter, "deprioritize-after", 30, "Number of consecutive failures after which a periodic is deprioritized")
+	flag.IntVar(&o.disableAfter, "disable-after", 45, "Number of consecutive failures after which a deprioritized periodic is disabled")
+	flag.DurationVar(&o.interval, "deprioritized-interval", 7*24*time.Hour, "Interval deprioritized periodics run on")
+	flag.DurationVar(&o.ownerGracePeriod, "owner-grace-period", 14*24*time.Hour, "Time the owners of a deprioritized periodic have to respond before it is disabled")
+	flag.BoolVar(&o.confirm, "confirm", false, "Write the changed configurations")
+	flag.BoolVar(&o.createPRs, "create-prs", false, "Open a pull request per organization with the changed configurations, notifying the owners of the jobs. Requires --release-repo")
+	flag.StringVar(&o.releaseRepo, "release-repo", "", "Path to the git checkout containing --config-dir, which pull requests are created from")
+	flag.StringVar(&o.prOrg, "pr-org", "openshift", "Organization of the repository to open pull requests against")
+	flag.StringVar(&o.prRepo, "pr-repo", "release", "Name of the repository to open pull requests against")
+	flag.StringVar(&o.prBranch, "pr-branch", "master", "Branch to open pull requests against")
+	flag.Parse()
+	return o
+}
+
+func (o *options) policy() autodisable.Policy {
+	return autodisable.Policy{DeprioritizeAfter: o.deprioritizeAfter, DisableAfter: o.disableAfter, Interval: o.interval, OwnerGracePeriod: o.ownerGracePeriod}
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.configDir == "" {
+		errs = append(errs, errors.New("--config-dir is required"))
+	}
+	if o.gcsCredentialsFile == "" {
+		errs = append(errs, errors.New("--gcs-credentials-file is required"))
+	}
+	if err := o.policy().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid policy: %w", err))
+	}
+	if o.createPRs {
+		if o.confirm {
+			errs = append(errs, errors.New("--create-prs and --confirm are mutually exclusive"))
+		}
+		if o.releaseRepo == "" {
+			errs = append(errs, errors.New("--release-repo is required when --create-prs is set"))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// decide determines the action to take on every periodic generated from the
+// configurations, based on its most recent results.
+func decide(ctx context.Context, configDir string, results *gcsResults, policy autodisable.Policy, now time.Time) ([]autodisable.Decision, error) {
+	var periodics []autodisable.Periodic
+	if err := config.OperateOnCIOperatorConfigDir(configDir, func(configuration *api.ReleaseBuildConfiguration, _ *config.Info) error {
+		periodics = append(periodics, autodisable.Periodics(configuration)...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load configurations: %w", err)
+	}
+	var decisions []autodisable.Decision
+	var errs []error
+	for _, periodic := range periodics {
+		recent, err := results.results(ctx, periodic.JobName(), policy.DisableAfter)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", periodic.JobName(), err))
+			continue
+		}
+		if decision := policy.Decide(periodic, autodisable.ConsecutiveFailures(recent, periodic.DeprioritizedAt), now); decision != nil {
+			logrus.WithField("job", periodic.JobName()).Infof("Job failed %d times in a row, will %s it.", decision.Failures, decision.Action)
+			decisions = append(decisions, *decision)
+		}
+	}
+	return decisions, utilerrors.NewAggregate(errs)
+}
+
+// owners reads the approvers of the configurations of a repository.
+func owners(configDir, org, repo string) []string {
+	raw, err := os.ReadFile(filepath.Join(configDir, org, repo, "OWNERS"))
+	if err != nil {
+		return nil
+	}
+	var cfg repoowners.Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		logrus.WithError(err).Warnf("Failed to parse the OWNERS of %s/%s.", org, repo)
+		return nil
+	}
+	return cfg.Approvers
+}
+
+// proposed filters the decisions down to those whose configurations changed.
+func proposed(decisions []autodisable.Decision, changes []configmutation.Change) []autodisable.Decision {
+	changed := map[api.Metadata]bool{}
+	for _, change := range changes {
+		changed[change.Info.Metadata] = true
+	}
+	var ret []autodisable.Decision
+	for _, decision := range decisions {
+		if changed[decision.Metadata] {
+			ret = append(ret, decision)
+		}
+	}
+	return ret
+}
+
+func prBody(configDir string, decisions []autodisable.Decision, policy autodisable.Policy) string {
+	lines := []string{
+		"This is an autogenerated PR that deprioritizes or disables periodic jobs that keep failing, so they stop using cloud resources without anyone looking at their results.",
+		fmt.Sprintf("Jobs failing %d times in a row are changed to run every %s. Deprioritized jobs still failing %d times in a row once their owners had %s to respond are disabled: they are marked with the `%s` annotation and no job is generated for them.", policy.DeprioritizeAfter, policy.Interval, policy.DisableAfter, policy.OwnerGracePeriod, api.DisabledAtAnnotation),
+		"If a job is still needed, please fix it and close this PR, or comment below. A disabled job is restored by removing the annotation from its test.",
+		"",
+		"| Job | Consecutive failures | Action |",
+		"| --- | --- | --- |",
+	}
+	approvers := map[string]bool{}
+	for _, decision := range decisions {
+		lines = append(lines, fmt.Sprintf("| `%s` | %d | %s |", decision.JobName(), decision.Failures, decision.Action))
+		for _, approver := range owners(configDir, decision.Org, decision.Repo) {
+			approvers[approver] = true
+		}
+	}
+	if len(approvers) != 0 {
+		var mentions []string
+		for approver := range approvers {
+			mentions = append(mentions, "@"+approver)
+		}
+		sort.Strings(mentions)
+		lines = append(lines, "", "/cc "+strings.Join(mentions, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	// creating pull requests changes the working directory
+	for _, dir := range []*string{&o.configDir, &o.releaseRepo} {
+		if *dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to determine the absolute path of %s.", *dir)
+		}
+		*dir = abs
+	}
+
+	ctx := context.Background()
+	gcsClient, err := storage.NewClient(ctx, option.WithCredentialsFile(o.gcsCredentialsFile))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create GCS client.")
+	}
+	policy := o.policy()
+	now := time.Now()
+	decisions, err := decide(ctx, o.configDir, &gcsResults{bucket: gcsClient.Bucket(o.gcsBucket)}, policy, now)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read the results of some jobs.")
+	}
+	changes, err := configmutation.Apply(o.configDir, autodisable.Mutation(decisions, policy, now))
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to change some configurations.")
+	}
+	for _, change := range changes {
+		diff, err := change.Diff()
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to diff %s.", change.Info.RelativePath())
+		}
+		fmt.Print(diff)
+	}
+
+	switch {
+	case o.confirm:
+		if err := configmutation.CommitAll(o.configDir, changes); err != nil {
+			logrus.WithError(err).Fatal("Failed to write configurations.")
+		}
+		logrus.Infof("Rewrote %d configurations.", len(changes))
+	case o.createPRs:
+		if err := o.PRCreationOptions.Finalize(); err != nil {
+			logrus.WithError(err).Fatal("Failed to set up pull request creation.")
+		}
+		proposal := configmutation.Proposal{
+			ReleaseRepo: o.releaseRepo,
+			ConfigDir:   o.configDir,
+			Org:         o.prOrg,
+			Repo:        o.prRepo,
+			Branch:      o.prBranch,
+			Title: func(org string) string {
+				return fmt.Sprintf("Deprioritize or disable failing periodics for %s", org)
+			},
+			Body: func(_ string, changes []configmutation.Change) string {
+				return prBody(o.configDir, proposed(decisions, changes), policy)
+			},
+		}
+		if err := proposal.CreatePullRequests(&o.PRCreationOptions, changes); err != nil {
+			logrus.WithError(err).Fatal("Failed to create pull requests.")
+		}
+	default:
+		logrus.Infof("Would rewrite %d configurations.", len(changes))
+	}
+}
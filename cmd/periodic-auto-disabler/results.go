@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/openshift/ci-tools/pkg/autodisable"
+)
+
+// finished is the part of the finished.json uploaded by Prow jobs we need.
+type finished struct {
+	Timestamp *int64 `json:"timestamp,omitempty"`
+	Passed    *bool  `json:"passed,omitempty"`
+}
+
+type gcsResults struct {
+	bucket *storage.BucketHandle
+}
+
+// results reads the results of the most recent runs of the job, stopping at
+// the first passing run or after the limit. Runs that did not finish yet are
+// ignored.
+func (g *gcsResults) results(ctx context.Context, job string, limit int) ([]autodisable.Result, error) {
+	it := g.bucket.Objects(ctx, &storage.Query{Prefix: path.Join("logs", job) + "/", Delimiter: "/"})
+	var builds []int64
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runs: %w", err)
+		}
+		if attrs.Prefix == "" {
+			continue
+		}
+		build, err := strconv.ParseInt(path.Base(attrs.Prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		builds = append(builds, build)
+	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i] > builds[j] })
+
+	var results []autodisable.Result
+	for _, build := range builds {
+		if len(results) == limit {
+			break
+		}
+		result, err := g.result(ctx, path.Join("logs", job, strconv.FormatInt(build, 10), "finished.json"))
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the result of run %d: %w", build, err)
+		}
+		results = append(results, *result)
+		if result.Passed {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (g *gcsResults) result(ctx context.Context, name string) (*autodisable.Result, error) {
+	reader, err := g.bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var f finished
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	result := &autodisable.Result{Passed: f.Passed != nil && *f.Passed}
+	if f.Timestamp != nil {
+		result.Finished = time.Unix(*f.Timestamp, 0)
+	}
+	return result, nil
+}
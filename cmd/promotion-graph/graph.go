@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/release"
+)
+
+// producer is a configuration promoting one of its images into a tag of the
+// integration stream.
+type producer struct {
+	api.Metadata `json:",inline"`
+	// Image is the pipeline image promoted into the tag.
+	Image string `json:"image"`
+}
+
+// tagNode holds everything known about a tag of the integration stream.
+type tagNode struct {
+	Producers []producer       `json:"producers,omitempty"`
+	Consumers []api.Metadata   `json:"consumers,omitempty"`
+	consumers sets.Set[string] `json:"-"`
+}
+
+// promotionGraph maps each tag of an integration stream to the
+// configurations producing and consuming it.
+type promotionGraph struct {
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Tags      map[string]*tagNode `json:"tags"`
+	// Duplicates lists the tags promoted by more than one configuration,
+	// where the last promotion to run wins.
+	Duplicates []string `json:"duplicates,omitempty"`
+	// Gaps lists the tags used by configurations but not promoted by any.
+	Gaps []string `json:"gaps,omitempty"`
+}
+
+func (g *promotionGraph) tag(name string) *tagNode {
+	node, ok := g.Tags[name]
+	if !ok {
+		node = &tagNode{consumers: sets.New[string]()}
+		g.Tags[name] = node
+	}
+	return node
+}
+
+// producedTags returns the tags of the stream a configuration promotes into,
+// mapped to the pipeline images promoted. Optional images are only promoted
+// when required, which the configuration alone does not tell, so they are
+// not produced.
+func producedTags(c *api.ReleaseBuildConfiguration, namespace, name string) map[string]string {
+	tags := map[string]string{}
+	promoted, _ := release.PromotedTagsWithRequiredImages(c)
+	for image, refs := range promoted {
+		for _, ref := range refs {
+			if ref.Namespace == namespace && ref.Name == name {
+				tags[ref.Tag] = image
+			}
+		}
+	}
+	return tags
+}
+
+// releaseStreams returns the release streams of a configuration which are
+// imported from the stream.
+func releaseStreams(c *api.ReleaseBuildConfiguration, namespace, name string) sets.Set[string] {
+	streams := sets.New[string]()
+	if tags := c.ReleaseTagConfiguration; tags != nil && tags.Namespace == namespace && tags.Name == name {
+		streams.Insert(api.ReleaseStreamFor(api.LatestReleaseName), api.ReleaseStreamFor(api.InitialReleaseName))
+	}
+	for release, config := range c.Releases {
+		if config.Integration != nil && config.Integration.Namespace == namespace && config.Integration.Name == name {
+			streams.Insert(api.ReleaseStreamFor(release))
+		}
+	}
+	return streams
+}
+
+// literalSteps returns the steps of a test defined in the configuration itself,
+// the ones referenced from the step registry are not known here.
+func literalSteps(test api.TestStepConfiguration) []api.LiteralTestStep {
+	var ret []api.LiteralTestStep
+	if literal := test.MultiStageTestConfigurationLiteral; literal != nil {
+		for _, phase := range [][]api.LiteralTestStep{literal.Pre, literal.Test, literal.Post} {
+			ret = append(ret, phase...)
+		}
+	}
+	if multiStage := test.MultiStageTestConfiguration; multiStage != nil {
+		for _, phase := range [][]api.TestStep{multiStage.Pre, multiStage.Test, multiStage.Post} {
+			for _, step := range phase {
+				if step.LiteralTestStep != nil {
+					ret = append(ret, *step.LiteralTestStep)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// consumedTags returns the tags of the stream a configuration imports, as
+// inputs of its builds or as dependencies of its tests, and whether it
+// assembles a release from the stream, which uses all of its tags.
+func consumedTags(c *api.ReleaseBuildConfiguration, namespace, name string) (sets.Set[string], bool) {
+	tags := sets.New[string]()
+	add := func(ref api.ImageStreamTagReference) {
+		if ref.Namespace == namespace && ref.Name == name {
+			tags.Insert(ref.Tag)
+		}
+	}
+	for _, ref := range c.BaseImages {
+		add(ref)
+	}
+	for _, ref := range c.BaseRPMImages {
+		add(ref)
+	}
+	if c.BuildRootImage != nil && c.BuildRootImage.ImageStreamTagReference != nil {
+		add(*c.BuildRootImage.ImageStreamTagReference)
+	}
+	streams := releaseStreams(c, namespace, name)
+	for _, test := range c.Tests {
+		for _, step := range literalSteps(test) {
+			if step.FromImage != nil {
+				add(*step.FromImage)
+			}
+			for _, dependency := range step.Dependencies {
+				if stream, tag, _ := c.DependencyParts(dependency, nil); streams.Has(stream) {
+					tags.Insert(tag)
+				}
+			}
+		}
+	}
+	return tags, streams.Len() != 0
+}
+
+// buildPromotionGraph finds the producers and consumers of every tag of the
+// `namespace/name` stream and detects the tags promoted more than once and
+// the tags used but never promoted.
+func buildPromotionGraph(configs []api.ReleaseBuildConfiguration, namespace, name string) *promotionGraph {
+	g := &promotionGraph{Namespace: namespace, Name: name, Tags: map[string]*tagNode{}}
+	consume := func(node *tagNode, c *api.ReleaseBuildConfiguration) {
+		if !node.consumers.Has(c.Metadata.AsString()) {
+			node.consumers.Insert(c.Metadata.AsString())
+			node.Consumers = append(node.Consumers, c.Metadata)
+		}
+	}
+	var releases []*api.ReleaseBuildConfiguration
+	for i := range configs {
+		c := &configs[i]
+		for tag, image := range producedTags(c, namespace, name) {
+			node := g.tag(tag)
+			node.Producers = append(node.Producers, producer{Metadata: c.Metadata, Image: image})
+		}
+		tags, wholeStream := consumedTags(c, namespace, name)
+		for tag := range tags {
+			consume(g.tag(tag), c)
+		}
+		if wholeStream {
+			releases = append(releases, c)
+		}
+	}
+	// releases assembled from the stream use every tag known in it
+	for _, c := range releases {
+		for _, node := range g.Tags {
+			consume(node, c)
+		}
+	}
+	for tag, node := range g.Tags {
+		sort.Slice(node.Producers, func(i, j int) bool {
+			return node.Producers[i].AsString() < node.Producers[j].AsString()
+		})
+		sort.Slice(node.Consumers, func(i, j int) bool {
+			return node.Consumers[i].AsString() < node.Consumers[j].AsString()
+		})
+		switch {
+		case len(node.Producers) > 1:
+			g.Duplicates = append(g.Duplicates, tag)
+		case len(node.Producers) == 0:
+			g.Gaps = append(g.Gaps, tag)
+		}
+	}
+	sort.Strings(g.Duplicates)
+	sort.Strings(g.Gaps)
+	return g
+}
+
+func (g *promotionGraph) sortedTags() []string {
+	var tags []string
+	for tag := range g.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// dot renders the graph in the Graphviz format: configurations point to the
+// tags they promote and tags point to the configurations using them.
+// Duplicated and missing tags are highlighted.
+func (g *promotionGraph) dot() string {
+	duplicates, gaps := sets.New[string](g.Duplicates...), sets.New[string](g.Gaps...)
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", fmt.Sprintf("%s/%s", g.Namespace, g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	configs := sets.New[string]()
+	var edges []string
+	for _, tag := range g.sortedTags() {
+		node := g.Tags[tag]
+		attrs := "shape=box"
+		switch {
+		case duplicates.Has(tag):
+			attrs += ", color=red, fontcolor=red"
+		case gaps.Has(tag):
+			attrs += ", color=red, fontcolor=red, style=dashed"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, %s];\n", "tag/"+tag, tag, attrs)
+		for _, p := range node.Producers {
+			configs.Insert(p.AsString())
+			edges = append(edges, fmt.Sprintf("  %q -> %q [label=%q];\n", p.AsString(), "tag/"+tag, p.Image))
+		}
+		for _, c := range node.Consumers {
+			configs.Insert(c.AsString())
+			edges = append(edges, fmt.Sprintf("  %q -> %q [style=dashed];\n", "tag/"+tag, c.AsString()))
+		}
+	}
+	for _, c := range sets.List(configs) {
+		fmt.Fprintf(&b, "  %q [shape=ellipse];\n", c)
+	}
+	for _, edge := range edges {
+		b.WriteString(edge)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
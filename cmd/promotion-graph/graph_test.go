@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestBuildPromotionGraph(t *testing.T) {
+	promotesTo := func(name string, targets ...api.PromotionTarget) *api.PromotionConfiguration {
+		for i := range targets {
+			targets[i].Namespace, targets[i].Name = "ocp", name
+		}
+		return &api.PromotionConfiguration{Targets: targets}
+	}
+	images := func(names ...string) []api.ProjectDirectoryImageBuildStepConfiguration {
+		var ret []api.ProjectDirectoryImageBuildStepConfiguration
+		for _, name := range names {
+			ret = append(ret, api.ProjectDirectoryImageBuildStepConfiguration{To: api.PipelineImageStreamTagReference(name)})
+		}
+		return ret
+	}
+	operator := api.Metadata{Org: "openshift", Repo: "operator", Branch: "master"}
+	installer := api.Metadata{Org: "openshift", Repo: "installer", Branch: "master"}
+	okd := api.Metadata{Org: "openshift", Repo: "installer", Branch: "master", Variant: "okd"}
+	old := api.Metadata{Org: "openshift", Repo: "operator", Branch: "release-4.14"}
+	e2e := api.Metadata{Org: "openshift", Repo: "e2e", Branch: "master"}
+	configs := []api.ReleaseBuildConfiguration{
+		{
+			Metadata: operator,
+			// optional images are only promoted when required by a test
+			Images:                 append(images("operator", "operator-tests"), api.ProjectDirectoryImageBuildStepConfiguration{To: "operator-debug", Optional: true}),
+			PromotionConfiguration: promotesTo("4.15", api.PromotionTarget{ExcludedImages: []string{"operator-tests"}}),
+			InputConfiguration: api.InputConfiguration{
+				BaseImages: map[string]api.ImageStreamTagReference{
+					"base":    {Namespace: "ocp", Name: "4.15", Tag: "base"},
+					"builder": {Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.21-openshift-4.15"},
+				},
+			},
+		},
+		{
+			Metadata:               installer,
+			Images:                 images("installer", "installer-artifacts"),
+			PromotionConfiguration: promotesTo("4.15", api.PromotionTarget{AdditionalImages: map[string]string{"installer-src": "src"}}),
+			InputConfiguration: api.InputConfiguration{
+				BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ocp", Name: "4.15", Tag: "operator"}},
+			},
+		},
+		{
+			Metadata:               okd,
+			Images:                 images("installer"),
+			PromotionConfiguration: promotesTo("4.15", api.PromotionTarget{ExcludedImages: []string{"*"}, AdditionalImages: map[string]string{"installer": "installer"}}),
+		},
+		{
+			Metadata:               old,
+			Images:                 images("operator"),
+			PromotionConfiguration: promotesTo("4.14"),
+		},
+		{
+			Metadata: e2e,
+			// the release assembled from the stream uses all of its tags
+			InputConfiguration: api.InputConfiguration{
+				Releases: map[string]api.UnresolvedRelease{"latest": {Integration: &api.Integration{Namespace: "ocp", Name: "4.15"}}},
+			},
+			Tests: []api.TestStepConfiguration{{
+				As: "e2e",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{
+						As:           "e2e",
+						FromImage:    &api.ImageStreamTagReference{Namespace: "ocp", Name: "4.15", Tag: "cli"},
+						Dependencies: []api.StepDependency{{Name: "stable:tests", Env: "TESTS"}, {Name: "pipeline:src", Env: "SRC"}},
+					}}},
+				},
+			}},
+		},
+		{
+			Metadata:               api.Metadata{Org: "openshift", Repo: "disabled", Branch: "master"},
+			Images:                 images("operator"),
+			PromotionConfiguration: promotesTo("4.15", api.PromotionTarget{Disabled: true}),
+		},
+	}
+	expected := &promotionGraph{
+		Namespace: "ocp",
+		Name:      "4.15",
+		Tags: map[string]*tagNode{
+			"base":                {Consumers: []api.Metadata{e2e, operator}},
+			"cli":                 {Consumers: []api.Metadata{e2e}},
+			"operator":            {Producers: []producer{{Metadata: operator, Image: "operator"}}, Consumers: []api.Metadata{e2e, installer}},
+			"installer":           {Producers: []producer{{Metadata: installer, Image: "installer"}, {Metadata: okd, Image: "installer"}}, Consumers: []api.Metadata{e2e}},
+			"installer-artifacts": {Producers: []producer{{Metadata: installer, Image: "installer-artifacts"}}, Consumers: []api.Metadata{e2e}},
+			"installer-src":       {Producers: []producer{{Metadata: installer, Image: "src"}}, Consumers: []api.Metadata{e2e}},
+			"tests":               {Consumers: []api.Metadata{e2e}},
+		},
+		Duplicates: []string{"installer"},
+		Gaps:       []string{"base", "cli", "tests"},
+	}
+	if diff := cmp.Diff(expected, buildPromotionGraph(configs, "ocp", "4.15"), cmpopts.IgnoreUnexported(tagNode{})); diff != "" {
+		t.Errorf("unexpected graph: %s", diff)
+	}
+}
+
+func TestDot(t *testing.T) {
+	operator := api.Metadata{Org: "openshift", Repo: "operator", Branch: "master"}
+	installer := api.Metadata{Org: "openshift", Repo: "installer", Branch: "master", Variant: "okd"}
+	g := &promotionGraph{
+		Namespace: "ocp",
+		Name:      "4.15",
+		Tags: map[string]*tagNode{
+			"base":     {Consumers: []api.Metadata{operator}},
+			"operator": {Producers: []producer{{Metadata: operator, Image: "operator"}}, Consumers: []api.Metadata{installer}},
+		},
+		Gaps: []string{"base"},
+	}
+	expected := `digraph "ocp/4.15" {
+  rankdir=LR;
+  "tag/base" [label="base", shape=box, color=red, fontcolor=red, style=dashed];
+  "tag/operator" [label="operator", shape=box];
+  "openshift/installer@master [okd]" [shape=ellipse];
+  "openshift/operator@master" [shape=ellipse];
+  "tag/base" -> "openshift/operator@master" [style=dashed];
+  "openshift/operator@master" -> "tag/operator" [label="operator"];
+  "tag/operator" -> "openshift/installer@master [okd]" [style=dashed];
+}
+`
+	if diff := cmp.Diff(expected, g.dot()); diff != "" {
+		t.Errorf("unexpected output: %s", diff)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/logrusutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+type options struct {
+	configDir string
+	namespace string
+	name      string
+	format    string
+}
+
+func gatherOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.configDir, "config-dir", "", "Path to the ci-operator configuration directory")
+	fs.StringVar(&o.namespace, "namespace", "ocp", "Namespace of the integration stream")
+	fs.StringVar(&o.name, "name", "", "Name of the integration stream, e.g. the release version")
+	fs.StringVar(&o.format, "format", "json", "Output format, one of json or dot")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse input")
+	}
+	return o
+}
+
+func (o *options) validate() error {
+	for flag, value := range map[string]string{
+		"--config-dir": o.configDir,
+		"--namespace":  o.namespace,
+		"--name":       o.name,
+	} {
+		if value == "" {
+			return fmt.Errorf("%s is required", flag)
+		}
+	}
+	if o.format != "json" && o.format != "dot" {
+		return fmt.Errorf("--format must be json or dot, not %q", o.format)
+	}
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	var configs []api.ReleaseBuildConfiguration
+	if err := config.OperateOnCIOperatorConfigDir(o.configDir, func(c *api.ReleaseBuildConfiguration, info *config.Info) error {
+		c.Metadata = info.Metadata
+		configs = append(configs, *c)
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to load ci-operator configurations.")
+	}
+
+	g := buildPromotionGraph(configs, o.namespace, o.name)
+	for _, tag := range g.Duplicates {
+		var producers []string
+		for _, p := range g.Tags[tag].Producers {
+			producers = append(producers, p.AsString())
+		}
+		logrus.WithField("tag", tag).WithField("producers", producers).Warn("Tag is promoted by more than one configuration.")
+	}
+	for _, tag := range g.Gaps {
+		logrus.WithField("tag", tag).Warn("Tag is used but not promoted by any configuration.")
+	}
+
+	switch o.format {
+	case "dot":
+		fmt.Print(g.dot())
+	case "json":
+		raw, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to serialize the promotion graph.")
+		}
+		fmt.Println(string(raw))
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// maxRows limits the number of jobs listed in the analysis, to keep the
+// comment posted to the pull request readable.
+const maxRows = 50
+
+// snapshot holds a version of the registry being compared.
+type snapshot struct {
+	registry.Snapshot
+	graph    registry.NodeByName
+	resolver registry.Resolver
+}
+
+func newSnapshot(s registry.Snapshot) (*snapshot, error) {
+	graph, err := registry.NewGraph(s.References, s.Chains, s.Workflows, s.Observers)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		Snapshot: s,
+		graph:    graph,
+		resolver: registry.NewResolver(s.References, s.Chains, s.Workflows, s.Observers),
+	}, nil
+}
+
+// changedNodes returns the components which differ between the snapshots,
+// including the ones added or removed.
+func changedNodes(base, pr *snapshot) []registry.Node {
+	var ret []registry.Node
+	collect := func(before, after interface{}, baseNodes, prNodes map[string]registry.Node) {
+		b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+		names := map[string]bool{}
+		for _, k := range b.MapKeys() {
+			names[k.String()] = true
+		}
+		for _, k := range a.MapKeys() {
+			names[k.String()] = true
+		}
+		for name := range names {
+			k := reflect.ValueOf(name)
+			bv, av := b.MapIndex(k), a.MapIndex(k)
+			if bv.IsValid() && av.IsValid() && reflect.DeepEqual(bv.Interface(), av.Interface()) {
+				continue
+			}
+			if node, ok := prNodes[name]; ok {
+				ret = append(ret, node)
+			} else if node, ok := baseNodes[name]; ok {
+				ret = append(ret, node)
+			}
+		}
+	}
+	collect(base.References, pr.References, base.graph.References, pr.graph.References)
+	collect(base.Chains, pr.Chains, base.graph.Chains, pr.graph.Chains)
+	collect(base.Workflows, pr.Workflows, base.graph.Workflows, pr.graph.Workflows)
+	collect(base.Observers, pr.Observers, base.graph.Observers, pr.graph.Observers)
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Type() != ret[j].Type() {
+			return ret[i].Type() < ret[j].Type()
+		}
+		return ret[i].Name() < ret[j].Name()
+	})
+	return ret
+}
+
+// estimator estimates the runtime and the cost of tests.
+type estimator struct {
+	// stepDurations are the average durations of steps, by name.
+	stepDurations map[string]time.Duration
+	// hourlyCost is the cost of an hour of a test, by cluster profile.
+	hourlyCost map[api.ClusterProfile]float64
+}
+
+// runtime sums the average durations of the steps of a test, also returning
+// how many steps have no known duration.
+func (e *estimator) runtime(test *api.MultiStageTestConfigurationLiteral) (time.Duration, int) {
+	var total time.Duration
+	var unknown int
+	for _, step := range test.Steps() {
+		if d, ok := e.stepDurations[step.As]; ok {
+			total += d
+		} else {
+			unknown++
+		}
+	}
+	return total, unknown
+}
+
+func (e *estimator) cost(profile api.ClusterProfile, runtime time.Duration) float64 {
+	return e.hourlyCost[profile] * runtime.Hours()
+}
+
+// impact describes how a registry change affects a job.
+type impact struct {
+	Job string
+	// Added marks jobs which only resolve with the change.
+	Added bool
+	// RuntimeDelta is the estimated change of the runtime of a run.
+	RuntimeDelta time.Duration
+	// CostDelta is the estimated change of the cost of a run.
+	CostDelta float64
+	// UnknownSteps counts the steps without a known duration.
+	UnknownSteps int
+	// Error is set when the test does not resolve with the change.
+	Error string
+}
+
+type analysis struct {
+	Changed []registry.Node
+	Impacts []impact
+}
+
+// analyze determines the jobs whose resolved test changes between the
+// snapshots and estimates the effect on their runtime and cost.
+func analyze(base, pr *snapshot, configs []api.ReleaseBuildConfiguration, e *estimator) analysis {
+	changed := changedNodes(base, pr)
+	ret := analysis{Changed: changed}
+	if len(changed) == 0 {
+		return ret
+	}
+	tests := map[registry.Consumer]api.TestStepConfiguration{}
+	for _, c := range configs {
+		for _, test := range c.Tests {
+			if test.MultiStageTestConfiguration != nil {
+				tests[registry.Consumer{Metadata: c.Metadata, Test: test.As}] = test
+			}
+		}
+	}
+	var baseChanged []registry.Node
+	for _, node := range changed {
+		if n := lookup(base.graph, node); n != nil {
+			baseChanged = append(baseChanged, n)
+		}
+	}
+	consumers := append(registry.NewConsumerIndex(pr.graph, configs).ConsumersOf(changed), registry.NewConsumerIndex(base.graph, configs).ConsumersOf(baseChanged)...)
+	seen := map[registry.Consumer]bool{}
+	for _, consumer := range consumers {
+		if seen[consumer] {
+			continue
+		}
+		seen[consumer] = true
+		test := tests[consumer]
+		var i impact
+		before, baseErr := base.resolver.Resolve(test.As, *test.MultiStageTestConfiguration)
+		after, prErr := pr.resolver.Resolve(test.As, *test.MultiStageTestConfiguration)
+		switch {
+		case prErr != nil:
+			i.Error = prErr.Error()
+		case baseErr != nil:
+			i.Added = true
+			i.RuntimeDelta, i.UnknownSteps = e.runtime(&after)
+			i.CostDelta = e.cost(after.ClusterProfile, i.RuntimeDelta)
+		case reflect.DeepEqual(before, after):
+			continue
+		default:
+			beforeRuntime, beforeUnknown := e.runtime(&before)
+			afterRuntime, afterUnknown := e.runtime(&after)
+			i.RuntimeDelta = afterRuntime - beforeRuntime
+			i.CostDelta = e.cost(after.ClusterProfile, afterRuntime) - e.cost(before.ClusterProfile, beforeRuntime)
+			i.UnknownSteps = max(beforeUnknown, afterUnknown)
+		}
+		for _, job := range jobNames(consumer.Metadata, test) {
+			i.Job = job
+			ret.Impacts = append(ret.Impacts, i)
+		}
+	}
+	sort.Slice(ret.Impacts, func(a, b int) bool { return ret.Impacts[a].Job < ret.Impacts[b].Job })
+	return ret
+}
+
+// jobNames returns the names of the jobs generated for a test, mirroring
+// how prowgen chooses between periodics, postsubmits and presubmits.
+func jobNames(metadata api.Metadata, test api.TestStepConfiguration) []string {
+	switch {
+	case test.IsPeriodic():
+		names := []string{metadata.JobName(jobconfig.PeriodicPrefix, test.As)}
+		if test.Presubmit {
+			names = append(names, metadata.JobName(jobconfig.PresubmitPrefix, test.As))
+		}
+		return names
+	case test.Postsubmit:
+		return []string{metadata.JobName(jobconfig.PostsubmitPrefix, test.As)}
+	default:
+		return []string{metadata.JobName(jobconfig.PresubmitPrefix, test.As)}
+	}
+}
+
+func lookup(graph registry.NodeByName, node registry.Node) registry.Node {
+	var nodes map[string]registry.Node
+	switch node.Type() {
+	case registry.Reference:
+		nodes = graph.References
+	case registry.Chain:
+		nodes = graph.Chains
+	case registry.Workflow:
+		nodes = graph.Workflows
+	case registry.Observer:
+		nodes = graph.Observers
+	}
+	return nodes[node.Name()]
+}
+
+// markdown renders the analysis as a pull request comment.
+func (a *analysis) markdown() string {
+	var b strings.Builder
+	if len(a.Changed) == 0 {
+		b.WriteString("This change does not modify any registry component.\n")
+		return b.String()
+	}
+	var runtime time.Duration
+	var cost float64
+	var failed int
+	for _, i := range a.Impacts {
+		runtime += i.RuntimeDelta
+		cost += i.CostDelta
+		if i.Error != "" {
+			failed++
+		}
+	}
+	fmt.Fprintf(&b, "This change modifies %d registry components, changing the resolved tests of %d jobs.\n", len(a.Changed), len(a.Impacts))
+	if len(a.Impacts) == 0 {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Estimated change per run of all affected jobs: runtime %s, cost %s.\n", signedDuration(runtime), signedCost(cost))
+	if failed != 0 {
+		fmt.Fprintf(&b, "\n**%d jobs fail to resolve with this change.**\n", failed)
+	}
+	b.WriteString("\n| Job | Change | Runtime | Cost |\n| --- | --- | --- | --- |\n")
+	for n, i := range a.Impacts {
+		if n == maxRows {
+			fmt.Fprintf(&b, "\n%d more jobs are not listed.\n", len(a.Impacts)-maxRows)
+			break
+		}
+		if i.Error != "" {
+			fmt.Fprintf(&b, "| `%s` | fails to resolve | - | - |\n", i.Job)
+			continue
+		}
+		change := "modified"
+		if i.Added {
+			change = "added"
+		}
+		runtime := signedDuration(i.RuntimeDelta)
+		if i.UnknownSteps != 0 {
+			runtime += fmt.Sprintf(" (%d steps unknown)", i.UnknownSteps)
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", i.Job, change, runtime, signedCost(i.CostDelta))
+	}
+	return b.String()
+}
+
+func signedDuration(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.Round(time.Second).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+func signedCost(c float64) string {
+	if c >= 0 {
+		return fmt.Sprintf("+$%.2f", c)
+	}
+	return fmt.Sprintf("-$%.2f", -c)
+}
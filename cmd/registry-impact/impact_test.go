@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestAnalyze(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	step := func(as, commands string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "cli", Commands: commands}
+	}
+	chains := registry.ChainByName{
+		"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}}},
+	}
+	workflows := registry.WorkflowByName{
+		"ipi": {
+			ClusterProfile: api.ClusterProfileAWS,
+			Pre:            []api.TestStep{{Chain: strPtr("setup")}},
+			Post:           []api.TestStep{{Reference: strPtr("teardown")}},
+		},
+	}
+	base, err := newSnapshot(registry.Snapshot{
+		References: registry.ReferenceByName{
+			"install":  step("install", "install"),
+			"teardown": step("teardown", "teardown"),
+			"e2e":      step("e2e", "e2e"),
+		},
+		Chains:    chains,
+		Workflows: workflows,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := newSnapshot(registry.Snapshot{
+		References: registry.ReferenceByName{
+			"install":  step("install", "install --slow"),
+			"teardown": step("teardown", "teardown"),
+			"e2e":      step("e2e", "e2e"),
+			"check":    step("check", "check"),
+		},
+		Chains: registry.ChainByName{
+			"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}, {Reference: strPtr("check")}}},
+		},
+		Workflows: workflows,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := []api.ReleaseBuildConfiguration{{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"},
+		Tests: []api.TestStepConfiguration{
+			{As: "e2e-aws", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi")}},
+			{As: "e2e-post", Postsubmit: true, MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi")}},
+			{As: "e2e-only", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: strPtr("e2e")}}}},
+			{As: "e2e-nightly", Cron: strPtr("@daily"), MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+				Workflow: strPtr("ipi"),
+				Pre:      []api.TestStep{{Reference: strPtr("install")}},
+			}},
+			{As: "e2e-pinned", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi@v2025-01-15")}},
+		},
+	}}
+	e := &estimator{
+		stepDurations: map[string]time.Duration{"install": time.Hour, "teardown": 30 * time.Minute, "check": 30 * time.Minute},
+		hourlyCost:    map[api.ClusterProfile]float64{api.ClusterProfileAWS: 2},
+	}
+	a := analyze(base, pr, configs, e)
+	if diff := cmp.Diff([]string{"setup", "check", "install"}, names(a.Changed)); diff != "" {
+		t.Errorf("unexpected changed nodes: %s", diff)
+	}
+	expected := []impact{
+		{Job: "branch-ci-org-repo-main-e2e-post", RuntimeDelta: 30 * time.Minute, CostDelta: 1},
+		{Job: "periodic-ci-org-repo-main-e2e-nightly"},
+		{Job: "pull-ci-org-repo-main-e2e-aws", RuntimeDelta: 30 * time.Minute, CostDelta: 1},
+	}
+	if diff := cmp.Diff(expected, a.Impacts); diff != "" {
+		t.Errorf("unexpected impacts: %s", diff)
+	}
+	expectedComment := "This change modifies 3 registry components, changing the resolved tests of 3 jobs.\n" +
+		"Estimated change per run of all affected jobs: runtime +1h0m0s, cost +$2.00.\n" +
+		"\n| Job | Change | Runtime | Cost |\n| --- | --- | --- | --- |\n" +
+		"| `branch-ci-org-repo-main-e2e-post` | modified | +30m0s | +$1.00 |\n" +
+		"| `periodic-ci-org-repo-main-e2e-nightly` | modified | +0s | +$0.00 |\n" +
+		"| `pull-ci-org-repo-main-e2e-aws` | modified | +30m0s | +$1.00 |\n"
+	if diff := cmp.Diff(expectedComment, a.markdown()); diff != "" {
+		t.Errorf("unexpected comment: %s", diff)
+	}
+}
+
+func names(nodes []registry.Node) []string {
+	var ret []string
+	for _, n := range nodes {
+		ret = append(ret, n.Name())
+	}
+	return ret
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+type options struct {
+	configDir         string
+	baseRegistryDir   string
+	registryDir       string
+	stepDurationsPath string
+	hourlyCostPath    string
+	org               string
+	repo              string
+	pullNumber        int
+	flagutil.GitHubOptions
+}
+
+func gatherOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.configDir, "config-dir", "", "Path to the ci-operator configuration directory")
+	fs.StringVar(&o.baseRegistryDir, "base-registry", "", "Path to the step registry before the change")
+	fs.StringVar(&o.registryDir, "registry", "", "Path to the step registry with the change")
+	fs.StringVar(&o.stepDurationsPath, "step-durations", "", "Path to a file mapping step names to their average duration")
+	fs.StringVar(&o.hourlyCostPath, "hourly-cost", "", "Path to a file mapping cluster profiles to the cost of an hour of a test using them")
+	fs.StringVar(&o.org, "org", "openshift", "Organization of the pull request to comment on")
+	fs.StringVar(&o.repo, "repo", "release", "Repository of the pull request to comment on")
+	fs.IntVar(&o.pullNumber, "pull-number", 0, "Number of the pull request to comment on; the analysis is printed if unset")
+	o.GitHubOptions.AddFlags(fs)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse input")
+	}
+	return o
+}
+
+func (o *options) validate() error {
+	for flag, value := range map[string]string{
+		"--config-dir":    o.configDir,
+		"--base-registry": o.baseRegistryDir,
+		"--registry":      o.registryDir,
+	} {
+		if value == "" {
+			return fmt.Errorf("%s is required", flag)
+		}
+	}
+	if o.pullNumber != 0 {
+		return o.GitHubOptions.Validate(false)
+	}
+	return nil
+}
+
+func loadSnapshot(path string) (*snapshot, error) {
+	references, chains, workflows, _, _, _, observers, err := load.Registry(path, load.RegistryFlag(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry %s: %w", path, err)
+	}
+	return newSnapshot(registry.Snapshot{References: references, Chains: chains, Workflows: workflows, Observers: observers})
+}
+
+func loadEstimator(stepDurationsPath, hourlyCostPath string) (*estimator, error) {
+	var durations map[string]prowv1.Duration
+	if err := unmarshalFile(stepDurationsPath, &durations); err != nil {
+		return nil, err
+	}
+	e := estimator{stepDurations: make(map[string]time.Duration, len(durations))}
+	for step, d := range durations {
+		e.stepDurations[step] = d.Duration
+	}
+	if err := unmarshalFile(hourlyCostPath, &e.hourlyCost); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func unmarshalFile(path string, into interface{}) error {
+	if path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(raw, into); err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	base, err := loadSnapshot(o.baseRegistryDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the base registry.")
+	}
+	pr, err := loadSnapshot(o.registryDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the changed registry.")
+	}
+	e, err := loadEstimator(o.stepDurationsPath, o.hourlyCostPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the estimates.")
+	}
+	var configs []api.ReleaseBuildConfiguration
+	if err := config.OperateOnCIOperatorConfigDir(o.configDir, func(c *api.ReleaseBuildConfiguration, info *config.Info) error {
+		c.Metadata = info.Metadata
+		configs = append(configs, *c)
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to load ci-operator configurations.")
+	}
+
+	a := analyze(base, pr, configs, e)
+	comment := a.markdown()
+	if o.pullNumber == 0 {
+		fmt.Print(comment)
+		return
+	}
+	client, err := o.GitHubOptions.GitHubClient(false)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create the GitHub client.")
+	}
+	if err := upsertComment(client, o.org, o.repo, o.pullNumber, comment); err != nil {
+		logrus.WithError(err).Fatal("Failed to comment on the pull request.")
+	}
+}
+
+// commentMarker identifies the comment holding the impact of the change, so
+// that later runs edit the comment instead of adding another one.
+const commentMarker = "<!-- registry-impact -->"
+
+type commentClient interface {
+	BotUserChecker() (func(candidate string) bool, error)
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
+	CreateComment(org, repo string, number int, comment string) error
+	EditComment(org, repo string, id int, comment string) error
+}
+
+// upsertComment comments the impact on the pull request, editing the comment
+// of an earlier run when there is one.
+func upsertComment(client commentClient, org, repo string, number int, comment string) error {
+	body := fmt.Sprintf("%s\n%s", commentMarker, comment)
+	isBot, err := client.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("failed to determine the user of the client: %w", err)
+	}
+	comments, err := client.ListIssueComments(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list the comments of the pull request: %w", err)
+	}
+	for _, c := range comments {
+		if isBot(c.User.Login) && strings.Contains(c.Body, commentMarker) {
+			return client.EditComment(org, repo, c.ID, body)
+		}
+	}
+	return client.CreateComment(org, repo, number, body)
+}
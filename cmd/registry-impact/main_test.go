@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+)
+
+func TestUpsertComment(t *testing.T) {
+	body := commentMarker + "\nimpact"
+	for _, tc := range []struct {
+		name           string
+		comments       []github.IssueComment
+		expectedAdded  []string
+		expectedEdited []string
+	}{
+		{
+			name:          "first run comments",
+			comments:      []github.IssueComment{{ID: 1, Body: "/test all", User: github.User{Login: "author"}}},
+			expectedAdded: []string{"org/repo#1:" + body},
+		},
+		{
+			name: "later runs edit the comment",
+			comments: []github.IssueComment{
+				{ID: 1, Body: "/test all", User: github.User{Login: "author"}},
+				{ID: 2, Body: commentMarker + "\nearlier run", User: github.User{Login: "k8s-ci-robot"}},
+			},
+			expectedEdited: []string{"org/repo#2:" + body},
+		},
+		{
+			name:          "comments of other users are not edited",
+			comments:      []github.IssueComment{{ID: 1, Body: "quoting " + commentMarker, User: github.User{Login: "author"}}},
+			expectedAdded: []string{"org/repo#1:" + body},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakegithub.NewFakeClient()
+			client.IssueComments[1] = tc.comments
+			client.IssueCommentID = len(tc.comments)
+			if err := upsertComment(client, "org", "repo", 1, "impact"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedAdded, client.IssueCommentsAdded); diff != "" {
+				t.Errorf("unexpected added comments: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedEdited, client.IssueCommentsEdited); diff != "" {
+				t.Errorf("unexpected edited comments: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+type options struct {
+	config.ConfirmableOptions
+	registryDir  string
+	snapshotsDir string
+	overlaysDir  string
+	mappingPath  string
+	reportPath   string
+}
+
+func gatherOptions() options {
+	o := options{}
+	o.Bind(flag.CommandLine)
+	flag.StringVar(&o.registryDir, "registry", "", "Path to the step registry directory")
+	flag.StringVar(&o.snapshotsDir, "registry-snapshots", "", "Path to the step registry snapshots directory, to resolve the components pinned to a snapshot")
+	flag.StringVar(&o.overlaysDir, "registry-overlays", "", "Path to the step registry overlays directory, to resolve configurations as their branches are")
+	flag.StringVar(&o.mappingPath, "mapping", "", "Path to the file mapping the old names of references, chains, workflows and parameters to the new ones")
+	flag.StringVar(&o.reportPath, "report", "", "Path to write the migration report to, printed to the standard output if unset")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if err := o.ConfirmableOptions.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.registryDir == "" {
+		errs = append(errs, fmt.Errorf("--registry is required"))
+	}
+	if o.mappingPath == "" {
+		errs = append(errs, fmt.Errorf("--mapping is required"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// mapping holds the new names of renamed registry components and parameters,
+// keyed by their old names.
+type mapping struct {
+	References map[string]string `json:"references,omitempty"`
+	Chains     map[string]string `json:"chains,omitempty"`
+	Workflows  map[string]string `json:"workflows,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+func loadMapping(path string) (*mapping, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %w", err)
+	}
+	var m mapping
+	if err := yaml.UnmarshalStrict(raw, &m); err != nil {
+		return nil, fmt.Errorf("failed to load mapping: %w", err)
+	}
+	return &m, nil
+}
+
+// renamed returns the new name of a component, unless it is pinned to a
+// registry snapshot, where the old name is still valid.
+func renamed(names map[string]string, name string) (string, bool) {
+	if _, version := registry.SplitVersion(name); version != "" {
+		return "", false
+	}
+	to, ok := names[name]
+	return to, ok
+}
+
+// rename renames a component in place.
+func rename(names map[string]string, kind string, name *string, changes *[]string) {
+	if name == nil {
+		return
+	}
+	if to, ok := renamed(names, *name); ok {
+		*changes = append(*changes, fmt.Sprintf("%s %s -> %s", kind, *name, to))
+		*name = to
+	}
+}
+
+func (m *mapping) migrateSteps(steps []api.TestStep, changes *[]string) {
+	for i := range steps {
+		rename(m.References, "ref", steps[i].Reference, changes)
+		rename(m.Chains, "chain", steps[i].Chain, changes)
+	}
+}
+
+// migrateTest updates a multi-stage test or workflow, returning the changes.
+func (m *mapping) migrateTest(test *api.MultiStageTestConfiguration) []string {
+	var changes []string
+	rename(m.Workflows, "workflow", test.Workflow, &changes)
+	for _, steps := range [][]api.TestStep{test.Pre, test.Test, test.Gather, test.Post} {
+		m.migrateSteps(steps, &changes)
+	}
+	for _, name := range sortedKeys(test.Environment) {
+		if renamed, ok := m.Parameters[name]; ok {
+			changes = append(changes, fmt.Sprintf("env %s -> %s", name, renamed))
+			test.Environment[renamed] = test.Environment[name]
+			delete(test.Environment, name)
+		}
+	}
+	return changes
+}
+
+// migrateChain updates a chain, returning the changes.
+func (m *mapping) migrateChain(chain *api.RegistryChain) []string {
+	var changes []string
+	m.migrateSteps(chain.Steps, &changes)
+	m.migrateParameters(chain.Environment, &changes)
+	return changes
+}
+
+// migrateRef updates the parameters a reference declares, returning the
+// changes.
+func (m *mapping) migrateRef(ref *api.LiteralTestStep) []string {
+	var changes []string
+	m.migrateParameters(ref.Environment, &changes)
+	return changes
+}
+
+func (m *mapping) migrateParameters(parameters []api.StepParameter, changes *[]string) {
+	for i := range parameters {
+		rename(m.Parameters, "env", &parameters[i].Name, changes)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fileReport lists the changes made to a file.
+type fileReport struct {
+	Path    string   `json:"path"`
+	Changes []string `json:"changes"`
+}
+
+type report struct {
+	Configs  []fileReport `json:"configs,omitempty"`
+	Registry []fileReport `json:"registry,omitempty"`
+	Errors   []string     `json:"errors,omitempty"`
+}
+
+// rewrittenFile is a file that needs rewriting.
+type rewrittenFile struct {
+	path string
+	raw  []byte
+}
+
+// migrateRegistry updates the references, chains and workflows of the
+// registry on disk, returning the rewritten files. The commands of references
+// declaring renamed parameters are rewritten to expand the new names.
+func (m *mapping) migrateRegistry(root string, r *report) ([]rewrittenFile, error) {
+	var files []rewrittenFile
+	err := filepath.WalkDir(root, func(path string, info fs.DirEntry, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		var kind string
+		switch {
+		case strings.HasSuffix(path, load.RefSuffix):
+			kind = "ref"
+		case strings.HasSuffix(path, load.ChainSuffix):
+			kind = "chain"
+		case strings.HasSuffix(path, load.WorkflowSuffix):
+			kind = "workflow"
+		default:
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var document yamlv3.Node
+		if err := yamlv3.Unmarshal(raw, &document); err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		component := mappingValue(&document, kind)
+		e := &edits{}
+		switch kind {
+		case "ref":
+			m.editParameters(mappingValue(component, "env"), e)
+		case "chain":
+			m.editSteps(mappingValue(component, "steps"), e)
+			m.editParameters(mappingValue(component, "env"), e)
+		case "workflow":
+			m.editTest(mappingValue(component, "steps"), e)
+		}
+		if len(e.changes) == 0 {
+			return nil
+		}
+		if raw, err = e.apply(raw); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		r.Registry = append(r.Registry, fileReport{Path: rel, Changes: e.changes})
+		files = append(files, rewrittenFile{path: path, raw: raw})
+		if commands := mappingValue(component, "commands"); kind == "ref" && commands != nil {
+			path := filepath.Join(filepath.Dir(path), commands.Value)
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if migrated := m.renameParameters(string(raw)); migrated != string(raw) {
+				files = append(files, rewrittenFile{path: path, raw: []byte(migrated)})
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// migrateConfig updates the multi-stage tests of a ci-operator configuration
+// on disk, returning nil if it does not change.
+func (m *mapping) migrateConfig(path string, r *report, relativePath string) (*rewrittenFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	e := m.editConfig(&document)
+	if len(e.changes) == 0 {
+		return nil, nil
+	}
+	if raw, err = e.apply(raw); err != nil {
+		return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+	r.Configs = append(r.Configs, fileReport{Path: relativePath, Changes: e.changes})
+	return &rewrittenFile{path: path, raw: raw}, nil
+}
+
+// validate resolves the migrated configurations against the migrated
+// registry, recording every failure in the report. Components pinned to
+// snapshots are resolved from them and the overlays of the branches of the
+// configurations are applied, as they are in jobs.
+func (m *mapping) validate(registryDir, snapshotsDir, overlaysDir string, configs []config.DataWithInfo, r *report) error {
+	// callers of renamed components are only valid once migrated
+	refs, chains, workflows, _, _, _, observers, err := load.Registry(registryDir, load.RegistryUnvalidated)
+	if err != nil {
+		return fmt.Errorf("failed to load the registry: %w", err)
+	}
+	for name, ref := range refs {
+		m.migrateRef(&ref)
+		refs[name] = ref
+	}
+	for name, chain := range chains {
+		m.migrateChain(&chain)
+		chains[name] = chain
+	}
+	for name, workflow := range workflows {
+		m.migrateTest(&workflow)
+		workflows[name] = workflow
+	}
+	if err := registry.Validate(refs, chains, workflows, observers); err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("registry: %v", err))
+	}
+	var snapshots map[string]registry.Snapshot
+	if snapshotsDir != "" {
+		if snapshots, err = load.RegistrySnapshots(snapshotsDir, load.RegistryFlag(0), nil); err != nil {
+			return err
+		}
+	}
+	var overlays []registry.Overlay
+	if overlaysDir != "" {
+		if overlays, err = load.RegistryOverlays(overlaysDir, load.RegistryFlag(0)); err != nil {
+			return err
+		}
+	}
+	resolver, err := registry.NewResolverWithOverlays(refs, chains, workflows, observers, snapshots, overlays)
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("registry: %v", err))
+		return nil
+	}
+	for _, c := range configs {
+		if _, err := registry.ResolveConfig(resolver, c.Configuration); err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", c.Info.RelativePath(), err))
+		}
+	}
+	return nil
+}
+
+func (r *report) write(path string) error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	if err := o.ConfirmableOptions.Complete(); err != nil {
+		logrus.Fatalf("Couldn't complete the config options: %v", err)
+	}
+	m, err := loadMapping(o.mappingPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the mapping.")
+	}
+
+	var r report
+	var configs []config.DataWithInfo
+	var files []rewrittenFile
+	if err := o.OperateOnCIOperatorConfigDir(o.ConfigDir, func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+		file, err := m.migrateConfig(info.Filename, &r, info.RelativePath())
+		if err != nil || file == nil {
+			return err
+		}
+		for i := range configuration.Tests {
+			if test := configuration.Tests[i].MultiStageTestConfiguration; test != nil {
+				m.migrateTest(test)
+			}
+		}
+		files = append(files, *file)
+		configs = append(configs, config.DataWithInfo{Configuration: *configuration, Info: *info})
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to migrate configurations.")
+	}
+	registryFiles, err := m.migrateRegistry(o.registryDir, &r)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to migrate the registry.")
+	}
+	if err := m.validate(o.registryDir, o.snapshotsDir, o.overlaysDir, configs, &r); err != nil {
+		logrus.WithError(err).Fatal("Failed to validate the migration.")
+	}
+	if err := r.write(o.reportPath); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	if len(r.Errors) != 0 {
+		logrus.Fatalf("The migration is not valid, %d errors found.", len(r.Errors))
+	}
+	if !o.Confirm {
+		logrus.Infof("Would rewrite %d configurations and %d registry files.", len(files), len(registryFiles))
+		return
+	}
+	for _, f := range append(files, registryFiles...) {
+		if err := os.WriteFile(f.path, f.raw, 0644); err != nil {
+			logrus.WithError(err).Fatal("Failed to write file.")
+		}
+	}
+}
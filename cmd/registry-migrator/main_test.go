@@ -0,0 +1,259 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+func TestMigrateTest(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	m := mapping{
+		References: map[string]string{"old-ref": "new-ref"},
+		Chains:     map[string]string{"old-chain": "new-chain"},
+		Workflows:  map[string]string{"old-workflow": "new-workflow"},
+		Parameters: map[string]string{"OLD_PARAM": "NEW_PARAM"},
+	}
+	test := api.MultiStageTestConfiguration{
+		Workflow: strPtr("old-workflow"),
+		Pre:      []api.TestStep{{Chain: strPtr("old-chain")}},
+		Test:     []api.TestStep{{Reference: strPtr("old-ref")}, {Reference: strPtr("other-ref")}},
+		Post:     []api.TestStep{{Reference: strPtr("old-ref@v2025-01-15")}},
+		Environment: api.TestEnvironment{
+			"OLD_PARAM":   "value",
+			"OTHER_PARAM": "other",
+		},
+	}
+	expected := api.MultiStageTestConfiguration{
+		Workflow: strPtr("new-workflow"),
+		Pre:      []api.TestStep{{Chain: strPtr("new-chain")}},
+		Test:     []api.TestStep{{Reference: strPtr("new-ref")}, {Reference: strPtr("other-ref")}},
+		Post:     []api.TestStep{{Reference: strPtr("old-ref@v2025-01-15")}},
+		Environment: api.TestEnvironment{
+			"NEW_PARAM":   "value",
+			"OTHER_PARAM": "other",
+		},
+	}
+	expectedChanges := []string{
+		"workflow old-workflow -> new-workflow",
+		"chain old-chain -> new-chain",
+		"ref old-ref -> new-ref",
+		"env OLD_PARAM -> NEW_PARAM",
+	}
+	changes := m.migrateTest(&test)
+	if diff := cmp.Diff(expectedChanges, changes); diff != "" {
+		t.Errorf("unexpected changes: %s", diff)
+	}
+	if diff := cmp.Diff(expected, test); diff != "" {
+		t.Errorf("unexpected test: %s", diff)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"new-ref/new-ref-ref.yaml":                      "ref:\n  as: new-ref\n  from: cli\n  commands: new-ref-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n  env:\n  - name: NEW_PARAM\n",
+		"new-ref/new-ref-commands.sh":                   "true\n",
+		"param/param-ref.yaml":                          "ref:\n  as: param\n  from: cli\n  commands: param-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n  env:\n  # read by the commands\n  - name: OLD_PARAM\n    default: \"\"\n",
+		"param/param-commands.sh":                       "echo \"${OLD_PARAM} $OLD_PARAM ${OLD_PARAM:-unset} $OLD_PARAM_SUFFIX\"\n",
+		"setup/setup-chain.yaml":                        "chain:\n  as: setup\n  # the steps\n  steps:\n  - ref: 'old-ref'\n  env:\n  - name: OLD_PARAM # overridden\n    default: value\n",
+		"cluster-profiles/cluster-profiles-config.yaml": "[]\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strPtr := func(s string) *string { return &s }
+	configs := []config.DataWithInfo{{
+		Configuration: api.ReleaseBuildConfiguration{
+			Tests: []api.TestStepConfiguration{{
+				As: "e2e",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test:        []api.TestStep{{Chain: strPtr("setup")}},
+					Environment: api.TestEnvironment{"NEW_PARAM": "value"},
+				},
+			}},
+		},
+		Info: config.Info{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}},
+	}}
+
+	for _, tc := range []struct {
+		name             string
+		mapping          mapping
+		expectedRegistry []fileReport
+		expectedFiles    map[string]string
+		expectedErrors   []string
+	}{
+		{
+			name: "valid migration",
+			mapping: mapping{
+				References: map[string]string{"old-ref": "new-ref"},
+				Parameters: map[string]string{"OLD_PARAM": "NEW_PARAM"},
+			},
+			expectedRegistry: []fileReport{
+				{
+					Path:    "param/param-ref.yaml",
+					Changes: []string{"env OLD_PARAM -> NEW_PARAM"},
+				},
+				{
+					Path:    "setup/setup-chain.yaml",
+					Changes: []string{"ref old-ref -> new-ref", "env OLD_PARAM -> NEW_PARAM"},
+				},
+			},
+			expectedFiles: map[string]string{
+				"param/param-ref.yaml":    "ref:\n  as: param\n  from: cli\n  commands: param-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n  env:\n  # read by the commands\n  - name: NEW_PARAM\n    default: \"\"\n",
+				"param/param-commands.sh": "echo \"${NEW_PARAM} $NEW_PARAM ${NEW_PARAM:-unset} $OLD_PARAM_SUFFIX\"\n",
+				"setup/setup-chain.yaml":  "chain:\n  as: setup\n  # the steps\n  steps:\n  - ref: 'new-ref'\n  env:\n  - name: NEW_PARAM # overridden\n    default: value\n",
+			},
+		},
+		{
+			name:    "parameter left behind",
+			mapping: mapping{References: map[string]string{"old-ref": "new-ref"}},
+			expectedRegistry: []fileReport{{
+				Path:    "setup/setup-chain.yaml",
+				Changes: []string{"ref old-ref -> new-ref"},
+			}},
+			expectedFiles: map[string]string{
+				"setup/setup-chain.yaml": "chain:\n  as: setup\n  # the steps\n  steps:\n  - ref: 'new-ref'\n  env:\n  - name: OLD_PARAM # overridden\n    default: value\n",
+			},
+			expectedErrors: []string{
+				`registry: chain/setup: parameter "OLD_PARAM" is overridden in [chain/setup] but not declared in any step`,
+				`org/repo/org-repo-main.yaml: Failed resolve MultiStageTestConfiguration: test/e2e: chain/setup: parameter "OLD_PARAM" is overridden in [chain/setup] but not declared in any step`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var r report
+			rewritten, err := tc.mapping.migrateRegistry(root, &r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actualFiles := map[string]string{}
+			for _, f := range rewritten {
+				rel, err := filepath.Rel(root, f.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				actualFiles[rel] = string(f.raw)
+			}
+			if diff := cmp.Diff(tc.expectedFiles, actualFiles); diff != "" {
+				t.Errorf("unexpected rewritten files: %s", diff)
+			}
+			if err := tc.mapping.validate(root, "", "", configs, &r); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedRegistry, r.Registry); diff != "" {
+				t.Errorf("unexpected registry report: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedErrors, r.Errors); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	m := mapping{
+		References: map[string]string{"old-ref": "new-ref"},
+		Chains:     map[string]string{"old-chain": "new-chain"},
+		Workflows:  map[string]string{"old-workflow": "new-workflow"},
+		Parameters: map[string]string{"OLD_PARAM": "NEW_PARAM"},
+	}
+	raw := `tests:
+# the end to end tests
+- as: e2e
+  steps:
+    workflow: "old-workflow" # shared with other repositories
+    env:
+      OLD_PARAM: old-ref
+    test:
+    - ref: old-ref
+    - chain: old-chain
+    - ref: old-ref@v2025-01-15
+- as: unit
+  commands: make test
+  container:
+    from: src
+zz_generated_metadata:
+  branch: main
+  org: org
+  repo: repo
+`
+	expected := `tests:
+# the end to end tests
+- as: e2e
+  steps:
+    workflow: "new-workflow" # shared with other repositories
+    env:
+      NEW_PARAM: old-ref
+    test:
+    - ref: new-ref
+    - chain: new-chain
+    - ref: old-ref@v2025-01-15
+- as: unit
+  commands: make test
+  container:
+    from: src
+zz_generated_metadata:
+  branch: main
+  org: org
+  repo: repo
+`
+	path := filepath.Join(t.TempDir(), "org-repo-main.yaml")
+	if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var r report
+	file, err := m.migrateConfig(path, &r, "org/repo/org-repo-main.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, string(file.raw)); diff != "" {
+		t.Errorf("unexpected configuration: %s", diff)
+	}
+	expectedReport := []fileReport{{
+		Path: "org/repo/org-repo-main.yaml",
+		Changes: []string{
+			"e2e: workflow old-workflow -> new-workflow",
+			"e2e: ref old-ref -> new-ref",
+			"e2e: chain old-chain -> new-chain",
+			"e2e: env OLD_PARAM -> NEW_PARAM",
+		},
+	}}
+	if diff := cmp.Diff(expectedReport, r.Configs); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+
+	// the rewritten file holds the configuration as it is validated
+	var original, migrated api.ReleaseBuildConfiguration
+	if err := yaml.Unmarshal([]byte(raw), &original); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(file.raw, &migrated); err != nil {
+		t.Fatal(err)
+	}
+	m.migrateTest(original.Tests[0].MultiStageTestConfiguration)
+	if diff := cmp.Diff(original, migrated); diff != "" {
+		t.Errorf("rewritten configuration differs from the migrated one: %s", diff)
+	}
+
+	unchanged := filepath.Join(t.TempDir(), "org-repo-other.yaml")
+	if err := os.WriteFile(unchanged, []byte("tests:\n- as: unit\n  commands: make test\n  container:\n    from: src\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if file, err := m.migrateConfig(unchanged, &r, "org/repo/org-repo-other.yaml"); err != nil || file != nil {
+		t.Errorf("expected configuration without changes not to be rewritten, got %v, %v", file, err)
+	}
+}
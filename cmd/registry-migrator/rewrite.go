@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// edits collects the renames in a YAML file. They are applied to the file
+// where the names are written, so that its comments and formatting are kept.
+type edits struct {
+	replacements []replacement
+	changes      []string
+}
+
+// replacement replaces the value of a scalar node.
+type replacement struct {
+	node *yamlv3.Node
+	to   string
+}
+
+func (e *edits) rename(names map[string]string, kind string, node *yamlv3.Node) {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return
+	}
+	if to, ok := renamed(names, node.Value); ok {
+		e.changes = append(e.changes, fmt.Sprintf("%s %s -> %s", kind, node.Value, to))
+		e.replacements = append(e.replacements, replacement{node: node, to: to})
+	}
+}
+
+// apply replaces the renamed values where they are written in the raw file,
+// leaving the rest of the file untouched.
+func (e *edits) apply(raw []byte) ([]byte, error) {
+	replacements := append([]replacement{}, e.replacements...)
+	// replace from the end, so that earlier positions stay valid
+	sort.Slice(replacements, func(i, j int) bool {
+		if replacements[i].node.Line != replacements[j].node.Line {
+			return replacements[i].node.Line > replacements[j].node.Line
+		}
+		return replacements[i].node.Column > replacements[j].node.Column
+	})
+	lines := strings.SplitAfter(string(raw), "\n")
+	for _, r := range replacements {
+		if r.node.Line < 1 || r.node.Line > len(lines) {
+			return nil, fmt.Errorf("could not find %s on line %d", r.node.Value, r.node.Line)
+		}
+		line := []rune(lines[r.node.Line-1])
+		// the column counts characters from 1, the value may be quoted
+		head, tail := string(line[:r.node.Column-1]), string(line[r.node.Column-1:])
+		if !strings.Contains(tail, r.node.Value) {
+			return nil, fmt.Errorf("could not find %s on line %d", r.node.Value, r.node.Line)
+		}
+		lines[r.node.Line-1] = head + strings.Replace(tail, r.node.Value, r.to, 1)
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// editSteps mirrors migrateSteps on the nodes of a list of steps.
+func (m *mapping) editSteps(steps *yamlv3.Node, e *edits) {
+	for _, step := range sequence(steps) {
+		e.rename(m.References, "ref", mappingValue(step, "ref"))
+		e.rename(m.Chains, "chain", mappingValue(step, "chain"))
+	}
+}
+
+// editTest mirrors migrateTest on the node of a multi-stage test or workflow.
+func (m *mapping) editTest(test *yamlv3.Node, e *edits) {
+	e.rename(m.Workflows, "workflow", mappingValue(test, "workflow"))
+	for _, phase := range []string{"pre", "test", "gather", "post"} {
+		m.editSteps(mappingValue(test, phase), e)
+	}
+	if env := mappingValue(test, "env"); env != nil && env.Kind == yamlv3.MappingNode {
+		for i := 0; i+1 < len(env.Content); i += 2 {
+			e.rename(m.Parameters, "env", env.Content[i])
+		}
+	}
+}
+
+// editParameters mirrors migrateParameters on the node of the parameters of
+// a chain or reference.
+func (m *mapping) editParameters(parameters *yamlv3.Node, e *edits) {
+	for _, parameter := range sequence(parameters) {
+		e.rename(m.Parameters, "env", mappingValue(parameter, "name"))
+	}
+}
+
+// editConfig collects the renames in the multi-stage tests of a ci-operator
+// configuration, the changes being prefixed with the name of their test.
+func (m *mapping) editConfig(document *yamlv3.Node) *edits {
+	ret := &edits{}
+	for _, test := range sequence(mappingValue(document, "tests")) {
+		var e edits
+		m.editTest(mappingValue(test, "steps"), &e)
+		var name string
+		if as := mappingValue(test, "as"); as != nil {
+			name = as.Value
+		}
+		ret.replacements = append(ret.replacements, e.replacements...)
+		for _, change := range e.changes {
+			ret.changes = append(ret.changes, fmt.Sprintf("%s: %s", name, change))
+		}
+	}
+	return ret
+}
+
+// renameParameters renames the parameters where the commands of a reference
+// expand them.
+func (m *mapping) renameParameters(commands string) string {
+	for _, from := range sortedKeys(m.Parameters) {
+		expansion := regexp.MustCompile(`\$(\{?)` + regexp.QuoteMeta(from) + `\b`)
+		commands = expansion.ReplaceAllString(commands, "$$${1}"+m.Parameters[from])
+	}
+	return commands
+}
+
+// mappingValue returns the value of the key in a mapping node, or nil.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node != nil && node.Kind == yamlv3.DocumentNode && len(node.Content) != 0 {
+		node = node.Content[0]
+	}
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequence returns the items of a sequence node, or nothing.
+func sequence(node *yamlv3.Node) []*yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
@@ -767,9 +767,13 @@ func getTestStepImages(config *api.ReleaseBuildConfiguration, images *sets.Set[s
 	if step.MultiStageTestConfigurationLiteral != nil {
 		getTestImages(config, images, step.MultiStageTestConfigurationLiteral.Pre)
 		getTestImages(config, images, step.MultiStageTestConfigurationLiteral.Test)
+		getTestImages(config, images, step.MultiStageTestConfigurationLiteral.Gather)
 		getTestImages(config, images, step.MultiStageTestConfigurationLiteral.Post)
 	} else if step.ContainerTestConfiguration != nil {
 		images.Insert(string(step.ContainerTestConfiguration.From))
+		for _, service := range step.ContainerTestConfiguration.Services {
+			images.Insert(string(service.From))
+		}
 	}
 }
 
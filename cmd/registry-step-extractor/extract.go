@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// location is an inline step in a configuration.
+type location struct {
+	config int
+	test   int
+	stage  string
+	index  int
+}
+
+// proposal is a reference replacing identical inline steps.
+type proposal struct {
+	name string
+	// existing is set when the steps are replaced with a reference which is
+	// already in the registry.
+	existing  bool
+	step      api.LiteralTestStep
+	locations []location
+}
+
+// normalize returns the step as a reference would define it, along with the
+// key identifying its content: steps only differing by their name or by
+// surrounding whitespace in their commands are considered identical.
+func normalize(step api.LiteralTestStep) (api.LiteralTestStep, string, error) {
+	normalized := *step.DeepCopy()
+	normalized.Commands = strings.TrimSpace(normalized.Commands) + "\n"
+	named := normalized.As
+	normalized.As = ""
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		return api.LiteralTestStep{}, "", fmt.Errorf("failed to marshal step %s: %w", step.As, err)
+	}
+	normalized.As = named
+	return normalized, string(raw), nil
+}
+
+// stages returns the steps of a test by the name of their stage.
+func stages(test *api.MultiStageTestConfiguration) []struct {
+	name  string
+	steps []api.TestStep
+} {
+	return []struct {
+		name  string
+		steps []api.TestStep
+	}{
+		{name: "pre", steps: test.Pre},
+		{name: "test", steps: test.Test},
+		{name: "gather", steps: test.Gather},
+		{name: "post", steps: test.Post},
+	}
+}
+
+// propose groups the identical inline steps of the configurations. Steps
+// identical to a reference of the registry are replaced with it, others are
+// extracted to a new reference when they are used at least minOccurrences
+// times. New references are named after the name the steps use most.
+func propose(configs []config.DataWithInfo, refs registry.ReferenceByName, minOccurrences int) ([]proposal, error) {
+	byContent := map[string]*proposal{}
+	var keys []string
+	for _, name := range sortedKeys(refs) {
+		step, key, err := normalize(refs[name])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byContent[key]; !ok {
+			byContent[key] = &proposal{name: name, existing: true, step: step}
+			keys = append(keys, key)
+		}
+	}
+	for i := range configs {
+		for j, test := range configs[i].Configuration.Tests {
+			if test.MultiStageTestConfiguration == nil {
+				continue
+			}
+			for _, stage := range stages(test.MultiStageTestConfiguration) {
+				for k, step := range stage.steps {
+					if step.LiteralTestStep == nil {
+						continue
+					}
+					normalized, key, err := normalize(*step.LiteralTestStep)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", configs[i].Info.RelativePath(), err)
+					}
+					p, ok := byContent[key]
+					if !ok {
+						p = &proposal{step: normalized}
+						byContent[key] = p
+						keys = append(keys, key)
+					}
+					p.locations = append(p.locations, location{config: i, test: j, stage: stage.name, index: k})
+				}
+			}
+		}
+	}
+
+	taken := names(refs)
+	var proposals []proposal
+	for _, key := range keys {
+		p := byContent[key]
+		if len(p.locations) == 0 || !p.existing && len(p.locations) < minOccurrences {
+			continue
+		}
+		if !p.existing {
+			p.name = uniqueName(mostUsedName(configs, p.locations), taken)
+			taken[p.name] = true
+			p.step.As = p.name
+		}
+		proposals = append(proposals, *p)
+	}
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].name < proposals[j].name })
+	return proposals, nil
+}
+
+func names(refs registry.ReferenceByName) map[string]bool {
+	ret := map[string]bool{}
+	for name := range refs {
+		ret[name] = true
+	}
+	return ret
+}
+
+func mostUsedName(configs []config.DataWithInfo, locations []location) string {
+	counts := map[string]int{}
+	for _, l := range locations {
+		counts[stepAt(configs, l).As]++
+	}
+	var ret string
+	for _, name := range sortedKeys(counts) {
+		if counts[name] > counts[ret] {
+			ret = name
+		}
+	}
+	return ret
+}
+
+// uniqueName suffixes the name with a number if a reference already uses it.
+func uniqueName(name string, taken map[string]bool) string {
+	ret := name
+	for i := 2; taken[ret]; i++ {
+		ret = fmt.Sprintf("%s-%d", name, i)
+	}
+	return ret
+}
+
+func stepAt(configs []config.DataWithInfo, l location) *api.LiteralTestStep {
+	test := configs[l.config].Configuration.Tests[l.test].MultiStageTestConfiguration
+	for _, stage := range stages(test) {
+		if stage.name == l.stage {
+			return stage.steps[l.index].LiteralTestStep
+		}
+	}
+	return nil
+}
+
+// rewrite replaces the inline steps with the references proposed for them,
+// returning the changes made to each configuration.
+func rewrite(configs []config.DataWithInfo, proposals []proposal) map[int][]string {
+	changes := map[int][]string{}
+	for _, p := range proposals {
+		for _, l := range p.locations {
+			test := configs[l.config].Configuration.Tests[l.test]
+			for _, stage := range stages(test.MultiStageTestConfiguration) {
+				if stage.name != l.stage {
+					continue
+				}
+				name := p.name
+				changes[l.config] = append(changes[l.config], fmt.Sprintf("%s: %s step %s -> ref %s", test.As, l.stage, stage.steps[l.index].As, name))
+				stage.steps[l.index] = api.TestStep{Reference: &name}
+			}
+		}
+	}
+	for _, c := range changes {
+		sort.Strings(c)
+	}
+	return changes
+}
+
+// registryFile is a file of the registry to write.
+type registryFile struct {
+	path string
+	raw  []byte
+}
+
+// referenceFiles returns the definition and the commands of a new reference,
+// laid out in the registry by the components of its name.
+func referenceFiles(p proposal) ([]registryFile, error) {
+	dir := filepath.Join(strings.Split(p.name, "-")...)
+	commands := p.name + load.CommandsSuffix + ".sh"
+	ref := api.RegistryReferenceConfig{Reference: api.RegistryReference{
+		LiteralTestStep: *p.step.DeepCopy(),
+		Documentation:   fmt.Sprintf("The %s step was extracted from %d identical inline steps of ci-operator configurations.", p.name, len(p.locations)),
+	}}
+	ref.Reference.Commands = commands
+	raw, err := yaml.Marshal(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reference %s: %w", p.name, err)
+	}
+	return []registryFile{
+		{path: filepath.Join(dir, p.name+load.RefSuffix), raw: raw},
+		{path: filepath.Join(dir, commands), raw: []byte(p.step.Commands)},
+	}, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
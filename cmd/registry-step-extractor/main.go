@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+type options struct {
+	config.ConfirmableOptions
+	registryDir    string
+	minOccurrences int
+	reportPath     string
+}
+
+func gatherOptions() options {
+	o := options{}
+	o.Bind(flag.CommandLine)
+	flag.StringVar(&o.registryDir, "registry", "", "Path to the step registry directory")
+	flag.IntVar(&o.minOccurrences, "min-occurrences", 2, "Number of identical inline steps from which a reference is extracted")
+	flag.StringVar(&o.reportPath, "report", "", "Path to write the extraction report to, printed to the standard output if unset")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if err := o.ConfirmableOptions.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.registryDir == "" {
+		errs = append(errs, errors.New("--registry is required"))
+	}
+	if o.minOccurrences < 2 {
+		errs = append(errs, errors.New("--min-occurrences must be at least 2"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// referenceReport describes a reference replacing inline steps.
+type referenceReport struct {
+	Name string `json:"name"`
+	// Existing is set when the reference was already in the registry.
+	Existing bool     `json:"existing,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Usages   []string `json:"usages"`
+}
+
+// fileReport lists the changes made to a file.
+type fileReport struct {
+	Path    string   `json:"path"`
+	Changes []string `json:"changes"`
+}
+
+type report struct {
+	References []referenceReport `json:"references,omitempty"`
+	Configs    []fileReport      `json:"configs,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+func (r *report) write(path string) error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+// extracted holds the outcome of the extraction.
+type extracted struct {
+	// configs are the configurations which changed
+	configs    []config.DataWithInfo
+	references registry.ReferenceByName
+	files      []registryFile
+}
+
+// extraction replaces the inline steps of the configurations with
+// references, recording the changes in the report.
+func extraction(configs []config.DataWithInfo, refs registry.ReferenceByName, minOccurrences int, r *report) (*extracted, error) {
+	proposals, err := propose(configs, refs, minOccurrences)
+	if err != nil {
+		return nil, err
+	}
+	ret := extracted{references: registry.ReferenceByName{}}
+	for _, p := range proposals {
+		reference := referenceReport{Name: p.name, Existing: p.existing}
+		for _, l := range p.locations {
+			reference.Usages = append(reference.Usages, fmt.Sprintf("%s: %s (%s)", configs[l.config].Info.RelativePath(), configs[l.config].Configuration.Tests[l.test].As, l.stage))
+		}
+		if !p.existing {
+			refFiles, err := referenceFiles(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range refFiles {
+				reference.Files = append(reference.Files, f.path)
+			}
+			ret.files = append(ret.files, refFiles...)
+			ret.references[p.name] = p.step
+		}
+		r.References = append(r.References, reference)
+	}
+	changes := rewrite(configs, proposals)
+	for i := range configs {
+		if len(changes[i]) == 0 {
+			continue
+		}
+		r.Configs = append(r.Configs, fileReport{Path: configs[i].Info.RelativePath(), Changes: changes[i]})
+		ret.configs = append(ret.configs, configs[i])
+	}
+	return &ret, nil
+}
+
+// validate resolves the rewritten configurations against the registry with
+// the new references, recording every failure in the report.
+func validate(registryDir string, e *extracted, r *report) error {
+	refs, chains, workflows, _, _, _, observers, err := load.Registry(registryDir, load.RegistryFlag(0))
+	if err != nil {
+		return fmt.Errorf("failed to load the registry: %w", err)
+	}
+	for name, ref := range e.references {
+		refs[name] = ref
+	}
+	if err := registry.Validate(refs, chains, workflows, observers); err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("registry: %v", err))
+	}
+	resolver := registry.NewResolver(refs, chains, workflows, observers)
+	for _, c := range e.configs {
+		// resolving changes the multi-stage tests the configurations share
+		// with the copy which is written out
+		if _, err := registry.ResolveConfig(resolver, *c.Configuration.DeepCopy()); err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", c.Info.RelativePath(), err))
+		}
+	}
+	return nil
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	if err := o.ConfirmableOptions.Complete(); err != nil {
+		logrus.Fatalf("Couldn't complete the config options: %v", err)
+	}
+	refs, _, _, _, _, _, _, err := load.Registry(o.registryDir, load.RegistryFlag(0))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load the registry.")
+	}
+
+	var configs []config.DataWithInfo
+	if err := o.OperateOnCIOperatorConfigDir(o.ConfigDir, func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+		configs = append(configs, config.DataWithInfo{Configuration: *configuration, Info: *info})
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Fatal("Failed to load configurations.")
+	}
+
+	var r report
+	e, err := extraction(configs, refs, o.minOccurrences, &r)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to extract references.")
+	}
+	if err := validate(o.registryDir, e, &r); err != nil {
+		logrus.WithError(err).Fatal("Failed to validate the extraction.")
+	}
+	if err := r.write(o.reportPath); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	if len(r.Errors) != 0 {
+		logrus.Fatalf("The extraction is not valid, %d errors found.", len(r.Errors))
+	}
+	if !o.Confirm {
+		logrus.Infof("Would rewrite %d configurations and write %d registry files.", len(e.configs), len(e.files))
+		return
+	}
+	for _, c := range e.configs {
+		if err := c.CommitTo(o.ConfigDir); err != nil {
+			logrus.WithError(err).Fatal("Failed to write configuration.")
+		}
+	}
+	for _, f := range e.files {
+		path := filepath.Join(o.registryDir, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			logrus.WithError(err).Fatal("Failed to create registry directory.")
+		}
+		if err := os.WriteFile(path, f.raw, 0644); err != nil {
+			logrus.WithError(err).Fatal("Failed to write registry file.")
+		}
+	}
+}
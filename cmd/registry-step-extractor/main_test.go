@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+)
+
+func TestExtraction(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"lint/lint-ref.yaml":                            "ref:\n  as: lint\n  from: src\n  commands: lint-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n",
+		"lint/lint-commands.sh":                         "make lint\n",
+		"cluster-profiles/cluster-profiles-config.yaml": "[]\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refs, _, _, _, _, _, _, err := load.Registry(root, load.RegistryFlag(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}}
+	step := func(as, commands string) api.TestStep {
+		return api.TestStep{LiteralTestStep: &api.LiteralTestStep{As: as, From: "src", Commands: commands, Resources: resources}}
+	}
+	configuration := func(repo string, steps ...api.TestStep) config.DataWithInfo {
+		return config.DataWithInfo{
+			Configuration: api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{{
+					As:                          "e2e",
+					MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Test: steps},
+				}},
+			},
+			Info: config.Info{Metadata: api.Metadata{Org: "org", Repo: repo, Branch: "main"}},
+		}
+	}
+	configs := []config.DataWithInfo{
+		configuration("first", step("unit", "make test\n"), step("check", "make lint")),
+		configuration("second", step("unit-tests", "  make test  "), step("only-here", "make once")),
+		configuration("third", step("unit", "make test")),
+	}
+
+	var r report
+	e, err := extraction(configs, refs, 2, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strPtr := func(s string) *string { return &s }
+	expectedChanged := []config.DataWithInfo{
+		configuration("first", api.TestStep{Reference: strPtr("unit")}, api.TestStep{Reference: strPtr("lint")}),
+		configuration("second", api.TestStep{Reference: strPtr("unit")}, step("only-here", "make once")),
+		configuration("third", api.TestStep{Reference: strPtr("unit")}),
+	}
+	if diff := cmp.Diff(expectedChanged, e.configs, cmp.AllowUnexported(config.Info{})); diff != "" {
+		t.Errorf("unexpected configurations: %s", diff)
+	}
+	expectedReport := report{
+		References: []referenceReport{
+			{Name: "lint", Existing: true, Usages: []string{"org/first/org-first-main.yaml: e2e (test)"}},
+			{
+				Name:  "unit",
+				Files: []string{"unit/unit-ref.yaml", "unit/unit-commands.sh"},
+				Usages: []string{
+					"org/first/org-first-main.yaml: e2e (test)",
+					"org/second/org-second-main.yaml: e2e (test)",
+					"org/third/org-third-main.yaml: e2e (test)",
+				},
+			},
+		},
+		Configs: []fileReport{
+			{Path: "org/first/org-first-main.yaml", Changes: []string{"e2e: test step check -> ref lint", "e2e: test step unit -> ref unit"}},
+			{Path: "org/second/org-second-main.yaml", Changes: []string{"e2e: test step unit-tests -> ref unit"}},
+			{Path: "org/third/org-third-main.yaml", Changes: []string{"e2e: test step unit -> ref unit"}},
+		},
+	}
+	if diff := cmp.Diff(expectedReport, r); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+	expectedFiles := map[string]string{
+		"unit/unit-ref.yaml": `ref:
+  as: unit
+  commands: unit-commands.sh
+  documentation: The unit step was extracted from 3 identical inline steps of ci-operator
+    configurations.
+  from: src
+  resources:
+    requests:
+      cpu: 100m
+`,
+		"unit/unit-commands.sh": "make test\n",
+	}
+	actualFiles := map[string]string{}
+	for _, f := range e.files {
+		actualFiles[f.path] = string(f.raw)
+	}
+	if diff := cmp.Diff(expectedFiles, actualFiles); diff != "" {
+		t.Errorf("unexpected registry files: %s", diff)
+	}
+
+	// resolving a test propagates its architecture into the multi-stage
+	// configuration, which must not leak into the files written out
+	e.configs[2].Configuration.Tests[0].NodeArchitecture = api.NodeArchitectureARM64
+	expectedChanged[2].Configuration.Tests[0].NodeArchitecture = api.NodeArchitectureARM64
+	if err := validate(root, e, &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Errors) != 0 {
+		t.Errorf("unexpected errors: %v", r.Errors)
+	}
+	// the configurations written out keep their references
+	if diff := cmp.Diff(expectedChanged, e.configs, cmp.AllowUnexported(config.Info{})); diff != "" {
+		t.Errorf("validation changed the configurations: %s", diff)
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	taken := map[string]bool{"unit": true, "unit-2": true}
+	if name := uniqueName("unit", taken); name != "unit-3" {
+		t.Errorf("expected unit-3, got %s", name)
+	}
+	if name := uniqueName("e2e", taken); name != "e2e" {
+		t.Errorf("expected e2e, got %s", name)
+	}
+}
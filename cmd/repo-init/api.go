@@ -462,13 +462,13 @@ func (s server) validateConfig(w http.ResponseWriter, r *http.Request) {
 
 		switch validationType {
 		case All:
-			if err := validation.IsValidConfiguration(generated, configRequest.Config.Org, configRequest.Config.Repo); err != nil {
+			if _, err := validation.IsValidConfiguration(generated, configRequest.Config.Org, configRequest.Config.Repo); err != nil {
 				validationErrors = append(validationErrors, err)
 			}
 		case BaseImages:
 			validationErrors = append(validationErrors, validation.ValidateBaseImages(context.AddField("base_images"), generated.BaseImages)...)
 		case ContainerImages:
-			validation.ValidateImages(context.AddField("images"), generated.Images)
+			validation.ValidateImages(context.AddField("images"), generated.Images, api.DefaultArchitectures())
 		case OperatorBundle:
 			validationErrors = append(validationErrors, validation.ValidateOperator(context.AddField("operator_bundle"), generated)...)
 		case Tests:
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	pjclientset "sigs.k8s.io/prow/pkg/client/clientset/versioned"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/attestation"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+type options struct {
+	gcsPath            string
+	gcsCredentialsFile string
+	namespace          string
+	dryRun             bool
+}
+
+func gatherOptions() options {
+	o := options{}
+	flag.StringVar(&o.gcsPath, "gcs-path", "", "Path of the run to reproduce, e.g. gs://test-platform-results/logs/<job>/<build>")
+	flag.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored")
+	flag.StringVar(&o.namespace, "prowjob-namespace", "ci", "Namespace to create the ProwJob in")
+	flag.BoolVar(&o.dryRun, "dry-run", false, "Print the ProwJob instead of submitting it")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if _, _, err := splitGCSPath(o.gcsPath); err != nil {
+		errs = append(errs, err)
+	}
+	if o.gcsCredentialsFile == "" {
+		errs = append(errs, errors.New("--gcs-credentials-file is required"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// splitGCSPath splits a gs:// path into its bucket and the path of the run.
+func splitGCSPath(gcsPath string) (string, string, error) {
+	trimmed, found := strings.CutPrefix(gcsPath, "gs://")
+	if !found {
+		return "", "", fmt.Errorf("--gcs-path must be a gs:// path, got %q", gcsPath)
+	}
+	bucket, dir, _ := strings.Cut(strings.TrimSuffix(trimmed, "/"), "/")
+	if bucket == "" || dir == "" {
+		return "", "", fmt.Errorf("--gcs-path must name a bucket and the path of a run, got %q", gcsPath)
+	}
+	return bucket, dir, nil
+}
+
+// run holds the artifacts of a previous run.
+type run struct {
+	bucket *storage.BucketHandle
+	dir    string
+}
+
+func (r *run) read(ctx context.Context, name string, into interface{}) error {
+	reader, err := r.bucket.Object(path.Join(r.dir, name)).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(raw, into); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *run) reproduction(ctx context.Context) (*prowapi.ProwJob, error) {
+	var original prowapi.ProwJob
+	if err := r.read(ctx, "prowjob.json", &original); err != nil {
+		return nil, err
+	}
+	var attested attestation.Attestation
+	if err := r.read(ctx, path.Join("artifacts", attestation.ArtifactFilename), &attested); err != nil {
+		return nil, fmt.Errorf("%w, the run may predate attestations", err)
+	}
+	var records []clone.Record
+	if err := r.read(ctx, "clone-records.json", &records); err != nil {
+		if !errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, err
+		}
+		logrus.Debug("The run did not clone any repository.")
+	}
+	return reproduction(&original, &attested, records)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	bucket, dir, _ := splitGCSPath(o.gcsPath)
+
+	ctx := context.Background()
+	gcsClient, err := storage.NewClient(ctx, option.WithCredentialsFile(o.gcsCredentialsFile))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create GCS client.")
+	}
+	r := run{bucket: gcsClient.Bucket(bucket), dir: dir}
+	prowjob, err := r.reproduction(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to reproduce the run.")
+	}
+
+	if o.dryRun {
+		raw, err := yaml.Marshal(prowjob)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to marshal the ProwJob.")
+		}
+		fmt.Println(string(raw))
+		return
+	}
+
+	clusterConfig, err := util.LoadClusterConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load cluster configuration.")
+	}
+	pjcset, err := pjclientset.NewForConfig(clusterConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create ProwJob clientset.")
+	}
+	created, err := pjcset.ProwV1().ProwJobs(o.namespace).Create(ctx, prowjob, metav1.CreateOptions{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to submit the ProwJob.")
+	}
+	logrus.WithFields(pjutil.ProwJobFields(created)).Info("Submitted the reproduction of the run.")
+}
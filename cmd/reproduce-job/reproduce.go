@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pjutil"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/attestation"
+	"github.com/openshift/ci-tools/pkg/util/gzip"
+)
+
+// reproducesAnnotation holds the name of the job a job reproduces.
+const reproducesAnnotation = "ci.openshift.io/reproduces"
+
+// configVariables are the variables ci-operator reads its configuration
+// from, which are replaced by the attested configuration.
+var configVariables = sets.New[string]("CONFIG_SPEC", "CONFIG_SPEC_GCS_URL", "UNRESOLVED_CONFIG")
+
+// promotionFlags make ci-operator promote images, which a reproduction must
+// never do.
+var promotionFlags = sets.New[string]("promote", "promote-dry-run")
+
+// reproduction creates a job running the same ci-operator configuration on
+// the same commits as a previous run. The configuration is the one recorded
+// in the attestation of the run, already resolved, so later changes to the
+// configuration or to the step registry do not affect the reproduction.
+// Commits which were not pinned by the job, e.g. the ones periodics cloned
+// from a branch, are read from the records of clonerefs.
+func reproduction(original *prowapi.ProwJob, attested *attestation.Attestation, records []clone.Record) (*prowapi.ProwJob, error) {
+	if err := attested.VerifyConfig(); err != nil {
+		return nil, fmt.Errorf("cannot use the configuration of the run: %w", err)
+	}
+	spec := original.Spec.DeepCopy()
+	if spec.PodSpec == nil || len(spec.PodSpec.Containers) == 0 {
+		return nil, fmt.Errorf("job %s does not run ci-operator in a pod", spec.Job)
+	}
+
+	spec.Refs = attested.Job.Refs.DeepCopy()
+	spec.ExtraRefs = nil
+	for _, refs := range attested.Job.ExtraRefs {
+		spec.ExtraRefs = append(spec.ExtraRefs, *refs.DeepCopy())
+	}
+	if spec.Refs != nil {
+		if err := pinRefs(spec.Refs, records); err != nil {
+			return nil, err
+		}
+	}
+	for i := range spec.ExtraRefs {
+		if err := pinRefs(&spec.ExtraRefs[i], records); err != nil {
+			return nil, err
+		}
+	}
+
+	container := &spec.PodSpec.Containers[0]
+	for _, arg := range container.Args {
+		for _, flag := range []string{"--config", "--unresolved-config"} {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return nil, fmt.Errorf("job %s passes its configuration with %s, which cannot be replaced", spec.Job, flag)
+			}
+		}
+	}
+	var args []string
+	for _, arg := range container.Args {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !promotionFlags.Has(name) {
+			args = append(args, arg)
+		}
+	}
+	container.Args = args
+	raw, err := yaml.Marshal(attested.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the configuration: %w", err)
+	}
+	config, err := gzip.CompressStringAndBase64(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compress and base64 encode CONFIG_SPEC: %w", err)
+	}
+	var env []corev1.EnvVar
+	for _, e := range container.Env {
+		if !configVariables.Has(e.Name) {
+			env = append(env, e)
+		}
+	}
+	container.Env = append(env, corev1.EnvVar{Name: "CONFIG_SPEC", Value: config})
+
+	// the reproduction must not report to the pull request or to Slack as
+	// if it were the original job
+	spec.Report = false
+	spec.ReporterConfig = nil
+
+	job := pjutil.NewProwJob(*spec, nil, map[string]string{reproducesAnnotation: original.Name})
+	return &job, nil
+}
+
+// pinRefs sets the commits the refs were tested at when the job did not pin
+// them, as clonerefs recorded them.
+func pinRefs(refs *prowapi.Refs, records []clone.Record) error {
+	for _, pull := range refs.Pulls {
+		if pull.SHA == "" {
+			return fmt.Errorf("%s/%s#%d: the commit of the pull request is unknown", refs.Org, refs.Repo, pull.Number)
+		}
+	}
+	if refs.BaseSHA != "" {
+		return nil
+	}
+	for _, record := range records {
+		if record.Refs.Org != refs.Org || record.Refs.Repo != refs.Repo || record.Refs.BaseRef != refs.BaseRef {
+			continue
+		}
+		// the final commit of refs with pull requests is a merge which
+		// cannot be checked out again
+		if record.Failed || record.FinalSHA == "" || len(refs.Pulls) > 0 {
+			break
+		}
+		refs.BaseSHA = record.FinalSHA
+		return nil
+	}
+	return fmt.Errorf("%s/%s@%s: the commit which was tested is unknown", refs.Org, refs.Repo, refs.BaseRef)
+}
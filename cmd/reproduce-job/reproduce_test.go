@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/clone"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/attestation"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	"github.com/openshift/ci-tools/pkg/util/gzip"
+)
+
+func TestReproduction(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{{As: "unit", Commands: "make test"}}}
+	digest, err := attestation.ConfigDigest(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configSpec, err := gzip.CompressStringAndBase64(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := func(env ...corev1.EnvVar) *prowapi.ProwJob {
+		return &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "original"},
+			Spec: prowapi.ProwJobSpec{
+				Type:           prowapi.PeriodicJob,
+				Job:            "periodic-ci-org-repo-master-unit",
+				Report:         true,
+				ReporterConfig: &prowapi.ReporterConfig{Slack: &prowapi.SlackReporterConfig{Channel: "#ci"}},
+				PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+					Args: []string{"--target=unit"},
+					Env:  env,
+				}}},
+			},
+		}
+	}
+	branch := prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"}
+	pull := prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "base", Pulls: []prowapi.Pull{{Number: 1, SHA: "head"}}}
+
+	for _, tc := range []struct {
+		name        string
+		original    *prowapi.ProwJob
+		job         prowdapi.JobSpec
+		config      *api.ReleaseBuildConfiguration
+		records     []clone.Record
+		expected    prowapi.ProwJobSpec
+		expectedErr error
+	}{
+		{
+			name:     "periodic is pinned to the commit it cloned",
+			original: original(corev1.EnvVar{Name: "UNRESOLVED_CONFIG", Value: "tests: []"}, corev1.EnvVar{Name: "JOB_VAR", Value: "value"}),
+			job:      prowdapi.JobSpec{ExtraRefs: []prowapi.Refs{branch}},
+			config:   config,
+			records:  []clone.Record{{Refs: branch, FinalSHA: "cloned"}},
+			expected: prowapi.ProwJobSpec{
+				Type:      prowapi.PeriodicJob,
+				Job:       "periodic-ci-org-repo-master-unit",
+				ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "cloned"}},
+				PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+					Args: []string{"--target=unit"},
+					Env:  []corev1.EnvVar{{Name: "JOB_VAR", Value: "value"}, {Name: "CONFIG_SPEC", Value: configSpec}},
+				}}},
+			},
+		},
+		{
+			name:     "presubmit keeps the commits it tested",
+			original: original(),
+			job:      prowdapi.JobSpec{Refs: &pull},
+			config:   config,
+			expected: prowapi.ProwJobSpec{
+				Type: prowapi.PeriodicJob,
+				Job:  "periodic-ci-org-repo-master-unit",
+				Refs: &pull,
+				PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+					Args: []string{"--target=unit"},
+					Env:  []corev1.EnvVar{{Name: "CONFIG_SPEC", Value: configSpec}},
+				}}},
+			},
+		},
+		{
+			name: "postsubmit does not promote",
+			original: func() *prowapi.ProwJob {
+				job := original()
+				job.Spec.PodSpec.Containers[0].Args = []string{"--promote", "--target=unit", "--promote-dry-run=true", "-promote"}
+				return job
+			}(),
+			job:    prowdapi.JobSpec{Refs: &pull},
+			config: config,
+			expected: prowapi.ProwJobSpec{
+				Type: prowapi.PeriodicJob,
+				Job:  "periodic-ci-org-repo-master-unit",
+				Refs: &pull,
+				PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+					Args: []string{"--target=unit"},
+					Env:  []corev1.EnvVar{{Name: "CONFIG_SPEC", Value: configSpec}},
+				}}},
+			},
+		},
+		{
+			name:        "commit of a branch was not recorded",
+			original:    original(),
+			job:         prowdapi.JobSpec{ExtraRefs: []prowapi.Refs{branch}},
+			config:      config,
+			records:     []clone.Record{{Refs: branch, Failed: true}},
+			expectedErr: errors.New("org/repo@master: the commit which was tested is unknown"),
+		},
+		{
+			name:        "configuration was not recorded",
+			original:    original(),
+			job:         prowdapi.JobSpec{Refs: &pull},
+			expectedErr: errors.New("cannot use the configuration of the run: the attestation does not record the configuration"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			attested := &attestation.Attestation{Job: tc.job, ConfigDigest: digest, Config: tc.config}
+			job, err := reproduction(tc.original, attested, tc.records)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err != nil {
+				return
+			}
+			testhelper.Diff(t, "spec", job.Spec, tc.expected)
+			testhelper.Diff(t, "reproduced job", job.Annotations[reproducesAnnotation], "original")
+		})
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -40,10 +43,46 @@ var (
 		},
 		[]string{"workload_name", "workload_type", "configured_amount", "determined_amount", "resource_type"},
 	)
+	quotaWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ci_operator_quota_wait_seconds",
+			Help:    "time jobs waited for the reservation of their cluster profile, sorted by profile/type",
+			Buckets: []float64{0, 60, 300, 900, 1800, 3600, 7200},
+		},
+		[]string{"profile", "type"},
+	)
+	versionSkew = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_version_skew_total",
+			Help: "number of jobs run by a ci-operator binary older than the deployed version, sorted by age/type/cluster",
+		},
+		[]string{"age", "type", "cluster"},
+	)
+	reusedResults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_reused_results_total",
+			Help: "number of presubmit targets which reported an earlier result instead of running again, sorted by job_name/type/cluster",
+		},
+		[]string{"job_name", "type", "cluster"},
+	)
+	featureGates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_feature_gates_total",
+			Help: "number of jobs run with each feature gate enabled or disabled, sorted by gate/enabled/org/type",
+		},
+		[]string{"gate", "enabled", "org", "type"},
+	)
+	podAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ci_operator_pod_api_errors_total",
+			Help: "errors returned by the pod exec and log APIs to jobs, sorted by operation/category/retried/type/cluster",
+		},
+		[]string{"operation", "category", "retried", "type", "cluster"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(errorRate, podScalerHighResourceCounter)
+	prometheus.MustRegister(errorRate, podScalerHighResourceCounter, quotaWaitSeconds, versionSkew, reusedResults, featureGates, podAPIErrors)
 }
 
 type options struct {
@@ -142,6 +181,129 @@ func recordHighResource(request *results.PodScalerRequest) {
 	podScalerHighResourceCounter.With(labels).Inc()
 }
 
+func validateQuotaWaitRequest(request *results.QuotaWaitRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if request.Profile == "" {
+		return fmt.Errorf("profile field in request is empty")
+	}
+	if request.WaitSeconds < 0 {
+		return fmt.Errorf("wait_seconds field in request is negative")
+	}
+	return nil
+}
+
+func recordQuotaWait(request *results.QuotaWaitRequest) {
+	labels := prometheus.Labels{
+		"profile": request.Profile,
+		"type":    request.Type,
+	}
+	quotaWaitSeconds.With(labels).Observe(request.WaitSeconds)
+}
+
+func validateVersionSkewRequest(request *results.VersionSkewRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if request.Cluster == "" {
+		return fmt.Errorf("cluster field in request is empty")
+	}
+	if request.Version == "" {
+		return fmt.Errorf("version field in request is empty")
+	}
+	return nil
+}
+
+// localRun replaces the job and type of version skews reported by runs which
+// are not Prow jobs.
+const localRun = "local"
+
+// releasedVersion matches the versions of the binaries built by
+// hack/install.sh from a clean checkout: v${build_date}-${git_commit}
+var releasedVersion = regexp.MustCompile(`^v(\d{8})-`)
+
+// skewBuckets bound how much older than the deployed version a binary is for
+// each value of the age label, so that the label has a fixed set of values
+// whatever versions are reported.
+var skewBuckets = []struct {
+	name string
+	days int
+}{
+	{name: "week", days: 7},
+	{name: "month", days: 31},
+	{name: "quarter", days: 92},
+}
+
+// skewBucket classifies how much older than the deployed version a binary is.
+// Binaries built locally cannot be compared, neither can any binary when the
+// deployed version is not known.
+func skewBucket(version, advertised string) string {
+	buildDate := func(v string) (time.Time, bool) {
+		m := releasedVersion.FindStringSubmatch(v)
+		if m == nil || strings.HasSuffix(v, "-dirty") {
+			return time.Time{}, false
+		}
+		date, err := time.Parse("20060102", m[1])
+		return date, err == nil
+	}
+	built, ok := buildDate(version)
+	if !ok {
+		return "locally-built"
+	}
+	deployed, ok := buildDate(advertised)
+	if !ok {
+		return "unknown"
+	}
+	days := int(deployed.Sub(built).Hours() / 24)
+	for _, bucket := range skewBuckets {
+		if days <= bucket.days {
+			return bucket.name
+		}
+	}
+	return "older"
+}
+
+func recordVersionSkew(request *results.VersionSkewRequest) {
+	labels := prometheus.Labels{
+		"age":     skewBucket(request.Version, request.AdvertisedVersion),
+		"type":    request.Type,
+		"cluster": request.Cluster,
+	}
+	versionSkew.With(labels).Inc()
+}
+
+func validateReusedResultRequest(request *results.ReusedResultRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if request.Cluster == "" {
+		return fmt.Errorf("cluster field in request is empty")
+	}
+	if request.Target == "" {
+		return fmt.Errorf("target field in request is empty")
+	}
+	return nil
+}
+
+func recordReusedResult(request *results.ReusedResultRequest) {
+	labels := prometheus.Labels{
+		"job_name": request.JobName,
+		"type":     request.Type,
+		"cluster":  request.Cluster,
+	}
+	reusedResults.With(labels).Inc()
+}
+
 type validator interface {
 	Validate(username, password string) bool
 }
@@ -212,6 +374,207 @@ func handlePodScalerResult() http.HandlerFunc {
 	}
 }
 
+func handleQuotaWait() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read quota wait request body: %w", err))
+			return
+		}
+
+		request := &results.QuotaWaitRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode quota wait request body: %w", err))
+			return
+		}
+
+		if err := validateQuotaWaitRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordQuotaWait(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Quota wait request processed")
+	}
+}
+
+// handleVersionSkew records the version skew of jobs. The skew of runs which
+// are not Prow jobs is recorded under a single job and type, as their names
+// are made up by whoever runs them.
+func handleVersionSkew(local bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read version skew request body: %w", err))
+			return
+		}
+
+		request := &results.VersionSkewRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode version skew request body: %w", err))
+			return
+		}
+		if local {
+			request.JobName, request.Type = localRun, localRun
+		}
+
+		if err := validateVersionSkewRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordVersionSkew(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Version skew request processed")
+	}
+}
+
+func validateFeatureGatesRequest(request *results.FeatureGatesRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if request.Cluster == "" {
+		return fmt.Errorf("cluster field in request is empty")
+	}
+	if len(request.Gates) == 0 {
+		return fmt.Errorf("gates field in request is empty")
+	}
+	return nil
+}
+
+func recordFeatureGates(request *results.FeatureGatesRequest) {
+	for gate, enabled := range request.Gates {
+		labels := prometheus.Labels{
+			"gate":    gate,
+			"enabled": strconv.FormatBool(enabled),
+			"org":     request.Org,
+			"type":    request.Type,
+		}
+		featureGates.With(labels).Inc()
+	}
+}
+
+func validatePodAPIErrorsRequest(request *results.PodAPIErrorsRequest) error {
+	if request.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if request.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if request.Cluster == "" {
+		return fmt.Errorf("cluster field in request is empty")
+	}
+	if len(request.Errors) == 0 {
+		return fmt.Errorf("errors field in request is empty")
+	}
+	for i, count := range request.Errors {
+		if count.Operation == "" || count.Category == "" {
+			return fmt.Errorf("errors[%d] has an empty operation or category", i)
+		}
+		if count.Count <= 0 {
+			return fmt.Errorf("errors[%d].count must be positive, got %d", i, count.Count)
+		}
+	}
+	return nil
+}
+
+func recordPodAPIErrors(request *results.PodAPIErrorsRequest) {
+	for _, count := range request.Errors {
+		labels := prometheus.Labels{
+			"operation": count.Operation,
+			"category":  count.Category,
+			"retried":   strconv.FormatBool(count.Retried),
+			"type":      request.Type,
+			"cluster":   request.Cluster,
+		}
+		podAPIErrors.With(labels).Add(float64(count.Count))
+	}
+}
+
+func handleReusedResult() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read reused result request body: %w", err))
+			return
+		}
+
+		request := &results.ReusedResultRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode reused result request body: %w", err))
+			return
+		}
+
+		if err := validateReusedResultRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordReusedResult(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Reused result request processed")
+	}
+}
+
+func handleFeatureGates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read feature gates request body: %w", err))
+			return
+		}
+
+		request := &results.FeatureGatesRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode feature gates request body: %w", err))
+			return
+		}
+
+		if err := validateFeatureGatesRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordFeatureGates(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Feature gates request processed")
+	}
+}
+
+func handlePodAPIErrors() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read pod API errors request body: %w", err))
+			return
+		}
+
+		request := &results.PodAPIErrorsRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode pod API errors request body: %w", err))
+			return
+		}
+
+		if err := validatePodAPIErrorsRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordPodAPIErrors(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Pod API errors request processed")
+	}
+}
+
 func main() {
 	o, err := gatherOptions()
 	if err != nil {
@@ -232,6 +595,12 @@ func main() {
 
 	http.Handle("/result", loginHandler(validator, handleCIOperatorResult()))
 	http.Handle("/pod-scaler", loginHandler(validator, handlePodScalerResult()))
+	http.Handle("/quota-wait", loginHandler(validator, handleQuotaWait()))
+	http.Handle("/version-skew", loginHandler(validator, handleVersionSkew(false)))
+	http.Handle("/local-version-skew", loginHandler(validator, handleVersionSkew(true)))
+	http.Handle("/reused-result", loginHandler(validator, handleReusedResult()))
+	http.Handle("/feature-gates", loginHandler(validator, handleFeatureGates()))
+	http.Handle("/pod-api-errors", loginHandler(validator, handlePodAPIErrors()))
 
 	metrics.ExposeMetrics("result-aggregator", prowConfig.PushGateway{}, flagutil.DefaultMetricsPort)
 
@@ -234,3 +234,170 @@ func TestValidatePodScalerRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateQuotaWaitRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.QuotaWaitRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.QuotaWaitRequest{JobName: "job", Type: "periodic", Profile: "aws", WaitSeconds: 30},
+		},
+		{
+			name:     "empty profile",
+			request:  &results.QuotaWaitRequest{JobName: "job", Type: "periodic", WaitSeconds: 30},
+			expected: fmt.Errorf("profile field in request is empty"),
+		},
+		{
+			name:     "negative wait",
+			request:  &results.QuotaWaitRequest{JobName: "job", Type: "periodic", Profile: "aws", WaitSeconds: -1},
+			expected: fmt.Errorf("wait_seconds field in request is negative"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validateQuotaWaitRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestValidateVersionSkewRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.VersionSkewRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.VersionSkewRequest{JobName: "job", Type: "periodic", Cluster: "build01", Version: "v20240101-abc", AdvertisedVersion: "v20240201-def"},
+		},
+		{
+			name:    "the advertised version is not required",
+			request: &results.VersionSkewRequest{JobName: "job", Type: "periodic", Cluster: "build01", Version: "v20240101-abc"},
+		},
+		{
+			name:     "empty version",
+			request:  &results.VersionSkewRequest{JobName: "job", Type: "periodic", Cluster: "build01", AdvertisedVersion: "v20240201-def"},
+			expected: fmt.Errorf("version field in request is empty"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validateVersionSkewRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestSkewBucket(t *testing.T) {
+	for _, tc := range []struct {
+		version, advertised, expected string
+	}{
+		{version: "v20240125-0123456789", advertised: "v20240201-abc", expected: "week"},
+		{version: "v20240101-0123456789", advertised: "v20240201-abc", expected: "month"},
+		{version: "v20231201-0123456789", advertised: "v20240201-abc", expected: "quarter"},
+		{version: "v20200101-0123456789", advertised: "v20240201-abc", expected: "older"},
+		{version: "v20240125-0123456789-dirty", advertised: "v20240201-abc", expected: "locally-built"},
+		{version: "1.32.4", advertised: "v20240201-abc", expected: "locally-built"},
+		{version: "v20240125-0123456789", expected: "unknown"},
+	} {
+		if actual := skewBucket(tc.version, tc.advertised); actual != tc.expected {
+			t.Errorf("%s against %q: expected %s, got %s", tc.version, tc.advertised, tc.expected, actual)
+		}
+	}
+}
+
+func TestValidateFeatureGatesRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.FeatureGatesRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.FeatureGatesRequest{JobName: "job", Type: "presubmit", Cluster: "build01", Org: "org", Gates: map[string]bool{"ResultReuse": true}},
+		},
+		{
+			name:     "no gates",
+			request:  &results.FeatureGatesRequest{JobName: "job", Type: "presubmit", Cluster: "build01", Org: "org"},
+			expected: fmt.Errorf("gates field in request is empty"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validateFeatureGatesRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePodAPIErrorsRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.PodAPIErrorsRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.PodAPIErrorsRequest{JobName: "job", Type: "presubmit", Cluster: "build01", Errors: []results.PodAPIErrorCount{{Operation: "logs", Category: "server", Retried: true, Count: 1}}},
+		},
+		{
+			name:     "no errors",
+			request:  &results.PodAPIErrorsRequest{JobName: "job", Type: "presubmit", Cluster: "build01"},
+			expected: fmt.Errorf("errors field in request is empty"),
+		},
+		{
+			name:     "no count",
+			request:  &results.PodAPIErrorsRequest{JobName: "job", Type: "presubmit", Cluster: "build01", Errors: []results.PodAPIErrorCount{{Operation: "logs", Category: "server"}}},
+			expected: fmt.Errorf("errors[0].count must be positive, got 0"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validatePodAPIErrorsRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestValidateReusedResultRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.ReusedResultRequest
+		expected error
+	}{
+		{
+			name:    "everything ok",
+			request: &results.ReusedResultRequest{JobName: "job", Type: "presubmit", Cluster: "build01", Target: "unit"},
+		},
+		{
+			name:     "empty target",
+			request:  &results.ReusedResultRequest{JobName: "job", Type: "presubmit", Cluster: "build01"},
+			expected: fmt.Errorf("target field in request is empty"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validateReusedResultRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
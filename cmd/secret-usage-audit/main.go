@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/secretaudit"
+)
+
+type options struct {
+	configDir           string
+	registryDir         string
+	snapshotsDir        string
+	overlaysDir         string
+	bootstrapConfigPath string
+	namespaces          flagutil.Strings
+	gcsBucket           string
+	gcsCredentialsFile  string
+	staleAfter          time.Duration
+	reportPath          string
+}
+
+func gatherOptions() options {
+	o := options{namespaces: flagutil.NewStrings("test-credentials")}
+	flag.StringVar(&o.configDir, "config-dir", "", "Path to the CI Operator configuration directory")
+	flag.StringVar(&o.registryDir, "registry", "", "Path to the step registry")
+	flag.StringVar(&o.snapshotsDir, "registry-snapshots", "", "Path to the stored snapshots of the step registry, which versions pinned with name@version are resolved from")
+	flag.StringVar(&o.overlaysDir, "registry-overlays", "", "Path to the registry overlays applied to configurations on matching branches")
+	flag.StringVar(&o.bootstrapConfigPath, "bootstrap-config", "", "Path to the configuration of ci-secret-bootstrap, the inventory of secrets")
+	flag.Var(&o.namespaces, "namespace", "Namespace whose secrets are audited, can be passed multiple times")
+	flag.StringVar(&o.gcsBucket, "gcs-bucket", "test-platform-results", "GCS bucket holding the results of the jobs")
+	flag.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored")
+	flag.DurationVar(&o.staleAfter, "stale-after", 30*24*time.Hour, "Time since the last run of the jobs mounting a secret after which it is reported as stale")
+	flag.StringVar(&o.reportPath, "report", "", "Path to write the report to, printed to the standard output if unset")
+	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.configDir == "" {
+		errs = append(errs, errors.New("--config-dir is required"))
+	}
+	if o.registryDir == "" {
+		errs = append(errs, errors.New("--registry is required"))
+	}
+	if o.bootstrapConfigPath == "" {
+		errs = append(errs, errors.New("--bootstrap-config is required"))
+	}
+	if o.gcsCredentialsFile == "" {
+		errs = append(errs, errors.New("--gcs-credentials-file is required"))
+	}
+	if o.staleAfter <= 0 {
+		errs = append(errs, errors.New("--stale-after must be positive"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func loadIndex(configDir, registryDir, snapshotsDir, overlaysDir string) (secretaudit.Index, error) {
+	resolver, err := load.RegistryResolver(registryDir, snapshotsDir, overlaysDir, load.RegistryFlag(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry: %w", err)
+	}
+	var configs []api.ReleaseBuildConfiguration
+	if err := config.OperateOnCIOperatorConfigDir(configDir, func(c *api.ReleaseBuildConfiguration, info *config.Info) error {
+		c.Metadata = info.Metadata
+		configs = append(configs, *c)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load configurations: %w", err)
+	}
+	return secretaudit.NewIndex(configs, resolver)
+}
+
+func lastRuns(ctx context.Context, runs *gcsRuns, jobs []string) (map[string]time.Time, error) {
+	ret := map[string]time.Time{}
+	var errs []error
+	for _, job := range jobs {
+		lastRun, ran, err := runs.lastRun(ctx, job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", job, err))
+			continue
+		}
+		if ran {
+			ret[job] = lastRun
+		}
+	}
+	return ret, utilerrors.NewAggregate(errs)
+}
+
+type report struct {
+	Secrets []secretaudit.Usage `json:"secrets,omitempty"`
+}
+
+func (r *report) write(path string) error {
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+	index, err := loadIndex(o.configDir, o.registryDir, o.snapshotsDir, o.overlaysDir)
+	if err != nil {
+		if index == nil {
+			logrus.WithError(err).Fatal("Failed to load the consumers of secrets.")
+		}
+		logrus.WithError(err).Warn("Failed to resolve some configurations.")
+	}
+	var bootstrapConfig secretbootstrap.Config
+	if err := secretbootstrap.LoadConfigFromFile(o.bootstrapConfigPath, &bootstrapConfig); err != nil {
+		logrus.WithError(err).Fatal("Failed to load the configuration of ci-secret-bootstrap.")
+	}
+
+	ctx := context.Background()
+	gcsClient, err := storage.NewClient(ctx, option.WithCredentialsFile(o.gcsCredentialsFile))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create GCS client.")
+	}
+	runs, err := lastRuns(ctx, &gcsRuns{bucket: gcsClient.Bucket(o.gcsBucket)}, index.Jobs())
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read the last runs of some jobs.")
+	}
+
+	r := report{Secrets: secretaudit.Audit(secretaudit.Inventory(bootstrapConfig, o.namespaces.Strings()), index, runs, time.Now().Add(-o.staleAfter))}
+	if err := r.write(o.reportPath); err != nil {
+		logrus.WithError(err).Fatal("Failed to write the report.")
+	}
+	counts := map[secretaudit.Status]int{}
+	for _, usage := range r.Secrets {
+		counts[usage.Status]++
+	}
+	logrus.Infof("Audited %d secrets: %d unused, %d stale, %d missing from the inventory.", len(r.Secrets), counts[secretaudit.StatusUnused], counts[secretaudit.StatusStale], counts[secretaudit.StatusMissing])
+}
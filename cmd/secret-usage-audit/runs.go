@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+)
+
+// started is the part of the started.json uploaded by Prow jobs we need.
+type started struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+type gcsRuns struct {
+	bucket *storage.BucketHandle
+}
+
+// lastRun reads the start time of the latest run of the job. Runs of
+// presubmits are stored per pull request, so their latest run is found
+// through the directory Prow maintains for each job. The second return value
+// is false if the job never ran.
+func (g *gcsRuns) lastRun(ctx context.Context, job string) (time.Time, bool, error) {
+	runDir := func(build string) (string, error) { return path.Join("logs", job, build), nil }
+	latest := path.Join("logs", job, "latest-build.txt")
+	if strings.HasPrefix(job, jobconfig.PresubmitPrefix+"-") {
+		directory := path.Join("pr-logs", "directory", job)
+		latest = path.Join(directory, "latest-build.txt")
+		runDir = func(build string) (string, error) {
+			link, err := g.read(ctx, path.Join(directory, build+".txt"))
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve run %s: %w", build, err)
+			}
+			_, dir, found := strings.Cut(strings.TrimPrefix(strings.TrimSpace(string(link)), "gs://"), "/")
+			if !found {
+				return "", fmt.Errorf("invalid link to run %s: %s", build, link)
+			}
+			return dir, nil
+		}
+	}
+
+	build, err := g.read(ctx, latest)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read the latest run: %w", err)
+	}
+	dir, err := runDir(strings.TrimSpace(string(build)))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	raw, err := g.read(ctx, path.Join(dir, "started.json"))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read the start of the latest run: %w", err)
+	}
+	var s started
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to unmarshal the start of the latest run: %w", err)
+	}
+	return time.Unix(s.Timestamp, 0), true, nil
+}
+
+func (g *gcsRuns) read(ctx context.Context, name string) ([]byte, error) {
+	reader, err := g.bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,90 @@
+// vault-secret-collection-generator generates the Vault policies and groups
+// of secret collections from their definitions, so that onboarding a
+// collection is a change to the definitions. Without --confirm, it only
+// reports where Vault drifted from the definitions and fails if it did.
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/secretcollection"
+	"github.com/openshift/ci-tools/pkg/vaultclient"
+)
+
+type options struct {
+	configPath    string
+	kvStorePrefix string
+	vaultAddr     string
+	vaultToken    string
+	vaultRole     string
+	confirm       bool
+}
+
+func gatherOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("vault-secret-collection-generator", flag.ContinueOnError)
+	fs.StringVar(&o.configPath, "config", "", "Path to the definitions of the secret collections")
+	fs.StringVar(&o.kvStorePrefix, "kv-store-prefix", "secret/self-managed", "Vault KV folder under which the secret collections are stored")
+	fs.StringVar(&o.vaultAddr, "vault-addr", "http://127.0.0.1:8300", "The address under which vault should be reached")
+	fs.StringVar(&o.vaultToken, "vault-token", os.Getenv("VAULT_TOKEN"), "The privileged token to use when communicating with vault, must be able to CRUD policies and groups, defaults to $VAULT_TOKEN")
+	fs.StringVar(&o.vaultRole, "vault-role", "", "The vault role to use for kubernetes service account auth, must be able to CRUD policies and groups")
+	fs.BoolVar(&o.confirm, "confirm", false, "Whether to update the drifted policies and groups in Vault")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.configPath == "" {
+		errs = append(errs, errors.New("--config is required"))
+	}
+	if o.vaultToken == "" && o.vaultRole == "" {
+		errs = append(errs, errors.New("--vault-token or --vault-role is required"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	config, err := secretcollection.LoadConfig(o.configPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load secret collections")
+	}
+
+	var client *vaultclient.VaultClient
+	if o.vaultRole != "" {
+		client, err = vaultclient.NewFromKubernetesAuth(o.vaultAddr, o.vaultRole)
+	} else {
+		client, err = vaultclient.New(o.vaultAddr, o.vaultToken)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to construct vault client")
+	}
+
+	drifts, err := secretcollection.Reconcile(secretcollection.NewClient(client), config, o.kvStorePrefix, o.confirm)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to reconcile secret collections")
+	}
+	switch {
+	case len(drifts) == 0:
+		logrus.Info("Vault matches the secret collection definitions.")
+	case o.confirm:
+		logrus.Infof("Updated %d drifted policies and groups.", len(drifts))
+	default:
+		logrus.Fatalf("Vault drifted from the secret collection definitions in %d places, run with --confirm to update it.", len(drifts))
+	}
+}
@@ -24,10 +24,11 @@ import (
 	"sigs.k8s.io/prow/pkg/metrics"
 	"sigs.k8s.io/prow/pkg/version"
 
+	"github.com/openshift/ci-tools/pkg/secretcollection"
 	"github.com/openshift/ci-tools/pkg/vaultclient"
 )
 
-const objectPrefix = "secret-collection-manager-managed"
+const objectPrefix = secretcollection.ObjectPrefix
 
 type option struct {
 	// Folder under which to create policies
@@ -408,20 +409,11 @@ func (m *secretCollectionManager) createSecretCollection(_ *logrus.Entry, userNa
 }
 
 func (m *secretCollectionManager) serializedPolicyFor(name string) (string, error) {
-	policy := managedVaultPolicy{Path: map[string]managedVaultPolicyCapabilityList{
-		m.kvMetadataPrefix + "/" + name + "/*": {Capabilities: []string{"list", "delete"}},
-		m.kvDataPrefix + "/" + name + "/*":     {Capabilities: []string{"create", "update", "read"}},
-	}}
-	serialized, err := json.Marshal(policy)
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize policy: %w", err)
-	}
-
-	return string(serialized), nil
+	return secretcollection.Policy(m.kvStorePrefix, name)
 }
 
 func prefixedName(name string) string {
-	return objectPrefix + "-" + name
+	return secretcollection.PrefixedName(name)
 }
 
 func nameFromPrefixedName(name string) string {
@@ -17,3 +17,7 @@ go run ./vendor/sigs.k8s.io/controller-tools/cmd/controller-gen object \
 go run ./vendor/sigs.k8s.io/controller-tools/cmd/controller-gen crd:crdVersions=v1 object \
     paths=./pkg/api/multiarchbuildconfig/v1 \
     output:dir=./pkg/api/multiarchbuildconfig/v1
+
+go run ./vendor/sigs.k8s.io/controller-tools/cmd/controller-gen crd:crdVersions=v1 object \
+    paths=./pkg/api/orchestration/v1 \
+    output:dir=./pkg/api/orchestration/v1
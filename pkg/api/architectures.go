@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ArchitectureRISCV64 is the 64-bit RISC-V architecture.
+const ArchitectureRISCV64 = "riscv64"
+
+// defaultArchitectures are the architectures images may be built for, unless
+// the build farm overrides them.
+var defaultArchitectures = []string{
+	"amd64",             // x86-64
+	"arm64",             // AArch64
+	"ppc64le",           // PowerPC 64-bit Little Endian
+	ArchitectureRISCV64, // RISC-V 64-bit
+	"s390x",             // IBM System z 64-bit
+}
+
+// DefaultArchitectures returns the architectures images may be built for
+// unless the build farm overrides them.
+func DefaultArchitectures() sets.Set[string] {
+	return sets.New[string](defaultArchitectures...)
+}
+
+// ParseArchitectures validates the architectures a build farm overrides the
+// default ones with, so that it can enable the architectures its nodes have
+// without a new release of the tools. Images are always built for amd64, so
+// it must be among them.
+func ParseArchitectures(archs []string) (sets.Set[string], error) {
+	ret := sets.New[string]()
+	for _, arch := range archs {
+		if arch == "" {
+			return nil, fmt.Errorf("architectures must not be empty")
+		}
+		ret.Insert(arch)
+	}
+	if !ret.Has(string(NodeArchitectureAMD64)) {
+		return nil, fmt.Errorf("the architectures must include %s, got %v", NodeArchitectureAMD64, sets.List(ret))
+	}
+	return ret, nil
+}
+
+// FilterArchitectures returns the architectures images may be built for among
+// the given ones, e.g. the architectures of the nodes of a build farm.
+func FilterArchitectures(supported sets.Set[string], archs []string) []string {
+	var ret []string
+	for _, arch := range archs {
+		if supported.Has(arch) {
+			ret = append(ret, arch)
+		}
+	}
+	return ret
+}
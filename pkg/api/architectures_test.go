@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestParseArchitectures(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		archs         []string
+		expected      []string
+		expectedError error
+	}{
+		{
+			name:     "override",
+			archs:    []string{"riscv64", "amd64", "loong64"},
+			expected: []string{"amd64", "loong64", "riscv64"},
+		},
+		{
+			name:          "amd64 is required",
+			archs:         []string{"arm64"},
+			expectedError: errors.New("the architectures must include amd64, got [arm64]"),
+		},
+		{
+			name:          "empty architecture",
+			archs:         []string{"amd64", ""},
+			expectedError: errors.New("architectures must not be empty"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseArchitectures(tc.archs)
+			testhelper.Diff(t, "error", err, tc.expectedError, testhelper.EquateErrorMessage)
+			var archs []string
+			if actual != nil {
+				archs = sets.List(actual)
+			}
+			testhelper.Diff(t, "architectures", archs, tc.expected)
+		})
+	}
+}
+
+func TestFilterArchitectures(t *testing.T) {
+	testhelper.Diff(t, "architectures", FilterArchitectures(DefaultArchitectures(), []string{"amd64", "riscv64", "mips64"}), []string{"amd64", "riscv64"})
+}
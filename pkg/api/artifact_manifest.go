@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ArtifactManifestFilename is the name of the manifest listing the artifacts
+// copied from the pods of a step, written next to them.
+const ArtifactManifestFilename = "ARTIFACT_MANIFEST.json"
+
+// ArtifactManifest lists the artifacts copied from the pods of a step.
+type ArtifactManifest struct {
+	// SizeLimit is the limit of the total size of the artifacts of the step
+	// in bytes, zero when unlimited.
+	SizeLimit int64 `json:"size_limit,omitempty"`
+	// Files are the artifacts, sorted by name.
+	Files []ArtifactManifestFile `json:"files"`
+}
+
+// ArtifactManifestFile is a single artifact.
+type ArtifactManifestFile struct {
+	// Name is the path of the artifact relative to the artifact directory
+	// of the step.
+	Name string `json:"name"`
+	// Size is the number of bytes stored.
+	Size int64 `json:"size"`
+	// SHA256 is the checksum of the bytes stored, unset when nothing was.
+	SHA256 string `json:"sha256,omitempty"`
+	// Truncated artifacts were cut short or left out to respect the size
+	// limit of the step.
+	Truncated bool `json:"truncated,omitempty"`
+	// OriginalSize is the size of a truncated artifact, when known.
+	OriginalSize int64 `json:"original_size,omitempty"`
+}
+
+// Truncated determines whether any artifact was truncated.
+func (m *ArtifactManifest) Truncated() bool {
+	for _, file := range m.Files {
+		if file.Truncated {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadArtifactManifest reads the manifest in the artifact directory of a
+// step, nil is returned when there is none.
+func LoadArtifactManifest(dir string) (*ArtifactManifest, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, ArtifactManifestFilename))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the artifact manifest: %w", err)
+	}
+	var manifest ArtifactManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the artifact manifest: %w", err)
+	}
+	return &manifest, nil
+}
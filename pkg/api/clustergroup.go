@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// ClusterGroupTests returns a test for each cluster group declared in the
+// configuration. The test provisions the cluster once using the `pre` steps of
+// the group, runs the `test` steps of all tests in the group sequentially, in
+// the order they are declared, gathers from the cluster once using the `gather`
+// steps and tears the cluster down once using the `post` steps of the group.
+// All tests in the group must set up, gather from and tear down the cluster the
+// same way and must not set conflicting parameters or dependency overrides. The
+// tests must be fully resolved.
+func ClusterGroupTests(tests []TestStepConfiguration) ([]TestStepConfiguration, error) {
+	var order []string
+	groups := map[string][]TestStepConfiguration{}
+	for _, test := range tests {
+		if test.ClusterGroup == "" {
+			continue
+		}
+		if _, seen := groups[test.ClusterGroup]; !seen {
+			order = append(order, test.ClusterGroup)
+		}
+		groups[test.ClusterGroup] = append(groups[test.ClusterGroup], test)
+	}
+	var ret []TestStepConfiguration
+	for _, name := range order {
+		test, err := clusterGroupTest(name, groups[name])
+		if err != nil {
+			return nil, fmt.Errorf("cluster group %s: %w", name, err)
+		}
+		ret = append(ret, test)
+	}
+	return ret, nil
+}
+
+func clusterGroupTest(name string, tests []TestStepConfiguration) (TestStepConfiguration, error) {
+	first := tests[0]
+	if first.MultiStageTestConfigurationLiteral == nil {
+		return TestStepConfiguration{}, fmt.Errorf("test %s is not a resolved multi-stage test", first.As)
+	}
+	ret := first
+	ret.As = name
+	ret.ClusterGroup = ""
+	literal := *first.MultiStageTestConfigurationLiteral
+	literal.Test = nil
+	literal.Environment = maps.Clone(literal.Environment)
+	literal.DependencyOverrides = maps.Clone(literal.DependencyOverrides)
+	for _, test := range tests {
+		ms := test.MultiStageTestConfigurationLiteral
+		if ms == nil {
+			return TestStepConfiguration{}, fmt.Errorf("test %s is not a resolved multi-stage test", test.As)
+		}
+		if ms.ClusterProfile != literal.ClusterProfile {
+			return TestStepConfiguration{}, fmt.Errorf("test %s uses cluster profile %q, but %s uses %q", test.As, ms.ClusterProfile, first.As, literal.ClusterProfile)
+		}
+		for _, field := range []struct {
+			name          string
+			value, shared interface{}
+		}{
+			{name: "pre", value: ms.Pre, shared: literal.Pre},
+			{name: "gather", value: ms.Gather, shared: literal.Gather},
+			{name: "gather_timeout", value: ms.GatherTimeout, shared: literal.GatherTimeout},
+			{name: "post", value: ms.Post, shared: literal.Post},
+			{name: "leases", value: ms.Leases, shared: literal.Leases},
+		} {
+			if !reflect.DeepEqual(field.value, field.shared) {
+				return TestStepConfiguration{}, fmt.Errorf("test %s sets %s differently than %s, but the tests in a group share one cluster", test.As, field.name, first.As)
+			}
+		}
+		for key, value := range ms.Environment {
+			if existing, ok := literal.Environment[key]; ok && existing != value {
+				return TestStepConfiguration{}, fmt.Errorf("test %s sets %s to %q, but it is already set to %q by another test in the group", test.As, key, value, existing)
+			}
+			if literal.Environment == nil {
+				literal.Environment = TestEnvironment{}
+			}
+			literal.Environment[key] = value
+		}
+		for key, value := range ms.DependencyOverrides {
+			if existing, ok := literal.DependencyOverrides[key]; ok && existing != value {
+				return TestStepConfiguration{}, fmt.Errorf("test %s overrides dependency %s with %q, but it is already overridden with %q by another test in the group", test.As, key, value, existing)
+			}
+			if literal.DependencyOverrides == nil {
+				literal.DependencyOverrides = DependencyOverrides{}
+			}
+			literal.DependencyOverrides[key] = value
+		}
+		for _, step := range ms.Test {
+			step.As = fmt.Sprintf("%s-%s", test.As, step.As)
+			literal.Test = append(literal.Test, step)
+		}
+	}
+	ret.MultiStageTestConfigurationLiteral = &literal
+	return ret, nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestClusterGroupTests(t *testing.T) {
+	test := func(as, group string, env TestEnvironment, steps ...string) TestStepConfiguration {
+		var literal []LiteralTestStep
+		for _, step := range steps {
+			literal = append(literal, LiteralTestStep{As: step, From: "src", Commands: step})
+		}
+		return TestStepConfiguration{
+			As:           as,
+			ClusterGroup: group,
+			MultiStageTestConfigurationLiteral: &MultiStageTestConfigurationLiteral{
+				ClusterProfile: ClusterProfileAWS,
+				Pre:            []LiteralTestStep{{As: "install", From: "installer", Commands: "install"}},
+				Test:           literal,
+				Post:           []LiteralTestStep{{As: "teardown", From: "installer", Commands: "teardown"}},
+				Environment:    env,
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name        string
+		tests       []TestStepConfiguration
+		expected    []TestStepConfiguration
+		expectedErr error
+	}{
+		{
+			name:  "no groups",
+			tests: []TestStepConfiguration{test("e2e", "", nil, "conformance")},
+		},
+		{
+			name: "tests in a group are merged",
+			tests: []TestStepConfiguration{
+				test("e2e-a", "e2e", TestEnvironment{"A": "a"}, "conformance"),
+				test("unrelated", "", nil, "other"),
+				test("e2e-b", "e2e", TestEnvironment{"B": "b"}, "conformance", "serial"),
+			},
+			expected: []TestStepConfiguration{{
+				As: "e2e",
+				MultiStageTestConfigurationLiteral: &MultiStageTestConfigurationLiteral{
+					ClusterProfile: ClusterProfileAWS,
+					Pre:            []LiteralTestStep{{As: "install", From: "installer", Commands: "install"}},
+					Test: []LiteralTestStep{
+						{As: "e2e-a-conformance", From: "src", Commands: "conformance"},
+						{As: "e2e-b-conformance", From: "src", Commands: "conformance"},
+						{As: "e2e-b-serial", From: "src", Commands: "serial"},
+					},
+					Post:        []LiteralTestStep{{As: "teardown", From: "installer", Commands: "teardown"}},
+					Environment: TestEnvironment{"A": "a", "B": "b"},
+				},
+			}},
+		},
+		{
+			name: "conflicting environment",
+			tests: []TestStepConfiguration{
+				test("e2e-a", "e2e", TestEnvironment{"A": "a"}, "conformance"),
+				test("e2e-b", "e2e", TestEnvironment{"A": "b"}, "conformance"),
+			},
+			expectedErr: errors.New(`cluster group e2e: test e2e-b sets A to "b", but it is already set to "a" by another test in the group`),
+		},
+		{
+			name: "conflicting dependency overrides",
+			tests: func() []TestStepConfiguration {
+				a, b := test("e2e-a", "e2e", nil, "conformance"), test("e2e-b", "e2e", nil, "conformance")
+				a.MultiStageTestConfigurationLiteral.DependencyOverrides = DependencyOverrides{"OO_INDEX": "quay.io/a:latest"}
+				b.MultiStageTestConfigurationLiteral.DependencyOverrides = DependencyOverrides{"OO_INDEX": "quay.io/b:latest"}
+				return []TestStepConfiguration{a, b}
+			}(),
+			expectedErr: errors.New(`cluster group e2e: test e2e-b overrides dependency OO_INDEX with "quay.io/b:latest", but it is already overridden with "quay.io/a:latest" by another test in the group`),
+		},
+		{
+			name: "different pre steps",
+			tests: func() []TestStepConfiguration {
+				a, b := test("e2e-a", "e2e", nil, "conformance"), test("e2e-b", "e2e", nil, "conformance")
+				b.MultiStageTestConfigurationLiteral.Pre = append(b.MultiStageTestConfigurationLiteral.Pre, LiteralTestStep{As: "configure", From: "cli", Commands: "configure"})
+				return []TestStepConfiguration{a, b}
+			}(),
+			expectedErr: errors.New(`cluster group e2e: test e2e-b sets pre differently than e2e-a, but the tests in a group share one cluster`),
+		},
+		{
+			name: "different gather steps",
+			tests: func() []TestStepConfiguration {
+				a, b := test("e2e-a", "e2e", nil, "conformance"), test("e2e-b", "e2e", nil, "conformance")
+				b.MultiStageTestConfigurationLiteral.Gather = []LiteralTestStep{{As: "must-gather", From: "cli", Commands: "gather"}}
+				return []TestStepConfiguration{a, b}
+			}(),
+			expectedErr: errors.New(`cluster group e2e: test e2e-b sets gather differently than e2e-a, but the tests in a group share one cluster`),
+		},
+		{
+			name: "different leases",
+			tests: func() []TestStepConfiguration {
+				a, b := test("e2e-a", "e2e", nil, "conformance"), test("e2e-b", "e2e", nil, "conformance")
+				b.MultiStageTestConfigurationLiteral.Leases = []StepLease{{ResourceType: "gpu-quota", Env: "GPU"}}
+				return []TestStepConfiguration{a, b}
+			}(),
+			expectedErr: errors.New(`cluster group e2e: test e2e-b sets leases differently than e2e-a, but the tests in a group share one cluster`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ClusterGroupTests(tc.tests)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected tests: %s", diff)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
@@ -31,7 +30,7 @@ func (config *ReleaseBuildConfiguration) Default() {
 			c.Architecture = ReleaseArchitectureAMD64
 		}
 		if c.Timeout == nil {
-			c.Timeout = &prowv1.Duration{Duration: time.Hour}
+			c.Timeout = &prowv1.Duration{Duration: DefaultClusterClaimTimeout}
 		}
 	}
 	defTest := func(t *TestStepConfiguration) {
@@ -44,6 +43,9 @@ func (config *ReleaseBuildConfiguration) Default() {
 			for i := range s.Test {
 				def(&s.Test[i])
 			}
+			for i := range s.Gather {
+				def(&s.Gather[i])
+			}
 			for i := range s.Post {
 				def(&s.Post[i])
 			}
@@ -28,9 +28,38 @@ const (
 	CloudLabel               = "ci-operator.openshift.io/cloud"
 	CloudClusterProfileLabel = "ci-operator.openshift.io/cloud-cluster-profile"
 
+	// AllowedWindowsAnnotation lists the windows during which a test is allowed to start
+	AllowedWindowsAnnotation = "ci-operator.openshift.io/allowed-windows"
+
+	// DeprioritizedAtAnnotation records on a periodic test when it was
+	// deprioritized for failing too often, in RFC 3339
+	DeprioritizedAtAnnotation = "ci.openshift.io/deprioritized-at"
+	// DisabledAtAnnotation records on a periodic test when it was disabled
+	// for failing too often, in RFC 3339. No job is generated for the test
+	// while it is set.
+	DisabledAtAnnotation = "ci.openshift.io/disabled-at"
+
+	// ResourceCeilingExemptionAnnotation exempts a test and its steps, or the
+	// build of an image, from the resource ceilings of its organization, its
+	// value explains why they need more
+	ResourceCeilingExemptionAnnotation = "ci.openshift.io/resource-ceiling-exemption"
+
 	NoBuildsLabel = "ci.openshift.io/no-builds"
 	NoBuildsValue = "true"
 
+	// CreatesLabel marks the objects ci-operator creates with the pipeline
+	// tag they create
+	CreatesLabel = "creates"
+	// CreatedByCILabel marks the objects ci-operator creates
+	CreatedByCILabel = "created-by-ci"
+	// JobReleaseKey labels generated jobs with the release they run for
+	JobReleaseKey = "job-release"
+	// CreatedByProwLabel and CreatedByTideLabel are set by Prow on its jobs
+	// and pods, they are repeated here so that they can be referred to
+	// without depending on Prow
+	CreatedByProwLabel = "created-by-prow"
+	CreatedByTideLabel = "created-by-tide"
+
 	// HiveCluster is the cluster where Hive is deployed
 	HiveCluster = ClusterHive
 
@@ -62,6 +91,9 @@ const (
 	CliEnv                = "CLI_DIR"
 	DefaultLeaseEnv       = "LEASED_RESOURCE"
 	DefaultIPPoolLeaseEnv = "IP_POOL_AVAILABLE"
+	// StableStreamSuffixEnv exposes the suffix of the isolated copies of the
+	// stable streams to the steps of tests isolating them
+	StableStreamSuffixEnv = "STABLE_STREAM_SUFFIX"
 	// SkipCensoringLabel is the label we use to mark a secret as not needing to be censored
 	SkipCensoringLabel = "ci.openshift.io/skip-censoring"
 
@@ -80,9 +112,20 @@ const (
 	ReleaseConfigAnnotation = "release.openshift.io/config"
 
 	ImageStreamImportRetries = 6
+
+	// NestedPodmanCapability is the capability tests running steps with
+	// `nested_podman` must declare.
+	NestedPodmanCapability = "nested-podman"
 )
 
 var (
+	// SchedulingAllowlist holds the node labels and taint keys that tests can use
+	// in `node_selector` and `tolerations`, with the values allowed for each.
+	SchedulingAllowlist = map[string]sets.Set[string]{
+		"ci-instance-type": sets.New[string]("high-perf", "large-memory", "metal"),
+		NvidiaGPUResource:  sets.New[string]("true"),
+	}
+
 	clusterNames = sets.New[string](
 		string(ClusterAPPCI),
 		string(ClusterARM01),
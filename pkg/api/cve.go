@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// CVEWaiversFileName is the name of the file in a repository which waives the
+// vulnerabilities the CVE gate of its promotion would otherwise fail on.
+const CVEWaiversFileName = ".ci-operator-cve-waivers.yaml"
+
+// CVEGate compares the critical vulnerabilities found in the images to promote
+// with the ones found in the images they replace in the central registry, and
+// fails the promotion when new ones are introduced.
+type CVEGate struct {
+	// WarnOnly reports the new vulnerabilities without failing the
+	// promotion.
+	WarnOnly bool `json:"warn_only,omitempty"`
+}
+
+// CVEWaivers are the vulnerabilities the CVE gate of the promotion of a
+// repository ignores, read from the CVEWaiversFileName file of the repository.
+type CVEWaivers struct {
+	Waivers []CVEWaiver `json:"waivers,omitempty"`
+}
+
+// CVEWaiver lets images be promoted even though they introduce a
+// vulnerability, e.g. while a fix is not available yet.
+type CVEWaiver struct {
+	// ID identifies the vulnerability, e.g. CVE-2024-3094.
+	ID string `json:"id"`
+	// Images are the names of the promoted images the waiver applies to,
+	// all images when unset.
+	Images []string `json:"images,omitempty"`
+	// Reason explains why the vulnerability is acceptable.
+	Reason string `json:"reason"`
+	// Until is an RFC3339 timestamp after which the waiver no longer
+	// applies.
+	Until string `json:"until,omitempty"`
+}
+
+// Validate checks that the waivers identify a vulnerability, are explained and
+// expire at a valid time.
+func (w CVEWaivers) Validate() error {
+	var errs []error
+	for i, waiver := range w.Waivers {
+		if waiver.ID == "" {
+			errs = append(errs, fmt.Errorf("waivers[%d].id: must be set", i))
+		}
+		if waiver.Reason == "" {
+			errs = append(errs, fmt.Errorf("waivers[%d].reason: must explain why the vulnerability is acceptable", i))
+		}
+		if waiver.Until != "" {
+			if _, err := time.Parse(time.RFC3339, waiver.Until); err != nil {
+				errs = append(errs, fmt.Errorf("waivers[%d].until: invalid timestamp %q: %w", i, waiver.Until, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Waives determines whether the vulnerability is waived for the image at the
+// given time.
+func (w CVEWaivers) Waives(image, id string, now time.Time) bool {
+	for _, waiver := range w.Waivers {
+		if waiver.ID != id {
+			continue
+		}
+		if waiver.Until != "" {
+			if until, err := time.Parse(time.RFC3339, waiver.Until); err != nil || !now.Before(until) {
+				continue
+			}
+		}
+		if len(waiver.Images) == 0 {
+			return true
+		}
+		for _, name := range waiver.Images {
+			if name == image {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCVEWaiversValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		waivers  CVEWaivers
+		expected error
+	}{
+		{
+			name:    "valid waivers",
+			waivers: CVEWaivers{Waivers: []CVEWaiver{{ID: "CVE-2024-3094", Reason: "not shipped", Until: "2024-07-01T00:00:00Z"}, {ID: "CVE-2024-0001", Images: []string{"cli"}, Reason: "no fix yet"}}},
+		},
+		{
+			name:     "missing id and reason",
+			waivers:  CVEWaivers{Waivers: []CVEWaiver{{Images: []string{"cli"}}}},
+			expected: errors.New("[waivers[0].id: must be set, waivers[0].reason: must explain why the vulnerability is acceptable]"),
+		},
+		{
+			name:     "invalid expiration",
+			waivers:  CVEWaivers{Waivers: []CVEWaiver{{ID: "CVE-2024-3094", Reason: "not shipped", Until: "2024-07-01"}}},
+			expected: errors.New(`waivers[0].until: invalid timestamp "2024-07-01": parsing time "2024-07-01" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", tc.waivers.Validate(), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestCVEWaiversWaives(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	waivers := CVEWaivers{Waivers: []CVEWaiver{
+		{ID: "CVE-2024-0001", Reason: "all images"},
+		{ID: "CVE-2024-0002", Images: []string{"cli"}, Reason: "only cli"},
+		{ID: "CVE-2024-0003", Reason: "expired", Until: "2024-06-01T00:00:00Z"},
+		{ID: "CVE-2024-0004", Reason: "not expired yet", Until: "2024-07-01T00:00:00Z"},
+	}}
+	for _, tc := range []struct {
+		image, id string
+		expected  bool
+	}{
+		{image: "tests", id: "CVE-2024-0001", expected: true},
+		{image: "cli", id: "CVE-2024-0002", expected: true},
+		{image: "tests", id: "CVE-2024-0002"},
+		{image: "cli", id: "CVE-2024-0003"},
+		{image: "cli", id: "CVE-2024-0004", expected: true},
+		{image: "cli", id: "CVE-2024-0005"},
+	} {
+		if actual := waivers.Waives(tc.image, tc.id, now); actual != tc.expected {
+			t.Errorf("%s in %s: expected waived %t, got %t", tc.id, tc.image, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// GoVersionPolicy declares the Go toolchain versions release admins allow in
+// the build roots of the configurations targeting each OCP release.
+// +k8s:deepcopy-gen=false
+type GoVersionPolicy struct {
+	// Releases maps OCP releases, e.g. 4.17, to the Go versions allowed for
+	// them, e.g. 1.22. Configurations targeting other releases are not
+	// restricted.
+	Releases map[string][]string `json:"releases"`
+	// Exemptions are the configurations whose build roots do not need to
+	// follow the policy.
+	Exemptions []GoVersionPolicyExemption `json:"exemptions,omitempty"`
+}
+
+// GoVersionPolicyExemption exempts the configurations of a repository, or of
+// one of its branches, from the Go version policy.
+// +k8s:deepcopy-gen=false
+type GoVersionPolicyExemption struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// Branch limits the exemption to one branch, all branches of the
+	// repository are exempt when unset.
+	Branch string `json:"branch,omitempty"`
+	// Reason explains why the exemption is needed.
+	Reason string `json:"reason"`
+}
+
+var goVersionRegExp = regexp.MustCompile(`^\d+\.\d+$`)
+
+// builderGoVersionRegExp extracts the Go version from builder image tags like
+// rhel-9-golang-1.22-openshift-4.17
+var builderGoVersionRegExp = regexp.MustCompile(`golang-(\d+\.\d+)`)
+
+// GoVersionFor determines the Go version used by a builder image from its tag,
+// returning an empty string if it cannot be determined.
+func GoVersionFor(tag string) string {
+	if match := builderGoVersionRegExp.FindStringSubmatch(tag); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Validate checks that the policy is well-formed.
+func (p *GoVersionPolicy) Validate() error {
+	var errs []error
+	var releases []string
+	for release := range p.Releases {
+		releases = append(releases, release)
+	}
+	sort.Strings(releases)
+	for _, release := range releases {
+		if len(p.Releases[release]) == 0 {
+			errs = append(errs, fmt.Errorf("releases[%s]: at least one Go version must be allowed", release))
+		}
+		for i, version := range p.Releases[release] {
+			if !goVersionRegExp.MatchString(version) {
+				errs = append(errs, fmt.Errorf("releases[%s][%d]: Go version %q must be in the major.minor form", release, i, version))
+			}
+		}
+	}
+	for i, exemption := range p.Exemptions {
+		if exemption.Org == "" || exemption.Repo == "" {
+			errs = append(errs, fmt.Errorf("exemptions[%d]: org and repo are required", i))
+		}
+		if exemption.Reason == "" {
+			errs = append(errs, fmt.Errorf("exemptions[%d]: reason is required", i))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Exempts determines whether a configuration is exempt from the policy.
+func (p *GoVersionPolicy) Exempts(metadata Metadata) bool {
+	for _, exemption := range p.Exemptions {
+		if exemption.Org == metadata.Org && exemption.Repo == metadata.Repo && (exemption.Branch == "" || exemption.Branch == metadata.Branch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestGoVersionPolicyValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   GoVersionPolicy
+		expected error
+	}{
+		{
+			name: "valid",
+			policy: GoVersionPolicy{
+				Releases:   map[string][]string{"4.17": {"1.22", "1.23"}},
+				Exemptions: []GoVersionPolicyExemption{{Org: "org", Repo: "repo", Reason: "reason"}},
+			},
+		},
+		{
+			name: "invalid",
+			policy: GoVersionPolicy{
+				Releases:   map[string][]string{"4.16": {}, "4.17": {"go1.22"}},
+				Exemptions: []GoVersionPolicyExemption{{Org: "org"}},
+			},
+			expected: errors.New(`[releases[4.16]: at least one Go version must be allowed, releases[4.17][0]: Go version "go1.22" must be in the major.minor form, exemptions[0]: org and repo are required, exemptions[0]: reason is required]`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.policy.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGoVersionFor(t *testing.T) {
+	for _, tc := range []struct {
+		tag      string
+		expected string
+	}{
+		{tag: "rhel-9-golang-1.22-openshift-4.17", expected: "1.22"},
+		{tag: "golang-1.21", expected: "1.21"},
+		{tag: "rhel-9-base-openshift-4.17"},
+	} {
+		if actual := GoVersionFor(tc.tag); actual != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.tag, tc.expected, actual)
+		}
+	}
+}
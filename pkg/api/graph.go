@@ -317,6 +317,12 @@ func ReleaseStreamFor(name string) string {
 	return fmt.Sprintf("%s-%s", StableImageStream, name)
 }
 
+// IsolatedStableStreamFor determines the ImageStream holding the copy of a
+// stable stream isolated for a test.
+func IsolatedStableStreamFor(stream, test string) string {
+	return fmt.Sprintf("%s-%s", stream, test)
+}
+
 // ReleaseNameFrom determines the named release that was imported
 // or assembled into an ImageStream.
 func ReleaseNameFrom(stream string) string {
@@ -632,6 +638,9 @@ func mergeSteps(into, from CIOperatorStepDetails) CIOperatorStepDetails {
 	if into.Failed == nil {
 		into.Failed = from.Failed
 	}
+	if into.ResourceUsage == nil {
+		into.ResourceUsage = from.ResourceUsage
+	}
 	if into.Substeps == nil {
 		into.Substeps = from.Substeps
 	}
@@ -656,6 +665,17 @@ type CIOperatorStepDetailInfo struct {
 	Manifests    []ctrlruntimeclient.Object `json:"manifests,omitempty"`
 	LogURL       string                     `json:"log_url,omitempty"`
 	Failed       *bool                      `json:"failed,omitempty"`
+	// ResourceUsage is the peak usage of the pod of the step, when the
+	// cluster exposes the metrics API.
+	ResourceUsage *StepResourceUsage `json:"resource_usage,omitempty"`
+}
+
+// StepResourceUsage is the peak resource usage of a step, summed over the
+// containers of its pod.
+// +k8s:deepcopy-gen=false
+type StepResourceUsage struct {
+	CPUMillicores int64 `json:"cpu_millicores"`
+	MemoryBytes   int64 `json:"memory_bytes"`
 }
 
 func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// HealthCheckImage is the image running the cluster-health-check binary.
+var HealthCheckImage = ImageStreamTagReference{Namespace: "ci", Name: "cluster-health-check", Tag: "latest"}
+
+// HealthCheck is the name of a check asserting the health of a cluster.
+type HealthCheck string
+
+const (
+	// HealthCheckClusterOperatorsAvailable asserts that all cluster operators
+	// are available and not degraded.
+	HealthCheckClusterOperatorsAvailable HealthCheck = "cluster_operators_available"
+	// HealthCheckNodesReady asserts that all nodes are ready.
+	HealthCheckNodesReady HealthCheck = "nodes_ready"
+	// HealthCheckNoPendingCSRs asserts that no certificate signing request is
+	// waiting to be approved or denied.
+	HealthCheckNoPendingCSRs HealthCheck = "no_pending_csrs"
+	// HealthCheckAPIResponsive asserts that the API server answers requests
+	// in a timely manner.
+	HealthCheckAPIResponsive HealthCheck = "api_responsive"
+)
+
+// HealthChecks lists all checks, in the order they run.
+var HealthChecks = []HealthCheck{HealthCheckAPIResponsive, HealthCheckClusterOperatorsAvailable, HealthCheckNodesReady, HealthCheckNoPendingCSRs}
+
+// HealthCheckStep is a typed step asserting the health of the cluster under
+// test. It is expanded into a literal step running the cluster-health-check
+// binary against the kubeconfig in the shared directory, which reports every
+// check as a jUnit test case. It can be used in any phase of a test, e.g.
+// after the installation in `pre` or before the teardown in `post`.
+type HealthCheckStep struct {
+	// Checks lists the checks to run, defaults to all of them:
+	// `api_responsive`, `cluster_operators_available`, `nodes_ready` and
+	// `no_pending_csrs`.
+	Checks []HealthCheck `json:"checks,omitempty"`
+	// Wait is how long failing checks are retried before the step fails,
+	// for clusters expected to settle. Checks run only once when unset.
+	Wait *prowv1.Duration `json:"wait,omitempty"`
+}
+
+// EnabledChecks lists the checks to run, in the order they run.
+func (h *HealthCheckStep) EnabledChecks() []HealthCheck {
+	if len(h.Checks) == 0 {
+		return HealthChecks
+	}
+	enabled := sets.New(h.Checks...)
+	var ret []HealthCheck
+	for _, check := range HealthChecks {
+		if enabled.Has(check) {
+			ret = append(ret, check)
+		}
+	}
+	return ret
+}
+
+// WaitDuration is how long failing checks are retried.
+func (h *HealthCheckStep) WaitDuration() time.Duration {
+	if h.Wait == nil {
+		return 0
+	}
+	return h.Wait.Duration
+}
+
+// Validate checks the configuration of the health checks.
+func (h *HealthCheckStep) Validate() []error {
+	var errs []error
+	known := sets.New(HealthChecks...)
+	seen := sets.New[HealthCheck]()
+	for _, check := range h.Checks {
+		if !known.Has(check) {
+			errs = append(errs, fmt.Errorf("unknown check %q, must be one of %v", check, HealthChecks))
+		} else if seen.Has(check) {
+			errs = append(errs, fmt.Errorf("duplicated check %q", check))
+		}
+		seen.Insert(check)
+	}
+	if h.Wait != nil && h.Wait.Duration < 0 {
+		errs = append(errs, fmt.Errorf("wait must not be negative, got %s", h.Wait.Duration))
+	}
+	return errs
+}
+
+func (h *HealthCheckStep) expand(step LiteralTestStep) LiteralTestStep {
+	image := HealthCheckImage
+	step.FromImage = &image
+	step.Commands = "cluster-health-check"
+	for _, check := range h.EnabledChecks() {
+		step.Commands += fmt.Sprintf(" --check=%s", check)
+	}
+	if wait := h.WaitDuration(); wait != 0 {
+		step.Commands += fmt.Sprintf(" --wait=%s", wait)
+		if step.Timeout == nil {
+			step.Timeout = &prowv1.Duration{Duration: wait + typedStepTimeoutMargin}
+		}
+	}
+	step.Commands += "\n"
+	return step
+}
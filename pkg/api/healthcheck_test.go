@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestExpandHealthCheckStep(t *testing.T) {
+	image := &ImageStreamTagReference{Namespace: "ci", Name: "cluster-health-check", Tag: "latest"}
+	resources := ResourceRequirements{Requests: ResourceList{"cpu": "10m", "memory": "100Mi"}}
+	for _, tc := range []struct {
+		name     string
+		step     LiteralTestStep
+		expected LiteralTestStep
+	}{
+		{
+			name: "defaults",
+			step: LiteralTestStep{As: "health", HealthCheck: &HealthCheckStep{}},
+			expected: LiteralTestStep{
+				As:        "health",
+				FromImage: image,
+				Commands:  "cluster-health-check --check=api_responsive --check=cluster_operators_available --check=nodes_ready --check=no_pending_csrs\n",
+				Resources: resources,
+			},
+		},
+		{
+			name: "configured checks are run in order, with a wait",
+			step: LiteralTestStep{As: "health", HealthCheck: &HealthCheckStep{
+				Checks: []HealthCheck{HealthCheckNodesReady, HealthCheckAPIResponsive},
+				Wait:   &prowv1.Duration{Duration: 15 * time.Minute},
+			}},
+			expected: LiteralTestStep{
+				As:        "health",
+				FromImage: image,
+				Commands:  "cluster-health-check --check=api_responsive --check=nodes_ready --wait=15m0s\n",
+				Timeout:   &prowv1.Duration{Duration: 25 * time.Minute},
+				Resources: resources,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, ExpandTypedStep(tc.step)); diff != "" {
+				t.Errorf("unexpected step: %s", diff)
+			}
+		})
+	}
+}
+
+func TestHealthCheckStepValidate(t *testing.T) {
+	step := HealthCheckStep{Checks: []HealthCheck{"nodes_ready", "nodes_ready", "etcd_happy"}, Wait: &prowv1.Duration{Duration: -time.Minute}}
+	expected := []error{
+		errors.New(`duplicated check "nodes_ready"`),
+		errors.New(`unknown check "etcd_happy", must be one of [api_responsive cluster_operators_available nodes_ready no_pending_csrs]`),
+		errors.New("wait must not be negative, got -1m0s"),
+	}
+	if diff := cmp.Diff(expected, step.Validate(), testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected errors: %s", diff)
+	}
+}
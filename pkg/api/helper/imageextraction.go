@@ -76,7 +76,7 @@ func TestInputImageStreamTagsFromResolvedConfig(cfg api.ReleaseBuildConfiguratio
 			insert(api.BuildCacheFor(cfg.Metadata), result)
 		}
 		if cfg.BuildRootImage.FromRepository && repoFileGetter != nil {
-			tagRef, err := tagReferenceInRepoConfigFile(cfg.Metadata, repoFileGetter)
+			tagRef, err := TagReferenceInRepoConfigFile(cfg.Metadata, repoFileGetter)
 			if err != nil {
 				logrus.WithError(err).WithField("metadata", fmt.Errorf("%s/%s#%s", cfg.Metadata.Org, cfg.Metadata.Repo, cfg.Metadata.Branch)).
 					Warn("Failed to get tag reference from the in-repo config file")
@@ -121,7 +121,10 @@ func TestInputImageStreamTagsFromResolvedConfig(cfg api.ReleaseBuildConfiguratio
 	return ImageStreamTagMap(result), utilerrors.NewAggregate(errs)
 }
 
-func tagReferenceInRepoConfigFile(metadata api.Metadata, repoFileGetter func(org, repo, branch string, _ ...github.Opt) github.FileGetter) (api.ImageStreamTagReference, error) {
+// TagReferenceInRepoConfigFile reads the build root declared in the
+// .ci-operator.yaml file of the repository, returning an empty reference if
+// the file does not exist.
+func TagReferenceInRepoConfigFile(metadata api.Metadata, repoFileGetter func(org, repo, branch string, _ ...github.Opt) github.FileGetter) (api.ImageStreamTagReference, error) {
 	var zero api.ImageStreamTagReference
 	data, err := repoFileGetter(metadata.Org, metadata.Repo, metadata.Branch)(api.CIOperatorInrepoConfigFileName)
 	if err != nil {
@@ -149,7 +152,7 @@ func imageStreamTagReferenceToString(istr api.ImageStreamTagReference) string {
 }
 
 func insertTagReferencesFromSteps(config api.MultiStageTestConfigurationLiteral, m map[string]types.NamespacedName) {
-	for _, subStep := range append(append(config.Pre, config.Test...), config.Post...) {
+	for _, subStep := range config.Steps() {
 		if subStep.FromImage != nil {
 			insert(*subStep.FromImage, m)
 		}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// InstallConfigImage is the image running the install-config-generator binary.
+var InstallConfigImage = ImageStreamTagReference{Namespace: "ci", Name: "install-config-generator", Tag: "latest"}
+
+// InstallConfigBase is what an install-config is composed on top of.
+type InstallConfigBase string
+
+const (
+	// InstallConfigBaseProfile generates a minimal install-config from the
+	// cluster profile of the test.
+	InstallConfigBaseProfile InstallConfigBase = "profile"
+	// InstallConfigBaseSharedDir starts from the install-config written to
+	// the shared directory by a previous step.
+	InstallConfigBaseSharedDir InstallConfigBase = "shared_dir"
+)
+
+// InstallConfigStep is a typed step composing the install-config.yaml of the
+// cluster under test in the shared directory. It is expanded into a literal
+// step running the install-config-generator binary, which merges the patches
+// into the base configuration and validates the result against the schema of
+// the installer. Steps can be chained, each starting from the shared
+// directory and adding its own patches.
+type InstallConfigStep struct {
+	// Base is what the install-config is composed on top of: `profile` (the
+	// default) generates a minimal configuration from the cluster profile,
+	// `shared_dir` starts from the install-config.yaml in the shared directory.
+	Base InstallConfigBase `json:"base,omitempty"`
+	// Patches are YAML documents merged into the install-config in order.
+	// Mappings are merged recursively, other values replace the existing ones.
+	// `${NAME}` references to parameters of the step are replaced beforehand.
+	Patches []string `json:"patches,omitempty"`
+}
+
+// BaseOrDefault is what the install-config is composed on top of.
+func (i *InstallConfigStep) BaseOrDefault() InstallConfigBase {
+	if i.Base == "" {
+		return InstallConfigBaseProfile
+	}
+	return i.Base
+}
+
+// Validate checks the configuration of the install-config composition.
+func (i *InstallConfigStep) Validate() []error {
+	var errs []error
+	switch i.Base {
+	case "", InstallConfigBaseProfile, InstallConfigBaseSharedDir:
+	default:
+		errs = append(errs, fmt.Errorf("base must be one of %s or %s, got %q", InstallConfigBaseProfile, InstallConfigBaseSharedDir, i.Base))
+	}
+	for idx, patch := range i.Patches {
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(patch), &parsed); err != nil {
+			errs = append(errs, fmt.Errorf("patches[%d] must be a YAML mapping: %w", idx, err))
+		}
+	}
+	return errs
+}
+
+func (i *InstallConfigStep) expand(step LiteralTestStep) LiteralTestStep {
+	image := InstallConfigImage
+	step.FromImage = &image
+	patches := i.Patches
+	if patches == nil {
+		patches = []string{}
+	}
+	// JSON strings never span lines, so the here-document cannot be
+	// terminated early by the content of a patch
+	raw, _ := json.Marshal(patches)
+	step.Commands = fmt.Sprintf("install-config-generator --base=%s --patches=/dev/stdin <<'PATCHES'\n%s\nPATCHES\n", i.BaseOrDefault(), raw)
+	return step
+}
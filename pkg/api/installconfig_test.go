@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestExpandInstallConfigStep(t *testing.T) {
+	step := LiteralTestStep{
+		As:          "install-config",
+		Environment: []StepParameter{{Name: "REPLICAS", Default: &[]string{"3"}[0]}},
+		InstallConfig: &InstallConfigStep{
+			Base:    InstallConfigBaseSharedDir,
+			Patches: []string{"controlPlane:\n  replicas: ${REPLICAS}\n"},
+		},
+	}
+	expected := LiteralTestStep{
+		As:          "install-config",
+		Environment: []StepParameter{{Name: "REPLICAS", Default: &[]string{"3"}[0]}},
+		FromImage:   &ImageStreamTagReference{Namespace: "ci", Name: "install-config-generator", Tag: "latest"},
+		Commands:    "install-config-generator --base=shared_dir --patches=/dev/stdin <<'PATCHES'\n[\"controlPlane:\\n  replicas: ${REPLICAS}\\n\"]\nPATCHES\n",
+		Resources:   ResourceRequirements{Requests: ResourceList{"cpu": "10m", "memory": "100Mi"}},
+	}
+	if diff := cmp.Diff(expected, ExpandTypedStep(step)); diff != "" {
+		t.Errorf("unexpected step: %s", diff)
+	}
+}
+
+func TestInstallConfigStepValidate(t *testing.T) {
+	step := InstallConfigStep{Base: "scratch", Patches: []string{"platform:\n  aws: {}\n", "- not a mapping\n"}}
+	expected := []error{
+		errors.New(`base must be one of profile or shared_dir, got "scratch"`),
+		errors.New("patches[1] must be a YAML mapping: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal array into Go value of type map[string]interface {}"),
+	}
+	if diff := cmp.Diff(expected, step.Validate(), testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected errors: %s", diff)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// LeakDetectionConfigKey is the key in the cluster profile secret that
+	// holds the leak detection configuration of the profile.
+	LeakDetectionConfigKey = "leak-detection.json"
+	// DefaultLeakDetectionImage is the image that scans for leaked resources.
+	DefaultLeakDetectionImage = "registry.ci.openshift.org/ci/leak-detector:latest"
+	// LeakDetectionClusterNamePlaceholder is replaced with the infrastructure
+	// name of the cluster in leak detection tags.
+	LeakDetectionClusterNamePlaceholder = "${CLUSTER_NAME}"
+	// LeakDetectionStepName is the name of the step appended to the `post`
+	// phase of tests when their cluster profile enables leak detection. Steps
+	// of tests cannot use it.
+	LeakDetectionStepName = "leak-detection"
+)
+
+var leakDetectionTagRegexp = regexp.MustCompile(`^[a-zA-Z0-9._/${}-]+$`)
+
+// LeakDetection configures a scan for cloud resources of the cluster which
+// survive the teardown. The scan runs as the last step of the `post` phase and
+// finds resources using the tags (on AWS) or labels (on GCP) the installer puts
+// on all resources of a cluster. Leaks are reported as a warning and recorded in
+// a manifest for the reaper, they never fail the test.
+type LeakDetection struct {
+	// Image is the pull spec of the image running the scan, defaults to the
+	// leak-detector image.
+	Image string `yaml:"image,omitempty" json:"image,omitempty"`
+	// Tag is the key of the tag or label identifying the resources of the
+	// cluster, in which `${CLUSTER_NAME}` is replaced with the infrastructure
+	// name of the cluster. Defaults to the one set by the installer.
+	Tag string `yaml:"tag,omitempty" json:"tag,omitempty"`
+}
+
+// ScanImage is the pull spec of the image running the scan.
+func (l *LeakDetection) ScanImage() string {
+	if l.Image == "" {
+		return DefaultLeakDetectionImage
+	}
+	return l.Image
+}
+
+// Validate checks that the tag can be used to look up the resources of a cluster.
+func (l *LeakDetection) Validate() error {
+	if l.Tag == "" {
+		return nil
+	}
+	if !leakDetectionTagRegexp.MatchString(l.Tag) {
+		return fmt.Errorf("tag %q must match %s", l.Tag, leakDetectionTagRegexp.String())
+	}
+	if !strings.Contains(l.Tag, LeakDetectionClusterNamePlaceholder) {
+		return fmt.Errorf("tag %q must contain %s", l.Tag, LeakDetectionClusterNamePlaceholder)
+	}
+	return nil
+}
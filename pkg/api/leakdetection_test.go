@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLeakDetectionValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ld       LeakDetection
+		expected error
+	}{
+		{
+			name: "default tag",
+		},
+		{
+			name: "custom tag",
+			ld:   LeakDetection{Tag: "sigs.k8s.io/cluster-api-provider-aws/cluster/${CLUSTER_NAME}"},
+		},
+		{
+			name:     "no placeholder",
+			ld:       LeakDetection{Tag: "owner"},
+			expected: errors.New(`tag "owner" must contain ${CLUSTER_NAME}`),
+		},
+		{
+			name:     "invalid characters",
+			ld:       LeakDetection{Tag: "owner='${CLUSTER_NAME}'"},
+			expected: errors.New(`tag "owner='${CLUSTER_NAME}'" must match ^[a-zA-Z0-9._/${}-]+$`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.ld.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
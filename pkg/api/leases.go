@@ -16,7 +16,7 @@ func LeasesForTest(s *MultiStageTestConfigurationLiteral) (ret []StepLease) {
 			Count:        1,
 		})
 	}
-	for _, step := range append(s.Pre, append(s.Test, s.Post...)...) {
+	for _, step := range s.Steps() {
 		ret = append(ret, step.Leases...)
 	}
 	ret = append(ret, s.Leases...)
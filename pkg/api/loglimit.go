@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// LogLimit caps the size of the output of a step kept in its log. When the
+// output is larger, the first `head` and the last `tail` bytes of it are kept
+// around a marker recording how much was truncated. A limit with neither set
+// keeps the whole output.
+type LogLimit struct {
+	// Head is how much of the start of the output is kept, as a quantity
+	// (e.g. `100Mi`).
+	Head string `json:"head,omitempty"`
+	// Tail is how much of the end of the output is kept, as a quantity (e.g.
+	// `100Mi`).
+	Tail string `json:"tail,omitempty"`
+}
+
+// Bytes are the sizes of the head and tail kept by the limit.
+func (l *LogLimit) Bytes() (head, tail int64, err error) {
+	for _, field := range []struct {
+		name  string
+		value string
+		into  *int64
+	}{{"head", l.Head, &head}, {"tail", l.Tail, &tail}} {
+		if field.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(field.value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: invalid quantity %q: %w", field.name, field.value, err)
+		}
+		if quantity.Sign() < 0 {
+			return 0, 0, fmt.Errorf("%s: must not be negative, got %s", field.name, field.value)
+		}
+		*field.into = quantity.Value()
+	}
+	return head, tail, nil
+}
+
+// Validate checks that the sizes of the limit are valid quantities.
+func (l *LogLimit) Validate() error {
+	_, _, err := l.Bytes()
+	return err
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLogLimitBytes(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		limit       LogLimit
+		head, tail  int64
+		expectedErr error
+	}{
+		{
+			name: "no limit",
+		},
+		{
+			name:  "head and tail",
+			limit: LogLimit{Head: "1Mi", Tail: "500k"},
+			head:  1 << 20,
+			tail:  500000,
+		},
+		{
+			name:  "only head",
+			limit: LogLimit{Head: "1Gi"},
+			head:  1 << 30,
+		},
+		{
+			name:        "invalid quantity",
+			limit:       LogLimit{Head: "1Mi", Tail: "lots"},
+			expectedErr: errors.New(`tail: invalid quantity "lots": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`),
+		},
+		{
+			name:        "negative quantity",
+			limit:       LogLimit{Head: "-1Mi"},
+			expectedErr: errors.New("head: must not be negative, got -1Mi"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			head, tail, err := tc.limit.Bytes()
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if head != tc.head || tail != tc.tail {
+				t.Errorf("expected head %d and tail %d, got %d and %d", tc.head, tc.tail, head, tail)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package migrate upgrades configurations written in older versions of the
+// ci-operator configuration schema to the latest one.
+package migrate
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// migration upgrades a configuration from the previous schema version to
+// `to`, returning a description of each change it made.
+type migration struct {
+	to      int
+	migrate func(*api.ReleaseBuildConfiguration) ([]string, error)
+}
+
+// migrations are ordered by the version they upgrade configurations to.
+var migrations = []migration{
+	{to: 2, migrate: releasesFromTagSpecification},
+}
+
+// Migrate upgrades the configuration in place to the latest schema version
+// and describes the changes made. Configurations from a newer schema than
+// the latest one known are rejected.
+func Migrate(config *api.ReleaseBuildConfiguration) ([]string, error) {
+	version := config.SchemaVersion
+	if version == 0 {
+		version = api.InitialSchemaVersion
+	}
+	if version > api.LatestSchemaVersion {
+		return nil, fmt.Errorf("schema_version: version %d is newer than the latest supported version %d", version, api.LatestSchemaVersion)
+	}
+	var changes []string
+	for _, m := range migrations {
+		if m.to <= version {
+			continue
+		}
+		migrated, err := m.migrate(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade the configuration to schema version %d: %w", m.to, err)
+		}
+		changes = append(changes, migrated...)
+		version = m.to
+	}
+	config.SchemaVersion = version
+	return changes, nil
+}
+
+// releasesFromTagSpecification replaces `tag_specification` with the
+// `releases` it is equivalent to: the `initial` and `latest` releases are
+// assembled from the integration stream, the latter including the images
+// built by the job. Base images which only name a tag are defaulted to the
+// integration stream explicitly, as `tag_specification` did implicitly.
+func releasesFromTagSpecification(config *api.ReleaseBuildConfiguration) ([]string, error) {
+	tagSpec := config.InputConfiguration.ReleaseTagConfiguration
+	if tagSpec == nil {
+		return nil, nil
+	}
+	for _, name := range []string{api.InitialReleaseName, api.LatestReleaseName} {
+		if _, ok := config.Releases[name]; ok {
+			return nil, fmt.Errorf("releases.%s: cannot be set along with tag_specification", name)
+		}
+	}
+	var changes []string
+	for _, images := range []struct {
+		field  string
+		images map[string]api.ImageStreamTagReference
+	}{
+		{field: "base_images", images: config.InputConfiguration.BaseImages},
+		{field: "base_rpm_images", images: config.InputConfiguration.BaseRPMImages},
+	} {
+		for _, name := range sets.List(sets.KeySet(images.images)) {
+			image := images.images[name]
+			if image.Tag == "" || image.Name != "" || image.Namespace != "" {
+				continue
+			}
+			image.Namespace, image.Name = tagSpec.Namespace, tagSpec.Name
+			images.images[name] = image
+			changes = append(changes, fmt.Sprintf("%s.%s: defaulted to %s", images.field, name, image.ISTagName()))
+		}
+	}
+	if config.Releases == nil {
+		config.Releases = map[string]api.UnresolvedRelease{}
+	}
+	config.Releases[api.InitialReleaseName] = api.UnresolvedRelease{Integration: &api.Integration{Namespace: tagSpec.Namespace, Name: tagSpec.Name}}
+	config.Releases[api.LatestReleaseName] = api.UnresolvedRelease{Integration: &api.Integration{Namespace: tagSpec.Namespace, Name: tagSpec.Name, IncludeBuiltImages: true}}
+	config.InputConfiguration.ReleaseTagConfiguration = nil
+	changes = append(changes, fmt.Sprintf("tag_specification: replaced with releases.%s and releases.%s from %s/%s", api.InitialReleaseName, api.LatestReleaseName, tagSpec.Namespace, tagSpec.Name))
+	return changes, nil
+}
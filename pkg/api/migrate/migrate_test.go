@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestMigrate(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		config          api.ReleaseBuildConfiguration
+		expected        api.ReleaseBuildConfiguration
+		expectedChanges []string
+		expectedErr     error
+	}{
+		{
+			name:     "configuration without a version only gets one",
+			config:   api.ReleaseBuildConfiguration{},
+			expected: api.ReleaseBuildConfiguration{SchemaVersion: api.LatestSchemaVersion},
+		},
+		{
+			name: "tag_specification is replaced with releases",
+			config: api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{
+					BaseImages: map[string]api.ImageStreamTagReference{
+						"base":  {Tag: "base"},
+						"tools": {Namespace: "ci", Name: "tools", Tag: "latest"},
+					},
+					BaseRPMImages: map[string]api.ImageStreamTagReference{
+						"rpms": {Tag: "rpms"},
+					},
+					Releases: map[string]api.UnresolvedRelease{
+						"previous": {Release: &api.Release{Version: "4.15", Channel: api.ReleaseChannelStable}},
+					},
+					ReleaseTagConfiguration: &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"},
+				},
+			},
+			expected: api.ReleaseBuildConfiguration{
+				SchemaVersion: api.LatestSchemaVersion,
+				InputConfiguration: api.InputConfiguration{
+					BaseImages: map[string]api.ImageStreamTagReference{
+						"base":  {Namespace: "ocp", Name: "4.16", Tag: "base"},
+						"tools": {Namespace: "ci", Name: "tools", Tag: "latest"},
+					},
+					BaseRPMImages: map[string]api.ImageStreamTagReference{
+						"rpms": {Namespace: "ocp", Name: "4.16", Tag: "rpms"},
+					},
+					Releases: map[string]api.UnresolvedRelease{
+						"previous": {Release: &api.Release{Version: "4.15", Channel: api.ReleaseChannelStable}},
+						"initial":  {Integration: &api.Integration{Namespace: "ocp", Name: "4.16"}},
+						"latest":   {Integration: &api.Integration{Namespace: "ocp", Name: "4.16", IncludeBuiltImages: true}},
+					},
+				},
+			},
+			expectedChanges: []string{
+				"base_images.base: defaulted to ocp/4.16:base",
+				"base_rpm_images.rpms: defaulted to ocp/4.16:rpms",
+				"tag_specification: replaced with releases.initial and releases.latest from ocp/4.16",
+			},
+		},
+		{
+			name: "configuration in the latest version is not changed",
+			config: api.ReleaseBuildConfiguration{
+				SchemaVersion: api.LatestSchemaVersion,
+				InputConfiguration: api.InputConfiguration{
+					ReleaseTagConfiguration: &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"},
+				},
+			},
+			expected: api.ReleaseBuildConfiguration{
+				SchemaVersion: api.LatestSchemaVersion,
+				InputConfiguration: api.InputConfiguration{
+					ReleaseTagConfiguration: &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"},
+				},
+			},
+		},
+		{
+			name: "tag_specification conflicting with releases",
+			config: api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{
+					Releases: map[string]api.UnresolvedRelease{
+						"latest": {Release: &api.Release{Version: "4.16", Channel: api.ReleaseChannelStable}},
+					},
+					ReleaseTagConfiguration: &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"},
+				},
+			},
+			expectedErr: errors.New("failed to upgrade the configuration to schema version 2: releases.latest: cannot be set along with tag_specification"),
+		},
+		{
+			name:        "configuration from a newer schema",
+			config:      api.ReleaseBuildConfiguration{SchemaVersion: api.LatestSchemaVersion + 1},
+			expectedErr: errors.New("schema_version: version 3 is newer than the latest supported version 2"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, err := Migrate(&tc.config)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err != nil {
+				return
+			}
+			testhelper.Diff(t, "changes", changes, tc.expectedChanges)
+			testhelper.Diff(t, "configuration", tc.config, tc.expected)
+		})
+	}
+}
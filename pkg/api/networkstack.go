@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NetworkStack is the IP stack of the network of the cluster under test.
+type NetworkStack string
+
+const (
+	NetworkStackIPv4      NetworkStack = "ipv4"
+	NetworkStackIPv6      NetworkStack = "ipv6"
+	NetworkStackDualStack NetworkStack = "dualstack"
+
+	// NetworkStackEnv exposes the network stack of a test to its steps.
+	NetworkStackEnv = "NETWORK_STACK"
+)
+
+// networkStackClusterTypes are the prefixes of the cluster types on whose
+// platforms the installer supports network stacks other than IPv4, which is
+// supported everywhere.
+var networkStackClusterTypes = map[NetworkStack][]string{
+	NetworkStackIPv6:      {"equinix", "metal"},
+	NetworkStackDualStack: {"equinix", "metal", "nutanix", "openstack", "vsphere"},
+}
+
+// OrDefault is the network stack, IPv4 when unset.
+func (n NetworkStack) OrDefault() NetworkStack {
+	if n == "" {
+		return NetworkStackIPv4
+	}
+	return n
+}
+
+// Validate checks that the network stack is known.
+func (n NetworkStack) Validate() error {
+	switch n {
+	case NetworkStackIPv4, NetworkStackIPv6, NetworkStackDualStack:
+		return nil
+	}
+	return fmt.Errorf("%q is not a network stack, expected one of %s, %s or %s", n, NetworkStackIPv4, NetworkStackIPv6, NetworkStackDualStack)
+}
+
+// SupportedBy determines whether a cluster of the given type can be installed
+// with the network stack. The stacks listed by a cluster profile replace the
+// ones supported by its platform.
+func (n NetworkStack) SupportedBy(clusterType string, profileStacks []NetworkStack) bool {
+	stack := n.OrDefault()
+	if len(profileStacks) != 0 {
+		return slices.Contains(profileStacks, stack)
+	}
+	if stack == NetworkStackIPv4 {
+		return true
+	}
+	return slices.ContainsFunc(networkStackClusterTypes[stack], func(prefix string) bool {
+		return strings.HasPrefix(clusterType, prefix)
+	})
+}
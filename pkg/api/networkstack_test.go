@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestNetworkStackSupportedBy(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		stack         NetworkStack
+		clusterType   string
+		profileStacks []NetworkStack
+		expected      bool
+	}{
+		{
+			name:        "IPv4 by default",
+			clusterType: "aws",
+			expected:    true,
+		},
+		{
+			name:        "IPv6 on bare metal",
+			stack:       NetworkStackIPv6,
+			clusterType: "equinix-ocp-metal",
+			expected:    true,
+		},
+		{
+			name:        "IPv6 on a cloud",
+			stack:       NetworkStackIPv6,
+			clusterType: "gcp",
+		},
+		{
+			name:        "dual-stack on vSphere",
+			stack:       NetworkStackDualStack,
+			clusterType: "vsphere-elastic",
+			expected:    true,
+		},
+		{
+			name:          "profile supporting dual-stack",
+			stack:         NetworkStackDualStack,
+			clusterType:   "aws",
+			profileStacks: []NetworkStack{NetworkStackIPv4, NetworkStackDualStack},
+			expected:      true,
+		},
+		{
+			name:          "profile supporting only IPv6",
+			clusterType:   "metal-telco5g",
+			profileStacks: []NetworkStack{NetworkStackIPv6},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.stack.SupportedBy(tc.clusterType, tc.profileStacks); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
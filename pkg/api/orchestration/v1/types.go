@@ -0,0 +1,114 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// AcceptedCondition indicates whether the configuration of the resource was
+	// validated and the resource is ready to be executed.
+	AcceptedCondition = "Accepted"
+
+	ValidConfigurationReason   = "ValidConfiguration"
+	InvalidConfigurationReason = "InvalidConfiguration"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=tr
+// +kubebuilder:subresource:status
+
+// TestRun represents the intent to run tests of a ci-operator configuration,
+// the cluster-native equivalent of a ci-operator invocation with targets.
+type TestRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:validation:Required
+	Spec   TestRunSpec   `json:"spec"`
+	Status TestRunStatus `json:"status,omitempty"`
+}
+
+// TestRunSpec specifies the configuration, the code under test and the tests
+// to run.
+type TestRunSpec struct {
+	// Config is a ci-operator configuration, in the same format as the files
+	// in openshift/release.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config api.ReleaseBuildConfiguration `json:"config"`
+	// Refs specifies the code to be tested.
+	Refs prowv1.Refs `json:"refs"`
+	// Targets are the names of the tests in the configuration to run.
+	// +kubebuilder:validation:MinItems=1
+	Targets []string `json:"targets"`
+}
+
+// TestRunStatus provides runtime data of the run.
+type TestRunStatus struct {
+	// ObservedGeneration is the generation of the spec the status reflects.
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TestRunList is a list of TestRun resources
+type TestRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TestRun `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=ip
+// +kubebuilder:subresource:status
+
+// ImagePipeline represents the intent to build the images of a ci-operator
+// configuration, the cluster-native equivalent of the image building part of
+// a ci-operator invocation.
+type ImagePipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:validation:Required
+	Spec   ImagePipelineSpec   `json:"spec"`
+	Status ImagePipelineStatus `json:"status,omitempty"`
+}
+
+// ImagePipelineSpec specifies the configuration, the code to build from and
+// the images to build.
+type ImagePipelineSpec struct {
+	// Config is a ci-operator configuration, in the same format as the files
+	// in openshift/release.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config api.ReleaseBuildConfiguration `json:"config"`
+	// Refs specifies the code to build the images from.
+	Refs prowv1.Refs `json:"refs"`
+	// Images are the names of the images in the configuration to build. All
+	// images are built when omitted.
+	Images []string `json:"images,omitempty"`
+}
+
+// ImagePipelineStatus provides runtime data of the pipeline.
+type ImagePipelineStatus struct {
+	// ObservedGeneration is the generation of the spec the status reflects.
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImagePipelineList is a list of ImagePipeline resources
+type ImagePipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ImagePipeline `json:"items"`
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -46,6 +47,30 @@ func ImageTargets(c *ReleaseBuildConfiguration) sets.Set[string] {
 	return imageTargets
 }
 
+// PromotesByCommit determines if a configuration promotes images tagged by
+// the commit they were built from.
+func PromotesByCommit(c *PromotionConfiguration) bool {
+	for _, target := range PromotionTargets(c) {
+		if !target.Disabled && target.TagByCommit {
+			return true
+		}
+	}
+	return false
+}
+
+// Paused determines whether the promotion to the target is paused at the
+// given time.
+func (t PromotionTarget) Paused(now time.Time) (bool, error) {
+	if t.PausedUntil == "" {
+		return false, nil
+	}
+	until, err := time.Parse(time.RFC3339, t.PausedUntil)
+	if err != nil {
+		return false, fmt.Errorf("invalid paused_until %q: %w", t.PausedUntil, err)
+	}
+	return now.Before(until), nil
+}
+
 // PromotesOfficialImages determines if a configuration will result in official images
 // being promoted. This is a proxy for determining if a configuration contributes to
 // the release payload.
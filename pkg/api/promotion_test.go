@@ -2,6 +2,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -176,3 +177,74 @@ func TestPromotionTargets(t *testing.T) {
 		}
 	}
 }
+
+func TestPromotesByCommit(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		input    *PromotionConfiguration
+		expected bool
+	}{
+		{
+			name: "no config",
+		},
+		{
+			name:  "targets tagging by name",
+			input: &PromotionConfiguration{Targets: []PromotionTarget{{Namespace: "ns", Name: "name"}}},
+		},
+		{
+			name:  "disabled target tagging by commit",
+			input: &PromotionConfiguration{Targets: []PromotionTarget{{Namespace: "ns", Name: "name"}, {Namespace: "ns", Tag: "latest", TagByCommit: true, Disabled: true}}},
+		},
+		{
+			name:     "target tagging by commit",
+			input:    &PromotionConfiguration{Targets: []PromotionTarget{{Namespace: "ns", Name: "name"}, {Namespace: "ns", Tag: "latest", TagByCommit: true}}},
+			expected: true,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := PromotesByCommit(testCase.input); actual != testCase.expected {
+			t.Errorf("%s: expected %v, got %v", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestPromotionTargetPaused(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name        string
+		pausedUntil string
+		expected    bool
+		expectedErr bool
+	}{
+		{
+			name: "not paused",
+		},
+		{
+			name:        "paused until later",
+			pausedUntil: "2024-06-10T00:00:00Z",
+			expected:    true,
+		},
+		{
+			name:        "pause is over",
+			pausedUntil: "2024-06-03T12:00:00Z",
+		},
+		{
+			name:        "pause in another time zone",
+			pausedUntil: "2024-06-03T13:30:00+02:00",
+		},
+		{
+			name:        "malformed timestamp",
+			pausedUntil: "2024-06-10",
+			expectedErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		paused, err := PromotionTarget{PausedUntil: testCase.pausedUntil}.Paused(now)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("%s: expected error %v, got %v", testCase.name, testCase.expectedErr, err)
+		}
+		if paused != testCase.expected {
+			t.Errorf("%s: expected %v, got %v", testCase.name, testCase.expected, paused)
+		}
+	}
+}
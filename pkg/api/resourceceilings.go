@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ResourceCeilingPolicy declares the largest cpu and memory requests the
+// builds, tests and test steps of the configurations of each organization may
+// make, so that a few oversized pods do not distort the scheduling in the build
+// farm. Tests and images which need more are annotated with
+// ResourceCeilingExemptionAnnotation in their configuration.
+// +k8s:deepcopy-gen=false
+type ResourceCeilingPolicy struct {
+	// Default are the ceilings for the organizations without ceilings of
+	// their own. Requests are not restricted when unset.
+	Default ResourceList `json:"default,omitempty"`
+	// Orgs maps organizations to their ceilings, which replace the default.
+	Orgs map[string]ResourceList `json:"orgs,omitempty"`
+}
+
+// Validate checks that the policy is well-formed.
+func (p *ResourceCeilingPolicy) Validate() error {
+	var errs []error
+	errs = append(errs, validateCeilings("default", p.Default)...)
+	var orgs []string
+	for org := range p.Orgs {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	for _, org := range orgs {
+		errs = append(errs, validateCeilings(fmt.Sprintf("orgs[%s]", org), p.Orgs[org])...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validateCeilings(fieldRoot string, ceilings ResourceList) []error {
+	var errs []error
+	for _, name := range []string{"cpu", "memory"} {
+		raw, ok := ceilings[name]
+		if !ok {
+			continue
+		}
+		if quantity, err := resource.ParseQuantity(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: invalid quantity: %w", fieldRoot, name, err))
+		} else if quantity.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("%s.%s: must be positive", fieldRoot, name))
+		}
+	}
+	var names []string
+	for name := range ceilings {
+		if name != "cpu" && name != "memory" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s.%s: only cpu and memory can be restricted", fieldRoot, name))
+	}
+	return errs
+}
+
+// CeilingsFor returns the ceilings for the configurations of an organization.
+func (p *ResourceCeilingPolicy) CeilingsFor(org string) ResourceList {
+	if ceilings, ok := p.Orgs[org]; ok {
+		return ceilings
+	}
+	return p.Default
+}
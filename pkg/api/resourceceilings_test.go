@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestResourceCeilingPolicyValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   ResourceCeilingPolicy
+		expected error
+	}{
+		{
+			name: "valid",
+			policy: ResourceCeilingPolicy{
+				Default: ResourceList{"cpu": "8", "memory": "16Gi"},
+				Orgs:    map[string]ResourceList{"openshift": {"cpu": "16"}},
+			},
+		},
+		{
+			name: "invalid",
+			policy: ResourceCeilingPolicy{
+				Default: ResourceList{"cpu": "many", "ephemeral-storage": "1Gi"},
+				Orgs:    map[string]ResourceList{"openshift": {"memory": "0"}},
+			},
+			expected: errors.New(`[default.cpu: invalid quantity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$', default.ephemeral-storage: only cpu and memory can be restricted, orgs[openshift].memory: must be positive]`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.policy.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceCeilingPolicyCeilingsFor(t *testing.T) {
+	policy := ResourceCeilingPolicy{
+		Default: ResourceList{"cpu": "8"},
+		Orgs:    map[string]ResourceList{"openshift": {"cpu": "16"}},
+	}
+	if diff := cmp.Diff(ResourceList{"cpu": "16"}, policy.CeilingsFor("openshift")); diff != "" {
+		t.Errorf("unexpected ceilings for an organization with its own: %s", diff)
+	}
+	if diff := cmp.Diff(ResourceList{"cpu": "8"}, policy.CeilingsFor("other")); diff != "" {
+		t.Errorf("unexpected ceilings for an organization without its own: %s", diff)
+	}
+}
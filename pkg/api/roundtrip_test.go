@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	fuzz "github.com/google/gofuzz"
+
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/yaml"
+)
+
+// roundTripIgnored are the differences expected after a round trip: empty
+// lists and maps are omitted, and fields holding state computed at runtime are
+// not serialized, which TestRoundTripRuntimeFields covers.
+var roundTripIgnored = []cmp.Option{
+	cmpopts.EquateEmpty(),
+	cmpopts.IgnoreUnexported(prowconfig.Retry{}, ProjectDirectoryImageBuildStepConfiguration{}),
+	cmp.FilterPath(notSerialized, cmp.Ignore()),
+}
+
+// notSerialized determines whether the path is a field excluded from the
+// serialization by its tag.
+func notSerialized(path cmp.Path) bool {
+	field, ok := path.Last().(cmp.StructField)
+	if !ok {
+		return false
+	}
+	parent := path.Index(-2).Type()
+	if parent.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := parent.FieldByName(field.Name())
+	return ok && f.Tag.Get("json") == "-"
+}
+
+func newRoundTripFuzzer() *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.3).NumElements(0, 2)
+}
+
+// testRoundTrip verifies that random values of a type are serialized
+// deterministically and load back identically.
+func testRoundTrip[T any](iterations int) func(t *testing.T) {
+	return func(t *testing.T) {
+		fuzzer := newRoundTripFuzzer()
+		for i := 0; i < iterations; i++ {
+			var in, out T
+			fuzzer.Fuzz(&in)
+			raw, err := yaml.Marshal(in)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if err := yaml.UnmarshalStrict(raw, &out); err != nil {
+				t.Fatalf("failed to unmarshal: %v\n%s", err, raw)
+			}
+			if diff := cmp.Diff(in, out, roundTripIgnored...); diff != "" {
+				t.Fatalf("value changed in a round trip: %s\n%s", diff, raw)
+			}
+			again, err := yaml.Marshal(out)
+			if err != nil {
+				t.Fatalf("failed to marshal again: %v", err)
+			}
+			if !bytes.Equal(raw, again) {
+				t.Fatalf("serialization changed in a round trip: %s", cmp.Diff(string(raw), string(again)))
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Run("ReleaseBuildConfiguration", testRoundTrip[ReleaseBuildConfiguration](100))
+	t.Run("CIOperatorInrepoConfig", testRoundTrip[CIOperatorInrepoConfig](100))
+	t.Run("RegistryReferenceConfig", testRoundTrip[RegistryReferenceConfig](200))
+	t.Run("RegistryChainConfig", testRoundTrip[RegistryChainConfig](200))
+	t.Run("RegistryWorkflowConfig", testRoundTrip[RegistryWorkflowConfig](200))
+	t.Run("RegistryObserverConfig", testRoundTrip[RegistryObserverConfig](200))
+	t.Run("ClusterProfileDetails", testRoundTrip[ClusterProfileDetails](200))
+	t.Run("ClusterClaimOwnerDetails", testRoundTrip[ClusterClaimOwnerDetails](200))
+}
+
+// TestRoundTripRuntimeFields covers the fields which only hold state computed
+// at runtime: they are not serialized, and so cannot be set in configurations.
+func TestRoundTripRuntimeFields(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in       interface{}
+		expected string
+	}{
+		{
+			name:     "pull spec of a dependency",
+			in:       StepDependency{Name: "src", Env: "SRC", PullSpec: "quay.io/org/src:latest"},
+			expected: "env: SRC\nname: src\n",
+		},
+		{
+			name: "sources and pull secret of an input image",
+			in: InputImageTagStepConfiguration{
+				InputImage: InputImage{To: "cli", BaseImage: ImageStreamTagReference{Namespace: "ocp", Name: "4.17", Tag: "cli"}},
+				Sources:    []ImageStreamSource{{SourceType: ImageStreamSourceTest, Name: "e2e"}},
+				PullSecret: "private-registry",
+			},
+			expected: "base_image:\n  name: \"4.17\"\n  namespace: ocp\n  tag: cli\nto: cli\n",
+		},
+		{
+			name:     "bundle image",
+			in:       *(&ProjectDirectoryImageBuildStepConfiguration{To: "bundle"}).WithBundleImage(true),
+			expected: "to: bundle\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := yaml.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(raw)); diff != "" {
+				t.Errorf("unexpected serialization: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRoundTripImageRef(t *testing.T) {
+	raw := []byte("to: bin\nref: org.repo\ndockerfile_path: Dockerfile\n")
+	var image ProjectDirectoryImageBuildStepConfiguration
+	if err := yaml.UnmarshalStrict(raw, &image); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if image.ProjectDirectoryImageBuildInputs.Ref != "org.repo" {
+		t.Errorf("expected the inputs of the image to link to org.repo, got %q", image.ProjectDirectoryImageBuildInputs.Ref)
+	}
+	again, err := yaml.Marshal(image)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if diff := cmp.Diff("dockerfile_path: Dockerfile\nref: org.repo\nto: bin\n", string(again)); diff != "" {
+		t.Errorf("unexpected serialization: %s", diff)
+	}
+}
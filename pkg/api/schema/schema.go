@@ -0,0 +1,202 @@
+// Package schema generates the JSON Schema of the ci-operator configuration
+// and of the step registry components, so that editors and external tools
+// can offer completion and validate files before they reach ci-operator.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// Version is the JSON Schema dialect the generated schema is written in.
+const Version = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe the configuration.
+type Schema struct {
+	Schema string `json:"$schema,omitempty"`
+	Ref    string `json:"$ref,omitempty"`
+	// Type is either a single type or a list of the types a value may have.
+	Type       interface{}        `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// AdditionalProperties is either a schema the values of unknown keys
+	// must match, or false when no unknown keys are allowed.
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// roots are the top-level objects of the files the schema describes, the
+// ci-operator configuration being the root of the document.
+var roots = []reflect.Type{
+	reflect.TypeOf(api.ReleaseBuildConfiguration{}),
+	reflect.TypeOf(api.RegistryReferenceConfig{}),
+	reflect.TypeOf(api.RegistryChainConfig{}),
+	reflect.TypeOf(api.RegistryWorkflowConfig{}),
+	reflect.TypeOf(api.RegistryObserverConfig{}),
+}
+
+// overrides describes the types which are serialized differently from their
+// Go structure.
+var overrides = map[reflect.Type]*Schema{
+	reflect.TypeOf(prowv1.Duration{}): {Type: "string"},
+}
+
+// Generate returns the JSON Schema of the ci-operator configuration. The
+// step registry components are described in its definitions, named after
+// their types, e.g. `#/$defs/RegistryChainConfig`.
+//
+// The schema only describes the structure of the files: the constraints
+// enforced by validation, such as required fields, are not part of it.
+func Generate() *Schema {
+	g := newGenerator()
+	for _, t := range roots {
+		g.schemaFor(t)
+	}
+	return &Schema{
+		Schema: Version,
+		Ref:    g.schemaFor(roots[0]).Ref,
+		Defs:   g.defs,
+	}
+}
+
+// Print returns the generated schema, indented.
+func Print() ([]byte, error) {
+	raw, err := json.MarshalIndent(Generate(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the schema: %w", err)
+	}
+	return raw, nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+	// names are the definitions the struct types are described in
+	names map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{defs: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+var (
+	jsonMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// schemaFor describes a type. Named structs are described once in the
+// definitions and referenced, which allows recursive types.
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if override, ok := overrides[t]; ok {
+		copied := *override
+		return &copied
+	}
+	if customSerialization(t) {
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: []string{"array", "null"}, Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// customSerialization determines whether the type controls its own
+// serialization, in which case its structure cannot be derived. The types of
+// this repository only implement it to decode strictly, so their structure
+// still applies.
+func customSerialization(t reflect.Type) bool {
+	if strings.HasPrefix(t.PkgPath(), "github.com/openshift/ci-tools/") {
+		return false
+	}
+	ptr := reflect.PointerTo(t)
+	return t.Implements(jsonMarshaler) || ptr.Implements(jsonMarshaler) || ptr.Implements(jsonUnmarshaler)
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.describeStruct(t)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = g.definitionName(t)
+		g.names[t] = name
+		// registered before the fields are described to terminate recursion
+		g.defs[name] = &Schema{}
+		*g.defs[name] = *g.describeStruct(t)
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// definitionName names the definition of a type after the type, qualifying
+// it with its package when the name is already taken.
+func (g *generator) definitionName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	return name
+}
+
+func (g *generator) describeStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	g.addFields(schema, t)
+	return schema
+}
+
+// addFields describes the serialized fields of a struct, inlining the
+// fields of embedded structs as encoding/json does.
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.schemaFor(field.Type)
+	}
+}
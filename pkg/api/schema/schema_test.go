@@ -0,0 +1,225 @@
+package schema
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type embedded struct {
+	Inlined string `json:"inlined"`
+}
+
+type node struct {
+	embedded `json:",inline"`
+	Name     string             `json:"name"`
+	Children []node             `json:"children,omitempty"`
+	Parent   *node              `json:"parent,omitempty"`
+	Labels   map[string]string  `json:"labels,omitempty"`
+	Timeout  *prowv1.Duration   `json:"timeout,omitempty"`
+	Untagged bool               `json:",omitempty"`
+	Ignored  string             `json:"-"`
+	Raw      []byte             `json:"raw,omitempty"`
+	Counts   map[string]float64 `json:"counts,omitempty"`
+	internal int
+}
+
+func TestSchemaFor(t *testing.T) {
+	g := newGenerator()
+	nodeRef := &Schema{Ref: "#/$defs/node"}
+	testhelper.Diff(t, "reference", g.schemaFor(reflect.TypeOf(&node{})), nodeRef)
+	expected := map[string]*Schema{
+		"node": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"inlined":  {Type: "string"},
+				"name":     {Type: "string"},
+				"children": {Type: []string{"array", "null"}, Items: nodeRef},
+				"parent":   nodeRef,
+				"labels":   {Type: []string{"object", "null"}, AdditionalProperties: &Schema{Type: "string"}},
+				"timeout":  {Type: "string"},
+				"Untagged": {Type: "boolean"},
+				"raw":      {Type: "string", Format: "byte"},
+				"counts":   {Type: []string{"object", "null"}, AdditionalProperties: &Schema{Type: "number"}},
+			},
+			AdditionalProperties: false,
+		},
+	}
+	testhelper.Diff(t, "definitions", g.defs, expected)
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate()
+	if schema.Ref != "#/$defs/ReleaseBuildConfiguration" {
+		t.Errorf("expected the document to describe the ci-operator configuration, got %q", schema.Ref)
+	}
+	for _, name := range []string{"RegistryReferenceConfig", "RegistryChainConfig", "RegistryWorkflowConfig", "RegistryObserverConfig"} {
+		if _, ok := schema.Defs[name]; !ok {
+			t.Errorf("expected a definition for %s", name)
+		}
+	}
+	for name, def := range schema.Defs {
+		for property, s := range def.Properties {
+			if s.Ref == "" {
+				continue
+			}
+			if _, ok := schema.Defs[s.Ref[len("#/$defs/"):]]; !ok {
+				t.Errorf("%s.%s: reference to a missing definition %s", name, property, s.Ref)
+			}
+		}
+	}
+	if _, err := Print(); err != nil {
+		t.Errorf("failed to print the schema: %v", err)
+	}
+}
+
+// validate reports where the value does not match the schema, resolving
+// references against the definitions.
+func validate(defs map[string]*Schema, schema *Schema, value interface{}, path string) []error {
+	if schema.Ref != "" {
+		return validate(defs, defs[strings.TrimPrefix(schema.Ref, "#/$defs/")], value, path)
+	}
+	if schema.Type == nil {
+		return nil
+	}
+	var types []string
+	switch t := schema.Type.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	}
+	var actual string
+	switch v := value.(type) {
+	case nil:
+		actual = "null"
+	case bool:
+		actual = "boolean"
+	case float64:
+		actual = "number"
+		if v == math.Trunc(v) {
+			actual = "integer"
+		}
+	case string:
+		actual = "string"
+	case []interface{}:
+		actual = "array"
+	case map[string]interface{}:
+		actual = "object"
+	}
+	matches := func(t string) bool {
+		return t == actual || (t == "number" && actual == "integer")
+	}
+	if !slices.ContainsFunc(types, matches) {
+		return []error{fmt.Errorf("%s: expected %v, got %s", path, types, actual)}
+	}
+	var errs []error
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			errs = append(errs, validate(defs, schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			field := fmt.Sprintf("%s.%s", path, key)
+			if property, ok := schema.Properties[key]; ok {
+				errs = append(errs, validate(defs, property, item, field)...)
+			} else if additional, ok := schema.AdditionalProperties.(*Schema); ok {
+				errs = append(errs, validate(defs, additional, item, field)...)
+			} else if schema.AdditionalProperties == false {
+				errs = append(errs, fmt.Errorf("%s: unknown field", field))
+			}
+		}
+	}
+	return errs
+}
+
+func TestValidate(t *testing.T) {
+	g := newGenerator()
+	root := g.schemaFor(reflect.TypeOf(node{}))
+	value := map[string]interface{}{
+		"name":     "root",
+		"children": []interface{}{map[string]interface{}{"name": 1.0}},
+		"counts":   map[string]interface{}{"a": 1.5},
+		"unknown":  true,
+	}
+	expected := []string{"root.children[0].name: expected [string], got integer", "root.unknown: unknown field"}
+	var actual []string
+	for _, err := range validate(g.defs, root, value, "root") {
+		actual = append(actual, err.Error())
+	}
+	sort.Strings(actual)
+	testhelper.Diff(t, "errors", actual, expected)
+}
+
+// TestConfigsMatchSchema ensures the schema accepts the configurations and
+// step registry components used by the integration and end-to-end tests,
+// as it rejects any field it does not know about.
+func TestConfigsMatchSchema(t *testing.T) {
+	schema := Generate()
+	files := map[string]string{}
+	for _, pattern := range []string{
+		"../../../test/integration/*/*/ci-operator/config/*/*/*.yaml",
+		"../../../test/e2e/*/config.yaml",
+		"../../../test/e2e/simple/dynamic-releases.yaml",
+		"../../../test/e2e/simple/template-config.yaml",
+		"../../../test/e2e/optional-operators/optional-operators.yaml",
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatalf("invalid pattern %s: %v", pattern, err)
+		}
+		for _, match := range matches {
+			files[match] = "ReleaseBuildConfiguration"
+		}
+	}
+	components := map[string]string{
+		"-ref.yaml":      "RegistryReferenceConfig",
+		"-chain.yaml":    "RegistryChainConfig",
+		"-workflow.yaml": "RegistryWorkflowConfig",
+		"-observer.yaml": "RegistryObserverConfig",
+	}
+	if err := filepath.WalkDir("../../../test", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.Contains(path, "/step-registry/") {
+			return err
+		}
+		for suffix, def := range components {
+			if strings.HasSuffix(path, suffix) {
+				files[path] = def
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to find the step registry components: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("found no configurations to validate")
+	}
+	for file, def := range files {
+		t.Run(file, func(t *testing.T) {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			var value interface{}
+			if err := yaml.Unmarshal(raw, &value); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", file, err)
+			}
+			for _, err := range validate(schema.Defs, schema.Defs[def], value, def) {
+				t.Error(err)
+			}
+		})
+	}
+}
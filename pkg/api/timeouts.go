@@ -0,0 +1,168 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+)
+
+const (
+	// DefaultClusterClaimTimeout is how long ci-operator waits for a claimed
+	// cluster when the claim does not set `timeout`.
+	DefaultClusterClaimTimeout = time.Hour
+	// DefaultStepTimeout is how long a step may run when it does not set
+	// `timeout`.
+	DefaultStepTimeout = entrypoint.DefaultTimeout
+)
+
+// TimeoutBound is one of the timeouts bounding how long a test may run.
+// +k8s:deepcopy-gen=false
+type TimeoutBound struct {
+	// Name identifies what the timeout bounds, e.g. `job` or `step install`.
+	Name string `json:"name"`
+	// Field is the path to the configuration field setting the timeout,
+	// relative to the test. It is empty when the default is used.
+	Field string `json:"field,omitempty"`
+	// Timeout is the effective timeout, zero when it is the default job
+	// timeout and that is not known.
+	Timeout prowv1.Duration `json:"timeout"`
+	// Within is the name of the bound this one runs within, empty for the
+	// outermost bound.
+	Within string `json:"within,omitempty"`
+}
+
+// IsDefault determines whether the timeout is not set in the configuration.
+func (b TimeoutBound) IsDefault() bool {
+	return b.Field == ""
+}
+
+// TimeoutChain lists the timeouts bounding a test, each one after the bound
+// it runs within.
+// +k8s:deepcopy-gen=false
+type TimeoutChain []TimeoutBound
+
+// Get returns the bound with the name.
+func (c TimeoutChain) Get(name string) (TimeoutBound, bool) {
+	for _, bound := range c {
+		if bound.Name == name {
+			return bound, true
+		}
+	}
+	return TimeoutBound{}, false
+}
+
+// DefaultJobTimeouts returns how long the default decoration configuration
+// of Prow lets the jobs of a repository run when their test does not set
+// `timeout`, zero when the configuration does not say.
+func DefaultJobTimeouts(prowConfig *prowconfig.Config) func(metadata Metadata) time.Duration {
+	return func(metadata Metadata) time.Duration {
+		decoration := prowConfig.Plank.GuessDefaultDecorationConfig(fmt.Sprintf("%s/%s", metadata.Org, metadata.Repo), "")
+		if decoration.Timeout == nil {
+			return 0
+		}
+		return decoration.Timeout.Duration
+	}
+}
+
+// JobTimeout is how long Prow lets the job of the test run, given the default
+// timeout of the jobs of the repository.
+func (config TestStepConfiguration) JobTimeout(defaultTimeout time.Duration) time.Duration {
+	switch {
+	case config.Soak != nil:
+		return config.Soak.Duration()
+	case config.Timeout != nil:
+		return config.Timeout.Duration
+	default:
+		return defaultTimeout
+	}
+}
+
+// TimeoutChain computes the effective timeouts of the test: the timeout of
+// the Prow job, the wait for a claimed cluster, the budgets of the phases of
+// a multi-stage test and the timeouts of its steps. The default job timeout
+// comes from the configuration of Prow and is zero when it is not known.
+// Steps referenced from the registry are only known once the configuration is
+// resolved.
+func (config TestStepConfiguration) TimeoutChain(defaultJobTimeout time.Duration) TimeoutChain {
+	job := TimeoutBound{Name: "job", Timeout: prowv1.Duration{Duration: config.JobTimeout(defaultJobTimeout)}}
+	switch {
+	case config.Soak != nil:
+		job.Field = "soak.max_duration_days"
+	case config.Timeout != nil:
+		job.Field = "timeout"
+	}
+	chain := TimeoutChain{job}
+	bound := func(name, field string, timeout *prowv1.Duration, defaultTimeout time.Duration, within string) TimeoutBound {
+		ret := TimeoutBound{Name: name, Timeout: prowv1.Duration{Duration: defaultTimeout}, Within: within}
+		if timeout != nil {
+			ret.Field, ret.Timeout = field, *timeout
+		}
+		return ret
+	}
+	if claim := config.ClusterClaim; claim != nil {
+		chain = append(chain, bound("cluster claim", "cluster_claim.timeout", claim.Timeout, DefaultClusterClaimTimeout, job.Name))
+	}
+
+	type phase struct {
+		name   string
+		budget *prowv1.Duration
+		// steps are nil where the step is referenced from the registry
+		steps []*LiteralTestStep
+	}
+	literal := func(steps []LiteralTestStep) []*LiteralTestStep {
+		var ret []*LiteralTestStep
+		for i := range steps {
+			ret = append(ret, &steps[i])
+		}
+		return ret
+	}
+	inline := func(steps []TestStep) []*LiteralTestStep {
+		var ret []*LiteralTestStep
+		for _, step := range steps {
+			ret = append(ret, step.LiteralTestStep)
+		}
+		return ret
+	}
+	var field string
+	var phases []phase
+	var gatherTimeout *prowv1.Duration
+	switch {
+	case config.MultiStageTestConfigurationLiteral != nil:
+		ms := config.MultiStageTestConfigurationLiteral
+		budgets := ms.Budgets
+		if budgets == nil {
+			budgets = &PhaseBudgets{}
+		}
+		field, gatherTimeout = "literal_steps", ms.GatherTimeout
+		phases = []phase{{"pre", budgets.Pre, literal(ms.Pre)}, {"test", budgets.Test, literal(ms.Test)}, {"gather", nil, literal(ms.Gather)}, {"post", budgets.Post, literal(ms.Post)}}
+	case config.MultiStageTestConfiguration != nil:
+		ms := config.MultiStageTestConfiguration
+		budgets := ms.Budgets
+		if budgets == nil {
+			budgets = &PhaseBudgets{}
+		}
+		field, gatherTimeout = "steps", ms.GatherTimeout
+		phases = []phase{{"pre", budgets.Pre, inline(ms.Pre)}, {"test", budgets.Test, inline(ms.Test)}, {"gather", nil, inline(ms.Gather)}, {"post", budgets.Post, inline(ms.Post)}}
+	}
+	for _, p := range phases {
+		within := job.Name
+		switch {
+		case p.name == "gather" && gatherTimeout != nil:
+			within = "phase gather"
+			chain = append(chain, TimeoutBound{Name: within, Field: fmt.Sprintf("%s.gather_timeout", field), Timeout: *gatherTimeout, Within: job.Name})
+		case p.budget != nil:
+			within = fmt.Sprintf("phase %s", p.name)
+			chain = append(chain, TimeoutBound{Name: within, Field: fmt.Sprintf("%s.budgets.%s", field, p.name), Timeout: *p.budget, Within: job.Name})
+		}
+		for i, step := range p.steps {
+			if step == nil {
+				continue
+			}
+			chain = append(chain, bound(fmt.Sprintf("step %s", step.As), fmt.Sprintf("%s.%s[%d].timeout", field, p.name, i), step.Timeout, DefaultStepTimeout, within))
+		}
+	}
+	return chain
+}
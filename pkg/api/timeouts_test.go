@@ -0,0 +1,105 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/utils/ptr"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestTimeoutChain(t *testing.T) {
+	duration := func(d time.Duration) *prowv1.Duration {
+		return &prowv1.Duration{Duration: d}
+	}
+	for _, tc := range []struct {
+		name     string
+		test     TestStepConfiguration
+		expected TimeoutChain
+	}{
+		{
+			name:     "container test uses the default job timeout",
+			test:     TestStepConfiguration{As: "unit", ContainerTestConfiguration: &ContainerTestConfiguration{From: "src"}},
+			expected: TimeoutChain{{Name: "job", Timeout: prowv1.Duration{Duration: 4 * time.Hour}}},
+		},
+		{
+			name: "soak test runs for its maximum duration",
+			test: TestStepConfiguration{As: "soak", Soak: &SoakConfiguration{MaxDurationDays: 2}},
+			expected: TimeoutChain{
+				{Name: "job", Field: "soak.max_duration_days", Timeout: prowv1.Duration{Duration: 48 * time.Hour}},
+			},
+		},
+		{
+			name: "literal multi-stage test",
+			test: TestStepConfiguration{
+				As:           "e2e",
+				Timeout:      duration(6 * time.Hour),
+				ClusterClaim: &ClusterClaim{},
+				MultiStageTestConfigurationLiteral: &MultiStageTestConfigurationLiteral{
+					Pre:           []LiteralTestStep{{As: "install", Timeout: duration(time.Hour)}},
+					Test:          []LiteralTestStep{{As: "test"}},
+					Gather:        []LiteralTestStep{{As: "must-gather", Timeout: duration(10 * time.Minute)}},
+					Post:          []LiteralTestStep{{As: "deprovision"}},
+					GatherTimeout: duration(20 * time.Minute),
+					Budgets:       &PhaseBudgets{Test: duration(3 * time.Hour)},
+				},
+			},
+			expected: TimeoutChain{
+				{Name: "job", Field: "timeout", Timeout: prowv1.Duration{Duration: 6 * time.Hour}},
+				{Name: "cluster claim", Timeout: prowv1.Duration{Duration: time.Hour}, Within: "job"},
+				{Name: "step install", Field: "literal_steps.pre[0].timeout", Timeout: prowv1.Duration{Duration: time.Hour}, Within: "job"},
+				{Name: "phase test", Field: "literal_steps.budgets.test", Timeout: prowv1.Duration{Duration: 3 * time.Hour}, Within: "job"},
+				{Name: "step test", Timeout: prowv1.Duration{Duration: 2 * time.Hour}, Within: "phase test"},
+				{Name: "phase gather", Field: "literal_steps.gather_timeout", Timeout: prowv1.Duration{Duration: 20 * time.Minute}, Within: "job"},
+				{Name: "step must-gather", Field: "literal_steps.gather[0].timeout", Timeout: prowv1.Duration{Duration: 10 * time.Minute}, Within: "phase gather"},
+				{Name: "step deprovision", Timeout: prowv1.Duration{Duration: 2 * time.Hour}, Within: "job"},
+			},
+		},
+		{
+			name: "unresolved multi-stage test only knows its literal steps",
+			test: TestStepConfiguration{
+				As: "e2e",
+				MultiStageTestConfiguration: &MultiStageTestConfiguration{
+					Test: []TestStep{
+						{Reference: ptr.To("from-registry")},
+						{LiteralTestStep: &LiteralTestStep{As: "inline", Timeout: duration(30 * time.Minute)}},
+					},
+				},
+			},
+			expected: TimeoutChain{
+				{Name: "job", Timeout: prowv1.Duration{Duration: 4 * time.Hour}},
+				{Name: "step inline", Field: "steps.test[1].timeout", Timeout: prowv1.Duration{Duration: 30 * time.Minute}, Within: "job"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "timeout chain", tc.test.TimeoutChain(4*time.Hour), tc.expected)
+		})
+	}
+}
+
+func TestDefaultJobTimeouts(t *testing.T) {
+	prowConfig := &prowconfig.Config{ProwConfig: prowconfig.ProwConfig{Plank: prowconfig.Plank{
+		DefaultDecorationConfigs: prowconfig.DefaultDecorationMapToSliceTesting(map[string]*prowv1.DecorationConfig{
+			"*":        {Timeout: &prowv1.Duration{Duration: 4 * time.Hour}},
+			"org/slow": {Timeout: &prowv1.Duration{Duration: 8 * time.Hour}},
+			"other":    {GracePeriod: &prowv1.Duration{Duration: time.Hour}},
+		}),
+	}}}
+	defaultJobTimeout := DefaultJobTimeouts(prowConfig)
+	for metadata, expected := range map[Metadata]time.Duration{
+		{Org: "org", Repo: "repo"}:   4 * time.Hour,
+		{Org: "org", Repo: "slow"}:   8 * time.Hour,
+		{Org: "other", Repo: "repo"}: 4 * time.Hour,
+	} {
+		if actual := defaultJobTimeout(metadata); actual != expected {
+			t.Errorf("%s/%s: expected %s, got %s", metadata.Org, metadata.Repo, expected, actual)
+		}
+	}
+	if actual := DefaultJobTimeouts(&prowconfig.Config{})(Metadata{Org: "org", Repo: "repo"}); actual != 0 {
+		t.Errorf("expected no default without decoration configs, got %s", actual)
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// ToolsDirectory holds the downloaded tools in the tools image, their
+	// binaries are linked into ToolsBinDirectory.
+	ToolsDirectory    = "/opt/ci-tools"
+	ToolsBinDirectory = "/usr/local/bin"
+)
+
+// ToolArchive is the format a tool is distributed in.
+type ToolArchive string
+
+const (
+	// ToolArchiveNone is a tool distributed as a single binary.
+	ToolArchiveNone  ToolArchive = ""
+	ToolArchiveTarGz ToolArchive = "tar.gz"
+	ToolArchiveZip   ToolArchive = "zip"
+)
+
+// ToolsImageConfiguration describes the "tools" pipeline image.
+type ToolsImageConfiguration struct {
+	// From is the pipeline image the tools are installed in, defaults to
+	// "root". The image needs `curl`, as well as `tar` or `unzip` for tools
+	// distributed in archives.
+	From PipelineImageStreamTagReference `json:"from,omitempty"`
+	// Tools is the manifest of the tools to install.
+	Tools []Tool `json:"tools"`
+}
+
+// Tool is a command line tool installed in the tools image at a pinned
+// version. Well-known tools only need a name and a version, other tools
+// are downloaded from a URL.
+type Tool struct {
+	// Name identifies the tool, it is one of `oc`, `jq`, `yq`, `aws` and
+	// `gcloud` for well-known tools.
+	Name string `json:"name"`
+	// Version is the version of the tool to install.
+	Version string `json:"version"`
+	// SHA256 is the expected checksum of the download, optional. As the
+	// download depends on the architecture, it should only be set for
+	// images built for a single one.
+	SHA256 string `json:"sha256,omitempty"`
+	// URL to download other tools from, where `{version}` is replaced with
+	// the version and `{arch}` with the architecture (`amd64`, `arm64`...).
+	URL string `json:"url,omitempty"`
+	// Archive is the format of the download of other tools: empty for a
+	// binary, `tar.gz` or `zip`.
+	Archive ToolArchive `json:"archive,omitempty"`
+	// Binaries are the paths of the binaries in the archive of other tools,
+	// linked into the PATH, with the same placeholders as the URL. Defaults
+	// to the name of the tool.
+	Binaries []string `json:"binaries,omitempty"`
+}
+
+// toolSource describes where a tool is downloaded from and how it is installed.
+type toolSource struct {
+	url      string
+	archive  ToolArchive
+	binaries []string
+	// install is run in the directory the archive was extracted to
+	install string
+	// architectures maps the architectures as reported by `uname -m` to
+	// the names the tool uses for them, which replace `{tool_arch}`. The
+	// installation fails on other architectures.
+	architectures map[string]string
+}
+
+// knownTools are the tools installed by name. In their URLs, `{uname_arch}`
+// is replaced with the architecture as reported by `uname -m`.
+var knownTools = map[string]toolSource{
+	"oc": {
+		url:      "https://mirror.openshift.com/pub/openshift-v4/{uname_arch}/clients/ocp/{version}/openshift-client-linux.tar.gz",
+		archive:  ToolArchiveTarGz,
+		binaries: []string{"oc", "kubectl"},
+	},
+	"jq": {
+		url: "https://github.com/jqlang/jq/releases/download/jq-{version}/jq-linux-{arch}",
+	},
+	"yq": {
+		url: "https://github.com/mikefarah/yq/releases/download/v{version}/yq_linux_{arch}",
+	},
+	"aws": {
+		url:     "https://awscli.amazonaws.com/awscli-exe-linux-{uname_arch}-{version}.zip",
+		archive: ToolArchiveZip,
+		install: fmt.Sprintf("./aws/install --install-dir %s/aws-cli --bin-dir %s && rm -rf ./aws", ToolsDirectory, ToolsBinDirectory),
+	},
+	"gcloud": {
+		url:      "https://dl.google.com/dl/cloudsdk/channels/rapid/downloads/google-cloud-cli-{version}-linux-{tool_arch}.tar.gz",
+		archive:  ToolArchiveTarGz,
+		binaries: []string{"google-cloud-sdk/bin/gcloud", "google-cloud-sdk/bin/gsutil"},
+		// the SDK is only published for these, arm64 being called arm
+		architectures: map[string]string{"x86_64": "x86_64", "aarch64": "arm"},
+	},
+}
+
+// KnownTools lists the tools which can be installed by name.
+func KnownTools() []string {
+	return sets.List(sets.KeySet(knownTools))
+}
+
+var (
+	toolNameRegexp    = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+	toolVersionRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._+-]*$`)
+	toolSHA256Regexp  = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	toolURLRegexp     = regexp.MustCompile(`^https://[a-zA-Z0-9._~:/?#@!&'()*+,;=%{}-]+$`)
+	toolBinaryRegexp  = regexp.MustCompile(`^[a-zA-Z0-9._/{}-]+$`)
+)
+
+// Validate checks that the tools can be installed. Everything ends up in
+// the script installing them, so values are restricted to safe characters.
+func (c *ToolsImageConfiguration) Validate() error {
+	if len(c.Tools) == 0 {
+		return errors.New("tools: at least one tool must be listed")
+	}
+	var errs []error
+	seen := sets.New[string]()
+	for i, tool := range c.Tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		if !toolNameRegexp.MatchString(tool.Name) {
+			errs = append(errs, fmt.Errorf("%s.name: %q must match %s", field, tool.Name, toolNameRegexp.String()))
+		} else if seen.Has(tool.Name) {
+			errs = append(errs, fmt.Errorf("%s.name: duplicate tool %q", field, tool.Name))
+		}
+		seen.Insert(tool.Name)
+		if !toolVersionRegexp.MatchString(tool.Version) {
+			errs = append(errs, fmt.Errorf("%s.version: %q must match %s", field, tool.Version, toolVersionRegexp.String()))
+		}
+		if tool.SHA256 != "" && !toolSHA256Regexp.MatchString(tool.SHA256) {
+			errs = append(errs, fmt.Errorf("%s.sha256: %q is not a SHA256 checksum", field, tool.SHA256))
+		}
+		if _, known := knownTools[tool.Name]; known {
+			if tool.URL != "" || tool.Archive != ToolArchiveNone || len(tool.Binaries) > 0 {
+				errs = append(errs, fmt.Errorf("%s: url, archive and binaries cannot be set for the well-known tool %q", field, tool.Name))
+			}
+			continue
+		}
+		if tool.URL == "" {
+			errs = append(errs, fmt.Errorf("%s.url: must be set for %q, which is not one of the well-known tools %s", field, tool.Name, strings.Join(KnownTools(), ", ")))
+		} else if !toolURLRegexp.MatchString(tool.URL) {
+			errs = append(errs, fmt.Errorf("%s.url: %q must be an HTTPS URL matching %s", field, tool.URL, toolURLRegexp.String()))
+		}
+		switch tool.Archive {
+		case ToolArchiveNone:
+			if len(tool.Binaries) > 0 {
+				errs = append(errs, fmt.Errorf("%s.binaries: can only be set for archives", field))
+			}
+		case ToolArchiveTarGz, ToolArchiveZip:
+		default:
+			errs = append(errs, fmt.Errorf("%s.archive: unknown archive %q, use one of %s, %s", field, tool.Archive, ToolArchiveTarGz, ToolArchiveZip))
+		}
+		for j, binary := range tool.Binaries {
+			if !toolBinaryRegexp.MatchString(binary) || path.IsAbs(binary) || strings.HasPrefix(path.Clean(binary), "..") {
+				errs = append(errs, fmt.Errorf("%s.binaries[%d]: %q must be a relative path in the archive", field, j, binary))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// BaseImage is the pipeline image the tools are installed in.
+func (c *ToolsImageConfiguration) BaseImage() PipelineImageStreamTagReference {
+	if c.From == "" {
+		return PipelineImageStreamTagReferenceRoot
+	}
+	return c.From
+}
+
+// InstallCommands renders the script installing the tools. The configuration
+// is expected to be valid.
+func (c *ToolsImageConfiguration) InstallCommands() string {
+	lines := []string{
+		`uname_arch="$( uname -m )"`,
+		`case "${uname_arch}" in x86_64) arch=amd64 ;; aarch64) arch=arm64 ;; *) arch="${uname_arch}" ;; esac`,
+		fmt.Sprintf("mkdir -p %s %s", ToolsDirectory, ToolsBinDirectory),
+	}
+	for _, tool := range c.Tools {
+		source, known := knownTools[tool.Name]
+		if !known {
+			source = toolSource{url: tool.URL, archive: tool.Archive, binaries: tool.Binaries}
+		}
+		if source.archive != ToolArchiveNone && len(source.binaries) == 0 && source.install == "" {
+			source.binaries = []string{tool.Name}
+		}
+		placeholders := strings.NewReplacer("{version}", tool.Version, "{arch}", "${arch}", "{uname_arch}", "${uname_arch}", "{tool_arch}", "${tool_arch}")
+		url := placeholders.Replace(source.url)
+		dir := path.Join(ToolsDirectory, tool.Name)
+		download := path.Join(dir, "download")
+		lines = append(lines, fmt.Sprintf("echo 'Installing %s %s'", tool.Name, tool.Version))
+		if len(source.architectures) > 0 {
+			var cases []string
+			for _, arch := range sets.List(sets.KeySet(source.architectures)) {
+				cases = append(cases, fmt.Sprintf("%s) tool_arch=%s ;;", arch, source.architectures[arch]))
+			}
+			lines = append(lines, fmt.Sprintf(`case "${uname_arch}" in %s *) echo "%s is not available for ${uname_arch}" >&2; exit 1 ;; esac`, strings.Join(cases, " "), tool.Name))
+		}
+		lines = append(lines,
+			fmt.Sprintf("mkdir -p %s", dir),
+			fmt.Sprintf(`curl --fail --silent --show-error --location --retry 5 --output %s "%s"`, download, url),
+		)
+		if tool.SHA256 != "" {
+			lines = append(lines, fmt.Sprintf("echo '%s  %s' | sha256sum --check --quiet", tool.SHA256, download))
+		}
+		switch source.archive {
+		case ToolArchiveNone:
+			lines = append(lines, fmt.Sprintf("install -m 0755 %s %s", download, path.Join(ToolsBinDirectory, tool.Name)))
+		case ToolArchiveTarGz:
+			lines = append(lines, fmt.Sprintf("tar -xzf %s -C %s", download, dir))
+		case ToolArchiveZip:
+			lines = append(lines, fmt.Sprintf("unzip -q %s -d %s", download, dir))
+		}
+		lines = append(lines, fmt.Sprintf("rm -f %s", download))
+		if source.install != "" {
+			lines = append(lines, fmt.Sprintf("( cd %s && %s )", dir, source.install))
+		}
+		if source.archive != ToolArchiveNone {
+			for _, binary := range source.binaries {
+				binary = placeholders.Replace(binary)
+				lines = append(lines, fmt.Sprintf("ln -sf %s %s", path.Join(dir, binary), path.Join(ToolsBinDirectory, path.Base(binary))))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestToolsImageConfigurationValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   ToolsImageConfiguration
+		expected error
+	}{
+		{
+			name: "well-known and other tools",
+			config: ToolsImageConfiguration{Tools: []Tool{
+				{Name: "oc", Version: "4.17.0"},
+				{Name: "jq", Version: "1.7.1", SHA256: "5942c9b0934e510ee61eb3e30273f1b3fe2590df93933a93d7c58b81d19c8ff5"},
+				{Name: "helm", Version: "3.16.2", URL: "https://get.helm.sh/helm-v{version}-linux-{arch}.tar.gz", Archive: ToolArchiveTarGz, Binaries: []string{"linux-{arch}/helm"}},
+			}},
+		},
+		{
+			name:     "no tools",
+			expected: errors.New("tools: at least one tool must be listed"),
+		},
+		{
+			name: "invalid tools",
+			config: ToolsImageConfiguration{Tools: []Tool{
+				{Name: "oc", Version: "4.17.0", URL: "https://example.com/oc"},
+				{Name: "oc", Version: "latest; rm -rf /"},
+				{Name: "kind", Version: "0.24.0", SHA256: "abc"},
+				{Name: "helm", Version: "3.16.2", URL: "http://get.helm.sh/helm", Archive: "rpm", Binaries: []string{"../helm"}},
+				{Name: "mc", Version: "1", URL: "https://dl.min.io/mc", Binaries: []string{"mc"}},
+			}},
+			expected: errors.New(`[` +
+				`tools[0]: url, archive and binaries cannot be set for the well-known tool "oc", ` +
+				`tools[1].name: duplicate tool "oc", ` +
+				`tools[1].version: "latest; rm -rf /" must match ^[a-zA-Z0-9][a-zA-Z0-9._+-]*$, ` +
+				`tools[2].sha256: "abc" is not a SHA256 checksum, ` +
+				`tools[2].url: must be set for "kind", which is not one of the well-known tools aws, gcloud, jq, oc, yq, ` +
+				`tools[3].url: "http://get.helm.sh/helm" must be an HTTPS URL matching ^https://[a-zA-Z0-9._~:/?#@!&'()*+,;=%{}-]+$, ` +
+				`tools[3].archive: unknown archive "rpm", use one of tar.gz, zip, ` +
+				`tools[3].binaries[0]: "../helm" must be a relative path in the archive, ` +
+				`tools[4].binaries: can only be set for archives]`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.config.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestToolsImageConfigurationInstallCommands(t *testing.T) {
+	config := ToolsImageConfiguration{Tools: []Tool{
+		{Name: "oc", Version: "4.17.0"},
+		{Name: "jq", Version: "1.7.1", SHA256: "5942c9b0934e510ee61eb3e30273f1b3fe2590df93933a93d7c58b81d19c8ff5"},
+		{Name: "aws", Version: "2.18.0"},
+		{Name: "gcloud", Version: "497.0.0"},
+		{Name: "kind", Version: "0.24.0", URL: "https://kind.sigs.k8s.io/dl/v{version}/kind-linux-{arch}"},
+		{Name: "helm", Version: "3.16.2", URL: "https://get.helm.sh/helm-v{version}-linux-{arch}.tar.gz", Archive: ToolArchiveTarGz, Binaries: []string{"linux-{arch}/helm"}},
+	}}
+	testhelper.CompareWithFixture(t, config.InstallCommands())
+}
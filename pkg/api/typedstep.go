@@ -0,0 +1,51 @@
+package api
+
+import (
+	"time"
+)
+
+// typedStepTimeoutMargin is added to how long a typed step waits for the
+// cluster to obtain the timeout of the step, leaving the binary time to
+// gather diagnostics.
+const typedStepTimeoutMargin = 10 * time.Minute
+
+// TypedSteps lists the names of the typed steps configured on the step. The
+// image and commands of typed steps are generated, so only one can be set.
+func (s *LiteralTestStep) TypedSteps() []string {
+	var ret []string
+	if s.Upgrade != nil {
+		ret = append(ret, "upgrade")
+	}
+	if s.HealthCheck != nil {
+		ret = append(ret, "health_check")
+	}
+	if s.InstallConfig != nil {
+		ret = append(ret, "install_config")
+	}
+	return ret
+}
+
+// ExpandTypedStep converts a typed step into the literal step running it.
+// Steps which are not typed are returned unchanged.
+func ExpandTypedStep(step LiteralTestStep) LiteralTestStep {
+	switch {
+	case step.Upgrade != nil:
+		upgrade := step.Upgrade
+		step.Upgrade = nil
+		step = upgrade.expand(step)
+	case step.HealthCheck != nil:
+		healthCheck := step.HealthCheck
+		step.HealthCheck = nil
+		step = healthCheck.expand(step)
+	case step.InstallConfig != nil:
+		installConfig := step.InstallConfig
+		step.InstallConfig = nil
+		step = installConfig.expand(step)
+	default:
+		return step
+	}
+	if step.Resources.Requests == nil {
+		step.Resources.Requests = ResourceList{"cpu": "10m", "memory": "100Mi"}
+	}
+	return step
+}
@@ -1,8 +1,13 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -16,6 +21,15 @@ const (
 	PromotionJobLabelKey = "ci-operator.openshift.io/is-promotion"
 )
 
+const (
+	// InitialSchemaVersion is the schema version of configurations which do
+	// not declare one.
+	InitialSchemaVersion = 1
+	// LatestSchemaVersion is the schema version configurations are upgraded
+	// to: it replaces `tag_specification` with `releases`.
+	LatestSchemaVersion = 2
+)
+
 // IsPromotionJob determines if a given ProwJob is a PromotionJob
 func IsPromotionJob(jobLabels map[string]string) bool {
 	_, ok := jobLabels[PromotionJobLabelKey]
@@ -35,6 +49,12 @@ func IsPromotionJob(jobLabels map[string]string) bool {
 type ReleaseBuildConfiguration struct {
 	Metadata Metadata `json:"zz_generated_metadata"`
 
+	// SchemaVersion is the version of the schema the configuration is
+	// written in, configurations without one use the first version.
+	// Configurations are upgraded to the latest version by
+	// determinize-ci-operator --upgrade-schema.
+	SchemaVersion int `json:"schema_version,omitempty"`
+
 	InputConfiguration `json:",inline"`
 
 	// BinaryBuildCommands will create a "bin" image based on "src" that
@@ -87,6 +107,11 @@ type ReleaseBuildConfiguration struct {
 	// DO NOT set this in the config
 	RpmBuildLocationList []RefLocation `json:"rpm_build_location_list,omitempty"`
 
+	// Tools will create a "tools" image from "root" (or the configured image)
+	// that contains the listed command line tools at pinned versions. Steps
+	// can use the image instead of installing the tools themselves.
+	Tools *ToolsImageConfiguration `json:"tools,omitempty"`
+
 	// CanonicalGoRepository is a directory path that represents
 	// the desired location of the contents of this repository in
 	// Go. If specified the location of the repository we are
@@ -130,6 +155,11 @@ type ReleaseBuildConfiguration struct {
 	// input types. The special name '*' may be used to set default
 	// requests and limits.
 	Resources ResourceConfiguration `json:"resources,omitempty"`
+
+	// DisabledValidationRules are the names of the validation rules this
+	// configuration opts out of. The test platform restricts which rules
+	// each repository may disable.
+	DisabledValidationRules []string `json:"disabled_validation_rules,omitempty"`
 }
 
 // RefCommands pairs a ref (in org/repo format) with commands
@@ -190,6 +220,9 @@ func (config ReleaseBuildConfiguration) IsPipelineImage(name string) bool {
 		strings.HasPrefix(name, string(PipelineImageStreamTagReferenceBundleSource)) {
 		return true
 	}
+	if config.Tools != nil && name == string(PipelineImageStreamTagReferenceTools) {
+		return true
+	}
 	if IsIndexImage(name) {
 		return true
 	}
@@ -301,6 +334,12 @@ type InputConfiguration struct {
 	Releases map[string]UnresolvedRelease `json:"releases,omitempty"`
 }
 
+// ExternalPullSecretName returns the name of the copy of a user-provided pull
+// secret that is created in the test namespace.
+func ExternalPullSecretName(name string) string {
+	return "external-pull-secret-" + name
+}
+
 // ExternalImage describes the external image that is imported into the pipeline
 type ExternalImage struct {
 	// Registry is the registry to pull images from (e.g. quay.io)
@@ -469,8 +508,29 @@ type BuildRootImageConfiguration struct {
 	// as a build cache, if the underlying build root has not changed since
 	// the previous cache was published.
 	UseBuildCache bool `json:"use_build_cache,omitempty"`
+
+	// BuildBackend selects how the images built by ci-operator, `src`,
+	// `bin`, `test-bin`, `rpms`, the RPM-injected base images and the
+	// operator bundles and indices, are built: with the OpenShift Build API
+	// by default, or with `buildah` in unprivileged pods. A build root built
+	// from `project_image` is cloned from git, which only the Build API
+	// supports, so it is always built with the Build API.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
+// BuildBackend is the implementation used to build images.
+type BuildBackend string
+
+const (
+	// BuildBackendBuild builds images with the OpenShift Build API.
+	BuildBackendBuild BuildBackend = "build"
+	// BuildBackendBuildah builds images by running buildah in unprivileged
+	// pods, for build farms where the Build controller is disabled. Those
+	// must provide the `ci-operator-buildah` cluster role granting the SCC
+	// the pods need.
+	BuildBackendBuildah BuildBackend = "buildah"
+)
+
 // ImageStreamTagReference identifies an ImageStreamTag
 type ImageStreamTagReference struct {
 	Namespace string `json:"namespace"`
@@ -548,6 +608,27 @@ type PromotionConfiguration struct {
 	// Cron generates promotion periodic alongside with promotion
 	// postsubmit
 	Cron string `json:"cron,omitempty"`
+
+	// ARTConsistencyCheck verifies that the build root used to build
+	// the images promoted to the `ocp` namespace matches the one ART
+	// uses to build the same components, and fails the promotion on a
+	// mismatch. This is useful to catch images that build in CI but
+	// would fail to build in brew.
+	ARTConsistencyCheck bool `json:"art_consistency_check,omitempty"`
+
+	// ImageDiffReport compares the promoted images with the ones they
+	// replace in the central registry after the promotion: the packages
+	// added, removed and updated, the size delta and whether the base
+	// image changed are reported in the promotion-image-diff.json
+	// artifact. This is useful to catch accidental bloat and unexpected
+	// content changes.
+	ImageDiffReport bool `json:"image_diff_report,omitempty"`
+
+	// CVEGate scans the images to promote and the ones they replace in
+	// the central registry before the promotion, and fails it when the
+	// images introduce critical vulnerabilities which are not waived in
+	// the .ci-operator-cve-waivers.yaml file of the repository.
+	CVEGate *CVEGate `json:"cve_gate,omitempty"`
 }
 
 type PromotionTarget struct {
@@ -570,6 +651,12 @@ type PromotionTarget struct {
 	// to be promoted.
 	TagByCommit bool `json:"tag_by_commit,omitempty"`
 
+	// CommitTagRetention is the number of commit tags of the branch
+	// kept in the central registry and on quay.io for each image promoted
+	// with TagByCommit: the tags of older commits of the branch are removed
+	// after each promotion. All commit tags are kept when it is not set.
+	CommitTagRetention int `json:"commit_tag_retention,omitempty"`
+
 	// ExcludedImages are image names that will not be promoted.
 	// Exclusions are made before additional_images are included.
 	// Use exclusions when you want to build images for testing
@@ -583,12 +670,41 @@ type PromotionTarget struct {
 	// the destination tag will not be created.
 	AdditionalImages map[string]string `json:"additional_images,omitempty"`
 
+	// AdditionalArchitectures are the architectures, besides amd64,
+	// the promoted images must cover. The images are promoted as
+	// the manifest lists their builds produce, so they must be built
+	// for each of the architectures.
+	AdditionalArchitectures []string `json:"additional_architectures,omitempty"`
+
 	// Disabled will no-op succeed instead of running the actual
 	// promotion step. This is useful when two branches need to
 	// promote to the same output imagestream on a cut-over but
 	// never concurrently, and you want to have promotion config
 	// in the ci-operator configuration files all the time.
 	Disabled bool `json:"disabled,omitempty"`
+
+	// PausedUntil is an RFC3339 timestamp until which the promotion
+	// to this target is skipped, e.g. during a release freeze. The
+	// target is promoted to again once the time has passed.
+	PausedUntil string `json:"paused_until,omitempty"`
+
+	// Mirror pushes the promoted tags to an external registry as
+	// well, after they are promoted to the central registry.
+	Mirror *PromotionMirror `json:"mirror,omitempty"`
+}
+
+// PromotionMirror is an external registry promoted tags are pushed to. Each
+// promoted tag `<namespace>/<name>:<tag>` is pushed to
+// `<registry>/<repository>/<name>:<tag>`.
+type PromotionMirror struct {
+	// Registry is the host of the registry, e.g. quay.io.
+	Registry string `json:"registry"`
+	// Repository is the path in the registry the image repositories are
+	// created under, e.g. the name of a quay.io organization.
+	Repository string `json:"repository"`
+	// Credentials is the name of a secret in the test-credentials
+	// namespace holding a .dockerconfigjson able to push to the registry.
+	Credentials string `json:"credentials"`
 }
 
 // StepConfiguration holds one step configuration.
@@ -616,6 +732,9 @@ type StepConfiguration struct {
 type InputImageTagStepConfiguration struct {
 	InputImage `json:",inline"`
 	Sources    []ImageStreamSource `json:"-"`
+	// PullSecret is the name of the pull secret of the steps the image is
+	// imported for, whose copy in the test namespace the import uses.
+	PullSecret string `json:"-"`
 }
 
 func (config InputImageTagStepConfiguration) TargetName() string {
@@ -712,6 +831,9 @@ type PipelineImageCacheStepConfiguration struct {
 
 	// Ref is an optional string linking to the extra_ref in "org.repo" format that this belongs to
 	Ref string `json:"ref,omitempty"`
+
+	// BuildBackend is the backend of the build root the image is built on.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 func (config PipelineImageCacheStepConfiguration) TargetName() string {
@@ -794,7 +916,9 @@ type TestStepConfiguration struct {
 	// ClusterClaim claims an OpenShift cluster and exposes environment variable ${KUBECONFIG} to the test container
 	ClusterClaim *ClusterClaim `json:"cluster_claim,omitempty"`
 
-	// AlwaysRun can be set to false to disable running the job on every PR
+	// AlwaysRun can be set to false to disable running the job on every PR,
+	// so that it only runs when requested. It only applies to presubmits and
+	// cannot be set to true along with conditional triggers.
 	AlwaysRun *bool `json:"always_run,omitempty"`
 
 	// Retry is a configuration entry for retrying periodic prowjobs
@@ -819,6 +943,12 @@ type TestStepConfiguration struct {
 	// Timeout overrides maximum prowjob duration
 	Timeout *prowv1.Duration `json:"timeout,omitempty"`
 
+	// ExpectedDuration is how long the test is expected to run for. It is not
+	// enforced during the run: ci-operator records the actual duration next to
+	// it in its JUnit results, so tests chronically running over it can be
+	// reported.
+	ExpectedDuration *prowv1.Duration `json:"expected_duration,omitempty"`
+
 	// NodeArchitecture is the architecture for the node where the test will run.
 	// If set, the generated test pod will include a nodeSelector for this architecture.
 	NodeArchitecture NodeArchitecture `json:"node_architecture,omitempty"`
@@ -826,6 +956,57 @@ type TestStepConfiguration struct {
 	// RestrictNetworkAccess restricts network access to RedHat intranet.
 	RestrictNetworkAccess *bool `json:"restrict_network_access,omitempty"`
 
+	// NodeSelector constrains the nodes the pods created for the test can be scheduled on.
+	// Only labels in SchedulingAllowlist can be used.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+
+	// Tolerations allow the pods created for the test to be scheduled on tainted nodes.
+	// Only taints in SchedulingAllowlist can be tolerated.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+
+	// Soak configures the test as a long-running soak test, which may run for
+	// days and reports its progress in periodic JUnit checkpoints.
+	Soak *SoakConfiguration `json:"soak,omitempty"`
+
+	// ReuseResults lets a presubmit test report the result of its last
+	// successful run on the pull request instead of running again when a new
+	// push does not change any of its inputs. ci-operator only reuses results
+	// when it is configured with a namespace to record them in.
+	ReuseResults *ReuseResultsConfiguration `json:"reuse_results,omitempty"`
+
+	// IsolateStableStreams gives the test its own copies of the stable image
+	// streams its steps use, so that steps retagging stable images cannot affect
+	// other tests running in the same namespace. The copy of a stream is named
+	// after the stream and the test, e.g. `stable-e2e`, its suffix is exposed to
+	// the steps in ${STABLE_STREAM_SUFFIX}. The copies are deleted once the test
+	// finishes. Only multi-stage tests can isolate stable streams.
+	IsolateStableStreams bool `json:"isolate_stable_streams,omitempty"`
+
+	// AllowedWindows restricts the times at which the test may start. When set,
+	// ci-operator skips the test if its job started outside all of the windows.
+	// Periodic tests must be scheduled with `cron` inside one of the windows.
+	AllowedWindows []AllowedWindow `json:"allowed_windows,omitempty"`
+
+	// ClusterGroup is the name of a group of multi-stage tests that share a single
+	// cluster within a job. Running the group as a target provisions the cluster once
+	// with the `pre` steps of the first test in the group, runs the `test` steps of all
+	// the tests in the group sequentially and tears the cluster down once with the
+	// `post` steps of the first test.
+	ClusterGroup string `json:"cluster_group,omitempty"`
+
+	// Spread, if set, prefers scheduling the pods created for the test away from the
+	// pods of concurrently running instances of the same test, e.g. shards of an
+	// aggregated job. Can be `node` or `zone`.
+	Spread Spread `json:"spread,omitempty"`
+
+	// Labels are added to the generated Prow job and to the pods created for the test.
+	// Reserved keys and keys using a reserved prefix are not allowed.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the generated Prow job and to the pods created for the test.
+	// Reserved keys and keys using a reserved prefix are not allowed.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
 	// Only one of the following can be not-null.
 	ContainerTestConfiguration                                *ContainerTestConfiguration                                `json:"container,omitempty"`
 	MultiStageTestConfiguration                               *MultiStageTestConfiguration                               `json:"steps,omitempty"`
@@ -913,6 +1094,21 @@ func (c *ClusterClaim) ClaimRelease(testName string) *ClaimRelease {
 	}
 }
 
+// PoolLabels are the labels of the cluster pools which may serve the claim.
+func (c *ClusterClaim) PoolLabels() map[string]string {
+	poolLabels := map[string]string{
+		"product":      string(c.Product),
+		"version":      c.Version,
+		"architecture": string(c.Architecture),
+		"cloud":        string(c.Cloud),
+		"owner":        c.Owner,
+	}
+	for k, v := range c.Labels {
+		poolLabels[k] = v
+	}
+	return poolLabels
+}
+
 // RegistryReferenceConfig is the struct that step references are unmarshalled into.
 type RegistryReferenceConfig struct {
 	// Reference is the top level field of a reference config.
@@ -927,6 +1123,15 @@ type RegistryReference struct {
 	Documentation string `json:"documentation,omitempty"`
 }
 
+// UnmarshalJSON decodes the reference. Implementing json.Unmarshaler keeps
+// sigs.k8s.io/yaml from matching the keys of objects nested in the inlined
+// step against the fields of the step itself when it converts YAML, which
+// would e.g. set the timeout of a step configuring `upgrade.timeout`.
+func (r *RegistryReference) UnmarshalJSON(data []byte) error {
+	type plain RegistryReference
+	return json.Unmarshal(data, (*plain)(r))
+}
+
 // RegistryChainConfig is the struct that chain references are unmarshalled into.
 type RegistryChainConfig struct {
 	// Chain is the top level field of a chain config.
@@ -977,6 +1182,12 @@ type RegistryObserver struct {
 	Documentation string `json:"documentation,omitempty"`
 }
 
+// UnmarshalJSON decodes the observer. See RegistryReference.UnmarshalJSON.
+func (o *RegistryObserver) UnmarshalJSON(data []byte) error {
+	type plain RegistryObserver
+	return json.Unmarshal(data, (*plain)(o))
+}
+
 // RegistryMetadata maps the registry info for each step in the registry by filename
 // +k8s:deepcopy-gen=false
 type RegistryMetadata map[string]RegistryInfo
@@ -1076,6 +1287,145 @@ type LiteralTestStep struct {
 	// NodeArchitecture is the architecture for the node where the test will run.
 	// If set, the generated test pod will include a nodeSelector for this architecture.
 	NodeArchitecture *NodeArchitecture `json:"node_architecture,omitempty"`
+	// PullSecret is the name of a secret in the test-credentials namespace
+	// used to pull the image for this step. It is copied into the test
+	// namespace and only linked to the Pod for this step.
+	PullSecret string `json:"pull_secret,omitempty"`
+	// NodeSelector constrains the nodes the Pod for this step can be scheduled on.
+	// Only labels in SchedulingAllowlist can be used.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Tolerations allow the Pod for this step to be scheduled on tainted nodes.
+	// Only taints in SchedulingAllowlist can be tolerated.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+	// NestedPodman provides a rootless podman runtime to the step, so that it
+	// can build and run containers. The Pod for this step runs in a user
+	// namespace with the devices podman needs. Tests using such steps must
+	// declare the `nested-podman` capability, which schedules them on the
+	// clusters that support it.
+	NestedPodman *bool `json:"nested_podman,omitempty"`
+	// LogLimit caps the size of the output of the step kept in its log,
+	// replacing the limit configured for all steps. An empty limit keeps the
+	// whole output.
+	LogLimit *LogLimit `json:"log_limit,omitempty"`
+	// Upgrade makes this a typed step upgrading the cluster under test. The
+	// image and commands of the step are generated and must not be set.
+	Upgrade *UpgradeStep `json:"upgrade,omitempty"`
+	// HealthCheck makes this a typed step asserting the health of the cluster
+	// under test. The image and commands of the step are generated and must
+	// not be set.
+	HealthCheck *HealthCheckStep `json:"health_check,omitempty"`
+	// InstallConfig makes this a typed step composing the install-config of
+	// the cluster under test. The image and commands of the step are
+	// generated and must not be set.
+	InstallConfig *InstallConfigStep `json:"install_config,omitempty"`
+}
+
+// SoakConfiguration configures a long-running multi-stage test.
+type SoakConfiguration struct {
+	// MaxDurationDays is the maximum number of days the test can run for.
+	MaxDurationDays int `json:"max_duration_days"`
+	// CheckpointInterval is how often the progress of the test is reported
+	// in a JUnit checkpoint. Defaults to one hour.
+	CheckpointInterval *prowv1.Duration `json:"checkpoint_interval,omitempty"`
+	// MaxCheckpoints is the number of the most recent checkpoints kept in the
+	// artifacts, older ones are removed. Defaults to 24.
+	MaxCheckpoints int `json:"max_checkpoints,omitempty"`
+}
+
+// ReuseResultsConfiguration configures which files of the repository the
+// result of a test depends on.
+type ReuseResultsConfiguration struct {
+	// SourcePaths are regular expressions matching the files the test depends
+	// on. A push changing only other files reuses the earlier result. All
+	// files are considered when unset.
+	SourcePaths []string `json:"source_paths,omitempty"`
+}
+
+// Duration is the maximum duration of the soak test.
+func (c *SoakConfiguration) Duration() time.Duration {
+	return time.Duration(c.MaxDurationDays) * 24 * time.Hour
+}
+
+// soakJobHeadroom is the time a soak job is given on top of the duration of
+// the test for everything else it runs: builds, imports, and the pre and post
+// steps of the test.
+const soakJobHeadroom = 8 * time.Hour
+
+// JobTimeout is the timeout of the job running the soak test, leaving room
+// for the steps that run before and after it.
+func (c *SoakConfiguration) JobTimeout() time.Duration {
+	return c.Duration() + soakJobHeadroom
+}
+
+// Interval is how often checkpoints are reported.
+func (c *SoakConfiguration) Interval() time.Duration {
+	if c.CheckpointInterval == nil {
+		return time.Hour
+	}
+	return c.CheckpointInterval.Duration
+}
+
+// Checkpoints is the number of checkpoints kept in the artifacts.
+func (c *SoakConfiguration) Checkpoints() int {
+	if c.MaxCheckpoints == 0 {
+		return 24
+	}
+	return c.MaxCheckpoints
+}
+
+// AllowedWindow is a recurring period of time during which a test may start.
+type AllowedWindow struct {
+	// Start is a cron expression for the opening of the window, in UTC.
+	Start string `json:"start"`
+	// Duration is how long the window stays open.
+	Duration prowv1.Duration `json:"duration"`
+}
+
+func (w AllowedWindow) String() string {
+	return fmt.Sprintf("%s for %s", w.Start, w.Duration.Duration)
+}
+
+// Schedule parses the start of the window as a standard cron expression with
+// five fields. The expression cannot set its own time zone, as windows are
+// always evaluated in UTC.
+func (w AllowedWindow) Schedule() (cron.Schedule, error) {
+	if strings.HasPrefix(w.Start, "TZ=") || strings.HasPrefix(w.Start, "CRON_TZ=") {
+		return nil, errors.New("time zones are not supported, windows are in UTC")
+	}
+	return cron.ParseStandard(w.Start)
+}
+
+// Contains determines whether the time is within an opening of the window,
+// both its start and its end included.
+func (w AllowedWindow) Contains(t time.Time) (bool, error) {
+	schedule, err := w.Schedule()
+	if err != nil {
+		return false, fmt.Errorf("invalid start of allowed window %q: %w", w.Start, err)
+	}
+	// Next only returns openings strictly after the time it is given
+	opening := schedule.Next(t.UTC().Add(-w.Duration.Duration - time.Nanosecond))
+	return !opening.After(t), nil
+}
+
+// Spread determines the topology across which concurrent instances of a test are spread.
+type Spread string
+
+const (
+	// SpreadNode spreads concurrent instances of a test across nodes.
+	SpreadNode Spread = "node"
+	// SpreadZone spreads concurrent instances of a test across zones.
+	SpreadZone Spread = "zone"
+)
+
+// Toleration allows a Pod to be scheduled on nodes with a matching taint.
+type Toleration struct {
+	// Key is the taint key the toleration applies to.
+	Key string `json:"key"`
+	// Value is the taint value the toleration matches. If empty, any
+	// taint with the key is tolerated.
+	Value string `json:"value,omitempty"`
+	// Effect is the taint effect to match. If empty, all effects are matched.
+	Effect string `json:"effect,omitempty"`
 }
 
 // StepParameter is a variable set by the test, with an optional default.
@@ -1096,6 +1446,34 @@ type CredentialReference struct {
 	Name string `json:"name"`
 	// MountPath is where the secret should be mounted.
 	MountPath string `json:"mount_path"`
+	// Cloud fetches the secret from a cloud secret manager when the step runs
+	// instead of copying it from a secret in the cluster. When set, Name is the
+	// name of the secret in the secret manager and Namespace must not be set.
+	Cloud *CloudCredentialSource `json:"cloud,omitempty"`
+}
+
+// CloudSecretManager is a cloud service that stores secrets.
+type CloudSecretManager string
+
+const (
+	// CloudSecretManagerGCP is the GCP Secret Manager.
+	CloudSecretManagerGCP CloudSecretManager = "gcp"
+	// CloudSecretManagerAWS is the AWS Secrets Manager.
+	CloudSecretManagerAWS CloudSecretManager = "aws"
+)
+
+// CloudCredentialSource describes where a credential is stored in a cloud
+// secret manager. The secret is fetched with the workload identity of the
+// test, so it never needs to be synced into a cluster namespace.
+type CloudCredentialSource struct {
+	// Provider is the secret manager holding the secret, one of `gcp` or `aws`.
+	Provider CloudSecretManager `json:"provider"`
+	// Project is the GCP project holding the secret.
+	Project string `json:"project,omitempty"`
+	// Region is the AWS region holding the secret.
+	Region string `json:"region,omitempty"`
+	// Version is the version of the secret to fetch, defaults to the current one.
+	Version string `json:"version,omitempty"`
 }
 
 // StepDependency defines a dependency on an image and the environment variable
@@ -1145,9 +1523,11 @@ func (s *LiteralTestStep) FromImageTag() (PipelineImageStreamTagReference, bool)
 type TestStep struct {
 	// LiteralTestStep is a full test step definition.
 	*LiteralTestStep `json:",inline,omitempty"`
-	// Reference is the name of a step reference.
+	// Reference is the name of a step reference. It can be pinned to a
+	// registry snapshot by appending its version, e.g. `name@v2025-01-15`.
 	Reference *string `json:"ref,omitempty"`
-	// Chain is the name of a step chain reference.
+	// Chain is the name of a step chain reference. It can be pinned to a
+	// registry snapshot by appending its version, e.g. `name@v2025-01-15`.
 	Chain *string `json:"chain,omitempty"`
 }
 
@@ -1160,12 +1540,18 @@ type MultiStageTestConfiguration struct {
 	Pre []TestStep `json:"pre,omitempty"`
 	// Test is the array of test steps that define the actual test.
 	Test []TestStep `json:"test,omitempty"`
+	// Gather is the array of test steps run after the tests finish to collect
+	// diagnostics, before `post`. Gather steps always run, even if previous
+	// steps fail, and their failures never fail the test.
+	Gather []TestStep `json:"gather,omitempty"`
 	// Post is the array of test steps run after the tests finish and teardown/deprovision resources.
 	// Post steps always run, even if previous steps fail. However, they have an option to skip
 	// execution if previous Pre and Test steps passed.
 	Post []TestStep `json:"post,omitempty"`
 	// Workflow is the name of the workflow to be used for this configuration. For fields defined in both
 	// the config and the workflow, the fields from the config will override what is set in Workflow.
+	// The workflow can be pinned to a registry snapshot by appending its version, e.g. `ipi-aws@v2025-01-15`,
+	// in which case the whole test is resolved from that snapshot.
 	Workflow *string `json:"workflow,omitempty"`
 	// Environment has the values of parameters for the steps.
 	Environment TestEnvironment `json:"env,omitempty"`
@@ -1183,6 +1569,11 @@ type MultiStageTestConfiguration struct {
 	// they fail. The given step must explicitly ask for being ignored by setting
 	// the OptionalOnSuccess flag to true.
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
+	// GatherTimeout is how long the whole `gather` phase may take. Gather steps
+	// still running when it expires are stopped and `post` steps start.
+	GatherTimeout *prowv1.Duration `json:"gather_timeout,omitempty"`
+	// Budgets limit how long the `pre`, `test` and `post` phases may take.
+	Budgets *PhaseBudgets `json:"budgets,omitempty"`
 	// Observers are the observers that should be running
 	Observers *Observers `json:"observers,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
@@ -1191,6 +1582,17 @@ type MultiStageTestConfiguration struct {
 	// NodeArchitecture is the architecture for the node where the test will run.
 	// If set, the generated test pod will include a nodeSelector for this architecture.
 	NodeArchitecture *NodeArchitecture `json:"node_architecture,omitempty"`
+	// ClientRelease is the name of the release from which the `oc` binary is
+	// injected into all steps that use one, for skew testing.
+	ClientRelease string `json:"client_release,omitempty"`
+	// ServerRelease is the name of the release that replaces the `latest`
+	// release payload in the dependencies of all steps, for skew testing.
+	ServerRelease string `json:"server_release,omitempty"`
+	// NetworkStack is the IP stack of the network of the cluster under test:
+	// `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the
+	// platform of the cluster profile and is exposed to all steps as
+	// $NETWORK_STACK.
+	NetworkStack NetworkStack `json:"network_stack,omitempty"`
 }
 type DependencyOverrides map[string]string
 
@@ -1204,6 +1606,10 @@ type MultiStageTestConfigurationLiteral struct {
 	Pre []LiteralTestStep `json:"pre,omitempty"`
 	// Test is the array of test steps that define the actual test.
 	Test []LiteralTestStep `json:"test,omitempty"`
+	// Gather is the array of test steps run after the tests finish to collect
+	// diagnostics, before `post`. Gather steps always run, even if previous
+	// steps fail, and their failures never fail the test.
+	Gather []LiteralTestStep `json:"gather,omitempty"`
 	// Post is the array of test steps run after the tests finish and teardown/deprovision resources.
 	// Post steps always run, even if previous steps fail.
 	Post []LiteralTestStep `json:"post,omitempty"`
@@ -1223,6 +1629,11 @@ type MultiStageTestConfigurationLiteral struct {
 	// they fail. The given step must explicitly ask for being ignored by setting
 	// the OptionalOnSuccess flag to true.
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
+	// GatherTimeout is how long the whole `gather` phase may take. Gather steps
+	// still running when it expires are stopped and `post` steps start.
+	GatherTimeout *prowv1.Duration `json:"gather_timeout,omitempty"`
+	// Budgets limit how long the `pre`, `test` and `post` phases may take.
+	Budgets *PhaseBudgets `json:"budgets,omitempty"`
 	// Observers are the observers that need to be run
 	Observers []Observer `json:"observers,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
@@ -1231,11 +1642,44 @@ type MultiStageTestConfigurationLiteral struct {
 	// NodeArchitecture is the architecture for the node where the test will run.
 	// If set, the generated test pod will include a nodeSelector for this architecture.
 	NodeArchitecture *NodeArchitecture `json:"node_architecture,omitempty"`
+	// ClientRelease is the name of the release from which the `oc` binary is
+	// injected into all steps that use one, for skew testing.
+	ClientRelease string `json:"client_release,omitempty"`
+	// ServerRelease is the name of the release that replaces the `latest`
+	// release payload in the dependencies of all steps, for skew testing.
+	ServerRelease string `json:"server_release,omitempty"`
+	// NetworkStack is the IP stack of the network of the cluster under test:
+	// `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the
+	// platform of the cluster profile and is exposed to all steps as
+	// $NETWORK_STACK.
+	NetworkStack NetworkStack `json:"network_stack,omitempty"`
 
 	// Override job timeout
 	Timeout *prowv1.Duration `json:"timeout,omitempty"`
 }
 
+// PhaseBudgets limit how long each phase of a multi-stage test may take, so a
+// slow phase cannot use up the time of the whole test. Steps still running when
+// the budget of their phase expires are stopped. When `pre` runs over its
+// budget, the `test` phase is skipped and `gather` and `post` steps still run.
+type PhaseBudgets struct {
+	// Pre is the budget of the `pre` phase.
+	Pre *prowv1.Duration `json:"pre,omitempty"`
+	// Test is the budget of the `test` phase.
+	Test *prowv1.Duration `json:"test,omitempty"`
+	// Post is the budget of the `post` phase.
+	Post *prowv1.Duration `json:"post,omitempty"`
+}
+
+// Steps returns the steps of all phases, in the order they run.
+func (c *MultiStageTestConfigurationLiteral) Steps() []LiteralTestStep {
+	ret := make([]LiteralTestStep, 0, len(c.Pre)+len(c.Test)+len(c.Gather)+len(c.Post))
+	for _, steps := range [][]LiteralTestStep{c.Pre, c.Test, c.Gather, c.Post} {
+		ret = append(ret, steps...)
+	}
+	return ret
+}
+
 // TestEnvironment has the values of parameters for multi-stage tests.
 type TestEnvironment map[string]string
 
@@ -1274,6 +1718,53 @@ type ContainerTestConfiguration struct {
 	// If the step should clone the source code prior to running the command.
 	// Defaults to `true` for `base_images`, `false` otherwise.
 	Clone *bool `json:"clone,omitempty"`
+	// Services are run next to the test for its whole duration, e.g. a
+	// database or an image registry the test needs.
+	Services []TestService `json:"services,omitempty"`
+}
+
+// TestService is a long-running process a container test needs. Services run as
+// sidecars in the pod of the test: they are started and ready before the
+// test starts and are terminated after it finishes. The test reaches them on
+// localhost.
+type TestService struct {
+	// As is the name of the service, used as the name of its container.
+	// Its resources are configured for `<test>-<as>`.
+	As string `json:"as"`
+	// From is the image stream tag in the pipeline to run the service from.
+	From PipelineImageStreamTagReference `json:"from"`
+	// Commands is the script the service runs. The entrypoint of the image
+	// is used when it is not set.
+	Commands string `json:"commands,omitempty"`
+	// Env are the environment variables set for the service.
+	Env map[string]string `json:"env,omitempty"`
+	// Ports are the ports the service listens on.
+	Ports []int32 `json:"ports,omitempty"`
+	// ReadinessProbe determines when the service is ready for the test to
+	// start. Services without one are ready as soon as they are started.
+	ReadinessProbe *TestServiceReadinessProbe `json:"readiness_probe,omitempty"`
+}
+
+// TestServiceReadinessProbe determines when a service is ready. Exactly one of
+// the checks must be set.
+type TestServiceReadinessProbe struct {
+	// TCPPort is a port the service is ready once it accepts connections on.
+	TCPPort int32 `json:"tcp_port,omitempty"`
+	// HTTPGet is a request the service is ready once it answers successfully.
+	HTTPGet *TestServiceHTTPGet `json:"http_get,omitempty"`
+	// Command is run in the container of the service, which is ready once
+	// it exits with 0.
+	Command []string `json:"command,omitempty"`
+	// Timeout is how long the service has to become ready, defaults to 5m.
+	Timeout *prowv1.Duration `json:"timeout,omitempty"`
+}
+
+// TestServiceHTTPGet is an HTTP request to a service.
+type TestServiceHTTPGet struct {
+	// Path is the path of the request, defaults to /.
+	Path string `json:"path,omitempty"`
+	// Port is the port to send the request to.
+	Port int32 `json:"port"`
 }
 
 // ClusterProfile is the name of a set of input variables
@@ -2315,6 +2806,7 @@ const (
 	PipelineImageStreamTagReferenceBinaries     PipelineImageStreamTagReference = "bin"
 	PipelineImageStreamTagReferenceTestBinaries PipelineImageStreamTagReference = "test-bin"
 	PipelineImageStreamTagReferenceRPMs         PipelineImageStreamTagReference = "rpms"
+	PipelineImageStreamTagReferenceTools        PipelineImageStreamTagReference = "tools"
 )
 
 // The fields in ReleaseBuildConfiguration which originate each pipeline image
@@ -2323,6 +2815,7 @@ const (
 	PipelineImageStreamTagSourceBinaries     = "binary_build_commands"
 	PipelineImageStreamTagSourceTestBinaries = "test_binary_build_commands"
 	PipelineImageStreamTagSourceRPMs         = "rpm_build_commands"
+	PipelineImageStreamTagSourceTools        = "tools"
 )
 
 // SourceStepConfiguration describes a step that
@@ -2341,6 +2834,9 @@ type SourceStepConfiguration struct {
 
 	// Ref is an optional string linking to the extra_ref in "org.repo" format that this belongs to
 	Ref string `json:"ref,omitempty"`
+
+	// BuildBackend is the backend of the build root the source is built on.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 func (config SourceStepConfiguration) TargetName() string {
@@ -2403,6 +2899,9 @@ type IndexGeneratorStepConfiguration struct {
 
 	// UpdateGraph defines the mode to us when updating the index graph
 	UpdateGraph IndexUpdate `json:"update_graph,omitempty"`
+
+	// BuildBackend is the backend of the build root the index is built on.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 func (config IndexGeneratorStepConfiguration) TargetName() string {
@@ -2427,6 +2926,12 @@ func IndexGeneratorName(indexName PipelineImageStreamTagReference) PipelineImage
 	return PipelineImageStreamTagReference(fmt.Sprintf("%s-gen", indexName))
 }
 
+// BuildContextName returns the name of the pipeline image holding the
+// filtered build context of an image.
+func BuildContextName(image PipelineImageStreamTagReference) PipelineImageStreamTagReference {
+	return PipelineImageStreamTagReference(fmt.Sprintf("%s-build-context", image))
+}
+
 // BundleSourceStepConfiguration describes a step that performs a set of
 // substitutions on all yaml files in the `src` image so that the
 // pullspecs in the operator manifests point to images inside the CI registry.
@@ -2435,6 +2940,9 @@ type BundleSourceStepConfiguration struct {
 	// Substitutions contains pullspecs that need to be replaced by images
 	// in the CI cluster for operator bundle images
 	Substitutions []PullSpecSubstitution `json:"substitutions,omitempty"`
+
+	// BuildBackend is the backend of the build root the bundle source is built on.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 func (config BundleSourceStepConfiguration) TargetName() string {
@@ -2486,8 +2994,11 @@ type ProjectDirectoryImageBuildStepConfiguration struct {
 	// AdditionalArchitectures is a list of additional architectures to build for. AMD64 architecture is included by default.
 	AdditionalArchitectures []string `json:"additional_architectures,omitempty"`
 
-	// Ref is an optional string linking to the extra_ref in "org.repo" format that this belongs to
-	Ref string `json:"ref,omitempty"`
+	// Annotations configure how the CI tooling treats the build, they are
+	// not set on the image. Only ci.openshift.io/resource-ceiling-exemption
+	// is supported, to exempt the build from the resource ceilings of the
+	// organization.
+	Annotations map[string]string `json:"annotations,omitempty"`
 
 	// isBundleImage indicates that this build step is a bundle image
 	isBundleImage bool
@@ -2497,6 +3008,12 @@ func (config ProjectDirectoryImageBuildStepConfiguration) TargetName() string {
 	return string(config.To)
 }
 
+// FiltersContext determines whether only some of the files of the context
+// directory are sent as the build context.
+func (config ProjectDirectoryImageBuildInputs) FiltersContext() bool {
+	return len(config.Include) > 0 || len(config.Exclude) > 0
+}
+
 // IsBundleImage returns the value of the isBundleImage field
 func (p *ProjectDirectoryImageBuildStepConfiguration) IsBundleImage() bool {
 	return p.isBundleImage
@@ -2524,15 +3041,32 @@ type ProjectDirectoryImageBuildInputs struct {
 
 	// Inputs is a map of tag reference name to image input changes
 	// that will populate the build context for the Dockerfile or
-	// alter the input image for a multi-stage build.
+	// alter the input image for a multi-stage build. Every input
+	// can be referenced as `pipeline:<name>` in the Dockerfile, e.g.
+	// in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.
 	Inputs map[string]ImageBuildInputs `json:"inputs,omitempty"`
 
+	// Include lists glob patterns of the files in the context_dir sent as
+	// the build context, all files are sent when unset. Patterns use shell
+	// syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched
+	// against paths relative to the context_dir; the files under a matching
+	// directory match as well. The Dockerfile is always sent.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude lists glob patterns of the files in the context_dir not sent
+	// as the build context, with the same syntax as Include.
+	Exclude []string `json:"exclude,omitempty"`
+
 	// BuildArgs contains build arguments that will be resolved in the Dockerfile.
 	// See https://docs.docker.com/engine/reference/builder/#/arg for more details.
 	BuildArgs []BuildArg `json:"build_args,omitempty"`
 
 	// Ref is an optional string linking to the extra_ref in "org.repo" format that this belongs to
 	Ref string `json:"ref,omitempty"`
+
+	// BuildBackend selects how the image is built: with the OpenShift Build
+	// API by default, or with `buildah` in unprivileged pods.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 type BuildArg struct {
@@ -2563,7 +3097,8 @@ type ImageBuildInputs struct {
 	// be replaced by the image reference from this step. For instance,
 	// if the Dockerfile defines FROM nginx:latest AS base, specifying
 	// either "nginx:latest" or "base" in this array will replace that
-	// image with the pipeline input.
+	// image with the pipeline input. The `pipeline:<name>` form of
+	// the input does not need to be listed.
 	As []string `json:"as,omitempty"`
 }
 
@@ -2583,6 +3118,9 @@ type ImageSourcePath struct {
 type RPMImageInjectionStepConfiguration struct {
 	From PipelineImageStreamTagReference `json:"from"`
 	To   PipelineImageStreamTagReference `json:"to,omitempty"`
+
+	// BuildBackend is the backend of the build root the image is built with.
+	BuildBackend BuildBackend `json:"build_backend,omitempty"`
 }
 
 func (config RPMImageInjectionStepConfiguration) TargetName() string {
@@ -2660,6 +3198,15 @@ type ClusterProfileDetails struct {
 	LeaseType   string                 `yaml:"lease_type,omitempty" json:"lease_type,omitempty"`
 	Secret      string                 `yaml:"secret,omitempty" json:"secret,omitempty"`
 	ConfigMap   string                 `yaml:"config_map,omitempty" json:"config_map,omitempty"`
+	// WorkloadIdentity issues short-lived federated tokens to steps instead of
+	// requiring long-lived cloud credentials in the profile secret.
+	WorkloadIdentity *WorkloadIdentity `yaml:"workload_identity,omitempty" json:"workload_identity,omitempty"`
+	// LeakDetection scans for cloud resources of the cluster that survive the
+	// teardown of multi-stage tests using the profile.
+	LeakDetection *LeakDetection `yaml:"leak_detection,omitempty" json:"leak_detection,omitempty"`
+	// NetworkStacks are the network stacks tests using the profile can select,
+	// replacing the ones supported by the platform of the profile.
+	NetworkStacks []NetworkStack `yaml:"network_stacks,omitempty" json:"network_stacks,omitempty"`
 }
 
 type ClusterProfileOwners struct {
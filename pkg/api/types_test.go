@@ -3,9 +3,12 @@ package api
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/google/go-cmp/cmp"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
 
 func TestOverlay(t *testing.T) {
@@ -345,3 +348,32 @@ func TestInputImageTagStepConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowedWindowContains(t *testing.T) {
+	window := AllowedWindow{Start: "0 22 * * *", Duration: prowv1.Duration{Duration: 4 * time.Hour}}
+	opening := time.Date(2024, time.June, 1, 22, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		t        time.Time
+		expected bool
+	}{
+		{name: "before the start", t: opening.Add(-time.Nanosecond)},
+		{name: "at the start", t: opening, expected: true},
+		{name: "within the window", t: opening.Add(2 * time.Hour), expected: true},
+		{name: "within the window in another time zone", t: opening.Add(2 * time.Hour).In(time.FixedZone("CEST", 2*60*60)), expected: true},
+		{name: "just before the end", t: opening.Add(4*time.Hour - time.Nanosecond), expected: true},
+		{name: "at the end", t: opening.Add(4 * time.Hour), expected: true},
+		{name: "just after the end", t: opening.Add(4*time.Hour + time.Nanosecond)},
+		{name: "a second after the end", t: opening.Add(4*time.Hour + time.Second)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := window.Contains(tc.t)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+const (
+	// UpgradeReleaseImageEnv is the environment variable exposing the pull
+	// spec of the release payload an upgrade step upgrades the cluster to.
+	UpgradeReleaseImageEnv = "OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE"
+	// DefaultUpgradeTimeout is how long an upgrade step waits for the upgrade
+	// to complete, unless configured otherwise.
+	DefaultUpgradeTimeout = 2 * time.Hour
+)
+
+// UpgradeImage is the image running the cluster-upgrade binary.
+var UpgradeImage = ImageStreamTagReference{Namespace: "ci", Name: "cluster-upgrade", Tag: "latest"}
+
+// UpgradeForce determines when the upgrade is forced, bypassing the
+// verification of the target release payload and the upgrade preconditions.
+type UpgradeForce string
+
+const (
+	// UpgradeForceNever never forces the upgrade.
+	UpgradeForceNever UpgradeForce = "never"
+	// UpgradeForceAlways always forces the upgrade.
+	UpgradeForceAlways UpgradeForce = "always"
+	// UpgradeForceIfUnverified forces the upgrade only once the cluster has
+	// refused the target release payload because its signature could not be
+	// verified, which is expected for payloads built in CI.
+	UpgradeForceIfUnverified UpgradeForce = "if_unverified"
+)
+
+// UpgradeStep is a typed step upgrading the cluster under test with
+// `oc adm upgrade` semantics. It is expanded into a literal step running the
+// cluster-upgrade binary, which sets the desired update on the ClusterVersion,
+// polls its status until the upgrade completes and records the progress in
+// machine-readable artifacts.
+type UpgradeStep struct {
+	// Release is the name of the release the cluster is upgraded to,
+	// defaults to `latest`.
+	Release string `json:"release,omitempty"`
+	// Channel is the update channel set on the cluster before upgrading.
+	// The channel is left untouched when unset.
+	Channel string `json:"channel,omitempty"`
+	// Timeout is how long to wait for the upgrade to complete, defaults to
+	// two hours.
+	Timeout *prowv1.Duration `json:"timeout,omitempty"`
+	// Force determines when the upgrade is forced: `never` (the default),
+	// `always` or `if_unverified`.
+	Force UpgradeForce `json:"force,omitempty"`
+}
+
+// TargetRelease is the name of the release the cluster is upgraded to.
+func (u *UpgradeStep) TargetRelease() string {
+	if u.Release == "" {
+		return LatestReleaseName
+	}
+	return u.Release
+}
+
+// UpgradeTimeout is how long to wait for the upgrade to complete.
+func (u *UpgradeStep) UpgradeTimeout() time.Duration {
+	if u.Timeout == nil {
+		return DefaultUpgradeTimeout
+	}
+	return u.Timeout.Duration
+}
+
+// ForceMode is the configured force mode.
+func (u *UpgradeStep) ForceMode() UpgradeForce {
+	if u.Force == "" {
+		return UpgradeForceNever
+	}
+	return u.Force
+}
+
+// Validate checks the configuration of the upgrade.
+func (u *UpgradeStep) Validate() []error {
+	var errs []error
+	if u.Timeout != nil && u.Timeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("timeout must be positive, got %s", u.Timeout.Duration))
+	}
+	switch u.Force {
+	case "", UpgradeForceNever, UpgradeForceAlways, UpgradeForceIfUnverified:
+	default:
+		errs = append(errs, fmt.Errorf("force must be one of %s, %s or %s, got %q", UpgradeForceNever, UpgradeForceAlways, UpgradeForceIfUnverified, u.Force))
+	}
+	if strings.ContainsAny(u.Channel, " \t\n'\"`$\\") {
+		errs = append(errs, errors.New("channel must not contain whitespace, quotes or shell expansions"))
+	}
+	return errs
+}
+
+func (u *UpgradeStep) expand(step LiteralTestStep) LiteralTestStep {
+	image := UpgradeImage
+	step.FromImage = &image
+	args := []string{
+		fmt.Sprintf(`--to="${%s}"`, UpgradeReleaseImageEnv),
+		fmt.Sprintf("--timeout=%s", u.UpgradeTimeout()),
+		fmt.Sprintf("--force=%s", u.ForceMode()),
+	}
+	if u.Channel != "" {
+		args = append(args, fmt.Sprintf("--channel=%s", u.Channel))
+	}
+	step.Commands = fmt.Sprintf("cluster-upgrade %s\n", strings.Join(args, " "))
+	step.Dependencies = append(step.Dependencies, StepDependency{
+		Name: fmt.Sprintf("%s:%s", ReleaseImageStream, u.TargetRelease()),
+		Env:  UpgradeReleaseImageEnv,
+	})
+	if step.Timeout == nil {
+		step.Timeout = &prowv1.Duration{Duration: u.UpgradeTimeout() + typedStepTimeoutMargin}
+	}
+	return step
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestExpandTypedStep(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		step     LiteralTestStep
+		expected LiteralTestStep
+	}{
+		{
+			name:     "literal step is unchanged",
+			step:     LiteralTestStep{As: "test", From: "src", Commands: "make test"},
+			expected: LiteralTestStep{As: "test", From: "src", Commands: "make test"},
+		},
+		{
+			name: "defaults",
+			step: LiteralTestStep{As: "upgrade", Upgrade: &UpgradeStep{}},
+			expected: LiteralTestStep{
+				As:           "upgrade",
+				FromImage:    &ImageStreamTagReference{Namespace: "ci", Name: "cluster-upgrade", Tag: "latest"},
+				Commands:     "cluster-upgrade --to=\"${OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE}\" --timeout=2h0m0s --force=never\n",
+				Dependencies: []StepDependency{{Name: "release:latest", Env: "OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE"}},
+				Timeout:      &prowv1.Duration{Duration: 2*time.Hour + 10*time.Minute},
+				Resources:    ResourceRequirements{Requests: ResourceList{"cpu": "10m", "memory": "100Mi"}},
+			},
+		},
+		{
+			name: "configured",
+			step: LiteralTestStep{
+				As:        "upgrade",
+				Timeout:   &prowv1.Duration{Duration: 5 * time.Hour},
+				Resources: ResourceRequirements{Requests: ResourceList{"cpu": "100m"}},
+				Upgrade: &UpgradeStep{
+					Release: "target",
+					Channel: "stable-4.20",
+					Timeout: &prowv1.Duration{Duration: 3 * time.Hour},
+					Force:   UpgradeForceIfUnverified,
+				},
+			},
+			expected: LiteralTestStep{
+				As:           "upgrade",
+				FromImage:    &ImageStreamTagReference{Namespace: "ci", Name: "cluster-upgrade", Tag: "latest"},
+				Commands:     "cluster-upgrade --to=\"${OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE}\" --timeout=3h0m0s --force=if_unverified --channel=stable-4.20\n",
+				Dependencies: []StepDependency{{Name: "release:target", Env: "OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE"}},
+				Timeout:      &prowv1.Duration{Duration: 5 * time.Hour},
+				Resources:    ResourceRequirements{Requests: ResourceList{"cpu": "100m"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, ExpandTypedStep(tc.step)); diff != "" {
+				t.Errorf("unexpected step: %s", diff)
+			}
+		})
+	}
+}
+
+func TestUpgradeStepValidate(t *testing.T) {
+	step := UpgradeStep{Channel: "stable $(whoami)", Timeout: &prowv1.Duration{}, Force: "sometimes"}
+	expected := []error{
+		errors.New("timeout must be positive, got 0s"),
+		errors.New(`force must be one of never, always or if_unverified, got "sometimes"`),
+		errors.New("channel must not contain whitespace, quotes or shell expansions"),
+	}
+	if diff := cmp.Diff(expected, step.Validate(), testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected errors: %s", diff)
+	}
+}
+
+func TestRegistryReferenceUnmarshalJSON(t *testing.T) {
+	raw := `ref:
+  as: upgrade
+  upgrade:
+    timeout: 3h
+`
+	expected := RegistryReferenceConfig{Reference: RegistryReference{LiteralTestStep: LiteralTestStep{
+		As:      "upgrade",
+		Upgrade: &UpgradeStep{Timeout: &prowv1.Duration{Duration: 3 * time.Hour}},
+	}}}
+	var actual RegistryReferenceConfig
+	if err := yaml.UnmarshalStrict([]byte(raw), &actual); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected reference: %s", diff)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ValidationRuleAllowlist declares which validation rules the configurations
+// of each repository may disable with `disabled_validation_rules`.
+// +k8s:deepcopy-gen=false
+type ValidationRuleAllowlist struct {
+	Entries []ValidationRuleAllowlistEntry `json:"entries"`
+}
+
+// ValidationRuleAllowlistEntry allows the configurations of a repository, or
+// of one of its branches, to disable some validation rules.
+// +k8s:deepcopy-gen=false
+type ValidationRuleAllowlistEntry struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// Branch limits the entry to one branch, all branches of the repository
+	// are allowed when unset.
+	Branch string `json:"branch,omitempty"`
+	// Rules are the names of the rules which may be disabled.
+	Rules []string `json:"rules"`
+	// Reason explains why the rules need to be disabled.
+	Reason string `json:"reason"`
+}
+
+// Validate checks that the allowlist is well-formed. The names of the rules
+// are checked with `isRule`.
+func (a *ValidationRuleAllowlist) Validate(isRule func(string) bool) error {
+	var errs []error
+	for i, entry := range a.Entries {
+		if entry.Org == "" || entry.Repo == "" {
+			errs = append(errs, fmt.Errorf("entries[%d]: org and repo are required", i))
+		}
+		if len(entry.Rules) == 0 {
+			errs = append(errs, fmt.Errorf("entries[%d]: at least one rule is required", i))
+		}
+		for j, rule := range entry.Rules {
+			if !isRule(rule) {
+				errs = append(errs, fmt.Errorf("entries[%d].rules[%d]: unknown rule %q", i, j, rule))
+			}
+		}
+		if entry.Reason == "" {
+			errs = append(errs, fmt.Errorf("entries[%d]: reason is required", i))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Allowed returns the rules a configuration may disable.
+func (a *ValidationRuleAllowlist) Allowed(metadata Metadata) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, entry := range a.Entries {
+		if entry.Org == metadata.Org && entry.Repo == metadata.Repo && (entry.Branch == "" || entry.Branch == metadata.Branch) {
+			ret.Insert(entry.Rules...)
+		}
+	}
+	return ret
+}
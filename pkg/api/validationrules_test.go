@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidationRuleAllowlistValidate(t *testing.T) {
+	isRule := sets.New[string]("shm-limit").Has
+	for _, tc := range []struct {
+		name      string
+		allowlist ValidationRuleAllowlist
+		expected  error
+	}{
+		{
+			name: "valid",
+			allowlist: ValidationRuleAllowlist{Entries: []ValidationRuleAllowlistEntry{
+				{Org: "openshift", Repo: "ml", Rules: []string{"shm-limit"}, Reason: "needs a large /dev/shm"},
+			}},
+		},
+		{
+			name: "invalid entry",
+			allowlist: ValidationRuleAllowlist{Entries: []ValidationRuleAllowlistEntry{
+				{Org: "openshift", Rules: []string{"no-such-rule"}},
+			}},
+			expected: errors.New(`[entries[0]: org and repo are required, entries[0].rules[0]: unknown rule "no-such-rule", entries[0]: reason is required]`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", tc.allowlist.Validate(isRule), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
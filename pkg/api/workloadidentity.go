@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// WorkloadIdentityConfigKey is the key in the cluster profile secret that
+	// holds the workload identity configuration of the profile.
+	WorkloadIdentityConfigKey = "workload-identity.json"
+	// GCPWorkloadIdentityCredentialsKey is the key in the cluster profile secret
+	// that holds the GCP credential configuration for workload identity federation.
+	GCPWorkloadIdentityCredentialsKey = "gcp-workload-identity-credentials.json"
+	// WorkloadIdentityTokenDir is where the federated token is mounted in steps.
+	WorkloadIdentityTokenDir = "/var/run/secrets/ci.openshift.io/cloud-token"
+	// WorkloadIdentityTokenFile is the name of the federated token file.
+	WorkloadIdentityTokenFile = "token"
+
+	// defaultWorkloadIdentityTokenExpiration is the lifetime of federated tokens
+	// when not configured, in seconds.
+	defaultWorkloadIdentityTokenExpiration = 3600
+	// minWorkloadIdentityTokenExpiration is the shortest lifetime Kubernetes
+	// allows for projected service account tokens, in seconds.
+	minWorkloadIdentityTokenExpiration = 600
+)
+
+// WorkloadIdentityProvider is the cloud that issues credentials in exchange for
+// federated tokens.
+type WorkloadIdentityProvider string
+
+const (
+	// WorkloadIdentityProviderGCP exchanges tokens using GCP workload identity federation.
+	WorkloadIdentityProviderGCP WorkloadIdentityProvider = "gcp"
+	// WorkloadIdentityProviderAWS exchanges tokens using AWS web identity federation.
+	WorkloadIdentityProviderAWS WorkloadIdentityProvider = "aws"
+)
+
+// WorkloadIdentity configures a cluster profile to issue short-lived federated
+// tokens to steps, which they exchange for cloud credentials, instead of relying
+// on long-lived keys stored in the cluster profile secret.
+type WorkloadIdentity struct {
+	// Provider is the cloud that the token is exchanged with, one of `gcp` or `aws`.
+	Provider WorkloadIdentityProvider `yaml:"provider" json:"provider"`
+	// Audience is the audience of the token. Defaults to the GCP workload
+	// identity provider or to `sts.amazonaws.com`.
+	Audience string `yaml:"audience,omitempty" json:"audience,omitempty"`
+	// ExpirationSeconds is the lifetime of the token, defaults to one hour.
+	ExpirationSeconds int64 `yaml:"expiration_seconds,omitempty" json:"expiration_seconds,omitempty"`
+	// GCPWorkloadIdentityProvider is the full resource name of the GCP workload
+	// identity pool provider, `projects/<number>/locations/global/workloadIdentityPools/<pool>/providers/<provider>`.
+	GCPWorkloadIdentityProvider string `yaml:"gcp_workload_identity_provider,omitempty" json:"gcp_workload_identity_provider,omitempty"`
+	// GCPServiceAccount is the email of the GCP service account impersonated by steps.
+	GCPServiceAccount string `yaml:"gcp_service_account,omitempty" json:"gcp_service_account,omitempty"`
+	// AWSRoleARN is the ARN of the AWS IAM role assumed by steps.
+	AWSRoleARN string `yaml:"aws_role_arn,omitempty" json:"aws_role_arn,omitempty"`
+}
+
+// TokenAudience is the audience the federated token is issued for.
+func (w *WorkloadIdentity) TokenAudience() string {
+	if w.Audience != "" {
+		return w.Audience
+	}
+	if w.Provider == WorkloadIdentityProviderGCP {
+		return "//iam.googleapis.com/" + w.GCPWorkloadIdentityProvider
+	}
+	return "sts.amazonaws.com"
+}
+
+// TokenExpirationSeconds is the lifetime of the federated token.
+func (w *WorkloadIdentity) TokenExpirationSeconds() int64 {
+	if w.ExpirationSeconds == 0 {
+		return defaultWorkloadIdentityTokenExpiration
+	}
+	return w.ExpirationSeconds
+}
+
+// Validate checks that the configuration is complete for the provider.
+func (w *WorkloadIdentity) Validate() error {
+	var errs []error
+	switch w.Provider {
+	case WorkloadIdentityProviderGCP:
+		if !strings.HasPrefix(w.GCPWorkloadIdentityProvider, "projects/") || !strings.Contains(w.GCPWorkloadIdentityProvider, "/workloadIdentityPools/") {
+			errs = append(errs, fmt.Errorf("gcp_workload_identity_provider: %q is not the resource name of a workload identity pool provider", w.GCPWorkloadIdentityProvider))
+		}
+		if !strings.HasSuffix(w.GCPServiceAccount, ".iam.gserviceaccount.com") {
+			errs = append(errs, fmt.Errorf("gcp_service_account: %q is not the email of a service account", w.GCPServiceAccount))
+		}
+		if w.AWSRoleARN != "" {
+			errs = append(errs, errors.New("aws_role_arn cannot be set for gcp"))
+		}
+	case WorkloadIdentityProviderAWS:
+		if !strings.HasPrefix(w.AWSRoleARN, "arn:") || !strings.Contains(w.AWSRoleARN, ":role/") {
+			errs = append(errs, fmt.Errorf("aws_role_arn: %q is not the ARN of a role", w.AWSRoleARN))
+		}
+		if w.GCPWorkloadIdentityProvider != "" || w.GCPServiceAccount != "" {
+			errs = append(errs, errors.New("gcp_workload_identity_provider and gcp_service_account cannot be set for aws"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("provider: expected one of %s or %s, got %q", WorkloadIdentityProviderGCP, WorkloadIdentityProviderAWS, w.Provider))
+	}
+	if w.ExpirationSeconds != 0 && w.ExpirationSeconds < minWorkloadIdentityTokenExpiration {
+		errs = append(errs, fmt.Errorf("expiration_seconds: must be at least %d", minWorkloadIdentityTokenExpiration))
+	}
+	return errors.Join(errs...)
+}
+
+// gcpExternalAccount is the credential configuration file used by GCP client
+// libraries and tools to exchange a federated token for GCP credentials.
+type gcpExternalAccount struct {
+	Type                           string                   `json:"type"`
+	Audience                       string                   `json:"audience"`
+	SubjectTokenType               string                   `json:"subject_token_type"`
+	TokenURL                       string                   `json:"token_url"`
+	ServiceAccountImpersonationURL string                   `json:"service_account_impersonation_url"`
+	CredentialSource               gcpExternalAccountSource `json:"credential_source"`
+}
+
+type gcpExternalAccountSource struct {
+	File string `json:"file"`
+}
+
+// GCPCredentialConfiguration generates the credential configuration for GCP
+// client libraries, which reads the federated token mounted in steps.
+func (w *WorkloadIdentity) GCPCredentialConfiguration() ([]byte, error) {
+	return json.MarshalIndent(gcpExternalAccount{
+		Type:                           "external_account",
+		Audience:                       w.TokenAudience(),
+		SubjectTokenType:               "urn:ietf:params:oauth:token-type:jwt",
+		TokenURL:                       "https://sts.googleapis.com/v1/token",
+		ServiceAccountImpersonationURL: fmt.Sprintf("https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken", w.GCPServiceAccount),
+		CredentialSource:               gcpExternalAccountSource{File: WorkloadIdentityTokenDir + "/" + WorkloadIdentityTokenFile},
+	}, "", "  ")
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestWorkloadIdentityValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		wi       WorkloadIdentity
+		expected error
+	}{
+		{
+			name: "valid gcp",
+			wi: WorkloadIdentity{
+				Provider:                    WorkloadIdentityProviderGCP,
+				GCPWorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/ci/providers/build-farm",
+				GCPServiceAccount:           "ci@project.iam.gserviceaccount.com",
+			},
+		},
+		{
+			name: "valid aws",
+			wi: WorkloadIdentity{
+				Provider:          WorkloadIdentityProviderAWS,
+				AWSRoleARN:        "arn:aws:iam::123456789012:role/ci",
+				ExpirationSeconds: 900,
+			},
+		},
+		{
+			name: "invalid gcp",
+			wi: WorkloadIdentity{
+				Provider:                    WorkloadIdentityProviderGCP,
+				GCPWorkloadIdentityProvider: "ci",
+				AWSRoleARN:                  "arn:aws:iam::123456789012:role/ci",
+				ExpirationSeconds:           60,
+			},
+			expected: errors.New(`gcp_workload_identity_provider: "ci" is not the resource name of a workload identity pool provider
+gcp_service_account: "" is not the email of a service account
+aws_role_arn cannot be set for gcp
+expiration_seconds: must be at least 600`),
+		},
+		{
+			name: "invalid aws",
+			wi: WorkloadIdentity{
+				Provider:          WorkloadIdentityProviderAWS,
+				GCPServiceAccount: "ci@project.iam.gserviceaccount.com",
+			},
+			expected: errors.New(`aws_role_arn: "" is not the ARN of a role
+gcp_workload_identity_provider and gcp_service_account cannot be set for aws`),
+		},
+		{
+			name:     "unknown provider",
+			wi:       WorkloadIdentity{Provider: "azure"},
+			expected: errors.New(`provider: expected one of gcp or aws, got "azure"`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.wi.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestWorkloadIdentityGCPCredentialConfiguration(t *testing.T) {
+	wi := WorkloadIdentity{
+		Provider:                    WorkloadIdentityProviderGCP,
+		GCPWorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/ci/providers/build-farm",
+		GCPServiceAccount:           "ci@project.iam.gserviceaccount.com",
+	}
+	expected := `{
+  "type": "external_account",
+  "audience": "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/ci/providers/build-farm",
+  "subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+  "token_url": "https://sts.googleapis.com/v1/token",
+  "service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/ci@project.iam.gserviceaccount.com:generateAccessToken",
+  "credential_source": {
+    "file": "/var/run/secrets/ci.openshift.io/cloud-token/token"
+  }
+}`
+	actual, err := wi.GCPCredentialConfiguration()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, string(actual)); diff != "" {
+		t.Errorf("unexpected configuration: %s", diff)
+	}
+}
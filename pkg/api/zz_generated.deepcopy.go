@@ -9,6 +9,57 @@ import (
 	"sigs.k8s.io/prow/pkg/config"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedWindow) DeepCopyInto(out *AllowedWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedWindow.
+func (in *AllowedWindow) DeepCopy() *AllowedWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactManifest) DeepCopyInto(out *ArtifactManifest) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]ArtifactManifestFile, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactManifest.
+func (in *ArtifactManifest) DeepCopy() *ArtifactManifest {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactManifest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactManifestFile) DeepCopyInto(out *ArtifactManifestFile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactManifestFile.
+func (in *ArtifactManifestFile) DeepCopy() *ArtifactManifestFile {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactManifestFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildArg) DeepCopyInto(out *BuildArg) {
 	*out = *in
@@ -100,6 +151,63 @@ func (in *CIOperatorInrepoConfig) DeepCopy() *CIOperatorInrepoConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CVEGate) DeepCopyInto(out *CVEGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CVEGate.
+func (in *CVEGate) DeepCopy() *CVEGate {
+	if in == nil {
+		return nil
+	}
+	out := new(CVEGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CVEWaiver) DeepCopyInto(out *CVEWaiver) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CVEWaiver.
+func (in *CVEWaiver) DeepCopy() *CVEWaiver {
+	if in == nil {
+		return nil
+	}
+	out := new(CVEWaiver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CVEWaivers) DeepCopyInto(out *CVEWaivers) {
+	*out = *in
+	if in.Waivers != nil {
+		in, out := &in.Waivers, &out.Waivers
+		*out = make([]CVEWaiver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CVEWaivers.
+func (in *CVEWaivers) DeepCopy() *CVEWaivers {
+	if in == nil {
+		return nil
+	}
+	out := new(CVEWaivers)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Candidate) DeepCopyInto(out *Candidate) {
 	*out = *in
@@ -131,6 +239,21 @@ func (in *ClaimRelease) DeepCopy() *ClaimRelease {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudCredentialSource) DeepCopyInto(out *CloudCredentialSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudCredentialSource.
+func (in *CloudCredentialSource) DeepCopy() *CloudCredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudCredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterClaim) DeepCopyInto(out *ClusterClaim) {
 	*out = *in
@@ -231,6 +354,21 @@ func (in *ClusterProfileDetails) DeepCopyInto(out *ClusterProfileDetails) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentity)
+		**out = **in
+	}
+	if in.LeakDetection != nil {
+		in, out := &in.LeakDetection, &out.LeakDetection
+		*out = new(LeakDetection)
+		**out = **in
+	}
+	if in.NetworkStacks != nil {
+		in, out := &in.NetworkStacks, &out.NetworkStacks
+		*out = make([]NetworkStack, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProfileDetails.
@@ -333,6 +471,13 @@ func (in *ContainerTestConfiguration) DeepCopyInto(out *ContainerTestConfigurati
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]TestService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerTestConfiguration.
@@ -348,6 +493,11 @@ func (in *ContainerTestConfiguration) DeepCopy() *ContainerTestConfiguration {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialReference) DeepCopyInto(out *CredentialReference) {
 	*out = *in
+	if in.Cloud != nil {
+		in, out := &in.Cloud, &out.Cloud
+		*out = new(CloudCredentialSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialReference.
@@ -419,6 +569,31 @@ func (in *GraphConfiguration) DeepCopy() *GraphConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckStep) DeepCopyInto(out *HealthCheckStep) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]HealthCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Wait != nil {
+		in, out := &in.Wait, &out.Wait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckStep.
+func (in *HealthCheckStep) DeepCopy() *HealthCheckStep {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageBuildInputs) DeepCopyInto(out *ImageBuildInputs) {
 	*out = *in
@@ -630,6 +805,26 @@ func (in *InputImageTagStepConfiguration) DeepCopy() *InputImageTagStepConfigura
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallConfigStep) DeepCopyInto(out *InstallConfigStep) {
+	*out = *in
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallConfigStep.
+func (in *InstallConfigStep) DeepCopy() *InstallConfigStep {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallConfigStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Integration) DeepCopyInto(out *Integration) {
 	*out = *in
@@ -645,6 +840,21 @@ func (in *Integration) DeepCopy() *Integration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeakDetection) DeepCopyInto(out *LeakDetection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeakDetection.
+func (in *LeakDetection) DeepCopy() *LeakDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(LeakDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 	*out = *in
@@ -667,7 +877,9 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 	if in.Credentials != nil {
 		in, out := &in.Credentials, &out.Credentials
 		*out = make([]CredentialReference, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Environment != nil {
 		in, out := &in.Environment, &out.Environment
@@ -721,6 +933,43 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 		*out = new(NodeArchitecture)
 		**out = **in
 	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]Toleration, len(*in))
+		copy(*out, *in)
+	}
+	if in.NestedPodman != nil {
+		in, out := &in.NestedPodman, &out.NestedPodman
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LogLimit != nil {
+		in, out := &in.LogLimit, &out.LogLimit
+		*out = new(LogLimit)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheckStep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstallConfig != nil {
+		in, out := &in.InstallConfig, &out.InstallConfig
+		*out = new(InstallConfigStep)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiteralTestStep.
@@ -733,6 +982,21 @@ func (in *LiteralTestStep) DeepCopy() *LiteralTestStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogLimit) DeepCopyInto(out *LogLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogLimit.
+func (in *LogLimit) DeepCopy() *LogLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(LogLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemoryBackedVolume) DeepCopyInto(out *MemoryBackedVolume) {
 	*out = *in
@@ -796,6 +1060,13 @@ func (in *MultiStageTestConfiguration) DeepCopyInto(out *MultiStageTestConfigura
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Gather != nil {
+		in, out := &in.Gather, &out.Gather
+		*out = make([]TestStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Post != nil {
 		in, out := &in.Post, &out.Post
 		*out = make([]TestStep, len(*in))
@@ -842,6 +1113,16 @@ func (in *MultiStageTestConfiguration) DeepCopyInto(out *MultiStageTestConfigura
 		*out = new(bool)
 		**out = **in
 	}
+	if in.GatherTimeout != nil {
+		in, out := &in.GatherTimeout, &out.GatherTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Budgets != nil {
+		in, out := &in.Budgets, &out.Budgets
+		*out = new(PhaseBudgets)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Observers != nil {
 		in, out := &in.Observers, &out.Observers
 		*out = new(Observers)
@@ -888,6 +1169,13 @@ func (in *MultiStageTestConfigurationLiteral) DeepCopyInto(out *MultiStageTestCo
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Gather != nil {
+		in, out := &in.Gather, &out.Gather
+		*out = make([]LiteralTestStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Post != nil {
 		in, out := &in.Post, &out.Post
 		*out = make([]LiteralTestStep, len(*in))
@@ -929,6 +1217,16 @@ func (in *MultiStageTestConfigurationLiteral) DeepCopyInto(out *MultiStageTestCo
 		*out = new(bool)
 		**out = **in
 	}
+	if in.GatherTimeout != nil {
+		in, out := &in.GatherTimeout, &out.GatherTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Budgets != nil {
+		in, out := &in.Budgets, &out.Budgets
+		*out = new(PhaseBudgets)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Observers != nil {
 		in, out := &in.Observers, &out.Observers
 		*out = make([]Observer, len(*in))
@@ -1260,6 +1558,36 @@ func (in *OutputImageTagStepConfiguration) DeepCopy() *OutputImageTagStepConfigu
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseBudgets) DeepCopyInto(out *PhaseBudgets) {
+	*out = *in
+	if in.Pre != nil {
+		in, out := &in.Pre, &out.Pre
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Test != nil {
+		in, out := &in.Test, &out.Test
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Post != nil {
+		in, out := &in.Post, &out.Post
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseBudgets.
+func (in *PhaseBudgets) DeepCopy() *PhaseBudgets {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseBudgets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineImageCacheStepConfiguration) DeepCopyInto(out *PipelineImageCacheStepConfiguration) {
 	*out = *in
@@ -1307,6 +1635,16 @@ func (in *ProjectDirectoryImageBuildInputs) DeepCopyInto(out *ProjectDirectoryIm
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.BuildArgs != nil {
 		in, out := &in.BuildArgs, &out.BuildArgs
 		*out = make([]BuildArg, len(*in))
@@ -1333,6 +1671,13 @@ func (in *ProjectDirectoryImageBuildStepConfiguration) DeepCopyInto(out *Project
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectDirectoryImageBuildStepConfiguration.
@@ -1355,6 +1700,11 @@ func (in *PromotionConfiguration) DeepCopyInto(out *PromotionConfiguration) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CVEGate != nil {
+		in, out := &in.CVEGate, &out.CVEGate
+		*out = new(CVEGate)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionConfiguration.
@@ -1367,6 +1717,21 @@ func (in *PromotionConfiguration) DeepCopy() *PromotionConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionMirror) DeepCopyInto(out *PromotionMirror) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionMirror.
+func (in *PromotionMirror) DeepCopy() *PromotionMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PromotionTarget) DeepCopyInto(out *PromotionTarget) {
 	*out = *in
@@ -1382,6 +1747,16 @@ func (in *PromotionTarget) DeepCopyInto(out *PromotionTarget) {
 			(*out)[key] = val
 		}
 	}
+	if in.AdditionalArchitectures != nil {
+		in, out := &in.AdditionalArchitectures, &out.AdditionalArchitectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(PromotionMirror)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionTarget.
@@ -1670,6 +2045,11 @@ func (in *ReleaseBuildConfiguration) DeepCopyInto(out *ReleaseBuildConfiguration
 		*out = make([]RefLocation, len(*in))
 		copy(*out, *in)
 	}
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = new(ToolsImageConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CanonicalGoRepository != nil {
 		in, out := &in.CanonicalGoRepository, &out.CanonicalGoRepository
 		*out = new(string)
@@ -1718,6 +2098,11 @@ func (in *ReleaseBuildConfiguration) DeepCopyInto(out *ReleaseBuildConfiguration
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.DisabledValidationRules != nil {
+		in, out := &in.DisabledValidationRules, &out.DisabledValidationRules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseBuildConfiguration.
@@ -1847,6 +2232,26 @@ func (in *ResourceRequirements) DeepCopy() *ResourceRequirements {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReuseResultsConfiguration) DeepCopyInto(out *ReuseResultsConfiguration) {
+	*out = *in
+	if in.SourcePaths != nil {
+		in, out := &in.SourcePaths, &out.SourcePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReuseResultsConfiguration.
+func (in *ReuseResultsConfiguration) DeepCopy() *ReuseResultsConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ReuseResultsConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Secret) DeepCopyInto(out *Secret) {
 	*out = *in
@@ -1862,6 +2267,26 @@ func (in *Secret) DeepCopy() *Secret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SoakConfiguration) DeepCopyInto(out *SoakConfiguration) {
+	*out = *in
+	if in.CheckpointInterval != nil {
+		in, out := &in.CheckpointInterval, &out.CheckpointInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SoakConfiguration.
+func (in *SoakConfiguration) DeepCopy() *SoakConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(SoakConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceStepConfiguration) DeepCopyInto(out *SourceStepConfiguration) {
 	*out = *in
@@ -2090,6 +2515,83 @@ func (in TestEnvironment) DeepCopy() TestEnvironment {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestService) DeepCopyInto(out *TestService) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(TestServiceReadinessProbe)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestService.
+func (in *TestService) DeepCopy() *TestService {
+	if in == nil {
+		return nil
+	}
+	out := new(TestService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestServiceHTTPGet) DeepCopyInto(out *TestServiceHTTPGet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestServiceHTTPGet.
+func (in *TestServiceHTTPGet) DeepCopy() *TestServiceHTTPGet {
+	if in == nil {
+		return nil
+	}
+	out := new(TestServiceHTTPGet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestServiceReadinessProbe) DeepCopyInto(out *TestServiceReadinessProbe) {
+	*out = *in
+	if in.HTTPGet != nil {
+		in, out := &in.HTTPGet, &out.HTTPGet
+		*out = new(TestServiceHTTPGet)
+		**out = **in
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestServiceReadinessProbe.
+func (in *TestServiceReadinessProbe) DeepCopy() *TestServiceReadinessProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(TestServiceReadinessProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TestStep) DeepCopyInto(out *TestStep) {
 	*out = *in
@@ -2179,11 +2681,57 @@ func (in *TestStepConfiguration) DeepCopyInto(out *TestStepConfiguration) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.ExpectedDuration != nil {
+		in, out := &in.ExpectedDuration, &out.ExpectedDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.RestrictNetworkAccess != nil {
 		in, out := &in.RestrictNetworkAccess, &out.RestrictNetworkAccess
 		*out = new(bool)
 		**out = **in
 	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]Toleration, len(*in))
+		copy(*out, *in)
+	}
+	if in.Soak != nil {
+		in, out := &in.Soak, &out.Soak
+		*out = new(SoakConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReuseResults != nil {
+		in, out := &in.ReuseResults, &out.ReuseResults
+		*out = new(ReuseResultsConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedWindows != nil {
+		in, out := &in.AllowedWindows, &out.AllowedWindows
+		*out = make([]AllowedWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.ContainerTestConfiguration != nil {
 		in, out := &in.ContainerTestConfiguration, &out.ContainerTestConfiguration
 		*out = new(ContainerTestConfiguration)
@@ -2246,6 +2794,63 @@ func (in *TestStepConfiguration) DeepCopy() *TestStepConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Toleration) DeepCopyInto(out *Toleration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Toleration.
+func (in *Toleration) DeepCopy() *Toleration {
+	if in == nil {
+		return nil
+	}
+	out := new(Toleration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Tool) DeepCopyInto(out *Tool) {
+	*out = *in
+	if in.Binaries != nil {
+		in, out := &in.Binaries, &out.Binaries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tool.
+func (in *Tool) DeepCopy() *Tool {
+	if in == nil {
+		return nil
+	}
+	out := new(Tool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolsImageConfiguration) DeepCopyInto(out *ToolsImageConfiguration) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]Tool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolsImageConfiguration.
+func (in *ToolsImageConfiguration) DeepCopy() *ToolsImageConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolsImageConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnresolvedRelease) DeepCopyInto(out *UnresolvedRelease) {
 	*out = *in
@@ -2281,6 +2886,26 @@ func (in *UnresolvedRelease) DeepCopy() *UnresolvedRelease {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStep) DeepCopyInto(out *UpgradeStep) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStep.
+func (in *UpgradeStep) DeepCopy() *UpgradeStep {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionBounds) DeepCopyInto(out *VersionBounds) {
 	*out = *in
@@ -2295,3 +2920,18 @@ func (in *VersionBounds) DeepCopy() *VersionBounds {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentity) DeepCopyInto(out *WorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentity.
+func (in *WorkloadIdentity) DeepCopy() *WorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,228 @@
+// Package attestation records the environment a ci-operator run happened in,
+// with everything needed to audit or reproduce the run later.
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ArtifactFilename is the name of the artifact holding the attestation.
+const ArtifactFilename = "ci-operator-attestation.json"
+
+// Attestation is the environment of a ci-operator run.
+type Attestation struct {
+	// Version is the version of ci-operator.
+	Version string `json:"version"`
+	// Commit is the commit ci-operator was built from, when known.
+	Commit string `json:"commit,omitempty"`
+	// Job is the specification of the job, as given by Prow. It holds the
+	// refs that were tested and the decoration configuration.
+	Job prowdapi.JobSpec `json:"job"`
+	// Namespace is the namespace the run happened in.
+	Namespace string `json:"namespace"`
+	// InputHash is the hash of the inputs of the run the namespace is named
+	// after.
+	InputHash string `json:"input_hash,omitempty"`
+	// ConfigDigest is the digest of the configuration after resolution,
+	// which embeds the steps of the registry the tests use.
+	ConfigDigest string `json:"config_digest"`
+	// Config is the configuration after resolution, which allows to run it
+	// again without the step registry it was resolved from.
+	Config *api.ReleaseBuildConfiguration `json:"config,omitempty"`
+	// Registry is where the step registry was loaded from: a directory, or
+	// the address of the configuration resolver. The state of the registry
+	// the run used is recorded by Steps.
+	Registry string `json:"registry,omitempty"`
+	// Steps are the digests of the multi-stage steps the tests ran, as
+	// resolved from the registry, so that a change of a step is noticed
+	// even when the registry it came from moved on.
+	Steps []Step `json:"steps,omitempty"`
+	// BuildCluster is the API server of the cluster the run happened on.
+	BuildCluster string `json:"build_cluster,omitempty"`
+	// ClusterProfiles are the cluster profiles of the targets of the run.
+	ClusterProfiles []string `json:"cluster_profiles,omitempty"`
+	// UtilityImages are the images decorating the pods of the run.
+	UtilityImages []Image `json:"utility_images,omitempty"`
+	// Inputs are the resolved inputs of the steps, such as the pull specs
+	// of the images they import.
+	Inputs []string `json:"inputs,omitempty"`
+}
+
+// Step is a multi-stage step used by the run.
+type Step struct {
+	Name string `json:"name"`
+	// Digest is the digest of the serialized step, with its commands.
+	Digest string `json:"digest"`
+}
+
+// Image is an image used by the run.
+type Image struct {
+	Name     string `json:"name"`
+	PullSpec string `json:"pull_spec"`
+	// Digest is the digest of the image, as pinned by the pull spec or as
+	// resolved from the registry when the pull spec is a tag.
+	Digest string `json:"digest,omitempty"`
+}
+
+// Resolver resolves the references of images to the descriptors of their
+// manifests in the registry, like the resolvers of containerd.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, ocispec.Descriptor, error)
+}
+
+// ResolveDigests records the digests the utility images referenced by tag
+// point to, as the tags may be moved after the run.
+func (a *Attestation) ResolveDigests(ctx context.Context, resolver Resolver) error {
+	var errs []error
+	for i, image := range a.UtilityImages {
+		if image.Digest != "" {
+			continue
+		}
+		named, err := reference.ParseNormalizedNamed(image.PullSpec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse the pull spec of the %s image: %w", image.Name, err))
+			continue
+		}
+		_, descriptor, err := resolver.Resolve(ctx, reference.TagNameOnly(named).String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve the digest of the %s image: %w", image.Name, err))
+			continue
+		}
+		a.UtilityImages[i].Digest = descriptor.Digest.String()
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// New records the parts of the environment that are derived from the job
+// and its configuration.
+func New(version string, jobSpec *api.JobSpec, config *api.ReleaseBuildConfiguration) (*Attestation, error) {
+	digest, err := ConfigDigest(config)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := stepDigests(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{
+		Version:       version,
+		Commit:        commit(),
+		Job:           jobSpec.JobSpec,
+		Namespace:     jobSpec.Namespace(),
+		ConfigDigest:  digest,
+		Config:        config,
+		Steps:         steps,
+		UtilityImages: utilityImages(jobSpec.DecorationConfig),
+	}, nil
+}
+
+// stepDigests records each distinct step of the multi-stage tests once,
+// sorted by name. Steps sharing a name but differing, e.g. through
+// overrides, are all recorded.
+func stepDigests(config *api.ReleaseBuildConfiguration) ([]Step, error) {
+	seen := map[Step]bool{}
+	var steps []Step
+	for _, test := range config.Tests {
+		literal := test.MultiStageTestConfigurationLiteral
+		if literal == nil {
+			continue
+		}
+		for _, phase := range [][]api.LiteralTestStep{literal.Pre, literal.Test, literal.Gather, literal.Post} {
+			for _, s := range phase {
+				raw, err := json.Marshal(s)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal the step %s: %w", s.As, err)
+				}
+				step := Step{Name: s.As, Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(raw))}
+				if !seen[step] {
+					seen[step] = true
+					steps = append(steps, step)
+				}
+			}
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].Name != steps[j].Name {
+			return steps[i].Name < steps[j].Name
+		}
+		return steps[i].Digest < steps[j].Digest
+	})
+	return steps, nil
+}
+
+// ConfigDigest is the digest of the serialized configuration.
+func ConfigDigest(config *api.ReleaseBuildConfiguration) (string, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the configuration: %w", err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(raw)), nil
+}
+
+// VerifyConfig checks that the recorded configuration is the one the run
+// used, as attested by its digest.
+func (a *Attestation) VerifyConfig() error {
+	if a.Config == nil {
+		return errors.New("the attestation does not record the configuration")
+	}
+	digest, err := ConfigDigest(a.Config)
+	if err != nil {
+		return err
+	}
+	if digest != a.ConfigDigest {
+		return fmt.Errorf("the digest of the recorded configuration is %s, but the run used %s", digest, a.ConfigDigest)
+	}
+	return nil
+}
+
+// commit is the VCS revision the binary was built from.
+func commit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+func utilityImages(decoration *prowapi.DecorationConfig) []Image {
+	if decoration == nil || decoration.UtilityImages == nil {
+		return nil
+	}
+	var ret []Image
+	for _, image := range []Image{
+		{Name: "clonerefs", PullSpec: decoration.UtilityImages.CloneRefs},
+		{Name: "initupload", PullSpec: decoration.UtilityImages.InitUpload},
+		{Name: "entrypoint", PullSpec: decoration.UtilityImages.Entrypoint},
+		{Name: "sidecar", PullSpec: decoration.UtilityImages.Sidecar},
+	} {
+		if image.PullSpec == "" {
+			continue
+		}
+		if i := strings.LastIndex(image.PullSpec, "@"); i != -1 {
+			image.Digest = image.PullSpec[i+1:]
+		}
+		ret = append(ret, image)
+	}
+	return ret
+}
@@ -0,0 +1,194 @@
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestNew(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{As: "unit", Commands: "make test"}},
+	}
+	digest, err := ConfigDigest(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name       string
+		decoration *prowapi.DecorationConfig
+		expected   []Image
+	}{
+		{
+			name: "undecorated job",
+		},
+		{
+			name: "utility images pinned by digest and by tag",
+			decoration: &prowapi.DecorationConfig{UtilityImages: &prowapi.UtilityImages{
+				CloneRefs:  "quay.io/prow/clonerefs@sha256:abc",
+				Entrypoint: "quay.io/prow/entrypoint:v1",
+				Sidecar:    "quay.io/prow/sidecar@sha256:def",
+			}},
+			expected: []Image{
+				{Name: "clonerefs", PullSpec: "quay.io/prow/clonerefs@sha256:abc", Digest: "sha256:abc"},
+				{Name: "entrypoint", PullSpec: "quay.io/prow/entrypoint:v1"},
+				{Name: "sidecar", PullSpec: "quay.io/prow/sidecar@sha256:def", Digest: "sha256:def"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := &api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job", BuildID: "1", DecorationConfig: tc.decoration}}
+			jobSpec.SetNamespace("ci-op-1234")
+			attestation, err := New("v1", jobSpec, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := &Attestation{
+				Version:       "v1",
+				Commit:        attestation.Commit,
+				Job:           jobSpec.JobSpec,
+				Namespace:     "ci-op-1234",
+				ConfigDigest:  digest,
+				Config:        config,
+				UtilityImages: tc.expected,
+			}
+			if diff := cmp.Diff(expected, attestation, cmpopts.IgnoreUnexported(prowdapi.JobSpec{})); diff != "" {
+				t.Errorf("unexpected attestation: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepDigests(t *testing.T) {
+	install := api.LiteralTestStep{As: "install", From: "cli", Commands: "install.sh"}
+	overridden := install
+	overridden.Commands = "install.sh --fast"
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{
+		{As: "unit", Commands: "make test"},
+		{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			Pre:    []api.LiteralTestStep{install},
+			Test:   []api.LiteralTestStep{{As: "test", From: "tests", Commands: "make e2e"}},
+			Gather: []api.LiteralTestStep{{As: "gather", From: "cli", Commands: "gather.sh"}},
+		}},
+		{As: "e2e-again", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			Pre: []api.LiteralTestStep{install},
+		}},
+		{As: "e2e-fast", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			Pre: []api.LiteralTestStep{overridden},
+		}},
+	}}
+	steps, err := stepDigests(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	testhelper.Diff(t, "steps", names, []string{"gather", "install", "install", "test"})
+	if steps[1].Digest == steps[2].Digest {
+		t.Errorf("expected the overridden step to have a digest of its own, got %s for both", steps[1].Digest)
+	}
+}
+
+func TestConfigDigest(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{{As: "unit"}}}
+	first, err := ConfigDigest(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Tests[0].As = "e2e"
+	second, err := ConfigDigest(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("expected the digest to change with the configuration, got %s for both", first)
+	}
+}
+
+func TestVerifyConfig(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{{As: "unit", Commands: "make test"}}}
+	digest, err := ConfigDigest(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name        string
+		attestation *Attestation
+		expected    error
+	}{
+		{
+			name:        "configuration matches its digest",
+			attestation: &Attestation{ConfigDigest: digest, Config: config},
+		},
+		{
+			name:        "configuration was not recorded",
+			attestation: &Attestation{ConfigDigest: digest},
+			expected:    errors.New("the attestation does not record the configuration"),
+		},
+		{
+			name:        "configuration does not match its digest",
+			attestation: &Attestation{ConfigDigest: "sha256:other", Config: config},
+			expected:    fmt.Errorf("the digest of the recorded configuration is %s, but the run used sha256:other", digest),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// the attestation is read back from its artifact
+			raw, err := json.Marshal(tc.attestation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var attestation Attestation
+			if err := json.Unmarshal(raw, &attestation); err != nil {
+				t.Fatal(err)
+			}
+			testhelper.Diff(t, "error", attestation.VerifyConfig(), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Resolve(_ context.Context, ref string) (string, ocispec.Descriptor, error) {
+	resolved, ok := f[ref]
+	if !ok {
+		return "", ocispec.Descriptor{}, fmt.Errorf("%s: not found", ref)
+	}
+	return ref, ocispec.Descriptor{Digest: digest.Digest(resolved)}, nil
+}
+
+func TestResolveDigests(t *testing.T) {
+	attestation := &Attestation{UtilityImages: []Image{
+		{Name: "clonerefs", PullSpec: "quay.io/prow/clonerefs@sha256:abc", Digest: "sha256:abc"},
+		{Name: "entrypoint", PullSpec: "quay.io/prow/entrypoint:v1"},
+		{Name: "initupload", PullSpec: "quay.io/prow/initupload"},
+		{Name: "sidecar", PullSpec: "quay.io/prow/sidecar:gone"},
+	}}
+	resolver := fakeResolver{
+		"quay.io/prow/entrypoint:v1":        "sha256:def",
+		"quay.io/prow/initupload:latest":    "sha256:ghi",
+		"quay.io/prow/clonerefs@sha256:abc": "sha256:other",
+	}
+	err := attestation.ResolveDigests(context.Background(), resolver)
+	testhelper.Diff(t, "error", err, errors.New("failed to resolve the digest of the sidecar image: quay.io/prow/sidecar:gone: not found"), testhelper.EquateErrorMessage)
+	testhelper.Diff(t, "utility images", attestation.UtilityImages, []Image{
+		{Name: "clonerefs", PullSpec: "quay.io/prow/clonerefs@sha256:abc", Digest: "sha256:abc"},
+		{Name: "entrypoint", PullSpec: "quay.io/prow/entrypoint:v1", Digest: "sha256:def"},
+		{Name: "initupload", PullSpec: "quay.io/prow/initupload", Digest: "sha256:ghi"},
+		{Name: "sidecar", PullSpec: "quay.io/prow/sidecar:gone"},
+	})
+}
@@ -0,0 +1,210 @@
+// Package autodisable decides which periodic jobs fail so consistently that
+// running them only burns cloud budget, and changes their ci-operator
+// configuration to run them less often or not at all.
+package autodisable
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/configmutation"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+)
+
+// Action is the change made to the configuration of a failing periodic.
+type Action string
+
+const (
+	// Deprioritize makes the periodic run on the interval set by the policy.
+	Deprioritize Action = "deprioritize"
+	// Disable marks the test of the periodic as disabled, so that no job is
+	// generated for it until the mark is removed.
+	Disable Action = "disable"
+)
+
+// Policy determines when failing periodics are acted upon.
+type Policy struct {
+	// DeprioritizeAfter is the number of consecutive failures after which a
+	// periodic is deprioritized.
+	DeprioritizeAfter int
+	// DisableAfter is the number of consecutive failures after which a
+	// periodic that was already deprioritized is disabled. Failures keep
+	// counting after deprioritization, but accumulate slower, so the owners
+	// have time to react before their job is disabled.
+	DisableAfter int
+	// Interval is the interval deprioritized periodics run on.
+	Interval time.Duration
+	// OwnerGracePeriod is how long the owners of a deprioritized periodic,
+	// notified of the deprioritization, have to respond by fixing it or by
+	// removing the mark of the deprioritization. Owners who let it pass are
+	// unresponsive, and only then is the periodic disabled.
+	OwnerGracePeriod time.Duration
+}
+
+// Validate ensures the policy is coherent.
+func (p Policy) Validate() error {
+	var errs []error
+	if p.DeprioritizeAfter < 1 {
+		errs = append(errs, errors.New("the number of failures to deprioritize after must be positive"))
+	}
+	if p.DisableAfter <= p.DeprioritizeAfter {
+		errs = append(errs, errors.New("the number of failures to disable after must be larger than the one to deprioritize after"))
+	}
+	if p.Interval <= 0 {
+		errs = append(errs, errors.New("the interval of deprioritized periodics must be positive"))
+	}
+	if p.OwnerGracePeriod < 0 {
+		errs = append(errs, errors.New("the grace period of the owners must not be negative"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Result is the outcome of a single run of a periodic.
+type Result struct {
+	Passed   bool
+	Finished time.Time
+}
+
+// ConsecutiveFailures counts the failures since the last passing run, only
+// considering the runs that finished after the given time.
+func ConsecutiveFailures(results []Result, since time.Time) int {
+	var sorted []Result
+	for _, result := range results {
+		if result.Finished.After(since) {
+			sorted = append(sorted, result)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Finished.After(sorted[j].Finished)
+	})
+	for i, result := range sorted {
+		if result.Passed {
+			return i
+		}
+	}
+	return len(sorted)
+}
+
+// Periodic is a periodic job generated from a ci-operator configuration.
+type Periodic struct {
+	api.MetadataWithTest
+	// DeprioritizedAt is when the periodic was deprioritized, as recorded in
+	// the configuration of its test. It is zero for periodics that were not.
+	DeprioritizedAt time.Time
+}
+
+// Deprioritized determines whether the periodic was already deprioritized.
+func (p *Periodic) Deprioritized() bool {
+	return !p.DeprioritizedAt.IsZero()
+}
+
+// Periodics lists the periodic jobs generated from the configuration that
+// are run by Prow. Disabled periodics are not run, so they are not listed.
+func Periodics(configuration *api.ReleaseBuildConfiguration) []Periodic {
+	var periodics []Periodic
+	for _, test := range configuration.Tests {
+		if !test.IsPeriodic() || test.ReleaseController || test.Presubmit {
+			continue
+		}
+		if _, disabled := test.Annotations[api.DisabledAtAnnotation]; disabled {
+			continue
+		}
+		periodic := Periodic{MetadataWithTest: api.MetadataWithTest{Metadata: configuration.Metadata, Test: test.As}}
+		if value, ok := test.Annotations[api.DeprioritizedAtAnnotation]; ok {
+			if at, err := time.Parse(time.RFC3339, value); err == nil {
+				periodic.DeprioritizedAt = at
+			}
+		}
+		periodics = append(periodics, periodic)
+	}
+	return periodics
+}
+
+// JobName is the name of the generated job.
+func (p *Periodic) JobName() string {
+	return p.MetadataWithTest.JobName(jobconfig.PeriodicPrefix)
+}
+
+// Decision is the action to take on a failing periodic.
+type Decision struct {
+	Periodic
+	Action   Action
+	Failures int
+}
+
+// Decide determines what to do with a periodic that failed the given number
+// of times in a row, returning nil when it should be left alone. Failures of
+// deprioritized periodics must only be counted since their deprioritization.
+// They are only disabled once their owners are unresponsive.
+func (p Policy) Decide(periodic Periodic, failures int, now time.Time) *Decision {
+	var action Action
+	switch {
+	case periodic.Deprioritized() && failures >= p.DisableAfter:
+		if now.Sub(periodic.DeprioritizedAt) < p.OwnerGracePeriod {
+			return nil
+		}
+		action = Disable
+	case !periodic.Deprioritized() && failures >= p.DeprioritizeAfter:
+		action = Deprioritize
+	default:
+		return nil
+	}
+	return &Decision{Periodic: periodic, Action: action, Failures: failures}
+}
+
+// Mutation changes the configurations of the periodics according to the
+// decisions. Deprioritized and disabled tests record the given time of the
+// change, the schedule of disabled tests is kept so that they can be restored
+// by removing the mark.
+func Mutation(decisions []Decision, policy Policy, now time.Time) configmutation.Mutation {
+	byConfig := map[api.Metadata]map[string]Action{}
+	for _, decision := range decisions {
+		if byConfig[decision.Metadata] == nil {
+			byConfig[decision.Metadata] = map[string]Action{}
+		}
+		byConfig[decision.Metadata][decision.Test] = decision.Action
+	}
+	return configmutation.Mutation{
+		Match: func(_ *api.ReleaseBuildConfiguration, info *config.Info) bool {
+			_, ok := byConfig[info.Metadata]
+			return ok
+		},
+		Transform: func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+			actions := byConfig[info.Metadata]
+			var tests []api.TestStepConfiguration
+			for _, test := range configuration.Tests {
+				switch actions[test.As] {
+				case Disable:
+					test.Annotations = annotate(test.Annotations, api.DisabledAtAnnotation, now)
+				case Deprioritize:
+					interval := policy.Interval.String()
+					test.Cron, test.MinimumInterval, test.Interval = nil, nil, &interval
+					test.Annotations = annotate(test.Annotations, api.DeprioritizedAtAnnotation, now)
+				case "":
+				default:
+					return fmt.Errorf("unknown action %q for test %s", actions[test.As], test.As)
+				}
+				tests = append(tests, test)
+			}
+			configuration.Tests = tests
+			return nil
+		},
+	}
+}
+
+// annotate returns a copy of the annotations recording the time in the key.
+func annotate(annotations map[string]string, key string, at time.Time) map[string]string {
+	ret := map[string]string{key: at.UTC().Format(time.RFC3339)}
+	for k, v := range annotations {
+		if _, ok := ret[k]; !ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
@@ -0,0 +1,156 @@
+package autodisable
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+var policy = Policy{DeprioritizeAfter: 3, DisableAfter: 5, Interval: 168 * time.Hour, OwnerGracePeriod: 336 * time.Hour}
+
+func TestPolicyValidate(t *testing.T) {
+	err := Policy{DeprioritizeAfter: 0, DisableAfter: 0, OwnerGracePeriod: -time.Hour}.Validate()
+	expected := errors.New("[the number of failures to deprioritize after must be positive, the number of failures to disable after must be larger than the one to deprioritize after, the interval of deprioritized periodics must be positive, the grace period of the owners must not be negative]")
+	if diff := cmp.Diff(expected, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+	if err := policy.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	now := time.Now()
+	result := func(passed bool, ago time.Duration) Result {
+		return Result{Passed: passed, Finished: now.Add(-ago)}
+	}
+	for _, tc := range []struct {
+		name     string
+		results  []Result
+		since    time.Time
+		expected int
+	}{
+		{name: "no results"},
+		{name: "last run passed", results: []Result{result(false, 2*time.Hour), result(true, time.Hour)}},
+		{
+			name:     "failures since the last pass, in any order",
+			results:  []Result{result(false, time.Hour), result(true, 4*time.Hour), result(false, 3*time.Hour), result(false, 2*time.Hour), result(false, 5*time.Hour)},
+			expected: 3,
+		},
+		{name: "never passed", results: []Result{result(false, time.Hour), result(false, 2*time.Hour)}, expected: 2},
+		{
+			name:     "only failures since the given time",
+			results:  []Result{result(false, time.Hour), result(false, 2*time.Hour), result(false, 4*time.Hour), result(false, 5*time.Hour)},
+			since:    now.Add(-3 * time.Hour),
+			expected: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := ConsecutiveFailures(tc.results, tc.since); actual != tc.expected {
+				t.Errorf("expected %d failures, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPeriodicsAndDecide(t *testing.T) {
+	str := func(s string) *string { return &s }
+	configuration := &api.ReleaseBuildConfiguration{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"},
+		Tests: []api.TestStepConfiguration{
+			{As: "unit"},
+			{As: "daily", Cron: str("0 0 * * *")},
+			{As: "weekly", Interval: str("168h")},
+			{As: "deprioritized", Interval: str("168h"), Annotations: map[string]string{api.DeprioritizedAtAnnotation: "2024-06-01T00:00:00Z"}},
+			{As: "disabled", Interval: str("168h"), Annotations: map[string]string{api.DeprioritizedAtAnnotation: "2024-06-01T00:00:00Z", api.DisabledAtAnnotation: "2024-07-01T00:00:00Z"}},
+			{As: "release", ReleaseController: true},
+			{As: "presubmit", Interval: str("24h"), Presubmit: true},
+		},
+	}
+	periodics := Periodics(configuration)
+	metadata := func(test string) api.MetadataWithTest {
+		return api.MetadataWithTest{Metadata: configuration.Metadata, Test: test}
+	}
+	expected := []Periodic{
+		{MetadataWithTest: metadata("daily")},
+		{MetadataWithTest: metadata("weekly")},
+		{MetadataWithTest: metadata("deprioritized"), DeprioritizedAt: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if diff := cmp.Diff(expected, periodics); diff != "" {
+		t.Fatalf("unexpected periodics: %s", diff)
+	}
+	if name := periodics[0].JobName(); name != "periodic-ci-org-repo-main-daily" {
+		t.Errorf("unexpected job name %s", name)
+	}
+
+	deprioritizedAt := periodics[2].DeprioritizedAt
+	for _, tc := range []struct {
+		name     string
+		periodic Periodic
+		failures int
+		now      time.Time
+		expected *Decision
+	}{
+		{name: "failing, but not for long enough", periodic: periodics[0], failures: 2},
+		{name: "deprioritized", periodic: periodics[0], failures: 3, expected: &Decision{Periodic: periodics[0], Action: Deprioritize, Failures: 3}},
+		{name: "not deprioritized yet is never disabled", periodic: periodics[0], failures: 10, expected: &Decision{Periodic: periodics[0], Action: Deprioritize, Failures: 10}},
+		{name: "running on the interval of the policy is not deprioritized", periodic: periodics[1], failures: 5, expected: &Decision{Periodic: periodics[1], Action: Deprioritize, Failures: 5}},
+		{name: "already deprioritized", periodic: periodics[2], failures: 4, now: deprioritizedAt.Add(30 * 24 * time.Hour)},
+		{name: "owners still have time to respond", periodic: periodics[2], failures: 5, now: deprioritizedAt.Add(7 * 24 * time.Hour)},
+		{name: "disabled once the owners are unresponsive", periodic: periodics[2], failures: 5, now: deprioritizedAt.Add(30 * 24 * time.Hour), expected: &Decision{Periodic: periodics[2], Action: Disable, Failures: 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, policy.Decide(tc.periodic, tc.failures, tc.now)); diff != "" {
+				t.Errorf("unexpected decision: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMutation(t *testing.T) {
+	str := func(s string) *string { return &s }
+	input := func() *api.ReleaseBuildConfiguration {
+		return &api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"},
+			Tests: []api.TestStepConfiguration{
+				{As: "unit"},
+				{As: "daily", Cron: str("0 0 * * *"), Annotations: map[string]string{"owner": "team-a"}},
+				{As: "frequent", MinimumInterval: str("1h")},
+				{As: "weekly", Interval: str("168h")},
+			},
+		}
+	}
+	configuration := input()
+	periodics := Periodics(configuration)
+	mutation := Mutation([]Decision{
+		{Periodic: periodics[0], Action: Deprioritize},
+		{Periodic: periodics[2], Action: Disable},
+	}, policy, time.Date(2024, time.June, 1, 2, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+
+	if mutation.Match(configuration, &config.Info{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main"}}) {
+		t.Error("expected configuration without decisions not to match")
+	}
+	info := &config.Info{Metadata: configuration.Metadata}
+	if !mutation.Match(configuration, info) {
+		t.Fatal("expected configuration with decisions to match")
+	}
+	if err := mutation.Transform(configuration, info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := input()
+	expected.Tests = []api.TestStepConfiguration{
+		{As: "unit"},
+		{As: "daily", Interval: str("168h0m0s"), Annotations: map[string]string{"owner": "team-a", api.DeprioritizedAtAnnotation: "2024-06-01T00:00:00Z"}},
+		{As: "frequent", MinimumInterval: str("1h")},
+		{As: "weekly", Interval: str("168h"), Annotations: map[string]string{api.DisabledAtAnnotation: "2024-06-01T00:00:00Z"}},
+	}
+	if diff := cmp.Diff(expected, configuration); diff != "" {
+		t.Errorf("unexpected configuration: %s", diff)
+	}
+}
@@ -184,6 +184,9 @@ func bumpTests(config *cioperatorapi.ReleaseBuildConfiguration, major int) error
 		if err := bumpTestSteps(test.MultiStageTestConfiguration.Test, major); err != nil {
 			return err
 		}
+		if err := bumpTestSteps(test.MultiStageTestConfiguration.Gather, major); err != nil {
+			return err
+		}
 		if err := bumpTestSteps(test.MultiStageTestConfiguration.Post, major); err != nil {
 			return err
 		}
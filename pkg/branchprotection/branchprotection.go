@@ -0,0 +1,145 @@
+// Package branchprotection derives the branch protection that repositories
+// are expected to have from their ci-operator configuration and generated
+// jobs, and detects where the protection on GitHub drifted from it.
+package branchprotection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ManagedContextPrefix is the prefix of the contexts reported by Prow jobs.
+// Required contexts without it are reported by other systems and are never
+// considered drift.
+const ManagedContextPrefix = "ci/prow/"
+
+// Intent is the set of contexts expected to be required on a branch.
+type Intent struct {
+	api.Metadata `json:",inline"`
+	// RequiredContexts are the contexts of the presubmits that run on every
+	// pull request against the branch and must pass for it to merge.
+	RequiredContexts []string `json:"required_contexts"`
+}
+
+// IntentsFor computes the expected required contexts for every branch that
+// has a ci-operator configuration. Presubmits are only expected to be
+// required when they run on every pull request and block merging: optional,
+// non-reporting and conditionally triggered jobs are never required.
+func IntentsFor(configs []api.ReleaseBuildConfiguration, jobConfig *prowconfig.JobConfig) ([]Intent, error) {
+	presubmits := map[string][]prowconfig.Presubmit{}
+	for orgRepo, jobs := range jobConfig.PresubmitsStatic {
+		jobs = append([]prowconfig.Presubmit{}, jobs...)
+		if err := prowconfig.SetPresubmitRegexes(jobs); err != nil {
+			return nil, fmt.Errorf("failed to compile presubmits for %s: %w", orgRepo, err)
+		}
+		presubmits[orgRepo] = jobs
+	}
+
+	branches := map[api.Metadata]sets.Set[string]{}
+	for _, configuration := range configs {
+		// variants share the protection of their branch
+		branch := api.Metadata{Org: configuration.Metadata.Org, Repo: configuration.Metadata.Repo, Branch: configuration.Metadata.Branch}
+		if _, ok := branches[branch]; !ok {
+			branches[branch] = sets.New[string]()
+		}
+		for _, job := range presubmits[fmt.Sprintf("%s/%s", branch.Org, branch.Repo)] {
+			if job.CouldRun(branch.Branch) && job.ContextRequired() && job.AlwaysRun {
+				branches[branch].Insert(job.Context)
+			}
+		}
+	}
+
+	var intents []Intent
+	for branch, contexts := range branches {
+		intents = append(intents, Intent{Metadata: branch, RequiredContexts: sets.List(contexts)})
+	}
+	sort.Slice(intents, func(i, j int) bool {
+		return intents[i].Metadata.AsString() < intents[j].Metadata.AsString()
+	})
+	return intents, nil
+}
+
+// Drift is the difference between the expected and actual protection of a
+// branch.
+type Drift struct {
+	api.Metadata `json:",inline"`
+	// Unprotected is set when the branch is not protected at all.
+	Unprotected bool `json:"unprotected,omitempty"`
+	// Missing contexts are expected to be required, but are not.
+	Missing []string `json:"missing,omitempty"`
+	// Stale contexts are required, but are not reported by any job that
+	// runs on every pull request, blocking pull requests from merging.
+	Stale []string `json:"stale,omitempty"`
+	// Remediation is the payload for the GitHub API endpoint updating the
+	// required status checks of the branch that resolves the drift. It
+	// keeps all required contexts not managed by Prow.
+	Remediation *github.RequiredStatusChecks `json:"remediation,omitempty"`
+}
+
+// Client gets the branch protection from GitHub.
+type Client interface {
+	GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error)
+}
+
+// Detect compares the intents with the branch protection on GitHub, returning
+// the branches that drifted. Branches that could not be checked are skipped
+// and their errors returned.
+func Detect(client Client, intents []Intent) ([]Drift, error) {
+	var drifts []Drift
+	var errs []error
+	for _, intent := range intents {
+		protection, err := client.GetBranchProtection(intent.Org, intent.Repo, intent.Branch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get branch protection for %s: %w", intent.Metadata.AsString(), err))
+			continue
+		}
+		if drift := detect(intent, protection); drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	return drifts, utilerrors.NewAggregate(errs)
+}
+
+func detect(intent Intent, protection *github.BranchProtection) *Drift {
+	expected := sets.New(intent.RequiredContexts...)
+	if protection == nil {
+		if expected.Len() == 0 {
+			return nil
+		}
+		return &Drift{
+			Metadata:    intent.Metadata,
+			Unprotected: true,
+			Missing:     sets.List(expected),
+			Remediation: &github.RequiredStatusChecks{Contexts: sets.List(expected)},
+		}
+	}
+
+	checks := protection.RequiredStatusChecks
+	if checks == nil {
+		checks = &github.RequiredStatusChecks{}
+	}
+	actual, managed := sets.New(checks.Contexts...), sets.New[string]()
+	for context := range actual {
+		if strings.HasPrefix(context, ManagedContextPrefix) {
+			managed.Insert(context)
+		}
+	}
+	missing, stale := expected.Difference(actual), managed.Difference(expected)
+	if missing.Len() == 0 && stale.Len() == 0 {
+		return nil
+	}
+	return &Drift{
+		Metadata:    intent.Metadata,
+		Missing:     sets.List(missing),
+		Stale:       sets.List(stale),
+		Remediation: &github.RequiredStatusChecks{Strict: checks.Strict, Contexts: sets.List(actual.Difference(stale).Union(missing))},
+	}
+}
@@ -0,0 +1,121 @@
+package branchprotection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func presubmit(name string, branches []string, configure func(*prowconfig.Presubmit)) prowconfig.Presubmit {
+	job := prowconfig.Presubmit{
+		JobBase:   prowconfig.JobBase{Name: "pull-ci-org-repo-" + name},
+		AlwaysRun: true,
+		Brancher:  prowconfig.Brancher{Branches: branches},
+		Reporter:  prowconfig.Reporter{Context: "ci/prow/" + name},
+	}
+	if configure != nil {
+		configure(&job)
+	}
+	return job
+}
+
+func TestIntentsFor(t *testing.T) {
+	configs := []api.ReleaseBuildConfiguration{
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}},
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main", Variant: "variant"}},
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "release-4.16"}},
+		{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main"}},
+	}
+	jobConfig := &prowconfig.JobConfig{PresubmitsStatic: map[string][]prowconfig.Presubmit{
+		"org/repo": {
+			presubmit("unit", []string{"^main$"}, nil),
+			presubmit("variant-e2e", []string{"^main$"}, nil),
+			presubmit("release-unit", []string{"^release-4\\.16$"}, nil),
+			presubmit("optional", []string{"^main$"}, func(p *prowconfig.Presubmit) { p.Optional = true }),
+			presubmit("silent", []string{"^main$"}, func(p *prowconfig.Presubmit) { p.SkipReport = true }),
+			presubmit("conditional", []string{"^main$"}, func(p *prowconfig.Presubmit) {
+				p.AlwaysRun = false
+				p.RunIfChanged = "^docs/"
+			}),
+		},
+	}}
+	intents, err := IntentsFor(configs, jobConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Intent{
+		{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main"}, RequiredContexts: []string{}},
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, RequiredContexts: []string{"ci/prow/unit", "ci/prow/variant-e2e"}},
+		{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "release-4.16"}, RequiredContexts: []string{"ci/prow/release-unit"}},
+	}
+	if diff := cmp.Diff(expected, intents); diff != "" {
+		t.Errorf("unexpected intents: %s", diff)
+	}
+}
+
+type fakeClient map[string]*github.BranchProtection
+
+func (c fakeClient) GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error) {
+	protection, ok := c[org+"/"+repo+"@"+branch]
+	if !ok {
+		return nil, errors.New("injected error")
+	}
+	return protection, nil
+}
+
+func TestDetect(t *testing.T) {
+	metadata := func(repo string) api.Metadata {
+		return api.Metadata{Org: "org", Repo: repo, Branch: "main"}
+	}
+	protected := func(strict bool, contexts ...string) *github.BranchProtection {
+		return &github.BranchProtection{RequiredStatusChecks: &github.RequiredStatusChecks{Strict: strict, Contexts: contexts}}
+	}
+	client := fakeClient{
+		"org/in-sync@main":     protected(false, "ci/prow/unit", "DCO"),
+		"org/renamed@main":     protected(true, "ci/prow/unit", "ci/prow/e2e-old", "DCO"),
+		"org/unprotected@main": nil,
+		"org/unchecked@main":   nil,
+		"org/no-checks@main":   {},
+	}
+	intents := []Intent{
+		{Metadata: metadata("in-sync"), RequiredContexts: []string{"ci/prow/unit"}},
+		{Metadata: metadata("renamed"), RequiredContexts: []string{"ci/prow/e2e", "ci/prow/unit"}},
+		{Metadata: metadata("unprotected"), RequiredContexts: []string{"ci/prow/unit"}},
+		{Metadata: metadata("unchecked")},
+		{Metadata: metadata("no-checks"), RequiredContexts: []string{"ci/prow/unit"}},
+		{Metadata: metadata("missing"), RequiredContexts: []string{"ci/prow/unit"}},
+	}
+	drifts, err := Detect(client, intents)
+	if diff := cmp.Diff(errors.New("failed to get branch protection for org/missing@main: injected error"), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+	expected := []Drift{
+		{
+			Metadata:    metadata("renamed"),
+			Missing:     []string{"ci/prow/e2e"},
+			Stale:       []string{"ci/prow/e2e-old"},
+			Remediation: &github.RequiredStatusChecks{Strict: true, Contexts: []string{"DCO", "ci/prow/e2e", "ci/prow/unit"}},
+		},
+		{
+			Metadata:    metadata("unprotected"),
+			Unprotected: true,
+			Missing:     []string{"ci/prow/unit"},
+			Remediation: &github.RequiredStatusChecks{Contexts: []string{"ci/prow/unit"}},
+		},
+		{
+			Metadata:    metadata("no-checks"),
+			Missing:     []string{"ci/prow/unit"},
+			Stale:       []string{},
+			Remediation: &github.RequiredStatusChecks{Contexts: []string{"ci/prow/unit"}},
+		},
+	}
+	if diff := cmp.Diff(expected, drifts); diff != "" {
+		t.Errorf("unexpected drifts: %s", diff)
+	}
+}
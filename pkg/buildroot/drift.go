@@ -0,0 +1,102 @@
+package buildroot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DigestRecordsFile is the name of the artifact ci-operator writes the
+// resolved build root digests to
+const DigestRecordsFile = "build-root-digests.json"
+
+// DigestRecord records the image that a build root read from the repository
+// resolved to in a single job run
+type DigestRecord struct {
+	// Ref is the org.repo the build root belongs to, empty for the primary ref
+	Ref string `json:"ref,omitempty"`
+	// ImageStreamTag is the build root declared in .ci-operator.yaml
+	ImageStreamTag string `json:"image_stream_tag"`
+	// Digest is the image the tag resolved to, empty if it could not be resolved
+	Digest string `json:"digest,omitempty"`
+	// Timestamp is when the record was taken
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DriftPolicy determines how often a build root tag may change
+type DriftPolicy struct {
+	// MaxChanges is the maximum number of digest changes allowed in Window
+	MaxChanges int
+	// Window is the period over which digest changes are counted
+	Window time.Duration
+}
+
+// CheckDrift reports build root tags that changed more often than the policy
+// allows in the window before now, or that could not be resolved in the
+// latest recorded run
+func CheckDrift(records []DigestRecord, policy DriftPolicy, now time.Time) []string {
+	byTag := map[string][]DigestRecord{}
+	for _, record := range records {
+		byTag[record.ImageStreamTag] = append(byTag[record.ImageStreamTag], record)
+	}
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var alerts []string
+	for _, tag := range tags {
+		history := byTag[tag]
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+		if latest := history[len(history)-1]; latest.Digest == "" {
+			alerts = append(alerts, fmt.Sprintf("%s could not be resolved in the latest run at %s", tag, latest.Timestamp.Format(time.RFC3339)))
+		}
+		var changes int
+		var previous string
+		var digests []string
+		for _, record := range history {
+			if record.Digest == "" {
+				continue
+			}
+			if previous != "" && record.Digest != previous && now.Sub(record.Timestamp) <= policy.Window {
+				changes++
+				digests = append(digests, record.Digest)
+			}
+			previous = record.Digest
+		}
+		if changes > policy.MaxChanges {
+			alerts = append(alerts, fmt.Sprintf("%s changed %d times in the last %s, more than the allowed %d: %s", tag, changes, policy.Window, policy.MaxChanges, strings.Join(digests, ", ")))
+		}
+	}
+	return alerts
+}
+
+// LoadDigestRecords loads all the digest records artifacts found under dir
+func LoadDigestRecords(dir string) ([]DigestRecord, error) {
+	var records []DigestRecord
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != DigestRecordsFile {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var fileRecords []DigestRecord
+		if err := json.Unmarshal(raw, &fileRecords); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", path, err)
+		}
+		records = append(records, fileRecords...)
+		return nil
+	})
+	return records, err
+}
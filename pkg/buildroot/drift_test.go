@@ -0,0 +1,62 @@
+package buildroot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckDrift(t *testing.T) {
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	record := func(tag, digest string, age time.Duration) DigestRecord {
+		return DigestRecord{ImageStreamTag: tag, Digest: digest, Timestamp: now.Add(-age)}
+	}
+	policy := DriftPolicy{MaxChanges: 1, Window: 7 * day}
+	for _, tc := range []struct {
+		name     string
+		records  []DigestRecord
+		expected []string
+	}{
+		{
+			name: "stable tag",
+			records: []DigestRecord{
+				record("ocp/builder:a", "sha256:1", 3*day),
+				record("ocp/builder:a", "sha256:1", 2*day),
+			},
+		},
+		{
+			name: "changes within policy, old changes ignored",
+			records: []DigestRecord{
+				record("ocp/builder:a", "sha256:1", 20*day),
+				record("ocp/builder:a", "sha256:2", 15*day),
+				record("ocp/builder:a", "sha256:3", 2*day),
+			},
+		},
+		{
+			name: "too many changes",
+			records: []DigestRecord{
+				record("ocp/builder:a", "sha256:3", day),
+				record("ocp/builder:a", "sha256:1", 3*day),
+				record("ocp/builder:a", "sha256:2", 2*day),
+			},
+			expected: []string{"ocp/builder:a changed 2 times in the last 168h0m0s, more than the allowed 1: sha256:2, sha256:3"},
+		},
+		{
+			name: "tag disappeared",
+			records: []DigestRecord{
+				record("ocp/builder:a", "sha256:1", 2*day),
+				record("ocp/builder:a", "", day),
+				record("ocp/builder:b", "sha256:1", day),
+			},
+			expected: []string{"ocp/builder:a could not be resolved in the latest run at 2024-06-09T00:00:00Z"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, CheckDrift(tc.records, policy, now)); diff != "" {
+				t.Errorf("unexpected alerts: %s", diff)
+			}
+		})
+	}
+}
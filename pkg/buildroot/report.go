@@ -0,0 +1,233 @@
+// Package buildroot inspects repositories that read their build root from
+// the repository and reports mismatches between the declared build root and
+// the Go version the repository actually needs.
+package buildroot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// dockerfileGoVersionRegExp matches the Go version in builder images referenced
+// from Dockerfiles, e.g. `golang:1.22` or `rhel-9-golang-1.22-openshift-4.17`
+var dockerfileGoVersionRegExp = regexp.MustCompile(`golang[-:](\d+\.\d+)`)
+
+// DockerfileBuilder is a Go builder image referenced by a Dockerfile
+type DockerfileBuilder struct {
+	// Path is the path of the Dockerfile relative to the repository root
+	Path string `json:"path"`
+	// Image is the image referenced in the FROM instruction
+	Image string `json:"image"`
+	// GoVersion is the Go version of the builder image
+	GoVersion string `json:"go_version"`
+}
+
+// Report describes the build root declared by a repository and what the
+// repository needs to build
+type Report struct {
+	// BuildRoot is the build root declared in .ci-operator.yaml
+	BuildRoot api.ImageStreamTagReference `json:"build_root"`
+	// BuildRootGoVersion is the Go version of the declared build root
+	BuildRootGoVersion string `json:"build_root_go_version,omitempty"`
+	// GoModVersion is the Go version the go.mod file requires, taking
+	// the toolchain directive into account
+	GoModVersion string `json:"go_mod_version,omitempty"`
+	// DockerfileBuilders are the Go builder images used in Dockerfiles
+	DockerfileBuilders []DockerfileBuilder `json:"dockerfile_builders,omitempty"`
+	// Mismatches are human-readable descriptions of the problems found
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+// Inspect reads the .ci-operator.yaml, go.mod and Dockerfiles in the given
+// repository checkout and reports any mismatches between them
+func Inspect(dir string) (*Report, error) {
+	root, err := readBuildRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{BuildRoot: root, BuildRootGoVersion: api.GoVersionFor(root.Tag)}
+	if report.GoModVersion, err = goModVersion(dir); err != nil {
+		return nil, err
+	}
+	if report.DockerfileBuilders, err = dockerfileBuilders(dir); err != nil {
+		return nil, err
+	}
+
+	if report.BuildRootGoVersion == "" {
+		report.Mismatches = append(report.Mismatches, fmt.Sprintf("could not determine the Go version of build root %s", root.ISTagName()))
+		return report, nil
+	}
+	if report.GoModVersion != "" && compareVersions(report.BuildRootGoVersion, report.GoModVersion) < 0 {
+		report.Mismatches = append(report.Mismatches, fmt.Sprintf("build root %s provides Go %s, but go.mod requires Go %s", root.ISTagName(), report.BuildRootGoVersion, report.GoModVersion))
+	}
+	for _, builder := range report.DockerfileBuilders {
+		if builder.GoVersion != report.BuildRootGoVersion {
+			report.Mismatches = append(report.Mismatches, fmt.Sprintf("%s builds with Go %s (%s), but build root %s provides Go %s", builder.Path, builder.GoVersion, builder.Image, root.ISTagName(), report.BuildRootGoVersion))
+		}
+	}
+	return report, nil
+}
+
+// Bump updates the build root in .ci-operator.yaml to provide the Go version
+// required by go.mod. Only the tag is replaced, the comments and the layout
+// of the file are kept. It returns whether the file was changed.
+func Bump(dir string, report *Report) (bool, error) {
+	if report.GoModVersion == "" || report.BuildRootGoVersion == "" || compareVersions(report.BuildRootGoVersion, report.GoModVersion) >= 0 {
+		return false, nil
+	}
+	root := report.BuildRoot
+	root.Tag = strings.Replace(root.Tag, "golang-"+report.BuildRootGoVersion, "golang-"+report.GoModVersion, 1)
+	path := filepath.Join(dir, api.CIOperatorInrepoConfigFileName)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", api.CIOperatorInrepoConfigFileName, err)
+	}
+	updated, err := replaceBuildRootTag(raw, report.BuildRoot.Tag, root.Tag)
+	if err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", api.CIOperatorInrepoConfigFileName, err)
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", api.CIOperatorInrepoConfigFileName, err)
+	}
+	report.BuildRoot = root
+	report.BuildRootGoVersion = report.GoModVersion
+	return true, nil
+}
+
+// replaceBuildRootTag replaces the tag of the build root where it is written
+// in the raw file, leaving the rest of the file untouched.
+func replaceBuildRootTag(raw []byte, from, to string) ([]byte, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	tag := mappingValue(mappingValue(&document, "build_root_image"), "tag")
+	if tag == nil || tag.Kind != yamlv3.ScalarNode || tag.Value != from {
+		return nil, fmt.Errorf("could not find the tag %s of the build root", from)
+	}
+	lines := strings.SplitAfter(string(raw), "\n")
+	line := []rune(lines[tag.Line-1])
+	// the column counts characters from 1, the value may be quoted
+	head, tail := string(line[:tag.Column-1]), string(line[tag.Column-1:])
+	if !strings.Contains(tail, from) {
+		return nil, fmt.Errorf("could not find the tag %s of the build root on line %d", from, tag.Line)
+	}
+	lines[tag.Line-1] = head + strings.Replace(tail, from, to, 1)
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// mappingValue returns the value of the key in a mapping node, or nil.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node != nil && node.Kind == yamlv3.DocumentNode && len(node.Content) != 0 {
+		node = node.Content[0]
+	}
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func readBuildRoot(dir string) (api.ImageStreamTagReference, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, api.CIOperatorInrepoConfigFileName))
+	if err != nil {
+		return api.ImageStreamTagReference{}, fmt.Errorf("failed to read %s: %w", api.CIOperatorInrepoConfigFileName, err)
+	}
+	var config api.CIOperatorInrepoConfig
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return api.ImageStreamTagReference{}, fmt.Errorf("failed to unmarshal %s: %w", api.CIOperatorInrepoConfigFileName, err)
+	}
+	return config.BuildRootImage, nil
+}
+
+// goModVersion returns the major.minor Go version required by go.mod, or an
+// empty string if the repository has no go.mod
+func goModVersion(dir string) (string, error) {
+	path := filepath.Join(dir, "go.mod")
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	file, err := modfile.Parse(path, raw, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	var version string
+	if file.Go != nil {
+		version = majorMinor(file.Go.Version)
+	}
+	if file.Toolchain != nil {
+		if toolchain := majorMinor(strings.TrimPrefix(file.Toolchain.Name, "go")); compareVersions(toolchain, version) > 0 {
+			version = toolchain
+		}
+	}
+	return version, nil
+}
+
+func dockerfileBuilders(dir string) ([]DockerfileBuilder, error) {
+	var ret []DockerfileBuilder
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); name == "vendor" || name == ".git" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(d.Name(), "Dockerfile") {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+				continue
+			}
+			image := fields[1]
+			if strings.HasPrefix(image, "--platform") && len(fields) > 2 {
+				image = fields[2]
+			}
+			if match := dockerfileGoVersionRegExp.FindStringSubmatch(image); match != nil {
+				ret = append(ret, DockerfileBuilder{Path: relative, Image: image, GoVersion: match[1]})
+			}
+		}
+		return nil
+	})
+	return ret, err
+}
+
+func majorMinor(version string) string {
+	return strings.TrimPrefix(semver.MajorMinor("v"+version), "v")
+}
+
+// compareVersions compares two major.minor versions, treating an empty
+// version as lower than any other
+func compareVersions(a, b string) int {
+	return semver.Compare("v"+a, "v"+b)
+}
@@ -0,0 +1,168 @@
+package buildroot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const ciOperatorYAML = `build_root_image:
+  namespace: ocp
+  name: builder
+  tag: rhel-9-golang-1.21-openshift-4.16
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	root := api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.21-openshift-4.16"}
+	for _, tc := range []struct {
+		name        string
+		files       map[string]string
+		expected    *Report
+		expectedErr bool
+	}{
+		{
+			name:        "no .ci-operator.yaml",
+			files:       map[string]string{"go.mod": "module foo\n\ngo 1.21\n"},
+			expectedErr: true,
+		},
+		{
+			name: "consistent repository",
+			files: map[string]string{
+				".ci-operator.yaml": ciOperatorYAML,
+				"go.mod":            "module foo\n\ngo 1.21.0\n",
+				"Dockerfile":        "FROM registry.ci.openshift.org/ocp/builder:rhel-9-golang-1.21-openshift-4.16 AS builder\nFROM registry.ci.openshift.org/ocp/4.16:base-rhel9\n",
+			},
+			expected: &Report{
+				BuildRoot:          root,
+				BuildRootGoVersion: "1.21",
+				GoModVersion:       "1.21",
+				DockerfileBuilders: []DockerfileBuilder{{Path: "Dockerfile", Image: "registry.ci.openshift.org/ocp/builder:rhel-9-golang-1.21-openshift-4.16", GoVersion: "1.21"}},
+			},
+		},
+		{
+			name: "toolchain requires newer Go, Dockerfile in vendor ignored",
+			files: map[string]string{
+				".ci-operator.yaml":       ciOperatorYAML,
+				"go.mod":                  "module foo\n\ngo 1.21.0\n\ntoolchain go1.22.4\n",
+				"images/Dockerfile.rhel":  "FROM golang:1.22 AS builder\n",
+				"vendor/other/Dockerfile": "FROM golang:1.19\n",
+			},
+			expected: &Report{
+				BuildRoot:          root,
+				BuildRootGoVersion: "1.21",
+				GoModVersion:       "1.22",
+				DockerfileBuilders: []DockerfileBuilder{{Path: "images/Dockerfile.rhel", Image: "golang:1.22", GoVersion: "1.22"}},
+				Mismatches: []string{
+					"build root ocp/builder:rhel-9-golang-1.21-openshift-4.16 provides Go 1.21, but go.mod requires Go 1.22",
+					"images/Dockerfile.rhel builds with Go 1.22 (golang:1.22), but build root ocp/builder:rhel-9-golang-1.21-openshift-4.16 provides Go 1.21",
+				},
+			},
+		},
+		{
+			name: "build root without Go version",
+			files: map[string]string{
+				".ci-operator.yaml": "build_root_image:\n  namespace: ci\n  name: tools\n  tag: latest\n",
+			},
+			expected: &Report{
+				BuildRoot:  api.ImageStreamTagReference{Namespace: "ci", Name: "tools", Tag: "latest"},
+				Mismatches: []string{"could not determine the Go version of build root ci/tools:latest"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			actual, err := Inspect(dir)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected report: %s", diff)
+			}
+		})
+	}
+}
+
+func TestBump(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".ci-operator.yaml": ciOperatorYAML,
+		"go.mod":            "module foo\n\ngo 1.22.0\n",
+	})
+	report, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("failed to inspect: %v", err)
+	}
+	bumped, err := Bump(dir, report)
+	if err != nil {
+		t.Fatalf("failed to bump: %v", err)
+	}
+	if !bumped {
+		t.Fatal("expected the build root to be bumped")
+	}
+	report, err = Inspect(dir)
+	if err != nil {
+		t.Fatalf("failed to inspect: %v", err)
+	}
+	expected := api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.22-openshift-4.16"}
+	if diff := cmp.Diff(expected, report.BuildRoot); diff != "" {
+		t.Errorf("unexpected build root: %s", diff)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Errorf("expected no mismatches after bump, got: %v", report.Mismatches)
+	}
+	if bumped, err := Bump(dir, report); err != nil || bumped {
+		t.Errorf("expected no second bump, got bumped=%t, err=%v", bumped, err)
+	}
+}
+
+func TestBumpKeepsTheLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".ci-operator.yaml": `# the image used to build the repository
+build_root_image:
+  name: builder
+  namespace: ocp
+  # bumped along with go.mod
+  tag: "rhel-9-golang-1.21-openshift-4.16" # do not use latest
+`,
+		"go.mod": "module foo\n\ngo 1.22.0\n",
+	})
+	report, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("failed to inspect: %v", err)
+	}
+	if bumped, err := Bump(dir, report); err != nil || !bumped {
+		t.Fatalf("expected the build root to be bumped, got bumped=%t, err=%v", bumped, err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, ".ci-operator.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	expected := `# the image used to build the repository
+build_root_image:
+  name: builder
+  namespace: ocp
+  # bumped along with go.mod
+  tag: "rhel-9-golang-1.22-openshift-4.16" # do not use latest
+`
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("unexpected file: %s", diff)
+	}
+}
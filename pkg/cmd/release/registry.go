@@ -207,6 +207,10 @@ func printTreeWorkflow(o *options, name string, w test, level uint) {
 	printTreeSteps(o, w.Pre, level+1)
 	fmt.Println("test:")
 	printTreeSteps(o, w.Test, level+1)
+	if len(w.Gather) != 0 {
+		fmt.Println("gather:")
+		printTreeSteps(o, w.Gather, level+1)
+	}
 	fmt.Println("post:")
 	printTreeSteps(o, w.Post, level+1)
 }
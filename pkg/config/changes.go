@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// GetChangedFiles returns the paths of all files added, modified or deleted
+// since revision `base` in the repository at `root`. Paths are relative to
+// `root`.
+func GetChangedFiles(root, base string) ([]string, error) {
+	diff, err := git(root, "diff", "--name-only", "--no-renames", base, "HEAD")
+	if err != nil || strings.TrimSpace(diff) == "" {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(diff), "\n"), nil
+}
+
+// GetNewRepositoryConfigs returns the ci-operator configurations added since
+// revision `base` in the repository at `root` for repositories which had no
+// configuration before. Paths are relative to the configuration directory,
+// like the ones returned by Metadata.RelativePath.
+func GetNewRepositoryConfigs(root, base string) ([]string, error) {
+	diff, err := git(root, "diff", "--name-only", "--no-renames", "--diff-filter=A", base, "HEAD", "--", CiopConfigInRepoPath)
+	if err != nil || strings.TrimSpace(diff) == "" {
+		return nil, err
+	}
+	existed := map[string]bool{}
+	var ret []string
+	for _, path := range strings.Split(strings.TrimSpace(diff), "\n") {
+		if filepath.Ext(path) != ".yaml" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		old, ok := existed[dir]
+		if !ok {
+			_, err := git(root, "cat-file", "-e", fmt.Sprintf("%s:%s", base, dir))
+			old = err == nil
+			existed[dir] = old
+		}
+		if !old {
+			ret = append(ret, strings.TrimPrefix(path, CiopConfigInRepoPath+"/"))
+		}
+	}
+	return ret, nil
+}
+
+// ValidationScope is the set of configurations affected by a change to the
+// release repo, which need to be validated again.
+type ValidationScope struct {
+	// Full is set when a change cannot be mapped to a subset of the
+	// configurations, so all of them need to be validated.
+	Full bool
+	// Reason explains why all the configurations need to be validated.
+	Reason string
+	// Configs holds the relative paths of the affected configurations.
+	Configs sets.Set[string]
+}
+
+// Includes determines whether a configuration needs to be validated.
+func (s *ValidationScope) Includes(metadata *api.Metadata) bool {
+	return s.Full || s.Configs.Has(metadata.RelativePath())
+}
+
+func fullScope(format string, args ...interface{}) *ValidationScope {
+	return &ValidationScope{Full: true, Reason: fmt.Sprintf(format, args...)}
+}
+
+// registryAuxiliaryFile determines whether a file in the step registry has
+// no effect on the resolved configurations.
+func registryAuxiliaryFile(path string) bool {
+	base := filepath.Base(path)
+	return base == "OWNERS" || filepath.Ext(base) == ".md" || strings.HasSuffix(base, ".metadata.json")
+}
+
+// GetValidationScope maps the files changed in the release repo to the
+// configurations which need to be validated: the changed configurations and
+// the ones with tests using the changed registry components, directly or
+// through the components including them. Changes to any of the `global` files
+// (e.g. the cluster profile configuration), to registry snapshots or overlays
+// and to registry files which do not map to a component of the current graph
+// (e.g. a removed step) affect all configurations. Other files are ignored.
+func GetValidationScope(changed []string, global sets.Set[string], graph registry.NodeByName, configs []api.ReleaseBuildConfiguration) *ValidationScope {
+	scope := &ValidationScope{Configs: sets.New[string]()}
+	var nodes []registry.Node
+	for _, path := range changed {
+		path = filepath.Clean(path)
+		switch {
+		case global.Has(path):
+			return fullScope("global configuration changed: %s", path)
+		case strings.HasPrefix(path, RegistrySnapshotsPath+"/") || strings.HasPrefix(path, RegistryOverlaysPath+"/"):
+			return fullScope("step registry snapshot or overlay changed: %s", path)
+		case strings.HasPrefix(path, RegistryPath+"/"):
+			if registryAuxiliaryFile(path) {
+				continue
+			}
+			node, err := loadRegistryStep(filepath.Base(path), graph)
+			if err != nil {
+				return fullScope("could not map step registry change to a component: %v", err)
+			}
+			if node == nil {
+				return fullScope("cluster profile configuration changed: %s", path)
+			}
+			nodes = append(nodes, node)
+		case strings.HasPrefix(path, CiopConfigInRepoPath+"/"):
+			if filepath.Ext(path) == ".yaml" {
+				scope.Configs.Insert(strings.TrimPrefix(path, CiopConfigInRepoPath+"/"))
+			}
+		}
+	}
+	for _, consumer := range registry.NewConsumerIndex(graph, configs).ConsumersOf(nodes) {
+		scope.Configs.Insert(consumer.Metadata.RelativePath())
+	}
+	return scope
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestGetChangedFiles(t *testing.T) {
+	files := []string{
+		"ci-operator/config/org/repo/org-repo-master.yaml",
+		"ci-operator/config/org/repo/org-repo-release-4.1.yaml",
+		"core-services/OWNERS",
+	}
+	cmd := `
+> ci-operator/config/org/repo/org-repo-master.yaml
+git rm --quiet core-services/OWNERS
+`
+	compareChanges(t, ".", files, cmd, GetChangedFiles, []string{
+		"ci-operator/config/org/repo/org-repo-master.yaml",
+		"core-services/OWNERS",
+	})
+}
+
+func TestGetNewRepositoryConfigs(t *testing.T) {
+	files := []string{
+		"ci-operator/config/org/repo/org-repo-master.yaml",
+		"core-services/OWNERS",
+	}
+	cmd := `
+mkdir -p ci-operator/config/org/new ci-operator/config/other/repo
+> ci-operator/config/org/repo/org-repo-release-4.1.yaml
+> ci-operator/config/org/new/org-new-master.yaml
+> ci-operator/config/org/new/org-new-release-4.1.yaml
+> ci-operator/config/other/repo/OWNERS
+git add .
+`
+	compareChanges(t, ".", files, cmd, GetNewRepositoryConfigs, []string{
+		"org/new/org-new-master.yaml",
+		"org/new/org-new-release-4.1.yaml",
+	})
+}
+
+func TestGetValidationScope(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	graph, err := registry.NewGraph(
+		registry.ReferenceByName{
+			"install": {As: "install"},
+			"e2e":     {As: "e2e"},
+		},
+		registry.ChainByName{
+			"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}}},
+		},
+		registry.WorkflowByName{
+			"ipi": {Pre: []api.TestStep{{Chain: strPtr("setup")}}},
+		},
+		registry.ObserverByName{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := []api.ReleaseBuildConfiguration{
+		{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			Tests: []api.TestStepConfiguration{{
+				As:                          "e2e",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi")},
+			}},
+		},
+		{
+			Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "master"},
+			Tests: []api.TestStepConfiguration{{
+				As:                          "e2e",
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: strPtr("e2e")}}},
+			}},
+		},
+	}
+	global := sets.New[string]("core-services/ci-secret-bootstrap/_config.yaml")
+	for _, tc := range []struct {
+		name     string
+		changed  []string
+		expected *ValidationScope
+	}{
+		{
+			name:     "no relevant changes",
+			changed:  []string{"ci-operator/jobs/org/repo/org-repo-master-presubmits.yaml", "ci-operator/step-registry/ipi/OWNERS"},
+			expected: &ValidationScope{Configs: sets.New[string]()},
+		},
+		{
+			name:     "changed configuration",
+			changed:  []string{"ci-operator/config/org/other/org-other-master.yaml", "ci-operator/config/org/other/OWNERS"},
+			expected: &ValidationScope{Configs: sets.New[string]("org/other/org-other-master.yaml")},
+		},
+		{
+			name:     "reference used through a workflow",
+			changed:  []string{"ci-operator/step-registry/install/install-commands.sh"},
+			expected: &ValidationScope{Configs: sets.New[string]("org/repo/org-repo-master.yaml")},
+		},
+		{
+			name: "configuration and components",
+			changed: []string{
+				"ci-operator/config/org/new/org-new-master.yaml",
+				"ci-operator/step-registry/setup/setup-chain.yaml",
+				"ci-operator/step-registry/e2e/e2e-ref.yaml",
+			},
+			expected: &ValidationScope{Configs: sets.New[string](
+				"org/new/org-new-master.yaml",
+				"org/other/org-other-master.yaml",
+				"org/repo/org-repo-master.yaml",
+			)},
+		},
+		{
+			name:     "removed component",
+			changed:  []string{"ci-operator/step-registry/upgrade/upgrade-ref.yaml"},
+			expected: &ValidationScope{Full: true, Reason: "could not map step registry change to a component: could not find registry component in registry graph: ref/upgrade"},
+		},
+		{
+			name:     "cluster profiles",
+			changed:  []string{"ci-operator/step-registry/cluster-profiles/cluster-profiles-config.yaml"},
+			expected: &ValidationScope{Full: true, Reason: "cluster profile configuration changed: ci-operator/step-registry/cluster-profiles/cluster-profiles-config.yaml"},
+		},
+		{
+			name:     "global configuration",
+			changed:  []string{"ci-operator/config/org/repo/org-repo-master.yaml", "core-services/ci-secret-bootstrap/_config.yaml"},
+			expected: &ValidationScope{Full: true, Reason: "global configuration changed: core-services/ci-secret-bootstrap/_config.yaml"},
+		},
+		{
+			name:     "registry overlay",
+			changed:  []string{"ci-operator/step-registry-overlays/release-4.1/e2e/e2e-ref.yaml"},
+			expected: &ValidationScope{Full: true, Reason: "step registry snapshot or overlay changed: ci-operator/step-registry-overlays/release-4.1/e2e/e2e-ref.yaml"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, GetValidationScope(tc.changed, global, graph, configs)); diff != "" {
+				t.Errorf("unexpected scope: %s", diff)
+			}
+		})
+	}
+}
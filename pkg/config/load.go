@@ -17,6 +17,7 @@ import (
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 
 	cioperatorapi "github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 	"github.com/openshift/ci-tools/pkg/validation"
@@ -134,12 +135,15 @@ func readCiOperatorConfig(configFilePath string, info Info) (*cioperatorapi.Rele
 		return nil, fmt.Errorf("failed to read ci-operator config (%w)", err)
 	}
 
+	if err := load.RejectYAMLAliases(data); err != nil {
+		return nil, fmt.Errorf("failed to load ci-operator config (%w)", err)
+	}
 	var configSpec cioperatorapi.ReleaseBuildConfiguration
 	if err := yaml.Unmarshal(data, &configSpec); err != nil {
 		return nil, fmt.Errorf("failed to load ci-operator config (%w)", err)
 	}
 
-	if err := validation.IsValidConfiguration(&configSpec, info.Org, info.Repo); err != nil {
+	if _, err := validation.IsValidConfiguration(&configSpec, info.Org, info.Repo); err != nil {
 		return nil, fmt.Errorf("invalid ci-operator config: %w", err)
 	}
 
@@ -44,6 +44,10 @@ const (
 	StagingNamespace = "ci-stg"
 	// RegistryPath is the path to the multistage step registry
 	RegistryPath = "ci-operator/step-registry"
+	// RegistrySnapshotsPath is the path to the stored snapshots of the step registry
+	RegistrySnapshotsPath = "ci-operator/step-registry-snapshots"
+	// RegistryOverlaysPath is the path to the branch-scoped overlays of the step registry
+	RegistryOverlaysPath = "ci-operator/step-registry-overlays"
 )
 
 // ConfigMapName returns the name of the ConfigMap to which config-updater would
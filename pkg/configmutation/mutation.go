@@ -0,0 +1,125 @@
+// Package configmutation applies bulk changes to all ci-operator
+// configurations, producing deterministic diffs that can be reviewed before
+// anything is written or proposed upstream.
+package configmutation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/validation"
+)
+
+// Mutation is a change to the configurations it matches.
+type Mutation struct {
+	// Match selects the configurations to transform. All configurations
+	// are transformed when unset.
+	Match func(*api.ReleaseBuildConfiguration, *config.Info) bool
+	// Transform changes a matching configuration in place.
+	Transform func(*api.ReleaseBuildConfiguration, *config.Info) error
+	// Validate is run on transformed configurations in addition to the
+	// regular ci-operator configuration validation.
+	Validate func(*api.ReleaseBuildConfiguration, *config.Info) error
+}
+
+// Change is the result of mutating a single configuration.
+type Change struct {
+	Info config.Info
+	// Before and After are the serialized configuration before and
+	// after the mutation.
+	Before []byte
+	After  []byte
+}
+
+// Diff returns the unified diff of the change, using the path of the
+// configuration relative to the configuration directory.
+func (c *Change) Diff() (string, error) {
+	path := c.Info.RelativePath()
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(c.Before)),
+		B:        difflib.SplitLines(string(c.After)),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+}
+
+// CommitTo writes the mutated configuration into the configuration directory.
+func (c *Change) CommitTo(dir string) error {
+	return os.WriteFile(filepath.Join(dir, c.Info.RelativePath()), c.After, 0664)
+}
+
+// Apply runs the mutation over all configurations in the directory, without
+// writing anything. Changes are sorted by configuration path and only contain
+// configurations that were actually modified. Configurations that fail to
+// transform or validate are left out of the changes and their errors returned.
+func Apply(configDir string, mutation Mutation) ([]Change, error) {
+	var changes []Change
+	var errs []error
+	if err := config.OperateOnCIOperatorConfigDir(configDir, func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+		if mutation.Match != nil && !mutation.Match(configuration, info) {
+			return nil
+		}
+		change, err := apply(configuration, info, mutation)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", info.RelativePath(), err))
+			return nil
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load configurations: %w", err)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Info.RelativePath() < changes[j].Info.RelativePath()
+	})
+	return changes, utilerrors.NewAggregate(errs)
+}
+
+func apply(configuration *api.ReleaseBuildConfiguration, info *config.Info, mutation Mutation) (*Change, error) {
+	before, err := yaml.Marshal(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	if err := mutation.Transform(configuration, info); err != nil {
+		return nil, fmt.Errorf("failed to transform configuration: %w", err)
+	}
+	after, err := yaml.Marshal(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transformed configuration: %w", err)
+	}
+	if bytes.Equal(before, after) {
+		return nil, nil
+	}
+	if _, err := validation.IsValidConfiguration(configuration, info.Org, info.Repo); err != nil {
+		return nil, fmt.Errorf("transformed configuration is invalid: %w", err)
+	}
+	if mutation.Validate != nil {
+		if err := mutation.Validate(configuration, info); err != nil {
+			return nil, fmt.Errorf("transformed configuration is invalid: %w", err)
+		}
+	}
+	return &Change{Info: *info, Before: before, After: after}, nil
+}
+
+// ByOrg groups the changes by the organization of their configurations,
+// keeping them sorted by path within each organization.
+func ByOrg(changes []Change) map[string][]Change {
+	byOrg := map[string][]Change{}
+	for _, change := range changes {
+		byOrg[change.Info.Org] = append(byOrg[change.Info.Org], change)
+	}
+	return byOrg
+}
@@ -0,0 +1,123 @@
+package configmutation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func writeConfigs(t *testing.T, dir string, configs ...api.ReleaseBuildConfiguration) {
+	t.Helper()
+	for _, c := range configs {
+		c.Resources = api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}}
+		c.Tests = append(c.Tests, api.TestStepConfiguration{As: "unit", Commands: "make test", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}})
+		raw, err := yaml.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, c.Metadata.RelativePath())
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func buildRoot(tag string) *api.BuildRootImageConfiguration {
+	return &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "openshift", Name: "release", Tag: tag}}
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigs(t, dir,
+		api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}, InputConfiguration: api.InputConfiguration{BuildRootImage: buildRoot("golang-1.21")}},
+		api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "main"}, InputConfiguration: api.InputConfiguration{BuildRootImage: buildRoot("golang-1.21")}},
+		api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "current", Branch: "main"}, InputConfiguration: api.InputConfiguration{BuildRootImage: buildRoot("golang-1.22")}},
+		api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "another", Repo: "repo", Branch: "main"}, InputConfiguration: api.InputConfiguration{BuildRootImage: buildRoot("golang-1.21")}},
+		api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "another", Repo: "repo", Branch: "release-4.16"}, InputConfiguration: api.InputConfiguration{BuildRootImage: buildRoot("golang-1.21")}},
+	)
+
+	changes, err := Apply(dir, Mutation{
+		Match: func(_ *api.ReleaseBuildConfiguration, info *config.Info) bool {
+			return info.Branch == "main"
+		},
+		Transform: func(c *api.ReleaseBuildConfiguration, _ *config.Info) error {
+			c.BuildRootImage.ImageStreamTagReference.Tag = "golang-1.22"
+			return nil
+		},
+		Validate: func(_ *api.ReleaseBuildConfiguration, info *config.Info) error {
+			if info.Repo == "other" {
+				return errors.New("not ready for golang-1.22")
+			}
+			return nil
+		},
+	})
+	expectedErr := errors.New("org/other/org-other-main.yaml: transformed configuration is invalid: not ready for golang-1.22")
+	if diff := cmp.Diff(expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Info.RelativePath())
+	}
+	if diff := cmp.Diff([]string{"another/repo/another-repo-main.yaml", "org/repo/org-repo-main.yaml"}, paths); diff != "" {
+		t.Errorf("unexpected changes: %s", diff)
+	}
+	byOrg := ByOrg(changes)
+	if len(byOrg) != 2 || len(byOrg["org"]) != 1 || len(byOrg["another"]) != 1 {
+		t.Errorf("unexpected grouping by org: %v", byOrg)
+	}
+
+	diff, err := changes[1].Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `--- a/org/repo/org-repo-main.yaml
++++ b/org/repo/org-repo-main.yaml
+@@ -2,7 +2,7 @@
+   image_stream_tag:
+     name: release
+     namespace: openshift
+-    tag: golang-1.21
++    tag: golang-1.22
+ resources:
+   '*':
+     requests:
+`
+	if d := cmp.Diff(expected, diff); d != "" {
+		t.Errorf("unexpected diff: %s", d)
+	}
+
+	if err := changes[1].CommitTo(dir); err != nil {
+		t.Fatal(err)
+	}
+	unchanged, err := Apply(dir, Mutation{
+		Transform: func(c *api.ReleaseBuildConfiguration, _ *config.Info) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unchanged) != 0 {
+		t.Errorf("expected no changes from a no-op mutation, got %d", len(unchanged))
+	}
+	written, err := config.LoadByFilename(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag := written["org-repo-main.yaml"].BuildRootImage.ImageStreamTagReference.Tag; tag != "golang-1.22" {
+		t.Errorf("expected the committed change to be written, got build root tag %s", tag)
+	}
+}
@@ -0,0 +1,74 @@
+package configmutation
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/github/prcreation"
+)
+
+// CommitAll writes all changes into the configuration directory.
+func CommitAll(dir string, changes []Change) error {
+	for _, change := range changes {
+		if err := change.CommitTo(dir); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Info.RelativePath(), err)
+		}
+	}
+	return nil
+}
+
+// Proposal configures how changes are proposed in pull requests.
+type Proposal struct {
+	// ReleaseRepo is the git checkout containing ConfigDir that pull
+	// requests are created from. Both paths must be absolute, as creating
+	// pull requests changes the working directory.
+	ReleaseRepo string
+	ConfigDir   string
+	// Org, Repo and Branch identify the branch pull requests are opened
+	// against.
+	Org    string
+	Repo   string
+	Branch string
+	// Title and Body render the pull request for the changes of an
+	// organization.
+	Title func(org string) string
+	Body  func(org string, changes []Change) string
+}
+
+// CreatePullRequests opens a pull request per organization, resetting the
+// release repository to its original state after each of them.
+func (p *Proposal) CreatePullRequests(o *prcreation.PRCreationOptions, changes []Change) error {
+	base, err := exec.Command("git", "-C", p.ReleaseRepo, "rev-parse", "HEAD").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to determine the current commit: %w\noutput: %s", err, string(base))
+	}
+	byOrg := ByOrg(changes)
+	var orgs []string
+	for org := range byOrg {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	var errs []error
+	for _, org := range orgs {
+		logger := logrus.WithField("org", org)
+		if err := CommitAll(p.ConfigDir, byOrg[org]); err != nil {
+			return err
+		}
+		if err := o.UpsertPR(p.ReleaseRepo, p.Org, p.Repo, p.Branch, p.Title(org), prcreation.PrBody(p.Body(org, byOrg[org]))); err != nil {
+			logger.WithError(err).Error("Failed to create pull request.")
+			errs = append(errs, fmt.Errorf("%s: %w", org, err))
+		} else {
+			logger.Infof("Proposed %d configurations.", len(byOrg[org]))
+		}
+		if out, err := exec.Command("git", "-C", p.ReleaseRepo, "reset", "--hard", strings.TrimSpace(string(base))).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to reset the release repository: %w\noutput: %s", err, string(out))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,284 @@
+package configmutation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/configquery"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// Spec declares a mutation in a form that can be reviewed and stored
+// alongside the change it produces.
+type Spec struct {
+	// Name identifies the mutation, and is used for the titles and branches
+	// of the pull requests proposing it.
+	Name string `json:"name"`
+	// Match selects the configurations to mutate.
+	Match Predicate `json:"match,omitempty"`
+	// Transforms are applied in order to every matching configuration.
+	Transforms []Transform `json:"transforms"`
+}
+
+// Predicate matches configurations. All the fields that are set must match.
+type Predicate struct {
+	// Orgs limits the mutation to configurations in these organizations.
+	Orgs []string `json:"orgs,omitempty"`
+	// Repos limits the mutation to configurations of these repositories,
+	// in the org/repo form.
+	Repos []string `json:"repos,omitempty"`
+	// Branches is a regular expression the branch must match.
+	Branches string `json:"branches,omitempty"`
+	// BaseImage limits the mutation to configurations using the image,
+	// in the namespace/name:tag form.
+	BaseImage string `json:"base_image,omitempty"`
+	// Workflow limits the mutation to configurations with tests using the
+	// workflow, regardless of the snapshot it is pinned to.
+	Workflow string `json:"workflow,omitempty"`
+	// ClusterProfile limits the mutation to configurations with tests
+	// running on the cluster profile.
+	ClusterProfile string `json:"cluster_profile,omitempty"`
+}
+
+// Transform is a single change to a configuration. Exactly one of the
+// fields must be set.
+type Transform struct {
+	// ReplaceBaseImage replaces an image, in the namespace/name:tag form,
+	// wherever the configuration imports it.
+	ReplaceBaseImage *Replacement `json:"replace_base_image,omitempty"`
+	// ReplaceWorkflow replaces a workflow in multi-stage tests. Workflows
+	// pinned to a registry snapshot are left alone.
+	ReplaceWorkflow *Replacement `json:"replace_workflow,omitempty"`
+	// ReplaceClusterProfile replaces the cluster profile of multi-stage tests.
+	ReplaceClusterProfile *Replacement `json:"replace_cluster_profile,omitempty"`
+}
+
+// Replacement replaces From with To.
+type Replacement struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// LoadSpec reads a mutation specification from the file.
+func LoadSpec(path string) (*Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mutation: %w", err)
+	}
+	var spec Spec
+	if err := yaml.UnmarshalStrict(raw, &spec); err != nil {
+		return nil, fmt.Errorf("failed to load mutation: %w", err)
+	}
+	return &spec, nil
+}
+
+// Mutation validates the specification and builds the mutation it declares.
+func (s *Spec) Mutation() (Mutation, error) {
+	var errs []error
+	if !validName.MatchString(s.Name) {
+		errs = append(errs, fmt.Errorf("name: must only contain alphanumeric characters and dashes, got %q", s.Name))
+	}
+	match, err := s.Match.matcher()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("match: %w", err))
+	}
+	if len(s.Transforms) == 0 {
+		errs = append(errs, errors.New("transforms: at least one transform is required"))
+	}
+	var transforms []func(*api.ReleaseBuildConfiguration)
+	for i, t := range s.Transforms {
+		transform, err := t.transformer()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transforms[%d]: %w", i, err))
+			continue
+		}
+		transforms = append(transforms, transform)
+	}
+	if len(errs) != 0 {
+		return Mutation{}, utilerrors.NewAggregate(errs)
+	}
+	return Mutation{
+		Match: match,
+		Transform: func(configuration *api.ReleaseBuildConfiguration, _ *config.Info) error {
+			for _, transform := range transforms {
+				transform(configuration)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func (p *Predicate) matcher() (func(*api.ReleaseBuildConfiguration, *config.Info) bool, error) {
+	orgs, repos := sets.New(p.Orgs...), sets.New(p.Repos...)
+	var branches *regexp.Regexp
+	if p.Branches != "" {
+		var err error
+		if branches, err = regexp.Compile(p.Branches); err != nil {
+			return nil, fmt.Errorf("branches: invalid regular expression: %w", err)
+		}
+	}
+	if p.BaseImage != "" {
+		if _, err := parseISTag(p.BaseImage); err != nil {
+			return nil, fmt.Errorf("base_image: %w", err)
+		}
+	}
+	return func(configuration *api.ReleaseBuildConfiguration, info *config.Info) bool {
+		switch {
+		case orgs.Len() != 0 && !orgs.Has(info.Org),
+			repos.Len() != 0 && !repos.Has(info.Org+"/"+info.Repo),
+			branches != nil && !branches.MatchString(info.Branch):
+			return false
+		}
+		for kind, item := range map[configquery.Kind]string{
+			configquery.BaseImage:      p.BaseImage,
+			configquery.Workflow:       p.Workflow,
+			configquery.ClusterProfile: p.ClusterProfile,
+		} {
+			if item != "" && !configquery.Uses(*configuration, kind, item) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (t *Transform) transformer() (func(*api.ReleaseBuildConfiguration), error) {
+	var set []string
+	var transform func(*api.ReleaseBuildConfiguration)
+	var err error
+	if r := t.ReplaceBaseImage; r != nil {
+		set = append(set, "replace_base_image")
+		transform, err = r.baseImage()
+	}
+	if r := t.ReplaceWorkflow; r != nil {
+		set = append(set, "replace_workflow")
+		transform, err = r.workflow()
+	}
+	if r := t.ReplaceClusterProfile; r != nil {
+		set = append(set, "replace_cluster_profile")
+		transform, err = r.clusterProfile()
+	}
+	if len(set) != 1 {
+		return nil, fmt.Errorf("exactly one transform must be set, got %d", len(set))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", set[0], err)
+	}
+	return transform, nil
+}
+
+func (r *Replacement) validate() error {
+	if r.From == "" || r.To == "" {
+		return errors.New("both from and to are required")
+	}
+	return nil
+}
+
+// parseISTag parses an image in the namespace/name:tag form.
+func parseISTag(s string) (api.ImageStreamTagReference, error) {
+	namespace, nameAndTag, ok := strings.Cut(s, "/")
+	if !ok || namespace == "" {
+		return api.ImageStreamTagReference{}, fmt.Errorf("invalid image %q, expected namespace/name:tag", s)
+	}
+	ref, err := util.ParseImageStreamTagReference(nameAndTag)
+	if err != nil || ref.Name == "" || ref.Tag == "" {
+		return api.ImageStreamTagReference{}, fmt.Errorf("invalid image %q, expected namespace/name:tag", s)
+	}
+	ref.Namespace = namespace
+	return ref, nil
+}
+
+func (r *Replacement) baseImage() (func(*api.ReleaseBuildConfiguration), error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := parseISTag(r.From); err != nil {
+		return nil, err
+	}
+	to, err := parseISTag(r.To)
+	if err != nil {
+		return nil, err
+	}
+	replace := func(ref *api.ImageStreamTagReference) {
+		if ref != nil && ref.ISTagName() == r.From {
+			ref.Namespace, ref.Name, ref.Tag = to.Namespace, to.Name, to.Tag
+		}
+	}
+	replaceAll := func(images map[string]api.ImageStreamTagReference) {
+		for name, image := range images {
+			replace(&image)
+			images[name] = image
+		}
+	}
+	return func(configuration *api.ReleaseBuildConfiguration) {
+		replaceAll(configuration.BaseImages)
+		replaceAll(configuration.BaseRPMImages)
+		if configuration.BuildRootImage != nil {
+			replace(configuration.BuildRootImage.ImageStreamTagReference)
+		}
+		for name, root := range configuration.BuildRootImages {
+			replace(root.ImageStreamTagReference)
+			configuration.BuildRootImages[name] = root
+		}
+		for _, test := range configuration.Tests {
+			if c := test.MultiStageTestConfigurationLiteral; c != nil {
+				for _, phase := range [][]api.LiteralTestStep{c.Pre, c.Test, c.Gather, c.Post} {
+					for i := range phase {
+						replace(phase[i].FromImage)
+					}
+				}
+				for i := range c.Observers {
+					replace(c.Observers[i].FromImage)
+				}
+			}
+		}
+	}, nil
+}
+
+func (r *Replacement) workflow() (func(*api.ReleaseBuildConfiguration), error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{r.From, r.To} {
+		if _, version := registry.SplitVersion(name); version != "" {
+			return nil, fmt.Errorf("workflow %q must not be pinned to a snapshot", name)
+		}
+	}
+	return func(configuration *api.ReleaseBuildConfiguration) {
+		for _, test := range configuration.Tests {
+			if c := test.MultiStageTestConfiguration; c != nil && c.Workflow != nil && *c.Workflow == r.From {
+				to := r.To
+				c.Workflow = &to
+			}
+		}
+	}, nil
+}
+
+func (r *Replacement) clusterProfile() (func(*api.ReleaseBuildConfiguration), error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	from, to := api.ClusterProfile(r.From), api.ClusterProfile(r.To)
+	return func(configuration *api.ReleaseBuildConfiguration) {
+		for _, test := range configuration.Tests {
+			if c := test.MultiStageTestConfiguration; c != nil && c.ClusterProfile == from {
+				c.ClusterProfile = to
+			}
+			if c := test.MultiStageTestConfigurationLiteral; c != nil && c.ClusterProfile == from {
+				c.ClusterProfile = to
+			}
+		}
+	}, nil
+}
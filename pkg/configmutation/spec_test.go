@@ -0,0 +1,119 @@
+package configmutation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSpecMutation(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	input := func() api.ReleaseBuildConfiguration {
+		return api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "release-4.16"},
+			InputConfiguration: api.InputConfiguration{
+				BaseImages:     map[string]api.ImageStreamTagReference{"base": {Namespace: "ocp", Name: "4.16", Tag: "base", As: "base"}},
+				BuildRootImage: buildRoot("golang-1.21"),
+			},
+			Tests: []api.TestStepConfiguration{
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi-aws"), ClusterProfile: api.ClusterProfileAWS}},
+				{As: "pinned", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi-aws@v2025-01-15"), ClusterProfile: api.ClusterProfileAWS}},
+				{As: "literal", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					ClusterProfile: api.ClusterProfileAWS,
+					Test:           []api.LiteralTestStep{{As: "test", FromImage: &api.ImageStreamTagReference{Namespace: "openshift", Name: "release", Tag: "golang-1.21"}}},
+				}},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name          string
+		spec          Spec
+		expectedErr   error
+		expectedMatch bool
+		expected      func(*api.ReleaseBuildConfiguration)
+	}{
+		{
+			name: "invalid spec",
+			spec: Spec{
+				Name:  "not a name",
+				Match: Predicate{Branches: "(", BaseImage: "golang"},
+				Transforms: []Transform{
+					{},
+					{ReplaceWorkflow: &Replacement{From: "ipi-aws@v2025-01-15", To: "ipi-aws-ovn"}},
+					{ReplaceBaseImage: &Replacement{From: "openshift/release:golang-1.21"}, ReplaceClusterProfile: &Replacement{From: "aws", To: "aws-2"}},
+				},
+			},
+			expectedErr: errors.New(`[name: must only contain alphanumeric characters and dashes, got "not a name", match: branches: invalid regular expression: error parsing regexp: missing closing ): ` + "`(`" + `, transforms[0]: exactly one transform must be set, got 0, transforms[1]: replace_workflow: workflow "ipi-aws@v2025-01-15" must not be pinned to a snapshot, transforms[2]: exactly one transform must be set, got 2]`),
+		},
+		{
+			name: "predicate not matching",
+			spec: Spec{
+				Name:       "ovn",
+				Match:      Predicate{Orgs: []string{"org"}, Branches: "^release-4\\.16$", Workflow: "ipi-gcp"},
+				Transforms: []Transform{{ReplaceWorkflow: &Replacement{From: "ipi-aws", To: "ipi-aws-ovn"}}},
+			},
+		},
+		{
+			name: "base image replaced everywhere it is imported",
+			spec: Spec{
+				Name:       "golang-1-22",
+				Match:      Predicate{Repos: []string{"org/repo"}, BaseImage: "openshift/release:golang-1.21"},
+				Transforms: []Transform{{ReplaceBaseImage: &Replacement{From: "openshift/release:golang-1.21", To: "openshift/release:golang-1.22"}}},
+			},
+			expectedMatch: true,
+			expected: func(c *api.ReleaseBuildConfiguration) {
+				c.BuildRootImage.ImageStreamTagReference.Tag = "golang-1.22"
+				c.Tests[2].MultiStageTestConfigurationLiteral.Test[0].FromImage.Tag = "golang-1.22"
+			},
+		},
+		{
+			name: "workflow and cluster profile replaced, pinned workflow left alone",
+			spec: Spec{
+				Name:  "ovn",
+				Match: Predicate{Workflow: "ipi-aws", ClusterProfile: "aws"},
+				Transforms: []Transform{
+					{ReplaceWorkflow: &Replacement{From: "ipi-aws", To: "ipi-aws-ovn"}},
+					{ReplaceClusterProfile: &Replacement{From: "aws", To: "aws-2"}},
+				},
+			},
+			expectedMatch: true,
+			expected: func(c *api.ReleaseBuildConfiguration) {
+				c.Tests[0].MultiStageTestConfiguration.Workflow = strPtr("ipi-aws-ovn")
+				c.Tests[0].MultiStageTestConfiguration.ClusterProfile = api.ClusterProfileAWS2
+				c.Tests[1].MultiStageTestConfiguration.ClusterProfile = api.ClusterProfileAWS2
+				c.Tests[2].MultiStageTestConfigurationLiteral.ClusterProfile = api.ClusterProfileAWS2
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mutation, err := tc.spec.Mutation()
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			configuration := input()
+			info := &config.Info{Metadata: configuration.Metadata}
+			if match := mutation.Match(&configuration, info); match != tc.expectedMatch {
+				t.Fatalf("expected match to be %t, got %t", tc.expectedMatch, match)
+			}
+			if !tc.expectedMatch {
+				return
+			}
+			if err := mutation.Transform(&configuration, info); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := input()
+			tc.expected(&expected)
+			if diff := cmp.Diff(expected, configuration); diff != "" {
+				t.Errorf("unexpected configuration: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package configquery indexes loaded ci-operator configurations by the
+// infrastructure they consume, so that questions like "who uses this base
+// image" can be answered without grepping the configuration directory.
+package configquery
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// Kind is the type of item that usages are indexed by.
+type Kind string
+
+const (
+	// BaseImage items are ImageStreamTags in the namespace/name:tag form.
+	BaseImage Kind = "baseImage"
+	// Workflow items are step registry workflow names, without versions.
+	Workflow Kind = "workflow"
+	// ClusterProfile items are cluster profile names.
+	ClusterProfile Kind = "clusterProfile"
+)
+
+// Kinds lists all the kinds of items that can be queried.
+var Kinds = []Kind{BaseImage, Workflow, ClusterProfile}
+
+// Usage identifies a configuration, and optionally a test in it, that
+// consumes an item.
+type Usage struct {
+	api.Metadata `json:",inline"`
+	// Test is the test consuming the item, empty when the item is used
+	// by the configuration as a whole.
+	Test string `json:"test,omitempty"`
+}
+
+// Resolver expands configurations with the step registry.
+type Resolver interface {
+	ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error)
+}
+
+// Index answers usage queries over a set of configurations.
+type Index struct {
+	usages map[Kind]map[string][]Usage
+}
+
+// NewIndex indexes the configurations, taking the usages of their resolved
+// forms into account. Configurations that fail to resolve are still indexed
+// by what they reference directly; the resolution errors are returned along
+// with the index.
+func NewIndex(configs config.ByOrgRepo, resolver Resolver) (*Index, error) {
+	usages := map[Kind]map[string]sets.Set[Usage]{}
+	add := func(kind Kind, item string, usage Usage) {
+		if item == "" {
+			return
+		}
+		if usages[kind] == nil {
+			usages[kind] = map[string]sets.Set[Usage]{}
+		}
+		if usages[kind][item] == nil {
+			usages[kind][item] = sets.New[Usage]()
+		}
+		usages[kind][item].Insert(usage)
+	}
+
+	var errs []error
+	for _, repos := range configs {
+		for _, configs := range repos {
+			for _, cfg := range configs {
+				indexConfig(cfg, add)
+				resolved, err := resolver.ResolveConfig(cfg)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to resolve %s: %w", cfg.Metadata.AsString(), err))
+					continue
+				}
+				indexConfig(resolved, add)
+			}
+		}
+	}
+
+	index := &Index{usages: map[Kind]map[string][]Usage{}}
+	for kind, items := range usages {
+		index.usages[kind] = map[string][]Usage{}
+		for item, set := range items {
+			list := set.UnsortedList()
+			sort.Slice(list, func(i, j int) bool {
+				if list[i].Metadata != list[j].Metadata {
+					return list[i].Metadata.AsString() < list[j].Metadata.AsString()
+				}
+				return list[i].Test < list[j].Test
+			})
+			index.usages[kind][item] = list
+		}
+	}
+	return index, utilerrors.NewAggregate(errs)
+}
+
+func indexConfig(cfg api.ReleaseBuildConfiguration, add func(Kind, string, Usage)) {
+	usage := Usage{Metadata: cfg.Metadata}
+	for _, image := range cfg.BaseImages {
+		add(BaseImage, image.ISTagName(), usage)
+	}
+	for _, image := range cfg.BaseRPMImages {
+		add(BaseImage, image.ISTagName(), usage)
+	}
+	if cfg.BuildRootImage != nil && cfg.BuildRootImage.ImageStreamTagReference != nil {
+		add(BaseImage, cfg.BuildRootImage.ImageStreamTagReference.ISTagName(), usage)
+	}
+	for _, root := range cfg.BuildRootImages {
+		if root.ImageStreamTagReference != nil {
+			add(BaseImage, root.ImageStreamTagReference.ISTagName(), usage)
+		}
+	}
+
+	for _, test := range cfg.Tests {
+		usage := Usage{Metadata: cfg.Metadata, Test: test.As}
+		if c := test.MultiStageTestConfiguration; c != nil {
+			if c.Workflow != nil {
+				name, _ := registry.SplitVersion(*c.Workflow)
+				add(Workflow, name, usage)
+			}
+			add(ClusterProfile, string(c.ClusterProfile), usage)
+		}
+		if c := test.MultiStageTestConfigurationLiteral; c != nil {
+			add(ClusterProfile, string(c.ClusterProfile), usage)
+			for _, phase := range [][]api.LiteralTestStep{c.Pre, c.Test, c.Gather, c.Post} {
+				for _, step := range phase {
+					if step.FromImage != nil {
+						add(BaseImage, step.FromImage.ISTagName(), usage)
+					}
+				}
+			}
+			for _, observer := range c.Observers {
+				if observer.FromImage != nil {
+					add(BaseImage, observer.FromImage.ISTagName(), usage)
+				}
+			}
+		}
+	}
+}
+
+// Uses determines whether the configuration references the item directly,
+// without resolving it against the step registry.
+func Uses(cfg api.ReleaseBuildConfiguration, kind Kind, item string) bool {
+	var used bool
+	indexConfig(cfg, func(k Kind, i string, _ Usage) {
+		used = used || (k == kind && i == item)
+	})
+	return used
+}
+
+// Query returns the usages of the item, sorted by configuration and test.
+func (i *Index) Query(kind Kind, item string) []Usage {
+	return i.usages[kind][item]
+}
+
+// ConfigSource provides the loaded configurations.
+type ConfigSource interface {
+	GetAll() config.ByOrgRepo
+	GetGeneration() int
+}
+
+// RegistrySource resolves configurations with the loaded step registry.
+type RegistrySource interface {
+	Resolver
+	GetGeneration() int
+}
+
+// Service keeps an Index over the configurations and registry loaded by
+// the agents. When either of them is reloaded, the index is rebuilt in the
+// background and queries are answered with the previous one until it is
+// replaced.
+type Service struct {
+	configs  ConfigSource
+	registry RegistrySource
+
+	// snapshot is the index currently answering queries
+	snapshot atomic.Pointer[indexSnapshot]
+	// building is held while an index is built, so that only one is built
+	// at a time
+	building sync.Mutex
+}
+
+// indexSnapshot is an index and the generations of the sources it was built
+// from.
+type indexSnapshot struct {
+	index              *Index
+	configGeneration   int
+	registryGeneration int
+}
+
+// NewService creates a query service on top of the sources.
+func NewService(configs ConfigSource, registry RegistrySource) *Service {
+	return &Service{configs: configs, registry: registry}
+}
+
+// Query returns the usages of the item in the loaded configurations. Only
+// the first query waits for an index to be built.
+func (s *Service) Query(kind Kind, item string) []Usage {
+	snapshot := s.snapshot.Load()
+	if snapshot == nil {
+		s.building.Lock()
+		if snapshot = s.snapshot.Load(); snapshot == nil {
+			snapshot = s.build()
+		}
+		s.building.Unlock()
+	} else if s.stale(snapshot) && s.building.TryLock() {
+		go func() {
+			defer s.building.Unlock()
+			if s.stale(s.snapshot.Load()) {
+				s.build()
+			}
+		}()
+	}
+	return snapshot.index.Query(kind, item)
+}
+
+// stale determines whether the sources were reloaded since the index was
+// built.
+func (s *Service) stale(snapshot *indexSnapshot) bool {
+	return snapshot.configGeneration != s.configs.GetGeneration() || snapshot.registryGeneration != s.registry.GetGeneration()
+}
+
+// build indexes the sources and replaces the index answering queries. The
+// generations are read first, so that a reload during the build makes the
+// index stale. Configurations which cannot be resolved are reported once per
+// build, the usages are still complete for the others.
+func (s *Service) build() *indexSnapshot {
+	snapshot := &indexSnapshot{configGeneration: s.configs.GetGeneration(), registryGeneration: s.registry.GetGeneration()}
+	var err error
+	snapshot.index, err = NewIndex(s.configs.GetAll(), s.registry)
+	if err != nil {
+		logrus.WithError(err).Warning("Some configurations could not be resolved when indexing usages")
+	}
+	s.snapshot.Store(snapshot)
+	return snapshot
+}
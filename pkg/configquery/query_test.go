@@ -0,0 +1,186 @@
+package configquery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+type fakeResolver struct {
+	generation int
+	resolves   int
+}
+
+// ResolveConfig expands every workflow into a literal configuration that
+// runs on the aws profile from the upi-installer image, and fails to
+// resolve configurations on the broken branch.
+func (r *fakeResolver) ResolveConfig(cfg api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	r.resolves++
+	if cfg.Metadata.Branch == "broken" {
+		return api.ReleaseBuildConfiguration{}, errors.New("unknown workflow")
+	}
+	var tests []api.TestStepConfiguration
+	for _, test := range cfg.Tests {
+		if test.MultiStageTestConfiguration != nil {
+			test = api.TestStepConfiguration{As: test.As, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				ClusterProfile: api.ClusterProfileAWS,
+				Test:           []api.LiteralTestStep{{As: "install", FromImage: &api.ImageStreamTagReference{Namespace: "ocp", Name: "4.16", Tag: "upi-installer"}}},
+			}}
+		}
+		tests = append(tests, test)
+	}
+	cfg.Tests = tests
+	return cfg, nil
+}
+
+func (r *fakeResolver) GetGeneration() int {
+	return r.generation
+}
+
+type fakeConfigs struct {
+	configs    config.ByOrgRepo
+	generation int
+}
+
+func (c *fakeConfigs) GetAll() config.ByOrgRepo {
+	return c.configs
+}
+
+func (c *fakeConfigs) GetGeneration() int {
+	return c.generation
+}
+
+func TestIndex(t *testing.T) {
+	workflow := "ipi-aws@v2025-01-15"
+	main := api.Metadata{Org: "org", Repo: "repo", Branch: "main"}
+	broken := api.Metadata{Org: "org", Repo: "repo", Branch: "broken"}
+	configs := config.ByOrgRepo{"org": {"repo": {
+		{
+			Metadata: main,
+			InputConfiguration: api.InputConfiguration{
+				BaseImages:     map[string]api.ImageStreamTagReference{"base": {Namespace: "ocp", Name: "4.16", Tag: "base"}},
+				BuildRootImage: &api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "openshift", Name: "release", Tag: "golang-1.22"}},
+			},
+			Tests: []api.TestStepConfiguration{
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &workflow}},
+			},
+		},
+		{
+			Metadata: broken,
+			InputConfiguration: api.InputConfiguration{
+				BaseImages: map[string]api.ImageStreamTagReference{"base": {Namespace: "ocp", Name: "4.16", Tag: "base"}},
+			},
+			Tests: []api.TestStepConfiguration{
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &workflow, ClusterProfile: api.ClusterProfileGCP}},
+			},
+		},
+	}}}
+
+	index, err := NewIndex(configs, &fakeResolver{})
+	if err == nil {
+		t.Error("expected an error resolving the broken configuration, got none")
+	}
+	for _, tc := range []struct {
+		name     string
+		kind     Kind
+		item     string
+		expected []Usage
+	}{
+		{
+			name:     "base image used by every configuration",
+			kind:     BaseImage,
+			item:     "ocp/4.16:base",
+			expected: []Usage{{Metadata: broken}, {Metadata: main}},
+		},
+		{
+			name:     "build root image",
+			kind:     BaseImage,
+			item:     "openshift/release:golang-1.22",
+			expected: []Usage{{Metadata: main}},
+		},
+		{
+			name:     "image only used in the resolved form",
+			kind:     BaseImage,
+			item:     "ocp/4.16:upi-installer",
+			expected: []Usage{{Metadata: main, Test: "e2e"}},
+		},
+		{
+			name:     "pinned workflow is indexed by name",
+			kind:     Workflow,
+			item:     "ipi-aws",
+			expected: []Usage{{Metadata: broken, Test: "e2e"}, {Metadata: main, Test: "e2e"}},
+		},
+		{
+			name:     "cluster profile only used in the resolved form",
+			kind:     ClusterProfile,
+			item:     string(api.ClusterProfileAWS),
+			expected: []Usage{{Metadata: main, Test: "e2e"}},
+		},
+		{
+			name:     "cluster profile of an unresolvable configuration",
+			kind:     ClusterProfile,
+			item:     string(api.ClusterProfileGCP),
+			expected: []Usage{{Metadata: broken, Test: "e2e"}},
+		},
+		{
+			name: "unused item",
+			kind: Workflow,
+			item: "ipi-gcp",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, index.Query(tc.kind, tc.item)); diff != "" {
+				t.Errorf("unexpected usages: %s", diff)
+			}
+		})
+	}
+}
+
+func TestServiceRebuildsIndexOnReload(t *testing.T) {
+	metadata := api.Metadata{Org: "org", Repo: "repo", Branch: "main"}
+	configs := &fakeConfigs{configs: config.ByOrgRepo{"org": {"repo": {{Metadata: metadata}}}}}
+	resolver := &fakeResolver{}
+	service := NewService(configs, resolver)
+
+	for i := 0; i < 2; i++ {
+		service.Query(BaseImage, "ocp/4.16:base")
+	}
+	if resolver.resolves != 1 {
+		t.Errorf("expected the index to be built once, configurations were resolved %d times", resolver.resolves)
+	}
+
+	configs.configs = config.ByOrgRepo{"org": {"repo": {{
+		Metadata:           metadata,
+		InputConfiguration: api.InputConfiguration{BaseImages: map[string]api.ImageStreamTagReference{"base": {Namespace: "ocp", Name: "4.16", Tag: "base"}}},
+	}}}}
+	configs.generation++
+	// the reload is noticed by a query, which is still answered with the
+	// previous index while the new one is built
+	usages := service.Query(BaseImage, "ocp/4.16:base")
+	if len(usages) != 0 {
+		t.Errorf("expected the previous index to answer while the new one is built, got %v", usages)
+	}
+	waitForBuild(service)
+	usages = service.Query(BaseImage, "ocp/4.16:base")
+	if diff := cmp.Diff([]Usage{{Metadata: metadata}}, usages); diff != "" {
+		t.Errorf("unexpected usages after reload: %s", diff)
+	}
+
+	resolver.generation++
+	service.Query(BaseImage, "ocp/4.16:base")
+	waitForBuild(service)
+	if resolver.resolves != 3 {
+		t.Errorf("expected the index to be rebuilt on registry reload, configurations were resolved %d times", resolver.resolves)
+	}
+}
+
+// waitForBuild waits for the index being built in the background, if any.
+func waitForBuild(service *Service) {
+	service.building.Lock()
+	defer service.building.Unlock()
+}
@@ -0,0 +1,253 @@
+package namespacepool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	projectapi "github.com/openshift/api/project/v1"
+	projectclientset "github.com/openshift/client-go/project/clientset/versioned"
+	projectv1client "github.com/openshift/client-go/project/clientset/versioned/typed/project/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	ControllerName = "namespacepool"
+
+	// PoolLabel marks the namespaces of the pool, its value is the state of
+	// the namespace.
+	PoolLabel = "ci.openshift.io/namespace-pool"
+	// StateWarming is the state of namespaces which are still being set up.
+	StateWarming = "warming"
+	// StateAvailable is the state of namespaces ready to be claimed.
+	StateAvailable = "available"
+	// StateClaimed is the state of namespaces claimed by a job.
+	StateClaimed = "claimed"
+
+	// namePrefix is the prefix of the names of the pooled namespaces.
+	namePrefix = "ci-op-pool-"
+	// adminRoleBinding grants the job admin access to the namespace, as a
+	// project request would grant it to the requester.
+	adminRoleBinding = "ci-operator-admin"
+)
+
+// Options configure the pool.
+type Options struct {
+	// Size is the number of namespaces kept warming or available.
+	Size int
+	// Admin is the service account running the jobs, in namespace/name
+	// format, which is granted admin access to the pooled namespaces.
+	Admin types.NamespacedName
+}
+
+// poolKey is the only request of the controller, as the whole pool is
+// reconciled at once.
+var poolKey = reconcile.Request{NamespacedName: types.NamespacedName{Name: ControllerName}}
+
+func AddToManager(clusterName string, mgr manager.Manager, opts Options) error {
+	// project requests return a project, which the controller-runtime client
+	// cannot decode
+	projectClient, err := projectclientset.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to construct project client: %w", err)
+	}
+	r := &reconciler{
+		client:          mgr.GetClient(),
+		projectRequests: projectClient.ProjectV1().ProjectRequests(),
+		log:             logrus.WithField("controller", ControllerName).WithField("cluster", clusterName),
+		opts:            opts,
+	}
+	c, err := controller.New(fmt.Sprintf("%s_%s", ControllerName, clusterName), mgr, controller.Options{
+		Reconciler: r,
+		// The pool is reconciled as a whole, concurrent reconciles would
+		// create too many namespaces.
+		MaxConcurrentReconciles: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	// every namespace event enqueues the pool, so that it is also filled when
+	// none of its namespaces exist yet
+	mapper := func(context.Context, *corev1.Namespace) []reconcile.Request {
+		return []reconcile.Request{poolKey}
+	}
+	if err := c.Watch(source.Kind(mgr.GetCache(), &corev1.Namespace{}, handler.TypedEnqueueRequestsFromMapFunc(mapper))); err != nil {
+		return fmt.Errorf("failed to construct watch for Namespaces: %w", err)
+	}
+	return nil
+}
+
+type reconciler struct {
+	client          ctrlruntimeclient.Client
+	projectRequests projectv1client.ProjectRequestInterface
+	log             *logrus.Entry
+	opts            Options
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	res, err := r.reconcile(ctx)
+	if err != nil && !apierrors.IsConflict(err) {
+		r.log.WithError(err).Error("Reconciliation failed")
+	}
+	return res, err
+}
+
+func (r *reconciler) reconcile(ctx context.Context) (reconcile.Result, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.client.List(ctx, namespaces, ctrlruntimeclient.HasLabels{PoolLabel}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list pooled namespaces: %w", err)
+	}
+	var pooled, warming int
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		switch ns.Labels[PoolLabel] {
+		case StateAvailable:
+			pooled++
+		case StateWarming:
+			pooled++
+			ready, err := r.warm(ctx, ns)
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("failed to set up namespace %s: %w", ns.Name, err)
+			}
+			if !ready {
+				warming++
+				continue
+			}
+			ns.Labels[PoolLabel] = StateAvailable
+			if err := r.client.Update(ctx, ns); err != nil {
+				return reconcile.Result{}, fmt.Errorf("failed to mark namespace %s as available: %w", ns.Name, err)
+			}
+			r.log.WithField("namespace", ns.Name).Info("Namespace is available")
+		}
+	}
+	for ; pooled < r.opts.Size; pooled++ {
+		name, err := r.create(ctx)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		r.log.WithField("namespace", name).Info("Created pooled namespace")
+		warming++
+	}
+	if warming > 0 {
+		// service account pull secrets are minted asynchronously
+		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// create requests a new pooled namespace. Namespaces are created through
+// project requests, like ci-operator creates them, so that the objects of the
+// project template of the cluster are created in them as well.
+func (r *reconciler) create(ctx context.Context) (string, error) {
+	name := namePrefix + utilrand.String(5)
+	request := &projectapi.ProjectRequest{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Labels: map[string]string{PoolLabel: StateWarming}},
+		DisplayName: fmt.Sprintf("%s - pooled", name),
+		Description: "Pre-warmed namespace for ci-operator to claim",
+	}
+	if _, err := r.projectRequests.Create(ctx, request, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to request pooled namespace %s: %w", name, err)
+	}
+	// the project template decides which labels the namespace gets, so the
+	// pool label is set on the namespace itself
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, PoolLabel, StateWarming))
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if err := r.client.Patch(ctx, ns, ctrlruntimeclient.RawPatch(types.MergePatchType, patch)); err != nil {
+		return "", fmt.Errorf("failed to label pooled namespace %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// warm sets up what ci-operator would otherwise set up when creating the
+// namespace and reports whether the namespace is ready to be claimed.
+func (r *reconciler) warm(ctx context.Context, ns *corev1.Namespace) (bool, error) {
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: adminRoleBinding},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: r.opts.Admin.Namespace,
+			Name:      r.opts.Admin.Name,
+		}},
+		RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"},
+	}
+	if err := r.client.Create(ctx, roleBinding); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create role binding: %w", err)
+	}
+	is := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: api.PipelineImageStream},
+		Spec:       imagev1.ImageStreamSpec{LookupPolicy: imagev1.ImageLookupPolicy{Local: true}},
+	}
+	if err := r.client.Create(ctx, is); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create the %s imagestream: %w", api.PipelineImageStream, err)
+	}
+	for _, name := range []string{"builder", "default"} {
+		sa := &corev1.ServiceAccount{}
+		if err := r.client.Get(ctx, types.NamespacedName{Namespace: ns.Name, Name: name}, sa); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get service account %s: %w", name, err)
+		}
+		if len(sa.ImagePullSecrets) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Claim takes the oldest available namespace out of the pool and returns its
+// name, or an empty name when none is available. The annotations are set in
+// the same update, so that a namespace is never claimed without them.
+func Claim(ctx context.Context, client ctrlruntimeclient.Client, annotations map[string]string) (string, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := client.List(ctx, namespaces, ctrlruntimeclient.MatchingLabels{PoolLabel: StateAvailable}); err != nil {
+		return "", fmt.Errorf("failed to list available namespaces: %w", err)
+	}
+	sort.Slice(namespaces.Items, func(i, j int) bool {
+		a, b := namespaces.Items[i], namespaces.Items[j]
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp)
+		}
+		return a.Name < b.Name
+	})
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		ns.Labels[PoolLabel] = StateClaimed
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			ns.Annotations[key] = value
+		}
+		// the update fails on a conflict when another job claimed it first
+		if err := client.Update(ctx, ns); err != nil {
+			if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", fmt.Errorf("failed to claim namespace %s: %w", ns.Name, err)
+		}
+		return ns.Name, nil
+	}
+	return "", nil
+}
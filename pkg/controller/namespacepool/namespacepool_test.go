@@ -0,0 +1,162 @@
+package namespacepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	projectapi "github.com/openshift/api/project/v1"
+	projectv1client "github.com/openshift/client-go/project/clientset/versioned/typed/project/v1"
+)
+
+func init() {
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+func pooled(name, state string, created time.Time) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		Labels:            map[string]string{PoolLabel: state},
+		CreationTimestamp: metav1.Time{Time: created},
+	}}
+}
+
+func serviceAccount(namespace, name string, minted bool) *corev1.ServiceAccount {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if minted {
+		sa.ImagePullSecrets = []corev1.LocalObjectReference{{Name: name + "-dockercfg"}}
+	}
+	return sa
+}
+
+// fakeProjectRequests creates the namespace of the requested project, without
+// the labels of the request, like a project template not copying them
+type fakeProjectRequests struct {
+	projectv1client.ProjectRequestInterface
+	client ctrlruntimeclient.Client
+}
+
+func (f *fakeProjectRequests) Create(ctx context.Context, request *projectapi.ProjectRequest, _ metav1.CreateOptions) (*projectapi.Project, error) {
+	if err := f.client.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: request.Name}}); err != nil {
+		return nil, err
+	}
+	return &projectapi.Project{ObjectMeta: metav1.ObjectMeta{Name: request.Name}}, nil
+}
+
+func poolStates(t *testing.T, client ctrlruntimeclient.Client) map[string]int {
+	namespaces := &corev1.NamespaceList{}
+	if err := client.List(context.Background(), namespaces, ctrlruntimeclient.HasLabels{PoolLabel}); err != nil {
+		t.Fatalf("failed to list namespaces: %v", err)
+	}
+	states := map[string]int{}
+	for _, ns := range namespaces.Items {
+		states[ns.Labels[PoolLabel]]++
+	}
+	return states
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name           string
+		objects        []ctrlruntimeclient.Object
+		expectedStates map[string]int
+		expectedResult reconcile.Result
+	}{
+		{
+			name:           "empty pool is filled",
+			expectedStates: map[string]int{StateWarming: 2},
+			expectedResult: reconcile.Result{RequeueAfter: 5 * time.Second},
+		},
+		{
+			name: "full pool is left alone",
+			objects: []ctrlruntimeclient.Object{
+				pooled("a", StateAvailable, now), pooled("b", StateAvailable, now), pooled("c", StateClaimed, now),
+			},
+			expectedStates: map[string]int{StateAvailable: 2, StateClaimed: 1},
+		},
+		{
+			name: "ready namespace becomes available",
+			objects: []ctrlruntimeclient.Object{
+				pooled("a", StateAvailable, now), pooled("b", StateWarming, now),
+				serviceAccount("b", "builder", true), serviceAccount("b", "default", true),
+			},
+			expectedStates: map[string]int{StateAvailable: 2},
+		},
+		{
+			name: "namespace without pull secrets keeps warming",
+			objects: []ctrlruntimeclient.Object{
+				pooled("a", StateAvailable, now), pooled("b", StateWarming, now),
+				serviceAccount("b", "builder", true), serviceAccount("b", "default", false),
+			},
+			expectedStates: map[string]int{StateAvailable: 1, StateWarming: 1},
+			expectedResult: reconcile.Result{RequeueAfter: 5 * time.Second},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			r := &reconciler{
+				client:          client,
+				projectRequests: &fakeProjectRequests{client: client},
+				log:             logrus.NewEntry(logrus.StandardLogger()),
+				opts:            Options{Size: 2, Admin: types.NamespacedName{Namespace: "ci", Name: "ci-operator"}},
+			}
+			result, err := r.Reconcile(context.Background(), poolKey)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedStates, poolStates(t, client)); diff != "" {
+				t.Errorf("unexpected pool: %s", diff)
+			}
+		})
+	}
+}
+
+func TestClaim(t *testing.T) {
+	now := time.Now()
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		pooled("newer", StateAvailable, now),
+		pooled("older", StateAvailable, now.Add(-time.Hour)),
+		pooled("warming", StateWarming, now.Add(-2*time.Hour)),
+	).Build()
+	annotations := map[string]string{"ttl": "1h"}
+	for _, expected := range []string{"older", "newer", ""} {
+		claimed, err := Claim(context.Background(), client, annotations)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claimed != expected {
+			t.Errorf("expected to claim %q, got %q", expected, claimed)
+		}
+		if claimed == "" {
+			continue
+		}
+		ns := &corev1.Namespace{}
+		if err := client.Get(context.Background(), types.NamespacedName{Name: claimed}, ns); err != nil {
+			t.Fatalf("failed to get claimed namespace: %v", err)
+		}
+		if diff := cmp.Diff(annotations, ns.Annotations); diff != "" {
+			t.Errorf("unexpected annotations of %s: %s", claimed, diff)
+		}
+	}
+	if diff := cmp.Diff(map[string]int{StateClaimed: 2, StateWarming: 1}, poolStates(t, client)); diff != "" {
+		t.Errorf("unexpected pool: %s", diff)
+	}
+}
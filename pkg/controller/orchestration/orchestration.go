@@ -0,0 +1,163 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntime "sigs.k8s.io/controller-runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	v1 "github.com/openshift/ci-tools/pkg/api/orchestration/v1"
+	"github.com/openshift/ci-tools/pkg/validation"
+)
+
+const ControllerName = "orchestration"
+
+func AddToManager(mgr manager.Manager) error {
+	logger := logrus.WithField("controller", ControllerName)
+	if err := ctrlruntime.NewControllerManagedBy(mgr).
+		Named(ControllerName + "_testrun").
+		For(&v1.TestRun{}).
+		Complete(&testRunReconciler{logger: logger.WithField("kind", "TestRun"), client: mgr.GetClient()}); err != nil {
+		return fmt.Errorf("failed to construct the TestRun controller: %w", err)
+	}
+	if err := ctrlruntime.NewControllerManagedBy(mgr).
+		Named(ControllerName + "_imagepipeline").
+		For(&v1.ImagePipeline{}).
+		Complete(&imagePipelineReconciler{logger: logger.WithField("kind", "ImagePipeline"), client: mgr.GetClient()}); err != nil {
+		return fmt.Errorf("failed to construct the ImagePipeline controller: %w", err)
+	}
+	return nil
+}
+
+type testRunReconciler struct {
+	logger *logrus.Entry
+	client ctrlruntimeclient.Client
+}
+
+func (r *testRunReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.logger.WithField("request", req.String())
+	run := &v1.TestRun{}
+	if err := r.client.Get(ctx, req.NamespacedName, run); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get the TestRun: %w", err)
+	}
+	if run.DeletionTimestamp != nil || run.Status.ObservedGeneration == run.Generation {
+		return reconcile.Result{}, nil
+	}
+
+	run = run.DeepCopy()
+	err := validateTestRun(run.Spec)
+	meta.SetStatusCondition(&run.Status.Conditions, acceptedCondition(run.Generation, err))
+	run.Status.ObservedGeneration = run.Generation
+	if err := r.client.Status().Update(ctx, run); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update the status of the TestRun: %w", err)
+	}
+	logger.WithField("accepted", err == nil).Info("Finished reconciliation")
+	return reconcile.Result{}, nil
+}
+
+type imagePipelineReconciler struct {
+	logger *logrus.Entry
+	client ctrlruntimeclient.Client
+}
+
+func (r *imagePipelineReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := r.logger.WithField("request", req.String())
+	pipeline := &v1.ImagePipeline{}
+	if err := r.client.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get the ImagePipeline: %w", err)
+	}
+	if pipeline.DeletionTimestamp != nil || pipeline.Status.ObservedGeneration == pipeline.Generation {
+		return reconcile.Result{}, nil
+	}
+
+	pipeline = pipeline.DeepCopy()
+	err := validateImagePipeline(pipeline.Spec)
+	meta.SetStatusCondition(&pipeline.Status.Conditions, acceptedCondition(pipeline.Generation, err))
+	pipeline.Status.ObservedGeneration = pipeline.Generation
+	if err := r.client.Status().Update(ctx, pipeline); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update the status of the ImagePipeline: %w", err)
+	}
+	logger.WithField("accepted", err == nil).Info("Finished reconciliation")
+	return reconcile.Result{}, nil
+}
+
+// acceptedCondition reflects the result of the validation of the spec.
+func acceptedCondition(generation int64, err error) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               v1.AcceptedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             v1.ValidConfigurationReason,
+		Message:            "The configuration is valid.",
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1.InvalidConfigurationReason
+		condition.Message = err.Error()
+	}
+	return condition
+}
+
+// validateSpec validates what all resources have in common: the ci-operator
+// configuration, validated the same way ci-operator does it at runtime, and
+// the code it runs on.
+func validateSpec(config api.ReleaseBuildConfiguration, refs prowv1.Refs) []error {
+	var errs []error
+	if refs.Org == "" || refs.Repo == "" {
+		errs = append(errs, errors.New("refs: org and repo must be set"))
+	}
+	if err := validation.IsValidRuntimeConfiguration(config.DeepCopy()); err != nil {
+		errs = append(errs, fmt.Errorf("invalid configuration: %w", err))
+	}
+	return errs
+}
+
+func validateTestRun(spec v1.TestRunSpec) error {
+	errs := validateSpec(spec.Config, spec.Refs)
+	if len(spec.Targets) == 0 {
+		errs = append(errs, errors.New("targets: at least one target must be set"))
+	}
+	tests := sets.New[string]()
+	for _, test := range spec.Config.Tests {
+		tests.Insert(test.As)
+	}
+	for _, target := range spec.Targets {
+		if !tests.Has(target) {
+			errs = append(errs, fmt.Errorf("targets: no test named %q in the configuration", target))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validateImagePipeline(spec v1.ImagePipelineSpec) error {
+	errs := validateSpec(spec.Config, spec.Refs)
+	images := sets.New[string]()
+	for _, image := range spec.Config.Images {
+		images.Insert(string(image.To))
+	}
+	for _, image := range spec.Images {
+		if !images.Has(image) {
+			errs = append(errs, fmt.Errorf("images: no image named %q in the configuration", image))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
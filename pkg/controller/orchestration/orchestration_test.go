@@ -0,0 +1,184 @@
+package orchestration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	v1 "github.com/openshift/ci-tools/pkg/api/orchestration/v1"
+)
+
+func testConfig() api.ReleaseBuildConfiguration {
+	return api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: "latest"},
+			},
+		},
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "bin"}},
+		Tests: []api.TestStepConfiguration{{
+			As:                         "unit",
+			Commands:                   "make test",
+			ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"},
+		}},
+		Resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "1"}}},
+	}
+}
+
+var testRefs = prowv1.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"}
+
+func scheme(t *testing.T) *runtime.Scheme {
+	ret := runtime.NewScheme()
+	if err := v1.AddToScheme(ret); err != nil {
+		t.Fatalf("failed to add to the scheme: %v", err)
+	}
+	return ret
+}
+
+func acceptedConditions(status metav1.ConditionStatus, reason, message string) []metav1.Condition {
+	return []metav1.Condition{{
+		Type:               v1.AcceptedCondition,
+		Status:             status,
+		ObservedGeneration: 1,
+		Reason:             reason,
+		Message:            message,
+	}}
+}
+
+func TestReconcileTestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		spec     v1.TestRunSpec
+		status   v1.TestRunStatus
+		expected v1.TestRunStatus
+	}{
+		{
+			name: "valid run is accepted",
+			spec: v1.TestRunSpec{Config: testConfig(), Refs: testRefs, Targets: []string{"unit"}},
+			expected: v1.TestRunStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionTrue, v1.ValidConfigurationReason, "The configuration is valid."),
+			},
+		},
+		{
+			name: "unknown target is rejected",
+			spec: v1.TestRunSpec{Config: testConfig(), Refs: testRefs, Targets: []string{"e2e"}},
+			expected: v1.TestRunStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionFalse, v1.InvalidConfigurationReason, `targets: no test named "e2e" in the configuration`),
+			},
+		},
+		{
+			name: "missing refs and targets are rejected",
+			spec: v1.TestRunSpec{Config: testConfig()},
+			expected: v1.TestRunStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionFalse, v1.InvalidConfigurationReason, "[refs: org and repo must be set, targets: at least one target must be set]"),
+			},
+		},
+		{
+			name:     "observed generation is not reconciled again",
+			spec:     v1.TestRunSpec{Targets: []string{"e2e"}},
+			status:   v1.TestRunStatus{ObservedGeneration: 1},
+			expected: v1.TestRunStatus{ObservedGeneration: 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			run := &v1.TestRun{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "run", Generation: 1},
+				Spec:       tc.spec,
+				Status:     tc.status,
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme(t)).WithObjects(run).WithStatusSubresource(run).Build()
+			r := &testRunReconciler{logger: logrus.NewEntry(logrus.StandardLogger()), client: client}
+			key := types.NamespacedName{Namespace: "ns", Name: "run"}
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := &v1.TestRun{}
+			if err := client.Get(context.Background(), key, actual); err != nil {
+				t.Fatalf("failed to get the TestRun: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual.Status, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("unexpected status: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileImagePipeline(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		spec     v1.ImagePipelineSpec
+		expected v1.ImagePipelineStatus
+	}{
+		{
+			name: "all images are accepted",
+			spec: v1.ImagePipelineSpec{Config: testConfig(), Refs: testRefs},
+			expected: v1.ImagePipelineStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionTrue, v1.ValidConfigurationReason, "The configuration is valid."),
+			},
+		},
+		{
+			name: "known image is accepted",
+			spec: v1.ImagePipelineSpec{Config: testConfig(), Refs: testRefs, Images: []string{"bin"}},
+			expected: v1.ImagePipelineStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionTrue, v1.ValidConfigurationReason, "The configuration is valid."),
+			},
+		},
+		{
+			name: "unknown image is rejected",
+			spec: v1.ImagePipelineSpec{Config: testConfig(), Refs: testRefs, Images: []string{"other"}},
+			expected: v1.ImagePipelineStatus{
+				ObservedGeneration: 1,
+				Conditions:         acceptedConditions(metav1.ConditionFalse, v1.InvalidConfigurationReason, `images: no image named "other" in the configuration`),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pipeline := &v1.ImagePipeline{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline", Generation: 1},
+				Spec:       tc.spec,
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme(t)).WithObjects(pipeline).WithStatusSubresource(pipeline).Build()
+			r := &imagePipelineReconciler{logger: logrus.NewEntry(logrus.StandardLogger()), client: client}
+			key := types.NamespacedName{Namespace: "ns", Name: "pipeline"}
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := &v1.ImagePipeline{}
+			if err := client.Get(context.Background(), key, actual); err != nil {
+				t.Fatalf("failed to get the ImagePipeline: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual.Status, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("unexpected status: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileMissing(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme(t)).Build()
+	key := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "missing"}}
+	for _, r := range []reconcile.Reconciler{
+		&testRunReconciler{logger: logrus.NewEntry(logrus.StandardLogger()), client: client},
+		&imagePipelineReconciler{logger: logrus.NewEntry(logrus.StandardLogger()), client: client},
+	} {
+		if _, err := r.Reconcile(context.Background(), key); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 	"time"
@@ -61,10 +62,11 @@ func FromConfig(
 	jobSpec *api.JobSpec,
 	templates []*templateapi.Template,
 	paramFile string,
-	promote bool,
+	promote, promoteDryRun bool,
 	clusterConfig *rest.Config,
 	podPendingTimeout time.Duration,
 	leaseClient *lease.Client,
+	quotaAdmission *steps.QuotaAdmission,
 	requiredTargets []string,
 	cloneAuthConfig *steps.CloneAuthConfig,
 	pullSecret, pushSecret *coreapi.Secret,
@@ -78,6 +80,12 @@ func FromConfig(
 	integratedStreams map[string]*configresolver.IntegratedStream,
 	injectedTest bool,
 	enableSecretsStoreCSIDriver bool,
+	stepLogLimit *api.LogLimit,
+	artMetadataEndpoint string,
+	cveScannerImage string,
+	importCoordinationNamespace string,
+	buildCacheNamespace string,
+	artifactOptions steps.ArtifactOptions,
 ) ([]api.Step, []api.Step, error) {
 	crclient, err := ctrlruntimeclient.NewWithWatch(clusterConfig, ctrlruntimeclient.Options{})
 	crclient = secretrecordingclient.Wrap(crclient, censor)
@@ -90,7 +98,11 @@ func FromConfig(
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
 	}
-	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient(), nodeArchitectures, manifestToolDockerCfg, localRegistryDNS)
+	var buildCache *steps.BuildCache
+	if buildCacheNamespace != "" {
+		buildCache = steps.NewBuildCache(crclient, buildCacheNamespace)
+	}
+	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient(), nodeArchitectures, manifestToolDockerCfg, localRegistryDNS, buildCache)
 
 	templateGetter, err := templateclientset.NewForConfig(clusterConfig)
 	if err != nil {
@@ -103,7 +115,7 @@ func FromConfig(
 		return nil, nil, fmt.Errorf("could not get core client for cluster config: %w", err)
 	}
 
-	podClient := kubernetes.NewPodClient(client, clusterConfig, coreGetter.RESTClient(), podPendingTimeout)
+	podClient := kubernetes.NewRetryingPodClient(kubernetes.NewPodClient(client, clusterConfig, coreGetter.RESTClient(), podPendingTimeout))
 
 	var hiveClient ctrlruntimeclient.WithWatch
 	if hiveKubeconfig != nil {
@@ -115,7 +127,17 @@ func FromConfig(
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = nil
 
-	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, nodeName, targetAdditionalSuffix, nodeArchitectures, integratedStreams, injectedTest, enableSecretsStoreCSIDriver)
+	var importCoordinator *utils.ImportCoordinator
+	if importCoordinationNamespace != "" {
+		importCoordinator = utils.NewImportCoordinator(crclient, importCoordinationNamespace, jobSpec.Namespace)
+	}
+
+	var imageScanner releasesteps.ImageScanner
+	if cveScannerImage != "" {
+		imageScanner = releasesteps.NewPodImageScanner(podClient, jobSpec, cveScannerImage, nodeArchitectures)
+	}
+
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, promoteDryRun, client, buildClient, templateClient, podClient, leaseClient, quotaAdmission, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, nodeName, targetAdditionalSuffix, nodeArchitectures, integratedStreams, injectedTest, enableSecretsStoreCSIDriver, stepLogLimit, artMetadataEndpoint, imageScanner, importCoordinator, artifactOptions)
 }
 
 func fromConfig(
@@ -125,12 +147,13 @@ func fromConfig(
 	jobSpec *api.JobSpec,
 	templates []*templateapi.Template,
 	paramFile string,
-	promote bool,
+	promote, promoteDryRun bool,
 	client loggingclient.LoggingClient,
 	buildClient steps.BuildClient,
 	templateClient steps.TemplateClient,
 	podClient kubernetes.PodClient,
 	leaseClient *lease.Client,
+	quotaAdmission *steps.QuotaAdmission,
 	hiveClient ctrlruntimeclient.WithWatch,
 	httpClient release.HTTPClient,
 	requiredTargets []string,
@@ -144,6 +167,11 @@ func fromConfig(
 	integratedStreams map[string]*configresolver.IntegratedStream,
 	injectedTest bool,
 	enableSecretsStoreCSIDriver bool,
+	stepLogLimit *api.LogLimit,
+	artMetadataEndpoint string,
+	imageScanner releasesteps.ImageScanner,
+	importCoordinator *utils.ImportCoordinator,
+	artifactOptions steps.ArtifactOptions,
 ) ([]api.Step, []api.Step, error) {
 	requiredNames := sets.New[string]()
 	for _, target := range requiredTargets {
@@ -170,7 +198,7 @@ func fromConfig(
 
 	for _, rawStep := range rawSteps {
 		if testStep := rawStep.TestStepConfiguration; testStep != nil {
-			steps, err := stepForTest(config, params, podClient, leaseClient, templateClient, client, hiveClient, jobSpec, inputImages, testStep, &imageConfigs, pullSecret, censor, nodeName, targetAdditionalSuffix, enableSecretsStoreCSIDriver)
+			steps, err := stepForTest(config, params, podClient, leaseClient, quotaAdmission, templateClient, client, hiveClient, jobSpec, inputImages, testStep, &imageConfigs, pullSecret, censor, nodeName, targetAdditionalSuffix, enableSecretsStoreCSIDriver, stepLogLimit, importCoordinator, artifactOptions)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -244,7 +272,7 @@ func fromConfig(
 				continue
 			}
 
-			step = steps.InputImageTagStep(&conf, client, jobSpec)
+			step = steps.InputImageTagStep(&conf, client, jobSpec, importCoordinator)
 			inputImages[conf.InputImage] = struct{}{}
 		} else if rawStep.PipelineImageCacheStepConfiguration != nil {
 			step = steps.PipelineImageCacheStep(*rawStep.PipelineImageCacheStepConfiguration, config.Resources, buildClient, podClient, jobSpec, pullSecret)
@@ -312,7 +340,7 @@ func fromConfig(
 	}
 
 	for _, template := range templates {
-		step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, config.Resources)
+		step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, config.Resources, artifactOptions)
 		var hasClusterType, hasUseLease bool
 		for _, p := range template.Parameters {
 			hasClusterType = hasClusterType || p.Name == "CLUSTER_TYPE"
@@ -357,19 +385,35 @@ func fromConfig(
 
 	var promotionSteps []api.Step
 	if promote {
-		if pushSecret == nil {
+		if pushSecret == nil && !promoteDryRun {
 			return nil, nil, errors.New("--image-mirror-push-secret is required for promoting images")
 		}
 		if config.PromotionConfiguration == nil {
 			return nil, nil, fmt.Errorf("cannot promote images, no promotion configuration defined")
 		}
 
-		promotionSteps = append(promotionSteps, releasesteps.PromotionStep(api.PromotionStepName, config, requiredNames, jobSpec, podClient, pushSecret, registryDomain(config.PromotionConfiguration), api.DefaultMirrorFunc, api.DefaultTargetNameFunc, nodeArchitectures))
+		var cveGate *releasesteps.CVEGate
+		if config.PromotionConfiguration.CVEGate != nil && imageScanner != nil {
+			waivers, err := cveWaiversFromRepository(repositoryPath(config.Metadata, jobSpec, injectedTest), os.ReadFile)
+			if err != nil {
+				return nil, nil, err
+			}
+			cveGate = releasesteps.NewCVEGate(imageScanner, waivers)
+		}
+		var artCheck *releasesteps.ARTConsistencyCheck
+		if config.PromotionConfiguration.ARTConsistencyCheck && artMetadataEndpoint != "" {
+			buildRoot, err := artBuildRoot(config, jobSpec, injectedTest, os.ReadFile)
+			if err != nil {
+				return nil, nil, err
+			}
+			artCheck = releasesteps.NewARTConsistencyCheck(httpClient, artMetadataEndpoint, buildRoot)
+		}
+		promotionSteps = append(promotionSteps, releasesteps.PromotionStep(api.PromotionStepName, config, requiredNames, jobSpec, podClient, pushSecret, registryDomain(config.PromotionConfiguration), api.DefaultMirrorFunc, api.DefaultTargetNameFunc, nodeArchitectures, artCheck, cveGate, promoteDryRun))
 		// Used primarily (only?) by the ci-chat-bot
 		if config.PromotionConfiguration.RegistryOverride != "" {
 			logrus.Info("No images to promote to quay.io if the registry is overridden")
 		} else {
-			promotionSteps = append(promotionSteps, releasesteps.PromotionStep(api.PromotionQuayStepName, config, requiredNames, jobSpec, podClient, pushSecret, api.QuayOpenShiftCIRepo, api.QuayMirrorFunc, api.QuayTargetNameFunc, nodeArchitectures))
+			promotionSteps = append(promotionSteps, releasesteps.PromotionStep(api.PromotionQuayStepName, config, requiredNames, jobSpec, podClient, pushSecret, api.QuayOpenShiftCIRepo, api.QuayMirrorFunc, api.QuayTargetNameFunc, nodeArchitectures, artCheck, cveGate, promoteDryRun))
 		}
 	}
 
@@ -418,6 +462,7 @@ func stepForTest(
 	params *api.DeferredParameters,
 	podClient kubernetes.PodClient,
 	leaseClient *lease.Client,
+	quotaAdmission *steps.QuotaAdmission,
 	templateClient steps.TemplateClient,
 	client loggingclient.LoggingClient,
 	hiveClient ctrlruntimeclient.WithWatch,
@@ -430,6 +475,9 @@ func stepForTest(
 	nodeName string,
 	targetAdditionalSuffix string,
 	enableSecretsStoreCSIDriver bool,
+	stepLogLimit *api.LogLimit,
+	importCoordinator *utils.ImportCoordinator,
+	artifactOptions steps.ArtifactOptions,
 ) ([]api.Step, error) {
 	if test := c.MultiStageTestConfigurationLiteral; test != nil {
 		leases := api.LeasesForTest(test)
@@ -438,13 +486,18 @@ func stepForTest(
 			params = api.NewDeferredParameters(params)
 		}
 		var ret []api.Step
-		step := multi_stage.MultiStageTestStep(*c, config, params, podClient, jobSpec, leases, nodeName, targetAdditionalSuffix, nil, enableSecretsStoreCSIDriver)
+		step := multi_stage.MultiStageTestStep(*c, config, params, podClient, jobSpec, leases, nodeName, targetAdditionalSuffix, nil, enableSecretsStoreCSIDriver, stepLogLimit, artifactOptions.LiveLogs, artifactOptions.StepArtifacts)
 		if ipPoolLease.ResourceType != "" {
 			step = steps.IPPoolStep(leaseClient, podClient, ipPoolLease, step, params, jobSpec.Namespace)
 		}
+		var opts []steps.LeaseStepOption
+		if c.Soak != nil {
+			opts = append(opts, steps.WithLeaseRenewal())
+		}
 		if len(leases) != 0 {
-			step = steps.LeaseStep(leaseClient, leases, step, jobSpec.Namespace)
+			step = steps.LeaseStep(leaseClient, leases, step, jobSpec.Namespace, opts...)
 		}
+		step = steps.ReservationStep(leaseClient, quotaAdmission, test.ClusterProfile, step, opts...)
 		if c.ClusterClaim != nil {
 			step = steps.ClusterClaimStep(c.As, c.ClusterClaim, hiveClient, client, jobSpec, step, censor)
 			name := c.ClusterClaim.ClaimRelease(c.As).ReleaseName
@@ -452,9 +505,16 @@ func stepForTest(
 			source := releasesteps.NewReleaseSourceFromClusterClaim(c.As, c.ClusterClaim, hiveClient)
 			ret = append(ret, releasesteps.ImportReleaseStep(name, nodeName, target, source, false, config.Resources, podClient, jobSpec, pullSecret, nil))
 		}
+		if len(c.AllowedWindows) != 0 {
+			step = steps.AllowedWindowsStep(c.AllowedWindows, step)
+		}
 		addProvidesForStep(step, params)
 		ret = append(ret, step)
-		ret = append(ret, stepsForStepImages(client, jobSpec, inputImages, test, imageConfigs)...)
+		imageSteps, err := stepsForStepImages(client, jobSpec, inputImages, test, imageConfigs, importCoordinator)
+		if err != nil {
+			return nil, fmt.Errorf("test %s: %w", c.As, err)
+		}
+		ret = append(ret, imageSteps...)
 		return ret, nil
 	}
 	if test := c.OpenshiftInstallerClusterTestConfiguration; test != nil {
@@ -462,7 +522,7 @@ func stepForTest(
 			return nil, nil
 		}
 		params = api.NewDeferredParameters(params)
-		step, err := clusterinstall.E2ETestStep(*c.OpenshiftInstallerClusterTestConfiguration, *c, params, podClient, templateClient, jobSpec, config.Resources)
+		step, err := clusterinstall.E2ETestStep(*c.OpenshiftInstallerClusterTestConfiguration, *c, params, podClient, templateClient, jobSpec, config.Resources, artifactOptions)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create end to end test step: %w", err)
 		}
@@ -471,25 +531,35 @@ func stepForTest(
 			Env:          api.DefaultLeaseEnv,
 			Count:        1,
 		}}, step, jobSpec.Namespace)
+		step = steps.ReservationStep(leaseClient, quotaAdmission, test.ClusterProfile, step)
+		if len(c.AllowedWindows) != 0 {
+			step = steps.AllowedWindowsStep(c.AllowedWindows, step)
+		}
 		addProvidesForStep(step, params)
 		return []api.Step{step}, nil
 	}
-	step := steps.TestStep(*c, config.Resources, podClient, jobSpec, nodeName)
+	step := steps.TestStep(*c, config.Resources, podClient, jobSpec, nodeName, artifactOptions.FailureLogLimit)
 	if c.ClusterClaim != nil {
 		step = steps.ClusterClaimStep(c.As, c.ClusterClaim, hiveClient, client, jobSpec, step, censor)
 	}
+	if len(c.AllowedWindows) != 0 {
+		step = steps.AllowedWindowsStep(c.AllowedWindows, step)
+	}
 	return []api.Step{step}, nil
 }
 
 // stepsForStepImages creates steps that import images referenced in test steps.
+// An image is imported once, so steps referencing it cannot use different pull
+// secrets.
 func stepsForStepImages(
 	client loggingclient.LoggingClient,
 	jobSpec *api.JobSpec,
 	inputImages inputImageSet,
 	test *api.MultiStageTestConfigurationLiteral,
 	imageConfigs *[]*api.InputImageTagStepConfiguration,
-) (ret []api.Step) {
-	for _, subStep := range append(append(test.Pre, test.Test...), test.Post...) {
+	importCoordinator *utils.ImportCoordinator,
+) (ret []api.Step, err error) {
+	for _, subStep := range test.Steps() {
 		if link, ok := subStep.FromImageTag(); ok {
 			source := api.ImageStreamSource{SourceType: api.ImageStreamSourceTest, Name: subStep.As}
 
@@ -498,7 +568,8 @@ func stepsForStepImages(
 					BaseImage: *subStep.FromImage,
 					To:        link,
 				},
-				Sources: []api.ImageStreamSource{source},
+				Sources:    []api.ImageStreamSource{source},
+				PullSecret: subStep.PullSecret,
 			}
 			// Determine if there are any other steps with the same BaseImage/To.
 			if _, ok := inputImages[config.InputImage]; ok {
@@ -506,20 +577,26 @@ func stepsForStepImages(
 					// If the existing step is an image tag step and it has the same image, then add the current step as a
 					// source of that same image
 					if existingImageConfig.Matches(config.InputImage) {
+						if existingImageConfig.PullSecret != "" && config.PullSecret != "" && existingImageConfig.PullSecret != config.PullSecret {
+							return nil, fmt.Errorf("step %s imports %s with the pull secret %s, but it is already imported with the pull secret %s", subStep.As, subStep.FromImage.ISTagName(), config.PullSecret, existingImageConfig.PullSecret)
+						}
 						existingImageConfig.AddSources(source)
+						if existingImageConfig.PullSecret == "" {
+							existingImageConfig.PullSecret = config.PullSecret
+						}
 					}
 				}
 			} else {
 				// This image doesn't already exist, so add it.
 				inputImages[config.InputImage] = struct{}{}
 
-				step := steps.InputImageTagStep(&config, client, jobSpec)
+				step := steps.InputImageTagStep(&config, client, jobSpec, importCoordinator)
 				ret = append(ret, step)
 				*imageConfigs = append(*imageConfigs, &config)
 			}
 		}
 	}
-	return
+	return ret, nil
 }
 
 // addProvidesForStep adds any required parameters to the deferred parameters map.
@@ -689,10 +766,11 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 			src = fmt.Sprintf("%s-%s", src, binaryBuildCommand.Ref)
 		}
 		buildSteps = append(buildSteps, api.StepConfiguration{PipelineImageCacheStepConfiguration: &api.PipelineImageCacheStepConfiguration{
-			From:     api.PipelineImageStreamTagReference(src),
-			To:       api.PipelineImageStreamTagReference(bin),
-			Commands: binaryBuildCommand.Commands,
-			Ref:      binaryBuildCommand.Ref,
+			From:         api.PipelineImageStreamTagReference(src),
+			To:           api.PipelineImageStreamTagReference(bin),
+			Commands:     binaryBuildCommand.Commands,
+			Ref:          binaryBuildCommand.Ref,
+			BuildBackend: buildRoots[binaryBuildCommand.Ref].BuildBackend,
 		}})
 	}
 
@@ -708,10 +786,11 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 			src = fmt.Sprintf("%s-%s", src, testBinaryBuildCommands.Ref)
 		}
 		buildSteps = append(buildSteps, api.StepConfiguration{PipelineImageCacheStepConfiguration: &api.PipelineImageCacheStepConfiguration{
-			From:     api.PipelineImageStreamTagReference(src),
-			To:       api.PipelineImageStreamTagReference(testBin),
-			Commands: testBinaryBuildCommands.Commands,
-			Ref:      testBinaryBuildCommands.Ref,
+			From:         api.PipelineImageStreamTagReference(src),
+			To:           api.PipelineImageStreamTagReference(testBin),
+			Commands:     testBinaryBuildCommands.Commands,
+			Ref:          testBinaryBuildCommands.Ref,
+			BuildBackend: buildRoots[testBinaryBuildCommands.Ref].BuildBackend,
 		}})
 	}
 
@@ -758,10 +837,11 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 		}
 
 		buildSteps = append(buildSteps, api.StepConfiguration{PipelineImageCacheStepConfiguration: &api.PipelineImageCacheStepConfiguration{
-			From:     api.PipelineImageStreamTagReference(from),
-			To:       api.PipelineImageStreamTagReference(rpms),
-			Commands: fmt.Sprintf(`%s; ln -s $( pwd )/%s %s`, rpmBuildCommands.Commands, out, api.RPMServeLocation),
-			Ref:      rpmBuildCommands.Ref,
+			From:         api.PipelineImageStreamTagReference(from),
+			To:           api.PipelineImageStreamTagReference(rpms),
+			Commands:     fmt.Sprintf(`%s; ln -s $( pwd )/%s %s`, rpmBuildCommands.Commands, out, api.RPMServeLocation),
+			Ref:          rpmBuildCommands.Ref,
+			BuildBackend: buildRoots[rpmBuildCommands.Ref].BuildBackend,
 		}})
 
 		buildSteps = append(buildSteps, api.StepConfiguration{RPMServeStepConfiguration: &api.RPMServeStepConfiguration{
@@ -770,6 +850,15 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 		}})
 	}
 
+	if tools := config.Tools; tools != nil {
+		buildSteps = append(buildSteps, api.StepConfiguration{PipelineImageCacheStepConfiguration: &api.PipelineImageCacheStepConfiguration{
+			From:         tools.BaseImage(),
+			To:           api.PipelineImageStreamTagReferenceTools,
+			Commands:     tools.InstallCommands(),
+			BuildBackend: buildRoots[""].BuildBackend,
+		}})
+	}
+
 	for alias, baseImage := range config.BaseImages {
 		config := api.InputImageTagStepConfiguration{
 			InputImage: api.InputImage{
@@ -794,8 +883,9 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 		buildSteps = append(buildSteps, api.StepConfiguration{InputImageTagStepConfiguration: &config})
 
 		buildSteps = append(buildSteps, api.StepConfiguration{RPMImageInjectionStepConfiguration: &api.RPMImageInjectionStepConfiguration{
-			From: intermediateTag,
-			To:   api.PipelineImageStreamTagReference(alias),
+			From:         intermediateTag,
+			To:           api.PipelineImageStreamTagReference(alias),
+			BuildBackend: buildRoots[""].BuildBackend,
 		}})
 	}
 
@@ -821,6 +911,7 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 		// Build a bundle source image that substitutes all values in `substitutions` in all `manifests` directories
 		buildSteps = append(buildSteps, api.StepConfiguration{BundleSourceStepConfiguration: &api.BundleSourceStepConfiguration{
 			Substitutions: config.Operator.Substitutions,
+			BuildBackend:  buildRoots[""].BuildBackend,
 		}})
 		// Build bundles
 		// First build named bundles and corresponding indices
@@ -836,6 +927,7 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
 					ContextDir:     bundleConfig.ContextDir,
 					DockerfilePath: bundleConfig.DockerfilePath,
+					BuildBackend:   buildRoots[""].BuildBackend,
 				},
 			}
 			buildSteps = append(buildSteps, api.StepConfiguration{ProjectDirectoryImageBuildStepConfiguration: bundle.WithBundleImage(true)})
@@ -850,12 +942,14 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 				OperatorIndex: []string{bundleConfig.As},
 				BaseIndex:     bundleConfig.BaseIndex,
 				UpdateGraph:   updateGraph,
+				BuildBackend:  buildRoots[""].BuildBackend,
 			}})
 			// Build the index
 			index := &api.ProjectDirectoryImageBuildStepConfiguration{
 				To: indexName,
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
 					DockerfilePath: steps.IndexDockerfileName,
+					BuildBackend:   buildRoots[""].BuildBackend,
 				},
 			}
 			buildSteps = append(buildSteps, api.StepConfiguration{ProjectDirectoryImageBuildStepConfiguration: index.WithBundleImage(true)})
@@ -871,6 +965,7 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
 					ContextDir:     bundle.ContextDir,
 					DockerfilePath: bundle.DockerfilePath,
+					BuildBackend:   buildRoots[""].BuildBackend,
 				},
 			}
 			buildSteps = append(buildSteps, api.StepConfiguration{ProjectDirectoryImageBuildStepConfiguration: image.WithBundleImage(true)})
@@ -881,12 +976,14 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 				To:            api.PipelineImageStreamTagReferenceIndexImageGenerator,
 				OperatorIndex: bundles,
 				UpdateGraph:   api.IndexUpdateSemver,
+				BuildBackend:  buildRoots[""].BuildBackend,
 			}})
 			// Build the index
 			image := &api.ProjectDirectoryImageBuildStepConfiguration{
 				To: api.PipelineImageStreamTagReferenceIndexImage,
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
 					DockerfilePath: steps.IndexDockerfileName,
+					BuildBackend:   buildRoots[""].BuildBackend,
 				},
 			}
 			buildSteps = append(buildSteps, api.StepConfiguration{ProjectDirectoryImageBuildStepConfiguration: image.WithBundleImage(true)})
@@ -1006,22 +1103,22 @@ func runtimeStepConfigsForBuild(
 		}
 	}
 
-	buildSteps = append(buildSteps, getSourceStepsForJobSpec(jobSpec, injectedTest)...)
+	buildSteps = append(buildSteps, getSourceStepsForJobSpec(jobSpec, injectedTest, buildRoots)...)
 
 	return buildSteps, nil
 }
 
-func getSourceStepsForJobSpec(jobSpec *api.JobSpec, injectedTest bool) []api.StepConfiguration {
+func getSourceStepsForJobSpec(jobSpec *api.JobSpec, injectedTest bool, buildRoots map[string]api.BuildRootImageConfiguration) []api.StepConfiguration {
 	var sourceSteps []api.StepConfiguration
 	primaryRef := determinePrimaryRef(jobSpec, injectedTest)
 	if primaryRef != nil {
-		sourceSteps = append(sourceSteps, sourceStepForRef(primaryRef, true))
+		sourceSteps = append(sourceSteps, sourceStepForRef(primaryRef, true, buildRoots))
 	}
 
 	// Any extra_refs for an injected test scenario are secondary refs
 	if injectedTest {
 		for _, ref := range jobSpec.ExtraRefs {
-			sourceSteps = append(sourceSteps, sourceStepForRef(&ref, false))
+			sourceSteps = append(sourceSteps, sourceStepForRef(&ref, false, buildRoots))
 		}
 	}
 
@@ -1043,7 +1140,7 @@ func determinePrimaryRef(jobSpec *api.JobSpec, injectedTest bool) *prowapi.Refs
 	return nil
 }
 
-func sourceStepForRef(ref *prowapi.Refs, primaryRef bool) api.StepConfiguration {
+func sourceStepForRef(ref *prowapi.Refs, primaryRef bool, buildRoots map[string]api.BuildRootImageConfiguration) api.StepConfiguration {
 	orgRepo := ""
 	root := api.PipelineImageStreamTagReferenceRoot
 	source := api.PipelineImageStreamTagReferenceSource
@@ -1062,6 +1159,7 @@ func sourceStepForRef(ref *prowapi.Refs, primaryRef bool) api.StepConfiguration
 		},
 		ClonerefsPath: "/clonerefs",
 		Ref:           orgRepo,
+		BuildBackend:  buildRoots[orgRepo].BuildBackend,
 	}}
 }
 
@@ -1125,6 +1223,73 @@ func buildRootImageStreamFromRepository(path string, readFile readFile) (*api.Im
 	return &config.BuildRootImage, validateCIOperatorInrepoConfig(&config)
 }
 
+// artBuildRoot is the build root the ART consistency check compares with the
+// one ART uses, read from the repository when the configuration defers to it.
+// It is nil when the images are not built from an image stream tag.
+func artBuildRoot(config *api.ReleaseBuildConfiguration, jobSpec *api.JobSpec, injectedTest bool, readFile readFile) (*api.ImageStreamTagReference, error) {
+	root := config.BuildRootImage
+	switch {
+	case root == nil:
+		return nil, nil
+	case root.FromRepository:
+		ref, err := buildRootImageStreamFromRepository(repositoryPath(config.Metadata, jobSpec, injectedTest), readFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the build root for the ART consistency check: %w", err)
+		}
+		return ref, nil
+	default:
+		return root.ImageStreamTagReference, nil
+	}
+}
+
+// repositoryPath is the path the repository the configuration belongs to is
+// cloned at, resolved like the build root of from_repository when the job
+// clones several repositories.
+func repositoryPath(metadata api.Metadata, jobSpec *api.JobSpec, injectedTest bool) string {
+	refs := jobSpec.ExtraRefs
+	if jobSpec.Refs != nil {
+		refs = append(refs, *jobSpec.Refs)
+	}
+	if len(refs) <= 1 {
+		return "."
+	}
+	var matchingRefs []prowapi.Refs
+	for _, r := range refs {
+		if r.Org == metadata.Org && r.Repo == metadata.Repo {
+			matchingRefs = append(matchingRefs, r)
+		}
+	}
+	if len(matchingRefs) == 0 {
+		primary := determinePrimaryRef(jobSpec, injectedTest)
+		if primary == nil {
+			return "."
+		}
+		matchingRefs = append(matchingRefs, *primary)
+	}
+	return decorate.DetermineWorkDir(codeMountPath, matchingRefs)
+}
+
+// cveWaiversFromRepository reads the vulnerabilities the CVE gate of the
+// promotion ignores from the repository, none are waived when the file does
+// not exist.
+func cveWaiversFromRepository(path string, readFile readFile) (api.CVEWaivers, error) {
+	var waivers api.CVEWaivers
+	data, err := readFile(fmt.Sprintf("%s/%s", path, api.CVEWaiversFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return waivers, nil
+		}
+		return waivers, fmt.Errorf("failed to read %s file: %w", api.CVEWaiversFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &waivers); err != nil {
+		return waivers, fmt.Errorf("failed to unmarshal %s: %w", api.CVEWaiversFileName, err)
+	}
+	if err := waivers.Validate(); err != nil {
+		return waivers, fmt.Errorf("invalid %s: %w", api.CVEWaiversFileName, err)
+	}
+	return waivers, nil
+}
+
 func resolveCLIOverrideImage(architecture api.ReleaseArchitecture, version string) (*coreapi.ObjectReference, error) {
 	if architecture == "" || architecture == api.ReleaseArchitectureAMD64 {
 		return nil, nil
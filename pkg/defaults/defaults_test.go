@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"sort"
 	"testing"
@@ -103,6 +105,43 @@ func TestStepConfigsForBuild(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "tools image",
+			input: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImage: &api.BuildRootImageConfiguration{
+						ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "root-ns", Name: "root-name", Tag: "manual"},
+					},
+				},
+				Tools: &api.ToolsImageConfiguration{Tools: []api.Tool{{Name: "jq", Version: "1.7.1"}}},
+			},
+			jobSpec: &api.JobSpec{
+				JobSpec: downwardapi.JobSpec{
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo"},
+				},
+			},
+			resolver: noopResolver,
+			output: []api.StepConfiguration{{
+				SourceStepConfiguration: addCloneRefs(&api.SourceStepConfiguration{
+					From: api.PipelineImageStreamTagReferenceRoot,
+					To:   api.PipelineImageStreamTagReferenceSource,
+				}),
+			}, {
+				InputImageTagStepConfiguration: &api.InputImageTagStepConfiguration{
+					InputImage: api.InputImage{
+						BaseImage: api.ImageStreamTagReference{Namespace: "root-ns", Name: "root-name", Tag: "manual"},
+						To:        api.PipelineImageStreamTagReferenceRoot,
+					},
+					Sources: []api.ImageStreamSource{{SourceType: api.ImageStreamSourceRoot}},
+				},
+			}, {
+				PipelineImageCacheStepConfiguration: &api.PipelineImageCacheStepConfiguration{
+					From:     api.PipelineImageStreamTagReferenceRoot,
+					To:       api.PipelineImageStreamTagReferenceTools,
+					Commands: (&api.ToolsImageConfiguration{Tools: []api.Tool{{Name: "jq", Version: "1.7.1"}}}).InstallCommands(),
+				},
+			}},
+		},
 		{
 			name: "minimal information provided with build cache in use",
 			input: &api.ReleaseBuildConfiguration{
@@ -660,6 +699,75 @@ func TestStepConfigsForBuild(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "rpm base image is injected with the build backend of the build root",
+			input: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImage: &api.BuildRootImageConfiguration{
+						ImageStreamTagReference: &api.ImageStreamTagReference{
+							Namespace: "root-ns",
+							Name:      "root-name",
+							Tag:       "manual",
+						},
+						BuildBackend: api.BuildBackendBuildah,
+					},
+					BaseRPMImages: map[string]api.ImageStreamTagReference{
+						"name": {
+							Namespace: "namespace",
+							Name:      "name",
+							Tag:       "tag",
+						},
+					},
+				},
+			},
+			jobSpec: &api.JobSpec{
+				JobSpec: downwardapi.JobSpec{
+					Refs: &prowapi.Refs{
+						Org:  "org",
+						Repo: "repo",
+					},
+				},
+			},
+			resolver: noopResolver,
+			output: []api.StepConfiguration{{
+				SourceStepConfiguration: addCloneRefs(&api.SourceStepConfiguration{
+					From:         api.PipelineImageStreamTagReferenceRoot,
+					To:           api.PipelineImageStreamTagReferenceSource,
+					BuildBackend: api.BuildBackendBuildah,
+				}),
+			}, {
+				InputImageTagStepConfiguration: &api.InputImageTagStepConfiguration{
+					InputImage: api.InputImage{
+						BaseImage: api.ImageStreamTagReference{
+							Namespace: "root-ns",
+							Name:      "root-name",
+							Tag:       "manual",
+						},
+						To: api.PipelineImageStreamTagReferenceRoot,
+					},
+					Sources: []api.ImageStreamSource{{SourceType: api.ImageStreamSourceRoot}},
+				},
+			}, {
+				InputImageTagStepConfiguration: &api.InputImageTagStepConfiguration{
+					InputImage: api.InputImage{
+						BaseImage: api.ImageStreamTagReference{
+							Namespace: "namespace",
+							Name:      "name",
+							Tag:       "tag",
+							As:        "name",
+						},
+						To: api.PipelineImageStreamTagReference("name-without-rpms"),
+					},
+					Sources: []api.ImageStreamSource{{SourceType: api.ImageStreamSourceBaseRpm, Name: "name"}},
+				},
+			}, {
+				RPMImageInjectionStepConfiguration: &api.RPMImageInjectionStepConfiguration{
+					From:         api.PipelineImageStreamTagReference("name-without-rpms"),
+					To:           api.PipelineImageStreamTagReference("name"),
+					BuildBackend: api.BuildBackendBuildah,
+				},
+			}},
+		},
 		{
 			name: "including an operator bundle creates the bundle-sub and the index-gen and index images",
 			input: &api.ReleaseBuildConfiguration{
@@ -1285,7 +1393,7 @@ func TestFromConfig(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	buildClient := steps.NewBuildClient(client, nil, nil, "", "")
+	buildClient := steps.NewBuildClient(client, nil, nil, "", "", nil)
 	var templateClient steps.TemplateClient
 	podClient := kubernetes.NewPodClient(client, nil, nil, 0)
 
@@ -1833,7 +1941,7 @@ func TestFromConfig(t *testing.T) {
 				params.Add(k, func() (string, error) { return v, nil })
 			}
 			graphConf := FromConfigStatic(&tc.config)
-			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.paramFiles, tc.promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, params, &secrets.DynamicCensor{}, api.ServiceDomainAPPCI, "", nil, map[string]*configresolver.IntegratedStream{}, tc.injectedTest, false)
+			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.paramFiles, tc.promote, false, client, buildClient, templateClient, podClient, leaseClient, nil, hiveClient, httpClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, params, &secrets.DynamicCensor{}, api.ServiceDomainAPPCI, "", nil, map[string]*configresolver.IntegratedStream{}, tc.injectedTest, false, nil, "", nil, nil, steps.ArtifactOptions{})
 			if diff := cmp.Diff(tc.expectedErr, err); diff != "" {
 				t.Errorf("unexpected error: %v", diff)
 			}
@@ -1904,11 +2012,170 @@ func TestRegistryDomain(t *testing.T) {
 	}
 }
 
+func TestCVEWaiversFromRepository(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		files         map[string]string
+		expected      api.CVEWaivers
+		expectedError error
+	}{
+		{
+			name: "no waivers",
+		},
+		{
+			name:     "waivers",
+			files:    map[string]string{"./.ci-operator-cve-waivers.yaml": "waivers:\n- id: CVE-2024-3094\n  images: [cli]\n  reason: not shipped\n"},
+			expected: api.CVEWaivers{Waivers: []api.CVEWaiver{{ID: "CVE-2024-3094", Images: []string{"cli"}, Reason: "not shipped"}}},
+		},
+		{
+			name:          "unexplained waiver",
+			files:         map[string]string{"./.ci-operator-cve-waivers.yaml": "waivers:\n- id: CVE-2024-3094\n"},
+			expectedError: errors.New("invalid .ci-operator-cve-waivers.yaml: waivers[0].reason: must explain why the vulnerability is acceptable"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			readFile := func(filename string) ([]byte, error) {
+				if data, ok := tc.files[filename]; ok {
+					return []byte(data), nil
+				}
+				return nil, fs.ErrNotExist
+			}
+			actual, err := cveWaiversFromRepository(".", readFile)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected waivers: %s", diff)
+			}
+		})
+	}
+}
+
+func TestARTBuildRoot(t *testing.T) {
+	builder := &api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.22-openshift-4.17"}
+	for _, tc := range []struct {
+		name          string
+		root          *api.BuildRootImageConfiguration
+		files         map[string]string
+		expected      *api.ImageStreamTagReference
+		expectedError error
+	}{
+		{
+			name: "no build root",
+		},
+		{
+			name:     "build root from an image stream tag",
+			root:     &api.BuildRootImageConfiguration{ImageStreamTagReference: builder},
+			expected: builder,
+		},
+		{
+			name:     "build root from the repository",
+			root:     &api.BuildRootImageConfiguration{FromRepository: true},
+			files:    map[string]string{"./.ci-operator.yaml": "build_root_image:\n  namespace: ocp\n  name: builder\n  tag: rhel-9-golang-1.22-openshift-4.17\n"},
+			expected: builder,
+		},
+		{
+			name:          "build root from the repository cannot be read",
+			root:          &api.BuildRootImageConfiguration{FromRepository: true},
+			expectedError: errors.New("failed to read the build root for the ART consistency check: failed to read .ci-operator.yaml file: file does not exist"),
+		},
+		{
+			name: "build root built from the repository",
+			root: &api.BuildRootImageConfiguration{ProjectImageBuild: &api.ProjectDirectoryImageBuildInputs{DockerfilePath: "Dockerfile.builder"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			readFile := func(filename string) ([]byte, error) {
+				if data, ok := tc.files[filename]; ok {
+					return []byte(data), nil
+				}
+				return nil, fs.ErrNotExist
+			}
+			config := &api.ReleaseBuildConfiguration{InputConfiguration: api.InputConfiguration{BuildRootImage: tc.root}}
+			actual, err := artBuildRoot(config, &api.JobSpec{}, false, readFile)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected build root: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepsForStepImagesPullSecret(t *testing.T) {
+	image := api.ImageStreamTagReference{Namespace: "private", Name: "tools", Tag: "latest"}
+	test := &api.MultiStageTestConfigurationLiteral{
+		Pre:  []api.LiteralTestStep{{As: "setup", FromImage: &image}},
+		Test: []api.LiteralTestStep{{As: "test", FromImage: &image, PullSecret: "private-registry"}},
+	}
+	var imageConfigs []*api.InputImageTagStepConfiguration
+	client := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build())
+	steps, err := stepsForStepImages(client, &api.JobSpec{}, inputImageSet{}, test, &imageConfigs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 1 || len(imageConfigs) != 1 {
+		t.Fatalf("expected the image to be imported once, got %d steps", len(steps))
+	}
+	if actual := imageConfigs[0].PullSecret; actual != "private-registry" {
+		t.Errorf("expected the import to use the pull secret of the step, got %q", actual)
+	}
+
+	test.Post = []api.LiteralTestStep{{As: "teardown", FromImage: &image, PullSecret: "other-registry"}}
+	imageConfigs = nil
+	_, err = stepsForStepImages(client, &api.JobSpec{}, inputImageSet{}, test, &imageConfigs, nil)
+	expectedErr := errors.New("step teardown imports private/tools:latest with the pull secret other-registry, but it is already imported with the pull secret private-registry")
+	if diff := cmp.Diff(expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error for conflicting pull secrets: %s", diff)
+	}
+}
+
+func TestRepositoryPath(t *testing.T) {
+	metadata := api.Metadata{Org: "org", Repo: "repo", Branch: "main"}
+	for _, tc := range []struct {
+		name     string
+		jobSpec  api.JobSpec
+		expected string
+	}{
+		{
+			name:     "single ref is the working directory",
+			jobSpec:  api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}},
+			expected: ".",
+		},
+		{
+			name: "ref of the configuration among several",
+			jobSpec: api.JobSpec{JobSpec: downwardapi.JobSpec{
+				Refs:      &prowapi.Refs{Org: "other", Repo: "tests"},
+				ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo"}},
+			}},
+			expected: "/home/prow/go/src/github.com/org/repo",
+		},
+		{
+			name: "primary ref when the configuration is not cloned",
+			jobSpec: api.JobSpec{JobSpec: downwardapi.JobSpec{
+				ExtraRefs: []prowapi.Refs{{Org: "first", Repo: "repo"}, {Org: "second", Repo: "repo", WorkDir: true}},
+			}},
+			expected: "/home/prow/go/src/github.com/second/repo",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := repositoryPath(metadata, &tc.jobSpec, false); actual != tc.expected {
+				t.Errorf("expected path %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestGetSourceStepsForJobSpec(t *testing.T) {
 	testCases := []struct {
 		name         string
 		jobSpec      api.JobSpec
 		injectedTest bool
+		buildRoots   map[string]api.BuildRootImageConfiguration
 		expected     []api.StepConfiguration
 	}{
 		{
@@ -1932,6 +2199,27 @@ func TestGetSourceStepsForJobSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "build backend of the build root is used for the source",
+			jobSpec: api.JobSpec{
+				JobSpec: downwardapi.JobSpec{
+					Type: "postsubmit",
+					Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "ABCD"},
+				},
+			},
+			buildRoots: map[string]api.BuildRootImageConfiguration{"": {BuildBackend: api.BuildBackendBuildah}},
+			expected: []api.StepConfiguration{
+				{
+					SourceStepConfiguration: &api.SourceStepConfiguration{
+						From:           api.PipelineImageStreamTagReferenceRoot,
+						To:             api.PipelineImageStreamTagReferenceSource,
+						ClonerefsImage: api.ImageStreamTagReference{Namespace: "ci", Name: "managed-clonerefs", Tag: "latest"},
+						ClonerefsPath:  "/clonerefs",
+						BuildBackend:   api.BuildBackendBuildah,
+					},
+				},
+			},
+		},
 		{
 			name: "rehearsal presubmit should include only the extra_ref with no suffix",
 			jobSpec: api.JobSpec{
@@ -2061,7 +2349,7 @@ func TestGetSourceStepsForJobSpec(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := getSourceStepsForJobSpec(&tc.jobSpec, tc.injectedTest)
+			result := getSourceStepsForJobSpec(&tc.jobSpec, tc.injectedTest, tc.buildRoots)
 			less := func(a, b api.StepConfiguration) bool {
 				return a.SourceStepConfiguration.Ref < b.SourceStepConfiguration.Ref
 			}
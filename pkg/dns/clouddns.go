@@ -0,0 +1,52 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dnsv1 "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+type cloudDNSClient struct {
+	rrsets *dnsv1.ResourceRecordSetsService
+}
+
+// NewCloudDNSClient creates a client for GCP Cloud DNS using the application
+// default credentials, including external account credentials.
+func NewCloudDNSClient(ctx context.Context, opts ...option.ClientOption) (Client, error) {
+	service, err := dnsv1.NewService(ctx, append([]option.ClientOption{option.WithScopes(dnsv1.NdevClouddnsReadwriteScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Cloud DNS client: %w", err)
+	}
+	return &cloudDNSClient{rrsets: service.ResourceRecordSets}, nil
+}
+
+// Upsert replaces the record set, creating it when it does not exist.
+func (c *cloudDNSClient) Upsert(ctx context.Context, record Record) error {
+	project, zone, _ := strings.Cut(record.Zone, "/")
+	set := &dnsv1.ResourceRecordSet{Name: record.fqdn(), Type: record.Type, Ttl: record.TTL, Rrdatas: record.Values}
+	_, err := c.rrsets.Patch(project, zone, record.fqdn(), record.Type, set).Context(ctx).Do()
+	if isNotFound(err) {
+		_, err = c.rrsets.Create(project, zone, set).Context(ctx).Do()
+	}
+	return err
+}
+
+// Delete removes the record set, succeeding when it is gone.
+func (c *cloudDNSClient) Delete(ctx context.Context, record Record) error {
+	project, zone, _ := strings.Cut(record.Zone, "/")
+	if _, err := c.rrsets.Delete(project, zone, record.fqdn(), record.Type).Context(ctx).Do(); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
@@ -0,0 +1,231 @@
+// Package dns manages DNS records created by tests in the zones of cloud DNS
+// providers. Every record is registered in a file in the shared directory of
+// the test before it is created, so that it can be removed in the `post`
+// phase even when the step that created it failed.
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RecordsFile is the file in the shared directory of a test where the managed
+// records are registered for cleanup.
+const RecordsFile = "dns-records.json"
+
+// Provider is a cloud DNS service.
+type Provider string
+
+const (
+	// ProviderAWS is AWS Route 53.
+	ProviderAWS Provider = "aws"
+	// ProviderGCP is GCP Cloud DNS.
+	ProviderGCP Provider = "gcp"
+)
+
+// Record is a DNS record set managed by a test.
+type Record struct {
+	// Provider is the DNS service hosting the zone.
+	Provider Provider `json:"provider"`
+	// Zone is the ID of the hosted zone in Route 53 or `<project>/<zone>` for
+	// a managed zone in Cloud DNS.
+	Zone string `json:"zone"`
+	// Name is the fully qualified domain name of the record.
+	Name string `json:"name"`
+	// Type is the type of the record, e.g. `A` or `CNAME`.
+	Type string `json:"type"`
+	// TTL is the time to live of the record in seconds.
+	TTL int64 `json:"ttl"`
+	// Values are the values of the record.
+	Values []string `json:"values"`
+}
+
+// fqdn is the name of the record with the trailing dot.
+func (r Record) fqdn() string {
+	return strings.TrimSuffix(r.Name, ".") + "."
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%s %s record %s in zone %s", r.Provider, r.Type, r.fqdn(), r.Zone)
+}
+
+// same determines whether the records identify the same record set.
+func (r Record) same(other Record) bool {
+	return r.Provider == other.Provider && r.Zone == other.Zone && r.fqdn() == other.fqdn() && r.Type == other.Type
+}
+
+var recordTypes = []string{"A", "AAAA", "CNAME", "TXT", "SRV"}
+
+// Validate checks that the record can be created.
+func (r Record) Validate() error {
+	var errs []error
+	switch r.Provider {
+	case ProviderAWS:
+	case ProviderGCP:
+		if parts := strings.Split(r.Zone, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			errs = append(errs, fmt.Errorf("zone: %q must be in the format <project>/<zone> for %s", r.Zone, r.Provider))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("provider: expected one of %s or %s, got %q", ProviderAWS, ProviderGCP, r.Provider))
+	}
+	if r.Zone == "" {
+		errs = append(errs, errors.New("zone cannot be empty"))
+	}
+	if msgs := validation.IsDNS1123Subdomain(strings.TrimPrefix(strings.TrimSuffix(r.Name, "."), "*.")); len(msgs) != 0 {
+		errs = append(errs, fmt.Errorf("name: %q is not a valid domain name: %s", r.Name, strings.Join(msgs, ", ")))
+	}
+	var validType bool
+	for _, t := range recordTypes {
+		validType = validType || r.Type == t
+	}
+	if !validType {
+		errs = append(errs, fmt.Errorf("type: expected one of %s, got %q", strings.Join(recordTypes, ", "), r.Type))
+	}
+	if r.TTL <= 0 {
+		errs = append(errs, errors.New("ttl must be positive"))
+	}
+	if len(r.Values) == 0 {
+		errs = append(errs, errors.New("at least one value is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// Client creates and deletes records in a DNS provider.
+type Client interface {
+	// Upsert creates the record or replaces its values if it exists.
+	Upsert(ctx context.Context, record Record) error
+	// Delete removes the record, succeeding if it does not exist.
+	Delete(ctx context.Context, record Record) error
+}
+
+// ClientFactory creates a client for a provider.
+type ClientFactory func(ctx context.Context, provider Provider) (Client, error)
+
+// NewClient creates a client for the provider using the default credentials
+// of the environment, which include workload identity.
+func NewClient(ctx context.Context, provider Provider) (Client, error) {
+	switch provider {
+	case ProviderAWS:
+		return NewRoute53Client(ctx)
+	case ProviderGCP:
+		return NewCloudDNSClient(ctx)
+	default:
+		return nil, fmt.Errorf("unknown DNS provider %q", provider)
+	}
+}
+
+// Registered returns the records registered in the file.
+func Registered(path string) ([]Record, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read registered records: %w", err)
+	}
+	var records []Record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse registered records: %w", err)
+	}
+	return records, nil
+}
+
+func writeRegistered(path string, records []Record) error {
+	raw, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registered records: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write registered records: %w", err)
+	}
+	return nil
+}
+
+// Upsert registers the record in the file for cleanup and creates it.
+func Upsert(ctx context.Context, client Client, path string, record Record) error {
+	if err := record.Validate(); err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+	records, err := Registered(path)
+	if err != nil {
+		return err
+	}
+	var updated bool
+	for i := range records {
+		if records[i].same(record) {
+			records[i], updated = record, true
+		}
+	}
+	if !updated {
+		records = append(records, record)
+	}
+	if err := writeRegistered(path, records); err != nil {
+		return err
+	}
+	if err := client.Upsert(ctx, record); err != nil {
+		return fmt.Errorf("failed to upsert %s: %w", record, err)
+	}
+	return nil
+}
+
+// Delete removes the record and unregisters it from the file.
+func Delete(ctx context.Context, client Client, path string, record Record) error {
+	records, err := Registered(path)
+	if err != nil {
+		return err
+	}
+	for _, registered := range records {
+		// deleting a record in Route 53 requires its current values
+		if registered.same(record) && len(record.Values) == 0 {
+			record.Values, record.TTL = registered.Values, registered.TTL
+		}
+	}
+	if err := client.Delete(ctx, record); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", record, err)
+	}
+	var remaining []Record
+	for _, registered := range records {
+		if !registered.same(record) {
+			remaining = append(remaining, registered)
+		}
+	}
+	return writeRegistered(path, remaining)
+}
+
+// Cleanup deletes all records registered in the file, in the reverse order of
+// their creation. Records that could not be deleted stay registered.
+func Cleanup(ctx context.Context, newClient ClientFactory, path string) error {
+	records, err := Registered(path)
+	if err != nil {
+		return err
+	}
+	clients := map[Provider]Client{}
+	var errs []error
+	var remaining []Record
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		client, ok := clients[record.Provider]
+		if !ok {
+			if client, err = newClient(ctx, record.Provider); err != nil {
+				errs = append(errs, fmt.Errorf("failed to create client for %s: %w", record, err))
+				remaining = append([]Record{record}, remaining...)
+				continue
+			}
+			clients[record.Provider] = client
+		}
+		if err := client.Delete(ctx, record); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", record, err))
+			remaining = append([]Record{record}, remaining...)
+		}
+	}
+	if err := writeRegistered(path, remaining); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
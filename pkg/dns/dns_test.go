@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeClient struct {
+	upserted []Record
+	deleted  []Record
+	failFor  string
+}
+
+func (c *fakeClient) Upsert(_ context.Context, record Record) error {
+	c.upserted = append(c.upserted, record)
+	return nil
+}
+
+func (c *fakeClient) Delete(_ context.Context, record Record) error {
+	if record.Name == c.failFor {
+		return errors.New("injected failure")
+	}
+	c.deleted = append(c.deleted, record)
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		record   Record
+		expected error
+	}{
+		{
+			name:   "valid record",
+			record: Record{Provider: ProviderAWS, Zone: "Z123", Name: "*.apps.ci.example.com.", Type: "A", TTL: 60, Values: []string{"10.0.0.1"}},
+		},
+		{
+			name:     "invalid record",
+			record:   Record{Provider: ProviderGCP, Zone: "zone", Name: "Not_Valid", Type: "MX"},
+			expected: errors.New("zone: \"zone\" must be in the format <project>/<zone> for gcp\nname: \"Not_Valid\" is not a valid domain name: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')\ntype: expected one of A, AAAA, CNAME, TXT, SRV, got \"MX\"\nttl must be positive\nat least one value is required"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.record.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestUpsertDeleteCleanup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RecordsFile)
+	client := &fakeClient{}
+	ctx := context.Background()
+	api := Record{Provider: ProviderAWS, Zone: "Z123", Name: "api.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1"}}
+	apps := Record{Provider: ProviderAWS, Zone: "Z123", Name: "*.apps.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.2"}}
+	extra := Record{Provider: ProviderAWS, Zone: "Z123", Name: "extra.ci.example.com", Type: "CNAME", TTL: 60, Values: []string{"api.ci.example.com"}}
+	for _, record := range []Record{api, apps, extra} {
+		if err := Upsert(ctx, client, path, record); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+	updated := api
+	updated.Values = []string{"10.0.0.3"}
+	if err := Upsert(ctx, client, path, updated); err != nil {
+		t.Fatalf("failed to upsert: %v", err)
+	}
+	if err := Delete(ctx, client, path, Record{Provider: ProviderAWS, Zone: "Z123", Name: "extra.ci.example.com.", Type: "CNAME"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	deleted := extra
+	deleted.Name = "extra.ci.example.com."
+	if diff := cmp.Diff([]Record{deleted}, client.deleted); diff != "" {
+		t.Errorf("unexpected deleted records: %s", diff)
+	}
+	registered, err := Registered(path)
+	if err != nil {
+		t.Fatalf("failed to read registered records: %v", err)
+	}
+	if diff := cmp.Diff([]Record{updated, apps}, registered); diff != "" {
+		t.Errorf("unexpected registered records: %s", diff)
+	}
+
+	client = &fakeClient{failFor: api.Name}
+	newClient := func(context.Context, Provider) (Client, error) { return client, nil }
+	expectedErr := errors.New("failed to delete aws A record api.ci.example.com. in zone Z123: injected failure")
+	if diff := cmp.Diff(expectedErr, Cleanup(ctx, newClient, path), testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+	if diff := cmp.Diff([]Record{apps}, client.deleted); diff != "" {
+		t.Errorf("unexpected deleted records: %s", diff)
+	}
+	if registered, err = Registered(path); err != nil {
+		t.Fatalf("failed to read registered records: %v", err)
+	}
+	if diff := cmp.Diff([]Record{updated}, registered); diff != "" {
+		t.Errorf("unexpected records left registered: %s", diff)
+	}
+}
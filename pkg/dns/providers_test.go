@@ -0,0 +1,151 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/api/option"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type request struct {
+	method, path, body string
+}
+
+func fakeServer(t *testing.T, status func(r *http.Request) int, requests *[]request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		*requests = append(*requests, request{method: r.Method, path: r.URL.Path, body: strings.TrimSpace(string(body))})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status(r))
+		if _, err := w.Write([]byte("{}")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type fakeRoute53 struct {
+	changes []route53types.Change
+	// records are the record sets of the zones, by zone
+	records map[string][]route53types.ResourceRecordSet
+	errs    map[string]error
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSets(_ context.Context, params *route53.ChangeResourceRecordSetsInput, _ ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.changes = append(f.changes, params.ChangeBatch.Changes...)
+	return &route53.ChangeResourceRecordSetsOutput{}, f.errs[aws.ToString(params.HostedZoneId)]
+}
+
+func (f *fakeRoute53) ListResourceRecordSets(_ context.Context, params *route53.ListResourceRecordSetsInput, _ ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: f.records[aws.ToString(params.HostedZoneId)]}, nil
+}
+
+func TestRoute53Client(t *testing.T) {
+	rejected := &route53types.InvalidChangeBatch{Message: aws.String("Tried to delete resource record set but it was not found")}
+	record := Record{Provider: ProviderAWS, Zone: "/hostedzone/Z123", Name: "api.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1"}}
+	for _, tc := range []struct {
+		name        string
+		zone        string
+		records     []route53types.ResourceRecordSet
+		err         error
+		expectedErr error
+	}{
+		{
+			name: "record is deleted",
+			zone: "Z123",
+		},
+		{
+			name: "zone does not exist anymore",
+			zone: "Zmissing",
+			err:  &route53types.NoSuchHostedZone{Message: aws.String("No hosted zone found with ID: Zmissing")},
+		},
+		{
+			name:    "record does not exist anymore",
+			zone:    "Z123",
+			records: []route53types.ResourceRecordSet{{Name: aws.String("other.ci.example.com."), Type: route53types.RRTypeA}},
+			err:     rejected,
+		},
+		{
+			name:        "record which exists is rejected",
+			zone:        "Z123",
+			records:     []route53types.ResourceRecordSet{{Name: aws.String("api.ci.example.com."), Type: route53types.RRTypeA}},
+			err:         rejected,
+			expectedErr: errors.New("InvalidChangeBatch: Tried to delete resource record set but it was not found"),
+		},
+		{
+			name:        "other errors",
+			zone:        "Z123",
+			err:         errors.New("throttled"),
+			expectedErr: errors.New("throttled"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeRoute53{records: map[string][]route53types.ResourceRecordSet{tc.zone: tc.records}}
+			client := &route53Client{client: fake}
+			record := record
+			record.Zone = tc.zone
+			if err := client.Upsert(context.Background(), record); err != nil {
+				t.Fatalf("failed to upsert: %v", err)
+			}
+			fake.errs = map[string]error{tc.zone: tc.err}
+			err := client.Delete(context.Background(), record)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			set := &route53types.ResourceRecordSet{Name: aws.String("api.ci.example.com."), Type: route53types.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("10.0.0.1")}}}
+			expected := []route53types.Change{{Action: route53types.ChangeActionUpsert, ResourceRecordSet: set}, {Action: route53types.ChangeActionDelete, ResourceRecordSet: set}}
+			if diff := cmp.Diff(expected, fake.changes, cmpopts.IgnoreUnexported(route53types.Change{}, route53types.ResourceRecordSet{}, route53types.ResourceRecord{})); diff != "" {
+				t.Errorf("unexpected changes: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCloudDNSClient(t *testing.T) {
+	var requests []request
+	server := fakeServer(t, func(r *http.Request) int {
+		if r.Method != http.MethodPost && strings.Contains(r.URL.Path, "new.ci.example.com") {
+			return http.StatusNotFound
+		}
+		return http.StatusOK
+	}, &requests)
+	client, err := NewCloudDNSClient(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	existing := Record{Provider: ProviderGCP, Zone: "project/zone", Name: "api.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.1"}}
+	created := Record{Provider: ProviderGCP, Zone: "project/zone", Name: "new.ci.example.com", Type: "A", TTL: 60, Values: []string{"10.0.0.2"}}
+	for _, record := range []Record{existing, created} {
+		if err := client.Upsert(context.Background(), record); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+	if err := client.Delete(context.Background(), created); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	expected := []request{
+		{method: http.MethodPatch, path: "/dns/v1/projects/project/managedZones/zone/rrsets/api.ci.example.com./A", body: `{"name":"api.ci.example.com.","rrdatas":["10.0.0.1"],"ttl":60,"type":"A"}`},
+		{method: http.MethodPatch, path: "/dns/v1/projects/project/managedZones/zone/rrsets/new.ci.example.com./A", body: `{"name":"new.ci.example.com.","rrdatas":["10.0.0.2"],"ttl":60,"type":"A"}`},
+		{method: http.MethodPost, path: "/dns/v1/projects/project/managedZones/zone/rrsets", body: `{"name":"new.ci.example.com.","rrdatas":["10.0.0.2"],"ttl":60,"type":"A"}`},
+		{method: http.MethodDelete, path: "/dns/v1/projects/project/managedZones/zone/rrsets/new.ci.example.com./A"},
+	}
+	if diff := cmp.Diff(expected, requests, cmp.AllowUnexported(request{})); diff != "" {
+		t.Errorf("unexpected requests: %s", diff)
+	}
+}
@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// route53Region is the region requests to the global Route 53 API are signed for.
+const route53Region = "us-east-1"
+
+type route53API interface {
+	ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error)
+	ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error)
+}
+
+type route53Client struct {
+	client route53API
+}
+
+// NewRoute53Client creates a client for AWS Route 53 using the default AWS
+// credential chain, including web identity tokens.
+func NewRoute53Client(ctx context.Context) (Client, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(route53Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return &route53Client{client: route53.NewFromConfig(cfg)}, nil
+}
+
+func (c *route53Client) Upsert(ctx context.Context, record Record) error {
+	return c.change(ctx, route53types.ChangeActionUpsert, record)
+}
+
+// Delete removes the record set. Records in zones which no longer exist are
+// gone, and so are records the change batch is rejected for when they cannot
+// be found anymore.
+func (c *route53Client) Delete(ctx context.Context, record Record) error {
+	err := c.change(ctx, route53types.ChangeActionDelete, record)
+	var noSuchZone *route53types.NoSuchHostedZone
+	var invalidBatch *route53types.InvalidChangeBatch
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &noSuchZone):
+		return nil
+	case errors.As(err, &invalidBatch):
+		exists, listErr := c.exists(ctx, record)
+		if listErr != nil {
+			return fmt.Errorf("%w (failed to determine whether the record still exists: %v)", err, listErr)
+		}
+		if !exists {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *route53Client) change(ctx context.Context, action route53types.ChangeAction, record Record) error {
+	var values []route53types.ResourceRecord
+	for _, value := range record.Values {
+		values = append(values, route53types.ResourceRecord{Value: aws.String(value)})
+	}
+	_, err := c.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID(record)),
+		ChangeBatch: &route53types.ChangeBatch{Changes: []route53types.Change{{
+			Action: action,
+			ResourceRecordSet: &route53types.ResourceRecordSet{
+				Name:            aws.String(record.fqdn()),
+				Type:            route53types.RRType(record.Type),
+				TTL:             aws.Int64(record.TTL),
+				ResourceRecords: values,
+			},
+		}}},
+	})
+	return err
+}
+
+// exists determines whether the zone holds a record set with the name and type
+// of the record, which are listed first when starting at them.
+func (c *route53Client) exists(ctx context.Context, record Record) (bool, error) {
+	out, err := c.client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID(record)),
+		StartRecordName: aws.String(record.fqdn()),
+		StartRecordType: route53types.RRType(record.Type),
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, set := range out.ResourceRecordSets {
+		if strings.EqualFold(aws.ToString(set.Name), record.fqdn()) && string(set.Type) == record.Type {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func zoneID(record Record) string {
+	return strings.TrimPrefix(record.Zone, "/hostedzone/")
+}
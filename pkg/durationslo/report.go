@@ -0,0 +1,82 @@
+package durationslo
+
+import (
+	"sort"
+	"time"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// Run holds the measurements recorded by a single run of a job.
+type Run struct {
+	Job          string
+	Measurements []Measurement
+}
+
+// Policy determines which tests are chronic violators.
+type Policy struct {
+	// MinRuns is the number of runs of a test needed to judge it.
+	MinRuns int
+	// Threshold is the fraction of runs over the expected duration from which
+	// a test is a chronic violator.
+	Threshold float64
+}
+
+// Violator is a test that chronically runs over its expected duration.
+type Violator struct {
+	Job        string           `json:"job"`
+	Test       string           `json:"test"`
+	Expected   *prowv1.Duration `json:"expected"`
+	Runs       int              `json:"runs"`
+	Violations int              `json:"violations"`
+	// Median is the median actual duration over all runs.
+	Median *prowv1.Duration `json:"median"`
+}
+
+// ChronicViolators aggregates the runs per job and test and returns the tests
+// that ran over their expected duration in at least the fraction of runs set
+// by the policy. The expected duration of the last run is reported, as the
+// declaration may have changed over time.
+func ChronicViolators(runs []Run, policy Policy) []Violator {
+	type key struct{ job, test string }
+	measurements := map[key][]Measurement{}
+	for _, run := range runs {
+		for _, m := range run.Measurements {
+			k := key{job: run.Job, test: m.Test}
+			measurements[k] = append(measurements[k], m)
+		}
+	}
+	var violators []Violator
+	for k, ms := range measurements {
+		if len(ms) < policy.MinRuns {
+			continue
+		}
+		var violations int
+		actual := make([]time.Duration, 0, len(ms))
+		for _, m := range ms {
+			if m.Violated() {
+				violations++
+			}
+			actual = append(actual, m.Actual)
+		}
+		if violations == 0 || float64(violations)/float64(len(ms)) < policy.Threshold {
+			continue
+		}
+		sort.Slice(actual, func(i, j int) bool { return actual[i] < actual[j] })
+		violators = append(violators, Violator{
+			Job:        k.job,
+			Test:       k.test,
+			Expected:   &prowv1.Duration{Duration: ms[len(ms)-1].Expected},
+			Runs:       len(ms),
+			Violations: violations,
+			Median:     &prowv1.Duration{Duration: actual[len(actual)/2]},
+		})
+	}
+	sort.Slice(violators, func(i, j int) bool {
+		if violators[i].Job != violators[j].Job {
+			return violators[i].Job < violators[j].Job
+		}
+		return violators[i].Test < violators[j].Test
+	})
+	return violators
+}
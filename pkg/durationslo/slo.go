@@ -0,0 +1,113 @@
+// Package durationslo compares the duration of tests with the duration they
+// are expected to take, records the measurements in the JUnit results of
+// ci-operator and reports the tests that chronically run over it.
+package durationslo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const (
+	// PropertyPrefix prefixes the names of the JUnit properties that hold
+	// the measurements.
+	PropertyPrefix = "duration-slo/"
+
+	expectedSuffix = "/expected"
+	actualSuffix   = "/actual"
+)
+
+// Measurement is the actual duration of a single run of a test next to its
+// expected duration.
+type Measurement struct {
+	Test     string
+	Expected time.Duration
+	Actual   time.Duration
+}
+
+// Violated determines whether the test ran over its expected duration.
+func (m Measurement) Violated() bool {
+	return m.Actual > m.Expected
+}
+
+// Measure matches the tests that declare an expected duration with the
+// details of the steps that ran them.
+func Measure(tests []api.TestStepConfiguration, details []api.CIOperatorStepDetails) []Measurement {
+	expected := map[string]time.Duration{}
+	for _, test := range tests {
+		if test.ExpectedDuration != nil {
+			expected[test.As] = test.ExpectedDuration.Duration
+		}
+	}
+	var measurements []Measurement
+	for _, detail := range details {
+		duration, ok := expected[detail.StepName]
+		if !ok || detail.Duration == nil {
+			continue
+		}
+		measurements = append(measurements, Measurement{Test: detail.StepName, Expected: duration, Actual: *detail.Duration})
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Test < measurements[j].Test
+	})
+	return measurements
+}
+
+// Properties serializes the measurements as JUnit properties.
+func Properties(measurements []Measurement) []*junit.TestSuiteProperty {
+	var properties []*junit.TestSuiteProperty
+	for _, m := range measurements {
+		properties = append(properties,
+			&junit.TestSuiteProperty{Name: PropertyPrefix + m.Test + expectedSuffix, Value: m.Expected.String()},
+			&junit.TestSuiteProperty{Name: PropertyPrefix + m.Test + actualSuffix, Value: m.Actual.String()},
+		)
+	}
+	return properties
+}
+
+// FromProperties parses the measurements out of JUnit properties, ignoring
+// all unrelated ones. Tests missing either of the durations are skipped.
+func FromProperties(properties []*junit.TestSuiteProperty) ([]Measurement, error) {
+	byTest := map[string]*Measurement{}
+	seen := map[string]int{}
+	for _, property := range properties {
+		if !strings.HasPrefix(property.Name, PropertyPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(property.Name, PropertyPrefix)
+		var test string
+		var field func(*Measurement) *time.Duration
+		switch {
+		case strings.HasSuffix(name, expectedSuffix):
+			test, field = strings.TrimSuffix(name, expectedSuffix), func(m *Measurement) *time.Duration { return &m.Expected }
+		case strings.HasSuffix(name, actualSuffix):
+			test, field = strings.TrimSuffix(name, actualSuffix), func(m *Measurement) *time.Duration { return &m.Actual }
+		default:
+			continue
+		}
+		duration, err := time.ParseDuration(property.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in property %s: %w", property.Name, err)
+		}
+		if _, ok := byTest[test]; !ok {
+			byTest[test] = &Measurement{Test: test}
+		}
+		*field(byTest[test]) = duration
+		seen[test]++
+	}
+	var measurements []Measurement
+	for test, m := range byTest {
+		if seen[test] == 2 {
+			measurements = append(measurements, *m)
+		}
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Test < measurements[j].Test
+	})
+	return measurements, nil
+}
@@ -0,0 +1,85 @@
+package durationslo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestMeasureRoundTrip(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+	tests := []api.TestStepConfiguration{
+		{As: "unit"},
+		{As: "e2e", ExpectedDuration: &prowv1.Duration{Duration: 45 * time.Minute}},
+		{As: "lint", ExpectedDuration: &prowv1.Duration{Duration: 10 * time.Minute}},
+		{As: "not-run", ExpectedDuration: &prowv1.Duration{Duration: time.Hour}},
+	}
+	details := []api.CIOperatorStepDetails{
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "unit", Duration: duration(time.Hour)}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "lint", Duration: duration(5 * time.Minute)}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "e2e", Duration: duration(50 * time.Minute)}},
+	}
+	measurements := Measure(tests, details)
+	expected := []Measurement{
+		{Test: "e2e", Expected: 45 * time.Minute, Actual: 50 * time.Minute},
+		{Test: "lint", Expected: 10 * time.Minute, Actual: 5 * time.Minute},
+	}
+	if diff := cmp.Diff(expected, measurements); diff != "" {
+		t.Fatalf("unexpected measurements: %s", diff)
+	}
+	if !measurements[0].Violated() || measurements[1].Violated() {
+		t.Errorf("expected only e2e to violate its expected duration")
+	}
+
+	properties := append(Properties(measurements),
+		&junit.TestSuiteProperty{Name: "unrelated", Value: "value"},
+		&junit.TestSuiteProperty{Name: PropertyPrefix + "incomplete" + expectedSuffix, Value: "1h0m0s"},
+	)
+	parsed, err := FromProperties(properties)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, parsed); diff != "" {
+		t.Errorf("unexpected parsed measurements: %s", diff)
+	}
+
+	_, err = FromProperties([]*junit.TestSuiteProperty{{Name: PropertyPrefix + "e2e" + actualSuffix, Value: "long"}})
+	if diff := cmp.Diff(errors.New(`invalid duration in property duration-slo/e2e/actual: time: invalid duration "long"`), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
+
+func TestChronicViolators(t *testing.T) {
+	run := func(job string, actual ...time.Duration) []Run {
+		var runs []Run
+		for _, a := range actual {
+			runs = append(runs, Run{Job: job, Measurements: []Measurement{{Test: "e2e", Expected: 45 * time.Minute, Actual: a}}})
+		}
+		return runs
+	}
+	var runs []Run
+	runs = append(runs, run("chronic", 50*time.Minute, 40*time.Minute, time.Hour)...)
+	runs = append(runs, run("occasional", 50*time.Minute, 40*time.Minute, 30*time.Minute)...)
+	runs = append(runs, run("rare", time.Hour, time.Hour)...)
+	runs = append(runs, run("fast", 10*time.Minute, 10*time.Minute, 10*time.Minute)...)
+
+	violators := ChronicViolators(runs, Policy{MinRuns: 3, Threshold: 0.5})
+	expected := []Violator{{
+		Job:        "chronic",
+		Test:       "e2e",
+		Expected:   &prowv1.Duration{Duration: 45 * time.Minute},
+		Runs:       3,
+		Violations: 2,
+		Median:     &prowv1.Duration{Duration: 50 * time.Minute},
+	}}
+	if diff := cmp.Diff(expected, violators); diff != "" {
+		t.Errorf("unexpected violators: %s", diff)
+	}
+}
@@ -0,0 +1,217 @@
+// Package featuregate governs behaviors of ci-operator which are risky to
+// enable for all jobs at once, so that the infrastructure can roll them out
+// gradually to the jobs of each organization and turn them off again without
+// changing the jobs.
+package featuregate
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Gate is a behavior that can be turned on and off per job.
+type Gate string
+
+const (
+	// BuildCache reuses pipeline images built from identical inputs by
+	// earlier jobs, when --build-cache-namespace is set.
+	BuildCache Gate = "BuildCache"
+	// ResultReuse reports earlier results of presubmit targets whose inputs
+	// did not change, when --result-reuse-namespace is set.
+	ResultReuse Gate = "ResultReuse"
+	// StreamArtifacts streams the artifacts of template tests to the bucket
+	// of the job, when --stream-artifacts is set.
+	StreamArtifacts Gate = "StreamArtifacts"
+	// StreamStepLogs uploads the logs of multi-stage steps while they run,
+	// when --stream-step-logs is set.
+	StreamStepLogs Gate = "StreamStepLogs"
+)
+
+// defaults holds whether the known gates are enabled when neither a flag nor
+// a rollout sets them. Gates which change the outcome of jobs stay disabled
+// until they are rolled out, those which only change how the outcome is
+// reported are enabled.
+var defaults = map[Gate]bool{
+	BuildCache:      false,
+	ResultReuse:     false,
+	StreamArtifacts: true,
+	StreamStepLogs:  true,
+}
+
+// Known returns the names of the known gates, sorted.
+func Known() []Gate {
+	return sortedGates(defaults)
+}
+
+// Configuration holds the rollout of the gates to the jobs of the
+// infrastructure, usually mounted from a ConfigMap.
+type Configuration struct {
+	// Gates maps gates to their rollout.
+	Gates map[Gate]Rollout `json:"gates"`
+}
+
+// Rollout enables a gate for a percentage of the jobs. Whether a job is in
+// the percentage is determined by its name, so a job keeps its gates between
+// runs as long as the rollout is not changed.
+type Rollout struct {
+	// Percentage of the jobs the gate is enabled for, unless their
+	// organization has its own. The gate keeps its default when unset.
+	Percentage *int `json:"percentage,omitempty"`
+	// Orgs maps organizations to the percentage of their jobs the gate is
+	// enabled for.
+	Orgs map[string]int `json:"orgs,omitempty"`
+}
+
+// LoadConfiguration reads and validates a rollout configuration file. The
+// file is mounted from an optional ConfigMap into generated jobs, so a missing
+// file is not an error: there is no configuration and the gates keep their
+// defaults.
+func LoadConfiguration(path string) (*Configuration, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the feature gate configuration: %w", err)
+	}
+	var c Configuration
+	if err := yaml.UnmarshalStrict(raw, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse the feature gate configuration: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid feature gate configuration: %w", err)
+	}
+	return &c, nil
+}
+
+// Validate checks that only known gates are rolled out and that percentages
+// are between 0 and 100.
+func (c *Configuration) Validate() error {
+	var errs []error
+	validate := func(gate Gate, what string, percentage int) {
+		if percentage < 0 || percentage > 100 {
+			errs = append(errs, fmt.Errorf("gate %s: %s must be between 0 and 100, got %d", gate, what, percentage))
+		}
+	}
+	for _, gate := range sortedGates(c.Gates) {
+		if _, known := defaults[gate]; !known {
+			errs = append(errs, fmt.Errorf("unknown gate %s", gate))
+			continue
+		}
+		rollout := c.Gates[gate]
+		if rollout.Percentage != nil {
+			validate(gate, "percentage", *rollout.Percentage)
+		}
+		var orgs []string
+		for org := range rollout.Orgs {
+			orgs = append(orgs, org)
+		}
+		sort.Strings(orgs)
+		for _, org := range orgs {
+			validate(gate, fmt.Sprintf("percentage of organization %s", org), rollout.Orgs[org])
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func sortedGates[V any](m map[Gate]V) []Gate {
+	var gates []Gate
+	for gate := range m {
+		gates = append(gates, gate)
+	}
+	sort.Slice(gates, func(i, j int) bool { return gates[i] < gates[j] })
+	return gates
+}
+
+// Overrides holds the gates set explicitly for a run, which take precedence
+// over any rollout. It is set from a flag in the Gate=true,Gate=false format.
+type Overrides map[Gate]bool
+
+func (o *Overrides) String() string {
+	if o == nil {
+		return ""
+	}
+	var pairs []string
+	for _, gate := range sortedGates(*o) {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", gate, (*o)[gate]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (o *Overrides) Set(value string) error {
+	if *o == nil {
+		*o = Overrides{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, raw, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("%q is not in the Gate=true|false format", pair)
+		}
+		gate := Gate(strings.TrimSpace(name))
+		if _, known := defaults[gate]; !known {
+			return fmt.Errorf("unknown gate %s", gate)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid value for gate %s: %w", gate, err)
+		}
+		(*o)[gate] = enabled
+	}
+	return nil
+}
+
+// Gates holds whether each known gate is enabled for a job.
+type Gates map[Gate]bool
+
+// Enabled determines whether the gate is enabled.
+func (g Gates) Enabled(gate Gate) bool {
+	return g[gate]
+}
+
+// Resolve determines the gates of a job of the organization. Overrides take
+// precedence, then the rollout to the organization, then the rollout to all
+// jobs and finally the default of the gate. The configuration may be nil.
+func Resolve(config *Configuration, overrides Overrides, org, job string) Gates {
+	gates := Gates{}
+	for gate, enabled := range defaults {
+		if override, set := overrides[gate]; set {
+			gates[gate] = override
+			continue
+		}
+		gates[gate] = enabled
+		if config == nil {
+			continue
+		}
+		rollout, configured := config.Gates[gate]
+		if !configured {
+			continue
+		}
+		if percentage, set := rollout.Orgs[org]; set {
+			gates[gate] = inRollout(gate, job, percentage)
+		} else if rollout.Percentage != nil {
+			gates[gate] = inRollout(gate, job, *rollout.Percentage)
+		}
+	}
+	return gates
+}
+
+// inRollout places the job in one of a hundred buckets per gate, so that the
+// jobs a gate is enabled for are independent of the other gates.
+func inRollout(gate Gate, job string, percentage int) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(string(gate) + "/" + job))
+	return int(h.Sum32()%100) < percentage
+}
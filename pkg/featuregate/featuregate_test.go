@@ -0,0 +1,181 @@
+package featuregate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLoadConfiguration(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		raw           string
+		missing       bool
+		expected      *Configuration
+		expectedError error
+	}{
+		{
+			name:    "missing file",
+			missing: true,
+		},
+		{
+			name: "valid rollout",
+			raw: `gates:
+  ResultReuse:
+    percentage: 10
+    orgs:
+      openshift: 50
+`,
+			expected: &Configuration{Gates: map[Gate]Rollout{ResultReuse: {Percentage: ptr.To(10), Orgs: map[string]int{"openshift": 50}}}},
+		},
+		{
+			name: "invalid percentages and unknown gate",
+			raw: `gates:
+  ResultReuse:
+    percentage: 110
+    orgs:
+      openshift: -1
+  SpeculativeBuilds:
+    percentage: 10
+`,
+			expectedError: errors.New("invalid feature gate configuration: [gate ResultReuse: percentage must be between 0 and 100, got 110, gate ResultReuse: percentage of organization openshift must be between 0 and 100, got -1, unknown gate SpeculativeBuilds]"),
+		},
+		{
+			name:          "unknown field",
+			raw:           "gates: {}\nrollout: 10\n",
+			expectedError: errors.New(`failed to parse the feature gate configuration: error unmarshaling JSON: while decoding JSON: json: unknown field "rollout"`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if !tc.missing {
+				if err := os.WriteFile(path, []byte(tc.raw), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			actual, err := LoadConfiguration(path)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected configuration: %s", diff)
+			}
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		values        []string
+		expected      Overrides
+		expectedError error
+	}{
+		{
+			name:     "gates set in one or more flags",
+			values:   []string{"ResultReuse=false, BuildCache=true", "StreamArtifacts=false"},
+			expected: Overrides{ResultReuse: false, BuildCache: true, StreamArtifacts: false},
+		},
+		{
+			name:          "unknown gate",
+			values:        []string{"SpeculativeBuilds=true"},
+			expected:      Overrides{},
+			expectedError: errors.New("unknown gate SpeculativeBuilds"),
+		},
+		{
+			name:          "missing value",
+			values:        []string{"ResultReuse"},
+			expected:      Overrides{},
+			expectedError: errors.New(`"ResultReuse" is not in the Gate=true|false format`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var overrides Overrides
+			var err error
+			for _, value := range tc.values {
+				if err = overrides.Set(value); err != nil {
+					break
+				}
+			}
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, overrides); diff != "" {
+				t.Errorf("unexpected overrides: %s", diff)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	config := &Configuration{Gates: map[Gate]Rollout{
+		BuildCache:  {Percentage: ptr.To(0), Orgs: map[string]int{"openshift": 100}},
+		ResultReuse: {Orgs: map[string]int{"openshift": 0}},
+	}}
+	for _, tc := range []struct {
+		name      string
+		config    *Configuration
+		overrides Overrides
+		org       string
+		expected  Gates
+	}{
+		{
+			name:     "defaults without configuration",
+			org:      "openshift",
+			expected: Gates{BuildCache: false, ResultReuse: false, StreamArtifacts: true, StreamStepLogs: true},
+		},
+		{
+			name:     "rollout to the organization",
+			config:   config,
+			org:      "openshift",
+			expected: Gates{BuildCache: true, ResultReuse: false, StreamArtifacts: true, StreamStepLogs: true},
+		},
+		{
+			name:     "rollout to other organizations",
+			config:   config,
+			org:      "openshift-priv",
+			expected: Gates{BuildCache: false, ResultReuse: false, StreamArtifacts: true, StreamStepLogs: true},
+		},
+		{
+			name:      "overrides take precedence",
+			config:    config,
+			overrides: Overrides{ResultReuse: true, StreamArtifacts: false},
+			org:       "openshift",
+			expected:  Gates{BuildCache: true, ResultReuse: true, StreamArtifacts: false, StreamStepLogs: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Resolve(tc.config, tc.overrides, tc.org, "pull-ci-openshift-ci-tools-master-unit")); diff != "" {
+				t.Errorf("unexpected gates: %s", diff)
+			}
+		})
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	var enabled int
+	for i := 0; i < 1000; i++ {
+		job := fmt.Sprintf("periodic-%d", i)
+		in := inRollout(ResultReuse, job, 30)
+		if in != inRollout(ResultReuse, job, 30) {
+			t.Fatalf("expected the rollout of %s to be stable", job)
+		}
+		if in && !inRollout(ResultReuse, job, 60) {
+			t.Errorf("expected %s to stay in the rollout when it grows", job)
+		}
+		if in {
+			enabled++
+		}
+	}
+	if enabled < 250 || enabled > 350 {
+		t.Errorf("expected about 30%% of the jobs in the rollout, got %d of 1000", enabled)
+	}
+}
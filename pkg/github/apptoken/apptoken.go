@@ -0,0 +1,153 @@
+// Package apptoken mints GitHub App installation tokens scoped to the
+// repositories of a job, so that jobs do not need a long-lived token with
+// access to every repository.
+package apptoken
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBaseURL = "https://api.github.com"
+	// refreshMargin is how long before its expiry a token is replaced.
+	refreshMargin = 10 * time.Minute
+	// retryInterval is how long to wait before retrying a failed refresh.
+	retryInterval = time.Minute
+)
+
+// Permissions are the permissions of the minted tokens: enough to clone the
+// repositories and report statuses on their commits.
+var Permissions = map[string]string{
+	"contents": "read",
+	"statuses": "write",
+}
+
+// Token is an installation token.
+type Token struct {
+	Value     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Minter mints installation tokens of a GitHub App.
+type Minter struct {
+	appID   string
+	key     *rsa.PrivateKey
+	client  *http.Client
+	baseURL string
+	now     func() time.Time
+}
+
+// NewMinter loads the private key of the GitHub App.
+func NewMinter(appID, privateKeyPath string) (*Minter, error) {
+	raw, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return &Minter{appID: appID, key: key, client: &http.Client{Timeout: time.Minute}, baseURL: defaultBaseURL, now: time.Now}, nil
+}
+
+// Mint mints a token of the installation of the App in the organization,
+// scoped to the repositories.
+func (m *Minter) Mint(ctx context.Context, org string, repos []string) (*Token, error) {
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	if err := m.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/installation", org, repos[0]), nil, &installation); err != nil {
+		return nil, fmt.Errorf("failed to find the installation for %s/%s: %w", org, repos[0], err)
+	}
+	request := struct {
+		Repositories []string          `json:"repositories"`
+		Permissions  map[string]string `json:"permissions"`
+	}{Repositories: repos, Permissions: Permissions}
+	var token Token
+	if err := m.do(ctx, http.MethodPost, fmt.Sprintf("/app/installations/%d/access_tokens", installation.ID), request, &token); err != nil {
+		return nil, fmt.Errorf("failed to mint token for installation %d: %w", installation.ID, err)
+	}
+	return &token, nil
+}
+
+func (m *Minter) do(ctx context.Context, method, path string, body, into interface{}) error {
+	now := m.now()
+	// GitHub rejects tokens valid for more than ten minutes and its clock may
+	// be slightly off, so the token is issued in the past.
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(9 * time.Minute).Unix(),
+		Issuer:    m.appID,
+	}).SignedString(m.key)
+	if err != nil {
+		return fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to construct request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+signed)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("got unexpected http status code %d, response body: %s", resp.StatusCode, string(raw))
+	}
+	return json.Unmarshal(raw, into)
+}
+
+// Refresh replaces the token before it expires until the context is done,
+// passing every new token to update. Failures are retried every minute, as
+// long-running jobs cannot clone or report statuses once the token expired.
+func Refresh(ctx context.Context, token *Token, mint func(context.Context) (*Token, error), update func(*Token) error) {
+	refreshTimer(ctx, token, mint, update, time.Now, time.After)
+}
+
+func refreshTimer(ctx context.Context, token *Token, mint func(context.Context) (*Token, error), update func(*Token) error, now func() time.Time, after func(time.Duration) <-chan time.Time) {
+	next := token.ExpiresAt.Sub(now()) - refreshMargin
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-after(next):
+		}
+		refreshed, err := mint(ctx)
+		if err == nil {
+			err = update(refreshed)
+		}
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to refresh the GitHub App token, will retry.")
+			next = retryInterval
+			continue
+		}
+		logrus.Debugf("Refreshed the GitHub App token, it expires at %s.", refreshed.ExpiresAt.Format(time.RFC3339))
+		token = refreshed
+		next = token.ExpiresAt.Sub(now()) - refreshMargin
+	}
+}
@@ -0,0 +1,136 @@
+package apptoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestMint(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+
+	testCases := []struct {
+		name          string
+		installation  int
+		expected      *Token
+		expectedError error
+	}{
+		{
+			name:         "token is minted for the repositories",
+			installation: http.StatusOK,
+			expected:     &Token{Value: "ghs_token", ExpiresAt: expiresAt},
+		},
+		{
+			name:          "app is not installed",
+			installation:  http.StatusNotFound,
+			expectedError: errors.New(`failed to find the installation for org/repo: got unexpected http status code 404, response body: {"message":"Not Found"}`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				claims := jwt.StandardClaims{}
+				if _, err := jwt.ParseWithClaims(bearer, &claims, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil }); err != nil {
+					t.Errorf("invalid JWT: %v", err)
+				}
+				if claims.Issuer != "1234" {
+					t.Errorf("unexpected issuer %q", claims.Issuer)
+				}
+				switch r.URL.Path {
+				case "/repos/org/repo/installation":
+					w.WriteHeader(tc.installation)
+					if tc.installation != http.StatusOK {
+						_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"id":42}`))
+				case "/app/installations/42/access_tokens":
+					var request struct {
+						Repositories []string          `json:"repositories"`
+						Permissions  map[string]string `json:"permissions"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+						t.Errorf("failed to decode request: %v", err)
+					}
+					if diff := cmp.Diff([]string{"repo", "other"}, request.Repositories); diff != "" {
+						t.Errorf("unexpected repositories: %s", diff)
+					}
+					if diff := cmp.Diff(Permissions, request.Permissions); diff != "" {
+						t.Errorf("unexpected permissions: %s", diff)
+					}
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"token":"ghs_token","expires_at":"2024-05-01T13:00:00Z"}`))
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+			// the JWT is validated against the current time
+			m := &Minter{appID: "1234", key: key, client: server.Client(), baseURL: server.URL, now: time.Now}
+			token, err := m.Mint(context.Background(), "org", []string{"repo", "other"})
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, token); diff != "" {
+				t.Errorf("unexpected token: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	after := func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		if len(waits) == 4 {
+			cancel()
+			return nil
+		}
+		ch := make(chan time.Time, 1)
+		ch <- now
+		return ch
+	}
+	mints := 0
+	mint := func(context.Context) (*Token, error) {
+		mints++
+		if mints == 1 {
+			return nil, errors.New("injected failure")
+		}
+		return &Token{Value: "refreshed", ExpiresAt: now.Add(time.Hour)}, nil
+	}
+	var updated []string
+	update := func(token *Token) error {
+		updated = append(updated, token.Value)
+		return nil
+	}
+	refreshTimer(ctx, &Token{Value: "initial", ExpiresAt: now.Add(30 * time.Minute)}, mint, update, func() time.Time { return now }, after)
+
+	if diff := cmp.Diff([]time.Duration{20 * time.Minute, time.Minute, 50 * time.Minute, 50 * time.Minute}, waits); diff != "" {
+		t.Errorf("unexpected waits: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"refreshed", "refreshed"}, updated); diff != "" {
+		t.Errorf("unexpected updates: %s", diff)
+	}
+}
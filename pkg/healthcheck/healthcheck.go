@@ -0,0 +1,191 @@
+// Package healthcheck asserts the health of a cluster with a set of checks,
+// reported as jUnit test cases.
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const (
+	// JUnitFile is the name of the jUnit file reporting the checks.
+	JUnitFile = "junit_cluster_health.xml"
+	// apiLatencyThreshold is the longest a request to the API server may
+	// take for the server to be considered responsive.
+	apiLatencyThreshold = 5 * time.Second
+)
+
+type check struct {
+	description string
+	run         func(ctx context.Context, client ctrlruntimeclient.Client) error
+}
+
+var checks = map[api.HealthCheck]check{
+	api.HealthCheckAPIResponsive:             {description: "API server is responsive", run: apiResponsive},
+	api.HealthCheckClusterOperatorsAvailable: {description: "Cluster operators are available", run: clusterOperatorsAvailable},
+	api.HealthCheckNodesReady:                {description: "Nodes are ready", run: nodesReady},
+	api.HealthCheckNoPendingCSRs:             {description: "No certificate signing requests are pending", run: noPendingCSRs},
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Check    api.HealthCheck
+	Duration time.Duration
+	// Err describes why the check failed, nil when it passed.
+	Err error
+}
+
+// Run runs the checks in order. Failing checks are retried every interval
+// until they pass or the wait elapses; they run only once when wait is zero.
+func Run(ctx context.Context, client ctrlruntimeclient.Client, names []api.HealthCheck, wait, interval time.Duration) []Result {
+	var results []Result
+	for _, name := range names {
+		start := time.Now()
+		err := runCheck(ctx, client, checks[name], wait, interval)
+		results = append(results, Result{Check: name, Duration: time.Since(start), Err: err})
+	}
+	return results
+}
+
+func runCheck(ctx context.Context, client ctrlruntimeclient.Client, c check, waitFor, interval time.Duration) error {
+	if c.run == nil {
+		return errors.New("unknown check")
+	}
+	if waitFor == 0 {
+		return c.run(ctx, client)
+	}
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, interval, waitFor, true, func(ctx context.Context) (bool, error) {
+		lastErr = c.run(ctx, client)
+		return lastErr == nil, nil
+	})
+	if err != nil && lastErr != nil {
+		return fmt.Errorf("still failing after %s: %w", waitFor, lastErr)
+	}
+	return err
+}
+
+// problems aggregates the problems found by a check into an error.
+func problems(found []string) error {
+	if len(found) == 0 {
+		return nil
+	}
+	sort.Strings(found)
+	return errors.New(strings.Join(found, "\n"))
+}
+
+func apiResponsive(ctx context.Context, client ctrlruntimeclient.Client) error {
+	start := time.Now()
+	if err := client.List(ctx, &coreapi.NamespaceList{}, ctrlruntimeclient.Limit(1)); err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	if latency := time.Since(start); latency > apiLatencyThreshold {
+		return fmt.Errorf("listing namespaces took %s, longer than %s", latency.Round(time.Millisecond), apiLatencyThreshold)
+	}
+	return nil
+}
+
+func operatorCondition(operator configv1.ClusterOperator, conditionType configv1.ClusterStatusConditionType) *configv1.ClusterOperatorStatusCondition {
+	for i := range operator.Status.Conditions {
+		if operator.Status.Conditions[i].Type == conditionType {
+			return &operator.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func clusterOperatorsAvailable(ctx context.Context, client ctrlruntimeclient.Client) error {
+	operators := &configv1.ClusterOperatorList{}
+	if err := client.List(ctx, operators); err != nil {
+		return fmt.Errorf("failed to list cluster operators: %w", err)
+	}
+	if len(operators.Items) == 0 {
+		return errors.New("no cluster operators found")
+	}
+	var found []string
+	for _, operator := range operators.Items {
+		if c := operatorCondition(operator, configv1.OperatorAvailable); c == nil {
+			found = append(found, fmt.Sprintf("ClusterOperator %s does not report Available", operator.Name))
+		} else if c.Status != configv1.ConditionTrue {
+			found = append(found, fmt.Sprintf("ClusterOperator %s Available=%s: %s: %s", operator.Name, c.Status, c.Reason, c.Message))
+		}
+		if c := operatorCondition(operator, configv1.OperatorDegraded); c != nil && c.Status == configv1.ConditionTrue {
+			found = append(found, fmt.Sprintf("ClusterOperator %s Degraded=True: %s: %s", operator.Name, c.Reason, c.Message))
+		}
+	}
+	return problems(found)
+}
+
+func nodesReady(ctx context.Context, client ctrlruntimeclient.Client) error {
+	nodes := &coreapi.NodeList{}
+	if err := client.List(ctx, nodes); err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return errors.New("no nodes found")
+	}
+	var found []string
+	for _, node := range nodes.Items {
+		var ready *coreapi.NodeCondition
+		for i := range node.Status.Conditions {
+			if node.Status.Conditions[i].Type == coreapi.NodeReady {
+				ready = &node.Status.Conditions[i]
+			}
+		}
+		if ready == nil {
+			found = append(found, fmt.Sprintf("Node %s does not report Ready", node.Name))
+		} else if ready.Status != coreapi.ConditionTrue {
+			found = append(found, fmt.Sprintf("Node %s Ready=%s: %s: %s", node.Name, ready.Status, ready.Reason, ready.Message))
+		}
+	}
+	return problems(found)
+}
+
+func noPendingCSRs(ctx context.Context, client ctrlruntimeclient.Client) error {
+	csrs := &certificatesv1.CertificateSigningRequestList{}
+	if err := client.List(ctx, csrs); err != nil {
+		return fmt.Errorf("failed to list certificate signing requests: %w", err)
+	}
+	var found []string
+	for _, csr := range csrs.Items {
+		if len(csr.Status.Conditions) == 0 {
+			found = append(found, fmt.Sprintf("CertificateSigningRequest %s from %s is pending", csr.Name, csr.Spec.Username))
+		}
+	}
+	return problems(found)
+}
+
+// JUnit reports every check as a test case.
+func JUnit(results []Result) *junit.TestSuites {
+	suite := &junit.TestSuite{Name: "cluster-health"}
+	for _, result := range results {
+		testCase := &junit.TestCase{
+			Name:     fmt.Sprintf("[cluster-health] %s", checks[result.Check].description),
+			Duration: result.Duration.Seconds(),
+		}
+		if result.Err != nil {
+			testCase.FailureOutput = &junit.FailureOutput{
+				Message: fmt.Sprintf("check %s failed", result.Check),
+				Output:  result.Err.Error(),
+			}
+			suite.NumFailed++
+		}
+		suite.Duration += testCase.Duration
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.NumTests = uint(len(suite.TestCases))
+	return &junit.TestSuites{Suites: []*junit.TestSuite{suite}}
+}
@@ -0,0 +1,157 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	configv1 "github.com/openshift/api/config/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func operator(name string, conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: configv1.ClusterOperatorStatus{Conditions: conditions}}
+}
+
+func node(name string, conditions ...coreapi.NodeCondition) *coreapi.Node {
+	return &coreapi.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: coreapi.NodeStatus{Conditions: conditions}}
+}
+
+var (
+	available = configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}
+	ready     = coreapi.NodeCondition{Type: coreapi.NodeReady, Status: coreapi.ConditionTrue}
+	approved  = &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "csr-approved"},
+		Status:     certificatesv1.CertificateSigningRequestStatus{Conditions: []certificatesv1.CertificateSigningRequestCondition{{Type: certificatesv1.CertificateApproved, Status: coreapi.ConditionTrue}}},
+	}
+	healthy = []ctrlruntimeclient.Object{
+		&coreapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		operator("etcd", available),
+		node("master-0", ready),
+		approved,
+	}
+)
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		objs []ctrlruntimeclient.Object
+		// readyAfter makes the nodes ready only after the given number of lists
+		readyAfter int
+		wait       time.Duration
+		expected   []Result
+	}{
+		{
+			name: "healthy cluster",
+			objs: healthy,
+			expected: []Result{
+				{Check: api.HealthCheckAPIResponsive},
+				{Check: api.HealthCheckClusterOperatorsAvailable},
+				{Check: api.HealthCheckNodesReady},
+				{Check: api.HealthCheckNoPendingCSRs},
+			},
+		},
+		{
+			name: "unhealthy cluster",
+			objs: []ctrlruntimeclient.Object{
+				operator("etcd", available),
+				operator("network", available, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "RolloutHung", Message: "DaemonSet is not progressing"}),
+				operator("console"),
+				node("master-0", ready),
+				node("worker-0", coreapi.NodeCondition{Type: coreapi.NodeReady, Status: coreapi.ConditionFalse, Reason: "KubeletNotReady", Message: "PLEG is not healthy"}),
+				approved,
+				&certificatesv1.CertificateSigningRequest{ObjectMeta: metav1.ObjectMeta{Name: "csr-pending"}, Spec: certificatesv1.CertificateSigningRequestSpec{Username: "system:node:worker-1"}},
+			},
+			expected: []Result{
+				{Check: api.HealthCheckAPIResponsive},
+				{Check: api.HealthCheckClusterOperatorsAvailable, Err: errors.New("ClusterOperator console does not report Available\nClusterOperator network Degraded=True: RolloutHung: DaemonSet is not progressing")},
+				{Check: api.HealthCheckNodesReady, Err: errors.New("Node worker-0 Ready=False: KubeletNotReady: PLEG is not healthy")},
+				{Check: api.HealthCheckNoPendingCSRs, Err: errors.New("CertificateSigningRequest csr-pending from system:node:worker-1 is pending")},
+			},
+		},
+		{
+			name:       "checks are retried",
+			objs:       healthy,
+			readyAfter: 2,
+			wait:       time.Second,
+			expected: []Result{
+				{Check: api.HealthCheckAPIResponsive},
+				{Check: api.HealthCheckClusterOperatorsAvailable},
+				{Check: api.HealthCheckNodesReady},
+				{Check: api.HealthCheckNoPendingCSRs},
+			},
+		},
+		{
+			name:       "checks are not retried without wait",
+			objs:       healthy,
+			readyAfter: 2,
+			expected: []Result{
+				{Check: api.HealthCheckAPIResponsive},
+				{Check: api.HealthCheckClusterOperatorsAvailable},
+				{Check: api.HealthCheckNodesReady, Err: errors.New("no nodes found")},
+				{Check: api.HealthCheckNoPendingCSRs},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := configv1.Install(scheme); err != nil {
+				t.Fatal(err)
+			}
+			var nodeLists int
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(tc.objs...).WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, client ctrlruntimeclient.WithWatch, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) error {
+					if nodes, ok := list.(*coreapi.NodeList); ok {
+						if nodeLists++; nodeLists < tc.readyAfter {
+							nodes.Items = nil
+							return nil
+						}
+					}
+					return client.List(ctx, list, opts...)
+				},
+			}).Build()
+			results := Run(context.Background(), client, api.HealthChecks, tc.wait, time.Millisecond)
+			if diff := cmp.Diff(tc.expected, results, testhelper.EquateErrorMessage, cmpopts.IgnoreFields(Result{}, "Duration")); diff != "" {
+				t.Errorf("unexpected results: %s", diff)
+			}
+		})
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	results := []Result{
+		{Check: api.HealthCheckAPIResponsive, Duration: time.Second},
+		{Check: api.HealthCheckNodesReady, Duration: 2 * time.Second, Err: errors.New("Node worker-0 does not report Ready")},
+	}
+	expected := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		Name:      "cluster-health",
+		NumTests:  2,
+		NumFailed: 1,
+		Duration:  3,
+		TestCases: []*junit.TestCase{
+			{Name: "[cluster-health] API server is responsive", Duration: 1},
+			{Name: "[cluster-health] Nodes are ready", Duration: 2, FailureOutput: &junit.FailureOutput{Message: "check nodes_ready failed", Output: "Node worker-0 does not report Ready"}},
+		},
+	}}}
+	if diff := cmp.Diff(expected, JUnit(results)); diff != "" {
+		t.Errorf("unexpected jUnit: %s", diff)
+	}
+}
@@ -0,0 +1,382 @@
+// Package installconfig composes the install-config.yaml of a cluster from
+// the data of its cluster profile and YAML patches parameterized by the test,
+// and validates the result against the schema of the installer.
+package installconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	installertypes "github.com/openshift/installer/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// Filename is the name of the install-config in the shared directory.
+	Filename = "install-config.yaml"
+	// pullSecretFile, sshKeyFile and baseDomainFile are the files of the
+	// cluster profile holding the data of the install-config.
+	pullSecretFile = "pull-secret"
+	sshKeyFile     = "ssh-publickey"
+	baseDomainFile = "baseDomain"
+	// redacted replaces secrets in the copy of the install-config kept in
+	// the artifacts.
+	redacted = "REDACTED"
+)
+
+// redactedFields are the top-level fields of the install-config holding
+// secrets or data specific to the environment of the cluster profile.
+var redactedFields = []string{"pullSecret", "sshKey", "additionalTrustBundle"}
+
+// platformCredentials are the fields holding credentials in the settings of
+// the platforms, at any depth: vSphere and Nutanix list the user and password
+// of their endpoints, bare metal those of the BMCs of its hosts.
+var platformCredentials = sets.New[string]("user", "username", "password", "clientSecret", "apiKey", "token")
+
+// platformsWithRegion are the platforms whose region is the leased resource.
+var platformsWithRegion = sets.New[string]("aws", "azure", "gcp", "ibmcloud", "powervs")
+
+// platformPrefixes map cluster types to the platform of the installer.
+var platformPrefixes = []struct{ prefix, platform string }{
+	{prefix: "aws", platform: "aws"},
+	{prefix: "azure", platform: "azure"},
+	{prefix: "gcp", platform: "gcp"},
+	{prefix: "ibmcloud", platform: "ibmcloud"},
+	{prefix: "nutanix", platform: "nutanix"},
+	{prefix: "openstack", platform: "openstack"},
+	{prefix: "powervs", platform: "powervs"},
+	{prefix: "vsphere", platform: "vsphere"},
+}
+
+// Config is an install-config being composed.
+type Config map[string]interface{}
+
+// Profile is the data used to generate a minimal install-config.
+type Profile struct {
+	Name        string
+	ClusterType string
+	Region      string
+	BaseDomain  string
+	PullSecret  string
+	SSHKey      string
+	// NetworkStack is the IP stack of the network of the cluster.
+	NetworkStack api.NetworkStack
+}
+
+// LoadProfile reads the data of the install-config from the cluster profile
+// directory and the environment of the step.
+func LoadProfile(profileDir string, getenv func(string) string) (Profile, error) {
+	profile := Profile{
+		Name:         fmt.Sprintf("%s-%s", getenv("NAMESPACE"), getenv("UNIQUE_HASH")),
+		ClusterType:  getenv("CLUSTER_TYPE"),
+		Region:       getenv("LEASED_RESOURCE"),
+		BaseDomain:   getenv("BASE_DOMAIN"),
+		NetworkStack: api.NetworkStack(getenv(api.NetworkStackEnv)).OrDefault(),
+	}
+	var errs []error
+	if getenv("NAMESPACE") == "" || getenv("UNIQUE_HASH") == "" {
+		errs = append(errs, errors.New("$NAMESPACE and $UNIQUE_HASH are required to name the cluster"))
+	}
+	if err := profile.NetworkStack.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid $%s: %w", api.NetworkStackEnv, err))
+	}
+	read := func(name string, required bool) string {
+		raw, err := os.ReadFile(filepath.Join(profileDir, name))
+		if err != nil {
+			if required || !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("failed to read %s from the cluster profile: %w", name, err))
+			}
+			return ""
+		}
+		return strings.TrimSpace(string(raw))
+	}
+	profile.PullSecret = read(pullSecretFile, true)
+	profile.SSHKey = read(sshKeyFile, false)
+	if profile.BaseDomain == "" {
+		profile.BaseDomain = read(baseDomainFile, false)
+	}
+	return profile, utilerrors.NewAggregate(errs)
+}
+
+// Platform is the platform of the installer for the cluster type.
+func Platform(clusterType string) string {
+	for _, p := range platformPrefixes {
+		if strings.HasPrefix(clusterType, p.prefix) {
+			return p.platform
+		}
+	}
+	return ""
+}
+
+// Skeleton generates a minimal install-config from the profile. The platform
+// is left empty for cluster types without an obvious platform, for patches to
+// set.
+func Skeleton(profile Profile) Config {
+	config := Config{
+		"apiVersion": "v1",
+		"metadata":   map[string]interface{}{"name": profile.Name},
+		"baseDomain": profile.BaseDomain,
+		"pullSecret": profile.PullSecret,
+		"platform":   map[string]interface{}{},
+	}
+	if profile.SSHKey != "" {
+		config["sshKey"] = profile.SSHKey
+	}
+	if platform := Platform(profile.ClusterType); platform != "" {
+		settings := map[string]interface{}{}
+		if platformsWithRegion.Has(platform) && profile.Region != "" {
+			settings["region"] = profile.Region
+		}
+		config["platform"] = map[string]interface{}{platform: settings}
+	}
+	if networking := networkingFor(profile.NetworkStack); networking != nil {
+		config["networking"] = networking
+	}
+	return config
+}
+
+// networkingFor generates the cluster and service networks of a network stack,
+// or nil for IPv4, for which the defaults of the installer are used. Machine
+// networks depend on the environment of the cluster and are left for patches
+// to set.
+func networkingFor(stack api.NetworkStack) map[string]interface{} {
+	ipv4Cluster := map[string]interface{}{"cidr": "10.128.0.0/14", "hostPrefix": 23}
+	ipv6Cluster := map[string]interface{}{"cidr": "fd01::/48", "hostPrefix": 64}
+	var clusterNetwork, serviceNetwork []interface{}
+	switch stack {
+	case api.NetworkStackIPv6:
+		clusterNetwork = []interface{}{ipv6Cluster}
+		serviceNetwork = []interface{}{"fd02::/112"}
+	case api.NetworkStackDualStack:
+		clusterNetwork = []interface{}{ipv4Cluster, ipv6Cluster}
+		serviceNetwork = []interface{}{"172.30.0.0/16", "fd02::/112"}
+	default:
+		return nil
+	}
+	return map[string]interface{}{
+		"networkType":    "OVNKubernetes",
+		"clusterNetwork": clusterNetwork,
+		"serviceNetwork": serviceNetwork,
+	}
+}
+
+// Load reads an install-config.
+func Load(path string) (Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install-config: %w", err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse install-config: %w", err)
+	}
+	return config, nil
+}
+
+var parameterRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandParameters replaces `${NAME}` references in the patch with the values
+// of the parameters. Referencing a parameter that is not set is an error.
+func ExpandParameters(patch string, lookup func(string) (string, bool)) (string, error) {
+	missing := sets.New[string]()
+	expanded := parameterRegexp.ReplaceAllStringFunc(patch, func(reference string) string {
+		name := parameterRegexp.FindStringSubmatch(reference)[1]
+		value, ok := lookup(name)
+		if !ok {
+			missing.Insert(name)
+		}
+		return value
+	})
+	if missing.Len() != 0 {
+		return "", fmt.Errorf("undefined parameters: %s", strings.Join(sets.List(missing), ", "))
+	}
+	return expanded, nil
+}
+
+// Apply merges the patches into the install-config, in order.
+func (c Config) Apply(patches []string, lookup func(string) (string, bool)) error {
+	for i, patch := range patches {
+		expanded, err := ExpandParameters(patch, lookup)
+		if err != nil {
+			return fmt.Errorf("patch %d: %w", i, err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(expanded), &parsed); err != nil {
+			return fmt.Errorf("patch %d: failed to parse: %w", i, err)
+		}
+		merge(c, parsed)
+	}
+	return nil
+}
+
+// merge merges src into dst. Mappings are merged recursively, other values
+// from src replace the ones in dst.
+func merge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// Validate checks that the install-config conforms to the schema of the
+// installer: unknown fields and values of the wrong type are rejected, and the
+// fields every installation needs must be set. The settings of the platforms
+// are only checked for the types of their values, as they change between the
+// versions of the installer and a test may install a newer version than the
+// one vendored here.
+func (c Config) Validate() error {
+	stable, err := json.Marshal(c.withoutPlatformSettings())
+	if err != nil {
+		return fmt.Errorf("failed to marshal install-config: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(stable))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&installertypes.InstallConfig{}); err != nil {
+		return fmt.Errorf("install-config does not match the installer schema: %w", err)
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal install-config: %w", err)
+	}
+	config := &installertypes.InstallConfig{}
+	if err := json.Unmarshal(raw, config); err != nil {
+		return fmt.Errorf("install-config does not match the installer schema: %w", err)
+	}
+	var errs []error
+	if config.APIVersion != installertypes.InstallConfigVersion {
+		errs = append(errs, fmt.Errorf("apiVersion must be %s, got %q", installertypes.InstallConfigVersion, config.APIVersion))
+	}
+	if config.ObjectMeta.Name == "" {
+		errs = append(errs, errors.New("metadata.name is required"))
+	}
+	if config.BaseDomain == "" {
+		errs = append(errs, errors.New("baseDomain is required"))
+	}
+	if config.PullSecret == "" {
+		errs = append(errs, errors.New("pullSecret is required"))
+	}
+	if platforms := c.platforms(); len(platforms) != 1 {
+		errs = append(errs, fmt.Errorf("exactly one platform must be configured, got %d: %v", len(platforms), platforms))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// withoutPlatformSettings is a copy of the install-config in which the
+// settings of the platform and of the platforms of the machine pools are
+// empty.
+func (c Config) withoutPlatformSettings() Config {
+	ret := Config{}
+	for key, value := range c {
+		ret[key] = value
+	}
+	if platform, ok := c["platform"].(map[string]interface{}); ok {
+		ret["platform"] = emptySettings(platform)
+	}
+	if pool, ok := c["controlPlane"].(map[string]interface{}); ok {
+		ret["controlPlane"] = withoutPoolPlatform(pool)
+	}
+	if pools, ok := c["compute"].([]interface{}); ok {
+		compute := make([]interface{}, 0, len(pools))
+		for _, item := range pools {
+			if pool, ok := item.(map[string]interface{}); ok {
+				item = withoutPoolPlatform(pool)
+			}
+			compute = append(compute, item)
+		}
+		ret["compute"] = compute
+	}
+	return ret
+}
+
+func withoutPoolPlatform(pool map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(pool))
+	for key, value := range pool {
+		ret[key] = value
+	}
+	if platform, ok := pool["platform"].(map[string]interface{}); ok {
+		ret["platform"] = emptySettings(platform)
+	}
+	return ret
+}
+
+// emptySettings keeps the names of the platforms, without their settings.
+func emptySettings(platform map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(platform))
+	for name := range platform {
+		ret[name] = map[string]interface{}{}
+	}
+	return ret
+}
+
+func (c Config) platforms() []string {
+	platform, _ := c["platform"].(map[string]interface{})
+	var ret []string
+	for name := range platform {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Marshal serializes the install-config.
+func (c Config) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// Redacted is a copy of the install-config without secrets, for the artifacts.
+func (c Config) Redacted() Config {
+	ret := Config{}
+	for key, value := range c {
+		ret[key] = value
+	}
+	for _, field := range redactedFields {
+		if _, ok := ret[field]; ok {
+			ret[field] = redacted
+		}
+	}
+	if platform, ok := ret["platform"]; ok {
+		ret["platform"] = redactCredentials(platform)
+	}
+	return ret
+}
+
+// redactCredentials returns a copy of the value with the platform credentials
+// it holds at any depth redacted.
+func redactCredentials(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if platformCredentials.Has(key) {
+				ret[key] = redacted
+				continue
+			}
+			ret[key] = redactCredentials(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			ret = append(ret, redactCredentials(item))
+		}
+		return ret
+	default:
+		return value
+	}
+}
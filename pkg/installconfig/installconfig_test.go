@@ -0,0 +1,208 @@
+package installconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLoadProfileAndSkeleton(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"pull-secret": "{\"auths\":{}}\n", "ssh-publickey": "ssh-rsa AAAA\n", "baseDomain": "ci.example.com\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := map[string]string{"NAMESPACE": "ci-op-abc", "UNIQUE_HASH": "xyz", "CLUSTER_TYPE": "aws-arm64", "LEASED_RESOURCE": "us-east-2"}
+	profile, err := LoadProfile(dir, func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Config{
+		"apiVersion": "v1",
+		"metadata":   map[string]interface{}{"name": "ci-op-abc-xyz"},
+		"baseDomain": "ci.example.com",
+		"pullSecret": `{"auths":{}}`,
+		"sshKey":     "ssh-rsa AAAA",
+		"platform":   map[string]interface{}{"aws": map[string]interface{}{"region": "us-east-2"}},
+	}
+	if diff := cmp.Diff(expected, Skeleton(profile)); diff != "" {
+		t.Errorf("unexpected skeleton: %s", diff)
+	}
+
+	_, err = LoadProfile(t.TempDir(), func(string) string { return "" })
+	if err == nil {
+		t.Fatal("expected an error for an empty profile")
+	}
+
+	env["NETWORK_STACK"] = "ipv5"
+	if _, err := LoadProfile(dir, func(name string) string { return env[name] }); err == nil {
+		t.Fatal("expected an error for an invalid network stack")
+	}
+}
+
+func TestSkeletonNetworking(t *testing.T) {
+	profile := Profile{Name: "ci-op-abc-xyz", ClusterType: "vsphere", BaseDomain: "ci.example.com", PullSecret: "{}"}
+	for _, tc := range []struct {
+		stack    api.NetworkStack
+		expected interface{}
+	}{
+		{stack: api.NetworkStackIPv4},
+		{
+			stack: api.NetworkStackIPv6,
+			expected: map[string]interface{}{
+				"networkType":    "OVNKubernetes",
+				"clusterNetwork": []interface{}{map[string]interface{}{"cidr": "fd01::/48", "hostPrefix": 64}},
+				"serviceNetwork": []interface{}{"fd02::/112"},
+			},
+		},
+		{
+			stack: api.NetworkStackDualStack,
+			expected: map[string]interface{}{
+				"networkType": "OVNKubernetes",
+				"clusterNetwork": []interface{}{
+					map[string]interface{}{"cidr": "10.128.0.0/14", "hostPrefix": 23},
+					map[string]interface{}{"cidr": "fd01::/48", "hostPrefix": 64},
+				},
+				"serviceNetwork": []interface{}{"172.30.0.0/16", "fd02::/112"},
+			},
+		},
+	} {
+		t.Run(string(tc.stack), func(t *testing.T) {
+			profile.NetworkStack = tc.stack
+			config := Skeleton(profile)
+			if diff := cmp.Diff(tc.expected, config["networking"]); diff != "" {
+				t.Errorf("unexpected networking: %s", diff)
+			}
+			if err := config.Validate(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"REPLICAS": "3", "TYPE": "m6a.xlarge"}[name]
+		return value, ok
+	}
+	config := Config{
+		"platform": map[string]interface{}{"aws": map[string]interface{}{"region": "us-east-2"}},
+		"compute":  []interface{}{map[string]interface{}{"name": "worker"}},
+	}
+	patches := []string{
+		"platform:\n  aws:\n    userTags:\n      team: ci\ncontrolPlane:\n  replicas: ${REPLICAS}\n",
+		"compute:\n- name: worker\n  platform:\n    aws:\n      type: ${TYPE}\n",
+	}
+	if err := config.Apply(patches, lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Config{
+		"platform":     map[string]interface{}{"aws": map[string]interface{}{"region": "us-east-2", "userTags": map[string]interface{}{"team": "ci"}}},
+		"controlPlane": map[string]interface{}{"replicas": float64(3)},
+		"compute":      []interface{}{map[string]interface{}{"name": "worker", "platform": map[string]interface{}{"aws": map[string]interface{}{"type": "m6a.xlarge"}}}},
+	}
+	if diff := cmp.Diff(expected, config); diff != "" {
+		t.Errorf("unexpected config: %s", diff)
+	}
+
+	err := config.Apply([]string{"baseDomain: ${DOMAIN}-${ZONE}\nsshKey: $LITERAL\n"}, lookup)
+	if diff := cmp.Diff(errors.New("patch 0: undefined parameters: DOMAIN, ZONE"), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() Config {
+		return Config{
+			"apiVersion": "v1",
+			"metadata":   map[string]interface{}{"name": "ci-op-abc-xyz"},
+			"baseDomain": "ci.example.com",
+			"pullSecret": "{}",
+			"platform":   map[string]interface{}{"gcp": map[string]interface{}{"region": "us-east1", "projectID": "ci"}},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		mutate   func(Config)
+		expected error
+	}{
+		{name: "valid", mutate: func(Config) {}},
+		{
+			name:     "unknown field",
+			mutate:   func(c Config) { c["controlPlane"] = map[string]interface{}{"name": "master", "replica": 3} },
+			expected: errors.New(`install-config does not match the installer schema: json: unknown field "replica"`),
+		},
+		{
+			name: "platform settings unknown to the vendored installer",
+			mutate: func(c Config) {
+				c["platform"] = map[string]interface{}{"gcp": map[string]interface{}{"region": "us-east1", "projectID": "ci", "newSetting": true}}
+				c["controlPlane"] = map[string]interface{}{"name": "master", "platform": map[string]interface{}{"gcp": map[string]interface{}{"newPoolSetting": "value"}}}
+				c["compute"] = []interface{}{map[string]interface{}{"name": "worker", "platform": map[string]interface{}{"gcp": map[string]interface{}{"newPoolSetting": "value"}}}}
+			},
+		},
+		{
+			name:     "platform settings of the wrong type",
+			mutate:   func(c Config) { c["platform"] = map[string]interface{}{"gcp": map[string]interface{}{"region": 1}} },
+			expected: errors.New("install-config does not match the installer schema: json: cannot unmarshal number into Go struct field InstallConfig.platform.gcp.region of type string"),
+		},
+		{
+			name:     "wrong type",
+			mutate:   func(c Config) { c["baseDomain"] = 3 },
+			expected: errors.New("install-config does not match the installer schema: json: cannot unmarshal number into Go struct field InstallConfig.baseDomain of type string"),
+		},
+		{
+			name: "missing fields",
+			mutate: func(c Config) {
+				delete(c, "pullSecret")
+				c["apiVersion"] = "v2"
+				c["platform"] = map[string]interface{}{}
+			},
+			expected: errors.New(`[apiVersion must be v1, got "v2", pullSecret is required, exactly one platform must be configured, got 0: []]`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := valid()
+			tc.mutate(config)
+			if diff := cmp.Diff(tc.expected, config.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	config := Config{
+		"pullSecret":            "secret",
+		"sshKey":                "ssh-rsa key",
+		"additionalTrustBundle": "-----BEGIN CERTIFICATE-----",
+		"baseDomain":            "ci.example.com",
+		"platform": map[string]interface{}{"vsphere": map[string]interface{}{
+			"vcenters": []interface{}{map[string]interface{}{"server": "vcenter.example.com", "user": "ci", "password": "hunter2"}},
+		}},
+	}
+	expected := Config{
+		"pullSecret":            "REDACTED",
+		"sshKey":                "REDACTED",
+		"additionalTrustBundle": "REDACTED",
+		"baseDomain":            "ci.example.com",
+		"platform": map[string]interface{}{"vsphere": map[string]interface{}{
+			"vcenters": []interface{}{map[string]interface{}{"server": "vcenter.example.com", "user": "REDACTED", "password": "REDACTED"}},
+		}},
+	}
+	if diff := cmp.Diff(expected, config.Redacted()); diff != "" {
+		t.Errorf("unexpected redacted config: %s", diff)
+	}
+	if config["pullSecret"] != "secret" {
+		t.Error("expected the original config to be unchanged")
+	}
+	if vcenter := config["platform"].(map[string]interface{})["vsphere"].(map[string]interface{})["vcenters"].([]interface{})[0].(map[string]interface{}); vcenter["password"] != "hunter2" {
+		t.Error("expected the platform of the original config to be unchanged")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/getlantern/deepcopy"
 	"github.com/ghodss/yaml"
@@ -36,13 +37,17 @@ const (
 	LabelBuildFarm               = "ci.openshift.io/build-farm"
 	LabelGenerator               = "ci.openshift.io/generator"
 	ReleaseControllerValue       = "true"
-	JobReleaseKey                = "job-release"
+	JobReleaseKey                = cioperatorapi.JobReleaseKey
 	PresubmitPrefix              = "pull"
 	PostsubmitPrefix             = "branch"
 	PeriodicPrefix               = "periodic"
 	newlyGenerated         label = "newly-generated"
 )
 
+// PruneAfterAnnotation holds the time after which a generated job that is
+// no longer generated is removed, in RFC3339 format
+const PruneAfterAnnotation = "ci.openshift.io/prune-after"
+
 // SimpleBranchRegexp matches a branch name that does not appear to be a regex (lacks wildcard,
 // group, or other modifiers). For instance, `master` is considered simple, `master-.*` would
 // not.
@@ -266,11 +271,33 @@ func readFromFile(path string) (*prowconfig.JobConfig, error) {
 	return jobConfig, nil
 }
 
+// WriteOption configures how WriteToDir handles existing jobs
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	pruneGracePeriod time.Duration
+	now              func() time.Time
+}
+
+// WithPruneGracePeriod retires stale jobs instead of removing them outright: they
+// no longer run automatically nor block merges, and are only removed once the
+// grace period has passed. This keeps their history and avoids open pull
+// requests waiting on contexts that will never be reported again.
+func WithPruneGracePeriod(gracePeriod time.Duration) WriteOption {
+	return func(o *writeOptions) {
+		o.pruneGracePeriod = gracePeriod
+	}
+}
+
 // WriteToDir takes a JobConfig and a target directory, and writes the Prow job configuration
 // into files in that directory. Jobs are sharded by branch and by type. If
 // target files already exist and contain Prow job configuration, the jobs will
 // be merged. Jobs will be pruned based on the provided Generator that match the matchLabels set
-func WriteToDir(jobDir, org, repo string, jobConfig *prowconfig.JobConfig, generator Generator, matchLabels labels.Set) error {
+func WriteToDir(jobDir, org, repo string, jobConfig *prowconfig.JobConfig, generator Generator, matchLabels labels.Set, opts ...WriteOption) error {
+	o := writeOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	allJobs := sets.Set[string]{}
 	files := map[string]*prowconfig.JobConfig{}
 	key := fmt.Sprintf("%s/%s", org, repo)
@@ -344,7 +371,7 @@ func WriteToDir(jobDir, org, repo string, jobConfig *prowconfig.JobConfig, gener
 				sortConfigFields(jobConfig)
 			}
 		}
-		jobConfig, err := Prune(jobConfig, generator, matchLabels)
+		jobConfig, err := prune(jobConfig, generator, matchLabels, o.pruneGracePeriod, o.now())
 		if err != nil {
 			return err
 		}
@@ -470,6 +497,10 @@ func mergeJobConfig(destination, source *prowconfig.JobConfig, allJobs sets.Set[
 // configuration and cannot be derived from the ci-operator configuration
 func mergePresubmits(old, new *prowconfig.Presubmit) prowconfig.Presubmit {
 	merged := *new
+	if _, retired := old.Annotations[PruneAfterAnnotation]; retired {
+		// the triggers of retired jobs were overridden when retiring them
+		return merged
+	}
 
 	merged.AlwaysRun = old.AlwaysRun
 	merged.RunIfChanged = old.RunIfChanged
@@ -485,7 +516,8 @@ func mergePresubmits(old, new *prowconfig.Presubmit) prowconfig.Presubmit {
 		return ""
 	}()
 
-	if new.RunIfChanged != "" || new.SkipIfOnlyChanged != "" || new.Annotations["pipeline_run_if_changed"] != "" {
+	// triggers declared in the ci-operator configuration take precedence
+	if new.RunIfChanged != "" || new.SkipIfOnlyChanged != "" || new.Annotations["pipeline_run_if_changed"] != "" || !new.AlwaysRun {
 		merged.RunIfChanged = new.RunIfChanged
 		merged.SkipIfOnlyChanged = new.SkipIfOnlyChanged
 		merged.AlwaysRun = new.AlwaysRun
@@ -509,6 +541,10 @@ func mergePresubmits(old, new *prowconfig.Presubmit) prowconfig.Presubmit {
 // configuration and cannot be derived from the ci-operator configuration
 func mergePostsubmits(old, new *prowconfig.Postsubmit) prowconfig.Postsubmit {
 	merged := *new
+	if _, retired := old.Annotations[PruneAfterAnnotation]; retired {
+		// the triggers of retired jobs were overridden when retiring them
+		return merged
+	}
 
 	if _, ok := merged.Labels[cioperatorapi.PromotionJobLabelKey]; !ok {
 		merged.MaxConcurrency = old.MaxConcurrency
@@ -673,6 +709,30 @@ func staleSelectorFor(generator Generator, pruneLabels labels.Set) (labels.Selec
 // Prune removes all generated jobs of the supplied Generator with values that are NOT newly-generated.
 // Prune() returns the resulting job config (which may even be completely empty).
 func Prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels labels.Set) (*prowconfig.JobConfig, error) {
+	return prune(jobConfig, generator, pruneLabels, 0, time.Time{})
+}
+
+// retire marks a stale job for removal once the grace period has passed,
+// returning whether the job should still be kept.
+func retire(job *prowconfig.JobBase, gracePeriod time.Duration, now time.Time) (bool, error) {
+	if gracePeriod == 0 {
+		return false, nil
+	}
+	if pruneAfter, ok := job.Annotations[PruneAfterAnnotation]; ok {
+		deadline, err := time.Parse(time.RFC3339, pruneAfter)
+		if err != nil {
+			return false, fmt.Errorf("job %s has an invalid %s annotation: %w", job.Name, PruneAfterAnnotation, err)
+		}
+		return now.Before(deadline), nil
+	}
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[PruneAfterAnnotation] = now.Add(gracePeriod).UTC().Truncate(time.Second).Format(time.RFC3339)
+	return true, nil
+}
+
+func prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels labels.Set, gracePeriod time.Duration, now time.Time) (*prowconfig.JobConfig, error) {
 	var pruned prowconfig.JobConfig
 	staleSelector, err := staleSelectorFor(generator, pruneLabels)
 	if err != nil {
@@ -691,9 +751,6 @@ func Prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels lab
 
 	for repo, jobs := range jobConfig.PresubmitsStatic {
 		for _, job := range jobs {
-			if isStale(job.JobBase) {
-				continue
-			}
 			// The job base might be shared with other job objects.
 			// We make a copy here to avoid the intervention in some corner cases identified for DPTP-3845.
 			// A better solution is to not share in the input but fix on the caller here is simpler.
@@ -701,6 +758,20 @@ func Prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels lab
 			if err := deepcopy.Copy(&copy, &job); err != nil {
 				return nil, fmt.Errorf("failed to deepcopy: %w", err)
 			}
+			if isStale(copy.JobBase) {
+				keep, err := retire(&copy.JobBase, gracePeriod, now)
+				if err != nil {
+					return nil, err
+				}
+				if !keep {
+					continue
+				}
+				// retired jobs only run when explicitly requested and never block merges
+				copy.AlwaysRun = false
+				copy.Optional = true
+				copy.RunIfChanged = ""
+				copy.SkipIfOnlyChanged = ""
+			}
 			if isGenerated(copy.JobBase) {
 				delete(copy.Labels, string(generator))
 			}
@@ -716,7 +787,23 @@ func Prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels lab
 	for repo, jobs := range jobConfig.PostsubmitsStatic {
 		for _, job := range jobs {
 			if isStale(job.JobBase) {
-				continue
+				var copy prowconfig.Postsubmit
+				if err := deepcopy.Copy(&copy, &job); err != nil {
+					return nil, fmt.Errorf("failed to deepcopy: %w", err)
+				}
+				keep, err := retire(&copy.JobBase, gracePeriod, now)
+				if err != nil {
+					return nil, err
+				}
+				if !keep {
+					continue
+				}
+				// retired jobs never run automatically
+				alwaysRun := false
+				copy.AlwaysRun = &alwaysRun
+				copy.RunIfChanged = ""
+				copy.SkipIfOnlyChanged = ""
+				job = copy
 			}
 			if isGenerated(job.JobBase) {
 				delete(job.Labels, string(generator))
@@ -730,13 +817,23 @@ func Prune(jobConfig *prowconfig.JobConfig, generator Generator, pruneLabels lab
 	}
 
 	for _, job := range jobConfig.Periodics {
-		if isStale(job.JobBase) {
-			continue
-		}
 		var copy prowconfig.Periodic
 		if err := deepcopy.Copy(&copy, &job); err != nil {
 			return nil, fmt.Errorf("failed to deepcopy: %w", err)
 		}
+		if isStale(copy.JobBase) {
+			keep, err := retire(&copy.JobBase, gracePeriod, now)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
+			// retired jobs only run when explicitly requested
+			copy.Cron = "@yearly"
+			copy.Interval = ""
+			copy.MinimumInterval = ""
+		}
 		if isGenerated(copy.JobBase) {
 			delete(copy.Labels, string(generator))
 		}
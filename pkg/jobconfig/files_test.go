@@ -3,6 +3,7 @@ package jobconfig
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -428,6 +429,33 @@ func TestMergePresubmits(t *testing.T) {
 			new:      &prowconfig.Presubmit{RegexpChangeMatcher: prowconfig.RegexpChangeMatcher{SkipIfOnlyChanged: "new"}},
 			expected: prowconfig.Presubmit{RegexpChangeMatcher: prowconfig.RegexpChangeMatcher{SkipIfOnlyChanged: "new"}},
 		},
+		{
+			name:     "Always run false from new takes precedence",
+			old:      &prowconfig.Presubmit{AlwaysRun: true},
+			new:      &prowconfig.Presubmit{AlwaysRun: false},
+			expected: prowconfig.Presubmit{AlwaysRun: false},
+		},
+		{
+			name:     "Always run false set by hand is kept when new always runs",
+			old:      &prowconfig.Presubmit{AlwaysRun: false},
+			new:      &prowconfig.Presubmit{AlwaysRun: true},
+			expected: prowconfig.Presubmit{AlwaysRun: false},
+		},
+		{
+			name:     "Run if changed added to old by hand is kept when new always runs",
+			old:      &prowconfig.Presubmit{RegexpChangeMatcher: prowconfig.RegexpChangeMatcher{RunIfChanged: "old"}},
+			new:      &prowconfig.Presubmit{AlwaysRun: true},
+			expected: prowconfig.Presubmit{RegexpChangeMatcher: prowconfig.RegexpChangeMatcher{RunIfChanged: "old"}},
+		},
+		{
+			name: "triggers of a retired job are not kept when it is generated again",
+			old: &prowconfig.Presubmit{
+				JobBase:  prowconfig.JobBase{Annotations: map[string]string{PruneAfterAnnotation: "2026-01-08T00:00:00Z"}},
+				Optional: true,
+			},
+			new:      &prowconfig.Presubmit{AlwaysRun: true},
+			expected: prowconfig.Presubmit{AlwaysRun: true},
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -995,6 +1023,113 @@ func TestPrune(t *testing.T) {
 	}
 }
 
+func TestPruneWithGracePeriod(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	gracePeriod := 7 * 24 * time.Hour
+	stale := func(annotations map[string]string) prowconfig.JobBase {
+		return prowconfig.JobBase{Name: "job", Labels: map[string]string{LabelGenerator: "prowgen"}, Annotations: annotations}
+	}
+	retired := func() prowconfig.JobBase {
+		return prowconfig.JobBase{Name: "job", Labels: map[string]string{LabelGenerator: "prowgen"}, Annotations: map[string]string{PruneAfterAnnotation: "2026-01-08T12:00:00Z"}}
+	}
+	alwaysRun := false
+	testCases := []struct {
+		name           string
+		jobconfig      *prowconfig.JobConfig
+		expectedConfig *prowconfig.JobConfig
+		expectedErr    bool
+	}{
+		{
+			name: "stale presubmit is retired",
+			jobconfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: stale(nil), AlwaysRun: true, RegexpChangeMatcher: prowconfig.RegexpChangeMatcher{RunIfChanged: "foo"}}},
+				},
+			},
+			expectedConfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: retired(), Optional: true}},
+				},
+			},
+		},
+		{
+			name: "stale postsubmit is retired",
+			jobconfig: &prowconfig.JobConfig{
+				PostsubmitsStatic: map[string][]prowconfig.Postsubmit{
+					"repo": {{JobBase: stale(nil)}},
+				},
+			},
+			expectedConfig: &prowconfig.JobConfig{
+				PostsubmitsStatic: map[string][]prowconfig.Postsubmit{
+					"repo": {{JobBase: retired(), AlwaysRun: &alwaysRun}},
+				},
+			},
+		},
+		{
+			name: "retired presubmit is kept within the grace period",
+			jobconfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: retired(), Optional: true}},
+				},
+			},
+			expectedConfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: retired(), Optional: true}},
+				},
+			},
+		},
+		{
+			name: "retired jobs are pruned after the grace period",
+			jobconfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: stale(map[string]string{PruneAfterAnnotation: "2026-01-01T11:59:59Z"}), Optional: true}},
+				},
+				PostsubmitsStatic: map[string][]prowconfig.Postsubmit{
+					"repo": {{JobBase: stale(map[string]string{PruneAfterAnnotation: "2026-01-01T11:59:59Z"}), AlwaysRun: &alwaysRun}},
+				},
+			},
+			expectedConfig: &prowconfig.JobConfig{},
+		},
+		{
+			name: "stale periodic is retired",
+			jobconfig: &prowconfig.JobConfig{
+				Periodics: []prowconfig.Periodic{{JobBase: stale(nil), Interval: "24h"}},
+			},
+			expectedConfig: &prowconfig.JobConfig{
+				Periodics: []prowconfig.Periodic{{JobBase: retired(), Cron: "@yearly"}},
+			},
+		},
+		{
+			name: "retired periodic is pruned after the grace period",
+			jobconfig: &prowconfig.JobConfig{
+				Periodics: []prowconfig.Periodic{{JobBase: stale(map[string]string{PruneAfterAnnotation: "2026-01-01T11:59:59Z"}), Cron: "@yearly"}},
+			},
+			expectedConfig: &prowconfig.JobConfig{},
+		},
+		{
+			name: "invalid annotation",
+			jobconfig: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: stale(map[string]string{PruneAfterAnnotation: "next week"})}},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pruned, err := prune(tc.jobconfig, "prowgen", nil, gracePeriod, now)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedConfig, pruned, unexportedFields...); diff != "" {
+				t.Fatalf("Pruned config differs:\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestIsGenerated(t *testing.T) {
 	testCases := []struct {
 		description string
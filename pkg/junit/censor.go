@@ -23,6 +23,10 @@ func CensorTestSuite(censor secretutil.Censorer, testSuite *TestSuite) {
 		}
 		testSuite.TestCases[i].SystemOut = censored(censor, testSuite.TestCases[i].SystemOut)
 		testSuite.TestCases[i].SystemErr = censored(censor, testSuite.TestCases[i].SystemErr)
+		for j := range testSuite.TestCases[i].Properties {
+			testSuite.TestCases[i].Properties[j].Name = censored(censor, testSuite.TestCases[i].Properties[j].Name)
+			testSuite.TestCases[i].Properties[j].Value = censored(censor, testSuite.TestCases[i].Properties[j].Value)
+		}
 	}
 	for i := range testSuite.Children {
 		CensorTestSuite(censor, testSuite.Children[i])
@@ -27,6 +27,9 @@ func TestCensorTestSuite(t *testing.T) {
 				},
 				SystemOut: "output containing secret",
 				SystemErr: "error containing secret",
+				Properties: []*TestSuiteProperty{
+					{Name: "secret usage", Value: "secret amount"},
+				},
 			},
 			{
 				Name:        "somehow also secret",
@@ -0,0 +1,98 @@
+package junit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	TestResultPassed  TestResult = "passed"
+	TestResultFailed  TestResult = "failed"
+	TestResultSkipped TestResult = "skipped"
+)
+
+// PhaseProperty is the property of test cases holding the phase of the
+// multi-stage test they ran in, one of pre, test, post or gather.
+const PhaseProperty = "phase"
+
+// TestResults is the JSON form of jUnit results, for tools that would rather
+// not parse XML.
+type TestResults struct {
+	Suites []TestSuiteResults `json:"suites"`
+}
+
+// TestSuiteResults holds the results of the test cases of a suite.
+type TestSuiteResults struct {
+	Name     string             `json:"name"`
+	Duration float64            `json:"duration_seconds"`
+	Tests    []TestCaseResult   `json:"tests,omitempty"`
+	Children []TestSuiteResults `json:"suites,omitempty"`
+}
+
+// TestCaseResult is the result of a test case.
+type TestCaseResult struct {
+	Name     string     `json:"name"`
+	Phase    string     `json:"phase,omitempty"`
+	Result   TestResult `json:"result"`
+	Duration float64    `json:"duration_seconds"`
+	// FailureReason is the message of the failure, or its output when the
+	// failure has no message.
+	FailureReason string `json:"failure_reason,omitempty"`
+	SkipReason    string `json:"skip_reason,omitempty"`
+}
+
+// NewTestResults converts jUnit results to their JSON form.
+func NewTestResults(suites *TestSuites) *TestResults {
+	results := &TestResults{Suites: []TestSuiteResults{}}
+	if suites == nil {
+		return results
+	}
+	for _, suite := range suites.Suites {
+		results.Suites = append(results.Suites, suiteResults(suite))
+	}
+	return results
+}
+
+func suiteResults(suite *TestSuite) TestSuiteResults {
+	ret := TestSuiteResults{Name: suite.Name, Duration: suite.Duration}
+	for _, test := range suite.TestCases {
+		ret.Tests = append(ret.Tests, caseResult(test))
+	}
+	for _, child := range suite.Children {
+		ret.Children = append(ret.Children, suiteResults(child))
+	}
+	return ret
+}
+
+func caseResult(test *TestCase) TestCaseResult {
+	ret := TestCaseResult{Name: test.Name, Result: TestResultPassed, Duration: test.Duration}
+	for _, property := range test.Properties {
+		if property.Name == PhaseProperty {
+			ret.Phase = property.Value
+		}
+	}
+	switch {
+	case test.FailureOutput != nil:
+		ret.Result = TestResultFailed
+		ret.FailureReason = test.FailureOutput.Message
+		if ret.FailureReason == "" {
+			ret.FailureReason = test.FailureOutput.Output
+		}
+	case test.SkipMessage != nil:
+		ret.Result = TestResultSkipped
+		ret.SkipReason = test.SkipMessage.Message
+	}
+	return ret
+}
+
+// WriteJSON encodes the jUnit results in their JSON form.
+func WriteJSON(w io.Writer, suites *TestSuites) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(NewTestResults(suites)); err != nil {
+		return fmt.Errorf("could not encode test results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package junit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		suites   *TestSuites
+		expected string
+	}{
+		{
+			name:     "no results",
+			expected: "{\n  \"suites\": []\n}\n",
+		},
+		{
+			name: "phases, failures and skips",
+			suites: &TestSuites{Suites: []*TestSuite{{
+				Name:     "step graph",
+				Duration: 300,
+				TestCases: []*TestCase{
+					{Name: "Run multi-stage test pre phase", Duration: 100, Properties: []*TestSuiteProperty{{Name: "usage/e2e-ipi-install/cpu-millicores", Value: "500"}, {Name: PhaseProperty, Value: "pre"}}},
+					{Name: "Run multi-stage test test phase", Duration: 200, FailureOutput: &FailureOutput{Output: "\"e2e\" pod \"e2e-test\" failed"}, Properties: []*TestSuiteProperty{{Name: PhaseProperty, Value: "test"}}},
+					{Name: "Run multi-stage test post phase", SkipMessage: &SkipMessage{Message: "the budget was exceeded"}, Properties: []*TestSuiteProperty{{Name: PhaseProperty, Value: "post"}}},
+					{Name: "Build image src", FailureOutput: &FailureOutput{Message: "build failed", Output: "full build log"}},
+				},
+				Children: []*TestSuite{{Name: "nested", TestCases: []*TestCase{{Name: "case"}}}},
+			}}},
+			expected: `{
+  "suites": [
+    {
+      "name": "step graph",
+      "duration_seconds": 300,
+      "tests": [
+        {
+          "name": "Run multi-stage test pre phase",
+          "phase": "pre",
+          "result": "passed",
+          "duration_seconds": 100
+        },
+        {
+          "name": "Run multi-stage test test phase",
+          "phase": "test",
+          "result": "failed",
+          "duration_seconds": 200,
+          "failure_reason": "\"e2e\" pod \"e2e-test\" failed"
+        },
+        {
+          "name": "Run multi-stage test post phase",
+          "phase": "post",
+          "result": "skipped",
+          "duration_seconds": 0,
+          "skip_reason": "the budget was exceeded"
+        },
+        {
+          "name": "Build image src",
+          "result": "failed",
+          "duration_seconds": 0,
+          "failure_reason": "build failed"
+        }
+      ],
+      "suites": [
+        {
+          "name": "nested",
+          "duration_seconds": 0,
+          "tests": [
+            {
+              "name": "case",
+              "result": "passed",
+              "duration_seconds": 0
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteJSON(&out, tc.suites); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("unexpected JSON: %s", diff)
+			}
+		})
+	}
+}
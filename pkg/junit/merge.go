@@ -0,0 +1,179 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryFilename is the name of the junit file summarizing the results of
+// the junit files produced by the steps of a test. It does not follow the
+// junit*.xml convention, so that tools reading every junit file do not report
+// the results of the steps twice nor mistake it for the junit of ci-operator.
+const SummaryFilename = "merged-results.xml"
+
+// IsJUnitFile determines whether the file holds junit results, following the
+// convention of naming those junit*.xml.
+func IsJUnitFile(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(base, "junit") && strings.HasSuffix(base, ".xml")
+}
+
+// ReadDir reads the junit files under the directory, nested ones included,
+// keyed by their path relative to it. A missing directory holds no files.
+func ReadDir(root string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !IsJUnitFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", rel, err)
+		}
+		files[filepath.ToSlash(rel)] = raw
+		return nil
+	})
+	return files, err
+}
+
+// Parse parses junit XML holding either a list of suites or a single one.
+func Parse(raw []byte) ([]*TestSuite, error) {
+	suites := &TestSuites{}
+	if err := xml.Unmarshal(raw, suites); err == nil {
+		return suites.Suites, nil
+	}
+	suite := &TestSuite{}
+	if err := xml.Unmarshal(raw, suite); err != nil {
+		return nil, err
+	}
+	return []*TestSuite{suite}, nil
+}
+
+// Merge combines the junit files, keyed by their path, into one set of
+// suites. Nested suites are flattened into suites named after the path of
+// their parents, and suites without a name are named after their file, so
+// that the results of a step are found under the same suite whichever file
+// and hierarchy it wrote them in. Test cases repeated in a suite, as retried
+// tests are, are reported once: a case that passed is kept over one that
+// failed, which is kept over one that was skipped, and the number of
+// attempts is recorded in its properties.
+func Merge(files map[string][]byte) (*TestSuites, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	merged := map[string]*mergedSuite{}
+	for _, name := range names {
+		suites, err := Parse(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", name, err)
+		}
+		fallback := strings.TrimSuffix(name, path.Ext(name))
+		for _, suite := range suites {
+			mergeSuite(merged, "", fallback, suite)
+		}
+	}
+	ret := &TestSuites{}
+	for _, suite := range merged {
+		ret.Suites = append(ret.Suites, suite.testSuite())
+	}
+	sort.Slice(ret.Suites, func(i, j int) bool {
+		return ret.Suites[i].Name < ret.Suites[j].Name
+	})
+	return ret, nil
+}
+
+type mergedSuite struct {
+	name       string
+	duration   float64
+	properties []*TestSuiteProperty
+	cases      map[string]*mergedCase
+}
+
+type mergedCase struct {
+	test     *TestCase
+	attempts int
+}
+
+func mergeSuite(merged map[string]*mergedSuite, parent, fallback string, suite *TestSuite) {
+	name := strings.TrimSpace(suite.Name)
+	if name == "" {
+		name = fallback
+	}
+	if parent != "" {
+		name = parent + "/" + name
+	}
+	if len(suite.TestCases) != 0 {
+		into, ok := merged[name]
+		if !ok {
+			into = &mergedSuite{name: name, properties: suite.Properties, cases: map[string]*mergedCase{}}
+			merged[name] = into
+		}
+		into.duration += suite.Duration
+		for _, test := range suite.TestCases {
+			kept, ok := into.cases[test.Name]
+			if !ok {
+				into.cases[test.Name] = &mergedCase{test: test, attempts: 1}
+				continue
+			}
+			kept.attempts++
+			if rank(test) >= rank(kept.test) {
+				kept.test = test
+			}
+		}
+	}
+	for i, child := range suite.Children {
+		mergeSuite(merged, name, strconv.Itoa(i), child)
+	}
+}
+
+// rank orders the results of the attempts of a test case by the one to keep.
+func rank(test *TestCase) int {
+	switch {
+	case test.FailureOutput != nil:
+		return 1
+	case test.SkipMessage != nil:
+		return 0
+	default:
+		return 2
+	}
+}
+
+func (s *mergedSuite) testSuite() *TestSuite {
+	suite := &TestSuite{Name: s.name, Duration: s.duration, Properties: s.properties}
+	for _, c := range s.cases {
+		if c.attempts > 1 {
+			c.test.Properties = append(c.test.Properties, &TestSuiteProperty{Name: "attempts", Value: strconv.Itoa(c.attempts)})
+		}
+		suite.TestCases = append(suite.TestCases, c.test)
+		suite.NumTests++
+		switch {
+		case c.test.FailureOutput != nil:
+			suite.NumFailed++
+		case c.test.SkipMessage != nil:
+			suite.NumSkipped++
+		}
+	}
+	sort.Slice(suite.TestCases, func(i, j int) bool {
+		return suite.TestCases[i].Name < suite.TestCases[j].Name
+	})
+	return suite
+}
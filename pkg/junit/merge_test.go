@@ -0,0 +1,134 @@
+package junit
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestIsJUnitFile(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"junit.xml":                  true,
+		"e2e/junit_e2e_20240101.xml": true,
+		"merged-results.xml":         false,
+		"e2e/merged-results.xml":     false,
+		"e2e/build-log.txt":          false,
+		"e2e/results.xml":            false,
+	} {
+		if actual := IsJUnitFile(name); actual != expected {
+			t.Errorf("%s: expected %t, got %t", name, expected, actual)
+		}
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"junit.xml":                    "top",
+		"step/artifacts/junit_e2e.xml": "nested",
+		"merged-results.xml":           "summary",
+		"step/build-log.txt":           "log",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]byte{"junit.xml": []byte("top"), "step/artifacts/junit_e2e.xml": []byte("nested")}
+	if diff := cmp.Diff(expected, files); diff != "" {
+		t.Errorf("unexpected files: %s", diff)
+	}
+	if files, err := ReadDir(filepath.Join(dir, "missing")); err != nil || len(files) != 0 {
+		t.Errorf("expected no files in a missing directory, got %v and %v", files, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		files         map[string][]byte
+		expected      *TestSuites
+		expectedError string
+	}{
+		{
+			name: "suites and single suite",
+			files: map[string][]byte{
+				"step/junit_a.xml": []byte(`<testsuites><testsuite name="e2e" time="3"><testcase name="a" time="1"/><testcase name="b" time="2"><failure>failed</failure></testcase></testsuite></testsuites>`),
+				"step/junit_b.xml": []byte(`<testsuite name="conformance" time="1"><testcase name="c" time="1"><skipped message="skip"/></testcase></testsuite>`),
+			},
+			expected: &TestSuites{Suites: []*TestSuite{
+				{
+					Name: "conformance", NumTests: 1, NumSkipped: 1, Duration: 1,
+					TestCases: []*TestCase{{Name: "c", Duration: 1, SkipMessage: &SkipMessage{Message: "skip"}}},
+				},
+				{
+					Name: "e2e", NumTests: 2, NumFailed: 1, Duration: 3,
+					TestCases: []*TestCase{
+						{Name: "a", Duration: 1},
+						{Name: "b", Duration: 2, FailureOutput: &FailureOutput{Output: "failed"}},
+					},
+				},
+			}},
+		},
+		{
+			name: "retries are deduplicated",
+			files: map[string][]byte{
+				"junit_1.xml": []byte(`<testsuite name="e2e"><testcase name="flaky"><failure>first</failure></testcase><testcase name="broken"><failure>first</failure></testcase></testsuite>`),
+				"junit_2.xml": []byte(`<testsuite name="e2e"><testcase name="flaky"/><testcase name="broken"><failure>second</failure></testcase><testcase name="skipped"><skipped/></testcase></testsuite>`),
+				"junit_3.xml": []byte(`<testsuite name=" e2e "><testcase name="flaky"><failure>third</failure></testcase><testcase name="skipped"><failure>ran</failure></testcase></testsuite>`),
+			},
+			expected: &TestSuites{Suites: []*TestSuite{
+				{
+					Name: "e2e", NumTests: 3, NumFailed: 2,
+					TestCases: []*TestCase{
+						{Name: "broken", FailureOutput: &FailureOutput{Output: "second"}, Properties: []*TestSuiteProperty{{Name: "attempts", Value: "2"}}},
+						{Name: "flaky", Properties: []*TestSuiteProperty{{Name: "attempts", Value: "3"}}},
+						{Name: "skipped", FailureOutput: &FailureOutput{Output: "ran"}, Properties: []*TestSuiteProperty{{Name: "attempts", Value: "2"}}},
+					},
+				},
+			}},
+		},
+		{
+			name: "nested and unnamed suites",
+			files: map[string][]byte{
+				"step/junit_nested.xml":  []byte(`<testsuite name="parent"><testsuite name="child"><testcase name="a"/></testsuite><testsuite><testcase name="b"/></testsuite></testsuite>`),
+				"step/junit_unnamed.xml": []byte(`<testsuites><testsuite><testcase name="c"/></testsuite></testsuites>`),
+			},
+			expected: &TestSuites{Suites: []*TestSuite{
+				{Name: "parent/1", NumTests: 1, TestCases: []*TestCase{{Name: "b"}}},
+				{Name: "parent/child", NumTests: 1, TestCases: []*TestCase{{Name: "a"}}},
+				{Name: "step/junit_unnamed", NumTests: 1, TestCases: []*TestCase{{Name: "c"}}},
+			}},
+		},
+		{
+			name:          "invalid file",
+			files:         map[string][]byte{"junit.xml": []byte("not xml")},
+			expectedError: "could not parse junit.xml: EOF",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Merge(tc.files)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.IgnoreTypes(xml.Name{})); diff != "" {
+				t.Errorf("unexpected suites: %s", diff)
+			}
+		})
+	}
+}
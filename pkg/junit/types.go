@@ -79,6 +79,9 @@ type TestCase struct {
 
 	// SystemErr is output written to stderr during the execution of this test case
 	SystemErr string `xml:"system-err,omitempty"`
+
+	// Properties holds other properties of the test case as a mapping of name to value
+	Properties []*TestSuiteProperty `xml:"properties>property,omitempty"`
 }
 
 // SkipMessage holds a message explaining why a test was skipped
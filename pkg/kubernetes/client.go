@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -76,7 +77,8 @@ type PodClient interface {
 	// its LoggingClient.
 	WithNewLoggingClient() PodClient
 	Exec(namespace, pod string, opts *coreapi.PodExecOptions) (remotecommand.Executor, error)
-	GetLogs(namespace, name string, opts *coreapi.PodLogOptions) *rest.Request
+	// StreamLogs opens a stream of the logs of a container of a pod.
+	StreamLogs(ctx context.Context, namespace, name string, opts *coreapi.PodLogOptions) (io.ReadCloser, error)
 }
 
 func NewPodClient(ctrlclient loggingclient.LoggingClient, config *rest.Config, client rest.Interface, pendingTimeout time.Duration) PodClient {
@@ -106,8 +108,8 @@ func (c podClient) Exec(namespace, pod string, opts *coreapi.PodExecOptions) (re
 	return e, nil
 }
 
-func (c podClient) GetLogs(namespace, name string, opts *coreapi.PodLogOptions) *rest.Request {
-	return c.client.Get().Namespace(namespace).Name(name).Resource("pods").SubResource("log").VersionedParams(opts, scheme.ParameterCodec)
+func (c podClient) StreamLogs(ctx context.Context, namespace, name string, opts *coreapi.PodLogOptions) (io.ReadCloser, error) {
+	return c.client.Get().Namespace(namespace).Name(name).Resource("pods").SubResource("log").VersionedParams(opts, scheme.ParameterCodec).Stream(ctx)
 }
 
 func (c podClient) WithNewLoggingClient() PodClient {
@@ -0,0 +1,255 @@
+package kubernetes
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Categories of the errors returned by the pod exec and log APIs.
+const (
+	// ErrorCategoryDialingBackend is an API server failing to reach the
+	// kubelet of the node running the pod.
+	ErrorCategoryDialingBackend = "dialing_backend"
+	// ErrorCategoryServer is an API server temporarily unable to serve the
+	// request: unavailable, overloaded or timing out.
+	ErrorCategoryServer = "server"
+	// ErrorCategoryConnection is the connection to the API server being
+	// refused or dropped.
+	ErrorCategoryConnection = "connection"
+	// ErrorCategoryPermanent is any other error, which is not retried.
+	ErrorCategoryPermanent = "permanent"
+)
+
+// PodAPIError is a kind of error returned by the pod exec and log APIs.
+type PodAPIError struct {
+	// Operation is the request which failed, "exec" or "logs"
+	Operation string
+	// Category is the category of the error, see CategorizeError
+	Category string
+	// Retried is whether the request was retried after the error
+	Retried bool
+}
+
+var podAPIErrors = struct {
+	sync.Mutex
+	counts map[PodAPIError]int
+}{counts: map[PodAPIError]int{}}
+
+func recordPodAPIError(podAPIError PodAPIError) {
+	podAPIErrors.Lock()
+	defer podAPIErrors.Unlock()
+	podAPIErrors.counts[podAPIError]++
+}
+
+// PodAPIErrors returns how many errors of each kind the pod exec and log APIs
+// returned so far. Nothing scrapes the metrics of ci-operator, so it sends
+// them to the result aggregator when the run ends.
+func PodAPIErrors() map[PodAPIError]int {
+	podAPIErrors.Lock()
+	defer podAPIErrors.Unlock()
+	ret := make(map[PodAPIError]int, len(podAPIErrors.counts))
+	for podAPIError, count := range podAPIErrors.counts {
+		ret[podAPIError] = count
+	}
+	return ret
+}
+
+// podAPIBackoff is how requests failing on transient errors are retried.
+var podAPIBackoff = wait.Backoff{
+	Duration: 2 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+// CategorizeError determines the category of an error returned by the pod
+// exec and log APIs and whether it is transient, i.e. whether retrying the
+// request may succeed.
+func CategorizeError(err error) (string, bool) {
+	switch {
+	case strings.Contains(err.Error(), "error dialing backend"):
+		return ErrorCategoryDialingBackend, true
+	case kerrors.IsServiceUnavailable(err), kerrors.IsTooManyRequests(err), kerrors.IsServerTimeout(err), kerrors.IsTimeout(err):
+		return ErrorCategoryServer, true
+	case utilnet.IsConnectionRefused(err), utilnet.IsConnectionReset(err), utilnet.IsProbableEOF(err):
+		return ErrorCategoryConnection, true
+	default:
+		return ErrorCategoryPermanent, false
+	}
+}
+
+// NewRetryingPodClient wraps a PodClient so that remote commands and log
+// streams which fail on transient API server errors are retried.
+func NewRetryingPodClient(client PodClient) PodClient {
+	return &retryingPodClient{PodClient: client, backoff: podAPIBackoff}
+}
+
+type retryingPodClient struct {
+	PodClient
+	backoff wait.Backoff
+}
+
+func (c retryingPodClient) WithNewLoggingClient() PodClient {
+	c.PodClient = c.PodClient.WithNewLoggingClient()
+	return &c
+}
+
+func (c retryingPodClient) Exec(namespace, pod string, opts *coreapi.PodExecOptions) (remotecommand.Executor, error) {
+	e, err := c.PodClient.Exec(namespace, pod, opts)
+	if err != nil {
+		return nil, err
+	}
+	logger := logrus.WithFields(logrus.Fields{"namespace": namespace, "pod": pod, "container": opts.Container})
+	return &retryingExecutor{Executor: e, backoff: c.backoff, logger: logger}, nil
+}
+
+func (c retryingPodClient) StreamLogs(ctx context.Context, namespace, name string, opts *coreapi.PodLogOptions) (io.ReadCloser, error) {
+	var ret io.ReadCloser
+	logger := logrus.WithFields(logrus.Fields{"namespace": namespace, "pod": name, "container": opts.Container})
+	backoff := c.backoff
+	err := retry(ctx, &backoff, "logs", logger, func() (bool, error) {
+		var err error
+		ret, err = c.PodClient.StreamLogs(ctx, namespace, name, opts)
+		return true, err
+	})
+	if err != nil || !replayable(opts) {
+		return ret, err
+	}
+	return &retryingLogStream{
+		ReadCloser:     ret,
+		ctx:            ctx,
+		initialBackoff: c.backoff,
+		backoff:        c.backoff,
+		logger:         logger,
+		open: func() (io.ReadCloser, error) {
+			return c.PodClient.StreamLogs(ctx, namespace, name, opts)
+		},
+	}, nil
+}
+
+// replayable determines whether a log stream starts at the beginning of the
+// log, so that it can be opened again and read from where it broke.
+func replayable(opts *coreapi.PodLogOptions) bool {
+	return opts.SinceSeconds == nil && opts.SinceTime == nil && opts.TailLines == nil && opts.LimitBytes == nil
+}
+
+// retryingLogStream opens a log stream again when reading it fails on a
+// transient error, skipping the part of the log which was already read. The
+// backoff is only reset once reading makes progress again.
+type retryingLogStream struct {
+	io.ReadCloser
+	ctx                     context.Context
+	initialBackoff, backoff wait.Backoff
+	logger                  *logrus.Entry
+	open                    func() (io.ReadCloser, error)
+	read                    int64
+}
+
+func (s *retryingLogStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	s.read += int64(n)
+	if err == nil || err == io.EOF {
+		if n != 0 {
+			s.backoff = s.initialBackoff
+		}
+		return n, err
+	}
+	failed := false
+	if err := retry(s.ctx, &s.backoff, "logs", s.logger, func() (bool, error) {
+		if !failed {
+			failed = true
+			return true, err
+		}
+		return true, s.reopen()
+	}); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+func (s *retryingLogStream) reopen() error {
+	_ = s.ReadCloser.Close()
+	stream, err := s.open()
+	if err != nil {
+		return err
+	}
+	s.ReadCloser = stream
+	_, err = io.CopyN(io.Discard, stream, s.read)
+	return err
+}
+
+// retryingExecutor retries remote commands which fail on transient errors.
+// A command is only retried if it did not produce any output yet and does
+// not read from its standard input, as it cannot be replayed otherwise.
+type retryingExecutor struct {
+	remotecommand.Executor
+	backoff wait.Backoff
+	logger  *logrus.Entry
+}
+
+func (e *retryingExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *retryingExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	var written bool
+	if options.Stdout != nil {
+		options.Stdout = &watchingWriter{Writer: options.Stdout, written: &written}
+	}
+	if options.Stderr != nil {
+		options.Stderr = &watchingWriter{Writer: options.Stderr, written: &written}
+	}
+	backoff := e.backoff
+	return retry(ctx, &backoff, "exec", e.logger, func() (bool, error) {
+		err := e.Executor.StreamWithContext(ctx, options)
+		return options.Stdin == nil && !written, err
+	})
+}
+
+// watchingWriter records whether anything was written through it.
+type watchingWriter struct {
+	io.Writer
+	written *bool
+}
+
+func (w *watchingWriter) Write(p []byte) (int, error) {
+	if len(p) != 0 {
+		*w.written = true
+	}
+	return w.Writer.Write(p)
+}
+
+// retry calls `f` until it succeeds, fails with an error which is not
+// transient, reports that it cannot be retried or the backoff is exhausted.
+func retry(ctx context.Context, backoff *wait.Backoff, operation string, logger *logrus.Entry, f func() (bool, error)) error {
+	for {
+		retriable, err := f()
+		if err == nil {
+			return nil
+		}
+		category, transient := CategorizeError(err)
+		retry := retriable && transient && backoff.Steps > 1 && ctx.Err() == nil
+		recordPodAPIError(PodAPIError{Operation: operation, Category: category, Retried: retry})
+		if !retry {
+			return err
+		}
+		delay := backoff.Step()
+		logger.WithError(err).WithField("category", category).Debugf("Retrying %s request in %s after a transient error.", operation, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
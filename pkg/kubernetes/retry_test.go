@@ -0,0 +1,305 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCategorizeError(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		err               error
+		expectedCategory  string
+		expectedTransient bool
+	}{
+		{
+			name:              "dialing backend",
+			err:               errors.New(`error dialing backend: dial tcp 10.0.0.1:10250: i/o timeout`),
+			expectedCategory:  ErrorCategoryDialingBackend,
+			expectedTransient: true,
+		},
+		{
+			name:              "service unavailable",
+			err:               kerrors.NewServiceUnavailable("unavailable"),
+			expectedCategory:  ErrorCategoryServer,
+			expectedTransient: true,
+		},
+		{
+			name:              "too many requests",
+			err:               kerrors.NewTooManyRequests("slow down", 1),
+			expectedCategory:  ErrorCategoryServer,
+			expectedTransient: true,
+		},
+		{
+			name:              "connection refused",
+			err:               syscall.ECONNREFUSED,
+			expectedCategory:  ErrorCategoryConnection,
+			expectedTransient: true,
+		},
+		{
+			name:             "not found",
+			err:              kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod"),
+			expectedCategory: ErrorCategoryPermanent,
+		},
+		{
+			name:             "command failure",
+			err:              errors.New("command terminated with exit code 1"),
+			expectedCategory: ErrorCategoryPermanent,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			category, transient := CategorizeError(tc.err)
+			if category != tc.expectedCategory {
+				t.Errorf("expected category %q, got %q", tc.expectedCategory, category)
+			}
+			if transient != tc.expectedTransient {
+				t.Errorf("expected transient to be %t, got %t", tc.expectedTransient, transient)
+			}
+		})
+	}
+}
+
+// flakyExecutor fails with the given errors before running the command,
+// writing its output first if `partial` is set.
+type flakyExecutor struct {
+	errs    []error
+	partial bool
+	calls   int
+}
+
+func (e *flakyExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *flakyExecutor) StreamWithContext(_ context.Context, options remotecommand.StreamOptions) error {
+	e.calls++
+	if e.partial || len(e.errs) == 0 {
+		if _, err := options.Stdout.Write([]byte("output")); err != nil {
+			return err
+		}
+	}
+	if len(e.errs) != 0 {
+		err := e.errs[0]
+		e.errs = e.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func TestRetryingExecutor(t *testing.T) {
+	backoff := wait.Backoff{Duration: time.Millisecond, Steps: 3}
+	dialing := errors.New("error dialing backend: EOF")
+	for _, tc := range []struct {
+		name          string
+		executor      *flakyExecutor
+		stdin         io.Reader
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "success",
+			executor:      &flakyExecutor{},
+			expectedCalls: 1,
+		},
+		{
+			name:          "transient errors are retried",
+			executor:      &flakyExecutor{errs: []error{dialing, dialing}},
+			expectedCalls: 3,
+		},
+		{
+			name:          "retries are limited",
+			executor:      &flakyExecutor{errs: []error{dialing, dialing, dialing}},
+			expectedCalls: 3,
+			expectedErr:   dialing,
+		},
+		{
+			name:          "permanent errors are not retried",
+			executor:      &flakyExecutor{errs: []error{errors.New("command terminated with exit code 1")}},
+			expectedCalls: 1,
+			expectedErr:   errors.New("command terminated with exit code 1"),
+		},
+		{
+			name:          "commands which produced output are not retried",
+			executor:      &flakyExecutor{errs: []error{dialing}, partial: true},
+			expectedCalls: 1,
+			expectedErr:   dialing,
+		},
+		{
+			name:          "commands reading input are not retried",
+			executor:      &flakyExecutor{errs: []error{dialing}},
+			stdin:         strings.NewReader("input"),
+			expectedCalls: 1,
+			expectedErr:   dialing,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := retryingExecutor{Executor: tc.executor, backoff: backoff, logger: logrus.NewEntry(logrus.StandardLogger())}
+			var stdout bytes.Buffer
+			err := e.Stream(remotecommand.StreamOptions{Stdin: tc.stdin, Stdout: &stdout})
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if tc.executor.calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, tc.executor.calls)
+			}
+			if err == nil && stdout.String() != "output" {
+				t.Errorf("unexpected output: %q", stdout.String())
+			}
+		})
+	}
+}
+
+type flakyLogsClient struct {
+	PodClient
+	errs  []error
+	calls int
+}
+
+func (c *flakyLogsClient) StreamLogs(context.Context, string, string, *coreapi.PodLogOptions) (io.ReadCloser, error) {
+	c.calls++
+	if len(c.errs) != 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader("logs")), nil
+}
+
+func TestRetryingPodClientStreamLogs(t *testing.T) {
+	inner := &flakyLogsClient{errs: []error{kerrors.NewServiceUnavailable("unavailable")}}
+	client := retryingPodClient{PodClient: inner, backoff: wait.Backoff{Duration: time.Millisecond, Steps: 3}}
+	s, err := client.StreamLogs(context.Background(), "ns", "pod", &coreapi.PodLogOptions{Container: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logs, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatalf("failed to read logs: %v", err)
+	}
+	if string(logs) != "logs" {
+		t.Errorf("unexpected logs: %q", string(logs))
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", inner.calls)
+	}
+}
+
+// brokenReader returns an error once it read all of its content.
+type brokenReader struct {
+	io.Reader
+	err error
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+// brokenLogsClient serves log streams which break after the given parts of
+// the log, then the whole log.
+type brokenLogsClient struct {
+	PodClient
+	log   string
+	parts []int
+	calls int
+}
+
+func (c *brokenLogsClient) StreamLogs(context.Context, string, string, *coreapi.PodLogOptions) (io.ReadCloser, error) {
+	c.calls++
+	if len(c.parts) != 0 {
+		part := c.parts[0]
+		c.parts = c.parts[1:]
+		return io.NopCloser(&brokenReader{Reader: strings.NewReader(c.log[:part]), err: syscall.ECONNRESET}), nil
+	}
+	return io.NopCloser(strings.NewReader(c.log)), nil
+}
+
+func TestRetryingPodClientReadLogs(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		parts         []int
+		opts          *coreapi.PodLogOptions
+		expected      string
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "broken streams are read again from where they broke",
+			parts:         []int{5, 12},
+			opts:          &coreapi.PodLogOptions{Container: "test", Follow: true},
+			expected:      "first line\nsecond line\n",
+			expectedCalls: 3,
+		},
+		{
+			name:          "progress resets the backoff",
+			parts:         []int{1, 2, 3, 4},
+			opts:          &coreapi.PodLogOptions{Container: "test"},
+			expected:      "first line\nsecond line\n",
+			expectedCalls: 5,
+		},
+		{
+			name:          "retries without progress are limited",
+			parts:         []int{5, 5, 5, 5},
+			opts:          &coreapi.PodLogOptions{Container: "test"},
+			expected:      "first",
+			expectedCalls: 3,
+			expectedErr:   syscall.ECONNRESET,
+		},
+		{
+			name:          "streams not starting at the beginning of the log are not read again",
+			parts:         []int{5},
+			opts:          &coreapi.PodLogOptions{Container: "test", TailLines: ptr.To[int64](10)},
+			expected:      "first",
+			expectedCalls: 1,
+			expectedErr:   syscall.ECONNRESET,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inner := &brokenLogsClient{log: "first line\nsecond line\n", parts: tc.parts}
+			client := retryingPodClient{PodClient: inner, backoff: wait.Backoff{Duration: time.Millisecond, Steps: 3}}
+			s, err := client.StreamLogs(context.Background(), "ns", "pod", tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			logs, err := io.ReadAll(s)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "logs", string(logs), tc.expected)
+			if inner.calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, inner.calls)
+			}
+		})
+	}
+}
+
+func TestPodAPIErrors(t *testing.T) {
+	before := PodAPIErrors()
+	inner := &flakyLogsClient{errs: []error{kerrors.NewServiceUnavailable("unavailable"), kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod")}}
+	client := retryingPodClient{PodClient: inner, backoff: wait.Backoff{Duration: time.Millisecond, Steps: 3}}
+	if _, err := client.StreamLogs(context.Background(), "ns", "pod", &coreapi.PodLogOptions{Container: "test"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	after := PodAPIErrors()
+	retried := PodAPIError{Operation: "logs", Category: ErrorCategoryServer, Retried: true}
+	permanent := PodAPIError{Operation: "logs", Category: ErrorCategoryPermanent}
+	if after[retried] != before[retried]+1 || after[permanent] != before[permanent]+1 {
+		t.Errorf("expected one retried and one permanent error to be recorded, got %v before and %v after", before, after)
+	}
+}
@@ -0,0 +1,81 @@
+package leaks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+type ec2Client interface {
+	ec2.DescribeTagsAPIClient
+	ec2.DescribeInstancesAPIClient
+}
+
+// liveInstanceStates are the states of instances which were not terminated.
+// Terminated instances remain visible with their tags for a while.
+var liveInstanceStates = []string{"pending", "running", "shutting-down", "stopping", "stopped"}
+
+type awsScanner struct {
+	client ec2Client
+	region string
+}
+
+// NewAWSScanner creates a scanner for the EC2 resources of a region, using the
+// default credential chain.
+func NewAWSScanner(ctx context.Context, region string) (Scanner, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return &awsScanner{client: ec2.NewFromConfig(cfg), region: region}, nil
+}
+
+// Scan lists the EC2 resources carrying the tag, whatever its value. Resources
+// outside of EC2, like load balancers or DNS records, are not covered.
+func (s *awsScanner) Scan(ctx context.Context, tag string) ([]Resource, error) {
+	var resources []Resource
+	var tagged bool
+	paginator := ec2.NewDescribeTagsPaginator(s.client, &ec2.DescribeTagsInput{
+		Filters: []ec2types.Filter{{Name: aws.String("key"), Values: []string{tag}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tags: %w", err)
+		}
+		for _, t := range page.Tags {
+			if t.ResourceType == ec2types.ResourceTypeInstance {
+				tagged = true
+				continue
+			}
+			resources = append(resources, Resource{Type: string(t.ResourceType), ID: aws.ToString(t.ResourceId), Location: s.region})
+		}
+	}
+	if !tagged {
+		return resources, nil
+	}
+	// Instances are selected by the tag rather than by the IDs listed above, as
+	// the request fails as a whole when one of them has disappeared since.
+	instancePaginator := ec2.NewDescribeInstancesPaginator(s.client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag-key"), Values: []string{tag}},
+			{Name: aws.String("instance-state-name"), Values: liveInstanceStates},
+		},
+	})
+	for instancePaginator.HasMorePages() {
+		page, err := instancePaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				resources = append(resources, Resource{Type: string(ec2types.ResourceTypeInstance), ID: aws.ToString(instance.InstanceId), Location: s.region})
+			}
+		}
+	}
+	return resources, nil
+}
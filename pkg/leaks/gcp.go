@@ -0,0 +1,86 @@
+package leaks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	cloudAssetEndpoint = "https://cloudasset.googleapis.com"
+	cloudAssetScope    = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+type gcpScanner struct {
+	endpoint   string
+	project    string
+	httpClient *http.Client
+}
+
+// NewGCPScanner creates a scanner for the resources of a project, using the
+// Cloud Asset API with the application default credentials.
+func NewGCPScanner(ctx context.Context, project string) (Scanner, error) {
+	client, err := google.DefaultClient(ctx, cloudAssetScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GCP credentials: %w", err)
+	}
+	return &gcpScanner{endpoint: cloudAssetEndpoint, project: project, httpClient: client}, nil
+}
+
+type searchAllResourcesResponse struct {
+	Results []struct {
+		Name      string `json:"name"`
+		AssetType string `json:"assetType"`
+		Location  string `json:"location"`
+	} `json:"results"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// Scan lists the resources of the project carrying the label, whatever its value.
+func (s *gcpScanner) Scan(ctx context.Context, label string) ([]Resource, error) {
+	var resources []Resource
+	query := url.Values{"query": []string{fmt.Sprintf("labels.%s:*", label)}}
+	for {
+		response, err := s.search(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range response.Results {
+			resources = append(resources, Resource{Type: result.AssetType, ID: result.Name, Location: result.Location})
+		}
+		if response.NextPageToken == "" {
+			return resources, nil
+		}
+		query.Set("pageToken", response.NextPageToken)
+	}
+}
+
+func (s *gcpScanner) search(ctx context.Context, query url.Values) (*searchAllResourcesResponse, error) {
+	u := fmt.Sprintf("%s/v1/projects/%s/resources:searchAll?%s", s.endpoint, s.project, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search resources: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search resources: %s: %s", resp.Status, body)
+	}
+	var ret searchAllResourcesResponse
+	if err := json.Unmarshal(body, &ret); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &ret, nil
+}
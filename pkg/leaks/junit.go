@@ -0,0 +1,44 @@
+package leaks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const testName = "Cloud resources are removed during teardown"
+
+// JUnit reports the leaks as a flake: a failing test case followed by a
+// passing one with the same name, so leaks are visible without failing the job.
+func JUnit(manifest *Manifest, scanErr error) *junit.TestSuites {
+	suite := &junit.TestSuite{Name: "leak-detection"}
+	var failure *junit.FailureOutput
+	switch {
+	case scanErr != nil:
+		failure = &junit.FailureOutput{
+			Message: "Could not scan for leaked resources",
+			Output:  scanErr.Error(),
+		}
+	case len(manifest.Resources) != 0:
+		var out strings.Builder
+		for _, r := range manifest.Resources {
+			fmt.Fprintf(&out, "%s %s", r.Type, r.ID)
+			if r.Location != "" {
+				fmt.Fprintf(&out, " (%s)", r.Location)
+			}
+			out.WriteString("\n")
+		}
+		failure = &junit.FailureOutput{
+			Message: fmt.Sprintf("%d resources of cluster %s tagged with %s survived the teardown", len(manifest.Resources), manifest.Cluster, manifest.Tag),
+			Output:  out.String(),
+		}
+	}
+	if failure != nil {
+		suite.TestCases = append(suite.TestCases, &junit.TestCase{Name: testName, FailureOutput: failure})
+		suite.NumFailed++
+	}
+	suite.TestCases = append(suite.TestCases, &junit.TestCase{Name: testName})
+	suite.NumTests = uint(len(suite.TestCases))
+	return &junit.TestSuites{Suites: []*junit.TestSuite{suite}}
+}
@@ -0,0 +1,134 @@
+// Package leaks finds the cloud resources of a cluster that survived its
+// teardown. Resources are looked up using the tag or label the installer puts
+// on all resources it creates, keyed on the infrastructure name of the cluster.
+// Leaks are recorded in a manifest consumed by the reaper, which removes them.
+package leaks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// ManifestFile is the name of the leak manifest in the artifacts of the step.
+	ManifestFile = "leaks.json"
+	// JUnitFile is the name of the jUnit file reporting the leaks.
+	JUnitFile = "junit_leaks.xml"
+)
+
+// Provider is the cloud the cluster was installed to.
+type Provider string
+
+const (
+	ProviderAWS Provider = "aws"
+	ProviderGCP Provider = "gcp"
+)
+
+// DefaultTag is the key of the tag or label the installer puts on all
+// resources of a cluster on the provider.
+func DefaultTag(provider Provider) string {
+	if provider == ProviderGCP {
+		return "kubernetes-io-cluster-" + api.LeakDetectionClusterNamePlaceholder
+	}
+	return "kubernetes.io/cluster/" + api.LeakDetectionClusterNamePlaceholder
+}
+
+// TagFor resolves the tag template for the cluster.
+func TagFor(template, infraID string) string {
+	return strings.ReplaceAll(template, api.LeakDetectionClusterNamePlaceholder, infraID)
+}
+
+// Resource is a cloud resource which survived the teardown of the cluster.
+type Resource struct {
+	// Type is the type of the resource as reported by the provider, e.g.
+	// `instance` or `compute.googleapis.com/Disk`.
+	Type string `json:"type"`
+	// ID identifies the resource: the ID on AWS, the full resource name on GCP.
+	ID string `json:"id"`
+	// Location is the region or zone of the resource, when it has one.
+	Location string `json:"location,omitempty"`
+}
+
+// Manifest lists the leaked resources of a cluster.
+type Manifest struct {
+	// Cluster is the infrastructure name of the cluster.
+	Cluster  string   `json:"cluster"`
+	Provider Provider `json:"provider"`
+	// Tag is the key of the tag or label the resources were looked up with.
+	Tag string `json:"tag"`
+	// Region is the AWS region that was scanned.
+	Region string `json:"region,omitempty"`
+	// Project is the GCP project that was scanned.
+	Project   string     `json:"project,omitempty"`
+	ScannedAt time.Time  `json:"scanned_at"`
+	Resources []Resource `json:"resources"`
+}
+
+// Scanner lists the resources carrying a tag.
+type Scanner interface {
+	Scan(ctx context.Context, tag string) ([]Resource, error)
+}
+
+// Metadata is the subset of the `metadata.json` written by the installer that
+// locates the resources of the cluster.
+type Metadata struct {
+	InfraID string `json:"infraID"`
+	AWS     *struct {
+		Region string `json:"region"`
+	} `json:"aws,omitempty"`
+	GCP *struct {
+		Region    string `json:"region"`
+		ProjectID string `json:"projectID"`
+	} `json:"gcp,omitempty"`
+}
+
+// ReadMetadata loads the installer metadata.
+func ReadMetadata(path string) (*Metadata, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Metadata
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	if m.InfraID == "" {
+		return nil, fmt.Errorf("%s does not contain the infrastructure name", path)
+	}
+	return &m, nil
+}
+
+// Provider is the cloud the cluster was installed to, if supported.
+func (m *Metadata) Provider() (Provider, error) {
+	switch {
+	case m.AWS != nil:
+		return ProviderAWS, nil
+	case m.GCP != nil:
+		return ProviderGCP, nil
+	}
+	return "", errors.New("leak detection is only supported on aws and gcp")
+}
+
+// Scan looks up the resources of the cluster that are still present.
+func Scan(ctx context.Context, scanner Scanner, manifest *Manifest) error {
+	resources, err := scanner.Scan(ctx, manifest.Tag)
+	if err != nil {
+		return err
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Type != resources[j].Type {
+			return resources[i].Type < resources[j].Type
+		}
+		return resources[i].ID < resources[j].ID
+	})
+	manifest.Resources = resources
+	return nil
+}
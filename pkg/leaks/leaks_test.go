@@ -0,0 +1,147 @@
+package leaks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestReadMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		content          string
+		expectedProvider Provider
+		expectedError    error
+	}{
+		{
+			name:             "aws",
+			content:          `{"clusterName":"ci-op-abc","infraID":"ci-op-abc-xyz","aws":{"region":"us-east-1"}}`,
+			expectedProvider: ProviderAWS,
+		},
+		{
+			name:             "gcp",
+			content:          `{"clusterName":"ci-op-abc","infraID":"ci-op-abc-xyz","gcp":{"region":"us-east1","projectID":"ci-project"}}`,
+			expectedProvider: ProviderGCP,
+		},
+		{
+			name:          "unsupported",
+			content:       `{"clusterName":"ci-op-abc","infraID":"ci-op-abc-xyz","azure":{"region":"eastus"}}`,
+			expectedError: errors.New("leak detection is only supported on aws and gcp"),
+		},
+		{
+			name:          "no infrastructure name",
+			content:       `{"clusterName":"ci-op-abc","aws":{"region":"us-east-1"}}`,
+			expectedError: errors.New("metadata.json does not contain the infrastructure name"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "metadata.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var provider Provider
+			metadata, err := ReadMetadata(path)
+			if err == nil {
+				provider, err = metadata.Provider()
+			}
+			if err != nil {
+				err = errors.New(strings.ReplaceAll(err.Error(), dir+"/", ""))
+			}
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if provider != tc.expectedProvider {
+				t.Errorf("expected provider %q, got %q", tc.expectedProvider, provider)
+			}
+		})
+	}
+}
+
+func TestTagFor(t *testing.T) {
+	for template, expected := range map[string]string{
+		DefaultTag(ProviderAWS):     "kubernetes.io/cluster/ci-op-abc-xyz",
+		DefaultTag(ProviderGCP):     "kubernetes-io-cluster-ci-op-abc-xyz",
+		"owner-${CLUSTER_NAME}-tag": "owner-ci-op-abc-xyz-tag",
+	} {
+		if actual := TagFor(template, "ci-op-abc-xyz"); actual != expected {
+			t.Errorf("%s: expected %s, got %s", template, expected, actual)
+		}
+	}
+}
+
+type fakeScanner []Resource
+
+func (s fakeScanner) Scan(context.Context, string) ([]Resource, error) {
+	return s, nil
+}
+
+func TestScanAndJUnit(t *testing.T) {
+	manifest := &Manifest{Cluster: "ci-op-abc-xyz", Provider: ProviderAWS, Tag: "kubernetes.io/cluster/ci-op-abc-xyz"}
+	if err := Scan(context.Background(), fakeScanner{
+		{Type: "volume", ID: "vol-2"},
+		{Type: "instance", ID: "i-1", Location: "us-east-1"},
+		{Type: "volume", ID: "vol-1"},
+	}, manifest); err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	expected := []Resource{
+		{Type: "instance", ID: "i-1", Location: "us-east-1"},
+		{Type: "volume", ID: "vol-1"},
+		{Type: "volume", ID: "vol-2"},
+	}
+	if diff := cmp.Diff(expected, manifest.Resources); diff != "" {
+		t.Errorf("unexpected resources: %s", diff)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		manifest *Manifest
+		err      error
+		expected []*junit.TestCase
+	}{
+		{
+			name:     "no leaks",
+			manifest: &Manifest{},
+			expected: []*junit.TestCase{{Name: testName}},
+		},
+		{
+			name:     "leaks",
+			manifest: manifest,
+			expected: []*junit.TestCase{
+				{Name: testName, FailureOutput: &junit.FailureOutput{
+					Message: "3 resources of cluster ci-op-abc-xyz tagged with kubernetes.io/cluster/ci-op-abc-xyz survived the teardown",
+					Output:  "instance i-1 (us-east-1)\nvolume vol-1\nvolume vol-2\n",
+				}},
+				{Name: testName},
+			},
+		},
+		{
+			name:     "scan failed",
+			manifest: &Manifest{},
+			err:      errors.New("failed to describe tags: access denied"),
+			expected: []*junit.TestCase{
+				{Name: testName, FailureOutput: &junit.FailureOutput{
+					Message: "Could not scan for leaked resources",
+					Output:  "failed to describe tags: access denied",
+				}},
+				{Name: testName},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			suites := JUnit(tc.manifest, tc.err)
+			if diff := cmp.Diff(tc.expected, suites.Suites[0].TestCases); diff != "" {
+				t.Errorf("unexpected test cases: %s", diff)
+			}
+		})
+	}
+}
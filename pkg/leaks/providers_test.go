@@ -0,0 +1,97 @@
+package leaks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type fakeEC2Client struct {
+	tags      []ec2types.TagDescription
+	instances []ec2types.Instance
+	requested *ec2.DescribeInstancesInput
+}
+
+func (c *fakeEC2Client) DescribeTags(_ context.Context, _ *ec2.DescribeTagsInput, _ ...func(*ec2.Options)) (*ec2.DescribeTagsOutput, error) {
+	return &ec2.DescribeTagsOutput{Tags: c.tags}, nil
+}
+
+func (c *fakeEC2Client) DescribeInstances(_ context.Context, input *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	c.requested = input
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: c.instances}}}, nil
+}
+
+func TestAWSScanner(t *testing.T) {
+	client := &fakeEC2Client{
+		tags: []ec2types.TagDescription{
+			{ResourceType: ec2types.ResourceTypeVolume, ResourceId: aws.String("vol-1")},
+			{ResourceType: ec2types.ResourceTypeInstance, ResourceId: aws.String("i-terminated")},
+			{ResourceType: ec2types.ResourceTypeInstance, ResourceId: aws.String("i-running")},
+		},
+		instances: []ec2types.Instance{{InstanceId: aws.String("i-running")}},
+	}
+	scanner := &awsScanner{client: client, region: "us-east-1"}
+	resources, err := scanner.Scan(context.Background(), "kubernetes.io/cluster/ci-op-abc-xyz")
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	expected := []Resource{
+		{Type: "volume", ID: "vol-1", Location: "us-east-1"},
+		{Type: "instance", ID: "i-running", Location: "us-east-1"},
+	}
+	if diff := cmp.Diff(expected, resources); diff != "" {
+		t.Errorf("unexpected resources: %s", diff)
+	}
+	if len(client.requested.InstanceIds) != 0 {
+		t.Errorf("expected instances to be selected by their tag, got IDs %v", client.requested.InstanceIds)
+	}
+	expectedFilters := []ec2types.Filter{
+		{Name: aws.String("tag-key"), Values: []string{"kubernetes.io/cluster/ci-op-abc-xyz"}},
+		{Name: aws.String("instance-state-name"), Values: liveInstanceStates},
+	}
+	if diff := cmp.Diff(expectedFilters, client.requested.Filters, cmpopts.IgnoreUnexported(ec2types.Filter{})); diff != "" {
+		t.Errorf("unexpected instance filters: %s", diff)
+	}
+}
+
+func TestGCPScanner(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/ci-project/resources:searchAll" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("pageToken") == "" {
+			_, _ = w.Write([]byte(`{"results":[{"name":"//compute.googleapis.com/projects/ci-project/zones/us-east1-b/disks/disk-1","assetType":"compute.googleapis.com/Disk","location":"us-east1-b"}],"nextPageToken":"next"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"name":"//compute.googleapis.com/projects/ci-project/global/firewalls/fw","assetType":"compute.googleapis.com/Firewall","location":"global"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	scanner := &gcpScanner{endpoint: server.URL, project: "ci-project", httpClient: server.Client()}
+	resources, err := scanner.Scan(context.Background(), "kubernetes-io-cluster-ci-op-abc-xyz")
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	expected := []Resource{
+		{Type: "compute.googleapis.com/Disk", ID: "//compute.googleapis.com/projects/ci-project/zones/us-east1-b/disks/disk-1", Location: "us-east1-b"},
+		{Type: "compute.googleapis.com/Firewall", ID: "//compute.googleapis.com/projects/ci-project/global/firewalls/fw", Location: "global"},
+	}
+	if diff := cmp.Diff(expected, resources); diff != "" {
+		t.Errorf("unexpected resources: %s", diff)
+	}
+	expectedQueries := []string{
+		"query=labels.kubernetes-io-cluster-ci-op-abc-xyz%3A%2A",
+		"pageToken=next&query=labels.kubernetes-io-cluster-ci-op-abc-xyz%3A%2A",
+	}
+	if diff := cmp.Diff(expectedQueries, queries); diff != "" {
+		t.Errorf("unexpected queries: %s", diff)
+	}
+}
@@ -49,6 +49,11 @@ type Client interface {
 	// Heartbeat updates all leases. It calls the cancellation function of each
 	// lease it fails to update.
 	Heartbeat() error
+	// Renew keeps leases for as long as they are held: the heartbeat keeps
+	// retrying to update them instead of giving up after the number of retries,
+	// so a long-running test does not lose its leases to an outage of the
+	// lease server.
+	Renew(names ...string)
 	// Release ends one lease by name.
 	Release(name string) error
 	// ReleaseAll ends all leases and returns the names of those that were
@@ -93,6 +98,8 @@ type client struct {
 
 type lease struct {
 	updateFailures int
+	// renew is set for leases that are never given up on failed updates
+	renew bool
 	// cancel holds a cancellation function for steps that depend on leases
 	// being active; we must cancel this when we encounter errors to tie the
 	// lifetime of the downstream user routines to those of the leases they
@@ -145,7 +152,7 @@ func (c *client) Heartbeat() error {
 			continue
 		}
 		logrus.WithError(err).Warnf("Failed to update lease %q", name)
-		if lease.updateFailures != c.retries {
+		if lease.updateFailures != c.retries || lease.renew {
 			c.leases[name].updateFailures++
 			continue
 		}
@@ -156,6 +163,16 @@ func (c *client) Heartbeat() error {
 	return utilerrors.NewAggregate(errs)
 }
 
+func (c *client) Renew(names ...string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, name := range names {
+		if l, ok := c.leases[name]; ok {
+			l.renew = true
+		}
+	}
+}
+
 func (c *client) Release(name string) error {
 	c.Lock()
 	defer c.Unlock()
@@ -131,3 +131,35 @@ func TestHeartbeatRetries(t *testing.T) {
 		})
 	}
 }
+
+func TestHeartbeatRenew(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	client := NewFakeClient("owner", "url", 0, map[string]error{
+		"updateone owner rtype_0 leased 0": errors.New("injected error"),
+		"updateone owner rtype_0 leased 1": errors.New("injected error"),
+	}, &calls)
+	var called bool
+	names, err := client.Acquire("rtype", 1, ctx, func() { called = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Renew(names...)
+	for i := 0; i < 3; i++ {
+		if err := client.Heartbeat(); err != nil {
+			t.Errorf("unexpected error (%d): %v", i, err)
+		}
+	}
+	if called {
+		t.Error("cancel function unexpectedly called")
+	}
+	expected := []string{
+		"acquireWaitWithPriority owner rtype free leased random",
+		"updateone owner rtype_0 leased 0",
+		"updateone owner rtype_0 leased 1",
+		"updateone owner rtype_0 leased 2",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("wrong calls to the boskos client: %v", diff.ObjectDiff(calls, expected))
+	}
+}
@@ -0,0 +1,113 @@
+package lease
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/boskos/common"
+	"sigs.k8s.io/yaml"
+)
+
+// reservationSuffix is appended to the name of a cluster profile to form the
+// resource type holding its reservations.
+const reservationSuffix = "-reservation"
+
+// Reservations caps the number of jobs using each cluster profile
+// concurrently, so that a burst of jobs using one profile cannot starve the
+// other profiles sharing the same cloud account.
+//
+// Each reservation is a resource type in the lease server with as many
+// resources as the maximum number of concurrent jobs. A job leases one of
+// them before it leases the resources of its cluster profile.
+type Reservations struct {
+	// Profiles maps cluster profiles to the maximum number of jobs using
+	// them concurrently.
+	Profiles map[string]int `json:"profiles"`
+}
+
+// LoadReservations reads and validates a reservations file. A missing file
+// means that no cluster profile has reservations, so that jobs can run on
+// clusters where the reservations were not deployed.
+func LoadReservations(path string) (*Reservations, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Reservations{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reservations: %w", err)
+	}
+	var r Reservations
+	if err := yaml.UnmarshalStrict(raw, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse reservations: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid reservations: %w", err)
+	}
+	return &r, nil
+}
+
+// Validate checks that every reservation allows at least one job.
+func (r *Reservations) Validate() error {
+	var errs []error
+	for _, profile := range r.profiles() {
+		if limit := r.Profiles[profile]; limit < 1 {
+			errs = append(errs, fmt.Errorf("profile %s: maximum number of concurrent jobs must be positive, got %d", profile, limit))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// ReservationType is the resource type holding the reservations of a cluster
+// profile, empty if the profile has no reservation.
+func (r *Reservations) ReservationType(profile string) string {
+	if r == nil {
+		return ""
+	}
+	if _, ok := r.Profiles[profile]; !ok {
+		return ""
+	}
+	return profile + reservationSuffix
+}
+
+// ResourceEntries are the resources to configure in the lease server for the
+// reservations to be enforced.
+func (r *Reservations) ResourceEntries() []common.ResourceEntry {
+	var entries []common.ResourceEntry
+	for _, profile := range r.profiles() {
+		rtype := profile + reservationSuffix
+		entry := common.ResourceEntry{Type: rtype, State: common.Free}
+		for i := 0; i < r.Profiles[profile]; i++ {
+			entry.Names = append(entry.Names, fmt.Sprintf("%s-%02d", rtype, i))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// UpdateBoskosConfig replaces the reservation resources in the configuration
+// of the lease server with the ones of the reservations: resources of
+// reservations which were removed are dropped, the other resources are kept
+// as they are.
+func (r *Reservations) UpdateBoskosConfig(config *common.BoskosConfig) {
+	var resources []common.ResourceEntry
+	for _, entry := range config.Resources {
+		if !strings.HasSuffix(entry.Type, reservationSuffix) {
+			resources = append(resources, entry)
+		}
+	}
+	config.Resources = append(resources, r.ResourceEntries()...)
+}
+
+func (r *Reservations) profiles() []string {
+	var ret []string
+	for profile := range r.Profiles {
+		ret = append(ret, profile)
+	}
+	sort.Strings(ret)
+	return ret
+}
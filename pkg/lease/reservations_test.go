@@ -0,0 +1,102 @@
+package lease
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/boskos/common"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLoadReservations(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		content     string
+		expected    *Reservations
+		expectedErr error
+	}{
+		{
+			name:     "valid",
+			content:  "profiles:\n  aws: 2\n  gcp: 10\n",
+			expected: &Reservations{Profiles: map[string]int{"aws": 2, "gcp": 10}},
+		},
+		{
+			name:     "missing file",
+			expected: &Reservations{},
+		},
+		{
+			name:        "unknown field",
+			content:     "profile:\n  aws: 2\n",
+			expectedErr: errors.New(`failed to parse reservations: error unmarshaling JSON: while decoding JSON: json: unknown field "profile"`),
+		},
+		{
+			name:        "non-positive limits",
+			content:     "profiles:\n  aws: 0\n  gcp: -1\n",
+			expectedErr: errors.New("invalid reservations: [profile aws: maximum number of concurrent jobs must be positive, got 0, profile gcp: maximum number of concurrent jobs must be positive, got -1]"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reservations.yaml")
+			if tc.content != "" {
+				if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			actual, err := LoadReservations(path)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected reservations: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReservationType(t *testing.T) {
+	r := &Reservations{Profiles: map[string]int{"aws": 2}}
+	if actual := r.ReservationType("aws"); actual != "aws-reservation" {
+		t.Errorf("unexpected reservation type: %q", actual)
+	}
+	if actual := r.ReservationType("gcp"); actual != "" {
+		t.Errorf("expected no reservation for gcp, got %q", actual)
+	}
+	var none *Reservations
+	if actual := none.ReservationType("aws"); actual != "" {
+		t.Errorf("expected no reservation without reservations, got %q", actual)
+	}
+}
+
+func TestResourceEntries(t *testing.T) {
+	r := &Reservations{Profiles: map[string]int{"gcp": 1, "aws": 2}}
+	expected := []common.ResourceEntry{
+		{Type: "aws-reservation", State: common.Free, Names: []string{"aws-reservation-00", "aws-reservation-01"}},
+		{Type: "gcp-reservation", State: common.Free, Names: []string{"gcp-reservation-00"}},
+	}
+	if diff := cmp.Diff(expected, r.ResourceEntries()); diff != "" {
+		t.Errorf("unexpected resource entries: %s", diff)
+	}
+}
+
+func TestUpdateBoskosConfig(t *testing.T) {
+	r := &Reservations{Profiles: map[string]int{"aws": 1}}
+	config := &common.BoskosConfig{Resources: []common.ResourceEntry{
+		{Type: "aws-quota-slice", State: common.Free, Names: []string{"us-east-1"}},
+		{Type: "aws-reservation", State: common.Free, Names: []string{"aws-reservation-00", "aws-reservation-01", "aws-reservation-02"}},
+		{Type: "gcp-reservation", State: common.Free, Names: []string{"gcp-reservation-00"}},
+		{Type: "gcp-quota-slice", State: common.Free, Names: []string{"us-east1"}},
+	}}
+	r.UpdateBoskosConfig(config)
+	expected := &common.BoskosConfig{Resources: []common.ResourceEntry{
+		{Type: "aws-quota-slice", State: common.Free, Names: []string{"us-east-1"}},
+		{Type: "gcp-quota-slice", State: common.Free, Names: []string{"us-east1"}},
+		{Type: "aws-reservation", State: common.Free, Names: []string{"aws-reservation-00"}},
+	}}
+	if diff := cmp.Diff(expected, config); diff != "" {
+		t.Errorf("unexpected configuration: %s", diff)
+	}
+}
@@ -1,6 +1,9 @@
 package agents
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -21,6 +24,7 @@ type RegistryAgent interface {
 	ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error)
 	GetRegistryComponents() (registry.ReferenceByName, registry.ChainByName, registry.WorkflowByName, map[string]string, api.RegistryMetadata)
 	GetGeneration() int
+	GetDigest() string
 	GetClusterProfiles() api.ClusterProfilesMap
 	GetClusterProfileDetails(profileName string) (*api.ClusterProfileDetails, error)
 	registry.Resolver
@@ -30,7 +34,10 @@ type registryAgent struct {
 	lock            *sync.RWMutex
 	resolver        registry.Resolver
 	registryPath    string
+	snapshotsPath   string
+	overlaysPath    string
 	generation      int
+	digest          string
 	errorMetrics    *prometheus.CounterVec
 	flags           load.RegistryFlag
 	references      registry.ReferenceByName
@@ -39,6 +46,7 @@ type registryAgent struct {
 	clusterProfiles api.ClusterProfilesMap
 	documentation   map[string]string
 	metadata        api.RegistryMetadata
+	snapshots       map[string]registry.Snapshot
 }
 
 var registryReloadTimeMetric = prometheus.NewHistogram(
@@ -59,7 +67,13 @@ type RegistryAgentOptions struct {
 	ErrorMetric *prometheus.CounterVec
 	// FlatRegistry describes if the registry is flat, which means org/repo/branch info can not be inferred
 	// from the filepath. Defaults to true.
-	FlatRegistry            *bool
+	FlatRegistry *bool
+	// SnapshotsPath is the directory holding the registry snapshots
+	// configurations can pin their references to, one subdirectory per version.
+	SnapshotsPath string
+	// OverlaysPath is the directory holding the overlays applied to the
+	// registry for configurations on matching branches.
+	OverlaysPath            string
 	UniversalSymlinkWatcher *UniversalSymlinkWatcher
 }
 
@@ -77,6 +91,18 @@ func WithRegistryFlat(v bool) RegistryAgentOption {
 	}
 }
 
+func WithRegistrySnapshots(path string) RegistryAgentOption {
+	return func(o *RegistryAgentOptions) {
+		o.SnapshotsPath = path
+	}
+}
+
+func WithRegistryOverlays(path string) RegistryAgentOption {
+	return func(o *RegistryAgentOptions) {
+		o.OverlaysPath = path
+	}
+}
+
 // NewRegistryAgent returns a RegistryAgent interface that automatically reloads when
 // the registry is changed on disk.
 func NewRegistryAgent(registryPath string, errCh chan error, opts ...RegistryAgentOption) (RegistryAgent, error) {
@@ -95,10 +121,12 @@ func NewRegistryAgent(registryPath string, errCh chan error, opts ...RegistryAge
 		flags |= load.RegistryFlat
 	}
 	a := &registryAgent{
-		registryPath: registryPath,
-		lock:         &sync.RWMutex{},
-		errorMetrics: opt.ErrorMetric,
-		flags:        flags,
+		registryPath:  registryPath,
+		snapshotsPath: opt.SnapshotsPath,
+		overlaysPath:  opt.OverlaysPath,
+		lock:          &sync.RWMutex{},
+		errorMetrics:  opt.ErrorMetric,
+		flags:         flags,
 	}
 	// Load config once so we fail early if that doesn't work and are ready as soon as we return
 	if err := a.loadRegistry(); err != nil {
@@ -137,6 +165,14 @@ func (a *registryAgent) GetGeneration() int {
 	return a.generation
 }
 
+// GetDigest returns the digest of the content of the registry, which, unlike
+// the generation, identifies the registry across processes.
+func (a *registryAgent) GetDigest() string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.digest
+}
+
 func (a *registryAgent) GetRegistryComponents() (registry.ReferenceByName, registry.ChainByName, registry.WorkflowByName, map[string]string, api.RegistryMetadata) {
 	return a.references, a.chains, a.workflows, a.documentation, a.metadata
 }
@@ -172,7 +208,33 @@ func (a *registryAgent) loadRegistry() error {
 		a.documentation = documentation
 		a.metadata = metadata
 		a.clusterProfiles = clusterProfiles
-		a.resolver = registry.NewResolver(references, chains, workflows, observers)
+		if a.snapshotsPath != "" {
+			snapshots, err := load.RegistrySnapshots(a.snapshotsPath, a.flags&load.RegistryFlat, a.snapshots)
+			if err != nil {
+				recordErrorForMetric(a.errorMetrics, "failed to load ci-operator registry snapshots")
+				return time.Duration(0), err
+			}
+			a.snapshots = snapshots
+		}
+		var overlays []registry.Overlay
+		if a.overlaysPath != "" {
+			if overlays, err = load.RegistryOverlays(a.overlaysPath, a.flags&load.RegistryFlat); err != nil {
+				recordErrorForMetric(a.errorMetrics, "failed to load ci-operator registry overlays")
+				return time.Duration(0), err
+			}
+		}
+		resolver, err := registry.NewResolverWithOverlays(references, chains, workflows, observers, a.snapshots, overlays)
+		if err != nil {
+			recordErrorForMetric(a.errorMetrics, "failed to load ci-operator registry overlays")
+			return time.Duration(0), err
+		}
+		digest, err := registryDigest(references, chains, workflows, observers, a.snapshots, overlays)
+		if err != nil {
+			recordErrorForMetric(a.errorMetrics, "failed to digest ci-operator registry")
+			return time.Duration(0), err
+		}
+		a.resolver = resolver
+		a.digest = digest
 		a.generation++
 		return time.Since(startTime), nil
 	}()
@@ -184,6 +246,37 @@ func (a *registryAgent) loadRegistry() error {
 	return nil
 }
 
+// registryDigest hashes everything resolutions depend on. Maps are serialized
+// with sorted keys, so the digest only changes with the content.
+func registryDigest(references registry.ReferenceByName, chains registry.ChainByName, workflows registry.WorkflowByName, observers registry.ObserverByName, snapshots map[string]registry.Snapshot, overlays []registry.Overlay) (string, error) {
+	type overlay struct {
+		Name     string
+		Branches []string
+		registry.Snapshot
+	}
+	content := struct {
+		registry.Snapshot
+		Snapshots map[string]registry.Snapshot
+		Overlays  []overlay
+	}{
+		Snapshot:  registry.Snapshot{References: references, Chains: chains, Workflows: workflows, Observers: observers},
+		Snapshots: snapshots,
+	}
+	for _, o := range overlays {
+		var branches []string
+		for _, branch := range o.Branches {
+			branches = append(branches, branch.String())
+		}
+		content.Overlays = append(content.Overlays, overlay{Name: o.Name, Branches: branches, Snapshot: o.Snapshot})
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to digest the registry: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func (a *registryAgent) Resolve(name string, config api.MultiStageTestConfiguration) (api.MultiStageTestConfigurationLiteral, error) {
 	return a.resolver.Resolve(name, config)
 }
@@ -2,11 +2,13 @@ package agents
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
@@ -67,3 +69,29 @@ func TestGetClusterProfileDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryDigest(t *testing.T) {
+	references := func(commands string) registry.ReferenceByName {
+		return registry.ReferenceByName{"step": {As: "step", From: "src", Commands: commands}}
+	}
+	overlays := func(branch string) []registry.Overlay {
+		return []registry.Overlay{{Name: "legacy", Branches: []*regexp.Regexp{regexp.MustCompile(branch)}}}
+	}
+	digest := func(references registry.ReferenceByName, overlays []registry.Overlay) string {
+		d, err := registryDigest(references, nil, nil, nil, nil, overlays)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return d
+	}
+	original := digest(references("make test"), overlays("release-4.1"))
+	if reloaded := digest(references("make test"), overlays("release-4.1")); reloaded != original {
+		t.Errorf("the digest of an identical registry changed from %s to %s", original, reloaded)
+	}
+	if changed := digest(references("make e2e"), overlays("release-4.1")); changed == original {
+		t.Error("the digest did not change with a reference")
+	}
+	if changed := digest(references("make test"), overlays("release-4.2")); changed == original {
+		t.Error("the digest did not change with the branches of an overlay")
+	}
+}
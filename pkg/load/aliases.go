@@ -0,0 +1,54 @@
+package load
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// RejectYAMLAliases returns an error for every YAML anchor and alias in the
+// document. Configuration files are loaded through JSON, which expands aliases
+// silently, so a file using them does not survive being rewritten by the tools
+// normalizing configuration and the shared content is easy to change in more
+// places than intended. Configuration is required to spell out the content
+// instead.
+func RejectYAMLAliases(raw []byte) error {
+	// anchors and aliases cannot be written without these
+	if !bytes.ContainsAny(raw, "&*") {
+		return nil
+	}
+	decoder := yamlv3.NewDecoder(bytes.NewReader(raw))
+	var errs []error
+	for {
+		var document yamlv3.Node
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// syntax errors are reported by the actual unmarshalling
+			return nil
+		}
+		errs = append(errs, aliasErrors(&document)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func aliasErrors(node *yamlv3.Node) []error {
+	var errs []error
+	if node.Anchor != "" {
+		errs = append(errs, fmt.Errorf("line %d: YAML anchor &%s is not allowed, repeat the content instead", node.Line, node.Anchor))
+	}
+	if node.Kind == yamlv3.AliasNode {
+		// the aliased node is reported where its anchor is defined
+		return append(errs, fmt.Errorf("line %d: YAML alias *%s is not allowed, repeat the content instead", node.Line, node.Value))
+	}
+	for _, child := range node.Content {
+		errs = append(errs, aliasErrors(child)...)
+	}
+	return errs
+}
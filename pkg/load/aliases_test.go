@@ -0,0 +1,59 @@
+package load
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRejectYAMLAliases(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		raw      string
+		expected error
+	}{
+		{
+			name: "no aliases",
+			raw: `tests:
+- as: unit
+  commands: make test && echo "*done*"
+`,
+		},
+		{
+			name: "anchor and alias",
+			raw: `resources:
+  '*': &default
+    requests:
+      cpu: 100m
+  unit: *default
+`,
+			expected: errors.New("[line 2: YAML anchor &default is not allowed, repeat the content instead, line 5: YAML alias *default is not allowed, repeat the content instead]"),
+		},
+		{
+			name: "merge key in a later document",
+			raw: `build_root:
+  image_stream_tag: {}
+---
+base: &base
+  cpu: 100m
+unit:
+  <<: *base
+  memory: 200Mi
+`,
+			expected: errors.New("[line 4: YAML anchor &base is not allowed, repeat the content instead, line 7: YAML alias *base is not allowed, repeat the content instead]"),
+		},
+		{
+			name: "syntax errors are left to unmarshalling",
+			raw:  "a: *\n\tb",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, RejectYAMLAliases([]byte(tc.raw)), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -30,12 +31,17 @@ const (
 	ObserverSuffix = "-observer.yaml"
 	CommandsSuffix = "-commands" // excluding the file extension
 	MetadataSuffix = ".metadata.json"
+	// OverlaysFile lists the registry overlays in their directory
+	OverlaysFile = "overlays.yaml"
 )
 
 const (
 	RegistryFlat = RegistryFlag(1) << iota
 	RegistryMetadata
 	RegistryDocumentation
+	// RegistryUnvalidated skips validating the registry as a whole, for
+	// callers which validate it once combined with other components
+	RegistryUnvalidated
 )
 
 // Registry takes the path to a registry config directory and returns the full set of references, chains,
@@ -97,6 +103,11 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 				return fmt.Errorf("file %s has incorrect prefix. Prefix should be %s", path, prefix)
 			}
 		}
+		if filepath.Ext(path) == ".yaml" && !strings.HasSuffix(path, CommandsSuffix+".yaml") {
+			if err := RejectYAMLAliases(raw); err != nil {
+				return fmt.Errorf("failed to load registry file %s: %w", path, err)
+			}
+		}
 		if strings.HasSuffix(path, RefSuffix) {
 			name, doc, ref, err := loadReference(raw, dir, prefix, flat)
 			if err != nil {
@@ -193,20 +204,22 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, nil, err
 	}
-	// create graph to verify that there are no cycles
-	if _, err = registry.NewGraph(references, chains, workflows, observers); err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
-	}
-	err = registry.Validate(references, chains, workflows, observers)
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
-	}
-	profiles, err = ClusterProfilesConfig(clusterProfilesConfigPath)
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
+	if flags&RegistryUnvalidated == 0 {
+		// create graph to verify that there are no cycles
+		if _, err = registry.NewGraph(references, chains, workflows, observers); err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+		err = registry.Validate(references, chains, workflows, observers)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+		profiles, err = ClusterProfilesConfig(clusterProfilesConfigPath)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
 	}
 	// validate the integrity of each reference
-	v := validation.NewValidator(nil, nil)
+	v := validation.NewValidator(nil, nil, nil)
 	var validationErrors []error
 	for _, r := range references {
 		if err := v.IsValidReference(r); err != nil {
@@ -219,12 +232,127 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 	return references, chains, workflows, profiles, documentation, metadata, observers, nil
 }
 
+// RegistrySnapshots loads the registry snapshots stored in a directory, one
+// subdirectory per version. Snapshots never change once stored, so the ones
+// found in `loaded` are reused instead of being read again.
+func RegistrySnapshots(root string, flags RegistryFlag, loaded map[string]registry.Snapshot) (map[string]registry.Snapshot, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry snapshots: %w", err)
+	}
+	snapshots := make(map[string]registry.Snapshot, len(entries))
+	for _, entry := range entries {
+		version := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(version, ".") {
+			continue
+		}
+		if snapshot, ok := loaded[version]; ok {
+			snapshots[version] = snapshot
+			continue
+		}
+		references, chains, workflows, _, _, _, observers, err := Registry(filepath.Join(root, version), flags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry snapshot %s: %w", version, err)
+		}
+		snapshots[version] = registry.Snapshot{
+			References: references,
+			Chains:     chains,
+			Workflows:  workflows,
+			Observers:  observers,
+		}
+	}
+	return snapshots, nil
+}
+
+// RegistryResolver loads the registry and creates a resolver for it. Versions
+// pinned with name@version are resolved from the snapshots stored in the
+// snapshots directory, if one is given, and are rejected otherwise. The
+// overlays in the overlays directory, if one is given, are applied to the
+// configurations on the branches they match.
+func RegistryResolver(path, snapshotsPath, overlaysPath string, flags RegistryFlag) (registry.Resolver, error) {
+	references, chains, workflows, _, _, _, observers, err := Registry(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots map[string]registry.Snapshot
+	if snapshotsPath != "" {
+		if snapshots, err = RegistrySnapshots(snapshotsPath, flags, nil); err != nil {
+			return nil, err
+		}
+	}
+	var overlays []registry.Overlay
+	if overlaysPath != "" {
+		if overlays, err = RegistryOverlays(overlaysPath, flags); err != nil {
+			return nil, err
+		}
+	}
+	return registry.NewResolverWithOverlays(references, chains, workflows, observers, snapshots, overlays)
+}
+
+// overlayConfig is an entry of the file listing the registry overlays.
+type overlayConfig struct {
+	// Name is the name of the directory holding the overlay.
+	Name string `json:"name"`
+	// Branches are the patterns of the branches the overlay applies to.
+	Branches []string `json:"branches"`
+}
+
+// RegistryOverlays loads the registry overlays listed in the overlays file of
+// a directory. Each overlay lives in the subdirectory with its name and only
+// holds the components it replaces.
+func RegistryOverlays(root string, flags RegistryFlag) ([]registry.Overlay, error) {
+	raw, err := gzip.ReadFileMaybeGZIP(filepath.Join(root, OverlaysFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry overlays: %w", err)
+	}
+	var configs []overlayConfig
+	if err := yaml.UnmarshalStrict(raw, &configs); err != nil {
+		return nil, fmt.Errorf("failed to load registry overlays: %w", err)
+	}
+	var overlays []registry.Overlay
+	for _, c := range configs {
+		if c.Name == "" || strings.ContainsRune(c.Name, filepath.Separator) {
+			return nil, fmt.Errorf("invalid registry overlay name: %q", c.Name)
+		}
+		if len(c.Branches) == 0 {
+			return nil, fmt.Errorf("registry overlay %s does not apply to any branch", c.Name)
+		}
+		overlay := registry.Overlay{Name: c.Name}
+		for _, branch := range c.Branches {
+			re, err := regexp.Compile(branch)
+			if err != nil {
+				return nil, fmt.Errorf("registry overlay %s: invalid branch pattern: %w", c.Name, err)
+			}
+			overlay.Branches = append(overlay.Branches, re)
+		}
+		references, chains, workflows, _, _, _, observers, err := Registry(filepath.Join(root, c.Name), flags|RegistryUnvalidated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry overlay %s: %w", c.Name, err)
+		}
+		overlay.Snapshot = registry.Snapshot{
+			References: references,
+			Chains:     chains,
+			Workflows:  workflows,
+			Observers:  observers,
+		}
+		overlays = append(overlays, overlay)
+	}
+	return overlays, nil
+}
+
 func loadReference(bytes []byte, baseDir, prefix string, flat bool) (string, string, api.LiteralTestStep, error) {
 	step := api.RegistryReferenceConfig{}
 	err := yaml.UnmarshalStrict(bytes, &step)
 	if err != nil {
 		return "", "", api.LiteralTestStep{}, err
 	}
+	if len(step.Reference.TypedSteps()) != 0 {
+		// the commands of typed steps are generated, they have no file to load
+		if step.Reference.Commands != "" {
+			return "", "", api.LiteralTestStep{}, fmt.Errorf("reference %s is a typed step and cannot set commands", step.Reference.As)
+		}
+		return step.Reference.As, step.Reference.Documentation, step.Reference.LiteralTestStep, nil
+	}
 	if !flat && step.Reference.Commands != fmt.Sprintf("%s%s%s", prefix, CommandsSuffix, filepath.Ext(step.Reference.Commands)) {
 		return "", "", api.LiteralTestStep{}, fmt.Errorf("reference %s has invalid command file path; command should be set to %s (with an optional extension like .sh)", step.Reference.As, fmt.Sprintf("%s%s", prefix, CommandsSuffix))
 	}
@@ -303,3 +431,53 @@ func ClusterClaimOwnersConfig(configPath string) (api.ClusterClaimOwnersMap, err
 	}
 	return clusterClaimOwnersMap, nil
 }
+
+// GoVersionPolicy loads the Go version policy for build roots
+func GoVersionPolicy(configPath string) (*api.GoVersionPolicy, error) {
+	configContents, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Go version policy: %w", err)
+	}
+	var policy api.GoVersionPolicy
+	if err := yaml.UnmarshalStrict(configContents, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Go version policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Go version policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// ResourceCeilingPolicy loads the policy restricting the resources builds and
+// tests may request
+func ResourceCeilingPolicy(configPath string) (*api.ResourceCeilingPolicy, error) {
+	configContents, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource ceiling policy: %w", err)
+	}
+	var policy api.ResourceCeilingPolicy
+	if err := yaml.UnmarshalStrict(configContents, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal resource ceiling policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid resource ceiling policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// ValidationRuleAllowlist loads the allowlist of the validation rules the
+// configurations of each repository may disable
+func ValidationRuleAllowlist(configPath string) (*api.ValidationRuleAllowlist, error) {
+	configContents, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation rule allowlist: %w", err)
+	}
+	var allowlist api.ValidationRuleAllowlist
+	if err := yaml.UnmarshalStrict(configContents, &allowlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal validation rule allowlist: %w", err)
+	}
+	if err := allowlist.Validate(validation.IsRule); err != nil {
+		return nil, fmt.Errorf("invalid validation rule allowlist: %w", err)
+	}
+	return &allowlist, nil
+}
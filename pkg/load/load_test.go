@@ -4,9 +4,12 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 
@@ -266,6 +269,163 @@ func TestRegistry(t *testing.T) {
 	}
 }
 
+func TestRegistrySnapshots(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v2025-01-15/install/install-ref.yaml", "ref:\n  as: install\n  from: cli\n  commands: install-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n")
+	write("v2025-01-15/install/install-commands.sh", "install\n")
+	write("v2025-01-15/cluster-profiles/cluster-profiles-config.yaml", "[]\n")
+	write("v2025-02-01/install/install-ref.yaml", "invalid")
+	write("README.md", "snapshots")
+	loaded := map[string]registry.Snapshot{
+		"v2025-02-01": {References: registry.ReferenceByName{"install": {As: "install"}}},
+		"v2024-12-01": {},
+	}
+	snapshots, err := RegistrySnapshots(root, RegistryFlat, loaded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]registry.Snapshot{
+		"v2025-01-15": {
+			References: registry.ReferenceByName{
+				"install": {
+					As:        "install",
+					From:      "cli",
+					Commands:  "install\n",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}},
+				},
+			},
+			Chains:    registry.ChainByName{},
+			Workflows: registry.WorkflowByName{},
+			Observers: registry.ObserverByName{},
+		},
+		"v2025-02-01": loaded["v2025-02-01"],
+	}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("unexpected snapshots: %s", diff.ObjectReflectDiff(expected, snapshots))
+	}
+	if _, err := RegistrySnapshots(root, RegistryFlat, nil); err == nil {
+		t.Error("expected an error loading an invalid snapshot")
+	}
+}
+
+func TestRegistryResolver(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []string{"registry", "snapshots/v2025-01-15"} {
+		write(dir+"/cluster-profiles/cluster-profiles-config.yaml", "[]\n")
+		write(dir+"/install/install-ref.yaml", "ref:\n  as: install\n  from: cli\n  commands: install-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n")
+		write(dir+"/install/install-commands.sh", dir+"\n")
+	}
+	install := "install@v2025-01-15"
+	config := api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &install}}}
+
+	resolver, err := RegistryResolver(filepath.Join(root, "registry"), filepath.Join(root, "snapshots"), "", RegistryFlat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resolved, err := resolver.Resolve("test", config)
+	if err != nil {
+		t.Fatalf("failed to resolve a pinned reference: %v", err)
+	}
+	if commands := resolved.Test[0].Commands; commands != "snapshots/v2025-01-15\n" {
+		t.Errorf("expected the reference to be resolved from the snapshot, got commands %q", commands)
+	}
+
+	resolver, err = RegistryResolver(filepath.Join(root, "registry"), "", "", RegistryFlat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := resolver.Resolve("test", config); err == nil {
+		t.Error("expected pinned references to be rejected without snapshots")
+	}
+}
+
+func TestRegistryOverlays(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		files         map[string]string
+		expected      []registry.Overlay
+		expectedError string
+	}{
+		{
+			name: "overlay replacing a reference",
+			files: map[string]string{
+				OverlaysFile:                               "- name: release-4.14\n  branches:\n  - ^release-4\\.14$\n",
+				"release-4.14/install/install-ref.yaml":    "ref:\n  as: install\n  from: cli\n  commands: install-commands.sh\n  resources:\n    requests:\n      cpu: 100m\n",
+				"release-4.14/install/install-commands.sh": "install --old\n",
+			},
+			expected: []registry.Overlay{{
+				Name:     "release-4.14",
+				Branches: []*regexp.Regexp{regexp.MustCompile(`^release-4\.14$`)},
+				Snapshot: registry.Snapshot{
+					References: registry.ReferenceByName{
+						"install": {
+							As:        "install",
+							From:      "cli",
+							Commands:  "install --old\n",
+							Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}},
+						},
+					},
+					Chains:    registry.ChainByName{},
+					Workflows: registry.WorkflowByName{},
+					Observers: registry.ObserverByName{},
+				},
+			}},
+		},
+		{
+			name:          "overlay without branches",
+			files:         map[string]string{OverlaysFile: "- name: release-4.14\n"},
+			expectedError: "registry overlay release-4.14 does not apply to any branch",
+		},
+		{
+			name:          "invalid branch pattern",
+			files:         map[string]string{OverlaysFile: "- name: release-4.14\n  branches:\n  - release-4.14(\n"},
+			expectedError: "registry overlay release-4.14: invalid branch pattern: error parsing regexp: missing closing ): `release-4.14(`",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range tc.files {
+				path = filepath.Join(root, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			overlays, err := RegistryOverlays(root, RegistryFlat)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, actualError)
+			}
+			if !reflect.DeepEqual(overlays, tc.expected) {
+				t.Errorf("unexpected overlays: %s", diff.ObjectReflectDiff(tc.expected, overlays))
+			}
+		})
+	}
+}
+
 func TestClusterProfilesConfig(t *testing.T) {
 	existingProfiles := make(api.ClusterProfilesMap)
 	for _, profileName := range api.ClusterProfiles() {
@@ -453,3 +613,70 @@ func TestClusterClaimOwnersConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryTypedReferences(t *testing.T) {
+	temp := t.TempDir()
+	for name, content := range map[string]string{
+		"cluster/health/cluster-health-ref.yaml": `ref:
+  as: cluster-health
+  health_check:
+    checks:
+    - nodes_ready
+  documentation: Asserts that the nodes are ready.
+`,
+		"cluster/upgrade/cluster-upgrade-ref.yaml": `ref:
+  as: cluster-upgrade
+  upgrade:
+    channel: stable-4.20
+  documentation: Upgrades the cluster.
+`,
+		"cluster/cluster-chain.yaml": `chain:
+  as: cluster
+  steps:
+  - ref: cluster-upgrade
+  - ref: cluster-health
+`,
+		"cluster-profiles/cluster-profiles-config.yaml": "[]\n",
+	} {
+		path := filepath.Join(temp, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	references, _, _, _, documentation, _, _, err := Registry(temp, RegistryDocumentation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := registry.ReferenceByName{
+		"cluster-health": {
+			As:          "cluster-health",
+			HealthCheck: &api.HealthCheckStep{Checks: []api.HealthCheck{api.HealthCheckNodesReady}},
+		},
+		"cluster-upgrade": {
+			As:      "cluster-upgrade",
+			Upgrade: &api.UpgradeStep{Channel: "stable-4.20"},
+		},
+	}
+	if diff := cmp.Diff(expected, references); diff != "" {
+		t.Errorf("unexpected references: %s", diff)
+	}
+	if doc := documentation["cluster-upgrade"]; doc != "Upgrades the cluster." {
+		t.Errorf("unexpected documentation: %q", doc)
+	}
+
+	if err := os.WriteFile(filepath.Join(temp, "cluster/health/cluster-health-ref.yaml"), []byte(`ref:
+  as: cluster-health
+  commands: cluster-health-commands.sh
+  health_check:
+    checks:
+    - nodes_ready
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, _, _, _, err := Registry(temp, RegistryUnvalidated); err == nil || !strings.Contains(err.Error(), "reference cluster-health is a typed step and cannot set commands") {
+		t.Errorf("expected an error about the commands of the typed step, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package preflight probes the health of the build farm before ci-operator
+// executes the graph of a job, so that jobs fail fast during an incident on
+// the farm instead of failing mid-run after a long time.
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	// ReasonInfrastructureDegraded classifies the failures of the pre-flight
+	// checks. Jobs failing for this reason did not run and can be retried.
+	ReasonInfrastructureDegraded results.Reason = "infrastructure_degraded"
+	// ReportFile is the name of the artifact holding the pre-flight report.
+	ReportFile = "ci-operator-preflight.json"
+)
+
+// Check probes one aspect of the health of the build farm.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a check.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report lists the outcomes of the checks. Retryable is set when a check
+// failed, as the job did not start and can run again once the build farm
+// recovers.
+type Report struct {
+	Retryable bool          `json:"retryable"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// Error is returned when the build farm is degraded.
+type Error struct {
+	Failed []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("the build farm is degraded, the job did not start and can be retried: %s", strings.Join(e.Failed, ", "))
+}
+
+// Run runs the checks concurrently, giving each of them at most the timeout.
+// The error is classified as ReasonInfrastructureDegraded when checks fail.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) (Report, error) {
+	report := Report{Checks: make([]CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := check.Run(checkCtx)
+			report.Checks[i] = CheckResult{Name: check.Name, Duration: time.Since(start)}
+			if err != nil {
+				report.Checks[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	var failed []string
+	for _, result := range report.Checks {
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, result.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return report, nil
+	}
+	report.Retryable = true
+	return report, results.ForReason(ReasonInfrastructureDegraded).ForError(&Error{Failed: failed})
+}
+
+// Marshal serializes the report for the artifacts.
+func (r Report) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// apiLatencySamples is the number of times the API server is probed, so
+// that a single slow or failed request does not abort the job.
+const apiLatencySamples = 3
+
+// APILatency checks that the API server answers within the latency. The
+// server is probed several times and the check fails when most of the probes
+// fail or the median latency is above the limit.
+func APILatency(client discovery.ServerVersionInterface, latency time.Duration) Check {
+	return Check{
+		Name: "api-latency",
+		Run: func(ctx context.Context) error {
+			var took []time.Duration
+			var errs []error
+			for i := 0; i < apiLatencySamples; i++ {
+				start := time.Now()
+				done := make(chan error, 1)
+				go func() {
+					_, err := client.ServerVersion()
+					done <- err
+				}()
+				select {
+				case err := <-done:
+					if err != nil {
+						errs = append(errs, err)
+						continue
+					}
+					took = append(took, time.Since(start))
+				case <-ctx.Done():
+					return fmt.Errorf("the API server did not answer: %w", ctx.Err())
+				}
+			}
+			if len(errs) > apiLatencySamples/2 {
+				return fmt.Errorf("could not reach the API server in %d of %d attempts: %w", len(errs), apiLatencySamples, errs[len(errs)-1])
+			}
+			sort.Slice(took, func(i, j int) bool { return took[i] < took[j] })
+			if median := took[len(took)/2]; median > latency {
+				return fmt.Errorf("the API server took %s to answer, more than %s", median.Truncate(time.Millisecond), latency)
+			}
+			return nil
+		},
+	}
+}
+
+// ImageStreamImports checks that the tags which are imported have an image.
+// A failed import of a tag which still has the image of an earlier import does
+// not prevent the job from running. Tags that do not exist are left to the
+// resolution of the inputs, which reports them as configuration errors, as
+// are tags the job cannot read.
+func ImageStreamImports(client ctrlruntimeclient.Client, tags []api.ImageStreamTagReference) Check {
+	return Check{
+		Name: "imagestream-imports",
+		Run: func(ctx context.Context) error {
+			var failed []string
+			for _, tag := range tags {
+				stream := &imagev1.ImageStream{}
+				if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: tag.Namespace, Name: tag.Name}, stream); err != nil {
+					if kerrors.IsNotFound(err) || kerrors.IsForbidden(err) {
+						continue
+					}
+					return fmt.Errorf("could not get imagestream %s/%s: %w", tag.Namespace, tag.Name, err)
+				}
+				if message, ok := missingImage(stream, tag.Tag); ok {
+					failed = append(failed, fmt.Sprintf("%s (%s)", tag.ISTagName(), message))
+				}
+			}
+			if len(failed) != 0 {
+				return fmt.Errorf("imagestream tags have no image: %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// missingImage determines whether a tag of the imagestream is declared but
+// has no image, with the reason when the import of the tag failed.
+func missingImage(stream *imagev1.ImageStream, tag string) (string, bool) {
+	declared := false
+	for _, spec := range stream.Spec.Tags {
+		declared = declared || spec.Name == tag
+	}
+	for _, status := range stream.Status.Tags {
+		if status.Tag != tag {
+			continue
+		}
+		if len(status.Items) != 0 {
+			return "", false
+		}
+		for _, condition := range status.Conditions {
+			if condition.Type == imagev1.ImportSuccess && condition.Status == coreapi.ConditionFalse {
+				return condition.Message, true
+			}
+		}
+		declared = true
+	}
+	if !declared {
+		return "", false
+	}
+	return "not imported yet", true
+}
+
+// InputImageStreamTags returns the imagestream tags the configuration uses
+// as inputs.
+func InputImageStreamTags(config *api.ReleaseBuildConfiguration) []api.ImageStreamTagReference {
+	seen := sets.New[string]()
+	var ret []api.ImageStreamTagReference
+	add := func(tag api.ImageStreamTagReference) {
+		if tag.Namespace == "" || tag.Name == "" || seen.Has(tag.ISTagName()) {
+			return
+		}
+		seen.Insert(tag.ISTagName())
+		ret = append(ret, tag)
+	}
+	if root := config.BuildRootImage; root != nil && root.ImageStreamTagReference != nil {
+		add(*root.ImageStreamTagReference)
+	}
+	for _, images := range []map[string]api.ImageStreamTagReference{config.BaseImages, config.BaseRPMImages} {
+		for _, image := range images {
+			add(image)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ISTagName() < ret[j].ISTagName()
+	})
+	return ret
+}
+
+// quotaResources are the resources without which no pod of the job can run.
+var quotaResources = sets.New[coreapi.ResourceName](
+	coreapi.ResourcePods,
+	coreapi.ResourceCPU, coreapi.ResourceMemory,
+	coreapi.ResourceRequestsCPU, coreapi.ResourceRequestsMemory,
+	coreapi.ResourceLimitsCPU, coreapi.ResourceLimitsMemory,
+)
+
+// QuotaHeadroom checks that no resource quota of the namespace is exhausted
+// for the resources pods need. It runs once the namespace is set up, quotas
+// the job cannot read are not checked.
+func QuotaHeadroom(client ctrlruntimeclient.Client, namespace string) Check {
+	return Check{
+		Name: "quota-headroom",
+		Run: func(ctx context.Context) error {
+			quotas := &coreapi.ResourceQuotaList{}
+			if err := client.List(ctx, quotas, ctrlruntimeclient.InNamespace(namespace)); err != nil {
+				if kerrors.IsNotFound(err) || kerrors.IsForbidden(err) {
+					return nil
+				}
+				return fmt.Errorf("could not list the resource quotas of namespace %s: %w", namespace, err)
+			}
+			var exhausted []string
+			for _, quota := range quotas.Items {
+				for name, hard := range quota.Status.Hard {
+					if !quotaResources.Has(name) || hard.IsZero() {
+						continue
+					}
+					if used, ok := quota.Status.Used[name]; ok && used.Cmp(hard) >= 0 {
+						exhausted = append(exhausted, fmt.Sprintf("%s in %s (%s/%s)", name, quota.Name, used.String(), hard.String()))
+					}
+				}
+			}
+			if len(exhausted) != 0 {
+				sort.Strings(exhausted)
+				return fmt.Errorf("resource quotas of namespace %s are exhausted: %s", namespace, strings.Join(exhausted, ", "))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,244 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func init() {
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		panic(fmt.Sprintf("failed to add imagev1 to scheme: %v", err))
+	}
+}
+
+type fakeServerVersion struct {
+	delays []time.Duration
+	errs   []error
+	calls  int
+}
+
+func (f *fakeServerVersion) ServerVersion() (*version.Info, error) {
+	call := f.calls
+	f.calls++
+	if call < len(f.delays) {
+		time.Sleep(f.delays[call])
+	}
+	if call < len(f.errs) {
+		return &version.Info{}, f.errs[call]
+	}
+	return &version.Info{}, nil
+}
+
+func TestRun(t *testing.T) {
+	passing := Check{Name: "passing", Run: func(context.Context) error { return nil }}
+	failing := Check{Name: "failing", Run: func(context.Context) error { return errors.New("oops") }}
+	hanging := Check{Name: "hanging", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	for _, tc := range []struct {
+		name          string
+		checks        []Check
+		expected      []CheckResult
+		expectedError error
+	}{
+		{
+			name:     "all checks pass",
+			checks:   []Check{passing},
+			expected: []CheckResult{{Name: "passing"}},
+		},
+		{
+			name:          "failing and hanging checks",
+			checks:        []Check{passing, failing, hanging},
+			expected:      []CheckResult{{Name: "passing"}, {Name: "failing", Error: "oops"}, {Name: "hanging", Error: "context deadline exceeded"}},
+			expectedError: errors.New("the build farm is degraded, the job did not start and can be retried: failing: oops, hanging: context deadline exceeded"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Run(context.Background(), tc.checks, 10*time.Millisecond)
+			for i := range report.Checks {
+				report.Checks[i].Duration = 0
+			}
+			testhelper.Diff(t, "checks", report.Checks, tc.expected)
+			testhelper.Diff(t, "error", err, tc.expectedError, testhelper.EquateErrorMessage)
+			if report.Retryable != (tc.expectedError != nil) {
+				t.Errorf("expected the report to be retryable only when checks fail, got %t", report.Retryable)
+			}
+			if err != nil {
+				testhelper.Diff(t, "reasons", results.Reasons(err), []string{string(ReasonInfrastructureDegraded)})
+			}
+		})
+	}
+}
+
+func TestAPILatency(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		client   *fakeServerVersion
+		expected string
+	}{
+		{
+			name:   "fast API server",
+			client: &fakeServerVersion{},
+		},
+		{
+			name:     "unreachable API server",
+			client:   &fakeServerVersion{errs: []error{errors.New("connection refused"), errors.New("connection refused"), errors.New("connection refused")}},
+			expected: "could not reach the API server in 3 of 3 attempts: connection refused",
+		},
+		{
+			name:   "a single failed probe is tolerated",
+			client: &fakeServerVersion{errs: []error{errors.New("connection reset")}},
+		},
+		{
+			name:     "slow API server",
+			client:   &fakeServerVersion{delays: []time.Duration{50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}},
+			expected: "the API server took",
+		},
+		{
+			name:   "a single slow probe is tolerated",
+			client: &fakeServerVersion{delays: []time.Duration{50 * time.Millisecond}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := APILatency(tc.client, 10*time.Millisecond).Run(context.Background())
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Errorf("expected no error, got %v", err)
+			case tc.expected != "" && (err == nil || !strings.HasPrefix(err.Error(), tc.expected)):
+				t.Errorf("expected an error starting with %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestImageStreamImports(t *testing.T) {
+	failedImport := imagev1.TagEventCondition{Type: imagev1.ImportSuccess, Status: coreapi.ConditionFalse, Message: "registry unavailable"}
+	stream := func(name string, latest imagev1.NamedTagEventList) runtime.Object {
+		latest.Tag = "latest"
+		return &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: name},
+			Spec:       imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{{Name: "latest"}, {Name: "pending"}}},
+			Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+				latest,
+				{Tag: "other", Conditions: []imagev1.TagEventCondition{{Type: imagev1.ImportSuccess, Status: coreapi.ConditionFalse, Message: "not relevant"}}},
+			}},
+		}
+	}
+	image := []imagev1.TagEvent{{Image: "sha256:abc"}}
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+		stream("healthy", imagev1.NamedTagEventList{Items: image}),
+		stream("stale", imagev1.NamedTagEventList{Items: image, Conditions: []imagev1.TagEventCondition{failedImport}}),
+		stream("broken", imagev1.NamedTagEventList{Conditions: []imagev1.TagEventCondition{failedImport}}),
+	).Build()
+	for _, tc := range []struct {
+		name     string
+		tags     []api.ImageStreamTagReference
+		expected error
+	}{
+		{
+			name: "imports succeeded",
+			tags: []api.ImageStreamTagReference{{Namespace: "ci", Name: "healthy", Tag: "latest"}, {Namespace: "ci", Name: "missing", Tag: "latest"}},
+		},
+		{
+			name: "failed import of a tag which still has an image",
+			tags: []api.ImageStreamTagReference{{Namespace: "ci", Name: "stale", Tag: "latest"}},
+		},
+		{
+			name:     "import failed",
+			tags:     []api.ImageStreamTagReference{{Namespace: "ci", Name: "healthy", Tag: "latest"}, {Namespace: "ci", Name: "broken", Tag: "latest"}},
+			expected: errors.New("imagestream tags have no image: ci/broken:latest (registry unavailable)"),
+		},
+		{
+			name:     "tag not imported yet",
+			tags:     []api.ImageStreamTagReference{{Namespace: "ci", Name: "healthy", Tag: "pending"}},
+			expected: errors.New("imagestream tags have no image: ci/healthy:pending (not imported yet)"),
+		},
+		{
+			name: "tag which is not declared",
+			tags: []api.ImageStreamTagReference{{Namespace: "ci", Name: "healthy", Tag: "undeclared"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", ImageStreamImports(client, tc.tags).Run(context.Background()), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestInputImageStreamTags(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: "latest"},
+			},
+			BaseImages: map[string]api.ImageStreamTagReference{
+				"base":  {Namespace: "ocp", Name: "4.18", Tag: "base"},
+				"again": {Namespace: "ci", Name: "root", Tag: "latest"},
+			},
+			BaseRPMImages: map[string]api.ImageStreamTagReference{
+				"rpms": {Namespace: "ocp", Name: "4.18", Tag: "rpms"},
+			},
+		},
+	}
+	expected := []api.ImageStreamTagReference{
+		{Namespace: "ci", Name: "root", Tag: "latest"},
+		{Namespace: "ocp", Name: "4.18", Tag: "base"},
+		{Namespace: "ocp", Name: "4.18", Tag: "rpms"},
+	}
+	testhelper.Diff(t, "tags", InputImageStreamTags(config), expected)
+}
+
+func TestQuotaHeadroom(t *testing.T) {
+	quota := func(name string, hard, used coreapi.ResourceList) runtime.Object {
+		return &coreapi.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-1234", Name: name},
+			Status:     coreapi.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		objects  []runtime.Object
+		expected error
+	}{
+		{
+			name: "no quota",
+		},
+		{
+			name: "quotas with headroom",
+			objects: []runtime.Object{
+				quota("compute", coreapi.ResourceList{"requests.cpu": resource.MustParse("10"), "pods": resource.MustParse("20")}, coreapi.ResourceList{"requests.cpu": resource.MustParse("2"), "pods": resource.MustParse("3")}),
+				quota("objects", coreapi.ResourceList{"services.loadbalancers": resource.MustParse("0"), "secrets": resource.MustParse("10")}, coreapi.ResourceList{"services.loadbalancers": resource.MustParse("0"), "secrets": resource.MustParse("10")}),
+			},
+		},
+		{
+			name: "exhausted quota",
+			objects: []runtime.Object{
+				quota("compute", coreapi.ResourceList{"requests.cpu": resource.MustParse("10"), "pods": resource.MustParse("20")}, coreapi.ResourceList{"requests.cpu": resource.MustParse("10"), "pods": resource.MustParse("3")}),
+			},
+			expected: errors.New("resource quotas of namespace ci-op-1234 are exhausted: requests.cpu in compute (10/10)"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(tc.objects...).Build()
+			testhelper.Diff(t, "error", QuotaHeadroom(client, "ci-op-1234").Run(context.Background()), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
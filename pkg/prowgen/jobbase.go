@@ -1,6 +1,7 @@
 package prowgen
 
 import (
+	"strings"
 	"time"
 
 	utilpointer "k8s.io/utils/pointer"
@@ -26,8 +27,12 @@ func jobRelease(configSpec *cioperatorapi.ReleaseBuildConfiguration) string {
 	return ""
 }
 
-// If any included buildRoot uses from_repository we must not skip cloning
+// If any included buildRoot uses from_repository we must not skip cloning,
+// nor when the CVE gate of the promotion reads its waivers from the repository
 func skipCloning(configSpec *cioperatorapi.ReleaseBuildConfiguration) bool {
+	if configSpec.PromotionConfiguration != nil && configSpec.PromotionConfiguration.CVEGate != nil {
+		return false
+	}
 	buildRoots := configSpec.BuildRootImages
 	if buildRoots == nil {
 		buildRoots = make(map[string]cioperatorapi.BuildRootImageConfiguration)
@@ -120,6 +125,19 @@ func NewProwJobBaseBuilderForTest(configSpec *cioperatorapi.ReleaseBuildConfigur
 		p.WithLabel(cioperatorapi.ClusterLabel, string(test.Cluster))
 	}
 	p.testName = test.As
+	for key, value := range test.Labels {
+		p.WithLabel(key, value)
+	}
+	for key, value := range test.Annotations {
+		p.WithAnnotation(key, value)
+	}
+	if len(test.AllowedWindows) != 0 {
+		var windows []string
+		for _, window := range test.AllowedWindows {
+			windows = append(windows, window.String())
+		}
+		p.WithAnnotation(cioperatorapi.AllowedWindowsAnnotation, strings.Join(windows, ", "))
+	}
 
 	maxCustomDuration := time.Hour * 8
 	if test.Timeout != nil && test.Timeout.Duration <= maxCustomDuration {
@@ -129,6 +147,14 @@ func NewProwJobBaseBuilderForTest(configSpec *cioperatorapi.ReleaseBuildConfigur
 		}
 		u.DecorationConfig.Timeout = test.Timeout
 	}
+	if test.Soak != nil {
+		// soak tests are allowed to run for longer than any regular job
+		u := &p.base.UtilityConfig
+		if u.DecorationConfig == nil {
+			u.DecorationConfig = &prowv1.DecorationConfig{}
+		}
+		u.DecorationConfig.Timeout = &prowv1.Duration{Duration: test.Soak.JobTimeout()}
+	}
 
 	p.PodSpec.Add(Secrets(test.Secret), Secrets(test.Secrets...))
 	p.PodSpec.Add(Targets(test.As))
@@ -246,6 +272,15 @@ func (p *prowJobBaseBuilder) WithLabel(key, value string) *prowJobBaseBuilder {
 	return p
 }
 
+// WithAnnotation sets an annotation to the given value
+func (p *prowJobBaseBuilder) WithAnnotation(key, value string) *prowJobBaseBuilder {
+	if p.base.Annotations == nil {
+		p.base.Annotations = map[string]string{}
+	}
+	p.base.Annotations[key] = value
+	return p
+}
+
 // Build builds and returns the final JobBase instance
 func (p *prowJobBaseBuilder) Build(namePrefix string) prowconfig.JobBase {
 	p.base.Name = p.info.JobName(namePrefix, p.testName)
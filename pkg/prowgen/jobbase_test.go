@@ -299,6 +299,29 @@ func TestNewProwJobBaseBuilderForTest(t *testing.T) {
 			},
 			info: defaultInfo,
 		},
+		{
+			name: "simple container-based test cloning the waivers of the CVE gate",
+			cfg: &ciop.ReleaseBuildConfiguration{
+				PromotionConfiguration: &ciop.PromotionConfiguration{CVEGate: &ciop.CVEGate{}},
+			},
+			test: ciop.TestStepConfiguration{
+				As:                         "simple",
+				Commands:                   "make",
+				ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "src"},
+			},
+			info: defaultInfo,
+		},
+		{
+			name: "simple container-based test with labels and annotations",
+			test: ciop.TestStepConfiguration{
+				As:                         "simple",
+				Commands:                   "make",
+				ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "src"},
+				Labels:                     map[string]string{"cost-center": "team-a"},
+				Annotations:                map[string]string{"chaos.example.com/exclude": "true"},
+			},
+			info: defaultInfo,
+		},
 		{
 			name: "simple container-based test with secret",
 			test: ciop.TestStepConfiguration{
@@ -329,6 +352,17 @@ func TestNewProwJobBaseBuilderForTest(t *testing.T) {
 			},
 			info: defaultInfo,
 		},
+		{
+			name: "multi-stage soak test",
+			test: ciop.TestStepConfiguration{
+				As: "simple",
+				MultiStageTestConfiguration: &ciop.MultiStageTestConfiguration{
+					Workflow: pointer.StringPtr("workflow"),
+				},
+				Soak: &ciop.SoakConfiguration{MaxDurationDays: 3},
+			},
+			info: defaultInfo,
+		},
 		{
 			name: "multi-stage test with CSI enabled",
 			test: ciop.TestStepConfiguration{
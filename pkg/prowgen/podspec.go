@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/ptr"
 
 	cioperatorapi "github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
@@ -23,6 +24,7 @@ var defaultPodSpec = corev1.PodSpec{
 				"--image-import-pull-secret=/etc/pull-secret/.dockerconfigjson",
 				"--gcs-upload-secret=/secrets/gcs/service-account.json",
 				"--report-credentials-file=/etc/report/credentials",
+				"--feature-gate-config=/etc/feature-gates/gates.yaml",
 			},
 			Command:         []string{"ci-operator"},
 			Image:           "ci-operator:latest",
@@ -51,6 +53,11 @@ var defaultPodSpec = corev1.PodSpec{
 					MountPath: cioperatorapi.ManifestToolLocalPusherSecretMountPath,
 					ReadOnly:  true,
 				},
+				{
+					Name:      "feature-gates",
+					MountPath: "/etc/feature-gates",
+					ReadOnly:  true,
+				},
 			},
 		},
 	},
@@ -73,6 +80,17 @@ var defaultPodSpec = corev1.PodSpec{
 				Secret: &corev1.SecretVolumeSource{SecretName: cioperatorapi.ManifestToolLocalPusherSecret},
 			},
 		},
+		{
+			// the rollout of feature gates is optional, ci-operator keeps
+			// the defaults of the gates when it is not deployed
+			Name: "feature-gates",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ci-operator-feature-gates"},
+					Optional:             ptr.To(true),
+				},
+			},
+		},
 	},
 }
 
@@ -443,6 +461,9 @@ func Template(template, command, fromImage, target string, profile cioperatorapi
 const (
 	boskosVolumeName           = "boskos"
 	boskosCredentialsParameter = "--lease-server-credentials-file=/etc/boskos/credentials"
+
+	leaseReservationsVolumeName = "lease-reservations"
+	leaseReservationsParameter  = "--lease-reservations-file=/etc/lease-reservations/reservations.yaml"
 )
 
 var (
@@ -460,20 +481,40 @@ var (
 		MountPath: "/etc/boskos",
 		ReadOnly:  true,
 	}
+	leaseReservationsVolume = corev1.Volume{
+		Name: leaseReservationsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "lease-reservations"},
+				Optional:             ptr.To(true),
+			},
+		},
+	}
+	leaseReservationsVolumeMount = corev1.VolumeMount{
+		Name:      leaseReservationsVolumeName,
+		MountPath: "/etc/lease-reservations",
+		ReadOnly:  true,
+	}
 )
 
 // LeaseClient configures ci-operator to be able to interact with Boskos (lease
-// server), providing the necessary secrets to do so
+// server), providing the necessary secrets to do so, and to honor the
+// reservations of cluster profiles before acquiring leases
 func LeaseClient() PodSpecMutator {
 	return func(spec *corev1.PodSpec) error {
 		container := &spec.Containers[0]
-		if err := addVolume(spec, boskosVolume); err != nil {
-			return err
+		for _, volume := range []corev1.Volume{boskosVolume, leaseReservationsVolume} {
+			if err := addVolume(spec, volume); err != nil {
+				return err
+			}
 		}
-		if err := addVolumeMount(container, boskosVolumeMount); err != nil {
-			return err
+		for _, mount := range []corev1.VolumeMount{boskosVolumeMount, leaseReservationsVolumeMount} {
+			if err := addVolumeMount(container, mount); err != nil {
+				return err
+			}
 		}
 		addUniqueParameter(container, boskosCredentialsParameter)
+		addUniqueParameter(container, leaseReservationsParameter)
 		return nil
 	}
 
@@ -46,7 +46,17 @@ func GenerateJobs(configSpec *cioperatorapi.ReleaseBuildConfiguration, info *Pro
 	rehearsals := info.Config.Rehearsals
 	disabledRehearsals := sets.New[string](rehearsals.DisabledRehearsals...)
 
+	clusterGroups := sets.New[string]()
 	for _, element := range configSpec.Tests {
+		if group := element.ClusterGroup; group != "" {
+			// tests sharing a cluster run in a single job for the whole group,
+			// configured like the first test in the group
+			if clusterGroups.Has(group) {
+				continue
+			}
+			clusterGroups.Insert(group)
+			element.As = group
+		}
 		g := NewProwJobBaseBuilderForTest(configSpec, info, NewCiOperatorPodSpecGenerator(), element)
 
 		if element.NodeArchitecture != "" {
@@ -73,16 +83,20 @@ func GenerateJobs(configSpec *cioperatorapi.ReleaseBuildConfiguration, info *Pro
 				injectCapabilities(g.base.Labels, []string{string(element.NodeArchitecture)})
 			}
 
-			periodic := GeneratePeriodicForTest(g, info, FromConfigSpec(configSpec), func(options *GeneratePeriodicOptions) {
-				options.Cron = cron
-				options.Capabilities = element.Capabilities
-				options.Interval = interval
-				options.MinimumInterval = minimumInterval
-				options.ReleaseController = element.ReleaseController
-				options.DisableRehearsal = disableRehearsal
-				options.Retry = element.Retry
-			})
-			periodics = append(periodics, *periodic)
+			// periodics disabled for failing consistently keep their schedule
+			// to be restored, but get no job until they are
+			if _, disabled := element.Annotations[cioperatorapi.DisabledAtAnnotation]; !disabled {
+				periodic := GeneratePeriodicForTest(g, info, FromConfigSpec(configSpec), func(options *GeneratePeriodicOptions) {
+					options.Cron = cron
+					options.Capabilities = element.Capabilities
+					options.Interval = interval
+					options.MinimumInterval = minimumInterval
+					options.ReleaseController = element.ReleaseController
+					options.DisableRehearsal = disableRehearsal
+					options.Retry = element.Retry
+				})
+				periodics = append(periodics, *periodic)
+			}
 			if element.Presubmit {
 				handlePresubmit(g, element, info, disableRehearsal, configSpec.Resources.RequirementsForStep(element.As).Requests, presubmits, orgrepo)
 			}
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	utilpointer "k8s.io/utils/pointer"
@@ -350,6 +351,63 @@ func TestGenerateJobs(t *testing.T) {
 				Branch: "branch",
 			}},
 		},
+		{
+			id: "tests in a cluster group generate a single presubmit for the group",
+			config: &ciop.ReleaseBuildConfiguration{
+				Tests: []ciop.TestStepConfiguration{
+					{As: "e2e-a", ClusterGroup: "e2e-shared", MultiStageTestConfiguration: &ciop.MultiStageTestConfiguration{ClusterProfile: ciop.ClusterProfileAWS}},
+					{As: "e2e-b", ClusterGroup: "e2e-shared", MultiStageTestConfiguration: &ciop.MultiStageTestConfiguration{ClusterProfile: ciop.ClusterProfileAWS}},
+					{As: "unit", ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "from"}}},
+			},
+			repoInfo: &ProwgenInfo{Metadata: ciop.Metadata{
+				Org:    "organization",
+				Repo:   "repository",
+				Branch: "branch",
+			}},
+		},
+		{
+			id: "periodic with allowed windows is annotated with them",
+			config: &ciop.ReleaseBuildConfiguration{
+				Tests: []ciop.TestStepConfiguration{
+					{
+						As:   "disruptive",
+						Cron: utilpointer.String("0 22 * * 6"),
+						AllowedWindows: []ciop.AllowedWindow{
+							{Start: "0 22 * * 6", Duration: prowv1.Duration{Duration: 4 * time.Hour}},
+						},
+						MultiStageTestConfiguration: &ciop.MultiStageTestConfiguration{ClusterProfile: ciop.ClusterProfileAWS},
+					},
+				},
+			},
+			repoInfo: &ProwgenInfo{Metadata: ciop.Metadata{
+				Org:    "organization",
+				Repo:   "repository",
+				Branch: "branch",
+			}},
+		},
+		{
+			id: "disabled periodic is not generated",
+			config: &ciop.ReleaseBuildConfiguration{
+				Tests: []ciop.TestStepConfiguration{
+					{
+						As:                         "disabled",
+						Cron:                       utilpointer.String("0 22 * * 6"),
+						Annotations:                map[string]string{ciop.DisabledAtAnnotation: "2024-06-01T00:00:00Z"},
+						ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "bin"},
+					},
+					{
+						As:                         "enabled",
+						Cron:                       utilpointer.String("0 22 * * 6"),
+						ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "bin"},
+					},
+				},
+			},
+			repoInfo: &ProwgenInfo{Metadata: ciop.Metadata{
+				Org:    "organization",
+				Repo:   "repository",
+				Branch: "branch",
+			}},
+		},
 		{
 			id: "promotion postsubmit and periodic ",
 			config: &ciop.ReleaseBuildConfiguration{
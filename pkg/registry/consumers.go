@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"sort"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// Consumer identifies a multi-stage test of a ci-operator configuration.
+type Consumer struct {
+	Metadata api.Metadata
+	Test     string
+}
+
+type nodeKey struct {
+	nodeType Type
+	name     string
+}
+
+// ConsumerIndex is a reverse index from the components of the registry to
+// the tests using them, directly or through the components including them.
+// Components pinned to a registry snapshot are not indexed, since changes to
+// the registry do not affect them.
+type ConsumerIndex struct {
+	consumers map[nodeKey][]Consumer
+}
+
+// NewConsumerIndex indexes the multi-stage tests of the configurations by
+// the components of the registry graph they use. Tests overriding a phase of
+// their workflow are still indexed as consumers of the whole workflow.
+func NewConsumerIndex(graph NodeByName, configs []api.ReleaseBuildConfiguration) *ConsumerIndex {
+	index := &ConsumerIndex{consumers: map[nodeKey][]Consumer{}}
+	for _, config := range configs {
+		for _, test := range config.Tests {
+			if test.MultiStageTestConfiguration == nil {
+				continue
+			}
+			consumer := Consumer{Metadata: config.Metadata, Test: test.As}
+			for key := range usedNodes(graph, test.MultiStageTestConfiguration) {
+				index.consumers[key] = append(index.consumers[key], consumer)
+			}
+		}
+	}
+	return index
+}
+
+// Consumers returns the tests using a component of the registry.
+func (i *ConsumerIndex) Consumers(nodeType Type, name string) []Consumer {
+	return i.consumers[nodeKey{nodeType: nodeType, name: name}]
+}
+
+// ConsumersOf returns the tests using any of the components, each at most
+// once, sorted by configuration and test name.
+func (i *ConsumerIndex) ConsumersOf(nodes []Node) []Consumer {
+	seen := map[Consumer]bool{}
+	var ret []Consumer
+	for _, node := range nodes {
+		for _, c := range i.Consumers(node.Type(), node.Name()) {
+			if !seen[c] {
+				seen[c] = true
+				ret = append(ret, c)
+			}
+		}
+	}
+	sort.Slice(ret, func(a, b int) bool {
+		if pa, pb := ret[a].Metadata.RelativePath(), ret[b].Metadata.RelativePath(); pa != pb {
+			return pa < pb
+		}
+		return ret[a].Test < ret[b].Test
+	})
+	return ret
+}
+
+func usedNodes(graph NodeByName, test *api.MultiStageTestConfiguration) map[nodeKey]struct{} {
+	used := map[nodeKey]struct{}{}
+	add := func(node Node) {
+		used[nodeKey{nodeType: node.Type(), name: node.Name()}] = struct{}{}
+		for _, d := range node.Descendants() {
+			used[nodeKey{nodeType: d.Type(), name: d.Name()}] = struct{}{}
+		}
+	}
+	lookup := func(nodes map[string]Node, name *string) {
+		if name == nil {
+			return
+		}
+		if node, ok := nodes[*name]; ok {
+			add(node)
+		}
+	}
+	lookup(graph.Workflows, test.Workflow)
+	for _, steps := range [][]api.TestStep{test.Pre, test.Test, test.Gather, test.Post} {
+		for _, step := range steps {
+			lookup(graph.References, step.Reference)
+			lookup(graph.Chains, step.Chain)
+		}
+	}
+	if test.Observers != nil {
+		for i := range test.Observers.Enable {
+			lookup(graph.Observers, &test.Observers.Enable[i])
+		}
+	}
+	return used
+}
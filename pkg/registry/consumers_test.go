@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestConsumerIndex(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	references := ReferenceByName{
+		"install":  {As: "install"},
+		"teardown": {As: "teardown"},
+		"e2e":      {As: "e2e"},
+	}
+	chains := ChainByName{
+		"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}}},
+	}
+	workflows := WorkflowByName{
+		"ipi": {
+			Pre:  []api.TestStep{{Chain: strPtr("setup")}},
+			Post: []api.TestStep{{Reference: strPtr("teardown")}},
+		},
+	}
+	graph, err := NewGraph(references, chains, workflows, ObserverByName{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := api.Metadata{Org: "org", Repo: "repo", Branch: "main"}
+	configs := []api.ReleaseBuildConfiguration{{
+		Metadata: metadata,
+		Tests: []api.TestStepConfiguration{
+			{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			{As: "workflow", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi")}},
+			{As: "pinned", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi@v2025-01-15")}},
+			{As: "steps", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+				Pre:  []api.TestStep{{Chain: strPtr("setup")}},
+				Test: []api.TestStep{{Reference: strPtr("e2e")}},
+			}},
+		},
+	}}
+	index := NewConsumerIndex(graph, configs)
+	for _, tc := range []struct {
+		name     string
+		nodes    []Node
+		expected []Consumer
+	}{
+		{
+			name:     "workflow",
+			nodes:    []Node{graph.Workflows["ipi"]},
+			expected: []Consumer{{Metadata: metadata, Test: "workflow"}},
+		},
+		{
+			name:  "reference through a chain",
+			nodes: []Node{graph.References["install"]},
+			expected: []Consumer{
+				{Metadata: metadata, Test: "steps"},
+				{Metadata: metadata, Test: "workflow"},
+			},
+		},
+		{
+			name:  "several components used by the same tests",
+			nodes: []Node{graph.References["e2e"], graph.Chains["setup"]},
+			expected: []Consumer{
+				{Metadata: metadata, Test: "steps"},
+				{Metadata: metadata, Test: "workflow"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, index.ConsumersOf(tc.nodes)); diff != "" {
+				t.Errorf("unexpected consumers: %s", diff)
+			}
+		})
+	}
+}
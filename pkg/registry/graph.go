@@ -369,7 +369,7 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 				}
 			}
 		}
-		steps := append(workflow.Pre, append(workflow.Test, workflow.Post...)...)
+		steps := append(workflow.Pre, append(workflow.Test, append(workflow.Gather, workflow.Post...)...)...)
 		for _, step := range steps {
 			if step.Reference != nil {
 				if _, exists := referenceNodes[*step.Reference]; !exists {
@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Overlay replaces components of the registry for configurations on the
+// branches it matches, so that release branches can keep the behavior of
+// older steps while the development branch moves forward.
+type Overlay struct {
+	// Name identifies the overlay in errors.
+	Name string
+	// Branches are the patterns the branch of a configuration is matched
+	// against to decide whether the overlay applies to it.
+	Branches []*regexp.Regexp
+	// Snapshot holds the components replacing the ones with the same name
+	// in the registry.
+	Snapshot
+}
+
+// Matches determines whether the overlay applies to configurations on a branch.
+func (o *Overlay) Matches(branch string) bool {
+	for _, re := range o.Branches {
+		if re.MatchString(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+type overlay struct {
+	*Overlay
+	registry *registry
+}
+
+// NewResolverWithOverlays creates a resolver that resolves pinned components
+// from the given registry snapshots and applies the first matching overlay
+// to configurations resolved with ResolveConfig. Each overlay is validated
+// merged with the registry.
+func NewResolverWithOverlays(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, snapshots map[string]Snapshot, overlays []Overlay) (Resolver, error) {
+	r := NewResolverWithSnapshots(stepsByName, chainsByName, workflowsByName, observersByName, snapshots).(*registry)
+	for i := range overlays {
+		o := &overlays[i]
+		merged := &registry{
+			stepsByName:     mergeComponents(stepsByName, o.References),
+			chainsByName:    mergeComponents(chainsByName, o.Chains),
+			workflowsByName: mergeComponents(workflowsByName, o.Workflows),
+			observersByName: mergeComponents(observersByName, o.Observers),
+			snapshots:       r.snapshots,
+		}
+		if _, err := NewGraph(merged.stepsByName, merged.chainsByName, merged.workflowsByName, merged.observersByName); err != nil {
+			return nil, fmt.Errorf("invalid registry overlay %s: %w", o.Name, err)
+		}
+		if err := Validate(merged.stepsByName, merged.chainsByName, merged.workflowsByName, merged.observersByName); err != nil {
+			return nil, fmt.Errorf("invalid registry overlay %s: %w", o.Name, err)
+		}
+		r.overlays = append(r.overlays, overlay{Overlay: o, registry: merged})
+	}
+	return r, nil
+}
+
+// forBranch returns the registry as seen by configurations on a branch.
+func (r *registry) forBranch(branch string) *registry {
+	for _, o := range r.overlays {
+		if o.Matches(branch) {
+			return o.registry
+		}
+	}
+	return r
+}
+
+// mergeComponents returns a copy of `dst` with the elements of `src` added,
+// replacing those with the same name.
+func mergeComponents[T any](dst, src map[string]T) map[string]T {
+	ret := make(map[string]T, len(dst)+len(src))
+	for k, v := range dst {
+		ret[k] = v
+	}
+	for k, v := range src {
+		ret[k] = v
+	}
+	return ret
+}
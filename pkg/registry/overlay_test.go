@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestResolveConfigWithOverlays(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	step := func(as, commands string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "cli", Commands: commands}
+	}
+	references := ReferenceByName{
+		"install":  step("install", "install --new"),
+		"teardown": step("teardown", "teardown"),
+	}
+	workflows := WorkflowByName{
+		"ipi": {
+			Pre:  []api.TestStep{{Reference: strPtr("install")}},
+			Post: []api.TestStep{{Reference: strPtr("teardown")}},
+		},
+	}
+	overlays := []Overlay{{
+		Name:     "release-4.14",
+		Branches: []*regexp.Regexp{regexp.MustCompile(`^release-4\.14$`)},
+		Snapshot: Snapshot{References: ReferenceByName{"install": step("install", "install --old")}},
+	}}
+	resolver, err := NewResolverWithOverlays(references, nil, workflows, nil, nil, overlays)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		branch   string
+		expected []api.LiteralTestStep
+	}{
+		{
+			name:     "branch without an overlay",
+			branch:   "main",
+			expected: []api.LiteralTestStep{step("install", "install --new")},
+		},
+		{
+			name:     "branch with an overlay",
+			branch:   "release-4.14",
+			expected: []api.LiteralTestStep{step("install", "install --old")},
+		},
+		{
+			name:     "pattern is anchored",
+			branch:   "release-4.140",
+			expected: []api.LiteralTestStep{step("install", "install --new")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := api.ReleaseBuildConfiguration{
+				Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: tc.branch},
+				Tests: []api.TestStepConfiguration{{
+					As:                          "e2e",
+					MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: strPtr("ipi")},
+				}},
+			}
+			resolved, err := ResolveConfig(resolver, config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, resolved.Tests[0].MultiStageTestConfigurationLiteral.Pre); diff != "" {
+				t.Errorf("unexpected pre steps: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewResolverWithOverlaysValidates(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	overlays := []Overlay{{
+		Name:     "release-4.14",
+		Branches: []*regexp.Regexp{regexp.MustCompile(`^release-4\.14$`)},
+		Snapshot: Snapshot{Chains: ChainByName{"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("missing")}}}}},
+	}}
+	_, err := NewResolverWithOverlays(ReferenceByName{}, ChainByName{}, WorkflowByName{}, ObserverByName{}, nil, overlays)
+	expected := errors.New("invalid registry overlay release-4.14: Chain setup contains non-existent reference missing")
+	if diff := cmp.Diff(expected, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
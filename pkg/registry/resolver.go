@@ -25,7 +25,7 @@ type ObserverByName map[string]api.Observer
 // A superset of this validation is performed later when actual test
 // configurations are resolved.
 func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) error {
-	reg := registry{stepsByName, chainsByName, workflowsByName, observersByName}
+	reg := registry{stepsByName: stepsByName, chainsByName: chainsByName, workflowsByName: workflowsByName, observersByName: observersByName}
 	var ret []error
 	for k := range chainsByName {
 		if _, err := reg.process([]api.TestStep{{Chain: &k}}, sets.New[string](), stackForChain()); err != nil {
@@ -34,7 +34,7 @@ func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 	}
 	for k, v := range workflowsByName {
 		stack := stackForWorkflow(k, v.Environment, v.Dependencies, v.DNSConfig, v.NodeArchitecture)
-		for _, s := range [][]api.TestStep{v.Pre, v.Test, v.Post} {
+		for _, s := range [][]api.TestStep{v.Pre, v.Test, v.Gather, v.Post} {
 			if _, err := reg.process(s, sets.New[string](), stack); err != nil {
 				ret = append(ret, err...)
 			}
@@ -55,6 +55,8 @@ type registry struct {
 	chainsByName    ChainByName
 	workflowsByName WorkflowByName
 	observersByName ObserverByName
+	snapshots       map[string]*registry
+	overlays        []overlay
 }
 
 func NewResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName) Resolver {
@@ -67,6 +69,17 @@ func NewResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflow
 }
 
 func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration) (api.MultiStageTestConfigurationLiteral, error) {
+	if config.Workflow != nil {
+		// a test using a pinned workflow is resolved entirely from its snapshot
+		if workflow, version := SplitVersion(*config.Workflow); version != "" {
+			snapshot, err := r.snapshot(version)
+			if err != nil {
+				return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("workflow %s: %w", *config.Workflow, err)
+			}
+			config.Workflow = &workflow
+			return snapshot.Resolve(name, config)
+		}
+	}
 	var overridden [][]api.TestStep
 	if config.Workflow != nil {
 		var errs []error
@@ -98,6 +111,11 @@ func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]a
 	} else {
 		overridden = append(overridden, workflow.Test)
 	}
+	if config.Gather == nil {
+		config.Gather = workflow.Gather
+	} else {
+		overridden = append(overridden, workflow.Gather)
+	}
 	if config.Post == nil {
 		config.Post = workflow.Post
 	} else {
@@ -109,6 +127,15 @@ func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]a
 	config.DNSConfig = overwriteIfUnset(workflow.DNSConfig, config.DNSConfig)
 	config.Observers = overwriteIfUnset(workflow.Observers, config.Observers)
 	config.NodeArchitecture = overwriteIfUnset(workflow.NodeArchitecture, config.NodeArchitecture)
+	if config.ClientRelease == "" {
+		config.ClientRelease = workflow.ClientRelease
+	}
+	if config.ServerRelease == "" {
+		config.ServerRelease = workflow.ServerRelease
+	}
+	if config.NetworkStack == "" {
+		config.NetworkStack = workflow.NetworkStack
+	}
 
 	if l, err := mergeLeases(workflow.Leases, config.Leases); err != nil {
 		errs = append(errs, err)
@@ -121,6 +148,8 @@ func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]a
 	if config.AllowBestEffortPostSteps == nil {
 		config.AllowBestEffortPostSteps = workflow.AllowBestEffortPostSteps
 	}
+	config.GatherTimeout = overwriteIfUnset(workflow.GatherTimeout, config.GatherTimeout)
+	config.Budgets = overwriteIfUnset(workflow.Budgets, config.Budgets)
 	return overridden, errs
 }
 
@@ -134,8 +163,13 @@ func (r *registry) resolveTest(
 		ClusterProfile:           config.ClusterProfile,
 		AllowSkipOnSuccess:       config.AllowSkipOnSuccess,
 		AllowBestEffortPostSteps: config.AllowBestEffortPostSteps,
+		GatherTimeout:            config.GatherTimeout,
+		Budgets:                  config.Budgets,
 		Leases:                   config.Leases,
 		DependencyOverrides:      config.DependencyOverrides,
+		ClientRelease:            config.ClientRelease,
+		ServerRelease:            config.ServerRelease,
+		NetworkStack:             config.NetworkStack,
 	}
 	if config.Workflow != nil {
 		stack.push(stackRecordForTest("workflow/"+*config.Workflow, nil, nil, nil, nil))
@@ -148,12 +182,16 @@ func (r *registry) resolveTest(
 	expandedFlow.Test = append(expandedFlow.Test, test...)
 	resolveErrors = append(resolveErrors, errs...)
 
+	gather, errs := r.process(config.Gather, sets.New[string](), stack)
+	expandedFlow.Gather = append(expandedFlow.Gather, gather...)
+	resolveErrors = append(resolveErrors, errs...)
+
 	post, errs := r.process(config.Post, sets.New[string](), stack)
 	expandedFlow.Post = append(expandedFlow.Post, post...)
 	resolveErrors = append(resolveErrors, errs...)
 
 	observerNames := sets.New[string]()
-	for _, step := range append(pre, append(test, post...)...) {
+	for _, step := range expandedFlow.Steps() {
 		observerNames = observerNames.Union(sets.New[string](step.Observers...))
 	}
 	if config.Observers != nil {
@@ -166,6 +204,10 @@ func (r *registry) resolveTest(
 
 	resolveErrors = append(resolveErrors, stack.checkUnused(&stack.records[0], overridden, r)...)
 
+	for _, steps := range [][]api.LiteralTestStep{expandedFlow.Pre, expandedFlow.Test, expandedFlow.Gather, expandedFlow.Post} {
+		applyReleaseSkew(steps, config.ClientRelease, config.ServerRelease)
+	}
+
 	if resolveErrors != nil {
 		return api.MultiStageTestConfigurationLiteral{}, utilerrors.NewAggregate(resolveErrors)
 	}
@@ -173,6 +215,13 @@ func (r *registry) resolveTest(
 }
 
 func (r *registry) ResolveWorkflow(name string) (api.MultiStageTestConfigurationLiteral, error) {
+	if name, version := SplitVersion(name); version != "" {
+		snapshot, err := r.snapshot(version)
+		if err != nil {
+			return api.MultiStageTestConfigurationLiteral{}, err
+		}
+		return snapshot.ResolveWorkflow(name)
+	}
 	workflow, ok := r.workflowsByName[name]
 	if !ok {
 		return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("no workflow named %s", name)
@@ -195,6 +244,31 @@ func (r *registry) ResolveChain(name string) (api.RegistryChain, error) {
 	return ret, nil
 }
 
+// applyReleaseSkew injects `oc` from the client release into all steps that use
+// one and points all dependencies on the `latest` release payload to the server
+// release.
+func applyReleaseSkew(steps []api.LiteralTestStep, clientRelease, serverRelease string) {
+	latest := fmt.Sprintf("%s:%s", api.ReleaseImageStream, api.LatestReleaseName)
+	for i := range steps {
+		step := &steps[i]
+		if clientRelease != "" && step.Cli != "" {
+			step.Cli = clientRelease
+		}
+		if serverRelease == "" || len(step.Dependencies) == 0 {
+			continue
+		}
+		// the dependencies are shared with the step in the registry
+		dependencies := make([]api.StepDependency, len(step.Dependencies))
+		copy(dependencies, step.Dependencies)
+		for j := range dependencies {
+			if dependencies[j].Name == latest {
+				dependencies[j].Name = fmt.Sprintf("%s:%s", api.ReleaseImageStream, serverRelease)
+			}
+		}
+		step.Dependencies = dependencies
+	}
+}
+
 // mergeEnvironments joins two environment maps.
 // A copy of `dst` is returned with elements overwritten by those in `src` if
 // they target the same variable.
@@ -280,6 +354,13 @@ func (r *registry) process(steps []api.TestStep, seen sets.Set[string], stack st
 }
 
 func (r *registry) processChain(name string, seen sets.Set[string], stack stack) ([]api.LiteralTestStep, []error) {
+	if name, version := SplitVersion(name); version != "" {
+		snapshot, err := r.snapshot(version)
+		if err != nil {
+			return nil, []error{stack.errorf("step chain %s: %v", name, err)}
+		}
+		return snapshot.processChain(name, seen, stack)
+	}
 	chain, ok := r.chainsByName[name]
 	if !ok {
 		return nil, []error{stack.errorf("unknown step chain: %s", name)}
@@ -294,8 +375,13 @@ func (r *registry) processChain(name string, seen sets.Set[string], stack stack)
 
 func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack stack) (ret api.LiteralTestStep, err []error) {
 	if ref := step.Reference; ref != nil {
+		name, version := SplitVersion(*ref)
+		snapshot, err := r.snapshot(version)
+		if err != nil {
+			return api.LiteralTestStep{}, []error{stack.errorf("step reference %s: %v", name, err)}
+		}
 		var ok bool
-		ret, ok = r.stepsByName[*ref]
+		ret, ok = snapshot.stepsByName[name]
 		if !ok {
 			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s", *ref)}
 		}
@@ -308,6 +394,7 @@ func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack
 		return api.LiteralTestStep{}, []error{stack.errorf("duplicate name: %s", ret.As)}
 	}
 	seen.Insert(ret.As)
+	ret = api.ExpandTypedStep(ret)
 	var errs []error
 	if ret.Leases != nil {
 		ret.Leases = append([]api.StepLease(nil), ret.Leases...)
@@ -370,17 +457,27 @@ func (r *registry) processObservers(observerNames sets.Set[string], stack stack)
 func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) error {
 	switch {
 	case s.Chain != nil:
-		c, ok := r.chainsByName[*s.Chain]
+		name, version := SplitVersion(*s.Chain)
+		snapshot, err := r.snapshot(version)
+		if err != nil {
+			return err
+		}
+		c, ok := snapshot.chainsByName[name]
 		if !ok {
-			return fmt.Errorf("invalid reference: %s", *s.Reference)
+			return fmt.Errorf("invalid reference: %s", *s.Chain)
 		}
 		for _, s := range c.Steps {
-			if err := r.iterateSteps(s, f); err != nil {
+			if err := snapshot.iterateSteps(s, f); err != nil {
 				return err
 			}
 		}
 	case s.Reference != nil:
-		r, ok := r.stepsByName[*s.Reference]
+		name, version := SplitVersion(*s.Reference)
+		snapshot, err := r.snapshot(version)
+		if err != nil {
+			return err
+		}
+		r, ok := snapshot.stepsByName[name]
 		if !ok {
 			return fmt.Errorf("invalid reference: %s", *s.Reference)
 		}
@@ -393,8 +490,19 @@ func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) er
 
 // ResolveConfig uses a resolver to resolve an entire ci-operator config
 func ResolveConfig(resolver Resolver, config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	if r, ok := resolver.(*registry); ok {
+		resolver = r.forBranch(config.Metadata.Branch)
+	}
 	var resolvedTests []api.TestStepConfiguration
 	for _, step := range config.Tests {
+		if literal := step.MultiStageTestConfigurationLiteral; literal != nil {
+			expanded := *literal
+			for _, steps := range []*[]api.LiteralTestStep{&expanded.Pre, &expanded.Test, &expanded.Gather, &expanded.Post} {
+				*steps = expandTypedSteps(*steps)
+				applyReleaseSkew(*steps, expanded.ClientRelease, expanded.ServerRelease)
+			}
+			step.MultiStageTestConfigurationLiteral = &expanded
+		}
 		// no changes if step is not multi-stage
 		if step.MultiStageTestConfiguration == nil {
 			resolvedTests = append(resolvedTests, step)
@@ -418,3 +526,16 @@ func ResolveConfig(resolver Resolver, config api.ReleaseBuildConfiguration) (api
 	config.Tests = resolvedTests
 	return config, nil
 }
+
+// expandTypedSteps converts typed steps of a literal configuration into the
+// literal steps running them.
+func expandTypedSteps(steps []api.LiteralTestStep) []api.LiteralTestStep {
+	if steps == nil {
+		return nil
+	}
+	ret := make([]api.LiteralTestStep, 0, len(steps))
+	for _, step := range steps {
+		ret = append(ret, api.ExpandTypedStep(step))
+	}
+	return ret
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/testhelper"
@@ -780,6 +782,34 @@ func TestResolve(t *testing.T) {
 					},
 				}},
 			},
+		}, {
+			name: "Workflow with gather steps and a gather timeout",
+			config: api.MultiStageTestConfiguration{
+				Workflow:      &awsWorkflow,
+				GatherTimeout: &prowv1.Duration{Duration: 10 * time.Minute},
+			},
+			workflowMap: WorkflowByName{
+				awsWorkflow: {
+					ClusterProfile: api.ClusterProfileAWS,
+					Test: []api.TestStep{{
+						LiteralTestStep: &api.LiteralTestStep{As: "e2e", From: "my-image", Commands: "make custom-e2e"},
+					}},
+					Gather: []api.TestStep{{
+						LiteralTestStep: &api.LiteralTestStep{As: "gather-must-gather", From: "cli", Commands: "oc adm must-gather"},
+					}},
+					Post: []api.TestStep{{
+						LiteralTestStep: &api.LiteralTestStep{As: "ipi-teardown", From: "installer", Commands: "openshift-cluster destroy"},
+					}},
+					GatherTimeout: &prowv1.Duration{Duration: time.Hour},
+				},
+			},
+			expectedRes: api.MultiStageTestConfigurationLiteral{
+				ClusterProfile: api.ClusterProfileAWS,
+				Test:           []api.LiteralTestStep{{As: "e2e", From: "my-image", Commands: "make custom-e2e"}},
+				Gather:         []api.LiteralTestStep{{As: "gather-must-gather", From: "cli", Commands: "oc adm must-gather"}},
+				Post:           []api.LiteralTestStep{{As: "ipi-teardown", From: "installer", Commands: "openshift-cluster destroy"}},
+				GatherTimeout:  &prowv1.Duration{Duration: 10 * time.Minute},
+			},
 		}, {
 			name: "Workflow with invalid parameter",
 			config: api.MultiStageTestConfiguration{
@@ -884,6 +914,65 @@ func TestResolve(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "Network stack from the workflow",
+			config: api.MultiStageTestConfiguration{
+				Workflow: &awsWorkflow,
+			},
+			workflowMap: WorkflowByName{
+				awsWorkflow: {
+					NetworkStack: api.NetworkStackIPv6,
+				},
+			},
+			expectedRes: api.MultiStageTestConfigurationLiteral{
+				NetworkStack: api.NetworkStackIPv6,
+			},
+		},
+		{
+			name: "Config overwrite network stack from workflow",
+			config: api.MultiStageTestConfiguration{
+				Workflow:     &awsWorkflow,
+				NetworkStack: api.NetworkStackDualStack,
+			},
+			workflowMap: WorkflowByName{
+				awsWorkflow: {
+					NetworkStack: api.NetworkStackIPv6,
+				},
+			},
+			expectedRes: api.MultiStageTestConfigurationLiteral{
+				NetworkStack: api.NetworkStackDualStack,
+			},
+		},
+		{
+			name: "Skew test with client and server releases",
+			config: api.MultiStageTestConfiguration{
+				ClientRelease: "initial",
+				ServerRelease: "target",
+				Test: []api.TestStep{{
+					Reference: &reference1,
+				}},
+			},
+			stepMap: ReferenceByName{
+				reference1: {
+					As:           "generic-unit-test",
+					From:         "cli",
+					Commands:     "oc version",
+					Cli:          "latest",
+					Dependencies: []api.StepDependency{{Name: "release:latest", Env: "RELEASE_IMAGE"}, {Name: "pipeline:src", Env: "SRC"}},
+				},
+			},
+			expectedRes: api.MultiStageTestConfigurationLiteral{
+				ClientRelease: "initial",
+				ServerRelease: "target",
+				Test: []api.LiteralTestStep{{
+					As:           "generic-unit-test",
+					From:         "cli",
+					Commands:     "oc version",
+					Cli:          "initial",
+					Dependencies: []api.StepDependency{{Name: "release:target", Env: "RELEASE_IMAGE"}, {Name: "pipeline:src", Env: "SRC"}},
+				}},
+			},
+		},
 		{
 			name: "Full AWS workflow on arm64",
 			config: api.MultiStageTestConfiguration{
@@ -1500,6 +1589,24 @@ func TestResolve(t *testing.T) {
 					NodeArchitecture: &nodeArchitectureARM64,
 				}},
 			},
+		}, {
+			name: "Typed upgrade step is expanded",
+			config: api.MultiStageTestConfiguration{
+				Test: []api.TestStep{{Reference: strPtr("upgrade")}},
+			},
+			stepMap: ReferenceByName{
+				"upgrade": {As: "upgrade", Upgrade: &api.UpgradeStep{Channel: "stable-4.20"}},
+			},
+			expectedRes: api.MultiStageTestConfigurationLiteral{
+				Test: []api.LiteralTestStep{{
+					As:           "upgrade",
+					FromImage:    &api.ImageStreamTagReference{Namespace: "ci", Name: "cluster-upgrade", Tag: "latest"},
+					Commands:     "cluster-upgrade --to=\"${OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE}\" --timeout=2h0m0s --force=never --channel=stable-4.20\n",
+					Dependencies: []api.StepDependency{{Name: "release:latest", Env: "OPENSHIFT_UPGRADE_RELEASE_IMAGE_OVERRIDE"}},
+					Timeout:      &prowv1.Duration{Duration: 2*time.Hour + 10*time.Minute},
+					Resources:    api.ResourceRequirements{Requests: api.ResourceList{"cpu": "10m", "memory": "100Mi"}},
+				}},
+			},
 		}} {
 		t.Run(testCase.name, func(t *testing.T) {
 			err := Validate(testCase.stepMap, testCase.chainMap, testCase.workflowMap, testCase.observerMap)
@@ -1970,3 +2077,37 @@ func TestResolveLeasesCopy(t *testing.T) {
 	expected := []api.StepLease{{Count: 42}, {Count: 0}}
 	testhelper.Diff(t, "leases", leases, expected)
 }
+
+func TestResolveConfigLiteralReleaseSkew(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "skew",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				ClientRelease: "initial",
+				ServerRelease: "target",
+				Test: []api.LiteralTestStep{{
+					As:           "generic-unit-test",
+					From:         "cli",
+					Commands:     "oc version",
+					Cli:          "latest",
+					Dependencies: []api.StepDependency{{Name: "release:latest", Env: "RELEASE_IMAGE"}, {Name: "pipeline:src", Env: "SRC"}},
+				}},
+			},
+		}},
+	}
+	resolved, err := ResolveConfig(NewResolver(nil, nil, nil, nil), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []api.LiteralTestStep{{
+		As:           "generic-unit-test",
+		From:         "cli",
+		Commands:     "oc version",
+		Cli:          "initial",
+		Dependencies: []api.StepDependency{{Name: "release:target", Env: "RELEASE_IMAGE"}, {Name: "pipeline:src", Env: "SRC"}},
+	}}
+	testhelper.Diff(t, "test steps", resolved.Tests[0].MultiStageTestConfigurationLiteral.Test, expected)
+	if cli := config.Tests[0].MultiStageTestConfigurationLiteral.Test[0].Cli; cli != "latest" {
+		t.Errorf("expected the input configuration not to be modified, got cli %q", cli)
+	}
+}
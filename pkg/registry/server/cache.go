@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/utils/lru"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+var (
+	resolutionCacheMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "configresolver_resolution_cache_requests_total",
+			Help: "resolutions of configurations by whether they were served from the cache",
+		},
+		[]string{"result"},
+	)
+	resolutionTimeMetric = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "configresolver_resolution_duration_seconds",
+			Help:    "duration of the resolutions of configurations not served from the cache, in seconds",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 3, 5, 10},
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(resolutionCacheMetric, resolutionTimeMetric)
+}
+
+// ConfigDigest identifies an unresolved configuration.
+func ConfigDigest(config api.ReleaseBuildConfiguration) (string, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return RawConfigDigest(raw), nil
+}
+
+// RawConfigDigest identifies a serialized unresolved configuration.
+func RawConfigDigest(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheKey struct {
+	digest     string
+	generation int
+}
+
+// CachingResolver caches resolved configurations by the digest of the
+// unresolved configuration and the generation of the registry, so that the
+// bursts of identical resolutions caused by presubmits only cost one. Entries
+// of previous generations are never served and age out of the cache.
+type CachingResolver struct {
+	resolver   Resolver
+	generation func() int
+	cache      *lru.Cache
+}
+
+// NewCachingResolver caches up to size resolved configurations.
+func NewCachingResolver(resolver Resolver, generation func() int, size int) *CachingResolver {
+	return &CachingResolver{resolver: resolver, generation: generation, cache: lru.New(size)}
+}
+
+func (c *CachingResolver) ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	digest, err := ConfigDigest(config)
+	if err != nil {
+		return api.ReleaseBuildConfiguration{}, err
+	}
+	// the generation is read before resolving, so a registry reloaded during
+	// the resolution can only make the entry unreachable rather than stale
+	key := cacheKey{digest: digest, generation: c.generation()}
+	if cached, ok := c.cache.Get(key); ok {
+		resolutionCacheMetric.WithLabelValues("hit").Inc()
+		return *cached.(*api.ReleaseBuildConfiguration).DeepCopy(), nil
+	}
+	resolutionCacheMetric.WithLabelValues("miss").Inc()
+	start := time.Now()
+	resolved, err := c.resolver.ResolveConfig(config)
+	if err != nil {
+		return resolved, err
+	}
+	resolutionTimeMetric.Observe(time.Since(start).Seconds())
+	c.cache.Add(key, resolved.DeepCopy())
+	return resolved, nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type countingResolver struct {
+	calls int
+	err   error
+}
+
+func (r *countingResolver) ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	r.calls++
+	if r.err != nil {
+		return api.ReleaseBuildConfiguration{}, r.err
+	}
+	config.Tests = append(config.Tests, api.TestStepConfiguration{As: "resolved"})
+	return config, nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	generation := 1
+	resolver := &countingResolver{}
+	cache := NewCachingResolver(resolver, func() int { return generation }, 10)
+	config := api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}}
+	expected := api.ReleaseBuildConfiguration{
+		Metadata: config.Metadata,
+		Tests:    []api.TestStepConfiguration{{As: "resolved"}},
+	}
+
+	resolve := func(config api.ReleaseBuildConfiguration, expectedCalls int) api.ReleaseBuildConfiguration {
+		t.Helper()
+		resolved, err := cache.ResolveConfig(config)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resolver.calls != expectedCalls {
+			t.Errorf("expected %d resolutions, got %d", expectedCalls, resolver.calls)
+		}
+		return resolved
+	}
+
+	resolved := resolve(config, 1)
+	if diff := cmp.Diff(expected, resolved); diff != "" {
+		t.Errorf("unexpected resolved config: %s", diff)
+	}
+	// callers mutating the result must not corrupt the cache
+	resolved.Tests[0].As = "mutated"
+	if diff := cmp.Diff(expected, resolve(config, 1)); diff != "" {
+		t.Errorf("unexpected cached config: %s", diff)
+	}
+
+	other := config
+	other.Metadata.Branch = "release"
+	resolve(other, 2)
+
+	generation = 2
+	resolve(config, 3)
+	resolve(config, 3)
+
+	resolver.err = errors.New("injected failure")
+	generation = 3
+	for i := 0; i < 2; i++ {
+		_, err := cache.ResolveConfig(config)
+		if diff := cmp.Diff(resolver.err, err, testhelper.EquateErrorMessage); diff != "" {
+			t.Errorf("unexpected error: %s", diff)
+		}
+	}
+	if resolver.calls != 5 {
+		t.Errorf("expected failed resolutions not to be cached, got %d resolutions", resolver.calls)
+	}
+}
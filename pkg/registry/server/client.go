@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/configresolver"
+	"github.com/openshift/ci-tools/pkg/load"
 )
 
 type ResolverClient interface {
@@ -24,14 +27,23 @@ type ResolverClient interface {
 	Resolve([]byte) (*api.ReleaseBuildConfiguration, error)
 	ClusterProfile(profileName string) (*api.ClusterProfileDetails, error)
 	IntegratedStream(namespace, name string) (*configresolver.IntegratedStream, error)
+	Version() (string, error)
 }
 
 func NewResolverClient(address string) ResolverClient {
 	return &resolverClient{Address: address}
 }
 
+// NewCachingResolverClient returns a client storing the configurations it
+// resolves in the directory, keyed by the digest of the unresolved
+// configuration and the digest of the registry of the resolver.
+func NewCachingResolverClient(address, cacheDir string) ResolverClient {
+	return &resolverClient{Address: address, cacheDir: cacheDir}
+}
+
 type resolverClient struct {
-	Address string
+	Address  string
+	cacheDir string
 }
 
 func (r *resolverClient) Config(info *api.Metadata) (*api.ReleaseBuildConfiguration, error) {
@@ -83,6 +95,9 @@ func (r *resolverClient) ConfigWithTest(base *api.Metadata, testSource *api.Meta
 
 func (r *resolverClient) Resolve(raw []byte) (*api.ReleaseBuildConfiguration, error) {
 	// check that the user has sent us something reasonable
+	if err := load.RejectYAMLAliases(raw); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 	unresolvedConfig := &api.ReleaseBuildConfiguration{}
 	if err := yaml.UnmarshalStrict(raw, unresolvedConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal unresolved config: invalid configuration: %w, raw: %v", err, string(raw))
@@ -91,12 +106,62 @@ func (r *resolverClient) Resolve(raw []byte) (*api.ReleaseBuildConfiguration, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal unresolved config: invalid configuration: %w", err)
 	}
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/resolve", r.Address), bytes.NewReader(encoded))
+	resolve := func() ([]byte, error) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/resolve", r.Address), bytes.NewReader(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request for configresolver: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return doRequest(req)
+	}
+	if r.cacheDir == "" {
+		data, err := resolve()
+		if err != nil {
+			return nil, err
+		}
+		return configFromResolverResponse(data)
+	}
+	return r.resolveCached(RawConfigDigest(encoded), resolve)
+}
+
+// resolveCached serves the resolved configuration from the cache directory
+// when the registry did not change since it was stored. Failing to use the
+// cache never fails the resolution.
+func (r *resolverClient) resolveCached(digest string, resolve func() ([]byte, error)) (*api.ReleaseBuildConfiguration, error) {
+	var path string
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/registryDigest", r.Address), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for configresolver: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	return configFromResolverRequest(req)
+	// the generation of the registry is local to each replica of the resolver,
+	// only the digest of its content can key a cache that outlives them
+	if registryDigest, err := doRequest(req); err != nil || len(bytes.TrimSpace(registryDigest)) == 0 {
+		logrus.WithError(err).Warn("Failed to get the registry digest, not using the cache of resolved configurations.")
+	} else {
+		path = filepath.Join(r.cacheDir, fmt.Sprintf("%s-%s.json", digest, strings.TrimSpace(string(registryDigest))))
+		if data, err := os.ReadFile(path); err == nil {
+			if config, err := configFromResolverResponse(data); err == nil {
+				logrus.Debugf("Using the resolved configuration cached in %s.", path)
+				return config, nil
+			}
+		}
+	}
+	data, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+	config, err := configFromResolverResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := os.MkdirAll(r.cacheDir, 0755); err != nil {
+			logrus.WithError(err).Warn("Failed to create the cache of resolved configurations.")
+		} else if err := os.WriteFile(path, data, 0644); err != nil {
+			logrus.WithError(err).Warn("Failed to cache the resolved configuration.")
+		}
+	}
+	return config, nil
 }
 
 type adapter struct{}
@@ -134,8 +199,12 @@ func configFromResolverRequest(req *http.Request) (*api.ReleaseBuildConfiguratio
 	if err != nil {
 		return nil, err
 	}
+	return configFromResolverResponse(data)
+}
+
+func configFromResolverResponse(data []byte) (*api.ReleaseBuildConfiguration, error) {
 	configSpecHTTP := &api.ReleaseBuildConfiguration{}
-	err = json.Unmarshal(data, configSpecHTTP)
+	err := json.Unmarshal(data, configSpecHTTP)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config from configresolver: invalid configuration: %w\nvalue:\n%s", err, string(data))
 	}
@@ -190,6 +259,19 @@ func (r *resolverClient) ClusterProfile(profileName string) (*api.ClusterProfile
 	return cp, nil
 }
 
+// Version gets the version of ci-operator advertised by the config resolver
+func (r *resolverClient) Version() (string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/version", r.Address), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for configresolver: %w", err)
+	}
+	data, err := doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 // IntegratedStream gets the info about an integrated stream by creating a request
 // to config resolver
 func (r *resolverClient) IntegratedStream(namespace, name string) (*configresolver.IntegratedStream, error) {
@@ -158,3 +158,63 @@ func TestClusterProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveCached(t *testing.T) {
+	registryDigest, resolutions := "a", 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/registryDigest":
+			_, _ = w.Write([]byte(registryDigest))
+		case "/resolve":
+			resolutions++
+			_, _ = w.Write([]byte(`{"zz_generated_metadata":{"org":"org","repo":"repo","branch":"main"}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := NewCachingResolverClient(server.URL, t.TempDir())
+	raw := []byte("zz_generated_metadata:\n  org: org\n  repo: repo\n  branch: main\n")
+	expected := &api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main"}}
+
+	for i, step := range []struct {
+		registryDigest      string
+		expectedResolutions int
+	}{
+		{registryDigest: "a", expectedResolutions: 1},
+		{registryDigest: "a", expectedResolutions: 1},
+		{registryDigest: "b", expectedResolutions: 2},
+		// a resolver that cannot digest its registry is never cached
+		{registryDigest: "", expectedResolutions: 3},
+		{registryDigest: "", expectedResolutions: 4},
+	} {
+		registryDigest = step.registryDigest
+		config, err := client.Resolve(raw)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if diff := cmp.Diff(expected, config); diff != "" {
+			t.Errorf("%d: unexpected config: %s", i, diff)
+		}
+		if resolutions != step.expectedResolutions {
+			t.Errorf("%d: expected %d resolutions, got %d", i, step.expectedResolutions, resolutions)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte("v20240110-abc1234\n"))
+	}))
+	defer server.Close()
+	version, err := NewResolverClient(server.URL).Version()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("v20240110-abc1234", version); diff != "" {
+		t.Errorf("unexpected version: %s", diff)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -15,7 +16,9 @@ import (
 	"sigs.k8s.io/prow/pkg/metrics"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/configquery"
 	"github.com/openshift/ci-tools/pkg/load/agents"
+	"github.com/openshift/ci-tools/pkg/validation"
 )
 
 const (
@@ -36,6 +39,16 @@ const (
 	NameQuery = "name"
 )
 
+// KindQuery is used for selecting the kind of item to fetch usages for
+const (
+	KindQuery = "kind"
+)
+
+// TestQuery is used for selecting the test to fetch timeouts for
+const (
+	TestQuery = "test"
+)
+
 type Resolver interface {
 	ResolveConfig(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error)
 }
@@ -99,6 +112,10 @@ func resolveAndRespond(resolver Resolver, config api.ReleaseBuildConfiguration,
 		logger.WithError(err).Errorf("failed to marshal config to JSON")
 		return
 	}
+	// surface non-fatal findings as HTTP warnings, leaving the body unchanged
+	for _, warning := range validation.ConfigurationWarnings(&config) {
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(jsonConfig); err != nil {
 		logrus.WithError(err).Error("Failed to write response")
@@ -542,3 +559,117 @@ func ProfileNameFromQuery(w http.ResponseWriter, r *http.Request) (string, error
 	}
 	return profileName, nil
 }
+
+// UsageQuerier answers which configurations consume an item
+type UsageQuerier interface {
+	Query(kind configquery.Kind, item string) []configquery.Usage
+}
+
+// UsagesResponse is the payload returned when querying for usages of an item
+type UsagesResponse struct {
+	Kind   configquery.Kind    `json:"kind"`
+	Name   string              `json:"name"`
+	Usages []configquery.Usage `json:"usages"`
+}
+
+// ResolveUsages serves the configurations and tests that consume a base image,
+// workflow or cluster profile, taking their resolved form into account
+func ResolveUsages(querier UsageQuerier, resolverMetrics *metrics.Metrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusNotImplemented)))
+			return
+		}
+		kind := configquery.Kind(r.URL.Query().Get(KindQuery))
+		if !sets.New(configquery.Kinds...).Has(kind) {
+			metrics.RecordError("invalid usages query", resolverMetrics.ErrorRate)
+			MissingQuery(w, KindQuery)
+			return
+		}
+		name := r.URL.Query().Get(NameQuery)
+		if name == "" {
+			metrics.RecordError("invalid usages query", resolverMetrics.ErrorRate)
+			MissingQuery(w, NameQuery)
+			return
+		}
+		usages := querier.Query(kind, name)
+		if usages == nil {
+			usages = []configquery.Usage{}
+		}
+		jsonContent, err := json.MarshalIndent(UsagesResponse{Kind: kind, Name: name, Usages: usages}, "", "  ")
+		if err != nil {
+			metrics.RecordError("failed to marshal usages to JSON", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to marshal usages of %s %s to JSON: %v", kind, name, err)
+			logrus.WithError(err).Errorf("failed to marshal usages of %s %s to JSON", kind, name)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(jsonContent); err != nil {
+			logrus.WithError(err).Errorf("Failed to write response: %v", err)
+		}
+	}
+}
+
+// ResolveTimeouts serves the timeout chains of the tests of a resolved
+// configuration, so users can see which timeout ends their jobs. The default
+// job timeout of the repository is read from the configuration of Prow. The
+// `test` query restricts the response to one test.
+func ResolveTimeouts(configs Getter, resolver Resolver, defaultJobTimeout func(metadata api.Metadata) time.Duration, resolverMetrics *metrics.Metrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metadata, err := MetadataFromQuery(w, r)
+		if err != nil {
+			// MetadataFromQuery deals with setting status code and writing response
+			// so we need to just log the error here
+			metrics.RecordError("invalid query", resolverMetrics.ErrorRate)
+			logrus.WithError(err).Warning("failed to read query from request")
+			return
+		}
+		logger := logrus.WithFields(api.LogFieldsFor(metadata))
+
+		config, err := configs.GetMatchingConfig(metadata)
+		if err != nil {
+			metrics.RecordError("config not found", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "failed to get config: %v", err)
+			logger.WithError(err).Warning("failed to get config")
+			return
+		}
+		config, err = resolver.ResolveConfig(config)
+		if err != nil {
+			metrics.RecordError("failed to resolve config with registry", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "failed to resolve config with registry: %v", err)
+			logger.WithError(err).Warning("failed to resolve config with registry")
+			return
+		}
+		testName := r.URL.Query().Get(TestQuery)
+		chains := map[string]api.TimeoutChain{}
+		for _, test := range config.Tests {
+			if testName == "" || test.As == testName {
+				chains[test.As] = test.TimeoutChain(defaultJobTimeout(metadata))
+			}
+		}
+		if testName != "" && len(chains) == 0 {
+			metrics.RecordError("test not found", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "test %s not found in the config", testName)
+			return
+		}
+		jsonContent, err := json.MarshalIndent(chains, "", "  ")
+		if err != nil {
+			metrics.RecordError("failed to marshal timeouts to JSON", resolverMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to marshal timeouts to JSON: %v", err)
+			logger.WithError(err).Errorf("failed to marshal timeouts to JSON")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(jsonContent); err != nil {
+			logrus.WithError(err).Errorf("Failed to write response: %v", err)
+		}
+	}
+}
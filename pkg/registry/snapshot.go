@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionSeparator separates the name of a registry component from the
+// version of the snapshot it is pinned to, e.g. `ipi-aws@v2025-01-15`.
+const VersionSeparator = "@"
+
+// Snapshot is a stored version of the registry which configurations can pin
+// their workflows, chains and references to.
+type Snapshot struct {
+	References ReferenceByName
+	Chains     ChainByName
+	Workflows  WorkflowByName
+	Observers  ObserverByName
+}
+
+// SplitVersion splits a registry component name into the name and the version
+// of the snapshot it is pinned to, which is empty if it is not pinned.
+func SplitVersion(name string) (string, string) {
+	if i := strings.LastIndex(name, VersionSeparator); i != -1 {
+		return name[:i], name[i+len(VersionSeparator):]
+	}
+	return name, ""
+}
+
+// NewResolverWithSnapshots creates a resolver that resolves pinned components
+// from the given registry snapshots, keyed by their version.
+func NewResolverWithSnapshots(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, snapshots map[string]Snapshot) Resolver {
+	versions := make(map[string]*registry, len(snapshots))
+	for version, s := range snapshots {
+		versions[version] = &registry{
+			stepsByName:     s.References,
+			chainsByName:    s.Chains,
+			workflowsByName: s.Workflows,
+			observersByName: s.Observers,
+			snapshots:       versions,
+		}
+	}
+	return &registry{
+		stepsByName:     stepsByName,
+		chainsByName:    chainsByName,
+		workflowsByName: workflowsByName,
+		observersByName: observersByName,
+		snapshots:       versions,
+	}
+}
+
+// snapshot returns the registry for a version, the current one if the
+// version is empty.
+func (r *registry) snapshot(version string) (*registry, error) {
+	if version == "" {
+		return r, nil
+	}
+	s, ok := r.snapshots[version]
+	if !ok {
+		return nil, fmt.Errorf("no registry snapshot for version %s", version)
+	}
+	return s, nil
+}
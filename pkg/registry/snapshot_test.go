@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSplitVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name, expectedName, expectedVersion string
+	}{
+		{name: "ipi-aws", expectedName: "ipi-aws"},
+		{name: "ipi-aws@v2025-01-15", expectedName: "ipi-aws", expectedVersion: "v2025-01-15"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			name, version := SplitVersion(tc.name)
+			if name != tc.expectedName || version != tc.expectedVersion {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tc.expectedName, tc.expectedVersion, name, version)
+			}
+		})
+	}
+}
+
+func TestResolvePinned(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	step := func(as, commands string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "cli", Commands: commands}
+	}
+	current := Snapshot{
+		References: ReferenceByName{
+			"install":  step("install", "install --new"),
+			"teardown": step("teardown", "teardown --new"),
+		},
+		Chains: ChainByName{
+			"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}}},
+		},
+		Workflows: WorkflowByName{
+			"ipi": {
+				Pre:  []api.TestStep{{Chain: strPtr("setup")}},
+				Post: []api.TestStep{{Reference: strPtr("teardown")}},
+			},
+		},
+	}
+	snapshots := map[string]Snapshot{
+		"v2025-01-15": {
+			References: ReferenceByName{
+				"install":  step("install", "install --old"),
+				"teardown": step("teardown", "teardown --old"),
+			},
+			Chains: ChainByName{
+				"setup": {As: "setup", Steps: []api.TestStep{{Reference: strPtr("install")}}},
+			},
+			Workflows: WorkflowByName{
+				"ipi": {
+					Pre:  []api.TestStep{{Chain: strPtr("setup")}},
+					Post: []api.TestStep{{Reference: strPtr("teardown")}},
+				},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name          string
+		config        api.MultiStageTestConfiguration
+		expected      api.MultiStageTestConfigurationLiteral
+		expectedError error
+	}{
+		{
+			name:   "workflow from the current registry",
+			config: api.MultiStageTestConfiguration{Workflow: strPtr("ipi")},
+			expected: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install", "install --new")},
+				Post: []api.LiteralTestStep{step("teardown", "teardown --new")},
+			},
+		},
+		{
+			name:   "pinned workflow is resolved from the snapshot",
+			config: api.MultiStageTestConfiguration{Workflow: strPtr("ipi@v2025-01-15")},
+			expected: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install", "install --old")},
+				Post: []api.LiteralTestStep{step("teardown", "teardown --old")},
+			},
+		},
+		{
+			name: "pinned chain and reference are resolved from the snapshot",
+			config: api.MultiStageTestConfiguration{
+				Pre:  []api.TestStep{{Chain: strPtr("setup@v2025-01-15")}},
+				Post: []api.TestStep{{Reference: strPtr("teardown")}, {Reference: strPtr("install@v2025-01-15")}},
+			},
+			expected: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install", "install --old")},
+				Post: []api.LiteralTestStep{step("teardown", "teardown --new"), step("install", "install --old")},
+			},
+		},
+		{
+			name:          "unknown workflow snapshot",
+			config:        api.MultiStageTestConfiguration{Workflow: strPtr("ipi@v2024-01-01")},
+			expectedError: errors.New("workflow ipi@v2024-01-01: no registry snapshot for version v2024-01-01"),
+		},
+		{
+			name:          "unknown reference snapshot",
+			config:        api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: strPtr("install@v2024-01-01")}}},
+			expectedError: errors.New("test/test: step reference install: no registry snapshot for version v2024-01-01"),
+		},
+		{
+			name:          "reference missing from the snapshot",
+			config:        api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: strPtr("e2e@v2025-01-15")}}},
+			expectedError: errors.New("test/test: invalid step reference: e2e@v2025-01-15"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := NewResolverWithSnapshots(current.References, current.Chains, current.Workflows, current.Observers, snapshots)
+			ret, err := resolver.Resolve("test", tc.config)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, ret); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
@@ -649,7 +649,8 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 				}
 				continue
 			}
-			testSteps := append(test.MultiStageTestConfiguration.Pre, append(test.MultiStageTestConfiguration.Test, test.MultiStageTestConfiguration.Post...)...)
+			ms := test.MultiStageTestConfiguration
+			testSteps := append(ms.Pre, append(ms.Test, append(ms.Gather, ms.Post...)...)...)
 			for _, testStep := range testSteps {
 				hasRef := testStep.Reference != nil && node.Type() == registry.Reference && node.Name() == *testStep.Reference
 				hasChain := testStep.Chain != nil && node.Type() == registry.Chain && node.Name() == *testStep.Chain
@@ -3,6 +3,7 @@ package rehearse
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -217,18 +218,23 @@ func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath s
 }
 
 func (r RehearsalConfig) createResolver(candidatePath string) (registry.Resolver, error) {
-	var registryRefs registry.ReferenceByName
-	var chains registry.ChainByName
-	var workflows registry.WorkflowByName
-	var observers registry.ObserverByName
-	if !r.NoRegistry {
-		var err error
-		registryRefs, chains, workflows, _, _, _, observers, err = load.Registry(filepath.Join(candidatePath, config.RegistryPath), load.RegistryFlag(0))
-		if err != nil {
-			return nil, fmt.Errorf("could not load step registry: %w", err)
-		}
+	if r.NoRegistry {
+		return registry.NewResolver(nil, nil, nil, nil), nil
+	}
+	// pinned versions are resolved from the snapshots stored in the release repository
+	snapshotsPath := filepath.Join(candidatePath, config.RegistrySnapshotsPath)
+	if _, err := os.Stat(snapshotsPath); err != nil {
+		snapshotsPath = ""
+	}
+	// so are the overlays applied to release branches
+	overlaysPath := filepath.Join(candidatePath, config.RegistryOverlaysPath)
+	if _, err := os.Stat(overlaysPath); err != nil {
+		overlaysPath = ""
+	}
+	resolver, err := load.RegistryResolver(filepath.Join(candidatePath, config.RegistryPath), snapshotsPath, overlaysPath, load.RegistryFlag(0))
+	if err != nil {
+		return nil, fmt.Errorf("could not load step registry: %w", err)
 	}
-	resolver := registry.NewResolver(registryRefs, chains, workflows, observers)
 	return resolver, nil
 }
 
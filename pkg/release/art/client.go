@@ -0,0 +1,87 @@
+package art
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/release"
+)
+
+// ResolveImageMetadata queries the ART image metadata endpoint for the given
+// component in the given OCP version
+func ResolveImageMetadata(client release.HTTPClient, endpoint, component, version string) (ImageMetadata, error) {
+	ret := ImageMetadata{}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return ret, err
+	}
+	req.Header.Set("Accept", "application/json")
+	q := req.URL.Query()
+	q.Add("name", component)
+	q.Add("version", version)
+	req.URL.RawQuery = q.Encode()
+	logrus.Debugf("Requesting ART image metadata from %s", req.URL.String())
+	resp, err := client.Do(req)
+	if err != nil {
+		return ret, fmt.Errorf("failed to request ART image metadata for %s: %w", component, err)
+	}
+	if resp == nil {
+		return ret, errors.New("failed to request ART image metadata: got a nil response")
+	}
+	defer resp.Body.Close()
+	data, readErr := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return ret, fmt.Errorf("failed to request ART image metadata for %s: server responded with %d: %s", component, resp.StatusCode, data)
+	}
+	if readErr != nil {
+		return ret, fmt.Errorf("failed to read response body: %w", readErr)
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return ret, fmt.Errorf("failed to unmarshal ART image metadata: %w (%s)", err, data)
+	}
+	return ret, nil
+}
+
+// CheckConsistency verifies that the build root used by CI for the promoted
+// images matches the one ART builds their components with. The components are
+// keyed by the image they are promoted from; images may share a component, in
+// which case its metadata is only requested once.
+func CheckConsistency(client release.HTTPClient, endpoint string, buildRoot api.ImageStreamTagReference, components map[string][]Component) error {
+	goVersion := api.GoVersionFor(buildRoot.Tag)
+	type result struct {
+		metadata ImageMetadata
+		err      error
+	}
+	resolved := map[Component]result{}
+	var errs []error
+	for _, image := range sets.List(sets.KeySet(components)) {
+		for _, component := range components[image] {
+			r, ok := resolved[component]
+			if !ok {
+				r.metadata, r.err = ResolveImageMetadata(client, endpoint, component.Name, component.Version)
+				resolved[component] = r
+			}
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", image, r.err))
+				continue
+			}
+			metadata := r.metadata
+			if metadata.BuildRoot != "" && metadata.BuildRoot != buildRoot.ISTagName() {
+				errs = append(errs, fmt.Errorf("%s: build root %s does not match the one used by ART for %s: %s", image, buildRoot.ISTagName(), component.Name, metadata.BuildRoot))
+			}
+			if metadata.GoVersion != "" && goVersion != "" && metadata.GoVersion != goVersion {
+				errs = append(errs, fmt.Errorf("%s: Go version %s does not match the one used by ART for %s: %s", image, goVersion, component.Name, metadata.GoVersion))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
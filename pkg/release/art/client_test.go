@@ -0,0 +1,117 @@
+package art
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	metadata := map[string]ImageMetadata{
+		"consistent":    {Name: "consistent", BuildRoot: "ocp/builder:rhel-9-golang-1.22-openshift-4.17", GoVersion: "1.22"},
+		"go-only":       {Name: "go-only", GoVersion: "1.22"},
+		"different-go":  {Name: "different-go", GoVersion: "1.21"},
+		"different-all": {Name: "different-all", BuildRoot: "ocp/builder:rhel-8-golang-1.21-openshift-4.17", GoVersion: "1.21"},
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if version := r.URL.Query().Get("version"); version != "4.17" {
+			http.Error(w, "unexpected version "+version, http.StatusBadRequest)
+			return
+		}
+		m, ok := metadata[r.URL.Query().Get("name")]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(m); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	buildRoot := api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.22-openshift-4.17"}
+
+	for _, tc := range []struct {
+		name             string
+		components       map[string][]Component
+		expectedErr      string
+		expectedRequests int
+	}{
+		{
+			name: "consistent components",
+			components: map[string][]Component{
+				"consistent": {{Name: "consistent", Version: "4.17"}},
+				"go-only":    {{Name: "go-only", Version: "4.17"}},
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "mismatched Go version",
+			components: map[string][]Component{
+				"consistent": {{Name: "consistent", Version: "4.17"}},
+				"other":      {{Name: "different-go", Version: "4.17"}},
+			},
+			expectedErr:      "other: Go version 1.22 does not match the one used by ART for different-go: 1.21",
+			expectedRequests: 2,
+		},
+		{
+			name:             "mismatched build root and Go version",
+			components:       map[string][]Component{"different-all": {{Name: "different-all", Version: "4.17"}}},
+			expectedErr:      "[different-all: build root ocp/builder:rhel-9-golang-1.22-openshift-4.17 does not match the one used by ART for different-all: ocp/builder:rhel-8-golang-1.21-openshift-4.17, different-all: Go version 1.22 does not match the one used by ART for different-all: 1.21]",
+			expectedRequests: 1,
+		},
+		{
+			name: "images sharing a component",
+			components: map[string][]Component{
+				"first":  {{Name: "different-go", Version: "4.17"}},
+				"second": {{Name: "different-go", Version: "4.17"}},
+			},
+			expectedErr:      "[first: Go version 1.22 does not match the one used by ART for different-go: 1.21, second: Go version 1.22 does not match the one used by ART for different-go: 1.21]",
+			expectedRequests: 1,
+		},
+		{
+			name:             "unknown component",
+			components:       map[string][]Component{"unknown": {{Name: "unknown", Version: "4.17"}}},
+			expectedErr:      "unknown: failed to request ART image metadata for unknown: server responded with 404: not found\n",
+			expectedRequests: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests = 0
+			err := CheckConsistency(server.Client(), server.URL, buildRoot, tc.components)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if requests != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestResolveImageMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`{"name":"component","build_root":"ocp/builder:tag","go_version":"1.22"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	actual, err := ResolveImageMetadata(server.Client(), server.URL, "component", "4.17")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := ImageMetadata{Name: "component", BuildRoot: "ocp/builder:tag", GoVersion: "1.22"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected metadata: %s", diff)
+	}
+}
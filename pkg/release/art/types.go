@@ -0,0 +1,20 @@
+package art
+
+// ImageMetadata is what the ART image metadata endpoint sends us when
+// querying for the build information of a component
+type ImageMetadata struct {
+	// Name is the name of the component, matching the promoted tag
+	Name string `json:"name"`
+	// BuildRoot is the builder image ART uses for the component,
+	// as a namespace/name:tag image stream tag reference
+	BuildRoot string `json:"build_root,omitempty"`
+	// GoVersion is the major.minor version of Go the component is built with
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+// Component identifies what ART builds: the tag an image is promoted to in the
+// stream of an OCP version
+type Component struct {
+	Name    string
+	Version string
+}
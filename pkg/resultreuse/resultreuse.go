@@ -0,0 +1,363 @@
+// Package resultreuse lets ci-operator report the result of an earlier run of
+// a presubmit target instead of running it again, when a new push to the pull
+// request does not change any of the inputs of the target: the files of the
+// repositories it depends on, the configuration including the steps of the
+// registry, and the input images. Only successful results are reused, failures
+// may have been flakes and always run again.
+package resultreuse
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	// OverrideLabel on a pull request makes its targets run even when an
+	// earlier result could be reused.
+	OverrideLabel = "ci/no-result-reuse"
+	// ArtifactFilename is the name of the artifact recording the reused
+	// result.
+	ArtifactFilename = "ci-operator-reused-result.json"
+	// recordLabel marks the ConfigMaps recording results.
+	recordLabel = "ci.openshift.io/result-reuse"
+	// recordKey is the key of the ConfigMaps holding the serialized record.
+	recordKey = "record.json"
+	// recordMaxAge is how long a result is reused. Inputs not tracked by the
+	// key, e.g. external services tests talk to, change over time. Older
+	// records are pruned.
+	recordMaxAge = 7 * 24 * time.Hour
+)
+
+// Inputs are what the result of a target depends on.
+type Inputs struct {
+	// Job is the name of the job running the target.
+	Job string `json:"job"`
+	// Target is the name of the target.
+	Target string `json:"target"`
+	// Org, Repo and Pull identify the pull request; results are only reused
+	// between pushes to the same pull request.
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	Pull int    `json:"pull"`
+	// Config is the resolved configuration, which includes the steps
+	// referenced from the registry.
+	Config string `json:"config"`
+	// Images are the inputs of the steps other than the source code, e.g. the
+	// digests of the input images.
+	Images []string `json:"images"`
+	// Sources are the blobs of the files the target depends on in each of
+	// the revisions which are tested, including the ones of the extra refs,
+	// by revision and path.
+	Sources map[string]map[string]string `json:"sources"`
+}
+
+// Key hashes the inputs.
+func (i Inputs) Key() (string, error) {
+	i.Images = append([]string{}, i.Images...)
+	sort.Strings(i.Images)
+	raw, err := json.Marshal(i)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize the inputs: %w", err)
+	}
+	hash := sha256.Sum256(raw)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// GitHubClient is the subset of the GitHub client needed to determine the
+// inputs of a target on a pull request.
+type GitHubClient interface {
+	GetRef(org, repo, ref string) (string, error)
+	GetSingleCommit(org, repo, SHA string) (github.RepositoryCommit, error)
+	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
+	// GetTree returns the blob SHA of each file in the revision, by path.
+	GetTree(org, repo, sha string) (map[string]string, error)
+}
+
+// gitHubClient adds listing the files of a revision to the prow client, which
+// does not implement the trees API.
+type gitHubClient struct {
+	github.Client
+	client   *http.Client
+	endpoint string
+	token    func() []byte
+}
+
+// NewGitHubClient wraps the prow client, listing the files of revisions with
+// the same token from the REST API at the endpoint.
+func NewGitHubClient(client github.Client, endpoint string, token func() []byte) GitHubClient {
+	return &gitHubClient{Client: client, client: &http.Client{Timeout: time.Minute}, endpoint: endpoint, token: token}
+}
+
+// GetTree lists the files of the revision in a single request, however many
+// directories the repository has.
+func (c *gitHubClient) GetTree(org, repo, sha string) (map[string]string, error) {
+	path := fmt.Sprintf("/repos/%s/%s/git/trees/%s?recursive=1", org, repo, sha)
+	req, err := http.NewRequest(http.MethodGet, c.endpoint+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %w", path, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+string(c.token()))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("could not get %s: status %d: %s", path, resp.StatusCode, string(body))
+	}
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+			SHA  string `json:"sha"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("could not parse the response for %s: %w", path, err)
+	}
+	if tree.Truncated {
+		return nil, fmt.Errorf("the tree of %s/%s@%s is too large to be listed", org, repo, sha)
+	}
+	ret := map[string]string{}
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" {
+			ret[entry.Path] = entry.SHA
+		}
+	}
+	return ret, nil
+}
+
+// Sources determines the files the target depends on in the revisions which
+// are tested: the base and the head of each pull request of the refs, and the
+// revisions of the extra refs. The files of the refs are matched with the
+// source paths, all files are matched when no paths are given; all files of
+// the extra refs are matched.
+func Sources(client GitHubClient, refs prowv1.Refs, extraRefs []prowv1.Refs, sourcePaths []string) (map[string]map[string]string, error) {
+	var patterns []*regexp.Regexp
+	for _, path := range sourcePaths {
+		pattern, err := regexp.Compile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source path %q: %w", path, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	ret := map[string]map[string]string{}
+	// the revisions are keyed by their role, as the key would otherwise
+	// change with every push even when no matched file changes
+	if err := revisionSources(client, refs, patterns, "", ret); err != nil {
+		return nil, err
+	}
+	for _, extra := range extraRefs {
+		if err := revisionSources(client, extra, nil, fmt.Sprintf("%s/%s@", extra.Org, extra.Repo), ret); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// revisionSources records the files matching the patterns in the base and
+// pull request revisions of the refs, keyed by the prefixed role of the
+// revision. The base is resolved from its branch when the refs do not pin it.
+func revisionSources(client GitHubClient, refs prowv1.Refs, patterns []*regexp.Regexp, prefix string, into map[string]map[string]string) error {
+	base := refs.BaseSHA
+	if base == "" {
+		if refs.BaseRef == "" {
+			return fmt.Errorf("the revision of %s/%s is not known", refs.Org, refs.Repo)
+		}
+		var err error
+		if base, err = client.GetRef(refs.Org, refs.Repo, "heads/"+refs.BaseRef); err != nil {
+			return fmt.Errorf("could not resolve the %s branch of %s/%s: %w", refs.BaseRef, refs.Org, refs.Repo, err)
+		}
+	}
+	files, err := filesAt(client, refs.Org, refs.Repo, base, patterns)
+	if err != nil {
+		return err
+	}
+	into[prefix+"base"] = files
+	for _, pull := range refs.Pulls {
+		if pull.SHA == "" {
+			return fmt.Errorf("the revision of %s/%s#%d is not known", refs.Org, refs.Repo, pull.Number)
+		}
+		files, err := filesAt(client, refs.Org, refs.Repo, pull.SHA, patterns)
+		if err != nil {
+			return err
+		}
+		into[fmt.Sprintf("%spull-%d", prefix, pull.Number)] = files
+	}
+	return nil
+}
+
+// filesAt returns the blob SHA of each file matching the patterns in the
+// revision, by path. Without patterns every file matches, which the tree of
+// the revision identifies without listing it.
+func filesAt(client GitHubClient, org, repo, sha string, patterns []*regexp.Regexp) (map[string]string, error) {
+	if len(patterns) == 0 {
+		commit, err := client.GetSingleCommit(org, repo, sha)
+		if err != nil {
+			return nil, fmt.Errorf("could not get %s/%s@%s: %w", org, repo, sha, err)
+		}
+		return map[string]string{"": commit.Commit.Tree.SHA}, nil
+	}
+	tree, err := client.GetTree(org, repo, sha)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the files of %s/%s@%s: %w", org, repo, sha, err)
+	}
+	ret := map[string]string{}
+	for path, blob := range tree {
+		for _, pattern := range patterns {
+			if pattern.MatchString(path) {
+				ret[path] = blob
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Overridden determines whether the pull request asks for its targets to run
+// again.
+func Overridden(client GitHubClient, org, repo string, number int) (bool, error) {
+	labels, err := client.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return false, err
+	}
+	for _, label := range labels {
+		if label.Name == OverrideLabel {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Record is the successful result of a target.
+type Record struct {
+	// Key identifies the inputs the target ran with.
+	Key string `json:"key"`
+	// Target is the name of the target.
+	Target string `json:"target"`
+	// Job and BuildID identify the run which produced the result.
+	Job     string `json:"job"`
+	BuildID string `json:"build_id"`
+	// SHA is the head of the pull request the target ran on.
+	SHA string `json:"sha"`
+	// Finished is when the run finished.
+	Finished time.Time `json:"finished"`
+}
+
+// Store records the results of targets in ConfigMaps of a namespace shared
+// by all jobs, in which the jobs must be allowed to manage ConfigMaps.
+type Store struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	now       func() time.Time
+}
+
+// NewStore creates a store recording results in the namespace.
+func NewStore(client ctrlruntimeclient.Client, namespace string) *Store {
+	return &Store{client: client, namespace: namespace, now: time.Now}
+}
+
+// recordName is the name of the ConfigMap recording the result for the key.
+func recordName(key string) string {
+	return "result-" + key[:32]
+}
+
+// Get returns the result recorded for the key, nil if there is none or it is
+// too old to be reused.
+func (s *Store) Get(ctx context.Context, key string) (*Record, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.namespace, Name: recordName(key)}, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get the recorded result: %w", err)
+	}
+	record := &Record{}
+	if err := json.Unmarshal([]byte(cm.Data[recordKey]), record); err != nil {
+		return nil, fmt.Errorf("could not parse the recorded result %s/%s: %w", s.namespace, cm.Name, err)
+	}
+	if record.Key != key || s.now().Sub(record.Finished) > recordMaxAge {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// Put records the result, replacing any earlier one for the same key, and
+// prunes the records too old to be reused.
+func (s *Store) Put(ctx context.Context, record Record) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not serialize the result: %w", err)
+	}
+	cm := &coreapi.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      recordName(record.Key),
+			Labels:    map[string]string{recordLabel: "true"},
+		},
+		Data: map[string]string{recordKey: string(raw)},
+	}
+	if err := s.client.Create(ctx, cm); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not record the result: %w", err)
+		}
+		existing := &coreapi.ConfigMap{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.namespace, Name: cm.Name}, existing); err != nil {
+			return fmt.Errorf("could not get the recorded result: %w", err)
+		}
+		existing.Labels, existing.Data = cm.Labels, cm.Data
+		if err := s.client.Update(ctx, existing); err != nil {
+			return fmt.Errorf("could not record the result: %w", err)
+		}
+	}
+	if err := s.prune(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to prune the recorded results.")
+	}
+	return nil
+}
+
+// prune deletes the records older than recordMaxAge. Records which cannot be
+// parsed are aged by the time they were created.
+func (s *Store) prune(ctx context.Context) error {
+	cms := &coreapi.ConfigMapList{}
+	if err := s.client.List(ctx, cms, ctrlruntimeclient.InNamespace(s.namespace), ctrlruntimeclient.MatchingLabels{recordLabel: "true"}); err != nil {
+		return fmt.Errorf("could not list the recorded results: %w", err)
+	}
+	var errs []error
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		finished := cm.CreationTimestamp.Time
+		record := &Record{}
+		if err := json.Unmarshal([]byte(cm.Data[recordKey]), record); err == nil {
+			finished = record.Finished
+		}
+		if s.now().Sub(finished) <= recordMaxAge {
+			continue
+		}
+		if err := s.client.Delete(ctx, cm); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete the recorded result %s/%s: %w", s.namespace, cm.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,319 @@
+package resultreuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+type fakeGitHub struct {
+	// trees are the blobs of the files of each revision, by path
+	trees  map[string]map[string]string
+	refs   map[string]string
+	labels []string
+	// listed is how many trees were listed
+	listed int
+}
+
+func (f *fakeGitHub) GetRef(org, repo, ref string) (string, error) {
+	sha, ok := f.refs[fmt.Sprintf("%s/%s/%s", org, repo, ref)]
+	if !ok {
+		return "", fmt.Errorf("no ref %s in %s/%s", ref, org, repo)
+	}
+	return sha, nil
+}
+
+func (f *fakeGitHub) GetSingleCommit(org, repo, sha string) (github.RepositoryCommit, error) {
+	tree, ok := f.trees[sha]
+	if !ok {
+		return github.RepositoryCommit{}, fmt.Errorf("no commit %s/%s@%s", org, repo, sha)
+	}
+	// identical trees have the same SHA
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return github.RepositoryCommit{}, err
+	}
+	return github.RepositoryCommit{SHA: sha, Commit: github.GitCommit{Tree: github.Tree{SHA: string(raw)}}}, nil
+}
+
+func (f *fakeGitHub) GetTree(org, repo, sha string) (map[string]string, error) {
+	tree, ok := f.trees[sha]
+	if !ok {
+		return nil, fmt.Errorf("no tree for %s/%s@%s", org, repo, sha)
+	}
+	f.listed++
+	return tree, nil
+}
+
+func (f *fakeGitHub) GetIssueLabels(string, string, int) ([]github.Label, error) {
+	var ret []github.Label
+	for _, label := range f.labels {
+		ret = append(ret, github.Label{Name: label})
+	}
+	return ret, nil
+}
+
+func TestKeyAcrossPushes(t *testing.T) {
+	client := &fakeGitHub{
+		trees: map[string]map[string]string{
+			"base":          {"pkg/a.go": "1", "docs/README.md": "1"},
+			"base-moved":    {"pkg/a.go": "1", "docs/README.md": "2"},
+			"push-1":        {"pkg/a.go": "2", "docs/README.md": "1"},
+			"push-2-docs":   {"pkg/a.go": "2", "docs/README.md": "3"},
+			"push-3-source": {"pkg/a.go": "3", "docs/README.md": "3"},
+			"extra":         {"main.go": "1"},
+			"extra-moved":   {"main.go": "2"},
+		},
+		refs: map[string]string{"org/other/heads/main": "extra"},
+	}
+	key := func(base, head string, extra prowv1.Refs) string {
+		refs := prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: base, Pulls: []prowv1.Pull{{Number: 1, SHA: head}}}
+		sources, err := Sources(client, refs, []prowv1.Refs{extra}, []string{"^pkg/"})
+		if err != nil {
+			t.Fatalf("failed to determine the sources: %v", err)
+		}
+		inputs := Inputs{Job: "job", Target: "unit", Org: "org", Repo: "repo", Pull: 1, Config: "config", Images: []string{"b", "a"}, Sources: sources}
+		ret, err := inputs.Key()
+		if err != nil {
+			t.Fatalf("failed to hash the inputs: %v", err)
+		}
+		return ret
+	}
+	extra := prowv1.Refs{Org: "org", Repo: "other", BaseRef: "main"}
+	reference := key("base", "push-1", extra)
+	for _, tc := range []struct {
+		name       string
+		base, head string
+		extra      prowv1.Refs
+		expected   bool
+	}{
+		{name: "push only changing other files", base: "base", head: "push-2-docs", extra: extra, expected: true},
+		{name: "base moved without changing source files", base: "base-moved", head: "push-1", extra: extra, expected: true},
+		{name: "push changing source files", base: "base", head: "push-3-source", extra: extra},
+		{name: "extra refs moved", base: "base", head: "push-1", extra: prowv1.Refs{Org: "org", Repo: "other", BaseRef: "main", BaseSHA: "extra-moved"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if matches := key(tc.base, tc.head, tc.extra) == reference; matches != tc.expected {
+				t.Errorf("expected the key to match: %t, got %t", tc.expected, matches)
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	client := &fakeGitHub{trees: map[string]map[string]string{
+		"base": {"pkg/a.go": "1", "pkg/sub/b.go": "2", "docs/README.md": "3", "Makefile": "4"},
+	}}
+	refs := prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"}
+	for _, tc := range []struct {
+		name           string
+		sourcePaths    []string
+		expected       map[string]map[string]string
+		expectedListed int
+	}{
+		{
+			name:           "files matching anchored paths",
+			sourcePaths:    []string{"^pkg/sub/", "^pkg/.*\\.go$", "^missing/"},
+			expected:       map[string]map[string]string{"base": {"pkg/a.go": "1", "pkg/sub/b.go": "2"}},
+			expectedListed: 1,
+		},
+		{
+			name:           "paths which are not anchored list the tree once",
+			sourcePaths:    []string{"^Makefile$", "\\.md$"},
+			expected:       map[string]map[string]string{"base": {"Makefile": "4", "docs/README.md": "3"}},
+			expectedListed: 1,
+		},
+		{
+			name:     "the tree identifies all files",
+			expected: map[string]map[string]string{"base": {"": `{"Makefile":"4","docs/README.md":"3","pkg/a.go":"1","pkg/sub/b.go":"2"}`}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client.listed = 0
+			actual, err := Sources(client, refs, nil, tc.sourcePaths)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected sources: %s", diff)
+			}
+			if client.listed != tc.expectedListed {
+				t.Errorf("expected %d trees to be listed, got %d", tc.expectedListed, client.listed)
+			}
+		})
+	}
+}
+
+func TestOverridden(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		labels   []string
+		expected bool
+	}{
+		{name: "no labels"},
+		{name: "other labels", labels: []string{"lgtm", "approved"}},
+		{name: "override label", labels: []string{"lgtm", OverrideLabel}, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Overridden(&fakeGitHub{labels: tc.labels}, "org", "repo", 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	now := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	record := func(key string, finished time.Time) *Record {
+		return &Record{Key: key, Target: "unit", Job: "job", BuildID: "1", SHA: "abc", Finished: finished}
+	}
+	for _, tc := range []struct {
+		name     string
+		recorded *Record
+		expected *Record
+	}{
+		{
+			name: "nothing recorded",
+		},
+		{
+			name:     "recent result",
+			recorded: record(key, now.Add(-time.Hour)),
+			expected: record(key, now.Add(-time.Hour)),
+		},
+		{
+			name:     "outdated result",
+			recorded: record(key, now.Add(-8*24*time.Hour)),
+		},
+		{
+			name:     "colliding name for another key",
+			recorded: record(key[:32]+"ffffffffffffffffffffffffffffffff", now),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().Build()
+			store := NewStore(client, "results")
+			store.now = func() time.Time { return now }
+			if tc.recorded != nil {
+				if err := store.Put(context.Background(), *tc.recorded); err != nil {
+					t.Fatalf("failed to record the result: %v", err)
+				}
+			}
+			actual, err := store.Get(context.Background(), key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected record: %s", diff)
+			}
+		})
+	}
+
+	t.Run("later result replaces the earlier one", func(t *testing.T) {
+		client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "results", Name: recordName(key)},
+			Data:       map[string]string{recordKey: "{}"},
+		}).Build()
+		store := NewStore(client, "results")
+		store.now = func() time.Time { return now }
+		later := record(key, now)
+		if err := store.Put(context.Background(), *later); err != nil {
+			t.Fatalf("failed to record the result: %v", err)
+		}
+		actual, err := store.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(later, actual); diff != "" {
+			t.Errorf("unexpected record: %s", diff)
+		}
+		cm := &coreapi.ConfigMap{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "results", Name: recordName(key)}, cm); err != nil {
+			t.Fatalf("failed to get the ConfigMap: %v", err)
+		}
+		if cm.Labels[recordLabel] != "true" {
+			t.Errorf("expected the ConfigMap to be labelled, got %v", cm.Labels)
+		}
+	})
+
+	t.Run("records too old to be reused are pruned", func(t *testing.T) {
+		recorded := func(name string, created time.Time, data string, labels map[string]string) *coreapi.ConfigMap {
+			return &coreapi.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "results", Name: name, Labels: labels, CreationTimestamp: metav1.Time{Time: created}},
+				Data:       map[string]string{recordKey: data},
+			}
+		}
+		serialize := func(r *Record) string {
+			raw, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(raw)
+		}
+		labels := map[string]string{recordLabel: "true"}
+		client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+			recorded("recent", now.Add(-30*24*time.Hour), serialize(record("recent", now.Add(-time.Hour))), labels),
+			recorded("outdated", now.Add(-time.Hour), serialize(record("outdated", now.Add(-8*24*time.Hour))), labels),
+			recorded("unparseable", now.Add(-8*24*time.Hour), "{", labels),
+			recorded("unrelated", now.Add(-30*24*time.Hour), "{", nil),
+		).Build()
+		store := NewStore(client, "results")
+		store.now = func() time.Time { return now }
+		if err := store.Put(context.Background(), *record(key, now)); err != nil {
+			t.Fatalf("failed to record the result: %v", err)
+		}
+		cms := &coreapi.ConfigMapList{}
+		if err := client.List(context.Background(), cms); err != nil {
+			t.Fatalf("failed to list the ConfigMaps: %v", err)
+		}
+		var names []string
+		for _, cm := range cms.Items {
+			names = append(names, cm.Name)
+		}
+		if diff := cmp.Diff([]string{"recent", recordName(key), "unrelated"}, names); diff != "" {
+			t.Errorf("unexpected records: %s", diff)
+		}
+	})
+}
+
+func TestGetTree(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/git/trees/sha" || r.URL.Query().Get("recursive") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("unexpected authorization: %q", auth)
+		}
+		_, _ = w.Write([]byte(`{"tree":[{"path":"pkg","type":"tree","sha":"1"},{"path":"pkg/a.go","type":"blob","sha":"2"}]}`))
+	}))
+	defer server.Close()
+	client := NewGitHubClient(nil, server.URL, func() []byte { return []byte("token") })
+	actual, err := client.GetTree("org", "repo", "sha")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"pkg/a.go": "2"}, actual); diff != "" {
+		t.Errorf("unexpected tree: %s", diff)
+	}
+	if _, err := client.GetTree("org", "repo", "missing"); err == nil {
+		t.Error("expected an error for a missing revision")
+	}
+}
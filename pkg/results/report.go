@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -96,6 +97,88 @@ type Request struct {
 	Reason string `json:"reason"`
 }
 
+// QuotaWaitRequest holds the time a job waited for the reservation of its
+// cluster profile before it could lease resources
+type QuotaWaitRequest struct {
+	// JobName is the name of the job that waited
+	JobName string `json:"job_name"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Profile is the cluster profile the job waited for
+	Profile string `json:"profile"`
+	// WaitSeconds is the time spent waiting, in seconds
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// VersionSkewRequest holds the version of a ci-operator binary that is older
+// than the version deployed in the infrastructure
+type VersionSkewRequest struct {
+	// JobName is the name of the job that ran the binary
+	JobName string `json:"job_name"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Cluster is the cluster's console hostname
+	Cluster string `json:"cluster"`
+	// Version is the version of the binary
+	Version string `json:"version"`
+	// AdvertisedVersion is the version deployed in the infrastructure
+	AdvertisedVersion string `json:"advertised_version"`
+}
+
+// ReusedResultRequest holds the target of a presubmit job which reported the
+// result of an earlier run on the pull request instead of running again
+type ReusedResultRequest struct {
+	// JobName is the name of the job whose target was not run
+	JobName string `json:"job_name"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Cluster is the cluster's console hostname
+	Cluster string `json:"cluster"`
+	// Target is the target whose result was reused
+	Target string `json:"target"`
+}
+
+// FeatureGatesRequest holds the feature gates a job ran with, for the
+// outcome of jobs to be compared between gates being rolled out
+type FeatureGatesRequest struct {
+	// JobName is the name of the job
+	JobName string `json:"job_name"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Cluster is the cluster's console hostname
+	Cluster string `json:"cluster"`
+	// Org is the organization the gates were resolved for
+	Org string `json:"org"`
+	// Gates maps the feature gates to whether they were enabled
+	Gates map[string]bool `json:"gates"`
+}
+
+// PodAPIErrorsRequest holds the errors the pod exec and log APIs returned
+// during a job, for transient API server errors to be tracked
+type PodAPIErrorsRequest struct {
+	// JobName is the name of the job
+	JobName string `json:"job_name"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Cluster is the cluster's console hostname
+	Cluster string `json:"cluster"`
+	// Errors are the counts of each kind of error
+	Errors []PodAPIErrorCount `json:"errors"`
+}
+
+// PodAPIErrorCount holds how many errors of a kind the pod exec and log APIs
+// returned
+type PodAPIErrorCount struct {
+	// Operation is the request which failed, "exec" or "logs"
+	Operation string `json:"operation"`
+	// Category is the category of the errors
+	Category string `json:"category"`
+	// Retried is whether the request was retried after the errors
+	Retried bool `json:"retried"`
+	// Count is the number of errors
+	Count int `json:"count"`
+}
+
 // PodScalerRequest holds the data from pod-scaler used to report a result to an aggregation server
 type PodScalerRequest struct {
 	WorkloadName     string
@@ -115,12 +198,39 @@ type Reporter interface {
 	// This action is best-effort and errors are logged but not exposed.
 	// Err may be nil in which case a success is reported.
 	Report(err error)
+	// ReportQuotaWait sends the time spent waiting for the reservation of a
+	// cluster profile to an aggregation server. This action is best-effort.
+	ReportQuotaWait(profile string, waited time.Duration)
+	// ReportVersionSkew sends the version of a ci-operator binary that is
+	// older than the advertised version to an aggregation server. This
+	// action is best-effort.
+	ReportVersionSkew(version, advertised string)
+	// ReportReusedResult sends the target whose earlier result was reported
+	// instead of running it again to an aggregation server. This action is
+	// best-effort.
+	ReportReusedResult(target string)
+	// ReportFeatureGates sends the feature gates resolved for the job of the
+	// organization to an aggregation server. This action is best-effort.
+	ReportFeatureGates(org string, gates map[string]bool)
+	// ReportPodAPIErrors sends the errors the pod exec and log APIs returned
+	// during the job to an aggregation server. This action is best-effort.
+	ReportPodAPIErrors(errors []PodAPIErrorCount)
 }
 
 type noopReporter struct{}
 
 func (r *noopReporter) Report(err error) {}
 
+func (r *noopReporter) ReportQuotaWait(profile string, waited time.Duration) {}
+
+func (r *noopReporter) ReportVersionSkew(version, advertised string) {}
+
+func (r *noopReporter) ReportReusedResult(target string) {}
+
+func (r *noopReporter) ReportFeatureGates(org string, gates map[string]bool) {}
+
+func (r *noopReporter) ReportPodAPIErrors(errors []PodAPIErrorCount) {}
+
 type reporter struct {
 	client             *http.Client
 	username, password string
@@ -171,6 +281,109 @@ func (r *reporter) report(request Request) {
 	sendRequest(req, r.client, r.username, r.password)
 }
 
+func (r *reporter) ReportQuotaWait(profile string, waited time.Duration) {
+	data, err := json.Marshal(QuotaWaitRequest{
+		JobName:     r.spec.Job,
+		Type:        string(r.spec.Type),
+		Profile:     profile,
+		WaitSeconds: waited.Seconds(),
+	})
+	if err != nil {
+		logrus.Tracef("could not marshal quota wait request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/quota-wait", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create quota wait request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
+func (r *reporter) ReportVersionSkew(version, advertised string) {
+	data, err := json.Marshal(VersionSkewRequest{
+		JobName:           r.spec.Job,
+		Type:              string(r.spec.Type),
+		Cluster:           r.consoleHost,
+		Version:           version,
+		AdvertisedVersion: advertised,
+	})
+	if err != nil {
+		logrus.Tracef("could not marshal version skew request: %v", err)
+		return
+	}
+	// runs which are not Prow jobs, like those started locally, are counted
+	// apart from the jobs
+	path := "version-skew"
+	if r.spec.ProwJobID == "" {
+		path = "local-version-skew"
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s", r.address, path), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create version skew request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
+func (r *reporter) ReportReusedResult(target string) {
+	data, err := json.Marshal(ReusedResultRequest{
+		JobName: r.spec.Job,
+		Type:    string(r.spec.Type),
+		Cluster: r.consoleHost,
+		Target:  target,
+	})
+	if err != nil {
+		logrus.Tracef("could not marshal reused result request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/reused-result", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create reused result request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
+func (r *reporter) ReportFeatureGates(org string, gates map[string]bool) {
+	data, err := json.Marshal(FeatureGatesRequest{
+		JobName: r.spec.Job,
+		Type:    string(r.spec.Type),
+		Cluster: r.consoleHost,
+		Org:     org,
+		Gates:   gates,
+	})
+	if err != nil {
+		logrus.Tracef("could not marshal feature gates request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/feature-gates", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create feature gates request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
+func (r *reporter) ReportPodAPIErrors(errors []PodAPIErrorCount) {
+	data, err := json.Marshal(PodAPIErrorsRequest{
+		JobName: r.spec.Job,
+		Type:    string(r.spec.Type),
+		Cluster: r.consoleHost,
+		Errors:  errors,
+	})
+	if err != nil {
+		logrus.Tracef("could not marshal pod API errors request: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/pod-api-errors", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create pod API errors request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
+}
+
 type PodScalerReporter interface {
 	ReportResourceConfigurationWarning(workloadName, workloadType, configuredAmount, determinedAmount, resourceType string)
 }
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -104,6 +105,186 @@ func TestReporter_Report(t *testing.T) {
 	}
 }
 
+func TestReporter_ReportQuotaWait(t *testing.T) {
+	var received string
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/quota-wait" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		received = string(raw)
+	}))
+	defer testServer.Close()
+
+	reporter := reporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		address: testServer.URL,
+		spec:    &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PeriodicJob}},
+	}
+	reporter.ReportQuotaWait("aws", 90*time.Second)
+	expected := `{"job_name":"runme","type":"periodic","profile":"aws","wait_seconds":90}`
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected request: %s", diff)
+	}
+}
+
+func TestReporter_ReportReusedResult(t *testing.T) {
+	var received string
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/reused-result" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		received = string(raw)
+	}))
+	defer testServer.Close()
+
+	reporter := reporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		address:     testServer.URL,
+		spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-unit", Type: v1.PresubmitJob}},
+		consoleHost: "build01",
+	}
+	reporter.ReportReusedResult("unit")
+	expected := `{"job_name":"pull-ci-org-repo-master-unit","type":"presubmit","cluster":"build01","target":"unit"}`
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected request: %s", diff)
+	}
+}
+
+func TestReporter_ReportFeatureGates(t *testing.T) {
+	var received string
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feature-gates" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		received = string(raw)
+	}))
+	defer testServer.Close()
+
+	reporter := reporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		address:     testServer.URL,
+		spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-unit", Type: v1.PresubmitJob}},
+		consoleHost: "build01",
+	}
+	reporter.ReportFeatureGates("org", map[string]bool{"ResultReuse": true, "BuildCache": false})
+	expected := `{"job_name":"pull-ci-org-repo-master-unit","type":"presubmit","cluster":"build01","org":"org","gates":{"BuildCache":false,"ResultReuse":true}}`
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected request: %s", diff)
+	}
+}
+
+func TestReporter_ReportPodAPIErrors(t *testing.T) {
+	var received string
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pod-api-errors" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		received = string(raw)
+	}))
+	defer testServer.Close()
+
+	reporter := reporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		address:     testServer.URL,
+		spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-unit", Type: v1.PresubmitJob}},
+		consoleHost: "build01",
+	}
+	reporter.ReportPodAPIErrors([]PodAPIErrorCount{{Operation: "exec", Category: "dialing_backend", Retried: true, Count: 2}})
+	expected := `{"job_name":"pull-ci-org-repo-master-unit","type":"presubmit","cluster":"build01","errors":[{"operation":"exec","category":"dialing_backend","retried":true,"count":2}]}`
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Errorf("unexpected request: %s", diff)
+	}
+}
+
+func TestReporter_ReportVersionSkew(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		prowJobID    string
+		expectedPath string
+	}{
+		{name: "Prow job", prowJobID: "uuid", expectedPath: "/version-skew"},
+		{name: "local run", expectedPath: "/local-version-skew"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var received string
+			testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.expectedPath {
+					t.Errorf("incorrect path: %s", r.URL.Path)
+					http.Error(w, "400 Bad Request", http.StatusBadRequest)
+					return
+				}
+				if user, _, ok := r.BasicAuth(); !ok || user != "user" {
+					t.Error("expected the credentials to be sent")
+				}
+				raw, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("failed to read body: %v", err)
+				}
+				received = string(raw)
+			}))
+			defer testServer.Close()
+
+			reporter := reporter{
+				client: &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+					},
+				},
+				username:    "user",
+				password:    "pass",
+				address:     testServer.URL,
+				spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "dev", Type: v1.PeriodicJob, ProwJobID: tc.prowJobID}},
+				consoleHost: "build01",
+			}
+			reporter.ReportVersionSkew("v20240101-abc", "v20240201-def")
+			expected := `{"job_name":"dev","type":"periodic","cluster":"build01","version":"v20240101-abc","advertised_version":"v20240201-def"}`
+			if diff := cmp.Diff(expected, received); diff != "" {
+				t.Errorf("unexpected request: %s", diff)
+			}
+		})
+	}
+}
+
 func TestOptions_Reporter(t *testing.T) {
 	// this simulates the flow for ci-operator while we migrate to using the tool
 	options := Options{} // no flags set
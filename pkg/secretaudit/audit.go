@@ -0,0 +1,214 @@
+// Package secretaudit maps the secrets available to tests to the steps
+// mounting them and the last time those ran, to find secrets that can be
+// cleaned up and review which jobs have access to which secrets.
+package secretaudit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// Secret identifies a secret in the build clusters.
+type Secret struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (s Secret) String() string {
+	return s.Namespace + "/" + s.Name
+}
+
+// Consumer is a step of a job mounting a secret.
+type Consumer struct {
+	Job  string `json:"job"`
+	Step string `json:"step"`
+}
+
+// Index maps secrets to the steps mounting them.
+type Index map[Secret][]Consumer
+
+// JobNamespace is the namespace of the Prow jobs, whose pods mount the secrets
+// of container tests.
+const JobNamespace = "ci"
+
+// NewIndex resolves the multi-stage tests of the configurations and indexes
+// the credentials of their steps, as well as the secrets of container tests. Configurations which fail to resolve are
+// skipped and reported in the error. Credentials fetched from a cloud secret
+// manager are not indexed, as they are not part of the secrets synced to the
+// clusters.
+func NewIndex(configs []api.ReleaseBuildConfiguration, resolver registry.Resolver) (Index, error) {
+	index := Index{}
+	var errs []error
+	for _, config := range configs {
+		resolved, err := registry.ResolveConfig(resolver, config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", config.Metadata.Basename(), err))
+			continue
+		}
+		for _, test := range resolved.Tests {
+			job := resolved.Metadata.JobName(jobPrefix(test), test.As)
+			for _, secret := range append([]*api.Secret{test.Secret}, test.Secrets...) {
+				if secret == nil {
+					continue
+				}
+				// the test container runs in the job pod, which mounts the secret
+				key := Secret{Namespace: JobNamespace, Name: secret.Name}
+				index[key] = append(index[key], Consumer{Job: job, Step: test.As})
+			}
+			if test.MultiStageTestConfigurationLiteral == nil {
+				continue
+			}
+			for _, step := range test.MultiStageTestConfigurationLiteral.Steps() {
+				for _, credential := range step.Credentials {
+					if credential.Cloud != nil {
+						continue
+					}
+					secret := Secret{Namespace: credential.Namespace, Name: credential.Name}
+					index[secret] = append(index[secret], Consumer{Job: job, Step: step.As})
+				}
+			}
+		}
+	}
+	return index, utilerrors.NewAggregate(errs)
+}
+
+func jobPrefix(test api.TestStepConfiguration) string {
+	switch {
+	case test.IsPeriodic():
+		return jobconfig.PeriodicPrefix
+	case test.Postsubmit:
+		return jobconfig.PostsubmitPrefix
+	default:
+		return jobconfig.PresubmitPrefix
+	}
+}
+
+// Inventory lists the secrets synced to the clusters in the namespaces.
+func Inventory(config secretbootstrap.Config, namespaces []string) []Secret {
+	allowed := map[string]bool{}
+	for _, namespace := range namespaces {
+		allowed[namespace] = true
+	}
+	seen := map[Secret]bool{}
+	var ret []Secret
+	for _, secretConfig := range config.Secrets {
+		for _, to := range secretConfig.To {
+			secret := Secret{Namespace: to.Namespace, Name: to.Name}
+			if allowed[to.Namespace] && !seen[secret] {
+				seen[secret] = true
+				ret = append(ret, secret)
+			}
+		}
+	}
+	return ret
+}
+
+// Status classifies the usage of a secret.
+type Status string
+
+const (
+	// StatusUnused marks secrets no step mounts.
+	StatusUnused Status = "unused"
+	// StatusStale marks secrets only mounted by jobs that did not run
+	// recently.
+	StatusStale Status = "stale"
+	// StatusUsed marks secrets mounted by jobs that ran recently.
+	StatusUsed Status = "used"
+	// StatusMissing marks secrets mounted by steps but not in the inventory.
+	StatusMissing Status = "missing"
+)
+
+// ConsumerUsage is a consumer of a secret and the last time its job ran.
+type ConsumerUsage struct {
+	Consumer `json:",inline"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+}
+
+// Usage describes how a secret is used.
+type Usage struct {
+	Secret `json:",inline"`
+	Status Status `json:"status"`
+	// LastUsed is the last time a job mounting the secret ran.
+	LastUsed  *time.Time      `json:"last_used,omitempty"`
+	Consumers []ConsumerUsage `json:"consumers,omitempty"`
+}
+
+// Audit classifies the usage of each secret of the inventory and of each
+// secret mounted by steps but missing from it. Secrets whose consumers did
+// not run since the cutoff are stale. lastRuns holds the time of the last run
+// of jobs, jobs without any known run are considered to have never run.
+func Audit(inventory []Secret, index Index, lastRuns map[string]time.Time, cutoff time.Time) []Usage {
+	known := map[Secret]bool{}
+	for _, secret := range inventory {
+		known[secret] = true
+	}
+	secrets := append([]Secret{}, inventory...)
+	for secret := range index {
+		if !known[secret] {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if secrets[i].Namespace != secrets[j].Namespace {
+			return secrets[i].Namespace < secrets[j].Namespace
+		}
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	var ret []Usage
+	for _, secret := range secrets {
+		usage := Usage{Secret: secret}
+		for _, consumer := range index[secret] {
+			c := ConsumerUsage{Consumer: consumer}
+			if lastRun, ok := lastRuns[consumer.Job]; ok {
+				c.LastRun = &lastRun
+				if usage.LastUsed == nil || lastRun.After(*usage.LastUsed) {
+					usage.LastUsed = &lastRun
+				}
+			}
+			usage.Consumers = append(usage.Consumers, c)
+		}
+		sort.Slice(usage.Consumers, func(i, j int) bool {
+			if usage.Consumers[i].Job != usage.Consumers[j].Job {
+				return usage.Consumers[i].Job < usage.Consumers[j].Job
+			}
+			return usage.Consumers[i].Step < usage.Consumers[j].Step
+		})
+		switch {
+		case !known[secret]:
+			usage.Status = StatusMissing
+		case len(usage.Consumers) == 0:
+			usage.Status = StatusUnused
+		case usage.LastUsed == nil || usage.LastUsed.Before(cutoff):
+			usage.Status = StatusStale
+		default:
+			usage.Status = StatusUsed
+		}
+		ret = append(ret, usage)
+	}
+	return ret
+}
+
+// Jobs lists the jobs of the consumers in the index, each once.
+func (i Index) Jobs() []string {
+	seen := map[string]bool{}
+	var ret []string
+	for _, consumers := range i {
+		for _, consumer := range consumers {
+			if !seen[consumer.Job] {
+				seen[consumer.Job] = true
+				ret = append(ret, consumer.Job)
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,151 @@
+package secretaudit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestNewIndex(t *testing.T) {
+	reference := "install"
+	workflow := "e2e"
+	missing := "missing"
+	references := registry.ReferenceByName{
+		"install": {As: "install", Credentials: []api.CredentialReference{
+			{Namespace: "test-credentials", Name: "cloud-creds", MountPath: "/creds"},
+			{Name: "token", Cloud: &api.CloudCredentialSource{}, MountPath: "/token"},
+		}},
+	}
+	workflows := registry.WorkflowByName{
+		"e2e": {Pre: []api.TestStep{{Reference: &reference}}},
+	}
+	metadata := api.Metadata{Org: "org", Repo: "repo", Branch: "main"}
+	cron := "@daily"
+	configs := []api.ReleaseBuildConfiguration{
+		{
+			Metadata: metadata,
+			Tests: []api.TestStepConfiguration{
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &workflow}},
+				{As: "nightly", Cron: &cron, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{As: "test", Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "cloud-creds"}}}},
+				}},
+				{As: "post", Postsubmit: true, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Post: []api.LiteralTestStep{{As: "publish", Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "registry-push"}}}},
+				}},
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "lint", Secret: &api.Secret{Name: "linter-token"}, ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "verify", Secrets: []*api.Secret{{Name: "linter-token"}, {Name: "quay-pull"}}, ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			},
+		},
+		{
+			Metadata: api.Metadata{Org: "org", Repo: "broken", Branch: "main"},
+			Tests: []api.TestStepConfiguration{
+				{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{Workflow: &missing}},
+			},
+		},
+	}
+	index, err := NewIndex(configs, registry.NewResolver(references, registry.ChainByName{}, workflows, registry.ObserverByName{}))
+	if diff := cmp.Diff(errors.New("org-broken-main.yaml: Failed resolve MultiStageTestConfiguration: no workflow named missing"), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+	expected := Index{
+		{Namespace: "ci", Name: "linter-token"}: {
+			{Job: "pull-ci-org-repo-main-lint", Step: "lint"},
+			{Job: "pull-ci-org-repo-main-verify", Step: "verify"},
+		},
+		{Namespace: "ci", Name: "quay-pull"}: {
+			{Job: "pull-ci-org-repo-main-verify", Step: "verify"},
+		},
+		{Namespace: "test-credentials", Name: "cloud-creds"}: {
+			{Job: "pull-ci-org-repo-main-e2e", Step: "install"},
+			{Job: "periodic-ci-org-repo-main-nightly", Step: "test"},
+		},
+		{Namespace: "test-credentials", Name: "registry-push"}: {
+			{Job: "branch-ci-org-repo-main-post", Step: "publish"},
+		},
+	}
+	if diff := cmp.Diff(expected, index); diff != "" {
+		t.Errorf("unexpected index: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"branch-ci-org-repo-main-post", "periodic-ci-org-repo-main-nightly", "pull-ci-org-repo-main-e2e", "pull-ci-org-repo-main-lint", "pull-ci-org-repo-main-verify"}, index.Jobs()); diff != "" {
+		t.Errorf("unexpected jobs: %s", diff)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	config := secretbootstrap.Config{Secrets: []secretbootstrap.SecretConfig{
+		{To: []secretbootstrap.SecretContext{
+			{Cluster: "build01", Namespace: "test-credentials", Name: "a"},
+			{Cluster: "build02", Namespace: "test-credentials", Name: "a"},
+			{Cluster: "build01", Namespace: "ci", Name: "a"},
+		}},
+		{To: []secretbootstrap.SecretContext{{Cluster: "build01", Namespace: "test-credentials", Name: "b"}}},
+	}}
+	expected := []Secret{{Namespace: "test-credentials", Name: "a"}, {Namespace: "test-credentials", Name: "b"}}
+	if diff := cmp.Diff(expected, Inventory(config, []string{"test-credentials"})); diff != "" {
+		t.Errorf("unexpected inventory: %s", diff)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	cutoff := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	recent, old := cutoff.Add(24*time.Hour), cutoff.Add(-24*time.Hour)
+	inventory := []Secret{
+		{Namespace: "test-credentials", Name: "used"},
+		{Namespace: "test-credentials", Name: "stale"},
+		{Namespace: "test-credentials", Name: "never-ran"},
+		{Namespace: "test-credentials", Name: "unused"},
+	}
+	index := Index{
+		{Namespace: "test-credentials", Name: "used"}: {
+			{Job: "recent", Step: "b"},
+			{Job: "old", Step: "a"},
+		},
+		{Namespace: "test-credentials", Name: "stale"}:     {{Job: "old", Step: "a"}},
+		{Namespace: "test-credentials", Name: "never-ran"}: {{Job: "new", Step: "a"}},
+		{Namespace: "test-credentials", Name: "missing"}:   {{Job: "recent", Step: "c"}},
+	}
+	lastRuns := map[string]time.Time{"recent": recent, "old": old}
+	expected := []Usage{
+		{
+			Secret:    Secret{Namespace: "test-credentials", Name: "missing"},
+			Status:    StatusMissing,
+			LastUsed:  &recent,
+			Consumers: []ConsumerUsage{{Consumer: Consumer{Job: "recent", Step: "c"}, LastRun: &recent}},
+		},
+		{
+			Secret:    Secret{Namespace: "test-credentials", Name: "never-ran"},
+			Status:    StatusStale,
+			Consumers: []ConsumerUsage{{Consumer: Consumer{Job: "new", Step: "a"}}},
+		},
+		{
+			Secret:    Secret{Namespace: "test-credentials", Name: "stale"},
+			Status:    StatusStale,
+			LastUsed:  &old,
+			Consumers: []ConsumerUsage{{Consumer: Consumer{Job: "old", Step: "a"}, LastRun: &old}},
+		},
+		{
+			Secret: Secret{Namespace: "test-credentials", Name: "unused"},
+			Status: StatusUnused,
+		},
+		{
+			Secret:   Secret{Namespace: "test-credentials", Name: "used"},
+			Status:   StatusUsed,
+			LastUsed: &recent,
+			Consumers: []ConsumerUsage{
+				{Consumer: Consumer{Job: "old", Step: "a"}, LastRun: &old},
+				{Consumer: Consumer{Job: "recent", Step: "b"}, LastRun: &recent},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, Audit(inventory, index, lastRuns, cutoff)); diff != "" {
+		t.Errorf("unexpected usage: %s", diff)
+	}
+}
@@ -0,0 +1,315 @@
+// Package secretcollection generates the Vault policies and groups backing
+// secret collections from their definitions, and detects where Vault drifted
+// away from them.
+package secretcollection
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/vaultclient"
+)
+
+const (
+	// ObjectPrefix prefixes the names of the policies and groups of secret
+	// collections.
+	ObjectPrefix = "secret-collection-manager-managed"
+	// managedMetadataKey marks groups as backing a secret collection.
+	managedMetadataKey = "created-by-secret-collection-manager"
+	// definedMembersMetadataKey records the members a group was last given
+	// from the definitions, so that the ones later removed from them can be
+	// told apart from the ones added through the secret collection manager.
+	definedMembersMetadataKey = "secret-collection-generator-members"
+)
+
+var nameRegex = regexp.MustCompile("^[a-z0-9-]+$")
+
+// Config holds the definitions of secret collections.
+type Config struct {
+	Collections []Collection `json:"collections"`
+}
+
+// Collection is a set of secrets under a common path in Vault, which its
+// members can manage.
+type Collection struct {
+	Name string `json:"name"`
+	// Members are the names under which users log into Vault.
+	Members []string `json:"members"`
+}
+
+// LoadConfig reads and validates the definitions of secret collections.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config Config
+	if err := yaml.UnmarshalStrict(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &config, nil
+}
+
+// Validate checks the names of the collections and that each has members.
+func (c *Config) Validate() error {
+	var errs []error
+	seen := sets.New[string]()
+	for i, collection := range c.Collections {
+		if !nameRegex.MatchString(collection.Name) {
+			errs = append(errs, fmt.Errorf("collections[%d]: name %q does not match regex '%s'", i, collection.Name, nameRegex))
+		}
+		if seen.Has(collection.Name) {
+			errs = append(errs, fmt.Errorf("collections[%d]: collection %s is defined more than once", i, collection.Name))
+		}
+		seen.Insert(collection.Name)
+		if len(collection.Members) == 0 {
+			errs = append(errs, fmt.Errorf("collections[%d]: collection %s has no members", i, collection.Name))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// PrefixedName is the name of the policy and group of a collection.
+func PrefixedName(name string) string {
+	return ObjectPrefix + "-" + name
+}
+
+type policy struct {
+	Path map[string]policyCapabilityList `json:"path,omitempty"`
+}
+
+type policyCapabilityList struct {
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Policy is the serialized policy granting the members of a collection access
+// to its secrets under the KV store prefix.
+func Policy(kvStorePrefix, name string) (string, error) {
+	p := policy{Path: map[string]policyCapabilityList{
+		vaultclient.InsertMetadataIntoPath(kvStorePrefix) + "/" + name + "/*": {Capabilities: []string{"list", "delete"}},
+		vaultclient.InsertDataIntoPath(kvStorePrefix) + "/" + name + "/*":     {Capabilities: []string{"create", "update", "read"}},
+	}}
+	serialized, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize policy: %w", err)
+	}
+	return string(serialized), nil
+}
+
+// Client is the subset of Vault operations needed to reconcile collections.
+type Client interface {
+	ListPolicies() ([]string, error)
+	GetPolicy(name string) (string, error)
+	PutPolicy(name, rules string) error
+	GetGroupByName(name string) (*vaultclient.Group, error)
+	PutGroup(group vaultclient.Group) error
+	GetUserFromAliasName(name string) (*vaultclient.Entity, error)
+}
+
+type client struct {
+	*vaultclient.VaultClient
+}
+
+// NewClient adapts a Vault client for the reconciliation.
+func NewClient(v *vaultclient.VaultClient) Client {
+	return &client{VaultClient: v}
+}
+
+func (c *client) ListPolicies() ([]string, error)       { return c.Sys().ListPolicies() }
+func (c *client) GetPolicy(name string) (string, error) { return c.Sys().GetPolicy(name) }
+func (c *client) PutPolicy(name, rules string) error    { return c.Sys().PutPolicy(name, rules) }
+
+func (c *client) PutGroup(group vaultclient.Group) error {
+	serialized, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group: %w", err)
+	}
+	return c.Put("identity/group/name/"+group.Name, serialized)
+}
+
+// Drift is a difference between the definition of a collection and Vault.
+type Drift struct {
+	Collection string
+	// Object is the Vault object that drifted, `policy` or `group`.
+	Object  string
+	Message string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s: %s", d.Object, PrefixedName(d.Collection), d.Message)
+}
+
+// Reconcile compares the policies and groups in Vault with the ones generated
+// from the definitions of the collections, and updates the drifted ones when
+// confirm is set. Collections that exist in Vault but are not defined are only
+// logged, as they may be managed through the secret collection manager.
+func Reconcile(c Client, config *Config, kvStorePrefix string, confirm bool) ([]Drift, error) {
+	var drifts []Drift
+	var errs []error
+	defined := sets.New[string]()
+	for _, collection := range config.Collections {
+		defined.Insert(PrefixedName(collection.Name))
+		collectionDrifts, err := reconcileCollection(c, collection, kvStorePrefix, confirm)
+		drifts = append(drifts, collectionDrifts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collection %s: %w", collection.Name, err))
+		}
+	}
+
+	policies, err := c.ListPolicies()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list policies: %w", err))
+	}
+	for _, name := range policies {
+		if strings.HasPrefix(name, ObjectPrefix+"-") && !defined.Has(name) {
+			logrus.WithField("policy", name).Info("Secret collection is not defined in the configuration, ignoring it.")
+		}
+	}
+	return drifts, utilerrors.NewAggregate(errs)
+}
+
+func reconcileCollection(c Client, collection Collection, kvStorePrefix string, confirm bool) ([]Drift, error) {
+	name := PrefixedName(collection.Name)
+	var drifts []Drift
+	var errs []error
+	drift := func(object, format string, args ...interface{}) {
+		d := Drift{Collection: collection.Name, Object: object, Message: fmt.Sprintf(format, args...)}
+		logrus.WithField("confirm", confirm).Info(d.String())
+		drifts = append(drifts, d)
+	}
+
+	expectedPolicy, err := Policy(kvStorePrefix, collection.Name)
+	if err != nil {
+		return nil, err
+	}
+	actualPolicy, err := c.GetPolicy(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+	if actualPolicy != expectedPolicy {
+		if actualPolicy == "" {
+			drift("policy", "missing")
+		} else {
+			drift("policy", "rules differ from the generated ones")
+		}
+		if confirm {
+			if err := c.PutPolicy(name, expectedPolicy); err != nil {
+				errs = append(errs, fmt.Errorf("failed to update policy: %w", err))
+			}
+		}
+	}
+
+	var memberIDs, definedMembers []string
+	idToMember := map[string]string{}
+	for _, member := range collection.Members {
+		user, err := c.GetUserFromAliasName(member)
+		if err != nil {
+			if vaultclient.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("member %s has never logged into Vault", member))
+			} else {
+				errs = append(errs, fmt.Errorf("failed to get member %s: %w", member, err))
+			}
+			continue
+		}
+		memberIDs = append(memberIDs, user.ID)
+		definedMembers = append(definedMembers, member)
+		idToMember[user.ID] = member
+	}
+	sort.Strings(memberIDs)
+	sort.Strings(definedMembers)
+
+	group, err := c.GetGroupByName(name)
+	if err != nil && !vaultclient.IsNotFound(err) {
+		return drifts, utilerrors.NewAggregate(append(errs, fmt.Errorf("failed to get group: %w", err)))
+	}
+	outdated := false
+	// members added through the secret collection manager are kept, only the
+	// ones removed from the definitions are revoked
+	members := sets.New[string](memberIDs...)
+	if err != nil {
+		drift("group", "missing")
+		outdated = true
+	} else {
+		if !sets.New[string](group.Policies...).Equal(sets.New[string](name)) {
+			drift("group", "policies %v differ from [%s]", group.Policies, name)
+			outdated = true
+		}
+		actual := sets.New[string](group.MemberEntityIDs...)
+		if missing := members.Difference(actual); missing.Len() != 0 {
+			var added []string
+			for _, id := range sets.List(missing) {
+				added = append(added, idToMember[id])
+			}
+			drift("group", "members to add: %v", added)
+			outdated = true
+		}
+		revoked, err := revokedMembers(c, group, sets.New[string](collection.Members...))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		revokedIDs := sets.New[string]()
+		if len(revoked) != 0 {
+			var removed []string
+			for id, member := range revoked {
+				revokedIDs.Insert(id)
+				removed = append(removed, member)
+			}
+			sort.Strings(removed)
+			drift("group", "members to remove: %v", removed)
+			outdated = true
+		}
+		if group.Metadata[definedMembersMetadataKey] != strings.Join(definedMembers, ",") {
+			drift("group", "members defined for the collection are not recorded")
+			outdated = true
+		}
+		members = members.Union(actual.Difference(revokedIDs))
+	}
+	if outdated && confirm {
+		if err := c.PutGroup(vaultclient.Group{
+			Name:            name,
+			Policies:        []string{name},
+			MemberEntityIDs: sets.List(members),
+			Metadata:        map[string]string{managedMetadataKey: "true", definedMembersMetadataKey: strings.Join(definedMembers, ",")},
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update group: %w", err))
+		}
+	}
+	return drifts, utilerrors.NewAggregate(errs)
+}
+
+// revokedMembers returns the members of the group, by identifier, which it was
+// given from the definitions before but which are no longer defined.
+func revokedMembers(c Client, group *vaultclient.Group, defined sets.Set[string]) (map[string]string, error) {
+	revoked := map[string]string{}
+	actual := sets.New[string](group.MemberEntityIDs...)
+	var errs []error
+	for _, member := range strings.Split(group.Metadata[definedMembersMetadataKey], ",") {
+		if member == "" || defined.Has(member) {
+			continue
+		}
+		user, err := c.GetUserFromAliasName(member)
+		if err != nil {
+			if !vaultclient.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to get removed member %s: %w", member, err))
+			}
+			continue
+		}
+		if actual.Has(user.ID) {
+			revoked[user.ID] = member
+		}
+	}
+	return revoked, utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,251 @@
+package secretcollection
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/vault/api"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	"github.com/openshift/ci-tools/pkg/vaultclient"
+)
+
+func TestLoadConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		raw           string
+		expected      *Config
+		expectedError error
+	}{
+		{
+			name:     "valid",
+			raw:      "collections:\n- name: team\n  members:\n  - alice\n",
+			expected: &Config{Collections: []Collection{{Name: "team", Members: []string{"alice"}}}},
+		},
+		{
+			name:          "unknown field",
+			raw:           "collections:\n- name: team\n  owners:\n  - alice\n",
+			expectedError: errors.New(`failed to parse config: error unmarshaling JSON: while decoding JSON: json: unknown field "owners"`),
+		},
+		{
+			name:          "invalid",
+			raw:           "collections:\n- name: Team\n  members:\n  - alice\n- name: Team\n",
+			expectedError: errors.New(`invalid config: [collections[0]: name "Team" does not match regex '^[a-z0-9-]+$', collections[1]: name "Team" does not match regex '^[a-z0-9-]+$', collections[1]: collection Team is defined more than once, collections[1]: collection Team has no members]`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.raw), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := LoadConfig(path)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, config); diff != "" {
+				t.Errorf("unexpected config: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	actual, err := Policy("secret/self-managed", "team")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"path":{"secret/data/self-managed/team/*":{"capabilities":["create","update","read"]},"secret/metadata/self-managed/team/*":{"capabilities":["list","delete"]}}}`
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected policy: %s", diff)
+	}
+}
+
+type fakeClient struct {
+	policies map[string]string
+	groups   map[string]*vaultclient.Group
+	users    map[string]string
+}
+
+func (f *fakeClient) ListPolicies() ([]string, error) {
+	var names []string
+	for name := range f.policies {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeClient) GetPolicy(name string) (string, error) {
+	return f.policies[name], nil
+}
+
+func (f *fakeClient) PutPolicy(name, rules string) error {
+	f.policies[name] = rules
+	return nil
+}
+
+func (f *fakeClient) GetGroupByName(name string) (*vaultclient.Group, error) {
+	group, ok := f.groups[name]
+	if !ok {
+		return nil, &api.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return group, nil
+}
+
+func (f *fakeClient) PutGroup(group vaultclient.Group) error {
+	f.groups[group.Name] = &group
+	return nil
+}
+
+func (f *fakeClient) GetUserFromAliasName(name string) (*vaultclient.Entity, error) {
+	id, ok := f.users[name]
+	if !ok {
+		return nil, &api.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return &vaultclient.Entity{ID: id}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	const prefix = "secret/self-managed"
+	teamPolicy, err := Policy(prefix, "team")
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	config := &Config{Collections: []Collection{{Name: "team", Members: []string{"alice", "bob"}}}}
+	users := map[string]string{"alice": "alice-id", "bob": "bob-id", "carol": "carol-id"}
+	upToDateGroup := func() *vaultclient.Group {
+		return &vaultclient.Group{
+			Name:            PrefixedName("team"),
+			Policies:        []string{PrefixedName("team")},
+			MemberEntityIDs: []string{"alice-id", "bob-id"},
+			Metadata:        map[string]string{managedMetadataKey: "true", definedMembersMetadataKey: "alice,bob"},
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		config          *Config
+		policies        map[string]string
+		groups          map[string]*vaultclient.Group
+		confirm         bool
+		expectedDrifts  []Drift
+		expectedMembers []string
+		expectedError   error
+	}{
+		{
+			name:     "up to date",
+			config:   config,
+			policies: map[string]string{PrefixedName("team"): teamPolicy, PrefixedName("other"): "{}"},
+			groups:   map[string]*vaultclient.Group{PrefixedName("team"): upToDateGroup()},
+		},
+		{
+			name:     "missing objects are reported",
+			config:   config,
+			policies: map[string]string{},
+			groups:   map[string]*vaultclient.Group{},
+			expectedDrifts: []Drift{
+				{Collection: "team", Object: "policy", Message: "missing"},
+				{Collection: "team", Object: "group", Message: "missing"},
+			},
+		},
+		{
+			name:     "drifted objects are reported",
+			config:   config,
+			policies: map[string]string{PrefixedName("team"): "{}"},
+			groups: map[string]*vaultclient.Group{PrefixedName("team"): {
+				Name:            PrefixedName("team"),
+				Policies:        []string{"admin"},
+				MemberEntityIDs: []string{"alice-id", "carol-id"},
+			}},
+			expectedDrifts: []Drift{
+				{Collection: "team", Object: "policy", Message: "rules differ from the generated ones"},
+				{Collection: "team", Object: "group", Message: "policies [admin] differ from [secret-collection-manager-managed-team]"},
+				{Collection: "team", Object: "group", Message: "members to add: [bob]"},
+				{Collection: "team", Object: "group", Message: "members defined for the collection are not recorded"},
+			},
+		},
+		{
+			name:     "members added outside of the definitions are kept",
+			config:   config,
+			policies: map[string]string{PrefixedName("team"): teamPolicy},
+			groups: map[string]*vaultclient.Group{PrefixedName("team"): {
+				Name:            PrefixedName("team"),
+				Policies:        []string{PrefixedName("team")},
+				MemberEntityIDs: []string{"alice-id", "carol-id"},
+				Metadata:        map[string]string{managedMetadataKey: "true", definedMembersMetadataKey: "alice"},
+			}},
+			confirm: true,
+			expectedDrifts: []Drift{
+				{Collection: "team", Object: "group", Message: "members to add: [bob]"},
+				{Collection: "team", Object: "group", Message: "members defined for the collection are not recorded"},
+			},
+			expectedMembers: []string{"alice-id", "bob-id", "carol-id"},
+		},
+		{
+			name:     "members removed from the definitions are revoked",
+			config:   config,
+			policies: map[string]string{PrefixedName("team"): teamPolicy},
+			groups: map[string]*vaultclient.Group{PrefixedName("team"): {
+				Name:            PrefixedName("team"),
+				Policies:        []string{PrefixedName("team")},
+				MemberEntityIDs: []string{"alice-id", "bob-id", "carol-id"},
+				Metadata:        map[string]string{managedMetadataKey: "true", definedMembersMetadataKey: "alice,bob,carol"},
+			}},
+			confirm: true,
+			expectedDrifts: []Drift{
+				{Collection: "team", Object: "group", Message: "members to remove: [carol]"},
+				{Collection: "team", Object: "group", Message: "members defined for the collection are not recorded"},
+			},
+			expectedMembers: []string{"alice-id", "bob-id"},
+		},
+		{
+			name:     "drifted objects are updated with confirm",
+			config:   config,
+			policies: map[string]string{},
+			groups:   map[string]*vaultclient.Group{},
+			confirm:  true,
+			expectedDrifts: []Drift{
+				{Collection: "team", Object: "policy", Message: "missing"},
+				{Collection: "team", Object: "group", Message: "missing"},
+			},
+		},
+		{
+			name:          "unknown member",
+			config:        &Config{Collections: []Collection{{Name: "team", Members: []string{"alice", "bob", "dave"}}}},
+			policies:      map[string]string{PrefixedName("team"): teamPolicy},
+			groups:        map[string]*vaultclient.Group{PrefixedName("team"): upToDateGroup()},
+			expectedError: errors.New("collection team: member dave has never logged into Vault"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeClient{policies: tc.policies, groups: tc.groups, users: users}
+			drifts, err := Reconcile(client, tc.config, prefix, tc.confirm)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedDrifts, drifts); diff != "" {
+				t.Errorf("unexpected drifts: %s", diff)
+			}
+			if !tc.confirm {
+				return
+			}
+			drifts, err = Reconcile(client, tc.config, prefix, false)
+			if err != nil {
+				t.Fatalf("unexpected error after update: %v", err)
+			}
+			if len(drifts) != 0 {
+				t.Errorf("expected no drift after update, got %v", drifts)
+			}
+			if tc.expectedMembers != nil {
+				if diff := cmp.Diff(tc.expectedMembers, client.groups[PrefixedName("team")].MemberEntityIDs); diff != "" {
+					t.Errorf("unexpected members: %s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// allowedWindowsStep wraps a test step and only executes it when the job
+// started within one of the windows the test allows. The start of the job is
+// checked rather than the start of the step, which only runs once its inputs
+// are built.
+type allowedWindowsStep struct {
+	windows []api.AllowedWindow
+	wrapped api.Step
+	started time.Time
+
+	skipped string
+}
+
+// AllowedWindowsStep wraps the step of a test restricted to windows. It is
+// created when ci-operator builds the graph of the job, which is the time the
+// job started.
+func AllowedWindowsStep(windows []api.AllowedWindow, wrapped api.Step) api.Step {
+	return &allowedWindowsStep{
+		windows: windows,
+		wrapped: wrapped,
+		started: time.Now(),
+	}
+}
+
+func (s *allowedWindowsStep) Inputs() (api.InputDefinition, error) {
+	return s.wrapped.Inputs()
+}
+
+func (s *allowedWindowsStep) Validate() error {
+	return s.wrapped.Validate()
+}
+
+func (s *allowedWindowsStep) Name() string                        { return s.wrapped.Name() }
+func (s *allowedWindowsStep) Description() string                 { return s.wrapped.Description() }
+func (s *allowedWindowsStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
+func (s *allowedWindowsStep) Creates() []api.StepLink             { return s.wrapped.Creates() }
+func (s *allowedWindowsStep) Provides() api.ParameterMap          { return s.wrapped.Provides() }
+func (s *allowedWindowsStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
+
+func (s *allowedWindowsStep) SubTests() []*junit.TestCase {
+	if s.skipped != "" {
+		return []*junit.TestCase{{
+			Name:        fmt.Sprintf("Run test %s", s.Name()),
+			SkipMessage: &junit.SkipMessage{Message: s.skipped},
+		}}
+	}
+	if subTests, ok := s.wrapped.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *allowedWindowsStep) Run(ctx context.Context) error {
+	for _, window := range s.windows {
+		in, err := window.Contains(s.started)
+		if err != nil {
+			return results.ForReason("checking_allowed_windows").ForError(err)
+		}
+		if in {
+			return s.wrapped.Run(ctx)
+		}
+	}
+	var windows []string
+	for _, window := range s.windows {
+		windows = append(windows, window.String())
+	}
+	s.skipped = fmt.Sprintf("job started at %s, outside of the allowed windows of the test: %s", s.started.UTC().Format(time.RFC3339), strings.Join(windows, ", "))
+	logrus.Warnf("Skipping test %s: %s", s.Name(), s.skipped)
+	return nil
+}
@@ -0,0 +1,93 @@
+package steps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+type fakeSubtestStep struct {
+	api.Step
+	ran bool
+}
+
+func (s *fakeSubtestStep) Name() string { return "e2e" }
+
+func (s *fakeSubtestStep) Run(context.Context) error {
+	s.ran = true
+	return nil
+}
+
+func (s *fakeSubtestStep) SubTests() []*junit.TestCase {
+	return []*junit.TestCase{{Name: "Run test e2e"}}
+}
+
+func TestAllowedWindowsStep(t *testing.T) {
+	windows := []api.AllowedWindow{
+		// Saturdays between 22:00 and 02:00
+		{Start: "0 22 * * 6", Duration: prowapi.Duration{Duration: 4 * time.Hour}},
+		// daily between 12:00 and 12:30
+		{Start: "0 12 * * *", Duration: prowapi.Duration{Duration: 30 * time.Minute}},
+	}
+	for _, tc := range []struct {
+		name             string
+		started          time.Time
+		expectedRun      bool
+		expectedSubTests []*junit.TestCase
+	}{
+		{
+			name:             "inside the first window",
+			started:          time.Date(2024, time.June, 2, 1, 0, 0, 0, time.UTC),
+			expectedRun:      true,
+			expectedSubTests: []*junit.TestCase{{Name: "Run test e2e"}},
+		},
+		{
+			name:             "inside the second window",
+			started:          time.Date(2024, time.June, 4, 12, 15, 0, 0, time.UTC),
+			expectedRun:      true,
+			expectedSubTests: []*junit.TestCase{{Name: "Run test e2e"}},
+		},
+		{
+			name:             "inside the second window in another time zone",
+			started:          time.Date(2024, time.June, 4, 14, 15, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			expectedRun:      true,
+			expectedSubTests: []*junit.TestCase{{Name: "Run test e2e"}},
+		},
+		{
+			name:             "at the end of the second window",
+			started:          time.Date(2024, time.June, 4, 12, 30, 0, 0, time.UTC),
+			expectedRun:      true,
+			expectedSubTests: []*junit.TestCase{{Name: "Run test e2e"}},
+		},
+		{
+			name:    "outside of the windows",
+			started: time.Date(2024, time.June, 2, 3, 0, 0, 0, time.UTC),
+			expectedSubTests: []*junit.TestCase{{
+				Name:        "Run test e2e",
+				SkipMessage: &junit.SkipMessage{Message: "job started at 2024-06-02T03:00:00Z, outside of the allowed windows of the test: 0 22 * * 6 for 4h0m0s, 0 12 * * * for 30m0s"},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := &fakeSubtestStep{}
+			step := AllowedWindowsStep(windows, wrapped).(*allowedWindowsStep)
+			step.started = tc.started
+			if err := step.Run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if wrapped.ran != tc.expectedRun {
+				t.Errorf("expected wrapped step to run: %t, ran: %t", tc.expectedRun, wrapped.ran)
+			}
+			if diff := cmp.Diff(tc.expectedSubTests, step.SubTests()); diff != "" {
+				t.Errorf("unexpected subtests: %s", diff)
+			}
+		})
+	}
+}
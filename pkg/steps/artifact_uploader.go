@@ -0,0 +1,168 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/io/providers"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// ArtifactUploader stores the artifacts copied out of the artifacts
+// containers of pods.
+type ArtifactUploader interface {
+	// Writer opens the artifact at the path, relative to the artifact
+	// directory of the job.
+	Writer(ctx context.Context, path string) (io.WriteCloser, error)
+}
+
+// directoryArtifactUploader stages the artifacts in a local directory, which
+// is uploaded along with the rest of the artifacts of ci-operator.
+type directoryArtifactUploader struct {
+	dir string
+}
+
+func (u directoryArtifactUploader) Writer(_ context.Context, p string) (io.WriteCloser, error) {
+	target := filepath.Join(u.dir, p)
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return nil, fmt.Errorf("could not create target directory %s for artifacts: %w", filepath.Dir(target), err)
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return nil, fmt.Errorf("could not create target file %s for artifact: %w", target, err)
+	}
+	return f, nil
+}
+
+// bucketArtifactUploader streams the artifacts straight into the artifact
+// directory of the job in its GCS or S3 bucket.
+type bucketArtifactUploader struct {
+	opener prowio.Opener
+	root   string
+}
+
+func (u bucketArtifactUploader) Writer(ctx context.Context, p string) (io.WriteCloser, error) {
+	w, err := u.opener.Writer(ctx, fmt.Sprintf("%s/%s", u.root, p))
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s/%s for writing: %w", u.root, p, err)
+	}
+	return w, nil
+}
+
+// NewBucketArtifactUploader returns an uploader writing into the artifact
+// directory of the job in the bucket its decoration configuration uploads to,
+// where the sidecar would upload the artifacts staged on local disk.
+func NewBucketArtifactUploader(opener prowio.Opener, jobSpec *api.JobSpec) (ArtifactUploader, error) {
+	root, err := jobArtifactsPath(jobSpec)
+	if err != nil {
+		return nil, err
+	}
+	return bucketArtifactUploader{opener: opener, root: root}, nil
+}
+
+// ArtifactReader reads the artifacts the sidecars of step pods uploaded.
+type ArtifactReader interface {
+	// ReadJUnit reads the junit files under the path, relative to the
+	// artifact directory of the job, keyed by their path relative to it.
+	ReadJUnit(ctx context.Context, path string) (map[string][]byte, error)
+}
+
+// bucketArtifactReader reads the artifacts from the artifact directory of the
+// job in its GCS or S3 bucket.
+type bucketArtifactReader struct {
+	opener prowio.Opener
+	root   string
+}
+
+func (r bucketArtifactReader) ReadJUnit(ctx context.Context, p string) (map[string][]byte, error) {
+	dir := fmt.Sprintf("%s/%s/", r.root, p)
+	// listed objects are named relative to the bucket
+	_, _, prefix, err := providers.ParseStoragePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := r.opener.Iterator(ctx, dir, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s: %w", dir, err)
+	}
+	files := map[string][]byte{}
+	for {
+		attrs, err := objects.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not list %s: %w", dir, err)
+		}
+		if attrs.IsDir || !junit.IsJUnitFile(attrs.Name) {
+			continue
+		}
+		rel := strings.TrimPrefix(attrs.Name, prefix)
+		raw, err := prowio.ReadContent(ctx, logrus.WithField("test", p), r.opener, dir+rel)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", rel, err)
+		}
+		files[rel] = raw
+	}
+}
+
+// NewBucketArtifactReader returns a reader of the artifact directory of the
+// job in the bucket its decoration configuration uploads to.
+func NewBucketArtifactReader(opener prowio.Opener, jobSpec *api.JobSpec) (ArtifactReader, error) {
+	root, err := jobArtifactsPath(jobSpec)
+	if err != nil {
+		return nil, err
+	}
+	return bucketArtifactReader{opener: opener, root: root}, nil
+}
+
+// jobArtifactsPath determines the location of the artifact directory of the
+// job in its bucket, like the sidecar does.
+func jobArtifactsPath(jobSpec *api.JobSpec) (string, error) {
+	if jobSpec.DecorationConfig == nil || jobSpec.DecorationConfig.GCSConfiguration == nil {
+		return "", errors.New("the job has no GCS configuration")
+	}
+	config := jobSpec.DecorationConfig.GCSConfiguration
+	if config.Bucket == "" {
+		return "", errors.New("the job has no bucket configured")
+	}
+	var builder gcs.RepoPathBuilder
+	switch config.PathStrategy {
+	case prowapi.PathStrategyExplicit:
+		builder = gcs.NewExplicitRepoPathBuilder()
+	case prowapi.PathStrategyLegacy:
+		builder = gcs.NewLegacyRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+	case prowapi.PathStrategySingle:
+		builder = gcs.NewSingleDefaultRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+	default:
+		return "", fmt.Errorf("unknown path strategy %q", config.PathStrategy)
+	}
+	bucket := config.Bucket
+	if !strings.Contains(bucket, "://") {
+		bucket = "gs://" + bucket
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(bucket, "/"), path.Join(config.PathPrefix, gcs.PathForSpec(&jobSpec.JobSpec, builder), "artifacts")), nil
+}
+
+// prefixedArtifactUploader stores the artifacts under a sub-directory.
+type prefixedArtifactUploader struct {
+	ArtifactUploader
+	prefix string
+}
+
+func (u prefixedArtifactUploader) Writer(ctx context.Context, p string) (io.WriteCloser, error) {
+	return u.ArtifactUploader.Writer(ctx, path.Join(u.prefix, p))
+}
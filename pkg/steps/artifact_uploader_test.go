@@ -0,0 +1,215 @@
+package steps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowio "sigs.k8s.io/prow/pkg/io"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+// memoryArtifactUploader keeps the artifacts closed successfully in memory.
+type memoryArtifactUploader struct {
+	lock  sync.Mutex
+	files map[string]string
+}
+
+func (u *memoryArtifactUploader) Writer(_ context.Context, path string) (io.WriteCloser, error) {
+	return &memoryArtifactWriter{uploader: u, path: path}, nil
+}
+
+type memoryArtifactWriter struct {
+	bytes.Buffer
+	uploader *memoryArtifactUploader
+	path     string
+}
+
+func (w *memoryArtifactWriter) Close() error {
+	w.uploader.lock.Lock()
+	defer w.uploader.lock.Unlock()
+	w.uploader.files[w.path] = w.String()
+	return nil
+}
+
+func TestArtifactWorkerStreamsToUploader(t *testing.T) {
+	tmp := t.TempDir()
+	pod := "pod"
+	podClient := &flakyArtifactsPodClient{
+		FakePodClient: &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				&coreapi.Pod{
+					ObjectMeta: meta.ObjectMeta{Name: pod, Namespace: "namespace"},
+					Status: coreapi.PodStatus{
+						ContainerStatuses: []coreapi.ContainerStatus{{
+							Name:  "artifacts",
+							State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+						}},
+					},
+				}).Build()),
+			},
+			Namespace: "namespace",
+			Name:      pod,
+		},
+		files: map[string]string{"a.txt": "first", "dir/b.txt": "second"},
+	}
+	uploader := &memoryArtifactUploader{files: map[string]string{}}
+	w := NewArtifactWorker(podClient, tmp, "namespace",
+		WithArtifactUploader(prefixedArtifactUploader{ArtifactUploader: uploader, prefix: "template"}),
+		WithDownloadBackoff(wait.Backoff{Steps: 3, Duration: time.Millisecond}))
+	w.CollectFromPod(pod, []string{"container"}, nil)
+	w.Complete(pod)
+	select {
+	case <-w.Done(pod):
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for artifact worker to finish")
+	}
+	expected := map[string]string{"template/a.txt": "first", "template/dir/b.txt": "second"}
+	if diff := cmp.Diff(expected, uploader.files); diff != "" {
+		t.Errorf("unexpected artifacts: %s", diff)
+	}
+	files, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Name() != "container-logs" && f.Name() != api.ArtifactManifestFilename {
+			t.Errorf("expected artifacts not to be staged in the artifact directory, found %s", f.Name())
+		}
+	}
+}
+
+func TestJobArtifactsPath(t *testing.T) {
+	gcsConfiguration := func(bucket string) *prowapi.DecorationConfig {
+		return &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: bucket, PathStrategy: prowapi.PathStrategyExplicit}}
+	}
+	for _, tc := range []struct {
+		name          string
+		spec          downwardapi.JobSpec
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "periodic uploading to a GCS bucket",
+			spec:     downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "periodic", BuildID: "1", DecorationConfig: gcsConfiguration("test-platform-results")},
+			expected: "gs://test-platform-results/logs/periodic/1/artifacts",
+		},
+		{
+			name: "presubmit uploading to an S3 bucket",
+			spec: downwardapi.JobSpec{
+				Type:             prowapi.PresubmitJob,
+				Job:              "pull-ci-org-repo-main-e2e",
+				BuildID:          "2",
+				Refs:             &prowapi.Refs{Org: "org", Repo: "repo", Pulls: []prowapi.Pull{{Number: 3}}},
+				DecorationConfig: gcsConfiguration("s3://results/"),
+			},
+			expected: "s3://results/pr-logs/pull/org_repo/3/pull-ci-org-repo-main-e2e/2/artifacts",
+		},
+		{
+			name:          "no GCS configuration",
+			spec:          downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "periodic", BuildID: "1"},
+			expectedError: errors.New("the job has no GCS configuration"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := jobArtifactsPath(&api.JobSpec{JobSpec: tc.spec})
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected path: %s", diff)
+			}
+		})
+	}
+}
+
+// memoryOpener serves the objects of a GCS bucket from memory.
+type memoryOpener struct {
+	prowio.Opener
+	bucket  string
+	objects map[string]string
+}
+
+func (o *memoryOpener) Iterator(_ context.Context, prefix, _ string) (prowio.ObjectIterator, error) {
+	var names []string
+	for name := range o.objects {
+		if strings.HasPrefix(fmt.Sprintf("gs://%s/%s", o.bucket, name), prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return &memoryObjectIterator{names: names}, nil
+}
+
+func (o *memoryOpener) Reader(_ context.Context, p string) (prowio.ReadCloser, error) {
+	data, ok := o.objects[strings.TrimPrefix(p, fmt.Sprintf("gs://%s/", o.bucket))]
+	if !ok {
+		return nil, fmt.Errorf("%s does not exist", p)
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+type memoryObjectIterator struct {
+	names []string
+}
+
+func (i *memoryObjectIterator) Next(_ context.Context) (prowio.ObjectAttributes, error) {
+	if len(i.names) == 0 {
+		return prowio.ObjectAttributes{}, io.EOF
+	}
+	name := i.names[0]
+	i.names = i.names[1:]
+	return prowio.ObjectAttributes{Name: name, ObjName: path.Base(name)}, nil
+}
+
+func TestBucketArtifactReaderReadJUnit(t *testing.T) {
+	opener := &memoryOpener{bucket: "test-platform-results", objects: map[string]string{
+		"logs/periodic/1/artifacts/e2e/install/artifacts/junit_install.xml":  "install",
+		"logs/periodic/1/artifacts/e2e/test/artifacts/results/junit_e2e.xml": "e2e",
+		"logs/periodic/1/artifacts/e2e/test/artifacts/results/e2e.log":       "not junit",
+		"logs/periodic/1/artifacts/e2e/merged-results.xml":                   "summary",
+		"logs/periodic/1/artifacts/e2e-upgrade/test/artifacts/junit_e2e.xml": "other test",
+	}}
+	jobSpec := &api.JobSpec{JobSpec: downwardapi.JobSpec{
+		Type:             prowapi.PeriodicJob,
+		Job:              "periodic",
+		BuildID:          "1",
+		DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "test-platform-results", PathStrategy: prowapi.PathStrategyExplicit}},
+	}}
+	reader, err := NewBucketArtifactReader(opener, jobSpec)
+	if err != nil {
+		t.Fatalf("failed to create the reader: %v", err)
+	}
+	files, err := reader.ReadJUnit(context.Background(), "e2e")
+	if err != nil {
+		t.Fatalf("failed to read the junit files: %v", err)
+	}
+	expected := map[string][]byte{
+		"install/artifacts/junit_install.xml":  []byte("install"),
+		"test/artifacts/results/junit_e2e.xml": []byte("e2e"),
+	}
+	if diff := cmp.Diff(expected, files); diff != "" {
+		t.Errorf("unexpected files: %s", diff)
+	}
+}
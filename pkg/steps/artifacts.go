@@ -2,8 +2,13 @@ package steps
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/remotecommand"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -47,6 +53,10 @@ const (
 	AnnotationSaveContainerLogs = "ci-operator.openshift.io/save-container-logs"
 	// artifactEnv is the env var in which we hold the artifact dir for users
 	artifactEnv = "ARTIFACT_DIR"
+	// failureLogTimeout bounds the time spent fetching the log of a failed
+	// container, so an unresponsive API server does not hold up the test
+	// results.
+	failureLogTimeout = time.Minute
 )
 
 // TestCaseNotifier allows a caller to generate per container JUnit test
@@ -58,13 +68,35 @@ const (
 type TestCaseNotifier struct {
 	nested  util.ContainerNotifier
 	lastPod *corev1.Pod
+
+	podClient       kubernetes.PodClient
+	failureLogLimit int64
+}
+
+// TestCaseNotifierOption configures a TestCaseNotifier.
+type TestCaseNotifierOption func(*TestCaseNotifier)
+
+// WithFailureLogs attaches up to limit bytes of the end of the log of failed
+// containers to their test case, censored with the secrets in the namespace
+// of the pod. A limit that is not positive leaves the notifier unchanged.
+func WithFailureLogs(podClient kubernetes.PodClient, limit int64) TestCaseNotifierOption {
+	return func(n *TestCaseNotifier) {
+		if limit > 0 {
+			n.podClient = podClient
+			n.failureLogLimit = limit
+		}
+	}
 }
 
 // NewTestCaseNotifier wraps the provided ContainerNotifier and will
 // create JUnit TestCase records for each container in the most recent
 // pod to have completed.
-func NewTestCaseNotifier(nested util.ContainerNotifier) *TestCaseNotifier {
-	return &TestCaseNotifier{nested: nested}
+func NewTestCaseNotifier(nested util.ContainerNotifier, opts ...TestCaseNotifierOption) *TestCaseNotifier {
+	n := &TestCaseNotifier{nested: nested}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 func (n *TestCaseNotifier) Notify(pod *coreapi.Pod, containerName string) {
@@ -76,10 +108,12 @@ func (n *TestCaseNotifier) Complete(podName string)             { n.nested.Compl
 func (n *TestCaseNotifier) Done(podName string) <-chan struct{} { return n.nested.Done(podName) }
 
 // SubTests returns one junit test for each terminated container with a name
-// in the annotation 'ci-operator.openshift.io/container-sub-tests' in the pod.
+// in the annotation 'ci-operator.openshift.io/container-sub-tests' in the pod
+// and a skipped one for each of those containers that never started.
 // Invoking SubTests clears the last pod, so subsequent calls will return no
-// tests unless Notify() has been called in the meantime.
-func (n *TestCaseNotifier) SubTests(prefix string) []*junit.TestCase {
+// tests unless Notify() has been called in the meantime. The properties are
+// set on each of the tests.
+func (n *TestCaseNotifier) SubTests(prefix string, properties ...*junit.TestSuiteProperty) []*junit.TestCase {
 	if n.lastPod == nil {
 		return nil
 	}
@@ -109,11 +143,19 @@ func (n *TestCaseNotifier) SubTests(prefix string) []*junit.TestCase {
 
 	var lastFinished time.Time
 	var tests []*junit.TestCase
+	started := sets.New[string]()
 	for _, status := range statuses {
+		if !names.Has(status.Name) {
+			continue
+		}
+		if status.State.Running != nil || status.LastTerminationState.Terminated != nil {
+			started.Insert(status.Name)
+		}
 		t := status.State.Terminated
-		if t == nil || !names.Has(status.Name) {
+		if t == nil {
 			continue
 		}
+		started.Insert(status.Name)
 		if lastFinished.Before(t.StartedAt.Time) {
 			lastFinished = t.StartedAt.Time
 		}
@@ -124,17 +166,121 @@ func (n *TestCaseNotifier) SubTests(prefix string) []*junit.TestCase {
 		lastFinished = t.FinishedAt.Time
 		if t.ExitCode != 0 {
 			test.FailureOutput = &junit.FailureOutput{
-				Output: t.Message,
+				Output: n.failureOutput(pod, status.Name, t.Message),
 			}
 		}
 		tests = append(tests, test)
 	}
+	waiting := map[string]string{}
+	for _, status := range statuses {
+		if status.State.Waiting != nil {
+			waiting[status.Name] = status.State.Waiting.Reason
+		}
+	}
+	for _, name := range sets.List(names.Difference(started)) {
+		message := "container never started"
+		if reason := waiting[name]; reason != "" {
+			message = fmt.Sprintf("%s: %s", message, reason)
+		}
+		tests = append(tests, &junit.TestCase{
+			Name:        fmt.Sprintf("%scontainer %s", prefix, name),
+			SkipMessage: &junit.SkipMessage{Message: message},
+		})
+	}
 	sort.Slice(tests, func(i, j int) bool {
 		return tests[i].Name < tests[j].Name
 	})
+	for _, test := range tests {
+		for _, property := range properties {
+			property := *property
+			test.Properties = append(test.Properties, &property)
+		}
+	}
 	return tests
 }
 
+// failureOutput is the termination message of the failed container followed
+// by the end of its log, if the notifier fetches it.
+func (n *TestCaseNotifier) failureOutput(pod *coreapi.Pod, container, message string) string {
+	if n.failureLogLimit <= 0 {
+		return message
+	}
+	logger := logrus.WithFields(logrus.Fields{"pod": pod.Name, "container": container})
+	ctx, cancel := context.WithTimeout(context.Background(), failureLogTimeout)
+	defer cancel()
+	excerpt, err := n.logTail(ctx, pod.Namespace, pod.Name, container)
+	if err != nil {
+		logger.WithError(err).Debug("Could not attach the log of the failed container to its test case.")
+		return message
+	}
+	if excerpt == "" {
+		return message
+	}
+	if message == "" {
+		return excerpt
+	}
+	return message + "\n\n" + excerpt
+}
+
+// logTail fetches the end of the log of the container, censored with the
+// secrets in the namespace. Every line holds at least its newline, so the
+// last lines up to the limit hold the last bytes up to the limit and the rest
+// of the log does not need to be streamed.
+func (n *TestCaseNotifier) logTail(ctx context.Context, namespace, name, container string) (string, error) {
+	tailLines := n.failureLogLimit
+	logs, err := n.podClient.StreamLogs(ctx, namespace, name, &coreapi.PodLogOptions{Container: container, TailLines: &tailLines})
+	if err != nil {
+		return "", fmt.Errorf("could not stream the log: %w", err)
+	}
+	defer logs.Close()
+	raw, truncated, err := ReadTail(logs, int(n.failureLogLimit))
+	if err != nil {
+		return "", fmt.Errorf("could not read the log: %w", err)
+	}
+	censor, err := NamespaceCensor(ctx, n.podClient, namespace)
+	if err != nil {
+		return "", err
+	}
+	censor.Censor(&raw)
+	if truncated {
+		return fmt.Sprintf("Last %d bytes of the log:\n%s", len(raw), raw), nil
+	}
+	return string(raw), nil
+}
+
+// ReadTail reads the last bytes of a log, up to the limit, and reports whether
+// the log was longer. The first line of a truncated log is dropped: it was cut
+// and may hold the end of a secret a censor would not recognize.
+func ReadTail(r io.Reader, limit int) ([]byte, bool, error) {
+	tail := &tailBuffer{limit: limit}
+	if _, err := io.Copy(tail, r); err != nil {
+		return nil, false, err
+	}
+	raw := tail.data
+	if tail.truncated {
+		if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+			raw = raw[i+1:]
+		}
+	}
+	return raw, tail.truncated, nil
+}
+
+// tailBuffer keeps the last bytes written to it, up to its limit.
+type tailBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if excess := len(b.data) - b.limit; excess > 0 {
+		b.data = append(b.data[:0], b.data[excess:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
 func waitForContainer(podClient kubernetes.PodClient, ns, name, containerName string) error {
 	logrus.WithFields(logrus.Fields{
 		"namespace": ns,
@@ -164,21 +310,226 @@ func waitForContainer(podClient kubernetes.PodClient, ns, name, containerName st
 	return kubernetes.WaitForConditionOnObject(ctx, podClient, ctrlruntimeclient.ObjectKey{Namespace: ns, Name: name}, &corev1.PodList{}, &corev1.Pod{}, evaluatorFunc, 300*5*time.Second)
 }
 
-func copyArtifacts(podClient kubernetes.PodClient, into, ns, name, containerName string, paths []string) error {
-	logrus.Tracef("Copying artifacts from %s into %s", name, into)
-	var args []string
-	for _, s := range paths {
-		args = append(args, "-C", s, ".")
+// artifactChecksums lists the files under the root in the container with
+// their SHA256 checksums, keyed by the path relative to the root.
+func artifactChecksums(podClient kubernetes.PodClient, ns, name, containerName, root string) (map[string]string, error) {
+	e, err := podClient.Exec(ns, name, &coreapi.PodExecOptions{
+		Container: containerName,
+		Stdout:    true,
+		Stderr:    true,
+		Command:   []string{"/bin/sh", "-c", fmt.Sprintf("cd %s && find . -type f -exec sha256sum {} +", root)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	if err := e.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: os.Stderr}); err != nil {
+		return nil, fmt.Errorf("could not run remote command: %w", err)
+	}
+	return parseArtifactChecksums(stdout.String())
+}
+
+// parseArtifactChecksums parses the output of sha256sum. The names of files
+// containing a backslash or a newline are escaped, and their line starts with
+// a backslash.
+func parseArtifactChecksums(output string) (map[string]string, error) {
+	checksums := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(line, "\\")
+		sum, file, found := strings.Cut(strings.TrimPrefix(line, "\\"), "  ")
+		if !found {
+			return nil, fmt.Errorf("invalid checksum line: %q", line)
+		}
+		if escaped {
+			var err error
+			if file, err = unescapeChecksumName(file); err != nil {
+				return nil, fmt.Errorf("invalid checksum line: %q: %w", line, err)
+			}
+		}
+		checksums[path.Clean(file)] = sum
 	}
+	return checksums, nil
+}
+
+// unescapeChecksumName reverts the escaping of a file name by sha256sum.
+func unescapeChecksumName(name string) (string, error) {
+	var unescaped strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			unescaped.WriteByte(name[i])
+			continue
+		}
+		i++
+		if i == len(name) {
+			return "", errors.New("name ends with an escape")
+		}
+		switch name[i] {
+		case '\\':
+			unescaped.WriteByte('\\')
+		case 'n':
+			unescaped.WriteByte('\n')
+		case 'r':
+			unescaped.WriteByte('\r')
+		default:
+			return "", fmt.Errorf("unknown escape sequence \\%c", name[i])
+		}
+	}
+	return unescaped.String(), nil
+}
+
+// artifactQuota bounds the total size of the artifacts copied for a step, it
+// is shared by the downloads from all of its pods. A nil quota is unlimited.
+type artifactQuota struct {
+	lock      sync.Mutex
+	limit     int64
+	remaining int64
+}
 
+func newArtifactQuota(limit int64) *artifactQuota {
+	if limit <= 0 {
+		return nil
+	}
+	return &artifactQuota{limit: limit, remaining: limit}
+}
+
+// take reserves up to n bytes and returns how many were reserved.
+func (q *artifactQuota) take(n int64) int64 {
+	if q == nil {
+		return n
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if n > q.remaining {
+		n = q.remaining
+	}
+	q.remaining -= n
+	return n
+}
+
+// release returns bytes which were reserved but not stored.
+func (q *artifactQuota) release(n int64) {
+	if q == nil {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.remaining += n
+}
+
+func (q *artifactQuota) exhausted() bool {
+	if q == nil {
+		return false
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.remaining == 0
+}
+
+// copyArtifacts copies the files under the root in the container into the
+// uploader, verifying them against the checksums computed in the container.
+// Transient failures are retried with the backoff, and only the files which
+// were not copied yet are requested again. Once the quota is exhausted, the
+// remaining files are left out. The files stored are returned even when the
+// copy fails.
+func copyArtifacts(podClient kubernetes.PodClient, into ArtifactUploader, ns, name, containerName, root string, backoff wait.Backoff, quota *artifactQuota) (map[string]api.ArtifactManifestFile, error) {
+	logger := logrus.WithFields(logrus.Fields{"pod": name, "container": containerName})
+	logger.Tracef("Copying artifacts from %s", name)
+	var remaining map[string]string
+	if err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		checksums, err := artifactChecksums(podClient, ns, name, containerName, root)
+		if err != nil {
+			logger.WithError(err).Debug("Failed to list artifacts, retrying.")
+			return false, nil
+		}
+		remaining = checksums
+		return true, nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not list artifacts: %w", err)
+	}
+
+	stored := map[string]api.ArtifactManifestFile{}
+	var size int64
+	var lastErr error
+	first := true
+	if err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		var files []string
+		if !first {
+			files = sets.List(sets.KeySet(remaining))
+		}
+		first = false
+		copied, err := copyArtifactFiles(podClient, into, ns, name, containerName, root, files, quota)
+		for file, entry := range copied {
+			expected, listed := remaining[file]
+			if !listed {
+				continue
+			}
+			if !entry.Truncated && entry.SHA256 != expected {
+				logger.Debugf("Checksum of artifact %s does not match, expected %s, got %s.", file, expected, entry.SHA256)
+				// The file is copied again, replacing this copy
+				quota.release(entry.Size)
+				continue
+			}
+			stored[file] = entry
+			size += entry.Size
+			delete(remaining, file)
+		}
+		if quota.exhausted() {
+			for file := range remaining {
+				stored[file] = api.ArtifactManifestFile{Name: file, Truncated: true}
+			}
+			logger.Warnf("Artifacts exceed the limit of %d bytes, %d files were left out.", quota.limit, len(remaining))
+			remaining = nil
+			lastErr = nil
+			return true, nil
+		}
+		if err != nil {
+			lastErr = err
+			logger.WithError(err).Debugf("Failed to copy artifacts, %d files remaining.", len(remaining))
+			return false, nil
+		}
+		lastErr = nil
+		return len(remaining) == 0, nil
+	}); err != nil {
+		if lastErr != nil {
+			err = lastErr
+		}
+		return stored, fmt.Errorf("could not copy %d artifacts: %w", len(remaining), err)
+	}
+
+	// If we're updating a substantial amount of artifacts, let the user know as a way to
+	// indicate why the step took a long amount of time. Conversely, if we just got a small
+	// number of files this is just noise and can be omitted to not distract from other steps.
+	if size > 1*1000*1000 {
+		logrus.Debugf("Copied %0.2fMB of artifacts from %s", float64(size)/1000000, name)
+	}
+
+	return stored, nil
+}
+
+// copyArtifactFiles streams a tarball of the files under the root in the
+// container into the uploader, all of them if none are specified, without
+// staging it on disk. Files are truncated to fit in the quota, and the stream
+// stops once it is exhausted. The files written are returned even if the
+// stream fails, so only the missing files need to be copied again.
+func copyArtifactFiles(podClient kubernetes.PodClient, into ArtifactUploader, ns, name, containerName, root string, files []string, quota *artifactQuota) (map[string]api.ArtifactManifestFile, error) {
+	command := []string{"tar", "czf", "-", "-C", root, "."}
+	var stdin io.Reader
+	if len(files) > 0 {
+		command = []string{"tar", "czf", "-", "-C", root, "-T", "-"}
+		stdin = strings.NewReader(strings.Join(files, "\n") + "\n")
+	}
 	e, err := podClient.Exec(ns, name, &coreapi.PodExecOptions{
 		Container: containerName,
+		Stdin:     stdin != nil,
 		Stdout:    true,
 		Stderr:    true,
-		Command:   append([]string{"tar", "czf", "-"}, args...),
+		Command:   command,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	r, w := io.Pipe()
 	defer func() {
@@ -189,7 +540,7 @@ func copyArtifacts(podClient kubernetes.PodClient, into, ns, name, containerName
 	go func() {
 		err := e.Stream(remotecommand.StreamOptions{
 			Stdout: w,
-			Stdin:  nil,
+			Stdin:  stdin,
 			Stderr: os.Stderr,
 		})
 		if err := w.CloseWithError(err); err != nil {
@@ -197,10 +548,10 @@ func copyArtifacts(podClient kubernetes.PodClient, into, ns, name, containerName
 		}
 	}()
 
-	size := int64(0)
+	copied := map[string]api.ArtifactManifestFile{}
 	gr, err := gzip.NewReader(r)
 	if err != nil {
-		return fmt.Errorf("could not read gzipped artifacts: %w", err)
+		return copied, fmt.Errorf("could not read gzipped artifacts: %w", err)
 	}
 	tr := tar.NewReader(gr)
 	for {
@@ -209,45 +560,55 @@ func copyArtifacts(podClient kubernetes.PodClient, into, ns, name, containerName
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("could not read artifact tarball: %w", err)
+			return copied, fmt.Errorf("could not read artifact tarball: %w", err)
 		}
 		name := path.Clean(h.Name)
 		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
 			continue
 		}
-		p := filepath.Join(into, name)
 		if h.FileInfo().IsDir() {
-			if err := os.MkdirAll(p, 0750); err != nil {
-				return fmt.Errorf("could not create target directory %s for artifacts: %w", p, err)
-			}
 			continue
 		}
 		if len(h.Linkname) > 0 {
-			fmt.Fprintf(os.Stderr, "warn: ignoring link when copying artifacts to %s: %s\n", into, h.Name)
+			fmt.Fprintf(os.Stderr, "warn: ignoring link when copying artifacts: %s\n", h.Name)
 			continue
 		}
-		f, err := os.Create(p)
-		if err != nil {
-			return fmt.Errorf("could not create target file %s for artifact: %w", p, err)
+		n := quota.take(h.Size)
+		if n == 0 && h.Size > 0 {
+			break
 		}
-		if _, err := io.Copy(f, tr); err != nil {
-			f.Close()
-			return fmt.Errorf("could not copy contents of file %s: %w", p, err)
+		entry, err := copyArtifactFile(into, name, tr, n)
+		if err != nil {
+			quota.release(n)
+			return copied, err
 		}
-		if err := f.Close(); err != nil {
-			return fmt.Errorf("could not close copied file %s: %w", p, err)
+		if n < h.Size {
+			entry.Truncated = true
+			entry.OriginalSize = h.Size
 		}
-		size += h.Size
+		copied[name] = entry
 	}
+	return copied, nil
+}
 
-	// If we're updating a substantial amount of artifacts, let the user know as a way to
-	// indicate why the step took a long amount of time. Conversely, if we just got a small
-	// number of files this is just noise and can be omitted to not distract from other steps.
-	if size > 1*1000*1000 {
-		logrus.Debugf("Copied %0.2fMB of artifacts from %s to %s", float64(size)/1000000, name, into)
+// copyArtifactFile stores the first n bytes of the artifact.
+func copyArtifactFile(into ArtifactUploader, name string, from io.Reader, n int64) (api.ArtifactManifestFile, error) {
+	entry := api.ArtifactManifestFile{Name: name}
+	f, err := into.Writer(context.TODO(), name)
+	if err != nil {
+		return entry, err
 	}
-
-	return nil
+	hash := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(f, hash), from, n); err != nil {
+		f.Close()
+		return entry, fmt.Errorf("could not copy contents of file %s: %w", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return entry, fmt.Errorf("could not close copied file %s: %w", name, err)
+	}
+	entry.Size = n
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, nil
 }
 
 func removeFile(podClient kubernetes.PodClient, ns, name, containerName string, paths []string) error {
@@ -371,7 +732,9 @@ type podContainersMap map[string]sets.Set[string]
 
 // ArtifactWorker tracks pods that have completed and have an 'artifacts' container
 // in them and will extract files from the container to a local directory. It also
-// gathers container logs on all pods.
+// gathers container logs on all pods. Artifacts are downloaded from multiple pods
+// concurrently and the channel returned by Done is closed once the download from
+// the pod has finished.
 //
 // This worker is thread safe and may be invoked in parallel.
 type ArtifactWorker struct {
@@ -379,6 +742,14 @@ type ArtifactWorker struct {
 	podClient kubernetes.PodClient
 	namespace string
 
+	concurrency int
+	backoff     wait.Backoff
+	// uploader stores the artifacts, the container logs and the manifest
+	// are always written into the directory
+	uploader ArtifactUploader
+	// quota bounds the size of the artifacts of all pods
+	quota *artifactQuota
+
 	// Processing this requires the lock, so it must not be held
 	// when writing into it.
 	podsToDownload chan string
@@ -387,22 +758,128 @@ type ArtifactWorker struct {
 	remaining    podWaitRecord
 	required     podContainersMap
 	hasArtifacts sets.Set[string]
+	// queued holds the pods which were handed to the downloaders, each pod is
+	// downloaded only once
+	queued sets.Set[string]
+	closed bool
+	// manifest holds the artifacts copied from all pods
+	manifest map[string]api.ArtifactManifestFile
+}
+
+const defaultArtifactDownloadConcurrency = 4
+
+// defaultArtifactDownloadBackoff bounds the attempts at downloading the artifacts
+// of a pod when the copy fails for transient reasons.
+var defaultArtifactDownloadBackoff = wait.Backoff{Steps: 5, Duration: 2 * time.Second, Factor: 2, Jitter: 0.1}
+
+type ArtifactWorkerOption func(*ArtifactWorker)
+
+// WithDownloadConcurrency sets the number of pods artifacts are downloaded from
+// at the same time.
+func WithDownloadConcurrency(concurrency int) ArtifactWorkerOption {
+	return func(w *ArtifactWorker) {
+		if concurrency > 0 {
+			w.concurrency = concurrency
+		}
+	}
+}
+
+// WithDownloadBackoff sets the backoff used to retry downloads failing for
+// transient reasons.
+func WithDownloadBackoff(backoff wait.Backoff) ArtifactWorkerOption {
+	return func(w *ArtifactWorker) {
+		w.backoff = backoff
+	}
+}
+
+// ArtifactOptions configures how the artifacts of the pods of a step are
+// gathered.
+type ArtifactOptions struct {
+	// Uploader streams the artifacts instead of staging them in the artifact
+	// directory, if set.
+	Uploader ArtifactUploader
+	// SizeLimit bounds the total size of the artifacts of each step in
+	// bytes, unlimited when zero.
+	SizeLimit int64
+	// LiveLogs receives the logs of multi-stage steps while they run, if
+	// set.
+	LiveLogs ArtifactUploader
+	// StepArtifacts reads the artifacts the sidecars of multi-stage steps
+	// uploaded, if set.
+	StepArtifacts ArtifactReader
+	// FailureLogLimit is how many bytes of the end of the log of failed
+	// containers are attached to their junit test case, none when zero.
+	FailureLogLimit int64
+	// DownloadConcurrency is the number of pods artifacts are downloaded
+	// from at the same time, the default when zero.
+	DownloadConcurrency int
+	// DownloadAttempts bounds the attempts at downloading the artifacts of
+	// a pod, the default when zero.
+	DownloadAttempts int
+}
+
+// workerOptions configures the worker gathering the artifacts of a step into
+// the sub-directory.
+func (o ArtifactOptions) workerOptions(subDir string) []ArtifactWorkerOption {
+	var opts []ArtifactWorkerOption
+	if o.Uploader != nil {
+		opts = append(opts, WithArtifactUploader(prefixedArtifactUploader{ArtifactUploader: o.Uploader, prefix: subDir}))
+	}
+	if o.SizeLimit > 0 {
+		opts = append(opts, WithArtifactSizeLimit(o.SizeLimit))
+	}
+	if o.DownloadConcurrency > 0 {
+		opts = append(opts, WithDownloadConcurrency(o.DownloadConcurrency))
+	}
+	if o.DownloadAttempts > 0 {
+		backoff := defaultArtifactDownloadBackoff
+		backoff.Steps = o.DownloadAttempts
+		opts = append(opts, WithDownloadBackoff(backoff))
+	}
+	return opts
+}
+
+// WithArtifactUploader streams the artifacts into the uploader instead of
+// staging them in the artifact directory.
+func WithArtifactUploader(uploader ArtifactUploader) ArtifactWorkerOption {
+	return func(w *ArtifactWorker) {
+		w.uploader = uploader
+	}
 }
 
-func NewArtifactWorker(podClient kubernetes.PodClient, artifactDir, namespace string) *ArtifactWorker {
+// WithArtifactSizeLimit truncates the artifacts once their total size reaches
+// the limit in bytes, which is recorded in the manifest.
+func WithArtifactSizeLimit(limit int64) ArtifactWorkerOption {
+	return func(w *ArtifactWorker) {
+		w.quota = newArtifactQuota(limit)
+	}
+}
+
+func NewArtifactWorker(podClient kubernetes.PodClient, artifactDir, namespace string, opts ...ArtifactWorkerOption) *ArtifactWorker {
 	// stream artifacts in the background
 	w := &ArtifactWorker{
 		podClient: podClient,
 		namespace: namespace,
 		dir:       artifactDir,
 
+		concurrency: defaultArtifactDownloadConcurrency,
+		backoff:     defaultArtifactDownloadBackoff,
+		uploader:    directoryArtifactUploader{dir: artifactDir},
+
 		remaining:    make(podWaitRecord),
 		required:     make(podContainersMap),
 		hasArtifacts: sets.New[string](),
+		queued:       sets.New[string](),
+		manifest:     map[string]api.ArtifactManifestFile{},
 
 		podsToDownload: make(chan string, 4),
 	}
-	go w.run()
+	for _, opt := range opts {
+		opt(w)
+	}
+	for i := 0; i < w.concurrency; i++ {
+		go w.run()
+	}
 	return w
 }
 
@@ -410,8 +887,11 @@ func (w *ArtifactWorker) run() {
 	for podName := range w.podsToDownload {
 		logger := logrus.WithField("pod", podName)
 		logger.Trace("Processing Pod to download artifacts.")
-		if err := w.downloadArtifacts(podName, w.hasArtifacts.Has(podName)); err != nil {
-			logger.WithError(err).Trace("Error downloading artifacts.")
+		w.lock.Lock()
+		hasArtifacts := w.hasArtifacts.Has(podName)
+		w.lock.Unlock()
+		if err := w.downloadArtifacts(podName, hasArtifacts); err != nil {
+			logger.WithError(err).Warn("Error downloading artifacts.")
 		}
 		// indicate we are done with this pod by removing the map entry
 		w.lock.Lock()
@@ -424,6 +904,18 @@ func (w *ArtifactWorker) run() {
 	}
 }
 
+// enqueue hands the pod to the downloaders unless it was already, it must be
+// called with the lock held.
+func (w *ArtifactWorker) enqueue(podName string) {
+	if w.closed || w.queued.Has(podName) {
+		return
+	}
+	w.queued.Insert(podName)
+	w.lock.Unlock()
+	w.podsToDownload <- podName
+	w.lock.Lock()
+}
+
 func (w *ArtifactWorker) downloadArtifacts(podName string, hasArtifacts bool) error {
 	logger := logrus.WithFields(logrus.Fields{"pod": podName, "hasArtifacts": hasArtifacts, "dir": w.dir})
 	logger.Trace("Downloading artifacts for Pod.")
@@ -457,12 +949,38 @@ func (w *ArtifactWorker) downloadArtifacts(podName string, hasArtifacts bool) er
 	}
 
 	logger.Trace("Copying artifacts from Pod.")
-	if err := copyArtifacts(w.podClient, w.dir, w.namespace, podName, "artifacts", []string{"/tmp/artifacts"}); err != nil {
+	stored, err := copyArtifacts(w.podClient, w.uploader, w.namespace, podName, "artifacts", "/tmp/artifacts", w.backoff, w.quota)
+	if err := w.writeManifest(stored); err != nil {
+		logger.WithError(err).Warn("Unable to write the artifact manifest.")
+	}
+	if err != nil {
 		return fmt.Errorf("unable to retrieve artifacts from pod %s: %w", podName, err)
 	}
 	return nil
 }
 
+// writeManifest records the artifacts copied from a pod and writes the
+// manifest of all artifacts copied so far into the artifact directory.
+func (w *ArtifactWorker) writeManifest(stored map[string]api.ArtifactManifestFile) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for name, file := range stored {
+		w.manifest[name] = file
+	}
+	manifest := api.ArtifactManifest{Files: []api.ArtifactManifestFile{}}
+	if w.quota != nil {
+		manifest.SizeLimit = w.quota.limit
+	}
+	for _, name := range sets.List(sets.KeySet(w.manifest)) {
+		manifest.Files = append(manifest.Files, w.manifest[name])
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not serialize the artifact manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(w.dir, api.ArtifactManifestFilename), raw, 0640)
+}
+
 func (w *ArtifactWorker) CollectFromPod(podName string, hasArtifacts []string, waitForContainers []string) {
 	w.lock.Lock()
 	defer w.lock.Unlock()
@@ -510,11 +1028,16 @@ func (w *ArtifactWorker) Complete(podName string) {
 
 	// when all containers in a given pod that output artifacts have completed, exit
 	if artifactContainers.containers.Len() > 0 {
-		w.lock.Unlock()
-		w.podsToDownload <- podName
-		w.lock.Lock()
+		w.enqueue(podName)
 	}
-	if len(w.remaining) == 0 {
+	w.closeIfFinished()
+}
+
+// closeIfFinished stops the downloaders once no pod remains, it must be called
+// with the lock held.
+func (w *ArtifactWorker) closeIfFinished() {
+	if len(w.remaining) == 0 && !w.closed {
+		w.closed = true
 		close(w.podsToDownload)
 	}
 }
@@ -549,20 +1072,23 @@ func (w *ArtifactWorker) Notify(pod *coreapi.Pod, containerName string) {
 
 	// no more artifact containers, we can start grabbing artifacts
 	if artifactContainers.containers.Len() == 0 {
-		w.lock.Unlock()
-		w.podsToDownload <- pod.Name
-		w.lock.Lock()
+		w.enqueue(pod.Name)
 	}
 
-	if len(w.remaining) == 0 {
-		close(w.podsToDownload)
-	}
+	w.closeIfFinished()
 }
 
+// Done returns a channel which is closed once the artifacts of the pod were
+// downloaded, including when that already happened.
 func (w *ArtifactWorker) Done(podName string) <-chan struct{} {
 	w.lock.Lock()
 	defer w.lock.Unlock()
-	return w.remaining[podName].done
+	if record, ok := w.remaining[podName]; ok || !w.queued.Has(podName) {
+		return record.done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
 }
 
 func addArtifactContainersFromPod(pod *coreapi.Pod, worker *ArtifactWorker) {
@@ -661,7 +1187,7 @@ func gatherContainerLogsOutput(podClient kubernetes.PodClient, artifactDir, name
 
 			w := gzip.NewWriter(file)
 			logger.Trace("Fetching container logs.")
-			if s, err := podClient.GetLogs(namespace, podName, &coreapi.PodLogOptions{Container: status.Name}).Stream(context.TODO()); err == nil {
+			if s, err := podClient.StreamLogs(context.TODO(), namespace, podName, &coreapi.PodLogOptions{Container: status.Name}); err == nil {
 				if _, err := io.Copy(w, s); err != nil {
 					validationErrors = append(validationErrors, fmt.Errorf("error: Unable to copy log output from pod container %s: %w", status.Name, err))
 				}
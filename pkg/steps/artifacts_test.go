@@ -1,8 +1,19 @@
 package steps
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,9 +23,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 
+	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/testhelper"
@@ -52,10 +67,11 @@ done
 
 func TestTestCaseNotifier_SubTests(t *testing.T) {
 	tests := []struct {
-		name      string
-		pod       *coreapi.Pod
-		prefix    string
-		wantTests []*junit.TestCase
+		name       string
+		pod        *coreapi.Pod
+		prefix     string
+		properties []*junit.TestSuiteProperty
+		wantTests  []*junit.TestCase
 	}{
 		{name: "nil"},
 		{
@@ -144,6 +160,30 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 					},
 				},
 			},
+			wantTests: []*junit.TestCase{
+				{Name: "container other", SkipMessage: &junit.SkipMessage{Message: "container never started"}},
+			},
+		},
+		{
+			name: "properties are set on each test",
+			pod: &coreapi.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Annotations: map[string]string{
+						annotationContainersForSubTestResults: "test,other",
+					},
+				},
+				Status: coreapi.PodStatus{
+					ContainerStatuses: []coreapi.ContainerStatus{
+						{Name: "test", State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{ExitCode: 0}}},
+						{Name: "other"},
+					},
+				},
+			},
+			properties: []*junit.TestSuiteProperty{{Name: junit.PhaseProperty, Value: "pre"}},
+			wantTests: []*junit.TestCase{
+				{Name: "container other", SkipMessage: &junit.SkipMessage{Message: "container never started"}, Properties: []*junit.TestSuiteProperty{{Name: junit.PhaseProperty, Value: "pre"}}},
+				{Name: "container test", Properties: []*junit.TestSuiteProperty{{Name: junit.PhaseProperty, Value: "pre"}}},
+			},
 		},
 		{
 			name: "no completed containers",
@@ -160,6 +200,33 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 					},
 				},
 			},
+			wantTests: []*junit.TestCase{
+				{Name: "container test", SkipMessage: &junit.SkipMessage{Message: "container never started"}},
+			},
+		},
+		{
+			name: "running and waiting containers",
+			pod: &coreapi.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Annotations: map[string]string{
+						annotationContainersForSubTestResults: "other,test,restarted",
+					},
+				},
+				Status: coreapi.PodStatus{
+					ContainerStatuses: []coreapi.ContainerStatus{
+						{Name: "test", State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}}},
+						{Name: "other", State: coreapi.ContainerState{Waiting: &coreapi.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+						{
+							Name:                 "restarted",
+							State:                coreapi.ContainerState{Waiting: &coreapi.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+							LastTerminationState: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{ExitCode: 1}},
+						},
+					},
+				},
+			},
+			wantTests: []*junit.TestCase{
+				{Name: "container other", SkipMessage: &junit.SkipMessage{Message: "container never started: ImagePullBackOff"}},
+			},
 		},
 		{
 			name: "single failed container",
@@ -263,7 +330,7 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 			},
 		},
 		{
-			name: "ignores unfinisted container",
+			name: "skips container that never started",
 			pod: &coreapi.Pod{
 				ObjectMeta: meta.ObjectMeta{
 					Annotations: map[string]string{
@@ -289,6 +356,7 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 				},
 			},
 			wantTests: []*junit.TestCase{
+				{Name: "container other", SkipMessage: &junit.SkipMessage{Message: "container never started"}},
 				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message"}},
 			},
 		},
@@ -479,7 +547,7 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 				nested:  util.NopNotifier,
 				lastPod: tt.pod,
 			}
-			tests := n.SubTests(tt.prefix)
+			tests := n.SubTests(tt.prefix, tt.properties...)
 			if !reflect.DeepEqual(tt.wantTests, tests) {
 				t.Fatalf("unexpected: %s", diff.ObjectReflectDiff(tt.wantTests, tests))
 			}
@@ -487,6 +555,90 @@ func TestTestCaseNotifier_SubTests(t *testing.T) {
 	}
 }
 
+func TestTestCaseNotifier_FailureLogs(t *testing.T) {
+	pod := &coreapi.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "pod",
+			Namespace:   "ns",
+			Annotations: map[string]string{annotationContainersForSubTestResults: "test,other"},
+		},
+		Status: coreapi.PodStatus{
+			ContainerStatuses: []coreapi.ContainerStatus{
+				{Name: "test", State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{ExitCode: 1, Message: "exit message"}}},
+				{Name: "other", State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{ExitCode: 0}}},
+			},
+		},
+	}
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "credentials", Namespace: "ns"},
+		Data:       map[string][]byte{"token": []byte("hunter2")},
+	}
+	for _, tc := range []struct {
+		name     string
+		logs     map[string]string
+		limit    int64
+		expected []*junit.TestCase
+	}{
+		{
+			name:  "whole log fits in the limit",
+			logs:  map[string]string{"ns/pod/test": "logging in with hunter2\nfailed\n", "ns/pod/other": "passed\n"},
+			limit: 1024,
+			expected: []*junit.TestCase{
+				{Name: "container other"},
+				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message\n\nlogging in with XXXXXXX\nfailed\n"}},
+			},
+		},
+		{
+			name:  "end of the log without the cut line",
+			logs:  map[string]string{"ns/pod/test": "first line\nlogging in with hunter2\nfailed\n"},
+			limit: 30,
+			expected: []*junit.TestCase{
+				{Name: "container other"},
+				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message\n\nLast 7 bytes of the log:\nfailed\n"}},
+			},
+		},
+		{
+			name:  "only the last lines up to the limit are read",
+			logs:  map[string]string{"ns/pod/test": "logging in with hunter2\nx\ny\nz\nfailed\n"},
+			limit: 9,
+			expected: []*junit.TestCase{
+				{Name: "container other"},
+				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message\n\nLast 7 bytes of the log:\nfailed\n"}},
+			},
+		},
+		{
+			name:  "log is not available",
+			logs:  map[string]string{},
+			limit: 1024,
+			expected: []*junit.TestCase{
+				{Name: "container other"},
+				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message"}},
+			},
+		},
+		{
+			name:  "logs are not fetched without a limit",
+			logs:  map[string]string{"ns/pod/test": "failed\n"},
+			limit: 0,
+			expected: []*junit.TestCase{
+				{Name: "container other"},
+				{Name: "container test", FailureOutput: &junit.FailureOutput{Output: "exit message"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &testhelper_kube.FakePodClient{
+				FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(secret).Build())},
+				Logs:            tc.logs,
+			}
+			n := NewTestCaseNotifier(util.NopNotifier, WithFailureLogs(client, tc.limit))
+			n.Notify(pod, "test")
+			if diff := cmp.Diff(tc.expected, n.SubTests("")); diff != "" {
+				t.Errorf("unexpected tests: %s", diff)
+			}
+		})
+	}
+}
+
 func TestArtifactWorker(t *testing.T) {
 	tmp, err := os.MkdirTemp("", "")
 	if err != nil {
@@ -537,11 +689,348 @@ func TestArtifactWorker(t *testing.T) {
 	for _, f := range files {
 		names = append(names, f.Name())
 	}
-	if diff := cmp.Diff(names, []string{"test.txt"}); diff != "" {
+	if diff := cmp.Diff(names, []string{api.ArtifactManifestFilename, "test.txt"}); diff != "" {
 		t.Fatalf("artifacts do not match expected: %s", diff)
 	}
 }
 
+// flakyArtifactsPodClient serves artifacts from memory, the first stream of
+// the tarball breaks after the first file.
+type flakyArtifactsPodClient struct {
+	*testhelper_kube.FakePodClient
+	files map[string]string
+	// firstFiles replaces the content of files in the first stream
+	firstFiles map[string]string
+
+	lock     sync.Mutex
+	streams  int
+	commands []string
+}
+
+func (c *flakyArtifactsPodClient) Exec(namespace, name string, opts *coreapi.PodExecOptions) (remotecommand.Executor, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.commands = append(c.commands, strings.Join(opts.Command, " "))
+	return &flakyArtifactsExecutor{client: c, command: opts.Command}, nil
+}
+
+type flakyArtifactsExecutor struct {
+	client  *flakyArtifactsPodClient
+	command []string
+}
+
+func (e *flakyArtifactsExecutor) Stream(opts remotecommand.StreamOptions) error {
+	names := sets.List(sets.KeySet(e.client.files))
+	switch e.command[0] {
+	case "/bin/sh":
+		for _, name := range names {
+			sum := sha256.Sum256([]byte(e.client.files[name]))
+			if _, err := opts.Stdout.Write([]byte(hex.EncodeToString(sum[:]) + "  ./" + name + "\n")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "rm":
+		return nil
+	}
+	if opts.Stdin != nil {
+		requested, err := io.ReadAll(opts.Stdin)
+		if err != nil {
+			return err
+		}
+		names = strings.Fields(string(requested))
+	}
+	e.client.lock.Lock()
+	e.client.streams++
+	broken := e.client.streams == 1
+	e.client.lock.Unlock()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		content := e.client.files[name]
+		if first, ok := e.client.firstFiles[name]; ok && broken {
+			content = first
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return err
+		}
+		if broken {
+			break
+		}
+	}
+	if !broken {
+		if err := tw.Close(); err != nil {
+			return err
+		}
+	} else if err := tw.Flush(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if _, err := opts.Stdout.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if broken {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (e *flakyArtifactsExecutor) StreamWithContext(_ context.Context, opts remotecommand.StreamOptions) error {
+	return e.Stream(opts)
+}
+
+func TestArtifactWorkerRetriesRemainingFiles(t *testing.T) {
+	tmp := t.TempDir()
+	pod := "pod"
+	podClient := &flakyArtifactsPodClient{
+		FakePodClient: &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				&coreapi.Pod{
+					ObjectMeta: meta.ObjectMeta{Name: pod, Namespace: "namespace"},
+					Status: coreapi.PodStatus{
+						ContainerStatuses: []coreapi.ContainerStatus{{
+							Name:  "artifacts",
+							State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+						}},
+					},
+				}).Build()),
+			},
+			Namespace: "namespace",
+			Name:      pod,
+		},
+		files: map[string]string{"a.txt": "first", "dir/b.txt": "second"},
+	}
+	w := NewArtifactWorker(podClient, tmp, "namespace", WithDownloadConcurrency(2), WithDownloadBackoff(wait.Backoff{Steps: 3, Duration: time.Millisecond}))
+	w.CollectFromPod(pod, []string{"container"}, nil)
+	w.Complete(pod)
+	select {
+	case <-w.Done(pod):
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for artifact worker to finish")
+	}
+	for name, content := range podClient.files {
+		raw, err := os.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("artifact %s was not copied: %v", name, err)
+		}
+		if diff := cmp.Diff(content, string(raw)); diff != "" {
+			t.Errorf("artifact %s does not match expected: %s", name, diff)
+		}
+	}
+	expected := []string{
+		"/bin/sh -c cd /tmp/artifacts && find . -type f -exec sha256sum {} +",
+		"tar czf - -C /tmp/artifacts .",
+		"tar czf - -C /tmp/artifacts -T -",
+		"rm -f /tmp/done",
+	}
+	if diff := cmp.Diff(expected, podClient.commands); diff != "" {
+		t.Errorf("unexpected commands: %s", diff)
+	}
+	select {
+	case <-w.Done(pod):
+	default:
+		t.Error("expected the worker to report the pod as done after the download")
+	}
+}
+
+func TestArtifactWorkerSizeLimit(t *testing.T) {
+	tmp := t.TempDir()
+	pod := "pod"
+	podClient := &flakyArtifactsPodClient{
+		FakePodClient: &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				&coreapi.Pod{
+					ObjectMeta: meta.ObjectMeta{Name: pod, Namespace: "namespace"},
+					Status: coreapi.PodStatus{
+						ContainerStatuses: []coreapi.ContainerStatus{{
+							Name:  "artifacts",
+							State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+						}},
+					},
+				}).Build()),
+			},
+			Namespace: "namespace",
+			Name:      pod,
+		},
+		files: map[string]string{"a.txt": "first", "dir/b.txt": "second", "dir/c.txt": "third"},
+	}
+	w := NewArtifactWorker(podClient, tmp, "namespace", WithArtifactSizeLimit(8), WithDownloadBackoff(wait.Backoff{Steps: 3, Duration: time.Millisecond}))
+	w.CollectFromPod(pod, []string{"container"}, nil)
+	w.Complete(pod)
+	select {
+	case <-w.Done(pod):
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for artifact worker to finish")
+	}
+	checksum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	expected := &api.ArtifactManifest{
+		SizeLimit: 8,
+		Files: []api.ArtifactManifestFile{
+			{Name: "a.txt", Size: 5, SHA256: checksum("first")},
+			{Name: "dir/b.txt", Size: 3, SHA256: checksum("sec"), Truncated: true, OriginalSize: 6},
+			{Name: "dir/c.txt", Truncated: true},
+		},
+	}
+	manifest, err := api.LoadArtifactManifest(tmp)
+	if err != nil {
+		t.Fatalf("failed to load the manifest: %v", err)
+	}
+	if diff := cmp.Diff(expected, manifest); diff != "" {
+		t.Errorf("unexpected manifest: %s", diff)
+	}
+	for name, content := range map[string]string{"a.txt": "first", "dir/b.txt": "sec"} {
+		raw, err := os.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("artifact %s was not copied: %v", name, err)
+		}
+		if diff := cmp.Diff(content, string(raw)); diff != "" {
+			t.Errorf("artifact %s does not match expected: %s", name, diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "dir/c.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected artifact over the limit not to be copied, got %v", err)
+	}
+}
+
+func TestArtifactWorkerSizeLimitReleasesMismatchedCopies(t *testing.T) {
+	tmp := t.TempDir()
+	pod := "pod"
+	podClient := &flakyArtifactsPodClient{
+		FakePodClient: &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				&coreapi.Pod{
+					ObjectMeta: meta.ObjectMeta{Name: pod, Namespace: "namespace"},
+					Status: coreapi.PodStatus{
+						ContainerStatuses: []coreapi.ContainerStatus{{
+							Name:  "artifacts",
+							State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+						}},
+					},
+				}).Build()),
+			},
+			Namespace: "namespace",
+			Name:      pod,
+		},
+		files:      map[string]string{"a.txt": "first", "dir/b.txt": "second"},
+		firstFiles: map[string]string{"a.txt": "FIRST"},
+	}
+	w := NewArtifactWorker(podClient, tmp, "namespace", WithArtifactSizeLimit(11), WithDownloadBackoff(wait.Backoff{Steps: 3, Duration: time.Millisecond}))
+	w.CollectFromPod(pod, []string{"container"}, nil)
+	w.Complete(pod)
+	select {
+	case <-w.Done(pod):
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for artifact worker to finish")
+	}
+	checksum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	expected := &api.ArtifactManifest{
+		SizeLimit: 11,
+		Files: []api.ArtifactManifestFile{
+			{Name: "a.txt", Size: 5, SHA256: checksum("first")},
+			{Name: "dir/b.txt", Size: 6, SHA256: checksum("second")},
+		},
+	}
+	manifest, err := api.LoadArtifactManifest(tmp)
+	if err != nil {
+		t.Fatalf("failed to load the manifest: %v", err)
+	}
+	if diff := cmp.Diff(expected, manifest); diff != "" {
+		t.Errorf("unexpected manifest: %s", diff)
+	}
+}
+
+func TestArtifactOptionsWorkerOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		options             ArtifactOptions
+		expectedConcurrency int
+		expectedBackoff     wait.Backoff
+	}{
+		{
+			name:                "defaults",
+			expectedConcurrency: defaultArtifactDownloadConcurrency,
+			expectedBackoff:     defaultArtifactDownloadBackoff,
+		},
+		{
+			name:                "configured download pool",
+			options:             ArtifactOptions{DownloadConcurrency: 8, DownloadAttempts: 2},
+			expectedConcurrency: 8,
+			expectedBackoff:     wait.Backoff{Steps: 2, Duration: 2 * time.Second, Factor: 2, Jitter: 0.1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &ArtifactWorker{concurrency: defaultArtifactDownloadConcurrency, backoff: defaultArtifactDownloadBackoff}
+			for _, opt := range tc.options.workerOptions("test") {
+				opt(w)
+			}
+			if w.concurrency != tc.expectedConcurrency {
+				t.Errorf("expected a concurrency of %d, got %d", tc.expectedConcurrency, w.concurrency)
+			}
+			testhelper.Diff(t, "backoff", w.backoff, tc.expectedBackoff)
+		})
+	}
+}
+
+func TestParseArtifactChecksums(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		output        string
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name:     "no files",
+			expected: map[string]string{},
+		},
+		{
+			name:     "paths are relative to the root",
+			output:   "abc  ./a.txt\ndef  ./dir/with space.txt\n",
+			expected: map[string]string{"a.txt": "abc", "dir/with space.txt": "def"},
+		},
+		{
+			name:     "escaped names are unescaped",
+			output:   "\\abc  ./back\\\\slash.txt\n\\def  ./new\\nline.txt\nghi  ./plain.txt\n",
+			expected: map[string]string{"back\\slash.txt": "abc", "new\nline.txt": "def", "plain.txt": "ghi"},
+		},
+		{
+			name:          "invalid escape",
+			output:        "\\abc  ./a\\t.txt\n",
+			expectedError: `invalid checksum line: "\\abc  ./a\\t.txt": unknown escape sequence \t`,
+		},
+		{
+			name:          "invalid line",
+			output:        "abc\n",
+			expectedError: `invalid checksum line: "abc"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseArtifactChecksums(tc.output)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); err == nil && diff != "" {
+				t.Errorf("unexpected checksums: %s", diff)
+			}
+		})
+	}
+}
+
 func TestAddArtifactsToPod(t *testing.T) {
 	testCases := []struct {
 		testID   string
@@ -0,0 +1,358 @@
+package steps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/prow/pkg/clonerefs"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// buildCacheTagPrefix prefixes the tags of the pipeline imagestream in the
+	// cache namespace which hold the images built from the inputs their key
+	// was computed from.
+	buildCacheTagPrefix = "cache-"
+	// BuildCacheKeyAnnotation records the full cache key on the cache tags.
+	BuildCacheKeyAnnotation = "ci.openshift.io/build-cache-key"
+	// BuildCacheLastUsedAnnotation records when the image of a cache tag was
+	// last stored or reused, so that unused images can be pruned.
+	BuildCacheLastUsedAnnotation = "ci.openshift.io/build-cache-last-used"
+
+	// buildCacheRetention is how long a cached image is kept without being
+	// reused.
+	buildCacheRetention = 7 * 24 * time.Hour
+	// buildCacheTouchInterval is how often reusing a cached image refreshes
+	// the time it was last used, so that jobs do not all update the tag.
+	buildCacheTouchInterval = time.Hour
+)
+
+// errNotCacheable is returned for builds whose inputs cannot be identified,
+// e.g. ones cloning a branch of a repository, which moves between jobs.
+var errNotCacheable = errors.New("the inputs of the build cannot be identified")
+
+// commitSHA matches the full SHA of a commit.
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// BuildCache reuses the pipeline images built by earlier jobs when the inputs
+// of a build are identical: the Dockerfile, the context the build copies in
+// and the digests of the images it builds from. Images are shared through the
+// pipeline imagestream of a namespace all jobs on the cluster can access,
+// tagged by the key of the inputs they were built from. Images which were not
+// reused for buildCacheRetention are pruned when new ones are stored.
+type BuildCache struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	now       func() time.Time
+}
+
+// NewBuildCache creates a cache sharing the images in the namespace, in which
+// the jobs must be allowed to manage imagestreams.
+func NewBuildCache(client ctrlruntimeclient.Client, namespace string) *BuildCache {
+	return &BuildCache{client: client, namespace: namespace, now: time.Now}
+}
+
+// buildCacheInputs are the inputs of a build which determine the image it
+// produces. References to imagestream tags are replaced with the digests of
+// the images they point to, so that the key does not depend on the namespace
+// the build runs in.
+type buildCacheInputs struct {
+	Dockerfile     string            `json:"dockerfile,omitempty"`
+	DockerfilePath string            `json:"dockerfilePath,omitempty"`
+	ContextDir     string            `json:"contextDir,omitempty"`
+	GitURI         string            `json:"gitURI,omitempty"`
+	GitCommit      string            `json:"gitCommit,omitempty"`
+	From           string            `json:"from,omitempty"`
+	Images         []buildCacheImage `json:"images,omitempty"`
+	BuildArgs      []coreapi.EnvVar  `json:"buildArgs,omitempty"`
+	Env            []coreapi.EnvVar  `json:"env,omitempty"`
+	Labels         []string          `json:"labels,omitempty"`
+	Architectures  []string          `json:"architectures"`
+}
+
+type buildCacheImage struct {
+	From  string                     `json:"from"`
+	Paths []buildapi.ImageSourcePath `json:"paths,omitempty"`
+}
+
+// Key computes the key of the inputs of the build for the architectures. Env
+// vars holding the clonerefs configuration identify the revisions of the
+// source code that is cloned. Builds cloning a repository are only cached
+// when they clone a commit, as the revision a branch points to is not known
+// until the build runs: neither are the ones whose clonerefs configuration
+// does not resolve every ref to a commit, e.g. periodics with a base_ref only.
+func (c *BuildCache) Key(ctx context.Context, build buildapi.Build, architectures []string) (string, error) {
+	archs := append([]string{}, architectures...)
+	if len(archs) == 0 {
+		archs = append(archs, string(api.NodeArchitectureAMD64))
+	}
+	sort.Strings(archs)
+	inputs := buildCacheInputs{
+		ContextDir:    build.Spec.Source.ContextDir,
+		Architectures: archs,
+	}
+	if build.Spec.Source.Dockerfile != nil {
+		inputs.Dockerfile = *build.Spec.Source.Dockerfile
+	}
+	if git := build.Spec.Source.Git; git != nil {
+		if !commitSHA.MatchString(git.Ref) {
+			return "", fmt.Errorf("%w: %s is cloned at %q, not at a commit", errNotCacheable, git.URI, git.Ref)
+		}
+		inputs.GitURI, inputs.GitCommit = git.URI, git.Ref
+	}
+	for _, image := range build.Spec.Source.Images {
+		digest, err := c.digestFor(ctx, build.Namespace, image.From)
+		if err != nil {
+			return "", err
+		}
+		inputs.Images = append(inputs.Images, buildCacheImage{From: digest, Paths: image.Paths})
+	}
+	if strategy := build.Spec.Strategy.DockerStrategy; strategy != nil {
+		if err := resolvedClonerefs(strategy.Env); err != nil {
+			return "", err
+		}
+		inputs.DockerfilePath = strategy.DockerfilePath
+		inputs.BuildArgs = strategy.BuildArgs
+		inputs.Env = strategy.Env
+		if strategy.From != nil {
+			digest, err := c.digestFor(ctx, build.Namespace, *strategy.From)
+			if err != nil {
+				return "", err
+			}
+			inputs.From = digest
+		}
+	}
+	for _, label := range build.Spec.Output.ImageLabels {
+		inputs.Labels = append(inputs.Labels, fmt.Sprintf("%s=%s", label.Name, label.Value))
+	}
+	sort.Strings(inputs.Labels)
+	raw, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize the inputs of the build: %w", err)
+	}
+	hash := sha256.Sum256(raw)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// resolvedClonerefs ensures the refs in the clonerefs configuration among the
+// env vars, if any, are all resolved to commits.
+func resolvedClonerefs(env []coreapi.EnvVar) error {
+	for _, variable := range env {
+		if variable.Name != clonerefs.JSONConfigEnvVar {
+			continue
+		}
+		var options clonerefs.Options
+		if err := json.Unmarshal([]byte(variable.Value), &options); err != nil {
+			return fmt.Errorf("could not parse the clonerefs configuration of the build: %w", err)
+		}
+		for _, ref := range options.GitRefs {
+			if ref.BaseSHA == "" {
+				return fmt.Errorf("%w: %s/%s is cloned at %q, not at a commit", errNotCacheable, ref.Org, ref.Repo, ref.BaseRef)
+			}
+			for _, pull := range ref.Pulls {
+				if pull.SHA == "" {
+					return fmt.Errorf("%w: the commit of %s/%s#%d is not known", errNotCacheable, ref.Org, ref.Repo, pull.Number)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// digestFor resolves the reference to an image to its digest.
+func (c *BuildCache) digestFor(ctx context.Context, namespace string, ref coreapi.ObjectReference) (string, error) {
+	switch ref.Kind {
+	case "ImageStreamTag":
+		if ref.Namespace != "" {
+			namespace = ref.Namespace
+		}
+		ist := &imagev1.ImageStreamTag{}
+		if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: ref.Name}, ist); err != nil {
+			return "", fmt.Errorf("could not resolve imagestream tag %s/%s: %w", namespace, ref.Name, err)
+		}
+		if ist.Image.Name == "" {
+			return "", fmt.Errorf("imagestream tag %s/%s does not point to an image", namespace, ref.Name)
+		}
+		return ist.Image.Name, nil
+	case "ImageStreamImage":
+		_, digest, _ := strings.Cut(ref.Name, "@")
+		return digest, nil
+	case "DockerImage":
+		if _, digest, ok := strings.Cut(ref.Name, "@"); ok {
+			return digest, nil
+		}
+		return "", fmt.Errorf("image %s is not referenced by digest", ref.Name)
+	default:
+		return "", fmt.Errorf("unsupported image reference kind %q", ref.Kind)
+	}
+}
+
+// cacheTag is the tag of the pipeline imagestream in the cache namespace
+// holding the image built from the inputs with the key.
+func cacheTag(key string) string {
+	return buildCacheTagPrefix + key[:32]
+}
+
+// Restore tags the image built from the inputs with the key into the output of
+// the build, if one was cached. It returns whether the build can be skipped.
+func (c *BuildCache) Restore(ctx context.Context, build buildapi.Build, key string) (bool, error) {
+	cached := &imagev1.ImageStreamTag{}
+	name := fmt.Sprintf("%s:%s", api.PipelineImageStream, cacheTag(key))
+	if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: name}, cached); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get cached image %s/%s: %w", c.namespace, name, err)
+	}
+	if cached.Annotations[BuildCacheKeyAnnotation] != key || cached.Image.Name == "" {
+		return false, nil
+	}
+	from := &coreapi.ObjectReference{
+		Kind:      "ImageStreamImage",
+		Namespace: c.namespace,
+		Name:      fmt.Sprintf("%s@%s", api.PipelineImageStream, cached.Image.Name),
+	}
+	if err := c.tag(ctx, build.Spec.Output.To.Namespace, build.Spec.Output.To.Name, from, nil); err != nil {
+		return false, err
+	}
+	logrus.Infof("Reusing image %s built from identical inputs by an earlier job for %s", cached.Image.Name, build.Spec.Output.To.Name)
+	if err := c.touch(ctx, cached); err != nil {
+		logrus.WithError(err).Warnf("Failed to record the use of cached image %s/%s.", c.namespace, name)
+	}
+	return true, nil
+}
+
+// touch records that the image of the cache tag was reused, unless that was
+// already recorded recently.
+func (c *BuildCache) touch(ctx context.Context, cached *imagev1.ImageStreamTag) error {
+	if lastUsed, err := time.Parse(time.RFC3339, cached.Annotations[BuildCacheLastUsedAnnotation]); err == nil && c.now().Sub(lastUsed) < buildCacheTouchInterval {
+		return nil
+	}
+	annotations := map[string]string{
+		BuildCacheKeyAnnotation:      cached.Annotations[BuildCacheKeyAnnotation],
+		BuildCacheLastUsedAnnotation: c.now().UTC().Format(time.RFC3339),
+	}
+	cached.Annotations = annotations
+	if cached.Tag != nil {
+		cached.Tag.Annotations = annotations
+	}
+	return c.client.Update(ctx, cached)
+}
+
+// Store records the output of the build as the image built from the inputs
+// with the key, then prunes the images which were not reused for too long.
+func (c *BuildCache) Store(ctx context.Context, build buildapi.Build, key string) error {
+	output := &imagev1.ImageStreamTag{}
+	if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: build.Spec.Output.To.Namespace, Name: build.Spec.Output.To.Name}, output); err != nil {
+		return fmt.Errorf("could not get built image %s/%s: %w", build.Spec.Output.To.Namespace, build.Spec.Output.To.Name, err)
+	}
+	if err := c.client.Create(ctx, &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: api.PipelineImageStream},
+	}); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create the %s/%s imagestream: %w", c.namespace, api.PipelineImageStream, err)
+	}
+	from := &coreapi.ObjectReference{
+		Kind:      "ImageStreamImage",
+		Namespace: build.Spec.Output.To.Namespace,
+		Name:      fmt.Sprintf("%s@%s", api.PipelineImageStream, output.Image.Name),
+	}
+	annotations := map[string]string{
+		BuildCacheKeyAnnotation:      key,
+		BuildCacheLastUsedAnnotation: c.now().UTC().Format(time.RFC3339),
+	}
+	if err := c.tag(ctx, c.namespace, fmt.Sprintf("%s:%s", api.PipelineImageStream, cacheTag(key)), from, annotations); err != nil {
+		return err
+	}
+	if err := c.prune(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to prune the build cache.")
+	}
+	return nil
+}
+
+// prune deletes the cache tags whose images were not used for longer than
+// buildCacheRetention. Tags without a record of their last use are aged by
+// the time their image was tagged.
+func (c *BuildCache) prune(ctx context.Context) error {
+	stream := &imagev1.ImageStream{}
+	if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: api.PipelineImageStream}, stream); err != nil {
+		return fmt.Errorf("could not get the %s/%s imagestream: %w", c.namespace, api.PipelineImageStream, err)
+	}
+	tagged := map[string]time.Time{}
+	for _, tag := range stream.Status.Tags {
+		if len(tag.Items) != 0 {
+			tagged[tag.Tag] = tag.Items[0].Created.Time
+		}
+	}
+	var errs []error
+	for _, tag := range stream.Spec.Tags {
+		if !strings.HasPrefix(tag.Name, buildCacheTagPrefix) {
+			continue
+		}
+		lastUsed, err := time.Parse(time.RFC3339, tag.Annotations[BuildCacheLastUsedAnnotation])
+		if err != nil {
+			lastUsed = tagged[tag.Name]
+		}
+		if c.now().Sub(lastUsed) <= buildCacheRetention {
+			continue
+		}
+		ist := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, tag.Name)}}
+		if err := c.client.Delete(ctx, ist); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete %s/%s: %w", c.namespace, ist.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// tag points the imagestream tag to the image, replacing any earlier image.
+func (c *BuildCache) tag(ctx context.Context, namespace, name string, from *coreapi.ObjectReference, annotations map[string]string) error {
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Tag: &imagev1.TagReference{
+			Annotations: annotations,
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: from,
+			ImportPolicy: imagev1.TagImportPolicy{
+				ImportMode: imagev1.ImportModePreserveOriginal,
+			},
+		},
+	}
+	if err := c.client.Create(ctx, ist); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not tag %s into %s/%s: %w", from.Name, namespace, name, err)
+		}
+		existing := &imagev1.ImageStreamTag{}
+		if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, existing); err != nil {
+			return fmt.Errorf("could not get imagestream tag %s/%s: %w", namespace, name, err)
+		}
+		existing.Annotations, existing.Tag = annotations, ist.Tag
+		if err := c.client.Update(ctx, existing); err != nil {
+			return fmt.Errorf("could not tag %s into %s/%s: %w", from.Name, namespace, name, err)
+		}
+	}
+	return nil
+}
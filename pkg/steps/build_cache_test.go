@@ -0,0 +1,313 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/clonerefs"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func cacheTestBuild(namespace, dockerfile string) buildapi.Build {
+	return buildapi.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "bin"},
+		Spec: buildapi.BuildSpec{
+			CommonSpec: buildapi.CommonSpec{
+				Source: buildapi.BuildSource{
+					Type:       buildapi.BuildSourceDockerfile,
+					Dockerfile: &dockerfile,
+					Images: []buildapi.ImageSource{{
+						From: coreapi.ObjectReference{Kind: "DockerImage", Name: "registry.ci/ci/clonerefs@sha256:clonerefs"},
+					}},
+				},
+				Strategy: buildapi.BuildStrategy{
+					DockerStrategy: &buildapi.DockerBuildStrategy{
+						From: &coreapi.ObjectReference{Kind: "ImageStreamTag", Namespace: namespace, Name: "pipeline:src"},
+					},
+				},
+				Output: buildapi.BuildOutput{
+					To: &coreapi.ObjectReference{Kind: "ImageStreamTag", Namespace: namespace, Name: "pipeline:bin"},
+				},
+			},
+		},
+	}
+}
+
+func cacheTestTag(namespace, name, image string, annotations map[string]string) *imagev1.ImageStreamTag {
+	return &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Annotations: annotations},
+		Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: image}},
+	}
+}
+
+func TestBuildCacheKey(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		cacheTestTag("ci-op-1", "pipeline:src", "sha256:src", nil),
+		cacheTestTag("ci-op-2", "pipeline:src", "sha256:src", nil),
+		cacheTestTag("ci-op-3", "pipeline:src", "sha256:other", nil),
+	).Build()
+	cache := NewBuildCache(client, "build-cache")
+	key := func(build buildapi.Build, archs ...string) string {
+		ret, err := cache.Key(context.Background(), build, archs)
+		if err != nil {
+			t.Fatalf("failed to compute the key: %v", err)
+		}
+		return ret
+	}
+	gitBuild := func(uri, ref string) buildapi.Build {
+		build := cacheTestBuild("ci-op-1", "FROM pipeline:src")
+		build.Spec.Source.Git = &buildapi.GitBuildSource{URI: uri, Ref: ref}
+		return build
+	}
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	reference := key(cacheTestBuild("ci-op-1", "FROM pipeline:src"))
+	gitReference := key(gitBuild("https://github.com/org/repo.git", commit))
+	for _, tc := range []struct {
+		name     string
+		key      string
+		expected bool
+	}{
+		{
+			name:     "identical inputs in another namespace",
+			key:      key(cacheTestBuild("ci-op-2", "FROM pipeline:src")),
+			expected: true,
+		},
+		{
+			name:     "amd64 is the default architecture",
+			key:      key(cacheTestBuild("ci-op-1", "FROM pipeline:src"), "amd64"),
+			expected: true,
+		},
+		{
+			name: "different base image",
+			key:  key(cacheTestBuild("ci-op-3", "FROM pipeline:src")),
+		},
+		{
+			name: "different Dockerfile",
+			key:  key(cacheTestBuild("ci-op-1", "FROM pipeline:src\nRUN make")),
+		},
+		{
+			name: "different architectures",
+			key:  key(cacheTestBuild("ci-op-1", "FROM pipeline:src"), "amd64", "arm64"),
+		},
+		{
+			name: "cloned repository",
+			key:  gitReference,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if matches := tc.key == reference; matches != tc.expected {
+				t.Errorf("expected the key to match: %t, got %t", tc.expected, matches)
+			}
+		})
+	}
+
+	if gitReference == key(gitBuild("https://github.com/org/fork.git", commit)) {
+		t.Error("expected the key to differ for another repository")
+	}
+	if gitReference == key(gitBuild("https://github.com/org/repo.git", "89abcdef0123456789abcdef0123456789abcdef")) {
+		t.Error("expected the key to differ for another commit")
+	}
+	if _, err := cache.Key(context.Background(), gitBuild("https://github.com/org/repo.git", "main"), nil); !errors.Is(err, errNotCacheable) {
+		t.Errorf("expected builds cloning a branch not to be cacheable, got %v", err)
+	}
+	clonerefsBuild := func(refs prowapi.Refs) buildapi.Build {
+		build := cacheTestBuild("ci-op-1", "FROM pipeline:src")
+		options, err := clonerefs.Encode(clonerefs.Options{GitRefs: []prowapi.Refs{refs}})
+		if err != nil {
+			t.Fatalf("failed to encode the clonerefs options: %v", err)
+		}
+		build.Spec.Strategy.DockerStrategy.Env = []coreapi.EnvVar{{Name: clonerefs.JSONConfigEnvVar, Value: options}}
+		return build
+	}
+	if _, err := cache.Key(context.Background(), clonerefsBuild(prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main"}), nil); !errors.Is(err, errNotCacheable) {
+		t.Errorf("expected periodics cloning a base_ref only not to be cacheable, got %v", err)
+	}
+	if _, err := cache.Key(context.Background(), clonerefsBuild(prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: commit, Pulls: []prowapi.Pull{{Number: 1}}}), nil); !errors.Is(err, errNotCacheable) {
+		t.Errorf("expected builds merging a pull request without a commit not to be cacheable, got %v", err)
+	}
+	if key(clonerefsBuild(prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: commit})) == reference {
+		t.Error("expected the key to depend on the resolved refs")
+	}
+	if _, err := cache.Key(context.Background(), cacheTestBuild("ci-op-4", "FROM pipeline:src"), nil); err == nil {
+		t.Error("expected an error when the base image cannot be resolved")
+	}
+}
+
+func TestBuildCacheRestore(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	cached := func(key string) ctrlruntimeclient.Object {
+		return cacheTestTag("build-cache", "pipeline:"+cacheTag(key), "sha256:cached", map[string]string{BuildCacheKeyAnnotation: key})
+	}
+	for _, tc := range []struct {
+		name     string
+		objects  []ctrlruntimeclient.Object
+		expected bool
+	}{
+		{
+			name: "nothing cached",
+		},
+		{
+			name:     "cached image is reused",
+			objects:  []ctrlruntimeclient.Object{cached(key)},
+			expected: true,
+		},
+		{
+			name:    "colliding tag for another key is ignored",
+			objects: []ctrlruntimeclient.Object{cached(key[:32] + "ffffffffffffffffffffffffffffffff")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			restored, err := NewBuildCache(client, "build-cache").Restore(context.Background(), cacheTestBuild("ci-op-1", "FROM pipeline:src"), key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if restored != tc.expected {
+				t.Fatalf("expected restored: %t, got %t", tc.expected, restored)
+			}
+			if !restored {
+				return
+			}
+			ist := &imagev1.ImageStreamTag{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1", Name: "pipeline:bin"}, ist); err != nil {
+				t.Fatalf("failed to get the output tag: %v", err)
+			}
+			expected := &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "build-cache", Name: "pipeline@sha256:cached"}
+			testhelper.Diff(t, "output tag source", ist.Tag.From, expected)
+		})
+	}
+}
+
+func TestBuildCacheStore(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	for _, tc := range []struct {
+		name    string
+		objects []ctrlruntimeclient.Object
+	}{
+		{
+			name:    "first image",
+			objects: []ctrlruntimeclient.Object{cacheTestTag("ci-op-1", "pipeline:bin", "sha256:built", nil)},
+		},
+		{
+			name: "earlier image is replaced",
+			objects: []ctrlruntimeclient.Object{
+				cacheTestTag("ci-op-1", "pipeline:bin", "sha256:built", nil),
+				&imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "build-cache", Name: api.PipelineImageStream}},
+				cacheTestTag("build-cache", "pipeline:"+cacheTag(key), "sha256:old", map[string]string{BuildCacheKeyAnnotation: key}),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			cache := NewBuildCache(client, "build-cache")
+			cache.now = func() time.Time { return time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC) }
+			if err := cache.Store(context.Background(), cacheTestBuild("ci-op-1", "FROM pipeline:src"), key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ist := &imagev1.ImageStreamTag{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "build-cache", Name: "pipeline:" + cacheTag(key)}, ist); err != nil {
+				t.Fatalf("failed to get the cache tag: %v", err)
+			}
+			testhelper.Diff(t, "key annotation", ist.Annotations[BuildCacheKeyAnnotation], key)
+			testhelper.Diff(t, "last used annotation", ist.Annotations[BuildCacheLastUsedAnnotation], "2024-06-04T12:00:00Z")
+			expected := &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "ci-op-1", Name: "pipeline@sha256:built"}
+			testhelper.Diff(t, "cache tag source", ist.Tag.From, expected)
+		})
+	}
+}
+
+func TestBuildCacheLastUsed(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	now := time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		lastUsed string
+		expected string
+	}{
+		{
+			name:     "use is recorded",
+			expected: "2024-06-04T12:00:00Z",
+		},
+		{
+			name:     "earlier use is refreshed",
+			lastUsed: "2024-06-03T12:00:00Z",
+			expected: "2024-06-04T12:00:00Z",
+		},
+		{
+			name:     "recent use is kept",
+			lastUsed: "2024-06-04T11:30:00Z",
+			expected: "2024-06-04T11:30:00Z",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{BuildCacheKeyAnnotation: key}
+			if tc.lastUsed != "" {
+				annotations[BuildCacheLastUsedAnnotation] = tc.lastUsed
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(cacheTestTag("build-cache", "pipeline:"+cacheTag(key), "sha256:cached", annotations)).Build()
+			cache := NewBuildCache(client, "build-cache")
+			cache.now = func() time.Time { return now }
+			if _, err := cache.Restore(context.Background(), cacheTestBuild("ci-op-1", "FROM pipeline:src"), key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ist := &imagev1.ImageStreamTag{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "build-cache", Name: "pipeline:" + cacheTag(key)}, ist); err != nil {
+				t.Fatalf("failed to get the cache tag: %v", err)
+			}
+			testhelper.Diff(t, "last used annotation", ist.Annotations[BuildCacheLastUsedAnnotation], tc.expected)
+			testhelper.Diff(t, "key annotation", ist.Annotations[BuildCacheKeyAnnotation], key)
+		})
+	}
+}
+
+func TestBuildCachePrune(t *testing.T) {
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	lastUsed := func(when string) map[string]string {
+		return map[string]string{BuildCacheLastUsedAnnotation: when}
+	}
+	stream := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "build-cache", Name: api.PipelineImageStream},
+		Spec: imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{
+			{Name: "cache-recent", Annotations: lastUsed("2024-06-09T12:00:00Z")},
+			{Name: "cache-unused", Annotations: lastUsed("2024-06-01T12:00:00Z")},
+			{Name: "cache-recently-tagged"},
+			{Name: "cache-tagged-long-ago"},
+			{Name: "other", Annotations: lastUsed("2024-06-01T12:00:00Z")},
+		}},
+		Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+			{Tag: "cache-recently-tagged", Items: []imagev1.TagEvent{{Created: metav1.NewTime(now.Add(-time.Hour))}}},
+			{Tag: "cache-tagged-long-ago", Items: []imagev1.TagEvent{{Created: metav1.NewTime(now.Add(-30 * 24 * time.Hour))}}},
+		}},
+	}
+	objects := []ctrlruntimeclient.Object{stream}
+	for _, tag := range stream.Spec.Tags {
+		objects = append(objects, cacheTestTag("build-cache", "pipeline:"+tag.Name, "sha256:"+tag.Name, tag.Annotations))
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(objects...).Build()
+	cache := NewBuildCache(client, "build-cache")
+	cache.now = func() time.Time { return now }
+	if err := cache.prune(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := &imagev1.ImageStreamTagList{}
+	if err := client.List(context.Background(), tags, ctrlruntimeclient.InNamespace("build-cache")); err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	var names []string
+	for _, tag := range tags.Items {
+		names = append(names, tag.Name)
+	}
+	testhelper.Diff(t, "remaining tags", names, []string{"pipeline:cache-recent", "pipeline:cache-recently-tagged", "pipeline:other"})
+}
@@ -18,6 +18,9 @@ type BuildClient interface {
 	NodeArchitectures() []string
 	ManifestToolDockerCfg() string
 	LocalRegistryDNS() string
+	// BuildCache returns the cache of images built by earlier jobs, nil when
+	// images are always built.
+	BuildCache() *BuildCache
 }
 
 type buildClient struct {
@@ -26,15 +29,17 @@ type buildClient struct {
 	nodeArchitectures     []string
 	manifestToolDockerCfg string
 	localRegistryDNS      string
+	buildCache            *BuildCache
 }
 
-func NewBuildClient(client loggingclient.LoggingClient, restClient rest.Interface, nodeArchitectures []string, manifestToolDockerCfg, localRegistryDNS string) BuildClient {
+func NewBuildClient(client loggingclient.LoggingClient, restClient rest.Interface, nodeArchitectures []string, manifestToolDockerCfg, localRegistryDNS string, buildCache *BuildCache) BuildClient {
 	return &buildClient{
 		LoggingClient:         client,
 		client:                restClient,
 		nodeArchitectures:     nodeArchitectures,
 		manifestToolDockerCfg: manifestToolDockerCfg,
 		localRegistryDNS:      localRegistryDNS,
+		buildCache:            buildCache,
 	}
 }
 
@@ -59,3 +64,7 @@ func (c *buildClient) ManifestToolDockerCfg() string {
 func (c *buildClient) LocalRegistryDNS() string {
 	return c.localRegistryDNS
 }
+
+func (c *buildClient) BuildCache() *BuildCache {
+	return c.buildCache
+}
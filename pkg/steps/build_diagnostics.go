@@ -0,0 +1,61 @@
+package steps
+
+import (
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// buildFailureSignature is a known cause of build failures, recognized by
+// patterns in the build log.
+type buildFailureSignature struct {
+	reason   results.Reason
+	patterns []string
+	summary  string
+	hint     string
+}
+
+var buildFailureSignatures = []buildFailureSignature{
+	{
+		reason:   "build_dns_failure",
+		patterns: []string{"Could not resolve host: ", "Temporary failure in name resolution", ": no such host"},
+		summary:  "a host could not be resolved during the build",
+		hint:     "this is usually a transient infrastructure problem, retest the job; if it persists, make sure every host the build reaches is publicly resolvable",
+	},
+	{
+		reason:   "build_rate_limited",
+		patterns: []string{"toomanyrequests", "429 Too Many Requests", "You have reached your pull rate limit"},
+		summary:  "the build was rate limited by a remote registry or server",
+		hint:     "mirror the images or artifacts the build pulls into the CI registry instead of pulling them from a public source",
+	},
+	{
+		reason:   "build_out_of_disk",
+		patterns: []string{"no space left on device"},
+		summary:  "the build ran out of disk space",
+		hint:     "reduce the size of the build context and the image, e.g. by cleaning package manager caches in the same RUN instruction that populates them",
+	},
+	{
+		reason:   "build_missing_base_image",
+		patterns: []string{"error: build error: no such image", "manifest unknown", "repository does not exist or may require authorization"},
+		summary:  "a base image of the build could not be found",
+		hint:     "check that every FROM instruction references an image that exists and that images replaced from the pipeline are declared in the configuration",
+	},
+}
+
+// diagnoseBuildLog returns the first known failure signature found in the
+// build log, or nil if none is recognized.
+func diagnoseBuildLog(log string) *buildFailureSignature {
+	for i, signature := range buildFailureSignatures {
+		for _, pattern := range signature.patterns {
+			if strings.Contains(log, pattern) {
+				return &buildFailureSignatures[i]
+			}
+		}
+	}
+	return nil
+}
+
+// wrap annotates the build failure with the reason and a remediation hint.
+func (s *buildFailureSignature) wrap(err error) error {
+	return results.ForReason(s.reason).WithError(err).Errorf("%v\n\nDiagnosis: %s\nHint: %s", err, s.summary, s.hint)
+}
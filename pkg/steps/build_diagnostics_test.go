@@ -0,0 +1,64 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+func TestDiagnoseBuildLog(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		log      string
+		expected results.Reason
+	}{
+		{
+			name: "unknown failure",
+			log:  "error: build error: building at STEP \"RUN make\": exit status 2",
+		},
+		{
+			name:     "dns failure",
+			log:      "curl: (6) Could not resolve host: mirror.example.com",
+			expected: "build_dns_failure",
+		},
+		{
+			name:     "rate limit",
+			log:      "Error: reading manifest latest in docker.io/library/fedora: toomanyrequests: You have reached your pull rate limit",
+			expected: "build_rate_limited",
+		},
+		{
+			name:     "out of disk",
+			log:      "error: build error: write /var/lib/containers/storage/overlay/l/file: no space left on device",
+			expected: "build_out_of_disk",
+		},
+		{
+			name:     "missing base image",
+			log:      "Error: initializing source docker://quay.io/org/missing:latest: reading manifest latest in quay.io/org/missing: manifest unknown",
+			expected: "build_missing_base_image",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual results.Reason
+			if diagnosis := diagnoseBuildLog(tc.log); diagnosis != nil {
+				actual = diagnosis.reason
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected diagnosis: %s", diff)
+			}
+		})
+	}
+}
+
+func TestBuildFailureSignatureWrap(t *testing.T) {
+	err := diagnoseBuildLog("no space left on device").wrap(errors.New("the build failed"))
+	expected := "the build failed\n\nDiagnosis: the build ran out of disk space\nHint: reduce the size of the build context and the image, e.g. by cleaning package manager caches in the same RUN instruction that populates them"
+	if diff := cmp.Diff(expected, err.Error()); diff != "" {
+		t.Errorf("unexpected error message: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"build_out_of_disk"}, results.Reasons(err)); diff != "" {
+		t.Errorf("unexpected reasons: %s", diff)
+	}
+}
@@ -0,0 +1,325 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+const (
+	// BuildBackendAnnotation marks the builds which are not created with the
+	// Build API but executed by another backend.
+	BuildBackendAnnotation = "ci.openshift.io/build-backend"
+
+	buildahImage = "quay.io/buildah/stable:v1.37"
+	// buildahServiceAccount is allowed to push to the pipeline image stream.
+	// The `builder` service account of the Build API cannot be used, as it
+	// does not exist on clusters with the Build capability disabled.
+	buildahServiceAccount = "ci-operator-buildah"
+	// buildahClusterRole grants the use of the SCC allowing the buildah
+	// container to add the SETUID and SETGID capabilities in a user
+	// namespace. It only exists on the clusters supporting the backend.
+	buildahClusterRole = "ci-operator-buildah"
+
+	buildahWorkspace     = "/workspace"
+	buildahContext       = buildahWorkspace + "/context"
+	buildahAuthFile      = buildahWorkspace + "/auth.json"
+	buildahPullSecretDir = "/var/run/ci-operator/pull-secret"
+	buildahSecretsDir    = "/var/run/ci-operator/secrets"
+	serviceAccountDir    = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// buildahPushFailedExitCode is the exit code of the buildah container
+	// when the built image could not be pushed. Like a build failing to push
+	// to the registry, the build is then retried.
+	buildahPushFailedExitCode = 75
+)
+
+// setBuildBackend records the backend executing the build.
+func setBuildBackend(build *buildapi.Build, backend api.BuildBackend) {
+	if backend != api.BuildBackendBuildah {
+		return
+	}
+	if build.Annotations == nil {
+		build.Annotations = map[string]string{}
+	}
+	build.Annotations[BuildBackendAnnotation] = string(backend)
+}
+
+func isBuildahBuild(build buildapi.Build) bool {
+	return build.Annotations[BuildBackendAnnotation] == string(api.BuildBackendBuildah)
+}
+
+// handleBuildahBuild executes the build in a pod running buildah instead of
+// creating it with the Build API.
+func handleBuildahBuild(ctx context.Context, client BuildClient, podClient kubernetes.PodClient, build buildapi.Build) error {
+	if err := setupBuildahRBAC(ctx, client, build.Namespace); err != nil {
+		return fmt.Errorf("could not build %s with buildah: %w", build.Name, err)
+	}
+	pod, err := buildahPod(build, client.LocalRegistryDNS())
+	if err != nil {
+		return fmt.Errorf("could not build %s with buildah: %w", build.Name, err)
+	}
+	const attempts = 5
+	var errs []error
+	if err := wait.ExponentialBackoff(wait.Backoff{Duration: time.Minute, Factor: 1.5, Steps: attempts}, func() (bool, error) {
+		finished, err := RunPod(ctx, podClient, pod.DeepCopy(), false)
+		if err == nil {
+			return true, nil
+		}
+		errs = append(errs, err)
+		if !isBuildahInfraFailure(finished) {
+			return false, fmt.Errorf("could not build %s with buildah: %w", build.Name, err)
+		}
+		logrus.Infof("Build %s previously failed from an infrastructure error, retrying...", build.Name)
+		return false, nil
+	}); err != nil {
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("build %s not successful after %d attempts: %w", build.Name, attempts, utilerrors.NewAggregate(errs))
+		}
+		return err
+	}
+	return nil
+}
+
+// isBuildahInfraFailure determines whether the buildah pod failed for reasons
+// the Build API would retry: eviction, running out of memory, a failed push or
+// a log hinting at an infrastructure error.
+func isBuildahInfraFailure(pod *corev1.Pod) bool {
+	if pod == nil {
+		return false
+	}
+	if pod.Status.Reason == "Evicted" {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		t := status.State.Terminated
+		if t == nil {
+			continue
+		}
+		if t.ExitCode == buildahPushFailedExitCode || t.Reason == "OOMKilled" || hintsAtInfraReason(t.Message) {
+			return true
+		}
+	}
+	return false
+}
+
+// setupBuildahRBAC creates the service account the buildah pods run as, which
+// may push to the pipeline image stream and use the SCC of the backend.
+func setupBuildahRBAC(ctx context.Context, client ctrlruntimeclient.Client, namespace string) error {
+	m := metav1.ObjectMeta{Namespace: namespace, Name: buildahServiceAccount}
+	sa := &corev1.ServiceAccount{ObjectMeta: m}
+	role := &rbacv1.Role{
+		ObjectMeta: m,
+		// the rules of system:image-pusher, for the pipeline image stream only
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{"", "image.openshift.io"},
+			Resources:     []string{"imagestreams/layers"},
+			ResourceNames: []string{api.PipelineImageStream},
+			Verbs:         []string{"get", "update"},
+		}},
+	}
+	subjects := []rbacv1.Subject{{Kind: "ServiceAccount", Name: buildahServiceAccount, Namespace: namespace}}
+	bindings := []rbacv1.RoleBinding{
+		{
+			ObjectMeta: m,
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: buildahServiceAccount},
+			Subjects:   subjects,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: buildahServiceAccount + "-scc"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: buildahClusterRole},
+			Subjects:   subjects,
+		},
+	}
+	return util.CreateRBACs(ctx, sa, role, bindings, client, time.Second, time.Minute)
+}
+
+// pullSpecFor determines the pull spec of an image referenced by a build:
+// image streams are pulled from the local registry.
+func pullSpecFor(ref corev1.ObjectReference, namespace, registry string) (string, error) {
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	switch ref.Kind {
+	case "DockerImage":
+		return ref.Name, nil
+	case "ImageStreamTag", "ImageStreamImage":
+		return fmt.Sprintf("%s/%s/%s", registry, namespace, ref.Name), nil
+	}
+	return "", fmt.Errorf("unsupported image reference kind %q", ref.Kind)
+}
+
+// buildahPod translates a build into a pod running buildah. The files of the
+// source images are copied into the build context from the root filesystem of
+// a buildah container of those images, so the source images need no shell or
+// utilities of their own. The inputs of the Dockerfile are pulled and the
+// built image is pushed into the image stream of the output. The pod runs
+// unprivileged: buildah uses the vfs storage driver and chroot isolation, and
+// the SETUID and SETGID capabilities it needs to switch users when running
+// the instructions of the Dockerfile are only granted in a user namespace, so
+// the users it maps are not privileged on the node. restricted-v2 does not
+// allow adding them: the SCC allowing it is granted by the
+// `ci-operator-buildah` cluster role, which is expected to use
+// `allowedCapabilities: [SETUID, SETGID]` and to require user namespaces.
+func buildahPod(build buildapi.Build, registry string) (*corev1.Pod, error) {
+	source, strategy := build.Spec.Source, build.Spec.Strategy.DockerStrategy
+	if source.Type == buildapi.BuildSourceGit || source.Git != nil || source.Binary != nil || len(source.ConfigMaps) > 0 {
+		return nil, fmt.Errorf("only Dockerfile and image sources are supported")
+	}
+	if strategy == nil {
+		return nil, fmt.Errorf("only the Docker strategy is supported")
+	}
+	if build.Spec.Output.To == nil {
+		return nil, fmt.Errorf("the build has no output")
+	}
+	output, err := pullSpecFor(*build.Spec.Output.To, build.Namespace, registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+
+	workspace := corev1.VolumeMount{Name: "workspace", MountPath: buildahWorkspace}
+	volumes := []corev1.Volume{{Name: "workspace", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	script := []string{
+		"set -euo pipefail",
+		fmt.Sprintf("buildah() { command buildah --root=%[1]s/storage --runroot=%[1]s/run --storage-driver=vfs \"$@\"; }", buildahWorkspace),
+		fmt.Sprintf("mkdir -p %s", buildahContext),
+	}
+	mounts := []corev1.VolumeMount{workspace}
+
+	if strategy.PullSecret != nil {
+		volumes = append(volumes, corev1.Volume{Name: "pull-secret", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: strategy.PullSecret.Name}}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "pull-secret", MountPath: buildahPullSecretDir, ReadOnly: true})
+		script = append(script, fmt.Sprintf("cp %s/%s %s", buildahPullSecretDir, corev1.DockerConfigJsonKey, buildahAuthFile))
+	}
+	// the service account is allowed to pull from and push to the local registry
+	script = append(script, fmt.Sprintf("buildah login --authfile=%[1]s --cert-dir=%[2]s --username=serviceaccount --password-stdin %[3]s < %[2]s/token", buildahAuthFile, serviceAccountDir, registry))
+
+	for i, image := range source.Images {
+		pullSpec, err := pullSpecFor(image.From, build.Namespace, registry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source image %s: %w", image.From.Name, err)
+		}
+		if len(image.Paths) == 0 && len(image.As) == 0 {
+			continue
+		}
+		script = append(script, fmt.Sprintf("buildah pull --quiet --authfile=%s --cert-dir=%s %s", buildahAuthFile, serviceAccountDir, shellQuote(pullSpec)))
+		if len(image.Paths) > 0 {
+			// mounting with the vfs driver needs no privileges, and the
+			// tools of the buildah image copy the files
+			container := fmt.Sprintf("context-%d", i)
+			script = append(script,
+				fmt.Sprintf("buildah from --quiet --pull=never --name=%s %s > /dev/null", container, shellQuote(pullSpec)),
+				fmt.Sprintf(`root="$(buildah mount %s)"`, container),
+			)
+			for _, p := range image.Paths {
+				destination := path.Join(buildahContext, p.DestinationDir)
+				script = append(script, fmt.Sprintf(`mkdir -p %[2]s && cp -a "${root}"%[1]s %[2]s`, shellQuote(p.SourcePath), shellQuote(destination)))
+			}
+			script = append(script, fmt.Sprintf("buildah umount %[1]s > /dev/null && buildah rm %[1]s > /dev/null", container))
+		}
+		for _, as := range image.As {
+			script = append(script, fmt.Sprintf("buildah tag %s %s", shellQuote(pullSpec), shellQuote(as)))
+		}
+	}
+
+	for i, secret := range source.Secrets {
+		name := fmt.Sprintf("secret-%d", i)
+		mountPath := path.Join(buildahSecretsDir, name)
+		volumes = append(volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secret.Secret.Name}}})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: mountPath, ReadOnly: true})
+		destination := path.Join(buildahContext, secret.DestinationDir)
+		script = append(script, fmt.Sprintf("mkdir -p %[2]s && cp -L %[1]s/* %[2]s", mountPath, shellQuote(destination)))
+	}
+
+	contextDir := path.Join(buildahContext, source.ContextDir)
+	dockerfile := path.Join(contextDir, "Dockerfile")
+	if strategy.DockerfilePath != "" && source.Dockerfile == nil {
+		dockerfile = path.Join(contextDir, strategy.DockerfilePath)
+	}
+	var env []corev1.EnvVar
+	if source.Dockerfile != nil {
+		env = append(env, corev1.EnvVar{Name: "DOCKERFILE", Value: *source.Dockerfile})
+		script = append(script, fmt.Sprintf(`mkdir -p %s && printf '%%s\n' "$DOCKERFILE" > %s`, shellQuote(contextDir), shellQuote(dockerfile)))
+	}
+	if strategy.From != nil {
+		// like the Build API, replace the image of the last stage
+		from, err := pullSpecFor(*strategy.From, build.Namespace, registry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base image: %w", err)
+		}
+		env = append(env, corev1.EnvVar{Name: "FROM_IMAGE", Value: from})
+		script = append(script, fmt.Sprintf(`awk -v from="$FROM_IMAGE" 'NR == FNR { if (toupper($1) == "FROM") last = FNR; next } FNR == last { for (i = 2; i <= NF; i++) if ($i !~ /^--/) { $i = from; break } } { print }' %[1]s %[1]s > %[1]s.ci && mv %[1]s.ci %[1]s`, shellQuote(dockerfile)))
+	}
+
+	bud := []string{"buildah", "bud", "--layers=false", "--no-cache", "--isolation=chroot", "--authfile=" + buildahAuthFile, "--cert-dir=" + serviceAccountDir, "-f", shellQuote(dockerfile), "-t", shellQuote(output)}
+	for _, arg := range strategy.BuildArgs {
+		bud = append(bud, "--build-arg", shellQuote(fmt.Sprintf("%s=%s", arg.Name, arg.Value)))
+	}
+	for _, e := range strategy.Env {
+		if e.Name == "BUILD_LOGLEVEL" {
+			continue
+		}
+		bud = append(bud, "--env", shellQuote(fmt.Sprintf("%s=%s", e.Name, e.Value)))
+	}
+	for _, label := range build.Spec.Output.ImageLabels {
+		bud = append(bud, "--label", shellQuote(fmt.Sprintf("%s=%s", label.Name, label.Value)))
+	}
+	bud = append(bud, shellQuote(contextDir))
+	script = append(script,
+		strings.Join(bud, " "),
+		fmt.Sprintf("buildah push --authfile=%s --cert-dir=%s %s docker://%s || exit %d", buildahAuthFile, serviceAccountDir, shellQuote(output), shellQuote(output), buildahPushFailedExitCode),
+	)
+
+	annotations := map[string]string{}
+	for key, value := range build.Annotations {
+		annotations[key] = value
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-build", build.Name),
+			Namespace:       build.Namespace,
+			Labels:          build.Labels,
+			Annotations:     annotations,
+			OwnerReferences: build.OwnerReferences,
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: buildahServiceAccount,
+			HostUsers:          ptr.To(false),
+			RestartPolicy:      corev1.RestartPolicyNever,
+			NodeSelector:       build.Spec.NodeSelector,
+			Containers: []corev1.Container{{
+				Name:      "buildah",
+				Image:     buildahImage,
+				Command:   []string{"/bin/bash", "-c", strings.Join(script, "\n")},
+				Env:       env,
+				Resources: build.Spec.Resources,
+				SecurityContext: &corev1.SecurityContext{
+					Privileged:   ptr.To(false),
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SETUID", "SETGID"}},
+				},
+				VolumeMounts:             mounts,
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			}},
+			Volumes: volumes,
+		},
+	}
+	return pod, nil
+}
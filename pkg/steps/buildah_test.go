@@ -0,0 +1,156 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestBuildahPod(t *testing.T) {
+	jobSpec := &api.JobSpec{
+		JobSpec: downwardapi.JobSpec{
+			Job:       "job",
+			BuildID:   "buildId",
+			ProwJobID: "prowJobId",
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "masterSHA",
+			},
+		},
+	}
+	jobSpec.SetNamespace("test-namespace")
+	dockerfile := "FROM pipeline:root\nRUN make"
+	pullSecret := &coreapi.Secret{}
+
+	for _, tc := range []struct {
+		name          string
+		build         *buildapi.Build
+		expectedError string
+	}{
+		{
+			name: "project image with inputs",
+			build: buildFromSource(jobSpec, "base", "image",
+				buildapi.BuildSource{
+					Type: buildapi.BuildSourceImage,
+					Images: []buildapi.ImageSource{{
+						From:  coreapi.ObjectReference{Kind: "ImageStreamTag", Name: "pipeline:src"},
+						As:    []string{"pipeline:src"},
+						Paths: []buildapi.ImageSourcePath{{SourcePath: "/go/src/github.com/org/repo/images/image/.", DestinationDir: "."}},
+					}},
+				},
+				"sha256:base", "Dockerfile.rhel", api.ResourceConfiguration{}, pullSecret, []api.BuildArg{{Name: "TAGS", Value: "release"}}, ""),
+		},
+		{
+			name: "image from a literal Dockerfile",
+			build: buildFromSource(jobSpec, "root", "bin",
+				buildapi.BuildSource{Type: buildapi.BuildSourceDockerfile, Dockerfile: &dockerfile},
+				"sha256:root", "", api.ResourceConfiguration{}, nil, nil, ""),
+		},
+		{
+			name: "git source is not supported",
+			build: buildFromSource(jobSpec, "", "root",
+				buildapi.BuildSource{Type: buildapi.BuildSourceGit, Git: &buildapi.GitBuildSource{URI: "https://github.com/org/repo.git"}},
+				"", "", api.ResourceConfiguration{}, nil, nil, ""),
+			expectedError: "only Dockerfile and image sources are supported",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setBuildBackend(tc.build, api.BuildBackendBuildah)
+			if !isBuildahBuild(*tc.build) {
+				t.Fatal("expected the build to be executed by buildah")
+			}
+			pod, err := buildahPod(*tc.build, "registry.svc:5000")
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tc.expectedError {
+				t.Fatalf("expected error %q, got %q", tc.expectedError, actualError)
+			}
+			if err == nil {
+				testhelper.CompareWithFixture(t, pod)
+			}
+		})
+	}
+}
+
+func TestSetBuildBackend(t *testing.T) {
+	build := buildapi.Build{}
+	setBuildBackend(&build, api.BuildBackendBuild)
+	if isBuildahBuild(build) || build.Annotations != nil {
+		t.Errorf("expected the build not to be annotated, got %v", build.Annotations)
+	}
+}
+
+func TestIsBuildahInfraFailure(t *testing.T) {
+	terminated := func(state coreapi.ContainerStateTerminated) *coreapi.Pod {
+		return &coreapi.Pod{Status: coreapi.PodStatus{ContainerStatuses: []coreapi.ContainerStatus{{
+			Name:  "buildah",
+			State: coreapi.ContainerState{Terminated: &state},
+		}}}}
+	}
+	for _, tc := range []struct {
+		name     string
+		pod      *coreapi.Pod
+		expected bool
+	}{
+		{name: "no pod"},
+		{name: "evicted pod", pod: &coreapi.Pod{Status: coreapi.PodStatus{Reason: "Evicted"}}, expected: true},
+		{name: "failed push", pod: terminated(coreapi.ContainerStateTerminated{ExitCode: buildahPushFailedExitCode}), expected: true},
+		{name: "out of memory", pod: terminated(coreapi.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}), expected: true},
+		{name: "log hints at infrastructure", pod: terminated(coreapi.ContainerStateTerminated{ExitCode: 1, Message: "curl: (6) Could not resolve host: mirror"}), expected: true},
+		{name: "failed instruction", pod: terminated(coreapi.ContainerStateTerminated{ExitCode: 1, Message: "error building at STEP \"RUN make\": exit status 2"})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isBuildahInfraFailure(tc.pod); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetupBuildahRBAC(t *testing.T) {
+	// an existing service account is not waited on to get its pull secrets
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: buildahServiceAccount},
+	}).Build()
+	if err := setupBuildahRBAC(context.Background(), client, "test-namespace"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	role := &rbacv1.Role{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "test-namespace", Name: buildahServiceAccount}, role); err != nil {
+		t.Fatalf("failed to get the role: %v", err)
+	}
+	expectedRules := []rbacv1.PolicyRule{{
+		APIGroups:     []string{"", "image.openshift.io"},
+		Resources:     []string{"imagestreams/layers"},
+		ResourceNames: []string{"pipeline"},
+		Verbs:         []string{"get", "update"},
+	}}
+	testhelper.Diff(t, "rules", role.Rules, expectedRules)
+	bindings := &rbacv1.RoleBindingList{}
+	if err := client.List(context.Background(), bindings, ctrlruntimeclient.InNamespace("test-namespace")); err != nil {
+		t.Fatalf("failed to list the role bindings: %v", err)
+	}
+	var roles []rbacv1.RoleRef
+	for _, binding := range bindings.Items {
+		testhelper.Diff(t, "subjects", binding.Subjects, []rbacv1.Subject{{Kind: "ServiceAccount", Name: buildahServiceAccount, Namespace: "test-namespace"}})
+		roles = append(roles, binding.RoleRef)
+	}
+	expectedRoles := []rbacv1.RoleRef{{Kind: "Role", Name: buildahServiceAccount}, {Kind: "ClusterRole", Name: buildahClusterRole}}
+	testhelper.Diff(t, "bound roles", roles, expectedRoles)
+}
@@ -78,6 +78,7 @@ func (s *bundleSourceStep) run(ctx context.Context) error {
 		nil,
 		"",
 	)
+	setBuildBackend(build, s.config.BuildBackend)
 
 	// Bundle images are not multi-arch by design. Here we build it without creating a manifest-listed image.
 	// Note that we are not configuring a node selector here, so the build will be scheduled on any available
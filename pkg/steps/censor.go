@@ -0,0 +1,67 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	coreapi "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/prow/pkg/secretutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// NamespaceCensor censors the content of the secrets the sidecar censors in
+// the logs it uploads from the namespace, the credentials of registries
+// included. Output of pods which ci-operator records itself must be censored
+// with it, as the censor of ci-operator only knows about its own secrets.
+func NamespaceCensor(ctx context.Context, client ctrlruntimeclient.Reader, namespace string) (secretutil.Censorer, error) {
+	secrets := coreapi.SecretList{}
+	if err := client.List(ctx, &secrets, ctrlruntimeclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("could not list secrets to determine content to censor: %w", err)
+	}
+	var values [][]byte
+	for _, secret := range secrets.Items {
+		if _, skip := secret.ObjectMeta.Labels[api.SkipCensoringLabel]; skip {
+			continue
+		}
+		if _, skip := secret.ObjectMeta.Annotations["kubernetes.io/service-account.name"]; skip {
+			continue
+		}
+		for key, value := range secret.Data {
+			values = append(values, value)
+			if key == coreapi.DockerConfigJsonKey || key == coreapi.DockerConfigKey {
+				values = append(values, registryAuths(value)...)
+			}
+		}
+	}
+	censor := secretutil.NewCensorer()
+	censor.RefreshBytes(values...)
+	return censor, nil
+}
+
+// registryAuths extracts the credentials from a registry configuration, as
+// they may be printed on their own.
+func registryAuths(raw []byte) [][]byte {
+	type auth struct {
+		Auth string `json:"auth"`
+	}
+	var config struct {
+		Auths map[string]auth `json:"auths"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil || config.Auths == nil {
+		var legacy map[string]auth
+		if err := json.Unmarshal(raw, &legacy); err != nil {
+			return nil
+		}
+		config.Auths = legacy
+	}
+	var auths [][]byte
+	for _, a := range config.Auths {
+		if a.Auth != "" {
+			auths = append(auths, []byte(a.Auth))
+		}
+	}
+	return auths
+}
@@ -45,6 +45,7 @@ func E2ETestStep(
 	templateClient steps.TemplateClient,
 	jobSpec *api.JobSpec,
 	resources api.ResourceConfiguration,
+	artifactOptions steps.ArtifactOptions,
 ) (api.Step, error) {
 	var template *templateapi.Template
 	if err := yaml.Unmarshal([]byte(installTemplateE2E), &template); err != nil {
@@ -97,7 +98,7 @@ func E2ETestStep(
 		params = api.NewOverrideParameters(params, overrides)
 	}
 
-	step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, resources)
+	step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, resources, artifactOptions)
 	subTests, ok := step.(nestedSubTests)
 	if !ok {
 		return nil, fmt.Errorf("unexpected %T", step)
@@ -30,6 +30,9 @@ type inputImageTagStep struct {
 	config  *api.InputImageTagStepConfiguration
 	client  loggingclient.LoggingClient
 	jobSpec *api.JobSpec
+	// importCoordinator deduplicates the imports of external images with
+	// other jobs, when set
+	importCoordinator *utils.ImportCoordinator
 
 	imageName string
 }
@@ -98,6 +101,20 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 			Name:      fmt.Sprintf("%s@%s", s.config.BaseImage.Name, s.imageName),
 			Namespace: s.config.BaseImage.Namespace,
 		}
+	} else if s.config.PullSecret != "" {
+		// the import uses the pull secrets of the namespace it happens in,
+		// so images needing a step's own secret cannot be shared with other jobs
+		secret := &coreapi.Secret{}
+		name := api.ExternalPullSecretName(s.config.PullSecret)
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: name}, secret); err != nil {
+			return fmt.Errorf("could not get pull secret %s to import %s: %w", name, from.Name, err)
+		}
+	} else if s.importCoordinator != nil {
+		if shared, err := s.importCoordinator.Source(ctx, from.Name); err != nil {
+			logrus.WithError(err).Warnf("Failed to import %s together with other jobs, importing it directly.", from.Name)
+		} else {
+			from = shared
+		}
 	}
 
 	ist := &imagev1.ImageStreamTag{
@@ -189,11 +206,13 @@ func (s *inputImageTagStep) Objects() []ctrlruntimeclient.Object {
 func InputImageTagStep(
 	config *api.InputImageTagStepConfiguration,
 	client loggingclient.LoggingClient,
-	jobSpec *api.JobSpec) api.Step {
+	jobSpec *api.JobSpec,
+	importCoordinator *utils.ImportCoordinator) api.Step {
 	// when source and destination client are the same, we don't need to use external imports
 	return &inputImageTagStep{
-		config:  config,
-		client:  client,
-		jobSpec: jobSpec,
+		config:            config,
+		client:            client,
+		jobSpec:           jobSpec,
+		importCoordinator: importCoordinator,
 	}
 }
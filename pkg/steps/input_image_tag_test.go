@@ -16,6 +16,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
 
 func TestInputImageTagStep(t *testing.T) {
@@ -71,7 +72,7 @@ func TestInputImageTagStep(t *testing.T) {
 	// Make a step instance
 	jobspec := &api.JobSpec{}
 	jobspec.SetNamespace("target-namespace")
-	iits := InputImageTagStep(&config, client, jobspec)
+	iits := InputImageTagStep(&config, client, jobspec, nil)
 
 	// Set up expectations for the step methods
 	specification := stepExpectation{
@@ -184,7 +185,7 @@ func TestInputImageTagStepExternal(t *testing.T) {
 	// Make a step instance
 	jobspec := &api.JobSpec{}
 	jobspec.SetNamespace("target-namespace")
-	iits := InputImageTagStep(&config, client, jobspec)
+	iits := InputImageTagStep(&config, client, jobspec, nil)
 
 	// Set up expectations for the step methods
 	specification := stepExpectation{
@@ -248,3 +249,62 @@ func TestInputImageTagStepExternal(t *testing.T) {
 		t.Errorf("Different ImageStreamTag 'pipeline:TO' after step execution:\n%s", diff.ObjectReflectDiff(expectedImageStreamTag, targetImageStreamTag))
 	}
 }
+
+func TestInputImageTagStepPullSecret(t *testing.T) {
+	pipeline := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "target-namespace", Name: api.PipelineImageStream},
+		Spec:       imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{{Name: "TO"}}},
+		Status: imagev1.ImageStreamStatus{
+			PublicDockerImageRepository: "some-reg/target-namespace/pipeline",
+			Tags: []imagev1.NamedTagEventList{{
+				Tag:   "TO",
+				Items: []imagev1.TagEvent{{Image: "sha256:47e2f82dbede8ff990e6e240f82d78830e7558f7b30df7bd8c0693992018b1e3"}},
+			}},
+		},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "target-namespace", Name: api.ExternalPullSecretName("private-registry")}}
+	for _, tc := range []struct {
+		name          string
+		objects       []ctrlruntimeclient.Object
+		expectedError bool
+	}{
+		{
+			name:          "missing pull secret",
+			objects:       []ctrlruntimeclient.Object{pipeline.DeepCopy()},
+			expectedError: true,
+		},
+		{
+			name:    "image is imported in the test namespace with the pull secret",
+			objects: []ctrlruntimeclient.Object{pipeline.DeepCopy(), secret.DeepCopy()},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := api.InputImageTagStepConfiguration{
+				InputImage: api.InputImage{
+					To:        "TO",
+					BaseImage: api.ImageStreamTagReference{Namespace: "private", Name: "tools", Tag: "latest"},
+				},
+				PullSecret: "private-registry",
+			}
+			client := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build())
+			jobspec := &api.JobSpec{}
+			jobspec.SetNamespace("target-namespace")
+			coordinator := utils.NewImportCoordinator(client, "ci-imports", func() string { return "job" })
+			err := InputImageTagStep(&config, client, jobspec, coordinator).Run(context.Background())
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("expected error: %t, got %v", tc.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			ist := &imagev1.ImageStreamTag{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "target-namespace", Name: "pipeline:TO"}, ist); err != nil {
+				t.Fatalf("failed to get the imported tag: %v", err)
+			}
+			expected := &corev1.ObjectReference{Kind: "DockerImage", Name: "quay-proxy.ci.openshift.org/openshift/ci:private_tools_latest"}
+			if !equality.Semantic.DeepEqual(expected, ist.Tag.From) {
+				t.Errorf("unexpected source of the tag: %s", diff.ObjectReflectDiff(expected, ist.Tag.From))
+			}
+		})
+	}
+}
@@ -30,12 +30,32 @@ type leaseStep struct {
 	client  *lease.Client
 	leases  []stepLease
 	wrapped api.Step
+	leaseOptions
 
 	// for sending heartbeats during lease acquisition
 	namespace func() string
 }
 
-func LeaseStep(client *lease.Client, leases []api.StepLease, wrapped api.Step, namespace func() string) api.Step {
+// leaseOptions are shared by the steps which hold leases while the step they
+// wrap runs.
+type leaseOptions struct {
+	// renew keeps the leases through failed updates, for long-running tests
+	renew bool
+}
+
+// LeaseStepOption configures a lease or reservation step.
+type LeaseStepOption func(*leaseOptions)
+
+// WithLeaseRenewal renews the leases for as long as the wrapped step runs,
+// so that a temporary outage of the lease server does not end a step that
+// runs for days.
+func WithLeaseRenewal() LeaseStepOption {
+	return func(o *leaseOptions) {
+		o.renew = true
+	}
+}
+
+func LeaseStep(client *lease.Client, leases []api.StepLease, wrapped api.Step, namespace func() string, opts ...LeaseStepOption) api.Step {
 	ret := leaseStep{
 		client:    client,
 		wrapped:   wrapped,
@@ -44,6 +64,9 @@ func LeaseStep(client *lease.Client, leases []api.StepLease, wrapped api.Step, n
 	for _, l := range leases {
 		ret.leases = append(ret.leases, stepLease{StepLease: l})
 	}
+	for _, opt := range opts {
+		opt(&ret.leaseOptions)
+	}
 	return &ret
 }
 
@@ -116,6 +139,11 @@ func (s *leaseStep) run(ctx context.Context) error {
 	if err := acquireLeases(client, ctx, cancel, s.leases); err != nil {
 		return err
 	}
+	if s.renew {
+		for _, l := range s.leases {
+			client.Renew(l.resources...)
+		}
+	}
 	wrappedErr := results.ForReason("executing_test").ForError(s.wrapped.Run(ctx))
 	logrus.Infof("Releasing leases for test %s", s.Name())
 	releaseErr := results.ForReason("releasing_lease").ForError(releaseLeases(client, s.leases...))
@@ -257,3 +257,45 @@ func TestAcquireRelease(t *testing.T) {
 		t.Fatalf("wrong calls to the lease client: %s", diff.ObjectDiff(calls, expected))
 	}
 }
+
+type stepHeartbeats struct {
+	stepNeedsLease
+	client lease.Client
+}
+
+func (s *stepHeartbeats) Run(ctx context.Context) error {
+	if err := s.client.Heartbeat(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func TestLeaseRenewal(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		opts          []LeaseStepOption
+		expectedError string
+	}{
+		{
+			name:          "leases are lost on failed updates",
+			expectedError: `exceeded number of retries for lease "rtype_0"`,
+		},
+		{
+			name: "renewed leases are kept",
+			opts: []LeaseStepOption{WithLeaseRenewal()},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := lease.NewFakeClient("owner", "url", 0, map[string]error{"updateone owner rtype_0 leased 0": errors.New("injected error")}, nil)
+			step := stepHeartbeats{client: client}
+			err := LeaseStep(&client, []api.StepLease{{ResourceType: "rtype", Count: 1}}, &step, emptyNamespace, tc.opts...).Run(context.Background())
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, actualError)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package multi_stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// budget is the duration of a phase budget, zero when the phase has none.
+func budget(d *prowapi.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.Duration
+}
+
+// errBudgetExceeded is the cause of the cancellation of the context of a
+// phase that ran over its budget, as opposed to the test being cancelled.
+var errBudgetExceeded = errors.New("phase exceeded its budget")
+
+// runWithBudget runs the steps of a phase, stopping them when the phase runs
+// over its budget. A zero budget does not limit the phase.
+func (s *multiStageTestStep) runWithBudget(
+	ctx context.Context,
+	phase string,
+	steps []api.LiteralTestStep,
+	budget time.Duration,
+	env []coreapi.EnvVar,
+	secretVolumes []coreapi.Volume,
+	secretVolumeMounts []coreapi.VolumeMount,
+) (exceeded bool, err error) {
+	if budget == 0 {
+		return false, s.runSteps(ctx, phase, steps, env, secretVolumes, secretVolumeMounts)
+	}
+	phaseCtx, cancel := context.WithTimeoutCause(ctx, budget, errBudgetExceeded)
+	defer cancel()
+	err = s.runSteps(phaseCtx, phase, steps, env, secretVolumes, secretVolumeMounts)
+	if err != nil && errors.Is(context.Cause(phaseCtx), errBudgetExceeded) {
+		return true, fmt.Errorf("%s phase exceeded its budget of %s: %w", phase, budget, err)
+	}
+	return false, err
+}
+
+// skipPhase reports a phase that did not run because the one before it ran
+// over its budget.
+func (s *multiStageTestStep) skipPhase(phase, reason string) {
+	logrus.Infof("Skipping multi-stage phase %s: %s", phase, reason)
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+	s.subTests = append(s.subTests, &junit.TestCase{
+		Name:        fmt.Sprintf("Run multi-stage test %s phase", phase),
+		SkipMessage: &junit.SkipMessage{Message: reason},
+		Properties:  []*junit.TestSuiteProperty{{Name: junit.PhaseProperty, Value: phase}},
+	})
+}
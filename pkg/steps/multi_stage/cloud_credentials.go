@@ -0,0 +1,101 @@
+package multi_stage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/secrets-store-csi-driver-provider-gcp/config"
+
+	csiapi "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// cloudCredentialName identifies a secret in a cloud secret manager in the
+// names of Kubernetes objects. The name of the secret is normalized, so it is
+// suffixed with a hash of the secret and its source: secrets with the same name
+// in different projects, regions or versions must not share objects.
+func cloudCredentialName(credential api.CredentialReference) string {
+	source := credential.Cloud
+	hash := sha256.Sum256([]byte(strings.Join([]string{string(source.Provider), source.Project, source.Region, source.Version, credential.Name}, "\x00")))
+	return fmt.Sprintf("%s-%x", strings.ReplaceAll(strings.ToLower(credential.Name), "_", "-"), hash[:4])
+}
+
+// spcName is the name of the SecretProviderClass that fetches the credential.
+func spcName(namespace string, credential api.CredentialReference) string {
+	if credential.Cloud != nil {
+		return fmt.Sprintf("%s-%s-%s-spc", namespace, credential.Cloud.Provider, cloudCredentialName(credential))
+	}
+	return fmt.Sprintf("%s-%s-spc", namespace, credential.Name)
+}
+
+// credentialVolumeName is the name of the volume the credential is mounted from.
+func credentialVolumeName(credential api.CredentialReference) string {
+	if credential.Cloud != nil {
+		return volumeName(string(credential.Cloud.Provider), cloudCredentialName(credential))
+	}
+	return volumeName(credential.Namespace, credential.Name)
+}
+
+// credentialCensorMountPath is where the credential is mounted for censoring.
+func credentialCensorMountPath(credential api.CredentialReference) string {
+	if credential.Cloud != nil {
+		return getMountPath(path.Join(string(credential.Cloud.Provider), cloudCredentialName(credential)))
+	}
+	return getMountPath(credential.Name)
+}
+
+// awsSecret is an object fetched by the AWS provider for the Secrets Store CSI driver.
+type awsSecret struct {
+	ObjectName         string `json:"objectName"`
+	ObjectType         string `json:"objectType"`
+	ObjectVersionLabel string `json:"objectVersionLabel,omitempty"`
+}
+
+// cloudSecretProviderClassSpec configures the Secrets Store CSI driver to fetch
+// the credential from a cloud secret manager using the workload identity of the
+// pod the credential is mounted into.
+func cloudSecretProviderClassSpec(credential api.CredentialReference) (csiapi.SecretProviderClassSpec, error) {
+	switch source := credential.Cloud; source.Provider {
+	case api.CloudSecretManagerGCP:
+		version := source.Version
+		if version == "" {
+			version = "latest"
+		}
+		secrets, err := yaml.Marshal([]config.Secret{{
+			ResourceName: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", source.Project, credential.Name, version),
+			Path:         credential.Name,
+		}})
+		if err != nil {
+			return csiapi.SecretProviderClassSpec{}, fmt.Errorf("could not marshal secret: %w", err)
+		}
+		return csiapi.SecretProviderClassSpec{
+			Provider: "gcp",
+			Parameters: map[string]string{
+				"auth":    "pod-adc",
+				"secrets": string(secrets),
+			},
+		}, nil
+	case api.CloudSecretManagerAWS:
+		objects, err := yaml.Marshal([]awsSecret{{
+			ObjectName:         credential.Name,
+			ObjectType:         "secretsmanager",
+			ObjectVersionLabel: source.Version,
+		}})
+		if err != nil {
+			return csiapi.SecretProviderClassSpec{}, fmt.Errorf("could not marshal secret: %w", err)
+		}
+		return csiapi.SecretProviderClassSpec{
+			Provider: "aws",
+			Parameters: map[string]string{
+				"region":  source.Region,
+				"objects": string(objects),
+			},
+		}, nil
+	default:
+		return csiapi.SecretProviderClassSpec{}, fmt.Errorf("unknown secret manager %q for credential %s", source.Provider, credential.Name)
+	}
+}
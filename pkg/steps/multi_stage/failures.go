@@ -0,0 +1,76 @@
+package multi_stage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	coreapi "k8s.io/api/core/v1"
+
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+const (
+	// FailedStepEnv is the name of the first step that failed.
+	FailedStepEnv = "FAILED_STEP"
+	// FailedStepPhaseEnv is the phase of the first step that failed.
+	FailedStepPhaseEnv = "FAILED_STEP_PHASE"
+	// FailedStepExitCodeEnv is the exit code of the first step that failed,
+	// empty when it did not exit on its own, e.g. when it timed out.
+	FailedStepExitCodeEnv = "FAILED_STEP_EXIT_CODE"
+	// FailedStepsEnv is a JSON list of all the steps that failed, in the order
+	// they ran.
+	FailedStepsEnv = "FAILED_STEPS"
+)
+
+// stepFailure describes a step which failed, so that `gather` and `post` steps
+// can collect data targeted at the failure.
+type stepFailure struct {
+	Step     string `json:"step"`
+	Phase    string `json:"phase"`
+	ExitCode *int32 `json:"exit_code,omitempty"`
+}
+
+// recordFailure records a failed step Pod.
+func (s *multiStageTestStep) recordFailure(phase string, pod *coreapi.Pod) {
+	failure := stepFailure{Step: pod.Labels[base_steps.LabelMetadataStep], Phase: phase}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName && status.State.Terminated != nil {
+			exitCode := status.State.Terminated.ExitCode
+			failure.ExitCode = &exitCode
+		}
+	}
+	s.subLock.Lock()
+	s.failures = append(s.failures, failure)
+	s.subLock.Unlock()
+}
+
+// failureEnv exposes the steps that failed in the phases before the current one
+// to `gather` and `post` steps. The variables are always set so scripts do not
+// need to handle them missing.
+func (s *multiStageTestStep) failureEnv() ([]coreapi.EnvVar, error) {
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+	failures := s.failures
+	if failures == nil {
+		failures = []stepFailure{}
+	}
+	raw, err := json.Marshal(failures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal step failures: %w", err)
+	}
+	var first stepFailure
+	var exitCode string
+	if len(failures) != 0 {
+		first = failures[0]
+		if first.ExitCode != nil {
+			exitCode = strconv.Itoa(int(*first.ExitCode))
+		}
+	}
+	return []coreapi.EnvVar{
+		{Name: FailedStepEnv, Value: first.Step},
+		{Name: FailedStepPhaseEnv, Value: first.Phase},
+		{Name: FailedStepExitCodeEnv, Value: exitCode},
+		{Name: FailedStepsEnv, Value: string(raw)},
+	}, nil
+}
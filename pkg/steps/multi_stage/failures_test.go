@@ -0,0 +1,78 @@
+package multi_stage
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+func TestFailureEnv(t *testing.T) {
+	failedPod := func(step string, terminated *coreapi.ContainerStateTerminated) *coreapi.Pod {
+		return &coreapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-" + step, Labels: map[string]string{base_steps.LabelMetadataStep: step}},
+			Status: coreapi.PodStatus{ContainerStatuses: []coreapi.ContainerStatus{
+				{Name: "sidecar", State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{}}},
+				{Name: containerName, State: coreapi.ContainerState{Terminated: terminated}},
+			}},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		record   func(s *multiStageTestStep)
+		expected []coreapi.EnvVar
+	}{
+		{
+			name:   "no failures",
+			record: func(*multiStageTestStep) {},
+			expected: []coreapi.EnvVar{
+				{Name: "FAILED_STEP"},
+				{Name: "FAILED_STEP_PHASE"},
+				{Name: "FAILED_STEP_EXIT_CODE"},
+				{Name: "FAILED_STEPS", Value: "[]"},
+			},
+		},
+		{
+			name: "install failed",
+			record: func(s *multiStageTestStep) {
+				s.recordFailure("pre", failedPod("ipi-install-install", &coreapi.ContainerStateTerminated{ExitCode: 3}))
+			},
+			expected: []coreapi.EnvVar{
+				{Name: "FAILED_STEP", Value: "ipi-install-install"},
+				{Name: "FAILED_STEP_PHASE", Value: "pre"},
+				{Name: "FAILED_STEP_EXIT_CODE", Value: "3"},
+				{Name: "FAILED_STEPS", Value: `[{"step":"ipi-install-install","phase":"pre","exit_code":3}]`},
+			},
+		},
+		{
+			name: "test timed out and gather failed",
+			record: func(s *multiStageTestStep) {
+				s.recordFailure("test", failedPod("e2e", nil))
+				s.recordFailure("gather", failedPod("gather-must-gather", &coreapi.ContainerStateTerminated{ExitCode: 1}))
+			},
+			expected: []coreapi.EnvVar{
+				{Name: "FAILED_STEP", Value: "e2e"},
+				{Name: "FAILED_STEP_PHASE", Value: "test"},
+				{Name: "FAILED_STEP_EXIT_CODE"},
+				{Name: "FAILED_STEPS", Value: `[{"step":"e2e","phase":"test"},{"step":"gather-must-gather","phase":"gather","exit_code":1}]`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &multiStageTestStep{subLock: &sync.Mutex{}}
+			tc.record(s)
+			env, err := s.failureEnv()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, env); diff != "" {
+				t.Errorf("unexpected environment: %s", diff)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package multi_stage
 
 import (
 	"fmt"
+	"maps"
 	"path/filepath"
 	"strings"
 
@@ -23,6 +24,12 @@ const (
 	containerName     = "test"
 	profileVolumeName = "cluster-profile"
 	vpnContainerName  = "vpn-client"
+	// crioDevicesAnnotation lists the host devices CRI-O adds to the containers
+	// of a pod.
+	crioDevicesAnnotation = "io.kubernetes.cri-o.Devices"
+	// nestedPodmanUID is the user running podman in the test container, which
+	// owns the subordinate IDs of the image.
+	nestedPodmanUID = 1000
 )
 
 func (s *multiStageTestStep) generateObservers(
@@ -87,12 +94,14 @@ func (s *multiStageTestStep) generatePods(
 			continue
 		}
 		image := step.From
-		if link, ok := step.FromImageTag(); ok {
+		if step.As == api.LeakDetectionStepName && s.leakDetection != nil {
+			image = s.leakDetection.ScanImage()
+		} else if link, ok := step.FromImageTag(); ok {
 			image = fmt.Sprintf("%s:%s", api.PipelineImageStream, link)
 		} else {
 			dep := api.StepDependency{Name: image}
 			stream, tag, _ := s.config.DependencyParts(dep, claimRelease)
-			image = fmt.Sprintf("%s:%s", stream, tag)
+			image = fmt.Sprintf("%s:%s", s.streamFor(stream), tag)
 		}
 		resources, err := base_steps.ResourcesFor(step.Resources)
 		if err != nil {
@@ -134,7 +143,11 @@ func (s *multiStageTestStep) generatePods(
 		} else {
 			commands = []string{"/bin/bash", "-c", CommandPrefix + step.Commands}
 		}
-		labels := map[string]string{base_steps.LabelMetadataStep: step.As}
+		labels := maps.Clone(s.labels)
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[base_steps.LabelMetadataStep] = step.As
 		pod, err := base_steps.GenerateBasePod(s.jobSpec, labels, name, s.nodeName,
 			containerName, commands, image, resources, artifactDir, s.jobSpec.DecorationConfig,
 			s.jobSpec.RawSpec(), secretVolumeMounts, &base_steps.GeneratePodOptions{PropagateExitCode: genPodOpts.IsObserver})
@@ -143,6 +156,9 @@ func (s *multiStageTestStep) generatePods(
 			continue
 		}
 		delete(pod.Labels, base_steps.ProwJobIdLabel)
+		for key, value := range s.annotations {
+			pod.Annotations[key] = value
+		}
 		pod.Annotations[base_steps.AnnotationSaveContainerLogs] = "true"
 		pod.Labels[MultiStageTestLabel] = s.name
 		needsKubeConfig := isKubeconfigNeeded(&step, genPodOpts)
@@ -170,6 +186,13 @@ func (s *multiStageTestStep) generatePods(
 			}
 			pod.Spec.NodeSelector[coreapi.LabelArchStable] = string(*step.NodeArchitecture)
 		}
+		base_steps.AddScheduling(pod, s.nodeSelector, s.tolerations)
+		base_steps.AddScheduling(pod, step.NodeSelector, step.Tolerations)
+		base_steps.AddSpread(pod, s.spread, base_steps.LabelMetadataOrg, base_steps.LabelMetadataRepo, base_steps.LabelMetadataBranch,
+			base_steps.LabelMetadataVariant, base_steps.LabelMetadataTarget, base_steps.LabelMetadataStep)
+		if step.PullSecret != "" {
+			pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, coreapi.LocalObjectReference{Name: api.ExternalPullSecretName(step.PullSecret)})
+		}
 		pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{Name: homeVolumeName, VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}})
 		pod.Spec.Volumes = append(pod.Spec.Volumes, secretVolumes...)
 		for idx := range pod.Spec.Containers {
@@ -182,7 +205,14 @@ func (s *multiStageTestStep) generatePods(
 			}
 		}
 
-		addSecretWrapper(pod, s.vpnConf, !needsKubeConfig, genPodOpts)
+		logLimit := s.logLimit
+		if step.LogLimit != nil {
+			logLimit = step.LogLimit
+		}
+		if err := addSecretWrapper(pod, s.vpnConf, !needsKubeConfig, genPodOpts, logLimit); err != nil {
+			errs = append(errs, fmt.Errorf("invalid log limit for step %s: %w", step.As, err))
+			continue
+		}
 		if s.vpnConf != nil {
 			s.addVPNClient(pod)
 		}
@@ -231,6 +261,9 @@ func (s *multiStageTestStep) generatePods(
 		}
 		if s.profile != "" {
 			addProfile(s.profileSecretName(), s.profile, pod)
+			if s.workloadIdentity != nil {
+				addWorkloadIdentity(s.workloadIdentity, pod)
+			}
 		}
 		if step.Cli != "" {
 			dependency := api.StepDependency{Name: fmt.Sprintf("%s:cli", api.ReleaseStreamFor(step.Cli))}
@@ -259,6 +292,9 @@ func (s *multiStageTestStep) generatePods(
 			}
 			setSecurityContexts(pod, vpnContainerName, s.vpnConf.namespaceUID, &caps, &seLinuxOpts)
 		}
+		if step.NestedPodman != nil && *step.NestedPodman {
+			addNestedPodman(pod, containerName)
+		}
 		ret = append(ret, *pod)
 	}
 	return ret, bestEffortSteps, utilerrors.NewAggregate(errs)
@@ -269,7 +305,14 @@ func isKubeconfigNeeded(step *api.LiteralTestStep, opts *generatePodOptions) boo
 	return needsKubeconfig || opts.IsObserver
 }
 
-func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, genPodOpts *generatePodOptions) {
+func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, genPodOpts *generatePodOptions, logLimit *api.LogLimit) error {
+	var logHead, logTail int64
+	if logLimit != nil {
+		var err error
+		if logHead, logTail, err = logLimit.Bytes(); err != nil {
+			return err
+		}
+	}
 	volume := "entrypoint-wrapper"
 	dir := "/tmp/entrypoint-wrapper"
 	bin := filepath.Join(dir, "entrypoint-wrapper")
@@ -302,10 +345,16 @@ func addSecretWrapper(pod *coreapi.Pod, vpnConf *vpnConf, skipKubeconfig bool, g
 	if genPodOpts.IsObserver {
 		container.Args = append(container.Args, "--mode=observer")
 	}
+	if logHead > 0 || logTail > 0 {
+		container.Args = append(container.Args,
+			fmt.Sprintf("--log-limit-head=%d", logHead),
+			fmt.Sprintf("--log-limit-tail=%d", logTail))
+	}
 	container.Args = append(container.Args, container.Command...)
 	container.Args = append(container.Args, args...)
 	container.Command = []string{bin}
 	container.VolumeMounts = append(container.VolumeMounts, mount)
+	return nil
 }
 
 func (s *multiStageTestStep) addVPNClient(pod *coreapi.Pod) {
@@ -377,6 +426,35 @@ func setSecurityContexts(
 	f(pod.Spec.Containers)
 }
 
+// addNestedPodman configures a container to run rootless podman.  The pod is
+// placed in a user namespace, so the users podman maps are not privileged on
+// the node, and CRI-O exposes the devices needed for the storage and network
+// of the nested containers.  The SCC allowing this is granted by the
+// `ci-operator-nested-podman` cluster role, which only exists on the clusters
+// with the `nested-podman` capability.
+func addNestedPodman(pod *coreapi.Pod, container string) {
+	pod.Annotations[crioDevicesAnnotation] = "/dev/fuse,/dev/net/tun"
+	hostUsers := false
+	pod.Spec.HostUsers = &hostUsers
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		if c.SecurityContext == nil {
+			c.SecurityContext = &coreapi.SecurityContext{}
+		}
+		uid := int64(nestedPodmanUID)
+		procMount := coreapi.UnmaskedProcMount
+		c.SecurityContext.RunAsUser = &uid
+		c.SecurityContext.Capabilities = &coreapi.Capabilities{
+			Add: []coreapi.Capability{"SETGID", "SETUID"},
+		}
+		c.SecurityContext.SELinuxOptions = &coreapi.SELinuxOptions{Type: "container_engine_t"}
+		c.SecurityContext.ProcMount = &procMount
+	}
+}
+
 func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.EnvVar {
 	var ret []coreapi.EnvVar
 	for _, env := range env {
@@ -407,7 +485,7 @@ func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]cor
 			ref = dependency.PullSpec
 		} else {
 			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
-			depRef, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, imageStream, name)()
+			depRef, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, s.streamFor(imageStream), name)()
 			if err != nil {
 				errs = append(errs, fmt.Errorf("could not determine image pull spec for image %s on step %s", dependency.Name, step.As))
 				continue
@@ -495,6 +573,46 @@ func addProfile(name string, profile api.ClusterProfile, pod *coreapi.Pod) {
 	}}...)
 }
 
+// addWorkloadIdentity mounts a short-lived token issued for the service account
+// of the pod and configures the cloud tools to exchange it for credentials.
+func addWorkloadIdentity(wi *api.WorkloadIdentity, pod *coreapi.Pod) {
+	volumeName := "cloud-token"
+	expiration := wi.TokenExpirationSeconds()
+	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+		Name: volumeName,
+		VolumeSource: coreapi.VolumeSource{
+			Projected: &coreapi.ProjectedVolumeSource{
+				Sources: []coreapi.VolumeProjection{{
+					ServiceAccountToken: &coreapi.ServiceAccountTokenProjection{
+						Audience:          wi.TokenAudience(),
+						ExpirationSeconds: &expiration,
+						Path:              api.WorkloadIdentityTokenFile,
+					},
+				}},
+			},
+		},
+	})
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{
+		Name:      volumeName,
+		MountPath: api.WorkloadIdentityTokenDir,
+		ReadOnly:  true,
+	})
+	switch wi.Provider {
+	case api.WorkloadIdentityProviderGCP:
+		credentials := filepath.Join(ClusterProfileMountPath, api.GCPWorkloadIdentityCredentialsKey)
+		container.Env = append(container.Env, []coreapi.EnvVar{
+			{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: credentials},
+			{Name: "CLOUDSDK_AUTH_CREDENTIAL_FILE_OVERRIDE", Value: credentials},
+		}...)
+	case api.WorkloadIdentityProviderAWS:
+		container.Env = append(container.Env, []coreapi.EnvVar{
+			{Name: "AWS_ROLE_ARN", Value: wi.AWSRoleARN},
+			{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: filepath.Join(api.WorkloadIdentityTokenDir, api.WorkloadIdentityTokenFile)},
+		}...)
+	}
+}
+
 func addCliInjector(imagestream string, pod *coreapi.Pod) {
 	volumeName := "cli"
 	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
@@ -548,31 +666,36 @@ func addSharedDirSecret(secret string, pod *coreapi.Pod) {
 }
 
 func addCredentials(credentials []api.CredentialReference, pod *coreapi.Pod, useCSI bool) {
-	if useCSI {
-		for _, credential := range credentials {
-			volumeName := volumeName(credential.Namespace, credential.Name)
-			readOnly := true
-			csiVolume := coreapi.Volume{
-				Name: volumeName,
-				VolumeSource: coreapi.VolumeSource{
-					CSI: &coreapi.CSIVolumeSource{
-						Driver:   "secrets-store.csi.k8s.io",
-						ReadOnly: &readOnly,
-						VolumeAttributes: map[string]string{
-							"secretProviderClass": fmt.Sprintf("%s-%s-spc", pod.Namespace, credential.Name),
-						},
+	for _, credential := range credentials {
+		if credential.Cloud == nil && !useCSI {
+			continue
+		}
+		volumeName := credentialVolumeName(credential)
+		readOnly := true
+		csiVolume := coreapi.Volume{
+			Name: volumeName,
+			VolumeSource: coreapi.VolumeSource{
+				CSI: &coreapi.CSIVolumeSource{
+					Driver:   "secrets-store.csi.k8s.io",
+					ReadOnly: &readOnly,
+					VolumeAttributes: map[string]string{
+						"secretProviderClass": spcName(pod.Namespace, credential),
 					},
 				},
-			}
-			pod.Spec.Volumes = append(pod.Spec.Volumes, csiVolume)
-			pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, coreapi.VolumeMount{
-				Name:      volumeName,
-				MountPath: credential.MountPath,
-			})
+			},
 		}
-	} else {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, csiVolume)
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, coreapi.VolumeMount{
+			Name:      volumeName,
+			MountPath: credential.MountPath,
+		})
+	}
+	if !useCSI {
 		//TODO: this is the old way, delete after we have enabled CSI Secrets for all repos
 		for _, credential := range credentials {
+			if credential.Cloud != nil {
+				continue
+			}
 			name := fmt.Sprintf("%s-%s", credential.Namespace, credential.Name)
 			volumeName := volumeName(credential.Namespace, credential.Name)
 			pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
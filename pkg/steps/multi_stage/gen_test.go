@@ -49,6 +49,14 @@ func TestGeneratePods(t *testing.T) {
 					As: "step4", From: "src", Commands: "command4", NodeArchitecture: &nodeArchitectureARM64,
 				}, {
 					As: "step5", From: "src", Commands: "command5", NodeArchitecture: &nodeArchitectureAMD64,
+				}, {
+					As: "step6", From: "src", Commands: "command6", PullSecret: "private-registry",
+				}, {
+					As: "step7", From: "src", Commands: "command7",
+					NodeSelector: map[string]string{"ci-instance-type": "large-memory"},
+					Tolerations:  []api.Toleration{{Key: "ci-instance-type", Value: "large-memory", Effect: "NoSchedule"}},
+				}, {
+					As: "step8", From: "src", Commands: "command8", LogLimit: &api.LogLimit{Head: "1Mi", Tail: "2Mi"},
 				}},
 			}},
 		},
@@ -84,7 +92,7 @@ func TestGeneratePods(t *testing.T) {
 		},
 	}
 	jobSpec.SetNamespace("namespace")
-	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
 	step.test[0].Resources = api.ResourceRequirements{
 		Requests: api.ResourceList{api.ShmResource: "2G"},
 		Limits:   api.ResourceList{api.ShmResource: "2G"}}
@@ -162,7 +170,7 @@ func TestGenerateObservers(t *testing.T) {
 		},
 	}
 	jobSpec.SetNamespace("namespace")
-	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
 	ret, err := step.generateObservers(observers, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
@@ -236,7 +244,7 @@ func TestGeneratePodsEnvironment(t *testing.T) {
 					Test:        test,
 					Environment: tc.env,
 				},
-			}, &api.ReleaseBuildConfiguration{}, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+			}, &api.ReleaseBuildConfiguration{}, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
 			pods, _, err := step.(*multiStageTestStep).generatePods(test, nil, nil, nil, nil)
 			if err != nil {
 				t.Fatal(err)
@@ -304,7 +312,7 @@ func TestGeneratePodBestEffort(t *testing.T) {
 		},
 	}
 	jobSpec.SetNamespace("namespace")
-	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
 	_, bestEffortSteps, err := step.generatePods(config.Tests[0].MultiStageTestConfigurationLiteral.Post, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
@@ -560,6 +568,72 @@ func TestAddCSICredentials(t *testing.T) {
 	}
 }
 
+func TestAddWorkloadIdentity(t *testing.T) {
+	expiration := int64(3600)
+	var testCases = []struct {
+		name     string
+		wi       api.WorkloadIdentity
+		expected coreapi.Pod
+	}{
+		{
+			name: "gcp",
+			wi: api.WorkloadIdentity{
+				Provider:                    api.WorkloadIdentityProviderGCP,
+				GCPWorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/ci/providers/build-farm",
+				GCPServiceAccount:           "ci@project.iam.gserviceaccount.com",
+			},
+			expected: coreapi.Pod{Spec: coreapi.PodSpec{
+				Containers: []coreapi.Container{{
+					VolumeMounts: []coreapi.VolumeMount{{Name: "cloud-token", MountPath: "/var/run/secrets/ci.openshift.io/cloud-token", ReadOnly: true}},
+					Env: []coreapi.EnvVar{
+						{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: "/var/run/secrets/ci.openshift.io/cluster-profile/gcp-workload-identity-credentials.json"},
+						{Name: "CLOUDSDK_AUTH_CREDENTIAL_FILE_OVERRIDE", Value: "/var/run/secrets/ci.openshift.io/cluster-profile/gcp-workload-identity-credentials.json"},
+					},
+				}},
+				Volumes: []coreapi.Volume{{Name: "cloud-token", VolumeSource: coreapi.VolumeSource{Projected: &coreapi.ProjectedVolumeSource{
+					Sources: []coreapi.VolumeProjection{{ServiceAccountToken: &coreapi.ServiceAccountTokenProjection{
+						Audience:          "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/ci/providers/build-farm",
+						ExpirationSeconds: &expiration,
+						Path:              "token",
+					}}},
+				}}}},
+			}},
+		},
+		{
+			name: "aws",
+			wi: api.WorkloadIdentity{
+				Provider:   api.WorkloadIdentityProviderAWS,
+				AWSRoleARN: "arn:aws:iam::123456789012:role/ci",
+			},
+			expected: coreapi.Pod{Spec: coreapi.PodSpec{
+				Containers: []coreapi.Container{{
+					VolumeMounts: []coreapi.VolumeMount{{Name: "cloud-token", MountPath: "/var/run/secrets/ci.openshift.io/cloud-token", ReadOnly: true}},
+					Env: []coreapi.EnvVar{
+						{Name: "AWS_ROLE_ARN", Value: "arn:aws:iam::123456789012:role/ci"},
+						{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: "/var/run/secrets/ci.openshift.io/cloud-token/token"},
+					},
+				}},
+				Volumes: []coreapi.Volume{{Name: "cloud-token", VolumeSource: coreapi.VolumeSource{Projected: &coreapi.ProjectedVolumeSource{
+					Sources: []coreapi.VolumeProjection{{ServiceAccountToken: &coreapi.ServiceAccountTokenProjection{
+						Audience:          "sts.amazonaws.com",
+						ExpirationSeconds: &expiration,
+						Path:              "token",
+					}}},
+				}}}},
+			}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pod := coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{}}}}
+			addWorkloadIdentity(&testCase.wi, &pod)
+			if diff := cmp.Diff(testCase.expected, pod); diff != "" {
+				t.Errorf("got incorrect Pod: %s", diff)
+			}
+		})
+	}
+}
+
 func TestGetClusterClaimPodParams(t *testing.T) {
 	var testCases = []struct {
 		name               string
@@ -652,3 +726,37 @@ func TestSetSecurityContexts(t *testing.T) {
 		})
 	}
 }
+
+func TestAddNestedPodman(t *testing.T) {
+	nonRoot := true
+	var uid int64 = 1007160000
+	for _, tc := range []struct {
+		name string
+		pod  coreapi.Pod
+	}{{
+		name: "no security context",
+		pod: coreapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Spec: coreapi.PodSpec{
+				Containers: []coreapi.Container{{Name: "test"}, {Name: "sidecar"}},
+			},
+		},
+	}, {
+		name: "security context of the VPN client",
+		pod: coreapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Spec: coreapi.PodSpec{
+				Containers: []coreapi.Container{
+					{Name: "test", SecurityContext: &coreapi.SecurityContext{RunAsNonRoot: &nonRoot, RunAsUser: &uid}},
+					{Name: "vpn-client"},
+				},
+			},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &tc.pod
+			addNestedPodman(pod, "test")
+			testhelper.CompareWithFixture(t, pod)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"time"
 
@@ -48,8 +49,11 @@ func (s *multiStageTestStep) createSharedDirSecret(ctx context.Context) error {
 func (s *multiStageTestStep) createCredentials(ctx context.Context) error {
 	logrus.Debugf("Creating multi-stage test credentials for %q", s.name)
 	toCreate := map[string]*coreapi.Secret{}
-	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+	for _, step := range s.steps() {
 		for _, credential := range step.Credentials {
+			if credential.Cloud != nil {
+				continue
+			}
 			// we don't want secrets imported from separate namespaces to collide
 			// but we want to keep them generally recognizable for debugging, and the
 			// chance we get a second-level collision (ns-a, name) and (ns, a-name) is
@@ -87,18 +91,38 @@ func (s *multiStageTestStep) createCredentials(ctx context.Context) error {
 // to fetch the appropriate secrets from GCP. This is done before
 // the individual steps are run to make sure the appropriate
 // SecretProviderClasses already exist and are available for the test pods.
+// Credentials stored in a cloud secret manager are always fetched using
+// SPCs, the rest only when the Secrets Store CSI driver is enabled.
 func (s *multiStageTestStep) createSPCs(ctx context.Context) error {
 	toCreate := map[string]*csiapi.SecretProviderClass{}
 
-	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+	for _, step := range s.steps() {
 		for _, credential := range step.Credentials {
-			name := fmt.Sprintf("%s-%s-spc", s.jobSpec.Namespace(), credential.Name)
+			if credential.Cloud == nil && !s.enableSecretsStoreCSIDriver {
+				continue
+			}
+			name := spcName(s.jobSpec.Namespace(), credential)
 			if _, exists := toCreate[name]; exists {
 				continue
 			}
-			secret, err := getSecretString(credential.Name)
-			if err != nil {
-				return err
+			var spec csiapi.SecretProviderClassSpec
+			if credential.Cloud != nil {
+				var err error
+				if spec, err = cloudSecretProviderClassSpec(credential); err != nil {
+					return err
+				}
+			} else {
+				secret, err := getSecretString(credential.Name)
+				if err != nil {
+					return err
+				}
+				spec = csiapi.SecretProviderClassSpec{
+					Provider: "gcp",
+					Parameters: map[string]string{
+						"auth":    "provider-adc",
+						"secrets": secret,
+					},
+				}
 			}
 			toCreate[name] = &csiapi.SecretProviderClass{
 				TypeMeta: meta.TypeMeta{
@@ -109,13 +133,7 @@ func (s *multiStageTestStep) createSPCs(ctx context.Context) error {
 					Name:      name,
 					Namespace: s.jobSpec.Namespace(),
 				},
-				Spec: csiapi.SecretProviderClassSpec{
-					Provider: "gcp",
-					Parameters: map[string]string{
-						"auth":    "provider-adc",
-						"secrets": secret,
-					},
-				},
+				Spec: spec,
 			}
 		}
 	}
@@ -143,7 +161,7 @@ func getSecretString(name string) (string, error) {
 func (s *multiStageTestStep) createCommandConfigMaps(ctx context.Context) error {
 	logrus.Debugf("Creating multi-stage test commands configmap for %q", s.name)
 	data := make(map[string]string)
-	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+	for _, step := range s.steps() {
 		data[step.As] = step.Commands
 	}
 	name := commandConfigMapForTest(s.name)
@@ -222,6 +240,18 @@ func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
 			Subjects: subj,
 		})
 	}
+	if slices.ContainsFunc(s.steps(), func(step api.LiteralTestStep) bool {
+		return step.NestedPodman != nil && *step.NestedPodman
+	}) {
+		bindings = append(bindings, rbacapi.RoleBinding{
+			ObjectMeta: meta.ObjectMeta{Namespace: ns, Name: s.name + "-nested-podman"},
+			RoleRef: rbacapi.RoleRef{
+				Kind: "ClusterRole",
+				Name: "ci-operator-nested-podman",
+			},
+			Subjects: subj,
+		})
+	}
 	if err := util.CreateRBACs(ctx, sa, role, bindings, s.client, 1*time.Second, 1*time.Minute); err != nil {
 		return err
 	}
@@ -88,6 +88,7 @@ func TestCreateSPCs(t *testing.T) {
 		pre          []api.LiteralTestStep
 		test         []api.LiteralTestStep
 		post         []api.LiteralTestStep
+		disableCSI   bool
 		expectedSPCs csiapi.SecretProviderClassList
 	}{
 		{
@@ -136,6 +137,77 @@ func TestCreateSPCs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "cloud credentials are fetched without the CSI driver enabled",
+			pre: []api.LiteralTestStep{{Credentials: []api.CredentialReference{
+				credential1,
+				{Name: "Gcp_Secret", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerGCP, Project: "project"}},
+			}}},
+			test: []api.LiteralTestStep{{Credentials: []api.CredentialReference{
+				{Name: "aws-secret", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerAWS, Region: "us-east-1", Version: "AWSPREVIOUS"}},
+			}}},
+			disableCSI: true,
+			expectedSPCs: csiapi.SecretProviderClassList{
+				Items: []csiapi.SecretProviderClass{
+					{
+						TypeMeta:   meta.TypeMeta{Kind: "SecretProviderClass", APIVersion: csiapi.GroupVersion.String()},
+						ObjectMeta: meta.ObjectMeta{Name: "test-ns-aws-aws-secret-d1e343c0-spc", Namespace: "test-ns", ResourceVersion: "1"},
+						Spec: csiapi.SecretProviderClassSpec{
+							Provider: "aws",
+							Parameters: map[string]string{
+								"region":  "us-east-1",
+								"objects": "- objectName: aws-secret\n  objectType: secretsmanager\n  objectVersionLabel: AWSPREVIOUS\n",
+							},
+						},
+					},
+					{
+						TypeMeta:   meta.TypeMeta{Kind: "SecretProviderClass", APIVersion: csiapi.GroupVersion.String()},
+						ObjectMeta: meta.ObjectMeta{Name: "test-ns-gcp-gcp-secret-a44ac71e-spc", Namespace: "test-ns", ResourceVersion: "1"},
+						Spec: csiapi.SecretProviderClassSpec{
+							Provider: "gcp",
+							Parameters: map[string]string{
+								"auth":    "pod-adc",
+								"secrets": "- fileName: \"\"\n  path: Gcp_Secret\n  resourceName: projects/project/secrets/Gcp_Secret/versions/latest\n",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "cloud credentials with the same name in different projects",
+			pre: []api.LiteralTestStep{{Credentials: []api.CredentialReference{
+				{Name: "secret", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerGCP, Project: "first"}},
+				{Name: "secret", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerGCP, Project: "second"}},
+			}}},
+			disableCSI: true,
+			expectedSPCs: csiapi.SecretProviderClassList{
+				Items: []csiapi.SecretProviderClass{
+					{
+						TypeMeta:   meta.TypeMeta{Kind: "SecretProviderClass", APIVersion: csiapi.GroupVersion.String()},
+						ObjectMeta: meta.ObjectMeta{Name: "test-ns-gcp-secret-1f47ce40-spc", Namespace: "test-ns", ResourceVersion: "1"},
+						Spec: csiapi.SecretProviderClassSpec{
+							Provider: "gcp",
+							Parameters: map[string]string{
+								"auth":    "pod-adc",
+								"secrets": "- fileName: \"\"\n  path: secret\n  resourceName: projects/first/secrets/secret/versions/latest\n",
+							},
+						},
+					},
+					{
+						TypeMeta:   meta.TypeMeta{Kind: "SecretProviderClass", APIVersion: csiapi.GroupVersion.String()},
+						ObjectMeta: meta.ObjectMeta{Name: "test-ns-gcp-secret-ff7550a5-spc", Namespace: "test-ns", ResourceVersion: "1"},
+						Spec: csiapi.SecretProviderClassSpec{
+							Provider: "gcp",
+							Parameters: map[string]string{
+								"auth":    "pod-adc",
+								"secrets": "- fileName: \"\"\n  path: secret\n  resourceName: projects/second/secrets/secret/versions/latest\n",
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			crclient := &testhelper_kube.FakePodExecutor{
@@ -153,6 +225,8 @@ func TestCreateSPCs(t *testing.T) {
 				post:    tc.post,
 				jobSpec: &api.JobSpec{},
 				client:  fakeClient,
+
+				enableSecretsStoreCSIDriver: !tc.disableCSI,
 			}
 			step.jobSpec.SetNamespace("test-ns")
 			err := step.createSPCs(context.TODO())
@@ -0,0 +1,79 @@
+package multi_stage
+
+import (
+	"context"
+	"encoding/xml"
+	"path"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+// sharedDirJUnitPrefix is the path the junit files steps leave in the
+// shared directory are merged under.
+const sharedDirJUnitPrefix = "shared"
+
+// writeJUnitSummary merges the junit files the steps of the test left in the
+// shared directory and in their artifact directories into a single summary in
+// the artifact directory of the test, once all steps ran. The sidecars of the
+// steps upload their artifact directories to the bucket of the job rather than
+// to the local one, so they are read from there when possible.
+func (s *multiStageTestStep) writeJUnitSummary(ctx context.Context) {
+	artifactDir, ok := api.Artifacts()
+	if !ok {
+		return
+	}
+	logger := logrus.WithField("test", s.name)
+	files, err := junit.ReadDir(filepath.Join(artifactDir, s.name))
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read the junit files of the test.")
+		return
+	}
+	if s.stepArtifacts != nil {
+		uploaded, err := s.stepArtifacts.ReadJUnit(ctx, s.name)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to read the junit files the steps of the test uploaded.")
+		}
+		for name, data := range uploaded {
+			files[name] = data
+		}
+	}
+	sharedDir := coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, &sharedDir); err != nil {
+		logger.WithError(err).Warn("Failed to get the shared directory to read the junit files of the test.")
+	}
+	for name, data := range sharedDir.Data {
+		if junit.IsJUnitFile(name) {
+			files[path.Join(sharedDirJUnitPrefix, name)] = data
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	suites, err := junit.Merge(files)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to merge the junit files of the test.")
+		return
+	}
+	censor, err := base_steps.NamespaceCensor(ctx, s.client, s.jobSpec.Namespace())
+	if err != nil {
+		logger.WithError(err).Warn("Failed to censor the junit summary of the test.")
+		return
+	}
+	for _, suite := range suites.Suites {
+		junit.CensorTestSuite(censor, suite)
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		logger.WithError(err).Warn("Failed to marshal the junit summary of the test.")
+		return
+	}
+	_ = api.SaveArtifact(censor, path.Join(s.name, junit.SummaryFilename), data)
+}
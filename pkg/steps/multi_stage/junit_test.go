@@ -0,0 +1,70 @@
+package multi_stage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+// fakeArtifactReader holds the junit files uploaded under each path.
+type fakeArtifactReader map[string]map[string][]byte
+
+func (r fakeArtifactReader) ReadJUnit(_ context.Context, path string) (map[string][]byte, error) {
+	return r[path], nil
+}
+
+func TestWriteJUnitSummary(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARTIFACTS", dir)
+	// the steps of the test uploaded their artifacts to the bucket
+	uploaded := fakeArtifactReader{"e2e": {
+		"template/artifacts/junit_nested.xml": []byte(`<testsuite name="nested"><testcase name="logs in with hunter2"/></testsuite>`),
+	}}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&coreapi.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "e2e", Namespace: "ns", Labels: map[string]string{api.SkipCensoringLabel: "true"}},
+			Data: map[string][]byte{
+				"junit_install.xml": []byte(`<testsuite name="install"><testcase name="install"><failure>failed</failure></testcase><testcase name="install"/></testsuite>`),
+				"kubeconfig":        []byte("not junit"),
+			},
+		},
+		&coreapi.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "credentials", Namespace: "ns"},
+			Data:       map[string][]byte{"token": []byte("hunter2")},
+		},
+	).Build()
+	jobSpec := api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	s := &multiStageTestStep{
+		name:          "e2e",
+		jobSpec:       &jobSpec,
+		client:        &testhelper_kube.FakePodClient{FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(client)}},
+		stepArtifacts: uploaded,
+	}
+	s.writeJUnitSummary(context.Background())
+	data, err := os.ReadFile(filepath.Join(dir, "e2e", junit.SummaryFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, substr := range []string{
+		`<testsuite name="install" tests="1" skipped="0" failures="0"`,
+		`<property name="attempts" value="2"></property>`,
+		`<testsuite name="nested" tests="1" skipped="0" failures="0"`,
+		`<testcase name="logs in with XXXXXXX"`,
+	} {
+		if !strings.Contains(string(data), substr) {
+			t.Errorf("summary does not contain %q:\n%s", substr, data)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package multi_stage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	utilpointer "k8s.io/utils/pointer"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const leakDetectionTimeout = 15 * time.Minute
+
+func (s *multiStageTestStep) readLeakDetection(secret *coreapi.Secret) error {
+	bytes, ok := secret.Data[api.LeakDetectionConfigKey]
+	if !ok {
+		return nil
+	}
+	var ld api.LeakDetection
+	if err := json.Unmarshal(bytes, &ld); err != nil {
+		return fmt.Errorf("failed to read leak detection configuration file: %w", err)
+	}
+	if err := ld.Validate(); err != nil {
+		return fmt.Errorf("invalid leak detection configuration: %w", err)
+	}
+	s.leakDetection = &ld
+	return nil
+}
+
+// leakDetectionStep scans for the resources of the cluster that survived the
+// teardown. It runs after all other `post` steps and never fails the test:
+// leaks are reported in the step's jUnit and manifest.
+func leakDetectionStep(ld *api.LeakDetection) api.LiteralTestStep {
+	commands := "leak-detector"
+	if ld.Tag != "" {
+		// the tag is validated not to contain quotes
+		commands += fmt.Sprintf(" --tag='%s'", ld.Tag)
+	}
+	return api.LiteralTestStep{
+		As:       api.LeakDetectionStepName,
+		Commands: commands,
+		Resources: api.ResourceRequirements{
+			Requests: api.ResourceList{"cpu": "10m", "memory": "100Mi"},
+		},
+		Timeout:    &prowapi.Duration{Duration: leakDetectionTimeout},
+		BestEffort: utilpointer.Bool(true),
+	}
+}
@@ -0,0 +1,109 @@
+package multi_stage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestReadLeakDetection(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          map[string][]byte
+		expected      *api.LeakDetection
+		expectedError error
+	}{
+		{
+			name: "not enabled",
+		},
+		{
+			name:     "defaults",
+			data:     map[string][]byte{api.LeakDetectionConfigKey: []byte(`{}`)},
+			expected: &api.LeakDetection{},
+		},
+		{
+			name:     "custom tag",
+			data:     map[string][]byte{api.LeakDetectionConfigKey: []byte(`{"image":"quay.io/org/scanner:v1","tag":"owner-${CLUSTER_NAME}"}`)},
+			expected: &api.LeakDetection{Image: "quay.io/org/scanner:v1", Tag: "owner-${CLUSTER_NAME}"},
+		},
+		{
+			name:          "invalid tag",
+			data:          map[string][]byte{api.LeakDetectionConfigKey: []byte(`{"tag":"owner"}`)},
+			expectedError: errors.New(`invalid leak detection configuration: tag "owner" must contain ${CLUSTER_NAME}`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := multiStageTestStep{}
+			err := s.readLeakDetection(&coreapi.Secret{Data: tc.data})
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, s.leakDetection); diff != "" {
+				t.Errorf("unexpected configuration: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLeakDetectionStepPod(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				ClusterProfile: api.ClusterProfileAWS,
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Hour},
+				GracePeriod: &prowapi.Duration{Duration: time.Second},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
+	step.leakDetection = &api.LeakDetection{Tag: "owner-${CLUSTER_NAME}"}
+	pods, _, err := step.generatePods([]api.LiteralTestStep{leakDetectionStep(step.leakDetection)}, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("expected one Pod, got %d", len(pods))
+	}
+	if actual, expected := pods[0].Name, "test-leak-detection"; actual != expected {
+		t.Errorf("expected Pod %s, got %s", expected, actual)
+	}
+	container := pods[0].Spec.Containers[0]
+	if actual, expected := container.Image, api.DefaultLeakDetectionImage; actual != expected {
+		t.Errorf("expected image %s, got %s", expected, actual)
+	}
+	var entrypointOptions string
+	for _, env := range container.Env {
+		if env.Name == "ENTRYPOINT_OPTIONS" {
+			entrypointOptions = env.Value
+		}
+	}
+	if !strings.Contains(entrypointOptions, `leak-detector --tag='owner-${CLUSTER_NAME}'`) {
+		t.Errorf("unexpected entrypoint options: %s", entrypointOptions)
+	}
+}
@@ -0,0 +1,156 @@
+package multi_stage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+// liveLogInterval is how often the log of a running step is uploaded.
+var liveLogInterval = 30 * time.Second
+
+// liveLogFilename is the object the live log is uploaded to. It is kept apart
+// from the build-log.txt the sidecar uploads once the step finishes, so that
+// a partial log uploaded late never replaces the complete one.
+const liveLogFilename = "live-log.txt"
+
+// liveLogFinished replaces the live log once the sidecar uploaded the complete
+// log of the step, so that the partial one is not mistaken for it.
+const liveLogFinished = "The step finished, its complete log is in build-log.txt.\n"
+
+// liveLogTailLines is how many lines at the end of the log of a step are
+// uploaded each time, so that the latest output can be followed however long
+// the log grows.
+var liveLogTailLines int64 = 10000
+
+// liveLogLimit bounds how much of the tail of the log is kept and uploaded
+// each time, for steps printing very long lines.
+var liveLogLimit int64 = 10 * 1024 * 1024
+
+// streamLiveLog uploads the tail of the log of the step container of the pod
+// to the artifact directory of the step while it runs, so that the output of
+// long steps can be followed before the sidecar uploads the complete log.
+// Objects in the bucket cannot be appended to, so the tail is uploaded again
+// whenever it changed. The returned function stops the upload and waits for
+// it, then replaces the live log with a pointer to the complete one when the
+// pod finished and its sidecar uploaded it.
+func (s *multiStageTestStep) streamLiveLog(ctx context.Context, pod *coreapi.Pod) func(finished bool) {
+	if s.liveLogs == nil {
+		return func(bool) {}
+	}
+	step, ok := pod.Labels[base_steps.LabelMetadataStep]
+	if !ok {
+		return func(bool) {}
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.CSI != nil {
+			logrus.Debugf("Not streaming the log of %s, the credentials it mounts with the CSI driver cannot be censored.", pod.Name)
+			return func(bool) {}
+		}
+	}
+	logger := logrus.WithField("pod", pod.Name)
+	target := path.Join(s.name, step, liveLogFilename)
+	streamCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var uploaded []byte
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(liveLogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			tail, finished, err := s.uploadLiveLog(streamCtx, pod.Namespace, pod.Name, target, uploaded)
+			if err != nil {
+				logger.WithError(err).Debug("Failed to upload the live log of the step.")
+				continue
+			}
+			uploaded = tail
+			if finished {
+				return
+			}
+		}
+	}()
+	return func(finished bool) {
+		cancel()
+		<-done
+		if !finished || uploaded == nil {
+			return
+		}
+		if err := s.writeLiveLog(ctx, target, []byte(liveLogFinished)); err != nil {
+			logger.WithError(err).Debug("Failed to replace the live log of the step.")
+		}
+	}
+}
+
+// uploadLiveLog uploads the censored tail of the log of the step container
+// unless it did not change since the tail uploaded last, and returns the tail
+// now uploaded. It reports the upload as finished once the container finished.
+func (s *multiStageTestStep) uploadLiveLog(ctx context.Context, namespace, name, target string, uploaded []byte) ([]byte, bool, error) {
+	pod := &coreapi.Pod{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, pod); err != nil {
+		return uploaded, false, fmt.Errorf("could not get the pod: %w", err)
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		if status.State.Terminated != nil {
+			return uploaded, true, nil
+		}
+		if status.State.Running == nil {
+			return uploaded, false, nil
+		}
+	}
+	// the limit applies to the start of what is streamed, so the end of the
+	// log is kept while reading it instead
+	logs, err := s.client.StreamLogs(ctx, namespace, name, &coreapi.PodLogOptions{Container: containerName, TailLines: &liveLogTailLines})
+	if err != nil {
+		return uploaded, false, fmt.Errorf("could not stream the log: %w", err)
+	}
+	defer logs.Close()
+	raw, _, err := base_steps.ReadTail(logs, int(liveLogLimit))
+	if err != nil {
+		return uploaded, false, fmt.Errorf("could not read the log: %w", err)
+	}
+	if len(raw) == 0 || bytes.Equal(raw, uploaded) {
+		return uploaded, false, nil
+	}
+	tail := bytes.Clone(raw)
+	censor, err := base_steps.NamespaceCensor(ctx, s.client, namespace)
+	if err != nil {
+		return uploaded, false, err
+	}
+	censor.Censor(&raw)
+	if err := s.writeLiveLog(ctx, target, raw); err != nil {
+		return uploaded, false, err
+	}
+	return tail, false, nil
+}
+
+func (s *multiStageTestStep) writeLiveLog(ctx context.Context, target string, content []byte) error {
+	w, err := s.liveLogs.Writer(ctx, target)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("could not write the log: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not upload the log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package multi_stage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+type memoryUploader map[string]string
+
+func (u memoryUploader) Writer(_ context.Context, path string) (io.WriteCloser, error) {
+	return &memoryWriter{uploader: u, path: path}, nil
+}
+
+type memoryWriter struct {
+	bytes.Buffer
+	uploader memoryUploader
+	path     string
+}
+
+func (w *memoryWriter) Close() error {
+	w.uploader[w.path] = w.String()
+	return nil
+}
+
+func TestUploadLiveLog(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:password"))
+	secrets := []*coreapi.Secret{
+		{
+			ObjectMeta: meta.ObjectMeta{Name: "token", Namespace: "ns"},
+			Data:       map[string][]byte{"token": []byte("hunter2")},
+		},
+		{
+			ObjectMeta: meta.ObjectMeta{Name: "pull-secret", Namespace: "ns"},
+			Data:       map[string][]byte{coreapi.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"` + auth + `"}}}`)},
+		},
+	}
+	tailLines := liveLogTailLines
+	liveLogTailLines = 2
+	t.Cleanup(func() { liveLogTailLines = tailLines })
+	for _, tc := range []struct {
+		name             string
+		state            coreapi.ContainerState
+		uploaded         string
+		log              string
+		limit            int64
+		expected         memoryUploader
+		expectedTail     string
+		expectedFinished bool
+	}{
+		{
+			name:         "running step",
+			state:        coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+			log:          "logging in with hunter2\nauth " + auth + "\n",
+			expected:     memoryUploader{"e2e/step/live-log.txt": "logging in with XXXXXXX\nauth " + "XXXXXXXXXXXXXXXXXXXX" + "\n"},
+			expectedTail: "logging in with hunter2\nauth " + auth + "\n",
+		},
+		{
+			name:         "log did not change",
+			state:        coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+			log:          "output",
+			uploaded:     "output",
+			expected:     memoryUploader{},
+			expectedTail: "output",
+		},
+		{
+			name:     "step did not start",
+			state:    coreapi.ContainerState{Waiting: &coreapi.ContainerStateWaiting{}},
+			log:      "output",
+			expected: memoryUploader{},
+		},
+		{
+			name:         "only the tail of a long log is uploaded",
+			state:        coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+			log:          "first\nsecond\nthird\nfourth\n",
+			uploaded:     "first\nsecond\n",
+			expected:     memoryUploader{"e2e/step/live-log.txt": "third\nfourth\n"},
+			expectedTail: "third\nfourth\n",
+		},
+		{
+			name:         "the cut line of a long tail is not uploaded",
+			state:        coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}},
+			log:          "the token is hunter2\nend\n",
+			limit:        10,
+			expected:     memoryUploader{"e2e/step/live-log.txt": "end\n"},
+			expectedTail: "end\n",
+		},
+		{
+			name:             "step finished",
+			state:            coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{}},
+			log:              "output",
+			uploaded:         "out",
+			expected:         memoryUploader{},
+			expectedTail:     "out",
+			expectedFinished: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.limit != 0 {
+				limit := liveLogLimit
+				liveLogLimit = tc.limit
+				t.Cleanup(func() { liveLogLimit = limit })
+			}
+			pod := &coreapi.Pod{
+				ObjectMeta: meta.ObjectMeta{Name: "e2e-step", Namespace: "ns"},
+				Status:     coreapi.PodStatus{ContainerStatuses: []coreapi.ContainerStatus{{Name: containerName, State: tc.state}}},
+			}
+			builder := fakectrlruntimeclient.NewClientBuilder().WithObjects(pod)
+			for _, secret := range secrets {
+				builder = builder.WithObjects(secret)
+			}
+			uploader := memoryUploader{}
+			s := &multiStageTestStep{
+				name: "e2e",
+				client: &testhelper_kube.FakePodClient{
+					FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(builder.Build())},
+					Logs:            map[string]string{"ns/e2e-step/test": tc.log},
+				},
+				liveLogs: uploader,
+			}
+			var uploaded []byte
+			if tc.uploaded != "" {
+				uploaded = []byte(tc.uploaded)
+			}
+			tail, finished, err := s.uploadLiveLog(context.Background(), "ns", "e2e-step", "e2e/step/live-log.txt", uploaded)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(tail) != tc.expectedTail || finished != tc.expectedFinished {
+				t.Errorf("expected tail %q and finished %t, got %q and %t", tc.expectedTail, tc.expectedFinished, tail, finished)
+			}
+			if diff := cmp.Diff(tc.expected, uploader); diff != "" {
+				t.Errorf("unexpected uploads: %s", diff)
+			}
+		})
+	}
+}
+
+type lockedUploader struct {
+	sync.Mutex
+	uploads memoryUploader
+}
+
+func (u *lockedUploader) Writer(_ context.Context, path string) (io.WriteCloser, error) {
+	return &lockedWriter{uploader: u, path: path}, nil
+}
+
+func (u *lockedUploader) get(path string) (string, bool) {
+	u.Lock()
+	defer u.Unlock()
+	content, ok := u.uploads[path]
+	return content, ok
+}
+
+type lockedWriter struct {
+	bytes.Buffer
+	uploader *lockedUploader
+	path     string
+}
+
+func (w *lockedWriter) Close() error {
+	w.uploader.Lock()
+	defer w.uploader.Unlock()
+	w.uploader.uploads[w.path] = w.String()
+	return nil
+}
+
+func TestStreamLiveLog(t *testing.T) {
+	interval := liveLogInterval
+	liveLogInterval = time.Millisecond
+	t.Cleanup(func() { liveLogInterval = interval })
+	for _, tc := range []struct {
+		name     string
+		finished bool
+		expected string
+	}{
+		{
+			name:     "live log is kept when the pod did not finish",
+			expected: "output\n",
+		},
+		{
+			name:     "live log is replaced once the complete log is uploaded",
+			finished: true,
+			expected: liveLogFinished,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &coreapi.Pod{
+				ObjectMeta: meta.ObjectMeta{Name: "e2e-step", Namespace: "ns", Labels: map[string]string{base_steps.LabelMetadataStep: "step"}},
+				Status:     coreapi.PodStatus{ContainerStatuses: []coreapi.ContainerStatus{{Name: containerName, State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}}}}},
+			}
+			uploader := &lockedUploader{uploads: memoryUploader{}}
+			s := &multiStageTestStep{
+				name: "e2e",
+				client: &testhelper_kube.FakePodClient{
+					FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(pod).Build())},
+					Logs:            map[string]string{"ns/e2e-step/test": "output\n"},
+				},
+				liveLogs: uploader,
+			}
+			stop := s.streamLiveLog(context.Background(), pod)
+			if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+				_, ok := uploader.get("e2e/step/live-log.txt")
+				return ok, nil
+			}); err != nil {
+				t.Fatalf("the live log was not uploaded: %v", err)
+			}
+			stop(tc.finished)
+			if content, _ := uploader.get("e2e/step/live-log.txt"); content != tc.expected {
+				t.Errorf("expected the live log to be %q, got %q", tc.expected, content)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package multi_stage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -20,6 +22,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
@@ -95,7 +98,9 @@ type multiStageTestStep struct {
 	client                      kubernetes.PodClient
 	jobSpec                     *api.JobSpec
 	observers                   []api.Observer
-	pre, test, post             []api.LiteralTestStep
+	pre, test, gather, post     []api.LiteralTestStep
+	gatherTimeout               time.Duration
+	budgets                     api.PhaseBudgets
 	subLock                     *sync.Mutex
 	subTests                    []*junit.TestCase
 	subSteps                    []api.CIOperatorStepDetailInfo
@@ -103,9 +108,29 @@ type multiStageTestStep struct {
 	leases                      []api.StepLease
 	clusterClaim                *api.ClusterClaim
 	vpnConf                     *vpnConf
+	workloadIdentity            *api.WorkloadIdentity
+	leakDetection               *api.LeakDetection
+	failures                    []stepFailure
 	cancelObservers             func(context.CancelFunc)
 	nodeArchitecture            api.NodeArchitecture
+	labels                      map[string]string
+	annotations                 map[string]string
+	nodeSelector                map[string]string
+	tolerations                 []api.Toleration
+	spread                      api.Spread
+	soak                        *api.SoakConfiguration
+	networkStack                api.NetworkStack
+	isolateStableStreams        bool
 	enableSecretsStoreCSIDriver bool
+	// logLimit caps the output of the steps which do not set their own limit.
+	logLimit *api.LogLimit
+	// isolatedStreams maps the stable streams used by the test to their
+	// isolated copies.
+	isolatedStreams map[string]string
+	// liveLogs receives the logs of the steps while they run, if set.
+	liveLogs base_steps.ArtifactUploader
+	// stepArtifacts reads the artifacts the steps uploaded, if set.
+	stepArtifacts base_steps.ArtifactReader
 }
 
 func MultiStageTestStep(
@@ -119,8 +144,11 @@ func MultiStageTestStep(
 	targetAdditionalSuffix string,
 	cancelObservers func(context.CancelFunc),
 	enableSecretsStoreCSIDriver bool,
+	logLimit *api.LogLimit,
+	liveLogs base_steps.ArtifactUploader,
+	stepArtifacts base_steps.ArtifactReader,
 ) api.Step {
-	return newMultiStageTestStep(testConfig, config, params, client, jobSpec, leases, nodeName, targetAdditionalSuffix, cancelObservers, enableSecretsStoreCSIDriver)
+	return newMultiStageTestStep(testConfig, config, params, client, jobSpec, leases, nodeName, targetAdditionalSuffix, cancelObservers, enableSecretsStoreCSIDriver, logLimit, liveLogs, stepArtifacts)
 }
 
 func newMultiStageTestStep(
@@ -134,6 +162,9 @@ func newMultiStageTestStep(
 	targetAdditionalSuffix string,
 	cancelObservers func(context.CancelFunc),
 	enableSecretsStoreCSIDriver bool,
+	logLimit *api.LogLimit,
+	liveLogs base_steps.ArtifactUploader,
+	stepArtifacts base_steps.ArtifactReader,
 ) *multiStageTestStep {
 	ms := testConfig.MultiStageTestConfigurationLiteral
 	var flags stepFlag
@@ -143,6 +174,14 @@ func newMultiStageTestStep(
 	if p := ms.AllowBestEffortPostSteps; p != nil && *p {
 		flags |= allowBestEffortPostSteps
 	}
+	test := ms.Test
+	if testConfig.Soak != nil {
+		test = soakSteps(ms.Test, testConfig.Soak)
+	}
+	var budgets api.PhaseBudgets
+	if ms.Budgets != nil {
+		budgets = *ms.Budgets
+	}
 	return &multiStageTestStep{
 		name:                        testConfig.As,
 		additionalSuffix:            targetAdditionalSuffix,
@@ -155,7 +194,10 @@ func newMultiStageTestStep(
 		jobSpec:                     jobSpec,
 		observers:                   ms.Observers,
 		pre:                         ms.Pre,
-		test:                        ms.Test,
+		test:                        test,
+		gather:                      ms.Gather,
+		gatherTimeout:               budget(ms.GatherTimeout),
+		budgets:                     budgets,
 		post:                        ms.Post,
 		flags:                       flags,
 		leases:                      leases,
@@ -163,8 +205,28 @@ func newMultiStageTestStep(
 		subLock:                     &sync.Mutex{},
 		cancelObservers:             cancelObservers,
 		nodeArchitecture:            testConfig.NodeArchitecture,
+		labels:                      testConfig.Labels,
+		annotations:                 testConfig.Annotations,
+		nodeSelector:                testConfig.NodeSelector,
+		tolerations:                 testConfig.Tolerations,
+		spread:                      testConfig.Spread,
+		soak:                        testConfig.Soak,
+		networkStack:                ms.NetworkStack,
+		isolateStableStreams:        testConfig.IsolateStableStreams,
 		enableSecretsStoreCSIDriver: enableSecretsStoreCSIDriver,
+		logLimit:                    logLimit,
+		liveLogs:                    liveLogs,
+		stepArtifacts:               stepArtifacts,
+	}
+}
+
+// steps returns the steps of all phases, in the order they run.
+func (s *multiStageTestStep) steps() []api.LiteralTestStep {
+	ret := make([]api.LiteralTestStep, 0, len(s.pre)+len(s.test)+len(s.gather)+len(s.post))
+	for _, steps := range [][]api.LiteralTestStep{s.pre, s.test, s.gather, s.post} {
+		ret = append(ret, steps...)
 	}
+	return ret
 }
 
 func (s *multiStageTestStep) profileSecretName() string {
@@ -192,18 +254,31 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 			return err
 		}
 	}
+	if s.leakDetection != nil {
+		s.post = append(slices.Clip(s.post), leakDetectionStep(s.leakDetection))
+	}
 	env, err := s.environment()
 	if err != nil {
 		return err
 	}
+	if s.isolateStableStreams {
+		if err := s.copyStableStreams(ctx); err != nil {
+			return fmt.Errorf("failed to isolate stable streams: %w", err)
+		}
+		defer s.deleteStableStreamCopies()
+	}
+	if watcher, err := s.watchSharedStreams(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to watch shared imagestreams, changes to them will not be reported")
+	} else {
+		defer s.reportSharedStreamMutations(watcher)
+	}
 	if err := s.createSharedDirSecret(ctx); err != nil {
 		return fmt.Errorf("failed to create secret: %w", err)
 	}
-	if s.enableSecretsStoreCSIDriver {
-		if err := s.createSPCs(ctx); err != nil {
-			return fmt.Errorf("failed to create SecretProviderClass objects: %w", err)
-		}
-	} else {
+	if err := s.createSPCs(ctx); err != nil {
+		return fmt.Errorf("failed to create SecretProviderClass objects: %w", err)
+	}
+	if !s.enableSecretsStoreCSIDriver {
 		if err := s.createCredentials(ctx); err != nil {
 			return fmt.Errorf("failed to create credentials: %w", err)
 		}
@@ -223,9 +298,7 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if s.enableSecretsStoreCSIDriver {
-		secretVolumes, secretVolumeMounts = s.addCredentialsToCensoring(secretVolumes, secretVolumeMounts)
-	}
+	secretVolumes, secretVolumeMounts = s.addCredentialsToCensoring(secretVolumes, secretVolumeMounts)
 	var errs []error
 	generateObserverOpt := defaultGeneratePodOptions()
 	generateObserverOpt.IsObserver = true
@@ -239,17 +312,25 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	observerDone := make(chan struct{})
 	go s.runObservers(observerContext, ctx, observers, observerDone)
 	s.flags |= shortCircuit
-	if err := s.runSteps(ctx, "pre", s.pre, env, secretVolumes, secretVolumeMounts); err != nil {
+	if exceeded, err := s.runWithBudget(ctx, "pre", s.pre, budget(s.budgets.Pre), env, secretVolumes, secretVolumeMounts); err != nil {
 		errs = append(errs, fmt.Errorf("%q pre steps failed: %w", s.name, err))
-	} else if err := s.runSteps(ctx, "test", s.test, env, secretVolumes, secretVolumeMounts); err != nil {
+		if exceeded {
+			s.skipPhase("test", fmt.Sprintf("the pre phase exceeded its budget of %s", budget(s.budgets.Pre)))
+		}
+	} else if err := s.withSoakCheckpoints(ctx, func() error {
+		_, err := s.runWithBudget(ctx, "test", s.test, budget(s.budgets.Test), env, secretVolumes, secretVolumeMounts)
+		return err
+	}); err != nil {
 		errs = append(errs, fmt.Errorf("%q test steps failed: %w", s.name, err))
 	}
-	s.cancelObserversContext(cancel) // signal to observers that we're tearing down
 	s.flags &= ^shortCircuit
-	if err := s.runSteps(context.Background(), "post", s.post, env, secretVolumes, secretVolumeMounts); err != nil {
+	s.runGather(env, secretVolumes, secretVolumeMounts)
+	s.cancelObserversContext(cancel) // signal to observers that we're tearing down
+	if _, err := s.runWithBudget(context.Background(), "post", s.post, budget(s.budgets.Post), env, secretVolumes, secretVolumeMounts); err != nil {
 		errs = append(errs, fmt.Errorf("%q post steps failed: %w", s.name, err))
 	}
 	<-observerDone // wait for the observers to finish so we get their jUnit
+	s.writeJUnitSummary(context.Background())
 	return utilerrors.NewAggregate(errs)
 }
 
@@ -271,7 +352,7 @@ func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
 		claimRelease = s.clusterClaim.ClaimRelease(s.name)
 	}
 	var needsReleaseImage, needsReleasePayload bool
-	for _, step := range append(append(s.pre, s.test...), s.post...) {
+	for _, step := range s.steps() {
 		if link, ok := step.FromImageTag(); ok {
 			ret = append(ret, api.InternalImageLink(link))
 		} else {
@@ -342,6 +423,28 @@ func (s *multiStageTestStep) getProfileData(ctx context.Context) error {
 	if err := s.readVPNData(&secret); err != nil {
 		return fmt.Errorf("failed to read VPN configuration from cluster profile: %w", err)
 	}
+	if err := s.readWorkloadIdentity(&secret); err != nil {
+		return fmt.Errorf("failed to read workload identity configuration from cluster profile: %w", err)
+	}
+	if err := s.readLeakDetection(&secret); err != nil {
+		return fmt.Errorf("failed to read leak detection configuration from cluster profile: %w", err)
+	}
+	return nil
+}
+
+func (s *multiStageTestStep) readWorkloadIdentity(secret *coreapi.Secret) error {
+	bytes, ok := secret.Data[api.WorkloadIdentityConfigKey]
+	if !ok {
+		return nil
+	}
+	var wi api.WorkloadIdentity
+	if err := json.Unmarshal(bytes, &wi); err != nil {
+		return fmt.Errorf("failed to read workload identity configuration file: %w", err)
+	}
+	if err := wi.Validate(); err != nil {
+		return fmt.Errorf("invalid workload identity configuration: %w", err)
+	}
+	s.workloadIdentity = &wi
 	return nil
 }
 
@@ -414,6 +517,12 @@ func (s *multiStageTestStep) environment() ([]coreapi.EnvVar, error) {
 			ret = append(ret, coreapi.EnvVar{Name: api.DefaultIPPoolLeaseEnv, Value: val})
 		}
 	}
+	if s.networkStack != "" {
+		ret = append(ret, coreapi.EnvVar{Name: api.NetworkStackEnv, Value: string(s.networkStack)})
+	}
+	if s.isolateStableStreams {
+		ret = append(ret, coreapi.EnvVar{Name: api.StableStreamSuffixEnv, Value: s.name})
+	}
 	return ret, nil
 }
 
@@ -477,12 +586,16 @@ func secretsForCensoring(client loggingclient.LoggingClient, namespace string, c
 func (s *multiStageTestStep) addCredentialsToCensoring(secretVolumes []coreapi.Volume, secretVolumeMounts []coreapi.VolumeMount) ([]coreapi.Volume, []coreapi.VolumeMount) {
 	seenCredentials := make(map[string]bool)
 	i := 0
-	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+	for _, step := range s.steps() {
 		for _, credential := range step.Credentials {
-			if seenCredentials[credential.Name] {
+			if credential.Cloud == nil && !s.enableSecretsStoreCSIDriver {
+				continue
+			}
+			name := spcName(s.jobSpec.Namespace(), credential)
+			if seenCredentials[name] {
 				continue
 			}
-			seenCredentials[credential.Name] = true
+			seenCredentials[name] = true
 			volumeName := fmt.Sprintf("censor-cred-%d", i)
 			readOnly := true
 			secretVolumes = append(secretVolumes, coreapi.Volume{
@@ -492,14 +605,14 @@ func (s *multiStageTestStep) addCredentialsToCensoring(secretVolumes []coreapi.V
 						Driver:   "secrets-store.csi.k8s.io",
 						ReadOnly: &readOnly,
 						VolumeAttributes: map[string]string{
-							"secretProviderClass": fmt.Sprintf("%s-%s-spc", s.jobSpec.Namespace(), credential.Name),
+							"secretProviderClass": name,
 						},
 					},
 				},
 			})
 			secretVolumeMounts = append(secretVolumeMounts, coreapi.VolumeMount{
 				Name:      volumeName,
-				MountPath: getMountPath(credential.Name),
+				MountPath: credentialCensorMountPath(credential),
 			})
 			i++
 		}
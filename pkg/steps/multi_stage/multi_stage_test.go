@@ -81,7 +81,7 @@ func TestRequires(t *testing.T) {
 				As:                                 "some-e2e",
 				ClusterClaim:                       tc.clusterClaim,
 				MultiStageTestConfigurationLiteral: &tc.steps,
-			}, &tc.config, api.NewDeferredParameters(nil), nil, nil, nil, "node-name", "", nil, false)
+			}, &tc.config, api.NewDeferredParameters(nil), nil, nil, nil, "node-name", "", nil, false, nil, nil, nil)
 			ret := step.Requires()
 			if len(ret) == len(tc.req) {
 				matches := true
@@ -286,6 +286,8 @@ func TestAddCredentialsToCensoring(t *testing.T) {
 				test:    tc.test,
 				post:    tc.post,
 				jobSpec: &api.JobSpec{},
+
+				enableSecretsStoreCSIDriver: true,
 			}
 			secretVolumes := []coreapi.Volume{{Name: "censor-0"}}
 			secretVolumeMounts := []coreapi.VolumeMount{{
@@ -322,11 +324,13 @@ func (f fakeStepParams) Get(key string) (string, error) {
 func TestEnvironment(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name      string
-		params    api.Parameters
-		leases    []api.StepLease
-		expected  []coreapi.EnvVar
-		expectErr bool
+		name         string
+		params       api.Parameters
+		leases       []api.StepLease
+		networkStack api.NetworkStack
+		isolate      bool
+		expected     []coreapi.EnvVar
+		expectErr    bool
 	}{
 		{
 			name:     "leases are exposed in environment",
@@ -352,13 +356,28 @@ func TestEnvironment(t *testing.T) {
 				{Name: "ORIGINAL_RELEASE_IMAGE_LATEST", Value: "latest"},
 			},
 		},
+		{
+			name:         "network stack is exposed in environment",
+			params:       fakeStepParams{},
+			networkStack: api.NetworkStackDualStack,
+			expected:     []coreapi.EnvVar{{Name: "NETWORK_STACK", Value: "dualstack"}},
+		},
+		{
+			name:     "suffix of isolated stable streams is exposed in environment",
+			params:   fakeStepParams{},
+			isolate:  true,
+			expected: []coreapi.EnvVar{{Name: "STABLE_STREAM_SUFFIX", Value: "e2e"}},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			s := &multiStageTestStep{
-				params: tc.params,
-				leases: tc.leases,
+				name:                 "e2e",
+				params:               tc.params,
+				leases:               tc.leases,
+				networkStack:         tc.networkStack,
+				isolateStableStreams: tc.isolate,
 			}
 			got, err := s.environment()
 			if (err != nil) != tc.expectErr {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +34,14 @@ func (s *multiStageTestStep) runSteps(
 ) error {
 	start := time.Now()
 	logrus.Infof("Running multi-stage phase %s", phase)
+	if phase == "gather" || phase == "post" {
+		failureEnv, err := s.failureEnv()
+		if err != nil {
+			s.flags |= hasPrevErrs
+			return err
+		}
+		env = append(slices.Clip(env), failureEnv...)
+	}
 	pods, bestEffortSteps, err := s.generatePods(steps, env, secretVolumes, secretVolumeMounts, &generatePodOptions{
 		enableSecretsStoreCSIDriver: s.enableSecretsStoreCSIDriver,
 	})
@@ -46,11 +55,16 @@ func (s *multiStageTestStep) runSteps(
 			s.flags |= hasPrevErrs
 		}
 	}()
-	if err := s.runPods(ctx, pods, bestEffortSteps); err != nil {
+	if err := s.runPods(ctx, phase, pods, bestEffortSteps); err != nil {
 		errs = append(errs, err)
 	}
 	select {
 	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), errBudgetExceeded) {
+			// only this phase ran out of time, the others must go on
+			errs = append(errs, s.deletePods(pods)...)
+			break
+		}
 		logrus.Infof("cleanup: Deleting pods with label %s=%s", MultiStageTestLabel, s.name)
 		if err := s.client.DeleteAllOf(base_steps.CleanupCtx, &coreapi.Pod{}, ctrlruntimeclient.InNamespace(s.jobSpec.Namespace()), ctrlruntimeclient.MatchingLabels{MultiStageTestLabel: s.name}); err != nil && !kerrors.IsNotFound(err) {
 			errs = append(errs, fmt.Errorf("failed to delete pods with label %s=%s: %w", MultiStageTestLabel, s.name, err))
@@ -64,9 +78,10 @@ func (s *multiStageTestStep) runSteps(
 	finished := time.Now()
 	duration := finished.Sub(start)
 	testCase := &junit.TestCase{
-		Name:      fmt.Sprintf("Run multi-stage test %s phase", phase),
-		Duration:  duration.Seconds(),
-		SystemOut: fmt.Sprintf("The collected steps of multi-stage phase %s.", phase),
+		Name:       fmt.Sprintf("Run multi-stage test %s phase", phase),
+		Duration:   duration.Seconds(),
+		SystemOut:  fmt.Sprintf("The collected steps of multi-stage phase %s.", phase),
+		Properties: []*junit.TestSuiteProperty{{Name: junit.PhaseProperty, Value: phase}},
 	}
 	verb := "succeeded"
 	if err != nil {
@@ -81,13 +96,26 @@ func (s *multiStageTestStep) runSteps(
 	return err
 }
 
-func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
+// deletePods stops the Pods of a phase.
+func (s *multiStageTestStep) deletePods(pods []coreapi.Pod) []error {
+	var errs []error
+	for i := range pods {
+		logrus.Infof("cleanup: Deleting pod %s", pods[i].Name)
+		if err := s.client.Delete(base_steps.CleanupCtx, &pods[i]); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete pod %s: %w", pods[i].Name, err))
+		}
+	}
+	return append(errs, errors.New("timed out"))
+}
+
+func (s *multiStageTestStep) runPods(ctx context.Context, phase string, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
 	var errs []error
 	for _, pod := range pods {
-		err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
+		err := s.runPod(ctx, phase, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
 		if err == nil {
 			continue
 		}
+		s.recordFailure(phase, &pod)
 		if bestEffortSteps != nil && bestEffortSteps.Has(pod.Name) {
 			logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
 			continue
@@ -113,7 +141,7 @@ func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []c
 			}
 		}(pod)
 		go func(p coreapi.Pod) {
-			err := s.runPod(textCtx, &p, base_steps.NewTestCaseNotifier(util.NopNotifier), util.Interruptible)
+			err := s.runPod(textCtx, "", &p, base_steps.NewTestCaseNotifier(util.NopNotifier), util.Interruptible)
 			if ctx.Err() == nil {
 				// when the observer is cancelled, we get an error here that we need to ignore, as it's not an error
 				// for the Pod to be deleted when it's cancelled, it's just expected
@@ -134,17 +162,28 @@ func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []c
 	done <- struct{}{}
 }
 
-func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag) error {
+// runPod runs the pod of a step, recording the test cases of its containers
+// under the phase the step belongs to. Observers run across phases and pass
+// none.
+func (s *multiStageTestStep) runPod(ctx context.Context, phase string, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag) error {
 	start := time.Now()
 	logrus.Infof("Running step %s.", pod.Name)
 	client := s.client.WithNewLoggingClient()
 	if _, err := util.CreateOrRestartPod(ctx, client, pod); err != nil {
 		return fmt.Errorf("failed to create or restart %s pod: %w", pod.Name, err)
 	}
+	samplingCtx, stopSampling := context.WithCancel(ctx)
+	peak := make(chan *api.StepResourceUsage, 1)
+	go samplePeakUsage(samplingCtx, client.New(), pod.Namespace, pod.Name, usageSamplingInterval, peak)
+	stopLiveLog := s.streamLiveLog(ctx, pod)
 	newPod, err := util.WaitForPodCompletion(ctx, client, pod.Namespace, pod.Name, notifier, flags)
+	stopSampling()
+	usage := <-peak
 	if newPod != nil {
-		pod = newPod
+		*pod = *newPod
 	}
+	// The sidecar uploaded the complete log once all containers of the pod finished
+	stopLiveLog(newPod != nil && (newPod.Status.Phase == coreapi.PodSucceeded || newPod.Status.Phase == coreapi.PodFailed))
 	finished := time.Now()
 	duration := finished.Sub(start)
 	verb := "succeeded"
@@ -154,15 +193,21 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	logrus.Infof("Step %s %s after %s.", pod.Name, verb, duration.Truncate(time.Second))
 	s.subLock.Lock()
 	s.subSteps = append(s.subSteps, api.CIOperatorStepDetailInfo{
-		StepName:    pod.Name,
-		Description: fmt.Sprintf("Run pod %s", pod.Name),
-		StartedAt:   &start,
-		FinishedAt:  &finished,
-		Duration:    &duration,
-		Failed:      utilpointer.Bool(err != nil),
-		Manifests:   client.Objects(),
+		StepName:      pod.Name,
+		Description:   fmt.Sprintf("Run pod %s", pod.Name),
+		StartedAt:     &start,
+		FinishedAt:    &finished,
+		Duration:      &duration,
+		Failed:        utilpointer.Bool(err != nil),
+		Manifests:     client.Objects(),
+		ResourceUsage: usage,
 	})
-	s.subTests = append(s.subTests, notifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), pod.Name))...)
+	var properties []*junit.TestSuiteProperty
+	if phase != "" {
+		properties = append(properties, &junit.TestSuiteProperty{Name: junit.PhaseProperty, Value: phase})
+	}
+	properties = append(properties, usageProperties(usage)...)
+	s.subTests = append(s.subTests, notifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), pod.Name), properties...)...)
 	s.subLock.Unlock()
 	if err != nil {
 		linksText := strings.Builder{}
@@ -182,3 +227,18 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	}
 	return nil
 }
+
+// runGather runs the `gather` phase, which collects diagnostics before the
+// `post` phase tears down the environment. It always runs, within its own
+// timeout when one is configured, and its failures are reported in the jUnit
+// but never change the result of the test or which `post` steps run.
+func (s *multiStageTestStep) runGather(env []coreapi.EnvVar, secretVolumes []coreapi.Volume, secretVolumeMounts []coreapi.VolumeMount) {
+	if len(s.gather) == 0 {
+		return
+	}
+	flags := s.flags
+	if _, err := s.runWithBudget(context.Background(), "gather", s.gather, s.gatherTimeout, env, secretVolumes, secretVolumeMounts); err != nil {
+		logrus.WithError(err).Warnf("%q gather steps failed, ignoring", s.name)
+	}
+	s.flags = flags
+}
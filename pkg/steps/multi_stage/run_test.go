@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
@@ -125,7 +127,7 @@ func TestRun(t *testing.T) {
 					Observers:          tc.observers,
 					AllowSkipOnSuccess: &yes,
 				},
-			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false)
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, nil, nil)
 
 			// An Observer pod failure doesn't make the test fail
 			failures := tc.failures.Delete(observerPodNames.UnsortedList()...)
@@ -169,6 +171,223 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunGather(t *testing.T) {
+	yes := true
+	for _, tc := range []struct {
+		name               string
+		failures           sets.Set[string]
+		expectedError      bool
+		expectedFailedStep string
+		expected           []string
+	}{
+		{
+			name: "no step fails",
+			expected: []string{
+				"test-pre0", "test-test0",
+				"test-gather0", "test-gather1",
+				"test-post0",
+			},
+		},
+		{
+			name:     "failure in a gather step, other gather steps run and the test passes",
+			failures: sets.New[string]("test-gather0"),
+			expected: []string{
+				"test-pre0", "test-test0",
+				"test-gather0", "test-gather1",
+				"test-post0",
+			},
+		},
+		{
+			name:               "failure in a pre step, gather should run",
+			failures:           sets.New[string]("test-pre0"),
+			expectedError:      true,
+			expectedFailedStep: "pre0",
+			expected: []string{
+				"test-pre0",
+				"test-gather0", "test-gather1",
+				"test-post0", "test-post1",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &testhelper_kube.FakePodExecutor{
+				LoggingClient: loggingclient.New(
+					fakectrlruntimeclient.NewClientBuilder().
+						WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+						WithObjects(sa).
+						Build()),
+				Failures: tc.failures,
+			}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			client := &testhelper_kube.FakePodClient{PendingTimeout: 30 * time.Minute, FakePodExecutor: crclient}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre:                []api.LiteralTestStep{{As: "pre0"}},
+					Test:               []api.LiteralTestStep{{As: "test0"}},
+					Gather:             []api.LiteralTestStep{{As: "gather0"}, {As: "gather1"}},
+					Post:               []api.LiteralTestStep{{As: "post0"}, {As: "post1", OptionalOnSuccess: &yes}},
+					AllowSkipOnSuccess: &yes,
+					GatherTimeout:      &prowapi.Duration{Duration: time.Hour},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, nil, nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedError {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedError, err)
+			}
+			var names []string
+			for _, pod := range crclient.CreatedPods {
+				names = append(names, pod.Name)
+				if pod.Name != "test-gather1" {
+					continue
+				}
+				for _, env := range pod.Spec.Containers[0].Env {
+					if env.Name == FailedStepEnv && env.Value != tc.expectedFailedStep {
+						t.Errorf("expected %s=%q in gather step, got %q", FailedStepEnv, tc.expectedFailedStep, env.Value)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunWithBudgets(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		running       sets.Set[string]
+		timeout       time.Duration
+		expectedError bool
+		expected      []string
+		expectedSkip  bool
+	}{
+		{
+			name: "all phases within their budget",
+			expected: []string{
+				"test-pre0", "test-test0",
+				"test-gather0",
+				"test-post0",
+			},
+		},
+		{
+			name:          "pre overruns its budget, test is skipped but gather and post run",
+			running:       sets.New[string]("test-pre0"),
+			expectedError: true,
+			expected: []string{
+				"test-pre0",
+				"test-gather0",
+				"test-post0",
+			},
+			expectedSkip: true,
+		},
+		{
+			name:          "test overruns its budget, gather and post run",
+			running:       sets.New[string]("test-test0"),
+			expectedError: true,
+			expected: []string{
+				"test-pre0", "test-test0",
+				"test-gather0",
+				"test-post0",
+			},
+		},
+		{
+			name:          "the test times out before the budget of pre, test is not skipped for the budget",
+			running:       sets.New[string]("test-pre0"),
+			timeout:       10 * time.Millisecond,
+			expectedError: true,
+			expected: []string{
+				"test-pre0",
+				"test-gather0",
+				"test-post0",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &testhelper_kube.FakePodExecutor{
+				LoggingClient: loggingclient.New(
+					fakectrlruntimeclient.NewClientBuilder().
+						WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+						WithObjects(sa).
+						Build()),
+				Running: tc.running,
+			}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			client := &testhelper_kube.FakePodClient{PendingTimeout: 30 * time.Minute, FakePodExecutor: crclient}
+			budget := &prowapi.Duration{Duration: 100 * time.Millisecond}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre:     []api.LiteralTestStep{{As: "pre0"}},
+					Test:    []api.LiteralTestStep{{As: "test0"}},
+					Gather:  []api.LiteralTestStep{{As: "gather0"}},
+					Post:    []api.LiteralTestStep{{As: "post0"}},
+					Budgets: &api.PhaseBudgets{Pre: budget, Test: budget, Post: budget},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, nil, nil)
+			ctx := context.Background()
+			if tc.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			if err := step.Run(ctx); (err != nil) != tc.expectedError {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedError, err)
+			}
+			var names []string
+			for _, pod := range crclient.CreatedPods {
+				names = append(names, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var skipped bool
+			for _, test := range step.(steps.SubtestReporter).SubTests() {
+				if test.Name == "Run multi-stage test test phase" && test.SkipMessage != nil {
+					skipped = true
+				}
+			}
+			if skipped != tc.expectedSkip {
+				t.Errorf("expected test phase skipped: %t, got %t", tc.expectedSkip, skipped)
+			}
+		})
+	}
+}
+
 func TestJUnit(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -261,14 +480,23 @@ func TestJUnit(t *testing.T) {
 					Test: []api.LiteralTestStep{{As: "test0"}, {As: "test1"}},
 					Post: []api.LiteralTestStep{{As: "post0"}, {As: "post1"}},
 				},
-			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", nil, false)
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", nil, false, nil, nil, nil)
 			if err := step.Run(context.Background()); tc.failures == nil && err != nil {
 				t.Error(err)
 				return
 			}
 			var names []string
-			for _, t := range step.(steps.SubtestReporter).SubTests() {
-				names = append(names, t.Name)
+			for _, test := range step.(steps.SubtestReporter).SubTests() {
+				names = append(names, test.Name)
+				var phase string
+				for _, property := range test.Properties {
+					if property.Name == junit.PhaseProperty {
+						phase = property.Value
+					}
+				}
+				if phase == "" || !strings.Contains(test.Name, " - test-"+phase) && test.Name != fmt.Sprintf("Run multi-stage test %s phase", phase) {
+					t.Errorf("test case %q has the wrong phase %q", test.Name, phase)
+				}
 			}
 			if !reflect.DeepEqual(names, tc.expected) {
 				t.Error(diff.ObjectReflectDiff(names, tc.expected))
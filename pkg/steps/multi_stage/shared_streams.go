@@ -0,0 +1,319 @@
+package multi_stage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	toolswatch "k8s.io/client-go/tools/watch"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+// tagMutation is a tag of a shared imagestream that changed while the test ran.
+type tagMutation struct {
+	// previous is the image the tag pointed to when the test started
+	previous string
+	// current is the image the tag points to now, empty when it was removed
+	current string
+	// at is when the change was observed
+	at time.Time
+	// steps are the steps of the test whose pods ran when the change was observed
+	steps []string
+}
+
+// sharedStreamWatcher records the tags of the imagestreams shared by all
+// tests in the namespace that change while a test runs. Steps are not meant
+// to modify these streams: the images they hold are the outputs of other
+// steps, and retagging them contaminates every test that runs afterwards.
+// Jobs with the same inputs share the namespace, as do the tests of a job, so
+// the changes are only attributed to the test when no pod of another test or
+// job ran in it meanwhile.
+type sharedStreamWatcher struct {
+	lock sync.Mutex
+	now  func() time.Time
+	// snapshot holds the image of each `stream:tag` when the test started
+	snapshot  map[string]string
+	mutations map[string]tagMutation
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// isSharedStream determines whether a stream is shared by the tests of the
+// namespace, as opposed to an isolated copy owned by one of them.
+func (s *multiStageTestStep) isSharedStream(name string) bool {
+	if name != api.PipelineImageStream && !api.IsReleaseStream(name) {
+		return false
+	}
+	for _, test := range s.config.Tests {
+		if test.IsolateStableStreams && strings.HasSuffix(name, "-"+test.As) {
+			return false
+		}
+	}
+	return true
+}
+
+// watchSharedStreams snapshots the tags of the shared imagestreams and watches
+// them for changes until the watcher is stopped.
+func (s *multiStageTestStep) watchSharedStreams(ctx context.Context) (*sharedStreamWatcher, error) {
+	namespace := s.jobSpec.Namespace()
+	streams := &imagev1.ImageStreamList{}
+	if err := s.client.List(ctx, streams, ctrlruntimeclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("could not list imagestreams: %w", err)
+	}
+	w := &sharedStreamWatcher{
+		now:       time.Now,
+		snapshot:  map[string]string{},
+		mutations: map[string]tagMutation{},
+		done:      make(chan struct{}),
+	}
+	for _, stream := range streams.Items {
+		if !s.isSharedStream(stream.Name) {
+			continue
+		}
+		for tag, image := range tagImages(&stream) {
+			w.snapshot[fmt.Sprintf("%s:%s", stream.Name, tag)] = image
+		}
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	lw := &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return s.client.Watch(watchCtx, &imagev1.ImageStreamList{}, &ctrlruntimeclient.ListOptions{Namespace: namespace, Raw: &options})
+		},
+	}
+	go func() {
+		defer close(w.done)
+		resourceVersion := streams.ResourceVersion
+		for {
+			s.watchFrom(watchCtx, w, lw, resourceVersion)
+			// the watch cannot be resumed, so the streams are listed again to
+			// catch the changes it missed and watched from there
+			for resourceVersion = ""; resourceVersion == ""; {
+				select {
+				case <-watchCtx.Done():
+					return
+				case <-time.After(sharedStreamsRelistDelay):
+				}
+				streams := &imagev1.ImageStreamList{}
+				if err := s.client.List(watchCtx, streams, ctrlruntimeclient.InNamespace(namespace)); err != nil {
+					logrus.WithError(err).Debug("Failed to list imagestreams to resume watching them.")
+					continue
+				}
+				s.observeSharedStreams(w, streams)
+				resourceVersion = streams.ResourceVersion
+			}
+		}
+	}()
+	return w, nil
+}
+
+// sharedStreamsRelistDelay is how long to wait before listing the shared
+// streams again when their watch ended.
+const sharedStreamsRelistDelay = time.Second
+
+// watchFrom observes the changes to the shared streams from a resource
+// version until the context is cancelled or the watch cannot be resumed, as
+// when the resource version expired.
+func (s *multiStageTestStep) watchFrom(ctx context.Context, w *sharedStreamWatcher, lw cache.Watcher, resourceVersion string) {
+	events, err := toolswatch.NewRetryWatcher(resourceVersion, lw)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to watch imagestreams.")
+		return
+	}
+	defer events.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events.ResultChan():
+			if !ok {
+				return
+			}
+			stream, ok := event.Object.(*imagev1.ImageStream)
+			if !ok || !s.isSharedStream(stream.Name) {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				w.observe(stream.Name, tagImages(stream))
+			case watch.Deleted:
+				w.observe(stream.Name, nil)
+			}
+		}
+	}
+}
+
+// observeSharedStreams compares all the shared streams to the snapshot,
+// including the ones that were removed.
+func (s *multiStageTestStep) observeSharedStreams(w *sharedStreamWatcher, streams *imagev1.ImageStreamList) {
+	found := sets.New[string]()
+	for _, stream := range streams.Items {
+		if s.isSharedStream(stream.Name) {
+			found.Insert(stream.Name)
+			w.observe(stream.Name, tagImages(&stream))
+		}
+	}
+	w.lock.Lock()
+	removed := sets.New[string]()
+	for name := range w.snapshot {
+		if stream := name[:strings.LastIndex(name, ":")]; !found.Has(stream) {
+			removed.Insert(stream)
+		}
+	}
+	w.lock.Unlock()
+	for _, stream := range sets.List(removed) {
+		w.observe(stream, nil)
+	}
+}
+
+// tagImages maps the tags of a stream to the images they currently point to.
+func tagImages(stream *imagev1.ImageStream) map[string]string {
+	ret := map[string]string{}
+	for _, tag := range stream.Status.Tags {
+		if len(tag.Items) != 0 {
+			ret[tag.Tag] = tag.Items[0].Image
+		}
+	}
+	return ret
+}
+
+// observe compares the current tags of a stream to the ones it had when the
+// test started. Tags created in the meantime are the outputs of other steps.
+func (w *sharedStreamWatcher) observe(stream string, tags map[string]string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	prefix := stream + ":"
+	for name, previous := range w.snapshot {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		current := tags[strings.TrimPrefix(name, prefix)]
+		if current == previous {
+			continue
+		}
+		if mutation, ok := w.mutations[name]; ok && mutation.current == current {
+			continue
+		}
+		w.mutations[name] = tagMutation{previous: previous, current: current, at: w.now()}
+	}
+}
+
+// stopWatchingSharedStreams stops the watch and compares the shared streams
+// a last time, as events may have been missed.
+func (s *multiStageTestStep) stopWatchingSharedStreams(ctx context.Context, w *sharedStreamWatcher) map[string]tagMutation {
+	w.cancel()
+	<-w.done
+	streams := &imagev1.ImageStreamList{}
+	if err := s.client.List(ctx, streams, ctrlruntimeclient.InNamespace(s.jobSpec.Namespace())); err != nil {
+		logrus.WithError(err).Warn("Failed to list imagestreams, changes to shared imagestreams may not be reported")
+	} else {
+		s.observeSharedStreams(w, streams)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return s.attributeToTest(ctx, w.mutations)
+}
+
+// attributeToTest drops the mutations observed while pods of other tests or
+// jobs ran in the namespace, as those may have made them, and records the
+// steps of the test that ran when the others were observed.
+func (s *multiStageTestStep) attributeToTest(ctx context.Context, mutations map[string]tagMutation) map[string]tagMutation {
+	if len(mutations) == 0 {
+		return mutations
+	}
+	pods := &coreapi.PodList{}
+	if err := s.client.List(ctx, pods, ctrlruntimeclient.InNamespace(s.jobSpec.Namespace()), ctrlruntimeclient.HasLabels{base_steps.LabelJobID}); err != nil {
+		logrus.WithError(err).Warn("Failed to list pods, changes to shared imagestreams cannot be attributed to this test")
+		return nil
+	}
+	job := base_steps.LabelsFor(s.jobSpec, nil, "")[base_steps.LabelJobID]
+	ret := map[string]tagMutation{}
+	for name, mutation := range mutations {
+		var other string
+		steps := sets.New[string]()
+		for _, pod := range pods.Items {
+			if !podRanAt(&pod, mutation.at) {
+				continue
+			}
+			if pod.Labels[base_steps.LabelJobID] == job && pod.Labels[MultiStageTestLabel] == s.name {
+				if step := pod.Labels[base_steps.LabelMetadataStep]; step != "" {
+					steps.Insert(step)
+				}
+				continue
+			}
+			other = pod.Name
+			break
+		}
+		if other != "" {
+			logrus.Debugf("Shared imagestream tag %s changed while pod %s of another test ran in the namespace, not attributing the change to this test", name, other)
+			continue
+		}
+		mutation.steps = sets.List(steps)
+		ret[name] = mutation
+	}
+	return ret
+}
+
+// podRanAt determines whether the pod was running at the given time.
+func podRanAt(pod *coreapi.Pod, at time.Time) bool {
+	if pod.CreationTimestamp.After(at) {
+		return false
+	}
+	if pod.Status.Phase != coreapi.PodSucceeded && pod.Status.Phase != coreapi.PodFailed {
+		return true
+	}
+	var finished time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.FinishedAt.After(finished) {
+			finished = terminated.FinishedAt.Time
+		}
+	}
+	return finished.IsZero() || !finished.Before(at)
+}
+
+// reportSharedStreamMutations reports the tags of the shared imagestreams the
+// test modified or removed. The test does not fail because of them, but the
+// tests that run afterwards may, so they are surfaced as a distinct test case.
+func (s *multiStageTestStep) reportSharedStreamMutations(w *sharedStreamWatcher) {
+	mutations := s.stopWatchingSharedStreams(context.Background(), w)
+	if len(mutations) == 0 {
+		return
+	}
+	var lines []string
+	for _, name := range sets.List(sets.KeySet(mutations)) {
+		mutation := mutations[name]
+		var line string
+		if mutation.current == "" {
+			line = fmt.Sprintf("%s was removed, it pointed to %s", name, mutation.previous)
+		} else {
+			line = fmt.Sprintf("%s was changed from %s to %s", name, mutation.previous, mutation.current)
+		}
+		if len(mutation.steps) != 0 {
+			line += fmt.Sprintf(" while step(s) %s ran", strings.Join(mutation.steps, ", "))
+		}
+		lines = append(lines, line)
+	}
+	message := fmt.Sprintf("shared imagestream tags were modified while multi-stage test %s ran, which affects other tests using them:\n%s", s.name, strings.Join(lines, "\n"))
+	logrus.Warn(message)
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+	s.subTests = append(s.subTests, &junit.TestCase{
+		Name:          fmt.Sprintf("Run multi-stage test %s - shared imagestreams are not modified", s.name),
+		FailureOutput: &junit.FailureOutput{Output: message},
+	})
+}
@@ -0,0 +1,256 @@
+package multi_stage
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestReportSharedStreamMutations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	pod := func(job, test, step string, phase coreapi.PodPhase, finished time.Time) *coreapi.Pod {
+		p := &coreapi.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:         "test-ns",
+				Name:              strings.Join([]string{job, test, step}, "-"),
+				Labels:            map[string]string{base_steps.LabelJobID: job, MultiStageTestLabel: test, base_steps.LabelMetadataStep: step},
+				CreationTimestamp: meta.Time{Time: now.Add(-time.Hour)},
+			},
+			Status: coreapi.PodStatus{Phase: phase},
+		}
+		if !finished.IsZero() {
+			p.Status.ContainerStatuses = []coreapi.ContainerStatus{{State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{FinishedAt: meta.Time{Time: finished}}}}}
+		}
+		return p
+	}
+	reported := []*junit.TestCase{{
+		Name: "Run multi-stage test e2e - shared imagestreams are not modified",
+		FailureOutput: &junit.FailureOutput{Output: `shared imagestream tags were modified while multi-stage test e2e ran, which affects other tests using them:
+pipeline:src was changed from sha256:src to sha256:retagged while step(s) e2e-test ran`},
+	}}
+	stream := func(name string, tags map[string]string) *imagev1.ImageStream {
+		is := &imagev1.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: "test-ns", Name: name}}
+		for tag, image := range tags {
+			is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{
+				Tag:   tag,
+				Items: []imagev1.TagEvent{{Image: image}},
+			})
+		}
+		return is
+	}
+	retag := func(client ctrlruntimeclient.Client, name string, tags map[string]string) error {
+		is := &imagev1.ImageStream{}
+		if err := client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: "test-ns", Name: name}, is); err != nil {
+			return err
+		}
+		is.Status.Tags = stream(name, tags).Status.Tags
+		return client.Update(context.TODO(), is)
+	}
+	for _, tc := range []struct {
+		name     string
+		pods     []ctrlruntimeclient.Object
+		mutate   func(ctrlruntimeclient.Client) error
+		expected []*junit.TestCase
+	}{
+		{
+			name:   "nothing is modified",
+			mutate: func(ctrlruntimeclient.Client) error { return nil },
+		},
+		{
+			name: "outputs of other steps and isolated copies are not reported",
+			mutate: func(client ctrlruntimeclient.Client) error {
+				if err := retag(client, "pipeline", map[string]string{"src": "sha256:src", "bin": "sha256:bin"}); err != nil {
+					return err
+				}
+				if err := retag(client, "stable-e2e", map[string]string{"cli": "sha256:retagged"}); err != nil {
+					return err
+				}
+				return retag(client, "unrelated", nil)
+			},
+		},
+		{
+			name: "modified and removed tags are reported",
+			mutate: func(client ctrlruntimeclient.Client) error {
+				if err := retag(client, "pipeline", map[string]string{"src": "sha256:retagged"}); err != nil {
+					return err
+				}
+				return client.Delete(context.TODO(), stream("stable", nil))
+			},
+			expected: []*junit.TestCase{{
+				Name: "Run multi-stage test e2e - shared imagestreams are not modified",
+				FailureOutput: &junit.FailureOutput{Output: `shared imagestream tags were modified while multi-stage test e2e ran, which affects other tests using them:
+pipeline:src was changed from sha256:src to sha256:retagged
+stable:cli was removed, it pointed to sha256:cli`},
+			}},
+		},
+		{
+			name: "modifications are attributed to the test when its pods ran alone",
+			pods: []ctrlruntimeclient.Object{
+				pod("self", "e2e", "e2e-test", coreapi.PodRunning, time.Time{}),
+				pod("self", "other", "other-test", coreapi.PodSucceeded, now.Add(-time.Minute)),
+				pod("other", "e2e", "e2e-test", coreapi.PodSucceeded, now.Add(-time.Minute)),
+			},
+			mutate: func(client ctrlruntimeclient.Client) error {
+				return retag(client, "pipeline", map[string]string{"src": "sha256:retagged"})
+			},
+			expected: reported,
+		},
+		{
+			name: "modifications are not attributed to the job when another job ran",
+			pods: []ctrlruntimeclient.Object{pod("self", "e2e", "e2e-test", coreapi.PodRunning, time.Time{}), pod("other", "e2e", "e2e-test", coreapi.PodRunning, time.Time{})},
+			mutate: func(client ctrlruntimeclient.Client) error {
+				return retag(client, "pipeline", map[string]string{"src": "sha256:retagged"})
+			},
+		},
+		{
+			name: "modifications are not attributed to the test when another test of the job ran",
+			pods: []ctrlruntimeclient.Object{pod("self", "e2e", "e2e-test", coreapi.PodRunning, time.Time{}), pod("self", "other", "other-test", coreapi.PodRunning, time.Time{})},
+			mutate: func(client ctrlruntimeclient.Client) error {
+				return retag(client, "pipeline", map[string]string{"src": "sha256:retagged"})
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := loggingclient.New(
+				fakectrlruntimeclient.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(
+						stream("pipeline", map[string]string{"src": "sha256:src"}),
+						stream("stable", map[string]string{"cli": "sha256:cli"}),
+						stream("stable-e2e", map[string]string{"cli": "sha256:cli"}),
+						stream("unrelated", map[string]string{"tag": "sha256:tag"}),
+					).
+					WithObjects(tc.pods...).
+					Build())
+			step := &multiStageTestStep{
+				name: "e2e",
+				config: &api.ReleaseBuildConfiguration{
+					Tests: []api.TestStepConfiguration{{As: "e2e", IsolateStableStreams: true}},
+				},
+				jobSpec: &api.JobSpec{},
+				client:  &testhelper_kube.FakePodClient{FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: client}},
+				subLock: &sync.Mutex{},
+			}
+			step.jobSpec.SetNamespace("test-ns")
+			step.jobSpec.ProwJobID = "self"
+			watcher, err := step.watchSharedStreams(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.mutate(client); err != nil {
+				t.Fatal(err)
+			}
+			step.reportSharedStreamMutations(watcher)
+			testhelper.Diff(t, "test cases", step.subTests, tc.expected)
+		})
+	}
+}
+
+// resumingClient serves the watches of imagestreams from fake watchers, and
+// lists them at a resource version as the server does.
+type resumingClient struct {
+	loggingclient.LoggingClient
+	watches chan *watch.FakeWatcher
+}
+
+func (c *resumingClient) List(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) error {
+	if err := c.LoggingClient.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	list.SetResourceVersion("1")
+	return nil
+}
+
+func (c *resumingClient) Watch(context.Context, ctrlruntimeclient.ObjectList, ...ctrlruntimeclient.ListOption) (watch.Interface, error) {
+	w := watch.NewFake()
+	c.watches <- w
+	return w, nil
+}
+
+func TestWatchSharedStreamsResumes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	stream := func(name, image string) *imagev1.ImageStream {
+		return &imagev1.ImageStream{
+			ObjectMeta: meta.ObjectMeta{Namespace: "test-ns", Name: name},
+			Status:     imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{Tag: "tag", Items: []imagev1.TagEvent{{Image: image}}}}},
+		}
+	}
+	client := &resumingClient{
+		LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(stream("pipeline", "sha256:old"), stream("stable", "sha256:old")).Build()),
+		watches:       make(chan *watch.FakeWatcher, 2),
+	}
+	step := &multiStageTestStep{
+		name:    "e2e",
+		config:  &api.ReleaseBuildConfiguration{},
+		jobSpec: &api.JobSpec{},
+		client:  &testhelper_kube.FakePodClient{FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: client}},
+	}
+	step.jobSpec.SetNamespace("test-ns")
+	w, err := step.watchSharedStreams(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		w.cancel()
+		<-w.done
+	}()
+	observed := func(name string) bool {
+		if err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+			w.lock.Lock()
+			defer w.lock.Unlock()
+			_, ok := w.mutations[name]
+			return ok, nil
+		}); err != nil {
+			t.Errorf("change to %s was not observed while watching", name)
+			return false
+		}
+		return true
+	}
+
+	first := <-client.watches
+	// the change is missed by the watch, which then expires
+	updated := stream("pipeline", "sha256:new")
+	updated.ResourceVersion = "999"
+	if err := client.LoggingClient.Update(context.TODO(), updated); err != nil {
+		t.Fatal(err)
+	}
+	first.Error(&meta.Status{Status: meta.StatusFailure, Code: http.StatusGone, Reason: meta.StatusReasonGone})
+	if !observed("pipeline:tag") {
+		return
+	}
+	second := <-client.watches
+	modified := stream("stable", "sha256:new")
+	modified.ResourceVersion = "1000"
+	second.Modify(modified)
+	observed("stable:tag")
+}
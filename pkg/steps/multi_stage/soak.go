@@ -0,0 +1,108 @@
+package multi_stage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/secretutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// soakSteps returns a copy of the test steps that are allowed to run for the
+// whole duration of the soak test.
+func soakSteps(steps []api.LiteralTestStep, soak *api.SoakConfiguration) []api.LiteralTestStep {
+	ret := make([]api.LiteralTestStep, len(steps))
+	copy(ret, steps)
+	for i := range ret {
+		if ret[i].Timeout == nil || ret[i].Timeout.Duration < soak.Duration() {
+			ret[i].Timeout = &prowapi.Duration{Duration: soak.Duration()}
+		}
+	}
+	return ret
+}
+
+// withSoakCheckpoints executes the function while periodically reporting
+// the progress of a soak test in JUnit checkpoints.
+func (s *multiStageTestStep) withSoakCheckpoints(ctx context.Context, run func() error) error {
+	if s.soak == nil {
+		return run()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		start := time.Now()
+		ticker := time.NewTicker(s.soak.Interval())
+		defer ticker.Stop()
+		for n := 1; ; n++ {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.saveSoakCheckpoint(n, now.Sub(start))
+			}
+		}
+	}()
+	err := run()
+	cancel()
+	<-done
+	return err
+}
+
+func soakCheckpointPath(name string, n int) string {
+	return fmt.Sprintf("junit_soak_%s_checkpoint_%04d.xml", name, n)
+}
+
+// saveSoakCheckpoint writes the nth checkpoint and removes those that are
+// older than the configured number of checkpoints to keep.
+func (s *multiStageTestStep) saveSoakCheckpoint(n int, elapsed time.Duration) {
+	data, err := s.soakCheckpoint(n, elapsed)
+	if err != nil {
+		logrus.WithError(err).Warnf("Failed to marshal checkpoint %d of soak test %s.", n, s.name)
+		return
+	}
+	logrus.Infof("Soak test %s still running after %s.", s.name, elapsed.Truncate(time.Second))
+	if err := api.SaveArtifact(secretutil.NewCensorer(), soakCheckpointPath(s.name, n), data); err != nil {
+		return
+	}
+	if old := n - s.soak.Checkpoints(); old > 0 {
+		if dir, ok := api.Artifacts(); ok {
+			if err := os.Remove(filepath.Join(dir, soakCheckpointPath(s.name, old))); err != nil && !os.IsNotExist(err) {
+				logrus.WithError(err).Warnf("Failed to remove checkpoint %d of soak test %s.", old, s.name)
+			}
+		}
+	}
+}
+
+func (s *multiStageTestStep) soakCheckpoint(n int, elapsed time.Duration) ([]byte, error) {
+	s.subLock.Lock()
+	testCases := make([]*junit.TestCase, len(s.subTests))
+	copy(testCases, s.subTests)
+	s.subLock.Unlock()
+	testCases = append(testCases, &junit.TestCase{
+		Name:      fmt.Sprintf("Soak test %s is running", s.name),
+		Duration:  elapsed.Seconds(),
+		SystemOut: fmt.Sprintf("Checkpoint %d: running for %s of at most %s.", n, elapsed.Truncate(time.Second), s.soak.Duration()),
+	})
+	suite := &junit.TestSuite{
+		Name:      fmt.Sprintf("soak test %s checkpoint %d", s.name, n),
+		NumTests:  uint(len(testCases)),
+		Duration:  elapsed.Seconds(),
+		TestCases: testCases,
+	}
+	for _, testCase := range testCases {
+		if testCase.FailureOutput != nil {
+			suite.NumFailed++
+		}
+	}
+	return xml.MarshalIndent(&junit.TestSuites{Suites: []*junit.TestSuite{suite}}, "", "    ")
+}
@@ -0,0 +1,76 @@
+package multi_stage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+func TestSoakSteps(t *testing.T) {
+	steps := []api.LiteralTestStep{
+		{As: "short"},
+		{As: "long", Timeout: &prowapi.Duration{Duration: 30 * 24 * time.Hour}},
+	}
+	soak := &api.SoakConfiguration{MaxDurationDays: 2}
+	expected := []api.LiteralTestStep{
+		{As: "short", Timeout: &prowapi.Duration{Duration: 48 * time.Hour}},
+		{As: "long", Timeout: &prowapi.Duration{Duration: 30 * 24 * time.Hour}},
+	}
+	if diff := cmp.Diff(expected, soakSteps(steps, soak)); diff != "" {
+		t.Errorf("unexpected steps: %s", diff)
+	}
+	if steps[0].Timeout != nil {
+		t.Errorf("original steps were modified")
+	}
+}
+
+func TestSaveSoakCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARTIFACTS", dir)
+	s := &multiStageTestStep{
+		name:     "soak",
+		subLock:  &sync.Mutex{},
+		soak:     &api.SoakConfiguration{MaxDurationDays: 1, MaxCheckpoints: 2},
+		subTests: []*junit.TestCase{{Name: "Run multi-stage test pre phase"}},
+	}
+	for n := 1; n <= 4; n++ {
+		s.saveSoakCheckpoint(n, time.Duration(n)*time.Hour)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	expected := []string{"junit_soak_soak_checkpoint_0003.xml", "junit_soak_soak_checkpoint_0004.xml"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Fatalf("unexpected checkpoints: %s", diff)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, expected[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, substr := range []string{
+		`name="Run multi-stage test pre phase"`,
+		`name="Soak test soak is running"`,
+		"Checkpoint 4: running for 4h0m0s of at most 24h0m0s.",
+	} {
+		if !strings.Contains(string(data), substr) {
+			t.Errorf("checkpoint does not contain %q:\n%s", substr, data)
+		}
+	}
+}
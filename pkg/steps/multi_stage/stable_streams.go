@@ -0,0 +1,123 @@
+package multi_stage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// stableStreams returns the stable streams the images and dependencies of
+// the steps of the test come from.
+func (s *multiStageTestStep) stableStreams() sets.Set[string] {
+	var claimRelease *api.ClaimRelease
+	if s.clusterClaim != nil {
+		claimRelease = s.clusterClaim.ClaimRelease(s.name)
+	}
+	ret := sets.New[string]()
+	add := func(name string) {
+		stream, _, _ := s.config.DependencyParts(api.StepDependency{Name: name}, claimRelease)
+		if api.IsReleaseStream(stream) {
+			ret.Insert(stream)
+		}
+	}
+	steps := s.steps()
+	for _, observer := range s.observers {
+		steps = append(steps, api.LiteralTestStep{From: observer.From, FromImage: observer.FromImage})
+	}
+	for _, step := range steps {
+		if _, ok := step.FromImageTag(); !ok && step.From != "" {
+			add(step.From)
+		}
+		for _, dependency := range step.Dependencies {
+			if dependency.PullSpec == "" {
+				add(dependency.Name)
+			}
+		}
+	}
+	return ret
+}
+
+// streamFor returns the stream the steps of the test use in place of a stream,
+// which is its isolated copy if the test has one.
+func (s *multiStageTestStep) streamFor(stream string) string {
+	if isolated, ok := s.isolatedStreams[stream]; ok {
+		return isolated
+	}
+	return stream
+}
+
+// copyStableStreams copies the stable streams used by the test into streams
+// of its own, so that the steps can retag images without affecting other
+// tests in the namespace.
+func (s *multiStageTestStep) copyStableStreams(ctx context.Context) error {
+	namespace := s.jobSpec.Namespace()
+	s.isolatedStreams = map[string]string{}
+	for _, stream := range sets.List(s.stableStreams()) {
+		source := &imagev1.ImageStream{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: stream}, source); err != nil {
+			return fmt.Errorf("could not get imagestream %s: %w", stream, err)
+		}
+		name := api.IsolatedStableStreamFor(stream, s.name)
+		logrus.Infof("Copying imagestream %s into %s for test %s", stream, name, s.name)
+		// a copy left over by a previous run holds tags retagged by that run
+		if err := s.deleteStream(ctx, name); err != nil {
+			return err
+		}
+		if err := s.client.Create(ctx, &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       imagev1.ImageStreamSpec{LookupPolicy: source.Spec.LookupPolicy},
+		}); err != nil {
+			return fmt.Errorf("could not create imagestream %s: %w", name, err)
+		}
+		for _, tag := range source.Status.Tags {
+			if len(tag.Items) == 0 || tag.Items[0].Image == "" {
+				continue
+			}
+			if err := s.client.Create(ctx, &imagev1.ImageStreamTag{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: fmt.Sprintf("%s:%s", name, tag.Tag)},
+				Tag: &imagev1.TagReference{
+					Name: tag.Tag,
+					From: &coreapi.ObjectReference{
+						Kind:      "ImageStreamImage",
+						Namespace: namespace,
+						Name:      fmt.Sprintf("%s@%s", stream, tag.Items[0].Image),
+					},
+					ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+				},
+			}); err != nil {
+				return fmt.Errorf("could not copy tag %s into imagestream %s: %w", tag.Tag, name, err)
+			}
+		}
+		s.isolatedStreams[stream] = name
+	}
+	return nil
+}
+
+// deleteStableStreamCopies garbage-collects the copies of the stable streams
+// once the test is done with them.
+func (s *multiStageTestStep) deleteStableStreamCopies() {
+	for _, name := range s.isolatedStreams {
+		if err := s.deleteStream(context.Background(), name); err != nil {
+			logrus.WithError(err).Warnf("Failed to delete the isolated copy %s of a stable stream", name)
+		}
+	}
+}
+
+func (s *multiStageTestStep) deleteStream(ctx context.Context, name string) error {
+	stream := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: name}}
+	if err := s.client.Delete(ctx, stream); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete imagestream %s: %w", name, err)
+	}
+	return nil
+}
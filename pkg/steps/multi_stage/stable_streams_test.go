@@ -0,0 +1,140 @@
+package multi_stage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestStableStreams(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "built"}},
+	}
+	step := &multiStageTestStep{
+		config: config,
+		pre:    []api.LiteralTestStep{{From: "cli"}, {From: "built"}},
+		test: []api.LiteralTestStep{{
+			From: "src",
+			Dependencies: []api.StepDependency{
+				{Name: "stable-initial:installer"},
+				{Name: "release:latest"},
+				{Name: "stable-other:tests", PullSpec: "quay.io/org/tests:latest"},
+			},
+		}},
+		observers: []api.Observer{{From: "stable-observer:tools"}},
+	}
+	testhelper.Diff(t, "streams", sets.List(step.stableStreams()), []string{"stable", "stable-initial", "stable-observer"})
+}
+
+func TestCopyStableStreams(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	stream := func(name string, tags ...string) *imagev1.ImageStream {
+		is := &imagev1.ImageStream{
+			ObjectMeta: meta.ObjectMeta{Namespace: "test-ns", Name: name},
+			Spec:       imagev1.ImageStreamSpec{LookupPolicy: imagev1.ImageLookupPolicy{Local: true}},
+		}
+		for _, tag := range tags {
+			is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{
+				Tag:   tag,
+				Items: []imagev1.TagEvent{{Image: "sha256:" + tag}},
+			})
+		}
+		return is
+	}
+	crclient := &testhelper_kube.FakePodExecutor{
+		LoggingClient: loggingclient.New(
+			fakectrlruntimeclient.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(
+					stream("stable", "cli", "tests"),
+					stream("stable-initial", "installer"),
+					// left over by a previous run
+					stream("stable-e2e", "retagged"),
+				).
+				Build()),
+	}
+	step := &multiStageTestStep{
+		name:   "e2e",
+		config: &api.ReleaseBuildConfiguration{},
+		test:   []api.LiteralTestStep{{From: "cli", Dependencies: []api.StepDependency{{Name: "stable-initial:installer"}}}},
+		jobSpec: &api.JobSpec{JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build_id",
+			ProwJobID: "prow_job_id",
+			Type:      prowapi.PeriodicJob,
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:       &prowapi.Duration{Duration: time.Minute},
+				GracePeriod:   &prowapi.Duration{Duration: time.Second},
+				UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar", Entrypoint: "entrypoint"},
+			},
+		}},
+		client: &testhelper_kube.FakePodClient{FakePodExecutor: crclient},
+	}
+	step.jobSpec.SetNamespace("test-ns")
+	if err := step.copyStableStreams(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.Diff(t, "isolated streams", step.isolatedStreams, map[string]string{
+		"stable":         "stable-e2e",
+		"stable-initial": "stable-initial-e2e",
+	})
+	testhelper.Diff(t, "stream for stable", step.streamFor("stable"), "stable-e2e")
+	testhelper.Diff(t, "stream for pipeline", step.streamFor(api.PipelineImageStream), api.PipelineImageStream)
+
+	tags := &imagev1.ImageStreamTagList{}
+	if err := crclient.List(context.TODO(), tags, ctrlruntimeclient.InNamespace("test-ns")); err != nil {
+		t.Fatal(err)
+	}
+	copied := map[string]string{}
+	for _, tag := range tags.Items {
+		copied[tag.Name] = tag.Tag.From.Name
+	}
+	testhelper.Diff(t, "copied tags", copied, map[string]string{
+		"stable-e2e:cli":               "stable@sha256:cli",
+		"stable-e2e:tests":             "stable@sha256:tests",
+		"stable-initial-e2e:installer": "stable-initial@sha256:installer",
+	})
+	copy := &imagev1.ImageStream{}
+	if err := crclient.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: "test-ns", Name: "stable-e2e"}, copy); err != nil {
+		t.Fatal(err)
+	}
+	if len(copy.Status.Tags) != 0 {
+		t.Errorf("the copy left over by a previous run was not replaced: %v", copy.Status.Tags)
+	}
+	testhelper.Diff(t, "lookup policy", copy.Spec.LookupPolicy, imagev1.ImageLookupPolicy{Local: true})
+
+	pods, _, err := step.generatePods([]api.LiteralTestStep{{As: "step", From: "cli"}}, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelper.Diff(t, "image", pods[0].Spec.Containers[0].Image, "stable-e2e:cli")
+
+	step.deleteStableStreamCopies()
+	streams := &imagev1.ImageStreamList{}
+	if err := crclient.List(context.TODO(), streams, ctrlruntimeclient.InNamespace("test-ns")); err != nil {
+		t.Fatal(err)
+	}
+	names := sets.New[string]()
+	for _, s := range streams.Items {
+		names.Insert(s.Name)
+	}
+	testhelper.Diff(t, "streams", sets.List(names), []string{"stable", "stable-initial"})
+}
@@ -0,0 +1,111 @@
+package multi_stage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// usageSamplingInterval is how often the usage of the pod of a step is
+// sampled. The metrics API only exposes the current usage, so the peak is
+// the highest of the samples.
+var usageSamplingInterval = 30 * time.Second
+
+var podMetricsGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetrics"}
+
+// usagePropertyPrefix prefixes the names of the jUnit properties that hold
+// the peak usage of a step.
+const usagePropertyPrefix = "resource-usage/"
+
+// podUsage returns the current usage of a pod, summed over its containers.
+func podUsage(ctx context.Context, client ctrlruntimeclient.Reader, namespace, name string) (api.StepResourceUsage, error) {
+	metrics := &unstructured.Unstructured{}
+	metrics.SetGroupVersionKind(podMetricsGVK)
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, metrics); err != nil {
+		return api.StepResourceUsage{}, err
+	}
+	containers, _, err := unstructured.NestedSlice(metrics.Object, "containers")
+	if err != nil {
+		return api.StepResourceUsage{}, fmt.Errorf("invalid metrics for pod %s: %w", name, err)
+	}
+	var cpu, memory resource.Quantity
+	for _, container := range containers {
+		fields, ok := container.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		usage, _, err := unstructured.NestedStringMap(fields, "usage")
+		if err != nil {
+			return api.StepResourceUsage{}, fmt.Errorf("invalid metrics for pod %s: %w", name, err)
+		}
+		for resourceName, total := range map[string]*resource.Quantity{"cpu": &cpu, "memory": &memory} {
+			value, ok := usage[resourceName]
+			if !ok {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return api.StepResourceUsage{}, fmt.Errorf("invalid %s usage %q for pod %s: %w", resourceName, value, name, err)
+			}
+			total.Add(quantity)
+		}
+	}
+	return api.StepResourceUsage{CPUMillicores: cpu.MilliValue(), MemoryBytes: memory.Value()}, nil
+}
+
+// samplePeakUsage samples the usage of a pod until the context is cancelled,
+// then sends the peak to the channel, or nil if no sample could be taken.
+func samplePeakUsage(ctx context.Context, client ctrlruntimeclient.Reader, namespace, name string, interval time.Duration, peak chan<- *api.StepResourceUsage) {
+	var highest *api.StepResourceUsage
+	defer func() { peak <- highest }()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		usage, err := podUsage(ctx, client, namespace, name)
+		switch {
+		case err == nil:
+			if highest == nil {
+				highest = &api.StepResourceUsage{}
+			}
+			highest.CPUMillicores = max(highest.CPUMillicores, usage.CPUMillicores)
+			highest.MemoryBytes = max(highest.MemoryBytes, usage.MemoryBytes)
+		case meta.IsNoMatchError(err):
+			logrus.Debugf("The metrics API is not available, not sampling the resource usage of pod %s.", name)
+			return
+		case kerrors.IsNotFound(err) || ctx.Err() != nil:
+			// the pod was not running yet or has already stopped
+		default:
+			logrus.WithError(err).Debugf("Failed to sample the resource usage of pod %s.", name)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// usageProperties serializes the peak usage of a step as jUnit properties of
+// the test cases of the step.
+func usageProperties(usage *api.StepResourceUsage) []*junit.TestSuiteProperty {
+	if usage == nil {
+		return nil
+	}
+	return []*junit.TestSuiteProperty{
+		{Name: usagePropertyPrefix + "cpu-millicores", Value: strconv.FormatInt(usage.CPUMillicores, 10)},
+		{Name: usagePropertyPrefix + "memory-bytes", Value: strconv.FormatInt(usage.MemoryBytes, 10)},
+	}
+}
@@ -0,0 +1,116 @@
+package multi_stage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// fakeMetricsReader serves one sample of the metrics API per call to Get and
+// cancels the sampling once it runs out of them.
+type fakeMetricsReader struct {
+	ctrlruntimeclient.Reader
+	samples []interface{}
+	cancel  context.CancelFunc
+}
+
+func (r *fakeMetricsReader) Get(_ context.Context, _ ctrlruntimeclient.ObjectKey, obj ctrlruntimeclient.Object, _ ...ctrlruntimeclient.GetOption) error {
+	if len(r.samples) == 0 {
+		r.cancel()
+		return context.Canceled
+	}
+	sample := r.samples[0]
+	r.samples = r.samples[1:]
+	if err, ok := sample.(error); ok {
+		return err
+	}
+	obj.(*unstructured.Unstructured).Object = sample.(map[string]interface{})
+	return nil
+}
+
+func podMetrics(usages ...map[string]interface{}) map[string]interface{} {
+	var containers []interface{}
+	for _, usage := range usages {
+		containers = append(containers, map[string]interface{}{"usage": usage})
+	}
+	return map[string]interface{}{"containers": containers}
+}
+
+func TestSamplePeakUsage(t *testing.T) {
+	notFound := kerrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}, "pod")
+	for _, tc := range []struct {
+		name     string
+		samples  []interface{}
+		expected *api.StepResourceUsage
+	}{
+		{
+			name: "peak over samples and containers",
+			samples: []interface{}{
+				notFound,
+				podMetrics(map[string]interface{}{"cpu": "250m", "memory": "100Mi"}, map[string]interface{}{"cpu": "10m", "memory": "20Mi"}),
+				podMetrics(map[string]interface{}{"cpu": "1500m", "memory": "50Mi"}, map[string]interface{}{"cpu": "20m", "memory": "10Mi"}),
+				errors.New("transient"),
+			},
+			expected: &api.StepResourceUsage{CPUMillicores: 1520, MemoryBytes: 120 << 20},
+		},
+		{
+			name:    "no samples",
+			samples: []interface{}{notFound, notFound},
+		},
+		{
+			name: "metrics API not available",
+			samples: []interface{}{
+				&meta.NoKindMatchError{GroupKind: podMetricsGVK.GroupKind(), SearchedVersions: []string{podMetricsGVK.Version}},
+				podMetrics(map[string]interface{}{"cpu": "1", "memory": "1Gi"}),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			peak := make(chan *api.StepResourceUsage, 1)
+			samplePeakUsage(ctx, &fakeMetricsReader{samples: tc.samples, cancel: cancel}, "ns", "pod", time.Millisecond, peak)
+			if diff := cmp.Diff(tc.expected, <-peak); diff != "" {
+				t.Errorf("unexpected peak usage: %s", diff)
+			}
+		})
+	}
+}
+
+func TestUsageProperties(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		usage    *api.StepResourceUsage
+		expected []*junit.TestSuiteProperty
+	}{
+		{
+			name: "usage was not sampled",
+		},
+		{
+			name:  "peak usage",
+			usage: &api.StepResourceUsage{CPUMillicores: 1500, MemoryBytes: 1024},
+			expected: []*junit.TestSuiteProperty{
+				{Name: "resource-usage/cpu-millicores", Value: "1500"},
+				{Name: "resource-usage/memory-bytes", Value: "1024"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, usageProperties(tc.usage)); diff != "" {
+				t.Errorf("unexpected properties: %s", diff)
+			}
+		})
+	}
+}
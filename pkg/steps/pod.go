@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
 
 	"github.com/sirupsen/logrus"
@@ -49,12 +50,18 @@ type PodStepConfiguration struct {
 	From               api.ImageStreamTagReference
 	Commands           string
 	Labels             map[string]string
+	Annotations        map[string]string
+	NodeSelector       map[string]string
+	Tolerations        []api.Toleration
+	Spread             api.Spread
 	NodeName           string
 	ServiceAccountName string
 	Secrets            []*api.Secret
 	MemoryBackedVolume *api.MemoryBackedVolume
 	Clone              bool
 	NodeArchitecture   api.NodeArchitecture
+	Services           []api.TestService
+	FailureLogLimit    int64
 }
 
 type GeneratePodOptions struct {
@@ -103,7 +110,7 @@ func (s *podStep) run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("pod step was invalid: %w", err)
 	}
-	testCaseNotifier := NewTestCaseNotifier(util.NopNotifier)
+	testCaseNotifier := NewTestCaseNotifier(util.NopNotifier, WithFailureLogs(s.client, s.config.FailureLogLimit))
 
 	if owner := s.jobSpec.Owner(); owner != nil {
 		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
@@ -138,9 +145,12 @@ func (s *podStep) SubTests() []*junit.TestCase {
 func (s *podStep) Requires() (ret []api.StepLink) {
 	if s.config.From.Name == api.PipelineImageStream {
 		ret = append(ret, api.InternalImageLink(api.PipelineImageStreamTagReference(s.config.From.Tag)))
-		return
+	} else {
+		ret = append(ret, api.ImagesReadyLink())
+	}
+	for _, service := range s.config.Services {
+		ret = append(ret, api.InternalImageLink(service.From))
 	}
-	ret = append(ret, api.ImagesReadyLink())
 	return
 }
 
@@ -172,18 +182,25 @@ func (s *podStep) ResolveMultiArch() sets.Set[string] {
 
 func (s *podStep) AddArchitectures(archs []string) {}
 
-func TestStep(config api.TestStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, nodeName string) api.Step {
+func TestStep(config api.TestStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, nodeName string, failureLogLimit int64) api.Step {
 	return PodStep(
 		"test",
 		PodStepConfiguration{
 			As:                 config.As,
 			From:               api.ImageStreamTagReference{Name: api.PipelineImageStream, Tag: string(config.ContainerTestConfiguration.From)},
 			Commands:           config.Commands,
+			Labels:             maps.Clone(config.Labels),
+			Annotations:        config.Annotations,
+			NodeSelector:       config.NodeSelector,
+			Tolerations:        config.Tolerations,
+			Spread:             config.Spread,
 			NodeName:           nodeName,
 			Secrets:            config.Secrets,
 			MemoryBackedVolume: config.ContainerTestConfiguration.MemoryBackedVolume,
 			Clone:              *config.ContainerTestConfiguration.Clone,
 			NodeArchitecture:   config.NodeArchitecture,
+			Services:           config.ContainerTestConfiguration.Services,
+			FailureLogLimit:    failureLogLimit,
 		},
 		resources,
 		client,
@@ -267,6 +284,59 @@ func GenerateBasePod(
 	return pod, nil
 }
 
+// AddScheduling adds the node selector and tolerations configured for a test
+// or a step to its pod.
+func AddScheduling(pod *coreapi.Pod, nodeSelector map[string]string, tolerations []api.Toleration) {
+	if len(nodeSelector) > 0 && pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = map[string]string{}
+	}
+	for key, value := range nodeSelector {
+		pod.Spec.NodeSelector[key] = value
+	}
+	for _, t := range tolerations {
+		toleration := coreapi.Toleration{Key: t.Key, Operator: coreapi.TolerationOpExists, Effect: coreapi.TaintEffect(t.Effect)}
+		if t.Value != "" {
+			toleration.Operator = coreapi.TolerationOpEqual
+			toleration.Value = t.Value
+		}
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+	}
+}
+
+// AddSpread adds a preferred anti-affinity to the pod against pods in any
+// namespace that share its values for the given labels, so that concurrently
+// running instances of the same test are spread across nodes or zones.
+func AddSpread(pod *coreapi.Pod, spread api.Spread, labels ...string) {
+	var topologyKey string
+	switch spread {
+	case api.SpreadNode:
+		topologyKey = coreapi.LabelHostname
+	case api.SpreadZone:
+		topologyKey = coreapi.LabelTopologyZone
+	default:
+		return
+	}
+	selector := map[string]string{}
+	for _, label := range labels {
+		selector[label] = pod.Labels[label]
+	}
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &coreapi.Affinity{}
+	}
+	if pod.Spec.Affinity.PodAntiAffinity == nil {
+		pod.Spec.Affinity.PodAntiAffinity = &coreapi.PodAntiAffinity{}
+	}
+	antiAffinity := pod.Spec.Affinity.PodAntiAffinity
+	antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, coreapi.WeightedPodAffinityTerm{
+		Weight: 100,
+		PodAffinityTerm: coreapi.PodAffinityTerm{
+			LabelSelector:     &meta.LabelSelector{MatchLabels: selector},
+			NamespaceSelector: &meta.LabelSelector{},
+			TopologyKey:       topologyKey,
+		},
+	})
+}
+
 func (s *podStep) generatePodForStep(image string, containerResources coreapi.ResourceRequirements, clone bool) (*coreapi.Pod, error) {
 	var secretVolumes []coreapi.Volume
 	var secretVolumeMounts []coreapi.VolumeMount
@@ -315,6 +385,11 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 	if err != nil {
 		return nil, err
 	}
+	for key, value := range s.config.Annotations {
+		pod.Annotations[key] = value
+	}
+	AddScheduling(pod, s.config.NodeSelector, s.config.Tolerations)
+	AddSpread(pod, s.config.Spread, LabelMetadataOrg, LabelMetadataRepo, LabelMetadataBranch, LabelMetadataVariant, LabelMetadataTarget)
 	pod.Spec.ServiceAccountName = s.config.ServiceAccountName
 	container := &pod.Spec.Containers[0]
 	container.VolumeMounts = append(container.VolumeMounts, secretVolumeMounts...)
@@ -346,6 +421,9 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 			},
 		})
 	}
+	if err := addServices(pod, s.config.As, s.config.Services, s.resources); err != nil {
+		return nil, err
+	}
 
 	return pod, nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilpointer "k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -103,6 +104,7 @@ func TestPodStepExecution(t *testing.T) {
 		purpose        string
 		podStatus      corev1.PodPhase
 		clone          bool
+		services       []api.TestService
 		expectRunError bool
 	}{
 		{
@@ -120,12 +122,33 @@ func TestPodStepExecution(t *testing.T) {
 			clone:          true,
 			expectRunError: false,
 		},
+		{
+			purpose:   "Successful pod with services",
+			podStatus: corev1.PodSucceeded,
+			services: []api.TestService{
+				{
+					As:             "postgres",
+					From:           "postgres",
+					Env:            map[string]string{"POSTGRES_USER": "test", "POSTGRES_PASSWORD": "test"},
+					Ports:          []int32{5432},
+					ReadinessProbe: &api.TestServiceReadinessProbe{TCPPort: 5432, Timeout: &prowapi.Duration{Duration: time.Minute}},
+				},
+				{
+					As:             "registry",
+					From:           "registry",
+					Commands:       "registry serve /etc/registry.yaml",
+					ReadinessProbe: &api.TestServiceReadinessProbe{HTTPGet: &api.TestServiceHTTPGet{Port: 5000}},
+				},
+			},
+			expectRunError: false,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.purpose, func(t *testing.T) {
 			ps, _ := preparePodStep(namespace)
 			ps.config.Clone = tc.clone
+			ps.config.Services = tc.services
 			ps.client = kubernetes.NewPodClient(loggingclient.New(&podStatusChangingClient{WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(), dest: tc.podStatus}), nil, nil, 0)
 
 			executionExpectation := executionExpectation{
@@ -311,10 +334,22 @@ func TestTestStepAndRequires(t *testing.T) {
 			},
 			expected: []api.StepLink{api.InternalImageLink("cli")},
 		},
+		{
+			name: "step with services",
+			config: api.TestStepConfiguration{
+				As: "some",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{
+					From:     "cli",
+					Clone:    utilpointer.Bool(false),
+					Services: []api.TestService{{As: "db", From: "postgres"}},
+				},
+			},
+			expected: []api.StepLink{api.InternalImageLink("cli"), api.InternalImageLink("postgres")},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := TestStep(tc.config, nil, nil, nil, "").Requires()
+			actual := TestStep(tc.config, nil, nil, nil, "", 0).Requires()
 			if len(actual) == len(tc.expected) {
 				matches := true
 				for i := range actual {
@@ -331,3 +366,46 @@ func TestTestStepAndRequires(t *testing.T) {
 		})
 	}
 }
+
+func TestAddSpread(t *testing.T) {
+	labels := map[string]string{LabelMetadataOrg: "org", LabelMetadataTarget: "e2e", LabelJobID: "id"}
+	term := func(topologyKey string) *corev1.Affinity {
+		return &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{LabelMetadataOrg: "org", LabelMetadataTarget: "e2e"}},
+					NamespaceSelector: &metav1.LabelSelector{},
+					TopologyKey:       topologyKey,
+				},
+			}},
+		}}
+	}
+	for _, tc := range []struct {
+		name     string
+		spread   api.Spread
+		expected *corev1.Affinity
+	}{
+		{
+			name: "no spread",
+		},
+		{
+			name:     "spread across nodes",
+			spread:   api.SpreadNode,
+			expected: term("kubernetes.io/hostname"),
+		},
+		{
+			name:     "spread across zones",
+			spread:   api.SpreadZone,
+			expected: term("topology.kubernetes.io/zone"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+			AddSpread(pod, tc.spread, LabelMetadataOrg, LabelMetadataTarget)
+			if diff := cmp.Diff(tc.expected, pod.Spec.Affinity); diff != "" {
+				t.Errorf("unexpected affinity: %s", diff)
+			}
+		})
+	}
+}
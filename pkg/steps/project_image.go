@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"strings"
+	"time"
 
 	coreapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -43,7 +45,7 @@ func (s *projectDirectoryImageBuildStep) Run(ctx context.Context) error {
 }
 
 func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
-	sourceTag, images, err := imagesFor(s.config, func(tag string) (string, error) {
+	sourceTag, images, filter, err := imagesFor(s.config, func(tag string) (string, error) {
 		return getWorkingDir(s.client, tag, s.jobSpec.Namespace())
 	}, s.releaseBuildConfig.IsBundleImage)
 	if err != nil {
@@ -53,6 +55,32 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if filter != nil {
+		source, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, string(sourceTag))()
+		if err != nil {
+			return fmt.Errorf("failed to resolve the source of the build context: %w", err)
+		}
+		dockerfile := filter.dockerfile(source)
+		// the source is referenced by the first stage of the Dockerfile, the
+		// image built from it is empty but for the filtered context
+		build := buildFromSource(
+			s.jobSpec, "", filter.tag,
+			buildapi.BuildSource{
+				Type:       buildapi.BuildSourceDockerfile,
+				Dockerfile: &dockerfile,
+			},
+			"",
+			"",
+			s.resources,
+			s.pullSecret,
+			nil,
+			s.config.Ref,
+		)
+		setBuildBackend(build, s.config.BuildBackend)
+		if err := handleBuild(ctx, s.client, s.podClient, *build); err != nil {
+			return fmt.Errorf("failed to filter the build context: %w", err)
+		}
+	}
 	build := buildFromSource(
 		s.jobSpec, s.config.From, s.config.To,
 		buildapi.BuildSource{
@@ -67,6 +95,12 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 		s.config.BuildArgs,
 		s.config.Ref,
 	)
+	setBuildBackend(build, s.config.BuildBackend)
+	if api.PromotesByCommit(s.releaseBuildConfig.PromotionConfiguration) {
+		// images tagged by commit are labelled with the source they come from
+		// by all builds, consumers also need to know when they were built
+		addBuildDateLabel(build, time.Now())
+	}
 
 	// Bundle images are non multi-arch by design. No manifest list is needed. Here we spawn a single build.
 	if s.config.IsBundleImage() {
@@ -79,10 +113,82 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 type workingDir func(tag string) (string, error)
 type isBundleImage func(tag string) bool
 
-func imagesFor(config api.ProjectDirectoryImageBuildStepConfiguration, workingDir workingDir, isBundleImage isBundleImage) (api.PipelineImageStreamTagReference, []buildapi.ImageSource, error) {
+// filteredContextDir is where the files of the filtered build context are
+// copied to in the image holding them.
+const filteredContextDir = "/tmp/build-context"
+
+// contextFilter is a build copying the files of the context directory which
+// are sent as the build context of an image into an image of their own, so
+// only they are transferred to the build of the image.
+type contextFilter struct {
+	// tag is the pipeline image holding the filtered build context.
+	tag api.PipelineImageStreamTagReference
+	// script copies the files to keep from the source image.
+	script string
+}
+
+// dockerfile builds the image holding the filtered build context from the
+// source image: the files are copied in a stage based on the source image and
+// only the copy ends up in the image, which has no other content.
+func (f *contextFilter) dockerfile(source string) string {
+	return fmt.Sprintf("FROM %s AS source\nRUN %s\nFROM scratch\nCOPY --from=source %s %s\n", source, f.script, filteredContextDir, filteredContextDir)
+}
+
+// shellQuote quotes a string for use as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// casePatterns turns the glob patterns into the alternatives of a shell `case`
+// pattern matching the paths and the files under them. The patterns are
+// validated to be safe to use unquoted.
+func casePatterns(patterns []string) string {
+	var alternatives []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		alternatives = append(alternatives, pattern, pattern+"/*")
+	}
+	return strings.Join(alternatives, "|")
+}
+
+func newContextFilter(config api.ProjectDirectoryImageBuildStepConfiguration, contextDir string) *contextFilter {
+	keep := "0"
+	if len(config.Include) == 0 {
+		keep = "1"
+	}
+	dockerfile := config.DockerfilePath
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	script := []string{
+		"set -eu",
+		fmt.Sprintf("mkdir -p %s", filteredContextDir),
+		fmt.Sprintf("cd %s", shellQuote(contextDir)),
+		`find . \( -type f -o -type l \) -print | while IFS= read -r file; do file="${file#./}"`,
+		fmt.Sprintf("keep=%s", keep),
+	}
+	if len(config.Include) > 0 {
+		script = append(script, fmt.Sprintf(`case "$file" in %s) keep=1 ;; esac`, casePatterns(config.Include)))
+	}
+	if len(config.Exclude) > 0 {
+		script = append(script, fmt.Sprintf(`case "$file" in %s) keep=0 ;; esac`, casePatterns(config.Exclude)))
+	}
+	script = append(script,
+		fmt.Sprintf(`if [ "$file" = %s ]; then keep=1; fi`, shellQuote(path.Clean(dockerfile))),
+		fmt.Sprintf(`if [ "$keep" = 1 ]; then mkdir -p "%[1]s/$(dirname "$file")" && cp -P "$file" "%[1]s/$file"; fi`, filteredContextDir),
+		"done",
+	)
+	return &contextFilter{
+		tag:    api.BuildContextName(config.To),
+		script: strings.Join(script, "; "),
+	}
+}
+
+func imagesFor(config api.ProjectDirectoryImageBuildStepConfiguration, workingDir workingDir, isBundleImage isBundleImage) (api.PipelineImageStreamTagReference, []buildapi.ImageSource, *contextFilter, error) {
 	images := buildInputsFromStep(config.Inputs)
 	var sourceTag string
 	var contextDir string
+	var filter *contextFilter
 	if isBundleImage(string(config.To)) {
 		// use the operator bundle source for bundle images
 		sourceTag = string(api.PipelineImageStreamTagReferenceBundleSource)
@@ -103,7 +209,13 @@ func imagesFor(config api.ProjectDirectoryImageBuildStepConfiguration, workingDi
 		source := fmt.Sprintf("%s:%s", api.PipelineImageStream, sourceTag)
 		baseDir, err := workingDir(source)
 		if err != nil {
-			return "", nil, fmt.Errorf("failed to get workingDir: %w", err)
+			return "", nil, nil, fmt.Errorf("failed to get workingDir: %w", err)
+		}
+		sourcePath := path.Join(baseDir, contextDir)
+		if config.FiltersContext() {
+			filter = newContextFilter(config, sourcePath)
+			source = fmt.Sprintf("%s:%s", api.PipelineImageStream, filter.tag)
+			sourcePath = filteredContextDir
 		}
 		images = append(images, buildapi.ImageSource{
 			From: coreapi.ObjectReference{
@@ -111,12 +223,12 @@ func imagesFor(config api.ProjectDirectoryImageBuildStepConfiguration, workingDi
 				Name: source,
 			},
 			Paths: []buildapi.ImageSourcePath{{
-				SourcePath:     fmt.Sprintf("%s/.", path.Join(baseDir, contextDir)),
+				SourcePath:     fmt.Sprintf("%s/.", sourcePath),
 				DestinationDir: ".",
 			}},
 		})
 	}
-	return api.PipelineImageStreamTagReference(sourceTag), images, nil
+	return api.PipelineImageStreamTagReference(sourceTag), images, filter, nil
 }
 
 func getWorkingDir(client ctrlruntimeclient.Client, source, namespace string) (string, error) {
@@ -21,6 +21,7 @@ func TestImagesFor(t *testing.T) {
 		isBundleImage isBundleImage
 		sourceTag     api.PipelineImageStreamTagReference
 		images        []buildapi.ImageSource
+		filter        *contextFilter
 		expectError   bool
 	}{
 		{
@@ -53,7 +54,7 @@ func TestImagesFor(t *testing.T) {
 						Kind: "ImageStreamTag",
 						Name: "pipeline:input",
 					},
-					As: []string{"asname", "asother"},
+					As: []string{"asname", "asother", "pipeline:input"},
 					Paths: []buildapi.ImageSourcePath{
 						{SourcePath: "first-source", DestinationDir: "first-dest"},
 						{SourcePath: "second-source", DestinationDir: "second-dest"},
@@ -107,7 +108,7 @@ func TestImagesFor(t *testing.T) {
 						Kind: "ImageStreamTag",
 						Name: "pipeline:input",
 					},
-					As: []string{"asname", "asother"},
+					As: []string{"asname", "asother", "pipeline:input"},
 					Paths: []buildapi.ImageSourcePath{
 						{SourcePath: "first-source", DestinationDir: "first-dest"},
 						{SourcePath: "second-source", DestinationDir: "second-dest"},
@@ -118,7 +119,7 @@ func TestImagesFor(t *testing.T) {
 						Kind: "ImageStreamTag",
 						Name: "pipeline:src",
 					},
-					As: []string{"assrc"},
+					As: []string{"assrc", "pipeline:src"},
 					Paths: []buildapi.ImageSourcePath{
 						{SourcePath: "custom-source", DestinationDir: "custom-dest"},
 					},
@@ -126,6 +127,50 @@ func TestImagesFor(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "inputs used as pipeline images",
+			config: api.ProjectDirectoryImageBuildStepConfiguration{
+				To: "output",
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{
+						"bin":  {},
+						"base": {As: []string{"pipeline:base", "ubi"}},
+					},
+				},
+			},
+			workingDir: func(tag string) (string, error) {
+				return "dir", nil
+			},
+			isBundleImage: func(tag string) bool {
+				return false
+			},
+			sourceTag: api.PipelineImageStreamTagReferenceSource,
+			images: []buildapi.ImageSource{
+				{
+					From: corev1.ObjectReference{
+						Kind: "ImageStreamTag",
+						Name: "pipeline:base",
+					},
+					As: []string{"pipeline:base", "ubi"},
+				},
+				{
+					From: corev1.ObjectReference{
+						Kind: "ImageStreamTag",
+						Name: "pipeline:bin",
+					},
+					As: []string{"pipeline:bin"},
+				},
+				{
+					From: corev1.ObjectReference{
+						Kind: "ImageStreamTag",
+						Name: "pipeline:src",
+					},
+					Paths: []buildapi.ImageSourcePath{
+						{SourcePath: "dir/.", DestinationDir: "."},
+					},
+				},
+			},
+		},
 		{
 			name: "fails to get working dir",
 			config: api.ProjectDirectoryImageBuildStepConfiguration{
@@ -181,7 +226,7 @@ func TestImagesFor(t *testing.T) {
 						Kind: "ImageStreamTag",
 						Name: "pipeline:input",
 					},
-					As: []string{"asname", "asother"},
+					As: []string{"asname", "asother", "pipeline:input"},
 					Paths: []buildapi.ImageSourcePath{
 						{SourcePath: "first-source", DestinationDir: "first-dest"},
 						{SourcePath: "second-source", DestinationDir: "second-dest"},
@@ -229,7 +274,7 @@ func TestImagesFor(t *testing.T) {
 						Kind: "ImageStreamTag",
 						Name: "pipeline:input",
 					},
-					As: []string{"asname", "asother"},
+					As: []string{"asname", "asother", "pipeline:input"},
 					Paths: []buildapi.ImageSourcePath{
 						{SourcePath: "first-source", DestinationDir: "first-dest"},
 						{SourcePath: "second-source", DestinationDir: "second-dest"},
@@ -247,10 +292,44 @@ func TestImagesFor(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "filtered context",
+			config: api.ProjectDirectoryImageBuildStepConfiguration{
+				To: "output",
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					ContextDir:     "context",
+					DockerfilePath: "images/Dockerfile",
+					Include:        []string{"cmd/tool", "go.*"},
+					Exclude:        []string{"*_test.go"},
+				},
+			},
+			workingDir: func(tag string) (string, error) {
+				return "dir", nil
+			},
+			isBundleImage: func(tag string) bool {
+				return false
+			},
+			sourceTag: api.PipelineImageStreamTagReferenceSource,
+			images: []buildapi.ImageSource{
+				{
+					From: corev1.ObjectReference{
+						Kind: "ImageStreamTag",
+						Name: "pipeline:output-build-context",
+					},
+					Paths: []buildapi.ImageSourcePath{
+						{SourcePath: "/tmp/build-context/.", DestinationDir: "."},
+					},
+				},
+			},
+			filter: &contextFilter{
+				tag:    "output-build-context",
+				script: `set -eu; mkdir -p /tmp/build-context; cd 'dir/context'; find . \( -type f -o -type l \) -print | while IFS= read -r file; do file="${file#./}"; keep=0; case "$file" in cmd/tool|cmd/tool/*|go.*|go.*/*) keep=1 ;; esac; case "$file" in *_test.go|*_test.go/*) keep=0 ;; esac; if [ "$file" = 'images/Dockerfile' ]; then keep=1; fi; if [ "$keep" = 1 ]; then mkdir -p "/tmp/build-context/$(dirname "$file")" && cp -P "$file" "/tmp/build-context/$file"; fi; done`,
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			sourceTag, images, err := imagesFor(testCase.config, testCase.workingDir, testCase.isBundleImage)
+			sourceTag, images, filter, err := imagesFor(testCase.config, testCase.workingDir, testCase.isBundleImage)
 			if testCase.expectError && err == nil {
 				t.Errorf("%s: expected an error but got none", testCase.name)
 			}
@@ -263,6 +342,21 @@ func TestImagesFor(t *testing.T) {
 			if diff := cmp.Diff(testCase.images, images); diff != "" {
 				t.Errorf("%s: got incorrect images: %v", testCase.name, diff)
 			}
+			if diff := cmp.Diff(testCase.filter, filter, cmp.AllowUnexported(contextFilter{})); diff != "" {
+				t.Errorf("%s: got incorrect context filter: %v", testCase.name, diff)
+			}
 		})
 	}
 }
+
+func TestContextFilterDockerfile(t *testing.T) {
+	filter := &contextFilter{tag: "output-build-context", script: "set -eu"}
+	expected := `FROM registry.example.com/ci-op-xyz/pipeline@sha256:abc AS source
+RUN set -eu
+FROM scratch
+COPY --from=source /tmp/build-context /tmp/build-context
+`
+	if diff := cmp.Diff(expected, filter.dockerfile("registry.example.com/ci-op-xyz/pipeline@sha256:abc")); diff != "" {
+		t.Errorf("unexpected Dockerfile: %s", diff)
+	}
+}
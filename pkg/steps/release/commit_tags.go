@@ -0,0 +1,291 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+)
+
+// commitTag matches the tags of images promoted by commit
+var commitTag = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// commitBranchAnnotationPrefix prefixes the annotations on the image streams
+// of the central registry recording the branch each commit tag was promoted
+// from, so that the tags of a branch are known without inspecting the images.
+const commitBranchAnnotationPrefix = "ci.openshift.io/commit-branch-"
+
+// quayCommitAnnotationPrefix prefixes the annotations on the image streams of
+// the central registry recording the commits promoted to quay.io, with the
+// time they were promoted at and their branch, as quay.io has no image
+// streams to record them on.
+const quayCommitAnnotationPrefix = "ci.openshift.io/quay-commit-"
+
+// pruneCommitTags records the branch of every commit promoted by commit and
+// enforces the retention of commit tags configured for the targets: only the
+// tags of the most recently promoted commits of the branch are kept, in the
+// central registry or on quay.io. Other branches may promote to the same
+// image streams, their tags are left to their own promotions. The branch is
+// recorded even without retention, so that the tags of a branch which does
+// not prune them are never mistaken for the tags of one that does.
+func (s *promotionStep) pruneCommitTags(ctx context.Context) error {
+	if s.registry != api.ServiceDomainAPPCIRegistry && s.registry != api.QuayOpenShiftCIRepo {
+		return nil
+	}
+	refs := mainRefs(s.jobSpec.Refs, s.jobSpec.ExtraRefs)
+	if refs == nil || refs.BaseRef == "" || refs.BaseSHA == "" {
+		return nil
+	}
+	var client ctrlruntimeclient.Client
+	var deleteQuayTag quayTagDeleter
+	var errs []error
+	for _, target := range api.PromotionTargets(s.configuration.PromotionConfiguration) {
+		if target.Disabled || !target.TagByCommit {
+			continue
+		}
+		if client == nil {
+			if client = s.appCIClient(); client == nil {
+				return errors.New("cannot access the central registry")
+			}
+		}
+		if s.registry == api.QuayOpenShiftCIRepo && deleteQuayTag == nil {
+			var err error
+			if deleteQuayTag, err = s.quayTagDeleter(); err != nil {
+				return err
+			}
+		}
+		tags, _ := toPromote(target, s.configuration.Images, s.requiredImages)
+		for _, name := range sets.List(sets.KeySet(tags)) {
+			var err error
+			if s.registry == api.QuayOpenShiftCIRepo {
+				err = pruneQuayCommitTags(ctx, client, deleteQuayTag, target.Namespace, name, refs.BaseRef, refs.BaseSHA, target.CommitTagRetention, s.now())
+			} else {
+				err = pruneCommitTags(ctx, client, target.Namespace, name, refs.BaseRef, refs.BaseSHA, target.CommitTagRetention)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// pruneCommitTags records that the commit was promoted from the branch and
+// removes the commit tags of the image stream promoted from the branch, except
+// for the `keep` most recently created ones. Nothing is removed when `keep` is
+// zero. Tags without a recorded branch may have been promoted from any branch
+// and are never removed.
+func pruneCommitTags(ctx context.Context, client ctrlruntimeclient.Client, namespace, name, branch, commit string, keep int) error {
+	var pruned []string
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pruned = nil
+		stream := &imagev1.ImageStream{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, stream); err != nil {
+			return err
+		}
+		if stream.Annotations == nil {
+			stream.Annotations = map[string]string{}
+		}
+		stream.Annotations[commitBranchAnnotationPrefix+commit] = branch
+		type tag struct {
+			name    string
+			created meta.Time
+		}
+		var tags []tag
+		present := sets.New[string](commit)
+		for _, t := range stream.Status.Tags {
+			if !commitTag.MatchString(t.Tag) || len(t.Items) == 0 {
+				continue
+			}
+			present.Insert(t.Tag)
+			if tagBranch, recorded := stream.Annotations[commitBranchAnnotationPrefix+t.Tag]; recorded && tagBranch == branch {
+				tags = append(tags, tag{name: t.Tag, created: t.Items[0].Created})
+			}
+		}
+		sort.SliceStable(tags, func(i, j int) bool {
+			return tags[j].created.Before(&tags[i].created)
+		})
+		for i := keep; keep > 0 && i < len(tags); i++ {
+			pruned = append(pruned, tags[i].name)
+			delete(stream.Annotations, commitBranchAnnotationPrefix+tags[i].name)
+		}
+		// the tags may also have been removed by other means
+		for key := range stream.Annotations {
+			if tag, ok := strings.CutPrefix(key, commitBranchAnnotationPrefix); ok && !present.Has(tag) {
+				delete(stream.Annotations, key)
+			}
+		}
+		return client.Update(ctx, stream)
+	}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to record the branch of commit %s in image stream %s/%s: %w", commit, namespace, name, err)
+	}
+	var errs []error
+	for _, t := range pruned {
+		logrus.Debugf("Removing the tag of commit %s from image stream %s/%s.", t, namespace, name)
+		ist := &imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: fmt.Sprintf("%s:%s", name, t)}}
+		if err := client.Delete(ctx, ist); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete image stream tag %s/%s: %w", namespace, ist.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// pruneQuayCommitTags records on the image stream of the central registry that
+// the commit was promoted to quay.io from the branch, and removes the tags of
+// the commits promoted from the branch from quay.io, except for the `keep`
+// most recently promoted ones. Nothing is removed when `keep` is zero, and
+// nothing is recorded when the image stream does not exist.
+func pruneQuayCommitTags(ctx context.Context, client ctrlruntimeclient.Client, deleteTag quayTagDeleter, namespace, name, branch, commit string, keep int, now time.Time) error {
+	var pruned []string
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pruned = nil
+		stream := &imagev1.ImageStream{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, stream); err != nil {
+			return err
+		}
+		if stream.Annotations == nil {
+			stream.Annotations = map[string]string{}
+		}
+		stream.Annotations[quayCommitAnnotationPrefix+commit] = fmt.Sprintf("%s %s", now.UTC().Format(time.RFC3339), branch)
+		type tag struct {
+			name     string
+			promoted time.Time
+		}
+		var tags []tag
+		for key, value := range stream.Annotations {
+			sha, ok := strings.CutPrefix(key, quayCommitAnnotationPrefix)
+			if !ok {
+				continue
+			}
+			raw, tagBranch, _ := strings.Cut(value, " ")
+			if tagBranch != branch {
+				continue
+			}
+			promoted, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				logrus.WithError(err).Debugf("Ignoring the malformed annotation %s on image stream %s/%s.", key, namespace, name)
+				continue
+			}
+			tags = append(tags, tag{name: sha, promoted: promoted})
+		}
+		sort.SliceStable(tags, func(i, j int) bool {
+			if !tags[i].promoted.Equal(tags[j].promoted) {
+				return tags[j].promoted.Before(tags[i].promoted)
+			}
+			return tags[i].name < tags[j].name
+		})
+		for i := keep; keep > 0 && i < len(tags); i++ {
+			pruned = append(pruned, tags[i].name)
+			delete(stream.Annotations, quayCommitAnnotationPrefix+tags[i].name)
+		}
+		return client.Update(ctx, stream)
+	}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to record the promotion of commit %s to quay.io in image stream %s/%s: %w", commit, namespace, name, err)
+	}
+	var errs []error
+	for _, sha := range pruned {
+		_, tag, _ := strings.Cut(api.QuayImage(api.ImageStreamTagReference{Namespace: namespace, Name: name, Tag: sha}), ":")
+		logrus.Debugf("Removing the tag %s of commit %s from %s.", tag, sha, api.QuayOpenShiftCIRepo)
+		if err := deleteTag(ctx, tag); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete tag %s from %s: %w", tag, api.QuayOpenShiftCIRepo, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// quayTagDeleter removes a tag from the repository on quay.io images are
+// promoted to. Removing a tag which does not exist is not an error.
+type quayTagDeleter func(ctx context.Context, tag string) error
+
+// quayTagDeleter removes tags from quay.io with the credentials for it in the
+// push secret.
+func (s *promotionStep) quayTagDeleter() (quayTagDeleter, error) {
+	if s.deleteQuayTag != nil {
+		return s.deleteQuayTag, nil
+	}
+	if s.pushSecret == nil {
+		return nil, errors.New("no push secret provided")
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+		return nil, fmt.Errorf("failed to deserialize push secret: %w", err)
+	}
+	host, repository, _ := strings.Cut(api.QuayOpenShiftCIRepo, "/")
+	auth, ok := dockercfg.Auths[host]
+	if !ok {
+		return nil, fmt.Errorf("push secret has no entry for %s", host)
+	}
+	client := &http.Client{Timeout: time.Minute}
+	return func(ctx context.Context, tag string) error {
+		return deleteRegistryTag(ctx, client, "https://"+host, host, repository, tag, auth.Username, auth.Password)
+	}, nil
+}
+
+// deleteRegistryTag removes a tag through the API of the registry, with a
+// token for the repository obtained with the credentials.
+func deleteRegistryTag(ctx context.Context, client *http.Client, server, service, repository, tag, username, password string) error {
+	tokenURL := fmt.Sprintf("%s/v2/auth?service=%s&scope=repository:%s:pull,push", server, url.QueryEscape(service), repository)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request a token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to request a token: server responded with %d", resp.StatusCode)
+	}
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode the token: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/v2/%s/manifests/%s", server, repository, tag), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	deleted, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer deleted.Body.Close()
+	switch deleted.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("server responded with %d", deleted.StatusCode)
+	}
+}
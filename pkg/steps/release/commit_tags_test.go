@@ -0,0 +1,287 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestPruneCommitTags(t *testing.T) {
+	now := time.Now()
+	commit := func(i int) string {
+		return strings.Repeat(fmt.Sprintf("%d", i), 40)
+	}
+	var objects []ctrlruntimeclient.Object
+	stream := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "tool", Annotations: map[string]string{
+		commitBranchAnnotationPrefix + commit(1): "main",
+		commitBranchAnnotationPrefix + commit(2): "main",
+		commitBranchAnnotationPrefix + commit(3): "main",
+		commitBranchAnnotationPrefix + commit(5): "release-4.18",
+		commitBranchAnnotationPrefix + commit(6): "release-4.18",
+		// the tag was removed by other means
+		commitBranchAnnotationPrefix + commit(7): "main",
+	}}}
+	// commit(4) is the one being promoted, the branch of commit(8) is unknown
+	// as it was promoted before branches were recorded
+	for i, tag := range []string{commit(8), commit(1), commit(2), "latest", commit(3), commit(4), commit(5), commit(6)} {
+		created := metav1.NewTime(now.Add(time.Duration(i) * time.Hour))
+		stream.Status.Tags = append(stream.Status.Tags, imagev1.NamedTagEventList{Tag: tag, Items: []imagev1.TagEvent{{Created: created}}})
+		objects = append(objects, &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "tool:" + tag}})
+	}
+	objects = append(objects, stream)
+	others := []string{"tool:" + commit(5), "tool:" + commit(6), "tool:latest"}
+
+	for _, tc := range []struct {
+		name                string
+		stream              string
+		keep                int
+		expected            []string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:     "older commit tags of the branch are removed",
+			stream:   "tool",
+			keep:     2,
+			expected: append([]string{"tool:" + commit(3), "tool:" + commit(4), "tool:" + commit(8)}, others...),
+			expectedAnnotations: map[string]string{
+				commitBranchAnnotationPrefix + commit(3): "main",
+				commitBranchAnnotationPrefix + commit(4): "main",
+				commitBranchAnnotationPrefix + commit(5): "release-4.18",
+				commitBranchAnnotationPrefix + commit(6): "release-4.18",
+			},
+		},
+		{
+			name:     "commit tags of unknown branches are kept",
+			stream:   "tool",
+			keep:     1,
+			expected: append([]string{"tool:" + commit(4), "tool:" + commit(8)}, others...),
+			expectedAnnotations: map[string]string{
+				commitBranchAnnotationPrefix + commit(4): "main",
+				commitBranchAnnotationPrefix + commit(5): "release-4.18",
+				commitBranchAnnotationPrefix + commit(6): "release-4.18",
+			},
+		},
+		{
+			name:     "the branch is recorded without retention",
+			stream:   "tool",
+			expected: append([]string{"tool:" + commit(1), "tool:" + commit(2), "tool:" + commit(3), "tool:" + commit(4), "tool:" + commit(8)}, others...),
+			expectedAnnotations: map[string]string{
+				commitBranchAnnotationPrefix + commit(1): "main",
+				commitBranchAnnotationPrefix + commit(2): "main",
+				commitBranchAnnotationPrefix + commit(3): "main",
+				commitBranchAnnotationPrefix + commit(4): "main",
+				commitBranchAnnotationPrefix + commit(5): "release-4.18",
+				commitBranchAnnotationPrefix + commit(6): "release-4.18",
+			},
+		},
+		{
+			name:     "image stream does not exist yet",
+			stream:   "new",
+			keep:     1,
+			expected: append([]string{"tool:" + commit(1), "tool:" + commit(2), "tool:" + commit(3), "tool:" + commit(4), "tool:" + commit(8)}, others...),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := imagev1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add imagev1 to scheme: %v", err)
+			}
+			var initial []ctrlruntimeclient.Object
+			for _, o := range objects {
+				initial = append(initial, o.DeepCopyObject().(ctrlruntimeclient.Object))
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(initial...).Build()
+			if err := pruneCommitTags(context.Background(), client, "ci", tc.stream, "main", commit(4), tc.keep); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var tags imagev1.ImageStreamTagList
+			if err := client.List(context.Background(), &tags); err != nil {
+				t.Fatalf("failed to list image stream tags: %v", err)
+			}
+			var names []string
+			for _, tag := range tags.Items {
+				names = append(names, tag.Name)
+			}
+			sort.Strings(names)
+			sort.Strings(tc.expected)
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("unexpected remaining tags: %s", diff)
+			}
+			if tc.expectedAnnotations == nil {
+				return
+			}
+			actual := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ci", Name: tc.stream}, actual); err != nil {
+				t.Fatalf("failed to get the image stream: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, actual.Annotations); diff != "" {
+				t.Errorf("unexpected annotations: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPruneQuayCommitTags(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	commit := func(i int) string {
+		return strings.Repeat(fmt.Sprintf("%d", i), 40)
+	}
+	promoted := func(hoursAgo int, branch string) string {
+		return fmt.Sprintf("%s %s", now.Add(-time.Duration(hoursAgo)*time.Hour).Format(time.RFC3339), branch)
+	}
+	stream := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "tool", Annotations: map[string]string{
+		quayCommitAnnotationPrefix + commit(1): promoted(3, "main"),
+		quayCommitAnnotationPrefix + commit(2): promoted(2, "main"),
+		quayCommitAnnotationPrefix + commit(3): promoted(1, "main"),
+		quayCommitAnnotationPrefix + commit(5): promoted(5, "release-4.18"),
+		"unrelated":                            "value",
+	}}}
+
+	for _, tc := range []struct {
+		name                string
+		stream              string
+		keep                int
+		deleteErr           error
+		expectedDeleted     []string
+		expectedAnnotations map[string]string
+		expectedErr         error
+	}{
+		{
+			name:            "older commit tags of the branch are removed from quay.io",
+			stream:          "tool",
+			keep:            2,
+			expectedDeleted: []string{"ci_tool_" + commit(2), "ci_tool_" + commit(1)},
+			expectedAnnotations: map[string]string{
+				quayCommitAnnotationPrefix + commit(3): promoted(1, "main"),
+				quayCommitAnnotationPrefix + commit(4): promoted(0, "main"),
+				quayCommitAnnotationPrefix + commit(5): promoted(5, "release-4.18"),
+				"unrelated":                            "value",
+			},
+		},
+		{
+			name:            "failures to remove tags are reported",
+			stream:          "tool",
+			keep:            2,
+			deleteErr:       errors.New("unauthorized"),
+			expectedDeleted: []string{"ci_tool_" + commit(2), "ci_tool_" + commit(1)},
+			expectedErr: errors.New("[failed to delete tag ci_tool_" + commit(2) + " from quay.io/openshift/ci: unauthorized, " +
+				"failed to delete tag ci_tool_" + commit(1) + " from quay.io/openshift/ci: unauthorized]"),
+		},
+		{
+			name:   "the promotion is recorded without retention",
+			stream: "tool",
+			expectedAnnotations: map[string]string{
+				quayCommitAnnotationPrefix + commit(1): promoted(3, "main"),
+				quayCommitAnnotationPrefix + commit(2): promoted(2, "main"),
+				quayCommitAnnotationPrefix + commit(3): promoted(1, "main"),
+				quayCommitAnnotationPrefix + commit(4): promoted(0, "main"),
+				quayCommitAnnotationPrefix + commit(5): promoted(5, "release-4.18"),
+				"unrelated":                            "value",
+			},
+		},
+		{
+			name:   "image stream does not exist",
+			stream: "new",
+			keep:   2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := imagev1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add imagev1 to scheme: %v", err)
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(stream.DeepCopy()).Build()
+			var deleted []string
+			deleteTag := func(_ context.Context, tag string) error {
+				deleted = append(deleted, tag)
+				return tc.deleteErr
+			}
+			err := pruneQuayCommitTags(context.Background(), client, deleteTag, "ci", tc.stream, "main", commit(4), tc.keep, now)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedDeleted, deleted); diff != "" {
+				t.Errorf("unexpected deleted tags: %s", diff)
+			}
+			if tc.expectedAnnotations == nil {
+				return
+			}
+			actual := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ci", Name: tc.stream}, actual); err != nil {
+				t.Fatalf("failed to get the image stream: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, actual.Annotations); diff != "" {
+				t.Errorf("unexpected annotations: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDeleteRegistryTag(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		status      int
+		expectedErr error
+	}{
+		{
+			name:   "tag is deleted",
+			status: http.StatusAccepted,
+		},
+		{
+			name:   "tag does not exist",
+			status: http.StatusNotFound,
+		},
+		{
+			name:        "deletion fails",
+			status:      http.StatusForbidden,
+			expectedErr: errors.New("server responded with 403"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/auth":
+					if username, password, ok := r.BasicAuth(); !ok || username != "robot" || password != "secret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					if scope := r.URL.Query().Get("scope"); scope != "repository:openshift/ci:pull,push" {
+						t.Errorf("unexpected scope: %s", scope)
+					}
+					_, _ = w.Write([]byte(`{"token":"token"}`))
+				case r.Method == http.MethodDelete && r.URL.Path == "/v2/openshift/ci/manifests/ci_tool_abc":
+					if r.Header.Get("Authorization") != "Bearer token" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					w.WriteHeader(tc.status)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+			err := deleteRegistryTag(context.Background(), server.Client(), server.URL, "quay.io", "openshift/ci", "ci_tool_abc", "robot", "secret")
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// externalMirror is a set of images pushed to external registries with the
+// same credentials.
+type externalMirror struct {
+	// credentials is the name of the secret with the credentials in the
+	// test-credentials namespace
+	credentials string
+	// images maps the pull specs the images are pushed to to their source
+	images map[string]string
+}
+
+// externalMirrors determines the images pushed to external registries by
+// the targets with a mirror, mapped to their source tag in the pipeline
+// image stream.
+func externalMirrors(configuration *api.ReleaseBuildConfiguration, requiredImages sets.Set[string], commitSha string) []externalMirror {
+	byCredentials := map[string]map[string]string{}
+	for _, target := range api.PromotionTargets(configuration.PromotionConfiguration) {
+		if target.Mirror == nil {
+			continue
+		}
+		tags, _ := toPromote(target, configuration.Images, requiredImages)
+		for dst, src := range tags {
+			for _, tag := range targetTags(target, dst, commitSha) {
+				if byCredentials[target.Mirror.Credentials] == nil {
+					byCredentials[target.Mirror.Credentials] = map[string]string{}
+				}
+				byCredentials[target.Mirror.Credentials][externalPullSpec(*target.Mirror, tag)] = src
+			}
+		}
+	}
+	var mirrors []externalMirror
+	for _, credentials := range sets.List(sets.KeySet(byCredentials)) {
+		mirrors = append(mirrors, externalMirror{credentials: credentials, images: byCredentials[credentials]})
+	}
+	return mirrors
+}
+
+// externalPullSpec is the pull spec a promoted tag is pushed to in a mirror.
+func externalPullSpec(mirror api.PromotionMirror, tag api.ImageStreamTagReference) string {
+	return fmt.Sprintf("%s/%s/%s:%s", mirror.Registry, mirror.Repository, tag.Name, tag.Tag)
+}
+
+// resolveExternalMirrors replaces the source tags of the images with their
+// pull specs, skipping the images missing from the pipeline image stream.
+func resolveExternalMirrors(mirrors []externalMirror, pipeline *imagev1.ImageStream) []externalMirror {
+	var resolved []externalMirror
+	for _, mirror := range mirrors {
+		images := map[string]string{}
+		for target, src := range mirror.images {
+			dockerImageReference := findDockerImageReference(pipeline, src)
+			if dockerImageReference == "" {
+				continue
+			}
+			images[target] = getPublicImageReference(dockerImageReference, pipeline.Status.PublicDockerImageRepository)
+		}
+		if len(images) != 0 {
+			resolved = append(resolved, externalMirror{credentials: mirror.credentials, images: images})
+		}
+	}
+	return resolved
+}
+
+// mirrorCredentialsSecret is the secret the promotion pod pushes to an
+// external registry with.
+func mirrorCredentialsSecret(credentials string) string {
+	return "promotion-mirror-" + credentials
+}
+
+// ensureMirrorCredentials creates the secret the promotion pod pushes to
+// external registries with. The images are pulled from the registry of the
+// build cluster, so the credentials for the external registry, copied into
+// the test namespace by ci-operator, are merged with the push secret.
+func (s *promotionStep) ensureMirrorCredentials(ctx context.Context, credentials string) error {
+	mirrorSecret := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.ExternalPullSecretName(credentials)}, mirrorSecret); err != nil {
+		return fmt.Errorf("failed to get the credentials %s: %w", credentials, err)
+	}
+	var push []byte
+	if s.pushSecret != nil {
+		push = s.pushSecret.Data[coreapi.DockerConfigJsonKey]
+	}
+	merged, err := mergeDockerConfigs(push, mirrorSecret.Data[coreapi.DockerConfigJsonKey])
+	if err != nil {
+		return fmt.Errorf("failed to merge the credentials %s with the push secret: %w", credentials, err)
+	}
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: mirrorCredentialsSecret(credentials)},
+		Type:       coreapi.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: merged},
+	}
+	if _, err := util.UpsertImmutableSecret(ctx, s.client, secret); err != nil {
+		return fmt.Errorf("failed to create the secret for the credentials %s: %w", credentials, err)
+	}
+	return nil
+}
+
+// mergeDockerConfigs merges the authentications of docker configurations,
+// the latter ones taking precedence for the registries they have in common.
+// The authentications are copied as they are.
+func mergeDockerConfigs(configs ...[]byte) ([]byte, error) {
+	type dockerConfigJSON struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	merged := dockerConfigJSON{Auths: map[string]json.RawMessage{}}
+	for _, raw := range configs {
+		if len(raw) == 0 {
+			continue
+		}
+		var config dockerConfigJSON
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, fmt.Errorf("failed to deserialize docker config: %w", err)
+		}
+		for registry, auth := range config.Auths {
+			merged.Auths[registry] = auth
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// externalMirrorTargets lists the external pull specs of the mirrors.
+func externalMirrorTargets(mirrors []externalMirror) []string {
+	var targets []string
+	for _, mirror := range mirrors {
+		for target := range mirror.images {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,116 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+)
+
+func TestExternalMirrors(t *testing.T) {
+	images := []api.ProjectDirectoryImageBuildStepConfiguration{{To: "tool"}, {To: "other"}}
+	quay := &api.PromotionMirror{Registry: "quay.io", Repository: "org/repo", Credentials: "quay-push"}
+	for _, tc := range []struct {
+		name     string
+		targets  []api.PromotionTarget
+		expected []externalMirror
+	}{
+		{
+			name:    "targets without mirrors push nothing externally",
+			targets: []api.PromotionTarget{{Namespace: "ci", Name: "latest"}},
+		},
+		{
+			name: "target mirrors its tags",
+			targets: []api.PromotionTarget{
+				{Namespace: "ci", Name: "latest", ExcludedImages: []string{"other"}, Mirror: quay},
+				{Namespace: "ci", Tag: "latest"},
+			},
+			expected: []externalMirror{{credentials: "quay-push", images: map[string]string{
+				"quay.io/org/repo/latest:tool": "tool",
+			}}},
+		},
+		{
+			name: "tags by commit are mirrored",
+			targets: []api.PromotionTarget{
+				{Namespace: "ci", Tag: "latest", TagByCommit: true, ExcludedImages: []string{"other"}, Mirror: &api.PromotionMirror{Registry: "registry.example.com", Repository: "ci", Credentials: "example-push"}},
+			},
+			expected: []externalMirror{{credentials: "example-push", images: map[string]string{
+				"registry.example.com/ci/tool:latest": "tool",
+				"registry.example.com/ci/tool:abc":    "tool",
+			}}},
+		},
+		{
+			name:    "disabled target is not mirrored",
+			targets: []api.PromotionTarget{{Namespace: "ci", Name: "latest", Disabled: true, Mirror: quay}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := &api.ReleaseBuildConfiguration{
+				Images:                 images,
+				PromotionConfiguration: &api.PromotionConfiguration{Targets: tc.targets},
+			}
+			mirrors := externalMirrors(configuration, sets.New[string](), "abc")
+			if diff := cmp.Diff(tc.expected, mirrors, cmp.AllowUnexported(externalMirror{})); diff != "" {
+				t.Errorf("unexpected mirrors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeDockerConfigs(t *testing.T) {
+	push := []byte(`{"auths":{"registry.ci.openshift.org":{"auth":"cHVzaDpwdXNo"},"quay.io":{"auth":"b2xkOm9sZA=="}}}`)
+	mirror := []byte(`{"auths":{"quay.io":{"auth":"bmV3Om5ldw=="}}}`)
+	merged, err := mergeDockerConfigs(push, nil, mirror)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"auths":{"quay.io":{"auth":"bmV3Om5ldw=="},"registry.ci.openshift.org":{"auth":"cHVzaDpwdXNo"}}}`
+	if diff := cmp.Diff(expected, string(merged)); diff != "" {
+		t.Errorf("unexpected merged config: %s", diff)
+	}
+	if _, err := mergeDockerConfigs([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestEnsureMirrorCredentials(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-test", Name: api.ExternalPullSecretName("quay-push")},
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"bmV3Om5ldw=="}}}`)},
+	}).Build()
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ci-op-test")
+	s := &promotionStep{
+		jobSpec: jobSpec,
+		client:  kubernetes.NewPodClient(loggingclient.New(client), nil, nil, 0),
+		pushSecret: &coreapi.Secret{Data: map[string][]byte{
+			coreapi.DockerConfigJsonKey: []byte(`{"auths":{"registry.ci.openshift.org":{"auth":"cHVzaDpwdXNo"}}}`),
+		}},
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.ensureMirrorCredentials(context.Background(), "quay-push"); err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	secret := &coreapi.Secret{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ci-op-test", Name: "promotion-mirror-quay-push"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"auths":{"quay.io":{"auth":"bmV3Om5ldw=="},"registry.ci.openshift.org":{"auth":"cHVzaDpwdXNo"}}}`
+	if diff := cmp.Diff(expected, string(secret.Data[coreapi.DockerConfigJsonKey])); diff != "" {
+		t.Errorf("unexpected credentials: %s", diff)
+	}
+	if secret.Type != coreapi.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret type %s", secret.Type)
+	}
+}
@@ -0,0 +1,73 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// requiredArchitectures maps the source tags promoted by the targets which
+// request additional architectures to the architectures they must cover.
+func requiredArchitectures(configuration *api.ReleaseBuildConfiguration, requiredImages sets.Set[string]) map[string]sets.Set[string] {
+	ret := map[string]sets.Set[string]{}
+	for _, target := range api.PromotionTargets(configuration.PromotionConfiguration) {
+		if len(target.AdditionalArchitectures) == 0 {
+			continue
+		}
+		tags, _ := toPromote(target, configuration.Images, requiredImages)
+		for _, src := range tags {
+			if ret[src] == nil {
+				ret[src] = sets.New[string](string(api.NodeArchitectureAMD64))
+			}
+			ret[src].Insert(target.AdditionalArchitectures...)
+		}
+	}
+	return ret
+}
+
+// manifestArchitectures returns the architectures of the manifests of an
+// image, which are only known for manifest lists.
+func manifestArchitectures(image imagev1.Image) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, manifest := range image.DockerImageManifests {
+		ret.Insert(manifest.Architecture)
+	}
+	return ret
+}
+
+// checkManifestLists makes sure the images promoted by the targets which
+// request additional architectures are manifest lists covering them, before
+// anything is promoted. Nothing needs to be assembled here: the builds already
+// push every image as a manifest list of its builds for each architecture, and
+// the mirroring keeps manifest lists, so an image only falls short when it was
+// not built for the architectures the targets request.
+func (s *promotionStep) checkManifestLists(ctx context.Context, configuration *api.ReleaseBuildConfiguration, pipeline *imagev1.ImageStream) error {
+	required := requiredArchitectures(configuration, s.requiredImages)
+	var errs []error
+	for _, src := range sets.List(sets.KeySet(required)) {
+		if findDockerImageReference(pipeline, src) == "" {
+			// images which were not built are not promoted
+			continue
+		}
+		ist := &imagev1.ImageStreamTag{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, src)}, ist); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("could not get image %s: %w", src, err)
+		}
+		if missing := required[src].Difference(manifestArchitectures(ist.Image)); missing.Len() != 0 {
+			errs = append(errs, fmt.Errorf("image %s is not a manifest list covering %s", src, strings.Join(sets.List(missing), ", ")))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,83 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCheckManifestLists(t *testing.T) {
+	const namespace = "ci-op-test"
+	pipeline := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: api.PipelineImageStream},
+	}
+	for _, tag := range []string{"list", "single", "partial"} {
+		pipeline.Status.Tags = append(pipeline.Status.Tags, imagev1.NamedTagEventList{Tag: tag, Items: []imagev1.TagEvent{{DockerImageReference: "registry/" + tag}}})
+	}
+	tag := func(name string, archs ...string) ctrlruntimeclient.Object {
+		ist := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: api.PipelineImageStream + ":" + name}}
+		for _, arch := range archs {
+			ist.Image.DockerImageManifests = append(ist.Image.DockerImageManifests, imagev1.ImageManifest{Architecture: arch})
+		}
+		return ist
+	}
+	target := api.PromotionTarget{Namespace: "ci", Name: "latest", AdditionalArchitectures: []string{"arm64"}}
+
+	for _, tc := range []struct {
+		name        string
+		images      []string
+		targets     []api.PromotionTarget
+		expectedErr error
+	}{
+		{
+			name:    "no target requests architectures",
+			images:  []string{"single"},
+			targets: []api.PromotionTarget{{Namespace: "ci", Name: "latest"}},
+		},
+		{
+			name:    "manifest list covers the architectures",
+			images:  []string{"list", "missing"},
+			targets: []api.PromotionTarget{target},
+		},
+		{
+			name:        "images not built for the architectures",
+			images:      []string{"list", "single", "partial"},
+			targets:     []api.PromotionTarget{target},
+			expectedErr: errors.New("[image partial is not a manifest list covering arm64, image single is not a manifest list covering amd64, arm64]"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := imagev1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add imagev1 to scheme: %v", err)
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(tag("list", "amd64", "arm64"), tag("single"), tag("partial", "amd64")).Build()
+			jobSpec := &api.JobSpec{}
+			jobSpec.SetNamespace(namespace)
+			configuration := &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{Targets: tc.targets}}
+			for _, image := range tc.images {
+				configuration.Images = append(configuration.Images, api.ProjectDirectoryImageBuildStepConfiguration{To: api.PipelineImageStreamTagReference(image)})
+			}
+			s := &promotionStep{
+				jobSpec:        jobSpec,
+				client:         kubernetes.NewPodClient(loggingclient.New(client), nil, nil, 0),
+				requiredImages: sets.New[string](),
+			}
+			err := s.checkManifestLists(context.Background(), configuration, pipeline)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
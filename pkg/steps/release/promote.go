@@ -3,11 +3,13 @@ package release
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -27,6 +29,8 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/release"
+	"github.com/openshift/ci-tools/pkg/release/art"
 	"github.com/openshift/ci-tools/pkg/release/prerelease"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps"
@@ -45,6 +49,25 @@ type promotionStep struct {
 	mirrorFunc        func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string)
 	targetNameFunc    func(string, api.PromotionTarget) string
 	nodeArchitectures []string
+	// artCheck verifies the build root of the images against ART, when the
+	// configuration requests it
+	artCheck *ARTConsistencyCheck
+	// cveGate scans the images before they are promoted, when the
+	// configuration requests it
+	cveGate *CVEGate
+	dryRun  bool
+	// targetClient accesses the image streams in the central registry, it
+	// is only needed to report the current state of the targets in a dry
+	// run and to remove old commit tags
+	targetClient ctrlruntimeclient.Client
+	// deleteQuayTag removes old commit tags from quay.io, it is set up
+	// from the push secret when unset
+	deleteQuayTag quayTagDeleter
+	now           func() time.Time
+
+	// paused holds the targets skipped because their promotion is paused
+	paused []pausedTarget
+	err    error
 }
 
 func (s *promotionStep) Inputs() (api.InputDefinition, error) {
@@ -54,7 +77,8 @@ func (s *promotionStep) Inputs() (api.InputDefinition, error) {
 func (*promotionStep) Validate() error { return nil }
 
 func (s *promotionStep) Run(ctx context.Context) error {
-	return results.ForReason("promoting_images").ForError(s.run(ctx))
+	s.err = results.ForReason("promoting_images").ForError(s.run(ctx))
+	return s.err
 }
 
 func mainRefs(refs *prowapi.Refs, extra []prowapi.Refs) *prowapi.Refs {
@@ -73,16 +97,31 @@ func (s *promotionStep) run(ctx context.Context) error {
 	}
 	logger := logrus.WithField("name", s.name)
 
+	var commitSha string
 	if refs := mainRefs(s.jobSpec.Refs, s.jobSpec.ExtraRefs); refs != nil {
-		opts = append(opts, WithCommitSha(refs.BaseSHA))
+		commitSha = refs.BaseSHA
+		opts = append(opts, WithCommitSha(commitSha))
 	}
 
-	tags, names := PromotedTagsWithRequiredImages(s.configuration, opts...)
+	configuration, paused, err := s.withoutPausedTargets()
+	if err != nil {
+		return err
+	}
+	s.paused = paused
+	for _, target := range paused {
+		logger.Infof("Promotion to %s is paused until %s, skipping it.", target.name, target.until)
+	}
+
+	tags, names := PromotedTagsWithRequiredImages(configuration, opts...)
 	if len(names) == 0 {
 		logger.Info("Nothing to promote, skipping...")
 		return nil
 	}
 
+	if err := s.checkARTConsistency(tags); err != nil {
+		return fmt.Errorf("images to promote are not consistent with ART: %w", err)
+	}
+
 	logger.Infof("Promoting tags to %s: %s", s.targets(), strings.Join(sets.List(names), ", "))
 	pipeline := &imagev1.ImageStream{}
 	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{
@@ -92,6 +131,28 @@ func (s *promotionStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not resolve pipeline imagestream: %w", err)
 	}
 
+	mirrors := resolveExternalMirrors(externalMirrors(configuration, s.requiredImages, commitSha), pipeline)
+	if s.registry == api.QuayOpenShiftCIRepo && len(mirrors) != 0 {
+		logger.Warnf("Pushing to external registries is not supported when promoting to %s, skipping: %s", api.QuayOpenShiftCIRepo, strings.Join(externalMirrorTargets(mirrors), ", "))
+		mirrors = nil
+	}
+
+	if err := s.checkManifestLists(ctx, configuration, pipeline); err != nil {
+		return fmt.Errorf("images to promote do not cover the requested architectures: %w", err)
+	}
+
+	if err := s.checkCVEs(ctx, tags, pipeline); err != nil {
+		return fmt.Errorf("images to promote did not pass the CVE gate: %w", err)
+	}
+
+	if s.dryRun {
+		s.reportDryRun(ctx, tags, pipeline)
+		if len(mirrors) != 0 {
+			logger.Infof("Would push to external registries: %s", strings.Join(externalMirrorTargets(mirrors), ", "))
+		}
+		return nil
+	}
+
 	timeStr := time.Now().Format("20060102150405")
 	imageMirrorTarget, namespaces := getImageMirrorTarget(tags, pipeline, s.registry, timeStr, s.mirrorFunc)
 	if len(imageMirrorTarget) == 0 {
@@ -111,12 +172,80 @@ func (s *promotionStep) run(ctx context.Context) error {
 		version = "4.14"
 	}
 
-	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, timeStr, s.jobSpec.Namespace(), s.name, version, s.nodeArchitectures), false); err != nil {
+	for _, mirror := range mirrors {
+		if err := s.ensureMirrorCredentials(ctx, mirror.credentials); err != nil {
+			return fmt.Errorf("failed to set up the credentials to push to external registries: %w", err)
+		}
+	}
+
+	var diffs []imageDiff
+	if s.configuration.PromotionConfiguration.ImageDiffReport {
+		diffs = s.imageDiffCandidates(ctx, tags, pipeline)
+	}
+
+	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, mirrors, timeStr, s.jobSpec.Namespace(), s.name, version, s.nodeArchitectures), false); err != nil {
 		return fmt.Errorf("unable to run promotion pod: %w", err)
 	}
+	if err := s.pruneCommitTags(ctx); err != nil {
+		logger.WithError(err).Warn("Failed to remove the tags of older commits from the central registry.")
+	}
+	if len(diffs) > 0 {
+		s.reportImageDiffs(ctx, diffs, version)
+	}
 	return nil
 }
 
+// ARTConsistencyCheck verifies that the images promoted to the official
+// namespace are built with the same build root as ART uses. The promotion steps
+// pushing to different registries share it, so that ART is queried once and
+// none of the steps promotes the images before they passed.
+type ARTConsistencyCheck struct {
+	client    release.HTTPClient
+	endpoint  string
+	buildRoot *api.ImageStreamTagReference
+
+	once sync.Once
+	err  error
+}
+
+// NewARTConsistencyCheck creates a check querying the ART image metadata
+// endpoint with the client, comparing the build root the images are built
+// with to the one ART uses.
+func NewARTConsistencyCheck(client release.HTTPClient, endpoint string, buildRoot *api.ImageStreamTagReference) *ARTConsistencyCheck {
+	return &ARTConsistencyCheck{client: client, endpoint: endpoint, buildRoot: buildRoot}
+}
+
+// checkARTConsistency runs the ART consistency check before the images are
+// promoted, if requested. The promotion fails when the check was requested
+// but cannot run, as the images would otherwise be promoted unverified.
+func (s *promotionStep) checkARTConsistency(tags map[string][]api.ImageStreamTagReference) error {
+	if !s.configuration.PromotionConfiguration.ARTConsistencyCheck {
+		return nil
+	}
+	if s.artCheck == nil {
+		return errors.New("no ART image metadata endpoint is configured")
+	}
+	if s.artCheck.buildRoot == nil {
+		return errors.New("the ART consistency check requires a build root imported from an image stream tag")
+	}
+	components := map[string][]art.Component{}
+	for image, targets := range tags {
+		for _, tag := range targets {
+			if api.RefersToOfficialImage(tag.Namespace, api.WithoutOKD) {
+				components[image] = append(components[image], art.Component{Name: tag.Tag, Version: tag.Name})
+			}
+		}
+	}
+	if len(components) == 0 {
+		return nil
+	}
+	s.artCheck.once.Do(func() {
+		logrus.WithField("name", s.name).Infof("Verifying the build root of %d images against ART", len(components))
+		s.artCheck.err = art.CheckConsistency(s.artCheck.client, s.artCheck.endpoint, *s.artCheck.buildRoot, components)
+	})
+	return s.artCheck.err
+}
+
 func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Set[string]) error {
 	if len(namespaces) == 0 {
 		return nil
@@ -126,17 +255,10 @@ func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Se
 	if s.configuration.PromotionConfiguration.RegistryOverride != "" {
 		return nil
 	}
-	var dockercfg credentialprovider.DockerConfigJSON
-	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
-		return fmt.Errorf("failed to deserialize push secret: %w", err)
-	}
-
-	appCIDockercfg, hasAppCIDockercfg := dockercfg.Auths[api.ServiceDomainAPPCIRegistry]
-	if !hasAppCIDockercfg {
-		return fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+	appCIKubeconfig, err := s.appCIKubeconfig()
+	if err != nil {
+		return err
 	}
-
-	appCIKubeconfig := &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}
 	client, err := corev1client.NewForConfig(appCIKubeconfig)
 	if err != nil {
 		return fmt.Errorf("failed to construct kubeconfig: %w", err)
@@ -161,6 +283,54 @@ func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Se
 	return nil
 }
 
+// appCIKubeconfig uses the credentials for the central registry in the push
+// secret to access the cluster backing it.
+func (s *promotionStep) appCIKubeconfig() (*rest.Config, error) {
+	if s.pushSecret == nil {
+		return nil, errors.New("no push secret provided")
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+		return nil, fmt.Errorf("failed to deserialize push secret: %w", err)
+	}
+
+	appCIDockercfg, hasAppCIDockercfg := dockercfg.Auths[api.ServiceDomainAPPCIRegistry]
+	if !hasAppCIDockercfg {
+		return nil, fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+	}
+	return &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}, nil
+}
+
+// centralRegistryClient returns a client for the image streams backing the
+// central registry, or nil when the targets live in another registry.
+func (s *promotionStep) centralRegistryClient() ctrlruntimeclient.Client {
+	if s.registry != api.ServiceDomainAPPCIRegistry {
+		return nil
+	}
+	return s.appCIClient()
+}
+
+// appCIClient accesses the image streams in the central registry, whichever
+// registry the step promotes to.
+func (s *promotionStep) appCIClient() ctrlruntimeclient.Client {
+	if s.targetClient != nil {
+		return s.targetClient
+	}
+	logger := logrus.WithField("name", s.name)
+	config, err := s.appCIKubeconfig()
+	if err != nil {
+		logger.WithError(err).Warn("Cannot access the central registry.")
+		return nil
+	}
+	client, err := ctrlruntimeclient.New(config, ctrlruntimeclient.Options{})
+	if err != nil {
+		logger.WithError(err).Warn("Failed to construct a client for the central registry.")
+		return nil
+	}
+	s.targetClient = client
+	return client
+}
+
 func getImageMirrorTarget(tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream, registry string, time string, mirrorFunc func(source, target string, tag api.ImageStreamTagReference, time string, imageMirror map[string]string)) (map[string]string, sets.Set[string]) {
 	if pipeline == nil {
 		return nil, nil
@@ -213,7 +383,19 @@ func getMirrorCommand(registryConfig string, images []string, loglevel int) stri
 		loglevel, registryConfig, strings.Join(images, " "))
 }
 
-func getPromotionPod(imageMirrorTarget map[string]string, timeStr string, namespace string, name string, cliVersion string, nodeArchitectures []string) *coreapi.Pod {
+// retryMirrorCommand tries up to 5 times to mirror to the destination. The loop will exit early with 0 if successful on any
+// iteration. If all attempts fail, it will exit with non-zero value.
+func retryMirrorCommand(mirrorCommand string) string {
+	return fmt.Sprintf("for r in {1..5}; do echo Mirror attempt $r; %s && break; backoff=$(($RANDOM %% 120))s; echo Sleeping randomized $backoff before retry; sleep $backoff; done", mirrorCommand)
+}
+
+// requireMirrorCommand retries the mirroring like retryMirrorCommand, but exits with a non-zero value when all attempts
+// fail, so that the images are not pushed to external registries when their promotion failed.
+func requireMirrorCommand(mirrorCommand string) string {
+	return fmt.Sprintf("mirrored=false; for r in {1..5}; do echo Mirror attempt $r; %s && mirrored=true && break; backoff=$(($RANDOM %% 120))s; echo Sleeping randomized $backoff before retry; sleep $backoff; done; $mirrored", mirrorCommand)
+}
+
+func getPromotionPod(imageMirrorTarget map[string]string, mirrors []externalMirror, timeStr string, namespace string, name string, cliVersion string, nodeArchitectures []string) *coreapi.Pod {
 	keys := make([]string, 0, len(imageMirrorTarget))
 	for k := range imageMirrorTarget {
 		keys = append(keys, k)
@@ -232,10 +414,14 @@ func getPromotionPod(imageMirrorTarget map[string]string, timeStr string, namesp
 
 	registryConfig := filepath.Join(api.RegistryPushCredentialsCICentralSecretMountPath, coreapi.DockerConfigJsonKey)
 	command := []string{"/bin/sh", "-c"}
-	// Try up to 5 times to mirror to the destination. The loop will exit early with 0 if successful on any iteration. If all attempts fail,
-	// it will exit with non-zero value.
-	mirrorTagsCommand := fmt.Sprintf("for r in {1..5}; do echo Mirror attempt $r; %s && break; backoff=$(($RANDOM %% 120))s; echo Sleeping randomized $backoff before retry; sleep $backoff; done", getMirrorCommand(registryConfig, images, 10))
+	mirrorTagsCommand := retryMirrorCommand(getMirrorCommand(registryConfig, images, 10))
 	var args []string
+	if len(mirrors) != 0 {
+		// images are only pushed to external registries once they are promoted,
+		// and the first mirroring that fails has to fail the pod
+		mirrorTagsCommand = requireMirrorCommand(getMirrorCommand(registryConfig, images, 10))
+		args = append(args, "set -e")
+	}
 	if len(pruneImages) > 0 {
 		// See https://github.com/openshift/release/blob/2080ec4a49337c27577a4b2ff08a538e96436e65/hack/qci_registry_pruner.py for details.
 		// Note that we don't retry here and we ignore failures because (a) it may be the first time an image tag is
@@ -244,17 +430,42 @@ func getPromotionPod(imageMirrorTarget map[string]string, timeStr string, namesp
 		args = append(args, fmt.Sprintf("%s || true", getMirrorCommand(registryConfig, pruneImages, 10)))
 	}
 	args = append(args, mirrorTagsCommand)
-	args = []string{strings.Join(args, "\n")}
-
-	image := fmt.Sprintf("%s/%s/%s:cli", api.DomainForService(api.ServiceRegistry), "ocp", cliVersion)
-	nodeSelector := map[string]string{"kubernetes.io/arch": "amd64"}
-
-	archs := sets.New[string](nodeArchitectures...)
-	if !archs.Has("amd64") && archs.Has("arm64") {
-		image = fmt.Sprintf("%s/%s/4.14:cli", api.DomainForService(api.ServiceRegistry), "ocp-arm64")
-		nodeSelector = map[string]string{"kubernetes.io/arch": "arm64"}
+	volumeMounts := []coreapi.VolumeMount{
+		{
+			Name:      "push-secret",
+			MountPath: "/etc/push-secret",
+			ReadOnly:  true,
+		},
+	}
+	volumes := []coreapi.Volume{
+		{
+			Name: "push-secret",
+			VolumeSource: coreapi.VolumeSource{
+				Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
+			},
+		},
 	}
+	// images pushed to external registries are mirrored with their own
+	// credentials, after the promotion to the central registry succeeded
+	for i, mirror := range mirrors {
+		volume := fmt.Sprintf("mirror-credentials-%d", i)
+		mountPath := fmt.Sprintf("/etc/mirror-credentials/%d", i)
+		var mirrorImages []string
+		for _, target := range sets.List(sets.KeySet(mirror.images)) {
+			mirrorImages = append(mirrorImages, fmt.Sprintf("%s=%s", mirror.images[target], target))
+		}
+		args = append(args, requireMirrorCommand(getMirrorCommand(filepath.Join(mountPath, coreapi.DockerConfigJsonKey), mirrorImages, 10)))
+		volumeMounts = append(volumeMounts, coreapi.VolumeMount{Name: volume, MountPath: mountPath, ReadOnly: true})
+		volumes = append(volumes, coreapi.Volume{
+			Name: volume,
+			VolumeSource: coreapi.VolumeSource{
+				Secret: &coreapi.SecretVolumeSource{SecretName: mirrorCredentialsSecret(mirror.credentials)},
+			},
+		})
+	}
+	args = []string{strings.Join(args, "\n")}
 
+	image, nodeSelector := cliImage(cliVersion, nodeArchitectures)
 	return &coreapi.Pod{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      name,
@@ -266,31 +477,39 @@ func getPromotionPod(imageMirrorTarget map[string]string, timeStr string, namesp
 			RestartPolicy: coreapi.RestartPolicyNever,
 			Containers: []coreapi.Container{
 				{
-					Name:    "promotion",
-					Image:   image,
-					Command: command,
-					Args:    args,
-					VolumeMounts: []coreapi.VolumeMount{
-						{
-							Name:      "push-secret",
-							MountPath: "/etc/push-secret",
-							ReadOnly:  true,
-						},
-					},
-				},
-			},
-			Volumes: []coreapi.Volume{
-				{
-					Name: "push-secret",
-					VolumeSource: coreapi.VolumeSource{
-						Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
-					},
+					Name:         "promotion",
+					Image:        image,
+					Command:      command,
+					Args:         args,
+					VolumeMounts: volumeMounts,
 				},
 			},
+			Volumes: volumes,
 		},
 	}
 }
 
+// cliImage determines the image providing `oc` for the pods run by the
+// promotion and the node selector they need to run it.
+func cliImage(cliVersion string, nodeArchitectures []string) (string, map[string]string) {
+	image := fmt.Sprintf("%s/%s/%s:cli", api.DomainForService(api.ServiceRegistry), "ocp", cliVersion)
+	arch := promotionArchitecture(nodeArchitectures)
+	if arch == "arm64" {
+		image = fmt.Sprintf("%s/%s/4.14:cli", api.DomainForService(api.ServiceRegistry), "ocp-arm64")
+	}
+	return image, map[string]string{"kubernetes.io/arch": arch}
+}
+
+// promotionArchitecture is the architecture of the nodes the pods of the
+// promotion run on.
+func promotionArchitecture(nodeArchitectures []string) string {
+	archs := sets.New[string](nodeArchitectures...)
+	if !archs.Has("amd64") && archs.Has("arm64") {
+		return "arm64"
+	}
+	return "amd64"
+}
+
 // findDockerImageReference returns DockerImageReference, the string that can be used to pull this image,
 // to a tag if it exists in the ImageStream's Spec
 func findDockerImageReference(is *imagev1.ImageStream, tag string) string {
@@ -391,28 +610,7 @@ func PromotedTagsWithRequiredImages(configuration *api.ReleaseBuildConfiguration
 		tags, names := toPromote(target, configuration.Images, opts.requiredImages)
 		requiredImages.Insert(names.UnsortedList()...)
 		for dst, src := range tags {
-			var tag api.ImageStreamTagReference
-			if target.Name != "" {
-				tag = api.ImageStreamTagReference{
-					Namespace: target.Namespace,
-					Name:      target.Name,
-					Tag:       dst,
-				}
-			} else { // promotion.Tag must be set
-				tag = api.ImageStreamTagReference{
-					Namespace: target.Namespace,
-					Name:      dst,
-					Tag:       target.Tag,
-				}
-			}
-			promotedTags[src] = append(promotedTags[src], tag)
-			if target.TagByCommit && opts.commitSha != "" {
-				promotedTags[src] = append(promotedTags[src], api.ImageStreamTagReference{
-					Namespace: target.Namespace,
-					Name:      dst,
-					Tag:       opts.commitSha,
-				})
-			}
+			promotedTags[src] = append(promotedTags[src], targetTags(target, dst, opts.commitSha)...)
 		}
 	}
 	// promote the binary build if one exists and this isn't disabled
@@ -427,6 +625,32 @@ func PromotedTagsWithRequiredImages(configuration *api.ReleaseBuildConfiguration
 	return promotedTags, requiredImages
 }
 
+// targetTags returns the tags an image is promoted to by a target.
+func targetTags(target api.PromotionTarget, dst, commitSha string) []api.ImageStreamTagReference {
+	var tags []api.ImageStreamTagReference
+	if target.Name != "" {
+		tags = append(tags, api.ImageStreamTagReference{
+			Namespace: target.Namespace,
+			Name:      target.Name,
+			Tag:       dst,
+		})
+	} else { // promotion.Tag must be set
+		tags = append(tags, api.ImageStreamTagReference{
+			Namespace: target.Namespace,
+			Name:      dst,
+			Tag:       target.Tag,
+		})
+	}
+	if target.TagByCommit && commitSha != "" {
+		tags = append(tags, api.ImageStreamTagReference{
+			Namespace: target.Namespace,
+			Name:      dst,
+			Tag:       commitSha,
+		})
+	}
+	return tags
+}
+
 func (s *promotionStep) Requires() []api.StepLink {
 	return []api.StepLink{api.AllStepsLink()}
 }
@@ -470,6 +694,9 @@ func PromotionStep(
 	mirrorFunc func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string),
 	targetNameFunc func(string, api.PromotionTarget) string,
 	nodeArchitectures []string,
+	artCheck *ARTConsistencyCheck,
+	cveGate *CVEGate,
+	dryRun bool,
 ) api.Step {
 	return &promotionStep{
 		name:              name,
@@ -482,5 +709,9 @@ func PromotionStep(
 		mirrorFunc:        mirrorFunc,
 		targetNameFunc:    targetNameFunc,
 		nodeArchitectures: nodeArchitectures,
+		artCheck:          artCheck,
+		cveGate:           cveGate,
+		dryRun:            dryRun,
+		now:               time.Now,
 	}
 }
@@ -0,0 +1,351 @@
+package release
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/prow/pkg/secretutil"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+const (
+	// CVEGateReportArtifact holds the vulnerabilities the images to promote
+	// introduce compared to the images they replace.
+	CVEGateReportArtifact = "promotion-cve-gate.json"
+
+	// SeverityCritical is the severity of the vulnerabilities the CVE gate
+	// fails the promotion on.
+	SeverityCritical = "CRITICAL"
+
+	cveScanContainer = "cve-scan"
+	// cveScanDockerConfig is where the scan pod finds the credentials to
+	// pull the images it scans, from the registry of the build farm and from
+	// the central registry.
+	cveScanDockerConfig = "/etc/cve-scan-docker"
+)
+
+// Vulnerability is a vulnerability found in a package of an image.
+type Vulnerability struct {
+	ID       string `json:"id"`
+	Package  string `json:"package"`
+	Severity string `json:"severity"`
+}
+
+// ImageScanner finds the vulnerabilities of images.
+type ImageScanner interface {
+	// Scan lists the vulnerabilities of the severity found in the images,
+	// by pull spec. Images which could not be scanned are missing from the
+	// result.
+	Scan(ctx context.Context, severity string, pullSpecs []string) (map[string][]Vulnerability, error)
+}
+
+// CVEGate compares the critical vulnerabilities of the images to promote with
+// the ones of the images they replace in the central registry. The promotion
+// steps pushing to different registries share it, so that the images are
+// scanned once and none of the steps promotes them before they passed.
+type CVEGate struct {
+	scanner ImageScanner
+	waivers api.CVEWaivers
+
+	once sync.Once
+	err  error
+}
+
+// NewCVEGate creates a gate scanning images with the scanner and ignoring the
+// waived vulnerabilities.
+func NewCVEGate(scanner ImageScanner, waivers api.CVEWaivers) *CVEGate {
+	return &CVEGate{scanner: scanner, waivers: waivers}
+}
+
+// cveGateReport is the artifact written by the CVE gate.
+type cveGateReport struct {
+	Images []cveDiff `json:"images"`
+}
+
+// cveDiff holds the critical vulnerabilities an image promoted to a target
+// introduces compared to the image the target points to.
+type cveDiff struct {
+	Target   string          `json:"target"`
+	Previous string          `json:"previous"`
+	Current  string          `json:"current"`
+	New      []Vulnerability `json:"new,omitempty"`
+	Waived   []Vulnerability `json:"waived,omitempty"`
+
+	// image is the name of the promoted image, which waivers refer to
+	image            string
+	previousPullSpec string
+	currentPullSpec  string
+}
+
+// checkCVEs runs the CVE gate before the images are promoted, if requested.
+func (s *promotionStep) checkCVEs(ctx context.Context, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) error {
+	config := s.configuration.PromotionConfiguration.CVEGate
+	if config == nil {
+		return nil
+	}
+	logger := logrus.WithField("name", s.name)
+	if s.cveGate == nil {
+		if config.WarnOnly {
+			logger.Warn("No image scanner configured, skipping the CVE gate.")
+			return nil
+		}
+		return errors.New("promotion.cve_gate is configured but no image scanner was given with --cve-scanner-image")
+	}
+	s.cveGate.once.Do(func() {
+		s.cveGate.err = s.cveGate.check(ctx, s, tags, pipeline)
+	})
+	if s.cveGate.err != nil && config.WarnOnly {
+		logger.WithError(s.cveGate.err).Warn("The images to promote did not pass the CVE gate.")
+		return nil
+	}
+	return s.cveGate.err
+}
+
+func (g *CVEGate) check(ctx context.Context, s *promotionStep, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) error {
+	diffs, err := cveGateCandidates(ctx, s, tags, pipeline)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	pullSpecs := sets.New[string]()
+	for _, diff := range diffs {
+		pullSpecs.Insert(diff.previousPullSpec, diff.currentPullSpec)
+	}
+	logrus.WithField("name", s.name).Infof("Scanning %d images for critical vulnerabilities", pullSpecs.Len())
+	found, err := g.scanner.Scan(ctx, SeverityCritical, sets.List(pullSpecs))
+	if err != nil {
+		return fmt.Errorf("could not scan the images to promote: %w", err)
+	}
+	now := s.now()
+	var failures []string
+	for i := range diffs {
+		previous, scannedPrevious := found[diffs[i].previousPullSpec]
+		current, scannedCurrent := found[diffs[i].currentPullSpec]
+		if !scannedPrevious || !scannedCurrent {
+			return fmt.Errorf("could not scan the images promoted to %s", diffs[i].Target)
+		}
+		compareVulnerabilities(&diffs[i], previous, current, g.waivers, now)
+		if len(diffs[i].New) > 0 {
+			var ids []string
+			for _, vulnerability := range diffs[i].New {
+				ids = append(ids, vulnerability.ID)
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", diffs[i].Target, strings.Join(ids, ", ")))
+		}
+	}
+	saveCVEGateReport(s.name, diffs)
+	if len(failures) > 0 {
+		return fmt.Errorf("images to promote introduce critical vulnerabilities which are not waived in %s: %s", api.CVEWaiversFileName, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// cveGateCandidates determines the targets the promotion will overwrite and
+// the images they point to in the central registry.
+func cveGateCandidates(ctx context.Context, s *promotionStep, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) ([]cveDiff, error) {
+	targets := s.appCIClient()
+	if targets == nil {
+		return nil, errors.New("cannot access the central registry to compare the images to promote with the ones they replace")
+	}
+	var diffs []cveDiff
+	for _, src := range sortedKeys(tags) {
+		dockerImageReference := findDockerImageReference(pipeline, src)
+		if dockerImageReference == "" {
+			continue
+		}
+		current := digestOf(dockerImageReference)
+		// the scan pod pulls the image through the public route of the
+		// registry of the build farm, as it does not trust its service CA
+		currentPullSpec := getPublicImageReference(dockerImageReference, pipeline.Status.PublicDockerImageRepository)
+		for _, dst := range tags[src] {
+			previous, known := s.currentDigest(ctx, targets, dst)
+			if !known {
+				return nil, fmt.Errorf("could not determine the image %s points to", dst.ISTagName())
+			}
+			if previous == "" || previous == current {
+				continue
+			}
+			diffs = append(diffs, cveDiff{
+				Target:           fmt.Sprintf("%s/%s", api.ServiceDomainAPPCIRegistry, dst.ISTagName()),
+				Previous:         previous,
+				Current:          current,
+				image:            src,
+				previousPullSpec: promotedPullSpec(api.ServiceDomainAPPCIRegistry, dst, previous),
+				currentPullSpec:  currentPullSpec,
+			})
+		}
+	}
+	return diffs, nil
+}
+
+// compareVulnerabilities records the vulnerabilities found in the current
+// image but not in the previous one, separating the waived ones.
+func compareVulnerabilities(diff *cveDiff, previous, current []Vulnerability, waivers api.CVEWaivers, now time.Time) {
+	known := sets.New[string]()
+	for _, vulnerability := range previous {
+		known.Insert(vulnerability.ID)
+	}
+	reported := sets.New[string]()
+	for _, vulnerability := range current {
+		if known.Has(vulnerability.ID) || reported.Has(vulnerability.ID) {
+			continue
+		}
+		reported.Insert(vulnerability.ID)
+		if waivers.Waives(diff.image, vulnerability.ID, now) {
+			diff.Waived = append(diff.Waived, vulnerability)
+		} else {
+			diff.New = append(diff.New, vulnerability)
+		}
+	}
+}
+
+func saveCVEGateReport(name string, diffs []cveDiff) {
+	logger := logrus.WithField("name", name)
+	data, err := json.MarshalIndent(cveGateReport{Images: diffs}, "", "  ")
+	if err != nil {
+		logger.WithError(err).Warn("Failed to serialize the CVE gate report.")
+		return
+	}
+	if err := api.SaveArtifact(secretutil.NewCensorer(), CVEGateReportArtifact, data); err != nil {
+		logger.WithError(err).Warn("Failed to save the CVE gate report.")
+	}
+}
+
+// podImageScanner scans images with trivy, in a pod in the namespace of the
+// job.
+type podImageScanner struct {
+	client            kubernetes.PodClient
+	jobSpec           *api.JobSpec
+	image             string
+	nodeArchitectures []string
+}
+
+// NewPodImageScanner creates a scanner running trivy from the image in a pod.
+func NewPodImageScanner(client kubernetes.PodClient, jobSpec *api.JobSpec, image string, nodeArchitectures []string) ImageScanner {
+	return &podImageScanner{client: client, jobSpec: jobSpec, image: image, nodeArchitectures: nodeArchitectures}
+}
+
+func (s *podImageScanner) Scan(ctx context.Context, severity string, pullSpecs []string) (map[string][]Vulnerability, error) {
+	pod := getCVEScanPod(pullSpecs, severity, s.jobSpec.Namespace(), fmt.Sprintf("%s-cve-scan", api.PromotionStepName), s.image, s.nodeArchitectures)
+	if _, err := steps.RunPod(ctx, s.client, pod, true); err != nil {
+		return nil, fmt.Errorf("unable to run CVE scan pod: %w", err)
+	}
+	logs, err := s.client.StreamLogs(ctx, pod.Namespace, pod.Name, &coreapi.PodLogOptions{Container: cveScanContainer})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the logs of the CVE scan pod: %w", err)
+	}
+	defer logs.Close()
+	return parseVulnerabilities(logs, sets.New[string](pullSpecs...))
+}
+
+// parseVulnerabilities parses the `<pull spec> <id> <package> <severity>`
+// lines printed for the vulnerabilities of each image and the
+// `<pull spec> scanned` line printed once it was scanned, ignoring other
+// output.
+func parseVulnerabilities(r io.Reader, pullSpecs sets.Set[string]) (map[string][]Vulnerability, error) {
+	found := map[string][]Vulnerability{}
+	scanned := sets.New[string]()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !pullSpecs.Has(fields[0]) {
+			continue
+		}
+		switch {
+		case len(fields) == 2 && fields[1] == "scanned":
+			scanned.Insert(fields[0])
+		case len(fields) == 4:
+			found[fields[0]] = append(found[fields[0]], Vulnerability{ID: fields[1], Package: fields[2], Severity: fields[3]})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the scan results: %w", err)
+	}
+	ret := map[string][]Vulnerability{}
+	for pullSpec := range scanned {
+		ret[pullSpec] = append([]Vulnerability{}, found[pullSpec]...)
+	}
+	return ret, nil
+}
+
+func getCVEScanPod(pullSpecs []string, severity, namespace, name, image string, nodeArchitectures []string) *coreapi.Pod {
+	nodeSelector := map[string]string{"kubernetes.io/arch": "amd64"}
+	if archs := sets.New[string](nodeArchitectures...); archs.Len() > 0 && !archs.Has("amd64") {
+		nodeSelector["kubernetes.io/arch"] = sets.List(archs)[0]
+	}
+	// the amd64 image of manifest lists is scanned, as all promoted images
+	// are built for it
+	template := `{{ range . }}{{ range .Vulnerabilities }}{{ .VulnerabilityID }} {{ .PkgName }} {{ .Severity }}{{ "\n" }}{{ end }}{{ end }}`
+	script := []string{
+		"set -uo pipefail",
+		"scan() {",
+		fmt.Sprintf(`  if ! out="$( trivy image --quiet --cache-dir /tmp/trivy --scanners vuln --platform linux/amd64 --severity %s --format template --template '%s' "$1" )"; then`, severity, template),
+		`    echo "Failed to scan $1" >&2`,
+		"    return",
+		"  fi",
+		`  while read -r line; do`,
+		`    if [ -n "${line}" ]; then echo "$1 ${line}"; fi`,
+		`  done <<<"${out}"`,
+		`  echo "$1 scanned"`,
+		"}",
+	}
+	for _, pullSpec := range pullSpecs {
+		script = append(script, fmt.Sprintf("scan '%s'", pullSpec))
+	}
+
+	return &coreapi.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: coreapi.PodSpec{
+			NodeSelector:  nodeSelector,
+			RestartPolicy: coreapi.RestartPolicyNever,
+			Containers: []coreapi.Container{
+				{
+					Name:    cveScanContainer,
+					Image:   image,
+					Command: []string{"/bin/bash", "-c", strings.Join(script, "\n")},
+					Env:     []coreapi.EnvVar{{Name: "DOCKER_CONFIG", Value: cveScanDockerConfig}},
+					VolumeMounts: []coreapi.VolumeMount{
+						{
+							Name:      "pull-secret",
+							MountPath: cveScanDockerConfig,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []coreapi.Volume{
+				{
+					Name: "pull-secret",
+					VolumeSource: coreapi.VolumeSource{
+						Secret: &coreapi.SecretVolumeSource{
+							SecretName: api.RegistryPullCredentialsSecret,
+							Items:      []coreapi.KeyToPath{{Key: coreapi.DockerConfigJsonKey, Path: "config.json"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
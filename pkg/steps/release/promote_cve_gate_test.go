@@ -0,0 +1,228 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeImageScanner struct {
+	found map[string][]Vulnerability
+	err   error
+	scans int
+}
+
+func (s *fakeImageScanner) Scan(_ context.Context, _ string, pullSpecs []string) (map[string][]Vulnerability, error) {
+	s.scans++
+	if s.err != nil {
+		return nil, s.err
+	}
+	ret := map[string][]Vulnerability{}
+	for _, pullSpec := range pullSpecs {
+		if found, ok := s.found[pullSpec]; ok {
+			ret[pullSpec] = found
+		}
+	}
+	return ret, nil
+}
+
+func TestCheckCVEs(t *testing.T) {
+	const (
+		currentA  = "registry.build01.ci.openshift.org/ci-op-y2n8rsh3/pipeline@sha256:aaa"
+		previousA = "registry.ci.openshift.org/ocp/4.16@sha256:old-a"
+	)
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	// the images are scanned through the public route of the registry
+	pipeline := &imagev1.ImageStream{
+		Status: imagev1.ImageStreamStatus{
+			PublicDockerImageRepository: "registry.build01.ci.openshift.org/ci-op-y2n8rsh3/pipeline",
+			Tags: []imagev1.NamedTagEventList{
+				{Tag: "a", Items: []imagev1.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:aaa"}}},
+				{Tag: "b", Items: []imagev1.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:bbb"}}},
+			},
+		},
+	}
+	tags := map[string][]api.ImageStreamTagReference{
+		"a": {{Namespace: "ocp", Name: "4.16", Tag: "a"}},
+		"b": {{Namespace: "ocp", Name: "4.16", Tag: "b"}},
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add imagev1 to scheme: %v", err)
+	}
+	// b was never promoted, so it has nothing to be compared with
+	targets := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(&imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.16:a"},
+		Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: "sha256:old-a"}},
+	}).Build()
+	scanned := map[string][]Vulnerability{
+		previousA: {{ID: "CVE-2024-0001", Package: "openssl", Severity: SeverityCritical}},
+		currentA: {
+			{ID: "CVE-2024-0001", Package: "openssl", Severity: SeverityCritical},
+			{ID: "CVE-2024-0002", Package: "glibc", Severity: SeverityCritical},
+			{ID: "CVE-2024-0002", Package: "glibc-common", Severity: SeverityCritical},
+		},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		gate          *api.CVEGate
+		scanner       *fakeImageScanner
+		waivers       api.CVEWaivers
+		noScanner     bool
+		expectedErr   error
+		expectedScans int
+	}{
+		{
+			name:          "new critical vulnerability fails the promotion",
+			gate:          &api.CVEGate{},
+			scanner:       &fakeImageScanner{found: scanned},
+			expectedErr:   errors.New("images to promote introduce critical vulnerabilities which are not waived in .ci-operator-cve-waivers.yaml: registry.ci.openshift.org/ocp/4.16:a: CVE-2024-0002"),
+			expectedScans: 1,
+		},
+		{
+			name:          "warn only",
+			gate:          &api.CVEGate{WarnOnly: true},
+			scanner:       &fakeImageScanner{found: scanned},
+			expectedScans: 1,
+		},
+		{
+			name:          "waived vulnerability",
+			gate:          &api.CVEGate{},
+			scanner:       &fakeImageScanner{found: scanned},
+			waivers:       api.CVEWaivers{Waivers: []api.CVEWaiver{{ID: "CVE-2024-0002", Images: []string{"a"}, Reason: "not exploitable", Until: "2024-07-01T00:00:00Z"}}},
+			expectedScans: 1,
+		},
+		{
+			name:          "expired waiver",
+			gate:          &api.CVEGate{},
+			scanner:       &fakeImageScanner{found: scanned},
+			waivers:       api.CVEWaivers{Waivers: []api.CVEWaiver{{ID: "CVE-2024-0002", Reason: "not exploitable", Until: "2024-06-01T00:00:00Z"}}},
+			expectedErr:   errors.New("images to promote introduce critical vulnerabilities which are not waived in .ci-operator-cve-waivers.yaml: registry.ci.openshift.org/ocp/4.16:a: CVE-2024-0002"),
+			expectedScans: 1,
+		},
+		{
+			name:          "image which could not be scanned",
+			gate:          &api.CVEGate{},
+			scanner:       &fakeImageScanner{found: map[string][]Vulnerability{previousA: nil}},
+			expectedErr:   errors.New("could not scan the images promoted to registry.ci.openshift.org/ocp/4.16:a"),
+			expectedScans: 1,
+		},
+		{
+			name:          "scanner failure",
+			gate:          &api.CVEGate{},
+			scanner:       &fakeImageScanner{err: errors.New("pod failed")},
+			expectedErr:   errors.New("could not scan the images to promote: pod failed"),
+			expectedScans: 1,
+		},
+		{
+			name:        "no scanner configured",
+			gate:        &api.CVEGate{},
+			noScanner:   true,
+			expectedErr: errors.New("promotion.cve_gate is configured but no image scanner was given with --cve-scanner-image"),
+		},
+		{
+			name:      "no scanner configured with warn only",
+			gate:      &api.CVEGate{WarnOnly: true},
+			noScanner: true,
+		},
+		{
+			name:    "gate not requested",
+			scanner: &fakeImageScanner{found: scanned},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gate *CVEGate
+			if !tc.noScanner {
+				gate = NewCVEGate(tc.scanner, tc.waivers)
+			}
+			configuration := &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{CVEGate: tc.gate}}
+			// the steps promoting to the central registry and to quay.io
+			// share the gate
+			for _, registry := range []string{api.ServiceDomainAPPCIRegistry, api.QuayOpenShiftCIRepo} {
+				s := &promotionStep{
+					name:          "promotion",
+					configuration: configuration,
+					registry:      registry,
+					cveGate:       gate,
+					targetClient:  targets,
+					now:           func() time.Time { return now },
+				}
+				err := s.checkCVEs(context.Background(), tags, pipeline)
+				if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+					t.Errorf("%s: unexpected error: %s", registry, diff)
+				}
+			}
+			if tc.scanner != nil && tc.scanner.scans != tc.expectedScans {
+				t.Errorf("expected %d scans, got %d", tc.expectedScans, tc.scanner.scans)
+			}
+		})
+	}
+}
+
+func TestCompareVulnerabilities(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	diff := cveDiff{image: "a"}
+	compareVulnerabilities(&diff,
+		[]Vulnerability{{ID: "CVE-2024-0001", Package: "openssl", Severity: SeverityCritical}},
+		[]Vulnerability{
+			{ID: "CVE-2024-0001", Package: "openssl", Severity: SeverityCritical},
+			{ID: "CVE-2024-0002", Package: "glibc", Severity: SeverityCritical},
+			{ID: "CVE-2024-0003", Package: "curl", Severity: SeverityCritical},
+		},
+		api.CVEWaivers{Waivers: []api.CVEWaiver{{ID: "CVE-2024-0003", Images: []string{"a"}, Reason: "fixed upstream soon"}}},
+		now,
+	)
+	expected := cveDiff{
+		New:    []Vulnerability{{ID: "CVE-2024-0002", Package: "glibc", Severity: SeverityCritical}},
+		Waived: []Vulnerability{{ID: "CVE-2024-0003", Package: "curl", Severity: SeverityCritical}},
+		image:  "a",
+	}
+	if d := cmp.Diff(expected, diff, cmp.AllowUnexported(cveDiff{})); d != "" {
+		t.Errorf("unexpected diff: %s", d)
+	}
+}
+
+func TestParseVulnerabilities(t *testing.T) {
+	logs := `registry.ci.openshift.org/ocp/4.16@sha256:old CVE-2024-0001 openssl CRITICAL
+registry.ci.openshift.org/ocp/4.16@sha256:old scanned
+registry.ci.openshift.org/ocp/4.16@sha256:clean scanned
+registry.ci.openshift.org/ocp/4.16@sha256:new CVE-2024-0002 glibc CRITICAL
+Failed to scan registry.ci.openshift.org/ocp/4.16@sha256:new
+registry.ci.openshift.org/other@sha256:old CVE-2024-0001 openssl CRITICAL
+`
+	pullSpecs := sets.New[string]("registry.ci.openshift.org/ocp/4.16@sha256:old", "registry.ci.openshift.org/ocp/4.16@sha256:new", "registry.ci.openshift.org/ocp/4.16@sha256:clean")
+	found, err := parseVulnerabilities(strings.NewReader(logs), pullSpecs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]Vulnerability{
+		"registry.ci.openshift.org/ocp/4.16@sha256:old":   {{ID: "CVE-2024-0001", Package: "openssl", Severity: SeverityCritical}},
+		"registry.ci.openshift.org/ocp/4.16@sha256:clean": {},
+	}
+	if d := cmp.Diff(expected, found); d != "" {
+		t.Errorf("unexpected vulnerabilities: %s", d)
+	}
+}
+
+func TestGetCVEScanPod(t *testing.T) {
+	pod := getCVEScanPod([]string{
+		"registry.build01.ci.openshift.org/ci-op-y2n8rsh3/pipeline@sha256:new",
+		"registry.ci.openshift.org/ocp/4.16@sha256:old",
+	}, SeverityCritical, "ci-op-y2n8rsh3", "promotion-cve-scan", "quay.io/aquasecurity/trivy:0.50.1", []string{"arm64"})
+	testhelper.CompareWithFixture(t, pod)
+}
@@ -0,0 +1,130 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// promotionChange is a tag the promotion would push to.
+type promotionChange struct {
+	source string
+	target string
+	// current is the digest the target points to, empty if it does not exist
+	current string
+	// currentKnown is false when the state of the target could not be read
+	currentKnown bool
+	new          string
+}
+
+func (c promotionChange) String() string {
+	switch {
+	case !c.currentKnown:
+		return fmt.Sprintf("%s would point to %s (from %s), its current digest is unknown", c.target, c.new, c.source)
+	case c.current == "":
+		return fmt.Sprintf("%s would be created with %s (from %s)", c.target, c.new, c.source)
+	case c.current == c.new:
+		return fmt.Sprintf("%s is up to date with %s (from %s)", c.target, c.new, c.source)
+	default:
+		return fmt.Sprintf("%s would be overwritten from %s to %s (from %s)", c.target, c.current, c.new, c.source)
+	}
+}
+
+// reportDryRun logs the tags the promotion would create or overwrite instead
+// of pushing them.
+func (s *promotionStep) reportDryRun(ctx context.Context, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) {
+	var targets ctrlruntimeclient.Reader
+	if client := s.centralRegistryClient(); client != nil {
+		targets = client
+	}
+	changes, missing := s.promotionChanges(ctx, tags, pipeline, targets)
+	logrus.WithField("name", s.name).Info(describePromotionChanges(changes, missing))
+}
+
+// promotionChanges determines the tags the promotion would push to and the
+// digests they currently point to. Source tags missing from the pipeline
+// image stream are returned separately, as they would be skipped.
+func (s *promotionStep) promotionChanges(ctx context.Context, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream, targets ctrlruntimeclient.Reader) ([]promotionChange, []string) {
+	var changes []promotionChange
+	var missing []string
+	timeStr := time.Now().Format("20060102150405")
+	for _, src := range sortedKeys(tags) {
+		dockerImageReference := findDockerImageReference(pipeline, src)
+		if dockerImageReference == "" {
+			missing = append(missing, src)
+			continue
+		}
+		source := getPublicImageReference(dockerImageReference, pipeline.Status.PublicDockerImageRepository)
+		for _, dst := range tags[src] {
+			change := promotionChange{source: src, new: digestOf(source)}
+			change.current, change.currentKnown = s.currentDigest(ctx, targets, dst)
+			mirror := map[string]string{}
+			s.mirrorFunc(source, fmt.Sprintf("%s/%s", s.registry, dst.ISTagName()), dst, timeStr, mirror)
+			for _, target := range sortedKeys(mirror) {
+				// mirroring functions may add bookkeeping tags copying the target itself
+				if mirror[target] != source {
+					continue
+				}
+				change.target = target
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes, missing
+}
+
+// currentDigest resolves the image a promotion target currently points to.
+func (s *promotionStep) currentDigest(ctx context.Context, targets ctrlruntimeclient.Reader, tag api.ImageStreamTagReference) (string, bool) {
+	if targets == nil {
+		return "", false
+	}
+	ist := &imagev1.ImageStreamTag{}
+	if err := targets.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: tag.Namespace, Name: fmt.Sprintf("%s:%s", tag.Name, tag.Tag)}, ist); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", true
+		}
+		logrus.WithField("name", s.name).WithError(err).Debugf("Failed to get the promotion target %s.", tag.ISTagName())
+		return "", false
+	}
+	return ist.Image.Name, true
+}
+
+func describePromotionChanges(changes []promotionChange, missing []string) string {
+	lines := []string{fmt.Sprintf("Promotion dry run, %d tags would be pushed:", len(changes))}
+	for _, change := range changes {
+		lines = append(lines, "  "+change.String())
+	}
+	if len(missing) > 0 {
+		lines = append(lines, fmt.Sprintf("Images missing from the pipeline image stream would not be promoted: %s", strings.Join(missing, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// digestOf returns the digest of a pull spec, or the pull spec itself when
+// it does not refer to one.
+func digestOf(pullSpec string) string {
+	if i := strings.LastIndex(pullSpec, "@"); i != -1 {
+		return pullSpec[i+1:]
+	}
+	return pullSpec
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
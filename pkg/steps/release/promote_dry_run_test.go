@@ -0,0 +1,109 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestPromotionChanges(t *testing.T) {
+	pipeline := &imagev1.ImageStream{
+		Status: imagev1.ImageStreamStatus{
+			PublicDockerImageRepository: "registry.build01.ci.openshift.org/ci-op-y2n8rsh3/pipeline",
+			Tags: []imagev1.NamedTagEventList{
+				{Tag: "a", Items: []imagev1.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:aaa"}}},
+				{Tag: "b", Items: []imagev1.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:bbb"}}},
+				{Tag: "c", Items: []imagev1.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:ccc"}}},
+			},
+		},
+	}
+	tags := map[string][]api.ImageStreamTagReference{
+		"a":       {{Namespace: "ocp", Name: "4.16", Tag: "a"}},
+		"b":       {{Namespace: "ocp", Name: "4.16", Tag: "b"}},
+		"c":       {{Namespace: "ocp", Name: "4.16", Tag: "c"}},
+		"missing": {{Namespace: "ocp", Name: "4.16", Tag: "missing"}},
+	}
+	istag := func(name, digest string) ctrlruntimeclient.Object {
+		return &imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: name},
+			Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: digest}},
+		}
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add imagev1 to scheme: %v", err)
+	}
+	targets := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+		istag("4.16:b", "sha256:old"),
+		istag("4.16:c", "sha256:ccc"),
+	).Build()
+
+	for _, tc := range []struct {
+		name            string
+		registry        string
+		mirrorFunc      func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string)
+		targets         ctrlruntimeclient.Reader
+		expectedChanges []promotionChange
+	}{
+		{
+			name:       "central registry",
+			registry:   api.ServiceDomainAPPCIRegistry,
+			mirrorFunc: api.DefaultMirrorFunc,
+			targets:    targets,
+			expectedChanges: []promotionChange{
+				{source: "a", target: "registry.ci.openshift.org/ocp/4.16:a", currentKnown: true, new: "sha256:aaa"},
+				{source: "b", target: "registry.ci.openshift.org/ocp/4.16:b", current: "sha256:old", currentKnown: true, new: "sha256:bbb"},
+				{source: "c", target: "registry.ci.openshift.org/ocp/4.16:c", current: "sha256:ccc", currentKnown: true, new: "sha256:ccc"},
+			},
+		},
+		{
+			name:       "quay ignores the pruning tags",
+			registry:   api.QuayOpenShiftCIRepo,
+			mirrorFunc: api.QuayMirrorFunc,
+			expectedChanges: []promotionChange{
+				{source: "a", target: "quay.io/openshift/ci:ocp_4.16_a", new: "sha256:aaa"},
+				{source: "b", target: "quay.io/openshift/ci:ocp_4.16_b", new: "sha256:bbb"},
+				{source: "c", target: "quay.io/openshift/ci:ocp_4.16_c", new: "sha256:ccc"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &promotionStep{name: "promotion", registry: tc.registry, mirrorFunc: tc.mirrorFunc}
+			changes, missing := s.promotionChanges(context.Background(), tags, pipeline, tc.targets)
+			if diff := cmp.Diff(tc.expectedChanges, changes, cmp.AllowUnexported(promotionChange{})); diff != "" {
+				t.Errorf("unexpected changes: %s", diff)
+			}
+			if diff := cmp.Diff([]string{"missing"}, missing); diff != "" {
+				t.Errorf("unexpected missing images: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDescribePromotionChanges(t *testing.T) {
+	changes := []promotionChange{
+		{source: "a", target: "registry.ci.openshift.org/ocp/4.16:a", currentKnown: true, new: "sha256:aaa"},
+		{source: "b", target: "registry.ci.openshift.org/ocp/4.16:b", current: "sha256:old", currentKnown: true, new: "sha256:bbb"},
+		{source: "c", target: "registry.ci.openshift.org/ocp/4.16:c", current: "sha256:ccc", currentKnown: true, new: "sha256:ccc"},
+		{source: "d", target: "quay.io/openshift/ci:ocp_4.16_d", new: "sha256:ddd"},
+	}
+	expected := `Promotion dry run, 4 tags would be pushed:
+  registry.ci.openshift.org/ocp/4.16:a would be created with sha256:aaa (from a)
+  registry.ci.openshift.org/ocp/4.16:b would be overwritten from sha256:old to sha256:bbb (from b)
+  registry.ci.openshift.org/ocp/4.16:c is up to date with sha256:ccc (from c)
+  quay.io/openshift/ci:ocp_4.16_d would point to sha256:ddd (from d), its current digest is unknown
+Images missing from the pipeline image stream would not be promoted: missing`
+	if diff := cmp.Diff(expected, describePromotionChanges(changes, []string{"missing"})); diff != "" {
+		t.Errorf("unexpected description: %s", diff)
+	}
+}
@@ -0,0 +1,392 @@
+package release
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/prow/pkg/secretutil"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+const (
+	// ImageDiffReportArtifact holds the comparison of the promoted images
+	// with the ones they replaced.
+	ImageDiffReportArtifact = "promotion-image-diff.json"
+
+	imageDiffContainer = "image-diff"
+)
+
+// imageDiffReport is the artifact written after a promotion requesting it.
+type imageDiffReport struct {
+	Images []imageDiff `json:"images"`
+}
+
+// imageDiff compares an image promoted to a target with the image the target
+// pointed to before the promotion.
+type imageDiff struct {
+	Target   string `json:"target"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+	// the sizes are the sums of the compressed layers, unset when unknown
+	PreviousSize int64 `json:"previous_size,omitempty"`
+	CurrentSize  int64 `json:"current_size,omitempty"`
+	SizeDelta    int64 `json:"size_delta,omitempty"`
+	// BaseImageChanged is unset when the base image of the promoted image
+	// is not known
+	BaseImageChanged *bool `json:"base_image_changed,omitempty"`
+	// the packages are compared when both images have an RPM database
+	AddedPackages   []string        `json:"added_packages,omitempty"`
+	RemovedPackages []string        `json:"removed_packages,omitempty"`
+	UpdatedPackages []packageUpdate `json:"updated_packages,omitempty"`
+
+	tag api.ImageStreamTagReference
+	// baseLayers are the layers of the pipeline image the promoted image
+	// was built from
+	baseLayers []string
+}
+
+// packageUpdate is a package whose version changed, packages are identified
+// by their name and architecture.
+type packageUpdate struct {
+	Name     string `json:"name"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+func (d imageDiff) String() string {
+	changes := []string{}
+	if d.PreviousSize != 0 && d.CurrentSize != 0 {
+		changes = append(changes, fmt.Sprintf("size %+.1f MB", float64(d.SizeDelta)/1e6))
+	}
+	if d.BaseImageChanged != nil && *d.BaseImageChanged {
+		changes = append(changes, "base image changed")
+	}
+	if len(d.AddedPackages)+len(d.RemovedPackages)+len(d.UpdatedPackages) > 0 {
+		changes = append(changes, fmt.Sprintf("%d packages added, %d removed, %d updated", len(d.AddedPackages), len(d.RemovedPackages), len(d.UpdatedPackages)))
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "no notable changes")
+	}
+	return fmt.Sprintf("%s changed from %s to %s: %s", d.Target, d.Previous, d.Current, strings.Join(changes, ", "))
+}
+
+// imageDiffCandidates determines the targets the promotion will overwrite,
+// which must be done before the promotion as it moves the tags.
+func (s *promotionStep) imageDiffCandidates(ctx context.Context, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) []imageDiff {
+	targets := s.centralRegistryClient()
+	if targets == nil {
+		logrus.WithField("name", s.name).Warn("Promoted images can only be compared with images in the central registry, skipping the image diff report.")
+		return nil
+	}
+	var diffs []imageDiff
+	for _, src := range sortedKeys(tags) {
+		dockerImageReference := findDockerImageReference(pipeline, src)
+		if dockerImageReference == "" {
+			continue
+		}
+		current := digestOf(dockerImageReference)
+		var baseLayers []string
+		for _, dst := range tags[src] {
+			previous, known := s.currentDigest(ctx, targets, dst)
+			if !known || previous == "" || previous == current {
+				continue
+			}
+			if baseLayers == nil {
+				baseLayers = s.baseLayers(ctx, src)
+			}
+			diffs = append(diffs, imageDiff{
+				Target:     fmt.Sprintf("%s/%s", s.registry, dst.ISTagName()),
+				Previous:   previous,
+				Current:    current,
+				tag:        dst,
+				baseLayers: baseLayers,
+			})
+		}
+	}
+	return diffs
+}
+
+// baseLayers resolves the layers of the pipeline image a promoted image was
+// built from, if it was built by this configuration.
+func (s *promotionStep) baseLayers(ctx context.Context, src string) []string {
+	for _, image := range s.configuration.Images {
+		if string(image.To) != src || image.From == "" {
+			continue
+		}
+		ist := &imagev1.ImageStreamTag{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{
+			Namespace: s.jobSpec.Namespace(),
+			Name:      fmt.Sprintf("%s:%s", api.PipelineImageStream, image.From),
+		}, ist); err != nil {
+			logrus.WithField("name", s.name).WithError(err).Debugf("Failed to get the base image of %s.", src)
+			return []string{}
+		}
+		digest := platformManifest(&ist.Image, promotionArchitecture(s.nodeArchitectures))
+		if digest == "" {
+			return layerNames(&ist.Image)
+		}
+		isi := &imagev1.ImageStreamImage{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{
+			Namespace: s.jobSpec.Namespace(),
+			Name:      fmt.Sprintf("%s@%s", api.PipelineImageStream, digest),
+		}, isi); err != nil {
+			logrus.WithField("name", s.name).WithError(err).Debugf("Failed to get the base image of %s for the promotion architecture.", src)
+			return []string{}
+		}
+		return layerNames(&isi.Image)
+	}
+	return []string{}
+}
+
+// reportImageDiffs compares the promoted images with the ones they replaced
+// and saves the report as an artifact. Failures only degrade the report.
+func (s *promotionStep) reportImageDiffs(ctx context.Context, diffs []imageDiff, cliVersion string) {
+	logger := logrus.WithField("name", s.name)
+	targets := s.centralRegistryClient()
+	for i := range diffs {
+		previous := s.promotedImage(ctx, targets, diffs[i].tag, diffs[i].Previous)
+		current := s.promotedImage(ctx, targets, diffs[i].tag, diffs[i].Current)
+		compareImages(&diffs[i], previous, current)
+	}
+
+	packages, err := s.imagePackages(ctx, diffs, cliVersion)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to list the packages of the promoted images, they will not be compared.")
+	}
+	for i := range diffs {
+		comparePackages(&diffs[i],
+			packages[promotedPullSpec(s.registry, diffs[i].tag, diffs[i].Previous)],
+			packages[promotedPullSpec(s.registry, diffs[i].tag, diffs[i].Current)])
+		logger.Info(diffs[i].String())
+	}
+
+	data, err := json.MarshalIndent(imageDiffReport{Images: diffs}, "", "  ")
+	if err != nil {
+		logger.WithError(err).Warn("Failed to serialize the image diff report.")
+		return
+	}
+	if err := api.SaveArtifact(secretutil.NewCensorer(), ImageDiffReportArtifact, data); err != nil {
+		logger.WithError(err).Warn("Failed to save the image diff report.")
+	}
+}
+
+// promotedImage resolves an image in the stream of a promotion target. For
+// manifest lists, the image for the architecture promotion runs on is used.
+func (s *promotionStep) promotedImage(ctx context.Context, targets ctrlruntimeclient.Reader, tag api.ImageStreamTagReference, digest string) *imagev1.Image {
+	isi := &imagev1.ImageStreamImage{}
+	if err := targets.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: tag.Namespace, Name: fmt.Sprintf("%s@%s", tag.Name, digest)}, isi); err != nil {
+		logrus.WithField("name", s.name).WithError(err).Debugf("Failed to get image %s in %s/%s.", digest, tag.Namespace, tag.Name)
+		return nil
+	}
+	manifest := platformManifest(&isi.Image, promotionArchitecture(s.nodeArchitectures))
+	if manifest == "" {
+		return &isi.Image
+	}
+	return s.promotedImage(ctx, targets, tag, manifest)
+}
+
+// platformManifest returns the digest of the image for the architecture
+// promotion runs on when the image is a manifest list, and nothing otherwise.
+// It is the architecture of the packages compared by the pod listing them.
+func platformManifest(image *imagev1.Image, arch string) string {
+	if len(image.DockerImageManifests) == 0 {
+		return ""
+	}
+	manifest := image.DockerImageManifests[0]
+	for _, m := range image.DockerImageManifests {
+		if m.OS == "linux" && m.Architecture == arch {
+			manifest = m
+			break
+		}
+	}
+	return manifest.Digest
+}
+
+func promotedPullSpec(registry string, tag api.ImageStreamTagReference, digest string) string {
+	return fmt.Sprintf("%s/%s/%s@%s", registry, tag.Namespace, tag.Name, digest)
+}
+
+func layerNames(image *imagev1.Image) []string {
+	names := []string{}
+	for _, layer := range image.DockerImageLayers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+func imageSize(image *imagev1.Image) int64 {
+	var size int64
+	for _, layer := range image.DockerImageLayers {
+		size += layer.LayerSize
+	}
+	return size
+}
+
+// compareImages records the size delta between the images and whether the
+// promoted image still starts with the layers of the previous base image.
+func compareImages(diff *imageDiff, previous, current *imagev1.Image) {
+	if previous != nil {
+		diff.PreviousSize = imageSize(previous)
+	}
+	if current != nil {
+		diff.CurrentSize = imageSize(current)
+	}
+	if diff.PreviousSize != 0 && diff.CurrentSize != 0 {
+		diff.SizeDelta = diff.CurrentSize - diff.PreviousSize
+	}
+	if previous == nil || len(diff.baseLayers) == 0 {
+		return
+	}
+	previousLayers := layerNames(previous)
+	changed := len(previousLayers) < len(diff.baseLayers)
+	for i := 0; !changed && i < len(diff.baseLayers); i++ {
+		changed = previousLayers[i] != diff.baseLayers[i]
+	}
+	diff.BaseImageChanged = &changed
+}
+
+// comparePackages records the packages added, removed and updated between
+// the package lists of the images, mapping names to versions.
+func comparePackages(diff *imageDiff, previous, current map[string]string) {
+	if len(previous) == 0 || len(current) == 0 {
+		return
+	}
+	for _, name := range sortedKeys(current) {
+		previousVersion, ok := previous[name]
+		switch {
+		case !ok:
+			diff.AddedPackages = append(diff.AddedPackages, fmt.Sprintf("%s-%s", name, current[name]))
+		case previousVersion != current[name]:
+			diff.UpdatedPackages = append(diff.UpdatedPackages, packageUpdate{Name: name, Previous: previousVersion, Current: current[name]})
+		}
+	}
+	for _, name := range sortedKeys(previous) {
+		if _, ok := current[name]; !ok {
+			diff.RemovedPackages = append(diff.RemovedPackages, fmt.Sprintf("%s-%s", name, previous[name]))
+		}
+	}
+}
+
+// imagePackages lists the packages installed in the compared images by
+// running a pod which extracts their RPM databases.
+func (s *promotionStep) imagePackages(ctx context.Context, diffs []imageDiff, cliVersion string) (map[string]map[string]string, error) {
+	pullSpecs := sets.New[string]()
+	for _, diff := range diffs {
+		pullSpecs.Insert(promotedPullSpec(s.registry, diff.tag, diff.Previous), promotedPullSpec(s.registry, diff.tag, diff.Current))
+	}
+	pod := getImageDiffPod(sets.List(pullSpecs), s.jobSpec.Namespace(), fmt.Sprintf("%s-image-diff", s.name), cliVersion, s.nodeArchitectures)
+	if _, err := steps.RunPod(ctx, s.client, pod, true); err != nil {
+		return nil, fmt.Errorf("unable to run image diff pod: %w", err)
+	}
+	logs, err := s.client.StreamLogs(ctx, pod.Namespace, pod.Name, &coreapi.PodLogOptions{Container: imageDiffContainer})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the logs of the image diff pod: %w", err)
+	}
+	defer logs.Close()
+	return parseImagePackages(logs, pullSpecs)
+}
+
+// parseImagePackages parses the `<pull spec> <name>.<arch> <version>` lines
+// printed for the packages of each image, ignoring other output. Packages
+// installed in several versions have them joined.
+func parseImagePackages(r io.Reader, pullSpecs sets.Set[string]) (map[string]map[string]string, error) {
+	versions := map[string]map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || !pullSpecs.Has(fields[0]) {
+			continue
+		}
+		if versions[fields[0]] == nil {
+			versions[fields[0]] = map[string][]string{}
+		}
+		versions[fields[0]][fields[1]] = append(versions[fields[0]][fields[1]], fields[2])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the package lists: %w", err)
+	}
+	packages := map[string]map[string]string{}
+	for pullSpec, byName := range versions {
+		packages[pullSpec] = map[string]string{}
+		for name, v := range byName {
+			sort.Strings(v)
+			packages[pullSpec][name] = strings.Join(v, ",")
+		}
+	}
+	return packages, nil
+}
+
+func getImageDiffPod(pullSpecs []string, namespace, name, cliVersion string, nodeArchitectures []string) *coreapi.Pod {
+	image, nodeSelector := cliImage(cliVersion, nodeArchitectures)
+	registryConfig := filepath.Join(api.RegistryPushCredentialsCICentralSecretMountPath, coreapi.DockerConfigJsonKey)
+	// images without an RPM database are listed without packages
+	script := []string{
+		"set -uo pipefail",
+		"packages() {",
+		`  dir="$( mktemp -d )"`,
+		`  mkdir -p "${dir}/usr" "${dir}/var"`,
+		fmt.Sprintf(`  if ! oc image extract --registry-config=%s --filter-by-os=linux/%s --path /usr/lib/sysimage/rpm/:"${dir}/usr" --path /var/lib/rpm/:"${dir}/var" "$1" >/dev/null 2>&1; then`, registryConfig, nodeSelector["kubernetes.io/arch"]),
+		`    echo "Failed to extract the package database of $1" >&2`,
+		"  fi",
+		`  for db in "${dir}/usr" "${dir}/var"; do`,
+		`    if [ -n "$( ls -A "${db}" )" ]; then`,
+		`      rpm --dbpath "${db}" -qa --queryformat "$1 %{NAME}.%{ARCH} %{VERSION}-%{RELEASE}\n"`,
+		"      break",
+		"    fi",
+		"  done",
+		`  rm -rf "${dir}"`,
+		"}",
+	}
+	for _, pullSpec := range pullSpecs {
+		script = append(script, fmt.Sprintf("packages '%s'", pullSpec))
+	}
+
+	return &coreapi.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: coreapi.PodSpec{
+			NodeSelector:  nodeSelector,
+			RestartPolicy: coreapi.RestartPolicyNever,
+			Containers: []coreapi.Container{
+				{
+					Name:    imageDiffContainer,
+					Image:   image,
+					Command: []string{"/bin/bash", "-c", strings.Join(script, "\n")},
+					VolumeMounts: []coreapi.VolumeMount{
+						{
+							Name:      "push-secret",
+							MountPath: api.RegistryPushCredentialsCICentralSecretMountPath,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []coreapi.Volume{
+				{
+					Name: "push-secret",
+					VolumeSource: coreapi.VolumeSource{
+						Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package release
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCompareImages(t *testing.T) {
+	image := func(layers ...string) *imagev1.Image {
+		ret := &imagev1.Image{}
+		for _, layer := range layers {
+			ret.DockerImageLayers = append(ret.DockerImageLayers, imagev1.ImageLayer{Name: layer, LayerSize: 1000})
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		name       string
+		baseLayers []string
+		previous   *imagev1.Image
+		current    *imagev1.Image
+		expected   imageDiff
+	}{
+		{
+			name:       "same base image",
+			baseLayers: []string{"base-1", "base-2"},
+			previous:   image("base-1", "base-2", "old"),
+			current:    image("base-1", "base-2", "new", "more"),
+			expected:   imageDiff{PreviousSize: 3000, CurrentSize: 4000, SizeDelta: 1000, BaseImageChanged: ptr.To(false)},
+		},
+		{
+			name:       "changed base image",
+			baseLayers: []string{"base-1", "base-3"},
+			previous:   image("base-1", "base-2", "old"),
+			current:    image("base-1", "base-3", "new"),
+			expected:   imageDiff{PreviousSize: 3000, CurrentSize: 3000, BaseImageChanged: ptr.To(true)},
+		},
+		{
+			name:       "previous image with fewer layers than the base image",
+			baseLayers: []string{"base-1", "base-2"},
+			previous:   image("base-1"),
+			current:    image("base-1", "base-2"),
+			expected:   imageDiff{PreviousSize: 1000, CurrentSize: 2000, SizeDelta: 1000, BaseImageChanged: ptr.To(true)},
+		},
+		{
+			name:     "unknown base and previous image",
+			current:  image("base-1", "new"),
+			expected: imageDiff{CurrentSize: 2000},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := imageDiff{baseLayers: tc.baseLayers}
+			compareImages(&diff, tc.previous, tc.current)
+			tc.expected.baseLayers = tc.baseLayers
+			if d := cmp.Diff(tc.expected, diff, cmp.AllowUnexported(imageDiff{})); d != "" {
+				t.Errorf("unexpected diff: %s", d)
+			}
+		})
+	}
+}
+
+func TestBaseLayers(t *testing.T) {
+	const namespace = "ci-op-test"
+	layers := func(names ...string) []imagev1.ImageLayer {
+		var ret []imagev1.ImageLayer
+		for _, name := range names {
+			ret = append(ret, imagev1.ImageLayer{Name: name})
+		}
+		return ret
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add imagev1 to scheme: %v", err)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "pipeline:single"},
+			Image:      imagev1.Image{DockerImageLayers: layers("single-1", "single-2")},
+		},
+		// the layers of a manifest list are the ones of the image for the
+		// architecture promotion runs on
+		&imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "pipeline:list"},
+			Image: imagev1.Image{DockerImageManifests: []imagev1.ImageManifest{
+				{Digest: "sha256:arm64", OS: "linux", Architecture: "arm64"},
+				{Digest: "sha256:amd64", OS: "linux", Architecture: "amd64"},
+			}},
+		},
+		&imagev1.ImageStreamImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "pipeline@sha256:arm64"},
+			Image:      imagev1.Image{DockerImageLayers: layers("arm64-1")},
+		},
+		&imagev1.ImageStreamImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "pipeline@sha256:amd64"},
+			Image:      imagev1.Image{DockerImageLayers: layers("amd64-1", "amd64-2")},
+		},
+	).Build()
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace(namespace)
+	s := &promotionStep{
+		jobSpec: jobSpec,
+		client:  kubernetes.NewPodClient(loggingclient.New(client), nil, nil, 0),
+		configuration: &api.ReleaseBuildConfiguration{Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+			{From: "single", To: "a"},
+			{From: "list", To: "b"},
+			{From: "missing", To: "c"},
+			{To: "d"},
+		}},
+	}
+	for src, expected := range map[string][]string{
+		"a": {"single-1", "single-2"},
+		"b": {"amd64-1", "amd64-2"},
+		"c": {},
+		"d": {},
+	} {
+		if d := cmp.Diff(expected, s.baseLayers(context.Background(), src)); d != "" {
+			t.Errorf("%s: unexpected layers: %s", src, d)
+		}
+	}
+	// promotion runs on arm64 when the build farm has no amd64 nodes
+	s.nodeArchitectures = []string{"arm64"}
+	if d := cmp.Diff([]string{"arm64-1"}, s.baseLayers(context.Background(), "b")); d != "" {
+		t.Errorf("b on arm64: unexpected layers: %s", d)
+	}
+}
+
+func TestComparePackages(t *testing.T) {
+	previous := map[string]string{
+		"bash.x86_64":    "5.1.8-6.el9",
+		"curl.x86_64":    "7.76.1-26.el9",
+		"python3.noarch": "3.9.18-1.el9",
+	}
+	current := map[string]string{
+		"bash.x86_64": "5.1.8-9.el9",
+		"curl.x86_64": "7.76.1-26.el9",
+		"jq.x86_64":   "1.6-16.el9",
+	}
+	diff := imageDiff{}
+	comparePackages(&diff, previous, current)
+	expected := imageDiff{
+		AddedPackages:   []string{"jq.x86_64-1.6-16.el9"},
+		RemovedPackages: []string{"python3.noarch-3.9.18-1.el9"},
+		UpdatedPackages: []packageUpdate{{Name: "bash.x86_64", Previous: "5.1.8-6.el9", Current: "5.1.8-9.el9"}},
+	}
+	if d := cmp.Diff(expected, diff, cmp.AllowUnexported(imageDiff{})); d != "" {
+		t.Errorf("unexpected diff: %s", d)
+	}
+
+	diff = imageDiff{}
+	comparePackages(&diff, nil, current)
+	if d := cmp.Diff(imageDiff{}, diff, cmp.AllowUnexported(imageDiff{})); d != "" {
+		t.Errorf("expected packages not to be compared without a previous package list: %s", d)
+	}
+}
+
+func TestParseImagePackages(t *testing.T) {
+	logs := `registry.ci.openshift.org/ocp/4.16@sha256:old bash.x86_64 5.1.8-6.el9
+registry.ci.openshift.org/ocp/4.16@sha256:old gpg-pubkey.(none) fd431d51-4ae0493b
+registry.ci.openshift.org/ocp/4.16@sha256:old gpg-pubkey.(none) 5a6340b3-6229229e
+Failed to extract the package database of registry.ci.openshift.org/ocp/4.16@sha256:new
+registry.ci.openshift.org/other@sha256:old bash.x86_64 5.1.8-6.el9
+`
+	pullSpecs := sets.New[string]("registry.ci.openshift.org/ocp/4.16@sha256:old", "registry.ci.openshift.org/ocp/4.16@sha256:new")
+	packages, err := parseImagePackages(strings.NewReader(logs), pullSpecs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]map[string]string{
+		"registry.ci.openshift.org/ocp/4.16@sha256:old": {
+			"bash.x86_64":       "5.1.8-6.el9",
+			"gpg-pubkey.(none)": "5a6340b3-6229229e,fd431d51-4ae0493b",
+		},
+	}
+	if d := cmp.Diff(expected, packages); d != "" {
+		t.Errorf("unexpected packages: %s", d)
+	}
+}
+
+func TestImageDiffString(t *testing.T) {
+	diff := imageDiff{
+		Target:           "registry.ci.openshift.org/ocp/4.16:a",
+		Previous:         "sha256:old",
+		Current:          "sha256:new",
+		PreviousSize:     100_000_000,
+		CurrentSize:      112_345_678,
+		SizeDelta:        12_345_678,
+		BaseImageChanged: ptr.To(true),
+		AddedPackages:    []string{"jq.x86_64-1.6-16.el9"},
+	}
+	expected := "registry.ci.openshift.org/ocp/4.16:a changed from sha256:old to sha256:new: size +12.3 MB, base image changed, 1 packages added, 0 removed, 0 updated"
+	if d := cmp.Diff(expected, diff.String()); d != "" {
+		t.Errorf("unexpected description: %s", d)
+	}
+}
+
+func TestGetImageDiffPod(t *testing.T) {
+	pod := getImageDiffPod([]string{
+		"registry.ci.openshift.org/ocp/4.16@sha256:new",
+		"registry.ci.openshift.org/ocp/4.16@sha256:old",
+	}, "ci-op-y2n8rsh3", "promotion-image-diff", "4.17", nil)
+	testhelper.CompareWithFixture(t, pod)
+}
@@ -0,0 +1,61 @@
+package release
+
+import (
+	"fmt"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// pausedTarget is a target which was not promoted to because its promotion
+// is paused.
+type pausedTarget struct {
+	name  string
+	until string
+}
+
+// withoutPausedTargets returns the configuration with the targets whose
+// promotion is paused disabled, along with the targets which were paused.
+func (s *promotionStep) withoutPausedTargets() (*api.ReleaseBuildConfiguration, []pausedTarget, error) {
+	now := s.now()
+	configuration := s.configuration
+	var paused []pausedTarget
+	for i, target := range api.PromotionTargets(s.configuration.PromotionConfiguration) {
+		if target.Disabled {
+			continue
+		}
+		isPaused, err := target.Paused(now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("promotion.to[%d]: %w", i, err)
+		}
+		if !isPaused {
+			continue
+		}
+		if configuration == s.configuration {
+			configuration = s.configuration.DeepCopy()
+		}
+		configuration.PromotionConfiguration.Targets[i].Disabled = true
+		paused = append(paused, pausedTarget{name: s.targetNameFunc(s.registry, target), until: target.PausedUntil})
+	}
+	return configuration, paused, nil
+}
+
+// SubTests reports the targets which were not promoted to because their
+// promotion is paused as skipped, next to the promotion itself.
+func (s *promotionStep) SubTests() []*junit.TestCase {
+	if len(s.paused) == 0 {
+		return nil
+	}
+	promotion := &junit.TestCase{Name: s.Description()}
+	if s.err != nil {
+		promotion.FailureOutput = &junit.FailureOutput{Output: s.err.Error()}
+	}
+	tests := []*junit.TestCase{promotion}
+	for _, target := range s.paused {
+		tests = append(tests, &junit.TestCase{
+			Name:        fmt.Sprintf("Promote images to %s", target.name),
+			SkipMessage: &junit.SkipMessage{Message: fmt.Sprintf("promotion to %s is paused until %s", target.name, target.until)},
+		})
+	}
+	return tests
+}
@@ -0,0 +1,103 @@
+package release
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestWithoutPausedTargets(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name             string
+		targets          []api.PromotionTarget
+		expectedDisabled []bool
+		expectedPaused   []pausedTarget
+		expectedErr      error
+	}{
+		{
+			name:             "no target is paused",
+			targets:          []api.PromotionTarget{{Namespace: "ci", Name: "latest"}, {Namespace: "ci", Name: "old", PausedUntil: "2024-06-01T00:00:00Z"}},
+			expectedDisabled: []bool{false, false},
+		},
+		{
+			name:             "paused target is disabled",
+			targets:          []api.PromotionTarget{{Namespace: "ci", Name: "latest"}, {Namespace: "ci", Name: "frozen", PausedUntil: "2024-06-10T00:00:00Z"}},
+			expectedDisabled: []bool{false, true},
+			expectedPaused:   []pausedTarget{{name: "ci/frozen", until: "2024-06-10T00:00:00Z"}},
+		},
+		{
+			name:             "disabled target is not reported as paused",
+			targets:          []api.PromotionTarget{{Namespace: "ci", Name: "frozen", Disabled: true, PausedUntil: "2024-06-10T00:00:00Z"}},
+			expectedDisabled: []bool{true},
+		},
+		{
+			name:        "malformed pause",
+			targets:     []api.PromotionTarget{{Namespace: "ci", Name: "frozen", PausedUntil: "2024-06-10"}},
+			expectedErr: errors.New(`promotion.to[0]: invalid paused_until "2024-06-10": parsing time "2024-06-10" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{Targets: tc.targets}}
+			original := configuration.DeepCopy()
+			s := &promotionStep{
+				configuration: configuration,
+				targetNameFunc: func(_ string, target api.PromotionTarget) string {
+					return target.Namespace + "/" + target.Name
+				},
+				now: func() time.Time { return now },
+			}
+			actual, paused, err := s.withoutPausedTargets()
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			var disabled []bool
+			for _, target := range actual.PromotionConfiguration.Targets {
+				disabled = append(disabled, target.Disabled)
+			}
+			if diff := cmp.Diff(tc.expectedDisabled, disabled); diff != "" {
+				t.Errorf("unexpected disabled targets: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedPaused, paused, cmp.AllowUnexported(pausedTarget{})); diff != "" {
+				t.Errorf("unexpected paused targets: %s", diff)
+			}
+			if diff := cmp.Diff(original, configuration); diff != "" {
+				t.Errorf("the configuration of the step was modified: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPromotionSubTests(t *testing.T) {
+	s := &promotionStep{
+		configuration:  &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ci", Name: "frozen"}}}},
+		targetNameFunc: func(_ string, target api.PromotionTarget) string { return target.Namespace + "/" + target.Name },
+	}
+	if tests := s.SubTests(); tests != nil {
+		t.Errorf("expected no sub-tests without paused targets, got %v", tests)
+	}
+	s.paused = []pausedTarget{{name: "ci/frozen", until: "2024-06-10T00:00:00Z"}}
+	s.err = errors.New("oops")
+	expected := []*junit.TestCase{
+		{
+			Name:          "Promote built images into the release image streams: ci/frozen",
+			FailureOutput: &junit.FailureOutput{Output: "oops"},
+		},
+		{
+			Name:        "Promote images to ci/frozen",
+			SkipMessage: &junit.SkipMessage{Message: "promotion to ci/frozen is paused until 2024-06-10T00:00:00Z"},
+		},
+	}
+	if diff := cmp.Diff(expected, s.SubTests()); diff != "" {
+		t.Errorf("unexpected sub-tests: %s", diff)
+	}
+}
@@ -1,6 +1,9 @@
 package release
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"testing"
@@ -655,6 +658,7 @@ func TestGetPromotionPod(t *testing.T) {
 	var testCases = []struct {
 		name              string
 		imageMirror       map[string]string
+		mirrors           []externalMirror
 		nodeArchitectures []string
 		namespace         string
 		expected          *coreapi.Pod
@@ -698,11 +702,23 @@ func TestGetPromotionPod(t *testing.T) {
 			},
 			namespace: "ci-op-zyvwvffx",
 		},
+		{
+			name: "external mirrors",
+			imageMirror: map[string]string{
+				"registry.ci.openshift.org/ci/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+			},
+			mirrors: []externalMirror{
+				{credentials: "quay-push", images: map[string]string{
+					"quay.io/org/repo/applyconfig:latest": "docker-registry.default.svc:5000/ci-op-y2n8rsh3/pipeline@sha256:afd71aa3cbbf7d2e00cd8696747b2abf164700147723c657919c20b13d13ec62",
+				}},
+			},
+			namespace: "ci-op-zyvwvffx",
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			testhelper.CompareWithFixture(t, getPromotionPod(testCase.imageMirror, "20240603235401", testCase.namespace, "promotion", "4.14", testCase.nodeArchitectures))
+			testhelper.CompareWithFixture(t, getPromotionPod(testCase.imageMirror, testCase.mirrors, "20240603235401", testCase.namespace, "promotion", "4.14", testCase.nodeArchitectures))
 		})
 	}
 }
@@ -926,3 +942,63 @@ func TestGetPublicImageReference(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckARTConsistency(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("name")+"@"+r.URL.Query().Get("version"))
+		if _, err := w.Write([]byte(`{"name":"component","go_version":"1.21"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	configuration := &api.ReleaseBuildConfiguration{
+		PromotionConfiguration: &api.PromotionConfiguration{ARTConsistencyCheck: true},
+	}
+	buildRoot := &api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-1.22-openshift-4.17"}
+	tags := map[string][]api.ImageStreamTagReference{
+		"a": {{Namespace: "ocp", Name: "4.17", Tag: "shared"}, {Namespace: "ocp", Name: "4.18", Tag: "a"}},
+		"b": {{Namespace: "ocp", Name: "4.17", Tag: "shared"}},
+		"c": {{Namespace: "other", Name: "4.17", Tag: "c"}},
+	}
+	check := NewARTConsistencyCheck(server.Client(), server.URL, buildRoot)
+	expectedErr := errors.New("[a: Go version 1.22 does not match the one used by ART for shared: 1.21, a: Go version 1.22 does not match the one used by ART for a: 1.21, b: Go version 1.22 does not match the one used by ART for shared: 1.21]")
+	// the steps promoting to the central registry and to quay.io share the
+	// check, so ART is queried once for every component
+	for _, step := range []api.Step{
+		PromotionStep(api.PromotionStepName, configuration, nil, &api.JobSpec{}, nil, nil, api.ServiceDomainAPPCIRegistry, nil, nil, nil, check, nil, false),
+		PromotionStep(api.PromotionQuayStepName, configuration, nil, &api.JobSpec{}, nil, nil, api.QuayOpenShiftCIRepo, nil, nil, nil, check, nil, false),
+	} {
+		err := step.(*promotionStep).checkARTConsistency(tags)
+		if diff := cmp.Diff(expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+			t.Errorf("%s: unexpected error: %s", step.Name(), diff)
+		}
+	}
+	sort.Strings(requests)
+	if diff := cmp.Diff([]string{"a@4.18", "shared@4.17"}, requests); diff != "" {
+		t.Errorf("unexpected requests: %s", diff)
+	}
+
+	// a requested check that cannot run fails the promotion
+	for _, tc := range []struct {
+		name     string
+		check    *ARTConsistencyCheck
+		expected error
+	}{
+		{
+			name:     "no endpoint",
+			expected: errors.New("no ART image metadata endpoint is configured"),
+		},
+		{
+			name:     "no build root",
+			check:    NewARTConsistencyCheck(server.Client(), server.URL, nil),
+			expected: errors.New("the ART consistency check requires a build root imported from an image stream tag"),
+		},
+	} {
+		step := PromotionStep(api.PromotionStepName, configuration, nil, &api.JobSpec{}, nil, nil, api.ServiceDomainAPPCIRegistry, nil, nil, nil, tc.check, nil, false)
+		err := step.(*promotionStep).checkARTConsistency(tags)
+		if diff := cmp.Diff(tc.expected, err, testhelper.EquateErrorMessage); diff != "" {
+			t.Errorf("%s: unexpected error: %s", tc.name, diff)
+		}
+	}
+}
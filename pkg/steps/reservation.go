@@ -0,0 +1,111 @@
+package steps
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/lease"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// QuotaAdmission enforces the reservations of cluster profiles when leases
+// are acquired.
+type QuotaAdmission struct {
+	Reservations *lease.Reservations
+	// Observe records the time a job waited for the reservation of a
+	// profile, it is optional.
+	Observe func(profile string, waited time.Duration)
+}
+
+// reservationStep wraps another step and holds a reservation of its cluster
+// profile while it runs. Waiting for the reservation is reported separately
+// from waiting for the leases of the profile, as it means the profile is at
+// its quota rather than the cloud account being out of capacity.
+type reservationStep struct {
+	client    *lease.Client
+	admission QuotaAdmission
+	profile   string
+	rtype     string
+	wrapped   api.Step
+	leaseOptions
+}
+
+// ReservationStep wraps the step with the admission of its cluster profile,
+// or returns the step unchanged when the profile has no reservation.
+func ReservationStep(client *lease.Client, admission *QuotaAdmission, profile api.ClusterProfile, wrapped api.Step, opts ...LeaseStepOption) api.Step {
+	if admission == nil {
+		return wrapped
+	}
+	rtype := admission.Reservations.ReservationType(string(profile))
+	if rtype == "" {
+		return wrapped
+	}
+	ret := &reservationStep{
+		client:    client,
+		admission: *admission,
+		profile:   string(profile),
+		rtype:     rtype,
+		wrapped:   wrapped,
+	}
+	for _, opt := range opts {
+		opt(&ret.leaseOptions)
+	}
+	return ret
+}
+
+func (s *reservationStep) Inputs() (api.InputDefinition, error) {
+	return s.wrapped.Inputs()
+}
+
+func (s *reservationStep) Validate() error {
+	if s.client == nil {
+		return NoLeaseClientErr
+	}
+	return nil
+}
+
+func (s *reservationStep) Name() string                        { return s.wrapped.Name() }
+func (s *reservationStep) Description() string                 { return s.wrapped.Description() }
+func (s *reservationStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
+func (s *reservationStep) Creates() []api.StepLink             { return s.wrapped.Creates() }
+func (s *reservationStep) Provides() api.ParameterMap          { return s.wrapped.Provides() }
+func (s *reservationStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
+
+func (s *reservationStep) SubTests() []*junit.TestCase {
+	if subTests, ok := s.wrapped.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *reservationStep) Run(ctx context.Context) error {
+	client := *s.client
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logrus.Infof("Waiting for quota of cluster profile %s for test %s (at most %d concurrent jobs)", s.profile, s.Name(), s.admission.Reservations.Profiles[s.profile])
+	start := time.Now()
+	names, err := client.Acquire(s.rtype, 1, ctx, cancel)
+	waited := time.Since(start)
+	if s.admission.Observe != nil {
+		s.admission.Observe(s.profile, waited)
+	}
+	if err != nil {
+		if err == lease.ErrNotFound {
+			printResourceMetrics(client, s.rtype)
+		}
+		return results.ForReason("waiting_for_quota").WithError(err).Errorf("failed to acquire quota of cluster profile %s after %s: %v", s.profile, waited.Round(time.Second), err)
+	}
+	logrus.Infof("Acquired quota of cluster profile %s after %s", s.profile, waited.Round(time.Second))
+	if s.renew {
+		client.Renew(names...)
+	}
+	wrappedErr := s.wrapped.Run(ctx)
+	releaseErr := results.ForReason("releasing_quota").ForError(client.Release(names[0]))
+	return aggregateWrappedErrorAndReleaseError(wrappedErr, releaseErr)
+}
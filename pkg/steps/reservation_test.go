@@ -0,0 +1,112 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/lease"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+func TestReservationStep(t *testing.T) {
+	admission := &QuotaAdmission{Reservations: &lease.Reservations{Profiles: map[string]int{"aws": 2}}}
+	step := &stepNeedsLease{}
+	if wrapped := ReservationStep(nil, admission, api.ClusterProfileGCP, step); wrapped != step {
+		t.Error("expected a profile without reservation not to be wrapped")
+	}
+	if wrapped := ReservationStep(nil, nil, api.ClusterProfileAWS, step); wrapped != step {
+		t.Error("expected a step not to be wrapped without admission")
+	}
+
+	for _, tc := range []struct {
+		name            string
+		runFails        bool
+		failures        map[string]error
+		expectedReasons []string
+		expectedCalls   []string
+	}{
+		{
+			name: "quota is held while the step runs",
+			expectedCalls: []string{
+				"acquireWaitWithPriority owner aws-reservation free leased random",
+				"releaseone owner aws-reservation_0 free",
+			},
+		},
+		{
+			name: "waiting for quota fails",
+			failures: map[string]error{
+				"acquireWaitWithPriority owner aws-reservation free leased random": errors.New("injected failure"),
+			},
+			expectedReasons: []string{"waiting_for_quota"},
+			expectedCalls:   []string{"acquireWaitWithPriority owner aws-reservation free leased random"},
+		},
+		{
+			name:     "step fails",
+			runFails: true,
+			expectedCalls: []string{
+				"acquireWaitWithPriority owner aws-reservation free leased random",
+				"releaseone owner aws-reservation_0 free",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var observed []string
+			client := lease.NewFakeClient("owner", "url", 0, tc.failures, &calls)
+			admission := &QuotaAdmission{
+				Reservations: &lease.Reservations{Profiles: map[string]int{"aws": 2}},
+				Observe:      func(profile string, _ time.Duration) { observed = append(observed, profile) },
+			}
+			s := &stepNeedsLease{fail: tc.runFails}
+			err := ReservationStep(&client, admission, api.ClusterProfileAWS, s).Run(context.Background())
+			if diff := cmp.Diff(tc.expectedReasons, results.Reasons(err)); diff != "" {
+				t.Errorf("unexpected reasons: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedCalls, calls); diff != "" {
+				t.Errorf("unexpected calls to the lease client: %s", diff)
+			}
+			if diff := cmp.Diff([]string{"aws"}, observed); diff != "" {
+				t.Errorf("unexpected observations: %s", diff)
+			}
+			if s.ran != (tc.failures == nil) {
+				t.Errorf("expected the step to run only when quota was acquired, ran: %t", s.ran)
+			}
+		})
+	}
+}
+
+func TestReservationRenewal(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		opts          []LeaseStepOption
+		expectedError string
+	}{
+		{
+			name:          "reservations are lost on failed updates",
+			expectedError: `exceeded number of retries for lease "aws-reservation_0"`,
+		},
+		{
+			name: "renewed reservations are kept",
+			opts: []LeaseStepOption{WithLeaseRenewal()},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := lease.NewFakeClient("owner", "url", 0, map[string]error{"updateone owner aws-reservation_0 leased 0": errors.New("injected error")}, nil)
+			admission := &QuotaAdmission{Reservations: &lease.Reservations{Profiles: map[string]int{"aws": 2}}}
+			step := stepHeartbeats{client: client}
+			err := ReservationStep(&client, admission, api.ClusterProfileAWS, &step, tc.opts...).Run(context.Background())
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, actualError)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// defaultServiceReadinessTimeout is how long a service has to become
+	// ready when its probe does not set a timeout.
+	defaultServiceReadinessTimeout = 5 * time.Minute
+	serviceProbePeriod             = 5 * time.Second
+)
+
+// addServices adds the services of a container test to its pod. They run as
+// sidecars: the kubelet starts them, and waits for them to be ready, before
+// the test container starts, and terminates them once it exits.
+func addServices(pod *coreapi.Pod, test string, services []api.TestService, resources api.ResourceConfiguration) error {
+	always := coreapi.ContainerRestartPolicyAlways
+	for _, service := range services {
+		containerResources, err := ResourcesFor(resources.RequirementsForStep(fmt.Sprintf("%s-%s", test, service.As)))
+		if err != nil {
+			return fmt.Errorf("unable to calculate resources for service %s: %w", service.As, err)
+		}
+		container := coreapi.Container{
+			Name:                     service.As,
+			Image:                    fmt.Sprintf("%s:%s", api.PipelineImageStream, service.From),
+			Resources:                containerResources,
+			RestartPolicy:            &always,
+			TerminationMessagePolicy: coreapi.TerminationMessageFallbackToLogsOnError,
+		}
+		if service.Commands != "" {
+			container.Command = []string{"/bin/bash", "-c", "#!/bin/bash\nset -eu\n" + service.Commands}
+		}
+		for name, value := range service.Env {
+			container.Env = append(container.Env, coreapi.EnvVar{Name: name, Value: value})
+		}
+		sort.Slice(container.Env, func(i, j int) bool {
+			return container.Env[i].Name < container.Env[j].Name
+		})
+		for _, port := range service.Ports {
+			container.Ports = append(container.Ports, coreapi.ContainerPort{ContainerPort: port, Protocol: coreapi.ProtocolTCP})
+		}
+		if probe := service.ReadinessProbe; probe != nil {
+			container.StartupProbe = startupProbeFor(probe)
+		}
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, container)
+	}
+	return nil
+}
+
+// startupProbeFor translates the readiness probe of a service to the startup
+// probe of its sidecar, which holds back the test container until it passes.
+func startupProbeFor(probe *api.TestServiceReadinessProbe) *coreapi.Probe {
+	timeout := defaultServiceReadinessTimeout
+	if probe.Timeout != nil {
+		timeout = probe.Timeout.Duration
+	}
+	ret := &coreapi.Probe{
+		PeriodSeconds:    int32(serviceProbePeriod.Seconds()),
+		FailureThreshold: int32((timeout + serviceProbePeriod - 1) / serviceProbePeriod),
+	}
+	switch {
+	case probe.TCPPort != 0:
+		ret.TCPSocket = &coreapi.TCPSocketAction{Port: intstr.FromInt32(probe.TCPPort)}
+	case probe.HTTPGet != nil:
+		path := probe.HTTPGet.Path
+		if path == "" {
+			path = "/"
+		}
+		ret.HTTPGet = &coreapi.HTTPGetAction{Path: path, Port: intstr.FromInt32(probe.HTTPGet.Port)}
+	default:
+		ret.Exec = &coreapi.ExecAction{Command: probe.Command}
+	}
+	return ret
+}
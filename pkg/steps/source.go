@@ -2,6 +2,7 @@ package steps
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -42,8 +43,8 @@ import (
 
 const (
 	CiAnnotationPrefix = "ci.openshift.io"
-	CreatesLabel       = "creates"
-	CreatedByCILabel   = "created-by-ci"
+	CreatesLabel       = api.CreatesLabel
+	CreatedByCILabel   = api.CreatedByCILabel
 
 	ProwJobIdLabel = "prow.k8s.io/id"
 
@@ -281,6 +282,7 @@ func createBuild(config api.SourceStepConfiguration, jobSpec *api.JobSpec, clone
 	}
 
 	build := buildFromSource(jobSpec, config.From, config.To, buildSource, fromDigest, "", resources, pullSecret, nil, config.Ref)
+	setBuildBackend(build, config.BuildBackend)
 	build.Spec.CommonSpec.Strategy.DockerStrategy.Env = append(
 		build.Spec.CommonSpec.Strategy.DockerStrategy.Env,
 		corev1.EnvVar{Name: clonerefs.JSONConfigEnvVar, Value: optionsJSON},
@@ -397,15 +399,18 @@ func buildInputsFromStep(inputs map[string]api.ImageBuildInputs) []buildapi.Imag
 		for _, path := range value.Paths {
 			paths = append(paths, buildapi.ImageSourcePath{SourcePath: path.SourcePath, DestinationDir: path.DestinationDir})
 		}
-		if len(value.As) == 0 && len(paths) == 0 {
-			continue
+		pullSpec := fmt.Sprintf("%s:%s", api.PipelineImageStream, name)
+		// every input can be referenced as `pipeline:<name>` in the Dockerfile
+		as := value.As
+		if !sets.New[string](as...).Has(pullSpec) {
+			as = append(append([]string{}, as...), pullSpec)
 		}
 		refs = append(refs, buildapi.ImageSource{
 			From: corev1.ObjectReference{
 				Kind: "ImageStreamTag",
-				Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, name),
+				Name: pullSpec,
 			},
-			As:    value.As,
+			As:    as,
 			Paths: paths,
 		})
 	}
@@ -471,6 +476,23 @@ func handleBuilds(ctx context.Context, buildClient BuildClient, podClient kubern
 		o = opts[0]
 	}
 
+	var cacheKey string
+	if cache := buildClient.BuildCache(); cache != nil {
+		key, err := cache.Key(ctx, build, o.Architectures)
+		if errors.Is(err, errNotCacheable) {
+			logrus.WithError(err).Debugf("Not caching the image built for %s.", build.Name)
+		} else if err != nil {
+			logrus.WithError(err).Warnf("Failed to compute the build cache key for %s, building it.", build.Name)
+		} else if restored, err := cache.Restore(ctx, build, key); err != nil {
+			logrus.WithError(err).Warnf("Failed to reuse a cached image for %s, building it.", build.Name)
+			cacheKey = key
+		} else if restored {
+			return nil
+		} else {
+			cacheKey = key
+		}
+	}
+
 	builds := constructMultiArchBuilds(build, o.Architectures)
 	errChan := make(chan error, len(builds))
 
@@ -499,6 +521,12 @@ func handleBuilds(ctx context.Context, buildClient BuildClient, podClient kubern
 		}
 	}
 
+	if len(errs) == 0 && cacheKey != "" {
+		if err := buildClient.BuildCache().Store(ctx, build, cacheKey); err != nil {
+			logrus.WithError(err).Warnf("Failed to store the image built for %s in the build cache.", build.Name)
+		}
+	}
+
 	return utilerrors.NewAggregate(errs)
 }
 
@@ -532,6 +560,9 @@ func constructMultiArchBuilds(build buildapi.Build, stepArchitectures []string)
 }
 
 func handleBuild(ctx context.Context, client BuildClient, podClient kubernetes.PodClient, build buildapi.Build) error {
+	if isBuildahBuild(build) {
+		return handleBuildahBuild(ctx, client, podClient, build)
+	}
 	const attempts = 5
 	ns, name := build.Namespace, build.Name
 	var errs []error
@@ -700,8 +731,12 @@ func waitForBuild(
 				return true, nil
 			case buildapi.BuildPhaseFailed, buildapi.BuildPhaseCancelled, buildapi.BuildPhaseError:
 				logrus.Infof("Build %s failed, printing logs:", build.Name)
-				printBuildLogs(buildClient, build.Namespace, build.Name)
-				return true, util.AppendLogToError(fmt.Errorf("the build %s failed after %s with reason %s: %s", build.Name, buildDuration(build).Truncate(time.Second), build.Status.Reason, build.Status.Message), build.Status.LogSnippet)
+				log := printBuildLogs(buildClient, build.Namespace, build.Name)
+				err := fmt.Errorf("the build %s failed after %s with reason %s: %s", build.Name, buildDuration(build).Truncate(time.Second), build.Status.Reason, build.Status.Message)
+				if diagnosis := diagnoseBuildLog(log + build.Status.LogSnippet); diagnosis != nil {
+					err = diagnosis.wrap(err)
+				}
+				return true, util.AppendLogToError(err, build.Status.LogSnippet)
 			}
 			return false, nil
 		}, 0)
@@ -738,17 +773,26 @@ func buildDuration(build *buildapi.Build) time.Duration {
 	return duration
 }
 
-func printBuildLogs(buildClient BuildClient, namespace, name string) {
+// buildLogDiagnosisLimit bounds how much of the log of a failed build is kept
+// to be diagnosed; builds fail at the end of their log, where the causes we
+// recognize are printed.
+const buildLogDiagnosisLimit = 1024 * 1024
+
+// printBuildLogs streams the logs of the build and returns their last bytes,
+// up to buildLogDiagnosisLimit
+func printBuildLogs(buildClient BuildClient, namespace, name string) string {
+	tail := &tailBuffer{limit: buildLogDiagnosisLimit}
 	if s, err := buildClient.Logs(namespace, name, &buildapi.BuildLogOptions{
 		NoWait: true,
 	}); err == nil {
 		defer s.Close()
-		if _, err := io.Copy(os.Stdout, s); err != nil {
+		if _, err := io.Copy(io.MultiWriter(os.Stdout, tail), s); err != nil {
 			logrus.WithError(err).Warn("Unable to copy log output from failed build.")
 		}
 	} else {
 		logrus.WithError(err).Warn("Unable to retrieve logs from failed build")
 	}
+	return string(tail.data)
 }
 
 func ResourcesFor(req api.ResourceRequirements) (corev1.ResourceRequirements, error) {
@@ -880,6 +924,21 @@ func addLabelsToBuild(refs *prowv1.Refs, build *buildapi.Build, contextDir strin
 	})
 }
 
+// buildDateLabel records when an image was built. It is only set on the
+// images of configurations promoting by commit, as it makes every build of
+// the same inputs produce a different image.
+const buildDateLabel = "build-date"
+
+func addBuildDateLabel(build *buildapi.Build, date time.Time) {
+	build.Spec.Output.ImageLabels = append(build.Spec.Output.ImageLabels, buildapi.ImageLabel{
+		Name:  buildDateLabel,
+		Value: date.UTC().Format(time.RFC3339),
+	})
+	sort.Slice(build.Spec.Output.ImageLabels, func(i, j int) bool {
+		return build.Spec.Output.ImageLabels[i].Name < build.Spec.Output.ImageLabels[j].Name
+	})
+}
+
 func istObjectReference(ctx context.Context, client ctrlruntimeclient.Client, reference api.ImageStreamTagReference) (corev1.ObjectReference, error) {
 	is := &imagev1.ImageStream{}
 	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: reference.Namespace, Name: reference.Name}, is); err != nil {
@@ -455,7 +455,7 @@ func TestWaitForBuild(t *testing.T) {
 							CompletionTimestamp: &end,
 						},
 					},
-				).Build()), nil, nil, "", ""),
+				).Build()), nil, nil, "", "", nil),
 			expected: fmt.Errorf("build didn't start running within 0s (phase: Pending)"),
 		},
 		{
@@ -484,7 +484,7 @@ func TestWaitForBuild(t *testing.T) {
 							Namespace: ns,
 						},
 					},
-				).Build()), nil, nil, "", ""),
+				).Build()), nil, nil, "", "", nil),
 			expected: fmt.Errorf("build didn't start running within 0s (phase: Pending):\nFound 0 events for Pod some-build-build:"),
 		},
 		{
@@ -525,7 +525,7 @@ func TestWaitForBuild(t *testing.T) {
 							}},
 						},
 					},
-				).Build()), nil, nil, "", ""),
+				).Build()), nil, nil, "", "", nil),
 			expected: fmt.Errorf(`build didn't start running within 0s (phase: Pending):
 * Container the-container is not ready with reason the_reason and message the_message
 Found 0 events for Pod some-build-build:`),
@@ -544,7 +544,7 @@ Found 0 events for Pod some-build-build:`),
 						StartTimestamp:      &start,
 						CompletionTimestamp: &end,
 					},
-				}).Build()), nil, nil, "", ""),
+				}).Build()), nil, nil, "", "", nil),
 			timeout: 30 * time.Minute,
 		},
 		{
@@ -568,6 +568,27 @@ Found 0 events for Pod some-build-build:`),
 			timeout:  30 * time.Minute,
 			expected: fmt.Errorf("%s\n\n%s", "the build some-build failed after 3s with reason reason: msg", "snippet"),
 		},
+		{
+			name: "build failed with a known signature",
+			buildClient: NewFakeBuildClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithIndex(&coreapi.Event{}, "involvedObject.uid", fakeInvolvedObjectUIDEventIndex).WithRuntimeObjects(
+				&buildapi.Build{
+					ObjectMeta: meta.ObjectMeta{
+						Name:              "some-build",
+						Namespace:         ns,
+						CreationTimestamp: start,
+					},
+					Status: buildapi.BuildStatus{
+						Phase:               buildapi.BuildPhaseFailed,
+						Reason:              "reason",
+						Message:             "msg",
+						LogSnippet:          "snippet",
+						StartTimestamp:      &start,
+						CompletionTimestamp: &end,
+					},
+				}).Build()), "curl: (6) Could not resolve host: mirror.example.com\n"),
+			timeout:  30 * time.Minute,
+			expected: fmt.Errorf("%s\n\n%s", "the build some-build failed after 3s with reason reason: msg\n\nDiagnosis: a host could not be resolved during the build\nHint: this is usually a transient infrastructure problem, retest the job; if it persists, make sure every host the build reaches is publicly resolvable", "snippet"),
+		},
 		{
 			name: "build already succeeded",
 			buildClient: NewBuildClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
@@ -588,7 +609,7 @@ Found 0 events for Pod some-build-build:`),
 							Time: now.Add(-59 * time.Minute),
 						},
 					},
-				}).Build()), nil, nil, "", ""),
+				}).Build()), nil, nil, "", "", nil),
 			timeout: 30 * time.Minute,
 		},
 		{
@@ -741,6 +762,22 @@ func TestCheckPending(t *testing.T) {
 	}
 }
 
+func TestPrintBuildLogs(t *testing.T) {
+	// only the end of long logs is kept, which is where builds fail
+	log := strings.Repeat("STEP 1/2: RUN make\n", buildLogDiagnosisLimit/10) + "no space left on device\n"
+	client := NewFakeBuildClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build()), log)
+	tail := printBuildLogs(client, "ns", "name")
+	if len(tail) != buildLogDiagnosisLimit {
+		t.Errorf("expected the last %d bytes of the log, got %d", buildLogDiagnosisLimit, len(tail))
+	}
+	if !strings.HasSuffix(log, tail) {
+		t.Error("expected the end of the log to be kept")
+	}
+	if diagnosis := diagnoseBuildLog(tail); diagnosis == nil || diagnosis.reason != "build_out_of_disk" {
+		t.Errorf("expected the failure to be diagnosed, got %v", diagnosis)
+	}
+}
+
 type fakeBuildClient struct {
 	loggingclient.LoggingClient
 	logContent        string
@@ -769,6 +806,10 @@ func (c *fakeBuildClient) LocalRegistryDNS() string {
 	return ""
 }
 
+func (c *fakeBuildClient) BuildCache() *BuildCache {
+	return nil
+}
+
 func Test_constructMultiArchBuilds(t *testing.T) {
 	tests := []struct {
 		name              string
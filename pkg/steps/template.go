@@ -53,12 +53,13 @@ const (
 )
 
 type templateExecutionStep struct {
-	template  *templateapi.Template
-	resources api.ResourceConfiguration
-	params    api.Parameters
-	podClient kubernetes.PodClient
-	client    TemplateClient
-	jobSpec   *api.JobSpec
+	template        *templateapi.Template
+	resources       api.ResourceConfiguration
+	params          api.Parameters
+	podClient       kubernetes.PodClient
+	client          TemplateClient
+	jobSpec         *api.JobSpec
+	artifactOptions ArtifactOptions
 
 	subTests []*junit.TestCase
 }
@@ -146,7 +147,7 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 	// now that the pods have been resolved by the template, add them to the artifact map
 	var notifier util.ContainerNotifier = util.NopNotifier
 	if artifactDir, artifactsRequested := api.Artifacts(); artifactsRequested {
-		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.template.Name), s.jobSpec.Namespace())
+		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.template.Name), s.jobSpec.Namespace(), s.artifactOptions.workerOptions(s.template.Name)...)
 		for _, ref := range instance.Status.Objects {
 			switch {
 			case ref.Ref.Kind == "Pod" && ref.Ref.APIVersion == "v1":
@@ -167,7 +168,7 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 		}
 	}
 
-	testCaseNotifier := NewTestCaseNotifier(notifier)
+	testCaseNotifier := NewTestCaseNotifier(notifier, WithFailureLogs(s.podClient, s.artifactOptions.FailureLogLimit))
 	for _, ref := range instance.Status.Objects {
 		switch {
 		case ref.Ref.Kind == "Pod" && ref.Ref.APIVersion == "v1":
@@ -278,14 +279,15 @@ func (s *templateExecutionStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
 
-func TemplateExecutionStep(template *templateapi.Template, params api.Parameters, podClient kubernetes.PodClient, templateClient TemplateClient, jobSpec *api.JobSpec, resources api.ResourceConfiguration) api.Step {
+func TemplateExecutionStep(template *templateapi.Template, params api.Parameters, podClient kubernetes.PodClient, templateClient TemplateClient, jobSpec *api.JobSpec, resources api.ResourceConfiguration, artifactOptions ArtifactOptions) api.Step {
 	return &templateExecutionStep{
-		template:  template,
-		resources: resources,
-		params:    params,
-		podClient: podClient,
-		client:    templateClient,
-		jobSpec:   jobSpec,
+		template:        template,
+		resources:       resources,
+		params:          params,
+		podClient:       podClient,
+		client:          templateClient,
+		jobSpec:         jobSpec,
+		artifactOptions: artifactOptions,
 	}
 }
 
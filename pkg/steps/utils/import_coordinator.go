@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// importStreamPrefix prefixes the imagestreams holding the images imported
+	// on behalf of all jobs, one per imported pull spec.
+	importStreamPrefix = "import-"
+	// importStreamTag is the tag the image is imported into.
+	importStreamTag = "latest"
+	// importCoordinationLabel marks the imagestreams managed by the
+	// coordinator, so that the ones no longer used can be pruned.
+	importCoordinationLabel = "ci.openshift.io/import-coordination"
+	// importClaimAnnotation records on an imagestream which job is importing
+	// the image, as `<holder> <RFC3339 time>`.
+	importClaimAnnotation = "ci.openshift.io/importing"
+	// importedAnnotation records on an imagestream the last import of the
+	// image, as `<image> <RFC3339 time>`. The tag events in the status do not
+	// record it: importing an image which did not change adds no event.
+	importedAnnotation = "ci.openshift.io/imported"
+	// importClaimTimeout is how long a claim is honored, a job which crashed
+	// while importing must not block the others forever.
+	importClaimTimeout = 10 * time.Minute
+	// importRetention is how long an imagestream no job imported or claimed
+	// is kept before it is pruned.
+	importRetention = 24 * time.Hour
+)
+
+// ImportCoordinator deduplicates the imports of the same external image by
+// concurrent jobs on a cluster. The first job to need an image claims it and
+// imports it into an imagestream dedicated to the image, the others wait for
+// that import and tag the result, so that the image is only pulled from its
+// registry once.
+type ImportCoordinator struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	// holder identifies the job in claims
+	holder  func() string
+	now     func() time.Time
+	poll    time.Duration
+	timeout time.Duration
+	// prune removes the imagestreams no job used recently, once per job
+	prune sync.Once
+}
+
+// NewImportCoordinator creates a coordinator sharing the imports in the
+// namespace, in which the jobs must be allowed to manage imagestreams.
+func NewImportCoordinator(client ctrlruntimeclient.Client, namespace string, holder func() string) *ImportCoordinator {
+	return &ImportCoordinator{
+		client:    client,
+		namespace: namespace,
+		holder:    holder,
+		now:       time.Now,
+		poll:      5 * time.Second,
+		timeout:   DefaultImageImportTimeout,
+	}
+}
+
+// importStream is the name of the imagestream holding the image imported from
+// a pull spec.
+func importStream(pullSpec string) string {
+	hash := sha256.Sum256([]byte(pullSpec))
+	return importStreamPrefix + hex.EncodeToString(hash[:])[:32]
+}
+
+// Source returns a reference to the shared image imported from the pull spec,
+// importing it unless another job is importing it. Imports which finished
+// before the job asked for the image are not reused, as the tag they were
+// imported from may have moved since.
+func (c *ImportCoordinator) Source(ctx context.Context, pullSpec string) (*coreapi.ObjectReference, error) {
+	name := importStream(pullSpec)
+	// the times recorded in the annotations have a precision of a second
+	since := c.now().Truncate(time.Second)
+	logger := logrus.WithField("pullSpec", pullSpec).WithField("imagestream", fmt.Sprintf("%s/%s", c.namespace, name))
+	var ref *coreapi.ObjectReference
+	err := wait.PollUntilContextTimeout(ctx, c.poll, c.timeout, true, func(ctx context.Context) (bool, error) {
+		stream := &imagev1.ImageStream{}
+		if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: name}, stream); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return false, fmt.Errorf("could not get the %s/%s imagestream: %w", c.namespace, name, err)
+			}
+			// the image was never imported, or its imagestream was pruned
+			stream = &imagev1.ImageStream{
+				ObjectMeta: meta.ObjectMeta{Namespace: c.namespace, Name: name, Labels: map[string]string{importCoordinationLabel: "true"}},
+			}
+			if err := c.client.Create(ctx, stream); err != nil {
+				if kerrors.IsAlreadyExists(err) {
+					return false, nil
+				}
+				return false, fmt.Errorf("could not create the %s/%s imagestream: %w", c.namespace, name, err)
+			}
+		}
+		if image := c.joinedImport(stream, since); image != "" {
+			ref = &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: c.namespace, Name: fmt.Sprintf("%s@%s", name, image)}
+			return true, nil
+		}
+		if holder, ok := c.claimedBy(stream); ok {
+			logger.WithField("holder", holder).Debug("Waiting for the image to be imported by another job")
+			return false, nil
+		}
+		claimed, err := c.claim(ctx, stream)
+		if err != nil || !claimed {
+			return false, err
+		}
+		logger.Debug("Importing the image on behalf of all jobs")
+		var image string
+		imported, err := ImportTagWithRetries(ctx, c.client, c.namespace, name, importStreamTag, pullSpec, api.ImageStreamImportRetries)
+		if err == nil {
+			var ok bool
+			if _, image, ok = strings.Cut(imported, "@"); !ok {
+				err = fmt.Errorf("imported pull spec %s has no digest", imported)
+			}
+		}
+		if releaseErr := c.release(ctx, name, image); releaseErr != nil {
+			logger.WithError(releaseErr).Warn("Failed to release the claim on the image import")
+		}
+		if err != nil {
+			return false, err
+		}
+		ref = &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: c.namespace, Name: fmt.Sprintf("%s@%s", name, image)}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s through %s/%s: %w", pullSpec, c.namespace, name, err)
+	}
+	c.prune.Do(func() {
+		if err := c.pruneStreams(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to prune the imagestreams of unused image imports")
+		}
+	})
+	return ref, nil
+}
+
+// annotatedTime parses the time recorded in an annotation of the coordinator
+// after the value it is recorded for.
+func annotatedTime(stream *imagev1.ImageStream, annotation string) (string, time.Time, bool) {
+	value, raw, _ := strings.Cut(stream.Annotations[annotation], " ")
+	at, err := time.Parse(time.RFC3339, raw)
+	return value, at, value != "" && err == nil
+}
+
+// joinedImport returns the image of the last import if it finished since the
+// job asked for the image, so it was in flight or started in the meantime, and
+// the tag still points to it.
+func (c *ImportCoordinator) joinedImport(stream *imagev1.ImageStream, since time.Time) string {
+	image, importedAt, ok := annotatedTime(stream, importedAnnotation)
+	if !ok || importedAt.Before(since) {
+		return ""
+	}
+	for _, t := range stream.Status.Tags {
+		if t.Tag == importStreamTag && len(t.Items) > 0 && t.Items[0].Image == image {
+			return image
+		}
+	}
+	return ""
+}
+
+// claimedBy returns the job importing the image, if any other job is.
+func (c *ImportCoordinator) claimedBy(stream *imagev1.ImageStream) (string, bool) {
+	holder, claimedAt, ok := annotatedTime(stream, importClaimAnnotation)
+	if !ok || holder == c.holder() || c.now().Sub(claimedAt) > importClaimTimeout {
+		return "", false
+	}
+	return holder, true
+}
+
+// claim records that this job imports the image. The update is rejected on a
+// conflict when another job claimed the image or changed the imagestream first.
+func (c *ImportCoordinator) claim(ctx context.Context, stream *imagev1.ImageStream) (bool, error) {
+	if stream.Annotations == nil {
+		stream.Annotations = map[string]string{}
+	}
+	stream.Annotations[importClaimAnnotation] = fmt.Sprintf("%s %s", c.holder(), c.now().Format(time.RFC3339))
+	if err := c.client.Update(ctx, stream); err != nil {
+		if kerrors.IsConflict(err) || kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not claim the import: %w", err)
+	}
+	return true, nil
+}
+
+// release removes the claim of this job on the imagestream and records the
+// image it imported, if the import succeeded.
+func (c *ImportCoordinator) release(ctx context.Context, name, image string) error {
+	return wait.ExponentialBackoff(wait.Backoff{Steps: 5, Duration: time.Second, Factor: 2}, func() (bool, error) {
+		stream := &imagev1.ImageStream{}
+		if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: name}, stream); err != nil {
+			return false, err
+		}
+		holder, _, _ := strings.Cut(stream.Annotations[importClaimAnnotation], " ")
+		if holder != c.holder() {
+			return true, nil
+		}
+		delete(stream.Annotations, importClaimAnnotation)
+		if image != "" {
+			stream.Annotations[importedAnnotation] = fmt.Sprintf("%s %s", image, c.now().Format(time.RFC3339))
+		}
+		if err := c.client.Update(ctx, stream); err != nil {
+			if kerrors.IsConflict(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// pruneStreams deletes the imagestreams of the images no job imported or
+// claimed for a while. The deletion is conditional on the version that was
+// listed, so an imagestream a job starts to use in the meantime is kept.
+func (c *ImportCoordinator) pruneStreams(ctx context.Context) error {
+	streams := &imagev1.ImageStreamList{}
+	if err := c.client.List(ctx, streams, ctrlruntimeclient.InNamespace(c.namespace), ctrlruntimeclient.MatchingLabels{importCoordinationLabel: "true"}); err != nil {
+		return fmt.Errorf("could not list the imagestreams of image imports: %w", err)
+	}
+	var errs []error
+	for i := range streams.Items {
+		stream := &streams.Items[i]
+		lastUsed := stream.CreationTimestamp.Time
+		for _, annotation := range []string{importedAnnotation, importClaimAnnotation} {
+			if _, at, ok := annotatedTime(stream, annotation); ok && at.After(lastUsed) {
+				lastUsed = at
+			}
+		}
+		if c.now().Sub(lastUsed) < importRetention {
+			continue
+		}
+		logrus.WithField("imagestream", fmt.Sprintf("%s/%s", c.namespace, stream.Name)).Debug("Pruning the imagestream of an unused image import")
+		if err := c.client.Delete(ctx, stream, ctrlruntimeclient.Preconditions{ResourceVersion: &stream.ResourceVersion}); err != nil && !kerrors.IsNotFound(err) && !kerrors.IsConflict(err) {
+			errs = append(errs, fmt.Errorf("could not delete the %s/%s imagestream: %w", c.namespace, stream.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
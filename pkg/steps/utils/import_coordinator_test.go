@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+// importingClient imports images into the status of the imagestreams, adding
+// a tag event only when the image changed
+type importingClient struct {
+	ctrlruntimeclient.Client
+	now     time.Time
+	imports int
+}
+
+func (c *importingClient) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	streamImport, ok := obj.(*imagev1.ImageStreamImport)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	c.imports++
+	stream := &imagev1.ImageStream{}
+	if err := c.Client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: streamImport.Namespace, Name: streamImport.Name}, stream); err != nil {
+		return err
+	}
+	tag := streamImport.Spec.Images[0].To.Name
+	if len(stream.Status.Tags) == 0 || stream.Status.Tags[0].Items[0].Image != "sha256:new" {
+		stream.Status.Tags = []imagev1.NamedTagEventList{{
+			Tag:   tag,
+			Items: []imagev1.TagEvent{{Image: "sha256:new", Created: metav1.Time{Time: c.now}}},
+		}}
+	}
+	if err := c.Client.Update(ctx, stream); err != nil {
+		return err
+	}
+	streamImport.Status.Images = []imagev1.ImageImportStatus{{Image: &imagev1.Image{DockerImageReference: "quay.io/org/image@sha256:new"}}}
+	return nil
+}
+
+func TestImportCoordinatorSource(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	pullSpec := "quay.io/org/image:latest"
+	name := importStream(pullSpec)
+	stream := func(annotations map[string]string, imported ...imagev1.TagEvent) *imagev1.ImageStream {
+		s := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "imports",
+			Name:              name,
+			Labels:            map[string]string{importCoordinationLabel: "true"},
+			Annotations:       annotations,
+			CreationTimestamp: metav1.Time{Time: now.Add(-72 * time.Hour)},
+		}}
+		if len(imported) > 0 {
+			s.Status.Tags = []imagev1.NamedTagEventList{{Tag: importStreamTag, Items: imported}}
+		}
+		return s
+	}
+	newRef := &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "imports", Name: name + "@sha256:new"}
+	for _, tc := range []struct {
+		name            string
+		objects         []ctrlruntimeclient.Object
+		expected        *coreapi.ObjectReference
+		expectedImports int
+		expectedErr     bool
+	}{
+		{
+			name:            "first import",
+			expected:        newRef,
+			expectedImports: 1,
+		},
+		{
+			name: "import finished since the image was asked for is joined",
+			objects: []ctrlruntimeclient.Object{stream(
+				map[string]string{importedAnnotation: "sha256:old " + now.Format(time.RFC3339)},
+				imagev1.TagEvent{Image: "sha256:old", Created: metav1.Time{Time: now.Add(-48 * time.Hour)}},
+			)},
+			expected: &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "imports", Name: name + "@sha256:old"},
+		},
+		{
+			name: "import finished before the image was asked for is redone",
+			objects: []ctrlruntimeclient.Object{stream(
+				map[string]string{importedAnnotation: "sha256:old " + now.Add(-time.Minute).Format(time.RFC3339)},
+				imagev1.TagEvent{Image: "sha256:old", Created: metav1.Time{Time: now.Add(-time.Minute)}},
+			)},
+			expected:        newRef,
+			expectedImports: 1,
+		},
+		{
+			name: "import which did not change the image is used",
+			objects: []ctrlruntimeclient.Object{stream(
+				map[string]string{importedAnnotation: "sha256:new " + now.Add(-2*time.Hour).Format(time.RFC3339)},
+				imagev1.TagEvent{Image: "sha256:new", Created: metav1.Time{Time: now.Add(-48 * time.Hour)}},
+			)},
+			expected:        newRef,
+			expectedImports: 1,
+		},
+		{
+			name:            "import not recorded by a job is redone",
+			objects:         []ctrlruntimeclient.Object{stream(nil, imagev1.TagEvent{Image: "sha256:old", Created: metav1.Time{Time: now.Add(-time.Minute)}})},
+			expected:        newRef,
+			expectedImports: 1,
+		},
+		{
+			name:            "expired claim is taken over",
+			objects:         []ctrlruntimeclient.Object{stream(map[string]string{importClaimAnnotation: "ci-op-other " + now.Add(-time.Hour).Format(time.RFC3339)})},
+			expected:        newRef,
+			expectedImports: 1,
+		},
+		{
+			name:        "waiting for the import of another job",
+			objects:     []ctrlruntimeclient.Object{stream(map[string]string{importClaimAnnotation: "ci-op-other " + now.Format(time.RFC3339)})},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &importingClient{Client: fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build(), now: now}
+			coordinator := NewImportCoordinator(client, "imports", func() string { return "ci-op-self" })
+			coordinator.now = func() time.Time { return now }
+			coordinator.poll = time.Millisecond
+			coordinator.timeout = 50 * time.Millisecond
+			ref, err := coordinator.Source(context.Background(), pullSpec)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, ref); diff != "" {
+				t.Errorf("unexpected reference: %s", diff)
+			}
+			if client.imports != tc.expectedImports {
+				t.Errorf("expected %d imports, got %d", tc.expectedImports, client.imports)
+			}
+			if tc.expectedErr {
+				return
+			}
+			actual := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "imports", Name: name}, actual); err != nil {
+				t.Fatalf("failed to get the imagestream: %v", err)
+			}
+			if value, claimed := actual.Annotations[importClaimAnnotation]; claimed && tc.expectedImports > 0 {
+				t.Errorf("expected the claim to be released, got %q", value)
+			}
+			if expected := "sha256:new " + now.Format(time.RFC3339); tc.expectedImports > 0 && actual.Annotations[importedAnnotation] != expected {
+				t.Errorf("expected the import to be recorded as %q, got %q", expected, actual.Annotations[importedAnnotation])
+			}
+		})
+	}
+}
+
+func TestImportCoordinatorPrune(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	stream := func(name string, labels, annotations map[string]string) *imagev1.ImageStream {
+		return &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "imports",
+			Name:              name,
+			Labels:            labels,
+			Annotations:       annotations,
+			CreationTimestamp: metav1.Time{Time: now.Add(-72 * time.Hour)},
+		}}
+	}
+	coordinated := map[string]string{importCoordinationLabel: "true"}
+	client := &importingClient{Client: fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		stream("import-unused", coordinated, map[string]string{importedAnnotation: "sha256:old " + now.Add(-48*time.Hour).Format(time.RFC3339)}),
+		stream("import-never-imported", coordinated, nil),
+		stream("import-recent", coordinated, map[string]string{importedAnnotation: "sha256:old " + now.Add(-2*time.Hour).Format(time.RFC3339)}),
+		stream("import-claimed", coordinated, map[string]string{importClaimAnnotation: "ci-op-other " + now.Add(-time.Minute).Format(time.RFC3339)}),
+		stream("unrelated", nil, nil),
+	).Build(), now: now}
+	coordinator := NewImportCoordinator(client, "imports", func() string { return "ci-op-self" })
+	coordinator.now = func() time.Time { return now }
+	coordinator.poll = time.Millisecond
+	coordinator.timeout = 50 * time.Millisecond
+	if _, err := coordinator.Source(context.Background(), "quay.io/org/image:latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	streams := &imagev1.ImageStreamList{}
+	if err := client.List(context.Background(), streams); err != nil {
+		t.Fatalf("failed to list the imagestreams: %v", err)
+	}
+	var actual []string
+	for _, s := range streams.Items {
+		actual = append(actual, s.Name)
+	}
+	expected := []string{"import-claimed", importStream("quay.io/org/image:latest"), "import-recent", "unrelated"}
+	sort.Strings(actual)
+	sort.Strings(expected)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected imagestreams: %s", diff)
+	}
+}
@@ -0,0 +1,192 @@
+// Package targetsummary consolidates the results of the targets of a
+// ci-operator run into a summary, as runs with many targets are hard to
+// skim from the step logs alone.
+package targetsummary
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+	"sigs.k8s.io/prow/pkg/pod-utils/gcs"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ArtifactFilename is the name of the artifact holding the summary.
+const ArtifactFilename = "ci-operator-summary.md"
+
+// Status is the outcome of a target.
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusInterrupted is a target which started but did not finish, e.g.
+	// because the job was aborted.
+	StatusInterrupted Status = "interrupted"
+	// StatusNotRun is a target which did not start, e.g. because one of
+	// its dependencies failed.
+	StatusNotRun Status = "not run"
+)
+
+// Target is the outcome of a single target of the run.
+type Target struct {
+	Name   string
+	Status Status
+	// Duration is how long the step of the target ran, zero if it did not.
+	Duration time.Duration
+	// Artifacts is the URL of the artifacts of the target, if known.
+	Artifacts string
+	// ArtifactsTruncated is set when artifacts of the target were truncated
+	// or left out to respect the artifact size limit.
+	ArtifactsTruncated bool
+}
+
+// Summarize determines the outcome of each target from the step graph of
+// the run. Artifacts links are only set when the URL of the artifacts of the
+// job is known.
+func Summarize(targets []string, graph api.CIOperatorStepGraph, artifactsURL string) []Target {
+	steps := map[string]api.CIOperatorStepDetails{}
+	for _, step := range graph {
+		steps[step.StepName] = step
+	}
+	var ret []Target
+	for _, name := range targets {
+		target := Target{Name: name, Status: StatusNotRun}
+		if artifactsURL != "" {
+			target.Artifacts = artifactsURL + "/" + name + "/"
+		}
+		if step, ok := steps[name]; ok && step.StartedAt != nil {
+			switch {
+			case step.Failed != nil && *step.Failed:
+				target.Status = StatusFailed
+			case step.FinishedAt == nil:
+				target.Status = StatusInterrupted
+			default:
+				target.Status = StatusSucceeded
+			}
+			if step.Duration != nil {
+				target.Duration = *step.Duration
+			}
+		}
+		ret = append(ret, target)
+	}
+	return ret
+}
+
+// MarkTruncatedArtifacts flags the targets with truncated artifacts, from
+// the artifact manifests written in their artifact directories.
+func MarkTruncatedArtifacts(targets []Target, artifactDir string) {
+	for i := range targets {
+		manifest, err := api.LoadArtifactManifest(filepath.Join(artifactDir, targets[i].Name))
+		if err != nil {
+			logrus.WithError(err).Warnf("Failed to load the artifact manifest of %s.", targets[i].Name)
+			continue
+		}
+		targets[i].ArtifactsTruncated = manifest != nil && manifest.Truncated()
+	}
+}
+
+// Markdown formats the summary as a table.
+func Markdown(targets []Target) string {
+	var succeeded int
+	for _, target := range targets {
+		if target.Status == StatusSucceeded {
+			succeeded++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d targets succeeded.\n\n", succeeded, len(targets))
+	b.WriteString("| Target | Status | Duration | Artifacts |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, target := range targets {
+		duration, artifacts := "-", "-"
+		if target.Duration != 0 {
+			duration = target.Duration.Truncate(time.Second).String()
+		}
+		if target.Artifacts != "" {
+			artifacts = fmt.Sprintf("[artifacts](%s)", target.Artifacts)
+		}
+		if target.ArtifactsTruncated {
+			artifacts += " (truncated)"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", target.Name, target.Status, duration, artifacts)
+	}
+	return b.String()
+}
+
+// ArtifactsURL is the URL of the artifacts directory of a job in the GCS web
+// front-end, empty when the job does not upload its artifacts.
+func ArtifactsURL(spec *downwardapi.JobSpec) string {
+	if spec.DecorationConfig == nil || spec.DecorationConfig.GCSConfiguration == nil {
+		return ""
+	}
+	config := spec.DecorationConfig.GCSConfiguration
+	var builder gcs.RepoPathBuilder
+	switch config.PathStrategy {
+	case prowapi.PathStrategyExplicit:
+		builder = gcs.NewExplicitRepoPathBuilder()
+	case prowapi.PathStrategyLegacy:
+		builder = gcs.NewLegacyRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+	case prowapi.PathStrategySingle:
+		builder = gcs.NewSingleDefaultRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+	default:
+		return ""
+	}
+	switch spec.Type {
+	case prowapi.PeriodicJob, prowapi.PostsubmitJob, prowapi.BatchJob:
+	case prowapi.PresubmitJob:
+		if spec.Refs == nil || len(spec.Refs.Pulls) == 0 {
+			return ""
+		}
+	default:
+		return ""
+	}
+	bucket := strings.TrimPrefix(config.Bucket, "gs://")
+	return fmt.Sprintf("%s/gcs/%s", api.URLForService(api.ServiceGCSWeb), path.Join(bucket, gcs.PathForSpec(spec, builder), "artifacts"))
+}
+
+// CommentClient is the part of the GitHub client needed to comment the
+// summary on a pull request.
+type CommentClient interface {
+	BotUserChecker() (func(candidate string) bool, error)
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
+	CreateComment(org, repo string, number int, comment string) error
+	EditComment(org, repo string, id int, comment string) error
+}
+
+// commentMarker identifies the comments holding the summary of a job, so that
+// later runs of the job edit the comment instead of adding another one.
+func commentMarker(job string) string {
+	return fmt.Sprintf("<!-- ci-operator target summary: %s -->", job)
+}
+
+// Comment comments the summary of the targets of a job on a pull request. The
+// comment of an earlier run of the job is edited when there is one, so that
+// the pull request holds a single summary per job.
+func Comment(client CommentClient, org, repo string, number int, job, summary string) error {
+	marker := commentMarker(job)
+	body := fmt.Sprintf("%s\nSummary of the targets of %s:\n\n%s", marker, job, summary)
+	isBot, err := client.BotUserChecker()
+	if err != nil {
+		return fmt.Errorf("failed to determine the user of the client: %w", err)
+	}
+	comments, err := client.ListIssueComments(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list the comments of the pull request: %w", err)
+	}
+	for _, comment := range comments {
+		if isBot(comment.User.Login) && strings.Contains(comment.Body, marker) {
+			return client.EditComment(org, repo, comment.ID, body)
+		}
+	}
+	return client.CreateComment(org, repo, number, body)
+}
@@ -0,0 +1,187 @@
+package targetsummary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/prow/pkg/github/fakegithub"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	duration := time.Hour + 2*time.Second + 300*time.Millisecond
+	yes, no := true, false
+	graph := api.CIOperatorStepGraph{
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "src", StartedAt: &start, FinishedAt: &end, Duration: &duration, Failed: &no}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "unit", StartedAt: &start, FinishedAt: &end, Duration: &duration, Failed: &no}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "e2e", StartedAt: &start, FinishedAt: &end, Duration: &duration, Failed: &yes}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "lint", StartedAt: &start}},
+	}
+	actual := Summarize([]string{"unit", "e2e", "lint", "images"}, graph, "https://artifacts")
+	expected := []Target{
+		{Name: "unit", Status: StatusSucceeded, Duration: duration, Artifacts: "https://artifacts/unit/"},
+		{Name: "e2e", Status: StatusFailed, Duration: duration, Artifacts: "https://artifacts/e2e/"},
+		{Name: "lint", Status: StatusInterrupted, Artifacts: "https://artifacts/lint/"},
+		{Name: "images", Status: StatusNotRun, Artifacts: "https://artifacts/images/"},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("unexpected summary: %s", diff)
+	}
+	expectedMarkdown := "1 of 4 targets succeeded.\n\n" +
+		"| Target | Status | Duration | Artifacts |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `unit` | succeeded | 1h0m2s | [artifacts](https://artifacts/unit/) |\n" +
+		"| `e2e` | failed | 1h0m2s | [artifacts](https://artifacts/e2e/) |\n" +
+		"| `lint` | interrupted | - | [artifacts](https://artifacts/lint/) |\n" +
+		"| `images` | not run | - | [artifacts](https://artifacts/images/) |\n"
+	if diff := cmp.Diff(expectedMarkdown, Markdown(actual)); diff != "" {
+		t.Errorf("unexpected markdown: %s", diff)
+	}
+}
+
+func TestMarkTruncatedArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for name, manifest := range map[string]string{
+		"unit": `{"files":[{"name":"a.txt","size":5,"sha256":"abc"}]}`,
+		"e2e":  `{"size_limit":8,"files":[{"name":"a.txt","size":5,"sha256":"abc"},{"name":"b.txt","truncated":true}]}`,
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, api.ArtifactManifestFilename), []byte(manifest), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := []Target{
+		{Name: "unit", Status: StatusSucceeded, Artifacts: "https://artifacts/unit/"},
+		{Name: "e2e", Status: StatusFailed, Artifacts: "https://artifacts/e2e/"},
+		{Name: "images", Status: StatusNotRun, Artifacts: "https://artifacts/images/"},
+	}
+	MarkTruncatedArtifacts(targets, dir)
+	expected := []Target{
+		{Name: "unit", Status: StatusSucceeded, Artifacts: "https://artifacts/unit/"},
+		{Name: "e2e", Status: StatusFailed, Artifacts: "https://artifacts/e2e/", ArtifactsTruncated: true},
+		{Name: "images", Status: StatusNotRun, Artifacts: "https://artifacts/images/"},
+	}
+	if diff := cmp.Diff(expected, targets); diff != "" {
+		t.Fatalf("unexpected targets: %s", diff)
+	}
+	expectedMarkdown := "1 of 3 targets succeeded.\n\n" +
+		"| Target | Status | Duration | Artifacts |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `unit` | succeeded | - | [artifacts](https://artifacts/unit/) |\n" +
+		"| `e2e` | failed | - | [artifacts](https://artifacts/e2e/) (truncated) |\n" +
+		"| `images` | not run | - | [artifacts](https://artifacts/images/) |\n"
+	if diff := cmp.Diff(expectedMarkdown, Markdown(targets)); diff != "" {
+		t.Errorf("unexpected markdown: %s", diff)
+	}
+}
+
+func TestArtifactsURL(t *testing.T) {
+	gcsConfig := &prowapi.GCSConfiguration{
+		Bucket:       "gs://test-platform-results",
+		PathStrategy: prowapi.PathStrategySingle,
+		DefaultOrg:   "openshift",
+		DefaultRepo:  "origin",
+	}
+	for _, tc := range []struct {
+		name     string
+		spec     downwardapi.JobSpec
+		expected string
+	}{
+		{
+			name: "presubmit",
+			spec: downwardapi.JobSpec{
+				Type:             prowapi.PresubmitJob,
+				Job:              "pull-ci-openshift-installer-master-images",
+				BuildID:          "123",
+				Refs:             &prowapi.Refs{Org: "openshift", Repo: "installer", Pulls: []prowapi.Pull{{Number: 42}}},
+				DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: gcsConfig},
+			},
+			expected: "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com/gcs/test-platform-results/pr-logs/pull/openshift_installer/42/pull-ci-openshift-installer-master-images/123/artifacts",
+		},
+		{
+			name: "periodic",
+			spec: downwardapi.JobSpec{
+				Type:             prowapi.PeriodicJob,
+				Job:              "periodic-ci-openshift-installer-master-e2e",
+				BuildID:          "123",
+				DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: gcsConfig},
+			},
+			expected: "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com/gcs/test-platform-results/logs/periodic-ci-openshift-installer-master-e2e/123/artifacts",
+		},
+		{
+			name: "undecorated job",
+			spec: downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "periodic", BuildID: "123"},
+		},
+		{
+			name: "presubmit without pulls",
+			spec: downwardapi.JobSpec{
+				Type:             prowapi.PresubmitJob,
+				Refs:             &prowapi.Refs{Org: "openshift", Repo: "installer"},
+				DecorationConfig: &prowapi.DecorationConfig{GCSConfiguration: gcsConfig},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, ArtifactsURL(&tc.spec)); diff != "" {
+				t.Errorf("unexpected URL: %s", diff)
+			}
+		})
+	}
+}
+
+func TestComment(t *testing.T) {
+	job := "pull-ci-org-repo-master-e2e"
+	body := commentMarker(job) + "\nSummary of the targets of " + job + ":\n\nsummary"
+	for _, tc := range []struct {
+		name           string
+		comments       []github.IssueComment
+		expectedAdded  []string
+		expectedEdited []string
+	}{
+		{
+			name:          "first run comments",
+			comments:      []github.IssueComment{{ID: 1, Body: "/test all", User: github.User{Login: "author"}}},
+			expectedAdded: []string{"org/repo#1:" + body},
+		},
+		{
+			name: "later runs edit the comment of the job",
+			comments: []github.IssueComment{
+				{ID: 1, Body: commentMarker("pull-ci-org-repo-master-unit") + "\nother job", User: github.User{Login: "k8s-ci-robot"}},
+				{ID: 2, Body: commentMarker(job) + "\nearlier run", User: github.User{Login: "k8s-ci-robot"}},
+			},
+			expectedEdited: []string{"org/repo#2:" + body},
+		},
+		{
+			name:          "comments of other users are not edited",
+			comments:      []github.IssueComment{{ID: 1, Body: "quoting " + commentMarker(job), User: github.User{Login: "author"}}},
+			expectedAdded: []string{"org/repo#1:" + body},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakegithub.NewFakeClient()
+			client.IssueComments[1] = tc.comments
+			client.IssueCommentID = len(tc.comments)
+			if err := Comment(client, "org", "repo", 1, job, "summary"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedAdded, client.IssueCommentsAdded); diff != "" {
+				t.Errorf("unexpected added comments: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedEdited, client.IssueCommentsEdited); diff != "" {
+				t.Errorf("unexpected edited comments: %s", diff)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,7 +15,6 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -24,7 +25,9 @@ import (
 
 type FakePodExecutor struct {
 	loggingclient.LoggingClient
-	Failures    sets.Set[string]
+	Failures sets.Set[string]
+	// Running holds the names of Pods which never finish.
+	Running     sets.Set[string]
 	CreatedPods []*coreapi.Pod
 	lock        sync.Mutex
 }
@@ -55,6 +58,9 @@ func (f *FakePodExecutor) Get(ctx context.Context, n ctrlruntimeclient.ObjectKey
 }
 
 func (f *FakePodExecutor) Watch(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) (watch.Interface, error) {
+	if _, ok := list.(*coreapi.PodList); !ok {
+		return f.LoggingClient.Watch(ctx, list, opts...)
+	}
 	if err := f.LoggingClient.List(ctx, list, opts...); err != nil {
 		return nil, err
 	}
@@ -69,6 +75,15 @@ func (f *FakePodExecutor) Watch(ctx context.Context, list ctrlruntimeclient.Obje
 }
 
 func (f *FakePodExecutor) process(pod *coreapi.Pod) {
+	if f.Running.Has(pod.Name) {
+		pod.Status.Phase = coreapi.PodRunning
+		for _, container := range pod.Spec.Containers {
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, coreapi.ContainerStatus{
+				Name:  container.Name,
+				State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{}}})
+		}
+		return
+	}
 	fail := f.Failures.Has(pod.Name)
 	if fail {
 		pod.Status.Phase = coreapi.PodFailed
@@ -102,6 +117,8 @@ type FakePodClient struct {
 	*FakePodExecutor
 	Namespace, Name string
 	PendingTimeout  time.Duration
+	// Logs holds the logs of containers, keyed by namespace/pod/container.
+	Logs map[string]string
 }
 
 func (f FakePodClient) GetPendingTimeout() time.Duration {
@@ -118,8 +135,24 @@ func (f *FakePodClient) Exec(namespace, name string, opts *coreapi.PodExecOption
 	return &testExecutor{command: opts.Command}, nil
 }
 
-func (*FakePodClient) GetLogs(string, string, *coreapi.PodLogOptions) *rest.Request {
-	return rest.NewRequestWithClient(nil, "", rest.ClientContentConfig{}, nil)
+func (f *FakePodClient) StreamLogs(_ context.Context, namespace, name string, opts *coreapi.PodLogOptions) (io.ReadCloser, error) {
+	logs, ok := f.Logs[fmt.Sprintf("%s/%s/%s", namespace, name, opts.Container)]
+	if !ok {
+		return nil, errors.New("logs are not available from the fake pod client")
+	}
+	if opts.TailLines != nil {
+		lines := strings.SplitAfter(logs, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if int64(len(lines)) > *opts.TailLines {
+			logs = strings.Join(lines[int64(len(lines))-*opts.TailLines:], "")
+		}
+	}
+	if opts.LimitBytes != nil && int64(len(logs)) > *opts.LimitBytes {
+		logs = logs[:*opts.LimitBytes]
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
 }
 
 func (f *FakePodClient) WithNewLoggingClient() kubernetes.PodClient {
@@ -142,6 +175,9 @@ AAAAAAAAAA4GtXigWTnQAoAAA=`)
 		}
 		_, err = opts.Stdout.Write(tar)
 		return err
+	} else if reflect.DeepEqual(e.command, []string{"/bin/sh", "-c", "cd /tmp/artifacts && find . -type f -exec sha256sum {} +"}) {
+		_, err := opts.Stdout.Write([]byte("f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2  ./test.txt\n"))
+		return err
 	} else if reflect.DeepEqual(e.command, []string{"rm", "-f", "/tmp/done"}) {
 		return nil
 	}
@@ -160,6 +196,9 @@ AAAAAAAAAA4GtXigWTnQAoAAA=`)
 		}
 		_, err = opts.Stdout.Write(tar)
 		return err
+	} else if reflect.DeepEqual(e.command, []string{"/bin/sh", "-c", "cd /tmp/artifacts && find . -type f -exec sha256sum {} +"}) {
+		_, err := opts.Stdout.Write([]byte("f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2  ./test.txt\n"))
+		return err
 	} else if reflect.DeepEqual(e.command, []string{"rm", "-f", "/tmp/done"}) {
 		return nil
 	}
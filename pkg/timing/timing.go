@@ -0,0 +1,219 @@
+// Package timing breaks the time ci-operator steps took down into the phases
+// it was spent in, based on the objects the steps created.
+package timing
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	buildv1 "github.com/openshift/api/build/v1"
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ArtifactFilename is the name of the artifact holding the breakdown.
+const ArtifactFilename = "ci-operator-timing.json"
+
+// uploadContainers are the containers that upload the logs and artifacts of
+// a pod, rather than doing the work of the step.
+var uploadContainers = sets.New[string]("initupload", "sidecar", "artifacts")
+
+// Step is the time a step spent in each phase. Times are in seconds. The
+// phases do not necessarily add up to the total, as the work done by a step
+// outside of pods and builds is not accounted for, and pods may run in
+// parallel.
+type Step struct {
+	Name  string  `json:"name"`
+	Total float64 `json:"total"`
+	// WaitingOnDependencies is the time between the start of the execution
+	// and the step becoming ready to run, when the last of its dependencies
+	// finished. The step may have started later than that.
+	WaitingOnDependencies float64 `json:"waiting_on_dependencies"`
+	// Queueing is the time pods waited to be scheduled and builds waited
+	// to start.
+	Queueing float64 `json:"queueing"`
+	// ImagePull is the time between pods being scheduled and their first
+	// container starting, and the time builds spent pulling images.
+	ImagePull float64 `json:"image_pull"`
+	// Execution is the time the containers doing the work of the step ran
+	// for, and the time builds spent building.
+	Execution float64 `json:"execution"`
+	// ArtifactUpload is the time spent uploading logs and artifacts after
+	// the work was done, and the time builds spent pushing images.
+	ArtifactUpload float64 `json:"artifact_upload"`
+	// ResourceUsage is the peak usage of the pods of the step, by the name
+	// of the sub-step running them.
+	ResourceUsage map[string]api.StepResourceUsage `json:"resource_usage,omitempty"`
+}
+
+type phases struct {
+	queueing, imagePull, execution, artifactUpload time.Duration
+}
+
+// Breakdown computes the time spent per phase for every step of the graph
+// that ran, sorted by the time the steps started.
+func Breakdown(graph api.CIOperatorStepGraph) []Step {
+	var ran []api.CIOperatorStepDetails
+	for _, step := range graph {
+		if step.StartedAt != nil && step.Duration != nil {
+			ran = append(ran, step)
+		}
+	}
+	sort.SliceStable(ran, func(i, j int) bool {
+		return ran[i].StartedAt.Before(*ran[j].StartedAt)
+	})
+	finished := map[string]time.Time{}
+	for _, step := range ran {
+		finished[step.StepName] = step.StartedAt.Add(*step.Duration)
+	}
+	var steps []Step
+	for _, step := range ran {
+		var p phases
+		for _, manifest := range step.Manifests {
+			switch obj := manifest.(type) {
+			case *coreapi.Pod:
+				p.addPod(obj)
+			case *buildv1.Build:
+				p.addBuild(obj)
+			}
+		}
+		var usage map[string]api.StepResourceUsage
+		for _, substep := range step.Substeps {
+			if substep.ResourceUsage == nil {
+				continue
+			}
+			if usage == nil {
+				usage = map[string]api.StepResourceUsage{}
+			}
+			usage[substep.StepName] = *substep.ResourceUsage
+		}
+		steps = append(steps, Step{
+			Name:                  step.StepName,
+			Total:                 step.Duration.Seconds(),
+			WaitingOnDependencies: between(*ran[0].StartedAt, readyAt(step, finished)).Seconds(),
+			Queueing:              p.queueing.Seconds(),
+			ImagePull:             p.imagePull.Seconds(),
+			Execution:             p.execution.Seconds(),
+			ArtifactUpload:        p.artifactUpload.Seconds(),
+			ResourceUsage:         usage,
+		})
+	}
+	return steps
+}
+
+// readyAt is when the last of the dependencies of the step finished, or when
+// the step started if that was earlier.
+func readyAt(step api.CIOperatorStepDetails, finished map[string]time.Time) time.Time {
+	var ready time.Time
+	for _, dependency := range step.Dependencies {
+		if at, ok := finished[dependency]; ok && at.After(ready) {
+			ready = at
+		}
+	}
+	if ready.After(*step.StartedAt) {
+		return *step.StartedAt
+	}
+	return ready
+}
+
+// between is the duration between the two times, zero when either is unset
+// or they are out of order.
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+func (p *phases) addPod(pod *coreapi.Pod) {
+	var scheduled time.Time
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == coreapi.PodScheduled && condition.Status == coreapi.ConditionTrue {
+			scheduled = condition.LastTransitionTime.Time
+		}
+	}
+	p.queueing += between(pod.CreationTimestamp.Time, scheduled)
+
+	var firstStart, workStart, workEnd time.Time
+	type span struct{ started, finished time.Time }
+	upload := map[string]span{}
+	for _, status := range append(append([]coreapi.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		var s span
+		switch {
+		case status.State.Terminated != nil:
+			s = span{started: status.State.Terminated.StartedAt.Time, finished: status.State.Terminated.FinishedAt.Time}
+		case status.State.Running != nil:
+			s = span{started: status.State.Running.StartedAt.Time}
+		default:
+			continue
+		}
+		if firstStart.IsZero() || s.started.Before(firstStart) {
+			firstStart = s.started
+		}
+		if uploadContainers.Has(status.Name) {
+			upload[status.Name] = s
+			continue
+		}
+		if workStart.IsZero() || s.started.Before(workStart) {
+			workStart = s.started
+		}
+		if s.finished.After(workEnd) {
+			workEnd = s.finished
+		}
+	}
+	p.imagePull += between(scheduled, firstStart)
+	p.execution += between(workStart, workEnd)
+	for name, s := range upload {
+		if name == "initupload" {
+			p.artifactUpload += between(s.started, s.finished)
+		} else {
+			p.artifactUpload += between(workEnd, s.finished)
+		}
+	}
+}
+
+func (p *phases) addBuild(build *buildv1.Build) {
+	var start, completion time.Time
+	if build.Status.StartTimestamp != nil {
+		start = build.Status.StartTimestamp.Time
+	}
+	if build.Status.CompletionTimestamp != nil {
+		completion = build.Status.CompletionTimestamp.Time
+	}
+	p.queueing += between(build.CreationTimestamp.Time, start)
+	if len(build.Status.Stages) == 0 {
+		p.execution += between(start, completion)
+		return
+	}
+	for _, stage := range build.Status.Stages {
+		duration := time.Duration(stage.DurationMilliseconds) * time.Millisecond
+		switch stage.Name {
+		case buildv1.StagePullImages:
+			p.imagePull += duration
+		case buildv1.StagePushImage:
+			p.artifactUpload += duration
+		default:
+			p.execution += duration
+		}
+	}
+}
+
+// Table renders the breakdown as a human-readable table.
+func Table(steps []Step) string {
+	format := func(seconds float64) string {
+		return (time.Duration(seconds * float64(time.Second))).Truncate(time.Second).String()
+	}
+	var out bytes.Buffer
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tTOTAL\tDEPENDENCIES\tQUEUEING\tIMAGE PULL\tEXECUTION\tARTIFACT UPLOAD")
+	for _, step := range steps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", step.Name, format(step.Total), format(step.WaitingOnDependencies), format(step.Queueing), format(step.ImagePull), format(step.Execution), format(step.ArtifactUpload))
+	}
+	_ = w.Flush()
+	return out.String()
+}
@@ -0,0 +1,88 @@
+package timing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	buildv1 "github.com/openshift/api/build/v1"
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestBreakdown(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) metav1.Time { return metav1.NewTime(start.Add(d)) }
+	terminated := func(name string, from, to time.Duration) coreapi.ContainerStatus {
+		return coreapi.ContainerStatus{Name: name, State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{StartedAt: at(from), FinishedAt: at(to)}}}
+	}
+	step := func(name string, started, duration time.Duration, manifests ...ctrlruntimeclient.Object) api.CIOperatorStepDetails {
+		startedAt := start.Add(started)
+		return api.CIOperatorStepDetails{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: name, StartedAt: &startedAt, Duration: &duration, Manifests: manifests}}
+	}
+	pod := &coreapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: at(10 * time.Minute)},
+		Status: coreapi.PodStatus{
+			Conditions: []coreapi.PodCondition{
+				{Type: coreapi.PodReady, Status: coreapi.ConditionTrue, LastTransitionTime: at(20 * time.Minute)},
+				{Type: coreapi.PodScheduled, Status: coreapi.ConditionTrue, LastTransitionTime: at(12 * time.Minute)},
+			},
+			InitContainerStatuses: []coreapi.ContainerStatus{
+				terminated("initupload", 15*time.Minute, 16*time.Minute),
+				terminated("place-entrypoint", 16*time.Minute, 17*time.Minute),
+			},
+			ContainerStatuses: []coreapi.ContainerStatus{
+				terminated("test", 17*time.Minute, 40*time.Minute),
+				terminated("sidecar", 17*time.Minute, 43*time.Minute),
+			},
+		},
+	}
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: at(0)},
+		Status: buildv1.BuildStatus{
+			StartTimestamp: &metav1.Time{Time: start.Add(time.Minute)},
+			Stages: []buildv1.StageInfo{
+				{Name: buildv1.StageFetchInputs, DurationMilliseconds: 60_000},
+				{Name: buildv1.StagePullImages, DurationMilliseconds: 120_000},
+				{Name: buildv1.StageBuild, DurationMilliseconds: 300_000},
+				{Name: buildv1.StagePushImage, DurationMilliseconds: 30_000},
+			},
+		},
+	}
+	// e2e became ready once src finished, but started later
+	e2e := step("e2e", 12*time.Minute, 35*time.Minute, pod)
+	e2e.Dependencies = []string{"src", "not-run"}
+	e2e.Substeps = []api.CIOperatorStepDetailInfo{
+		{StepName: "e2e-install", ResourceUsage: &api.StepResourceUsage{CPUMillicores: 1500, MemoryBytes: 2 << 30}},
+		{StepName: "e2e-metrics-unavailable"},
+	}
+	graph := api.CIOperatorStepGraph{
+		e2e,
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "not-run"}},
+		step("src", 0, 10*time.Minute, build),
+		step("lint", 5*time.Minute, time.Minute),
+	}
+	expected := []Step{
+		{Name: "src", Total: 600, Queueing: 60, ImagePull: 120, Execution: 360, ArtifactUpload: 30},
+		{Name: "lint", Total: 60},
+		{Name: "e2e", Total: 2100, WaitingOnDependencies: 600, Queueing: 120, ImagePull: 180, Execution: 1440, ArtifactUpload: 240, ResourceUsage: map[string]api.StepResourceUsage{
+			"e2e-install": {CPUMillicores: 1500, MemoryBytes: 2 << 30},
+		}},
+	}
+	breakdown := Breakdown(graph)
+	if diff := cmp.Diff(expected, breakdown); diff != "" {
+		t.Fatalf("unexpected breakdown: %s", diff)
+	}
+
+	expectedTable := `STEP  TOTAL  DEPENDENCIES  QUEUEING  IMAGE PULL  EXECUTION  ARTIFACT UPLOAD
+src   10m0s  0s            1m0s      2m0s        6m0s       30s
+lint  1m0s   0s            0s        0s          0s         0s
+e2e   35m0s  10m0s         2m0s      3m0s        24m0s      4m0s
+`
+	if diff := cmp.Diff(expectedTable, Table(breakdown)); diff != "" {
+		t.Errorf("unexpected table: %s", diff)
+	}
+}
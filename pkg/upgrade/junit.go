@@ -0,0 +1,33 @@
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// JUnit reports the upgrade as a single test case, failing with the
+// diagnostics when the upgrade did not complete.
+func JUnit(result *Result) *junit.TestSuites {
+	testCase := &junit.TestCase{
+		Name:     fmt.Sprintf("Cluster upgrades to %s", result.Target),
+		Duration: result.Finished.Sub(result.Started).Seconds(),
+	}
+	if !result.Completed {
+		var out strings.Builder
+		for _, diagnostic := range result.Diagnostics {
+			out.WriteString(diagnostic + "\n")
+		}
+		if len(result.Progress) != 0 {
+			last := result.Progress[len(result.Progress)-1]
+			fmt.Fprintf(&out, "Last observed: upgrade %s to %s, %d/%d operators updated. %s\n", last.State, last.Version, last.UpdatedOperators, last.TotalOperators, last.Progressing)
+		}
+		testCase.FailureOutput = &junit.FailureOutput{Message: result.Error, Output: out.String()}
+	}
+	suite := &junit.TestSuite{Name: "cluster-upgrade", NumTests: 1, Duration: testCase.Duration, TestCases: []*junit.TestCase{testCase}}
+	if testCase.FailureOutput != nil {
+		suite.NumFailed = 1
+	}
+	return &junit.TestSuites{Suites: []*junit.TestSuite{suite}}
+}
@@ -0,0 +1,285 @@
+// Package upgrade orchestrates the upgrade of a cluster to a release payload
+// the way `oc adm upgrade --to-image` does, polling the status of the
+// ClusterVersion until the upgrade completes and recording its progress.
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// ProgressFile is the name of the artifact recording the progress of the
+	// upgrade.
+	ProgressFile = "upgrade-progress.json"
+	// JUnitFile is the name of the jUnit file reporting the upgrade.
+	JUnitFile = "junit_upgrade.xml"
+
+	// clusterVersionName is the name of the singleton ClusterVersion.
+	clusterVersionName = "version"
+	// The ClusterVersion conditions without a constant in the API.
+	conditionFailing         configv1.ClusterStatusConditionType = "Failing"
+	conditionReleaseAccepted configv1.ClusterStatusConditionType = "ReleaseAccepted"
+)
+
+// Options configure an upgrade.
+type Options struct {
+	// Target is the pull spec of the release payload to upgrade to.
+	Target string
+	// Channel is set on the cluster before upgrading, when not empty.
+	Channel string
+	// Timeout is how long to wait for the upgrade to complete.
+	Timeout time.Duration
+	// Force determines when the upgrade is forced.
+	Force api.UpgradeForce
+	// PollInterval is how often the status of the cluster is checked.
+	PollInterval time.Duration
+}
+
+// Progress is the status of the upgrade at a point in time.
+type Progress struct {
+	Time time.Time `json:"time"`
+	// State is the state of the most recent update in the history.
+	State configv1.UpdateState `json:"state,omitempty"`
+	// Version is the version of the most recent update in the history.
+	Version string `json:"version,omitempty"`
+	// Progressing, Failing and Available are the messages of the
+	// corresponding conditions of the ClusterVersion.
+	Progressing string `json:"progressing,omitempty"`
+	Failing     string `json:"failing,omitempty"`
+	Available   string `json:"available,omitempty"`
+	// UpdatedOperators is the number of cluster operators at the desired
+	// version, out of TotalOperators.
+	UpdatedOperators int `json:"updated_operators"`
+	TotalOperators   int `json:"total_operators"`
+}
+
+// changedFrom determines whether the progress is worth recording after the
+// previous one.
+func (p Progress) changedFrom(previous Progress) bool {
+	previous.Time = p.Time
+	return p != previous
+}
+
+// Result is the machine-readable record of an upgrade.
+type Result struct {
+	Target   string    `json:"target"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Forced is set when the upgrade was forced.
+	Forced bool `json:"forced"`
+	// Completed is set when the cluster finished upgrading to the target.
+	Completed bool   `json:"completed"`
+	Error     string `json:"error,omitempty"`
+	// Progress lists the distinct states the upgrade went through.
+	Progress []Progress `json:"progress"`
+	// Diagnostics describe why the upgrade did not complete.
+	Diagnostics []string `json:"diagnostics,omitempty"`
+}
+
+// Upgrader upgrades a cluster.
+type Upgrader struct {
+	client  ctrlruntimeclient.Client
+	options Options
+	now     func() time.Time
+}
+
+// New creates an upgrader for the cluster the client talks to.
+func New(client ctrlruntimeclient.Client, options Options) *Upgrader {
+	return &Upgrader{client: client, options: options, now: time.Now}
+}
+
+// Run starts the upgrade and waits for it to complete. The result is always
+// returned, the error is set when the upgrade did not complete.
+func (u *Upgrader) Run(ctx context.Context) (*Result, error) {
+	result := &Result{Target: u.options.Target, Started: u.now()}
+	err := u.run(ctx, result)
+	result.Finished = u.now()
+	if err != nil {
+		result.Error = err.Error()
+		result.Diagnostics = u.diagnose(ctx)
+	}
+	return result, err
+}
+
+func (u *Upgrader) run(ctx context.Context, result *Result) error {
+	forced := u.options.Force == api.UpgradeForceAlways
+	if err := u.requestUpgrade(ctx, forced); err != nil {
+		return err
+	}
+	result.Forced = forced
+	logrus.Infof("Requested the upgrade to %s.", u.options.Target)
+
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, u.options.PollInterval, u.options.Timeout, true, func(ctx context.Context) (bool, error) {
+		cv, operators, err := u.observe(ctx)
+		if err != nil {
+			lastErr = err
+			logrus.WithError(err).Warn("Failed to observe the upgrade, retrying.")
+			return false, nil
+		}
+		lastErr = nil
+		progress := progressOf(cv, operators, u.now())
+		if len(result.Progress) == 0 || progress.changedFrom(result.Progress[len(result.Progress)-1]) {
+			result.Progress = append(result.Progress, progress)
+			logrus.Infof("Upgrade %s to %s: %d/%d operators updated. %s", progress.State, progress.Version, progress.UpdatedOperators, progress.TotalOperators, progress.Progressing)
+		}
+		if completed(cv, u.options.Target) {
+			return true, nil
+		}
+		if !result.Forced && u.options.Force == api.UpgradeForceIfUnverified && unverified(cv) {
+			logrus.Info("The release payload could not be verified, forcing the upgrade.")
+			if err := u.requestUpgrade(ctx, true); err != nil {
+				return false, err
+			}
+			result.Forced = true
+		}
+		return false, nil
+	})
+	switch {
+	case err == nil:
+		result.Completed = true
+		return nil
+	case wait.Interrupted(err) && lastErr != nil:
+		return fmt.Errorf("upgrade to %s did not complete within %s: %w", u.options.Target, u.options.Timeout, lastErr)
+	case wait.Interrupted(err):
+		return fmt.Errorf("upgrade to %s did not complete within %s", u.options.Target, u.options.Timeout)
+	default:
+		return err
+	}
+}
+
+// requestUpgrade sets the channel and desired update on the ClusterVersion,
+// which the cluster-version operator updates concurrently.
+func (u *Upgrader) requestUpgrade(ctx context.Context, force bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cv := &configv1.ClusterVersion{}
+		if err := u.client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: clusterVersionName}, cv); err != nil {
+			return fmt.Errorf("failed to get the cluster version: %w", err)
+		}
+		if u.options.Channel != "" {
+			cv.Spec.Channel = u.options.Channel
+		}
+		cv.Spec.DesiredUpdate = &configv1.Update{Image: u.options.Target, Force: force}
+		if err := u.client.Update(ctx, cv); err != nil {
+			return fmt.Errorf("failed to request the upgrade: %w", err)
+		}
+		return nil
+	})
+}
+
+func (u *Upgrader) observe(ctx context.Context) (*configv1.ClusterVersion, []configv1.ClusterOperator, error) {
+	cv := &configv1.ClusterVersion{}
+	if err := u.client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: clusterVersionName}, cv); err != nil {
+		return nil, nil, fmt.Errorf("failed to get the cluster version: %w", err)
+	}
+	operators := &configv1.ClusterOperatorList{}
+	if err := u.client.List(ctx, operators); err != nil {
+		return nil, nil, fmt.Errorf("failed to list cluster operators: %w", err)
+	}
+	return cv, operators.Items, nil
+}
+
+func condition(conditions []configv1.ClusterOperatorStatusCondition, conditionType configv1.ClusterStatusConditionType) *configv1.ClusterOperatorStatusCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func progressOf(cv *configv1.ClusterVersion, operators []configv1.ClusterOperator, now time.Time) Progress {
+	progress := Progress{Time: now, TotalOperators: len(operators)}
+	if len(cv.Status.History) != 0 {
+		progress.State = cv.Status.History[0].State
+		progress.Version = cv.Status.History[0].Version
+	}
+	message := func(conditionType configv1.ClusterStatusConditionType, status configv1.ConditionStatus) string {
+		if c := condition(cv.Status.Conditions, conditionType); c != nil && c.Status == status {
+			return c.Message
+		}
+		return ""
+	}
+	progress.Progressing = message(configv1.OperatorProgressing, configv1.ConditionTrue)
+	progress.Failing = message(conditionFailing, configv1.ConditionTrue)
+	progress.Available = message(configv1.OperatorAvailable, configv1.ConditionTrue)
+	desired := cv.Status.Desired.Version
+	for _, operator := range operators {
+		for _, version := range operator.Status.Versions {
+			if version.Name == "operator" && desired != "" && version.Version == desired {
+				progress.UpdatedOperators++
+			}
+		}
+	}
+	return progress
+}
+
+// completed determines whether the cluster finished upgrading to the target.
+func completed(cv *configv1.ClusterVersion, target string) bool {
+	if len(cv.Status.History) == 0 {
+		return false
+	}
+	latest := cv.Status.History[0]
+	return latest.Image == target && latest.State == configv1.CompletedUpdate
+}
+
+// unverified determines whether the cluster refused the desired release
+// payload because its signature could not be verified.
+func unverified(cv *configv1.ClusterVersion) bool {
+	c := condition(cv.Status.Conditions, conditionReleaseAccepted)
+	return c != nil && c.Status == configv1.ConditionFalse && strings.Contains(strings.ToLower(c.Message), "verif")
+}
+
+// diagnose describes the state of the cluster which may explain why the
+// upgrade did not complete.
+func (u *Upgrader) diagnose(ctx context.Context) []string {
+	cv, operators, err := u.observe(ctx)
+	if err != nil {
+		return []string{fmt.Sprintf("Could not gather diagnostics: %v", err)}
+	}
+	return diagnostics(cv, operators)
+}
+
+func diagnostics(cv *configv1.ClusterVersion, operators []configv1.ClusterOperator) []string {
+	var ret []string
+	for _, conditionType := range []configv1.ClusterStatusConditionType{conditionFailing, conditionReleaseAccepted} {
+		c := condition(cv.Status.Conditions, conditionType)
+		if c == nil {
+			continue
+		}
+		if (conditionType == conditionFailing) == (c.Status == configv1.ConditionTrue) {
+			ret = append(ret, fmt.Sprintf("ClusterVersion %s=%s: %s: %s", c.Type, c.Status, c.Reason, c.Message))
+		}
+	}
+	sort.Slice(operators, func(i, j int) bool { return operators[i].Name < operators[j].Name })
+	for _, operator := range operators {
+		if c := condition(operator.Status.Conditions, configv1.OperatorAvailable); c == nil || c.Status != configv1.ConditionTrue {
+			ret = append(ret, operatorDiagnostic(operator.Name, configv1.OperatorAvailable, c))
+		}
+		if c := condition(operator.Status.Conditions, configv1.OperatorDegraded); c != nil && c.Status == configv1.ConditionTrue {
+			ret = append(ret, operatorDiagnostic(operator.Name, configv1.OperatorDegraded, c))
+		}
+	}
+	return ret
+}
+
+func operatorDiagnostic(name string, conditionType configv1.ClusterStatusConditionType, c *configv1.ClusterOperatorStatusCondition) string {
+	if c == nil {
+		return fmt.Sprintf("ClusterOperator %s does not report %s", name, conditionType)
+	}
+	return fmt.Sprintf("ClusterOperator %s %s=%s: %s: %s", name, c.Type, c.Status, c.Reason, c.Message)
+}
@@ -0,0 +1,214 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+const target = "registry.ci/release@sha256:target"
+
+func operator(name, version string, conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: configv1.ClusterOperatorStatus{
+			Versions:   []configv1.OperandVersion{{Name: "operator", Version: version}},
+			Conditions: conditions,
+		},
+	}
+}
+
+func partial(spec configv1.ClusterVersionSpec) configv1.ClusterVersionStatus {
+	return configv1.ClusterVersionStatus{
+		Desired: configv1.Release{Version: "4.20.1", Image: spec.DesiredUpdate.Image},
+		History: []configv1.UpdateHistory{{State: configv1.PartialUpdate, Version: "4.20.1", Image: spec.DesiredUpdate.Image}},
+		Conditions: []configv1.ClusterOperatorStatusCondition{
+			{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, Message: "Working towards 4.20.1"},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		// status is the status of the ClusterVersion at the given poll
+		status func(spec configv1.ClusterVersionSpec, poll int) configv1.ClusterVersionStatus
+		force  api.UpgradeForce
+		// conflicts is how many updates of the ClusterVersion conflict
+		conflicts        int
+		expected         *Result
+		expectedErr      error
+		expectedDesired  *configv1.Update
+		expectedProgress int
+	}{
+		{
+			name: "upgrade completes",
+			status: func(spec configv1.ClusterVersionSpec, poll int) configv1.ClusterVersionStatus {
+				status := partial(spec)
+				if poll > 1 {
+					status.History[0].State = configv1.CompletedUpdate
+					status.Conditions[0].Status = configv1.ConditionFalse
+				}
+				return status
+			},
+			expected: &Result{
+				Target: target, Started: now, Finished: now, Completed: true,
+				Progress: []Progress{
+					{Time: now, State: configv1.PartialUpdate, Version: "4.20.1", Progressing: "Working towards 4.20.1", UpdatedOperators: 1, TotalOperators: 2},
+					{Time: now, State: configv1.CompletedUpdate, Version: "4.20.1", UpdatedOperators: 1, TotalOperators: 2},
+				},
+			},
+			expectedDesired: &configv1.Update{Image: target},
+		},
+		{
+			name:      "conflicting updates are retried",
+			conflicts: 2,
+			status: func(spec configv1.ClusterVersionSpec, _ int) configv1.ClusterVersionStatus {
+				status := partial(spec)
+				status.History[0].State = configv1.CompletedUpdate
+				return status
+			},
+			expected: &Result{
+				Target: target, Started: now, Finished: now, Completed: true,
+				Progress: []Progress{
+					{Time: now, State: configv1.CompletedUpdate, Version: "4.20.1", Progressing: "Working towards 4.20.1", UpdatedOperators: 1, TotalOperators: 2},
+				},
+			},
+			expectedDesired: &configv1.Update{Image: target},
+		},
+		{
+			name:  "unverified payload is forced",
+			force: api.UpgradeForceIfUnverified,
+			status: func(spec configv1.ClusterVersionSpec, _ int) configv1.ClusterVersionStatus {
+				status := partial(spec)
+				if !spec.DesiredUpdate.Force {
+					status.History = nil
+					status.Conditions = []configv1.ClusterOperatorStatusCondition{{Type: conditionReleaseAccepted, Status: configv1.ConditionFalse, Message: "The update cannot be verified: signature missing"}}
+					return status
+				}
+				status.History[0].State = configv1.CompletedUpdate
+				return status
+			},
+			expected: &Result{
+				Target: target, Started: now, Finished: now, Forced: true, Completed: true,
+				Progress: []Progress{
+					{Time: now, UpdatedOperators: 1, TotalOperators: 2},
+					{Time: now, State: configv1.CompletedUpdate, Version: "4.20.1", Progressing: "Working towards 4.20.1", UpdatedOperators: 1, TotalOperators: 2},
+				},
+			},
+			expectedDesired: &configv1.Update{Image: target, Force: true},
+		},
+		{
+			name:  "upgrade times out",
+			force: api.UpgradeForceAlways,
+			status: func(spec configv1.ClusterVersionSpec, _ int) configv1.ClusterVersionStatus {
+				return partial(spec)
+			},
+			expected: &Result{
+				Target: target, Started: now, Finished: now, Forced: true,
+				Error: "upgrade to registry.ci/release@sha256:target did not complete within 50ms",
+				Progress: []Progress{
+					{Time: now, State: configv1.PartialUpdate, Version: "4.20.1", Progressing: "Working towards 4.20.1", UpdatedOperators: 1, TotalOperators: 2},
+				},
+				Diagnostics: []string{"ClusterOperator network Degraded=True: RolloutHung: DaemonSet is not progressing"},
+			},
+			expectedErr:     errors.New("upgrade to registry.ci/release@sha256:target did not complete within 50ms"),
+			expectedDesired: &configv1.Update{Image: target, Force: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := configv1.Install(scheme); err != nil {
+				t.Fatal(err)
+			}
+			var polls, conflicts int
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}, Spec: configv1.ClusterVersionSpec{Channel: "stable-4.19"}},
+				operator("etcd", "4.20.1", configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}),
+				operator("network", "4.19.9",
+					configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+					configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "RolloutHung", Message: "DaemonSet is not progressing"},
+				),
+			).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, client ctrlruntimeclient.WithWatch, key ctrlruntimeclient.ObjectKey, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.GetOption) error {
+					if err := client.Get(ctx, key, obj, opts...); err != nil {
+						return err
+					}
+					if cv, ok := obj.(*configv1.ClusterVersion); ok && cv.Spec.DesiredUpdate != nil {
+						polls++
+						cv.Status = tc.status(cv.Spec, polls)
+					}
+					return nil
+				},
+				Update: func(ctx context.Context, client ctrlruntimeclient.WithWatch, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.UpdateOption) error {
+					if _, ok := obj.(*configv1.ClusterVersion); ok && conflicts < tc.conflicts {
+						conflicts++
+						return kerrors.NewConflict(configv1.Resource("clusterversions"), obj.GetName(), errors.New("modified"))
+					}
+					return client.Update(ctx, obj, opts...)
+				},
+			}).Build()
+
+			upgrader := New(client, Options{Target: target, Channel: "stable-4.20", Timeout: 50 * time.Millisecond, Force: tc.force, PollInterval: time.Millisecond})
+			upgrader.now = func() time.Time { return now }
+			result, err := upgrader.Run(context.Background())
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+			cv := &configv1.ClusterVersion{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Name: "version"}, cv); err != nil {
+				t.Fatal(err)
+			}
+			if cv.Spec.Channel != "stable-4.20" {
+				t.Errorf("expected the channel to be set, got %s", cv.Spec.Channel)
+			}
+			if diff := cmp.Diff(tc.expectedDesired, cv.Spec.DesiredUpdate); diff != "" {
+				t.Errorf("unexpected desired update: %s", diff)
+			}
+		})
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	result := &Result{
+		Target:      target,
+		Started:     now,
+		Finished:    now.Add(time.Minute),
+		Error:       "upgrade did not complete",
+		Progress:    []Progress{{State: configv1.PartialUpdate, Version: "4.20.1", UpdatedOperators: 1, TotalOperators: 2, Progressing: "Working towards 4.20.1"}},
+		Diagnostics: []string{"ClusterOperator network does not report Available"},
+	}
+	testCase := &junit.TestCase{
+		Name:     "Cluster upgrades to registry.ci/release@sha256:target",
+		Duration: 60,
+		FailureOutput: &junit.FailureOutput{
+			Message: "upgrade did not complete",
+			Output:  "ClusterOperator network does not report Available\nLast observed: upgrade Partial to 4.20.1, 1/2 operators updated. Working towards 4.20.1\n",
+		},
+	}
+	expected := &junit.TestSuites{Suites: []*junit.TestSuite{{Name: "cluster-upgrade", NumTests: 1, NumFailed: 1, Duration: 60, TestCases: []*junit.TestCase{testCase}}}}
+	if diff := cmp.Diff(expected, JUnit(result)); diff != "" {
+		t.Errorf("unexpected jUnit: %s", diff)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -274,6 +275,19 @@ func podDuration(pod *corev1.Pod) time.Duration {
 	return duration
 }
 
+// sidecarNames returns the names of the init containers which run for the
+// whole lifetime of the pod. They are terminated once the other containers
+// exit, so their exit codes do not determine the result of the pod.
+func sidecarNames(pod *corev1.Pod) sets.Set[string] {
+	names := sets.New[string]()
+	for _, container := range pod.Spec.InitContainers {
+		if policy := container.RestartPolicy; policy != nil && *policy == corev1.ContainerRestartPolicyAlways {
+			names.Insert(container.Name)
+		}
+	}
+	return names
+}
+
 func podJobIsOK(pod *corev1.Pod) bool {
 	if pod.Status.Phase == corev1.PodSucceeded {
 		return true
@@ -283,11 +297,15 @@ func podJobIsOK(pod *corev1.Pod) bool {
 	}
 	// if all containers except artifacts are in terminated and have exit code 0, we're ok
 	hasArtifacts := false
+	sidecars := sidecarNames(pod)
 	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
 		// don't succeed until everything has started at least once
 		if status.State.Waiting != nil && status.LastTerminationState.Terminated == nil {
 			return false
 		}
+		if sidecars.Has(status.Name) {
+			continue
+		}
 		if status.Name == "artifacts" {
 			hasArtifacts = true
 			continue
@@ -314,12 +332,13 @@ func podJobIsFailed(pod *corev1.Pod) bool {
 		return false
 	}
 	// if any container is in a non-zero status we have failed
+	sidecars := sidecarNames(pod)
 	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
 		// don't fail until everything has started at least once
 		if status.State.Waiting != nil && status.LastTerminationState.Terminated == nil {
 			return false
 		}
-		if status.Name == "artifacts" {
+		if status.Name == "artifacts" || sidecars.Has(status.Name) {
 			continue
 		}
 		if s := status.State.Terminated; s != nil {
@@ -495,9 +514,9 @@ func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod,
 			continue
 		}
 
-		if s, err := podClient.GetLogs(pod.Namespace, pod.Name, &corev1.PodLogOptions{
+		if s, err := podClient.StreamLogs(context.TODO(), pod.Namespace, pod.Name, &corev1.PodLogOptions{
 			Container: status.Name,
-		}).Stream(context.TODO()); err == nil {
+		}); err == nil {
 			logs := &bytes.Buffer{}
 			if _, err := io.Copy(logs, s); err != nil {
 				logrus.WithError(err).Warnf("Unable to copy log output from failed pod container %s.", status.Name)
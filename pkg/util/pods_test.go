@@ -235,3 +235,28 @@ func TestCheckPending(t *testing.T) {
 		})
 	}
 }
+
+func TestPodJobResultIgnoresSidecars(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	terminated := func(name string, exitCode int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}}}
+	}
+	pod := func(test corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "place-entrypoint"}, {Name: "postgres", RestartPolicy: &always}},
+			},
+			Status: corev1.PodStatus{
+				Phase:                 corev1.PodRunning,
+				InitContainerStatuses: []corev1.ContainerStatus{terminated("place-entrypoint", 0), terminated("postgres", 143)},
+				ContainerStatuses:     []corev1.ContainerStatus{test},
+			},
+		}
+	}
+	if succeeded := pod(terminated("test", 0)); !podJobIsOK(succeeded) || podJobIsFailed(succeeded) {
+		t.Errorf("expected the pod to have succeeded despite the exit code of its sidecar")
+	}
+	if failed := pod(terminated("test", 1)); podJobIsOK(failed) || !podJobIsFailed(failed) {
+		t.Errorf("expected the pod to have failed")
+	}
+}
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// ConfigCheck validates a configuration using the validator of the worker
+// running it.
+type ConfigCheck func(validator *Validator, config *api.ReleaseBuildConfiguration) error
+
+// ValidateAll runs the check on every configuration with a pool of workers,
+// each with its own validator created by newValidator since validators are not
+// safe for concurrent use. If workers is zero, it is treated as
+// `runtime.GOMAXPROCS(0)`.
+func ValidateAll(configs []api.ReleaseBuildConfiguration, workers int, newValidator func() Validator, check ConfigCheck) *Report {
+	report := &Report{Errors: map[string][]error{}}
+	var lock sync.Mutex
+	inputCh := make(chan *api.ReleaseBuildConfiguration)
+	produce := func() error {
+		defer close(inputCh)
+		for i := range configs {
+			inputCh <- &configs[i]
+		}
+		return nil
+	}
+	map_ := func() error {
+		validator := newValidator()
+		for c := range inputCh {
+			if err := check(&validator, c); err != nil {
+				lock.Lock()
+				path := c.Metadata.RelativePath()
+				report.Errors[path] = append(report.Errors[path], utilerrors.Flatten(utilerrors.NewAggregate([]error{err})).Errors()...)
+				lock.Unlock()
+			}
+		}
+		return nil
+	}
+	// all the errors are recorded in the report by the workers
+	errCh := make(chan error)
+	_ = util.ProduceMap(workers, produce, map_, errCh)
+	report.Validated = len(configs)
+	return report
+}
+
+// Report holds the errors of a bulk validation, grouped by configuration.
+type Report struct {
+	// Validated is the number of validated configurations.
+	Validated int
+	// Errors maps the relative paths of the invalid configurations to their
+	// errors.
+	Errors map[string][]error
+}
+
+func (r *Report) paths() []string {
+	var paths []string
+	for path := range r.Errors {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+var indexRegex = regexp.MustCompile(`\[\d+\]`)
+
+// errorKind classifies an error by the field it is reported on, ignoring the
+// indices of list items, e.g. `tests[*].as`. Errors not reported on a field
+// are classified as `other`.
+func errorKind(err error) string {
+	field, _, found := strings.Cut(err.Error(), ": ")
+	if !found || field == "" || strings.ContainsAny(field, " \t\n") {
+		return "other"
+	}
+	return indexRegex.ReplaceAllString(field, "[*]")
+}
+
+// Counts is the number of errors of each kind.
+func (r *Report) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, errs := range r.Errors {
+		for _, err := range errs {
+			counts[errorKind(err)]++
+		}
+	}
+	return counts
+}
+
+// String formats the errors grouped by configuration, followed by the number
+// of errors of each kind, most frequent first.
+func (r *Report) String() string {
+	var b strings.Builder
+	total := 0
+	for _, path := range r.paths() {
+		fmt.Fprintf(&b, "%s:\n", path)
+		for _, err := range r.Errors[path] {
+			fmt.Fprintf(&b, "  - %v\n", err)
+			total++
+		}
+	}
+	counts := r.Counts()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	fmt.Fprintf(&b, "%d errors in %d of %d configurations\n", total, len(r.Errors), r.Validated)
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "  %d\t%s\n", counts[kind], kind)
+	}
+	return b.String()
+}
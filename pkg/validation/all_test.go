@@ -0,0 +1,93 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateAll(t *testing.T) {
+	var configs []api.ReleaseBuildConfiguration
+	for _, repo := range []string{"valid", "invalid", "broken", "other"} {
+		configs = append(configs, api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: repo, Branch: "main"}})
+	}
+	check := func(_ *Validator, config *api.ReleaseBuildConfiguration) error {
+		switch config.Metadata.Repo {
+		case "invalid":
+			return utilerrors.NewAggregate([]error{
+				errors.New("tests[0].as: is required"),
+				errors.New("tests[3].as: is required"),
+				errors.New("tests[1].steps.test[2].commands: is required"),
+			})
+		case "broken":
+			return errors.New("failed to resolve: no workflow named e2e")
+		case "other":
+			return errors.New("tests[2].as: is required")
+		}
+		return nil
+	}
+	validators := 0
+	newValidator := func() Validator {
+		validators++
+		return NewValidator(nil, nil, nil)
+	}
+	report := ValidateAll(configs, 1, newValidator, check)
+
+	if validators != 1 {
+		t.Errorf("expected one validator per worker, got %d", validators)
+	}
+	expectedErrors := map[string][]error{
+		"org/invalid/org-invalid-main.yaml": {
+			errors.New("tests[0].as: is required"),
+			errors.New("tests[3].as: is required"),
+			errors.New("tests[1].steps.test[2].commands: is required"),
+		},
+		"org/broken/org-broken-main.yaml": {errors.New("failed to resolve: no workflow named e2e")},
+		"org/other/org-other-main.yaml":   {errors.New("tests[2].as: is required")},
+	}
+	if diff := cmp.Diff(expectedErrors, report.Errors, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected errors: %s", diff)
+	}
+	expectedCounts := map[string]int{"tests[*].as": 3, "tests[*].steps.test[*].commands": 1, "other": 1}
+	if diff := cmp.Diff(expectedCounts, report.Counts()); diff != "" {
+		t.Errorf("unexpected counts: %s", diff)
+	}
+	expectedReport := `org/broken/org-broken-main.yaml:
+  - failed to resolve: no workflow named e2e
+org/invalid/org-invalid-main.yaml:
+  - tests[0].as: is required
+  - tests[3].as: is required
+  - tests[1].steps.test[2].commands: is required
+org/other/org-other-main.yaml:
+  - tests[2].as: is required
+5 errors in 3 of 4 configurations
+  3	tests[*].as
+  1	other
+  1	tests[*].steps.test[*].commands
+`
+	if diff := cmp.Diff(expectedReport, report.String()); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func TestValidateAllParallel(t *testing.T) {
+	var configs []api.ReleaseBuildConfiguration
+	for i := 0; i < 100; i++ {
+		configs = append(configs, api.ReleaseBuildConfiguration{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "main", Variant: string(rune('a' + i%26))}})
+	}
+	report := ValidateAll(configs, 8, func() Validator { return NewValidator(nil, nil, nil) }, func(_ *Validator, config *api.ReleaseBuildConfiguration) error {
+		return errors.New("invalid")
+	})
+	if report.Validated != 100 {
+		t.Errorf("expected 100 validated configurations, got %d", report.Validated)
+	}
+	if diff := cmp.Diff(map[string]int{"other": 100}, report.Counts()); diff != "" {
+		t.Errorf("unexpected counts: %s", diff)
+	}
+}
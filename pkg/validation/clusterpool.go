@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// WithClusterPools returns a validator which also checks that the cluster
+// claims of tests are served by one of the given cluster pools. Otherwise,
+// a claim no pool serves only fails when the test is scheduled.
+func (v Validator) WithClusterPools(pools []hivev1.ClusterPool) Validator {
+	if pools == nil {
+		pools = []hivev1.ClusterPool{}
+	}
+	v.clusterPools = pools
+	return v
+}
+
+// validateClusterPool checks that one of the known cluster pools serves the
+// claim, when the pools are known.
+func (v *Validator) validateClusterPool(fieldRoot string, claim *api.ClusterClaim) error {
+	if v.clusterPools == nil {
+		return nil
+	}
+	// the pools ci-operator may claim a cluster from
+	selector := labels.SelectorFromSet(claim.PoolLabels())
+	for _, pool := range v.clusterPools {
+		if selector.Matches(labels.Set(pool.Labels)) {
+			return nil
+		}
+	}
+	description := fmt.Sprintf("%s %s clusters for %s on %s owned by %s", claim.Product, claim.Version, claim.Architecture, claim.Cloud, claim.Owner)
+	if len(claim.Labels) != 0 {
+		description += fmt.Sprintf(" with labels %s", labels.Set(claim.Labels))
+	}
+	return fmt.Errorf("%s.cluster_claim: no cluster pool provides %s", fieldRoot, description)
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateClusterPool(t *testing.T) {
+	pool := func(labels map[string]string) hivev1.ClusterPool {
+		return hivev1.ClusterPool{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+	}
+	pools := []hivev1.ClusterPool{
+		pool(map[string]string{"product": "ocp", "version": "4.16", "architecture": "amd64", "cloud": "aws", "owner": "dpp", "region": "us-east-1"}),
+		pool(map[string]string{"product": "ocp", "version": "4.16", "architecture": "arm64", "cloud": "aws", "owner": "obs"}),
+	}
+	claim := func(mutate func(*api.ClusterClaim)) *api.ClusterClaim {
+		c := &api.ClusterClaim{Product: api.ReleaseProductOCP, Version: "4.16", Architecture: api.ReleaseArchitectureAMD64, Cloud: api.CloudAWS, Owner: "dpp"}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+	for _, tc := range []struct {
+		name     string
+		pools    []hivev1.ClusterPool
+		claim    *api.ClusterClaim
+		expected error
+	}{
+		{
+			name:  "pools are not checked unless they are known",
+			claim: claim(func(c *api.ClusterClaim) { c.Version = "4.1" }),
+		},
+		{
+			name:  "a pool serves the claim",
+			pools: pools,
+			claim: claim(nil),
+		},
+		{
+			name:  "a pool serves the claim with labels",
+			pools: pools,
+			claim: claim(func(c *api.ClusterClaim) { c.Labels = map[string]string{"region": "us-east-1"} }),
+		},
+		{
+			name:     "no pool serves the version",
+			pools:    pools,
+			claim:    claim(func(c *api.ClusterClaim) { c.Version = "4.17" }),
+			expected: errors.New("tests[0].cluster_claim: no cluster pool provides ocp 4.17 clusters for amd64 on aws owned by dpp"),
+		},
+		{
+			name:     "no pool serves the architecture for the owner",
+			pools:    pools,
+			claim:    claim(func(c *api.ClusterClaim) { c.Architecture = api.ReleaseArchitectureARM64 }),
+			expected: errors.New("tests[0].cluster_claim: no cluster pool provides ocp 4.16 clusters for arm64 on aws owned by dpp"),
+		},
+		{
+			name:     "no pool has the labels",
+			pools:    pools,
+			claim:    claim(func(c *api.ClusterClaim) { c.Labels = map[string]string{"region": "eu-west-1"} }),
+			expected: errors.New("tests[0].cluster_claim: no cluster pool provides ocp 4.16 clusters for amd64 on aws owned by dpp with labels region=eu-west-1"),
+		},
+		{
+			name:     "there are no pools",
+			pools:    []hivev1.ClusterPool{},
+			claim:    claim(nil),
+			expected: errors.New("tests[0].cluster_claim: no cluster pool provides ocp 4.16 clusters for amd64 on aws owned by dpp"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator(nil, nil, nil)
+			if tc.pools != nil {
+				v = v.WithClusterPools(tc.pools)
+			}
+			testhelper.Diff(t, "error", v.validateClusterPool("tests[0]", tc.claim), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
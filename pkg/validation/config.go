@@ -3,14 +3,20 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/imagebuilder"
+	"github.com/openshift/library-go/pkg/image/reference"
+
 	"github.com/openshift/ci-tools/pkg/api"
 )
 
@@ -18,12 +24,30 @@ import (
 type Validator struct {
 	validClusterProfiles    api.ClusterProfilesMap
 	validClusterClaimOwners api.ClusterClaimOwnersMap
+	// validPullSecrets holds the names of the secrets in the test-credentials
+	// namespace which can be used to pull images. If unset, the existence of
+	// pull secrets is not checked.
+	validPullSecrets sets.Set[string]
 	// hasTrapCache avoids redundant regexp searches on step commands.
 	hasTrapCache map[string]bool
+	// strict enables the strict ruleset, see WithStrictMode.
+	strict bool
+	// clusterPools are the cluster pools claims may be served from. If
+	// unset, the cluster claims are not checked against them.
+	clusterPools []hivev1.ClusterPool
+	// resourceCeilings restricts the requests of builds and tests, see
+	// WithResourceCeilings.
+	resourceCeilings *api.ResourceCeilingPolicy
+	// defaultJobTimeout enables the validation of the timeout chains of
+	// tests, see WithTimeoutChains.
+	defaultJobTimeout func(metadata api.Metadata) time.Duration
+	// architectures are the architectures images may be built for, see
+	// WithArchitectures.
+	architectures sets.Set[string]
 }
 
 // NewValidator creates an object that optimizes bulk validations.
-func NewValidator(profiles api.ClusterProfilesMap, clusterClaimOwners api.ClusterClaimOwnersMap) Validator {
+func NewValidator(profiles api.ClusterProfilesMap, clusterClaimOwners api.ClusterClaimOwnersMap, pullSecrets sets.Set[string]) Validator {
 	ret := Validator{
 		hasTrapCache: make(map[string]bool),
 	}
@@ -33,6 +57,9 @@ func NewValidator(profiles api.ClusterProfilesMap, clusterClaimOwners api.Cluste
 	if clusterClaimOwners != nil {
 		ret.validClusterClaimOwners = clusterClaimOwners
 	}
+	if pullSecrets != nil {
+		ret.validPullSecrets = pullSecrets
+	}
 	return ret
 }
 
@@ -41,14 +68,15 @@ func newSingleUseValidator() Validator {
 }
 
 // IsValidResolvedConfiguration behaves as ValidateAtRuntime and also validates that all
-// test steps are fully resolved.
-func (v *Validator) IsValidResolvedConfiguration(config *api.ReleaseBuildConfiguration) error {
+// test steps are fully resolved. Non-fatal findings are returned as warnings.
+func (v *Validator) IsValidResolvedConfiguration(config *api.ReleaseBuildConfiguration) ([]string, error) {
 	config.Default()
 	return v.validateConfiguration(NewConfigContext(), config, "", "", true, false)
 }
 
-// IsValidConfiguration validates all the configuration's values.
-func (v *Validator) IsValidConfiguration(config *api.ReleaseBuildConfiguration, org, repo string) error {
+// IsValidConfiguration validates all the configuration's values. Non-fatal
+// findings are returned as warnings.
+func (v *Validator) IsValidConfiguration(config *api.ReleaseBuildConfiguration, org, repo string) ([]string, error) {
 	config.Default()
 	return v.validateConfiguration(NewConfigContext(), config, org, repo, false, false)
 }
@@ -111,25 +139,44 @@ func (c *configContext) addPipelineImage(name api.PipelineImageStreamTagReferenc
 // repo structure
 func IsValidRuntimeConfiguration(config *api.ReleaseBuildConfiguration) error {
 	v := newSingleUseValidator()
-	return v.validateConfiguration(NewConfigContext(), config, "", "", false, false)
+	_, err := v.validateConfiguration(NewConfigContext(), config, "", "", false, false)
+	return err
 }
 
 // IsValidResolvedConfiguration behaves as ValidateAtRuntime and also validates that all
-// test steps are fully resolved.
-func IsValidResolvedConfiguration(config *api.ReleaseBuildConfiguration, mergedConfig bool) error {
+// test steps are fully resolved. Non-fatal findings are returned as warnings.
+// Images may be built for the given architectures, or the default ones if unset.
+func IsValidResolvedConfiguration(config *api.ReleaseBuildConfiguration, mergedConfig bool, architectures sets.Set[string]) ([]string, error) {
 	config.Default()
-	v := newSingleUseValidator()
+	v := newSingleUseValidator().WithArchitectures(architectures)
 	return v.validateConfiguration(NewConfigContext(), config, "", "", true, mergedConfig)
 }
 
-// IsValidConfiguration validates all the configuration's values.
-func IsValidConfiguration(config *api.ReleaseBuildConfiguration, org, repo string) error {
+// IsValidClusterGroupTests validates the tests synthesized to run the cluster
+// groups of a resolved configuration.
+func IsValidClusterGroupTests(config *api.ReleaseBuildConfiguration, tests []api.TestStepConfiguration, architectures sets.Set[string]) error {
+	v := newSingleUseValidator().WithArchitectures(architectures)
+	releases := sets.KeySet(config.Releases)
+	images := sets.New[string]()
+	for _, i := range config.Images {
+		images.Insert(string(i.To))
+	}
+	validationErrors := v.validateTestStepConfiguration(NewConfigContext(), "cluster_groups", tests, config.ReleaseTagConfiguration, &config.Metadata, releases, images, true)
+	return aggregateValidationErrors(filterDisabledRules(config, validationErrors))
+}
+
+// IsValidConfiguration validates all the configuration's values. Non-fatal
+// findings are returned as warnings.
+func IsValidConfiguration(config *api.ReleaseBuildConfiguration, org, repo string) ([]string, error) {
 	config.Default()
 	v := newSingleUseValidator()
 	return v.validateConfiguration(NewConfigContext(), config, org, repo, false, false)
 }
 
-func (v *Validator) validateConfiguration(ctx *configContext, config *api.ReleaseBuildConfiguration, org, repo string, resolved, mergedConfig bool) error {
+// validateConfiguration returns the non-fatal findings about the configuration
+// along with the errors making it invalid.
+func (v *Validator) validateConfiguration(ctx *configContext, config *api.ReleaseBuildConfiguration, org, repo string, resolved, mergedConfig bool) ([]string, error) {
+	var warnings []string
 	var validationErrors []error
 	if config.BinaryBuildCommands != "" {
 		ctx.pipelineImages[api.PipelineImageStreamTagReferenceBinaries] = "binary_build_commands"
@@ -152,7 +199,12 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 			ctx.pipelineImages[api.PipelineImageStreamTagReference(fmt.Sprintf("%s-%s", api.PipelineImageStreamTagReferenceRPMs, c.Ref))] = "rpm_build_commands"
 		}
 	}
-	validationErrors = append(validationErrors, validateReleaseBuildConfiguration(config, org, repo, mergedConfig)...)
+	if config.Tools != nil {
+		ctx.pipelineImages[api.PipelineImageStreamTagReferenceTools] = api.PipelineImageStreamTagSourceTools
+		validationErrors = append(validationErrors, validateToolsImage(config)...)
+	}
+	validationErrors = append(validationErrors, validateReleaseBuildConfiguration(config, org, repo, mergedConfig, v.resourceCeilings)...)
+	warnings = append(warnings, resourceWarnings("resources", config.Resources)...)
 	if config.InputConfiguration.BuildRootImage != nil {
 		validationErrors = append(validationErrors, validateBuildRootImageConfiguration(ctx.AddField("build_root"), config.InputConfiguration.BuildRootImage, len(config.Images) > 0, "")...)
 	} else if len(config.InputConfiguration.BuildRootImages) > 0 {
@@ -169,10 +221,12 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 	}
 	validationErrors = append(validationErrors, ValidateBaseImages(ctx.AddField("base_images"), config.InputConfiguration.BaseImages)...)
 	validationErrors = append(validationErrors, validateBaseRPMImages(ctx.AddField("base_rpm_images"), config.InputConfiguration.BaseRPMImages)...)
-	validationErrors = append(validationErrors, validateExternalConfiguration(ctx.AddField("external_images"), config.ExternalImages)...)
+	validationErrors = append(validationErrors, v.validateExternalConfiguration(ctx.AddField("external_images"), config.ExternalImages)...)
 	validationErrors = append(validationErrors, validateBaseAndExternalCollision(config.InputConfiguration.BaseImages, config.ExternalImages)...)
+	validationErrors = append(validationErrors, validateSchemaVersion(config)...)
 	// Validate tag_specification
 	if config.InputConfiguration.ReleaseTagConfiguration != nil {
+		warnings = append(warnings, "tag_specification: deprecated, use releases.latest.integration instead")
 		validationErrors = append(validationErrors, validateReleaseTagConfiguration("tag_specification", *config.InputConfiguration.ReleaseTagConfiguration)...)
 	}
 
@@ -185,14 +239,34 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 				len(api.ImageTargets(config)) > 0,
 				config.ReleaseTagConfiguration,
 				config.Releases)...)
+		validationErrors = append(validationErrors, v.validatePromotionMirrors("promotion", *config.PromotionConfiguration)...)
+		validationErrors = append(validationErrors, validatePromotionArchitectures("promotion", *config.PromotionConfiguration, config.Images, v.supportedArchitectures())...)
+		if config.PromotionConfiguration.ARTConsistencyCheck && !api.PromotesOfficialImages(config, api.WithoutOKD) {
+			validationErrors = append(validationErrors, errors.New("promotion.art_consistency_check: can only be set when promoting to the ocp namespace"))
+		}
+		if config.PromotionConfiguration.ImageDiffReport && config.PromotionConfiguration.RegistryOverride != "" {
+			validationErrors = append(validationErrors, errors.New("promotion.image_diff_report: cannot be set with registry_override, only images promoted to the central registry can be compared"))
+		}
+		if config.PromotionConfiguration.CVEGate != nil && config.PromotionConfiguration.RegistryOverride != "" {
+			validationErrors = append(validationErrors, errors.New("promotion.cve_gate: cannot be set with registry_override, only images promoted to the central registry can be compared"))
+		}
 	}
 
 	validationErrors = append(validationErrors, validateReleases("releases", config.Releases, config.ReleaseTagConfiguration != nil)...)
-	validationErrors = append(validationErrors, ValidateImages(ctx.AddField("images"), config.Images)...)
+	validationErrors = append(validationErrors, ValidateImages(ctx.AddField("images"), config.Images, v.supportedArchitectures())...)
+	validationErrors = append(validationErrors, validateImageDependencyCycles(config)...)
 	validationErrors = append(validationErrors, v.ValidateTestStepConfiguration(ctx, config, resolved)...)
 	// this validation brings together a large amount of data from separate
 	// parts of the configuration, so it's written as a standalone method
 	validationErrors = append(validationErrors, validateTestStepDependencies(config)...)
+	if v.strict {
+		validationErrors = append(validationErrors, validateStrict(config)...)
+	}
+	warnings = append(warnings, configurationWarnings(config)...)
+	return warnings, aggregateValidationErrors(filterDisabledRules(config, validationErrors))
+}
+
+func aggregateValidationErrors(validationErrors []error) error {
 	var lines []string
 	for _, err := range validationErrors {
 		if err == nil {
@@ -210,6 +284,22 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 	}
 }
 
+// validateToolsImage validates the manifest of the tools image and the image
+// the tools are installed in.
+func validateToolsImage(config *api.ReleaseBuildConfiguration) []error {
+	var errs []error
+	if err := config.Tools.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	switch from := config.Tools.BaseImage(); {
+	case from == api.PipelineImageStreamTagReferenceTools:
+		errs = append(errs, errors.New("tools.from: the tools cannot be installed in the tools image"))
+	case from == api.PipelineImageStreamTagReferenceRoot && config.BuildRootImage == nil && len(config.BuildRootImages) == 0:
+		errs = append(errs, errors.New("tools.from: the tools are installed in the build root by default, which is not configured"))
+	}
+	return errs
+}
+
 func validateBaseAndExternalCollision(baseImages map[string]api.ImageStreamTagReference, externalImage map[string]api.ExternalImage) []error {
 	var validationErrors []error
 	for name := range externalImage {
@@ -220,7 +310,7 @@ func validateBaseAndExternalCollision(baseImages map[string]api.ImageStreamTagRe
 	return validationErrors
 }
 
-func validateExternalConfiguration(ctx *configContext, externalImages map[string]api.ExternalImage) []error {
+func (v *Validator) validateExternalConfiguration(ctx *configContext, externalImages map[string]api.ExternalImage) []error {
 	var validationErrors []error
 	istRefs := make(map[string]api.ImageStreamTagReference)
 	for name, ei := range externalImages {
@@ -241,12 +331,29 @@ func validateExternalConfiguration(ctx *configContext, externalImages map[string
 		if ei.Namespace == "" {
 			validationErrors = append(validationErrors, ctx.errorf("%s.namespace value required but not provided", name))
 		}
+		if ei.PullSecret != "" {
+			if err := v.validatePullSecret(ei.PullSecret); err != nil {
+				validationErrors = append(validationErrors, ctx.errorf("%s.pull_secret: %v", name, err))
+			}
+		}
 		istRefs[name] = ei.ImageStreamTagReference
 	}
 	validationErrors = append(validationErrors, validateImageStreamTagReferenceMap("external_images", istRefs)...)
 	return validationErrors
 }
 
+// validatePullSecret verifies that a pull secret name is valid and, if the
+// available secrets are known, that it exists.
+func (v *Validator) validatePullSecret(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+		return fmt.Errorf("%q is not a valid secret name: %s", name, strings.Join(errs, ", "))
+	}
+	if v.validPullSecrets != nil && !v.validPullSecrets.Has(name) {
+		return fmt.Errorf("secret %q does not exist in the test-credentials namespace", name)
+	}
+	return nil
+}
+
 func (v *Validator) ValidateTestStepConfiguration(ctx *configContext, config *api.ReleaseBuildConfiguration, resolved bool) []error {
 	var validationErrors []error
 
@@ -284,12 +391,30 @@ func validateBuildRootImageConfiguration(ctx *configContext, input *api.BuildRoo
 	} else if input.ImageStreamTagReference != nil {
 		ret = append(ret, validateBuildRootImageStreamTag(ctx.AddField("image_stream_tag"), *input.ImageStreamTagReference)...)
 	}
+	if input.ProjectImageBuild != nil && input.ProjectImageBuild.FiltersContext() {
+		ret = append(ret, ctx.AddField("project_image").errorf("include and exclude are not supported for the build root"))
+	}
+	ret = append(ret, validateBuildBackend(ctx.AddField("build_backend"), input.BuildBackend)...)
+	if input.ProjectImageBuild != nil && input.ProjectImageBuild.BuildBackend == api.BuildBackendBuildah {
+		// the build root is built from a git source, which only the Build API supports
+		ret = append(ret, ctx.AddField("project_image").AddField("build_backend").errorf("%s is not supported for the build root", api.BuildBackendBuildah))
+	} else if input.ProjectImageBuild != nil {
+		ret = append(ret, validateBuildBackend(ctx.AddField("project_image").AddField("build_backend"), input.ProjectImageBuild.BuildBackend)...)
+	}
 	if err := ctx.addPipelineImage(api.PipelineImageStreamTagReferenceRoot, ref); err != nil {
 		ret = append(ret, err)
 	}
 	return
 }
 
+func validateBuildBackend(ctx *configContext, backend api.BuildBackend) []error {
+	switch backend {
+	case "", api.BuildBackendBuild, api.BuildBackendBuildah:
+		return nil
+	}
+	return []error{ctx.errorf("unknown build backend %q, use one of %s, %s", backend, api.BuildBackendBuild, api.BuildBackendBuildah)}
+}
+
 func validateBuildRootImageStreamTag(ctx *configContext, buildRoot api.ImageStreamTagReference) []error {
 	var validationErrors []error
 	if len(buildRoot.Namespace) == 0 {
@@ -304,16 +429,9 @@ func validateBuildRootImageStreamTag(ctx *configContext, buildRoot api.ImageStre
 	return validationErrors
 }
 
-func ValidateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildStepConfiguration) []error {
+func ValidateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildStepConfiguration, architectures sets.Set[string]) []error {
 	var validationErrors []error
 
-	var validArchitectures = sets.New[string](
-		"amd64",   // x86-64
-		"arm64",   // AArch64
-		"ppc64le", // PowerPC 64-bit Little Endian
-		"s390x",   // IBM System z 64-bit
-	)
-
 	for num, image := range images {
 		ctxN := ctx.addIndex(num)
 		if image.To == "" {
@@ -322,14 +440,24 @@ func ValidateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildS
 		if err := ctxN.addPipelineImage(image.To, image.Ref); err != nil {
 			validationErrors = append(validationErrors, err)
 		}
+		if image.FiltersContext() {
+			// the filtered build context is held in an image of its own
+			if err := ctxN.addPipelineImage(api.BuildContextName(image.To), image.Ref); err != nil {
+				validationErrors = append(validationErrors, err)
+			}
+		}
 		if image.DockerfileLiteral != nil && (image.ContextDir != "" || image.DockerfilePath != "") {
 			validationErrors = append(validationErrors, ctxN.errorf("dockerfile_literal is mutually exclusive with context_dir and dockerfile_path"))
 		}
+		validationErrors = append(validationErrors, validateImageInputs(ctxN, image)...)
+		validationErrors = append(validationErrors, validateDockerfileLiteral(ctxN, image)...)
+		validationErrors = append(validationErrors, validateContextPatterns(ctxN.AddField("include"), image.Include)...)
+		validationErrors = append(validationErrors, validateContextPatterns(ctxN.AddField("exclude"), image.Exclude)...)
+		validationErrors = append(validationErrors, validateBuildBackend(ctxN.AddField("build_backend"), image.BuildBackend)...)
+		validationErrors = append(validationErrors, validateImageAnnotations(ctxN.AddField("annotations"), image.Annotations)...)
 		for _, arch := range image.AdditionalArchitectures {
-			if !validArchitectures.Has(arch) {
-				archList := validArchitectures.UnsortedList()
-				sort.Strings(archList)
-				validationErrors = append(validationErrors, ctxN.errorf("invalid architecture: %s. Use one of %s", arch, strings.Join(archList, ", ")))
+			if !architectures.Has(arch) {
+				validationErrors = append(validationErrors, withRule(RuleImageArchitecture, ctxN.errorf("invalid architecture: %s. Use one of %s", arch, strings.Join(sets.List(architectures), ", "))))
 			}
 		}
 
@@ -337,6 +465,150 @@ func ValidateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildS
 	return validationErrors
 }
 
+// validateImageAnnotations verifies that images are only annotated to be
+// exempt from the resource ceilings, with the reason.
+func validateImageAnnotations(ctx *configContext, annotations map[string]string) []error {
+	var validationErrors []error
+	for _, key := range sets.List(sets.KeySet(annotations)) {
+		if key != api.ResourceCeilingExemptionAnnotation {
+			validationErrors = append(validationErrors, ctx.errorf("unsupported annotation %q, only %s is supported", key, api.ResourceCeilingExemptionAnnotation))
+			continue
+		}
+		if err := toolingAnnotations[key](annotations[key]); err != nil {
+			validationErrors = append(validationErrors, ctx.errorf("value %q for key %q %v", annotations[key], key, err))
+		}
+	}
+	return validationErrors
+}
+
+// validateImageInputs verifies that the `pipeline:<name>` references the
+// inputs replace point to the input itself.
+func validateImageInputs(ctx *configContext, image api.ProjectDirectoryImageBuildStepConfiguration) []error {
+	var validationErrors []error
+	pipelinePrefix := api.PipelineImageStream + ":"
+	var names []string
+	for name := range image.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for num, as := range image.Inputs[name].As {
+			if tag := strings.TrimPrefix(as, pipelinePrefix); tag != as && tag != name {
+				validationErrors = append(validationErrors, ctx.AddField("inputs").addKey(name).AddField("as").addIndex(num).errorf("%q refers to the input %q, not %q", as, tag, name))
+			}
+		}
+	}
+	return validationErrors
+}
+
+// validateDockerfileLiteral verifies that the images a literal Dockerfile is
+// built from are replaced with images of the pipeline: `pipeline:<name>`
+// references must point to images declared as inputs of the build, as those
+// are the only ones replaced, and the other images of `FROM` instructions must
+// be replaced by an input or by `from`. Adding remote files is not allowed, as
+// builds must only depend on the images of the pipeline and the source code.
+// Dockerfiles from the repository are not available here, only literal ones
+// are checked.
+func validateDockerfileLiteral(ctx *configContext, image api.ProjectDirectoryImageBuildStepConfiguration) []error {
+	if image.DockerfileLiteral == nil {
+		return nil
+	}
+	var validationErrors []error
+	pipelinePrefix := api.PipelineImageStream + ":"
+	ctx = ctx.AddField("dockerfile_literal")
+	node, err := imagebuilder.ParseDockerfile(strings.NewReader(*image.DockerfileLiteral))
+	if err != nil {
+		return []error{ctx.errorf("could not parse the Dockerfile: %v", err)}
+	}
+	replaced := sets.New[string]()
+	for _, input := range image.Inputs {
+		replaced.Insert(input.As...)
+	}
+	var lastFrom int
+	for i, child := range node.Children {
+		if child.Value == "from" {
+			lastFrom = i
+		}
+	}
+	stages := sets.New[string]()
+	for i, child := range node.Children {
+		var refs []string
+		switch child.Value {
+		case "from":
+			if child.Next == nil {
+				continue
+			}
+			ref := child.Next.Value
+			refs = append(refs, ref)
+			switch {
+			case strings.HasPrefix(ref, pipelinePrefix), strings.Contains(ref, "$"), ref == "scratch":
+			case stages.Has(strings.ToLower(ref)), replaced.Has(ref):
+			case i == lastFrom && image.From != "":
+			default:
+				validationErrors = append(validationErrors, ctx.errorf("line %d: %q is not replaced by an image of the pipeline, declare it in the `as` of one of the inputs", child.StartLine, ref))
+			}
+			if as := child.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+				stages.Insert(strings.ToLower(as.Next.Value))
+			}
+		case "add":
+			for arg := child.Next; arg != nil && arg.Next != nil; arg = arg.Next {
+				if isRemoteSource(arg.Value) {
+					validationErrors = append(validationErrors, ctx.errorf("line %d: adding the remote file %q is not allowed", child.StartLine, arg.Value))
+				}
+			}
+		case "copy":
+			for _, flag := range child.Flags {
+				if ref := strings.TrimPrefix(flag, "--from="); ref != flag {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		for _, ref := range refs {
+			tag := strings.TrimPrefix(ref, pipelinePrefix)
+			if tag == ref || strings.Contains(tag, "$") {
+				continue
+			}
+			if _, declared := image.Inputs[tag]; !declared {
+				validationErrors = append(validationErrors, ctx.errorf("line %d: %q is used but %q is not declared in inputs", child.StartLine, ref, tag))
+			}
+		}
+	}
+	return validationErrors
+}
+
+// isRemoteSource determines whether the source of an ADD instruction is
+// fetched from the network.
+func isRemoteSource(source string) bool {
+	for _, prefix := range []string{"http://", "https://", "git@"} {
+		if strings.HasPrefix(strings.ToLower(source), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextPatternRegex restricts the patterns filtering the build context to
+// characters which are safe to use unquoted in a shell `case` pattern.
+var contextPatternRegex = regexp.MustCompile(`^[A-Za-z0-9._+@=,/*?!\[\]-]+$`)
+
+func validateContextPatterns(ctx *configContext, patterns []string) []error {
+	var validationErrors []error
+	for num, pattern := range patterns {
+		ctxN := ctx.addIndex(num)
+		if !contextPatternRegex.MatchString(pattern) {
+			validationErrors = append(validationErrors, ctxN.errorf("pattern %q must be a non-empty glob of letters, digits and the characters ._+@=,/*?![]-", pattern))
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			validationErrors = append(validationErrors, ctxN.errorf("invalid pattern %q: %v", pattern, err))
+		}
+		if strings.HasPrefix(pattern, "/") || sets.New(strings.Split(pattern, "/")...).Has("..") {
+			validationErrors = append(validationErrors, ctxN.errorf("pattern %q must be relative to the context directory", pattern))
+		}
+	}
+	return validationErrors
+}
+
 func ValidateOperator(ctx *configContext, config *api.ReleaseBuildConfiguration) []error {
 	// validateOperator needs a method that maps `substitute.with` values to image links
 	// to validate the value is meaningful in the context of the configuration
@@ -488,7 +760,7 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 		}
 
 		if openshiftWebhookForbiddingNamespaces.MatchString(target.Namespace) && !exceptions.Has(target.Namespace) {
-			validationErrors = append(validationErrors, fmt.Errorf("%s: cannot promote to namespace %s matching this regular expression: (^kube.*|^openshift.*|^default$|^redhat.*)", thisFieldRoot(i), target.Namespace))
+			validationErrors = append(validationErrors, withRule(RulePromotionNamespace, fmt.Errorf("%s: cannot promote to namespace %s matching this regular expression: (^kube.*|^openshift.*|^default$|^redhat.*)", thisFieldRoot(i), target.Namespace)))
 		}
 
 		if len(target.Name) == 0 && len(target.Tag) == 0 {
@@ -499,6 +771,18 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 			validationErrors = append(validationErrors, fmt.Errorf("%s: both name and tag defined", thisFieldRoot(i)))
 		}
 
+		if target.CommitTagRetention < 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.commit_tag_retention: must not be negative", thisFieldRoot(i)))
+		} else if target.CommitTagRetention > 0 && !target.TagByCommit {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.commit_tag_retention: requires tag_by_commit", thisFieldRoot(i)))
+		}
+
+		if target.PausedUntil != "" {
+			if _, err := time.Parse(time.RFC3339, target.PausedUntil); err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.paused_until: must be an RFC3339 timestamp like 2006-01-02T15:04:05Z, got %q", thisFieldRoot(i), target.PausedUntil))
+			}
+		}
+
 		if promotesOfficialImages && imageTargets {
 			if _, ok := releases["latest"]; !ok && releaseTagConfiguration == nil {
 				validationErrors = append(validationErrors, fmt.Errorf("importing the release stream is required to ensure the promoted images to the namespace %s can be integrated properly. Although it can be achieved by tag_specification or releases[\"latest\"], adding an e2e test is strongly suggested", target.Namespace))
@@ -521,6 +805,86 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 	return validationErrors
 }
 
+// validatePromotionMirrors verifies the external registries the promotion
+// targets push to.
+func (v *Validator) validatePromotionMirrors(fieldRoot string, input api.PromotionConfiguration) []error {
+	var validationErrors []error
+	for i, target := range api.PromotionTargets(&input) {
+		if target.Mirror == nil {
+			continue
+		}
+		mirror := *target.Mirror
+		fieldRoot := fmt.Sprintf("%s.to[%d].mirror", fieldRoot, i)
+		validRegistry := false
+		if mirror.Registry == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.registry: must be set", fieldRoot))
+		} else if strings.Contains(mirror.Registry, "/") {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.registry: must be a registry host without a scheme or a path, got %q", fieldRoot, mirror.Registry))
+		} else {
+			validRegistry = true
+		}
+		if mirror.Repository == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.repository: must be set", fieldRoot))
+		} else if validRegistry {
+			// images are pushed to <registry>/<repository>/<name>:<tag>
+			ref, err := reference.Parse(fmt.Sprintf("%s/%s/image:tag", mirror.Registry, mirror.Repository))
+			if err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.repository: %q is not a valid repository: %w", fieldRoot, mirror.Repository, err))
+			} else if ref.Registry != mirror.Registry {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.registry: %q is not a registry host", fieldRoot, mirror.Registry))
+			}
+		}
+		if mirror.Credentials == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.credentials: must be set", fieldRoot))
+		} else if err := v.validatePullSecret(mirror.Credentials); err != nil {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.credentials: %w", fieldRoot, err))
+		}
+	}
+	return validationErrors
+}
+
+// validatePromotionArchitectures verifies that the images promoted by targets
+// requesting additional architectures are built for them, as the manifest
+// lists promoted are assembled from the builds.
+func validatePromotionArchitectures(fieldRoot string, input api.PromotionConfiguration, images []api.ProjectDirectoryImageBuildStepConfiguration, architectures sets.Set[string]) []error {
+	var validationErrors []error
+	byName := map[string]api.ProjectDirectoryImageBuildStepConfiguration{}
+	for _, image := range images {
+		byName[string(image.To)] = image
+	}
+	for i, target := range api.PromotionTargets(&input) {
+		if len(target.AdditionalArchitectures) == 0 {
+			continue
+		}
+		fieldRoot := fmt.Sprintf("%s.to[%d].additional_architectures", fieldRoot, i)
+		excluded := sets.New[string](target.ExcludedImages...)
+		promoted := sets.New[string]()
+		for _, image := range images {
+			if !image.Optional && !excluded.Has(string(image.To)) {
+				promoted.Insert(string(image.To))
+			}
+		}
+		for _, src := range target.AdditionalImages {
+			if _, ok := byName[src]; ok {
+				promoted.Insert(src)
+			}
+		}
+		for _, arch := range target.AdditionalArchitectures {
+			if !architectures.Has(arch) {
+				validationErrors = append(validationErrors, withRule(RuleImageArchitecture, fmt.Errorf("%s: invalid architecture: %s. Use one of %s", fieldRoot, arch, strings.Join(sets.List(architectures), ", "))))
+				continue
+			}
+			for _, name := range sets.List(promoted) {
+				image := byName[name]
+				if arch != string(api.NodeArchitectureAMD64) && !image.MultiArch && !sets.New[string](image.AdditionalArchitectures...).Has(arch) {
+					validationErrors = append(validationErrors, fmt.Errorf("%s: image %s is not built for %s, it must be added to the additional_architectures of the image", fieldRoot, name, arch))
+				}
+			}
+		}
+	}
+	return validationErrors
+}
+
 func validateReleaseTagConfiguration(fieldRoot string, input api.ReleaseTagConfiguration) []error {
 	var validationErrors []error
 
@@ -535,7 +899,7 @@ func validateReleaseTagConfiguration(fieldRoot string, input api.ReleaseTagConfi
 	return validationErrors
 }
 
-func validateReleaseBuildConfiguration(input *api.ReleaseBuildConfiguration, org, repo string, mergedConfig bool) []error {
+func validateReleaseBuildConfiguration(input *api.ReleaseBuildConfiguration, org, repo string, mergedConfig bool, ceilings *api.ResourceCeilingPolicy) []error {
 	var validationErrors []error
 
 	// Third conjunct is a corner case, the config can e.g. promote its `src`
@@ -567,11 +931,102 @@ func validateReleaseBuildConfiguration(input *api.ReleaseBuildConfiguration, org
 		validationErrors = append(validationErrors, errors.New("it is not permissible to directly set: ‘binary_build_commands_list’, ‘test_binary_build_commands_list’, ‘rpm_build_commands_list’, or ‘rpm_build_location_list’"))
 	}
 
-	validationErrors = append(validationErrors, validateResources("resources", input.Resources)...)
+	if input.Metadata.Org != "" {
+		org = input.Metadata.Org
+	}
+	exempt := resourceCeilingExemptions(input.Tests, input.Images)
+	validationErrors = append(validationErrors, validateResources("resources", input.Resources, ceilings, org, exempt)...)
+	if ceilings != nil {
+		validationErrors = append(validationErrors, validateStepRequestCeilings(input.Tests, ceilings.CeilingsFor(org), org, exempt)...)
+	}
 	return validationErrors
 }
 
-func validateResources(fieldRoot string, resources api.ResourceConfiguration) []error {
+// resourceCeilingExemptions returns the names of the tests and images
+// annotated to be exempt from the resource ceilings.
+func resourceCeilingExemptions(tests []api.TestStepConfiguration, images []api.ProjectDirectoryImageBuildStepConfiguration) sets.Set[string] {
+	exempt := sets.New[string]()
+	for _, test := range tests {
+		if _, ok := test.Annotations[api.ResourceCeilingExemptionAnnotation]; ok {
+			exempt.Insert(test.As)
+		}
+	}
+	for _, image := range images {
+		if _, ok := image.Annotations[api.ResourceCeilingExemptionAnnotation]; ok {
+			exempt.Insert(string(image.To))
+		}
+	}
+	return exempt
+}
+
+// validateStepRequestCeilings verifies that the steps of the multi-stage
+// tests, literal or resolved from the registry, do not request more than the
+// ceilings.
+func validateStepRequestCeilings(tests []api.TestStepConfiguration, ceilings api.ResourceList, org string, exempt sets.Set[string]) []error {
+	var validationErrors []error
+	stepRemedy := fmt.Sprintf("annotate the test with %s if it needs more", api.ResourceCeilingExemptionAnnotation)
+	validatePhase := func(fieldRoot string, steps []api.TestStep) {
+		for i, step := range steps {
+			if step.LiteralTestStep != nil {
+				validationErrors = append(validationErrors, validateRequestCeilings(fmt.Sprintf("%s[%d].resources.requests", fieldRoot, i), step.Resources.Requests, ceilings, org, stepRemedy)...)
+			}
+		}
+	}
+	for i, test := range tests {
+		if exempt.Has(test.As) {
+			continue
+		}
+		if literal := test.MultiStageTestConfigurationLiteral; literal != nil {
+			fieldRoot := fmt.Sprintf("tests[%d].literal_steps", i)
+			validatePhase(fieldRoot+".pre", asTestSteps(literal.Pre))
+			validatePhase(fieldRoot+".test", asTestSteps(literal.Test))
+			validatePhase(fieldRoot+".gather", asTestSteps(literal.Gather))
+			validatePhase(fieldRoot+".post", asTestSteps(literal.Post))
+		}
+		if unresolved := test.MultiStageTestConfiguration; unresolved != nil {
+			fieldRoot := fmt.Sprintf("tests[%d].steps", i)
+			validatePhase(fieldRoot+".pre", unresolved.Pre)
+			validatePhase(fieldRoot+".test", unresolved.Test)
+			validatePhase(fieldRoot+".gather", unresolved.Gather)
+			validatePhase(fieldRoot+".post", unresolved.Post)
+		}
+	}
+	return validationErrors
+}
+
+func asTestSteps(steps []api.LiteralTestStep) []api.TestStep {
+	ret := make([]api.TestStep, 0, len(steps))
+	for i := range steps {
+		ret = append(ret, api.TestStep{LiteralTestStep: &steps[i]})
+	}
+	return ret
+}
+
+// WithResourceCeilings returns a validator which also restricts the cpu and
+// memory the builds and tests may request to the ceilings of the policy.
+func (v Validator) WithResourceCeilings(policy *api.ResourceCeilingPolicy) Validator {
+	v.resourceCeilings = policy
+	return v
+}
+
+// WithArchitectures replaces the architectures images may be built for, e.g.
+// when the build farms gain nodes of a new architecture.
+func (v Validator) WithArchitectures(architectures sets.Set[string]) Validator {
+	v.architectures = architectures
+	return v
+}
+
+func (v *Validator) supportedArchitectures() sets.Set[string] {
+	if v.architectures == nil {
+		return api.DefaultArchitectures()
+	}
+	return v.architectures
+}
+
+// validateResources verifies the resource configuration and, if a policy is
+// given, that the requests of the builds and tests which are not exempt do
+// not exceed the ceilings of the organization.
+func validateResources(fieldRoot string, resources api.ResourceConfiguration, ceilings *api.ResourceCeilingPolicy, org string, exempt sets.Set[string]) []error {
 	var validationErrors []error
 	if len(resources) == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("'%s' should be specified to provide resource requests", fieldRoot))
@@ -581,12 +1036,46 @@ func validateResources(fieldRoot string, resources api.ResourceConfiguration) []
 		}
 		for key := range resources {
 			validationErrors = append(validationErrors, validateResourceRequirements(fmt.Sprintf("%s.%s", fieldRoot, key), resources[key])...)
+			if ceilings != nil && !exempt.Has(key) {
+				// the blanket policy applies to all the builds and tests
+				// without their own entry, so it cannot be exempted
+				remedy := fmt.Sprintf("annotate the test or image with %s if it needs more", api.ResourceCeilingExemptionAnnotation)
+				if key == "*" {
+					remedy = fmt.Sprintf("lower it and give the tests or images which need more their own entry, annotated with %s", api.ResourceCeilingExemptionAnnotation)
+				}
+				validationErrors = append(validationErrors, validateRequestCeilings(fmt.Sprintf("%s.%s.requests", fieldRoot, key), resources[key].Requests, ceilings.CeilingsFor(org), org, remedy)...)
+			}
 		}
 	}
 
 	return validationErrors
 }
 
+// validateRequestCeilings verifies that the requests do not exceed the
+// ceilings, suggesting the remedy otherwise. Invalid requests are reported by
+// validateResourceList and invalid ceilings by the validation of the policy.
+func validateRequestCeilings(fieldRoot string, requests, ceilings api.ResourceList, org, remedy string) []error {
+	var validationErrors []error
+	for _, name := range []string{"cpu", "memory"} {
+		raw, ok := requests[name]
+		if !ok || ceilings[name] == "" {
+			continue
+		}
+		request, err := resource.ParseQuantity(raw)
+		if err != nil {
+			continue
+		}
+		ceiling, err := resource.ParseQuantity(ceilings[name])
+		if err != nil {
+			continue
+		}
+		if request.Cmp(ceiling) > 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.%s: %s exceeds the ceiling of %s for the %s organization, %s", fieldRoot, name, raw, ceilings[name], org, remedy))
+		}
+	}
+	return validationErrors
+}
+
 func validateResourceRequirements(fieldRoot string, requirements api.ResourceRequirements) []error {
 	var validationErrors []error
 
@@ -621,7 +1110,7 @@ func validateResourceList(fieldRoot string, list api.ResourceList) []error {
 			if key == api.ShmResource {
 				maxSize := resource.MustParse("2G")
 				if quantity.Cmp(maxSize) > 0 {
-					validationErrors = append(validationErrors, fmt.Errorf("%s.%s: quantity cannot be greater than %v", fieldRoot, key, maxSize))
+					validationErrors = append(validationErrors, withRule(RuleShmLimit, fmt.Errorf("%s.%s: quantity cannot be greater than %v", fieldRoot, key, maxSize)))
 				}
 			}
 		case "devices.kubevirt.io/kvm":
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/diff"
 	"k8s.io/utils/ptr"
 
@@ -61,6 +62,42 @@ func TestValidateBuildRoot(t *testing.T) {
 			},
 			expectedValid: false,
 		},
+		{
+			name: "filtering the context of project_image causes error",
+			buildRootImageConfig: &api.BuildRootImageConfiguration{
+				ProjectImageBuild: &api.ProjectDirectoryImageBuildInputs{
+					DockerfilePath: "Dockerfile.test",
+					Include:        []string{"hack"},
+				},
+			},
+			expectedValid: false,
+		},
+		{
+			name: "buildah backend for the images built on the build root is allowed",
+			buildRootImageConfig: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "test_namespace", Name: "test_name", Tag: "test"},
+				BuildBackend:            api.BuildBackendBuildah,
+			},
+			expectedValid: true,
+		},
+		{
+			name: "unknown build backend causes error",
+			buildRootImageConfig: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "test_namespace", Name: "test_name", Tag: "test"},
+				BuildBackend:            "kaniko",
+			},
+			expectedValid: false,
+		},
+		{
+			name: "buildah backend for project_image causes error",
+			buildRootImageConfig: &api.BuildRootImageConfiguration{
+				ProjectImageBuild: &api.ProjectDirectoryImageBuildInputs{
+					DockerfilePath: "Dockerfile.test",
+					BuildBackend:   api.BuildBackendBuildah,
+				},
+			},
+			expectedValid: false,
+		},
 		{
 			name:                 "build root without any content causes an error",
 			buildRootImageConfig: &api.BuildRootImageConfiguration{},
@@ -158,7 +195,8 @@ func TestValidateExternalConfiguration(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := validateExternalConfiguration(NewConfigContext().AddField("external"), tc.externalConfig); (err != nil) && tc.expectedValid {
+			v := newSingleUseValidator()
+			if err := v.validateExternalConfiguration(NewConfigContext().AddField("external"), tc.externalConfig); (err != nil) && tc.expectedValid {
 				t.Errorf("expected to be valid, got: %v", err)
 			} else if !tc.expectedValid && err == nil {
 				t.Error("expected to be invalid, but returned valid")
@@ -380,7 +418,7 @@ func TestValidateResources(t *testing.T) {
 		},
 	} {
 		t.Run(testCase.name, func(t *testing.T) {
-			err := validateResources("", testCase.input)
+			err := validateResources("", testCase.input, nil, "", nil)
 			if err == nil && testCase.expectedErr {
 				t.Errorf("%s: expected an error, but got none", testCase.name)
 			}
@@ -391,6 +429,108 @@ func TestValidateResources(t *testing.T) {
 	}
 }
 
+func TestValidateResourceCeilings(t *testing.T) {
+	policy := &api.ResourceCeilingPolicy{
+		Default: api.ResourceList{"cpu": "8", "memory": "16Gi"},
+		Orgs:    map[string]api.ResourceList{"big": {"cpu": "32"}},
+	}
+	large := api.ResourceRequirements{Requests: api.ResourceList{"cpu": "32", "memory": "20Gi"}}
+	small := api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m", "memory": "200Mi"}}
+	config := func(annotations map[string]string) *api.ReleaseBuildConfiguration {
+		return &api.ReleaseBuildConfiguration{
+			Metadata:  api.Metadata{Org: "org", Repo: "repo"},
+			Resources: api.ResourceConfiguration{"*": small, "unit": large},
+			Tests: []api.TestStepConfiguration{
+				{As: "unit", Annotations: annotations, ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "e2e", Annotations: annotations, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre:    []api.LiteralTestStep{{As: "install", Resources: small}},
+					Test:   []api.LiteralTestStep{{As: "small", Resources: small}, {As: "large", Resources: large}},
+					Gather: []api.LiteralTestStep{{As: "must-gather", Resources: large}},
+				}},
+				{As: "inline", Annotations: annotations, MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test: []api.TestStep{{Reference: ptr.To("ref")}, {LiteralTestStep: &api.LiteralTestStep{As: "large", Resources: large}}},
+				}},
+			},
+		}
+	}
+	withImage := func(c *api.ReleaseBuildConfiguration, annotations map[string]string) *api.ReleaseBuildConfiguration {
+		c.Resources["image"] = large
+		c.Images = []api.ProjectDirectoryImageBuildStepConfiguration{{To: "image", Annotations: annotations}}
+		return c
+	}
+	for _, tc := range []struct {
+		name     string
+		config   *api.ReleaseBuildConfiguration
+		policy   *api.ResourceCeilingPolicy
+		expected []error
+	}{
+		{
+			name:   "no policy",
+			config: config(nil),
+		},
+		{
+			name:   "requests above the default ceilings",
+			config: config(nil),
+			policy: policy,
+			expected: []error{
+				errors.New("resources.unit.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, annotate the test or image with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("resources.unit.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, annotate the test or image with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[1].literal_steps.test[1].resources.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[1].literal_steps.test[1].resources.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[1].literal_steps.gather[0].resources.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[1].literal_steps.gather[0].resources.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[2].steps.test[1].resources.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("tests[2].steps.test[1].resources.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, annotate the test with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+			},
+		},
+		{
+			name: "ceilings of the organization replace the default",
+			config: func() *api.ReleaseBuildConfiguration {
+				c := config(nil)
+				c.Metadata.Org = "big"
+				return c
+			}(),
+			policy: policy,
+		},
+		{
+			name:   "annotated tests are exempt",
+			config: config(map[string]string{api.ResourceCeilingExemptionAnnotation: "the tests compile the world"}),
+			policy: policy,
+		},
+		{
+			name:   "builds of images are not exempt with the tests",
+			config: withImage(config(map[string]string{api.ResourceCeilingExemptionAnnotation: "the tests compile the world"}), nil),
+			policy: policy,
+			expected: []error{
+				errors.New("resources.image.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, annotate the test or image with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+				errors.New("resources.image.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, annotate the test or image with ci.openshift.io/resource-ceiling-exemption if it needs more"),
+			},
+		},
+		{
+			name: "the blanket policy cannot be exempted",
+			config: func() *api.ReleaseBuildConfiguration {
+				c := config(map[string]string{api.ResourceCeilingExemptionAnnotation: "the tests compile the world"})
+				c.Resources["*"] = large
+				return c
+			}(),
+			policy: policy,
+			expected: []error{
+				errors.New("resources.*.requests.cpu: 32 exceeds the ceiling of 8 for the org organization, lower it and give the tests or images which need more their own entry, annotated with ci.openshift.io/resource-ceiling-exemption"),
+				errors.New("resources.*.requests.memory: 20Gi exceeds the ceiling of 16Gi for the org organization, lower it and give the tests or images which need more their own entry, annotated with ci.openshift.io/resource-ceiling-exemption"),
+			},
+		},
+		{
+			name:   "annotated images are exempt",
+			config: withImage(config(map[string]string{api.ResourceCeilingExemptionAnnotation: "the tests compile the world"}), map[string]string{api.ResourceCeilingExemptionAnnotation: "the image embeds the world"}),
+			policy: policy,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateReleaseBuildConfiguration(tc.config, "org", "repo", false, tc.policy), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
 func TestValidatePromotion(t *testing.T) {
 	var testCases = []struct {
 		name                    string
@@ -425,6 +565,34 @@ func TestValidatePromotion(t *testing.T) {
 			imageTargets: true,
 			expected:     []error{errors.New("promotion.to[0]: both name and tag defined")},
 		},
+		{
+			name:         "commit tag retention with tags by commit is valid",
+			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "foo", Tag: "bar", TagByCommit: true, CommitTagRetention: 10}}},
+			imageTargets: true,
+		},
+		{
+			name:         "commit tag retention without tags by commit yields errors",
+			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "foo", Tag: "bar", CommitTagRetention: 10}}},
+			imageTargets: true,
+			expected:     []error{errors.New("promotion.to[0].commit_tag_retention: requires tag_by_commit")},
+		},
+		{
+			name:         "negative commit tag retention yields errors",
+			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "foo", Tag: "bar", TagByCommit: true, CommitTagRetention: -1}}},
+			imageTargets: true,
+			expected:     []error{errors.New("promotion.to[0].commit_tag_retention: must not be negative")},
+		},
+		{
+			name:         "paused target",
+			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "foo", Tag: "bar", PausedUntil: "2024-06-10T00:00:00Z"}}},
+			imageTargets: true,
+		},
+		{
+			name:         "malformed pause yields errors",
+			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "foo", Tag: "bar", PausedUntil: "June 10th"}}},
+			imageTargets: true,
+			expected:     []error{errors.New(`promotion.to[0].paused_until: must be an RFC3339 timestamp like 2006-01-02T15:04:05Z, got "June 10th"`)},
+		},
 		{
 			name:         "cannot promote to namespace openshift-some",
 			input:        api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "openshift-some", Tag: "bar"}}},
@@ -492,6 +660,117 @@ func TestValidatePromotion(t *testing.T) {
 	}
 }
 
+func TestValidatePromotionArchitectures(t *testing.T) {
+	images := []api.ProjectDirectoryImageBuildStepConfiguration{
+		{To: "multi", AdditionalArchitectures: []string{"arm64", "s390x"}},
+		{To: "single"},
+		{To: "legacy", MultiArch: true},
+		{To: "optional", Optional: true},
+	}
+	var testCases = []struct {
+		name     string
+		input    api.PromotionConfiguration
+		expected []error
+	}{
+		{
+			name:  "no architectures requested",
+			input: api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ci", Name: "latest"}}},
+		},
+		{
+			name: "all promoted images are built for the architectures",
+			input: api.PromotionConfiguration{Targets: []api.PromotionTarget{{
+				Namespace:               "ci",
+				Name:                    "latest",
+				ExcludedImages:          []string{"single"},
+				AdditionalArchitectures: []string{"arm64"},
+			}}},
+		},
+		{
+			name: "promoted images are not built for the architectures",
+			input: api.PromotionConfiguration{Targets: []api.PromotionTarget{{
+				Namespace:               "ci",
+				Name:                    "latest",
+				ExcludedImages:          []string{"legacy"},
+				AdditionalImages:        map[string]string{"other": "optional"},
+				AdditionalArchitectures: []string{"s390x", "sparc"},
+			}}},
+			expected: []error{
+				errors.New("promotion.to[0].additional_architectures: image optional is not built for s390x, it must be added to the additional_architectures of the image"),
+				errors.New("promotion.to[0].additional_architectures: image single is not built for s390x, it must be added to the additional_architectures of the image"),
+				errors.New("promotion.to[0].additional_architectures: invalid architecture: sparc. Use one of amd64, arm64, ppc64le, riscv64, s390x"),
+			},
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expected, validatePromotionArchitectures("promotion", test.input, images, api.DefaultArchitectures()), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("got incorrect errors: %v", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePromotionMirrors(t *testing.T) {
+	target := func(mirror *api.PromotionMirror) api.PromotionConfiguration {
+		return api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ci", Name: "latest", Mirror: mirror}}}
+	}
+	var testCases = []struct {
+		name     string
+		input    api.PromotionConfiguration
+		expected []error
+	}{
+		{
+			name:  "no mirror",
+			input: target(nil),
+		},
+		{
+			name:  "valid mirror",
+			input: target(&api.PromotionMirror{Registry: "quay.io", Repository: "org/repo", Credentials: "quay-push"}),
+		},
+		{
+			name:  "registry with a port",
+			input: target(&api.PromotionMirror{Registry: "registry.example.com:5000", Repository: "ci", Credentials: "quay-push"}),
+		},
+		{
+			name:  "empty mirror",
+			input: target(&api.PromotionMirror{}),
+			expected: []error{
+				errors.New("promotion.to[0].mirror.registry: must be set"),
+				errors.New("promotion.to[0].mirror.repository: must be set"),
+				errors.New("promotion.to[0].mirror.credentials: must be set"),
+			},
+		},
+		{
+			name:     "registry with a scheme",
+			input:    target(&api.PromotionMirror{Registry: "https://quay.io", Repository: "org/repo", Credentials: "quay-push"}),
+			expected: []error{errors.New(`promotion.to[0].mirror.registry: must be a registry host without a scheme or a path, got "https://quay.io"`)},
+		},
+		{
+			name:     "registry which is not a host",
+			input:    target(&api.PromotionMirror{Registry: "quay", Repository: "org/repo", Credentials: "quay-push"}),
+			expected: []error{errors.New(`promotion.to[0].mirror.registry: "quay" is not a registry host`)},
+		},
+		{
+			name:     "invalid repository",
+			input:    target(&api.PromotionMirror{Registry: "quay.io", Repository: "Org/Repo", Credentials: "quay-push"}),
+			expected: []error{errors.New(`promotion.to[0].mirror.repository: "Org/Repo" is not a valid repository: repository name must be lowercase`)},
+		},
+		{
+			name:     "unknown credentials",
+			input:    target(&api.PromotionMirror{Registry: "quay.io", Repository: "org/repo", Credentials: "other"}),
+			expected: []error{errors.New(`promotion.to[0].mirror.credentials: secret "other" does not exist in the test-credentials namespace`)},
+		},
+	}
+	v := NewValidator(nil, nil, sets.New[string]("quay-push"))
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expected, v.validatePromotionMirrors("promotion", test.input), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("got incorrect errors: %v", diff)
+			}
+		})
+	}
+}
+
 func TestValidateReleaseTagConfiguration(t *testing.T) {
 	var testCases = []struct {
 		name     string
@@ -535,11 +814,46 @@ func TestValidateImages(t *testing.T) {
 				errors.New("images[1]: duplicate image name 'same-thing' (previously defined by field 'images[0]')"),
 			},
 		},
+		{
+			name: "buildah backend is allowed",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{BuildBackend: api.BuildBackendBuildah},
+				To:                               "amsterdam",
+			}},
+		},
+		{
+			name: "unknown build backend",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{BuildBackend: "kaniko"},
+				To:                               "amsterdam",
+			}},
+			output: []error{
+				errors.New(`images[0].build_backend: unknown build backend "kaniko", use one of build, buildah`),
+			},
+		},
+		{
+			name: "image exempt from the resource ceilings",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:          "amsterdam",
+				Annotations: map[string]string{api.ResourceCeilingExemptionAnnotation: "the image embeds the world"},
+			}},
+		},
+		{
+			name: "unsupported image annotations",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:          "amsterdam",
+				Annotations: map[string]string{api.ResourceCeilingExemptionAnnotation: " ", "owner": "me"},
+			}},
+			output: []error{
+				errors.New(`images[0].annotations: value " " for key "ci.openshift.io/resource-ceiling-exemption" must explain why more resources are needed`),
+				errors.New(`images[0].annotations: unsupported annotation "owner", only ci.openshift.io/resource-ceiling-exemption is supported`),
+			},
+		},
 		{
 			name: "Dockerfile literal is mutually exclusive with context_dir",
 			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
-					DockerfileLiteral: ptr.To("FROM foo"),
+					DockerfileLiteral: ptr.To("FROM scratch"),
 					ContextDir:        "foo",
 				},
 				To: "amsterdam",
@@ -552,7 +866,7 @@ func TestValidateImages(t *testing.T) {
 			name: "Dockerfile literal is mutually exclusive with dockerfile_path",
 			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
 				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
-					DockerfileLiteral: ptr.To("FROM foo"),
+					DockerfileLiteral: ptr.To("FROM scratch"),
 					DockerfilePath:    "foo",
 				},
 				To: "amsterdam",
@@ -568,7 +882,120 @@ func TestValidateImages(t *testing.T) {
 				To:                      "amsterdam",
 			}},
 			output: []error{
-				errors.New("images[0]: invalid architecture: foo. Use one of amd64, arm64, ppc64le, s390x"),
+				withRule(RuleImageArchitecture, errors.New("images[0]: invalid architecture: foo. Use one of amd64, arm64, ppc64le, riscv64, s390x")),
+			},
+		},
+		{
+			name: "Dockerfile literal using declared pipeline images",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					DockerfileLiteral: ptr.To("ARG TAG=bin\nFROM pipeline:base AS builder\nCOPY --from=pipeline:bin /bin/tool /bin/\nFROM pipeline:$TAG\nCOPY --from=builder /out /out\nFROM registry.ci.openshift.org/ocp/4.15:base"),
+					Inputs: map[string]api.ImageBuildInputs{
+						"base": {As: []string{"pipeline:base", "registry.ci.openshift.org/ocp/4.15:base"}},
+						"bin":  {},
+					},
+				},
+				To: "amsterdam",
+			}},
+		},
+		{
+			name: "Dockerfile literal using undeclared pipeline images",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					DockerfileLiteral: ptr.To("FROM pipeline:base AS builder\nCOPY --from=pipeline:bin /bin/tool /bin/\nFROM pipeline:base"),
+					Inputs: map[string]api.ImageBuildInputs{
+						"base": {},
+					},
+				},
+				To: "amsterdam",
+			}},
+			output: []error{
+				errors.New(`images[0].dockerfile_literal: line 2: "pipeline:bin" is used but "bin" is not declared in inputs`),
+			},
+		},
+		{
+			name: "Dockerfile literal built from replaced images",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				From: "base",
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					DockerfileLiteral: ptr.To("FROM golang AS builder\nRUN make\nFROM Builder as second\nADD --chown=1000 https.tar.gz /\nFROM scratch\nFROM registry.ci.openshift.org/ocp/4.15:base"),
+					Inputs: map[string]api.ImageBuildInputs{
+						"golang": {As: []string{"golang"}},
+					},
+				},
+				To: "amsterdam",
+			}},
+		},
+		{
+			name: "Dockerfile literal built from images which are not replaced",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					DockerfileLiteral: ptr.To("FROM golang AS builder\nADD https://example.com/tool.tar.gz git@github.com:org/repo.git /opt/\nFROM ubi\nADD [\"http://example.com/file\", \"/file\"]"),
+					Inputs: map[string]api.ImageBuildInputs{
+						"golang": {As: []string{"golang"}},
+					},
+				},
+				To: "amsterdam",
+			}},
+			output: []error{
+				errors.New(`images[0].dockerfile_literal: line 2: adding the remote file "https://example.com/tool.tar.gz" is not allowed`),
+				errors.New(`images[0].dockerfile_literal: line 2: adding the remote file "git@github.com:org/repo.git" is not allowed`),
+				errors.New(`images[0].dockerfile_literal: line 3: "ubi" is not replaced by an image of the pipeline, declare it in the ` + "`as`" + ` of one of the inputs`),
+				errors.New(`images[0].dockerfile_literal: line 4: adding the remote file "http://example.com/file" is not allowed`),
+			},
+		},
+		{
+			name: "input replacing another pipeline image",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{
+						"base": {As: []string{"ubi", "pipeline:bin"}},
+					},
+				},
+				To: "amsterdam",
+			}},
+			output: []error{
+				errors.New(`images[0].inputs[base].as[1]: "pipeline:bin" refers to the input "bin", not "base"`),
+			},
+		},
+		{
+			name: "valid context patterns",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Include: []string{"cmd/tool/", "go.*", "pkg/[a-m]*"},
+					Exclude: []string{"*_test.go", "testdata"},
+				},
+				To: "amsterdam",
+			}},
+		},
+		{
+			name: "filtered build context collides with an image",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{To: "amsterdam-build-context"},
+				{
+					ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{Include: []string{"cmd"}},
+					To:                               "amsterdam",
+				},
+			},
+			output: []error{
+				errors.New("images[1]: duplicate image name 'amsterdam-build-context' (previously defined by field 'images[0]')"),
+			},
+		},
+		{
+			name: "invalid context patterns",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Include: []string{"", "cmd/$(id)", "/abs"},
+					Exclude: []string{"../up", "pkg/[a"},
+				},
+				To: "amsterdam",
+			}},
+			output: []error{
+				errors.New(`images[0].include[0]: pattern "" must be a non-empty glob of letters, digits and the characters ._+@=,/*?![]-`),
+				errors.New(`images[0].include[1]: pattern "cmd/$(id)" must be a non-empty glob of letters, digits and the characters ._+@=,/*?![]-`),
+				errors.New(`images[0].include[2]: pattern "/abs" must be relative to the context directory`),
+				errors.New(`images[0].exclude[0]: pattern "../up" must be relative to the context directory`),
+				errors.New(`images[0].exclude[1]: invalid pattern "pkg/[a": syntax error in pattern`),
 			},
 		},
 	}
@@ -577,7 +1004,7 @@ func TestValidateImages(t *testing.T) {
 			config := &api.ReleaseBuildConfiguration{
 				Images: testCase.input,
 			}
-			if actual, expected := ValidateImages(NewConfigContext().AddField("images"), config.Images), testCase.output; !reflect.DeepEqual(actual, expected) {
+			if actual, expected := ValidateImages(NewConfigContext().AddField("images"), config.Images, api.DefaultArchitectures()), testCase.output; !reflect.DeepEqual(actual, expected) {
 				t.Errorf("%s: got incorrect errors: %s", testCase.name, cmp.Diff(actual, expected, cmp.Comparer(func(x, y error) bool {
 					return x.Error() == y.Error()
 				})))
@@ -853,9 +1280,25 @@ func TestReleaseBuildConfiguration_validateImages(t *testing.T) {
 			Resources: resources,
 		},
 		expected: errors.New(`invalid configuration: it is not permissible to directly set: ‘build_roots’ directly in the config`),
+	}, {
+		name: "inputs provided by the pipeline",
+		config: api.ReleaseBuildConfiguration{
+			InputConfiguration: api.InputConfiguration{
+				BuildRootImage: &root,
+				BaseImages:     map[string]api.ImageStreamTagReference{"base": {Namespace: "ns", Name: "name", Tag: "tag"}},
+			},
+			BinaryBuildCommands: "make",
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{To: "image", ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					Inputs: map[string]api.ImageBuildInputs{"base": {}, "bin": {}, "other": {}, "root": {}, "src": {}},
+				}},
+				{To: "other"},
+			},
+			Resources: resources,
+		},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
-			err := IsValidResolvedConfiguration(&tc.config, tc.mergedConfig)
+			_, err := IsValidResolvedConfiguration(&tc.config, tc.mergedConfig, nil)
 			testhelper.Diff(t, "error", err, tc.expected, testhelper.EquateErrorMessage)
 		})
 	}
@@ -1197,6 +1640,32 @@ func TestPipelineImages(t *testing.T) {
 			Resources:          resources,
 		},
 		expected: errors.New(`invalid configuration: images[0]: duplicate image name 'rpms' (previously defined by field 'rpm_build_commands')`),
+	}, {
+		name: "tools",
+		conf: api.ReleaseBuildConfiguration{
+			Tools:              &api.ToolsImageConfiguration{Tools: []api.Tool{{Name: "oc", Version: "4.17.0"}}},
+			InputConfiguration: input,
+			Images:             makeImages("tools"),
+			Resources:          resources,
+		},
+		expected: errors.New(`invalid configuration: images[0]: duplicate image name 'tools' (previously defined by field 'tools')`),
+	}, {
+		name: "tools without a build root",
+		conf: api.ReleaseBuildConfiguration{
+			Tools:     &api.ToolsImageConfiguration{Tools: []api.Tool{{Name: "oc", Version: "4.17.0"}}},
+			Images:    makeImages("to0"),
+			Resources: resources,
+		},
+		expected: errors.New(`invalid configuration: tools.from: the tools are installed in the build root by default, which is not configured`),
+	}, {
+		name: "invalid tools",
+		conf: api.ReleaseBuildConfiguration{
+			Tools:              &api.ToolsImageConfiguration{From: "tools", Tools: []api.Tool{{Name: "kubectl", Version: "1.31.0"}}},
+			InputConfiguration: input,
+			Images:             makeImages("to0"),
+			Resources:          resources,
+		},
+		expected: errors.New("configuration has 2 errors:\n\n  * tools[0].url: must be set for \"kubectl\", which is not one of the well-known tools aws, gcloud, jq, oc, yq\n  * tools.from: the tools cannot be installed in the tools image\n"),
 	}, {
 		name: "bundle",
 		conf: api.ReleaseBuildConfiguration{
@@ -1388,7 +1857,7 @@ func TestPipelineImages(t *testing.T) {
 		},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
-			err := IsValidConfiguration(&tc.conf, "org", "repo")
+			_, err := IsValidConfiguration(&tc.conf, "org", "repo")
 			testhelper.Diff(t, "error", err, tc.expected, testhelper.EquateErrorMessage)
 		})
 	}
@@ -1494,8 +1963,53 @@ func TestValidateReleaseBuildConfiguration(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.input.Resources = map[string]api.ResourceRequirements{"*": {Requests: map[string]string{"cpu": "1"}}}
-			err := validateReleaseBuildConfiguration(tc.input, "org", "repo", tc.mergedConfig)
+			err := validateReleaseBuildConfiguration(tc.input, "org", "repo", tc.mergedConfig, nil)
 			testhelper.Diff(t, "error", err, tc.expected, testhelper.EquateErrorMessage)
 		})
 	}
 }
+
+func TestIsValidClusterGroupTests(t *testing.T) {
+	step := func(as string) api.LiteralTestStep {
+		return api.LiteralTestStep{
+			As:        as,
+			From:      "src",
+			Commands:  "make test",
+			Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "1"}},
+		}
+	}
+	group := func(steps ...api.LiteralTestStep) []api.TestStepConfiguration {
+		return []api.TestStepConfiguration{{
+			As: "e2e",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				ClusterProfile: api.ClusterProfileAWS,
+				Test:           steps,
+			},
+		}}
+	}
+	for _, tc := range []struct {
+		name        string
+		tests       []api.TestStepConfiguration
+		expectedErr error
+	}{
+		{
+			name:  "valid group",
+			tests: group(step("a-conformance"), step("b-conformance")),
+		},
+		{
+			name:        "steps of different tests end up with the same name",
+			tests:       group(step("a-b-c"), step("a-b-c")),
+			expectedErr: errors.New("invalid configuration: cluster_groups[0].steps.test[1]: duplicated name \"a-b-c\""),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &api.ReleaseBuildConfiguration{
+				Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			}
+			err := IsValidClusterGroupTests(config, tc.tests, nil)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
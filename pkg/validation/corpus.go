@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// CorpusValidator validates the properties of the whole set of configurations
+// in the config directory, which cannot be checked one configuration at a
+// time.
+type CorpusValidator struct {
+	// promotedBy maps every promoted tag to the configurations promoting it.
+	promotedBy map[api.ImageStreamTagReference][]*api.Metadata
+}
+
+// PromotedTagsFunc returns the tags a configuration promotes its images to.
+// Promotion is implemented by the steps, which validation does not depend
+// on, so callers usually pass release.PromotedTags.
+type PromotedTagsFunc func(configuration *api.ReleaseBuildConfiguration) []api.ImageStreamTagReference
+
+// NewCorpusValidator indexes all the configurations loaded from the config
+// directory. All of them must be passed, even when only some are validated,
+// as a collision may involve a configuration which did not change.
+func NewCorpusValidator(configs []api.ReleaseBuildConfiguration, promotedTags PromotedTagsFunc) *CorpusValidator {
+	v := &CorpusValidator{promotedBy: map[api.ImageStreamTagReference][]*api.Metadata{}}
+	for i := range configs {
+		for _, tag := range promotedTags(&configs[i]) {
+			v.promotedBy[tag] = append(v.promotedBy[tag], &configs[i].Metadata)
+		}
+	}
+	return v
+}
+
+// Validate reports every tag promoted by more than one configuration, as the
+// last one to promote would silently overwrite the images of the others.
+func (v *CorpusValidator) Validate() []error {
+	var tags []api.ImageStreamTagReference
+	for tag, promotedBy := range v.promotedBy {
+		if len(promotedBy) > 1 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].ISTagName() < tags[j].ISTagName()
+	})
+	var errs []error
+	for _, tag := range tags {
+		var formatted []string
+		for _, info := range v.promotedBy[tag] {
+			identifier := fmt.Sprintf("%s/%s@%s", info.Org, info.Repo, info.Branch)
+			if info.Variant != "" {
+				identifier = fmt.Sprintf("%s [%s]", identifier, info.Variant)
+			}
+			formatted = append(formatted, identifier)
+		}
+		sort.Strings(formatted)
+		errs = append(errs, fmt.Errorf("output tag %s is promoted from more than one place: %v", tag.ISTagName(), strings.Join(formatted, ", ")))
+	}
+	return errs
+}
@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/release"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCorpusValidator(t *testing.T) {
+	promoting := func(org, repo, variant, namespace, name string, images ...string) api.ReleaseBuildConfiguration {
+		config := api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: org, Repo: repo, Branch: "master", Variant: variant},
+			PromotionConfiguration: &api.PromotionConfiguration{
+				Targets: []api.PromotionTarget{{Namespace: namespace, Name: name}},
+			},
+		}
+		for _, image := range images {
+			config.Images = append(config.Images, api.ProjectDirectoryImageBuildStepConfiguration{To: api.PipelineImageStreamTagReference(image)})
+		}
+		return config
+	}
+	for _, tc := range []struct {
+		name     string
+		configs  []api.ReleaseBuildConfiguration
+		expected []error
+	}{
+		{
+			name: "no collisions",
+			configs: []api.ReleaseBuildConfiguration{
+				promoting("org", "a", "", "ocp", "4.17", "a"),
+				promoting("org", "b", "", "ocp", "4.17", "b"),
+				promoting("org", "c", "", "ocp", "4.18", "a"),
+			},
+		},
+		{
+			name: "collision between repositories",
+			configs: []api.ReleaseBuildConfiguration{
+				promoting("org", "b", "", "ocp", "4.17", "shared", "b"),
+				promoting("org", "a", "", "ocp", "4.17", "shared", "a"),
+				promoting("other", "c", "", "ocp", "4.17", "c", "b"),
+			},
+			expected: []error{
+				errors.New("output tag ocp/4.17:b is promoted from more than one place: org/b@master, other/c@master"),
+				errors.New("output tag ocp/4.17:shared is promoted from more than one place: org/a@master, org/b@master"),
+			},
+		},
+		{
+			name: "collision between variants",
+			configs: []api.ReleaseBuildConfiguration{
+				promoting("org", "a", "", "ocp", "4.17", "a"),
+				promoting("org", "a", "okd", "ocp", "4.17", "a"),
+			},
+			expected: []error{
+				errors.New("output tag ocp/4.17:a is promoted from more than one place: org/a@master, org/a@master [okd]"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", NewCorpusValidator(tc.configs, release.PromotedTags).Validate(), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/imagebuilder"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// imageDependencies maps each image built by the configuration to the images
+// of the pipeline its build depends on: the image it is built `from`, its
+// inputs, the `pipeline:` images its literal Dockerfile is built from and,
+// for operator bundles, the images substituted into the manifests.
+func imageDependencies(config *api.ReleaseBuildConfiguration) map[string]sets.Set[string] {
+	graph := map[string]sets.Set[string]{}
+	add := func(image string, dependencies ...string) {
+		if graph[image] == nil {
+			graph[image] = sets.New[string]()
+		}
+		graph[image].Insert(dependencies...)
+	}
+	for _, image := range config.Images {
+		to := string(image.To)
+		add(to)
+		if image.From != "" {
+			add(to, string(image.From))
+		}
+		for name := range image.Inputs {
+			add(to, name)
+		}
+		if image.DockerfileLiteral != nil {
+			add(to, literalPipelineImages(image)...)
+		}
+	}
+	if operator := config.Operator; operator != nil {
+		source := string(api.PipelineImageStreamTagReferenceBundleSource)
+		add(source)
+		for _, substitution := range operator.Substitutions {
+			with := substitution.With
+			if stream, name, ok := strings.Cut(with, ":"); ok {
+				if stream != api.PipelineImageStream && stream != api.StableImageStream {
+					continue
+				}
+				with = name
+			}
+			add(source, with)
+		}
+		for i, bundle := range operator.Bundles {
+			name := api.BundleName(i)
+			if bundle.As != "" {
+				name = bundle.As
+			}
+			add(name, source)
+		}
+	}
+	// only the images built by the configuration can be part of a cycle
+	for _, dependencies := range graph {
+		for dependency := range dependencies {
+			if _, ok := graph[dependency]; !ok {
+				dependencies.Delete(dependency)
+			}
+		}
+	}
+	return graph
+}
+
+// literalPipelineImages returns the images of the pipeline the stages of a
+// literal Dockerfile are built from, with the build arguments expanded the
+// way the build does. Dockerfiles which cannot be parsed are reported by the
+// validation of the images.
+func literalPipelineImages(image api.ProjectDirectoryImageBuildStepConfiguration) []string {
+	node, err := imagebuilder.ParseDockerfile(strings.NewReader(*image.DockerfileLiteral))
+	if err != nil {
+		return nil
+	}
+	args := map[string]string{}
+	for _, arg := range image.BuildArgs {
+		args[arg.Name] = arg.Value
+	}
+	stages, err := imagebuilder.NewStages(node, imagebuilder.NewBuilder(args))
+	if err != nil {
+		return nil
+	}
+	var ret []string
+	for _, stage := range stages {
+		from, err := stage.Builder.From(stage.Node)
+		if err != nil {
+			continue
+		}
+		if name, ok := strings.CutPrefix(from, api.PipelineImageStream+":"); ok {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// validateImageDependencyCycles ensures the builds of the images do not
+// depend on each other in a cycle, which would otherwise only be detected
+// when ci-operator builds its execution graph. Each cycle is reported once,
+// with the path of images it goes through.
+func validateImageDependencyCycles(config *api.ReleaseBuildConfiguration) []error {
+	graph := imageDependencies(config)
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var cycles [][]string
+	var visit func(string)
+	visit = func(image string) {
+		state[image] = inPath
+		path = append(path, image)
+		for _, dependency := range sets.List(graph[image]) {
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case inPath:
+				for i := range path {
+					if path[i] == dependency {
+						cycles = append(cycles, append(append([]string{}, path[i:]...), dependency))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[image] = done
+	}
+	images := make([]string, 0, len(graph))
+	for image := range graph {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		if state[image] == unvisited {
+			visit(image)
+		}
+	}
+	var validationErrors []error
+	for _, cycle := range cycles {
+		validationErrors = append(validationErrors, fmt.Errorf("images: cycle in the dependencies of the images: %s", strings.Join(cycle, " -> ")))
+	}
+	return validationErrors
+}
@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateImageDependencyCycles(t *testing.T) {
+	image := func(to, from string, inputs ...string) api.ProjectDirectoryImageBuildStepConfiguration {
+		ret := api.ProjectDirectoryImageBuildStepConfiguration{
+			To:   api.PipelineImageStreamTagReference(to),
+			From: api.PipelineImageStreamTagReference(from),
+		}
+		for _, input := range inputs {
+			if ret.Inputs == nil {
+				ret.Inputs = map[string]api.ImageBuildInputs{}
+			}
+			ret.Inputs[input] = api.ImageBuildInputs{As: []string{"registry.ci/" + input}}
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		name     string
+		config   api.ReleaseBuildConfiguration
+		expected []error
+	}{
+		{
+			name: "images depending on each other without cycles",
+			config: api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					image("base", "root"),
+					image("cli", "base", "tools"),
+					image("tools", "base"),
+					image("operator", "cli"),
+				},
+				Operator: &api.OperatorStepConfiguration{
+					Bundles:       []api.Bundle{{As: "bundle"}},
+					Substitutions: []api.PullSpecSubstitution{{PullSpec: "quay.io/org/operator:latest", With: "pipeline:operator"}},
+				},
+			},
+		},
+		{
+			name: "image built from itself",
+			config: api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{image("base", "base")},
+			},
+			expected: []error{errors.New("images: cycle in the dependencies of the images: base -> base")},
+		},
+		{
+			name: "cycle through inputs",
+			config: api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					image("cli", "base", "tools"),
+					image("tools", "installer"),
+					image("installer", "", "cli"),
+					image("unrelated", "cli"),
+				},
+			},
+			expected: []error{errors.New("images: cycle in the dependencies of the images: cli -> tools -> installer -> cli")},
+		},
+		{
+			name: "cycle through a literal Dockerfile",
+			config: api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					{To: "cli", ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+						DockerfileLiteral: ptr.To("FROM --platform=linux/amd64 pipeline:tools AS builder\nFROM scratch"),
+					}},
+					image("tools", "cli"),
+				},
+			},
+			expected: []error{errors.New("images: cycle in the dependencies of the images: cli -> tools -> cli")},
+		},
+		{
+			name: "image built from a bundle substituting it",
+			config: api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					image("operator", "ci-bundle0"),
+				},
+				Operator: &api.OperatorStepConfiguration{
+					Bundles:       []api.Bundle{{}},
+					Substitutions: []api.PullSpecSubstitution{{PullSpec: "quay.io/org/operator:latest", With: "operator"}},
+				},
+			},
+			expected: []error{errors.New("images: cycle in the dependencies of the images: ci-bundle0 -> src-bundle -> operator -> ci-bundle0")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateImageDependencyCycles(&tc.config), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestLiteralPipelineImages(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		dockerfile string
+		buildArgs  []api.BuildArg
+		expected   []string
+	}{
+		{
+			name:       "stages built from pipeline images",
+			dockerfile: "FROM --platform=linux/amd64 pipeline:tools AS builder\nfrom pipeline:base as runtime\nFROM builder\nFROM registry.ci/other:latest",
+			expected:   []string{"tools", "base"},
+		},
+		{
+			name:       "build arguments are expanded with their defaults",
+			dockerfile: "ARG BUILDER=pipeline:tools\nARG BASE\nFROM ${BUILDER} AS builder\nFROM $BASE",
+			expected:   []string{"tools"},
+		},
+		{
+			name:       "build arguments are expanded with the values of the build",
+			dockerfile: "ARG BUILDER=pipeline:tools\nARG BASE\nFROM ${BUILDER} AS builder\nFROM $BASE",
+			buildArgs:  []api.BuildArg{{Name: "BUILDER", Value: "pipeline:cli"}, {Name: "BASE", Value: "pipeline:base"}},
+			expected:   []string{"cli", "base"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			image := api.ProjectDirectoryImageBuildStepConfiguration{ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+				DockerfileLiteral: ptr.To(tc.dockerfile),
+				BuildArgs:         tc.buildArgs,
+			}}
+			testhelper.Diff(t, "images", literalPipelineImages(image), tc.expected)
+		})
+	}
+}
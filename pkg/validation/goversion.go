@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// InRepoBuildRootGetter returns the build root a configuration reads from the
+// repository, or nil if the repository does not declare one.
+type InRepoBuildRootGetter func(metadata api.Metadata) (*api.ImageStreamTagReference, error)
+
+// policyReleases returns the OCP releases a configuration targets which are
+// restricted by the policy: the ones it promotes to and the ones it tests with.
+func policyReleases(policy *api.GoVersionPolicy, config *api.ReleaseBuildConfiguration) []string {
+	releases := sets.New[string]()
+	add := func(namespace, name string) {
+		if namespace != "ocp" {
+			return
+		}
+		if _, restricted := policy.Releases[name]; restricted {
+			releases.Insert(name)
+		}
+	}
+	for _, target := range api.PromotionTargets(config.PromotionConfiguration) {
+		if !target.Disabled {
+			add(target.Namespace, target.Name)
+		}
+	}
+	if tags := config.ReleaseTagConfiguration; tags != nil {
+		add(tags.Namespace, tags.Name)
+	}
+	for _, release := range config.Releases {
+		if release.Integration != nil {
+			add(release.Integration.Namespace, release.Integration.Name)
+		}
+	}
+	return sets.List(releases)
+}
+
+// ValidateGoVersionPolicy verifies that the Go versions of the build roots of
+// a configuration are allowed by the policy for all the releases it targets.
+// Build roots read from the repository are only checked when `inRepo` is set,
+// the build roots of other repositories are read from those repositories.
+// Golang images whose Go version cannot be determined from their name or tag
+// are rejected, other images are not restricted.
+func ValidateGoVersionPolicy(policy *api.GoVersionPolicy, config *api.ReleaseBuildConfiguration, inRepo InRepoBuildRootGetter) error {
+	if policy.Exempts(config.Metadata) {
+		return nil
+	}
+	releases := policyReleases(policy, config)
+	if len(releases) == 0 {
+		return nil
+	}
+	roots := map[string]api.BuildRootImageConfiguration{}
+	// repositories holds the repository each build root is read from when it
+	// is read from a repository
+	repositories := map[string]api.Metadata{}
+	if config.BuildRootImage != nil {
+		roots["build_root"] = *config.BuildRootImage
+		repositories["build_root"] = config.Metadata
+	}
+	for ref, root := range config.BuildRootImages {
+		field := fmt.Sprintf("build_roots.%s", ref)
+		roots[field] = root
+		// the build roots of other repositories are keyed by org.repo
+		if org, repo, ok := strings.Cut(ref, "."); ok {
+			repositories[field] = api.Metadata{Org: org, Repo: repo, Branch: config.Metadata.Branch}
+		}
+	}
+	var fields []string
+	for field := range roots {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var errs []error
+	for _, field := range fields {
+		root := roots[field]
+		tag := root.ImageStreamTagReference
+		if root.FromRepository {
+			if inRepo == nil {
+				continue
+			}
+			repository, known := repositories[field]
+			if !known {
+				errs = append(errs, fmt.Errorf("%s: could not determine the repository to read the build root from", field))
+				continue
+			}
+			var err error
+			if tag, err = inRepo(repository); err != nil {
+				errs = append(errs, fmt.Errorf("%s: could not read the build root from the repository: %w", field, err))
+				continue
+			}
+			field = fmt.Sprintf("%s (from %s)", field, api.CIOperatorInrepoConfigFileName)
+		}
+		if tag == nil {
+			continue
+		}
+		version := goVersionOf(tag)
+		if version == "" {
+			if strings.Contains(tag.ISTagName(), "golang") {
+				errs = append(errs, fmt.Errorf("%s: could not determine the Go version of %s, use a tag naming it like rhel-9-golang-1.22-openshift-4.17", field, tag.ISTagName()))
+			}
+			continue
+		}
+		for _, release := range releases {
+			if allowed := policy.Releases[release]; !sets.New[string](allowed...).Has(version) {
+				errs = append(errs, fmt.Errorf("%s: Go %s of %s is not allowed for release %s, use one of: %s", field, version, tag.ISTagName(), release, strings.Join(allowed, ", ")))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// goVersionOf determines the Go version of a builder image from its tag or,
+// for images named after their version, from its name.
+func goVersionOf(tag *api.ImageStreamTagReference) string {
+	if version := api.GoVersionFor(tag.Tag); version != "" {
+		return version
+	}
+	return api.GoVersionFor(tag.Name)
+}
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateGoVersionPolicy(t *testing.T) {
+	policy := &api.GoVersionPolicy{
+		Releases: map[string][]string{
+			"4.16": {"1.21", "1.22"},
+			"4.17": {"1.22"},
+		},
+		Exemptions: []api.GoVersionPolicyExemption{
+			{Org: "openshift", Repo: "legacy", Reason: "vendors an old toolchain"},
+			{Org: "openshift", Repo: "operator", Branch: "release-4.16", Reason: "backports only"},
+		},
+	}
+	builder := func(version, release string) *api.ImageStreamTagReference {
+		return &api.ImageStreamTagReference{Namespace: "ocp", Name: "builder", Tag: "rhel-9-golang-" + version + "-openshift-" + release}
+	}
+	promotingTo := func(metadata api.Metadata, release string, root api.BuildRootImageConfiguration) *api.ReleaseBuildConfiguration {
+		return &api.ReleaseBuildConfiguration{
+			Metadata:               metadata,
+			InputConfiguration:     api.InputConfiguration{BuildRootImage: &root},
+			PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ocp", Name: release}}},
+		}
+	}
+	operator := api.Metadata{Org: "openshift", Repo: "operator", Branch: "master"}
+	for _, tc := range []struct {
+		name     string
+		config   *api.ReleaseBuildConfiguration
+		inRepo   InRepoBuildRootGetter
+		expected error
+	}{
+		{
+			name:   "allowed version",
+			config: promotingTo(operator, "4.16", api.BuildRootImageConfiguration{ImageStreamTagReference: builder("1.21", "4.16")}),
+		},
+		{
+			name:     "version not allowed",
+			config:   promotingTo(operator, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: builder("1.21", "4.17")}),
+			expected: errors.New("build_root: Go 1.21 of ocp/builder:rhel-9-golang-1.21-openshift-4.17 is not allowed for release 4.17, use one of: 1.22"),
+		},
+		{
+			name: "release tested with and per-repository build roots",
+			config: &api.ReleaseBuildConfiguration{
+				Metadata: operator,
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImages: map[string]api.BuildRootImageConfiguration{
+						"openshift/api":  {ImageStreamTagReference: builder("1.20", "4.16")},
+						"openshift/test": {ImageStreamTagReference: builder("1.22", "4.16")},
+					},
+					Releases: map[string]api.UnresolvedRelease{
+						"latest": {Integration: &api.Integration{Namespace: "ocp", Name: "4.16"}},
+					},
+				},
+			},
+			expected: errors.New("build_roots.openshift/api: Go 1.20 of ocp/builder:rhel-9-golang-1.20-openshift-4.16 is not allowed for release 4.16, use one of: 1.21, 1.22"),
+		},
+		{
+			name:   "release without a policy",
+			config: promotingTo(operator, "4.15", api.BuildRootImageConfiguration{ImageStreamTagReference: builder("1.20", "4.15")}),
+		},
+		{
+			name:   "unknown Go version",
+			config: promotingTo(operator, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "python", Tag: "3.11"}}),
+		},
+		{
+			name:     "golang image without a Go version",
+			config:   promotingTo(operator, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "openshift", Name: "release", Tag: "golang"}}),
+			expected: errors.New("build_root: could not determine the Go version of openshift/release:golang, use a tag naming it like rhel-9-golang-1.22-openshift-4.17"),
+		},
+		{
+			name:     "Go version in the name of the image",
+			config:   promotingTo(operator, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "openshift", Name: "golang-1.21", Tag: "latest"}}),
+			expected: errors.New("build_root: Go 1.21 of openshift/golang-1.21:latest is not allowed for release 4.17, use one of: 1.22"),
+		},
+		{
+			name: "build root read from another repository",
+			config: &api.ReleaseBuildConfiguration{
+				Metadata: operator,
+				InputConfiguration: api.InputConfiguration{
+					BuildRootImages: map[string]api.BuildRootImageConfiguration{
+						"openshift.api":      {FromRepository: true},
+						"openshift.operator": {FromRepository: true},
+					},
+				},
+				PromotionConfiguration: &api.PromotionConfiguration{Targets: []api.PromotionTarget{{Namespace: "ocp", Name: "4.17"}}},
+			},
+			inRepo: func(metadata api.Metadata) (*api.ImageStreamTagReference, error) {
+				if metadata == (api.Metadata{Org: "openshift", Repo: "api", Branch: "master"}) {
+					return builder("1.21", "4.17"), nil
+				}
+				return builder("1.22", "4.17"), nil
+			},
+			expected: errors.New("build_roots.openshift.api (from .ci-operator.yaml): Go 1.21 of ocp/builder:rhel-9-golang-1.21-openshift-4.17 is not allowed for release 4.17, use one of: 1.22"),
+		},
+		{
+			name:   "exempt repository",
+			config: promotingTo(api.Metadata{Org: "openshift", Repo: "legacy", Branch: "master"}, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: builder("1.19", "4.17")}),
+		},
+		{
+			name:     "exemption of another branch",
+			config:   promotingTo(operator, "4.17", api.BuildRootImageConfiguration{ImageStreamTagReference: builder("1.19", "4.17")}),
+			expected: errors.New("build_root: Go 1.19 of ocp/builder:rhel-9-golang-1.19-openshift-4.17 is not allowed for release 4.17, use one of: 1.22"),
+		},
+		{
+			name:   "build root from the repository is not checked",
+			config: promotingTo(operator, "4.17", api.BuildRootImageConfiguration{FromRepository: true}),
+		},
+		{
+			name:   "build root from the repository",
+			config: promotingTo(operator, "4.17", api.BuildRootImageConfiguration{FromRepository: true}),
+			inRepo: func(api.Metadata) (*api.ImageStreamTagReference, error) {
+				return builder("1.21", "4.17"), nil
+			},
+			expected: errors.New("build_root (from .ci-operator.yaml): Go 1.21 of ocp/builder:rhel-9-golang-1.21-openshift-4.17 is not allowed for release 4.17, use one of: 1.22"),
+		},
+		{
+			name:   "repository without a build root",
+			config: promotingTo(operator, "4.17", api.BuildRootImageConfiguration{FromRepository: true}),
+			inRepo: func(api.Metadata) (*api.ImageStreamTagReference, error) {
+				return nil, nil
+			},
+		},
+		{
+			name:   "build root from the repository cannot be read",
+			config: promotingTo(operator, "4.17", api.BuildRootImageConfiguration{FromRepository: true}),
+			inRepo: func(api.Metadata) (*api.ImageStreamTagReference, error) {
+				return nil, errors.New("not found")
+			},
+			expected: errors.New("build_root: could not read the build root from the repository: not found"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateGoVersionPolicy(policy, tc.config, tc.inRepo)
+			if diff := cmp.Diff(tc.expected, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
@@ -98,6 +98,15 @@ func validateContainerTest(
 		}
 		ret = append(ret, errors.New(msg))
 	}
+	for i, service := range c.Services {
+		if _, ok := pipelineImages[service.From]; !ok {
+			msg := fmt.Sprintf("tests[%s].services[%d].from: unknown image %q", s.As, i, service.From)
+			if s := pipelineImageToConfigField[service.From]; s != "" {
+				msg = fmt.Sprintf("%s (configuration is missing `%s`)", msg, s)
+			}
+			ret = append(ret, errors.New(msg))
+		}
+	}
 	return
 }
 
@@ -123,6 +132,9 @@ func validateMultiStageTest(
 	for i, step := range ms.Test {
 		ret = append(ret, f("test", i, step)...)
 	}
+	for i, step := range ms.Gather {
+		ret = append(ret, f("gather", i, step)...)
+	}
 	for i, step := range ms.Post {
 		ret = append(ret, f("post", i, step)...)
 	}
@@ -134,4 +146,5 @@ var pipelineImageToConfigField = map[api.PipelineImageStreamTagReference]string{
 	api.PipelineImageStreamTagReferenceBinaries:     api.PipelineImageStreamTagSourceBinaries,
 	api.PipelineImageStreamTagReferenceTestBinaries: api.PipelineImageStreamTagSourceTestBinaries,
 	api.PipelineImageStreamTagReferenceRPMs:         api.PipelineImageStreamTagSourceRPMs,
+	api.PipelineImageStreamTagReferenceTools:        api.PipelineImageStreamTagSourceTools,
 }
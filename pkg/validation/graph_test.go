@@ -226,6 +226,21 @@ func TestIsValidGraph_ContainerTestFrom(t *testing.T) {
 			Tests: tests("rpms"),
 		},
 		expected: errs("tests[test-rpms].from: unknown image \"rpms\" (configuration is missing `rpm_build_commands`)"),
+	}, {
+		name: "service from unknown images",
+		config: api.ReleaseBuildConfiguration{
+			Tests: []api.TestStepConfiguration{{
+				As: "test-services",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{
+					From:     "src",
+					Services: []api.TestService{{As: "db", From: "src"}, {As: "cache", From: "redis"}, {As: "build", From: "bin"}},
+				},
+			}},
+		},
+		expected: errs(
+			`tests[test-services].services[1].from: unknown image "redis"`,
+			"tests[test-services].services[2].from: unknown image \"bin\" (configuration is missing `binary_build_commands`)",
+		),
 	}, {
 		name:   "from src",
 		config: api.ReleaseBuildConfiguration{Tests: tests("src")},
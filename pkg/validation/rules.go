@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// Rule is a validation check which configurations may opt out of with
+// `disabled_validation_rules`.
+type Rule struct {
+	Name        string
+	Description string
+}
+
+var rules = map[string]Rule{}
+
+// registerRule makes a check known under its name and returns the name.
+func registerRule(name, description string) string {
+	if _, exists := rules[name]; exists {
+		panic(fmt.Sprintf("validation rule %q registered twice", name))
+	}
+	rules[name] = Rule{Name: name, Description: description}
+	return name
+}
+
+var (
+	RulePromotionNamespace = registerRule("promotion-namespace", "images must not be promoted to namespaces reserved by the cluster, e.g. ones starting with kube or openshift")
+	RuleShmLimit           = registerRule("shm-limit", "the size of /dev/shm must not exceed 2G")
+	RuleImageArchitecture  = registerRule("image-architecture", "images must only be built for the architectures available in the build farm")
+	RuleResourceRequests   = registerRule("resource-requests", "in strict mode, container tests and literal test steps must request cpu and memory explicitly")
+)
+
+// Rules returns all the rules, sorted by name.
+func Rules() []Rule {
+	var ret []Rule
+	for _, rule := range rules {
+		ret = append(ret, rule)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// IsRule determines whether a rule with the name exists.
+func IsRule(name string) bool {
+	_, exists := rules[name]
+	return exists
+}
+
+// ruleError is an error raised by a rule.
+type ruleError struct {
+	rule string
+	err  error
+}
+
+func (e *ruleError) Error() string { return e.err.Error() }
+func (e *ruleError) Unwrap() error { return e.err }
+
+// withRule attributes an error to a rule, so that it is dropped when the
+// rule is disabled.
+func withRule(rule string, err error) error {
+	return &ruleError{rule: rule, err: err}
+}
+
+// filterDisabledRules drops the errors raised by the rules the configuration
+// disables, and reports the names of unknown rules.
+func filterDisabledRules(config *api.ReleaseBuildConfiguration, errs []error) []error {
+	disabled := sets.New[string]()
+	var ret []error
+	for i, name := range config.DisabledValidationRules {
+		if !IsRule(name) {
+			ret = append(ret, fmt.Errorf("disabled_validation_rules[%d]: unknown rule %q", i, name))
+		}
+		disabled.Insert(name)
+	}
+	for _, err := range errs {
+		var re *ruleError
+		if errors.As(err, &re) && disabled.Has(re.rule) {
+			continue
+		}
+		ret = append(ret, err)
+	}
+	return ret
+}
+
+// ValidateDisabledRules verifies that a configuration only disables the rules
+// the allowlist allows for its repository.
+func ValidateDisabledRules(allowlist *api.ValidationRuleAllowlist, config *api.ReleaseBuildConfiguration) error {
+	allowed := allowlist.Allowed(config.Metadata)
+	var errs []error
+	for i, name := range config.DisabledValidationRules {
+		if !allowed.Has(name) {
+			errs = append(errs, fmt.Errorf("disabled_validation_rules[%d]: rule %q may not be disabled for %s", i, name, config.Metadata.AsString()))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestFilterDisabledRules(t *testing.T) {
+	errs := []error{
+		errors.New("plain"),
+		withRule(RuleShmLimit, errors.New("shm")),
+		withRule(RuleImageArchitecture, errors.New("architecture")),
+	}
+	for _, tc := range []struct {
+		name     string
+		disabled []string
+		expected []error
+	}{
+		{
+			name:     "no rules disabled",
+			expected: errs,
+		},
+		{
+			name:     "rule disabled",
+			disabled: []string{RuleShmLimit},
+			expected: []error{errors.New("plain"), errors.New("architecture")},
+		},
+		{
+			name:     "unknown rule",
+			disabled: []string{"no-such-rule"},
+			expected: []error{errors.New(`disabled_validation_rules[0]: unknown rule "no-such-rule"`), errors.New("plain"), errors.New("shm"), errors.New("architecture")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &api.ReleaseBuildConfiguration{DisabledValidationRules: tc.disabled}
+			testhelper.Diff(t, "errors", filterDisabledRules(config, errs), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestDisabledRuleIsNotEnforced(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "builder", Tag: "latest"},
+			},
+		},
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+			{To: "image", AdditionalArchitectures: []string{"sparc64"}},
+		},
+		Resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "1"}}},
+	}
+	if _, err := IsValidConfiguration(&config, "org", "repo"); err == nil {
+		t.Fatal("expected the architecture to be rejected")
+	}
+	config.DisabledValidationRules = []string{RuleImageArchitecture}
+	if _, err := IsValidConfiguration(&config, "org", "repo"); err != nil {
+		t.Errorf("unexpected error with the rule disabled: %v", err)
+	}
+}
+
+func TestValidateDisabledRules(t *testing.T) {
+	allowlist := &api.ValidationRuleAllowlist{Entries: []api.ValidationRuleAllowlistEntry{
+		{Org: "openshift", Repo: "internal", Rules: []string{RulePromotionNamespace}, Reason: "promotes to openshift-internal"},
+		{Org: "openshift", Repo: "ml", Branch: "main", Rules: []string{RuleShmLimit}, Reason: "needs a large /dev/shm"},
+	}}
+	for _, tc := range []struct {
+		name     string
+		metadata api.Metadata
+		disabled []string
+		expected error
+	}{
+		{
+			name:     "allowed rule",
+			metadata: api.Metadata{Org: "openshift", Repo: "internal", Branch: "master"},
+			disabled: []string{RulePromotionNamespace},
+		},
+		{
+			name:     "rule not allowed for the repository",
+			metadata: api.Metadata{Org: "openshift", Repo: "internal", Branch: "master"},
+			disabled: []string{RuleShmLimit},
+			expected: errors.New(`disabled_validation_rules[0]: rule "shm-limit" may not be disabled for openshift/internal@master`),
+		},
+		{
+			name:     "rule not allowed for the branch",
+			metadata: api.Metadata{Org: "openshift", Repo: "ml", Branch: "release-4.17"},
+			disabled: []string{RuleShmLimit},
+			expected: errors.New(`disabled_validation_rules[0]: rule "shm-limit" may not be disabled for openshift/ml@release-4.17`),
+		},
+		{
+			name:     "nothing disabled",
+			metadata: api.Metadata{Org: "openshift", Repo: "other", Branch: "master"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &api.ReleaseBuildConfiguration{Metadata: tc.metadata, DisabledValidationRules: tc.disabled}
+			testhelper.Diff(t, "error", ValidateDisabledRules(allowlist, config), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// validateSchemaVersion ensures the configuration declares a known schema
+// version and only uses the fields that version supports.
+func validateSchemaVersion(config *api.ReleaseBuildConfiguration) []error {
+	if config.SchemaVersion < 0 || config.SchemaVersion > api.LatestSchemaVersion {
+		return []error{fmt.Errorf("schema_version: unknown version %d, the latest version is %d", config.SchemaVersion, api.LatestSchemaVersion)}
+	}
+	if config.SchemaVersion >= 2 && config.InputConfiguration.ReleaseTagConfiguration != nil {
+		return []error{fmt.Errorf("tag_specification: not supported in schema version %d, use releases.initial and releases.latest instead", config.SchemaVersion)}
+	}
+	return nil
+}
+
+// ValidateLatestSchemaVersion requires the configuration to be written in the
+// latest schema version. Existing configurations keep working in the version
+// they were written in and are upgraded with determinize-ci-operator
+// --upgrade-schema, but new ones are expected to use the latest version from
+// the start.
+func ValidateLatestSchemaVersion(config *api.ReleaseBuildConfiguration) error {
+	if config.SchemaVersion != api.LatestSchemaVersion {
+		return fmt.Errorf("schema_version: new configurations must use the latest schema version %d", api.LatestSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateSchemaVersion(t *testing.T) {
+	tagSpec := &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"}
+	for _, tc := range []struct {
+		name     string
+		config   api.ReleaseBuildConfiguration
+		expected []error
+	}{
+		{
+			name:   "no version with tag_specification",
+			config: api.ReleaseBuildConfiguration{InputConfiguration: api.InputConfiguration{ReleaseTagConfiguration: tagSpec}},
+		},
+		{
+			name:   "latest version",
+			config: api.ReleaseBuildConfiguration{SchemaVersion: api.LatestSchemaVersion},
+		},
+		{
+			name:     "latest version with tag_specification",
+			config:   api.ReleaseBuildConfiguration{SchemaVersion: api.LatestSchemaVersion, InputConfiguration: api.InputConfiguration{ReleaseTagConfiguration: tagSpec}},
+			expected: []error{errors.New("tag_specification: not supported in schema version 2, use releases.initial and releases.latest instead")},
+		},
+		{
+			name:     "unknown version",
+			config:   api.ReleaseBuildConfiguration{SchemaVersion: 3},
+			expected: []error{errors.New("schema_version: unknown version 3, the latest version is 2")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateSchemaVersion(&tc.config), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestValidateLatestSchemaVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		version  int
+		expected error
+	}{
+		{
+			name:    "latest version",
+			version: api.LatestSchemaVersion,
+		},
+		{
+			name:     "no version",
+			expected: errors.New("schema_version: new configurations must use the latest schema version 2"),
+		},
+		{
+			name:     "older version",
+			version:  api.InitialSchemaVersion,
+			expected: errors.New("schema_version: new configurations must use the latest schema version 2"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateLatestSchemaVersion(&api.ReleaseBuildConfiguration{SchemaVersion: tc.version})
+			testhelper.Diff(t, "error", err, tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
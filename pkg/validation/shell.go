@@ -0,0 +1,327 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ambientParameters are set in the environment of every step, by the shell,
+// by Prow or by ci-operator.
+var ambientParameters = sets.New[string](
+	// shell
+	"HOME", "PATH", "PWD", "OLDPWD", "HOSTNAME", "USER", "UID", "EUID", "PPID", "SHELL", "IFS", "RANDOM",
+	"SECONDS", "LINENO", "FUNCNAME", "PIPESTATUS", "OSTYPE", "HOSTTYPE", "TMPDIR", "REPLY", "OPTARG", "OPTIND",
+	// Prow
+	"CI", "JOB_NAME", "JOB_SPEC", "JOB_TYPE", "PROW_JOB_ID", "BUILD_ID", "BUILD_NUMBER", "REPO_OWNER", "REPO_NAME", "ARTIFACTS",
+	// ci-operator
+	"ARTIFACT_DIR", "SHARED_DIR", "CLUSTER_PROFILE_DIR", "KUBECONFIG", "KUBECONFIGMINIMAL", "KUBEADMIN_PASSWORD_FILE",
+	"NAMESPACE", "JOB_NAME_SAFE", "JOB_NAME_HASH", "UNIQUE_HASH", "IMAGE_FORMAT", "OPENSHIFT_CI", "NETWORK_STACK",
+	"FAILED_STEP", "FAILED_STEPS", "FAILED_STEP_PHASE", "FAILED_STEP_EXIT_CODE", "CLUSTER_TYPE", "CLUSTER_PROFILE_NAME",
+	"GOOGLE_APPLICATION_CREDENTIALS", "CLOUDSDK_AUTH_CREDENTIAL_FILE_OVERRIDE", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE",
+	api.CliEnv, api.DefaultLeaseEnv, api.DefaultIPPoolLeaseEnv,
+)
+
+// ambientParameterPrefixes prefix the names of families of parameters set in
+// the environment of steps.
+var ambientParameterPrefixes = []string{"BASH", "PULL_", "RELEASE_IMAGE_", "ORIGINAL_RELEASE_IMAGE_", "LOCAL_IMAGE_", "GIT_CONFIG_"}
+
+var (
+	nounsetPattern  = regexp.MustCompile(`(?m)^\s*set\s+(?:-[a-zA-Z]*u|.*-o\s+nounset)`)
+	unsetPattern    = regexp.MustCompile(`(?m)^\s*set\s+(?:\+[a-zA-Z]*u|.*\+o\s+nounset)`)
+	sourcePattern   = regexp.MustCompile(`(?m)(?:^|[;&|(]|\s)(?:source|\.|eval)\s+\S`)
+	shebangPattern  = regexp.MustCompile(`^#!\s*(?:/usr)?/bin/(?:env\s+)?sh\s*\n`)
+	assignPattern   = regexp.MustCompile(`(?:^|[\s;&|(])([A-Za-z_][A-Za-z0-9_]*)(?:\[[^\]]*\])?\+?=`)
+	forPattern      = regexp.MustCompile(`\bfor\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b`)
+	printfPattern   = regexp.MustCompile(`\bprintf\s+-v\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	declarePattern  = regexp.MustCompile(`(?m)(?:^|[\s;&|(])(?:export|local|declare|typeset|readonly|read|mapfile|readarray|getopts)\s+([^;&|\n]*)`)
+	identifier      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	bashismPatterns = []struct {
+		pattern     *regexp.Regexp
+		description string
+	}{
+		{regexp.MustCompile(`\[\[`), "`[[` tests"},
+		{regexp.MustCompile(`<<<`), "here-strings"},
+		{regexp.MustCompile(`(?m)^\s*function\s+[A-Za-z_]`), "the `function` keyword"},
+		{regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*=\(`), "arrays"},
+		{regexp.MustCompile(`&>`), "`&>` redirections"},
+		{regexp.MustCompile(`(?m)(?:^|[;&|]\s*)source\s`), "`source`"},
+		{regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*(?://?|:[0-9])`), "substitutions and substrings in parameter expansions"},
+		{regexp.MustCompile(`\$'`), "`$'...'` quoting"},
+	}
+)
+
+// expansion is a `${...}` parameter expansion without a default value.
+type expansion struct {
+	name string
+	line int
+}
+
+// shellContext is an open quoting or substitution construct.
+type shellContext struct {
+	kind string
+	line int
+	// depth counts the parentheses opened in a command substitution
+	depth int
+}
+
+const (
+	shellSingleQuote = "single quote"
+	shellDoubleQuote = "double quote"
+	shellAnsiQuote   = "`$'` quote"
+	shellBacktick    = "backtick"
+	shellCommandSub  = "`$(` substitution"
+	shellParameter   = "`${` expansion"
+)
+
+// scanShell finds the first unterminated construct of the script and the
+// parameter expansions without a default value. It understands quotes,
+// escapes, comments, substitutions and here-documents, which is enough to
+// find mistakes without parsing the script.
+func scanShell(script string) (*shellContext, []expansion) {
+	var stack []*shellContext
+	var expansions []expansion
+	top := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		return stack[len(stack)-1].kind
+	}
+	lines := strings.Split(script, "\n")
+	for lineIdx := 0; lineIdx < len(lines); lineIdx++ {
+		line, lineNo := lines[lineIdx], lineIdx+1
+		var heredocs []string
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			next := byte(0)
+			if i+1 < len(line) {
+				next = line[i+1]
+			}
+			switch top() {
+			case shellSingleQuote:
+				if c == '\'' {
+					stack = stack[:len(stack)-1]
+				}
+				continue
+			case shellAnsiQuote:
+				if c == '\\' {
+					i++
+				} else if c == '\'' {
+					stack = stack[:len(stack)-1]
+				}
+				continue
+			case shellDoubleQuote:
+				switch {
+				case c == '\\':
+					i++
+				case c == '"':
+					stack = stack[:len(stack)-1]
+				case c == '`':
+					stack = append(stack, &shellContext{kind: shellBacktick, line: lineNo})
+				case c == '$' && next == '(':
+					stack = append(stack, &shellContext{kind: shellCommandSub, line: lineNo})
+					i++
+				case c == '$' && next == '{':
+					if e, ok := parseExpansion(line[i+2:]); ok {
+						expansions = append(expansions, expansion{name: e, line: lineNo})
+					}
+					stack = append(stack, &shellContext{kind: shellParameter, line: lineNo})
+					i++
+				}
+				continue
+			}
+			// unquoted contexts
+			switch {
+			case c == '\\':
+				i++
+			case c == '\'':
+				stack = append(stack, &shellContext{kind: shellSingleQuote, line: lineNo})
+			case c == '"':
+				stack = append(stack, &shellContext{kind: shellDoubleQuote, line: lineNo})
+			case c == '`':
+				if top() == shellBacktick {
+					stack = stack[:len(stack)-1]
+				} else {
+					stack = append(stack, &shellContext{kind: shellBacktick, line: lineNo})
+				}
+			case c == '$' && next == '\'':
+				stack = append(stack, &shellContext{kind: shellAnsiQuote, line: lineNo})
+				i++
+			case c == '$' && next == '(':
+				stack = append(stack, &shellContext{kind: shellCommandSub, line: lineNo})
+				i++
+			case c == '$' && next == '{':
+				if e, ok := parseExpansion(line[i+2:]); ok {
+					expansions = append(expansions, expansion{name: e, line: lineNo})
+				}
+				stack = append(stack, &shellContext{kind: shellParameter, line: lineNo})
+				i++
+			case c == '(' && top() == shellCommandSub:
+				stack[len(stack)-1].depth++
+			case c == ')' && top() == shellCommandSub:
+				if stack[len(stack)-1].depth > 0 {
+					stack[len(stack)-1].depth--
+				} else {
+					stack = stack[:len(stack)-1]
+				}
+			case c == '}' && top() == shellParameter:
+				stack = stack[:len(stack)-1]
+			case c == '#' && top() != shellParameter && (i == 0 || strings.ContainsRune(" \t;&|(", rune(line[i-1]))):
+				i = len(line)
+			case c == '<' && next == '<' && top() != shellParameter:
+				if i+2 < len(line) && line[i+2] == '<' {
+					// here-string
+					i += 2
+					continue
+				}
+				delimiter, consumed := parseHeredoc(line[i+2:])
+				if delimiter != "" {
+					heredocs = append(heredocs, delimiter)
+				}
+				i += 1 + consumed
+			}
+		}
+		// the bodies of here-documents start on the next line
+		for _, delimiter := range heredocs {
+			for lineIdx++; lineIdx < len(lines); lineIdx++ {
+				if strings.TrimSpace(lines[lineIdx]) == delimiter {
+					break
+				}
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return stack[0], expansions
+	}
+	return nil, expansions
+}
+
+// parseExpansion returns the name of the parameter expanded by the text
+// following `${` if it has no default value.
+func parseExpansion(text string) (string, bool) {
+	name := identifier.FindString(text)
+	if name == "" {
+		// positional and special parameters, lengths and indirections
+		return "", false
+	}
+	rest := strings.TrimPrefix(text[len(name):], ":")
+	if rest == "" || strings.ContainsRune("-=+?", rune(rest[0])) {
+		return "", false
+	}
+	return name, true
+}
+
+// parseHeredoc returns the delimiter of a here-document from the text
+// following `<<` and the number of characters it spans.
+func parseHeredoc(text string) (string, int) {
+	consumed := 0
+	if strings.HasPrefix(text, "-") {
+		consumed++
+	}
+	for consumed < len(text) && (text[consumed] == ' ' || text[consumed] == '\t') {
+		consumed++
+	}
+	rest := text[consumed:]
+	for _, quote := range []string{"'", `"`} {
+		if strings.HasPrefix(rest, quote) {
+			if end := strings.Index(rest[1:], quote); end != -1 {
+				return rest[1 : end+1], consumed + end + 2
+			}
+		}
+	}
+	rest = strings.TrimPrefix(rest, `\`)
+	delimiter := identifier.FindString(rest)
+	return delimiter, consumed + len(delimiter)
+}
+
+// definedParameters returns the parameters the script sets itself.
+func definedParameters(script string) sets.Set[string] {
+	ret := sets.New[string]()
+	for _, pattern := range []*regexp.Regexp{assignPattern, forPattern, printfPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(script, -1) {
+			ret.Insert(match[1])
+		}
+	}
+	for _, match := range declarePattern.FindAllStringSubmatch(script, -1) {
+		for _, word := range strings.Fields(match[1]) {
+			if name := identifier.FindString(word); name != "" {
+				ret.Insert(name)
+			}
+		}
+	}
+	return ret
+}
+
+// commandsSyntaxError reports the unbalanced quotes in the commands of a step,
+// which the shell fails to parse on every run.
+func commandsSyntaxError(commands string) error {
+	if unterminated, _ := scanShell(commands); unterminated != nil {
+		return fmt.Errorf("unterminated %s opened on line %d", unterminated.kind, unterminated.line)
+	}
+	return nil
+}
+
+// lintCommands reports likely mistakes in the commands of a step which would
+// only be found when the step fails at runtime: parameters expanded without a
+// default value while they may be unset and `nounset` is in effect, and
+// bash-only syntax in scripts run with `/bin/sh`. Parameters are only checked
+// when the lease variables of the test are known, as those are set for all
+// its steps. The environment of the image of the step is not known here, so
+// the findings are only warnings.
+func lintCommands(step api.LiteralTestStep, testLeases sets.Set[string]) []string {
+	var problems []string
+	_, expansions := scanShell(step.Commands)
+
+	runAsScript := step.RunAsScript != nil && *step.RunAsScript
+	// commands not run as a script are run with `set -eu`
+	nounset := !runAsScript || nounsetPattern.MatchString(step.Commands)
+	// sourced and evaluated files may set any parameter
+	if testLeases != nil && nounset && !unsetPattern.MatchString(step.Commands) && !sourcePattern.MatchString(step.Commands) {
+		defined := definedParameters(step.Commands).Union(testLeases)
+		for _, env := range step.Environment {
+			defined.Insert(env.Name)
+		}
+		for _, dependency := range step.Dependencies {
+			defined.Insert(dependency.Env)
+		}
+		for _, lease := range step.Leases {
+			defined.Insert(lease.Env)
+		}
+		reported := sets.New[string]()
+		for _, e := range expansions {
+			if defined.Has(e.name) || ambientParameters.Has(e.name) || reported.Has(e.name) || hasAmbientPrefix(e.name) {
+				continue
+			}
+			reported.Insert(e.name)
+			problems = append(problems, fmt.Sprintf("parameter %s expanded on line %d is not set by the step and has no default value", e.name, e.line))
+		}
+	}
+
+	if runAsScript && shebangPattern.MatchString(step.Commands) {
+		var bashisms []string
+		for _, bashism := range bashismPatterns {
+			if bashism.pattern.MatchString(step.Commands) {
+				bashisms = append(bashisms, bashism.description)
+			}
+		}
+		sort.Strings(bashisms)
+		if len(bashisms) > 0 {
+			problems = append(problems, fmt.Sprintf("script run with /bin/sh uses bash-only syntax: %s", strings.Join(bashisms, ", ")))
+		}
+	}
+	return problems
+}
+
+func hasAmbientPrefix(name string) bool {
+	for _, prefix := range ambientParameterPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
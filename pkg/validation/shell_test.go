@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLintCommands(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		step       api.LiteralTestStep
+		testLeases sets.Set[string]
+		expected   []string
+	}{
+		{
+			name: "valid commands",
+			step: api.LiteralTestStep{Commands: `# don't fail on comments
+echo "it's $(date "+%s")" '${NOT_EXPANDED}' ` + "`uname \"-a\"`" + `
+cat <<EOF > "${SHARED_DIR}/file"
+it's a here-document with ${UNKNOWN}
+EOF
+for name in a b; do echo "${name}"; done
+read -r first second <<< "a b"
+echo "${first}${second}${OPTIONAL:-}${LEASE}${PARAM}${RELEASE_IMAGE_LATEST}${#}${1:-}"`,
+				Environment: []api.StepParameter{{Name: "PARAM"}},
+			},
+			testLeases: sets.New[string]("LEASE"),
+		},
+		{
+			name:       "unset parameters without defaults",
+			step:       api.LiteralTestStep{Commands: "echo \"${MISSING}\"\necho ${MISSING}\necho ${OTHER%/*}"},
+			testLeases: sets.New[string](),
+			expected: []string{
+				"parameter MISSING expanded on line 1 is not set by the step and has no default value",
+				"parameter OTHER expanded on line 3 is not set by the step and has no default value",
+			},
+		},
+		{
+			name: "parameters are not checked without the leases of the test",
+			step: api.LiteralTestStep{Commands: "echo ${MISSING}"},
+		},
+		{
+			name:       "parameters are not checked when files are sourced",
+			step:       api.LiteralTestStep{Commands: "source \"${SHARED_DIR}/env\"\necho ${FROM_ENV}"},
+			testLeases: sets.New[string](),
+		},
+		{
+			name:       "parameters are not checked in scripts without nounset",
+			step:       api.LiteralTestStep{Commands: "#!/bin/bash\necho ${MISSING}", RunAsScript: ptr.To(true)},
+			testLeases: sets.New[string](),
+		},
+		{
+			name:       "bashisms in a script run with sh",
+			step:       api.LiteralTestStep{Commands: "#!/bin/sh\nif [[ -n \"${X:-}\" ]]; then arr=(a b); fi", RunAsScript: ptr.To(true)},
+			testLeases: sets.New[string](),
+			expected:   []string{"script run with /bin/sh uses bash-only syntax: `[[` tests, arrays"},
+		},
+		{
+			name:       "bashisms are allowed when run with bash",
+			step:       api.LiteralTestStep{Commands: "#!/bin/sh\nif [[ -n \"${X:-}\" ]]; then arr=(a b); fi"},
+			testLeases: sets.New[string](),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, lintCommands(tc.step, tc.testLeases)); diff != "" {
+				t.Errorf("unexpected problems: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCommandsSyntaxError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		commands string
+		expected error
+	}{
+		{
+			name:     "balanced quotes",
+			commands: `echo "it's $(date "+%s")" '${NOT_EXPANDED}'`,
+		},
+		{
+			name:     "unbalanced single quote",
+			commands: "echo ok\necho 'unterminated\necho done",
+			expected: errors.New("unterminated single quote opened on line 2"),
+		},
+		{
+			name:     "unbalanced double quote in a substitution",
+			commands: `echo "$(echo "unterminated)"`,
+			expected: errors.New("unterminated double quote opened on line 1"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, commandsSyntaxError(tc.commands), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// requiredRequests are the resources the tests must request explicitly in
+// strict mode.
+var requiredRequests = []string{"cpu", "memory"}
+
+// WithStrictMode returns a validator which also enforces the strict ruleset.
+// The strict rules are too demanding to be imposed on all configurations, so
+// callers admitting configurations opt into them.
+func (v Validator) WithStrictMode() Validator {
+	v.strict = true
+	return v
+}
+
+// validateStrict enforces the strict ruleset: the containers of container
+// tests and literal test steps of all phases must request cpu and memory
+// explicitly instead of inheriting the requests of the `*` blanket policy,
+// which is sized for builds rather than for tests.
+func validateStrict(config *api.ReleaseBuildConfiguration) []error {
+	var validationErrors []error
+	for i, test := range config.Tests {
+		fieldRoot := fmt.Sprintf("tests[%d]", i)
+		switch {
+		case test.ContainerTestConfiguration != nil:
+			if missing := missingRequests(config.Resources[test.As]); missing != nil {
+				validationErrors = append(validationErrors, withRule(RuleResourceRequests, fmt.Errorf("%s: resources.%s must request %s for the test instead of inheriting the requests of resources.*", fieldRoot, test.As, strings.Join(missing, " and "))))
+			}
+		case test.MultiStageTestConfigurationLiteral != nil:
+			ms := test.MultiStageTestConfigurationLiteral
+			for _, phase := range []struct {
+				name  string
+				steps []api.LiteralTestStep
+			}{{"pre", ms.Pre}, {"test", ms.Test}, {"gather", ms.Gather}, {"post", ms.Post}} {
+				for j, step := range phase.steps {
+					validationErrors = append(validationErrors, validateStepRequests(fmt.Sprintf("%s.literal_steps.%s[%d]", fieldRoot, phase.name, j), step)...)
+				}
+			}
+		case test.MultiStageTestConfiguration != nil:
+			ms := test.MultiStageTestConfiguration
+			for _, phase := range []struct {
+				name  string
+				steps []api.TestStep
+			}{{"pre", ms.Pre}, {"test", ms.Test}, {"gather", ms.Gather}, {"post", ms.Post}} {
+				for j, step := range phase.steps {
+					// steps from the registry are only known once the
+					// configuration is resolved
+					if step.LiteralTestStep != nil {
+						validationErrors = append(validationErrors, validateStepRequests(fmt.Sprintf("%s.steps.%s[%d]", fieldRoot, phase.name, j), *step.LiteralTestStep)...)
+					}
+				}
+			}
+		}
+	}
+	return validationErrors
+}
+
+func validateStepRequests(fieldRoot string, step api.LiteralTestStep) []error {
+	if missing := missingRequests(step.Resources); missing != nil {
+		return []error{withRule(RuleResourceRequests, fmt.Errorf("%s: step %s must request %s", fieldRoot, step.As, strings.Join(missing, " and ")))}
+	}
+	return nil
+}
+
+// missingRequests returns the required resources the requirements do not
+// request.
+func missingRequests(requirements api.ResourceRequirements) []string {
+	var missing []string
+	for _, name := range requiredRequests {
+		if _, ok := requirements.Requests[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateStrict(t *testing.T) {
+	requests := api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m", "memory": "200Mi"}}
+	for _, tc := range []struct {
+		name     string
+		config   api.ReleaseBuildConfiguration
+		expected []error
+	}{
+		{
+			name: "all tests request resources",
+			config: api.ReleaseBuildConfiguration{
+				Resources: api.ResourceConfiguration{"*": requests, "unit": requests},
+				Tests: []api.TestStepConfiguration{
+					{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						Pre:  []api.LiteralTestStep{{As: "install", Resources: requests}},
+						Test: []api.LiteralTestStep{{As: "test", Resources: requests}},
+					}},
+					{As: "unresolved", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test: []api.TestStep{
+							{Reference: ptr.To("from-registry")},
+							{LiteralTestStep: &api.LiteralTestStep{As: "inline", Resources: requests}},
+						},
+					}},
+				},
+			},
+		},
+		{
+			name: "tests inheriting the blanket requests",
+			config: api.ReleaseBuildConfiguration{
+				Resources: api.ResourceConfiguration{
+					"*":     requests,
+					"other": {Requests: api.ResourceList{"cpu": "100m"}},
+				},
+				Tests: []api.TestStepConfiguration{
+					{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+					{As: "other", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						Gather: []api.LiteralTestStep{{As: "must-gather", Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}}}},
+						Post:   []api.LiteralTestStep{{As: "teardown", Resources: api.ResourceRequirements{Limits: api.ResourceList{"memory": "1Gi"}}}},
+					}},
+					{As: "unresolved", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Pre:    []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "inline"}}},
+						Gather: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "gather"}}},
+					}},
+				},
+			},
+			expected: []error{
+				errors.New("tests[0]: resources.unit must request cpu and memory for the test instead of inheriting the requests of resources.*"),
+				errors.New("tests[1]: resources.other must request memory for the test instead of inheriting the requests of resources.*"),
+				errors.New("tests[2].literal_steps.gather[0]: step must-gather must request memory"),
+				errors.New("tests[2].literal_steps.post[0]: step teardown must request cpu and memory"),
+				errors.New("tests[3].steps.pre[0]: step inline must request cpu and memory"),
+				errors.New("tests[3].steps.gather[0]: step gather must request cpu and memory"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateStrict(&tc.config), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestStrictModeIsOptIn(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BuildRootImage: &api.BuildRootImageConfiguration{
+				ImageStreamTagReference: &api.ImageStreamTagReference{Namespace: "ci", Name: "builder", Tag: "latest"},
+			},
+		},
+		Tests: []api.TestStepConfiguration{
+			{As: "unit", Commands: "make test", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+		},
+		Resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "1", "memory": "1Gi"}}},
+	}
+	validator := NewValidator(nil, nil, nil)
+	if _, err := validator.IsValidConfiguration(&config, "org", "repo"); err != nil {
+		t.Fatalf("expected the configuration to be valid without strict mode: %v", err)
+	}
+	validator = validator.WithStrictMode()
+	if _, err := validator.IsValidConfiguration(&config, "org", "repo"); err == nil {
+		t.Fatal("expected the configuration to be rejected in strict mode")
+	}
+	config.DisabledValidationRules = []string{RuleResourceRequests}
+	if _, err := validator.IsValidConfiguration(&config, "org", "repo"); err != nil {
+		t.Fatalf("expected the disabled rule not to be enforced: %v", err)
+	}
+}
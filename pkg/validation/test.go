@@ -1,9 +1,11 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -35,6 +38,7 @@ const (
 	testStageUnknown testStage = iota
 	testStagePre
 	testStageTest
+	testStageGather
 	testStagePost
 
 	// These are a bit arbitrary but they reflect what was working when I set
@@ -178,6 +182,9 @@ func (v *Validator) validateTestStepConfiguration(
 		if test.MinimumInterval != nil && test.Interval != nil {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: `interval` and `minimum_interval` cannot both be set", fieldRootN))
 		}
+		if len(test.AllowedWindows) != 0 && (test.Interval != nil || test.MinimumInterval != nil) {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: `interval` and `minimum_interval` cannot be set with `allowed_windows`, schedule the test with `cron` inside its windows instead", fieldRootN))
+		}
 		if test.Cron != nil && test.ReleaseController {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: `cron` cannot be set for release controller jobs", fieldRootN))
 		}
@@ -193,6 +200,14 @@ func (v *Validator) validateTestStepConfiguration(
 		if test.RunIfChanged != "" && test.SkipIfOnlyChanged != "" {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: `run_if_changed` and `skip_if_only_changed` are mutually exclusive", fieldRootN))
 		}
+		if test.AlwaysRun != nil {
+			if *test.AlwaysRun && (test.RunIfChanged != "" || test.SkipIfOnlyChanged != "" || test.PipelineRunIfChanged != "") {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: `always_run: true` is mutually exclusive with `run_if_changed`/`skip_if_only_changed`/`pipeline_run_if_changed`", fieldRootN))
+			}
+			if test.Postsubmit || (test.IsPeriodic() && !test.Presubmit) {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: `always_run` can only be set for presubmits", fieldRootN))
+			}
+		}
 
 		if test.Interval != nil {
 			if _, err := time.ParseDuration(*test.Interval); err != nil {
@@ -214,6 +229,20 @@ func (v *Validator) validateTestStepConfiguration(
 		if test.Timeout != nil && test.Timeout.Duration > maxJobTimeout {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: job timeout is limited to %s", fieldRootN, maxJobTimeout))
 		}
+		if test.ExpectedDuration != nil {
+			if test.ExpectedDuration.Duration <= 0 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.expected_duration: must be positive, got %s", fieldRootN, test.ExpectedDuration.Duration))
+			} else if test.Timeout != nil && test.ExpectedDuration.Duration > test.Timeout.Duration {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.expected_duration: must not be longer than the timeout of the test (%s)", fieldRootN, test.Timeout.Duration))
+			}
+		}
+		if v.defaultJobTimeout != nil {
+			var defaultJobTimeout time.Duration
+			if metadata != nil {
+				defaultJobTimeout = v.defaultJobTimeout(*metadata)
+			}
+			validationErrors = append(validationErrors, validateTimeoutChain(fieldRootN, &test, defaultJobTimeout)...)
+		}
 
 		// Validate Secret/Secrets
 		if test.Secret != nil && test.Secrets != nil {
@@ -248,8 +277,20 @@ func (v *Validator) validateTestStepConfiguration(
 			}
 		}
 
+		validationErrors = append(validationErrors, validateScheduling(fieldRootN, test.NodeSelector, test.Tolerations)...)
+		if test.Spread != "" && test.Spread != api.SpreadNode && test.Spread != api.SpreadZone {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.spread: expected one of %s or %s", fieldRootN, api.SpreadNode, api.SpreadZone))
+		}
+		validationErrors = append(validationErrors, validateSoak(fieldRootN, test)...)
+		validationErrors = append(validationErrors, validateReuseResults(fieldRootN, test)...)
+		validationErrors = append(validationErrors, validateIsolateStableStreams(fieldRootN, test, release, releases)...)
+		validationErrors = append(validationErrors, validateAllowedWindows(fieldRootN, test.AllowedWindows)...)
+		validationErrors = append(validationErrors, validateTestMetadata(fieldRootN+".labels", test.Labels, true)...)
+		validationErrors = append(validationErrors, validateTestMetadata(fieldRootN+".annotations", test.Annotations, false)...)
+
 		validationErrors = append(validationErrors, v.validateTestConfigurationType(fieldRootN, test, metadata, release, releases, inputImagesSeen, resolved)...)
 	}
+	validationErrors = append(validationErrors, validateClusterGroups(fieldRoot, input)...)
 	for tag, field := range inputImagesSeen {
 		if err := configCtx.AddField(string(field)).addPipelineImage(tag, ""); err != nil {
 			validationErrors = append(validationErrors, err)
@@ -258,6 +299,48 @@ func (v *Validator) validateTestStepConfiguration(
 	return validationErrors
 }
 
+// validateClusterGroups ensures that tests sharing a cluster can be run as one
+func validateClusterGroups(fieldRoot string, tests []api.TestStepConfiguration) []error {
+	var errs []error
+	names := sets.New[string]()
+	for _, test := range tests {
+		names.Insert(test.As)
+	}
+	profiles := map[string]api.ClusterProfile{}
+	for num, test := range tests {
+		group := test.ClusterGroup
+		if group == "" {
+			continue
+		}
+		fieldRootN := fmt.Sprintf("%s[%d].cluster_group", fieldRoot, num)
+		if len(validation.IsDNS1123Subdomain(group)) != 0 {
+			errs = append(errs, fmt.Errorf("%s: '%s' is not a valid Kubernetes object name", fieldRootN, group))
+		}
+		if names.Has(group) {
+			errs = append(errs, fmt.Errorf("%s: %q is already the name of a test", fieldRootN, group))
+		}
+		var profile api.ClusterProfile
+		switch {
+		case test.MultiStageTestConfiguration != nil:
+			profile = test.MultiStageTestConfiguration.ClusterProfile
+		case test.MultiStageTestConfigurationLiteral != nil:
+			profile = test.MultiStageTestConfigurationLiteral.ClusterProfile
+		default:
+			errs = append(errs, fmt.Errorf("%s: only multi-stage tests can share a cluster", fieldRootN))
+			continue
+		}
+		if test.ClusterClaim != nil {
+			errs = append(errs, fmt.Errorf("%s: tests using a cluster claim cannot share a cluster", fieldRootN))
+		}
+		if existing, ok := profiles[group]; !ok {
+			profiles[group] = profile
+		} else if existing != profile {
+			errs = append(errs, fmt.Errorf("%s: all tests in the group must use the same cluster profile, found %q and %q", fieldRootN, existing, profile))
+		}
+	}
+	return errs
+}
+
 // validateTestStepDependencies ensures that users have referenced valid dependencies
 func validateTestStepDependencies(config *api.ReleaseBuildConfiguration) []error {
 	hasOverride := func(test *api.TestStepConfiguration, dep string) bool {
@@ -331,6 +414,10 @@ func validateTestStepDependencies(config *api.ReleaseBuildConfiguration) []error
 						if config.RpmBuildCommands == "" && !hasOverride(test, dependency.Env) {
 							errs = append(errs, validationError("this dependency requires built RPMs, which are not configured"))
 						}
+					case string(api.PipelineImageStreamTagReferenceTools):
+						if config.Tools == nil && !hasOverride(test, dependency.Env) {
+							errs = append(errs, validationError("this dependency requires a tools image, which is not configured"))
+						}
 					case string(api.PipelineImageStreamTagReferenceIndexImage):
 						if config.Operator == nil && !hasOverride(test, dependency.Env) {
 							errs = append(errs, validationError("this dependency requires an operator bundle configuration, which is not configured"))
@@ -399,6 +486,7 @@ func validateTestStepDependencies(config *api.ReleaseBuildConfiguration) []error
 			}{
 				{field: "pre", list: test.MultiStageTestConfiguration.Pre},
 				{field: "test", list: test.MultiStageTestConfiguration.Test},
+				{field: "gather", list: test.MultiStageTestConfiguration.Gather},
 				{field: "post", list: test.MultiStageTestConfiguration.Post},
 			} {
 				errs = append(errs, processSteps(item.list, testIdx, "steps", item.field, claimRelease)...)
@@ -411,6 +499,7 @@ func validateTestStepDependencies(config *api.ReleaseBuildConfiguration) []error
 			}{
 				{field: "pre", list: test.MultiStageTestConfigurationLiteral.Pre},
 				{field: "test", list: test.MultiStageTestConfigurationLiteral.Test},
+				{field: "gather", list: test.MultiStageTestConfigurationLiteral.Gather},
 				{field: "post", list: test.MultiStageTestConfigurationLiteral.Post},
 			} {
 				errs = append(errs, processLiteralSteps(item.list, testIdx, "literal_steps", item.field, claimRelease)...)
@@ -438,6 +527,29 @@ func (v *Validator) validateClusterProfile(fieldRoot string, p api.ClusterProfil
 	return []error{fmt.Errorf("%s: invalid cluster profile %q", fieldRoot, p)}
 }
 
+// validateNetworkStack ensures the network stack of a test can be installed
+// with its cluster profile. The profile of tests using workflows may only be
+// known once they are resolved, only the value is checked until then.
+func (v *Validator) validateNetworkStack(fieldRoot string, stack api.NetworkStack, profile api.ClusterProfile) []error {
+	if stack == "" {
+		return nil
+	}
+	if err := stack.Validate(); err != nil {
+		return []error{fmt.Errorf("%s.network_stack: %w", fieldRoot, err)}
+	}
+	if profile == "" {
+		return nil
+	}
+	var profileStacks []api.NetworkStack
+	if details, ok := v.validClusterProfiles[profile]; ok {
+		profileStacks = details.NetworkStacks
+	}
+	if !stack.SupportedBy(profile.ClusterType(), profileStacks) {
+		return []error{fmt.Errorf("%s.network_stack: %s is not supported by cluster profile %s", fieldRoot, stack, profile)}
+	}
+	return nil
+}
+
 // verifyClusterProfileOwnership checks if metadata's org and repo match those in the profile,
 // verifying if it's one of the owners of the profile.
 func verifyClusterProfileOwnership(profile api.ClusterProfileDetails, m *api.Metadata) error {
@@ -528,6 +640,11 @@ func (v *Validator) validateTestConfigurationType(
 		if test.MultiStageTestConfigurationLiteral == nil && test.MultiStageTestConfiguration == nil {
 			validationErrors = append(validationErrors, fmt.Errorf("%s.cluster_claim cannot be set on a test which is not a multi-stage test", fieldRoot))
 		}
+		if claim.Version != "" && claim.Cloud != "" && claim.Owner != "" {
+			if err := v.validateClusterPool(fieldRoot, claim); err != nil {
+				validationErrors = append(validationErrors, err)
+			}
+		}
 	}
 	typeCount := 0
 	if cluster := test.Cluster; cluster != "" && !api.ValidClusterName(string(cluster)) {
@@ -543,6 +660,7 @@ func (v *Validator) validateTestConfigurationType(
 		if testConfig.From == "" {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: 'from' is required", fieldRoot))
 		}
+		validationErrors = append(validationErrors, validateTestServices(fieldRoot, testConfig.Services)...)
 	}
 	var needsReleaseRpms bool
 	if testConfig := test.OpenshiftAnsibleClusterTestConfiguration; testConfig != nil {
@@ -591,11 +709,16 @@ func (v *Validator) validateTestConfigurationType(
 		}
 		context := newContext(fieldPath(fieldRoot), testConfig.Environment, releases, inputImagesSeen)
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
+		validationErrors = append(validationErrors, validateSkewReleases(fieldRoot, testConfig.ClientRelease, testConfig.ServerRelease, release, releases, claimRelease)...)
+		validationErrors = append(validationErrors, v.validateNetworkStack(fieldRoot, testConfig.NetworkStack, testConfig.ClusterProfile)...)
 		if testConfig.NodeArchitecture != nil {
 			validationErrors = append(validationErrors, validateNodeArchitecture(fieldRoot, *testConfig.NodeArchitecture))
 		}
+		validationErrors = append(validationErrors, validateGatherTimeout(fieldRoot, testConfig.GatherTimeout)...)
+		validationErrors = append(validationErrors, validatePhaseBudgets(fieldRoot, testConfig.Budgets, testConfig.GatherTimeout, test.Timeout)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("pre"), testStagePre, testConfig.Pre, claimRelease)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("test"), testStageTest, testConfig.Test, claimRelease)...)
+		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("gather"), testStageGather, testConfig.Gather, claimRelease)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("post"), testStagePost, testConfig.Post, claimRelease)...)
 	}
 	if testConfig := test.MultiStageTestConfigurationLiteral; testConfig != nil {
@@ -606,15 +729,23 @@ func (v *Validator) validateTestConfigurationType(
 			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile, metadata)...)
 		}
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
+		validationErrors = append(validationErrors, validateSkewReleases(fieldRoot, testConfig.ClientRelease, testConfig.ServerRelease, release, releases, claimRelease)...)
+		validationErrors = append(validationErrors, v.validateNetworkStack(fieldRoot, testConfig.NetworkStack, testConfig.ClusterProfile)...)
+		validationErrors = append(validationErrors, validateGatherTimeout(fieldRoot, testConfig.GatherTimeout)...)
+		validationErrors = append(validationErrors, validatePhaseBudgets(fieldRoot, testConfig.Budgets, testConfig.GatherTimeout, test.Timeout)...)
 		for i, s := range testConfig.Pre {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("pre").addIndex(i), testStagePre, s, claimRelease)...)
 		}
 		for i, s := range testConfig.Test {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("test").addIndex(i), testStageTest, s, claimRelease)...)
 		}
+		for i, s := range testConfig.Gather {
+			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("gather").addIndex(i), testStageGather, s, claimRelease)...)
+		}
 		for i, s := range testConfig.Post {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("post").addIndex(i), testStagePost, s, claimRelease)...)
 		}
+		validationErrors = append(validationErrors, validateNestedPodman(fieldRoot, test.Capabilities, testConfig)...)
 	}
 	if typeCount == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s has no type, you may want to specify 'container' for a container based test", fieldRoot))
@@ -632,6 +763,62 @@ func (v *Validator) validateTestConfigurationType(
 	return validationErrors
 }
 
+// validateGatherTimeout ensures the `gather` phase is given time to run
+func validateGatherTimeout(fieldRoot string, timeout *prowv1.Duration) []error {
+	if timeout != nil && timeout.Duration <= 0 {
+		return []error{fmt.Errorf("%s.gather_timeout: must be positive, got %s", fieldRoot, timeout.Duration)}
+	}
+	return nil
+}
+
+// validatePhaseBudgets ensures the budgets of all phases fit in the timeout
+// of the test, so that no phase can be starved by the ones before it
+func validatePhaseBudgets(fieldRoot string, budgets *api.PhaseBudgets, gatherTimeout, timeout *prowv1.Duration) []error {
+	if budgets == nil {
+		return nil
+	}
+	var errs []error
+	var total time.Duration
+	for _, item := range []struct {
+		field  string
+		budget *prowv1.Duration
+	}{{"pre", budgets.Pre}, {"test", budgets.Test}, {"post", budgets.Post}} {
+		if item.budget == nil {
+			continue
+		}
+		if item.budget.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s.budgets.%s: must be positive, got %s", fieldRoot, item.field, item.budget.Duration))
+		}
+		total += item.budget.Duration
+	}
+	if gatherTimeout != nil {
+		total += gatherTimeout.Duration
+	}
+	if timeout != nil && total > timeout.Duration {
+		errs = append(errs, fmt.Errorf("%s.budgets: the phases are given %s in total, more than the timeout of the test (%s)", fieldRoot, total, timeout.Duration))
+	}
+	return errs
+}
+
+// validateSkewReleases ensures that the releases a skew test runs its client
+// and server from are configured
+func validateSkewReleases(fieldRoot, clientRelease, serverRelease string, release *api.ReleaseTagConfiguration, releases sets.Set[string], claimRelease *api.ClaimRelease) []error {
+	configured := releases.Clone()
+	if release != nil {
+		configured.Insert(api.InitialReleaseName, api.LatestReleaseName)
+	}
+	if claimRelease != nil {
+		configured.Insert(claimRelease.ReleaseName)
+	}
+	var errs []error
+	for _, item := range []struct{ field, name string }{{"client_release", clientRelease}, {"server_release", serverRelease}} {
+		if item.name != "" && !configured.Has(item.name) {
+			errs = append(errs, fmt.Errorf("%s.%s: release %q is not configured in 'tag_specification' or 'releases'", fieldRoot, item.field, item.name))
+		}
+	}
+	return errs
+}
+
 func (v *Validator) validateTestSteps(context *context, stage testStage, steps []api.TestStep, claimRelease *api.ClaimRelease) (ret []error) {
 	for i, s := range steps {
 		contextI := context.addIndex(i)
@@ -685,6 +872,19 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 			context.namesSeen.Insert(step.As)
 		}
 	}
+	if step.As == api.LeakDetectionStepName {
+		ret = append(ret, context.errorf("name %q is reserved for the leak detection step", step.As))
+	}
+	if typed := step.TypedSteps(); len(typed) != 0 {
+		ret = append(ret, validateTypedStep(context, step)...)
+		if len(typed) > 1 {
+			ret = append(ret, context.errorf("only one of `%s` can be set", strings.Join(typed, "`, `")))
+		}
+		if step.From != "" || step.FromImage != nil || step.Commands != "" {
+			ret = append(ret, context.errorf("`from`, `from_image` and `commands` cannot be set on an `%s` step", typed[0]))
+		}
+		step = api.ExpandTypedStep(step)
+	}
 	var fromImageTag *api.PipelineImageStreamTagReference
 	if t, ok := step.FromImageTag(); ok {
 		fromImageTag = &t
@@ -714,8 +914,19 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 			ret = append(ret, err)
 		}
 	}
+	ret = append(ret, validateScheduling(string(context.field), step.NodeSelector, step.Tolerations)...)
+	if step.PullSecret != "" {
+		if err := v.validatePullSecret(step.PullSecret); err != nil {
+			ret = append(ret, context.addField("pull_secret").errorf("%v", err))
+		}
+	}
+	if step.LogLimit != nil {
+		if err := step.LogLimit.Validate(); err != nil {
+			ret = append(ret, context.addField("log_limit").errorf("%v", err))
+		}
+	}
 	switch stage {
-	case testStagePre, testStageTest:
+	case testStagePre, testStageTest, testStageGather:
 		if step.OptionalOnSuccess != nil {
 			ret = append(ret, context.errorf("`optional_on_success` is only allowed for Post steps"))
 		}
@@ -723,6 +934,28 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 	return ret
 }
 
+func validateTypedStep(context *context, step api.LiteralTestStep) (ret []error) {
+	if step.Upgrade != nil {
+		upgradeContext := context.addField("upgrade")
+		for _, err := range step.Upgrade.Validate() {
+			ret = append(ret, upgradeContext.errorf("%v", err))
+		}
+	}
+	if step.HealthCheck != nil {
+		healthCheckContext := context.addField("health_check")
+		for _, err := range step.HealthCheck.Validate() {
+			ret = append(ret, healthCheckContext.errorf("%v", err))
+		}
+	}
+	if step.InstallConfig != nil {
+		installConfigContext := context.addField("install_config")
+		for _, err := range step.InstallConfig.Validate() {
+			ret = append(ret, installConfigContext.errorf("%v", err))
+		}
+	}
+	return ret
+}
+
 func validateFromAndFromImage(
 	context *context,
 	from string,
@@ -779,7 +1012,9 @@ func (v *Validator) validateCommands(test api.LiteralTestStep) []error {
 	if v.commandHasTrap(test.Commands) && test.GracePeriod == nil {
 		validationErrors = append(validationErrors, fmt.Errorf("test `%s` has `commands` containing `trap` command, but test step is missing grace_period", test.As))
 	}
-
+	if err := commandsSyntaxError(test.Commands); err != nil {
+		validationErrors = append(validationErrors, fmt.Errorf("test `%s` has invalid `commands`: %w", test.As, err))
+	}
 	return validationErrors
 }
 
@@ -789,7 +1024,9 @@ func validateCredentials(fieldRoot string, credentials []api.CredentialReference
 		if credential.Name == "" {
 			errs = append(errs, fmt.Errorf("%s.credentials[%d].name cannot be empty", fieldRoot, i))
 		}
-		if credential.Namespace == "" {
+		if credential.Cloud != nil {
+			errs = append(errs, validateCloudCredential(fmt.Sprintf("%s.credentials[%d]", fieldRoot, i), credential)...)
+		} else if credential.Namespace == "" {
 			errs = append(errs, fmt.Errorf("%s.credentials[%d].namespace cannot be empty", fieldRoot, i))
 		}
 		if credential.MountPath == "" {
@@ -828,6 +1065,39 @@ func validateCredentials(fieldRoot string, credentials []api.CredentialReference
 	return errs
 }
 
+// cloudSecretNameRegexp matches the names of secrets valid in all supported
+// cloud secret managers.
+var cloudSecretNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func validateCloudCredential(fieldRoot string, credential api.CredentialReference) []error {
+	var errs []error
+	if credential.Namespace != "" {
+		errs = append(errs, fmt.Errorf("%s.namespace cannot be set for credentials from a cloud secret manager", fieldRoot))
+	}
+	if credential.Name != "" && !cloudSecretNameRegexp.MatchString(credential.Name) {
+		errs = append(errs, fmt.Errorf("%s.name: %q must only contain alphanumeric characters, '-' and '_'", fieldRoot, credential.Name))
+	}
+	switch source := credential.Cloud; source.Provider {
+	case api.CloudSecretManagerGCP:
+		if source.Project == "" {
+			errs = append(errs, fmt.Errorf("%s.cloud.project cannot be empty for %s", fieldRoot, source.Provider))
+		}
+		if source.Region != "" {
+			errs = append(errs, fmt.Errorf("%s.cloud.region cannot be set for %s", fieldRoot, source.Provider))
+		}
+	case api.CloudSecretManagerAWS:
+		if source.Region == "" {
+			errs = append(errs, fmt.Errorf("%s.cloud.region cannot be empty for %s", fieldRoot, source.Provider))
+		}
+		if source.Project != "" {
+			errs = append(errs, fmt.Errorf("%s.cloud.project cannot be set for %s", fieldRoot, source.Provider))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s.cloud.provider: expected one of %s or %s, got %q", fieldRoot, api.CloudSecretManagerGCP, api.CloudSecretManagerAWS, source.Provider))
+	}
+	return errs
+}
+
 func ValidateSecretInStep(ns, name string) error {
 	// only secrets in test-credentials namespace can be used in a step
 	if ns != "test-credentials" {
@@ -887,6 +1157,119 @@ func validateDNSConfig(fieldRoot string, dnsConfig []api.StepDNSConfig) (ret []e
 	return errs
 }
 
+// reservedMetadataDomains are the label and annotation key domains used by the
+// CI tooling itself, which tests are not allowed to set
+var reservedMetadataDomains = []string{"ci.openshift.io", "ci-operator.openshift.io", "dptp.openshift.io", "pj-rehearse.openshift.io", "prow.k8s.io", "kubernetes.io", "k8s.io", "capability"}
+
+// reservedMetadataKeys are the keys without a reserved prefix which the CI
+// tooling sets on jobs and pods, which tests are not allowed to set either
+var reservedMetadataKeys = sets.New[string](
+	api.CreatedByCILabel,
+	api.CreatesLabel,
+	"OPENSHIFT_CI",
+	api.JobReleaseKey,
+	api.CreatedByProwLabel,
+	api.CreatedByTideLabel,
+)
+
+// toolingAnnotations are the annotations with a reserved prefix that the CI
+// tooling reads from or sets on tests in their configuration, with the
+// validation of their values
+var toolingAnnotations = map[string]func(value string) error{
+	api.DeprioritizedAtAnnotation: func(value string) error {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("is not a time in RFC 3339: %w", err)
+		}
+		return nil
+	},
+	api.ResourceCeilingExemptionAnnotation: func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return errors.New("must explain why more resources are needed")
+		}
+		return nil
+	},
+}
+
+func validateTestMetadata(fieldRoot string, metadata map[string]string, labels bool) []error {
+	var errs []error
+	for _, key := range sets.List(sets.KeySet(metadata)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("%s: key %q is invalid: %s", fieldRoot, key, strings.Join(msgs, "; ")))
+			continue
+		}
+		if validate, ok := toolingAnnotations[key]; ok && !labels {
+			if err := validate(metadata[key]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: value %q for key %q %w", fieldRoot, metadata[key], key, err))
+			}
+			continue
+		}
+		if reservedMetadataKeys.Has(key) {
+			errs = append(errs, fmt.Errorf("%s: key %q is reserved", fieldRoot, key))
+		} else if domain, _, found := strings.Cut(key, "/"); found {
+			for _, reserved := range reservedMetadataDomains {
+				if domain == reserved || strings.HasSuffix(domain, "."+reserved) {
+					errs = append(errs, fmt.Errorf("%s: key %q uses the reserved prefix %s/", fieldRoot, key, reserved))
+					break
+				}
+			}
+		}
+		if !labels {
+			continue
+		}
+		if msgs := validation.IsValidLabelValue(metadata[key]); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("%s: value %q for key %q is invalid: %s", fieldRoot, metadata[key], key, strings.Join(msgs, "; ")))
+		}
+	}
+	return errs
+}
+
+func validateScheduling(fieldRoot string, nodeSelector map[string]string, tolerations []api.Toleration) []error {
+	var errs []error
+	allowed := sets.List(sets.KeySet(api.SchedulingAllowlist))
+	for _, key := range sets.List(sets.KeySet(nodeSelector)) {
+		values, ok := api.SchedulingAllowlist[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s.node_selector: label %q is not allowed, expected one of %v", fieldRoot, key, allowed))
+		} else if !values.Has(nodeSelector[key]) {
+			errs = append(errs, fmt.Errorf("%s.node_selector: value %q for label %q is not allowed, expected one of %v", fieldRoot, nodeSelector[key], key, sets.List(values)))
+		}
+	}
+	effects := sets.New[string]("", "NoSchedule", "PreferNoSchedule", "NoExecute")
+	for i, toleration := range tolerations {
+		fieldRootN := fmt.Sprintf("%s.tolerations[%d]", fieldRoot, i)
+		values, ok := api.SchedulingAllowlist[toleration.Key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s.key: taint %q is not allowed, expected one of %v", fieldRootN, toleration.Key, allowed))
+		} else if toleration.Value != "" && !values.Has(toleration.Value) {
+			errs = append(errs, fmt.Errorf("%s.value: value %q for taint %q is not allowed, expected one of %v", fieldRootN, toleration.Value, toleration.Key, sets.List(values)))
+		}
+		if !effects.Has(toleration.Effect) {
+			errs = append(errs, fmt.Errorf("%s.effect: %q is not a valid taint effect", fieldRootN, toleration.Effect))
+		}
+	}
+	return errs
+}
+
+// validateNestedPodman ensures tests running steps with a nested container
+// runtime are only scheduled on the clusters which allow it.
+func validateNestedPodman(fieldRoot string, capabilities []string, test *api.MultiStageTestConfigurationLiteral) []error {
+	if slices.Contains(capabilities, api.NestedPodmanCapability) {
+		return nil
+	}
+	var errs []error
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{{"pre", test.Pre}, {"test", test.Test}, {"gather", test.Gather}, {"post", test.Post}} {
+		for i, step := range phase.steps {
+			if step.NestedPodman != nil && *step.NestedPodman {
+				errs = append(errs, fmt.Errorf("%s.steps.%s[%d].nested_podman: test must declare the %q capability", fieldRoot, phase.name, i, api.NestedPodmanCapability))
+			}
+		}
+	}
+	return errs
+}
+
 func validateNodeArchitecture(fieldRoot string, nodeArchitecture api.NodeArchitecture) error {
 	if nodeArchitecture != api.NodeArchitectureAMD64 && nodeArchitecture != api.NodeArchitectureARM64 {
 		return fmt.Errorf("%s.nodeArchitecture expected one of %v or %v", fieldRoot, api.NodeArchitectureAMD64, api.NodeArchitectureARM64)
@@ -911,3 +1294,156 @@ func validateLeases(context *context, leases []api.StepLease) (ret []error) {
 	}
 	return
 }
+
+// maxSoakDays is the longest duration a soak test can run for.
+const maxSoakDays = 14
+
+func validateSoak(fieldRoot string, test api.TestStepConfiguration) []error {
+	soak := test.Soak
+	if soak == nil {
+		return nil
+	}
+	fieldRoot = fieldRoot + ".soak"
+	var ret []error
+	if test.MultiStageTestConfiguration == nil && test.MultiStageTestConfigurationLiteral == nil {
+		ret = append(ret, fmt.Errorf("%s: only multi-stage tests can be soak tests", fieldRoot))
+	}
+	if !test.IsPeriodic() {
+		ret = append(ret, fmt.Errorf("%s: soak tests must be periodic", fieldRoot))
+	}
+	if test.Timeout != nil {
+		ret = append(ret, fmt.Errorf("%s: soak tests cannot set timeout, use max_duration_days instead", fieldRoot))
+	}
+	if soak.MaxDurationDays < 1 || soak.MaxDurationDays > maxSoakDays {
+		ret = append(ret, fmt.Errorf("%s.max_duration_days: must be between 1 and %d", fieldRoot, maxSoakDays))
+	}
+	if soak.CheckpointInterval != nil && soak.CheckpointInterval.Duration < 5*time.Minute {
+		ret = append(ret, fmt.Errorf("%s.checkpoint_interval: must be at least 5m", fieldRoot))
+	}
+	if soak.MaxCheckpoints < 0 {
+		ret = append(ret, fmt.Errorf("%s.max_checkpoints: must not be negative", fieldRoot))
+	}
+	return ret
+}
+
+// validateReuseResults ensures that only tests running on pull requests reuse
+// earlier results and that the paths they depend on are valid.
+func validateReuseResults(fieldRoot string, test api.TestStepConfiguration) []error {
+	reuse := test.ReuseResults
+	if reuse == nil {
+		return nil
+	}
+	fieldRoot = fieldRoot + ".reuse_results"
+	var ret []error
+	if test.Postsubmit || (test.IsPeriodic() && !test.Presubmit) {
+		ret = append(ret, fmt.Errorf("%s: only presubmit tests can reuse results", fieldRoot))
+	}
+	for i, path := range reuse.SourcePaths {
+		if path == "" {
+			ret = append(ret, fmt.Errorf("%s.source_paths[%d]: must not be empty", fieldRoot, i))
+		} else if _, err := regexp.Compile(path); err != nil {
+			ret = append(ret, fmt.Errorf("%s.source_paths[%d]: %q is not a valid regular expression: %w", fieldRoot, i, path, err))
+		}
+	}
+	return ret
+}
+
+// validateIsolateStableStreams ensures that the isolated copies of the stable
+// streams of a test cannot be confused with the streams of releases.
+func validateIsolateStableStreams(fieldRoot string, test api.TestStepConfiguration, release *api.ReleaseTagConfiguration, releases sets.Set[string]) []error {
+	if !test.IsolateStableStreams {
+		return nil
+	}
+	fieldRoot = fieldRoot + ".isolate_stable_streams"
+	if test.MultiStageTestConfiguration == nil && test.MultiStageTestConfigurationLiteral == nil {
+		return []error{fmt.Errorf("%s: only multi-stage tests can isolate stable streams", fieldRoot)}
+	}
+	names := releases.Clone()
+	if release != nil {
+		names.Insert(api.LatestReleaseName, api.InitialReleaseName)
+	}
+	streams := sets.New[string](api.StableImageStream)
+	for name := range names {
+		streams.Insert(api.ReleaseStreamFor(name))
+	}
+	var ret []error
+	for _, stream := range sets.List(streams) {
+		if isolated := api.IsolatedStableStreamFor(stream, test.As); streams.Has(isolated) {
+			ret = append(ret, fmt.Errorf("%s: the isolated copy of the %s stream would be the stream of release %q", fieldRoot, stream, api.ReleaseNameFrom(isolated)))
+		}
+	}
+	return ret
+}
+
+// reservedServiceNames are the names of the containers in the pod of a
+// container test which services cannot use.
+var reservedServiceNames = sets.New[string]("test", "artifacts", "clonerefs", "initupload", "place-entrypoint", "sidecar")
+
+func validateTestServices(fieldRoot string, services []api.TestService) []error {
+	var ret []error
+	seen := sets.New[string]()
+	validPort := func(field string, port int32) {
+		if port < 1 || port > 65535 {
+			ret = append(ret, fmt.Errorf("%s: %d is not a valid port", field, port))
+		}
+	}
+	for i, service := range services {
+		fieldRootN := fmt.Sprintf("%s.services[%d]", fieldRoot, i)
+		switch {
+		case service.As == "":
+			ret = append(ret, fmt.Errorf("%s.as: must be set", fieldRootN))
+		case reservedServiceNames.Has(service.As):
+			ret = append(ret, fmt.Errorf("%s.as: %q is reserved", fieldRootN, service.As))
+		case seen.Has(service.As):
+			ret = append(ret, fmt.Errorf("%s.as: duplicated name %q", fieldRootN, service.As))
+		default:
+			if errs := validation.IsDNS1123Label(service.As); len(errs) > 0 {
+				ret = append(ret, fmt.Errorf("%s.as: %q is not a valid name: %s", fieldRootN, service.As, strings.Join(errs, ", ")))
+			}
+		}
+		seen.Insert(service.As)
+		if service.From == "" {
+			ret = append(ret, fmt.Errorf("%s.from: must be set", fieldRootN))
+		}
+		for j, port := range service.Ports {
+			validPort(fmt.Sprintf("%s.ports[%d]", fieldRootN, j), port)
+		}
+		probe := service.ReadinessProbe
+		if probe == nil {
+			continue
+		}
+		var checks int
+		if probe.TCPPort != 0 {
+			checks++
+			validPort(fieldRootN+".readiness_probe.tcp_port", probe.TCPPort)
+		}
+		if probe.HTTPGet != nil {
+			checks++
+			validPort(fieldRootN+".readiness_probe.http_get.port", probe.HTTPGet.Port)
+		}
+		if len(probe.Command) != 0 {
+			checks++
+		}
+		if checks != 1 {
+			ret = append(ret, fmt.Errorf("%s.readiness_probe: exactly one of tcp_port, http_get or command must be set", fieldRootN))
+		}
+		if probe.Timeout != nil && probe.Timeout.Duration <= 0 {
+			ret = append(ret, fmt.Errorf("%s.readiness_probe.timeout: must be positive", fieldRootN))
+		}
+	}
+	return ret
+}
+
+func validateAllowedWindows(fieldRoot string, windows []api.AllowedWindow) []error {
+	var ret []error
+	for i, window := range windows {
+		fieldRootN := fmt.Sprintf("%s.allowed_windows[%d]", fieldRoot, i)
+		if _, err := window.Schedule(); err != nil {
+			ret = append(ret, fmt.Errorf("%s.start: cannot parse cron: %w", fieldRootN, err))
+		}
+		if window.Duration.Duration <= 0 {
+			ret = append(ret, fmt.Errorf("%s.duration: must be positive", fieldRootN))
+		}
+	}
+	return ret
+}
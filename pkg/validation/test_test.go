@@ -22,6 +22,7 @@ func TestValidateTests(t *testing.T) {
 	invalidCronString := "r 0 * * 1"
 	intervalString := "6h"
 	invalidIntervalString := "6t"
+	alwaysRun, neverRun := true, false
 	for _, tc := range []struct {
 		id            string
 		release       *api.ReleaseTagConfiguration
@@ -490,6 +491,19 @@ func TestValidateTests(t *testing.T) {
 			},
 			expectedError: errors.New("tests[0]: `interval` and `minimum_interval` cannot both be set"),
 		},
+		{
+			id: "interval and allowed_windows together are invalid",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "unit",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+					Interval:                   &intervalString,
+					AllowedWindows:             []api.AllowedWindow{{Start: "0 22 * * 6", Duration: prowv1.Duration{Duration: 4 * time.Hour}}},
+				},
+			},
+			expectedError: errors.New("tests[0]: `interval` and `minimum_interval` cannot be set with `allowed_windows`, schedule the test with `cron` inside its windows instead"),
+		},
 		{
 			id: "cron and releaseInforming together are invalid",
 			tests: []api.TestStepConfiguration{
@@ -688,6 +702,71 @@ func TestValidateTests(t *testing.T) {
 			},
 			expectedError: errors.New("tests[0]: `optional` and `postsubmit` are mututally exclusive"),
 		},
+		{
+			id: "always_run is mutually exclusive with run_if_changed",
+			tests: []api.TestStepConfiguration{{
+				As:                         "unit",
+				Commands:                   "commands",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+				AlwaysRun:                  &alwaysRun,
+				RunIfChanged:               "^README.md$",
+			}},
+			expectedError: errors.New("tests[0]: `always_run: true` is mutually exclusive with `run_if_changed`/`skip_if_only_changed`/`pipeline_run_if_changed`"),
+		},
+		{
+			id: "always_run false with run_if_changed is valid",
+			tests: []api.TestStepConfiguration{{
+				As:                         "unit",
+				Commands:                   "commands",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+				AlwaysRun:                  &neverRun,
+				RunIfChanged:               "^README.md$",
+			}},
+		},
+		{
+			id: "always_run is only valid for presubmits",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "unit",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+					AlwaysRun:                  &neverRun,
+					Postsubmit:                 true,
+				},
+			},
+			expectedError: errors.New("tests[0]: `always_run` can only be set for presubmits"),
+		},
+		{
+			id: "expected duration within the timeout is valid",
+			tests: []api.TestStepConfiguration{{
+				As:                         "unit",
+				Commands:                   "commands",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+				ExpectedDuration:           &prowv1.Duration{Duration: 45 * time.Minute},
+				Timeout:                    &prowv1.Duration{Duration: time.Hour},
+			}},
+		},
+		{
+			id: "expected duration must be positive",
+			tests: []api.TestStepConfiguration{{
+				As:                         "unit",
+				Commands:                   "commands",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+				ExpectedDuration:           &prowv1.Duration{},
+			}},
+			expectedError: errors.New("tests[0].expected_duration: must be positive, got 0s"),
+		},
+		{
+			id: "expected duration longer than the timeout",
+			tests: []api.TestStepConfiguration{{
+				As:                         "unit",
+				Commands:                   "commands",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+				ExpectedDuration:           &prowv1.Duration{Duration: 2 * time.Hour},
+				Timeout:                    &prowv1.Duration{Duration: time.Hour},
+			}},
+			expectedError: errors.New("tests[0].expected_duration: must not be longer than the timeout of the test (1h0m0s)"),
+		},
 		{
 			id: "test name too long",
 			tests: []api.TestStepConfiguration{
@@ -856,6 +935,16 @@ func TestValidateTestSteps(t *testing.T) {
 		},
 		},
 		errs: []error{errors.New(`test[0]: duplicated name "s0"`)},
+	}, {
+		name: "name of the leak detection step",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "leak-detection",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources},
+		}},
+		errs: []error{errors.New(`test[0]: name "leak-detection" is reserved for the leak detection step`)},
 	}, {
 		name: "no image",
 		steps: []api.TestStep{{
@@ -1092,6 +1181,21 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test `trapper-keeper` has `commands` containing `trap` command, but test step is missing grace_period"),
 		},
+	}, {
+		name: "Workflow with unterminated quote in commands",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:       "unterminated",
+				From:     "installer",
+				Commands: "echo 'unterminated",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1000m"},
+					Limits:   api.ResourceList{"memory": "2Gi"},
+				}},
+		}},
+		errs: []error{
+			errors.New("test `unterminated` has invalid `commands`: unterminated single quote opened on line 1"),
+		},
 	}, {
 		name: "Workflow with best effort with timeout",
 		steps: []api.TestStep{{
@@ -1132,13 +1236,65 @@ func TestValidateTestSteps(t *testing.T) {
 				Resources: resources},
 		}},
 		clusterClaim: api.ClaimRelease{ReleaseName: "myclaim-as", OverrideName: "myclaim"},
+	}, {
+		name: "valid upgrade step",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:      "upgrade",
+				Upgrade: &api.UpgradeStep{Channel: "stable-4.20", Force: api.UpgradeForceIfUnverified},
+			},
+		}},
+	}, {
+		name: "invalid upgrade step",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:       "upgrade",
+				From:     "cli",
+				Commands: "oc adm upgrade",
+				Upgrade:  &api.UpgradeStep{Force: "sometimes"},
+			},
+		}},
+		errs: []error{
+			errors.New(`test[0].upgrade: force must be one of never, always or if_unverified, got "sometimes"`),
+			errors.New("test[0]: `from`, `from_image` and `commands` cannot be set on an `upgrade` step"),
+			errors.New("test[0]: `from` and `from_image` cannot be set together"),
+		},
+	}, {
+		name: "valid health check step",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:          "health",
+				HealthCheck: &api.HealthCheckStep{Checks: []api.HealthCheck{api.HealthCheckNodesReady}},
+			},
+		}},
+	}, {
+		name: "multiple typed steps",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:          "health",
+				Upgrade:     &api.UpgradeStep{},
+				HealthCheck: &api.HealthCheckStep{Checks: []api.HealthCheck{"etcd_happy"}},
+			},
+		}},
+		errs: []error{
+			errors.New(`test[0].health_check: unknown check "etcd_happy", must be one of [api_responsive cluster_operators_available nodes_ready no_pending_csrs]`),
+			errors.New("test[0]: only one of `upgrade`, `health_check` can be set"),
+		},
+	}, {
+		name: "valid install-config step",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:            "install-config",
+				InstallConfig: &api.InstallConfigStep{Patches: []string{"controlPlane:\n  replicas: 3\n"}},
+			},
+		}},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 			context := newContext("test", nil, tc.releases, make(testInputImages))
 			if tc.seen != nil {
 				context.namesSeen = tc.seen
 			}
-			v := NewValidator(nil, nil)
+			v := NewValidator(nil, nil, nil)
 			ret := v.validateTestSteps(context, testStageTest, tc.steps, &tc.clusterClaim)
 			if len(ret) > 0 && len(tc.errs) == 0 {
 				t.Fatalf("Unexpected error %v", ret)
@@ -1179,7 +1335,7 @@ func TestValidatePostSteps(t *testing.T) {
 			if tc.seen != nil {
 				context.namesSeen = tc.seen
 			}
-			v := NewValidator(nil, nil)
+			v := NewValidator(nil, nil, nil)
 			ret := v.validateTestSteps(context, testStagePost, tc.steps, nil)
 			if !errListMessagesEqual(ret, tc.errs) {
 				t.Fatal(diff.ObjectReflectDiff(ret, tc.errs))
@@ -1212,7 +1368,7 @@ func TestValidateParameters(t *testing.T) {
 		err:    []error{errors.New("test: unresolved parameter(s): [TEST1]")},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
-			v := NewValidator(nil, nil)
+			v := NewValidator(nil, nil, nil)
 			err := v.validateLiteralTestStep(newContext("test", tc.env, tc.releases, make(testInputImages)), testStageTest, api.LiteralTestStep{
 				As:       "as",
 				From:     "from",
@@ -1230,6 +1386,84 @@ func TestValidateParameters(t *testing.T) {
 	}
 }
 
+func TestValidateStepPullSecret(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		pullSecret  string
+		pullSecrets sets.Set[string]
+		err         []error
+	}{{
+		name: "no pull secret",
+	}, {
+		name:       "pull secret, available secrets unknown",
+		pullSecret: "registry-pull-credentials",
+	}, {
+		name:        "pull secret exists",
+		pullSecret:  "registry-pull-credentials",
+		pullSecrets: sets.New[string]("registry-pull-credentials"),
+	}, {
+		name:        "pull secret does not exist",
+		pullSecret:  "registry-pull-credentials",
+		pullSecrets: sets.New[string]("other"),
+		err:         []error{errors.New(`test.pull_secret: secret "registry-pull-credentials" does not exist in the test-credentials namespace`)},
+	}, {
+		name:       "invalid pull secret name",
+		pullSecret: "Invalid_Name",
+		err:        []error{errors.New(`test.pull_secret: "Invalid_Name" is not a valid secret name: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`)},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator(nil, nil, tc.pullSecrets)
+			err := v.validateLiteralTestStep(newContext("test", nil, nil, make(testInputImages)), testStageTest, api.LiteralTestStep{
+				As:       "as",
+				From:     "from",
+				Commands: "commands",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1"},
+					Limits:   api.ResourceList{"memory": "1m"},
+				},
+				PullSecret: tc.pullSecret,
+			}, nil)
+			if diff := diff.ObjectReflectDiff(err, tc.err); diff != "<no diffs>" {
+				t.Errorf("incorrect error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateStepLogLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		logLimit *api.LogLimit
+		err      []error
+	}{{
+		name: "no log limit",
+	}, {
+		name:     "valid log limit",
+		logLimit: &api.LogLimit{Head: "100Mi", Tail: "100Mi"},
+	}, {
+		name:     "invalid log limit",
+		logLimit: &api.LogLimit{Tail: "-1"},
+		err:      []error{errors.New("test.log_limit: tail: must not be negative, got -1")},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator(nil, nil, nil)
+			err := v.validateLiteralTestStep(newContext("test", nil, nil, make(testInputImages)), testStageTest, api.LiteralTestStep{
+				As:       "as",
+				From:     "from",
+				Commands: "commands",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1"},
+					Limits:   api.ResourceList{"memory": "1m"},
+				},
+				LogLimit: tc.logLimit,
+			}, nil)
+			if diff := diff.ObjectReflectDiff(err, tc.err); diff != "<no diffs>" {
+				t.Errorf("incorrect error: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateCredentials(t *testing.T) {
 	var testCases = []struct {
 		name   string
@@ -1257,6 +1491,30 @@ func TestValidateCredentials(t *testing.T) {
 				errors.New("root.credentials[0].namespace cannot be empty"),
 			},
 		},
+		{
+			name: "valid cloud creds",
+			input: []api.CredentialReference{
+				{Name: "gcp_secret", MountPath: "/gcp", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerGCP, Project: "project"}},
+				{Name: "aws-secret", MountPath: "/aws", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerAWS, Region: "us-east-1"}},
+			},
+		},
+		{
+			name: "invalid cloud creds",
+			input: []api.CredentialReference{
+				{Namespace: "ns", Name: "gcp/secret", MountPath: "/gcp", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerGCP, Region: "us-east-1"}},
+				{Name: "aws-secret", MountPath: "/aws", Cloud: &api.CloudCredentialSource{Provider: api.CloudSecretManagerAWS, Project: "project"}},
+				{Name: "azure-secret", MountPath: "/azure", Cloud: &api.CloudCredentialSource{Provider: "azure"}},
+			},
+			output: []error{
+				errors.New("root.credentials[0].namespace cannot be set for credentials from a cloud secret manager"),
+				errors.New(`root.credentials[0].name: "gcp/secret" must only contain alphanumeric characters, '-' and '_'`),
+				errors.New("root.credentials[0].cloud.project cannot be empty for gcp"),
+				errors.New("root.credentials[0].cloud.region cannot be set for gcp"),
+				errors.New("root.credentials[1].cloud.region cannot be empty for aws"),
+				errors.New("root.credentials[1].cloud.project cannot be set for aws"),
+				errors.New(`root.credentials[2].cloud.provider: expected one of gcp or aws, got "azure"`),
+			},
+		},
 		{
 			name: "cred mount with no path means error",
 			input: []api.CredentialReference{
@@ -1441,96 +1699,447 @@ func TestValidateNodeArchitecture(t *testing.T) {
 	}
 }
 
-func TestValidateLeases(t *testing.T) {
-	for _, tc := range []struct {
-		name string
-		test api.MultiStageTestConfigurationLiteral
-		err  []error
-	}{{
-		name: "valid leases",
-		test: api.MultiStageTestConfigurationLiteral{
-			Leases: []api.StepLease{
-				{ResourceType: "aws-quota-slice", Env: "AWS_LEASED_RESOURCE"},
-				{ResourceType: "gcp-quota-slice", Env: "GCP_LEASED_RESOURCE"},
-			},
-		},
-	}, {
-		name: "invalid empty name",
-		test: api.MultiStageTestConfigurationLiteral{
-			Leases: []api.StepLease{{Env: "AWS_LEASED_RESOURCE"}},
-		},
-		err: []error{
-			errors.New("tests[0].steps.leases[0]: 'resource_type' cannot be empty"),
-		},
-	}, {
-		name: "invalid empty environment variable",
-		test: api.MultiStageTestConfigurationLiteral{
-			Leases: []api.StepLease{{ResourceType: "aws-quota-slice"}},
+func TestValidateClusterGroups(t *testing.T) {
+	multiStage := func(as, group string, profile api.ClusterProfile) api.TestStepConfiguration {
+		return api.TestStepConfiguration{As: as, ClusterGroup: group, MultiStageTestConfiguration: &api.MultiStageTestConfiguration{ClusterProfile: profile}}
+	}
+	var testCases = []struct {
+		name   string
+		tests  []api.TestStepConfiguration
+		output []error
+	}{
+		{
+			name:  "no groups",
+			tests: []api.TestStepConfiguration{multiStage("e2e", "", api.ClusterProfileAWS)},
 		},
-		err: []error{
-			errors.New("tests[0].steps.leases[0]: 'env' cannot be empty"),
+		{
+			name:  "valid group",
+			tests: []api.TestStepConfiguration{multiStage("e2e-a", "e2e", api.ClusterProfileAWS), multiStage("e2e-b", "e2e", api.ClusterProfileAWS)},
 		},
-	}, {
-		name: "invalid duplicate name",
-		test: api.MultiStageTestConfigurationLiteral{
-			Leases: []api.StepLease{
-				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
-				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+		{
+			name: "invalid groups",
+			tests: []api.TestStepConfiguration{
+				multiStage("e2e-a", "e2e-b", api.ClusterProfileAWS),
+				multiStage("e2e-b", "e2e-b", api.ClusterProfileGCP),
+				{As: "unit", ClusterGroup: "Unit_Group", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
 			},
-		},
-		err: []error{
-			errors.New("tests[0].steps.leases[1]: duplicate environment variable: AWS_LEASED_RESOURCE"),
-		},
-	}, {
-		name: "invalid duplicate name from other steps",
-		test: api.MultiStageTestConfigurationLiteral{
-			Leases: []api.StepLease{
-				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+			output: []error{
+				errors.New(`root[0].cluster_group: "e2e-b" is already the name of a test`),
+				errors.New(`root[1].cluster_group: "e2e-b" is already the name of a test`),
+				errors.New(`root[1].cluster_group: all tests in the group must use the same cluster profile, found "aws" and "gcp"`),
+				errors.New(`root[2].cluster_group: 'Unit_Group' is not a valid Kubernetes object name`),
+				errors.New(`root[2].cluster_group: only multi-stage tests can share a cluster`),
 			},
-			Test: []api.LiteralTestStep{{
-				As:       "as",
-				From:     "from",
-				Commands: "commands",
-				Resources: api.ResourceRequirements{
-					Requests: api.ResourceList{"cpu": "1"},
-					Limits:   api.ResourceList{"memory": "1m"},
-				},
-				Leases: []api.StepLease{
-					{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
-				},
-			}},
-		},
-		err: []error{
-			errors.New("tests[0].steps.test[0].leases[0]: duplicate environment variable: AWS_LEASED_RESOURCE"),
 		},
-	}} {
-		t.Run(tc.name, func(t *testing.T) {
-			test := api.TestStepConfiguration{
-				MultiStageTestConfigurationLiteral: &tc.test,
-			}
-			v := NewValidator(nil, nil)
-			err := v.validateTestConfigurationType("tests[0]", test, nil, nil, nil, make(testInputImages), true)
-			if diff := diff.ObjectReflectDiff(tc.err, err); diff != "<no diffs>" {
-				t.Errorf("unexpected error: %s", diff)
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateClusterGroups("root", testCase.tests)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
 			}
 		})
 	}
 }
 
-func TestValidateTestConfigurationType(t *testing.T) {
+func TestValidateGatherSteps(t *testing.T) {
+	resources := api.ResourceRequirements{
+		Requests: api.ResourceList{"cpu": "1"},
+		Limits:   api.ResourceList{"memory": "1m"},
+	}
+	yes := true
 	for _, tc := range []struct {
-		name     string
-		test     api.TestStepConfiguration
-		expected []error
-	}{
-		{
-			name: "valid claim",
-			test: api.TestStepConfiguration{
-				ClusterClaim: &api.ClusterClaim{
-					Product:      api.ReleaseProductOCP,
-					Version:      "4.6.0",
-					Architecture: api.ReleaseArchitectureAMD64,
-					Cloud:        api.CloudAWS,
+		name  string
+		steps []api.TestStep
+		errs  []error
+	}{{
+		name: "Valid gather steps",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "as",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources},
+		}},
+	}, {
+		name: "Gather step optional on success",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:                "as",
+				From:              "from",
+				Commands:          "commands",
+				Resources:         resources,
+				OptionalOnSuccess: &yes},
+		}},
+		errs: []error{
+			errors.New("test[0]: `optional_on_success` is only allowed for Post steps"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			context := newContext("test", nil, nil, make(testInputImages))
+			v := NewValidator(nil, nil, nil)
+			ret := v.validateTestSteps(context, testStageGather, tc.steps, nil)
+			if !errListMessagesEqual(ret, tc.errs) {
+				t.Fatal(diff.ObjectReflectDiff(ret, tc.errs))
+			}
+		})
+	}
+}
+
+func TestValidateGatherTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		timeout  *prowv1.Duration
+		expected []error
+	}{
+		{
+			name: "no timeout",
+		},
+		{
+			name:    "valid timeout",
+			timeout: &prowv1.Duration{Duration: 30 * time.Minute},
+		},
+		{
+			name:     "negative timeout",
+			timeout:  &prowv1.Duration{Duration: -time.Minute},
+			expected: []error{errors.New("root.gather_timeout: must be positive, got -1m0s")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, validateGatherTimeout("root", tc.timeout), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePhaseBudgets(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		budgets       *api.PhaseBudgets
+		gatherTimeout *prowv1.Duration
+		timeout       *prowv1.Duration
+		expected      []error
+	}{
+		{
+			name: "no budgets",
+		},
+		{
+			name: "budgets fit in the timeout",
+			budgets: &api.PhaseBudgets{
+				Pre:  &prowv1.Duration{Duration: time.Hour},
+				Test: &prowv1.Duration{Duration: 2 * time.Hour},
+				Post: &prowv1.Duration{Duration: time.Hour},
+			},
+			gatherTimeout: &prowv1.Duration{Duration: 30 * time.Minute},
+			timeout:       &prowv1.Duration{Duration: 5 * time.Hour},
+		},
+		{
+			name:    "budgets without a timeout",
+			budgets: &api.PhaseBudgets{Pre: &prowv1.Duration{Duration: 10 * time.Hour}},
+		},
+		{
+			name: "negative budget",
+			budgets: &api.PhaseBudgets{
+				Pre:  &prowv1.Duration{Duration: time.Hour},
+				Post: &prowv1.Duration{Duration: -time.Minute},
+			},
+			expected: []error{errors.New("root.budgets.post: must be positive, got -1m0s")},
+		},
+		{
+			name: "budgets exceed the timeout",
+			budgets: &api.PhaseBudgets{
+				Pre:  &prowv1.Duration{Duration: time.Hour},
+				Test: &prowv1.Duration{Duration: 2 * time.Hour},
+				Post: &prowv1.Duration{Duration: time.Hour},
+			},
+			gatherTimeout: &prowv1.Duration{Duration: 30 * time.Minute},
+			timeout:       &prowv1.Duration{Duration: 4 * time.Hour},
+			expected:      []error{errors.New("root.budgets: the phases are given 4h30m0s in total, more than the timeout of the test (4h0m0s)")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, validatePhaseBudgets("root", tc.budgets, tc.gatherTimeout, tc.timeout), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateSkewReleases(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		clientRelease string
+		serverRelease string
+		release       *api.ReleaseTagConfiguration
+		releases      sets.Set[string]
+		output        []error
+	}{
+		{
+			name: "not a skew test",
+		},
+		{
+			name:          "releases from tag_specification",
+			clientRelease: "initial",
+			serverRelease: "latest",
+			release:       &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.16"},
+		},
+		{
+			name:          "releases from releases",
+			clientRelease: "previous",
+			serverRelease: "latest",
+			releases:      sets.New[string]("previous", "latest"),
+		},
+		{
+			name:          "releases not configured",
+			clientRelease: "initial",
+			serverRelease: "next",
+			releases:      sets.New[string]("latest"),
+			output: []error{
+				errors.New(`root.client_release: release "initial" is not configured in 'tag_specification' or 'releases'`),
+				errors.New(`root.server_release: release "next" is not configured in 'tag_specification' or 'releases'`),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateSkewReleases("root", testCase.clientRelease, testCase.serverRelease, testCase.release, testCase.releases, nil)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateScheduling(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		nodeSelector map[string]string
+		tolerations  []api.Toleration
+		output       []error
+	}{
+		{
+			name: "nothing set",
+		},
+		{
+			name:         "allowed node selector and tolerations",
+			nodeSelector: map[string]string{"ci-instance-type": "large-memory"},
+			tolerations: []api.Toleration{
+				{Key: "ci-instance-type", Value: "large-memory", Effect: "NoSchedule"},
+				{Key: "nvidia.com/gpu"},
+			},
+		},
+		{
+			name:         "node selector not in allowlist",
+			nodeSelector: map[string]string{"ci-instance-type": "huge", "node-role.kubernetes.io/master": ""},
+			output: []error{
+				errors.New(`root.node_selector: value "huge" for label "ci-instance-type" is not allowed, expected one of [high-perf large-memory metal]`),
+				errors.New(`root.node_selector: label "node-role.kubernetes.io/master" is not allowed, expected one of [ci-instance-type nvidia.com/gpu]`),
+			},
+		},
+		{
+			name: "tolerations not in allowlist",
+			tolerations: []api.Toleration{
+				{Key: "node-role.kubernetes.io/master"},
+				{Key: "ci-instance-type", Value: "huge", Effect: "Sometimes"},
+			},
+			output: []error{
+				errors.New(`root.tolerations[0].key: taint "node-role.kubernetes.io/master" is not allowed, expected one of [ci-instance-type nvidia.com/gpu]`),
+				errors.New(`root.tolerations[1].value: value "huge" for taint "ci-instance-type" is not allowed, expected one of [high-perf large-memory metal]`),
+				errors.New(`root.tolerations[1].effect: "Sometimes" is not a valid taint effect`),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateScheduling("root", testCase.nodeSelector, testCase.tolerations)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateTestMetadata(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		input  map[string]string
+		labels bool
+		output []error
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:   "valid labels",
+			input:  map[string]string{"cost-center": "team-a", "chaos.example.com/exclude": "true"},
+			labels: true,
+		},
+		{
+			name:   "reserved prefixes",
+			input:  map[string]string{"ci.openshift.io/job": "a", "node.kubernetes.io/role": "b", "release.openshift.io/ok": "c"},
+			labels: true,
+			output: []error{
+				errors.New("root: key \"ci.openshift.io/job\" uses the reserved prefix ci.openshift.io/"),
+				errors.New("root: key \"node.kubernetes.io/role\" uses the reserved prefix kubernetes.io/"),
+			},
+		},
+		{
+			name:   "invalid label value",
+			input:  map[string]string{"owner": "not a label value"},
+			labels: true,
+			output: []error{
+				errors.New("root: value \"not a label value\" for key \"owner\" is invalid: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')"),
+			},
+		},
+		{
+			name:  "annotations set by the tooling",
+			input: map[string]string{"ci.openshift.io/deprioritized-at": "2024-06-01T00:00:00Z"},
+		},
+		{
+			name:  "annotations set by the tooling with an invalid value",
+			input: map[string]string{"ci.openshift.io/deprioritized-at": "yesterday"},
+			output: []error{
+				errors.New("root: value \"yesterday\" for key \"ci.openshift.io/deprioritized-at\" is not a time in RFC 3339: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""),
+			},
+		},
+		{
+			name:  "resource ceiling exemption without a reason",
+			input: map[string]string{"ci.openshift.io/resource-ceiling-exemption": " "},
+			output: []error{
+				errors.New("root: value \" \" for key \"ci.openshift.io/resource-ceiling-exemption\" must explain why more resources are needed"),
+			},
+		},
+		{
+			name:   "annotations set by the tooling are not labels",
+			input:  map[string]string{"ci.openshift.io/deprioritized-at": "2024-06-01"},
+			labels: true,
+			output: []error{
+				errors.New("root: key \"ci.openshift.io/deprioritized-at\" uses the reserved prefix ci.openshift.io/"),
+			},
+		},
+		{
+			name:   "reserved keys without a prefix",
+			input:  map[string]string{"created-by-ci": "false", "job-release": "4.18", "created-by-prow": "true", "release": "4.18"},
+			labels: true,
+			output: []error{
+				errors.New("root: key \"created-by-ci\" is reserved"),
+				errors.New("root: key \"created-by-prow\" is reserved"),
+				errors.New("root: key \"job-release\" is reserved"),
+			},
+		},
+		{
+			name:  "annotation values are not restricted",
+			input: map[string]string{"description": "not a label value"},
+		},
+		{
+			name:  "invalid key",
+			input: map[string]string{"not a key": ""},
+			output: []error{
+				errors.New("root: key \"not a key\" is invalid: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateTestMetadata("root", testCase.input, testCase.labels)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateLeases(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		test api.MultiStageTestConfigurationLiteral
+		err  []error
+	}{{
+		name: "valid leases",
+		test: api.MultiStageTestConfigurationLiteral{
+			Leases: []api.StepLease{
+				{ResourceType: "aws-quota-slice", Env: "AWS_LEASED_RESOURCE"},
+				{ResourceType: "gcp-quota-slice", Env: "GCP_LEASED_RESOURCE"},
+			},
+		},
+	}, {
+		name: "invalid empty name",
+		test: api.MultiStageTestConfigurationLiteral{
+			Leases: []api.StepLease{{Env: "AWS_LEASED_RESOURCE"}},
+		},
+		err: []error{
+			errors.New("tests[0].steps.leases[0]: 'resource_type' cannot be empty"),
+		},
+	}, {
+		name: "invalid empty environment variable",
+		test: api.MultiStageTestConfigurationLiteral{
+			Leases: []api.StepLease{{ResourceType: "aws-quota-slice"}},
+		},
+		err: []error{
+			errors.New("tests[0].steps.leases[0]: 'env' cannot be empty"),
+		},
+	}, {
+		name: "invalid duplicate name",
+		test: api.MultiStageTestConfigurationLiteral{
+			Leases: []api.StepLease{
+				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+			},
+		},
+		err: []error{
+			errors.New("tests[0].steps.leases[1]: duplicate environment variable: AWS_LEASED_RESOURCE"),
+		},
+	}, {
+		name: "invalid duplicate name from other steps",
+		test: api.MultiStageTestConfigurationLiteral{
+			Leases: []api.StepLease{
+				{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+			},
+			Test: []api.LiteralTestStep{{
+				As:       "as",
+				From:     "from",
+				Commands: "commands",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"cpu": "1"},
+					Limits:   api.ResourceList{"memory": "1m"},
+				},
+				Leases: []api.StepLease{
+					{ResourceType: "aws", Env: "AWS_LEASED_RESOURCE"},
+				},
+			}},
+		},
+		err: []error{
+			errors.New("tests[0].steps.test[0].leases[0]: duplicate environment variable: AWS_LEASED_RESOURCE"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := api.TestStepConfiguration{
+				MultiStageTestConfigurationLiteral: &tc.test,
+			}
+			v := NewValidator(nil, nil, nil)
+			err := v.validateTestConfigurationType("tests[0]", test, nil, nil, nil, make(testInputImages), true)
+			if diff := diff.ObjectReflectDiff(tc.err, err); diff != "<no diffs>" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateTestConfigurationType(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		test     api.TestStepConfiguration
+		expected []error
+	}{
+		{
+			name: "valid claim",
+			test: api.TestStepConfiguration{
+				ClusterClaim: &api.ClusterClaim{
+					Product:      api.ReleaseProductOCP,
+					Version:      "4.6.0",
+					Architecture: api.ReleaseArchitectureAMD64,
+					Cloud:        api.CloudAWS,
 					Owner:        "dpp",
 					Timeout:      &prowv1.Duration{Duration: time.Hour},
 					Labels:       map[string]string{"size": "2", "multi-az": "false"},
@@ -1705,7 +2314,7 @@ func TestValidateTestConfigurationType(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			v := NewValidator(nil, nil)
+			v := NewValidator(nil, nil, nil)
 			actual := v.validateTestConfigurationType("test", tc.test, nil, nil, nil, make(testInputImages), false)
 			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("expected differs from actual: %s", diff)
@@ -1754,7 +2363,7 @@ func TestVerifyClusterProfileOwnership(t *testing.T) {
 			Owners:  []api.ClusterProfileOwners{},
 		},
 	}
-	v := NewValidator(cpMap, nil)
+	v := NewValidator(cpMap, nil, nil)
 
 	for _, tc := range []struct {
 		name     string
@@ -1877,7 +2486,7 @@ func TestVerifyClusterClaimOwnership(t *testing.T) {
 			Owners: []api.ClusterClaimOwnerDetails{},
 		},
 	}
-	v := NewValidator(nil, clusterClaim)
+	v := NewValidator(nil, clusterClaim, nil)
 
 	for _, tc := range []struct {
 		name     string
@@ -1959,3 +2568,339 @@ func TestVerifyClusterClaimOwnership(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSoak(t *testing.T) {
+	cron := "@weekly"
+	multiStage := &api.MultiStageTestConfiguration{}
+	var testCases = []struct {
+		name   string
+		test   api.TestStepConfiguration
+		output []error
+	}{
+		{
+			name: "not a soak test",
+			test: api.TestStepConfiguration{As: "test"},
+		},
+		{
+			name: "valid soak test",
+			test: api.TestStepConfiguration{
+				As:                          "test",
+				Cron:                        &cron,
+				MultiStageTestConfiguration: multiStage,
+				Soak: &api.SoakConfiguration{
+					MaxDurationDays:    7,
+					CheckpointInterval: &prowv1.Duration{Duration: 6 * time.Hour},
+					MaxCheckpoints:     10,
+				},
+			},
+		},
+		{
+			name: "invalid soak test",
+			test: api.TestStepConfiguration{
+				As:                         "test",
+				Timeout:                    &prowv1.Duration{Duration: time.Hour},
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"},
+				Soak: &api.SoakConfiguration{
+					MaxDurationDays:    30,
+					CheckpointInterval: &prowv1.Duration{Duration: time.Minute},
+					MaxCheckpoints:     -1,
+				},
+			},
+			output: []error{
+				errors.New("root.soak: only multi-stage tests can be soak tests"),
+				errors.New("root.soak: soak tests must be periodic"),
+				errors.New("root.soak: soak tests cannot set timeout, use max_duration_days instead"),
+				errors.New("root.soak.max_duration_days: must be between 1 and 14"),
+				errors.New("root.soak.checkpoint_interval: must be at least 5m"),
+				errors.New("root.soak.max_checkpoints: must not be negative"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateSoak("root", testCase.test)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateReuseResults(t *testing.T) {
+	cron := "@daily"
+	var testCases = []struct {
+		name   string
+		test   api.TestStepConfiguration
+		output []error
+	}{
+		{
+			name: "results are not reused",
+			test: api.TestStepConfiguration{As: "test"},
+		},
+		{
+			name: "valid reuse",
+			test: api.TestStepConfiguration{As: "test", ReuseResults: &api.ReuseResultsConfiguration{SourcePaths: []string{"^pkg/", `\.go$`}}},
+		},
+		{
+			name: "periodic test also running as a presubmit",
+			test: api.TestStepConfiguration{As: "test", Cron: &cron, Presubmit: true, ReuseResults: &api.ReuseResultsConfiguration{}},
+		},
+		{
+			name: "periodic test",
+			test: api.TestStepConfiguration{As: "test", Cron: &cron, ReuseResults: &api.ReuseResultsConfiguration{}},
+			output: []error{
+				errors.New("root.reuse_results: only presubmit tests can reuse results"),
+			},
+		},
+		{
+			name: "postsubmit with invalid paths",
+			test: api.TestStepConfiguration{As: "test", Postsubmit: true, ReuseResults: &api.ReuseResultsConfiguration{SourcePaths: []string{"", "pkg/("}}},
+			output: []error{
+				errors.New("root.reuse_results: only presubmit tests can reuse results"),
+				errors.New("root.reuse_results.source_paths[0]: must not be empty"),
+				errors.New("root.reuse_results.source_paths[1]: \"pkg/(\" is not a valid regular expression: error parsing regexp: missing closing ): `pkg/(`"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateReuseResults("root", testCase.test)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateIsolateStableStreams(t *testing.T) {
+	multiStage := &api.MultiStageTestConfiguration{}
+	var testCases = []struct {
+		name     string
+		test     api.TestStepConfiguration
+		release  *api.ReleaseTagConfiguration
+		releases sets.Set[string]
+		output   []error
+	}{
+		{
+			name:     "streams are not isolated",
+			test:     api.TestStepConfiguration{As: "initial", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			release:  &api.ReleaseTagConfiguration{},
+			releases: sets.New[string](),
+		},
+		{
+			name:     "valid isolation",
+			test:     api.TestStepConfiguration{As: "e2e", IsolateStableStreams: true, MultiStageTestConfiguration: multiStage},
+			release:  &api.ReleaseTagConfiguration{},
+			releases: sets.New[string]("other"),
+		},
+		{
+			name:     "not a multi-stage test",
+			test:     api.TestStepConfiguration{As: "e2e", IsolateStableStreams: true, ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			releases: sets.New[string](),
+			output:   []error{errors.New("root.isolate_stable_streams: only multi-stage tests can isolate stable streams")},
+		},
+		{
+			name:     "copies collide with release streams",
+			test:     api.TestStepConfiguration{As: "e2e", IsolateStableStreams: true, MultiStageTestConfiguration: multiStage},
+			release:  &api.ReleaseTagConfiguration{},
+			releases: sets.New[string]("e2e", "initial-e2e"),
+			output: []error{
+				errors.New(`root.isolate_stable_streams: the isolated copy of the stable stream would be the stream of release "e2e"`),
+				errors.New(`root.isolate_stable_streams: the isolated copy of the stable-initial stream would be the stream of release "initial-e2e"`),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateIsolateStableStreams("root", testCase.test, testCase.release, testCase.releases)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateAllowedWindows(t *testing.T) {
+	var testCases = []struct {
+		name    string
+		windows []api.AllowedWindow
+		output  []error
+	}{
+		{
+			name: "no windows",
+		},
+		{
+			name: "valid windows",
+			windows: []api.AllowedWindow{
+				{Start: "0 22 * * 6", Duration: prowv1.Duration{Duration: 4 * time.Hour}},
+				{Start: "@daily", Duration: prowv1.Duration{Duration: time.Hour}},
+			},
+		},
+		{
+			name: "invalid windows",
+			windows: []api.AllowedWindow{
+				{Start: "0 22 * * 6"},
+				{Start: "not a cron", Duration: prowv1.Duration{Duration: time.Hour}},
+				{Start: "0 0 22 * * 6", Duration: prowv1.Duration{Duration: time.Hour}},
+				{Start: "CRON_TZ=Europe/Prague 0 22 * * 6", Duration: prowv1.Duration{Duration: time.Hour}},
+			},
+			output: []error{
+				errors.New("root.allowed_windows[0].duration: must be positive"),
+				errors.New("root.allowed_windows[1].start: cannot parse cron: expected exactly 5 fields, found 3: [not a cron]"),
+				errors.New("root.allowed_windows[2].start: cannot parse cron: expected exactly 5 fields, found 6: [0 0 22 * * 6]"),
+				errors.New("root.allowed_windows[3].start: cannot parse cron: time zones are not supported, windows are in UTC"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateAllowedWindows("root", testCase.windows)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateTestServices(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		services []api.TestService
+		output   []error
+	}{
+		{
+			name: "no services",
+		},
+		{
+			name: "valid services",
+			services: []api.TestService{
+				{As: "postgres", From: "postgres", Ports: []int32{5432}, ReadinessProbe: &api.TestServiceReadinessProbe{TCPPort: 5432}},
+				{As: "registry", From: "registry", ReadinessProbe: &api.TestServiceReadinessProbe{HTTPGet: &api.TestServiceHTTPGet{Path: "/v2/", Port: 5000}, Timeout: &prowv1.Duration{Duration: time.Minute}}},
+				{As: "cache", From: "redis", ReadinessProbe: &api.TestServiceReadinessProbe{Command: []string{"redis-cli", "ping"}}},
+			},
+		},
+		{
+			name: "invalid services",
+			services: []api.TestService{
+				{From: "postgres"},
+				{As: "sidecar", From: "postgres"},
+				{As: "Registry", Ports: []int32{0}},
+				{As: "db", From: "postgres", ReadinessProbe: &api.TestServiceReadinessProbe{TCPPort: 5432, Command: []string{"true"}}},
+				{As: "db", From: "postgres", ReadinessProbe: &api.TestServiceReadinessProbe{HTTPGet: &api.TestServiceHTTPGet{Port: 70000}, Timeout: &prowv1.Duration{}}},
+				{As: "cache", From: "redis", ReadinessProbe: &api.TestServiceReadinessProbe{}},
+			},
+			output: []error{
+				errors.New("root.services[0].as: must be set"),
+				errors.New(`root.services[1].as: "sidecar" is reserved`),
+				errors.New(`root.services[2].as: "Registry" is not a valid name: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`),
+				errors.New("root.services[2].from: must be set"),
+				errors.New("root.services[2].ports[0]: 0 is not a valid port"),
+				errors.New("root.services[3].readiness_probe: exactly one of tcp_port, http_get or command must be set"),
+				errors.New(`root.services[4].as: duplicated name "db"`),
+				errors.New("root.services[4].readiness_probe.http_get.port: 70000 is not a valid port"),
+				errors.New("root.services[4].readiness_probe.timeout: must be positive"),
+				errors.New("root.services[5].readiness_probe: exactly one of tcp_port, http_get or command must be set"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateTestServices("root", testCase.services)
+			if diff := cmp.Diff(err, testCase.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateNestedPodman(t *testing.T) {
+	yes, no := true, false
+	steps := &api.MultiStageTestConfigurationLiteral{
+		Pre:  []api.LiteralTestStep{{As: "setup", NestedPodman: &no}},
+		Test: []api.LiteralTestStep{{As: "build"}, {As: "e2e", NestedPodman: &yes}},
+		Post: []api.LiteralTestStep{{As: "teardown", NestedPodman: &yes}},
+	}
+	for _, tc := range []struct {
+		name         string
+		capabilities []string
+		test         *api.MultiStageTestConfigurationLiteral
+		expected     []error
+	}{
+		{
+			name: "no step uses nested podman",
+			test: &api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: "e2e", NestedPodman: &no}}},
+		},
+		{
+			name:         "capability declared",
+			capabilities: []string{"arm64", "nested-podman"},
+			test:         steps,
+		},
+		{
+			name:         "capability missing",
+			capabilities: []string{"arm64"},
+			test:         steps,
+			expected: []error{
+				errors.New(`root.steps.test[1].nested_podman: test must declare the "nested-podman" capability`),
+				errors.New(`root.steps.post[0].nested_podman: test must declare the "nested-podman" capability`),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateNestedPodman("root", tc.capabilities, tc.test)
+			if diff := cmp.Diff(tc.expected, errs, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkStack(t *testing.T) {
+	v := NewValidator(api.ClusterProfilesMap{
+		api.ClusterProfileAWS:  {Profile: api.ClusterProfileAWS},
+		api.ClusterProfileAWS2: {Profile: api.ClusterProfileAWS2, NetworkStacks: []api.NetworkStack{api.NetworkStackIPv4, api.NetworkStackDualStack}},
+	}, nil, nil)
+	for _, tc := range []struct {
+		name     string
+		stack    api.NetworkStack
+		profile  api.ClusterProfile
+		expected []error
+	}{
+		{
+			name:    "no network stack",
+			profile: api.ClusterProfileAWS,
+		},
+		{
+			name:    "IPv4",
+			stack:   api.NetworkStackIPv4,
+			profile: api.ClusterProfileAWS,
+		},
+		{
+			name:     "unknown network stack",
+			stack:    "ipv5",
+			profile:  api.ClusterProfileAWS,
+			expected: []error{errors.New(`root.network_stack: "ipv5" is not a network stack, expected one of ipv4, ipv6 or dualstack`)},
+		},
+		{
+			name:     "not supported by the platform",
+			stack:    api.NetworkStackDualStack,
+			profile:  api.ClusterProfileAWS,
+			expected: []error{errors.New("root.network_stack: dualstack is not supported by cluster profile aws")},
+		},
+		{
+			name:    "supported by the profile",
+			stack:   api.NetworkStackDualStack,
+			profile: api.ClusterProfileAWS2,
+		},
+		{
+			name:  "profile from a workflow",
+			stack: api.NetworkStackIPv6,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := v.validateNetworkStack("root", tc.stack, tc.profile)
+			if diff := cmp.Diff(tc.expected, errs, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// WithTimeoutChains returns a validator which also rejects timeouts longer
+// than the timeout they run within. The default job timeout of a repository
+// comes from the configuration of Prow, chains bounded by it are not checked
+// when it is zero. At runtime the chains are only reported as warnings, see
+// TimeoutChainWarnings.
+func (v Validator) WithTimeoutChains(defaultJobTimeout func(metadata api.Metadata) time.Duration) Validator {
+	v.defaultJobTimeout = defaultJobTimeout
+	return v
+}
+
+// TimeoutChainWarnings reports the timeouts of the tests being run which are
+// longer than the timeout they run within, given the timeout of their job.
+func TimeoutChainWarnings(config *api.ReleaseBuildConfiguration, tests sets.Set[string], jobTimeout time.Duration) []string {
+	var warnings []string
+	for i, test := range config.Tests {
+		if !tests.Has(test.As) {
+			continue
+		}
+		for _, err := range validateTimeoutChain(fmt.Sprintf("tests[%d]", i), &test, jobTimeout) {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	return warnings
+}
+
+// validateTimeoutChain ensures that no timeout set in the configuration is
+// longer than the timeout it runs within, which would be cut short without
+// the configuration saying so. The budgets of the phases are left to
+// validatePhaseBudgets, which validates them together.
+func validateTimeoutChain(fieldRoot string, test *api.TestStepConfiguration, defaultJobTimeout time.Duration) []error {
+	chain := test.TimeoutChain(defaultJobTimeout)
+	var errs []error
+	for _, bound := range chain {
+		if bound.IsDefault() || bound.Within == "" || strings.Contains(bound.Field, ".budgets.") {
+			continue
+		}
+		within, ok := chain.Get(bound.Within)
+		if !ok || within.Timeout.Duration == 0 || bound.Timeout.Duration <= within.Timeout.Duration {
+			continue
+		}
+		source := "the default"
+		if !within.IsDefault() {
+			source = fmt.Sprintf("set by %s", within.Field)
+		}
+		errs = append(errs, fmt.Errorf("%s.%s: the %s timeout (%s) is longer than the %s timeout it runs within (%s, %s)", fieldRoot, bound.Field, bound.Name, bound.Timeout.Duration, within.Name, within.Timeout.Duration, source))
+	}
+	return errs
+}
@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateTimeoutChain(t *testing.T) {
+	duration := func(d time.Duration) *prowv1.Duration {
+		return &prowv1.Duration{Duration: d}
+	}
+	for _, tc := range []struct {
+		name              string
+		test              api.TestStepConfiguration
+		defaultJobTimeout time.Duration
+		expected          []error
+	}{
+		{
+			name: "timeouts fit in the ones they run within",
+			test: api.TestStepConfiguration{
+				As:           "e2e",
+				Timeout:      duration(6 * time.Hour),
+				ClusterClaim: &api.ClusterClaim{Timeout: duration(2 * time.Hour)},
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test:    []api.LiteralTestStep{{As: "test", Timeout: duration(5 * time.Hour)}},
+					Budgets: &api.PhaseBudgets{Test: duration(5 * time.Hour)},
+				},
+			},
+		},
+		{
+			name: "defaults longer than the timeouts they run within are not reported",
+			test: api.TestStepConfiguration{
+				As:      "e2e",
+				Timeout: duration(30 * time.Minute),
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{As: "test"}},
+				},
+			},
+		},
+		{
+			name: "budgets are not reported",
+			test: api.TestStepConfiguration{
+				As: "e2e",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Budgets: &api.PhaseBudgets{Pre: duration(10 * time.Hour)},
+				},
+			},
+		},
+		{
+			name: "timeouts are not compared to an unknown default job timeout",
+			test: api.TestStepConfiguration{
+				As:           "e2e",
+				ClusterClaim: &api.ClusterClaim{Timeout: duration(5 * time.Hour)},
+			},
+		},
+		{
+			name: "timeouts longer than the ones they run within",
+			test: api.TestStepConfiguration{
+				As:           "e2e",
+				ClusterClaim: &api.ClusterClaim{Timeout: duration(5 * time.Hour)},
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre:           []api.LiteralTestStep{{As: "install", Timeout: duration(5 * time.Hour)}},
+					Test:          []api.LiteralTestStep{{As: "test", Timeout: duration(2 * time.Hour)}},
+					Gather:        []api.LiteralTestStep{{As: "must-gather", Timeout: duration(time.Hour)}},
+					GatherTimeout: duration(30 * time.Minute),
+					Budgets:       &api.PhaseBudgets{Test: duration(time.Hour)},
+				},
+			},
+			defaultJobTimeout: 4 * time.Hour,
+			expected: []error{
+				errors.New("tests[0].cluster_claim.timeout: the cluster claim timeout (5h0m0s) is longer than the job timeout it runs within (4h0m0s, the default)"),
+				errors.New("tests[0].literal_steps.pre[0].timeout: the step install timeout (5h0m0s) is longer than the job timeout it runs within (4h0m0s, the default)"),
+				errors.New("tests[0].literal_steps.test[0].timeout: the step test timeout (2h0m0s) is longer than the phase test timeout it runs within (1h0m0s, set by literal_steps.budgets.test)"),
+				errors.New("tests[0].literal_steps.gather[0].timeout: the step must-gather timeout (1h0m0s) is longer than the phase gather timeout it runs within (30m0s, set by literal_steps.gather_timeout)"),
+			},
+		},
+		{
+			name: "step timeout longer than the timeout of the test",
+			test: api.TestStepConfiguration{
+				As:      "e2e",
+				Timeout: duration(time.Hour),
+				MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+					Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "test", Timeout: duration(90 * time.Minute)}}},
+				},
+			},
+			expected: []error{
+				errors.New("tests[0].steps.test[0].timeout: the step test timeout (1h30m0s) is longer than the job timeout it runs within (1h0m0s, set by timeout)"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateTimeoutChain("tests[0]", &tc.test, tc.defaultJobTimeout), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestTimeoutChainWarnings(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{
+		{As: "unit", ClusterClaim: &api.ClusterClaim{Timeout: &prowv1.Duration{Duration: 3 * time.Hour}}},
+		{As: "e2e", ClusterClaim: &api.ClusterClaim{Timeout: &prowv1.Duration{Duration: 3 * time.Hour}}},
+	}}
+	expected := []string{"tests[1].cluster_claim.timeout: the cluster claim timeout (3h0m0s) is longer than the job timeout it runs within (2h0m0s, the default)"}
+	testhelper.Diff(t, "warnings", TimeoutChainWarnings(config, sets.New[string]("e2e"), 2*time.Hour), expected)
+}
@@ -0,0 +1,345 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+var (
+	// largeCPURequest and largeMemoryRequest are the requests above which a
+	// container is unlikely to be scheduled on a typical build farm node.
+	largeCPURequest    = resource.MustParse("16")
+	largeMemoryRequest = resource.MustParse("64Gi")
+)
+
+// ConfigurationWarnings returns the non-fatal findings of the validation of
+// a configuration, for callers which do not need it validated. Unlike
+// validation errors, they do not make the configuration invalid.
+func ConfigurationWarnings(config *api.ReleaseBuildConfiguration) []string {
+	v := newSingleUseValidator()
+	warnings, _ := v.validateConfiguration(NewConfigContext(), config, "", "", false, true)
+	return warnings
+}
+
+// configurationWarnings returns the findings which are not tied to the
+// validation of a single field: base images nothing in the configuration
+// uses, inputs of images nothing in the configuration provides, unusually
+// large resource requests of steps, problems in the commands of steps and
+// environment variables given different values by a test and its steps.
+func configurationWarnings(config *api.ReleaseBuildConfiguration) []string {
+	var warnings []string
+	warnings = append(warnings, unusedBaseImageWarnings(config)...)
+	warnings = append(warnings, missingImageInputWarnings(config)...)
+	for i, test := range config.Tests {
+		fieldRoot := fmt.Sprintf("tests[%d].steps", i)
+		switch {
+		case test.MultiStageTestConfigurationLiteral != nil:
+			steps := test.MultiStageTestConfigurationLiteral
+			warnings = append(warnings, environmentCollisionWarnings(fieldRoot, steps)...)
+			testLeases := sets.New[string]()
+			for _, lease := range api.LeasesForTest(steps) {
+				testLeases.Insert(lease.Env)
+			}
+			for _, phase := range []struct {
+				name  string
+				steps []api.LiteralTestStep
+			}{
+				{name: "pre", steps: steps.Pre},
+				{name: "test", steps: steps.Test},
+				{name: "gather", steps: steps.Gather},
+				{name: "post", steps: steps.Post},
+			} {
+				for j, step := range phase.steps {
+					stepRoot := fmt.Sprintf("%s.%s[%d]", fieldRoot, phase.name, j)
+					warnings = append(warnings, requestWarnings(stepRoot+".resources", step.Resources)...)
+					warnings = append(warnings, commandWarnings(stepRoot, step, testLeases)...)
+				}
+			}
+		case test.MultiStageTestConfiguration != nil:
+			steps := test.MultiStageTestConfiguration
+			testLeases := unresolvedTestLeases(steps)
+			for _, phase := range []struct {
+				name  string
+				steps []api.TestStep
+			}{
+				{name: "pre", steps: steps.Pre},
+				{name: "test", steps: steps.Test},
+				{name: "gather", steps: steps.Gather},
+				{name: "post", steps: steps.Post},
+			} {
+				for j, step := range phase.steps {
+					if step.LiteralTestStep != nil {
+						stepRoot := fmt.Sprintf("%s.%s[%d]", fieldRoot, phase.name, j)
+						warnings = append(warnings, requestWarnings(stepRoot+".resources", step.LiteralTestStep.Resources)...)
+						warnings = append(warnings, commandWarnings(stepRoot, *step.LiteralTestStep, testLeases)...)
+					}
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// unresolvedTestLeases returns the lease variables of a test which is not
+// resolved, or nil when the workflow or registry steps it uses may add more.
+func unresolvedTestLeases(steps *api.MultiStageTestConfiguration) sets.Set[string] {
+	if steps.Workflow != nil {
+		return nil
+	}
+	literal := &api.MultiStageTestConfigurationLiteral{
+		ClusterProfile: steps.ClusterProfile,
+		Leases:         steps.Leases,
+	}
+	for _, phase := range []struct {
+		from []api.TestStep
+		to   *[]api.LiteralTestStep
+	}{
+		{from: steps.Pre, to: &literal.Pre},
+		{from: steps.Test, to: &literal.Test},
+		{from: steps.Post, to: &literal.Post},
+		{from: steps.Gather, to: &literal.Gather},
+	} {
+		for _, step := range phase.from {
+			if step.LiteralTestStep == nil {
+				return nil
+			}
+			*phase.to = append(*phase.to, *step.LiteralTestStep)
+		}
+	}
+	testLeases := sets.New[string]()
+	for _, lease := range api.LeasesForTest(literal) {
+		testLeases.Insert(lease.Env)
+	}
+	return testLeases
+}
+
+// commandWarnings lints the commands of a step. The parameters the commands
+// expand are only checked when the lease variables of the test are given.
+func commandWarnings(fieldRoot string, step api.LiteralTestStep, testLeases sets.Set[string]) []string {
+	var warnings []string
+	for _, problem := range lintCommands(api.ExpandTypedStep(step), testLeases) {
+		warnings = append(warnings, fmt.Sprintf("%s.commands: %s", fieldRoot, problem))
+	}
+	return warnings
+}
+
+// environmentCollisionWarnings reports the environment variables which the
+// test and its steps define with different values. Resolving the test turns
+// the defaults of the workflow, chains and steps into the default of each
+// step parameter, so a variable set differently at those levels only shows
+// up as steps running with different values. The environment of the test
+// overrides the values of all the steps, so it is reported along with the
+// definitions it hides.
+func environmentCollisionWarnings(fieldRoot string, test *api.MultiStageTestConfigurationLiteral) []string {
+	type definition struct {
+		field, step, value string
+	}
+	definitions := map[string][]definition{}
+	values := map[string]sets.Set[string]{}
+	define := func(name string, d definition) {
+		definitions[name] = append(definitions[name], d)
+		if values[name] == nil {
+			values[name] = sets.New[string]()
+		}
+		values[name].Insert(d.value)
+	}
+	for _, name := range sets.List(sets.KeySet(test.Environment)) {
+		define(name, definition{field: fmt.Sprintf("%s.env.%s", fieldRoot, name), step: "test", value: test.Environment[name]})
+	}
+	record := func(field, step string, env []api.StepParameter) {
+		for i, param := range env {
+			if param.Default == nil {
+				continue
+			}
+			define(param.Name, definition{field: fmt.Sprintf("%s.env[%d]", field, i), step: step, value: *param.Default})
+		}
+	}
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{
+		{name: "pre", steps: test.Pre},
+		{name: "test", steps: test.Test},
+		{name: "gather", steps: test.Gather},
+		{name: "post", steps: test.Post},
+	} {
+		for i, step := range phase.steps {
+			record(fmt.Sprintf("%s.%s[%d]", fieldRoot, phase.name, i), step.As, step.Environment)
+		}
+	}
+	for i, observer := range test.Observers {
+		record(fmt.Sprintf("%s.observers[%d]", fieldRoot, i), observer.Name, observer.Environment)
+	}
+	var warnings []string
+	for _, name := range sets.List(sets.KeySet(values)) {
+		if values[name].Len() < 2 {
+			continue
+		}
+		var defined []string
+		for _, d := range definitions[name] {
+			defined = append(defined, fmt.Sprintf("%s (%s): %q", d.field, d.step, d.value))
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: environment variable %s is defined with different values: %s", fieldRoot, name, strings.Join(defined, ", ")))
+	}
+	return warnings
+}
+
+// resourceWarnings reports the unusually large requests of the resources
+// configured for the containers of the configuration.
+func resourceWarnings(fieldRoot string, resources api.ResourceConfiguration) []string {
+	var warnings []string
+	for _, key := range sets.List(sets.KeySet(resources)) {
+		warnings = append(warnings, requestWarnings(fmt.Sprintf("%s.%s", fieldRoot, key), resources[key])...)
+	}
+	return warnings
+}
+
+// requestWarnings reports the requests above which a container is unlikely
+// to be scheduled.
+func requestWarnings(fieldRoot string, resources api.ResourceRequirements) []string {
+	var warnings []string
+	for name, limit := range map[string]resource.Quantity{"cpu": largeCPURequest, "memory": largeMemoryRequest} {
+		request, ok := resources.Requests[name]
+		if !ok {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(request)
+		if err != nil {
+			// reported by the validation of the resources
+			continue
+		}
+		if quantity.Cmp(limit) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s.requests.%s: %s is larger than %s and may not be schedulable", fieldRoot, name, request, limit.String()))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// unusedBaseImageWarnings reports the base images which are not used by any
+// image build, test or promotion. Configurations using registry components or
+// raw steps are not checked, as the images those use are not known here.
+func unusedBaseImageWarnings(config *api.ReleaseBuildConfiguration) []string {
+	if len(config.InputConfiguration.BaseImages) == 0 {
+		return nil
+	}
+	used, complete := baseImageUsage(config)
+	if !complete {
+		return nil
+	}
+	var warnings []string
+	for _, name := range sets.List(sets.KeySet(config.InputConfiguration.BaseImages)) {
+		if !used.Has(name) {
+			warnings = append(warnings, fmt.Sprintf("base_images.%s: not used by any image, test or promotion", name))
+		}
+	}
+	return warnings
+}
+
+// missingImageInputWarnings reports the inputs of images which are not images
+// of the pipeline, which the builds would wait for until they time out. They
+// are not errors, as configurations using raw steps or images created by the
+// graph itself may provide images this cannot know about.
+func missingImageInputWarnings(config *api.ReleaseBuildConfiguration) []string {
+	if len(config.RawSteps) > 0 {
+		return nil
+	}
+	var warnings []string
+	for i, image := range config.Images {
+		for _, name := range sets.List(sets.KeySet(image.Inputs)) {
+			if !config.IsPipelineImage(name) && !config.BuildsImage(name) {
+				warnings = append(warnings, fmt.Sprintf("images[%d].inputs[%s]: no base image import, build root, build command or image build provides the pipeline image %q", i, name, name))
+			}
+		}
+	}
+	return warnings
+}
+
+// baseImageUsage collects the pipeline image names referenced by the
+// configuration. The second return value is false when some references cannot
+// be known without resolving the configuration.
+func baseImageUsage(config *api.ReleaseBuildConfiguration) (sets.Set[string], bool) {
+	if len(config.RawSteps) > 0 {
+		return nil, false
+	}
+	used := sets.New[string]()
+	// dependencies are resolved the way the step graph does, so that both
+	// `tools` and `pipeline:tools` use the base image while `stable:tools`
+	// does not
+	dependency := func(name string) {
+		if stream, tag, _ := config.DependencyParts(api.StepDependency{Name: name}, nil); stream == api.PipelineImageStream {
+			used.Insert(tag)
+		}
+	}
+	for _, image := range config.Images {
+		used.Insert(string(image.From))
+		for name := range image.Inputs {
+			used.Insert(name)
+		}
+	}
+	if config.Operator != nil {
+		for _, substitution := range config.Operator.Substitutions {
+			used.Insert(substitution.With)
+		}
+	}
+	if config.PromotionConfiguration != nil {
+		for _, target := range config.PromotionConfiguration.Targets {
+			for _, source := range target.AdditionalImages {
+				used.Insert(source)
+			}
+		}
+	}
+	literalSteps := func(steps []api.LiteralTestStep) {
+		for _, step := range steps {
+			used.Insert(step.From)
+			for _, d := range step.Dependencies {
+				if d.PullSpec == "" {
+					dependency(d.Name)
+				}
+			}
+		}
+	}
+	for _, test := range config.Tests {
+		switch {
+		case test.ContainerTestConfiguration != nil:
+			used.Insert(string(test.ContainerTestConfiguration.From))
+			for _, service := range test.ContainerTestConfiguration.Services {
+				used.Insert(string(service.From))
+			}
+		case test.MultiStageTestConfiguration != nil:
+			steps := test.MultiStageTestConfiguration
+			if steps.Workflow != nil || steps.Observers != nil {
+				return nil, false
+			}
+			for _, phase := range [][]api.TestStep{steps.Pre, steps.Test, steps.Gather, steps.Post} {
+				for _, step := range phase {
+					if step.LiteralTestStep == nil {
+						return nil, false
+					}
+					literalSteps([]api.LiteralTestStep{*step.LiteralTestStep})
+				}
+			}
+			for _, name := range steps.Dependencies {
+				dependency(name)
+			}
+		case test.MultiStageTestConfigurationLiteral != nil:
+			steps := test.MultiStageTestConfigurationLiteral
+			for _, phase := range [][]api.LiteralTestStep{steps.Pre, steps.Test, steps.Gather, steps.Post} {
+				literalSteps(phase)
+			}
+			for _, observer := range steps.Observers {
+				used.Insert(observer.From)
+			}
+			for _, name := range steps.Dependencies {
+				dependency(name)
+			}
+		}
+	}
+	return used, true
+}
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestConfigurationWarnings(t *testing.T) {
+	baseImages := map[string]api.ImageStreamTagReference{
+		"base":  {Namespace: "ocp", Name: "base", Tag: "latest"},
+		"tools": {Namespace: "ocp", Name: "tools", Tag: "latest"},
+	}
+	ref := "ipi-install"
+	for _, tc := range []struct {
+		name     string
+		config   *api.ReleaseBuildConfiguration
+		expected []string
+	}{
+		{
+			name:   "no findings",
+			config: &api.ReleaseBuildConfiguration{},
+		},
+		{
+			name: "deprecated tag_specification",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{
+					ReleaseTagConfiguration: &api.ReleaseTagConfiguration{Namespace: "ocp", Name: "4.17"},
+				},
+			},
+			expected: []string{"tag_specification: deprecated, use releases.latest.integration instead"},
+		},
+		{
+			name: "large resource requests",
+			config: &api.ReleaseBuildConfiguration{
+				Resources: api.ResourceConfiguration{
+					"*":    {Requests: api.ResourceList{"cpu": "100m", "memory": "200Mi"}},
+					"unit": {Requests: api.ResourceList{"cpu": "32", "memory": "128Gi"}, Limits: api.ResourceList{"memory": "256Gi"}},
+				},
+			},
+			expected: []string{
+				"resources.unit.requests.cpu: 32 is larger than 16 and may not be schedulable",
+				"resources.unit.requests.memory: 128Gi is larger than 64Gi and may not be schedulable",
+			},
+		},
+		{
+			name: "large resource requests of steps",
+			config: &api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						Test: []api.LiteralTestStep{{As: "e2e", From: "src", Commands: "make e2e", Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "32"}}}},
+					}},
+					{As: "unresolved", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Pre: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "setup", From: "src", Commands: "make setup", Resources: api.ResourceRequirements{Requests: api.ResourceList{"memory": "128Gi"}}}}},
+					}},
+				},
+			},
+			expected: []string{
+				"tests[0].steps.test[0].resources.requests.cpu: 32 is larger than 16 and may not be schedulable",
+				"tests[1].steps.pre[0].resources.requests.memory: 128Gi is larger than 64Gi and may not be schedulable",
+			},
+		},
+		{
+			name: "unused base image",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Images:             []api.ProjectDirectoryImageBuildStepConfiguration{{From: "base", To: "image"}},
+			},
+			expected: []string{"base_images.tools: not used by any image, test or promotion"},
+		},
+		{
+			name: "base images used by tests",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Tests: []api.TestStepConfiguration{
+					{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "base"}},
+					{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "e2e", From: "src", Dependencies: []api.StepDependency{{Name: "tools", Env: "TOOLS"}}}}},
+					}},
+				},
+			},
+		},
+		{
+			name: "base images used by qualified dependencies",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						Test: []api.LiteralTestStep{{As: "e2e", From: "base", Dependencies: []api.StepDependency{{Name: "pipeline:tools", Env: "TOOLS"}}}},
+					}},
+				},
+			},
+		},
+		{
+			name: "dependencies on other streams do not use base images",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						Test:         []api.LiteralTestStep{{As: "e2e", From: "base", Dependencies: []api.StepDependency{{Name: "stable:tools", Env: "TOOLS"}}}},
+						Dependencies: api.TestDependencies{"OTHER": "release:latest"},
+					}},
+				},
+			},
+			expected: []string{"base_images.tools: not used by any image, test or promotion"},
+		},
+		{
+			name: "inputs of images not provided by the pipeline",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					{To: "image", ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+						Inputs: map[string]api.ImageBuildInputs{"base": {}, "missing": {}, "other": {}, "src": {}, "tools": {}},
+					}},
+					{To: "other"},
+				},
+			},
+			expected: []string{`images[0].inputs[missing]: no base image import, build root, build command or image build provides the pipeline image "missing"`},
+		},
+		{
+			name: "commands of literal steps are linted",
+			config: &api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+						ClusterProfile: api.ClusterProfileAWS,
+						Test: []api.LiteralTestStep{
+							{As: "injected", From: "src", Commands: "echo ${CLUSTER_TYPE} ${CLUSTER_PROFILE_NAME} ${LEASED_RESOURCE}"},
+							{As: "unset", From: "src", Commands: "echo ${GOPATH}"},
+						},
+					}},
+					{As: "unresolved", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "unresolved", From: "src", Commands: "#!/bin/sh\necho ${GOPATH}\n[[ -n x ]]", RunAsScript: ptr.To(true)}}},
+					}},
+				},
+			},
+			expected: []string{
+				"tests[0].steps.test[1].commands: parameter GOPATH expanded on line 1 is not set by the step and has no default value",
+				"tests[1].steps.test[0].commands: script run with /bin/sh uses bash-only syntax: `[[` tests",
+			},
+		},
+		{
+			name: "parameters of unresolved tests are checked when all their steps are literal",
+			config: &api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{
+					{As: "literal", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						ClusterProfile: api.ClusterProfileAWS,
+						Test:           []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "literal", From: "src", Commands: "echo ${LEASED_RESOURCE} ${GOPATH}"}}},
+					}},
+					{As: "reference", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Pre:  []api.TestStep{{Reference: &ref}},
+						Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{As: "reference", From: "src", Commands: "echo ${LEASED_RESOURCE} ${GOPATH}"}}},
+					}},
+				},
+			},
+			expected: []string{
+				"tests[0].steps.test[0].commands: parameter GOPATH expanded on line 1 is not set by the step and has no default value",
+			},
+		},
+		{
+			name: "base images not checked with registry references",
+			config: &api.ReleaseBuildConfiguration{
+				InputConfiguration: api.InputConfiguration{BaseImages: baseImages},
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Pre: []api.TestStep{{Reference: &ref}},
+					}},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, ConfigurationWarnings(tc.config)); diff != "" {
+				t.Errorf("unexpected warnings: %s", diff)
+			}
+		})
+	}
+}
+
+func TestEnvironmentCollisionWarnings(t *testing.T) {
+	step := func(as string, env ...api.StepParameter) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, Environment: env}
+	}
+	param := func(name, value string) api.StepParameter {
+		return api.StepParameter{Name: name, Default: &value}
+	}
+	for _, tc := range []struct {
+		name     string
+		test     api.MultiStageTestConfigurationLiteral
+		expected []string
+	}{
+		{
+			name: "steps agree on the values",
+			test: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install", param("TOPOLOGY", "ha"), param("FIPS", "false"))},
+				Test: []api.LiteralTestStep{step("e2e", param("TOPOLOGY", "ha"), api.StepParameter{Name: "FIPS"})},
+			},
+		},
+		{
+			name: "the test agrees with the steps",
+			test: api.MultiStageTestConfigurationLiteral{
+				Environment: api.TestEnvironment{"TOPOLOGY": "single", "UNUSED": "true"},
+				Pre:         []api.LiteralTestStep{step("install", param("TOPOLOGY", "single"))},
+			},
+		},
+		{
+			name: "the test overrides the steps",
+			test: api.MultiStageTestConfigurationLiteral{
+				Environment: api.TestEnvironment{"TOPOLOGY": "single"},
+				Pre:         []api.LiteralTestStep{step("install", param("TOPOLOGY", "ha"))},
+				Test:        []api.LiteralTestStep{step("e2e", param("TOPOLOGY", "single"))},
+			},
+			expected: []string{
+				`tests[0].steps: environment variable TOPOLOGY is defined with different values: tests[0].steps.env.TOPOLOGY (test): "single", tests[0].steps.pre[0].env[0] (install): "ha", tests[0].steps.test[0].env[0] (e2e): "single"`,
+			},
+		},
+		{
+			name: "steps and observers disagree",
+			test: api.MultiStageTestConfigurationLiteral{
+				Pre:       []api.LiteralTestStep{step("install", param("FIPS", "false"), param("TOPOLOGY", "ha"))},
+				Test:      []api.LiteralTestStep{step("e2e", param("TOPOLOGY", "single"))},
+				Post:      []api.LiteralTestStep{step("deprovision", param("TOPOLOGY", "ha"))},
+				Observers: []api.Observer{{Name: "watcher", Environment: []api.StepParameter{param("FIPS", "true")}}},
+			},
+			expected: []string{
+				`tests[0].steps: environment variable FIPS is defined with different values: tests[0].steps.pre[0].env[0] (install): "false", tests[0].steps.observers[0].env[0] (watcher): "true"`,
+				`tests[0].steps: environment variable TOPOLOGY is defined with different values: tests[0].steps.pre[0].env[1] (install): "ha", tests[0].steps.test[0].env[0] (e2e): "single", tests[0].steps.post[0].env[0] (deprovision): "ha"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, environmentCollisionWarnings("tests[0].steps", &tc.test)); diff != "" {
+				t.Errorf("unexpected warnings: %s", diff)
+			}
+		})
+	}
+}
@@ -216,12 +216,15 @@ func workflowDotFile(name string, workflows registry.WorkflowByName, chains regi
 		chains: chains,
 		graph:  graph{label: fmt.Sprintf(`%s "%s"`, wfType, name)},
 	}
-	roots := [3]int{
+	roots := []int{
 		b.addSubgraph("Pre", workflow.Pre),
 		b.addSubgraph("Test", workflow.Test),
-		b.addSubgraph("Post", workflow.Post),
 	}
-	return writeDotFile(b.graph, roots[:], false)
+	if len(workflow.Gather) != 0 {
+		roots = append(roots, b.addSubgraph("Gather", workflow.Gather))
+	}
+	roots = append(roots, b.addSubgraph("Post", workflow.Post))
+	return writeDotFile(b.graph, roots, false)
 }
 
 func renderDotFile(dot string) ([]byte, error) {
@@ -145,6 +145,10 @@ const workflowJobPage = `
 {{ template "stepTable" .Workflow.Steps.Pre }}
 <h3 id="test" title="Steps in the {{ toLower $type }} that run actual tests, in runtime order"><a href="#test">Test Steps</a></h3>
 {{ template "stepTable" .Workflow.Steps.Test }}
+{{ if .Workflow.Steps.Gather }}
+<h3 id="gather" title="Steps run by this {{ toLower $type }} to collect diagnostics before teardown, in runtime order"><a href="#gather">Gather Steps</a></h3>
+{{ template "stepTable" .Workflow.Steps.Gather }}
+{{ end }}
 <h3 id="post" title="Steps run by this {{ toLower $type }} to clean up and teardown test resources, in runtime order"><a href="#post">Post Steps</a></h3>
 {{ template "stepTable" .Workflow.Steps.Post }}
 <h3 id="dependencies" title="Dependencies of this {{ toLower $type }}"><a href="#dependencies">Dependencies</a></h3>
@@ -436,6 +440,7 @@ const templateDefinitions = `
 					</td>
 					<td>{{ if gt (len $config.Pre) 0 }}<b>Pre:</b>{{ template "stepList" $config.Pre }}{{ end }}
 					    {{ if gt (len $config.Test) 0 }}<b>Test:</b>{{ template "stepList" $config.Test }}{{ end }}
+						{{ if gt (len $config.Gather) 0 }}<b>Gather:</b>{{ template "stepList" $config.Gather }}{{ end }}
 						{{ if gt (len $config.Post) 0 }}<b>Post:</b>{{ template "stepList" $config.Post }}{{ end }}
 					</td>
 				</tr>
@@ -712,7 +717,7 @@ func jobToWorkflow(name string, config api.MultiStageTestConfiguration, workflow
 	// If there are literal test steps, we need to add the command to the docs, without changing the original map
 	// check if there are literal test steps
 	literalExists := false
-	for _, step := range append(append(config.Pre, config.Test...), config.Post...) {
+	for _, step := range append(append(config.Pre, config.Test...), append(config.Gather, config.Post...)...) {
 		if step.LiteralTestStep != nil {
 			literalExists = true
 			break
@@ -724,7 +729,7 @@ func jobToWorkflow(name string, config api.MultiStageTestConfiguration, workflow
 			newDocs[k] = v
 		}
 		docs = newDocs
-		for _, step := range append(append(config.Pre, config.Test...), config.Post...) {
+		for _, step := range append(append(config.Pre, config.Test...), append(config.Gather, config.Post...)...) {
 			if step.LiteralTestStep != nil {
 				baseDoc := fmt.Sprintf(`Container image: <span style="font-family:monospace">%s</span>`, step.From)
 				if highlighted, err := syntaxBash(step.Commands); err == nil {
@@ -748,6 +753,9 @@ func jobToWorkflow(name string, config api.MultiStageTestConfiguration, workflow
 		if config.Test == nil {
 			config.Test = workflow.Test
 		}
+		if config.Gather == nil {
+			config.Gather = workflow.Gather
+		}
 		if config.Post == nil {
 			config.Post = workflow.Post
 		}
@@ -946,7 +954,7 @@ func setWorkflowDependencies(t *template.Template, refs registry.ReferenceByName
 				}
 
 				var worklist []api.TestStep
-				for _, steps := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+				for _, steps := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Gather, workflow.Post} {
 					worklist = append(worklist, steps...)
 				}
 
@@ -1011,7 +1019,7 @@ func setWorkflowEnvironment(t *template.Template, refs registry.ReferenceByName,
 				}
 
 				var worklist []api.TestStep
-				for _, steps := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+				for _, steps := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Gather, workflow.Post} {
 					worklist = append(worklist, steps...)
 				}
 
@@ -1298,6 +1306,8 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 		writeErrorPage(w, fmt.Errorf("Could not find metadata for file `%s`. Please contact the Developer Productivity Test Platform.", refMetadataName), http.StatusInternalServerError)
 		return
 	}
+	// typed steps are shown with the image and commands they run
+	step := api.ExpandTypedStep(refs[name])
 	ref := struct {
 		Reference api.RegistryReference
 		Metadata  api.RegistryInfo
@@ -1305,18 +1315,18 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 		Reference: api.RegistryReference{
 			LiteralTestStep: api.LiteralTestStep{
 				As:                name,
-				Commands:          refs[name].Commands,
-				From:              refs[name].From,
-				FromImage:         refs[name].FromImage,
-				Dependencies:      refs[name].Dependencies,
-				Environment:       refs[name].Environment,
-				Leases:            refs[name].Leases,
-				Timeout:           refs[name].Timeout,
-				GracePeriod:       refs[name].GracePeriod,
-				Resources:         refs[name].Resources,
-				OptionalOnSuccess: refs[name].OptionalOnSuccess,
-				BestEffort:        refs[name].BestEffort,
-				Cli:               refs[name].Cli,
+				Commands:          step.Commands,
+				From:              step.From,
+				FromImage:         step.FromImage,
+				Dependencies:      step.Dependencies,
+				Environment:       step.Environment,
+				Leases:            step.Leases,
+				Timeout:           step.Timeout,
+				GracePeriod:       step.GracePeriod,
+				Resources:         step.Resources,
+				OptionalOnSuccess: step.OptionalOnSuccess,
+				BestEffort:        step.BestEffort,
+				Cli:               step.Cli,
 			},
 			Documentation: docs[name],
 		},
@@ -37,6 +37,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# the pipeline will caches on. The one way is to take the reference\n" +
 	"# from an image stream, and the other from a dockerfile.\n" +
 	"build_root:\n" +
+	"    # BuildBackend selects how the images built by ci-operator, `src`,\n" +
+	"    # `bin`, `test-bin`, `rpms`, the RPM-injected base images and the\n" +
+	"    # operator bundles and indices, are built: with the OpenShift Build API\n" +
+	"    # by default, or with `buildah` in unprivileged pods. A build root built\n" +
+	"    # from `project_image` is cloned from git, which only the Build API\n" +
+	"    # supports, so it is always built with the Build API.\n" +
+	"    build_backend: ' '\n" +
 	"    # If the BuildRoot images pullspec should be read from a file in the repository (BuildRootImageFileName).\n" +
 	"    from_repository: true\n" +
 	"    image_stream_tag:\n" +
@@ -53,6 +60,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"              # Value of the build arg.\n" +
 	"              value: ' '\n" +
+	"        # BuildBackend selects how the image is built: with the OpenShift Build\n" +
+	"        # API by default, or with `buildah` in unprivileged pods.\n" +
+	"        build_backend: ' '\n" +
 	"        # ContextDir is the directory in the project\n" +
 	"        # from which this build should be run.\n" +
 	"        context_dir: ' '\n" +
@@ -62,16 +72,30 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # DockerfilePath is the path to a Dockerfile in the\n" +
 	"        # project to run relative to the context_dir.\n" +
 	"        dockerfile_path: ' '\n" +
+	"        # Exclude lists glob patterns of the files in the context_dir not sent\n" +
+	"        # as the build context, with the same syntax as Include.\n" +
+	"        exclude:\n" +
+	"            - \"\"\n" +
+	"        # Include lists glob patterns of the files in the context_dir sent as\n" +
+	"        # the build context, all files are sent when unset. Patterns use shell\n" +
+	"        # syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched\n" +
+	"        # against paths relative to the context_dir; the files under a matching\n" +
+	"        # directory match as well. The Dockerfile is always sent.\n" +
+	"        include:\n" +
+	"            - \"\"\n" +
 	"        # Inputs is a map of tag reference name to image input changes\n" +
 	"        # that will populate the build context for the Dockerfile or\n" +
-	"        # alter the input image for a multi-stage build.\n" +
+	"        # alter the input image for a multi-stage build. Every input\n" +
+	"        # can be referenced as `pipeline:<name>` in the Dockerfile, e.g.\n" +
+	"        # in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.\n" +
 	"        inputs:\n" +
 	"            \"\":\n" +
 	"                # As is a list of multi-stage step names or image names that will\n" +
 	"                # be replaced by the image reference from this step. For instance,\n" +
 	"                # if the Dockerfile defines FROM nginx:latest AS base, specifying\n" +
 	"                # either \"nginx:latest\" or \"base\" in this array will replace that\n" +
-	"                # image with the pipeline input.\n" +
+	"                # image with the pipeline input. The `pipeline:<name>` form of\n" +
+	"                # the input does not need to be listed.\n" +
 	"                as:\n" +
 	"                    - \"\"\n" +
 	"                # Paths is a list of paths to copy out of this image and into the\n" +
@@ -95,6 +119,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# DO NOT set this in the config\n" +
 	"build_roots:\n" +
 	"    \"\":\n" +
+	"        # BuildBackend selects how the images built by ci-operator, `src`,\n" +
+	"        # `bin`, `test-bin`, `rpms`, the RPM-injected base images and the\n" +
+	"        # operator bundles and indices, are built: with the OpenShift Build API\n" +
+	"        # by default, or with `buildah` in unprivileged pods. A build root built\n" +
+	"        # from `project_image` is cloned from git, which only the Build API\n" +
+	"        # supports, so it is always built with the Build API.\n" +
+	"        build_backend: ' '\n" +
 	"        # If the BuildRoot images pullspec should be read from a file in the repository (BuildRootImageFileName).\n" +
 	"        from_repository: true\n" +
 	"        image_stream_tag:\n" +
@@ -111,6 +142,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  name: ' '\n" +
 	"                  # Value of the build arg.\n" +
 	"                  value: ' '\n" +
+	"            # BuildBackend selects how the image is built: with the OpenShift Build\n" +
+	"            # API by default, or with `buildah` in unprivileged pods.\n" +
+	"            build_backend: ' '\n" +
 	"            # ContextDir is the directory in the project\n" +
 	"            # from which this build should be run.\n" +
 	"            context_dir: ' '\n" +
@@ -120,16 +154,30 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # DockerfilePath is the path to a Dockerfile in the\n" +
 	"            # project to run relative to the context_dir.\n" +
 	"            dockerfile_path: ' '\n" +
+	"            # Exclude lists glob patterns of the files in the context_dir not sent\n" +
+	"            # as the build context, with the same syntax as Include.\n" +
+	"            exclude:\n" +
+	"                - \"\"\n" +
+	"            # Include lists glob patterns of the files in the context_dir sent as\n" +
+	"            # the build context, all files are sent when unset. Patterns use shell\n" +
+	"            # syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched\n" +
+	"            # against paths relative to the context_dir; the files under a matching\n" +
+	"            # directory match as well. The Dockerfile is always sent.\n" +
+	"            include:\n" +
+	"                - \"\"\n" +
 	"            # Inputs is a map of tag reference name to image input changes\n" +
 	"            # that will populate the build context for the Dockerfile or\n" +
-	"            # alter the input image for a multi-stage build.\n" +
+	"            # alter the input image for a multi-stage build. Every input\n" +
+	"            # can be referenced as `pipeline:<name>` in the Dockerfile, e.g.\n" +
+	"            # in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.\n" +
 	"            inputs:\n" +
 	"                \"\":\n" +
 	"                    # As is a list of multi-stage step names or image names that will\n" +
 	"                    # be replaced by the image reference from this step. For instance,\n" +
 	"                    # if the Dockerfile defines FROM nginx:latest AS base, specifying\n" +
 	"                    # either \"nginx:latest\" or \"base\" in this array will replace that\n" +
-	"                    # image with the pipeline input.\n" +
+	"                    # image with the pipeline input. The `pipeline:<name>` form of\n" +
+	"                    # the input does not need to be listed.\n" +
 	"                    as:\n" +
 	"                        - \"\"\n" +
 	"                    # Paths is a list of paths to copy out of this image and into the\n" +
@@ -160,6 +208,11 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"canonical_go_repository_list:\n" +
 	"    - ref: ' '\n" +
 	"      repository: ' '\n" +
+	"# DisabledValidationRules are the names of the validation rules this\n" +
+	"# configuration opts out of. The test platform restricts which rules\n" +
+	"# each repository may disable.\n" +
+	"disabled_validation_rules:\n" +
+	"    - \"\"\n" +
 	"# ExternalImages are images that are imported into the pipeline from an external source.\n" +
 	"external_images:\n" +
 	"    \"\":\n" +
@@ -183,6 +236,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    - # AdditionalArchitectures is a list of additional architectures to build for. AMD64 architecture is included by default.\n" +
 	"      additional_architectures:\n" +
 	"        - \"\"\n" +
+	"      # Annotations configure how the CI tooling treats the build, they are\n" +
+	"      # not set on the image. Only ci.openshift.io/resource-ceiling-exemption\n" +
+	"      # is supported, to exempt the build from the resource ceilings of the\n" +
+	"      # organization.\n" +
+	"      annotations:\n" +
+	"        \"\": \"\"\n" +
 	"      # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
 	"      # See https://docs.docker.com/engine/reference/builder/#/arg for more details.\n" +
 	"      build_args:\n" +
@@ -190,6 +249,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          name: ' '\n" +
 	"          # Value of the build arg.\n" +
 	"          value: ' '\n" +
+	"      # BuildBackend selects how the image is built: with the OpenShift Build\n" +
+	"      # API by default, or with `buildah` in unprivileged pods.\n" +
+	"      build_backend: ' '\n" +
 	"      # ContextDir is the directory in the project\n" +
 	"      # from which this build should be run.\n" +
 	"      context_dir: ' '\n" +
@@ -199,17 +261,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      # DockerfilePath is the path to a Dockerfile in the\n" +
 	"      # project to run relative to the context_dir.\n" +
 	"      dockerfile_path: ' '\n" +
+	"      # Exclude lists glob patterns of the files in the context_dir not sent\n" +
+	"      # as the build context, with the same syntax as Include.\n" +
+	"      exclude:\n" +
+	"        - \"\"\n" +
 	"      from: ' '\n" +
+	"      # Include lists glob patterns of the files in the context_dir sent as\n" +
+	"      # the build context, all files are sent when unset. Patterns use shell\n" +
+	"      # syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched\n" +
+	"      # against paths relative to the context_dir; the files under a matching\n" +
+	"      # directory match as well. The Dockerfile is always sent.\n" +
+	"      include:\n" +
+	"        - \"\"\n" +
 	"      # Inputs is a map of tag reference name to image input changes\n" +
 	"      # that will populate the build context for the Dockerfile or\n" +
-	"      # alter the input image for a multi-stage build.\n" +
+	"      # alter the input image for a multi-stage build. Every input\n" +
+	"      # can be referenced as `pipeline:<name>` in the Dockerfile, e.g.\n" +
+	"      # in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.\n" +
 	"      inputs:\n" +
 	"        \"\":\n" +
 	"            # As is a list of multi-stage step names or image names that will\n" +
 	"            # be replaced by the image reference from this step. For instance,\n" +
 	"            # if the Dockerfile defines FROM nginx:latest AS base, specifying\n" +
 	"            # either \"nginx:latest\" or \"base\" in this array will replace that\n" +
-	"            # image with the pipeline input.\n" +
+	"            # image with the pipeline input. The `pipeline:<name>` form of\n" +
+	"            # the input does not need to be listed.\n" +
 	"            as:\n" +
 	"                - \"\"\n" +
 	"            # Paths is a list of paths to copy out of this image and into the\n" +
@@ -263,14 +339,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# have been completed so that tests can be run prior to promotion.\n" +
 	"# If no promotion is defined, it is defaulted from the ReleaseTagConfiguration.\n" +
 	"promotion:\n" +
+	"    # ARTConsistencyCheck verifies that the build root used to build\n" +
+	"    # the images promoted to the `ocp` namespace matches the one ART\n" +
+	"    # uses to build the same components, and fails the promotion on a\n" +
+	"    # mismatch. This is useful to catch images that build in CI but\n" +
+	"    # would fail to build in brew.\n" +
+	"    art_consistency_check: true\n" +
 	"    # Cron generates promotion periodic alongside with promotion\n" +
 	"    # postsubmit\n" +
 	"    cron: ' '\n" +
+	"    # CVEGate scans the images to promote and the ones they replace in\n" +
+	"    # the central registry before the promotion, and fails it when the\n" +
+	"    # images introduce critical vulnerabilities which are not waived in\n" +
+	"    # the .ci-operator-cve-waivers.yaml file of the repository.\n" +
+	"    cve_gate:\n" +
+	"        warn_only: true\n" +
 	"    # DisableBuildCache stops us from uploading the build cache.\n" +
 	"    # This is useful (only) for CI chat bot invocations where\n" +
 	"    # promotion does not imply output artifacts are being created\n" +
 	"    # for posterity.\n" +
 	"    disable_build_cache: true\n" +
+	"    # ImageDiffReport compares the promoted images with the ones they\n" +
+	"    # replace in the central registry after the promotion: the packages\n" +
+	"    # added, removed and updated, the size delta and whether the base\n" +
+	"    # image changed are reported in the promotion-image-diff.json\n" +
+	"    # artifact. This is useful to catch accidental bloat and unexpected\n" +
+	"    # content changes.\n" +
+	"    image_diff_report: true\n" +
 	"    # RegistryOverride is an override for the registry domain to\n" +
 	"    # which we will mirror images. This is an advanced option and\n" +
 	"    # should *not* be used in common test workflows. The CI chat\n" +
@@ -279,7 +374,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    # Targets configure a set of images to be pushed to\n" +
 	"    # a registry.\n" +
 	"    to:\n" +
-	"        - # AdditionalImages is a mapping of images to promote. The\n" +
+	"        - # AdditionalArchitectures are the architectures, besides amd64,\n" +
+	"          # the promoted images must cover. The images are promoted as\n" +
+	"          # the manifest lists their builds produce, so they must be built\n" +
+	"          # for each of the architectures.\n" +
+	"          additional_architectures:\n" +
+	"            - \"\"\n" +
+	"          # AdditionalImages is a mapping of images to promote. The\n" +
 	"          # images will be taken from the pipeline image stream. The\n" +
 	"          # key is the name to promote as and the value is the source\n" +
 	"          # name. If you specify a tag that does not exist as the source\n" +
@@ -298,6 +399,17 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          # but not promote them afterwards.\n" +
 	"          excluded_images:\n" +
 	"            - \"\"\n" +
+	"          # Mirror pushes the promoted tags to an external registry as\n" +
+	"          # well, after they are promoted to the central registry.\n" +
+	"          mirror:\n" +
+	"            # Credentials is the name of a secret in the test-credentials\n" +
+	"            # namespace holding a .dockerconfigjson able to push to the registry.\n" +
+	"            credentials: ' '\n" +
+	"            # Registry is the host of the registry, e.g. quay.io.\n" +
+	"            registry: ' '\n" +
+	"            # Repository is the path in the registry the image repositories are\n" +
+	"            # created under, e.g. the name of a quay.io organization.\n" +
+	"            repository: ' '\n" +
 	"          # Name is an optional image stream name to use that\n" +
 	"          # contains all component tags. If specified, tag is\n" +
 	"          # ignored.\n" +
@@ -305,6 +417,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          # Namespace identifies the namespace to which the built\n" +
 	"          # artifacts will be published to.\n" +
 	"          namespace: ' '\n" +
+	"          # PausedUntil is an RFC3339 timestamp until which the promotion\n" +
+	"          # to this target is skipped, e.g. during a release freeze. The\n" +
+	"          # target is promoted to again once the time has passed.\n" +
+	"          paused_until: ' '\n" +
 	"          # Tag is the ImageStreamTag tagged in for each\n" +
 	"          # build image's ImageStream.\n" +
 	"          tag: ' '\n" +
@@ -317,6 +433,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# included in the final pipeline.\n" +
 	"raw_steps:\n" +
 	"    - bundle_source_step:\n" +
+	"        # BuildBackend is the backend of the build root the bundle source is built on.\n" +
+	"        build_backend: ' '\n" +
 	"        # Substitutions contains pullspecs that need to be replaced by images\n" +
 	"        # in the CI cluster for operator bundle images\n" +
 	"        substitutions:\n" +
@@ -327,6 +445,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      index_generator_step:\n" +
 	"        # BaseIndex is the index image to add the bundle(s) to. If unset, a new index is created\n" +
 	"        base_index: ' '\n" +
+	"        # BuildBackend is the backend of the build root the index is built on.\n" +
+	"        build_backend: ' '\n" +
 	"        # OperatorIndex is a list of the names of the bundle images that the\n" +
 	"        # index will contain in its database.\n" +
 	"        operator_index:\n" +
@@ -370,6 +490,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            namespace: ' '\n" +
 	"            tag: ' '\n" +
 	"      pipeline_image_cache_step:\n" +
+	"        # BuildBackend is the backend of the build root the image is built on.\n" +
+	"        build_backend: ' '\n" +
 	"        # Commands are the shell commands to run in\n" +
 	"        # the repository root to create the cached\n" +
 	"        # content.\n" +
@@ -386,6 +508,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"              # Value of the build arg.\n" +
 	"              value: ' '\n" +
+	"        # BuildBackend selects how the image is built: with the OpenShift Build\n" +
+	"        # API by default, or with `buildah` in unprivileged pods.\n" +
+	"        build_backend: ' '\n" +
 	"        # ContextDir is the directory in the project\n" +
 	"        # from which this build should be run.\n" +
 	"        context_dir: ' '\n" +
@@ -395,16 +520,30 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # DockerfilePath is the path to a Dockerfile in the\n" +
 	"        # project to run relative to the context_dir.\n" +
 	"        dockerfile_path: ' '\n" +
+	"        # Exclude lists glob patterns of the files in the context_dir not sent\n" +
+	"        # as the build context, with the same syntax as Include.\n" +
+	"        exclude:\n" +
+	"            - \"\"\n" +
+	"        # Include lists glob patterns of the files in the context_dir sent as\n" +
+	"        # the build context, all files are sent when unset. Patterns use shell\n" +
+	"        # syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched\n" +
+	"        # against paths relative to the context_dir; the files under a matching\n" +
+	"        # directory match as well. The Dockerfile is always sent.\n" +
+	"        include:\n" +
+	"            - \"\"\n" +
 	"        # Inputs is a map of tag reference name to image input changes\n" +
 	"        # that will populate the build context for the Dockerfile or\n" +
-	"        # alter the input image for a multi-stage build.\n" +
+	"        # alter the input image for a multi-stage build. Every input\n" +
+	"        # can be referenced as `pipeline:<name>` in the Dockerfile, e.g.\n" +
+	"        # in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.\n" +
 	"        inputs:\n" +
 	"            \"\":\n" +
 	"                # As is a list of multi-stage step names or image names that will\n" +
 	"                # be replaced by the image reference from this step. For instance,\n" +
 	"                # if the Dockerfile defines FROM nginx:latest AS base, specifying\n" +
 	"                # either \"nginx:latest\" or \"base\" in this array will replace that\n" +
-	"                # image with the pipeline input.\n" +
+	"                # image with the pipeline input. The `pipeline:<name>` form of\n" +
+	"                # the input does not need to be listed.\n" +
 	"                as:\n" +
 	"                    - \"\"\n" +
 	"                # Paths is a list of paths to copy out of this image and into the\n" +
@@ -421,6 +560,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # AdditionalArchitectures is a list of additional architectures to build for. AMD64 architecture is included by default.\n" +
 	"        additional_architectures:\n" +
 	"            - \"\"\n" +
+	"        # Annotations configure how the CI tooling treats the build, they are\n" +
+	"        # not set on the image. Only ci.openshift.io/resource-ceiling-exemption\n" +
+	"        # is supported, to exempt the build from the resource ceilings of the\n" +
+	"        # organization.\n" +
+	"        annotations:\n" +
+	"            \"\": \"\"\n" +
 	"        # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
 	"        # See https://docs.docker.com/engine/reference/builder/#/arg for more details.\n" +
 	"        build_args:\n" +
@@ -428,6 +573,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"              # Value of the build arg.\n" +
 	"              value: ' '\n" +
+	"        # BuildBackend selects how the image is built: with the OpenShift Build\n" +
+	"        # API by default, or with `buildah` in unprivileged pods.\n" +
+	"        build_backend: ' '\n" +
 	"        # ContextDir is the directory in the project\n" +
 	"        # from which this build should be run.\n" +
 	"        context_dir: ' '\n" +
@@ -437,17 +585,31 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # DockerfilePath is the path to a Dockerfile in the\n" +
 	"        # project to run relative to the context_dir.\n" +
 	"        dockerfile_path: ' '\n" +
+	"        # Exclude lists glob patterns of the files in the context_dir not sent\n" +
+	"        # as the build context, with the same syntax as Include.\n" +
+	"        exclude:\n" +
+	"            - \"\"\n" +
 	"        from: ' '\n" +
+	"        # Include lists glob patterns of the files in the context_dir sent as\n" +
+	"        # the build context, all files are sent when unset. Patterns use shell\n" +
+	"        # syntax (`*`, `?`, `[...]`, where `*` also matches `/`) and are matched\n" +
+	"        # against paths relative to the context_dir; the files under a matching\n" +
+	"        # directory match as well. The Dockerfile is always sent.\n" +
+	"        include:\n" +
+	"            - \"\"\n" +
 	"        # Inputs is a map of tag reference name to image input changes\n" +
 	"        # that will populate the build context for the Dockerfile or\n" +
-	"        # alter the input image for a multi-stage build.\n" +
+	"        # alter the input image for a multi-stage build. Every input\n" +
+	"        # can be referenced as `pipeline:<name>` in the Dockerfile, e.g.\n" +
+	"        # in `FROM pipeline:bin AS builder` or `COPY --from=pipeline:bin`.\n" +
 	"        inputs:\n" +
 	"            \"\":\n" +
 	"                # As is a list of multi-stage step names or image names that will\n" +
 	"                # be replaced by the image reference from this step. For instance,\n" +
 	"                # if the Dockerfile defines FROM nginx:latest AS base, specifying\n" +
 	"                # either \"nginx:latest\" or \"base\" in this array will replace that\n" +
-	"                # image with the pipeline input.\n" +
+	"                # image with the pipeline input. The `pipeline:<name>` form of\n" +
+	"                # the input does not need to be listed.\n" +
 	"                as:\n" +
 	"                    - \"\"\n" +
 	"                # Paths is a list of paths to copy out of this image and into the\n" +
@@ -519,6 +681,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # Version is the minor version to search for\n" +
 	"            version: ' '\n" +
 	"      rpm_image_injection_step:\n" +
+	"        # BuildBackend is the backend of the build root the image is built with.\n" +
+	"        build_backend: ' '\n" +
 	"        from: ' '\n" +
 	"        to: ' '\n" +
 	"      rpm_serve_step:\n" +
@@ -526,6 +690,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # Ref is an optional string linking to the extra_ref in \"org.repo\" format that this belongs to\n" +
 	"        ref: ' '\n" +
 	"      source_step:\n" +
+	"        # BuildBackend is the backend of the build root the source is built on.\n" +
+	"        build_backend: ' '\n" +
 	"        # ClonerefsImage is the image where we get the clonerefs tool\n" +
 	"        clonerefs_image:\n" +
 	"            # As is an optional string to use as the intermediate name for this reference.\n" +
@@ -541,8 +707,22 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        ref: ' '\n" +
 	"        to: ' '\n" +
 	"      test_step:\n" +
-	"        # AlwaysRun can be set to false to disable running the job on every PR\n" +
+	"        # AllowedWindows restricts the times at which the test may start. When set,\n" +
+	"        # ci-operator skips the test if its job started outside all of the windows.\n" +
+	"        # Periodic tests must be scheduled with `cron` inside one of the windows.\n" +
+	"        allowed_windows:\n" +
+	"            - # Duration is how long the window stays open.\n" +
+	"              duration: 0s\n" +
+	"              # Start is a cron expression for the opening of the window, in UTC.\n" +
+	"              start: ' '\n" +
+	"        # AlwaysRun can be set to false to disable running the job on every PR,\n" +
+	"        # so that it only runs when requested. It only applies to presubmits and\n" +
+	"        # cannot be set to true along with conditional triggers.\n" +
 	"        always_run: false\n" +
+	"        # Annotations are added to the generated Prow job and to the pods created for the test.\n" +
+	"        # Reserved keys and keys using a reserved prefix are not allowed.\n" +
+	"        annotations:\n" +
+	"            \"\": \"\"\n" +
 	"        # As is the name of the test.\n" +
 	"        as: ' '\n" +
 	"        # Capabilities is the list of strings that\n" +
@@ -574,6 +754,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            timeout: 0s\n" +
 	"            # Version is the version of the product\n" +
 	"            version: ' '\n" +
+	"        # ClusterGroup is the name of a group of multi-stage tests that share a single\n" +
+	"        # cluster within a job. Running the group as a target provisions the cluster once\n" +
+	"        # with the `pre` steps of the first test in the group, runs the `test` steps of all\n" +
+	"        # the tests in the group sequentially and tears the cluster down once with the\n" +
+	"        # `post` steps of the first test.\n" +
+	"        cluster_group: ' '\n" +
 	"        # Commands are the shell commands to run in\n" +
 	"        # the repository root to execute tests.\n" +
 	"        commands: ' '\n" +
@@ -591,14 +777,62 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # Size is the requested size of the volume as a Kubernetes\n" +
 	"                # quantity, i.e. \"1Gi\" or \"500M\"\n" +
 	"                size: ' '\n" +
+	"            # Services are run next to the test for its whole duration, e.g. a\n" +
+	"            # database or an image registry the test needs.\n" +
+	"            services:\n" +
+	"                - # As is the name of the service, used as the name of its container.\n" +
+	"                  # Its resources are configured for `<test>-<as>`.\n" +
+	"                  as: ' '\n" +
+	"                  # Commands is the script the service runs. The entrypoint of the image\n" +
+	"                  # is used when it is not set.\n" +
+	"                  commands: ' '\n" +
+	"                  # Env are the environment variables set for the service.\n" +
+	"                  env:\n" +
+	"                    \"\": \"\"\n" +
+	"                  # From is the image stream tag in the pipeline to run the service from.\n" +
+	"                  from: ' '\n" +
+	"                  # Ports are the ports the service listens on.\n" +
+	"                  ports:\n" +
+	"                    - 0\n" +
+	"                  # ReadinessProbe determines when the service is ready for the test to\n" +
+	"                  # start. Services without one are ready as soon as they are started.\n" +
+	"                  readiness_probe:\n" +
+	"                    # Command is run in the container of the service, which is ready once\n" +
+	"                    # it exits with 0.\n" +
+	"                    command:\n" +
+	"                        - \"\"\n" +
+	"                    # HTTPGet is a request the service is ready once it answers successfully.\n" +
+	"                    http_get:\n" +
+	"                        # Path is the path of the request, defaults to /.\n" +
+	"                        path: ' '\n" +
+	"                        # Port is the port to send the request to.\n" +
+	"                        port: 0\n" +
+	"                    # Timeout is how long the service has to become ready, defaults to 5m.\n" +
+	"                    timeout: 0s\n" +
 	"        # Cron is how often the test is expected to run outside\n" +
 	"        # of pull request workflows. Setting this field will\n" +
 	"        # create a periodic job instead of a presubmit\n" +
 	"        cron: \"\"\n" +
+	"        # ExpectedDuration is how long the test is expected to run for. It is not\n" +
+	"        # enforced during the run: ci-operator records the actual duration next to\n" +
+	"        # it in its JUnit results, so tests chronically running over it can be\n" +
+	"        # reported.\n" +
+	"        expected_duration: 0s\n" +
 	"        # Interval is how frequently the test should be run based\n" +
 	"        # on the last time the test ran. Setting this field will\n" +
 	"        # create a periodic job instead of a presubmit\n" +
 	"        interval: \"\"\n" +
+	"        # IsolateStableStreams gives the test its own copies of the stable image\n" +
+	"        # streams its steps use, so that steps retagging stable images cannot affect\n" +
+	"        # other tests running in the same namespace. The copy of a stream is named\n" +
+	"        # after the stream and the test, e.g. `stable-e2e`, its suffix is exposed to\n" +
+	"        # the steps in ${STABLE_STREAM_SUFFIX}. The copies are deleted once the test\n" +
+	"        # finishes. Only multi-stage tests can isolate stable streams.\n" +
+	"        isolate_stable_streams: true\n" +
+	"        # Labels are added to the generated Prow job and to the pods created for the test.\n" +
+	"        # Reserved keys and keys using a reserved prefix are not allowed.\n" +
+	"        labels:\n" +
+	"            \"\": \"\"\n" +
 	"        literal_steps:\n" +
 	"            # AllowBestEffortPostSteps defines if any `post` steps can be ignored when\n" +
 	"            # they fail. The given step must explicitly ask for being ignored by setting\n" +
@@ -608,6 +842,17 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"            # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"            allow_skip_on_success: false\n" +
+	"            # Budgets limit how long the `pre`, `test` and `post` phases may take.\n" +
+	"            budgets:\n" +
+	"                # Post is the budget of the `post` phase.\n" +
+	"                post: 0s\n" +
+	"                # Pre is the budget of the `pre` phase.\n" +
+	"                pre: 0s\n" +
+	"                # Test is the budget of the `test` phase.\n" +
+	"                test: 0s\n" +
+	"            # ClientRelease is the name of the release from which the `oc` binary is\n" +
+	"            # injected into all steps that use one, for skew testing.\n" +
+	"            client_release: ' '\n" +
 	"            # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"            cluster_profile: ' '\n" +
 	"            # Dependencies holds override values for dependency parameters.\n" +
@@ -628,12 +873,179 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # Environment has the values of parameters for the steps.\n" +
 	"            env:\n" +
 	"                \"\": \"\"\n" +
+	"            # Gather is the array of test steps run after the tests finish to collect\n" +
+	"            # diagnostics, before `post`. Gather steps always run, even if previous\n" +
+	"            # steps fail, and their failures never fail the test.\n" +
+	"            gather:\n" +
+	"                - # As is the name of the LiteralTestStep.\n" +
+	"                  as: ' '\n" +
+	"                  # BestEffort defines if this step should cause the job to fail when the\n" +
+	"                  # step fails. This only applies when AllowBestEffortPostSteps flag is set\n" +
+	"                  # to true in MultiStageTestConfiguration. This option is applicable to\n" +
+	"                  # `post` steps.\n" +
+	"                  best_effort: false\n" +
+	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                  # will be injected into this step.\n" +
+	"                  cli: ' '\n" +
+	"                  # Commands is the command(s) that will be run inside the image.\n" +
+	"                  commands: ' '\n" +
+	"                  # Credentials defines the credentials we'll mount into this step.\n" +
+	"                  credentials:\n" +
+	"                    - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                      # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                      # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                      cloud:\n" +
+	"                        # Project is the GCP project holding the secret.\n" +
+	"                        project: ' '\n" +
+	"                        # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                        provider: ' '\n" +
+	"                        # Region is the AWS region holding the secret.\n" +
+	"                        region: ' '\n" +
+	"                        # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                        version: ' '\n" +
+	"                      # MountPath is where the secret should be mounted.\n" +
+	"                      mount_path: ' '\n" +
+	"                      # Names is which source secret to mount.\n" +
+	"                      name: ' '\n" +
+	"                      # Namespace is where the source secret exists.\n" +
+	"                      namespace: ' '\n" +
+	"                  # Dependencies lists images which must be available before the test runs\n" +
+	"                  # and the environment variables which are used to expose their pull specs.\n" +
+	"                  dependencies:\n" +
+	"                    - # Env is the environment variable that the image's pull spec is exposed with\n" +
+	"                      env: ' '\n" +
+	"                      # Name is the tag or stream:tag that this dependency references\n" +
+	"                      name: ' '\n" +
+	"                  # DnsConfig for step's Pod.\n" +
+	"                  dnsConfig:\n" +
+	"                    # Nameservers is a list of IP addresses that will be used as DNS servers for the Pod\n" +
+	"                    nameservers:\n" +
+	"                        - \"\"\n" +
+	"                    # Searches is a list of DNS search domains for host-name lookup\n" +
+	"                    searches:\n" +
+	"                        - \"\"\n" +
+	"                  # Environment lists parameters that should be set by the test.\n" +
+	"                  env:\n" +
+	"                    - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                      default: \"\"\n" +
+	"                      # Documentation is a textual description of the parameter.\n" +
+	"                      documentation: ' '\n" +
+	"                      # Name of the environment variable.\n" +
+	"                      name: ' '\n" +
+	"                  # From is the container image that will be used for this step.\n" +
+	"                  from: ' '\n" +
+	"                  # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
+	"                  from_image:\n" +
+	"                    # As is an optional string to use as the intermediate name for this reference.\n" +
+	"                    as: ' '\n" +
+	"                    name: ' '\n" +
+	"                    namespace: ' '\n" +
+	"                    tag: ' '\n" +
+	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
+	"                  # SIGKILL when aborting a Step.\n" +
+	"                  grace_period: 0s\n" +
+	"                  # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"                  # under test. The image and commands of the step are generated and must\n" +
+	"                  # not be set.\n" +
+	"                  health_check:\n" +
+	"                    checks:\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  # InstallConfig makes this a typed step composing the install-config of\n" +
+	"                  # the cluster under test. The image and commands of the step are\n" +
+	"                  # generated and must not be set.\n" +
+	"                  install_config:\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        - \"\"\n" +
+	"                  # Leases lists resources that should be acquired for the test.\n" +
+	"                  leases:\n" +
+	"                    - # Env is the environment variable that will contain the resource name.\n" +
+	"                      env: ' '\n" +
+	"                      # ResourceType is the type of resource that will be leased.\n" +
+	"                      resource_type: ' '\n" +
+	"                  # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"                  # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"                  # whole output.\n" +
+	"                  log_limit:\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"                  # can build and run containers. The Pod for this step runs in a user\n" +
+	"                  # namespace with the devices podman needs. Tests using such steps must\n" +
+	"                  # declare the `nested-podman` capability, which schedules them on the\n" +
+	"                  # clusters that support it.\n" +
+	"                  nested_podman: false\n" +
+	"                  # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
+	"                  # so no local copy of it will be created for the step and if the step\n" +
+	"                  # creates one, it will not be propagated.\n" +
+	"                  no_kubeconfig: false\n" +
+	"                  # NodeArchitecture is the architecture for the node where the test will run.\n" +
+	"                  # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
+	"                  node_architecture: \"\"\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    \"\": \"\"\n" +
+	"                  # Observers are the observers that should be running\n" +
+	"                  observers:\n" +
+	"                    - \"\"\n" +
+	"                  # OptionalOnSuccess defines if this step should be skipped as long\n" +
+	"                  # as all `pre` and `test` steps were successful and AllowSkipOnSuccess\n" +
+	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
+	"                  # applicable to `post` steps.\n" +
+	"                  optional_on_success: false\n" +
+	"                  # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"                  # used to pull the image for this step. It is copied into the test\n" +
+	"                  # namespace and only linked to the Pod for this step.\n" +
+	"                  pull_secret: ' '\n" +
+	"                  # Resources defines the resource requirements for the step.\n" +
+	"                  resources:\n" +
+	"                    # Limits are resource limits applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    limits:\n" +
+	"                        \"\": \"\"\n" +
+	"                    # Requests are resource requests applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    requests:\n" +
+	"                        \"\": \"\"\n" +
+	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
+	"                  # in the test container instead of being executed directly via bash\n" +
+	"                  run_as_script: false\n" +
+	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                  timeout: 0s\n" +
+	"                  # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"                  # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"                  tolerations:\n" +
+	"                    - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                      effect: ' '\n" +
+	"                      # Key is the taint key the toleration applies to.\n" +
+	"                      key: ' '\n" +
+	"                      # Value is the taint value the toleration matches. If empty, any\n" +
+	"                      # taint with the key is tolerated.\n" +
+	"                      value: ' '\n" +
+	"                  # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"                  # image and commands of the step are generated and must not be set.\n" +
+	"                  upgrade:\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                    timeout: 0s\n" +
+	"            # GatherTimeout is how long the whole `gather` phase may take. Gather steps\n" +
+	"            # still running when it expires are stopped and `post` steps start.\n" +
+	"            gather_timeout: 0s\n" +
 	"            # Leases lists resources that should be acquired for the test.\n" +
 	"            leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
 	"                  env: ' '\n" +
 	"                  # ResourceType is the type of resource that will be leased.\n" +
 	"                  resource_type: ' '\n" +
+	"            # NetworkStack is the IP stack of the network of the cluster under test:\n" +
+	"            # `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the\n" +
+	"            # platform of the cluster profile and is exposed to all steps as\n" +
+	"            # $NETWORK_STACK.\n" +
+	"            network_stack: ' '\n" +
 	"            # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"            # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"            node_architecture: \"\"\n" +
@@ -692,7 +1104,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
-	"                    - # MountPath is where the secret should be mounted.\n" +
+	"                    - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                      # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                      # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                      cloud:\n" +
+	"                        # Project is the GCP project holding the secret.\n" +
+	"                        project: ' '\n" +
+	"                        # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                        provider: ' '\n" +
+	"                        # Region is the AWS region holding the secret.\n" +
+	"                        region: ' '\n" +
+	"                        # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                        version: ' '\n" +
+	"                      # MountPath is where the secret should be mounted.\n" +
 	"                      mount_path: ' '\n" +
 	"                      # Names is which source secret to mount.\n" +
 	"                      name: ' '\n" +
@@ -733,12 +1157,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"                  # under test. The image and commands of the step are generated and must\n" +
+	"                  # not be set.\n" +
+	"                  health_check:\n" +
+	"                    checks:\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  # InstallConfig makes this a typed step composing the install-config of\n" +
+	"                  # the cluster under test. The image and commands of the step are\n" +
+	"                  # generated and must not be set.\n" +
+	"                  install_config:\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        - \"\"\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
 	"                      env: ' '\n" +
 	"                      # ResourceType is the type of resource that will be leased.\n" +
 	"                      resource_type: ' '\n" +
+	"                  # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"                  # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"                  # whole output.\n" +
+	"                  log_limit:\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"                  # can build and run containers. The Pod for this step runs in a user\n" +
+	"                  # namespace with the devices podman needs. Tests using such steps must\n" +
+	"                  # declare the `nested-podman` capability, which schedules them on the\n" +
+	"                  # clusters that support it.\n" +
+	"                  nested_podman: false\n" +
 	"                  # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"                  # so no local copy of it will be created for the step and if the step\n" +
 	"                  # creates one, it will not be propagated.\n" +
@@ -746,6 +1196,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"                  # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"                  node_architecture: \"\"\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    \"\": \"\"\n" +
 	"                  # Observers are the observers that should be running\n" +
 	"                  observers:\n" +
 	"                    - \"\"\n" +
@@ -754,6 +1208,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"                  # used to pull the image for this step. It is copied into the test\n" +
+	"                  # namespace and only linked to the Pod for this step.\n" +
+	"                  pull_secret: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -769,6 +1227,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"                  # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"                  tolerations:\n" +
+	"                    - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                      effect: ' '\n" +
+	"                      # Key is the taint key the toleration applies to.\n" +
+	"                      key: ' '\n" +
+	"                      # Value is the taint value the toleration matches. If empty, any\n" +
+	"                      # taint with the key is tolerated.\n" +
+	"                      value: ' '\n" +
+	"                  # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"                  # image and commands of the step are generated and must not be set.\n" +
+	"                  upgrade:\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                    timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -785,7 +1261,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
-	"                    - # MountPath is where the secret should be mounted.\n" +
+	"                    - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                      # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                      # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                      cloud:\n" +
+	"                        # Project is the GCP project holding the secret.\n" +
+	"                        project: ' '\n" +
+	"                        # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                        provider: ' '\n" +
+	"                        # Region is the AWS region holding the secret.\n" +
+	"                        region: ' '\n" +
+	"                        # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                        version: ' '\n" +
+	"                      # MountPath is where the secret should be mounted.\n" +
 	"                      mount_path: ' '\n" +
 	"                      # Names is which source secret to mount.\n" +
 	"                      name: ' '\n" +
@@ -826,12 +1314,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"                  # under test. The image and commands of the step are generated and must\n" +
+	"                  # not be set.\n" +
+	"                  health_check:\n" +
+	"                    checks:\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  # InstallConfig makes this a typed step composing the install-config of\n" +
+	"                  # the cluster under test. The image and commands of the step are\n" +
+	"                  # generated and must not be set.\n" +
+	"                  install_config:\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        - \"\"\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
 	"                      env: ' '\n" +
 	"                      # ResourceType is the type of resource that will be leased.\n" +
 	"                      resource_type: ' '\n" +
+	"                  # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"                  # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"                  # whole output.\n" +
+	"                  log_limit:\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"                  # can build and run containers. The Pod for this step runs in a user\n" +
+	"                  # namespace with the devices podman needs. Tests using such steps must\n" +
+	"                  # declare the `nested-podman` capability, which schedules them on the\n" +
+	"                  # clusters that support it.\n" +
+	"                  nested_podman: false\n" +
 	"                  # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"                  # so no local copy of it will be created for the step and if the step\n" +
 	"                  # creates one, it will not be propagated.\n" +
@@ -839,6 +1353,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"                  # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"                  node_architecture: \"\"\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    \"\": \"\"\n" +
 	"                  # Observers are the observers that should be running\n" +
 	"                  observers:\n" +
 	"                    - \"\"\n" +
@@ -847,6 +1365,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"                  # used to pull the image for this step. It is copied into the test\n" +
+	"                  # namespace and only linked to the Pod for this step.\n" +
+	"                  pull_secret: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -862,6 +1384,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"                  # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"                  tolerations:\n" +
+	"                    - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                      effect: ' '\n" +
+	"                      # Key is the taint key the toleration applies to.\n" +
+	"                      key: ' '\n" +
+	"                      # Value is the taint value the toleration matches. If empty, any\n" +
+	"                      # taint with the key is tolerated.\n" +
+	"                      value: ' '\n" +
+	"                  # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"                  # image and commands of the step are generated and must not be set.\n" +
+	"                  upgrade:\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                    timeout: 0s\n" +
+	"            # ServerRelease is the name of the release that replaces the `latest`\n" +
+	"            # release payload in the dependencies of all steps, for skew testing.\n" +
+	"            server_release: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -878,7 +1421,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  # Credentials defines the credentials we'll mount into this step.\n" +
 	"                  credentials:\n" +
-	"                    - # MountPath is where the secret should be mounted.\n" +
+	"                    - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                      # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                      # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                      cloud:\n" +
+	"                        # Project is the GCP project holding the secret.\n" +
+	"                        project: ' '\n" +
+	"                        # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                        provider: ' '\n" +
+	"                        # Region is the AWS region holding the secret.\n" +
+	"                        region: ' '\n" +
+	"                        # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                        version: ' '\n" +
+	"                      # MountPath is where the secret should be mounted.\n" +
 	"                      mount_path: ' '\n" +
 	"                      # Names is which source secret to mount.\n" +
 	"                      name: ' '\n" +
@@ -919,12 +1474,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"                  # under test. The image and commands of the step are generated and must\n" +
+	"                  # not be set.\n" +
+	"                  health_check:\n" +
+	"                    checks:\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  # InstallConfig makes this a typed step composing the install-config of\n" +
+	"                  # the cluster under test. The image and commands of the step are\n" +
+	"                  # generated and must not be set.\n" +
+	"                  install_config:\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        - \"\"\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
 	"                      env: ' '\n" +
 	"                      # ResourceType is the type of resource that will be leased.\n" +
 	"                      resource_type: ' '\n" +
+	"                  # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"                  # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"                  # whole output.\n" +
+	"                  log_limit:\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"                  # can build and run containers. The Pod for this step runs in a user\n" +
+	"                  # namespace with the devices podman needs. Tests using such steps must\n" +
+	"                  # declare the `nested-podman` capability, which schedules them on the\n" +
+	"                  # clusters that support it.\n" +
+	"                  nested_podman: false\n" +
 	"                  # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"                  # so no local copy of it will be created for the step and if the step\n" +
 	"                  # creates one, it will not be propagated.\n" +
@@ -932,6 +1513,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"                  # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"                  node_architecture: \"\"\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    \"\": \"\"\n" +
 	"                  # Observers are the observers that should be running\n" +
 	"                  observers:\n" +
 	"                    - \"\"\n" +
@@ -940,6 +1525,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"                  # used to pull the image for this step. It is copied into the test\n" +
+	"                  # namespace and only linked to the Pod for this step.\n" +
+	"                  pull_secret: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -955,6 +1544,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"                  # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"                  tolerations:\n" +
+	"                    - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                      effect: ' '\n" +
+	"                      # Key is the taint key the toleration applies to.\n" +
+	"                      key: ' '\n" +
+	"                      # Value is the taint value the toleration matches. If empty, any\n" +
+	"                      # taint with the key is tolerated.\n" +
+	"                      value: ' '\n" +
+	"                  # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"                  # image and commands of the step are generated and must not be set.\n" +
+	"                  upgrade:\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                    timeout: 0s\n" +
 	"            # Override job timeout\n" +
 	"            timeout: 0s\n" +
 	"        # MinimumInterval to wait between two runs of the job. Consecutive\n" +
@@ -965,6 +1572,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"        # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"        node_architecture: ' '\n" +
+	"        # NodeSelector constrains the nodes the pods created for the test can be scheduled on.\n" +
+	"        # Only labels in SchedulingAllowlist can be used.\n" +
+	"        node_selector:\n" +
+	"            \"\": \"\"\n" +
 	"        openshift_ansible:\n" +
 	"            cluster_profile: ' '\n" +
 	"        openshift_ansible_custom:\n" +
@@ -1010,6 +1621,16 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        retry:\n" +
 	"            interval: ' '\n" +
 	"            run_all: true\n" +
+	"        # ReuseResults lets a presubmit test report the result of its last\n" +
+	"        # successful run on the pull request instead of running again when a new\n" +
+	"        # push does not change any of its inputs. ci-operator only reuses results\n" +
+	"        # when it is configured with a namespace to record them in.\n" +
+	"        reuse_results:\n" +
+	"            # SourcePaths are regular expressions matching the files the test depends\n" +
+	"            # on. A push changing only other files reuses the earlier result. All\n" +
+	"            # files are considered when unset.\n" +
+	"            source_paths:\n" +
+	"                - \"\"\n" +
 	"        # RunIfChanged is a regex that will result in the test only running if something that matches it was changed.\n" +
 	"        run_if_changed: ' '\n" +
 	"        # Secret is an optional secret object which\n" +
@@ -1034,6 +1655,18 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"        # SkipIfOnlyChanged is a regex that will result in the test being skipped if all changed files match that regex.\n" +
 	"        skip_if_only_changed: ' '\n" +
+	"        # Soak configures the test as a long-running soak test, which may run for\n" +
+	"        # days and reports its progress in periodic JUnit checkpoints.\n" +
+	"        soak:\n" +
+	"            # CheckpointInterval is how often the progress of the test is reported\n" +
+	"            # in a JUnit checkpoint. Defaults to one hour.\n" +
+	"            checkpoint_interval: 0s\n" +
+	"            # MaxDurationDays is the maximum number of days the test can run for.\n" +
+	"            max_duration_days: 0\n" +
+	"        # Spread, if set, prefers scheduling the pods created for the test away from the\n" +
+	"        # pods of concurrently running instances of the same test, e.g. shards of an\n" +
+	"        # aggregated job. Can be `node` or `zone`.\n" +
+	"        spread: ' '\n" +
 	"        steps:\n" +
 	"            # AllowBestEffortPostSteps defines if any `post` steps can be ignored when\n" +
 	"            # they fail. The given step must explicitly ask for being ignored by setting\n" +
@@ -1043,6 +1676,17 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"            # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"            allow_skip_on_success: false\n" +
+	"            # Budgets limit how long the `pre`, `test` and `post` phases may take.\n" +
+	"            budgets:\n" +
+	"                # Post is the budget of the `post` phase.\n" +
+	"                post: 0s\n" +
+	"                # Pre is the budget of the `pre` phase.\n" +
+	"                pre: 0s\n" +
+	"                # Test is the budget of the `test` phase.\n" +
+	"                test: 0s\n" +
+	"            # ClientRelease is the name of the release from which the `oc` binary is\n" +
+	"            # injected into all steps that use one, for skew testing.\n" +
+	"            client_release: ' '\n" +
 	"            # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"            cluster_profile: ' '\n" +
 	"            # Dependencies holds override values for dependency parameters.\n" +
@@ -1063,12 +1707,128 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # Environment has the values of parameters for the steps.\n" +
 	"            env:\n" +
 	"                \"\": \"\"\n" +
+	"            # Gather is the array of test steps run after the tests finish to collect\n" +
+	"            # diagnostics, before `post`. Gather steps always run, even if previous\n" +
+	"            # steps fail, and their failures never fail the test.\n" +
+	"            gather:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - as: ' '\n" +
+	"                  best_effort: false\n" +
+	"                  # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
+	"                  chain: \"\"\n" +
+	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                  # will be injected into this step.\n" +
+	"                  cli: ' '\n" +
+	"                  commands: ' '\n" +
+	"                  credentials:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - cloud:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        project: ' '\n" +
+	"                        provider: ' '\n" +
+	"                        region: ' '\n" +
+	"                        version: ' '\n" +
+	"                      mount_path: ' '\n" +
+	"                      name: ' '\n" +
+	"                      namespace: ' '\n" +
+	"                  dependencies:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      name: ' '\n" +
+	"                  dnsConfig:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    nameservers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    searches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - default: \"\"\n" +
+	"                      documentation: ' '\n" +
+	"                      name: ' '\n" +
+	"                  from: ' '\n" +
+	"                  from_image:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    as: ' '\n" +
+	"                    name: ' '\n" +
+	"                    namespace: ' '\n" +
+	"                    tag: ' '\n" +
+	"                  grace_period: 0s\n" +
+	"                  health_check:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    checks:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  install_config:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  leases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      resource_type: ' '\n" +
+	"                  log_limit:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  nested_podman: false\n" +
+	"                  no_kubeconfig: false\n" +
+	"                  node_architecture: \"\"\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"                  observers:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  optional_on_success: false\n" +
+	"                  pull_secret: ' '\n" +
+	"                  # Reference is the name of a step reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
+	"                  ref: \"\"\n" +
+	"                  # Resources defines the resource requirements for the step.\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                  run_as_script: false\n" +
+	"                  timeout: 0s\n" +
+	"                  tolerations:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - effect: ' '\n" +
+	"                      key: ' '\n" +
+	"                      value: ' '\n" +
+	"                  upgrade:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    timeout: 0s\n" +
+	"            # GatherTimeout is how long the whole `gather` phase may take. Gather steps\n" +
+	"            # still running when it expires are stopped and `post` steps start.\n" +
+	"            gather_timeout: 0s\n" +
 	"            # Leases lists resources that should be acquired for the test.\n" +
 	"            leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
 	"                  env: ' '\n" +
 	"                  # ResourceType is the type of resource that will be leased.\n" +
 	"                  resource_type: ' '\n" +
+	"            # NetworkStack is the IP stack of the network of the cluster under test:\n" +
+	"            # `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the\n" +
+	"            # platform of the cluster profile and is exposed to all steps as\n" +
+	"            # $NETWORK_STACK.\n" +
+	"            network_stack: ' '\n" +
 	"            # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"            # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"            node_architecture: \"\"\n" +
@@ -1087,7 +1847,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1095,7 +1856,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - mount_path: ' '\n" +
+	"                    - cloud:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        project: ' '\n" +
+	"                        provider: ' '\n" +
+	"                        region: ' '\n" +
+	"                        version: ' '\n" +
+	"                      mount_path: ' '\n" +
 	"                      name: ' '\n" +
 	"                      namespace: ' '\n" +
 	"                  dependencies:\n" +
@@ -1123,18 +1890,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    namespace: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  health_check:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    checks:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  install_config:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
 	"                      resource_type: ' '\n" +
+	"                  log_limit:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  nested_podman: false\n" +
 	"                  no_kubeconfig: false\n" +
 	"                  node_architecture: \"\"\n" +
-	"                  # Observers are the observers that should be running\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
 	"                  observers:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  pull_secret: ' '\n" +
+	"                  # Reference is the name of a step reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1147,12 +1937,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  tolerations:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - effect: ' '\n" +
+	"                      key: ' '\n" +
+	"                      value: ' '\n" +
+	"                  upgrade:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1160,7 +1962,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - mount_path: ' '\n" +
+	"                    - cloud:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        project: ' '\n" +
+	"                        provider: ' '\n" +
+	"                        region: ' '\n" +
+	"                        version: ' '\n" +
+	"                      mount_path: ' '\n" +
 	"                      name: ' '\n" +
 	"                      namespace: ' '\n" +
 	"                  dependencies:\n" +
@@ -1188,18 +1996,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    namespace: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  health_check:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    checks:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  install_config:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
 	"                      resource_type: ' '\n" +
+	"                  log_limit:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  nested_podman: false\n" +
 	"                  no_kubeconfig: false\n" +
 	"                  node_architecture: \"\"\n" +
-	"                  # Observers are the observers that should be running\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
 	"                  observers:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  pull_secret: ' '\n" +
+	"                  # Reference is the name of a step reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1212,12 +2043,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  tolerations:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - effect: ' '\n" +
+	"                      key: ' '\n" +
+	"                      value: ' '\n" +
+	"                  upgrade:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    timeout: 0s\n" +
+	"            # ServerRelease is the name of the release that replaces the `latest`\n" +
+	"            # release payload in the dependencies of all steps, for skew testing.\n" +
+	"            server_release: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1225,7 +2071,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  commands: ' '\n" +
 	"                  credentials:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
-	"                    - mount_path: ' '\n" +
+	"                    - cloud:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        project: ' '\n" +
+	"                        provider: ' '\n" +
+	"                        region: ' '\n" +
+	"                        version: ' '\n" +
+	"                      mount_path: ' '\n" +
 	"                      name: ' '\n" +
 	"                      namespace: ' '\n" +
 	"                  dependencies:\n" +
@@ -1253,18 +2105,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    namespace: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  health_check:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    checks:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    wait: 0s\n" +
+	"                  install_config:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    base: ' '\n" +
+	"                    patches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
 	"                      resource_type: ' '\n" +
+	"                  log_limit:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    head: ' '\n" +
+	"                    tail: ' '\n" +
+	"                  nested_podman: false\n" +
 	"                  no_kubeconfig: false\n" +
 	"                  node_architecture: \"\"\n" +
-	"                  # Observers are the observers that should be running\n" +
+	"                  # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"                  # Only labels in SchedulingAllowlist can be used.\n" +
+	"                  node_selector:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
 	"                  observers:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  pull_secret: ' '\n" +
+	"                  # Reference is the name of a step reference. It can be pinned to a\n" +
+	"                  # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1277,11 +2152,34 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  tolerations:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - effect: ' '\n" +
+	"                      key: ' '\n" +
+	"                      value: ' '\n" +
+	"                  upgrade:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    channel: ' '\n" +
+	"                    force: ' '\n" +
+	"                    release: ' '\n" +
+	"                    timeout: 0s\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
+	"            # The workflow can be pinned to a registry snapshot by appending its version, e.g. `ipi-aws@v2025-01-15`,\n" +
+	"            # in which case the whole test is resolved from that snapshot.\n" +
 	"            workflow: \"\"\n" +
 	"        # Timeout overrides maximum prowjob duration\n" +
 	"        timeout: 0s\n" +
+	"        # Tolerations allow the pods created for the test to be scheduled on tainted nodes.\n" +
+	"        # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"        tolerations:\n" +
+	"            - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"              effect: ' '\n" +
+	"              # Key is the taint key the toleration applies to.\n" +
+	"              key: ' '\n" +
+	"              # Value is the taint value the toleration matches. If empty, any\n" +
+	"              # taint with the key is tolerated.\n" +
+	"              value: ' '\n" +
 	"# Releases maps semantic release payload identifiers\n" +
 	"# to the names that they will be exposed under. For\n" +
 	"# instance, an 'initial' name will be exposed as\n" +
@@ -1391,8 +2289,22 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# The images launched as pods but have no explicit access to\n" +
 	"# the cluster they are running on.\n" +
 	"tests:\n" +
-	"    - # AlwaysRun can be set to false to disable running the job on every PR\n" +
+	"    - # AllowedWindows restricts the times at which the test may start. When set,\n" +
+	"      # ci-operator skips the test if its job started outside all of the windows.\n" +
+	"      # Periodic tests must be scheduled with `cron` inside one of the windows.\n" +
+	"      allowed_windows:\n" +
+	"        - # Duration is how long the window stays open.\n" +
+	"          duration: 0s\n" +
+	"          # Start is a cron expression for the opening of the window, in UTC.\n" +
+	"          start: ' '\n" +
+	"      # AlwaysRun can be set to false to disable running the job on every PR,\n" +
+	"      # so that it only runs when requested. It only applies to presubmits and\n" +
+	"      # cannot be set to true along with conditional triggers.\n" +
 	"      always_run: false\n" +
+	"      # Annotations are added to the generated Prow job and to the pods created for the test.\n" +
+	"      # Reserved keys and keys using a reserved prefix are not allowed.\n" +
+	"      annotations:\n" +
+	"        \"\": \"\"\n" +
 	"      # As is the name of the test.\n" +
 	"      as: ' '\n" +
 	"      # Capabilities is the list of strings that\n" +
@@ -1424,6 +2336,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        timeout: 0s\n" +
 	"        # Version is the version of the product\n" +
 	"        version: ' '\n" +
+	"      # ClusterGroup is the name of a group of multi-stage tests that share a single\n" +
+	"      # cluster within a job. Running the group as a target provisions the cluster once\n" +
+	"      # with the `pre` steps of the first test in the group, runs the `test` steps of all\n" +
+	"      # the tests in the group sequentially and tears the cluster down once with the\n" +
+	"      # `post` steps of the first test.\n" +
+	"      cluster_group: ' '\n" +
 	"      # Commands are the shell commands to run in\n" +
 	"      # the repository root to execute tests.\n" +
 	"      commands: ' '\n" +
@@ -1441,14 +2359,62 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # Size is the requested size of the volume as a Kubernetes\n" +
 	"            # quantity, i.e. \"1Gi\" or \"500M\"\n" +
 	"            size: ' '\n" +
+	"        # Services are run next to the test for its whole duration, e.g. a\n" +
+	"        # database or an image registry the test needs.\n" +
+	"        services:\n" +
+	"            - # As is the name of the service, used as the name of its container.\n" +
+	"              # Its resources are configured for `<test>-<as>`.\n" +
+	"              as: ' '\n" +
+	"              # Commands is the script the service runs. The entrypoint of the image\n" +
+	"              # is used when it is not set.\n" +
+	"              commands: ' '\n" +
+	"              # Env are the environment variables set for the service.\n" +
+	"              env:\n" +
+	"                \"\": \"\"\n" +
+	"              # From is the image stream tag in the pipeline to run the service from.\n" +
+	"              from: ' '\n" +
+	"              # Ports are the ports the service listens on.\n" +
+	"              ports:\n" +
+	"                - 0\n" +
+	"              # ReadinessProbe determines when the service is ready for the test to\n" +
+	"              # start. Services without one are ready as soon as they are started.\n" +
+	"              readiness_probe:\n" +
+	"                # Command is run in the container of the service, which is ready once\n" +
+	"                # it exits with 0.\n" +
+	"                command:\n" +
+	"                    - \"\"\n" +
+	"                # HTTPGet is a request the service is ready once it answers successfully.\n" +
+	"                http_get:\n" +
+	"                    # Path is the path of the request, defaults to /.\n" +
+	"                    path: ' '\n" +
+	"                    # Port is the port to send the request to.\n" +
+	"                    port: 0\n" +
+	"                # Timeout is how long the service has to become ready, defaults to 5m.\n" +
+	"                timeout: 0s\n" +
 	"      # Cron is how often the test is expected to run outside\n" +
 	"      # of pull request workflows. Setting this field will\n" +
 	"      # create a periodic job instead of a presubmit\n" +
 	"      cron: \"\"\n" +
+	"      # ExpectedDuration is how long the test is expected to run for. It is not\n" +
+	"      # enforced during the run: ci-operator records the actual duration next to\n" +
+	"      # it in its JUnit results, so tests chronically running over it can be\n" +
+	"      # reported.\n" +
+	"      expected_duration: 0s\n" +
 	"      # Interval is how frequently the test should be run based\n" +
 	"      # on the last time the test ran. Setting this field will\n" +
 	"      # create a periodic job instead of a presubmit\n" +
 	"      interval: \"\"\n" +
+	"      # IsolateStableStreams gives the test its own copies of the stable image\n" +
+	"      # streams its steps use, so that steps retagging stable images cannot affect\n" +
+	"      # other tests running in the same namespace. The copy of a stream is named\n" +
+	"      # after the stream and the test, e.g. `stable-e2e`, its suffix is exposed to\n" +
+	"      # the steps in ${STABLE_STREAM_SUFFIX}. The copies are deleted once the test\n" +
+	"      # finishes. Only multi-stage tests can isolate stable streams.\n" +
+	"      isolate_stable_streams: true\n" +
+	"      # Labels are added to the generated Prow job and to the pods created for the test.\n" +
+	"      # Reserved keys and keys using a reserved prefix are not allowed.\n" +
+	"      labels:\n" +
+	"        \"\": \"\"\n" +
 	"      literal_steps:\n" +
 	"        # AllowBestEffortPostSteps defines if any `post` steps can be ignored when\n" +
 	"        # they fail. The given step must explicitly ask for being ignored by setting\n" +
@@ -1458,6 +2424,17 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"        # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"        allow_skip_on_success: false\n" +
+	"        # Budgets limit how long the `pre`, `test` and `post` phases may take.\n" +
+	"        budgets:\n" +
+	"            # Post is the budget of the `post` phase.\n" +
+	"            post: 0s\n" +
+	"            # Pre is the budget of the `pre` phase.\n" +
+	"            pre: 0s\n" +
+	"            # Test is the budget of the `test` phase.\n" +
+	"            test: 0s\n" +
+	"        # ClientRelease is the name of the release from which the `oc` binary is\n" +
+	"        # injected into all steps that use one, for skew testing.\n" +
+	"        client_release: ' '\n" +
 	"        # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"        cluster_profile: ' '\n" +
 	"        # Dependencies holds override values for dependency parameters.\n" +
@@ -1478,12 +2455,179 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # Environment has the values of parameters for the steps.\n" +
 	"        env:\n" +
 	"            \"\": \"\"\n" +
+	"        # Gather is the array of test steps run after the tests finish to collect\n" +
+	"        # diagnostics, before `post`. Gather steps always run, even if previous\n" +
+	"        # steps fail, and their failures never fail the test.\n" +
+	"        gather:\n" +
+	"            - # As is the name of the LiteralTestStep.\n" +
+	"              as: ' '\n" +
+	"              # BestEffort defines if this step should cause the job to fail when the\n" +
+	"              # step fails. This only applies when AllowBestEffortPostSteps flag is set\n" +
+	"              # to true in MultiStageTestConfiguration. This option is applicable to\n" +
+	"              # `post` steps.\n" +
+	"              best_effort: false\n" +
+	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"              # will be injected into this step.\n" +
+	"              cli: ' '\n" +
+	"              # Commands is the command(s) that will be run inside the image.\n" +
+	"              commands: ' '\n" +
+	"              # Credentials defines the credentials we'll mount into this step.\n" +
+	"              credentials:\n" +
+	"                - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                  # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                  # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                  cloud:\n" +
+	"                    # Project is the GCP project holding the secret.\n" +
+	"                    project: ' '\n" +
+	"                    # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                    provider: ' '\n" +
+	"                    # Region is the AWS region holding the secret.\n" +
+	"                    region: ' '\n" +
+	"                    # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                    version: ' '\n" +
+	"                  # MountPath is where the secret should be mounted.\n" +
+	"                  mount_path: ' '\n" +
+	"                  # Names is which source secret to mount.\n" +
+	"                  name: ' '\n" +
+	"                  # Namespace is where the source secret exists.\n" +
+	"                  namespace: ' '\n" +
+	"              # Dependencies lists images which must be available before the test runs\n" +
+	"              # and the environment variables which are used to expose their pull specs.\n" +
+	"              dependencies:\n" +
+	"                - # Env is the environment variable that the image's pull spec is exposed with\n" +
+	"                  env: ' '\n" +
+	"                  # Name is the tag or stream:tag that this dependency references\n" +
+	"                  name: ' '\n" +
+	"              # DnsConfig for step's Pod.\n" +
+	"              dnsConfig:\n" +
+	"                # Nameservers is a list of IP addresses that will be used as DNS servers for the Pod\n" +
+	"                nameservers:\n" +
+	"                    - \"\"\n" +
+	"                # Searches is a list of DNS search domains for host-name lookup\n" +
+	"                searches:\n" +
+	"                    - \"\"\n" +
+	"              # Environment lists parameters that should be set by the test.\n" +
+	"              env:\n" +
+	"                - # Default if not set, optional, makes the parameter not required if set.\n" +
+	"                  default: \"\"\n" +
+	"                  # Documentation is a textual description of the parameter.\n" +
+	"                  documentation: ' '\n" +
+	"                  # Name of the environment variable.\n" +
+	"                  name: ' '\n" +
+	"              # From is the container image that will be used for this step.\n" +
+	"              from: ' '\n" +
+	"              # FromImage is a literal ImageStreamTag reference to use for this step.\n" +
+	"              from_image:\n" +
+	"                # As is an optional string to use as the intermediate name for this reference.\n" +
+	"                as: ' '\n" +
+	"                name: ' '\n" +
+	"                namespace: ' '\n" +
+	"                tag: ' '\n" +
+	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
+	"              # SIGKILL when aborting a Step.\n" +
+	"              grace_period: 0s\n" +
+	"              # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"              # under test. The image and commands of the step are generated and must\n" +
+	"              # not be set.\n" +
+	"              health_check:\n" +
+	"                checks:\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              # InstallConfig makes this a typed step composing the install-config of\n" +
+	"              # the cluster under test. The image and commands of the step are\n" +
+	"              # generated and must not be set.\n" +
+	"              install_config:\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    - \"\"\n" +
+	"              # Leases lists resources that should be acquired for the test.\n" +
+	"              leases:\n" +
+	"                - # Env is the environment variable that will contain the resource name.\n" +
+	"                  env: ' '\n" +
+	"                  # ResourceType is the type of resource that will be leased.\n" +
+	"                  resource_type: ' '\n" +
+	"              # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"              # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"              # whole output.\n" +
+	"              log_limit:\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"              # can build and run containers. The Pod for this step runs in a user\n" +
+	"              # namespace with the devices podman needs. Tests using such steps must\n" +
+	"              # declare the `nested-podman` capability, which schedules them on the\n" +
+	"              # clusters that support it.\n" +
+	"              nested_podman: false\n" +
+	"              # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
+	"              # so no local copy of it will be created for the step and if the step\n" +
+	"              # creates one, it will not be propagated.\n" +
+	"              no_kubeconfig: false\n" +
+	"              # NodeArchitecture is the architecture for the node where the test will run.\n" +
+	"              # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
+	"              node_architecture: \"\"\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                \"\": \"\"\n" +
+	"              # Observers are the observers that should be running\n" +
+	"              observers:\n" +
+	"                - \"\"\n" +
+	"              # OptionalOnSuccess defines if this step should be skipped as long\n" +
+	"              # as all `pre` and `test` steps were successful and AllowSkipOnSuccess\n" +
+	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
+	"              # applicable to `post` steps.\n" +
+	"              optional_on_success: false\n" +
+	"              # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"              # used to pull the image for this step. It is copied into the test\n" +
+	"              # namespace and only linked to the Pod for this step.\n" +
+	"              pull_secret: ' '\n" +
+	"              # Resources defines the resource requirements for the step.\n" +
+	"              resources:\n" +
+	"                # Limits are resource limits applied to an individual step in the job.\n" +
+	"                # These are directly used in creating the Pods that execute the Job.\n" +
+	"                limits:\n" +
+	"                    \"\": \"\"\n" +
+	"                # Requests are resource requests applied to an individual step in the job.\n" +
+	"                # These are directly used in creating the Pods that execute the Job.\n" +
+	"                requests:\n" +
+	"                    \"\": \"\"\n" +
+	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
+	"              # in the test container instead of being executed directly via bash\n" +
+	"              run_as_script: false\n" +
+	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"              timeout: 0s\n" +
+	"              # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"              # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"              tolerations:\n" +
+	"                - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                  effect: ' '\n" +
+	"                  # Key is the taint key the toleration applies to.\n" +
+	"                  key: ' '\n" +
+	"                  # Value is the taint value the toleration matches. If empty, any\n" +
+	"                  # taint with the key is tolerated.\n" +
+	"                  value: ' '\n" +
+	"              # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"              # image and commands of the step are generated and must not be set.\n" +
+	"              upgrade:\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                timeout: 0s\n" +
+	"        # GatherTimeout is how long the whole `gather` phase may take. Gather steps\n" +
+	"        # still running when it expires are stopped and `post` steps start.\n" +
+	"        gather_timeout: 0s\n" +
 	"        # Leases lists resources that should be acquired for the test.\n" +
 	"        leases:\n" +
 	"            - # Env is the environment variable that will contain the resource name.\n" +
 	"              env: ' '\n" +
 	"              # ResourceType is the type of resource that will be leased.\n" +
 	"              resource_type: ' '\n" +
+	"        # NetworkStack is the IP stack of the network of the cluster under test:\n" +
+	"        # `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the\n" +
+	"        # platform of the cluster profile and is exposed to all steps as\n" +
+	"        # $NETWORK_STACK.\n" +
+	"        network_stack: ' '\n" +
 	"        # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"        # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"        node_architecture: \"\"\n" +
@@ -1542,7 +2686,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
-	"                - # MountPath is where the secret should be mounted.\n" +
+	"                - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                  # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                  # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                  cloud:\n" +
+	"                    # Project is the GCP project holding the secret.\n" +
+	"                    project: ' '\n" +
+	"                    # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                    provider: ' '\n" +
+	"                    # Region is the AWS region holding the secret.\n" +
+	"                    region: ' '\n" +
+	"                    # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                    version: ' '\n" +
+	"                  # MountPath is where the secret should be mounted.\n" +
 	"                  mount_path: ' '\n" +
 	"                  # Names is which source secret to mount.\n" +
 	"                  name: ' '\n" +
@@ -1583,12 +2739,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"              # under test. The image and commands of the step are generated and must\n" +
+	"              # not be set.\n" +
+	"              health_check:\n" +
+	"                checks:\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              # InstallConfig makes this a typed step composing the install-config of\n" +
+	"              # the cluster under test. The image and commands of the step are\n" +
+	"              # generated and must not be set.\n" +
+	"              install_config:\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    - \"\"\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
 	"                  env: ' '\n" +
 	"                  # ResourceType is the type of resource that will be leased.\n" +
 	"                  resource_type: ' '\n" +
+	"              # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"              # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"              # whole output.\n" +
+	"              log_limit:\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"              # can build and run containers. The Pod for this step runs in a user\n" +
+	"              # namespace with the devices podman needs. Tests using such steps must\n" +
+	"              # declare the `nested-podman` capability, which schedules them on the\n" +
+	"              # clusters that support it.\n" +
+	"              nested_podman: false\n" +
 	"              # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"              # so no local copy of it will be created for the step and if the step\n" +
 	"              # creates one, it will not be propagated.\n" +
@@ -1596,6 +2778,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"              # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"              node_architecture: \"\"\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                \"\": \"\"\n" +
 	"              # Observers are the observers that should be running\n" +
 	"              observers:\n" +
 	"                - \"\"\n" +
@@ -1604,6 +2790,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"              # used to pull the image for this step. It is copied into the test\n" +
+	"              # namespace and only linked to the Pod for this step.\n" +
+	"              pull_secret: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1619,6 +2809,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"              # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"              tolerations:\n" +
+	"                - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                  effect: ' '\n" +
+	"                  # Key is the taint key the toleration applies to.\n" +
+	"                  key: ' '\n" +
+	"                  # Value is the taint value the toleration matches. If empty, any\n" +
+	"                  # taint with the key is tolerated.\n" +
+	"                  value: ' '\n" +
+	"              # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"              # image and commands of the step are generated and must not be set.\n" +
+	"              upgrade:\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1635,7 +2843,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
-	"                - # MountPath is where the secret should be mounted.\n" +
+	"                - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                  # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                  # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                  cloud:\n" +
+	"                    # Project is the GCP project holding the secret.\n" +
+	"                    project: ' '\n" +
+	"                    # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                    provider: ' '\n" +
+	"                    # Region is the AWS region holding the secret.\n" +
+	"                    region: ' '\n" +
+	"                    # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                    version: ' '\n" +
+	"                  # MountPath is where the secret should be mounted.\n" +
 	"                  mount_path: ' '\n" +
 	"                  # Names is which source secret to mount.\n" +
 	"                  name: ' '\n" +
@@ -1676,12 +2896,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"              # under test. The image and commands of the step are generated and must\n" +
+	"              # not be set.\n" +
+	"              health_check:\n" +
+	"                checks:\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              # InstallConfig makes this a typed step composing the install-config of\n" +
+	"              # the cluster under test. The image and commands of the step are\n" +
+	"              # generated and must not be set.\n" +
+	"              install_config:\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    - \"\"\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
 	"                  env: ' '\n" +
 	"                  # ResourceType is the type of resource that will be leased.\n" +
 	"                  resource_type: ' '\n" +
+	"              # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"              # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"              # whole output.\n" +
+	"              log_limit:\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"              # can build and run containers. The Pod for this step runs in a user\n" +
+	"              # namespace with the devices podman needs. Tests using such steps must\n" +
+	"              # declare the `nested-podman` capability, which schedules them on the\n" +
+	"              # clusters that support it.\n" +
+	"              nested_podman: false\n" +
 	"              # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"              # so no local copy of it will be created for the step and if the step\n" +
 	"              # creates one, it will not be propagated.\n" +
@@ -1689,6 +2935,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"              # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"              node_architecture: \"\"\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                \"\": \"\"\n" +
 	"              # Observers are the observers that should be running\n" +
 	"              observers:\n" +
 	"                - \"\"\n" +
@@ -1697,6 +2947,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"              # used to pull the image for this step. It is copied into the test\n" +
+	"              # namespace and only linked to the Pod for this step.\n" +
+	"              pull_secret: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1712,6 +2966,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"              # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"              tolerations:\n" +
+	"                - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                  effect: ' '\n" +
+	"                  # Key is the taint key the toleration applies to.\n" +
+	"                  key: ' '\n" +
+	"                  # Value is the taint value the toleration matches. If empty, any\n" +
+	"                  # taint with the key is tolerated.\n" +
+	"                  value: ' '\n" +
+	"              # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"              # image and commands of the step are generated and must not be set.\n" +
+	"              upgrade:\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                timeout: 0s\n" +
+	"        # ServerRelease is the name of the release that replaces the `latest`\n" +
+	"        # release payload in the dependencies of all steps, for skew testing.\n" +
+	"        server_release: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1728,7 +3003,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              # Credentials defines the credentials we'll mount into this step.\n" +
 	"              credentials:\n" +
-	"                - # MountPath is where the secret should be mounted.\n" +
+	"                - # Cloud fetches the secret from a cloud secret manager when the step runs\n" +
+	"                  # instead of copying it from a secret in the cluster. When set, Name is the\n" +
+	"                  # name of the secret in the secret manager and Namespace must not be set.\n" +
+	"                  cloud:\n" +
+	"                    # Project is the GCP project holding the secret.\n" +
+	"                    project: ' '\n" +
+	"                    # Provider is the secret manager holding the secret, one of `gcp` or `aws`.\n" +
+	"                    provider: ' '\n" +
+	"                    # Region is the AWS region holding the secret.\n" +
+	"                    region: ' '\n" +
+	"                    # Version is the version of the secret to fetch, defaults to the current one.\n" +
+	"                    version: ' '\n" +
+	"                  # MountPath is where the secret should be mounted.\n" +
 	"                  mount_path: ' '\n" +
 	"                  # Names is which source secret to mount.\n" +
 	"                  name: ' '\n" +
@@ -1769,12 +3056,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HealthCheck makes this a typed step asserting the health of the cluster\n" +
+	"              # under test. The image and commands of the step are generated and must\n" +
+	"              # not be set.\n" +
+	"              health_check:\n" +
+	"                checks:\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              # InstallConfig makes this a typed step composing the install-config of\n" +
+	"              # the cluster under test. The image and commands of the step are\n" +
+	"              # generated and must not be set.\n" +
+	"              install_config:\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    - \"\"\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
 	"                  env: ' '\n" +
 	"                  # ResourceType is the type of resource that will be leased.\n" +
 	"                  resource_type: ' '\n" +
+	"              # LogLimit caps the size of the output of the step kept in its log,\n" +
+	"              # replacing the limit configured for all steps. An empty limit keeps the\n" +
+	"              # whole output.\n" +
+	"              log_limit:\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              # NestedPodman provides a rootless podman runtime to the step, so that it\n" +
+	"              # can build and run containers. The Pod for this step runs in a user\n" +
+	"              # namespace with the devices podman needs. Tests using such steps must\n" +
+	"              # declare the `nested-podman` capability, which schedules them on the\n" +
+	"              # clusters that support it.\n" +
+	"              nested_podman: false\n" +
 	"              # NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,\n" +
 	"              # so no local copy of it will be created for the step and if the step\n" +
 	"              # creates one, it will not be propagated.\n" +
@@ -1782,6 +3095,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"              # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"              node_architecture: \"\"\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                \"\": \"\"\n" +
 	"              # Observers are the observers that should be running\n" +
 	"              observers:\n" +
 	"                - \"\"\n" +
@@ -1790,6 +3107,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # PullSecret is the name of a secret in the test-credentials namespace\n" +
+	"              # used to pull the image for this step. It is copied into the test\n" +
+	"              # namespace and only linked to the Pod for this step.\n" +
+	"              pull_secret: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1805,6 +3126,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # Tolerations allow the Pod for this step to be scheduled on tainted nodes.\n" +
+	"              # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"              tolerations:\n" +
+	"                - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"                  effect: ' '\n" +
+	"                  # Key is the taint key the toleration applies to.\n" +
+	"                  key: ' '\n" +
+	"                  # Value is the taint value the toleration matches. If empty, any\n" +
+	"                  # taint with the key is tolerated.\n" +
+	"                  value: ' '\n" +
+	"              # Upgrade makes this a typed step upgrading the cluster under test. The\n" +
+	"              # image and commands of the step are generated and must not be set.\n" +
+	"              upgrade:\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
+	"                timeout: 0s\n" +
 	"        # Override job timeout\n" +
 	"        timeout: 0s\n" +
 	"      # MinimumInterval to wait between two runs of the job. Consecutive\n" +
@@ -1815,6 +3154,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"      # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"      node_architecture: ' '\n" +
+	"      # NodeSelector constrains the nodes the pods created for the test can be scheduled on.\n" +
+	"      # Only labels in SchedulingAllowlist can be used.\n" +
+	"      node_selector:\n" +
+	"        \"\": \"\"\n" +
 	"      openshift_ansible:\n" +
 	"        cluster_profile: ' '\n" +
 	"      openshift_ansible_custom:\n" +
@@ -1860,6 +3203,16 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      retry:\n" +
 	"        interval: ' '\n" +
 	"        run_all: true\n" +
+	"      # ReuseResults lets a presubmit test report the result of its last\n" +
+	"      # successful run on the pull request instead of running again when a new\n" +
+	"      # push does not change any of its inputs. ci-operator only reuses results\n" +
+	"      # when it is configured with a namespace to record them in.\n" +
+	"      reuse_results:\n" +
+	"        # SourcePaths are regular expressions matching the files the test depends\n" +
+	"        # on. A push changing only other files reuses the earlier result. All\n" +
+	"        # files are considered when unset.\n" +
+	"        source_paths:\n" +
+	"            - \"\"\n" +
 	"      # RunIfChanged is a regex that will result in the test only running if something that matches it was changed.\n" +
 	"      run_if_changed: ' '\n" +
 	"      # Secret is an optional secret object which\n" +
@@ -1884,6 +3237,18 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          name: ' '\n" +
 	"      # SkipIfOnlyChanged is a regex that will result in the test being skipped if all changed files match that regex.\n" +
 	"      skip_if_only_changed: ' '\n" +
+	"      # Soak configures the test as a long-running soak test, which may run for\n" +
+	"      # days and reports its progress in periodic JUnit checkpoints.\n" +
+	"      soak:\n" +
+	"        # CheckpointInterval is how often the progress of the test is reported\n" +
+	"        # in a JUnit checkpoint. Defaults to one hour.\n" +
+	"        checkpoint_interval: 0s\n" +
+	"        # MaxDurationDays is the maximum number of days the test can run for.\n" +
+	"        max_duration_days: 0\n" +
+	"      # Spread, if set, prefers scheduling the pods created for the test away from the\n" +
+	"      # pods of concurrently running instances of the same test, e.g. shards of an\n" +
+	"      # aggregated job. Can be `node` or `zone`.\n" +
+	"      spread: ' '\n" +
 	"      steps:\n" +
 	"        # AllowBestEffortPostSteps defines if any `post` steps can be ignored when\n" +
 	"        # they fail. The given step must explicitly ask for being ignored by setting\n" +
@@ -1893,6 +3258,17 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"        # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"        allow_skip_on_success: false\n" +
+	"        # Budgets limit how long the `pre`, `test` and `post` phases may take.\n" +
+	"        budgets:\n" +
+	"            # Post is the budget of the `post` phase.\n" +
+	"            post: 0s\n" +
+	"            # Pre is the budget of the `pre` phase.\n" +
+	"            pre: 0s\n" +
+	"            # Test is the budget of the `test` phase.\n" +
+	"            test: 0s\n" +
+	"        # ClientRelease is the name of the release from which the `oc` binary is\n" +
+	"        # injected into all steps that use one, for skew testing.\n" +
+	"        client_release: ' '\n" +
 	"        # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"        cluster_profile: ' '\n" +
 	"        # Dependencies holds override values for dependency parameters.\n" +
@@ -1913,12 +3289,128 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # Environment has the values of parameters for the steps.\n" +
 	"        env:\n" +
 	"            \"\": \"\"\n" +
+	"        # Gather is the array of test steps run after the tests finish to collect\n" +
+	"        # diagnostics, before `post`. Gather steps always run, even if previous\n" +
+	"        # steps fail, and their failures never fail the test.\n" +
+	"        gather:\n" +
+	"            # LiteralTestStep is a full test step definition.\n" +
+	"            - as: ' '\n" +
+	"              best_effort: false\n" +
+	"              # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
+	"              chain: \"\"\n" +
+	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"              # will be injected into this step.\n" +
+	"              cli: ' '\n" +
+	"              commands: ' '\n" +
+	"              credentials:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - cloud:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    project: ' '\n" +
+	"                    provider: ' '\n" +
+	"                    region: ' '\n" +
+	"                    version: ' '\n" +
+	"                  mount_path: ' '\n" +
+	"                  name: ' '\n" +
+	"                  namespace: ' '\n" +
+	"              dependencies:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - env: ' '\n" +
+	"                  name: ' '\n" +
+	"              dnsConfig:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                nameservers:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                searches:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"              env:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - default: \"\"\n" +
+	"                  documentation: ' '\n" +
+	"                  name: ' '\n" +
+	"              from: ' '\n" +
+	"              from_image:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                as: ' '\n" +
+	"                name: ' '\n" +
+	"                namespace: ' '\n" +
+	"                tag: ' '\n" +
+	"              grace_period: 0s\n" +
+	"              health_check:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                checks:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              install_config:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"              leases:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - env: ' '\n" +
+	"                  resource_type: ' '\n" +
+	"              log_limit:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              nested_podman: false\n" +
+	"              no_kubeconfig: false\n" +
+	"              node_architecture: \"\"\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                \"\": \"\"\n" +
+	"              observers:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
+	"              optional_on_success: false\n" +
+	"              pull_secret: ' '\n" +
+	"              # Reference is the name of a step reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
+	"              ref: \"\"\n" +
+	"              # Resources defines the resource requirements for the step.\n" +
+	"              resources:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                limits:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"                requests:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"              run_as_script: false\n" +
+	"              timeout: 0s\n" +
+	"              tolerations:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - effect: ' '\n" +
+	"                  key: ' '\n" +
+	"                  value: ' '\n" +
+	"              upgrade:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                timeout: 0s\n" +
+	"        # GatherTimeout is how long the whole `gather` phase may take. Gather steps\n" +
+	"        # still running when it expires are stopped and `post` steps start.\n" +
+	"        gather_timeout: 0s\n" +
 	"        # Leases lists resources that should be acquired for the test.\n" +
 	"        leases:\n" +
 	"            - # Env is the environment variable that will contain the resource name.\n" +
 	"              env: ' '\n" +
 	"              # ResourceType is the type of resource that will be leased.\n" +
 	"              resource_type: ' '\n" +
+	"        # NetworkStack is the IP stack of the network of the cluster under test:\n" +
+	"        # `ipv4` (the default), `ipv6` or `dualstack`. It must be supported by the\n" +
+	"        # platform of the cluster profile and is exposed to all steps as\n" +
+	"        # $NETWORK_STACK.\n" +
+	"        network_stack: ' '\n" +
 	"        # NodeArchitecture is the architecture for the node where the test will run.\n" +
 	"        # If set, the generated test pod will include a nodeSelector for this architecture.\n" +
 	"        node_architecture: \"\"\n" +
@@ -1937,7 +3429,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -1945,7 +3438,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - mount_path: ' '\n" +
+	"                - cloud:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    project: ' '\n" +
+	"                    provider: ' '\n" +
+	"                    region: ' '\n" +
+	"                    version: ' '\n" +
+	"                  mount_path: ' '\n" +
 	"                  name: ' '\n" +
 	"                  namespace: ' '\n" +
 	"              dependencies:\n" +
@@ -1973,18 +3472,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                namespace: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              health_check:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                checks:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              install_config:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
 	"                  resource_type: ' '\n" +
+	"              log_limit:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              nested_podman: false\n" +
 	"              no_kubeconfig: false\n" +
 	"              node_architecture: \"\"\n" +
-	"              # Observers are the observers that should be running\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                \"\": \"\"\n" +
 	"              observers:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              pull_secret: ' '\n" +
+	"              # Reference is the name of a step reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -1997,12 +3519,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              tolerations:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - effect: ' '\n" +
+	"                  key: ' '\n" +
+	"                  value: ' '\n" +
+	"              upgrade:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -2010,7 +3544,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - mount_path: ' '\n" +
+	"                - cloud:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    project: ' '\n" +
+	"                    provider: ' '\n" +
+	"                    region: ' '\n" +
+	"                    version: ' '\n" +
+	"                  mount_path: ' '\n" +
 	"                  name: ' '\n" +
 	"                  namespace: ' '\n" +
 	"              dependencies:\n" +
@@ -2038,18 +3578,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                namespace: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              health_check:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                checks:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              install_config:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
 	"                  resource_type: ' '\n" +
+	"              log_limit:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              nested_podman: false\n" +
 	"              no_kubeconfig: false\n" +
 	"              node_architecture: \"\"\n" +
-	"              # Observers are the observers that should be running\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                \"\": \"\"\n" +
 	"              observers:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              pull_secret: ' '\n" +
+	"              # Reference is the name of a step reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -2062,12 +3625,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              tolerations:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - effect: ' '\n" +
+	"                  key: ' '\n" +
+	"                  value: ' '\n" +
+	"              upgrade:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                timeout: 0s\n" +
+	"        # ServerRelease is the name of the release that replaces the `latest`\n" +
+	"        # release payload in the dependencies of all steps, for skew testing.\n" +
+	"        server_release: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -2075,7 +3653,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              commands: ' '\n" +
 	"              credentials:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
-	"                - mount_path: ' '\n" +
+	"                - cloud:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    project: ' '\n" +
+	"                    provider: ' '\n" +
+	"                    region: ' '\n" +
+	"                    version: ' '\n" +
+	"                  mount_path: ' '\n" +
 	"                  name: ' '\n" +
 	"                  namespace: ' '\n" +
 	"              dependencies:\n" +
@@ -2103,18 +3687,41 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                namespace: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              health_check:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                checks:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                wait: 0s\n" +
+	"              install_config:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                base: ' '\n" +
+	"                patches:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
 	"                  resource_type: ' '\n" +
+	"              log_limit:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                head: ' '\n" +
+	"                tail: ' '\n" +
+	"              nested_podman: false\n" +
 	"              no_kubeconfig: false\n" +
 	"              node_architecture: \"\"\n" +
-	"              # Observers are the observers that should be running\n" +
+	"              # NodeSelector constrains the nodes the Pod for this step can be scheduled on.\n" +
+	"              # Only labels in SchedulingAllowlist can be used.\n" +
+	"              node_selector:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                \"\": \"\"\n" +
 	"              observers:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              pull_secret: ' '\n" +
+	"              # Reference is the name of a step reference. It can be pinned to a\n" +
+	"              # registry snapshot by appending its version, e.g. `name@v2025-01-15`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -2127,11 +3734,50 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              tolerations:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - effect: ' '\n" +
+	"                  key: ' '\n" +
+	"                  value: ' '\n" +
+	"              upgrade:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                channel: ' '\n" +
+	"                force: ' '\n" +
+	"                release: ' '\n" +
+	"                timeout: 0s\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
+	"        # The workflow can be pinned to a registry snapshot by appending its version, e.g. `ipi-aws@v2025-01-15`,\n" +
+	"        # in which case the whole test is resolved from that snapshot.\n" +
 	"        workflow: \"\"\n" +
 	"      # Timeout overrides maximum prowjob duration\n" +
 	"      timeout: 0s\n" +
+	"      # Tolerations allow the pods created for the test to be scheduled on tainted nodes.\n" +
+	"      # Only taints in SchedulingAllowlist can be tolerated.\n" +
+	"      tolerations:\n" +
+	"        - # Effect is the taint effect to match. If empty, all effects are matched.\n" +
+	"          effect: ' '\n" +
+	"          # Key is the taint key the toleration applies to.\n" +
+	"          key: ' '\n" +
+	"          # Value is the taint value the toleration matches. If empty, any\n" +
+	"          # taint with the key is tolerated.\n" +
+	"          value: ' '\n" +
+	"# Tools will create a \"tools\" image from \"root\" (or the configured image)\n" +
+	"# that contains the listed command line tools at pinned versions. Steps\n" +
+	"# can use the image instead of installing the tools themselves.\n" +
+	"tools:\n" +
+	"    from: ' '\n" +
+	"    # Tools will create a \"tools\" image from \"root\" (or the configured image)\n" +
+	"    # that contains the listed command line tools at pinned versions. Steps\n" +
+	"    # can use the image instead of installing the tools themselves.\n" +
+	"    tools:\n" +
+	"        - archive: ' '\n" +
+	"          binaries:\n" +
+	"            - \"\"\n" +
+	"          name: ' '\n" +
+	"          sha256: ' '\n" +
+	"          url: ' '\n" +
+	"          version: ' '\n" +
 	"zz_generated_metadata:\n" +
 	"    branch: ' '\n" +
 	"    org: ' '\n" +